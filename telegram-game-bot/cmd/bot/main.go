@@ -4,6 +4,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -16,14 +18,27 @@ import (
 	"telegram-game-bot/internal/config"
 	"telegram-game-bot/internal/game"
 	"telegram-game-bot/internal/game/allin"
+	"telegram-game-bot/internal/game/blackjack"
 	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/heist"
 	"telegram-game-bot/internal/game/rob"
 	"telegram-game-bot/internal/game/sicbo"
 	"telegram-game-bot/internal/game/slot"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/chaos"
 	"telegram-game-bot/internal/pkg/db"
+	"telegram-game-bot/internal/pkg/events"
 	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/metrics"
+	"telegram-game-bot/internal/pkg/pacer"
+	"telegram-game-bot/internal/pkg/readiness"
+	"telegram-game-bot/internal/pkg/shard"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/pkg/tracing"
+	"telegram-game-bot/internal/pkg/ttlstore"
 	"telegram-game-bot/internal/repository"
 	"telegram-game-bot/internal/service"
+	"telegram-game-bot/internal/shop"
 )
 
 func main() {
@@ -31,20 +46,68 @@ func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
 
+	// Logged errors are counted for the admin digest
+	errorCounter := metrics.NewErrorCounter()
+	log.Logger = log.Logger.Hook(errorCounter)
+
 	// Load configuration
 	cfg, err := config.Load("config")
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Msg("Invalid configuration: " + err.Error())
+	}
 
 	log.Info().Msg("Configuration loaded successfully")
 
+	// User names are cleaned before they are put into bot messages
+	textfilter.SetDefault(textfilter.New(textfilter.Options{
+		BlockedWords: cfg.Filter.BlockedWords,
+		Replacement:  cfg.Filter.Replacement,
+		MaxLength:    cfg.Filter.MaxNameLength,
+		MaxRepeat:    cfg.Filter.MaxRepeat,
+	}))
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Staging fault injection of Telegram API calls and database queries
+	var injector *chaos.Injector
+	var dbOpts []db.Option
+	if cfg.Chaos.Enabled {
+		injector = chaos.New(cfg.Chaos.TelegramFailPercent, cfg.Chaos.DBFailPercent)
+		dbOpts = append(dbOpts, db.WithFaults(injector.DBFault))
+		log.Warn().
+			Float64("telegram_fail_percent", cfg.Chaos.TelegramFailPercent).
+			Float64("db_fail_percent", cfg.Chaos.DBFailPercent).
+			Msg("Chaos mode enabled: faults are injected, do not run in production")
+	}
+
+	// Optional OpenTelemetry tracing of updates, queries and Telegram API calls
+	var tracer *tracing.Tracer
+	if cfg.Tracing.Endpoint != "" {
+		tracer, err = tracing.New(ctx, cfg.Tracing.Endpoint, cfg.Tracing.ServiceName, cfg.Tracing.SamplePercent)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create tracer")
+		}
+		dbOpts = append(dbOpts, db.WithTracer(tracer))
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := tracer.Shutdown(shutdownCtx); err != nil {
+				log.Warn().Err(err).Msg("Failed to export the remaining spans")
+			}
+		}()
+		log.Info().
+			Str("endpoint", cfg.Tracing.Endpoint).
+			Float64("sample_percent", cfg.Tracing.SamplePercent).
+			Msg("Tracing enabled")
+	}
+
 	// Initialize database connection pool
-	dbPool, err := db.NewPool(ctx, &cfg.Database)
+	dbPool, err := db.NewPool(ctx, &cfg.Database, dbOpts...)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
@@ -59,6 +122,51 @@ func main() {
 	userRepo := repository.NewUserRepository(dbPool.Pool)
 	txRepo := repository.NewTransactionRepository(dbPool.Pool)
 	inventoryRepo := repository.NewInventoryRepository(dbPool.Pool)
+	promoRepo := repository.NewPromoRepository(dbPool.Pool)
+	supportRepo := repository.NewSupportRepository(dbPool.Pool)
+	compensationRepo := repository.NewCompensationRepository(dbPool.Pool)
+	robProtectionRepo := repository.NewRobProtectionRepository(dbPool.Pool)
+	robHitRepo := repository.NewRobHitRepository(dbPool.Pool)
+	raidRepo := repository.NewRaidRepository(dbPool.Pool)
+	pricingRepo := repository.NewPricingRepository(dbPool.Pool)
+	refundRepo := repository.NewRefundRepository(dbPool.Pool)
+	mergeRepo := repository.NewMergeRepository(dbPool.Pool)
+	balanceHistoryRepo := repository.NewBalanceHistoryRepository(dbPool.Pool)
+	personaRepo := repository.NewPersonaRepository(dbPool.Pool)
+	balanceAlertRepo := repository.NewBalanceAlertRepository(dbPool.Pool)
+	outboxRepo := repository.NewOutboxRepository(dbPool.Pool)
+	sicboSummaryRepo := repository.NewSicBoSummaryRepository(dbPool.Pool)
+	gamblingLimitRepo := repository.NewGamblingLimitRepository(dbPool.Pool)
+	cosmeticRepo := repository.NewCosmeticRepository(dbPool.Pool)
+	sicboAutoRepo := repository.NewSicBoAutoRepository(dbPool.Pool)
+	treasuryRepo := repository.NewTreasuryRepository(dbPool.Pool)
+	pvpRepo := repository.NewPvPRepository(dbPool.Pool)
+	bailoutRepo := repository.NewBailoutRepository(dbPool.Pool)
+	celebrationRepo := repository.NewCelebrationRepository(dbPool.Pool)
+	banterRepo := repository.NewBanterRepository(dbPool.Pool)
+	robMessageRepo := repository.NewRobMessageRepository(dbPool.Pool)
+	mediaAssetRepo := repository.NewMediaAssetRepository(dbPool.Pool)
+	chatSettingsRepo := repository.NewChatSettingsRepository(dbPool.Pool)
+	chatWhitelistRepo := repository.NewChatWhitelistRepository(dbPool.Pool)
+	robInsuranceRepo := repository.NewRobInsuranceRepository(dbPool.Pool)
+	featureFlagRepo := repository.NewFeatureFlagRepository(dbPool.Pool)
+	gameRoundRepo := repository.NewGameRoundRepository(dbPool.Pool)
+	sandboxRepo := repository.NewSandboxRepository(dbPool.Pool)
+	poolRepo := repository.NewPoolRepository(dbPool.Pool)
+	maintenanceRepo := repository.NewMaintenanceRepository(dbPool.Pool)
+	winRecordRepo := repository.NewWinRecordRepository(dbPool.Pool)
+	digestRepo := repository.NewDigestRepository(dbPool.Pool)
+	idempotencyRepo := repository.NewIdempotencyRepository(dbPool.Pool)
+	comebackRepo := repository.NewComebackRepository(dbPool.Pool)
+
+	// Every recorded transaction is published as a balance change
+	eventBus := events.NewBus()
+	txRepo.SetEventBus(eventBus)
+	refundRepo.SetEventBus(eventBus)
+	inventoryRepo.SetEventBus(eventBus)
+	bailoutRepo.SetEventBus(eventBus)
+	comebackRepo.SetEventBus(eventBus)
+	idempotencyRepo.SetEventBus(eventBus)
 
 	// Initialize services
 	accountService := service.NewAccountService(
@@ -70,7 +178,16 @@ func main() {
 
 	transferService := service.NewTransferService(userRepo, txRepo)
 
+	// Retried balance changes with an idempotency key are applied once
+	accountService.SetIdempotency(idempotencyRepo)
+	transferService.SetIdempotency(idempotencyRepo)
+
 	rankingService := service.NewRankingService(userRepo, txRepo, time.Local)
+	if cfg.Stats.AggregateCheckMinutes > 0 {
+		rankingService.SetAggregates(repository.NewUserAggregateRepository(dbPool.Pool))
+	}
+
+	chatStatsService := service.NewChatStatsService(time.Local)
 
 	// Initialize user lock
 	userLock := lock.NewUserLock()
@@ -87,6 +204,20 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to register dice game")
 	}
 
+	// Register triple dice and best-of-three dice modes
+	if err := gameRegistry.Register(dice.NewTriple(&dice.Config{
+		MaxBet:   cfg.Games.Dice.MaxBet,
+		Cooldown: cfg.Games.Dice.CooldownSeconds,
+	})); err != nil {
+		log.Fatal().Err(err).Msg("Failed to register triple dice game")
+	}
+	if err := gameRegistry.Register(dice.NewBestOfThree(&dice.Config{
+		MaxBet:   cfg.Games.Dice.MaxBet,
+		Cooldown: cfg.Games.Dice.CooldownSeconds,
+	})); err != nil {
+		log.Fatal().Err(err).Msg("Failed to register best-of-three dice game")
+	}
+
 	// Register slot game
 	slotGame := slot.New(&slot.Config{
 		Cooldown: cfg.Games.Slot.CooldownSeconds,
@@ -95,20 +226,206 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to register slot game")
 	}
 
+	// Register daily free spin (zero-stake slot variant)
+	if err := gameRegistry.Register(slot.NewFreeSpin()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to register free spin game")
+	}
+
+	// Register blackjack, played with buttons and settled through the registry
+	blackjackGame := blackjack.New(&blackjack.Config{
+		MaxBet:   cfg.Games.Blackjack.MaxBet,
+		Cooldown: cfg.Games.Blackjack.CooldownSeconds,
+	})
+	if err := gameRegistry.Register(blackjackGame); err != nil {
+		log.Fatal().Err(err).Msg("Failed to register blackjack game")
+	}
+
 	// Initialize SicBo game (multiplayer)
 	sicboGame := sicbo.New()
+	sicboGame.SetMinPlayers(cfg.Games.SicBo.MinPlayers)
+
+	// Initialize Heist game (cooperative, recruited with a join button)
+	heistGame := heist.New()
 
 	// Initialize Rob game
 	robGame := rob.NewRobGame(userRepo, txRepo, userLock)
+	robCfg := cfg.Games.Rob
+	robGame.SetAmountPolicy(rob.NewAmountPolicy(robCfg.AmountMode, robCfg.MinPercent, robCfg.MaxPercent, robCfg.MinAmount, robCfg.MaxAmount))
+	robGame.SetProtectionConfig(rob.ProtectionConfig{
+		NewUserGrace:    time.Duration(robCfg.NewUserGraceHours) * time.Hour,
+		ExtendCost:      robCfg.ProtectExtendCost,
+		ExtendDuration:  time.Duration(robCfg.ProtectExtendMinutes) * time.Minute,
+		MaxRemaining:    time.Duration(robCfg.ProtectMaxMinutes) * time.Minute,
+		VictimHourlyCap: robCfg.VictimHourlyCap,
+		PairDailyCap:    robCfg.PairDailyCap,
+	})
+	robGame.SetProtectionRepository(robProtectionRepo)
+	robGame.SetHitRepository(robHitRepo)
+	robGame.SetPvPRepository(pvpRepo)
+	if err := robGame.LoadProtections(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load rob protections")
+	}
+
+	// Flavor texts of rob results, from the config file and /robmsg
+	var configuredRobMessages []model.RobMessage
+	for _, m := range robCfg.Messages {
+		configuredRobMessages = append(configuredRobMessages, model.RobMessage{Outcome: m.Outcome, Tier: m.Tier, Text: m.Text})
+	}
+	robMessageService := service.NewRobMessageService(robMessageRepo, map[string]int{
+		model.RobTierCommon: robCfg.MessageWeights.Common,
+		model.RobTierRare:   robCfg.MessageWeights.Rare,
+		model.RobTierEpic:   robCfg.MessageWeights.Epic,
+	}, configuredRobMessages)
+	robGame.SetMessagePool(robMessageService)
 
 	// Initialize All-In game
 	allInGame := allin.NewAllInGame(userRepo, txRepo, userLock)
+	allInGame.SetPvPRepository(pvpRepo)
 
 	// Initialize Shop service
 	shopService := service.NewShopService(userRepo, txRepo, inventoryRepo, userLock)
+	inventoryCleanup := service.NewInventoryCleanupService(inventoryRepo,
+		time.Duration(cfg.Shop.ExpiryWarningHours)*time.Hour, cfg.Shop.PurchaseRetentionDays)
+	shopService.SetPricing(pricingRepo, shop.DemandPricing{
+		StepUnits:        cfg.Shop.DemandStepUnits,
+		StepPercent:      cfg.Shop.DemandStepPercent,
+		MaxMarkupPercent: cfg.Shop.DemandMaxMarkupPercent,
+	})
+	shopService.SetPvPRepository(pvpRepo)
+
+	// Initialize Promo service
+	promoService := service.NewPromoService(userRepo, txRepo, promoRepo, shopService, userLock)
+
+	// Initialize Support service
+	supportService := service.NewSupportService(supportRepo, userRepo, txRepo, refundRepo, userLock)
+
+	// Initialize Refund service (admin reversal of specific transactions)
+	refundService := service.NewRefundService(refundRepo, txRepo, userLock)
+	txSearchService := service.NewTxSearchService(txRepo, userRepo)
+	rtpService := service.NewRTPService(txRepo)
+	mergeService := service.NewMergeService(mergeRepo, userRepo, userLock)
+	balanceHistory := service.NewBalanceHistoryService(balanceHistoryRepo, time.Local)
+
+	// Initialize Compensation service
+	compensationService := service.NewCompensationService(
+		compensationRepo,
+		userRepo,
+		txRepo,
+		userLock,
+		cfg.Compensation.AutoApproveLimit,
+		cfg.Compensation.MaxPerUser,
+		cfg.Compensation.MaxPerIncident,
+	)
+	// Entries are credited with their ID as idempotency key, so a payout
+	// retried after a crash before the entry was marked paid is not doubled
+	compensationService.SetAccountService(accountService)
+
+	// Initialize Raid service (scores successful robs between raid sides)
+	raidService := service.NewRaidService(raidRepo, userRepo, txRepo, userLock)
+	if err := raidService.Load(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load current raid")
+	}
+	robGame.AddHook(raidService)
+
+	// Initialize Rob insurance service (pool funded by a cut of successful robs, nil when disabled)
+	var robInsurance *service.RobInsuranceService
+	if robCfg.InsuranceCutPercent > 0 {
+		robInsurance = service.NewRobInsuranceService(robInsuranceRepo, userRepo, txRepo, robCfg.InsuranceCutPercent,
+			robCfg.InsuranceFloor, robCfg.InsuranceCoverPercent, robCfg.InsuranceMaxPayout)
+		robGame.SetInsurance(robInsurance)
+	}
+
+	// Initialize Persona service (per-chat flavor text of game results)
+	personaService := service.NewPersonaService(personaRepo)
+
+	// Initialize Balance alert service (DMs about large changes while offline)
+	balanceAlerts := service.NewBalanceAlertService(balanceAlertRepo,
+		cfg.Notify.BalanceThreshold, time.Duration(cfg.Notify.OfflineMinutes)*time.Minute)
+	balanceAlerts.Subscribe(eventBus)
+
+	// Initialize Outbox service (DMs retried until delivered) and SicBo settlement DMs
+	outboxService := service.NewOutboxService(outboxRepo)
+	sicboSummaries := service.NewSicBoSummaryService(sicboSummaryRepo, outboxService)
+
+	// Initialize Self exclusion service (users locking themselves out of gambling)
+	selfExclusions := service.NewSelfExclusionService(userRepo)
+
+	// Initialize Gambling limit service (self-set daily wager and loss caps)
+	gamblingLimits := service.NewGamblingLimitService(gamblingLimitRepo, time.Local)
+	gamblingLimits.Subscribe(eventBus)
+
+	// Initialize Records service (biggest single dice and slot wins per chat)
+	recordsService := service.NewRecordsService(winRecordRepo, time.Local)
+	recordsService.Subscribe(eventBus)
+
+	// Initialize Cosmetic service (cosmetic-only items sold for Telegram Stars)
+	cosmeticService := service.NewCosmeticService(cosmeticRepo, cfg.Payments.Enabled)
+
+	// Initialize SicBo auto-start service (scheduled rounds per chat)
+	sicboAutoService := service.NewSicBoAutoService(sicboAutoRepo, time.Local)
+	treasuryService := service.NewTreasuryService(treasuryRepo, time.Local)
+
+	// Initialize Digest service (nightly admin DM, nil when disabled)
+	var digestService *service.DigestService
+	if cfg.Digest.Enabled {
+		digestService = service.NewDigestService(digestRepo, treasuryService, rankingService,
+			cfg.Admin.IDs, cfg.Digest.FlagProfit, cfg.Digest.Hour, time.Local)
+		digestService.SetErrorCounts(errorCounter)
+	}
+	pvpService := service.NewPvPService(pvpRepo)
+	exportService := service.NewExportService(userRepo, txRepo)
+	celebrationService := service.NewCelebrationService(celebrationRepo,
+		time.Duration(cfg.Celebration.ChatCooldownSeconds)*time.Second)
+	banterService := service.NewBanterService(banterRepo, cfg.Banter.ChancePercent,
+		time.Duration(cfg.Banter.ChatCooldownSeconds)*time.Second, cfg.Banter.DefaultLocale)
+	mediaAssets := service.NewMediaAssetService(mediaAssetRepo)
+	chatSettings := service.NewChatSettingsService(chatSettingsRepo)
+	whitelist := service.NewWhitelistService(chatWhitelistRepo, cfg.Whitelist.Chats, cfg.Support.ChatID)
+	featureFlags := service.NewFeatureFlagService(featureFlagRepo, map[string]bool{
+		service.FlagDiceInsurance: cfg.Games.Dice.InsuranceEnabled,
+		service.FlagHeist:         true,
+		service.FlagCelebrations:  true,
+		service.FlagBanter:        cfg.Banter.Enabled,
+	})
+	sandboxService := service.NewSandboxService(sandboxRepo, cfg.Sandbox.StartBalance)
+	poolService := service.NewPoolService(poolRepo, accountService, userLock, cfg.Pool.RakePercent,
+		time.Duration(cfg.Pool.WindowMinutes)*time.Minute)
+	poolService.SetCompensation(compensationService)
+	maintenanceService := service.NewMaintenanceService(maintenanceRepo, cfg.Whitelist.Chats,
+		time.Duration(cfg.Maintenance.BlockLeadMinutes)*time.Minute, time.Local)
+	bailoutService := service.NewBailoutService(bailoutRepo, cfg.Bailout.Floor, cfg.Bailout.Grant,
+		time.Duration(cfg.Bailout.BelowHours)*time.Hour, time.Duration(cfg.Bailout.IntervalDays)*24*time.Hour)
+	gameRounds := service.NewGameRoundService(gameRoundRepo, txRepo)
+	txPartitions := service.NewTransactionPartitionService(repository.NewTransactionPartitionRepository(dbPool.Pool),
+		cfg.Partitions.MonthsAhead, cfg.Partitions.RetentionMonths)
+	var archiveService *service.ArchiveService
+	if cfg.Archive.InactiveDays > 0 {
+		archiveService = service.NewArchiveService(userRepo, time.Duration(cfg.Archive.InactiveDays)*24*time.Hour)
+	}
+	var comebackService *service.ComebackService
+	if cfg.Comeback.Bonus > 0 {
+		comebackService = service.NewComebackService(comebackRepo, cfg.Comeback.Bonus, service.ComebackRules{
+			Inactive:    time.Duration(cfg.Comeback.InactiveDays) * 24 * time.Hour,
+			RegularDays: cfg.Comeback.RegularDays,
+			MonthlyCap:  cfg.Comeback.MonthlyCap,
+		}, time.Duration(cfg.Comeback.ClaimHours)*time.Hour, time.Local)
+	}
+	var degradedService *service.DegradedService
+	if cfg.Degraded.CheckSeconds > 0 {
+		degradedService = service.NewDegradedService(dbPool, cfg.Degraded.Failures)
+		degradedService.Subscribe(eventBus)
+	}
+	var dailyRewards *service.DailyRewardService
+	if cfg.Daily.Dynamic {
+		dailyRewards = service.NewDailyRewardService(treasuryRepo, cfg.Daily.Reward, cfg.Daily.MinReward,
+			cfg.Daily.MaxReward, cfg.Daily.TargetInflation, cfg.Daily.EMADays)
+	}
 
 	// Connect shop service to rob game and all-in game for item effects
 	robGame.SetItemChecker(shopService)
+	if robCfg.CriticalStealItem {
+		robGame.SetItemThief(shopService)
+	}
 	allInGame.SetItemChecker(shopService)
 
 	log.Info().
@@ -116,18 +433,148 @@ func main() {
 		Strs("games", gameRegistry.Commands()).
 		Msg("Games registered")
 
+	// Chats processed by this instance when chats are sharded across instances
+	shards, err := shard.New(cfg.Sharding.Count, cfg.Sharding.Shards)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid sharding configuration")
+	}
+	if shards.Enabled() {
+		log.Info().
+			Int("count", cfg.Sharding.Count).
+			Ints("shards", cfg.Sharding.Shards).
+			Bool("primary", shards.Primary()).
+			Msg("Processing a shard of the chats")
+	}
+
+	// Handler timings, served for Prometheus if a listen address is configured
+	metricsRegistry := metrics.NewRegistry()
+	handlerDurations := metrics.NewHistogramVec("tgbot_handler_duration_seconds",
+		"Time spent handling a command, button or message, or settling a round (job:*).", "handler", metrics.DefaultBuckets)
+	metricsRegistry.Register(handlerDurations)
+
+	// Sends to chats that rate limit or mute the bot are held back
+	sendPacer := pacer.New(time.Duration(cfg.Bot.Pacing.MaxWaitSeconds)*time.Second, time.Duration(cfg.Bot.Pacing.MutedMinutes)*time.Minute)
+	deferredSends := metrics.NewHistogramVec("tgbot_send_deferred_seconds",
+		"Sends held back or refused because the chat rate limits or muted the bot, by reason.", "reason", metrics.DefaultBuckets)
+	sendPacer.SetMetrics(deferredSends)
+	metricsRegistry.Register(deferredSends)
+
+	// Updates of chats owned by other instances, forwarded to their owner or dropped
+	var shardForwarder *shard.Forwarder
+	shardSkips := metrics.NewCounterVec("tgbot_shard_skipped_updates_total",
+		"Updates of chats owned by other instances, by outcome (forwarded, forward_failed, dropped).", "outcome")
+	if shards.Enabled() {
+		shardForwarder = shard.NewForwarder(cfg.Sharding.ForwardURLs, cfg.Bot.Webhook.SecretToken)
+		metricsRegistry.Register(shardSkips)
+	}
+
+	// Readiness served on /readyz, closed until the caches are warm and the bot takes updates
+	ready := readiness.New()
+	if cfg.Metrics.ListenAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metricsRegistry.Handler())
+		mux.Handle("/readyz", ready)
+		metricsServer := &http.Server{Addr: cfg.Metrics.ListenAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			log.Info().Str("addr", cfg.Metrics.ListenAddr).Msg("Serving metrics")
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Msg("Metrics server stopped")
+			}
+		}()
+		defer metricsServer.Close()
+	}
+
+	// Anonymized public stats, served and/or written to a file if configured
+	var publicStats *service.PublicStatsService
+	if cfg.PublicStats.ListenAddr != "" || cfg.PublicStats.OutputFile != "" {
+		publicStats = service.NewPublicStatsService(userRepo, txRepo, time.Local, cfg.PublicStats.OutputFile)
+	}
+	if cfg.PublicStats.ListenAddr != "" {
+		publicStatsServer := &http.Server{Addr: cfg.PublicStats.ListenAddr, Handler: publicStats, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			log.Info().Str("addr", cfg.PublicStats.ListenAddr).Msg("Serving public stats")
+			if err := publicStatsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Msg("Public stats server stopped")
+			}
+		}()
+		defer publicStatsServer.Close()
+	}
+
+	// Durable ephemeral state; the bot keeps it in memory otherwise
+	var stateStore ttlstore.Store
+	if cfg.State.Backend == config.StateBackendPostgres {
+		stateStore = repository.NewTTLStoreRepository(dbPool.Pool)
+	}
+
 	// Create bot dependencies
 	deps := &bot.Dependencies{
-		Config:          cfg,
-		AccountService:  accountService,
-		TransferService: transferService,
-		RankingService:  rankingService,
-		ShopService:     shopService,
-		GameRegistry:    gameRegistry,
-		SicBoGame:       sicboGame,
-		RobGame:         robGame,
-		AllInGame:       allInGame,
-		UserLock:        userLock,
+		Config:              cfg,
+		AccountService:      accountService,
+		TransferService:     transferService,
+		RankingService:      rankingService,
+		ShopService:         shopService,
+		PromoService:        promoService,
+		SupportService:      supportService,
+		RefundService:       refundService,
+		TxSearchService:     txSearchService,
+		RTPService:          rtpService,
+		MergeService:        mergeService,
+		BalanceHistory:      balanceHistory,
+		CompensationService: compensationService,
+		ChatStatsService:    chatStatsService,
+		RaidService:         raidService,
+		PersonaService:      personaService,
+		BalanceAlerts:       balanceAlerts,
+		OutboxService:       outboxService,
+		SicBoSummaries:      sicboSummaries,
+		SelfExclusions:      selfExclusions,
+		GamblingLimits:      gamblingLimits,
+		CosmeticService:     cosmeticService,
+		SicBoAutoService:    sicboAutoService,
+		TreasuryService:     treasuryService,
+		PvPService:          pvpService,
+		ExportService:       exportService,
+		PoolService:         poolService,
+		MaintenanceService:  maintenanceService,
+		RecordsService:      recordsService,
+		DigestService:       digestService,
+		ComebackService:     comebackService,
+		DegradedService:     degradedService,
+		BailoutService:      bailoutService,
+		ArchiveService:      archiveService,
+		DailyRewards:        dailyRewards,
+		CelebrationService:  celebrationService,
+		BanterService:       banterService,
+		RobMessageService:   robMessageService,
+		TxPartitions:        txPartitions,
+		PublicStats:         publicStats,
+		Ready:               ready,
+		MediaAssets:         mediaAssets,
+		ChatSettings:        chatSettings,
+		Whitelist:           whitelist,
+		RobInsurance:        robInsurance,
+		FeatureFlags:        featureFlags,
+		GameRounds:          gameRounds,
+		SandboxService:      sandboxService,
+		InventoryCleanup:    inventoryCleanup,
+		GameRegistry:        gameRegistry,
+		SicBoGame:           sicboGame,
+		HeistGame:           heistGame,
+		BlackjackGame:       blackjackGame,
+		BlackjackHands:      repository.NewBlackjackHandRepository(dbPool.Pool),
+		HandlerDurations:    handlerDurations,
+		Chaos:               injector,
+		Pacer:               sendPacer,
+		Tracer:              tracer,
+		StateStore:          stateStore,
+		Database:            dbPool,
+		EventBus:            eventBus,
+		Shards:              shards,
+		ShardForwarder:      shardForwarder,
+		ShardSkips:          shardSkips,
+		RobGame:             robGame,
+		AllInGame:           allInGame,
+		UserLock:            userLock,
 	}
 
 	// Initialize bot
@@ -195,21 +642,7 @@ func runMigrations(ctx context.Context, pool *db.Pool) error {
 	}
 	log.Info().Msg("Migration 2: transactions table created")
 
-	// Migration 3: Create daily stats view
-	_, err = pool.Exec(ctx, `
-		CREATE OR REPLACE VIEW daily_game_stats AS
-		SELECT 
-			user_id,
-			SUM(amount) as net_profit,
-			DATE(created_at) as game_date
-		FROM transactions
-		WHERE type IN ('dice', 'slot', 'sicbo_win', 'sicbo_bet', 'rob', 'robbed')
-		GROUP BY user_id, DATE(created_at);
-	`)
-	if err != nil {
-		return err
-	}
-	log.Info().Msg("Migration 3: daily_game_stats view created")
+	// Migration 3 created the daily_game_stats view, dropped by migration 59
 
 	// Migration 4: Create shop system tables
 	// user_items - stores stackable items like handcuffs
@@ -227,37 +660,1101 @@ func runMigrations(ctx context.Context, pool *db.Pool) error {
 	}
 	log.Info().Msg("Migration 4a: user_items table created")
 
-	// user_effects - stores time-based effects (shield, thorn armor, bloodthirst sword)
+	// handcuff_locks - stores users locked by handcuffs
 	_, err = pool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS user_effects (
+		CREATE TABLE IF NOT EXISTS handcuff_locks (
+			target_id BIGINT PRIMARY KEY,
+			locked_by BIGINT NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_handcuff_locks_expires ON handcuff_locks(expires_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 4c: handcuff_locks table created")
+
+	// Migration 5: Create promo code tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS promo_codes (
+			code VARCHAR(32) PRIMARY KEY,
+			reward_amount BIGINT NOT NULL DEFAULT 0,
+			reward_item VARCHAR(50),
+			max_uses INT NOT NULL DEFAULT 1,
+			used_count INT NOT NULL DEFAULT 0,
+			expires_at TIMESTAMPTZ,
+			created_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS promo_redemptions (
+			code VARCHAR(32) NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+			user_id BIGINT NOT NULL,
+			redeemed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (code, user_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_promo_redemptions_user ON promo_redemptions(user_id);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 5: promo code tables created")
+
+	// Migration 6: Add daily free spin tracking
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS last_free_spin BIGINT NOT NULL DEFAULT 0;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 6: users.last_free_spin column added")
+
+	// Migration 7: Add leaderboard privacy flag
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_from_leaderboard BOOLEAN NOT NULL DEFAULT FALSE;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 7: users.hide_from_leaderboard column added")
+
+	// Migration 8: Create support tickets table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS support_tickets (
 			id BIGSERIAL PRIMARY KEY,
 			user_id BIGINT NOT NULL,
-			effect_type VARCHAR(50) NOT NULL,
-			expires_at TIMESTAMPTZ NOT NULL,
+			message TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'open',
+			resolved_by BIGINT,
+			refund_tx_id BIGINT,
+			refund_amount BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			resolved_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_support_tickets_user_status ON support_tickets(user_id, status);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 8: support_tickets table created")
+
+	// Migration 9: Create compensation tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS compensation_incidents (
+			id BIGSERIAL PRIMARY KEY,
+			kind VARCHAR(50) NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			total_amount BIGINT NOT NULL DEFAULT 0,
+			reviewed_by BIGINT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			resolved_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_compensation_incidents_status ON compensation_incidents(status);
+
+		CREATE TABLE IF NOT EXISTS compensation_entries (
+			id BIGSERIAL PRIMARY KEY,
+			incident_id BIGINT NOT NULL REFERENCES compensation_incidents(id) ON DELETE CASCADE,
+			user_id BIGINT NOT NULL,
+			amount BIGINT NOT NULL,
+			paid BOOLEAN NOT NULL DEFAULT FALSE,
+			paid_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_compensation_entries_incident ON compensation_entries(incident_id);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 9: compensation tables created")
+
+	// Migration 10: Create rob protection table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS rob_protections (
+			user_id BIGINT PRIMARY KEY,
+			consecutive_count INT NOT NULL DEFAULT 0,
+			protected_until TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 10: rob protection table created")
+
+	// Migration 11: Create rob hits table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS rob_hits (
+			id BIGSERIAL PRIMARY KEY,
+			robber_id BIGINT NOT NULL,
+			victim_id BIGINT NOT NULL,
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);
-		CREATE INDEX IF NOT EXISTS idx_user_effects_user ON user_effects(user_id);
-		CREATE INDEX IF NOT EXISTS idx_user_effects_expires ON user_effects(expires_at);
+		CREATE INDEX IF NOT EXISTS idx_rob_hits_victim_created ON rob_hits(victim_id, created_at);
+		CREATE INDEX IF NOT EXISTS idx_rob_hits_created ON rob_hits(created_at);
 	`)
 	if err != nil {
 		return err
 	}
-	log.Info().Msg("Migration 4b: user_effects table created")
+	log.Info().Msg("Migration 11: rob hits table created")
 
-	// handcuff_locks - stores users locked by handcuffs
+	// Migration 12: Create raid event tables
 	_, err = pool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS handcuff_locks (
-			target_id BIGINT PRIMARY KEY,
-			locked_by BIGINT NOT NULL,
-			expires_at TIMESTAMPTZ NOT NULL,
+		CREATE TABLE IF NOT EXISTS raid_events (
+			id BIGSERIAL PRIMARY KEY,
+			chat_a BIGINT NOT NULL,
+			chat_b BIGINT NOT NULL,
+			prize_pool BIGINT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+			starts_at TIMESTAMPTZ NOT NULL,
+			ends_at TIMESTAMPTZ NOT NULL,
+			winner_chat BIGINT,
+			created_by BIGINT NOT NULL,
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);
-		CREATE INDEX IF NOT EXISTS idx_handcuff_locks_expires ON handcuff_locks(expires_at);
+		CREATE INDEX IF NOT EXISTS idx_raid_events_status ON raid_events(status);
+
+		CREATE TABLE IF NOT EXISTS raid_members (
+			raid_id BIGINT NOT NULL REFERENCES raid_events(id) ON DELETE CASCADE,
+			user_id BIGINT NOT NULL,
+			chat_id BIGINT NOT NULL,
+			points INT NOT NULL DEFAULT 0,
+			joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (raid_id, user_id)
+		);
 	`)
 	if err != nil {
 		return err
 	}
-	log.Info().Msg("Migration 4c: handcuff_locks table created")
+	log.Info().Msg("Migration 12: raid event tables created")
+
+	// Migration 13: Create shop pricing tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS shop_sales (
+			target VARCHAR(50) PRIMARY KEY,
+			discount_percent INT NOT NULL,
+			ends_at TIMESTAMPTZ NOT NULL,
+			created_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS shop_item_sales (
+			item_type VARCHAR(50) NOT NULL,
+			sale_date DATE NOT NULL DEFAULT CURRENT_DATE,
+			sold_count INT NOT NULL DEFAULT 0,
+			PRIMARY KEY (item_type, sale_date)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 13: shop pricing tables created")
+
+	// Migration 14: Add item expiry to user items
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE user_items ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+		ALTER TABLE user_items ADD COLUMN IF NOT EXISTS expiry_warned BOOLEAN NOT NULL DEFAULT FALSE;
+		CREATE INDEX IF NOT EXISTS idx_user_items_expires_at ON user_items(expires_at) WHERE expires_at IS NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 14: user item expiry added")
+
+	// Migration 15: Add unique user handles and backfill existing users
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(16);
+
+		DO $$
+		DECLARE
+			u RECORD;
+			h TEXT;
+		BEGIN
+			FOR u IN SELECT telegram_id FROM users WHERE handle IS NULL LOOP
+				LOOP
+					h := '#' || upper(substr(md5(random()::text), 1, 6));
+					EXIT WHEN NOT EXISTS (SELECT 1 FROM users WHERE handle = h);
+				END LOOP;
+				UPDATE users SET handle = h WHERE telegram_id = u.telegram_id;
+			END LOOP;
+		END $$;
+
+		ALTER TABLE users ALTER COLUMN handle SET NOT NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 15: user handles added")
+
+	// Migration 16: Create transaction refund audit table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS transaction_refunds (
+			tx_id BIGINT PRIMARY KEY REFERENCES transactions(id),
+			user_id BIGINT NOT NULL,
+			amount BIGINT NOT NULL,
+			refund_tx_id BIGINT REFERENCES transactions(id),
+			refunded_by BIGINT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			item_type VARCHAR(50),
+			item_uses INT NOT NULL DEFAULT 0,
+			effect_seconds BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_transaction_refunds_user ON transaction_refunds(user_id);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 16: transaction refund table created")
+
+	// Migration 17: Create chat persona table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS chat_personas (
+			chat_id BIGINT PRIMARY KEY,
+			nickname VARCHAR(32) NOT NULL DEFAULT '',
+			win_template TEXT NOT NULL DEFAULT '',
+			lose_template TEXT NOT NULL DEFAULT '',
+			theme VARCHAR(20) NOT NULL DEFAULT '',
+			updated_by BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 17: chat persona table created")
+
+	// Migration 18: Create balance alert opt-in table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS balance_alerts (
+			user_id BIGINT PRIMARY KEY,
+			threshold BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 18: balance alert table created")
+
+	// Migration 19: Create Telegram Stars cosmetic purchase tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS star_purchases (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			item_id VARCHAR(64) NOT NULL,
+			currency VARCHAR(8) NOT NULL,
+			amount INT NOT NULL,
+			payload VARCHAR(128) NOT NULL,
+			telegram_charge_id VARCHAR(255) NOT NULL UNIQUE,
+			provider_charge_id VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_star_purchases_user ON star_purchases(user_id, created_at DESC);
+		CREATE TABLE IF NOT EXISTS user_cosmetics (
+			user_id BIGINT NOT NULL,
+			item_id VARCHAR(64) NOT NULL,
+			kind VARCHAR(20) NOT NULL,
+			equipped BOOLEAN NOT NULL DEFAULT FALSE,
+			purchase_id BIGINT NOT NULL REFERENCES star_purchases(id),
+			acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, item_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 19: star purchase tables created")
+
+	// Migration 20: Create sicbo auto-start schedule table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS sicbo_auto_schedules (
+			chat_id BIGINT PRIMARY KEY,
+			interval_minutes INT NOT NULL,
+			start_hour SMALLINT NOT NULL DEFAULT 0,
+			end_hour SMALLINT NOT NULL DEFAULT 0,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			updated_by BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 20: sicbo auto-start schedule table created")
+
+	// Migration 21: Create treasury account booked against every transaction
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS treasury (
+			id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+			balance BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		INSERT INTO treasury (id, balance)
+		SELECT 1, -COALESCE(SUM(balance), 0) FROM users
+		ON CONFLICT (id) DO NOTHING;
+		CREATE OR REPLACE FUNCTION treasury_post_transaction() RETURNS TRIGGER AS $$
+		BEGIN
+			UPDATE treasury SET balance = balance - NEW.amount, updated_at = NOW() WHERE id = 1;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+		-- Superseded by the ledger trigger of migration 22: creating it again on a
+		-- later boot would post every transaction to the treasury twice
+		DO $$
+		BEGIN
+			IF to_regclass('ledger_entries') IS NULL THEN
+				DROP TRIGGER IF EXISTS trg_treasury_post_transaction ON transactions;
+				CREATE TRIGGER trg_treasury_post_transaction
+					AFTER INSERT ON transactions
+					FOR EACH ROW EXECUTE FUNCTION treasury_post_transaction();
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 21: treasury account created")
+
+	// Migration 22: Create double-entry ledger, transactions become its per-user legs
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS ledger_entries (
+			id BIGSERIAL PRIMARY KEY,
+			debit_account BIGINT NOT NULL,
+			credit_account BIGINT NOT NULL,
+			amount BIGINT NOT NULL CHECK (amount > 0),
+			type VARCHAR(50) NOT NULL,
+			description TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			CHECK (debit_account <> credit_account)
+		);
+		CREATE INDEX IF NOT EXISTS idx_ledger_entries_debit ON ledger_entries(debit_account, created_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_ledger_entries_credit ON ledger_entries(credit_account, created_at DESC);
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ledger_entry_id BIGINT;
+		CREATE INDEX IF NOT EXISTS idx_transactions_ledger_entry ON transactions(ledger_entry_id);
+		-- Backfill: earlier transactions become entries against the treasury
+		UPDATE transactions SET ledger_entry_id = nextval('ledger_entries_id_seq')
+		WHERE ledger_entry_id IS NULL AND amount <> 0;
+		INSERT INTO ledger_entries (id, debit_account, credit_account, amount, type, description, created_at)
+		SELECT t.ledger_entry_id,
+			CASE WHEN t.amount > 0 THEN 0 ELSE t.user_id END,
+			CASE WHEN t.amount > 0 THEN t.user_id ELSE 0 END,
+			ABS(t.amount), t.type, t.description, t.created_at
+		FROM transactions t
+		WHERE t.ledger_entry_id IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM ledger_entries e WHERE e.id = t.ledger_entry_id);
+		-- Transactions recorded without an entry are booked against the treasury
+		CREATE OR REPLACE FUNCTION ledger_book_transaction() RETURNS TRIGGER AS $$
+		BEGIN
+			IF NEW.ledger_entry_id IS NULL AND NEW.amount <> 0 THEN
+				INSERT INTO ledger_entries (debit_account, credit_account, amount, type, description, created_at)
+				VALUES (
+					CASE WHEN NEW.amount > 0 THEN 0 ELSE NEW.user_id END,
+					CASE WHEN NEW.amount > 0 THEN NEW.user_id ELSE 0 END,
+					ABS(NEW.amount), NEW.type, NEW.description, NEW.created_at
+				)
+				RETURNING id INTO NEW.ledger_entry_id;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS trg_ledger_book_transaction ON transactions;
+		CREATE TRIGGER trg_ledger_book_transaction
+			BEFORE INSERT ON transactions
+			FOR EACH ROW EXECUTE FUNCTION ledger_book_transaction();
+		-- The treasury follows the ledger instead of the transactions
+		DROP TRIGGER IF EXISTS trg_treasury_post_transaction ON transactions;
+		CREATE OR REPLACE FUNCTION treasury_post_ledger_entry() RETURNS TRIGGER AS $$
+		BEGIN
+			IF NEW.credit_account = 0 THEN
+				UPDATE treasury SET balance = balance + NEW.amount, updated_at = NOW() WHERE id = 1;
+			ELSIF NEW.debit_account = 0 THEN
+				UPDATE treasury SET balance = balance - NEW.amount, updated_at = NOW() WHERE id = 1;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS trg_treasury_post_ledger_entry ON ledger_entries;
+		CREATE TRIGGER trg_treasury_post_ledger_entry
+			AFTER INSERT ON ledger_entries
+			FOR EACH ROW EXECUTE FUNCTION treasury_post_ledger_entry();
+		-- One signed leg per account and entry, in the shape of transactions
+		CREATE OR REPLACE VIEW ledger_legs AS
+		SELECT id AS entry_id, credit_account AS account, amount, type, description, created_at
+		FROM ledger_entries
+		UNION ALL
+		SELECT id, debit_account, -amount, type, description, created_at
+		FROM ledger_entries;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 22: ledger entries created")
+
+	// Migration 23: Create PvP preference table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS pvp_preferences (
+			user_id BIGINT PRIMARY KEY REFERENCES users(telegram_id) ON DELETE CASCADE,
+			opted_out BOOLEAN NOT NULL DEFAULT FALSE,
+			changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_pvp_preferences_opted_out ON pvp_preferences(user_id) WHERE opted_out;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 23: PvP preference table created")
+
+	// Migration 24: Create bailout state table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS bailout_states (
+			user_id BIGINT PRIMARY KEY REFERENCES users(telegram_id) ON DELETE CASCADE,
+			below_since TIMESTAMPTZ,
+			last_grant_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_bailout_states_below_since ON bailout_states(below_since) WHERE below_since IS NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 24: bailout state table created")
+
+	// Migration 25: Add ping setting to chat personas
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE chat_personas ADD COLUMN IF NOT EXISTS no_pings BOOLEAN NOT NULL DEFAULT FALSE;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 25: chat persona ping setting added")
+
+	// Migration 26: Create celebration media table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS celebration_media (
+			id BIGSERIAL PRIMARY KEY,
+			kind VARCHAR(32) NOT NULL,
+			media_type VARCHAR(16) NOT NULL,
+			file_id TEXT NOT NULL,
+			added_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (kind, file_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_celebration_media_kind ON celebration_media(kind);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 26: celebration media table created")
+
+	// Migration 27: Add data export tracking
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS last_export BIGINT NOT NULL DEFAULT 0;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 27: users.last_export column added")
+
+	// Migration 28: Create sandbox tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS sandbox_chats (
+			chat_id BIGINT PRIMARY KEY,
+			enabled_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS sandbox_balances (
+			chat_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL,
+			balance BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (chat_id, user_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 28: sandbox tables created")
+
+	// Migration 29: Add default stake
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS default_stake BIGINT NOT NULL DEFAULT 0;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 29: users.default_stake column added")
+
+	// Migration 30: Create betting pool tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS pools (
+			id BIGSERIAL PRIMARY KEY,
+			chat_id BIGINT NOT NULL,
+			question TEXT NOT NULL,
+			outcomes TEXT[] NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'open',
+			rake_percent INT NOT NULL,
+			closes_at TIMESTAMPTZ NOT NULL,
+			winning_outcome INT,
+			created_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			resolved_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_pools_chat_status ON pools(chat_id, status);
+		CREATE TABLE IF NOT EXISTS pool_bets (
+			id BIGSERIAL PRIMARY KEY,
+			pool_id BIGINT NOT NULL REFERENCES pools(id) ON DELETE CASCADE,
+			user_id BIGINT NOT NULL,
+			outcome INT NOT NULL,
+			amount BIGINT NOT NULL CHECK (amount > 0),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_pool_bets_pool ON pool_bets(pool_id);
+		CREATE TABLE IF NOT EXISTS pool_resolutions (
+			id BIGSERIAL PRIMARY KEY,
+			pool_id BIGINT NOT NULL REFERENCES pools(id) ON DELETE CASCADE,
+			admin_id BIGINT NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			winning_outcome INT,
+			total_pool BIGINT NOT NULL,
+			rake BIGINT NOT NULL,
+			paid BIGINT NOT NULL,
+			winners INT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 30: betting pool tables created")
+
+	// Migration 31: Create maintenance windows table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS maintenance_windows (
+			id BIGSERIAL PRIMARY KEY,
+			starts_at TIMESTAMPTZ NOT NULL,
+			ends_at TIMESTAMPTZ NOT NULL,
+			announced INT NOT NULL DEFAULT -1,
+			created_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			cancelled_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS idx_maintenance_windows_ends ON maintenance_windows(ends_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 31: maintenance windows table created")
+
+	// Migration 32: Create win records table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS win_records (
+			chat_id BIGINT NOT NULL,
+			game VARCHAR(20) NOT NULL,
+			period VARCHAR(10) NOT NULL,
+			period_start DATE NOT NULL,
+			user_id BIGINT NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			amount BIGINT NOT NULL,
+			set_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (chat_id, game, period, period_start)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 32: win records table created")
+
+	// Migration 33: Track /struggle attempts per handcuff lock
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE handcuff_locks ADD COLUMN IF NOT EXISTS struggle_attempts INT NOT NULL DEFAULT 0;
+		ALTER TABLE handcuff_locks ADD COLUMN IF NOT EXISTS last_struggle_at TIMESTAMPTZ;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 33: handcuff struggle columns added")
+
+	// Migration 34: Create SicBo summary opt-ins and the DM outbox
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS sicbo_summary_optins (
+			user_id BIGINT PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS dm_outbox (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			text TEXT NOT NULL,
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_dm_outbox_next_attempt ON dm_outbox(next_attempt_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 34: sicbo summary opt-ins and dm outbox tables created")
+
+	// Migration 35: Add self exclusion to users
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS self_excluded_until TIMESTAMPTZ;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 35: self exclusion column added")
+
+	// Migration 36: Create gambling limits and daily totals tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS gambling_limits (
+			user_id BIGINT PRIMARY KEY,
+			daily_wager BIGINT NOT NULL DEFAULT 0,
+			daily_loss BIGINT NOT NULL DEFAULT 0,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS gambling_day_totals (
+			user_id BIGINT NOT NULL,
+			day DATE NOT NULL,
+			wagered BIGINT NOT NULL DEFAULT 0,
+			net BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (user_id, day)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 36: gambling limits tables created")
+
+	// Migration 37: Create media assets table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS media_assets (
+			key VARCHAR(50) PRIMARY KEY,
+			media_type VARCHAR(20) NOT NULL,
+			file_id TEXT NOT NULL,
+			updated_by BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 37: media assets table created")
+
+	// Migration 38: Create chat settings table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS chat_settings (
+			chat_id BIGINT PRIMARY KEY,
+			games_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			timezone VARCHAR(50) NOT NULL DEFAULT '',
+			cleanup_minutes INT NOT NULL DEFAULT 30,
+			onboarded_at TIMESTAMPTZ,
+			updated_by BIGINT NOT NULL DEFAULT 0,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 38: chat settings table created")
+
+	// Migration 39: Create user merges table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS user_merges (
+			id BIGSERIAL PRIMARY KEY,
+			old_id BIGINT NOT NULL UNIQUE,
+			new_id BIGINT NOT NULL,
+			balance BIGINT NOT NULL DEFAULT 0,
+			items INT NOT NULL DEFAULT 0,
+			effects INT NOT NULL DEFAULT 0,
+			cosmetics INT NOT NULL DEFAULT 0,
+			transactions INT NOT NULL DEFAULT 0,
+			merged_by BIGINT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_user_merges_new_id ON user_merges(new_id);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 39: user merges table created")
+
+	// Migration 40: Create balance history table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS balance_history (
+			user_id BIGINT NOT NULL,
+			day DATE NOT NULL,
+			balance BIGINT NOT NULL,
+			PRIMARY KEY (user_id, day)
+		);
+		CREATE INDEX IF NOT EXISTS idx_balance_history_day ON balance_history(day);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 40: balance history table created")
+
+	// Migration 41: Add quick bets flag to chat settings
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS quick_bets BOOLEAN NOT NULL DEFAULT FALSE;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 41: chat quick bets flag added")
+
+	// Migration 42: Create TTL store table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS ttl_store (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_ttl_store_prefix ON ttl_store(key text_pattern_ops);
+		CREATE INDEX IF NOT EXISTS idx_ttl_store_expires ON ttl_store(expires_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 42: TTL store table created")
+
+	// Migration 43: Add quiet hours to chat settings
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS quiet_start SMALLINT NOT NULL DEFAULT 0;
+		ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS quiet_end SMALLINT NOT NULL DEFAULT 0;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 43: chat quiet hours added")
+
+	// Migration 44: Create chat whitelist table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS chat_whitelist (
+			chat_id BIGINT PRIMARY KEY,
+			allowed BOOLEAN NOT NULL,
+			updated_by BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 44: chat whitelist table created")
+
+	// Migration 45: Create rob insurance pool
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS rob_insurance_pool (
+			id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+			balance BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+			collected BIGINT NOT NULL DEFAULT 0,
+			paid_out BIGINT NOT NULL DEFAULT 0,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		INSERT INTO rob_insurance_pool (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 45: rob insurance pool created")
+
+	// Migration 46: Create feature flags table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS feature_flags (
+			flag TEXT NOT NULL,
+			scope TEXT NOT NULL,
+			target_id BIGINT NOT NULL DEFAULT 0,
+			enabled BOOLEAN NOT NULL,
+			updated_by BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (flag, scope, target_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 46: feature flags table created")
+
+	// Migration 47: Add user archival
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS idx_users_active_balance ON users(balance DESC) WHERE archived_at IS NULL;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 47: user archival added")
+
+	// Migration 48: Create game rounds table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS game_rounds (
+			id BIGSERIAL PRIMARY KEY,
+			game TEXT NOT NULL,
+			user_id BIGINT NOT NULL,
+			chat_id BIGINT NOT NULL,
+			bet BIGINT NOT NULL,
+			dice_values INT[] NOT NULL,
+			premium BIGINT NOT NULL DEFAULT 0,
+			refund_percent INT NOT NULL DEFAULT 0,
+			payout BIGINT NOT NULL,
+			refund BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_game_rounds_user_time ON game_rounds(user_id, created_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 48: game rounds table created")
+
+	// Migration 49: Indexes for admin transaction search
+	_, err = pool.Exec(ctx, `
+		CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_transactions_type_id ON transactions(type, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_transactions_abs_amount ON transactions((ABS(amount)), id DESC);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 49: transaction search indexes created")
+
+	// Migration 50: Create idempotency key table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			key VARCHAR(128) PRIMARY KEY,
+			operation VARCHAR(32) NOT NULL,
+			fingerprint TEXT NOT NULL,
+			completed BOOLEAN NOT NULL DEFAULT FALSE,
+			user_id BIGINT NOT NULL DEFAULT 0,
+			balance BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 50: idempotency key table created")
+
+	// Migration 51: Create welcome-back offer tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS comeback_optins (
+			user_id BIGINT PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS comeback_offers (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			bonus BIGINT NOT NULL,
+			delivered BOOLEAN NOT NULL DEFAULT FALSE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			claimed_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_comeback_offers_user_time ON comeback_offers(user_id, created_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 51: welcome-back offer tables created")
+
+	// Migration 52: Create banter phrase table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS banter_phrases (
+			id BIGSERIAL PRIMARY KEY,
+			kind VARCHAR(16) NOT NULL,
+			locale VARCHAR(8) NOT NULL,
+			text TEXT NOT NULL,
+			added_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (kind, locale, text)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 52: banter phrase table created")
+
+	// Migration 53: Create user daily aggregate tables
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS user_daily_aggregates (
+			user_id BIGINT NOT NULL,
+			day DATE NOT NULL,
+			net_profit BIGINT NOT NULL,
+			games INT NOT NULL,
+			PRIMARY KEY (user_id, day)
+		);
+		CREATE TABLE IF NOT EXISTS aggregate_watermarks (
+			name VARCHAR(32) PRIMARY KEY,
+			through_day DATE NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 53: user daily aggregate tables created")
+
+	// Migration 54: Partition transactions by month, the existing rows becoming the legacy partition
+	_, err = pool.Exec(ctx, `
+		DO $$
+		DECLARE
+			boundary TIMESTAMPTZ := date_trunc('month', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+			idx RECORD;
+			legacy_end TIMESTAMPTZ;
+			month TIMESTAMPTZ;
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_class WHERE oid = to_regclass('transactions') AND relkind = 'r') THEN
+				ALTER TABLE transaction_refunds DROP CONSTRAINT IF EXISTS transaction_refunds_tx_id_fkey;
+				ALTER TABLE transaction_refunds DROP CONSTRAINT IF EXISTS transaction_refunds_refund_tx_id_fkey;
+
+				ALTER TABLE transactions RENAME TO transactions_legacy;
+				FOR idx IN SELECT indexname FROM pg_indexes WHERE tablename = 'transactions_legacy' LOOP
+					EXECUTE format('ALTER INDEX %I RENAME TO %I', idx.indexname, idx.indexname || '_legacy');
+				END LOOP;
+				DROP TRIGGER IF EXISTS trg_ledger_book_transaction ON transactions_legacy;
+
+				CREATE TABLE transactions (
+					id BIGINT NOT NULL DEFAULT nextval('transactions_id_seq'),
+					user_id BIGINT NOT NULL REFERENCES users(telegram_id) ON DELETE CASCADE,
+					amount BIGINT NOT NULL,
+					type VARCHAR(50) NOT NULL,
+					description TEXT,
+					created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+					ledger_entry_id BIGINT,
+					PRIMARY KEY (id, created_at)
+				) PARTITION BY RANGE (created_at);
+				ALTER SEQUENCE transactions_id_seq OWNED BY transactions.id;
+
+				-- Same definitions as the legacy indexes, which are attached instead of rebuilt
+				CREATE INDEX idx_transactions_user_time ON transactions(user_id, created_at DESC);
+				CREATE INDEX idx_transactions_type_time ON transactions(type, created_at DESC);
+				CREATE INDEX idx_transactions_ledger_entry ON transactions(ledger_entry_id);
+				CREATE INDEX idx_transactions_user_id ON transactions(user_id, id DESC);
+				CREATE INDEX idx_transactions_type_id ON transactions(type, id DESC);
+				CREATE INDEX idx_transactions_abs_amount ON transactions((ABS(amount)), id DESC);
+
+				-- Bounded above every existing row, or the attach fails validation: the
+				-- start of next month, later if rows are dated past it
+				SELECT GREATEST(boundary + INTERVAL '1 month',
+						date_trunc('month', MAX(created_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' + INTERVAL '1 month')
+					INTO legacy_end FROM transactions_legacy;
+				EXECUTE format('ALTER TABLE transactions ATTACH PARTITION transactions_legacy FOR VALUES FROM (MINVALUE) TO (%L)', legacy_end);
+
+				CREATE TRIGGER trg_ledger_book_transaction
+					BEFORE INSERT ON transactions
+					FOR EACH ROW EXECUTE FUNCTION ledger_book_transaction();
+			END IF;
+
+			-- Two months from the end of the legacy partition, or from this month once
+			-- it is past, so inserts work before the scheduler first runs
+			SELECT substring(pg_get_expr(c.relpartbound, c.oid) FROM 'TO \(''(.*)''\)')::timestamptz
+				INTO legacy_end FROM pg_class c
+				WHERE c.oid = to_regclass('transactions_legacy') AND c.relispartition;
+			boundary := GREATEST(boundary, legacy_end);
+			FOR month IN SELECT generate_series(boundary, boundary + INTERVAL '1 month', INTERVAL '1 month') LOOP
+				EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF transactions FOR VALUES FROM (%L) TO (%L)',
+					'transactions_' || to_char(month AT TIME ZONE 'UTC', '"y"YYYY"m"MM'), month, month + INTERVAL '1 month');
+			END LOOP;
+		END $$;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 54: transactions partitioned by month")
+
+	// Migration 55: Create rob_messages table
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS rob_messages (
+			id BIGSERIAL PRIMARY KEY,
+			outcome VARCHAR(16) NOT NULL,
+			tier VARCHAR(16) NOT NULL,
+			text TEXT NOT NULL,
+			added_by BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (outcome, text)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 55: rob_messages table created")
+
+	// Migration 56: Create user_effects table (time-based effects: shield, thorn armor, bloodthirst sword)
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS user_effects (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			effect_type VARCHAR(50) NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_user_effects_user ON user_effects(user_id);
+		CREATE INDEX IF NOT EXISTS idx_user_effects_expires ON user_effects(expires_at);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 56: user_effects table created")
+
+	// Migration 57: Create blackjack_hands table (stakes of hands in play, refunded at startup)
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS blackjack_hands (
+			user_id BIGINT PRIMARY KEY,
+			chat_id BIGINT NOT NULL,
+			sandbox_chat_id BIGINT NOT NULL DEFAULT 0,
+			stake BIGINT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 57: blackjack_hands table created")
+
+	// Migration 58: Add compensation for item uses over the stack caps to user_merges
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE user_merges ADD COLUMN IF NOT EXISTS compensation BIGINT NOT NULL DEFAULT 0;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 58: user merge compensation added")
+
+	// Migration 59: Drop the daily_game_stats view, rankings are read from transactions
+	_, err = pool.Exec(ctx, `
+		DROP VIEW IF EXISTS daily_game_stats;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 59: daily_game_stats view dropped")
+
+	// Migration 60: Add the balance scope of a pool's stakes to pools
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE pools ADD COLUMN IF NOT EXISTS sandbox_chat_id BIGINT NOT NULL DEFAULT 0;
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 60: pool sandbox scope added")
+
+	// Migration 61: Convert held shield uses into shield effect time (one hour per 10 uses)
+	_, err = pool.Exec(ctx, `
+		WITH held AS (
+			DELETE FROM user_items WHERE item_type = 'shield'
+			RETURNING user_id, use_count, expires_at
+		)
+		INSERT INTO user_effects (user_id, effect_type, expires_at, created_at)
+		SELECT user_id, 'shield', NOW() + use_count * INTERVAL '6 minutes', NOW()
+		FROM held
+		WHERE use_count > 0 AND (expires_at IS NULL OR expires_at > NOW());
+	`)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Migration 61: shield uses converted to effect time")
 
 	log.Info().Msg("All migrations completed successfully")
 	return nil
@@ -0,0 +1,344 @@
+// Package main is a load testing tool for the Telegram Game Bot.
+// It simulates concurrent users placing dice bets, robbing each other and buying
+// items through the real services against a test database, then reports
+// throughput and latency percentiles per operation.
+//
+// Simulated users get synthetic IDs starting at the -base-id value and are
+// deleted after the run unless -keep is set. Never point this tool at a production database.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/db"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+	"telegram-game-bot/internal/shop"
+)
+
+// Operation names in report order
+const (
+	opBet = "bet"
+	opRob = "rob"
+	opBuy = "buy"
+)
+
+var opOrder = []string{opBet, opRob, opBuy}
+
+// options holds the command line flags
+type options struct {
+	configPath    string
+	migrationsDir string
+	migrate       bool
+	users         int
+	duration      time.Duration
+	bet           int64
+	balance       int64
+	baseID        int64
+	betWeight     int
+	robWeight     int
+	buyWeight     int
+	keep          bool
+	verbose       bool
+}
+
+// simulator drives the services the bot handlers use
+type simulator struct {
+	opts           options
+	pool           *db.Pool
+	accountService *service.AccountService
+	shopService    *service.ShopService
+	robGame        *rob.RobGame
+	userLock       *lock.UserLock
+	items          []shop.ItemConfig
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.configPath, "config", "config", "directory containing config.yaml")
+	flag.StringVar(&opts.migrationsDir, "migrations", "migrations", "directory containing the *.up.sql migrations")
+	flag.BoolVar(&opts.migrate, "migrate", false, "apply the migrations before the run")
+	flag.IntVar(&opts.users, "users", 50, "number of concurrent simulated users")
+	flag.DurationVar(&opts.duration, "duration", 30*time.Second, "length of the run")
+	flag.Int64Var(&opts.bet, "bet", 100, "dice bet amount")
+	flag.Int64Var(&opts.balance, "balance", 100000, "starting balance top up of every simulated user")
+	flag.Int64Var(&opts.baseID, "base-id", 9_000_000_000_000, "first synthetic user ID")
+	flag.IntVar(&opts.betWeight, "bet-weight", 6, "relative weight of dice bets")
+	flag.IntVar(&opts.robWeight, "rob-weight", 2, "relative weight of robberies")
+	flag.IntVar(&opts.buyWeight, "buy-weight", 2, "relative weight of item purchases")
+	flag.BoolVar(&opts.keep, "keep", false, "keep the simulated users and their records after the run")
+	flag.BoolVar(&opts.verbose, "v", false, "log service output")
+	flag.Parse()
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+	if !opts.verbose {
+		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	}
+
+	if opts.users < 2 {
+		log.Fatal().Msg("-users must be at least 2 so robberies have a victim")
+	}
+	if opts.betWeight < 0 || opts.robWeight < 0 || opts.buyWeight < 0 || opts.betWeight+opts.robWeight+opts.buyWeight == 0 {
+		log.Fatal().Msg("operation weights must be non-negative and not all zero")
+	}
+
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	ctx := context.Background()
+	pool, err := db.NewPool(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer pool.Close()
+
+	if opts.migrate {
+		if err := applyMigrations(ctx, pool, opts.migrationsDir); err != nil {
+			log.Fatal().Err(err).Msg("Failed to apply migrations")
+		}
+	}
+
+	sim := newSimulator(opts, pool)
+	if err := sim.setup(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Failed to create simulated users")
+	}
+	if !opts.keep {
+		defer func() {
+			if err := sim.cleanup(context.Background()); err != nil {
+				log.Error().Err(err).Msg("Failed to clean up simulated users")
+			}
+		}()
+	}
+
+	fmt.Printf("Running %d users for %s (bet %d, weights bet=%d rob=%d buy=%d)\n",
+		opts.users, opts.duration, opts.bet, opts.betWeight, opts.robWeight, opts.buyWeight)
+
+	stats, elapsed := sim.run(ctx)
+	writeReport(os.Stdout, stats, opOrder, elapsed)
+}
+
+// newSimulator wires the services like cmd/bot/main.go
+func newSimulator(opts options, pool *db.Pool) *simulator {
+	userRepo := repository.NewUserRepository(pool.Pool)
+	txRepo := repository.NewTransactionRepository(pool.Pool)
+	inventoryRepo := repository.NewInventoryRepository(pool.Pool)
+
+	userLock := lock.NewUserLock()
+	accountService := service.NewAccountService(userRepo, txRepo, 0, 24)
+	shopService := service.NewShopService(userRepo, txRepo, inventoryRepo, userLock)
+
+	robGame := rob.NewRobGame(userRepo, txRepo, userLock)
+	// Simulated users are brand new, so the new user grace would block every robbery
+	robGame.SetProtectionConfig(rob.ProtectionConfig{})
+	robGame.SetItemChecker(shopService)
+
+	return &simulator{
+		opts:           opts,
+		pool:           pool,
+		accountService: accountService,
+		shopService:    shopService,
+		robGame:        robGame,
+		userLock:       userLock,
+		items:          shop.GetAllItems(),
+	}
+}
+
+// applyMigrations applies the *.up.sql files of a directory in order.
+// The migrations are idempotent, so applying them to a migrated database is safe.
+func applyMigrations(ctx context.Context, pool *db.Pool, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations found in %s", dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// userID returns the synthetic ID of the i-th simulated user
+func (s *simulator) userID(i int) int64 {
+	return s.opts.baseID + int64(i)
+}
+
+// setup registers the simulated users and tops up their balance
+func (s *simulator) setup(ctx context.Context) error {
+	desc := "loadtest top up"
+	for i := 0; i < s.opts.users; i++ {
+		id := s.userID(i)
+		if _, _, err := s.accountService.EnsureUser(ctx, id, fmt.Sprintf("loadtest_%d", i)); err != nil {
+			return err
+		}
+		if _, err := s.accountService.UpdateBalance(ctx, id, s.opts.balance, model.TxTypeAdminAdd, &desc, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanup deletes the simulated users and everything they wrote
+func (s *simulator) cleanup(ctx context.Context) error {
+	first, last := s.userID(0), s.userID(s.opts.users-1)
+	statements := []string{
+		`DELETE FROM transactions WHERE user_id BETWEEN $1 AND $2`,
+		`DELETE FROM user_items WHERE user_id BETWEEN $1 AND $2`,
+		`DELETE FROM user_effects WHERE user_id BETWEEN $1 AND $2`,
+		`DELETE FROM daily_purchases WHERE user_id BETWEEN $1 AND $2`,
+		`DELETE FROM handcuff_locks WHERE target_id BETWEEN $1 AND $2 OR locked_by BETWEEN $1 AND $2`,
+		`DELETE FROM users WHERE telegram_id BETWEEN $1 AND $2`,
+	}
+	for _, stmt := range statements {
+		if _, err := s.pool.Exec(ctx, stmt, first, last); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run starts one worker per simulated user and returns the merged stats
+func (s *simulator) run(ctx context.Context) (map[string]*opStats, time.Duration) {
+	deadline := time.Now().Add(s.opts.duration)
+	results := make([]map[string]*opStats, s.opts.users)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < s.opts.users; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.worker(ctx, i, deadline)
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	merged := make(map[string]*opStats, len(opOrder))
+	for _, name := range opOrder {
+		merged[name] = &opStats{}
+	}
+	for _, result := range results {
+		for name, stats := range result {
+			merged[name].merge(stats)
+		}
+	}
+	return merged, elapsed
+}
+
+// worker performs random operations as one user until the deadline
+func (s *simulator) worker(ctx context.Context, i int, deadline time.Time) map[string]*opStats {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))
+	userID := s.userID(i)
+	stats := map[string]*opStats{opBet: {}, opRob: {}, opBuy: {}}
+	totalWeight := s.opts.betWeight + s.opts.robWeight + s.opts.buyWeight
+
+	for time.Now().Before(deadline) {
+		var name string
+		var op func() outcome
+		switch pick := rng.Intn(totalWeight); {
+		case pick < s.opts.betWeight:
+			name, op = opBet, func() outcome { return s.placeBet(ctx, userID, rng) }
+		case pick < s.opts.betWeight+s.opts.robWeight:
+			name, op = opRob, func() outcome { return s.rob(ctx, i, rng) }
+		default:
+			name, op = opBuy, func() outcome { return s.buy(ctx, userID, rng) }
+		}
+
+		start := time.Now()
+		result := op()
+		stats[name].record(time.Since(start), result)
+	}
+	return stats
+}
+
+// placeBet plays a dice bet the way HandleDice does: deduct, roll, credit
+func (s *simulator) placeBet(ctx context.Context, userID int64, rng *rand.Rand) outcome {
+	s.userLock.Lock(userID)
+	defer s.userLock.Unlock(userID)
+
+	balance, err := s.accountService.GetBalance(ctx, userID)
+	if err != nil {
+		return outcomeError
+	}
+	if balance < s.opts.bet {
+		return outcomeRejected
+	}
+
+	desc := fmt.Sprintf("骰子游戏下注 %d", s.opts.bet)
+	if _, err := s.accountService.UpdateBalance(ctx, userID, -s.opts.bet, model.TxTypeDice, &desc, ""); err != nil {
+		return outcomeError
+	}
+
+	payout := dice.CalculatePayout(rng.Intn(6)+1, rng.Intn(6)+1, s.opts.bet)
+	if credit := s.opts.bet + payout; payout >= 0 && credit > 0 {
+		desc := fmt.Sprintf("骰子游戏赢得 %d", payout)
+		if _, err := s.accountService.UpdateBalance(ctx, userID, credit, model.TxTypeDice, &desc, ""); err != nil {
+			return outcomeError
+		}
+	}
+	return outcomeOK
+}
+
+// rob robs another random simulated user
+func (s *simulator) rob(ctx context.Context, i int, rng *rand.Rand) outcome {
+	victim := rng.Intn(s.opts.users - 1)
+	if victim >= i {
+		victim++
+	}
+
+	result, err := s.robGame.Rob(ctx, s.userID(i), s.userID(victim),
+		fmt.Sprintf("loadtest_%d", i), fmt.Sprintf("loadtest_%d", victim), nil)
+	if err != nil {
+		return outcomeError
+	}
+	if !result.Success {
+		return outcomeRejected
+	}
+	return outcomeOK
+}
+
+// buy purchases a random shop item
+func (s *simulator) buy(ctx context.Context, userID int64, rng *rand.Rand) outcome {
+	item := s.items[rng.Intn(len(s.items))]
+	err := s.shopService.PurchaseItem(ctx, userID, item.Type)
+	switch {
+	case err == nil:
+		return outcomeOK
+	case errors.Is(err, service.ErrDailyLimitReached),
+		errors.Is(err, service.ErrMaxItemTypesReached),
+		errors.Is(err, service.ErrInventoryFull),
+		errors.Is(err, service.ErrInsufficientBalance):
+		return outcomeRejected
+	default:
+		return outcomeError
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// outcome is the result of a single simulated operation
+type outcome int
+
+const (
+	outcomeOK       outcome = iota // 操作成功
+	outcomeRejected                // 被游戏规则拒绝（冷却、余额不足、限购等）
+	outcomeError                   // 系统错误
+)
+
+// opStats collects the latencies and outcomes of one operation kind.
+// Each worker keeps its own opStats, merged after the run, so recording is lock free.
+type opStats struct {
+	latencies []time.Duration
+	rejected  int
+	errors    int
+}
+
+// record adds one operation to the stats
+func (s *opStats) record(latency time.Duration, result outcome) {
+	s.latencies = append(s.latencies, latency)
+	switch result {
+	case outcomeRejected:
+		s.rejected++
+	case outcomeError:
+		s.errors++
+	}
+}
+
+// merge adds another worker's stats
+func (s *opStats) merge(other *opStats) {
+	s.latencies = append(s.latencies, other.latencies...)
+	s.rejected += other.rejected
+	s.errors += other.errors
+}
+
+// count returns the number of recorded operations
+func (s *opStats) count() int {
+	return len(s.latencies)
+}
+
+// percentile returns the latency below which p (0-1) of the operations completed.
+// The latencies must be sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// writeReport prints throughput and latency per operation kind
+func writeReport(w io.Writer, stats map[string]*opStats, order []string, elapsed time.Duration) {
+	fmt.Fprintf(w, "\n%-6s %9s %9s %9s %9s %10s %10s %10s %10s\n",
+		"op", "count", "ok", "rejected", "errors", "ops/s", "p50", "p99", "max")
+
+	total := 0
+	for _, name := range order {
+		s := stats[name]
+		if s == nil || s.count() == 0 {
+			continue
+		}
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		total += s.count()
+
+		fmt.Fprintf(w, "%-6s %9d %9d %9d %9d %10.1f %10s %10s %10s\n",
+			name,
+			s.count(),
+			s.count()-s.rejected-s.errors,
+			s.rejected,
+			s.errors,
+			float64(s.count())/elapsed.Seconds(),
+			roundLatency(percentile(s.latencies, 0.50)),
+			roundLatency(percentile(s.latencies, 0.99)),
+			roundLatency(s.latencies[len(s.latencies)-1]),
+		)
+	}
+
+	fmt.Fprintf(w, "\n%d operations in %s (%.1f ops/s)\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+}
+
+// roundLatency rounds a latency for display
+func roundLatency(d time.Duration) time.Duration {
+	if d >= time.Millisecond {
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,113 @@
+// Package main is a replay tool for investigating disputed game outcomes.
+// Given a recorded round ID or the ID of one of its transactions, it loads
+// the round's inputs (stake, insurance, the values Telegram rolled),
+// re-executes the game calculators and prints a step by step explanation
+// and whether the replayed outcome matches the recorded one.
+//
+// Only /dice and /slot rounds are recorded; their randomness comes from the
+// Telegram dice values, which are stored with the round. The tool only reads
+// from the database. It exits with status 1 on a mismatch.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/db"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+func main() {
+	var (
+		configPath string
+		roundID    int64
+		txID       int64
+	)
+	flag.StringVar(&configPath, "config", "config", "directory containing config.yaml")
+	flag.Int64Var(&roundID, "round", 0, "ID of the recorded round to replay")
+	flag.Int64Var(&txID, "tx", 0, "ID of a transaction of the round to replay")
+	flag.Parse()
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+
+	if (roundID > 0) == (txID > 0) {
+		log.Fatal().Msg("pass exactly one of -round and -tx")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	ctx := context.Background()
+	pool, err := db.NewPool(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer pool.Close()
+
+	rounds := service.NewGameRoundService(repository.NewGameRoundRepository(pool.Pool),
+		repository.NewTransactionRepository(pool.Pool))
+
+	var round *model.GameRound
+	if txID > 0 {
+		var tx *model.Transaction
+		round, tx, err = rounds.ForTransaction(ctx, txID)
+		if tx != nil {
+			writeTransaction(os.Stdout, tx)
+		}
+	} else {
+		round, err = rounds.Get(ctx, roundID)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrGameRoundNotFound) || errors.Is(err, service.ErrGameRoundNotGame) || errors.Is(err, service.ErrTxNotFound) {
+			log.Fatal().Msg(err.Error())
+		}
+		log.Fatal().Err(err).Msg("Failed to load the round")
+	}
+
+	replay, err := service.ReplayGameRound(round)
+	if err != nil {
+		log.Fatal().Err(err).Int64("round_id", round.ID).Msg("Failed to replay the round")
+	}
+	writeReplay(os.Stdout, round, replay)
+	if !replay.Match {
+		os.Exit(1)
+	}
+}
+
+// writeTransaction prints the transaction the round was looked up by
+func writeTransaction(w io.Writer, tx *model.Transaction) {
+	desc := ""
+	if tx.Description != nil {
+		desc = " " + *tx.Description
+	}
+	fmt.Fprintf(w, "Transaction #%d: user %d, %s %+d at %s%s\n\n",
+		tx.ID, tx.UserID, tx.Type, tx.Amount, tx.CreatedAt.Format(time.RFC3339), desc)
+}
+
+// writeReplay prints the round and the replay steps
+func writeReplay(w io.Writer, round *model.GameRound, replay *service.GameRoundReplay) {
+	fmt.Fprintf(w, "Round #%d: %s by user %d in chat %d at %s\n",
+		round.ID, round.Game, round.UserID, round.ChatID, round.CreatedAt.Format(time.RFC3339))
+	for i, step := range replay.Steps {
+		fmt.Fprintf(w, "%2d. %s\n", i+1, step)
+	}
+	if replay.Match {
+		fmt.Fprintln(w, "\nOK: the recorded outcome is reproduced by the current game rules")
+	} else {
+		fmt.Fprintln(w, "\nMISMATCH: the recorded outcome differs from the current game rules")
+	}
+}
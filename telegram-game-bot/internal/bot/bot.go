@@ -5,6 +5,7 @@ package bot
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -14,49 +15,175 @@ import (
 	"telegram-game-bot/internal/config"
 	"telegram-game-bot/internal/game"
 	"telegram-game-bot/internal/game/allin"
+	"telegram-game-bot/internal/game/blackjack"
+	"telegram-game-bot/internal/game/heist"
 	"telegram-game-bot/internal/game/rob"
 	"telegram-game-bot/internal/game/sicbo"
 	"telegram-game-bot/internal/handler"
+	"telegram-game-bot/internal/pkg/chaos"
+	"telegram-game-bot/internal/pkg/db"
+	"telegram-game-bot/internal/pkg/events"
 	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/metrics"
+	"telegram-game-bot/internal/pkg/pacer"
+	"telegram-game-bot/internal/pkg/readiness"
+	"telegram-game-bot/internal/pkg/shard"
+	"telegram-game-bot/internal/pkg/tracing"
+	"telegram-game-bot/internal/pkg/ttlstore"
+	"telegram-game-bot/internal/repository"
 	"telegram-game-bot/internal/service"
 )
 
 // Bot wraps the telebot instance with application dependencies.
 type Bot struct {
-	bot             *tele.Bot
-	cfg             *config.Config
-	accountService  *service.AccountService
-	transferService *service.TransferService
-	rankingService  *service.RankingService
-	shopService     *service.ShopService
-	gameRegistry    *game.Registry
-	sicboGame       *sicbo.SicBoGame
-	robGame         *rob.RobGame
-	allInGame       *allin.AllInGame
-	userLock        *lock.UserLock
+	bot                 *tele.Bot
+	cfg                 *config.Config
+	accountService      *service.AccountService
+	transferService     *service.TransferService
+	rankingService      *service.RankingService
+	shopService         *service.ShopService
+	promoService        *service.PromoService
+	supportService      *service.SupportService
+	compensationService *service.CompensationService
+	chatStatsService    *service.ChatStatsService
+	raidService         *service.RaidService
+	gameRegistry        *game.Registry
+	sicboGame           *sicbo.SicBoGame
+	robGame             *rob.RobGame
+	allInGame           *allin.AllInGame
+	userLock            *lock.UserLock
 
 	// Handlers
-	accountHandler  *handler.AccountHandler
-	transferHandler *handler.TransferHandler
-	adminHandler    *handler.AdminHandler
-	rankingHandler  *handler.RankingHandler
-	gameHandler     *handler.GameHandler
-	shopHandler     *handler.ShopHandler
-	allInHandler    *handler.AllInHandler
+	accountHandler      *handler.AccountHandler
+	transferHandler     *handler.TransferHandler
+	adminHandler        *handler.AdminHandler
+	rankingHandler      *handler.RankingHandler
+	gameHandler         *handler.GameHandler
+	shopHandler         *handler.ShopHandler
+	allInHandler        *handler.AllInHandler
+	promoHandler        *handler.PromoHandler
+	supportHandler      *handler.SupportHandler
+	compensationHandler *handler.CompensationHandler
+	chatStatsHandler    *handler.ChatStatsHandler
+	raidHandler         *handler.RaidHandler
+	personaHandler      *handler.PersonaHandler
+	balanceAlertHandler *handler.BalanceAlertHandler
+	sicboSummaryHandler *handler.SicBoSummaryHandler
+	selfExclusionHandler *handler.SelfExclusionHandler
+	gamblingLimitHandler *handler.GamblingLimitHandler
+	outboxHandler       *handler.OutboxHandler
+	cosmeticHandler     *handler.CosmeticHandler
+	pvpHandler          *handler.PvPHandler
+	exportHandler       *handler.ExportHandler
+	poolHandler         *handler.PoolHandler
+	celebrationHandler  *handler.CelebrationHandler // Nil if celebrations are not wired
+	banterHandler       *handler.BanterHandler      // Nil if banter is not wired
+	robMessageHandler   *handler.RobMessageHandler  // Nil if rob messages are not wired
+	mediaAssetHandler   *handler.MediaAssetHandler  // Nil if media assets are not wired
+	chatSettingsHandler *handler.ChatSettingsHandler // Nil if chat settings are not wired
+	whitelistHandler    *handler.WhitelistHandler
+	featureFlagHandler  *handler.FeatureFlagHandler // Nil if feature flags are not wired
+	whitelist           *service.WhitelistService
+	mergeHandler        *handler.MergeHandler
+	wealthHandler       *handler.WealthHandler
+	chatSettings        *service.ChatSettingsService
+	featureFlags        *service.FeatureFlagService // Nil if feature flags are not wired
+	sandboxHandler      *handler.SandboxHandler     // Nil if the sandbox is not wired
+	sandbox             *service.SandboxService
+	maintenanceHandler  *handler.MaintenanceHandler // Nil if maintenance is not wired
+	maintenance         *service.MaintenanceService
+	archive             *service.ArchiveService // Nil if archival is disabled
+	selfCheckHandler    *handler.SelfCheckHandler
+	recordsHandler      *handler.RecordsHandler // Nil if win records are not wired
+	digestHandler       *handler.DigestHandler  // Nil if the admin digest is disabled
+	comebackHandler     *handler.ComebackHandler // Nil if comeback offers are disabled
+	degraded            *service.DegradedService // Nil if the database probe is disabled
+	degradedHandler     *handler.DegradedHandler
+	heistGame           *heist.HeistGame // Nil if heists are not wired
+	blackjackGame       *blackjack.BlackjackGame // Nil if blackjack is not wired
+	handlerDurations    *metrics.HistogramVec
+	tracer              *tracing.Tracer
+	stateStore          ttlstore.Store
+	txPartitions        *service.TransactionPartitionService
+	publicStats         *service.PublicStatsService
+	ready               *readiness.Gate
+	shards              *shard.Set // Nil processes every chat
+	shardForwarder      *shard.Forwarder // Nil drops the updates of other shards
+	shardSkips          *metrics.CounterVec
+	balanceAlerts       *service.BalanceAlertService
+	selfExclusions      *service.SelfExclusionService
+	gamblingLimits      *service.GamblingLimitService
 }
 
 // Dependencies holds all the dependencies needed by the bot handlers.
 type Dependencies struct {
-	Config          *config.Config
-	AccountService  *service.AccountService
-	TransferService *service.TransferService
-	RankingService  *service.RankingService
-	ShopService     *service.ShopService
-	GameRegistry    *game.Registry
-	SicBoGame       *sicbo.SicBoGame
-	RobGame         *rob.RobGame
-	AllInGame       *allin.AllInGame
-	UserLock        *lock.UserLock
+	Config              *config.Config
+	AccountService      *service.AccountService
+	TransferService     *service.TransferService
+	RankingService      *service.RankingService
+	ShopService         *service.ShopService
+	PromoService        *service.PromoService
+	SupportService      *service.SupportService
+	RefundService       *service.RefundService
+	TxSearchService     *service.TxSearchService
+	RTPService          *service.RTPService
+	MergeService        *service.MergeService
+	BalanceHistory      *service.BalanceHistoryService
+	CompensationService *service.CompensationService
+	ChatStatsService    *service.ChatStatsService
+	RaidService         *service.RaidService
+	PersonaService      *service.PersonaService
+	BalanceAlerts       *service.BalanceAlertService
+	OutboxService       *service.OutboxService
+	SicBoSummaries      *service.SicBoSummaryService
+	SelfExclusions      *service.SelfExclusionService
+	GamblingLimits      *service.GamblingLimitService
+	CosmeticService     *service.CosmeticService
+	SicBoAutoService    *service.SicBoAutoService
+	TreasuryService     *service.TreasuryService
+	PvPService          *service.PvPService
+	ExportService       *service.ExportService
+	PoolService         *service.PoolService
+	BailoutService      *service.BailoutService
+	ArchiveService      *service.ArchiveService // Optional: archival of inactive users
+	DailyRewards        *service.DailyRewardService // Optional: daily reward scaled inverse to inflation
+	CelebrationService  *service.CelebrationService
+	BanterService       *service.BanterService // Optional: phrases appended to game results
+	RobMessageService   *service.RobMessageService // Optional: flavor texts of rob results
+	TxPartitions        *service.TransactionPartitionService // Optional: monthly partitions of transactions
+	PublicStats         *service.PublicStatsService          // Optional: anonymized public stats page
+	Ready               *readiness.Gate                      // Optional: opened once the bot takes updates
+	MediaAssets         *service.MediaAssetService // Optional: runtime-configurable media such as the shop banner
+	ChatSettings        *service.ChatSettingsService // Optional: per chat settings chosen in the setup wizard
+	Whitelist           *service.WhitelistService    // Configured chats plus those changed with /whitelist
+	RobInsurance        *service.RobInsuranceService // Optional: rob insurance pool shown by /pool
+	FeatureFlags        *service.FeatureFlagService  // Optional: features turned on and off per chat or user
+	GameRounds          *service.GameRoundService    // Optional: dice and slot rounds recorded for cmd/replay
+	SandboxService      *service.SandboxService
+	MaintenanceService  *service.MaintenanceService
+	RecordsService      *service.RecordsService
+	DigestService       *service.DigestService // Optional: nightly admin digest
+	ComebackService     *service.ComebackService // Optional: welcome-back offers
+	DegradedService     *service.DegradedService // Optional: degraded mode while the database is down
+	InventoryCleanup    *service.InventoryCleanupService
+	GameRegistry        *game.Registry
+	SicBoGame           *sicbo.SicBoGame
+	HeistGame           *heist.HeistGame
+	BlackjackGame       *blackjack.BlackjackGame
+	BlackjackHands      *repository.BlackjackHandRepository // Optional: stakes of blackjack hands in play, refunded after a restart
+	HandlerDurations    *metrics.HistogramVec // Optional: timings of every handler
+	Chaos               *chaos.Injector       // Optional: fails Telegram API calls in staging
+	Pacer               *pacer.Pacer          // Optional: holds back sends to chats restricting the bot
+	Tracer              *tracing.Tracer       // Optional: exports spans of updates and jobs
+	StateStore          ttlstore.Store        // Optional: durable cooldowns, rate limits and handled callbacks (nil = in memory)
+	Database            *db.Pool              // Optional: pinged by /selfcheck
+	EventBus            *events.Bus           // Optional: game wins are published here
+	Shards              *shard.Set            // Optional: chats processed by this instance
+	ShardForwarder      *shard.Forwarder      // Optional: forwards the updates of other shards to their owner
+	ShardSkips          *metrics.CounterVec   // Optional: updates of other shards, by outcome
+	RobGame             *rob.RobGame
+	AllInGame           *allin.AllInGame
+	UserLock            *lock.UserLock
 }
 
 // New creates a new Bot instance with the given dependencies.
@@ -70,6 +197,29 @@ func New(deps *Dependencies) (*Bot, error) {
 		Token:  deps.Config.Bot.Token,
 		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
 	}
+	if webhook := deps.Config.Bot.Webhook; webhook.Listen != "" {
+		poller := &tele.Webhook{Listen: webhook.Listen, SecretToken: webhook.SecretToken}
+		if webhook.PublicURL != "" {
+			poller.Endpoint = &tele.WebhookEndpoint{PublicURL: webhook.PublicURL}
+		}
+		pref.Poller = poller
+	}
+	if deps.ChatSettings != nil {
+		pref.Poller = tele.NewMiddlewarePoller(pref.Poller, QuickBetFilter(deps.ChatSettings))
+	}
+	var transport http.RoundTripper
+	if deps.Chaos != nil {
+		transport = deps.Chaos.Transport(nil)
+	}
+	if deps.Pacer != nil {
+		transport = deps.Pacer.Transport(transport)
+	}
+	if deps.Tracer != nil {
+		transport = deps.Tracer.Transport(transport)
+	}
+	if transport != nil {
+		pref.Client = &http.Client{Timeout: time.Minute, Transport: transport}
+	}
 
 	teleBot, err := tele.NewBot(pref)
 	if err != nil {
@@ -77,17 +227,35 @@ func New(deps *Dependencies) (*Bot, error) {
 	}
 
 	b := &Bot{
-		bot:             teleBot,
-		cfg:             deps.Config,
-		accountService:  deps.AccountService,
-		transferService: deps.TransferService,
-		rankingService:  deps.RankingService,
-		shopService:     deps.ShopService,
-		gameRegistry:    deps.GameRegistry,
-		sicboGame:       deps.SicBoGame,
-		robGame:         deps.RobGame,
-		allInGame:       deps.AllInGame,
-		userLock:        deps.UserLock,
+		bot:                 teleBot,
+		cfg:                 deps.Config,
+		accountService:      deps.AccountService,
+		transferService:     deps.TransferService,
+		rankingService:      deps.RankingService,
+		shopService:         deps.ShopService,
+		promoService:        deps.PromoService,
+		supportService:      deps.SupportService,
+		compensationService: deps.CompensationService,
+		chatStatsService:    deps.ChatStatsService,
+		raidService:         deps.RaidService,
+		handlerDurations:    deps.HandlerDurations,
+		tracer:              deps.Tracer,
+		stateStore:          deps.StateStore,
+		txPartitions:        deps.TxPartitions,
+		publicStats:         deps.PublicStats,
+		ready:               deps.Ready,
+		shards:              deps.Shards,
+		shardForwarder:      deps.ShardForwarder,
+		shardSkips:          deps.ShardSkips,
+		gameRegistry:        deps.GameRegistry,
+		sicboGame:           deps.SicBoGame,
+		robGame:             deps.RobGame,
+		allInGame:           deps.AllInGame,
+		userLock:            deps.UserLock,
+		balanceAlerts:       deps.BalanceAlerts,
+		selfExclusions:      deps.SelfExclusions,
+		gamblingLimits:      deps.GamblingLimits,
+		whitelist:           deps.Whitelist,
 	}
 
 	// Initialize handlers
@@ -95,9 +263,238 @@ func New(deps *Dependencies) (*Bot, error) {
 	b.transferHandler = handler.NewTransferHandler(deps.AccountService, deps.TransferService, deps.UserLock)
 	b.adminHandler = handler.NewAdminHandler(deps.AccountService, deps.UserLock)
 	b.rankingHandler = handler.NewRankingHandler(deps.RankingService)
-	b.gameHandler = handler.NewGameHandler(deps.Config, deps.AccountService, deps.GameRegistry, deps.SicBoGame, deps.RobGame, deps.UserLock)
+	b.gameHandler = handler.NewGameHandler(deps.Config, deps.AccountService, deps.CompensationService, deps.GameRegistry, deps.SicBoGame, deps.RobGame, deps.UserLock)
+	if deps.StateStore != nil {
+		b.gameHandler.Cooldowns().SetStore(deps.StateStore)
+	} else {
+		b.stateStore = ttlstore.NewMemory()
+	}
 	b.shopHandler = handler.NewShopHandler(deps.ShopService, deps.AccountService)
 	b.allInHandler = handler.NewAllInHandler(deps.AccountService, deps.AllInGame, deps.UserLock)
+	b.allInHandler.SetCooldowns(b.gameHandler.Cooldowns())
+	b.promoHandler = handler.NewPromoHandler(deps.PromoService, deps.AccountService)
+	b.supportHandler = handler.NewSupportHandler(deps.Config, deps.SupportService, deps.AccountService)
+	b.compensationHandler = handler.NewCompensationHandler(deps.CompensationService)
+	b.chatStatsHandler = handler.NewChatStatsHandler(deps.Config, deps.ChatStatsService, deps.SicBoGame)
+	b.raidHandler = handler.NewRaidHandler(deps.Config, deps.RaidService, deps.AccountService)
+	b.personaHandler = handler.NewPersonaHandler(deps.Config, deps.PersonaService)
+	b.balanceAlertHandler = handler.NewBalanceAlertHandler(deps.BalanceAlerts)
+	b.sicboSummaryHandler = handler.NewSicBoSummaryHandler(deps.SicBoSummaries)
+	b.selfExclusionHandler = handler.NewSelfExclusionHandler(deps.SelfExclusions)
+	b.gamblingLimitHandler = handler.NewGamblingLimitHandler(deps.GamblingLimits)
+	b.cosmeticHandler = handler.NewCosmeticHandler(deps.CosmeticService)
+	b.pvpHandler = handler.NewPvPHandler(deps.PvPService)
+	b.exportHandler = handler.NewExportHandler(deps.ExportService)
+	b.poolHandler = handler.NewPoolHandler(deps.Config, deps.PoolService, deps.AccountService)
+	if deps.RobInsurance != nil {
+		b.poolHandler.SetRobInsurance(deps.RobInsurance)
+	}
+
+	// Admins reverse specific transactions with /refundtx
+	b.adminHandler.SetRefundService(deps.RefundService)
+
+	// Admins search transactions for support investigations with /findtx
+	b.adminHandler.SetTxSearch(deps.TxSearchService)
+
+	// Admins compare the realized RTP of the house games with the payout tables with /rtp
+	b.adminHandler.SetRTP(deps.RTPService)
+	b.mergeHandler = handler.NewMergeHandler(deps.MergeService)
+	b.wealthHandler = handler.NewWealthHandler(deps.BalanceHistory, deps.AccountService)
+
+	// Admins review the house account with /treasury
+	b.adminHandler.SetTreasury(deps.TreasuryService)
+
+	// Cosmetics bought with stars show in /my and the private shop
+	b.accountHandler.SetCosmetics(deps.CosmeticService)
+	b.shopHandler.SetCosmetics(deps.CosmeticService)
+
+	// /simulate runs with the live daily reward and robbery amounts
+	robCfg := deps.Config.Games.Rob
+	b.adminHandler.SetSimulationDefaults(service.EconomySimConfig{
+		DailyReward: deps.Config.Daily.Reward,
+		RobAmounts:  rob.NewAmountPolicy(robCfg.AmountMode, robCfg.MinPercent, robCfg.MaxPercent, robCfg.MinAmount, robCfg.MaxAmount),
+	})
+
+	// Game results feed the pinned chat statistics
+	b.gameHandler.SetChatStats(deps.ChatStatsService)
+
+	// Game results are worded by the chat's persona
+	b.gameHandler.SetPersona(deps.PersonaService)
+
+	// SicBo rounds start on a per-chat schedule
+	b.gameHandler.SetSicBoAuto(deps.SicBoAutoService)
+
+	// /cooldowns lists the all-in cooldowns next to the game cooldowns
+	if deps.AllInGame != nil {
+		b.gameHandler.Cooldowns().Register(handler.CooldownAllInRob, func(_ context.Context, userID int64) time.Duration {
+			return deps.AllInGame.GetRobCooldown(userID)
+		})
+		b.gameHandler.Cooldowns().Register(handler.CooldownAllInDice, func(_ context.Context, userID int64) time.Duration {
+			return deps.AllInGame.GetDiceCooldown(userID)
+		})
+	}
+
+	// DMs, admin messages and group announcements of the services are sent through the bot
+	notifier := handler.NewNotifier(teleBot, deps.Config)
+	announcer := handler.NewAnnouncer(teleBot)
+
+	// Compensation DMs and approval requests
+	deps.CompensationService.SetNotifier(notifier)
+
+	// Item expiry warnings are sent as DMs as well
+	deps.InventoryCleanup.SetNotifier(notifier)
+	b.shopHandler.SetInventoryCleanup(deps.InventoryCleanup)
+
+	// Balance change alerts are sent as DMs as well
+	deps.BalanceAlerts.SetNotifier(notifier)
+
+	// Outbox DMs are retried until delivered; SicBo summaries are sent through it
+	deps.OutboxService.SetSender(handler.NewDirectSender(teleBot))
+	b.outboxHandler = handler.NewOutboxHandler(deps.OutboxService)
+	b.gameHandler.SetSicBoSummaries(deps.SicBoSummaries)
+
+	// Bailout grants are announced as DMs as well
+	if deps.BailoutService != nil {
+		deps.BailoutService.SetNotifier(notifier)
+		b.accountHandler.SetBailout(deps.BailoutService)
+	}
+
+	// The daily reward follows recent inflation when enabled
+	if deps.DailyRewards != nil {
+		deps.AccountService.SetDailyRewarder(deps.DailyRewards)
+		b.accountHandler.SetDailyRewards(deps.DailyRewards)
+	}
+
+	// The nightly admin digest is sent as DMs as well
+	if deps.DigestService != nil {
+		deps.DigestService.SetNotifier(notifier)
+		b.digestHandler = handler.NewDigestHandler(deps.DigestService)
+	}
+
+	// Welcome-back offers are DMs with a claim button
+	if deps.ComebackService != nil {
+		deps.ComebackService.SetNotifier(handler.NewComebackNotifier(teleBot))
+		b.comebackHandler = handler.NewComebackHandler(deps.ComebackService)
+	}
+
+	// Admins are alerted when the database goes down and comes back
+	if deps.DegradedService != nil {
+		deps.DegradedService.SetNotifier(notifier)
+		b.accountHandler.SetDegraded(deps.DegradedService)
+		b.degraded = deps.DegradedService
+		b.degradedHandler = handler.NewDegradedHandler(deps.DegradedService)
+	}
+
+	// The shop banner and other media are configured at runtime
+	if deps.MediaAssets != nil {
+		b.shopHandler.SetMediaAssets(deps.MediaAssets)
+		b.mediaAssetHandler = handler.NewMediaAssetHandler(deps.MediaAssets)
+	}
+
+	// Groups choose games, timezone and message cleanup in the setup wizard
+	if deps.ChatSettings != nil {
+		b.chatSettings = deps.ChatSettings
+		b.gameHandler.SetChatSettings(deps.ChatSettings)
+		deps.SicBoAutoService.SetLocator(deps.ChatSettings)
+		b.chatSettingsHandler = handler.NewChatSettingsHandler(deps.Config, deps.ChatSettings, deps.Whitelist)
+	}
+
+	// Admins change the whitelist at runtime on top of the configured one
+	b.gameHandler.SetWhitelist(deps.Whitelist)
+	b.raidHandler.SetWhitelist(deps.Whitelist)
+	b.whitelistHandler = handler.NewWhitelistHandler(deps.Whitelist)
+	if b.chatSettingsHandler != nil {
+		b.whitelistHandler.SetChatSettings(b.chatSettingsHandler)
+	}
+
+	// Dice and slot rounds are recorded so disputed outcomes can be replayed
+	if deps.GameRounds != nil {
+		b.gameHandler.SetGameRounds(deps.GameRounds)
+	}
+
+	// Admins turn features on and off per chat or user
+	if deps.FeatureFlags != nil {
+		b.gameHandler.SetFeatureFlags(deps.FeatureFlags)
+		b.featureFlags = deps.FeatureFlags
+		b.featureFlagHandler = handler.NewFeatureFlagHandler(deps.FeatureFlags, deps.AccountService)
+	}
+
+	// Raid announcements are posted in both participating chats
+	deps.RaidService.SetNotifier(announcer)
+
+	// Big wins are celebrated with stickers and animations
+	if deps.CelebrationService != nil {
+		deps.CelebrationService.SetSender(handler.NewCelebrationSender(teleBot))
+		b.gameHandler.SetCelebrations(deps.CelebrationService)
+		deps.RaidService.SetCelebrations(deps.CelebrationService)
+		b.celebrationHandler = handler.NewCelebrationHandler(deps.CelebrationService)
+	}
+
+	// Game results occasionally get a taunt or congratulation
+	if deps.BanterService != nil {
+		b.gameHandler.SetBanter(deps.BanterService)
+		b.banterHandler = handler.NewBanterHandler(deps.BanterService)
+	}
+
+	// Rob results read differently from one rob to the next
+	if deps.RobMessageService != nil {
+		b.robMessageHandler = handler.NewRobMessageHandler(deps.RobMessageService)
+	}
+
+	// Cooperative heists recruited with a join button
+	if deps.HeistGame != nil {
+		b.heistGame = deps.HeistGame
+		b.gameHandler.SetHeist(deps.HeistGame)
+	}
+
+	// Blackjack hands played with hit, stand and double buttons
+	if deps.BlackjackGame != nil {
+		b.blackjackGame = deps.BlackjackGame
+		b.gameHandler.SetBlackjack(deps.BlackjackGame)
+		if deps.BlackjackHands != nil {
+			b.gameHandler.SetBlackjackHands(deps.BlackjackHands)
+		}
+	}
+
+	// Inactive users are archived and restored on their next interaction
+	if deps.ArchiveService != nil {
+		b.archive = deps.ArchiveService
+		b.accountHandler.SetArchive(deps.ArchiveService)
+	}
+
+	// Sandbox chats play the house games with play money
+	if deps.SandboxService != nil {
+		b.sandbox = deps.SandboxService
+		deps.AccountService.SetSandbox(deps.SandboxService)
+		b.gameHandler.SetSandbox(deps.SandboxService)
+		if deps.PoolService != nil {
+			deps.PoolService.SetSandbox(deps.SandboxService)
+		}
+		b.sandboxHandler = handler.NewSandboxHandler(deps.Config, deps.SandboxService, deps.SicBoGame)
+	}
+
+	// Scheduled maintenance is announced to the chats and pauses the bot
+	if deps.MaintenanceService != nil {
+		b.maintenance = deps.MaintenanceService
+		deps.MaintenanceService.SetNotifier(announcer)
+		deps.MaintenanceService.SetChatLister(deps.Whitelist)
+		b.gameHandler.SetMaintenance(deps.MaintenanceService)
+		b.maintenanceHandler = handler.NewMaintenanceHandler(deps.Config, deps.MaintenanceService)
+	}
+
+	// Dice and slot wins feed the per-chat win records, announced when broken
+	b.gameHandler.SetEventBus(deps.EventBus)
+	if deps.RecordsService != nil {
+		deps.RecordsService.SetNotifier(handler.NewRecordsAnnouncer(teleBot, deps.ChatSettings))
+		b.recordsHandler = handler.NewRecordsHandler(deps.RecordsService)
+	}
+
+	// Admins check the database, Telegram and background loops with /selfcheck
+	if deps.Database != nil {
+		b.selfCheckHandler = handler.NewSelfCheckHandler(deps.Database)
+	} else {
+		b.selfCheckHandler = handler.NewSelfCheckHandler(nil)
+	}
 
 	// Register middleware
 	b.registerMiddleware()
@@ -110,8 +507,64 @@ func New(deps *Dependencies) (*Bot, error) {
 
 // registerMiddleware registers all middleware.
 func (b *Bot) registerMiddleware() {
+	// Handler timings, first so they cover every other middleware
+	if b.handlerDurations != nil {
+		b.gameHandler.SetDurations(b.handlerDurations)
+		b.bot.Use(MetricsMiddleware(b.handlerDurations, time.Duration(b.cfg.Metrics.SlowHandlerMs)*time.Millisecond))
+	}
+
+	// Update spans, before the other middlewares so refusals are traced too
+	if b.tracer != nil {
+		b.gameHandler.SetTracer(b.tracer)
+		b.bot.Use(TracingMiddleware(b.tracer))
+	}
+
+	// Updates of chats owned by other instances are forwarded or dropped before any work
+	if b.shards.Enabled() {
+		b.gameHandler.SetShards(b.shards)
+		b.bot.Use(ShardMiddleware(b.shards, b.shardForwarder, b.shardSkips))
+	}
+
+	// Callbacks already handled, e.g. redelivered webhook updates, are dropped
+	b.bot.Use(CallbackDedupeMiddleware(b.stateStore))
+
 	// Whitelist middleware - check if chat is allowed
-	b.bot.Use(WhitelistMiddleware(b.cfg))
+	b.bot.Use(WhitelistMiddleware(b.cfg, b.whitelist, b.stateStore))
+
+	// Balance changes are refused while the database is down
+	if b.degraded != nil {
+		b.bot.Use(DegradedMiddleware(b.cfg, b.degraded))
+	}
+
+	// Group activity decides who is offline for balance alerts
+	b.bot.Use(ActivityMiddleware(b.balanceAlerts))
+
+	// Any interaction restores archived users
+	if b.archive != nil {
+		b.bot.Use(InteractionMiddleware(b.archive))
+	}
+
+	// Players are refused during maintenance, new rounds shortly before it
+	if b.maintenance != nil {
+		b.bot.Use(MaintenanceMiddleware(b.cfg, b.maintenance))
+	}
+
+	// Commands moving real coins are refused in sandbox chats
+	if b.sandbox != nil {
+		b.bot.Use(SandboxMiddleware(b.sandbox))
+	}
+
+	// Gambling is refused in groups that disabled games
+	if b.chatSettings != nil {
+		b.bot.Use(ChatGamesMiddleware(b.chatSettings))
+		b.bot.Use(QuietHoursMiddleware(b.chatSettings))
+	}
+
+	// Gambling is refused to users who excluded themselves
+	b.bot.Use(SelfExclusionMiddleware(b.selfExclusions))
+
+	// Gambling is refused to users over the daily limits they set
+	b.bot.Use(GamblingLimitMiddleware(b.gamblingLimits))
 
 	// Logging middleware
 	b.bot.Use(LoggingMiddleware())
@@ -136,21 +589,92 @@ func (b *Bot) registerHandlers() {
 	adminGroup.Handle("/admin_sub", b.adminHandler.HandleAdminSub)
 	adminGroup.Handle("/admin_set", b.adminHandler.HandleAdminSet)
 	adminGroup.Handle("/admin_gift_all", b.adminHandler.HandleAdminGiftAll)
+	adminGroup.Handle("/refundtx", b.adminHandler.HandleRefundTx)
+	adminGroup.Handle("/findtx", b.adminHandler.HandleFindTx)
+	adminGroup.Handle("/merge", b.mergeHandler.HandleMerge)
+	adminGroup.Handle("/simulate", b.adminHandler.HandleSimulate)
+	adminGroup.Handle("/rtp", b.adminHandler.HandleRTP)
+	adminGroup.Handle("/treasury", b.adminHandler.HandleTreasury)
+	if b.celebrationHandler != nil {
+		adminGroup.Handle("/celebrate", b.celebrationHandler.HandleCelebrate)
+	}
+	if b.banterHandler != nil {
+		adminGroup.Handle("/banter", b.banterHandler.HandleBanter)
+	}
+	if b.robMessageHandler != nil {
+		adminGroup.Handle("/robmsg", b.robMessageHandler.HandleRobMessage)
+	}
+	if b.mediaAssetHandler != nil {
+		adminGroup.Handle("/setasset", b.mediaAssetHandler.HandleSetAsset)
+	}
+	adminGroup.Handle("/gencode", b.promoHandler.HandleGenCode)
+	adminGroup.Handle("/comp_pending", b.compensationHandler.HandleCompPending)
+	adminGroup.Handle("/comp_approve", b.compensationHandler.HandleCompApprove)
+	adminGroup.Handle("/comp_reject", b.compensationHandler.HandleCompReject)
+	adminGroup.Handle("/pinstats", b.chatStatsHandler.HandlePinStats)
+	adminGroup.Handle("/raid_start", b.raidHandler.HandleRaidStart)
+	adminGroup.Handle("/raid_cancel", b.raidHandler.HandleRaidCancel)
+	adminGroup.Handle("/pool_resolve", b.poolHandler.HandlePoolResolve)
+	adminGroup.Handle("/pool_cancel", b.poolHandler.HandlePoolCancel)
+	adminGroup.Handle("/sale", b.shopHandler.HandleSale)
+	if b.maintenanceHandler != nil {
+		adminGroup.Handle("/maintenance", b.maintenanceHandler.HandleMaintenance)
+	}
+	adminGroup.Handle("/selfcheck", b.selfCheckHandler.HandleSelfCheck)
+	if b.digestHandler != nil {
+		adminGroup.Handle("/digest", b.digestHandler.HandleDigest)
+	}
+	if b.comebackHandler != nil {
+		adminGroup.Handle("/comeback_stats", b.comebackHandler.HandleComebackStats)
+	}
+	adminGroup.Handle("/whitelist", b.whitelistHandler.HandleWhitelist)
+	if b.featureFlagHandler != nil {
+		adminGroup.Handle("/flag", b.featureFlagHandler.HandleFlag)
+	}
 
 	// Ranking handler
 	b.bot.Handle("/daily_top", b.rankingHandler.HandleDailyTop)
 
 	// Game handlers
 	b.bot.Handle("/dice", b.gameHandler.HandleDice)
+	b.bot.Handle("/dice3", b.gameHandler.HandleDice3)
+	b.bot.Handle("/dicebo3", b.gameHandler.HandleDiceBo3)
 	b.bot.Handle("/slot", b.gameHandler.HandleSlot)
+	b.bot.Handle("/freespin", b.gameHandler.HandleFreeSpin)
+	b.bot.Handle("/cooldowns", b.gameHandler.HandleCooldowns)
+	b.bot.Handle("/stake", b.gameHandler.HandleStake)
+	if b.recordsHandler != nil {
+		b.bot.Handle("/records", b.recordsHandler.HandleRecords)
+	}
 
 	// SicBo handlers
 	b.bot.Handle("/sicbo", b.gameHandler.HandleSicBoStart)
 	b.bot.Handle("/sicbo_settle", b.gameHandler.HandleSicBoSettle)
+	b.bot.Handle("/sicbo_auto", b.gameHandler.HandleSicBoAuto)
+	b.bot.Handle("/sicbo_force", b.gameHandler.HandleSicBoForce)
 	b.bot.Handle("/mybets", b.gameHandler.HandleMyBets)
 
+	// Heist handler
+	if b.heistGame != nil {
+		b.bot.Handle("/heist", b.gameHandler.HandleHeist)
+	}
+
+	// Blackjack handler
+	if b.blackjackGame != nil {
+		b.bot.Handle("/blackjack", b.gameHandler.HandleBlackjack)
+	}
+
 	// Rob game handler
 	b.bot.Handle("/dj", b.gameHandler.HandleDajie)
+	b.bot.Handle("/protect", b.gameHandler.HandleProtect)
+
+	// Raid event handlers
+	b.bot.Handle("/raid", b.raidHandler.HandleRaid)
+	b.bot.Handle("/raid_join", b.raidHandler.HandleRaidJoin)
+
+	// Betting pools (opening restricted to admins)
+	b.bot.Handle("/pool", b.poolHandler.HandlePool)
+	b.bot.Handle("/pool_bet", b.poolHandler.HandlePoolBet)
 
 	// All-in game handlers
 	b.bot.Handle("/shdj", b.allInHandler.HandleAllInRob)
@@ -161,6 +685,59 @@ func (b *Bot) registerHandlers() {
 	b.bot.Handle("/bag", b.shopHandler.HandleBag)
 	b.bot.Handle("/handcuff", b.shopHandler.HandleHandcuff)
 	b.bot.Handle("/key", b.shopHandler.HandleKey)
+	b.bot.Handle("/struggle", b.shopHandler.HandleStruggle)
+
+	// Promo code handler
+	b.bot.Handle("/redeem", b.promoHandler.HandleRedeem)
+
+	// Support ticket handler
+	b.bot.Handle("/support", b.supportHandler.HandleSupport)
+
+	// Balance change alerts
+	b.bot.Handle("/alerts", b.balanceAlertHandler.HandleAlerts)
+	b.bot.Handle("/wealth", b.wealthHandler.HandleWealth)
+
+	// Personal SicBo settlement DMs
+	b.bot.Handle("/sicbodm", b.sicboSummaryHandler.HandleSicBoDM)
+
+	// Welcome-back offer DMs
+	if b.comebackHandler != nil {
+		b.bot.Handle("/comeback", b.comebackHandler.HandleComeback)
+	}
+
+	// Voluntary self exclusion from all gambling
+	b.bot.Handle("/selfexclude", b.selfExclusionHandler.HandleSelfExclude)
+
+	// Self-set daily wager and loss limits
+	b.bot.Handle("/limits", b.gamblingLimitHandler.HandleLimits)
+
+	// PvP opt-out
+	b.bot.Handle("/pvp", b.pvpHandler.HandlePvP)
+
+	// Export of the user's own transactions (private chat, once a day)
+	b.bot.Handle("/export_me", b.exportHandler.HandleExportMe)
+
+	// Chat persona (changes restricted to group admins)
+	b.bot.Handle("/persona", b.personaHandler.HandlePersona)
+
+	// Sandbox mode with play money (changes restricted to group admins)
+	if b.sandboxHandler != nil {
+		b.bot.Handle("/sandbox", b.sandboxHandler.HandleSandbox)
+	}
+
+	// Setup wizard posted when joining a group, reopened with /settings
+	if b.chatSettingsHandler != nil {
+		b.bot.Handle(tele.OnAddedToGroup, b.chatSettingsHandler.HandleAddedToGroup)
+		b.bot.Handle("/settings", b.chatSettingsHandler.HandleSettings)
+	}
+
+	// Cosmetics sold for Telegram Stars
+	b.bot.Handle("/stars", b.cosmeticHandler.HandleStars)
+	b.bot.Handle(tele.OnCheckout, b.cosmeticHandler.HandleCheckout)
+	b.bot.Handle(tele.OnPayment, b.cosmeticHandler.HandlePayment)
+
+	// Text bets replying to the sicbo panel
+	b.bot.Handle(tele.OnText, b.gameHandler.HandleSicBoTextBet)
 
 	// Generic callback handler for sicbo and shop buttons
 	b.bot.Handle(tele.OnCallback, b.handleCallback)
@@ -204,6 +781,54 @@ func (b *Bot) handleCallback(c tele.Context) error {
 		return b.allInHandler.HandleDuelCallback(c)
 	}
 
+	// Route "再来一次" buttons of dice and slot results
+	if strings.HasPrefix(data, "replay_") {
+		log.Debug().Msg("Routing to replay handler")
+		return b.gameHandler.HandleReplayCallback(c)
+	}
+
+	// Route heist join buttons
+	if strings.HasPrefix(data, "heist_") {
+		log.Debug().Msg("Routing to heist handler")
+		return b.gameHandler.HandleHeistCallback(c)
+	}
+
+	// Route blackjack hit, stand and double buttons
+	if strings.HasPrefix(data, "bj_") && b.blackjackGame != nil {
+		log.Debug().Msg("Routing to blackjack handler")
+		return b.gameHandler.HandleBlackjackCallback(c)
+	}
+
+	// Route setup wizard callbacks
+	if strings.HasPrefix(data, "setup_") && b.chatSettingsHandler != nil {
+		log.Debug().Msg("Routing to chat settings handler")
+		return b.chatSettingsHandler.HandleSettingsCallback(c)
+	}
+
+	// Route confirmations of admin balance adjustments
+	if strings.HasPrefix(data, "adjust_") {
+		log.Debug().Msg("Routing to admin adjustment handler")
+		return b.adminHandler.HandleAdjustmentCallback(c)
+	}
+
+	// Route confirmations of account merges
+	if strings.HasPrefix(data, "merge_") {
+		log.Debug().Msg("Routing to merge handler")
+		return b.mergeHandler.HandleMergeCallback(c)
+	}
+
+	// Route support ticket callbacks
+	if strings.HasPrefix(data, "support_") {
+		log.Debug().Msg("Routing to support handler")
+		return b.supportHandler.HandleSupportCallback(c)
+	}
+
+	// Route welcome-back offer callbacks
+	if strings.HasPrefix(data, "comeback_") && b.comebackHandler != nil {
+		log.Debug().Msg("Routing to comeback handler")
+		return b.comebackHandler.HandleComebackCallback(c)
+	}
+
 	// Route sicbo callbacks
 	log.Debug().Msg("Routing to sicbo handler")
 	return b.gameHandler.HandleSicBoCallback(c)
@@ -212,11 +837,117 @@ func (b *Bot) handleCallback(c tele.Context) error {
 // Start starts the bot polling.
 func (b *Bot) Start() {
 	log.Info().Msg("Starting bot...")
+
+	// Load the caches of the first commands before taking updates
+	if b.cfg.Bot.WarmupSeconds > 0 {
+		b.warmUp(time.Duration(b.cfg.Bot.WarmupSeconds) * time.Second)
+	}
 	
 	// Start message cleaner for auto-deleting old bot messages
 	b.gameHandler.StartMessageCleaner(b.bot)
 	log.Info().Msg("Message cleaner started (30 min interval)")
-	
+
+	// Start probing the database, on every instance since each serves commands
+	if b.degradedHandler != nil {
+		b.degradedHandler.StartProbe(time.Duration(b.cfg.Degraded.CheckSeconds) * time.Second)
+	}
+
+	// Start refreshing sicbo panels and settling finished sessions
+	b.gameHandler.StartSicBoCoordinator(b.bot)
+
+	// Start recovering sicbo sessions the coordinator failed to settle
+	b.gameHandler.StartSicBoWatchdog(b.bot)
+
+	// Start heist scheduler settling heists once recruiting ends
+	if b.heistGame != nil {
+		b.gameHandler.StartHeistScheduler(b.bot)
+	}
+
+	// Refund the blackjack hands a previous run left unsettled, then start
+	// standing hands left without a button press
+	if b.blackjackGame != nil {
+		b.gameHandler.RefundOrphanedBlackjackHands(context.Background(), b.shards.Owns)
+		b.gameHandler.StartBlackjackScheduler(b.bot)
+	}
+
+	// Start scheduled sicbo rounds
+	b.gameHandler.StartSicBoAutoScheduler(b.bot)
+
+	// Start deleting expired cooldowns, rate limits and handled callbacks
+	b.startStateCleaner(time.Duration(b.cfg.State.CleanupMinutes) * time.Minute)
+
+	// Start recalculating the dynamic daily reward on every instance
+	b.accountHandler.StartDailyRewardScheduler(time.Duration(b.cfg.Daily.RecalcMinutes) * time.Minute)
+
+	// Start picking up whitelist changes made on other instances
+	b.whitelistHandler.StartRefresher()
+	if b.featureFlagHandler != nil {
+		b.featureFlagHandler.StartRefresher()
+	}
+
+	// Start refreshing pinned chat statistics
+	b.chatStatsHandler.StartRefresher(b.bot)
+
+	// Start refreshing the public stats page served or written by this instance
+	if b.publicStats != nil {
+		b.startPublicStatsRefresher(time.Duration(b.cfg.PublicStats.RefreshMinutes) * time.Minute)
+	}
+
+	// Start following scheduled maintenance, announced by a single instance
+	if b.maintenanceHandler != nil {
+		b.maintenanceHandler.StartScheduler(b.shards.Primary())
+	}
+
+	// Jobs not tied to a chat run on a single instance when chats are sharded
+	if b.shards.Primary() {
+		// Start raid scheduler
+		b.raidHandler.StartScheduler()
+
+		// Start checking that every balance change was booked against the treasury
+		b.adminHandler.StartTreasuryAudit()
+
+		// Start purging empty and expired inventory
+		b.shopHandler.StartInventoryCleaner(time.Duration(b.cfg.Shop.CleanupMinutes) * time.Minute)
+
+		// Start paying recovery grants to players stuck below the balance floor
+		b.accountHandler.StartBailoutScheduler(time.Duration(b.cfg.Bailout.CheckMinutes) * time.Minute)
+
+		// Start archiving users inactive for months
+		b.accountHandler.StartArchiveScheduler(time.Duration(b.cfg.Archive.CheckMinutes) * time.Minute)
+
+		// Start aggregating the profit of finished days for /my
+		b.rankingHandler.StartAggregateScheduler(time.Duration(b.cfg.Stats.AggregateCheckMinutes) * time.Minute)
+
+		// Start creating and archiving the monthly partitions of transactions
+		if b.txPartitions != nil {
+			b.startPartitionMaintainer(time.Duration(b.cfg.Partitions.CheckMinutes) * time.Minute)
+		}
+
+		// Start recording the balances of active users for /wealth
+		b.wealthHandler.StartScheduler()
+
+		// Start delivering queued direct messages
+		b.outboxHandler.StartScheduler()
+
+		// Post the setup wizard in whitelisted chats never onboarded
+		if b.chatSettingsHandler != nil {
+			go b.chatSettingsHandler.OnboardWhitelisted(b.bot)
+		}
+
+		// Start sending the nightly admin digest
+		if b.digestHandler != nil {
+			b.digestHandler.StartScheduler()
+		}
+
+		// Start sending welcome-back offers
+		if b.comebackHandler != nil {
+			b.comebackHandler.StartScheduler(time.Duration(b.cfg.Comeback.CheckMinutes) * time.Minute)
+		}
+	}
+
+	if b.ready != nil {
+		b.ready.Open()
+	}
 	b.bot.Start()
 }
 
@@ -224,6 +955,20 @@ func (b *Bot) Start() {
 func (b *Bot) Stop() {
 	log.Info().Msg("Stopping bot...")
 	b.bot.Stop()
+
+	// Hands, heists and duel matches in play cannot be finished after a
+	// restart, their stakes are returned
+	if b.blackjackGame != nil {
+		b.gameHandler.RefundBlackjackHands(context.Background(), b.bot)
+	}
+	if b.heistGame != nil {
+		b.gameHandler.RefundHeists(context.Background(), b.bot)
+	}
+	if b.allInGame != nil {
+		if err := b.allInGame.RefundDuelMatches(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to refund duel matches on shutdown")
+		}
+	}
 }
 
 // GetBot returns the underlying telebot instance.
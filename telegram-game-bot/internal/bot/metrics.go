@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/metrics"
+)
+
+// HandlerLabel names the flow an update runs for metrics: the command for
+// commands (/dice), the prefix of the callback data for buttons (cb:sicbo)
+// and "text" for other messages, e.g. sicbo text bets.
+func HandlerLabel(c tele.Context) string {
+	if cb := c.Callback(); cb != nil {
+		data := strings.TrimPrefix(cb.Data, "\f")
+		data, _, _ = strings.Cut(data, "|")
+		prefix, _, _ := strings.Cut(data, "_")
+		if prefix == "" {
+			return "cb"
+		}
+		return "cb:" + prefix
+	}
+
+	msg := c.Message()
+	if msg == nil {
+		return "update"
+	}
+	if msg.Payment != nil {
+		return "payment"
+	}
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "text"
+	}
+	command, _, _ := strings.Cut(fields[0], "@")
+	return strings.ToLower(command)
+}
+
+// MetricsMiddleware creates a middleware that times every handler end-to-end,
+// feeds the durations into a histogram and logs handlers slower than slow
+// (0 disables the log).
+func MetricsMiddleware(durations *metrics.HistogramVec, slow time.Duration) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start)
+
+			label := HandlerLabel(c)
+			durations.Observe(label, elapsed.Seconds())
+
+			if slow > 0 && elapsed >= slow {
+				event := log.Warn().
+					Str("handler", label).
+					Dur("elapsed", elapsed).
+					Err(err)
+				if sender := c.Sender(); sender != nil {
+					event = event.Int64("user_id", sender.ID)
+				}
+				if chat := c.Chat(); chat != nil {
+					event = event.Int64("chat_id", chat.ID)
+				}
+				event.Msg("Slow handler")
+			}
+			return err
+		}
+	}
+}
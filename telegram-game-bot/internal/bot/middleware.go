@@ -5,6 +5,11 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +17,11 @@ import (
 	tele "gopkg.in/telebot.v3"
 
 	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/metrics"
+	"telegram-game-bot/internal/pkg/shard"
+	"telegram-game-bot/internal/pkg/ttlstore"
+	"telegram-game-bot/internal/service"
 )
 
 // privateUserCache tracks users who have used the bot in whitelisted groups.
@@ -23,11 +33,11 @@ var (
 )
 
 // Rate limiting for private chat
-var (
-	rateLimitCache   = make(map[int64]time.Time) // userID -> last request time
-	rateLimitMu      sync.RWMutex
-	rateLimitWindow  = 1 * time.Second // Minimum interval between requests
-)
+var rateLimitWindow = 1 * time.Second // Minimum interval between requests
+
+// callbackDedupeTTL is how long handled callbacks are remembered; Telegram
+// redelivers unacknowledged webhook updates well within it
+const callbackDedupeTTL = 10 * time.Minute
 
 // AllowPrivateUser marks a user as allowed to use private chat.
 func AllowPrivateUser(userID int64) {
@@ -43,27 +53,32 @@ func IsPrivateUserAllowed(userID int64) bool {
 	return privateUserCache[userID]
 }
 
-// checkRateLimit checks if user is rate limited, returns true if allowed
-func checkRateLimit(userID int64) bool {
-	rateLimitMu.Lock()
-	defer rateLimitMu.Unlock()
-	
-	now := time.Now()
-	lastTime, exists := rateLimitCache[userID]
-	
-	if exists && now.Sub(lastTime) < rateLimitWindow {
-		return false // Rate limited
+// checkRateLimit checks if user is rate limited, returns true if allowed.
+// Requests are allowed when the store fails.
+func checkRateLimit(store ttlstore.Store, userID int64) bool {
+	key := "ratelimit:" + strconv.FormatInt(userID, 10)
+	allowed, err := store.SetNX(context.Background(), key, "", time.Now().Add(rateLimitWindow))
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to check rate limit")
+		return true
 	}
-	
-	rateLimitCache[userID] = now
-	return true
+	return allowed
 }
 
 // WhitelistMiddleware creates a middleware that checks if the chat is whitelisted.
 // Requirements: 7.1, 7.2
-func WhitelistMiddleware(cfg *config.Config) tele.MiddlewareFunc {
+// Chats are checked against whitelist, the configured chats together with
+// those admins changed with /whitelist. Private chat requests are rate
+// limited through limits.
+func WhitelistMiddleware(cfg *config.Config, whitelist *service.WhitelistService, limits ttlstore.Store) tele.MiddlewareFunc {
 	return func(next tele.HandlerFunc) tele.HandlerFunc {
 		return func(c tele.Context) error {
+			// Payments are validated by their handlers; a successful payment has
+			// already been charged and must never be dropped or rate limited
+			if c.PreCheckoutQuery() != nil || (c.Message() != nil && c.Message().Payment != nil) {
+				return next(c)
+			}
+
 			chat := c.Chat()
 			sender := c.Sender()
 
@@ -79,7 +94,7 @@ func WhitelistMiddleware(cfg *config.Config) tele.MiddlewareFunc {
 				}
 				
 				// Rate limit check for private chat
-				if !checkRateLimit(sender.ID) {
+				if !checkRateLimit(limits, sender.ID) {
 					log.Debug().
 						Int64("user_id", sender.ID).
 						Msg("Rate limited private chat request")
@@ -87,7 +102,7 @@ func WhitelistMiddleware(cfg *config.Config) tele.MiddlewareFunc {
 				}
 				
 				// If whitelist is configured, only allow users from whitelisted groups
-				if len(cfg.Whitelist.Chats) > 0 {
+				if whitelist.Restricted() {
 					if !IsPrivateUserAllowed(sender.ID) {
 						log.Debug().
 							Int64("user_id", sender.ID).
@@ -101,7 +116,7 @@ func WhitelistMiddleware(cfg *config.Config) tele.MiddlewareFunc {
 
 			// For group chats, check whitelist
 			// Requirements: 7.1
-			if !cfg.IsChatAllowed(chat.ID) {
+			if !whitelist.IsChatAllowed(chat.ID) {
 				log.Debug().
 					Int64("chat_id", chat.ID).
 					Msg("Ignoring command from non-whitelisted chat")
@@ -142,6 +157,360 @@ func AdminMiddleware(cfg *config.Config) tele.MiddlewareFunc {
 	}
 }
 
+// ActivityTracker records that a user was active in a group.
+type ActivityTracker interface {
+	Touch(userID int64)
+}
+
+// ActivityMiddleware creates a middleware that reports group activity of users,
+// e.g. so balance alerts are only sent to users away from the groups.
+func ActivityMiddleware(tracker ActivityTracker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			chat := c.Chat()
+			sender := c.Sender()
+			if chat != nil && sender != nil && chat.Type != tele.ChatPrivate {
+				tracker.Touch(sender.ID)
+			}
+			return next(c)
+		}
+	}
+}
+
+// InteractionRecorder records that a user interacted with the bot.
+type InteractionRecorder interface {
+	RecordInteraction(ctx context.Context, userID int64)
+}
+
+// InteractionMiddleware creates a middleware that reports every interaction
+// of users, e.g. so archived users are restored before their update is handled.
+func InteractionMiddleware(recorder InteractionRecorder) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			if sender := c.Sender(); sender != nil && !sender.IsBot {
+				recorder.RecordInteraction(context.Background(), sender.ID)
+			}
+			return next(c)
+		}
+	}
+}
+
+// SandboxChecker tells which chats play with play money.
+type SandboxChecker interface {
+	IsSandbox(ctx context.Context, chatID int64) bool
+}
+
+// SandboxMiddleware creates a middleware that refuses commands moving real
+// coins (transfers, daily rewards, PvP...) in sandbox chats.
+func SandboxMiddleware(sandbox SandboxChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			chat := c.Chat()
+			if chat == nil || chat.Type == tele.ChatPrivate || c.Callback() != nil {
+				return next(c)
+			}
+			if !service.SandboxAllows(c.Text()) && sandbox.IsSandbox(context.Background(), chat.ID) {
+				return c.Reply("🧪 本群处于沙盒模式，此命令暂停使用")
+			}
+			return next(c)
+		}
+	}
+}
+
+// MaintenanceChecker tells whether the bot is in or close to maintenance.
+type MaintenanceChecker interface {
+	Window() *model.MaintenanceWindow
+	Active(now time.Time) bool
+	BlocksNewRounds(now time.Time) bool
+	FormatTime(t time.Time) string
+}
+
+// MaintenanceMiddleware creates a middleware that refuses players during
+// maintenance and refuses new rounds shortly before it. Admins are never
+// refused, so they can check on the bot or end maintenance early.
+func MaintenanceMiddleware(cfg *config.Config, maintenance MaintenanceChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			// Payments were already charged and must never be dropped
+			if c.PreCheckoutQuery() != nil || (c.Message() != nil && c.Message().Payment != nil) {
+				return next(c)
+			}
+			sender := c.Sender()
+			window := maintenance.Window()
+			if sender == nil || window == nil || cfg.IsAdmin(sender.ID) {
+				return next(c)
+			}
+
+			now := time.Now()
+			if maintenance.Active(now) {
+				text := fmt.Sprintf("🔧 系统维护中，预计 %s 恢复", maintenance.FormatTime(window.EndsAt))
+				if c.Callback() != nil {
+					return c.Respond(&tele.CallbackResponse{Text: text, ShowAlert: true})
+				}
+				if strings.HasPrefix(c.Text(), "/") {
+					return c.Reply(text)
+				}
+				return nil
+			}
+			if c.Callback() == nil && maintenance.BlocksNewRounds(now) && service.MaintenanceBlocks(c.Text()) {
+				return c.Reply(fmt.Sprintf("🔧 即将于 %s 开始维护，暂停开新局", maintenance.FormatTime(window.StartsAt)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// DegradedChecker tells whether the bot runs degraded because the database is down.
+type DegradedChecker interface {
+	Degraded() bool
+}
+
+// DegradedMiddleware creates a middleware that refuses balance changes while
+// the database is down. Read-only commands answering from cached values pass,
+// as do admins so they can check on the bot.
+func DegradedMiddleware(cfg *config.Config, degraded DegradedChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			// Payments were already charged and must never be dropped
+			if c.PreCheckoutQuery() != nil || (c.Message() != nil && c.Message().Payment != nil) {
+				return next(c)
+			}
+			sender := c.Sender()
+			if sender == nil || !degraded.Degraded() || cfg.IsAdmin(sender.ID) {
+				return next(c)
+			}
+
+			if c.Callback() != nil {
+				return c.Respond(&tele.CallbackResponse{Text: service.DegradedRefusal, ShowAlert: true})
+			}
+			if !service.DegradedAllows(c.Text()) {
+				return c.Reply(service.DegradedRefusal)
+			}
+			// Text bets are ignored silently like any refused text bet
+			if gamblingUpdate(c) {
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
+
+// gamblingUpdate reports whether an update gambles: a gambling command, a
+// betting button or a text bet replying to the SicBo panel.
+func gamblingUpdate(c tele.Context) bool {
+	if callback := c.Callback(); callback != nil {
+		return service.IsGamblingCallback(callback.Data)
+	}
+	msg := c.Message()
+	if msg == nil || !service.IsGambling(c.Text()) {
+		return false
+	}
+	// Only replies to the SicBo panel are text bets
+	return strings.HasPrefix(c.Text(), "/") || msg.ReplyTo != nil
+}
+
+// refuseGambling answers a refused gambling update; text bets are ignored silently
+func refuseGambling(c tele.Context, text string) error {
+	if c.Callback() != nil {
+		return c.Respond(&tele.CallbackResponse{Text: text, ShowAlert: true})
+	}
+	if strings.HasPrefix(c.Text(), "/") {
+		return c.Reply(text)
+	}
+	return nil
+}
+
+// SelfExclusionChecker tells which users excluded themselves from gambling.
+type SelfExclusionChecker interface {
+	ExcludedUntil(ctx context.Context, userID int64) (time.Time, bool)
+}
+
+// SelfExclusionMiddleware creates a middleware that refuses gambling commands,
+// betting buttons and text bets of users who excluded themselves. Admins are
+// refused as well, the exclusion was their own choice.
+func SelfExclusionMiddleware(exclusions SelfExclusionChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			sender := c.Sender()
+			if sender == nil || !gamblingUpdate(c) {
+				return next(c)
+			}
+
+			until, excluded := exclusions.ExcludedUntil(context.Background(), sender.ID)
+			if !excluded {
+				return next(c)
+			}
+			return refuseGambling(c, fmt.Sprintf("🚫 你已自我禁赌至 %s，期间无法参与任何游戏", until.Format("2006-01-02 15:04")))
+		}
+	}
+}
+
+// GamblingLimitChecker tells which users reached their daily gambling limits.
+type GamblingLimitChecker interface {
+	Check(ctx context.Context, userID int64) string
+}
+
+// GamblingLimitMiddleware creates a middleware that refuses gambling of users
+// who reached a daily wager or loss limit they set, until the day rolls over.
+func GamblingLimitMiddleware(limits GamblingLimitChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			sender := c.Sender()
+			if sender == nil || !gamblingUpdate(c) {
+				return next(c)
+			}
+
+			switch limits.Check(context.Background(), sender.ID) {
+			case service.GamblingLimitWager:
+				return refuseGambling(c, "🛑 今日下注已达你设置的上限，明天再来吧")
+			case service.GamblingLimitLoss:
+				return refuseGambling(c, "🛑 今日亏损已达你设置的上限，明天再来吧")
+			}
+			return next(c)
+		}
+	}
+}
+
+// ChatGamesChecker tells which chats disabled games.
+type ChatGamesChecker interface {
+	GamesEnabled(ctx context.Context, chatID int64) bool
+}
+
+// ChatGamesMiddleware creates a middleware that refuses gambling in groups
+// whose admins disabled games in the chat settings.
+func ChatGamesMiddleware(settings ChatGamesChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			chat := c.Chat()
+			if chat == nil || chat.Type == tele.ChatPrivate || !gamblingUpdate(c) {
+				return next(c)
+			}
+			if settings.GamesEnabled(context.Background(), chat.ID) {
+				return next(c)
+			}
+			return refuseGambling(c, "🚫 本群已关闭游戏，管理员可通过 /settings 重新开启")
+		}
+	}
+}
+
+// QuietHoursChecker tells which chats are in their quiet hours.
+type QuietHoursChecker interface {
+	QuietHours(chatID int64, now time.Time) (string, bool)
+}
+
+// QuietHoursMiddleware creates a middleware that refuses gambling in groups
+// during the quiet hours set by their admins, with a short notice.
+func QuietHoursMiddleware(settings QuietHoursChecker) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			chat := c.Chat()
+			if chat == nil || chat.Type == tele.ChatPrivate || !gamblingUpdate(c) {
+				return next(c)
+			}
+			hours, quiet := settings.QuietHours(chat.ID, time.Now())
+			if !quiet {
+				return next(c)
+			}
+			return refuseGambling(c, fmt.Sprintf("🌙 本群 %s 为安静时段，暂停游戏", hours))
+		}
+	}
+}
+
+// QuickBetChecker tells which chats accept shorthand bets.
+type QuickBetChecker interface {
+	QuickBetsEnabled(chatID int64) bool
+}
+
+// QuickBetFilter creates a poller filter that rewrites shorthand bets such as
+// "d100" into the command they stand for, in groups that enabled quick bets.
+// Rewritten before routing, quick bets pass the same middlewares and
+// cooldowns as the commands.
+func QuickBetFilter(settings QuickBetChecker) func(*tele.Update) bool {
+	return func(upd *tele.Update) bool {
+		msg := upd.Message
+		if msg == nil || msg.Chat == nil || msg.Chat.Type == tele.ChatPrivate || msg.Text == "" {
+			return true
+		}
+		command, ok := service.ParseQuickBet(msg.Text)
+		if !ok || !settings.QuickBetsEnabled(msg.Chat.ID) {
+			return true
+		}
+		msg.Text = command
+		return true
+	}
+}
+
+// CallbackDedupeMiddleware creates a middleware that drops callbacks already
+// handled, e.g. redelivered webhook updates or the same update reaching two
+// instances, remembered in store.
+func CallbackDedupeMiddleware(store ttlstore.Store) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			callback := c.Callback()
+			if callback == nil || callback.ID == "" {
+				return next(c)
+			}
+			first, err := store.SetNX(context.Background(), "callback:"+callback.ID, "", time.Now().Add(callbackDedupeTTL))
+			if err != nil {
+				log.Warn().Err(err).Str("callback_id", callback.ID).Msg("Failed to dedupe callback")
+				return next(c)
+			}
+			if !first {
+				log.Debug().Str("callback_id", callback.ID).Msg("Dropping duplicate callback")
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
+
+// ShardMiddleware creates a middleware that skips the updates of chats owned
+// by other instances. Updates without a chat (pre-checkout queries) are
+// sharded by the sender, whose private chat has the same ID. Skipped updates
+// are forwarded to their owner if forward has its URL, dropped otherwise, and
+// counted in skipped by outcome: forwarded, forward_failed or dropped.
+func ShardMiddleware(shards *shard.Set, forward *shard.Forwarder, skipped *metrics.CounterVec) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			var key int64
+			if chat := c.Chat(); chat != nil {
+				key = chat.ID
+			} else if sender := c.Sender(); sender != nil {
+				key = sender.ID
+			}
+			if shards.Owns(key) {
+				return next(c)
+			}
+
+			owner := shards.Of(key)
+			outcome := "dropped"
+			if forward != nil {
+				update, err := json.Marshal(c.Update())
+				if err == nil {
+					var ok bool
+					if ok, err = forward.Forward(context.Background(), owner, update); ok && err == nil {
+						outcome = "forwarded"
+					}
+				}
+				if err != nil {
+					log.Warn().Err(err).Int("update_id", c.Update().ID).Int("shard", owner).Msg("Failed to forward update to its shard")
+					outcome = "forward_failed"
+				}
+			}
+			log.Debug().
+				Int("update_id", c.Update().ID).
+				Int64("chat_id", key).
+				Int("shard", owner).
+				Str("outcome", outcome).
+				Msg("Skipped update of another shard")
+			if skipped != nil {
+				skipped.Inc(outcome)
+			}
+			return nil
+		}
+	}
+}
+
 // LoggingMiddleware creates a middleware that logs all incoming messages.
 func LoggingMiddleware() tele.MiddlewareFunc {
 	return func(next tele.HandlerFunc) tele.HandlerFunc {
@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+)
+
+// startPartitionMaintainer starts creating the coming monthly partitions of
+// transactions and archiving those past the retention, first right away. It
+// runs on the primary instance only, so DDL is never issued twice at once.
+func (b *Bot) startPartitionMaintainer(interval time.Duration) {
+	go func() {
+		b.txPartitions.Run(context.Background(), time.Now())
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("tx_partitions", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("tx_partitions")
+			b.txPartitions.Run(context.Background(), now)
+		}
+	}()
+}
@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+)
+
+// startPublicStatsRefresher starts refreshing the public stats page, first
+// right away so the page is served as soon as possible. Every instance with
+// the page configured refreshes its own copy.
+func (b *Bot) startPublicStatsRefresher(interval time.Duration) {
+	go func() {
+		b.publicStats.Refresh(context.Background(), time.Now())
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("public_stats", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("public_stats")
+			b.publicStats.Refresh(context.Background(), now)
+		}
+	}()
+}
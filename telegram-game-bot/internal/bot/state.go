@@ -0,0 +1,30 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+)
+
+// startStateCleaner starts deleting expired keys of the state store. It runs
+// on every instance: an in-memory store is local, and deleting expired rows
+// of a shared one twice is harmless.
+func (b *Bot) startStateCleaner(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("state_cleanup", interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("state_cleanup")
+			deleted, err := b.stateStore.DeleteExpired(context.Background())
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete expired state")
+				continue
+			}
+			log.Debug().Int64("deleted", deleted).Msg("Expired state deleted")
+		}
+	}()
+}
@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/handler"
+	"telegram-game-bot/internal/pkg/tracing"
+)
+
+// TracingMiddleware creates a middleware that records a span for every
+// update, named like the handler metrics. Handlers read its context with
+// handler.RequestContext so their queries are children of the update span.
+func TracingMiddleware(tracer *tracing.Tracer) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			ctx, span := tracer.Start(context.Background(), HandlerLabel(c), trace.SpanKindServer)
+			if sender := c.Sender(); sender != nil {
+				span.SetAttributes(attribute.Int64("user_id", sender.ID))
+			}
+			if chat := c.Chat(); chat != nil {
+				span.SetAttributes(attribute.Int64("chat_id", chat.ID))
+			}
+			c.Set(handler.TraceContextKey, ctx)
+
+			err := next(c)
+			tracing.End(span, err)
+			return err
+		}
+	}
+}
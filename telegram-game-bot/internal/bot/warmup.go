@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// warmupStep loads one cache before the bot takes updates
+type warmupStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// runWarmup runs the steps concurrently and waits for them, at most until
+// ctx is done: a step still running then is abandoned (its queries are
+// cancelled by ctx) and the bot starts with that cache cold. Returns the
+// names of the steps that did not finish successfully.
+func runWarmup(ctx context.Context, steps []warmupStep) []string {
+	var mu sync.Mutex
+	pending := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		pending[step.name] = true
+	}
+	var failed []string
+	gaveUp := false // Steps finishing after the timeout are no longer reported
+	var wg sync.WaitGroup
+	for _, step := range steps {
+		wg.Add(1)
+		go func(step warmupStep) {
+			defer wg.Done()
+			start := time.Now()
+			err := step.run(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if gaveUp {
+				return
+			}
+			delete(pending, step.name)
+			if err != nil {
+				failed = append(failed, step.name)
+				log.Warn().Err(err).Str("step", step.name).Dur("took", time.Since(start)).Msg("Warm-up step failed")
+				return
+			}
+			log.Debug().Str("step", step.name).Dur("took", time.Since(start)).Msg("Warm-up step done")
+		}(step)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	gaveUp = true
+	for name := range pending {
+		failed = append(failed, name)
+		log.Warn().Str("step", name).Msg("Warm-up step timed out")
+	}
+	return failed
+}
+
+// warmUp preloads the caches read by the first commands after a deploy, so
+// they do not all wait on a cold database: chat settings, feature flags, the
+// whitelist, the leaderboards and the shop prices. The /top leaderboard is
+// also kept as the last known one served while the database is down.
+func (b *Bot) warmUp(timeout time.Duration) {
+	steps := []warmupStep{
+		{"whitelist", b.whitelist.Refresh},
+		{"leaderboard", func(ctx context.Context) error {
+			users, err := b.rankingService.GetTopUsers(ctx, 10)
+			if err == nil && b.degraded != nil {
+				b.degraded.RememberTop(users, time.Now())
+			}
+			return err
+		}},
+		{"daily_top", func(ctx context.Context) error {
+			if _, err := b.rankingService.GetDailyWinners(ctx, 10); err != nil {
+				return err
+			}
+			_, err := b.rankingService.GetDailyLosers(ctx, 10)
+			return err
+		}},
+		{"shop", func(ctx context.Context) error {
+			b.shopService.PriceQuotes(ctx)
+			return nil
+		}},
+	}
+	if b.chatSettings != nil {
+		steps = append(steps, warmupStep{"chat_settings", b.chatSettings.Warm})
+	}
+	if b.featureFlags != nil {
+		steps = append(steps, warmupStep{"feature_flags", b.featureFlags.Refresh})
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	failed := runWarmup(ctx, steps)
+	log.Info().
+		Int("steps", len(steps)).
+		Strs("failed", failed).
+		Dur("took", time.Since(start)).
+		Msg("Caches warmed up")
+}
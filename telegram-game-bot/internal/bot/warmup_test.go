@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestRunWarmup tests that every step runs, failed steps are reported, and
+// a step still running at the timeout neither blocks the start nor counts
+// as done.
+func TestRunWarmup(t *testing.T) {
+	ran := make(chan string, 3)
+	steps := []warmupStep{
+		{"ok", func(context.Context) error { ran <- "ok"; return nil }},
+		{"failing", func(context.Context) error { ran <- "failing"; return errors.New("down") }},
+		{"slow", func(ctx context.Context) error {
+			ran <- "slow"
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond) // Finishes after the warm-up gave up
+			return nil
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	failed := runWarmup(ctx, steps)
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("Warm-up took %v despite the timeout", took)
+	}
+
+	sort.Strings(failed)
+	if len(failed) != 2 || failed[0] != "failing" || failed[1] != "slow" {
+		t.Fatalf("Failed steps %v, want [failing slow]", failed)
+	}
+	if len(ran) != 3 {
+		t.Fatalf("%d steps ran, want 3", len(ran))
+	}
+}
@@ -12,17 +12,69 @@ import (
 
 // Config holds all application configuration.
 type Config struct {
-	Bot       BotConfig       `mapstructure:"bot"`
-	Database  DatabaseConfig  `mapstructure:"database"`
-	Admin     AdminConfig     `mapstructure:"admin"`
-	Whitelist WhitelistConfig `mapstructure:"whitelist"`
-	Daily     DailyConfig     `mapstructure:"daily"`
-	Games     GamesConfig     `mapstructure:"games"`
+	Bot          BotConfig          `mapstructure:"bot"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Admin        AdminConfig        `mapstructure:"admin"`
+	Whitelist    WhitelistConfig    `mapstructure:"whitelist"`
+	Daily        DailyConfig        `mapstructure:"daily"`
+	Bailout      BailoutConfig      `mapstructure:"bailout"`
+	Archive      ArchiveConfig      `mapstructure:"archive"`
+	Stats        StatsConfig        `mapstructure:"stats"`
+	Partitions   PartitionsConfig   `mapstructure:"partitions"`
+	PublicStats  PublicStatsConfig  `mapstructure:"public_stats"`
+	Comeback     ComebackConfig     `mapstructure:"comeback"`
+	Degraded     DegradedConfig     `mapstructure:"degraded"`
+	Games        GamesConfig        `mapstructure:"games"`
+	Support      SupportConfig      `mapstructure:"support"`
+	Compensation CompensationConfig `mapstructure:"compensation"`
+	ChatStats    ChatStatsConfig    `mapstructure:"chat_stats"`
+	Shop         ShopConfig         `mapstructure:"shop"`
+	Notify       NotifyConfig       `mapstructure:"notify"`
+	Payments     PaymentsConfig     `mapstructure:"payments"`
+	Filter       FilterConfig       `mapstructure:"filter"`
+	Celebration  CelebrationConfig  `mapstructure:"celebration"`
+	Banter       BanterConfig       `mapstructure:"banter"`
+	Sandbox      SandboxConfig      `mapstructure:"sandbox"`
+	Pool         PoolConfig         `mapstructure:"pool"`
+	Maintenance  MaintenanceConfig  `mapstructure:"maintenance"`
+	Digest       DigestConfig       `mapstructure:"digest"`
+	Metrics      MetricsConfig      `mapstructure:"metrics"`
+	Tracing      TracingConfig      `mapstructure:"tracing"`
+	State        StateConfig        `mapstructure:"state"`
+	Chaos        ChaosConfig        `mapstructure:"chaos"`
+	Sharding     ShardingConfig     `mapstructure:"sharding"`
 }
 
 // BotConfig holds Telegram bot configuration.
 type BotConfig struct {
-	Token string `mapstructure:"token"`
+	Token   string        `mapstructure:"token"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+	Pacing  PacingConfig  `mapstructure:"pacing"`
+
+	WarmupSeconds int `mapstructure:"warmup_seconds"` // Longest the cache warm-up may delay taking updates (0 = no warm-up)
+}
+
+// PacingConfig holds how sends to chats restricting the bot are held back.
+type PacingConfig struct {
+	MaxWaitSeconds int `mapstructure:"max_wait_seconds"` // Longest a send waits for a chat's flood limit; longer waits fail fast
+	MutedMinutes   int `mapstructure:"muted_minutes"`    // Sends fail fast this long after the bot was muted in a chat (0 = disabled)
+}
+
+// WebhookConfig holds webhook mode configuration; long polling is used without a listen address.
+type WebhookConfig struct {
+	Listen      string `mapstructure:"listen"`       // Address receiving updates, e.g. ":8443" ("" = long polling)
+	PublicURL   string `mapstructure:"public_url"`   // URL registered with Telegram, e.g. behind a load balancer
+	SecretToken string `mapstructure:"secret_token"` // Requests without this token are dropped
+}
+
+// ShardingConfig holds the chats processed by this instance when chats are
+// sharded across instances (requires webhook mode).
+type ShardingConfig struct {
+	Count  int   `mapstructure:"count"`  // Total number of shards (0 or 1 = no sharding)
+	Shards []int `mapstructure:"shards"` // Shards owned by this instance; shard 0 also runs the global jobs
+	// Webhook URL of the instance owning each shard, by shard; updates of other
+	// shards are forwarded there instead of dropped (empty = no forwarding)
+	ForwardURLs []string `mapstructure:"forward_urls"`
 }
 
 // DatabaseConfig holds PostgreSQL connection configuration.
@@ -48,24 +100,208 @@ type WhitelistConfig struct {
 	Chats []int64 `mapstructure:"chats"`
 }
 
+// SupportConfig holds support ticket configuration.
+type SupportConfig struct {
+	ChatID int64 `mapstructure:"chat_id"` // Admin chat receiving /support tickets (0 = disabled)
+}
+
+// CompensationConfig holds automatic compensation configuration.
+type CompensationConfig struct {
+	AutoApproveLimit int64 `mapstructure:"auto_approve_limit"` // Incident totals above this need admin approval
+	MaxPerUser       int64 `mapstructure:"max_per_user"`       // Cap per user per incident
+	MaxPerIncident   int64 `mapstructure:"max_per_incident"`   // Cap on the total of one incident
+}
+
+// ChatStatsConfig holds pinned chat statistics configuration.
+type ChatStatsConfig struct {
+	RefreshSeconds     int `mapstructure:"refresh_seconds"`       // Refresh interval of pinned stats (0 = disabled)
+	MaxEditsPerRefresh int `mapstructure:"max_edits_per_refresh"` // Edit budget per refresh across all chats
+}
+
+// ShopConfig holds shop pricing configuration.
+type ShopConfig struct {
+	DemandStepUnits        int `mapstructure:"demand_step_units"`         // Units sold today (all users) per price step (0 = no demand pricing)
+	DemandStepPercent      int `mapstructure:"demand_step_percent"`       // Markup added per step
+	DemandMaxMarkupPercent int `mapstructure:"demand_max_markup_percent"` // Cap on the demand markup
+
+	CleanupMinutes        int `mapstructure:"cleanup_minutes"`         // Inventory cleanup interval (0 = disabled)
+	ExpiryWarningHours    int `mapstructure:"expiry_warning_hours"`    // Warn owners this long before an item expires (0 = no warning)
+	PurchaseRetentionDays int `mapstructure:"purchase_retention_days"` // Days of daily purchase records to keep
+}
+
+// NotifyConfig holds balance change notification configuration.
+type NotifyConfig struct {
+	BalanceThreshold int64 `mapstructure:"balance_threshold"` // Minimum (and default) alert threshold (0 = alerts disabled)
+	OfflineMinutes   int   `mapstructure:"offline_minutes"`   // Users inactive in groups this long count as offline
+}
+
+// FilterConfig holds the filter applied to user names in bot messages.
+type FilterConfig struct {
+	BlockedWords  []string `mapstructure:"blocked_words"`   // Masked case-insensitively
+	Replacement   string   `mapstructure:"replacement"`     // Replaces each blocked word
+	MaxNameLength int      `mapstructure:"max_name_length"` // Longer names are cut (0 = unlimited)
+	MaxRepeat     int      `mapstructure:"max_repeat"`      // Longest run of one character kept (0 = unlimited)
+}
+
+// CelebrationConfig holds the media sent after jackpot-class wins.
+type CelebrationConfig struct {
+	ChatCooldownSeconds int `mapstructure:"chat_cooldown_seconds"` // Minimum time between celebrations in a chat
+}
+
+// BanterConfig holds the phrases occasionally appended to game results.
+type BanterConfig struct {
+	Enabled             bool   `mapstructure:"enabled"`               // Default of the banter feature flag
+	ChancePercent       int    `mapstructure:"chance_percent"`        // Chance a result gets a phrase
+	ChatCooldownSeconds int    `mapstructure:"chat_cooldown_seconds"` // Minimum time between phrases in a chat
+	DefaultLocale       string `mapstructure:"default_locale"`        // Phrases used when the player's language has none
+}
+
+// SandboxConfig holds the play money of sandbox chats.
+type SandboxConfig struct {
+	StartBalance int64 `mapstructure:"start_balance"` // Play money each player starts with in a sandbox chat
+}
+
+// MaintenanceConfig holds scheduled maintenance configuration.
+type MaintenanceConfig struct {
+	BlockLeadMinutes int `mapstructure:"block_lead_minutes"` // New rounds are refused this long before maintenance
+}
+
+// DigestConfig holds the nightly admin digest configuration.
+type DigestConfig struct {
+	Enabled    bool  `mapstructure:"enabled"`     // DM the admins a daily digest
+	Hour       int   `mapstructure:"hour"`        // Local hour the digest is sent at
+	FlagProfit int64 `mapstructure:"flag_profit"` // Players winning this much from games in a day are flagged (0 = no flagging)
+}
+
+// PoolConfig holds parimutuel betting pool configuration.
+type PoolConfig struct {
+	RakePercent   int `mapstructure:"rake_percent"`   // Share of each resolved pool kept by the house
+	WindowMinutes int `mapstructure:"window_minutes"` // Default betting window of /pool
+}
+
+// MetricsConfig holds handler metrics configuration.
+type MetricsConfig struct {
+	ListenAddr    string `mapstructure:"listen_addr"`     // Address serving /metrics for Prometheus ("" = disabled)
+	SlowHandlerMs int    `mapstructure:"slow_handler_ms"` // Handlers slower than this are logged (0 = disabled)
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	Endpoint      string  `mapstructure:"endpoint"`       // OTLP/HTTP collector, e.g. "http://localhost:4318" ("" = disabled)
+	ServiceName   string  `mapstructure:"service_name"`   // service.name of the exported spans
+	SamplePercent float64 `mapstructure:"sample_percent"` // Share of updates and jobs traced
+}
+
+// StateConfig holds where ephemeral state (cooldowns, rate limits, handled
+// callbacks) is kept.
+type StateConfig struct {
+	Backend        string `mapstructure:"backend"`         // "memory" (per instance) or "postgres" (durable, shared)
+	CleanupMinutes int    `mapstructure:"cleanup_minutes"` // Interval of deleting expired keys
+}
+
+// State backends
+const (
+	StateBackendMemory   = "memory"
+	StateBackendPostgres = "postgres"
+)
+
+// ChaosConfig holds fault injection configuration for staging.
+type ChaosConfig struct {
+	Enabled             bool    `mapstructure:"enabled"`               // Never enable in production
+	TelegramFailPercent float64 `mapstructure:"telegram_fail_percent"` // Telegram API calls failed before being sent
+	DBFailPercent       float64 `mapstructure:"db_fail_percent"`       // Database queries failed before being run
+}
+
+// PaymentsConfig holds Telegram Stars payment configuration.
+type PaymentsConfig struct {
+	Enabled bool `mapstructure:"enabled"` // Sell cosmetics for Telegram Stars with /stars
+}
+
 // DailyConfig holds daily reward configuration.
 type DailyConfig struct {
-	Reward        int64 `mapstructure:"reward"`
-	CooldownHours int   `mapstructure:"cooldown_hours"`
+	Reward          int64   `mapstructure:"reward"`
+	CooldownHours   int     `mapstructure:"cooldown_hours"`
+	Dynamic         bool    `mapstructure:"dynamic"`          // Scale the reward inverse to recent inflation
+	MinReward       int64   `mapstructure:"min_reward"`       // Lower bound of the dynamic reward
+	MaxReward       int64   `mapstructure:"max_reward"`       // Upper bound of the dynamic reward
+	TargetInflation float64 `mapstructure:"target_inflation"` // Daily inflation in percent at which the dynamic reward equals reward
+	EMADays         int     `mapstructure:"ema_days"`         // Days averaged by the inflation EMA
+	RecalcMinutes   int     `mapstructure:"recalc_minutes"`   // Interval of the reward recalculation job
+}
+
+// BailoutConfig holds the recovery grant for players stuck below a floor.
+type BailoutConfig struct {
+	Floor        int64 `mapstructure:"floor"`         // Balances below this count as bankrupt
+	Grant        int64 `mapstructure:"grant"`         // Coins credited per bailout (0 = disabled)
+	BelowHours   int   `mapstructure:"below_hours"`   // Time below the floor before a bailout
+	IntervalDays int   `mapstructure:"interval_days"` // Minimum days between bailouts of a user
+	CheckMinutes int   `mapstructure:"check_minutes"` // Interval of the bailout job
+}
+
+// ArchiveConfig holds the archival of inactive users.
+type ArchiveConfig struct {
+	InactiveDays int `mapstructure:"inactive_days"` // Users inactive this long are archived (0 = disabled)
+	CheckMinutes int `mapstructure:"check_minutes"` // Interval of the archival job
+}
+
+// StatsConfig holds the daily aggregates behind the rolling and lifetime profit of /my.
+type StatsConfig struct {
+	AggregateCheckMinutes int `mapstructure:"aggregate_check_minutes"` // Interval of the aggregation job (0 = read transactions only)
+}
+
+// PublicStatsConfig holds the anonymized public stats page.
+type PublicStatsConfig struct {
+	ListenAddr     string `mapstructure:"listen_addr"`     // Address serving the page over HTTP ("" = not served)
+	OutputFile     string `mapstructure:"output_file"`     // File the page is written to for a static server ("" = not written)
+	RefreshMinutes int    `mapstructure:"refresh_minutes"` // Interval of refreshing the page
+}
+
+// PartitionsConfig holds the monthly partitions of the transactions table.
+type PartitionsConfig struct {
+	MonthsAhead     int `mapstructure:"months_ahead"`     // Partitions created ahead of the current month
+	RetentionMonths int `mapstructure:"retention_months"` // Older partitions are detached (0 = kept forever)
+	CheckMinutes    int `mapstructure:"check_minutes"`    // Interval of the partition job
+}
+
+// ComebackConfig holds the welcome-back bonus offered to regulars who stopped playing.
+type ComebackConfig struct {
+	Bonus        int64 `mapstructure:"bonus"`         // Coins of the bonus (0 = disabled)
+	InactiveDays int   `mapstructure:"inactive_days"` // Days without playing before an offer
+	RegularDays  int   `mapstructure:"regular_days"`  // Days played in the 30 days before, to count as a regular
+	MonthlyCap   int   `mapstructure:"monthly_cap"`   // Offers per user per calendar month
+	ClaimHours   int   `mapstructure:"claim_hours"`   // How long an offer can be claimed
+	CheckMinutes int   `mapstructure:"check_minutes"` // Interval of the offer job
+}
+
+// DegradedConfig holds the database probe deciding when the bot runs degraded.
+type DegradedConfig struct {
+	CheckSeconds int `mapstructure:"check_seconds"` // Interval of the database probe (0 = disabled)
+	Failures     int `mapstructure:"failures"`      // Failed probes in a row before degrading
 }
 
 
 // GamesConfig holds game-specific configuration.
 type GamesConfig struct {
-	Dice  DiceConfig  `mapstructure:"dice"`
-	Slot  SlotConfig  `mapstructure:"slot"`
-	SicBo SicBoConfig `mapstructure:"sicbo"`
+	Dice     DiceConfig     `mapstructure:"dice"`
+	Slot     SlotConfig     `mapstructure:"slot"`
+	SicBo    SicBoConfig    `mapstructure:"sicbo"`
+	FreeSpin FreeSpinConfig `mapstructure:"freespin"`
+	Rob      RobConfig      `mapstructure:"rob"`
+	Heist    HeistConfig    `mapstructure:"heist"`
+
+	Blackjack BlackjackConfig `mapstructure:"blackjack"`
+
+	Aggression AggressionConfig `mapstructure:"aggression"`
 }
 
 // DiceConfig holds dice game configuration.
 type DiceConfig struct {
 	MaxBet          int64 `mapstructure:"max_bet"`
 	CooldownSeconds int   `mapstructure:"cooldown_seconds"`
+
+	InsuranceEnabled        bool `mapstructure:"insurance_enabled"`         // /dice <金额> insure is accepted, default of the dice_insurance feature flag
+	InsurancePremiumPercent int  `mapstructure:"insurance_premium_percent"` // Premium in percent of the stake
+	InsuranceRefundPercent  int  `mapstructure:"insurance_refund_percent"`  // Share of the stake refunded on a loss
 }
 
 // SlotConfig holds slot game configuration.
@@ -73,10 +309,82 @@ type SlotConfig struct {
 	CooldownSeconds int `mapstructure:"cooldown_seconds"`
 }
 
+// FreeSpinConfig holds daily free slot spin configuration.
+type FreeSpinConfig struct {
+	CooldownHours int `mapstructure:"cooldown_hours"`
+}
+
 // SicBoConfig holds sic bo game configuration.
 type SicBoConfig struct {
 	BettingDurationSeconds int   `mapstructure:"betting_duration_seconds"`
 	FixedBetAmount         int64 `mapstructure:"fixed_bet_amount"`
+	MinPlayers             int   `mapstructure:"min_players"` // Distinct players a round needs, otherwise bets are refunded
+	PerTopic               bool  `mapstructure:"per_topic"`   // Forum topics play separate sessions instead of one per chat
+}
+
+// HeistConfig holds cooperative heist configuration.
+type HeistConfig struct {
+	JoinDurationSeconds int   `mapstructure:"join_duration_seconds"` // Recruiting phase before the success roll
+	MaxBuyIn            int64 `mapstructure:"max_buy_in"`            // Highest buy-in a heist can be opened with
+}
+
+// BlackjackConfig holds blackjack configuration.
+type BlackjackConfig struct {
+	MaxBet          int64 `mapstructure:"max_bet"`          // Highest bet of a hand, below the balance tier limits
+	CooldownSeconds int   `mapstructure:"cooldown_seconds"` // Between the end of a hand and the next /blackjack
+	IdleSeconds     int   `mapstructure:"idle_seconds"`     // Hands without a button press for this long stand automatically
+}
+
+// RobConfig holds rob game configuration.
+type RobConfig struct {
+	AmountMode string  `mapstructure:"amount_mode"` // "fixed" (10-1000) or "scaled" (percentage of balance)
+	MinPercent float64 `mapstructure:"min_percent"` // Scaled mode: lower percentage of the target's balance
+	MaxPercent float64 `mapstructure:"max_percent"` // Scaled mode: upper percentage of the target's balance
+	MinAmount  int64   `mapstructure:"min_amount"`  // Scaled mode: floor of a robbery
+	MaxAmount  int64   `mapstructure:"max_amount"`  // Scaled mode: ceiling of a robbery
+
+	NewUserGraceHours    int   `mapstructure:"new_user_grace_hours"`   // Accounts younger than this cannot be robbed (0 = disabled)
+	ProtectExtendCost    int64 `mapstructure:"protect_extend_cost"`    // Coins per /protect extend (0 = disabled)
+	ProtectExtendMinutes int   `mapstructure:"protect_extend_minutes"` // Protection added per extension
+	ProtectMaxMinutes    int   `mapstructure:"protect_max_minutes"`    // Cap on remaining protection after extending
+
+	VictimHourlyCap int `mapstructure:"victim_hourly_cap"` // Successful robs of one victim per hour across all robbers (0 = no cap)
+	PairDailyCap    int `mapstructure:"pair_daily_cap"`    // Successful robs of one victim by one robber per 24 hours (0 = no cap)
+
+	CriticalStealItem bool `mapstructure:"critical_steal_item"` // Great sword critical hits also steal one item use of the victim
+
+	InsuranceCutPercent   int   `mapstructure:"insurance_cut_percent"`   // Share of every successful rob paid by the robber into the insurance pool (0 = disabled)
+	InsuranceFloor        int64 `mapstructure:"insurance_floor"`         // Victims robbed below this balance are compensated from the pool
+	InsuranceCoverPercent int   `mapstructure:"insurance_cover_percent"` // Share of the stolen amount compensated
+	InsuranceMaxPayout    int64 `mapstructure:"insurance_max_payout"`    // Cap on one compensation
+
+	MessageWeights RobMessageWeights  `mapstructure:"message_weights"` // Relative chance of each rarity tier of flavor texts
+	Messages       []RobMessageConfig `mapstructure:"messages"`        // Flavor texts besides those added with /robmsg
+}
+
+// RobMessageWeights holds the relative chances of the rarity tiers of rob
+// flavor texts; tiers without texts for an outcome are skipped.
+type RobMessageWeights struct {
+	Common int `mapstructure:"common"`
+	Rare   int `mapstructure:"rare"`
+	Epic   int `mapstructure:"epic"`
+}
+
+// RobMessageConfig is a rob flavor text defined in the config file.
+type RobMessageConfig struct {
+	Outcome string `mapstructure:"outcome"` // fail, counter, success or critical
+	Tier    string `mapstructure:"tier"`    // common, rare or epic
+	Text    string `mapstructure:"text"`    // Template with {robber} {victim} {amount} {item}
+}
+
+// AggressionConfig holds the cooldown shared by all attack actions.
+// Each attack adds its seconds to the attacker's level, which drains in real
+// time; an attack is allowed while its seconds still fit under BucketSeconds.
+type AggressionConfig struct {
+	BucketSeconds   int `mapstructure:"bucket_seconds"`    // Level an attacker can build up (0 = disabled)
+	RobSeconds      int `mapstructure:"rob_seconds"`       // Added per /dj
+	AllInRobSeconds int `mapstructure:"allin_rob_seconds"` // Added per /shdj
+	DuelSeconds     int `mapstructure:"duel_seconds"`      // Added per /duijue challenge
 }
 
 // DSN returns the PostgreSQL connection string.
@@ -139,13 +447,162 @@ func setDefaults(v *viper.Viper) {
 	// Daily reward defaults
 	v.SetDefault("daily.reward", 500)
 	v.SetDefault("daily.cooldown_hours", 24)
+	v.SetDefault("daily.dynamic", false)
+	v.SetDefault("daily.min_reward", 200)
+	v.SetDefault("daily.max_reward", 1000)
+	v.SetDefault("daily.target_inflation", 1.0)
+	v.SetDefault("daily.ema_days", 7)
+	v.SetDefault("daily.recalc_minutes", 60)
+
+	v.SetDefault("bailout.floor", 100)
+	v.SetDefault("bailout.grant", 300)
+	v.SetDefault("bailout.below_hours", 24)
+	v.SetDefault("bailout.interval_days", 7)
+	v.SetDefault("bailout.check_minutes", 30)
+
+	v.SetDefault("archive.inactive_days", 180)
+	v.SetDefault("archive.check_minutes", 360)
+
+	// Stats defaults
+	v.SetDefault("stats.aggregate_check_minutes", 60)
+
+	// Transaction partition defaults
+	v.SetDefault("partitions.months_ahead", 2)
+	v.SetDefault("partitions.retention_months", 0)
+	v.SetDefault("partitions.check_minutes", 360)
+
+	// Public stats page defaults
+	v.SetDefault("public_stats.listen_addr", "")
+	v.SetDefault("public_stats.output_file", "")
+	v.SetDefault("public_stats.refresh_minutes", 10)
+	v.SetDefault("comeback.bonus", 200)
+	v.SetDefault("comeback.inactive_days", 7)
+	v.SetDefault("comeback.regular_days", 5)
+	v.SetDefault("comeback.monthly_cap", 1)
+	v.SetDefault("comeback.claim_hours", 72)
+	v.SetDefault("comeback.check_minutes", 60)
+	v.SetDefault("degraded.check_seconds", 10)
+	v.SetDefault("degraded.failures", 3)
 
 	// Game defaults
 	v.SetDefault("games.dice.max_bet", 1000)
 	v.SetDefault("games.dice.cooldown_seconds", 3)
+	v.SetDefault("games.dice.insurance_enabled", true)
+	v.SetDefault("games.dice.insurance_premium_percent", 10)
+	v.SetDefault("games.dice.insurance_refund_percent", 50)
 	v.SetDefault("games.slot.cooldown_seconds", 5)
 	v.SetDefault("games.sicbo.betting_duration_seconds", 60)
 	v.SetDefault("games.sicbo.fixed_bet_amount", 100)
+	v.SetDefault("games.sicbo.min_players", 2)
+	v.SetDefault("games.sicbo.per_topic", false)
+	v.SetDefault("games.freespin.cooldown_hours", 24)
+	v.SetDefault("games.heist.join_duration_seconds", 120)
+	v.SetDefault("games.heist.max_buy_in", 5000)
+	v.SetDefault("games.blackjack.max_bet", 10000)
+	v.SetDefault("games.blackjack.cooldown_seconds", 3)
+	v.SetDefault("games.blackjack.idle_seconds", 120)
+	v.SetDefault("games.rob.amount_mode", "fixed")
+	v.SetDefault("games.rob.min_percent", 0.5)
+	v.SetDefault("games.rob.max_percent", 3)
+	v.SetDefault("games.rob.min_amount", 10)
+	v.SetDefault("games.rob.max_amount", 5000)
+	v.SetDefault("games.rob.new_user_grace_hours", 24)
+	v.SetDefault("games.rob.protect_extend_cost", 500)
+	v.SetDefault("games.rob.protect_extend_minutes", 30)
+	v.SetDefault("games.rob.protect_max_minutes", 120)
+	v.SetDefault("games.rob.victim_hourly_cap", 5)
+	v.SetDefault("games.rob.pair_daily_cap", 2)
+	v.SetDefault("games.rob.critical_steal_item", false)
+	v.SetDefault("games.rob.insurance_cut_percent", 5)
+	v.SetDefault("games.rob.insurance_floor", 1000)
+	v.SetDefault("games.rob.insurance_cover_percent", 50)
+	v.SetDefault("games.rob.insurance_max_payout", 500)
+	v.SetDefault("games.rob.message_weights.common", 80)
+	v.SetDefault("games.rob.message_weights.rare", 17)
+	v.SetDefault("games.rob.message_weights.epic", 3)
+	v.SetDefault("games.aggression.bucket_seconds", 600)
+	v.SetDefault("games.aggression.rob_seconds", 120)
+	v.SetDefault("games.aggression.allin_rob_seconds", 300)
+	v.SetDefault("games.aggression.duel_seconds", 180)
+
+	// Compensation defaults
+	v.SetDefault("compensation.auto_approve_limit", 5000)
+	v.SetDefault("compensation.max_per_user", 10000)
+	v.SetDefault("compensation.max_per_incident", 100000)
+
+	// Pinned chat stats defaults
+	v.SetDefault("chat_stats.refresh_seconds", 30)
+	v.SetDefault("chat_stats.max_edits_per_refresh", 20)
+
+	// Shop pricing defaults
+	v.SetDefault("shop.demand_step_units", 20)
+	v.SetDefault("shop.demand_step_percent", 5)
+	v.SetDefault("shop.demand_max_markup_percent", 50)
+	v.SetDefault("shop.cleanup_minutes", 60)
+	v.SetDefault("shop.expiry_warning_hours", 24)
+	v.SetDefault("shop.purchase_retention_days", 7)
+
+	// Balance notification defaults
+	v.SetDefault("notify.balance_threshold", 5000)
+	v.SetDefault("notify.offline_minutes", 10)
+
+	// Payments defaults
+	v.SetDefault("payments.enabled", false)
+
+	// Name filter defaults
+	v.SetDefault("filter.replacement", "***")
+	v.SetDefault("filter.max_name_length", 32)
+	v.SetDefault("filter.max_repeat", 4)
+
+	// Celebration defaults
+	v.SetDefault("celebration.chat_cooldown_seconds", 300)
+
+	// Banter defaults
+	v.SetDefault("banter.enabled", false)
+	v.SetDefault("banter.chance_percent", 10)
+	v.SetDefault("banter.chat_cooldown_seconds", 120)
+	v.SetDefault("banter.default_locale", "zh")
+
+	// Sandbox defaults
+	v.SetDefault("sandbox.start_balance", 10000)
+
+	// Pool defaults
+	v.SetDefault("pool.rake_percent", 5)
+	v.SetDefault("pool.window_minutes", 60)
+
+	// Maintenance defaults
+	v.SetDefault("maintenance.block_lead_minutes", 5)
+
+	// Admin digest defaults
+	v.SetDefault("digest.enabled", true)
+	v.SetDefault("digest.hour", 23)
+	v.SetDefault("digest.flag_profit", 50000)
+
+	// Metrics defaults
+	v.SetDefault("metrics.listen_addr", "")
+	v.SetDefault("metrics.slow_handler_ms", 2000)
+
+	// Tracing defaults
+	v.SetDefault("tracing.endpoint", "")
+	v.SetDefault("tracing.service_name", "telegram-game-bot")
+	v.SetDefault("tracing.sample_percent", 100)
+
+	// State defaults
+	v.SetDefault("state.backend", StateBackendMemory)
+	v.SetDefault("state.cleanup_minutes", 5)
+
+	// Pacing defaults
+	v.SetDefault("bot.pacing.max_wait_seconds", 5)
+	v.SetDefault("bot.pacing.muted_minutes", 10)
+	v.SetDefault("bot.warmup_seconds", 20)
+
+	// Chaos defaults
+	v.SetDefault("chaos.enabled", false)
+	v.SetDefault("chaos.telegram_fail_percent", 0)
+	v.SetDefault("chaos.db_fail_percent", 0)
+
+	// Sharding defaults
+	v.SetDefault("sharding.count", 0)
 }
 
 // IsAdmin checks if a user ID is in the admin list.
@@ -159,7 +616,11 @@ func (c *Config) IsAdmin(userID int64) bool {
 }
 
 // IsChatAllowed checks if a chat ID is in the whitelist.
+// The support chat is always allowed so admins can resolve tickets there.
 func (c *Config) IsChatAllowed(chatID int64) bool {
+	if c.Support.ChatID != 0 && chatID == c.Support.ChatID {
+		return true
+	}
 	// Empty whitelist means all chats are allowed
 	if len(c.Whitelist.Chats) == 0 {
 		return true
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/msgtmpl"
+)
+
+// minSicBoBettingSeconds is the shortest sicbo betting phase accepted
+const minSicBoBettingSeconds = 10
+
+// ValidationError lists every problem found in a configuration, so all of
+// them can be fixed at once instead of one per restart.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// validator collects configuration problems
+type validator struct {
+	problems []string
+}
+
+// check records a problem unless ok
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// nonNegative records a problem if value is below zero
+func (v *validator) nonNegative(key string, value int64) {
+	v.check(value >= 0, "%s must not be negative (got %d)", key, value)
+}
+
+// positive records a problem if value is not above zero
+func (v *validator) positive(key string, value int64) {
+	v.check(value > 0, "%s must be positive (got %d)", key, value)
+}
+
+// percent records a problem if value is not a percentage
+func (v *validator) percent(key string, value float64) {
+	v.check(value >= 0 && value <= 100, "%s must be between 0 and 100 (got %v)", key, value)
+}
+
+// Validate checks required fields and value ranges of the whole configuration.
+// It returns a *ValidationError listing every problem, or nil.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(c.Bot.Token != "", "bot.token is required")
+	v.check(c.Bot.Webhook.Listen != "" || c.Bot.Webhook.PublicURL == "", "bot.webhook.public_url is set but bot.webhook.listen is empty")
+	v.nonNegative("bot.pacing.max_wait_seconds", int64(c.Bot.Pacing.MaxWaitSeconds))
+	v.nonNegative("bot.pacing.muted_minutes", int64(c.Bot.Pacing.MutedMinutes))
+	v.nonNegative("bot.warmup_seconds", int64(c.Bot.WarmupSeconds))
+
+	v.check(c.Database.Host != "", "database.host is required")
+	v.check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535 (got %d)", c.Database.Port)
+	v.check(c.Database.Name != "", "database.name is required")
+	v.positive("database.pool_size", int64(c.Database.PoolSize))
+	v.check(c.Database.ConnectTimeout > 0, "database.connect_timeout must be positive (got %v)", c.Database.ConnectTimeout)
+
+	v.check(len(c.Admin.IDs) > 0, "admin.ids must list at least one admin")
+	for _, id := range c.Admin.IDs {
+		v.check(id > 0, "admin.ids: %d is not a user ID", id)
+	}
+	seen := make(map[int64]bool, len(c.Whitelist.Chats))
+	for _, id := range c.Whitelist.Chats {
+		v.check(id < 0, "whitelist.chats: %d is not a group chat ID (group IDs are negative)", id)
+		v.check(!seen[id], "whitelist.chats: %d is listed twice", id)
+		seen[id] = true
+	}
+	v.check(c.Support.ChatID <= 0, "support.chat_id: %d is not a group chat ID (group IDs are negative)", c.Support.ChatID)
+
+	v.positive("daily.reward", c.Daily.Reward)
+	v.positive("daily.cooldown_hours", int64(c.Daily.CooldownHours))
+	if c.Daily.Dynamic {
+		v.positive("daily.min_reward", c.Daily.MinReward)
+		v.check(c.Daily.MinReward <= c.Daily.Reward && c.Daily.Reward <= c.Daily.MaxReward,
+			"daily.reward (%d) must be between daily.min_reward (%d) and daily.max_reward (%d)", c.Daily.Reward, c.Daily.MinReward, c.Daily.MaxReward)
+		v.check(c.Daily.TargetInflation > 0, "daily.target_inflation must be positive (got %v)", c.Daily.TargetInflation)
+		v.positive("daily.ema_days", int64(c.Daily.EMADays))
+		v.positive("daily.recalc_minutes", int64(c.Daily.RecalcMinutes))
+	}
+	v.nonNegative("bailout.grant", c.Bailout.Grant)
+	if c.Bailout.Grant > 0 {
+		v.positive("bailout.below_hours", int64(c.Bailout.BelowHours))
+		v.positive("bailout.interval_days", int64(c.Bailout.IntervalDays))
+		v.positive("bailout.check_minutes", int64(c.Bailout.CheckMinutes))
+	}
+
+	c.Games.validate(v)
+
+	v.nonNegative("compensation.auto_approve_limit", c.Compensation.AutoApproveLimit)
+	v.positive("compensation.max_per_user", c.Compensation.MaxPerUser)
+	v.check(c.Compensation.MaxPerUser <= c.Compensation.MaxPerIncident,
+		"compensation.max_per_user (%d) must not exceed compensation.max_per_incident (%d)", c.Compensation.MaxPerUser, c.Compensation.MaxPerIncident)
+
+	v.nonNegative("chat_stats.refresh_seconds", int64(c.ChatStats.RefreshSeconds))
+	if c.ChatStats.RefreshSeconds > 0 {
+		v.positive("chat_stats.max_edits_per_refresh", int64(c.ChatStats.MaxEditsPerRefresh))
+	}
+
+	v.nonNegative("shop.demand_step_units", int64(c.Shop.DemandStepUnits))
+	v.nonNegative("shop.demand_step_percent", int64(c.Shop.DemandStepPercent))
+	v.nonNegative("shop.demand_max_markup_percent", int64(c.Shop.DemandMaxMarkupPercent))
+	v.nonNegative("shop.cleanup_minutes", int64(c.Shop.CleanupMinutes))
+	v.nonNegative("shop.expiry_warning_hours", int64(c.Shop.ExpiryWarningHours))
+	v.positive("shop.purchase_retention_days", int64(c.Shop.PurchaseRetentionDays))
+
+	v.nonNegative("notify.balance_threshold", c.Notify.BalanceThreshold)
+	v.nonNegative("notify.offline_minutes", int64(c.Notify.OfflineMinutes))
+	v.nonNegative("filter.max_name_length", int64(c.Filter.MaxNameLength))
+	v.nonNegative("filter.max_repeat", int64(c.Filter.MaxRepeat))
+	v.nonNegative("stats.aggregate_check_minutes", int64(c.Stats.AggregateCheckMinutes))
+	v.positive("partitions.months_ahead", int64(c.Partitions.MonthsAhead))
+	v.nonNegative("partitions.retention_months", int64(c.Partitions.RetentionMonths))
+	v.positive("partitions.check_minutes", int64(c.Partitions.CheckMinutes))
+	v.positive("public_stats.refresh_minutes", int64(c.PublicStats.RefreshMinutes))
+	v.nonNegative("celebration.chat_cooldown_seconds", int64(c.Celebration.ChatCooldownSeconds))
+	v.percent("banter.chance_percent", float64(c.Banter.ChancePercent))
+	v.nonNegative("banter.chat_cooldown_seconds", int64(c.Banter.ChatCooldownSeconds))
+	v.positive("sandbox.start_balance", c.Sandbox.StartBalance)
+	v.percent("pool.rake_percent", float64(c.Pool.RakePercent))
+	v.positive("pool.window_minutes", int64(c.Pool.WindowMinutes))
+	v.nonNegative("maintenance.block_lead_minutes", int64(c.Maintenance.BlockLeadMinutes))
+	v.check(c.Digest.Hour >= 0 && c.Digest.Hour <= 23, "digest.hour must be between 0 and 23 (got %d)", c.Digest.Hour)
+	v.nonNegative("digest.flag_profit", c.Digest.FlagProfit)
+	v.nonNegative("metrics.slow_handler_ms", int64(c.Metrics.SlowHandlerMs))
+	v.percent("tracing.sample_percent", c.Tracing.SamplePercent)
+	v.check(c.State.Backend == StateBackendMemory || c.State.Backend == StateBackendPostgres,
+		"state.backend must be %q or %q (got %q)", StateBackendMemory, StateBackendPostgres, c.State.Backend)
+	v.positive("state.cleanup_minutes", int64(c.State.CleanupMinutes))
+	v.percent("chaos.telegram_fail_percent", c.Chaos.TelegramFailPercent)
+	v.percent("chaos.db_fail_percent", c.Chaos.DBFailPercent)
+
+	v.nonNegative("sharding.count", int64(c.Sharding.Count))
+	if c.Sharding.Count > 1 {
+		v.check(c.Bot.Webhook.Listen != "", "sharding requires webhook mode (bot.webhook.listen)")
+		v.check(len(c.Sharding.Shards) > 0, "sharding.shards must list the shards of this instance")
+		for _, id := range c.Sharding.Shards {
+			v.check(id >= 0 && id < c.Sharding.Count, "sharding.shards: %d out of range 0-%d", id, c.Sharding.Count-1)
+		}
+		v.check(len(c.Sharding.ForwardURLs) <= c.Sharding.Count, "sharding.forward_urls has %d URLs for %d shards", len(c.Sharding.ForwardURLs), c.Sharding.Count)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validate checks the game sections
+func (g *GamesConfig) validate(v *validator) {
+	v.positive("games.dice.max_bet", g.Dice.MaxBet)
+	v.nonNegative("games.dice.cooldown_seconds", int64(g.Dice.CooldownSeconds))
+	if g.Dice.InsuranceEnabled {
+		v.check(g.Dice.InsurancePremiumPercent > 0 && g.Dice.InsurancePremiumPercent <= 100,
+			"games.dice.insurance_premium_percent must be between 1 and 100 (got %d)", g.Dice.InsurancePremiumPercent)
+		v.percent("games.dice.insurance_refund_percent", float64(g.Dice.InsuranceRefundPercent))
+	}
+	v.nonNegative("games.slot.cooldown_seconds", int64(g.Slot.CooldownSeconds))
+	v.positive("games.freespin.cooldown_hours", int64(g.FreeSpin.CooldownHours))
+
+	v.check(g.SicBo.BettingDurationSeconds >= minSicBoBettingSeconds,
+		"games.sicbo.betting_duration_seconds must be at least %d (got %d)", minSicBoBettingSeconds, g.SicBo.BettingDurationSeconds)
+	v.positive("games.sicbo.fixed_bet_amount", g.SicBo.FixedBetAmount)
+	v.positive("games.sicbo.min_players", int64(g.SicBo.MinPlayers))
+
+	v.positive("games.heist.join_duration_seconds", int64(g.Heist.JoinDurationSeconds))
+	v.positive("games.heist.max_buy_in", g.Heist.MaxBuyIn)
+
+	v.positive("games.blackjack.max_bet", g.Blackjack.MaxBet)
+	v.nonNegative("games.blackjack.cooldown_seconds", int64(g.Blackjack.CooldownSeconds))
+	v.positive("games.blackjack.idle_seconds", int64(g.Blackjack.IdleSeconds))
+
+	rob := g.Rob
+	v.check(rob.AmountMode == "fixed" || rob.AmountMode == "scaled", "games.rob.amount_mode must be \"fixed\" or \"scaled\" (got %q)", rob.AmountMode)
+	if rob.AmountMode == "scaled" {
+		v.percent("games.rob.min_percent", rob.MinPercent)
+		v.percent("games.rob.max_percent", rob.MaxPercent)
+		v.check(rob.MinPercent <= rob.MaxPercent, "games.rob.min_percent (%v) must not exceed games.rob.max_percent (%v)", rob.MinPercent, rob.MaxPercent)
+		v.nonNegative("games.rob.min_amount", rob.MinAmount)
+		v.check(rob.MinAmount <= rob.MaxAmount, "games.rob.min_amount (%d) must not exceed games.rob.max_amount (%d)", rob.MinAmount, rob.MaxAmount)
+	}
+	v.nonNegative("games.rob.new_user_grace_hours", int64(rob.NewUserGraceHours))
+	v.nonNegative("games.rob.protect_extend_cost", rob.ProtectExtendCost)
+	if rob.ProtectExtendCost > 0 {
+		v.positive("games.rob.protect_extend_minutes", int64(rob.ProtectExtendMinutes))
+		v.check(rob.ProtectMaxMinutes >= rob.ProtectExtendMinutes,
+			"games.rob.protect_max_minutes (%d) must be at least games.rob.protect_extend_minutes (%d)", rob.ProtectMaxMinutes, rob.ProtectExtendMinutes)
+	}
+	v.nonNegative("games.rob.victim_hourly_cap", int64(rob.VictimHourlyCap))
+	v.nonNegative("games.rob.pair_daily_cap", int64(rob.PairDailyCap))
+	v.percent("games.rob.insurance_cut_percent", float64(rob.InsuranceCutPercent))
+	if rob.InsuranceCutPercent > 0 {
+		v.positive("games.rob.insurance_floor", rob.InsuranceFloor)
+		v.percent("games.rob.insurance_cover_percent", float64(rob.InsuranceCoverPercent))
+		v.positive("games.rob.insurance_max_payout", rob.InsuranceMaxPayout)
+	}
+	v.nonNegative("games.rob.message_weights.common", int64(rob.MessageWeights.Common))
+	v.nonNegative("games.rob.message_weights.rare", int64(rob.MessageWeights.Rare))
+	v.nonNegative("games.rob.message_weights.epic", int64(rob.MessageWeights.Epic))
+	for i, m := range rob.Messages {
+		v.check(slices.Contains(model.RobMessageOutcomes, m.Outcome), "games.rob.messages[%d].outcome must be one of %v (got %q)", i, model.RobMessageOutcomes, m.Outcome)
+		v.check(slices.Contains(model.RobMessageTiers, m.Tier), "games.rob.messages[%d].tier must be one of %v (got %q)", i, model.RobMessageTiers, m.Tier)
+		v.check(strings.TrimSpace(m.Text) != "", "games.rob.messages[%d].text is required", i)
+		if err := msgtmpl.Validate(m.Text, model.RobMessageVars); err != nil {
+			v.check(false, "games.rob.messages[%d].text: %v", i, err)
+		}
+	}
+
+	v.nonNegative("games.aggression.bucket_seconds", int64(g.Aggression.BucketSeconds))
+	v.nonNegative("games.aggression.rob_seconds", int64(g.Aggression.RobSeconds))
+	v.nonNegative("games.aggression.allin_rob_seconds", int64(g.Aggression.AllInRobSeconds))
+	v.nonNegative("games.aggression.duel_seconds", int64(g.Aggression.DuelSeconds))
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// loadDefaults loads a configuration made of the defaults plus the required fields
+func loadDefaults(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	cfg.Bot.Token = "123:token"
+	cfg.Admin.IDs = []int64{327294302}
+	return cfg
+}
+
+// TestValidateDefaults tests that the defaults are a valid configuration.
+func TestValidateDefaults(t *testing.T) {
+	if err := loadDefaults(t).Validate(); err != nil {
+		t.Fatalf("Validate defaults: %v", err)
+	}
+}
+
+// TestValidateReportsEveryProblem tests that all problems are reported together.
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := loadDefaults(t)
+	cfg.Bot.Token = ""
+	cfg.Admin.IDs = nil
+	cfg.Whitelist.Chats = []int64{-100123, 42, -100123}
+	cfg.Games.SicBo.BettingDurationSeconds = 0
+	cfg.Games.Rob.AmountMode = "scaled"
+	cfg.Games.Rob.MinAmount = 100
+	cfg.Games.Rob.MaxAmount = 10
+	cfg.Sharding.Count = 2
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate error = %v, want *ValidationError", err)
+	}
+	for _, want := range []string{
+		"bot.token",
+		"admin.ids",
+		"42 is not a group chat ID",
+		"-100123 is listed twice",
+		"games.sicbo.betting_duration_seconds",
+		"games.rob.min_amount",
+		"sharding requires webhook mode",
+		"sharding.shards",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Report does not mention %q:\n%v", want, err)
+		}
+	}
+	if len(verr.Problems) != 8 {
+		t.Errorf("Got %d problems, want 8:\n%v", len(verr.Problems), err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package cosmetic provides cosmetic items bought with Telegram Stars.
+// Cosmetics only change how things look (titles, shop skins, pet accessories)
+// and are kept strictly separate from the coin economy: they are never bought
+// with coins, never give coins and never affect games.
+package cosmetic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CurrencyStars is the currency code of Telegram Stars.
+const CurrencyStars = "XTR"
+
+// Kind represents the slot a cosmetic is equipped in
+type Kind string
+
+const (
+	KindTitle        Kind = "title"         // 称号 - 显示在 /my
+	KindShopSkin     Kind = "shop_skin"     // 商店皮肤 - 替换私聊商店标题
+	KindPetAccessory Kind = "pet_accessory" // 宠物饰品 - 显示在 /my
+)
+
+// KindNames holds the display names of the cosmetic kinds
+var KindNames = map[Kind]string{
+	KindTitle:        "称号",
+	KindShopSkin:     "商店皮肤",
+	KindPetAccessory: "宠物饰品",
+}
+
+// Item holds the configuration of a cosmetic item
+type Item struct {
+	ID          string // 唯一标识（用于支付载荷，只含小写字母、数字和下划线）
+	Kind        Kind   // 装备位置
+	Name        string // 显示名称
+	Emoji       string // 图标
+	Stars       int    // 价格（Telegram Stars）
+	Display     string // 装备后显示的内容
+	Description string // 描述
+}
+
+// Label returns the emoji and name of the item
+func (i Item) Label() string {
+	return i.Emoji + " " + i.Name
+}
+
+// Items contains all cosmetic items.
+// Item IDs end up in payment payloads and the purchase ledger, never rename them.
+var Items = map[string]Item{
+	"title_high_roller": {
+		ID: "title_high_roller", Kind: KindTitle, Name: "豪赌客", Emoji: "🎩", Stars: 50,
+		Display: "🎩 豪赌客", Description: "在 /my 中显示称号「豪赌客」",
+	},
+	"title_lucky_star": {
+		ID: "title_lucky_star", Kind: KindTitle, Name: "幸运星", Emoji: "🌟", Stars: 50,
+		Display: "🌟 幸运星", Description: "在 /my 中显示称号「幸运星」",
+	},
+	"title_night_owl": {
+		ID: "title_night_owl", Kind: KindTitle, Name: "夜猫子", Emoji: "🦉", Stars: 30,
+		Display: "🦉 夜猫子", Description: "在 /my 中显示称号「夜猫子」",
+	},
+	"skin_neon": {
+		ID: "skin_neon", Kind: KindShopSkin, Name: "霓虹商店", Emoji: "🌃", Stars: 80,
+		Display: "🌃 霓虹商店", Description: "私聊商店标题换成霓虹风格",
+	},
+	"skin_sakura": {
+		ID: "skin_sakura", Kind: KindShopSkin, Name: "樱花商店", Emoji: "🌸", Stars: 80,
+		Display: "🌸 樱花小铺", Description: "私聊商店标题换成樱花风格",
+	},
+	"pet_bow": {
+		ID: "pet_bow", Kind: KindPetAccessory, Name: "蝴蝶结", Emoji: "🎀", Stars: 20,
+		Display: "🎀 蝴蝶结", Description: "给宠物戴上蝴蝶结，显示在 /my",
+	},
+	"pet_crown": {
+		ID: "pet_crown", Kind: KindPetAccessory, Name: "小王冠", Emoji: "👑", Stars: 60,
+		Display: "👑 小王冠", Description: "给宠物戴上小王冠，显示在 /my",
+	},
+}
+
+// Get returns a cosmetic item by ID
+func Get(id string) (Item, bool) {
+	item, ok := Items[id]
+	return item, ok
+}
+
+// All returns all cosmetic items ordered by kind, price and ID
+func All() []Item {
+	kindOrder := map[Kind]int{KindTitle: 0, KindShopSkin: 1, KindPetAccessory: 2}
+
+	items := make([]Item, 0, len(Items))
+	for _, item := range Items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return kindOrder[items[i].Kind] < kindOrder[items[j].Kind]
+		}
+		if items[i].Stars != items[j].Stars {
+			return items[i].Stars < items[j].Stars
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// payloadPrefix marks invoice payloads of cosmetic purchases
+const payloadPrefix = "cosmetic"
+
+// ErrInvalidPayload is returned for payloads not created by FormatPayload.
+var ErrInvalidPayload = errors.New("invalid cosmetic payload")
+
+// FormatPayload creates the invoice payload of a cosmetic bought by a user.
+// Format: cosmetic:<item id>:<user id>
+func FormatPayload(itemID string, userID int64) string {
+	return fmt.Sprintf("%s:%s:%d", payloadPrefix, itemID, userID)
+}
+
+// ParsePayload parses an invoice payload created by FormatPayload.
+func ParsePayload(payload string) (itemID string, userID int64, err error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != payloadPrefix || parts[1] == "" {
+		return "", 0, ErrInvalidPayload
+	}
+	userID, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || userID <= 0 {
+		return "", 0, ErrInvalidPayload
+	}
+	return parts[1], userID, nil
+}
+
+// ShopHeader is the title line of the private chat shop replaced by shop skins
+const ShopHeader = "🏪 游戏商店"
+
+// ApplyShopSkin replaces the shop title of a shop message with the skin's
+func ApplyShopSkin(msg string, skin Item) string {
+	if skin.Kind != KindShopSkin || skin.Display == "" {
+		return msg
+	}
+	return strings.Replace(msg, ShopHeader, skin.Display, 1)
+}
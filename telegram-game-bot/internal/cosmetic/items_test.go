@@ -0,0 +1,85 @@
+package cosmetic
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestItemsConfig tests that every cosmetic has a usable configuration
+func TestItemsConfig(t *testing.T) {
+	idPattern := regexp.MustCompile(`^[a-z0-9_]+$`)
+	for id, item := range Items {
+		if item.ID != id {
+			t.Errorf("Item %s has mismatched ID %q", id, item.ID)
+		}
+		if !idPattern.MatchString(id) {
+			t.Errorf("Item ID %q must only contain lowercase letters, digits and underscores", id)
+		}
+		if _, ok := KindNames[item.Kind]; !ok {
+			t.Errorf("Item %s has unknown kind %q", id, item.Kind)
+		}
+		if item.Stars <= 0 {
+			t.Errorf("Item %s has non-positive price %d", id, item.Stars)
+		}
+		if item.Name == "" || item.Display == "" {
+			t.Errorf("Item %s needs a name and display text", id)
+		}
+		// Telegram limits invoice payloads to 128 bytes
+		if len(FormatPayload(id, 1<<62)) > 128 {
+			t.Errorf("Payload of item %s is too long", id)
+		}
+	}
+	if len(All()) != len(Items) {
+		t.Fatalf("All returned %d items, want %d", len(All()), len(Items))
+	}
+}
+
+// TestPayloadRoundTrip tests that payloads parse back to the item and buyer
+func TestPayloadRoundTrip(t *testing.T) {
+	for id := range Items {
+		itemID, userID, err := ParsePayload(FormatPayload(id, 123456789))
+		if err != nil {
+			t.Fatalf("ParsePayload failed for %s: %v", id, err)
+		}
+		if itemID != id || userID != 123456789 {
+			t.Fatalf("ParsePayload returned (%s, %d), want (%s, 123456789)", itemID, userID, id)
+		}
+	}
+}
+
+// TestParsePayloadInvalid tests that foreign and malformed payloads are rejected
+func TestParsePayloadInvalid(t *testing.T) {
+	payloads := []string{
+		"",
+		"cosmetic",
+		"cosmetic:title_night_owl",
+		"cosmetic::1",
+		"cosmetic:title_night_owl:abc",
+		"cosmetic:title_night_owl:0",
+		"cosmetic:title_night_owl:-5",
+		"cosmetic:title:night_owl:1",
+		"coins:title_night_owl:1",
+	}
+	for _, payload := range payloads {
+		if _, _, err := ParsePayload(payload); err != ErrInvalidPayload {
+			t.Errorf("ParsePayload(%q) error = %v, want ErrInvalidPayload", payload, err)
+		}
+	}
+}
+
+// TestApplyShopSkin tests that only shop skins replace the shop title
+func TestApplyShopSkin(t *testing.T) {
+	msg := ShopHeader + "\n余额: 100 金币"
+
+	skin := Items["skin_neon"]
+	if got := ApplyShopSkin(msg, skin); !strings.HasPrefix(got, skin.Display+"\n") {
+		t.Fatalf("Shop skin not applied: %q", got)
+	}
+	if got := ApplyShopSkin(msg, Items["title_night_owl"]); got != msg {
+		t.Fatalf("Title changed the shop message: %q", got)
+	}
+	if got := ApplyShopSkin(msg, Item{}); got != msg {
+		t.Fatalf("Empty item changed the shop message: %q", got)
+	}
+}
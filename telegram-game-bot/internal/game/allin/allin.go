@@ -9,8 +9,11 @@ import (
 	"sync"
 	"time"
 
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
 	"telegram-game-bot/internal/pkg/lock"
 	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
 )
 
 // Constants for all-in game configuration
@@ -25,12 +28,13 @@ const (
 
 // Transaction types
 const (
-	TxTypeAllInRobWin  = "allin_rob_win"
-	TxTypeAllInRobLose = "allin_rob_lose"
-	TxTypeDuelWin      = "duel_win"
-	TxTypeDuelLose     = "duel_lose"
-	TxTypeDiceWin      = "dice_win"
-	TxTypeDiceLose     = "dice_lose"
+	TxTypeAllInRobWin  = model.TxTypeAllInRobWin
+	TxTypeAllInRobLose = model.TxTypeAllInRobLose
+	TxTypeDuelWin      = model.TxTypeDuelWin
+	TxTypeDuelLose     = model.TxTypeDuelLose
+	TxTypeDuelEscrow   = model.TxTypeDuelEscrow
+	TxTypeDiceWin      = model.TxTypeDiceWin
+	TxTypeDiceLose     = model.TxTypeDiceLose
 )
 
 // Errors
@@ -44,12 +48,14 @@ var (
 	ErrNoPendingDuel       = errors.New("没有待处理的对决")
 	ErrDuelTimeout         = errors.New("对决已超时")
 	ErrNotDuelTarget       = errors.New("这不是你的对决")
+	ErrPvPOptedOut         = errors.New("你已退出 PvP，无法发起梭哈打劫或对决（发送 /pvp on 重新加入）")
+	ErrTargetPvPOptedOut   = errors.New("目标已退出 PvP，无法被梭哈打劫或挑战")
 )
 
 // ItemEffectChecker interface for checking shop item effects
 type ItemEffectChecker interface {
 	HasEmperorClothes(ctx context.Context, userID int64) bool
-	DecrementUseCountByString(ctx context.Context, userID int64, effectType string) error
+	UseItem(ctx context.Context, userID int64, effectType string) (remaining int, consumed bool)
 }
 
 // DuelRequest represents a pending duel challenge
@@ -62,6 +68,7 @@ type DuelRequest struct {
 	CreatedAt      time.Time
 	MessageID      int
 	ChatID         int64
+	BestOf         int // 1 for a single all-in roll, DuelMatchRounds for a best-of-three match
 }
 
 // AllInResult represents the result of an all-in rob
@@ -72,6 +79,13 @@ type AllInResult struct {
 	VictimName   string
 	NewBalance   int64
 	Message      string
+	ItemsUsed    []shop.ItemUse // Items consumed, with the uses left
+}
+
+// Attempted reports whether the all-in robbery was carried out, as opposed to
+// being rejected before any coins moved.
+func (r *AllInResult) Attempted() bool {
+	return r.Success || r.Amount > 0
 }
 
 // DuelResult represents the result of a duel
@@ -101,10 +115,13 @@ type AllInGame struct {
 	txRepo      *repository.TransactionRepository
 	userLock    *lock.UserLock
 	itemChecker ItemEffectChecker
+	pvpRepo     *repository.PvPRepository // Optional: users who opted out of PvP
 
 	robCooldowns  map[int64]time.Time
 	diceCooldowns map[int64]time.Time
 	pendingDuels  map[int64]*DuelRequest // target_id -> request
+	duelMatches   map[int64]*DuelMatch   // match_id -> running best-of-three match
+	nextMatchID   int64
 	
 	mu sync.RWMutex
 }
@@ -122,9 +139,38 @@ func NewAllInGame(
 		robCooldowns:  make(map[int64]time.Time),
 		diceCooldowns: make(map[int64]time.Time),
 		pendingDuels:  make(map[int64]*DuelRequest),
+		duelMatches:   make(map[int64]*DuelMatch),
 	}
 }
 
+// SetPvPRepository sets the repository of PvP opt-outs; opted-out users can
+// neither attack nor be attacked
+func (g *AllInGame) SetPvPRepository(repo *repository.PvPRepository) {
+	g.pvpRepo = repo
+}
+
+// checkPvPOptOut returns an error if the attacker or the target opted out of PvP
+func (g *AllInGame) checkPvPOptOut(ctx context.Context, attackerID, targetID int64) error {
+	if g.pvpRepo == nil {
+		return nil
+	}
+	attackerOut, err := g.pvpRepo.IsOptedOut(ctx, attackerID)
+	if err != nil {
+		return err
+	}
+	if attackerOut {
+		return ErrPvPOptedOut
+	}
+	targetOut, err := g.pvpRepo.IsOptedOut(ctx, targetID)
+	if err != nil {
+		return err
+	}
+	if targetOut {
+		return ErrTargetPvPOptedOut
+	}
+	return nil
+}
+
 // SetItemChecker sets the item effect checker
 func (g *AllInGame) SetItemChecker(checker ItemEffectChecker) {
 	g.itemChecker = checker
@@ -178,21 +224,29 @@ func (g *AllInGame) AllInRob(ctx context.Context, robberID, victimID int64, robb
 		return nil, ErrTargetNotFound
 	}
 
+	// Check PvP opt-outs
+	if err := g.checkPvPOptOut(ctx, robberID, victimID); err != nil {
+		return nil, err
+	}
+
 	// Check cooldown
 	if remaining := g.GetRobCooldown(robberID); remaining > 0 {
-		secs := int(remaining.Seconds()) + 1
 		return &AllInResult{
 			Success: false,
-			Message: fmt.Sprintf("梭哈打劫冷却中，请等待 %d 秒", secs),
+			Message: "梭哈打劫冷却中，请等待 " + cooldown.Format(remaining),
 		}, nil
 	}
 
 	// Check emperor clothes
 	if g.itemChecker != nil && g.itemChecker.HasEmperorClothes(ctx, victimID) {
-		g.itemChecker.DecrementUseCountByString(ctx, victimID, "emperor_clothes")
+		var used []shop.ItemUse
+		if remaining, consumed := g.itemChecker.UseItem(ctx, victimID, string(shop.ItemEmperorClothes)); consumed {
+			used = append(used, shop.ItemUse{UserID: victimID, Type: shop.ItemEmperorClothes, Remaining: remaining})
+		}
 		return &AllInResult{
-			Success: false,
-			Message: "👑 目标有皇帝的新衣，无法梭哈打劫",
+			Success:   false,
+			Message:   "👑 目标有皇帝的新衣，无法梭哈打劫",
+			ItemsUsed: used,
 		}, nil
 	}
 
@@ -253,14 +307,20 @@ func (g *AllInGame) AllInRob(ctx context.Context, robberID, victimID int64, robb
 
 	if success {
 		// Success: robber wins
-		g.userRepo.UpdateBalance(ctx, victimID, -amount)
-		newRobber, _ := g.userRepo.UpdateBalance(ctx, robberID, amount)
+		if _, err := g.userRepo.UpdateBalance(ctx, victimID, -amount); err != nil {
+			return nil, fmt.Errorf("扣除目标用户余额失败: %w", err)
+		}
+		newRobber, err := g.userRepo.UpdateBalance(ctx, robberID, amount)
+		if err != nil {
+			// Try to rollback victim's balance
+			g.userRepo.UpdateBalance(ctx, victimID, amount)
+			return nil, fmt.Errorf("增加打劫者余额失败: %w", err)
+		}
 
-		// Record transactions
+		// Record the victim -> robber flow
 		winDesc := fmt.Sprintf("梭哈打劫 %s 成功，获得 %d 金币", victimName, amount)
-		g.txRepo.Create(ctx, robberID, amount, TxTypeAllInRobWin, &winDesc)
 		loseDesc := fmt.Sprintf("被 %s 梭哈打劫，损失 %d 金币", robberName, amount)
-		g.txRepo.Create(ctx, victimID, -amount, TxTypeAllInRobLose, &loseDesc)
+		g.txRepo.CreateTransfer(ctx, victimID, robberID, amount, TxTypeAllInRobLose, TxTypeAllInRobWin, &loseDesc, &winDesc)
 
 		return &AllInResult{
 			Success:      true,
@@ -273,14 +333,19 @@ func (g *AllInGame) AllInRob(ctx context.Context, robberID, victimID int64, robb
 	} else {
 		// Failure: robber loses all
 		loseAmount := robber.Balance
-		g.userRepo.UpdateBalance(ctx, robberID, -loseAmount)
-		g.userRepo.UpdateBalance(ctx, victimID, loseAmount)
+		if _, err := g.userRepo.UpdateBalance(ctx, robberID, -loseAmount); err != nil {
+			return nil, fmt.Errorf("扣除打劫者余额失败: %w", err)
+		}
+		if _, err := g.userRepo.UpdateBalance(ctx, victimID, loseAmount); err != nil {
+			// Try to rollback robber's balance
+			g.userRepo.UpdateBalance(ctx, robberID, loseAmount)
+			return nil, fmt.Errorf("增加目标用户余额失败: %w", err)
+		}
 
-		// Record transactions
+		// Record the robber -> victim flow
 		loseDesc := fmt.Sprintf("梭哈打劫 %s 失败，损失 %d 金币", victimName, loseAmount)
-		g.txRepo.Create(ctx, robberID, -loseAmount, TxTypeAllInRobLose, &loseDesc)
 		winDesc := fmt.Sprintf("被 %s 梭哈打劫失败，获得 %d 金币", robberName, loseAmount)
-		g.txRepo.Create(ctx, victimID, loseAmount, TxTypeAllInRobWin, &winDesc)
+		g.txRepo.CreateTransfer(ctx, robberID, victimID, loseAmount, TxTypeAllInRobLose, TxTypeAllInRobWin, &loseDesc, &winDesc)
 
 		return &AllInResult{
 			Success:      false,
@@ -298,10 +363,9 @@ func (g *AllInGame) AllInRob(ctx context.Context, robberID, victimID int64, robb
 func (g *AllInGame) AllInDice(ctx context.Context, userID int64, userName string) (*DiceResult, error) {
 	// Check cooldown
 	if remaining := g.GetDiceCooldown(userID); remaining > 0 {
-		secs := int(remaining.Seconds()) + 1
 		return &DiceResult{
 			Won:     false,
-			Message: fmt.Sprintf("梭哈骰子冷却中，请等待 %d 秒", secs),
+			Message: "梭哈骰子冷却中，请等待 " + cooldown.Format(remaining),
 		}, nil
 	}
 
@@ -354,7 +418,9 @@ func (g *AllInGame) AllInDice(ctx context.Context, userID int64, userName string
 		}, nil
 	} else {
 		// Lose: balance becomes 0
-		g.userRepo.UpdateBalance(ctx, userID, -oldBalance)
+		if _, err := g.userRepo.UpdateBalance(ctx, userID, -oldBalance); err != nil {
+			return nil, fmt.Errorf("扣除余额失败: %w", err)
+		}
 
 		loseDesc := fmt.Sprintf("梭哈骰子 %d+%d=%d 输了，损失 %d 金币", dice1, dice2, total, oldBalance)
 		g.txRepo.Create(ctx, userID, -oldBalance, TxTypeDiceLose, &loseDesc)
@@ -371,8 +437,9 @@ func (g *AllInGame) AllInDice(ctx context.Context, userID int64, userName string
 	}
 }
 
-// CreateDuel creates a duel challenge
-func (g *AllInGame) CreateDuel(ctx context.Context, challengerID, targetID int64, challengerName, targetName string, chatID int64) (*DuelRequest, error) {
+// CreateDuel creates a duel challenge. bestOf is 1 for a single all-in roll or
+// DuelMatchRounds for a best-of-three match with escalating stakes.
+func (g *AllInGame) CreateDuel(ctx context.Context, challengerID, targetID int64, challengerName, targetName string, chatID int64, bestOf int) (*DuelRequest, error) {
 	// Check self-duel
 	if challengerID == targetID {
 		return nil, ErrSelfAllIn
@@ -384,6 +451,11 @@ func (g *AllInGame) CreateDuel(ctx context.Context, challengerID, targetID int64
 		return nil, ErrTargetNotFound
 	}
 
+	// Check PvP opt-outs
+	if err := g.checkPvPOptOut(ctx, challengerID, targetID); err != nil {
+		return nil, err
+	}
+
 	// Check if challenger already has pending duel
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -399,6 +471,14 @@ func (g *AllInGame) CreateDuel(ctx context.Context, challengerID, targetID int64
 		return nil, errors.New("目标已有待处理的对决")
 	}
 
+	// Check if either player is still in a match
+	if g.inDuelMatch(challengerID) {
+		return nil, ErrPendingDuel
+	}
+	if g.inDuelMatch(targetID) {
+		return nil, errors.New("目标正在对决中")
+	}
+
 	// Get balances
 	challenger, err := g.userRepo.GetByID(ctx, challengerID)
 	if err != nil {
@@ -423,6 +503,11 @@ func (g *AllInGame) CreateDuel(ctx context.Context, challengerID, targetID int64
 	if target.Balance < amount {
 		amount = target.Balance
 	}
+	if bestOf == DuelMatchRounds {
+		amount = DuelMatchStake(challenger.Balance, target.Balance)
+	} else {
+		bestOf = 1
+	}
 
 	// Create duel request
 	duel := &DuelRequest{
@@ -433,6 +518,7 @@ func (g *AllInGame) CreateDuel(ctx context.Context, challengerID, targetID int64
 		Amount:         amount,
 		CreatedAt:      time.Now(),
 		ChatID:         chatID,
+		BestOf:         bestOf,
 	}
 
 	g.pendingDuels[targetID] = duel
@@ -532,14 +618,19 @@ func (g *AllInGame) AcceptDuel(ctx context.Context, targetID int64) (*DuelResult
 	}
 
 	// Transfer coins
-	g.userRepo.UpdateBalance(ctx, loserID, -amount)
-	g.userRepo.UpdateBalance(ctx, winnerID, amount)
+	if _, err := g.userRepo.UpdateBalance(ctx, loserID, -amount); err != nil {
+		return nil, fmt.Errorf("扣除输家余额失败: %w", err)
+	}
+	if _, err := g.userRepo.UpdateBalance(ctx, winnerID, amount); err != nil {
+		// Try to rollback loser's balance
+		g.userRepo.UpdateBalance(ctx, loserID, amount)
+		return nil, fmt.Errorf("增加赢家余额失败: %w", err)
+	}
 
-	// Record transactions
+	// Record the loser -> winner flow
 	winDesc := fmt.Sprintf("对决 %s 获胜，获得 %d 金币", loserName, amount)
-	g.txRepo.Create(ctx, winnerID, amount, TxTypeDuelWin, &winDesc)
 	loseDesc := fmt.Sprintf("对决 %s 失败，损失 %d 金币", winnerName, amount)
-	g.txRepo.Create(ctx, loserID, -amount, TxTypeDuelLose, &loseDesc)
+	g.txRepo.CreateTransfer(ctx, loserID, winnerID, amount, TxTypeDuelLose, TxTypeDuelWin, &loseDesc, &winDesc)
 
 	return &DuelResult{
 		WinnerID:   winnerID,
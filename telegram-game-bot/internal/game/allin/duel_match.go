@@ -0,0 +1,433 @@
+package allin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Best-of-three duel configuration
+const (
+	DuelMatchRounds      = 3  // Rounds of a match at most
+	DuelMatchWins        = 2  // Round wins needed to take the match
+	DuelDecisionTimeout  = 30 // Seconds the round loser has to double or quit
+	DuelMatchStakeDivide = 4  // The first stake is a quarter of the poorer balance, so two doubles stay affordable
+)
+
+// DuelDecision is the choice of a round loser between two rounds.
+type DuelDecision int
+
+// Round loser decisions
+const (
+	DuelContinue DuelDecision = iota // Play on at the current stake (the timeout default)
+	DuelDouble                       // Both players escrow the stake once more
+	DuelQuit                         // Concede the match, the opponent takes the pot
+)
+
+// Errors
+var (
+	ErrNoDuelMatch       = errors.New("对决已结束或不存在")
+	ErrNoDuelDecision    = errors.New("现在不需要做决定")
+	ErrNotDuelDecider    = errors.New("只有上一局的输家可以选择加倍或认输")
+	ErrDuelDoubleBlocked = errors.New("双方余额不足以加倍")
+)
+
+// DuelMatch is a running best-of-three duel. Both stakes are escrowed: they
+// leave the players' balances when the match starts and when a round loser
+// doubles, and the pot is paid to the winner at the end.
+//
+// Matches are kept in memory; RefundDuelMatches returns the stakes of the
+// matches still running when the bot stops.
+type DuelMatch struct {
+	ID        int64
+	ChatID    int64
+	PlayerIDs [2]int64  // Challenger first, then the target
+	Names     [2]string // Display names in PlayerIDs order
+	Stake     int64     // Coins escrowed by each player so far
+	Wins      [2]int    // Rounds won per player
+	Rounds    [][2]int  // Dice values per round, in PlayerIDs order
+	Decider   int       // Index of the player to double or quit, -1 if no decision is pending
+
+	decisions chan DuelDecision
+}
+
+// Pot returns the coins paid to the winner.
+func (m *DuelMatch) Pot() int64 {
+	return 2 * m.Stake
+}
+
+// Winner returns the index of the player who took the match, -1 while it runs.
+func (m *DuelMatch) Winner() int {
+	for i, wins := range m.Wins {
+		if wins >= DuelMatchWins {
+			return i
+		}
+	}
+	return -1
+}
+
+// Record records the dice of a round and returns the index of the round
+// winner, -1 for a tie. Tied rounds are replayed and count towards no one.
+func (m *DuelMatch) Record(first, second int) int {
+	m.Rounds = append(m.Rounds, [2]int{first, second})
+	switch {
+	case first > second:
+		m.Wins[0]++
+		return 0
+	case second > first:
+		m.Wins[1]++
+		return 1
+	}
+	return -1
+}
+
+// snapshot returns a copy of the match safe to read without the game lock
+func (m *DuelMatch) snapshot() *DuelMatch {
+	c := *m
+	c.Rounds = append([][2]int(nil), m.Rounds...)
+	c.decisions = nil
+	return &c
+}
+
+// DuelMatchStake returns the first stake of a match between two balances.
+func DuelMatchStake(balanceA, balanceB int64) int64 {
+	poorer := balanceA
+	if balanceB < poorer {
+		poorer = balanceB
+	}
+	return poorer / DuelMatchStakeDivide
+}
+
+// DuelDoubleExtra returns the coins each player escrows when the stake is
+// doubled, capped by what both players can still cover.
+func DuelDoubleExtra(stake, balanceA, balanceB int64) int64 {
+	extra := stake
+	if balanceA < extra {
+		extra = balanceA
+	}
+	if balanceB < extra {
+		extra = balanceB
+	}
+	if extra < 0 {
+		return 0
+	}
+	return extra
+}
+
+// DuelRoundResult is the outcome of one round of a match.
+type DuelRoundResult struct {
+	Match       *DuelMatch  // State after the round
+	RoundWinner int         // Index of the round winner, -1 for a tie
+	Result      *DuelResult // Set when the round decided the match
+}
+
+// inDuelMatch reports whether the user plays a running match (g.mu held)
+func (g *AllInGame) inDuelMatch(userID int64) bool {
+	for _, m := range g.duelMatches {
+		if m.PlayerIDs[0] == userID || m.PlayerIDs[1] == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// lockPair locks two users in ID order and returns the unlock function
+func (g *AllInGame) lockPair(a, b int64) func() {
+	first, second := a, b
+	if b < a {
+		first, second = b, a
+	}
+	g.userLock.Lock(first)
+	g.userLock.Lock(second)
+	return func() {
+		g.userLock.Unlock(second)
+		g.userLock.Unlock(first)
+	}
+}
+
+// escrow moves a stake out of (negative amount) or back to a player's balance
+func (g *AllInGame) escrow(ctx context.Context, userID, amount int64, desc string) error {
+	if _, err := g.userRepo.UpdateBalance(ctx, userID, amount); err != nil {
+		return err
+	}
+	g.txRepo.Create(ctx, userID, amount, TxTypeDuelEscrow, &desc)
+	return nil
+}
+
+// escrowBoth escrows the same amount from both players of a match, refunding
+// the first if the second fails (both users locked)
+func (g *AllInGame) escrowBoth(ctx context.Context, m *DuelMatch, amount int64) error {
+	desc := fmt.Sprintf("三局两胜对决押注 %d 金币", amount)
+	if err := g.escrow(ctx, m.PlayerIDs[0], -amount, desc); err != nil {
+		return err
+	}
+	if err := g.escrow(ctx, m.PlayerIDs[1], -amount, desc); err != nil {
+		g.escrow(ctx, m.PlayerIDs[0], amount, fmt.Sprintf("三局两胜对决押注退还 %d 金币", amount))
+		return err
+	}
+	return nil
+}
+
+// StartDuelMatch accepts a best-of-three duel and escrows the first stake of
+// both players.
+func (g *AllInGame) StartDuelMatch(ctx context.Context, targetID int64) (*DuelMatch, error) {
+	g.mu.Lock()
+	duel, exists := g.pendingDuels[targetID]
+	if !exists {
+		g.mu.Unlock()
+		return nil, ErrNoPendingDuel
+	}
+	delete(g.pendingDuels, targetID)
+	g.mu.Unlock()
+
+	if time.Since(duel.CreatedAt) > time.Duration(DuelTimeout)*time.Second {
+		return nil, ErrDuelTimeout
+	}
+
+	unlock := g.lockPair(duel.ChallengerID, targetID)
+	defer unlock()
+
+	challenger, err := g.userRepo.GetByID(ctx, duel.ChallengerID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := g.userRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if challenger.Balance < MinAllInBalance || target.Balance < MinAllInBalance {
+		return nil, ErrInsufficientBalance
+	}
+
+	m := &DuelMatch{
+		ChatID:    duel.ChatID,
+		PlayerIDs: [2]int64{duel.ChallengerID, targetID},
+		Names:     [2]string{duel.ChallengerName, duel.TargetName},
+		Stake:     DuelMatchStake(challenger.Balance, target.Balance),
+		Decider:   -1,
+		decisions: make(chan DuelDecision, 1),
+	}
+	if err := g.escrowBoth(ctx, m, m.Stake); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	g.nextMatchID++
+	m.ID = g.nextMatchID
+	g.duelMatches[m.ID] = m
+	snapshot := m.snapshot()
+	g.mu.Unlock()
+
+	return snapshot, nil
+}
+
+// RecordDuelRound records the dice of a round. A decided match is settled;
+// otherwise the round loser has to decide before the next round.
+func (g *AllInGame) RecordDuelRound(ctx context.Context, matchID int64, first, second int) (*DuelRoundResult, error) {
+	g.mu.Lock()
+	m, exists := g.duelMatches[matchID]
+	if !exists {
+		g.mu.Unlock()
+		return nil, ErrNoDuelMatch
+	}
+	roundWinner := m.Record(first, second)
+	if roundWinner >= 0 && m.Winner() < 0 {
+		m.Decider = 1 - roundWinner
+	}
+	winner := m.Winner()
+	if winner >= 0 {
+		delete(g.duelMatches, matchID)
+	}
+	snapshot := m.snapshot()
+	g.mu.Unlock()
+
+	res := &DuelRoundResult{Match: snapshot, RoundWinner: roundWinner}
+	if winner >= 0 {
+		res.Result = g.settleDuelMatch(ctx, snapshot, winner)
+	}
+	return res, nil
+}
+
+// SubmitDuelDecision hands the round loser's choice to the running match.
+func (g *AllInGame) SubmitDuelDecision(matchID, userID int64, decision DuelDecision) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m, exists := g.duelMatches[matchID]
+	if !exists {
+		return ErrNoDuelMatch
+	}
+	if m.Decider < 0 {
+		return ErrNoDuelDecision
+	}
+	if m.PlayerIDs[m.Decider] != userID {
+		return ErrNotDuelDecider
+	}
+	m.Decider = -1
+	m.decisions <- decision
+	return nil
+}
+
+// AwaitDuelDecision waits for the round loser's choice. After
+// DuelDecisionTimeout the match continues at the current stake.
+func (g *AllInGame) AwaitDuelDecision(matchID int64) DuelDecision {
+	g.mu.RLock()
+	m, exists := g.duelMatches[matchID]
+	g.mu.RUnlock()
+	if !exists {
+		return DuelContinue
+	}
+
+	select {
+	case decision := <-m.decisions:
+		return decision
+	case <-time.After(time.Duration(DuelDecisionTimeout) * time.Second):
+	}
+
+	// A choice may have arrived just as the timeout fired
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m.Decider = -1
+	select {
+	case decision := <-m.decisions:
+		return decision
+	default:
+		return DuelContinue
+	}
+}
+
+// DoubleDuelStake escrows the stake once more from both players and returns
+// the extra coins each one put in.
+func (g *AllInGame) DoubleDuelStake(ctx context.Context, matchID int64) (int64, error) {
+	g.mu.RLock()
+	m, exists := g.duelMatches[matchID]
+	var snapshot *DuelMatch
+	if exists {
+		snapshot = m.snapshot()
+	}
+	g.mu.RUnlock()
+	if !exists {
+		return 0, ErrNoDuelMatch
+	}
+
+	unlock := g.lockPair(snapshot.PlayerIDs[0], snapshot.PlayerIDs[1])
+	defer unlock()
+
+	first, err := g.userRepo.GetByID(ctx, snapshot.PlayerIDs[0])
+	if err != nil {
+		return 0, err
+	}
+	second, err := g.userRepo.GetByID(ctx, snapshot.PlayerIDs[1])
+	if err != nil {
+		return 0, err
+	}
+	extra := DuelDoubleExtra(snapshot.Stake, first.Balance, second.Balance)
+	if extra == 0 {
+		return 0, ErrDuelDoubleBlocked
+	}
+	if err := g.escrowBoth(ctx, snapshot, extra); err != nil {
+		return 0, err
+	}
+
+	g.mu.Lock()
+	m.Stake += extra
+	g.mu.Unlock()
+	return extra, nil
+}
+
+// DuelDoubleOffer returns the extra coins each player would escrow if
+// the stake were doubled now, 0 if doubling is not possible.
+func (g *AllInGame) DuelDoubleOffer(ctx context.Context, match *DuelMatch) int64 {
+	first, err := g.userRepo.GetByID(ctx, match.PlayerIDs[0])
+	if err != nil {
+		return 0
+	}
+	second, err := g.userRepo.GetByID(ctx, match.PlayerIDs[1])
+	if err != nil {
+		return 0
+	}
+	return DuelDoubleExtra(match.Stake, first.Balance, second.Balance)
+}
+
+// ForfeitDuelMatch ends a match conceded by the player at index quitter and
+// pays the pot to the opponent.
+func (g *AllInGame) ForfeitDuelMatch(ctx context.Context, matchID int64, quitter int) (*DuelResult, error) {
+	g.mu.Lock()
+	m, exists := g.duelMatches[matchID]
+	if !exists {
+		g.mu.Unlock()
+		return nil, ErrNoDuelMatch
+	}
+	delete(g.duelMatches, matchID)
+	snapshot := m.snapshot()
+	g.mu.Unlock()
+
+	return g.settleDuelMatch(ctx, snapshot, 1-quitter), nil
+}
+
+// CancelDuelMatch ends a match that cannot be played on, refunding both stakes.
+func (g *AllInGame) CancelDuelMatch(ctx context.Context, matchID int64) error {
+	g.mu.Lock()
+	m, exists := g.duelMatches[matchID]
+	if !exists {
+		g.mu.Unlock()
+		return ErrNoDuelMatch
+	}
+	delete(g.duelMatches, matchID)
+	snapshot := m.snapshot()
+	g.mu.Unlock()
+
+	unlock := g.lockPair(snapshot.PlayerIDs[0], snapshot.PlayerIDs[1])
+	defer unlock()
+
+	desc := fmt.Sprintf("三局两胜对决取消，退还押注 %d 金币", snapshot.Stake)
+	for _, id := range snapshot.PlayerIDs {
+		if err := g.escrow(ctx, id, snapshot.Stake, desc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefundDuelMatches cancels every running match, refunding both stakes.
+// Called when the bot stops, as the matches cannot be finished after a restart.
+func (g *AllInGame) RefundDuelMatches(ctx context.Context) error {
+	g.mu.RLock()
+	ids := make([]int64, 0, len(g.duelMatches))
+	for id := range g.duelMatches {
+		ids = append(ids, id)
+	}
+	g.mu.RUnlock()
+
+	var errs []error
+	for _, id := range ids {
+		if err := g.CancelDuelMatch(ctx, id); err != nil && !errors.Is(err, ErrNoDuelMatch) {
+			errs = append(errs, fmt.Errorf("match %d: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// settleDuelMatch pays the pot of a finished match to the winner
+func (g *AllInGame) settleDuelMatch(ctx context.Context, m *DuelMatch, winner int) *DuelResult {
+	loser := 1 - winner
+	winnerID := m.PlayerIDs[winner]
+
+	g.userLock.Lock(winnerID)
+	defer g.userLock.Unlock(winnerID)
+
+	pot := m.Pot()
+	g.userRepo.UpdateBalance(ctx, winnerID, pot)
+	desc := fmt.Sprintf("三局两胜对决战胜 %s，赢得奖池 %d 金币", m.Names[loser], pot)
+	g.txRepo.Create(ctx, winnerID, pot, TxTypeDuelWin, &desc)
+
+	return &DuelResult{
+		WinnerID:   winnerID,
+		WinnerName: m.Names[winner],
+		LoserID:    m.PlayerIDs[loser],
+		LoserName:  m.Names[loser],
+		Amount:     m.Stake,
+		Message:    fmt.Sprintf("⚔️ 三局两胜结果：%s 获胜！(%d:%d)\n💰 %s 赢得奖池 %d 金币", m.Names[winner], m.Wins[winner], m.Wins[loser], m.Names[winner], pot),
+	}
+}
@@ -0,0 +1,98 @@
+package allin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pgregory.net/rapid"
+)
+
+// TestDuelMatchRecordProperty tests that a match ends as soon as one player
+// has two round wins, ties count towards no one, and the other player never
+// reaches two wins as well.
+func TestDuelMatchRecordProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		m := &DuelMatch{Decider: -1}
+		rolls := 0
+		for m.Winner() < 0 {
+			first := rapid.IntRange(1, 6).Draw(t, "first")
+			second := rapid.IntRange(1, 6).Draw(t, "second")
+			before := m.Wins
+
+			roundWinner := m.Record(first, second)
+			rolls++
+
+			switch {
+			case first == second && (roundWinner != -1 || m.Wins != before):
+				t.Fatalf("Tie %d:%d counted for %d", first, second, roundWinner)
+			case first > second && roundWinner != 0:
+				t.Fatalf("Round %d:%d won by %d", first, second, roundWinner)
+			case second > first && roundWinner != 1:
+				t.Fatalf("Round %d:%d won by %d", first, second, roundWinner)
+			}
+			if rolls > 200 {
+				return
+			}
+		}
+
+		winner := m.Winner()
+		if m.Wins[winner] != DuelMatchWins || m.Wins[1-winner] >= DuelMatchWins {
+			t.Fatalf("Match ended at %v", m.Wins)
+		}
+		if decided := m.Wins[0] + m.Wins[1]; decided > DuelMatchRounds {
+			t.Fatalf("Match took %d decided rounds", decided)
+		}
+		if len(m.Rounds) != rolls {
+			t.Fatalf("Recorded %d rounds, rolled %d", len(m.Rounds), rolls)
+		}
+	})
+}
+
+// TestDuelMatchStakeProperty tests that two doubles of the first stake stay
+// within the poorer balance.
+func TestDuelMatchStakeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		a := rapid.Int64Range(MinAllInBalance, 1_000_000).Draw(t, "a")
+		b := rapid.Int64Range(MinAllInBalance, 1_000_000).Draw(t, "b")
+
+		stake := DuelMatchStake(a, b)
+		if stake <= 0 {
+			t.Fatalf("Stake %d for balances %d and %d", stake, a, b)
+		}
+		// Stake, a double and another double of the doubled stake
+		if total := stake * DuelMatchStakeDivide; total > min(a, b) {
+			t.Fatalf("Escalated stakes %d exceed balance %d", total, min(a, b))
+		}
+	})
+}
+
+// TestDuelDoubleExtraProperty tests that doubling never asks for more than the
+// stake or than either player holds.
+func TestDuelDoubleExtraProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		stake := rapid.Int64Range(0, 10_000).Draw(t, "stake")
+		a := rapid.Int64Range(-100, 20_000).Draw(t, "a")
+		b := rapid.Int64Range(-100, 20_000).Draw(t, "b")
+
+		extra := DuelDoubleExtra(stake, a, b)
+		if extra < 0 || extra > stake || (extra > 0 && (extra > a || extra > b)) {
+			t.Fatalf("Extra %d for stake %d and balances %d, %d", extra, stake, a, b)
+		}
+		if a >= stake && b >= stake && extra != stake {
+			t.Fatalf("Extra %d, want the full stake %d", extra, stake)
+		}
+	})
+}
+
+// TestDuelMatchSnapshot tests that snapshots do not share the rounds.
+func TestDuelMatchSnapshot(t *testing.T) {
+	m := &DuelMatch{Decider: -1, Stake: 50, decisions: make(chan DuelDecision, 1)}
+	m.Record(6, 1)
+	snap := m.snapshot()
+	m.Record(1, 6)
+
+	assert.Len(t, snap.Rounds, 1)
+	assert.Equal(t, [2]int{1, 0}, snap.Wins)
+	assert.Nil(t, snap.decisions)
+	assert.Equal(t, int64(100), snap.Pot())
+}
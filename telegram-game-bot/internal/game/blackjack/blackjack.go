@@ -0,0 +1,487 @@
+// Package blackjack implements blackjack (21点) against the dealer.
+// The player is dealt two cards and hits, stands or doubles down with the
+// buttons of the hand message; the dealer then draws to 17 and the hand is
+// paid: a natural blackjack pays 3:2, other wins 1:1 and ties push.
+package blackjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"telegram-game-bot/internal/game"
+)
+
+const (
+	// DefaultMaxBet is the maximum allowed bet for blackjack
+	DefaultMaxBet = 10000
+
+	// DefaultCooldown is the cooldown between blackjack hands in seconds
+	DefaultCooldown = 3
+
+	// Target is the total a hand must not exceed
+	Target = 21
+
+	// DealerStandsOn is the total the dealer stops drawing at, soft totals included
+	DealerStandsOn = 17
+)
+
+// Hand outcomes
+const (
+	OutcomeBlackjack = "blackjack" // Natural 21 on the first two cards, pays 3:2
+	OutcomeWin       = "win"       // Higher total than the dealer, or the dealer busted
+	OutcomePush      = "push"      // Same total as the dealer
+	OutcomeLose      = "lose"      // Lower total than the dealer
+	OutcomeBust      = "bust"      // Player went over 21
+)
+
+// Errors for blackjack
+var (
+	ErrInvalidBet   = errors.New("bet amount must be positive")
+	ErrBetTooHigh   = errors.New("bet exceeds maximum allowed")
+	ErrHandExists   = errors.New("a hand is already in play")
+	ErrNoHand       = errors.New("no hand in play")
+	ErrCannotDouble = errors.New("can only double down on the first two cards")
+	ErrMissingCards = errors.New("player and dealer cards are required")
+)
+
+// Card is a playing card.
+type Card struct {
+	Rank int // 1 (ace) to 13 (king)
+	Suit int // 0-3, index of suitSymbols
+}
+
+var suitSymbols = [4]string{"♠", "♥", "♦", "♣"}
+
+var rankNames = [14]string{"", "A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+
+// String returns the card as rank and suit, e.g. "A♠" or "10♥".
+func (c Card) String() string {
+	if c.Rank < 1 || c.Rank > 13 || c.Suit < 0 || c.Suit > 3 {
+		return "?"
+	}
+	return rankNames[c.Rank] + suitSymbols[c.Suit]
+}
+
+// points returns the card's value with an ace counted as 1
+func (c Card) points() int {
+	if c.Rank >= 10 {
+		return 10
+	}
+	return c.Rank
+}
+
+// NewDeck returns the 52 cards of a deck in order.
+func NewDeck() []Card {
+	deck := make([]Card, 0, 52)
+	for suit := 0; suit < 4; suit++ {
+		for rank := 1; rank <= 13; rank++ {
+			deck = append(deck, Card{Rank: rank, Suit: suit})
+		}
+	}
+	return deck
+}
+
+// shuffledDeck returns a deck shuffled with rng
+func shuffledDeck(rng *rand.Rand) []Card {
+	deck := NewDeck()
+	rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+	return deck
+}
+
+// HandValue returns the best total of cards and whether it is soft, i.e. an
+// ace counts as 11.
+func HandValue(cards []Card) (total int, soft bool) {
+	hasAce := false
+	for _, c := range cards {
+		total += c.points()
+		if c.Rank == 1 {
+			hasAce = true
+		}
+	}
+	if hasAce && total+10 <= Target {
+		return total + 10, true
+	}
+	return total, false
+}
+
+// IsBlackjack reports whether cards are a natural: 21 with two cards.
+func IsBlackjack(cards []Card) bool {
+	total, _ := HandValue(cards)
+	return len(cards) == 2 && total == Target
+}
+
+// DealerShouldHit reports whether the dealer draws another card.
+func DealerShouldHit(cards []Card) bool {
+	total, _ := HandValue(cards)
+	return total < DealerStandsOn
+}
+
+// DetermineOutcome returns the outcome of a finished hand.
+func DetermineOutcome(player, dealer []Card) string {
+	playerTotal, _ := HandValue(player)
+	dealerTotal, _ := HandValue(dealer)
+	playerNatural, dealerNatural := IsBlackjack(player), IsBlackjack(dealer)
+
+	switch {
+	case playerTotal > Target:
+		return OutcomeBust
+	case playerNatural && dealerNatural:
+		return OutcomePush
+	case playerNatural:
+		return OutcomeBlackjack
+	case dealerNatural:
+		return OutcomeLose
+	case dealerTotal > Target, playerTotal > dealerTotal:
+		return OutcomeWin
+	case playerTotal == dealerTotal:
+		return OutcomePush
+	default:
+		return OutcomeLose
+	}
+}
+
+// CalculatePayout calculates the net payout of a finished hand with stake
+// wagered, a doubled bet included.
+// Rules:
+//   - blackjack: 3:2, rounded down
+//   - win: 1:1
+//   - push: 0
+//   - lose or bust: -stake
+func CalculatePayout(player, dealer []Card, stake int64) int64 {
+	switch DetermineOutcome(player, dealer) {
+	case OutcomeBlackjack:
+		return stake * 3 / 2
+	case OutcomeWin:
+		return stake
+	case OutcomePush:
+		return 0
+	default:
+		return -stake
+	}
+}
+
+// SimulateHand plays a hand from a deck shuffled with rng where the player
+// draws like the dealer, and returns its net payout. It backs the economy
+// simulation; real players choose their own moves.
+func SimulateHand(rng *rand.Rand, bet int64) int64 {
+	h := &Hand{Bet: bet, deck: shuffledDeck(rng)}
+	h.dealOpening()
+	if !IsBlackjack(h.Player) && !IsBlackjack(h.Dealer) {
+		for DealerShouldHit(h.Player) {
+			h.Player = append(h.Player, h.draw())
+		}
+		if total, _ := HandValue(h.Player); total <= Target {
+			for DealerShouldHit(h.Dealer) {
+				h.Dealer = append(h.Dealer, h.draw())
+			}
+		}
+	}
+	return CalculatePayout(h.Player, h.Dealer, bet)
+}
+
+// Hand is a player's hand against the dealer.
+type Hand struct {
+	UserID     int64
+	ChatID     int64
+	Bet        int64 // Initial bet; a doubled hand wagers twice this
+	Player     []Card
+	Dealer     []Card // The second card is the hole card until the hand is finished
+	Doubled    bool
+	Finished   bool
+	LastAction time.Time
+	deck       []Card // Cards left to draw
+}
+
+// Stake returns the coins wagered on the hand.
+func (h *Hand) Stake() int64 {
+	if h.Doubled {
+		return h.Bet * 2
+	}
+	return h.Bet
+}
+
+// snapshot returns a copy of the hand that is safe to read without the lock
+func (h *Hand) snapshot() *Hand {
+	return &Hand{
+		UserID:     h.UserID,
+		ChatID:     h.ChatID,
+		Bet:        h.Bet,
+		Player:     append([]Card(nil), h.Player...),
+		Dealer:     append([]Card(nil), h.Dealer...),
+		Doubled:    h.Doubled,
+		Finished:   h.Finished,
+		LastAction: h.LastAction,
+	}
+}
+
+// dealOpening deals two cards each, alternating from the player
+func (h *Hand) dealOpening() {
+	h.Player = append(h.Player, h.draw())
+	h.Dealer = append(h.Dealer, h.draw())
+	h.Player = append(h.Player, h.draw())
+	h.Dealer = append(h.Dealer, h.draw())
+}
+
+// draw takes the top card of the deck
+func (h *Hand) draw() Card {
+	c := h.deck[0]
+	h.deck = h.deck[1:]
+	return c
+}
+
+// BlackjackGame implements the Game interface for blackjack and keeps the
+// hands in play, one per player. Every hand is dealt from a fresh deck.
+type BlackjackGame struct {
+	maxBet   int64
+	cooldown int
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	hands map[int64]*Hand // userID -> hand in play
+}
+
+// Config holds configuration for blackjack.
+type Config struct {
+	MaxBet   int64
+	Cooldown int
+}
+
+// New creates a new BlackjackGame with the given configuration.
+func New(cfg *Config) *BlackjackGame {
+	maxBet := int64(DefaultMaxBet)
+	cooldown := DefaultCooldown
+
+	if cfg != nil {
+		if cfg.MaxBet > 0 {
+			maxBet = cfg.MaxBet
+		}
+		if cfg.Cooldown > 0 {
+			cooldown = cfg.Cooldown
+		}
+	}
+
+	return &BlackjackGame{
+		maxBet:   maxBet,
+		cooldown: cooldown,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		hands:    make(map[int64]*Hand),
+	}
+}
+
+// Name returns the game's display name.
+func (g *BlackjackGame) Name() string {
+	return "Blackjack"
+}
+
+// Command returns the command that triggers this game.
+func (g *BlackjackGame) Command() string {
+	return "blackjack"
+}
+
+// Description returns a brief description of the game.
+func (g *BlackjackGame) Description() string {
+	return "Beat the dealer to 21: hit, stand or double down. Blackjack pays 3:2, the dealer stands on 17"
+}
+
+// MaxBet returns the maximum allowed bet.
+func (g *BlackjackGame) MaxBet() int64 {
+	return g.maxBet
+}
+
+// Cooldown returns the cooldown duration in seconds.
+func (g *BlackjackGame) Cooldown() int {
+	return g.cooldown
+}
+
+// ValidateBet checks if the bet amount is valid.
+func (g *BlackjackGame) ValidateBet(bet int64, params map[string]any) error {
+	if bet <= 0 {
+		return ErrInvalidBet
+	}
+	if bet > g.maxBet {
+		return fmt.Errorf("%w: max bet is %d", ErrBetTooHigh, g.maxBet)
+	}
+	return nil
+}
+
+// Play settles a finished hand given as "player" and "dealer" cards in
+// params; "doubled" marks a doubled bet. Hands are played with Deal, Hit,
+// Stand and Double.
+func (g *BlackjackGame) Play(ctx context.Context, userID int64, bet int64, params map[string]any) (*game.GameResult, error) {
+	if err := g.ValidateBet(bet, params); err != nil {
+		return nil, err
+	}
+
+	player, ok1 := params["player"].([]Card)
+	dealer, ok2 := params["dealer"].([]Card)
+	if !ok1 || !ok2 || len(player) < 2 || len(dealer) < 2 {
+		return nil, ErrMissingCards
+	}
+	stake := bet
+	if doubled, _ := params["doubled"].(bool); doubled {
+		stake = bet * 2
+	}
+
+	outcome := DetermineOutcome(player, dealer)
+	payout := CalculatePayout(player, dealer, stake)
+	playerTotal, _ := HandValue(player)
+	dealerTotal, _ := HandValue(dealer)
+
+	var description string
+	switch outcome {
+	case OutcomeBlackjack:
+		description = fmt.Sprintf("🃏 Blackjack! You won %d coins!", payout)
+	case OutcomeWin:
+		description = fmt.Sprintf("🃏 %d vs %d\n🎉 You won %d coins!", playerTotal, dealerTotal, payout)
+	case OutcomePush:
+		description = fmt.Sprintf("🃏 %d vs %d\n😐 Push! Your bet is returned.", playerTotal, dealerTotal)
+	case OutcomeBust:
+		description = fmt.Sprintf("🃏 Bust with %d! You lost %d coins.", playerTotal, -payout)
+	default:
+		description = fmt.Sprintf("🃏 %d vs %d\n😢 You lost %d coins.", playerTotal, dealerTotal, -payout)
+	}
+
+	return &game.GameResult{
+		Payout:      payout,
+		Description: description,
+		Details: map[string]any{
+			"outcome":      outcome,
+			"player_total": playerTotal,
+			"dealer_total": dealerTotal,
+			"stake":        stake,
+			"bet":          bet,
+		},
+	}, nil
+}
+
+// Deal starts a hand for a player with a fresh deck. A natural of the player
+// or the dealer finishes the hand right away.
+func (g *BlackjackGame) Deal(chatID, userID, bet int64) (*Hand, error) {
+	if err := g.ValidateBet(bet, nil); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exists := g.hands[userID]; exists {
+		return nil, ErrHandExists
+	}
+
+	h := &Hand{UserID: userID, ChatID: chatID, Bet: bet, LastAction: time.Now(), deck: shuffledDeck(g.rng)}
+	h.dealOpening()
+
+	if IsBlackjack(h.Player) || IsBlackjack(h.Dealer) {
+		h.Finished = true
+		return h.snapshot(), nil
+	}
+	g.hands[userID] = h
+	return h.snapshot(), nil
+}
+
+// Hand returns the hand a player has in play.
+func (g *BlackjackGame) Hand(userID int64) (*Hand, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	h, ok := g.hands[userID]
+	if !ok {
+		return nil, ErrNoHand
+	}
+	return h.snapshot(), nil
+}
+
+// Hit draws a card for the player. Reaching 21 or more finishes the hand.
+func (g *BlackjackGame) Hit(userID int64) (*Hand, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	h, ok := g.hands[userID]
+	if !ok {
+		return nil, ErrNoHand
+	}
+	h.Player = append(h.Player, h.draw())
+	h.LastAction = time.Now()
+	if total, _ := HandValue(h.Player); total >= Target {
+		g.finishLocked(h)
+	}
+	return h.snapshot(), nil
+}
+
+// Stand ends the player's turn; the dealer plays and the hand is finished.
+func (g *BlackjackGame) Stand(userID int64) (*Hand, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	h, ok := g.hands[userID]
+	if !ok {
+		return nil, ErrNoHand
+	}
+	h.LastAction = time.Now()
+	g.finishLocked(h)
+	return h.snapshot(), nil
+}
+
+// Double doubles the bet on the first two cards and draws exactly one more
+// card; the hand is then finished. The caller collects the extra bet.
+func (g *BlackjackGame) Double(userID int64) (*Hand, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	h, ok := g.hands[userID]
+	if !ok {
+		return nil, ErrNoHand
+	}
+	if len(h.Player) != 2 {
+		return nil, ErrCannotDouble
+	}
+	h.Doubled = true
+	h.Player = append(h.Player, h.draw())
+	h.LastAction = time.Now()
+	g.finishLocked(h)
+	return h.snapshot(), nil
+}
+
+// Idle returns the players whose hands saw no action since before.
+func (g *BlackjackGame) Idle(before time.Time) []int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var users []int64
+	for userID, h := range g.hands {
+		if h.LastAction.Before(before) {
+			users = append(users, userID)
+		}
+	}
+	return users
+}
+
+// Discard removes the hands in play without settling them, e.g. to refund
+// their stakes when the bot stops.
+func (g *BlackjackGame) Discard() []*Hand {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hands := make([]*Hand, 0, len(g.hands))
+	for userID, h := range g.hands {
+		hands = append(hands, h.snapshot())
+		delete(g.hands, userID)
+	}
+	return hands
+}
+
+// finishLocked lets the dealer draw unless the player busted, then removes
+// the hand from play; the caller must hold g.mu
+func (g *BlackjackGame) finishLocked(h *Hand) {
+	if total, _ := HandValue(h.Player); total <= Target {
+		for DealerShouldHit(h.Dealer) {
+			h.Dealer = append(h.Dealer, h.draw())
+		}
+	}
+	h.Finished = true
+	delete(g.hands, h.UserID)
+}
@@ -0,0 +1,181 @@
+package blackjack
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// cards builds a hand of ranks, suits do not matter
+func cards(ranks ...int) []Card {
+	hand := make([]Card, len(ranks))
+	for i, r := range ranks {
+		hand[i] = Card{Rank: r, Suit: i % 4}
+	}
+	return hand
+}
+
+// TestHandValue tests hard and soft totals.
+func TestHandValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		hand  []Card
+		total int
+		soft  bool
+	}{
+		{"two tens", cards(10, 13), 20, false},
+		{"ace six is soft 17", cards(1, 6), 17, true},
+		{"ace six ten is hard 17", cards(1, 6, 10), 17, false},
+		{"two aces", cards(1, 1), 12, true},
+		{"natural", cards(1, 12), 21, true},
+		{"bust", cards(10, 5, 9), 24, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, soft := HandValue(tt.hand)
+			assert.Equal(t, tt.total, total)
+			assert.Equal(t, tt.soft, soft)
+		})
+	}
+}
+
+// TestCalculatePayout tests the payout of each outcome.
+func TestCalculatePayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		player   []Card
+		dealer   []Card
+		expected int64
+	}{
+		{"blackjack pays 3:2", cards(1, 13), cards(10, 9), 150},
+		{"both naturals push", cards(1, 13), cards(1, 10), 0},
+		{"dealer natural beats 21", cards(7, 7, 7), cards(1, 10), -100},
+		{"bust loses to dealer bust", cards(10, 6, 8), cards(10, 6, 9), -100},
+		{"dealer bust wins", cards(10, 2), cards(10, 6, 9), 100},
+		{"higher total wins", cards(10, 9), cards(10, 7), 100},
+		{"same total pushes", cards(10, 8), cards(9, 9), 0},
+		{"lower total loses", cards(10, 7), cards(10, 8), -100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CalculatePayout(tt.player, tt.dealer, 100))
+		})
+	}
+}
+
+// TestDealerStandsOnSoft17 tests that the dealer stops drawing at any 17.
+func TestDealerStandsOnSoft17(t *testing.T) {
+	assert.True(t, DealerShouldHit(cards(10, 6)))
+	assert.False(t, DealerShouldHit(cards(1, 6)))
+	assert.False(t, DealerShouldHit(cards(10, 7)))
+}
+
+// TestHandLifecycleProperty tests that every hand ends finished with the
+// dealer at 17 or more unless the player busted or a natural was dealt.
+func TestHandLifecycleProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		g := New(nil)
+		g.rng = rand.New(rand.NewSource(rapid.Int64().Draw(t, "seed")))
+		bet := rapid.Int64Range(1, DefaultMaxBet).Draw(t, "bet")
+
+		hand, err := g.Deal(1, 42, bet)
+		require.NoError(t, err)
+		for !hand.Finished {
+			var next *Hand
+			switch rapid.IntRange(0, 2).Draw(t, "action") {
+			case 0:
+				next, err = g.Hit(42)
+			case 1:
+				next, err = g.Stand(42)
+			default:
+				next, err = g.Double(42)
+			}
+			if errors.Is(err, ErrCannotDouble) {
+				continue
+			}
+			require.NoError(t, err)
+			hand = next
+		}
+
+		_, err = g.Hand(42)
+		require.ErrorIs(t, err, ErrNoHand)
+
+		playerTotal, _ := HandValue(hand.Player)
+		dealerTotal, _ := HandValue(hand.Dealer)
+		naturalDealt := len(hand.Player) == 2 && (IsBlackjack(hand.Player) || IsBlackjack(hand.Dealer))
+		if playerTotal <= Target && !naturalDealt && dealerTotal < DealerStandsOn {
+			t.Fatalf("dealer stopped at %d", dealerTotal)
+		}
+		if hand.Doubled && len(hand.Player) != 3 {
+			t.Fatalf("doubled hand has %d cards", len(hand.Player))
+		}
+
+		payout := CalculatePayout(hand.Player, hand.Dealer, hand.Stake())
+		if payout < -hand.Stake() || payout > hand.Stake()*3/2 {
+			t.Fatalf("payout %d out of range for stake %d", payout, hand.Stake())
+		}
+	})
+}
+
+// TestDealRejectsSecondHand tests that a player has one hand at a time.
+func TestDealRejectsSecondHand(t *testing.T) {
+	g := New(nil)
+	for {
+		hand, err := g.Deal(1, 42, 100)
+		require.NoError(t, err)
+		if !hand.Finished {
+			break
+		}
+	}
+
+	_, err := g.Deal(1, 42, 100)
+	assert.ErrorIs(t, err, ErrHandExists)
+
+	_, err = g.Deal(1, 42, DefaultMaxBet+1)
+	assert.ErrorIs(t, err, ErrBetTooHigh)
+}
+
+// TestPlay tests that Play settles a finished hand with a doubled stake.
+func TestPlay(t *testing.T) {
+	g := New(nil)
+
+	result, err := g.Play(context.Background(), 42, 100, map[string]any{
+		"player":  cards(5, 6, 10),
+		"dealer":  cards(10, 8),
+		"doubled": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), result.Payout)
+	assert.Equal(t, OutcomeWin, result.Details["outcome"])
+
+	_, err = g.Play(context.Background(), 42, 100, nil)
+	assert.ErrorIs(t, err, ErrMissingCards)
+}
+
+// TestDiscard tests that discarded hands leave play unsettled.
+func TestDiscard(t *testing.T) {
+	g := New(nil)
+	for {
+		hand, err := g.Deal(1, 42, 100)
+		require.NoError(t, err)
+		if !hand.Finished {
+			break
+		}
+	}
+
+	hands := g.Discard()
+	require.Len(t, hands, 1)
+	assert.Equal(t, int64(42), hands[0].UserID)
+	assert.False(t, hands[0].Finished)
+
+	_, err := g.Hand(42)
+	assert.ErrorIs(t, err, ErrNoHand)
+	assert.Empty(t, g.Discard())
+}
@@ -0,0 +1,155 @@
+package dice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"telegram-game-bot/internal/game"
+)
+
+const (
+	// BestOfThreeWinsNeeded is the number of round wins that decides the match
+	BestOfThreeWinsNeeded = 2
+
+	// MaxBestOfThreeRolls caps the rolls of a match; an undecided match is a push
+	MaxBestOfThreeRolls = 7
+)
+
+// ErrMatchUnfinished is returned when a best-of-three match is played before it is decided
+var ErrMatchUnfinished = errors.New("best-of-three match is not finished")
+
+// BestOfThreeGame is a best-of-three dice duel against the bot.
+// Each round the player and the bot roll one die, the higher die wins the round
+// and ties are rolled again. The stake rides across all rounds: whoever wins two
+// rounds first takes it at 1:1.
+type BestOfThreeGame struct {
+	maxBet   int64
+	cooldown int
+}
+
+// NewBestOfThree creates a new BestOfThreeGame with the given configuration.
+func NewBestOfThree(cfg *Config) *BestOfThreeGame {
+	d := New(cfg)
+	return &BestOfThreeGame{
+		maxBet:   d.maxBet,
+		cooldown: d.cooldown,
+	}
+}
+
+// Name returns the game's display name.
+func (d *BestOfThreeGame) Name() string {
+	return "Best of Three Dice"
+}
+
+// Command returns the command that triggers this game.
+func (d *BestOfThreeGame) Command() string {
+	return "dicebo3"
+}
+
+// Description returns a brief description of the game.
+func (d *BestOfThreeGame) Description() string {
+	return "Dice duel against the bot: win two rounds first to double your stake"
+}
+
+// MaxBet returns the maximum allowed bet.
+func (d *BestOfThreeGame) MaxBet() int64 {
+	return d.maxBet
+}
+
+// Cooldown returns the cooldown duration in seconds.
+func (d *BestOfThreeGame) Cooldown() int {
+	return d.cooldown
+}
+
+// ValidateBet checks if the bet amount is valid.
+func (d *BestOfThreeGame) ValidateBet(bet int64, params map[string]any) error {
+	if bet <= 0 {
+		return ErrInvalidBet
+	}
+	if bet > d.maxBet {
+		return fmt.Errorf("%w: max bet is %d", ErrBetTooHigh, d.maxBet)
+	}
+	return nil
+}
+
+// Play scores a finished match from the "rounds" param ([][2]int of player and bot dice).
+func (d *BestOfThreeGame) Play(ctx context.Context, userID int64, bet int64, params map[string]any) (*game.GameResult, error) {
+	if err := d.ValidateBet(bet, params); err != nil {
+		return nil, err
+	}
+
+	rounds, ok := params["rounds"].([][2]int)
+	if !ok {
+		return nil, ErrMissingDice
+	}
+	for _, round := range rounds {
+		if round[0] < 1 || round[0] > 6 || round[1] < 1 || round[1] > 6 {
+			return nil, ErrInvalidDice
+		}
+	}
+
+	payout, finished := CalculateBestOfThreePayout(rounds, bet)
+	if !finished {
+		return nil, ErrMatchUnfinished
+	}
+	playerWins, botWins := ScoreBestOfThree(rounds)
+
+	var description string
+	switch {
+	case payout > 0:
+		description = fmt.Sprintf("🎲 Best of three %d:%d\n🎉 You won %d coins!", playerWins, botWins, payout)
+	case payout == 0:
+		description = fmt.Sprintf("🎲 Best of three %d:%d\n😐 Undecided, your bet is returned.", playerWins, botWins)
+	default:
+		description = fmt.Sprintf("🎲 Best of three %d:%d\n😢 You lost %d coins.", playerWins, botWins, -payout)
+	}
+
+	return &game.GameResult{
+		Payout:      payout,
+		Description: description,
+		Details: map[string]any{
+			"player_wins": playerWins,
+			"bot_wins":    botWins,
+			"rolls":       len(rounds),
+			"bet":         bet,
+		},
+	}, nil
+}
+
+// ScoreBestOfThree counts the rounds won by the player and the bot; ties count for nobody.
+func ScoreBestOfThree(rounds [][2]int) (playerWins, botWins int) {
+	for _, round := range rounds {
+		switch {
+		case round[0] > round[1]:
+			playerWins++
+		case round[1] > round[0]:
+			botWins++
+		}
+	}
+	return playerWins, botWins
+}
+
+// IsBestOfThreeFinished reports whether no more rolls are needed.
+func IsBestOfThreeFinished(rounds [][2]int) bool {
+	playerWins, botWins := ScoreBestOfThree(rounds)
+	return playerWins >= BestOfThreeWinsNeeded || botWins >= BestOfThreeWinsNeeded || len(rounds) >= MaxBestOfThreeRolls
+}
+
+// CalculateBestOfThreePayout returns the net payout of a match and whether it is finished.
+// The winner of two rounds takes the stake at 1:1; a match cut off by MaxBestOfThreeRolls is a push.
+func CalculateBestOfThreePayout(rounds [][2]int, bet int64) (int64, bool) {
+	if !IsBestOfThreeFinished(rounds) {
+		return 0, false
+	}
+
+	playerWins, botWins := ScoreBestOfThree(rounds)
+	switch {
+	case playerWins >= BestOfThreeWinsNeeded:
+		return bet, true
+	case botWins >= BestOfThreeWinsNeeded:
+		return -bet, true
+	default:
+		return 0, true
+	}
+}
@@ -0,0 +1,26 @@
+package dice
+
+// Bet insurance defaults: a premium of 10% of the stake refunds half the
+// stake on a loss.
+const (
+	DefaultInsurancePremiumPercent = 10
+	DefaultInsuranceRefundPercent  = 50
+)
+
+// InsurancePremium returns the premium of insuring bet, rounded up so that
+// small bets still pay at least one coin.
+func InsurancePremium(bet int64, premiumPercent int) int64 {
+	if bet <= 0 || premiumPercent <= 0 {
+		return 0
+	}
+	return (bet*int64(premiumPercent) + 99) / 100
+}
+
+// InsuranceRefund returns what an insured bet gets back for the rolled dice:
+// refundPercent of the stake on a loss (total 2-6), nothing otherwise.
+func InsuranceRefund(dice1, dice2 int, bet int64, refundPercent int) int64 {
+	if CalculatePayout(dice1, dice2, bet) >= 0 {
+		return 0
+	}
+	return bet * int64(refundPercent) / 100
+}
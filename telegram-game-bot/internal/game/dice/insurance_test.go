@@ -0,0 +1,76 @@
+package dice
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pgregory.net/rapid"
+)
+
+// TestInsuranceExpectedValue documents the expected value of dice insurance
+// over all 36 rolls at the default rates. A loss (2-6) comes up 15 times in
+// 36, so the insurance returns 15/36 × 50% ≈ 20.8% of the stake for a 10%
+// premium: insured players gain about 10.8% of the stake per game on top of
+// the +1/36 edge of the dice table, paid by the treasury.
+func TestInsuranceExpectedValue(t *testing.T) {
+	const bet = 3600
+	var plain, insured int64
+	for d1 := 1; d1 <= 6; d1++ {
+		for d2 := 1; d2 <= 6; d2++ {
+			payout := CalculatePayout(d1, d2, bet)
+			plain += payout
+			insured += payout - InsurancePremium(bet, DefaultInsurancePremiumPercent) +
+				InsuranceRefund(d1, d2, bet, DefaultInsuranceRefundPercent)
+		}
+	}
+
+	// Per game in coins of a 3600 stake: 100 without insurance, 490 with it
+	assert.Equal(t, int64(100*36), plain)
+	assert.Equal(t, int64(490*36), insured)
+}
+
+// TestInsuranceSimulation plays insured games with random dice and checks
+// that the average return per game converges to the exact expected value.
+func TestInsuranceSimulation(t *testing.T) {
+	const (
+		bet   = 1000
+		games = 200_000
+	)
+	rng := rand.New(rand.NewPCG(1, 2))
+	premium := InsurancePremium(bet, DefaultInsurancePremiumPercent)
+
+	var total int64
+	for i := 0; i < games; i++ {
+		d1, d2 := rng.IntN(6)+1, rng.IntN(6)+1
+		total += CalculatePayout(d1, d2, bet) - premium + InsuranceRefund(d1, d2, bet, DefaultInsuranceRefundPercent)
+	}
+
+	// Exact: 1000/36 + 15/36 × 500 - 100 ≈ 136.1 coins per game
+	mean := float64(total) / games
+	assert.InDelta(t, 136.1, mean, 10, "mean return per insured game")
+}
+
+// TestInsuranceBoundedProperty tests that the premium never exceeds the
+// stake and that the refund is bounded by the stake and only follows a loss.
+func TestInsuranceBoundedProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		d1 := rapid.IntRange(1, 6).Draw(t, "d1")
+		d2 := rapid.IntRange(1, 6).Draw(t, "d2")
+		bet := rapid.Int64Range(1, 1_000_000).Draw(t, "bet")
+		premiumPercent := rapid.IntRange(1, 100).Draw(t, "premium")
+		refundPercent := rapid.IntRange(0, 100).Draw(t, "refund")
+
+		premium := InsurancePremium(bet, premiumPercent)
+		refund := InsuranceRefund(d1, d2, bet, refundPercent)
+		if premium < 1 || premium > bet {
+			t.Fatalf("Premium %d for bet %d at %d%%", premium, bet, premiumPercent)
+		}
+		if refund < 0 || refund > bet {
+			t.Fatalf("Refund %d for bet %d at %d%%", refund, bet, refundPercent)
+		}
+		if CalculatePayout(d1, d2, bet) >= 0 && refund != 0 {
+			t.Fatalf("Refund %d without a loss (%d+%d)", refund, d1, d2)
+		}
+	})
+}
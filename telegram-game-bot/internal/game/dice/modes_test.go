@@ -0,0 +1,145 @@
+package dice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// TestCalculateTriplePayout tests the triple dice paytable.
+func TestCalculateTriplePayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		dice     [3]int
+		expected int64
+	}{
+		{"triple 1s", [3]int{1, 1, 1}, 300},
+		{"triple 6s", [3]int{6, 6, 6}, 300},
+		{"total 10 loses", [3]int{3, 3, 4}, -100},
+		{"total 11 push", [3]int{3, 4, 4}, 0},
+		{"total 12 wins", [3]int{2, 4, 6}, 100},
+		{"total 15 wins", [3]int{4, 5, 6}, 100},
+		{"total 17 pays 2x", [3]int{5, 6, 6}, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CalculateTriplePayout(tt.dice[0], tt.dice[1], tt.dice[2], 100))
+		})
+	}
+}
+
+// TestTriplePayoutExpectedValue tests that the triple dice paytable is fair over all 216 outcomes.
+func TestTriplePayoutExpectedValue(t *testing.T) {
+	var sum int64
+	for d1 := 1; d1 <= 6; d1++ {
+		for d2 := 1; d2 <= 6; d2++ {
+			for d3 := 1; d3 <= 6; d3++ {
+				sum += CalculateTriplePayout(d1, d2, d3, 1)
+			}
+		}
+	}
+	assert.Equal(t, int64(0), sum)
+}
+
+// TestTriplePayoutProperty tests that the triple payout is order independent and bounded.
+// *For any* dice d1, d2, d3 and bet B: payout ∈ [-B, 3B] and permuting the dice does not change it.
+func TestTriplePayoutProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		d1 := rapid.IntRange(1, 6).Draw(t, "d1")
+		d2 := rapid.IntRange(1, 6).Draw(t, "d2")
+		d3 := rapid.IntRange(1, 6).Draw(t, "d3")
+		bet := rapid.Int64Range(1, 10000).Draw(t, "bet")
+
+		payout := CalculateTriplePayout(d1, d2, d3, bet)
+		if payout < -bet || payout > 3*bet {
+			t.Fatalf("Payout %d out of range for bet %d", payout, bet)
+		}
+		if payout != CalculateTriplePayout(d3, d1, d2, bet) || payout != CalculateTriplePayout(d2, d3, d1, bet) {
+			t.Fatalf("Payout should not depend on dice order: (%d,%d,%d)", d1, d2, d3)
+		}
+	})
+}
+
+// TestTripleDiceGame_Play tests playing triple dice through the Game interface.
+func TestTripleDiceGame_Play(t *testing.T) {
+	g := NewTriple(nil)
+	assert.Equal(t, "dice3", g.Command())
+
+	result, err := g.Play(context.Background(), 1, 100, map[string]any{"dice1": 6, "dice2": 6, "dice3": 6})
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), result.Payout)
+	assert.Equal(t, true, result.Details["triple"])
+
+	_, err = g.Play(context.Background(), 1, 100, map[string]any{"dice1": 1, "dice2": 2})
+	assert.ErrorIs(t, err, ErrMissingDice)
+
+	_, err = g.Play(context.Background(), 1, 100, map[string]any{"dice1": 1, "dice2": 2, "dice3": 7})
+	assert.ErrorIs(t, err, ErrInvalidDice)
+}
+
+// TestBestOfThreePayoutProperty tests best-of-three scoring.
+// *For any* sequence of rolls: the match finishes exactly when a side has two round wins
+// or the roll cap is reached, and the payout is +B, -B or 0 accordingly.
+func TestBestOfThreePayoutProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		bet := rapid.Int64Range(1, 10000).Draw(t, "bet")
+
+		var rounds [][2]int
+		for !IsBestOfThreeFinished(rounds) {
+			if _, finished := CalculateBestOfThreePayout(rounds, bet); finished {
+				t.Fatalf("Unfinished match reported as finished")
+			}
+			rounds = append(rounds, [2]int{
+				rapid.IntRange(1, 6).Draw(t, "player"),
+				rapid.IntRange(1, 6).Draw(t, "bot"),
+			})
+		}
+
+		if len(rounds) > MaxBestOfThreeRolls {
+			t.Fatalf("Match took %d rolls, cap is %d", len(rounds), MaxBestOfThreeRolls)
+		}
+
+		payout, finished := CalculateBestOfThreePayout(rounds, bet)
+		if !finished {
+			t.Fatalf("Finished match reported as unfinished")
+		}
+
+		playerWins, botWins := ScoreBestOfThree(rounds)
+		switch {
+		case playerWins == BestOfThreeWinsNeeded:
+			if payout != bet {
+				t.Fatalf("Player won %d:%d, payout %d, expected %d", playerWins, botWins, payout, bet)
+			}
+		case botWins == BestOfThreeWinsNeeded:
+			if payout != -bet {
+				t.Fatalf("Bot won %d:%d, payout %d, expected %d", playerWins, botWins, payout, -bet)
+			}
+		default:
+			if payout != 0 || len(rounds) != MaxBestOfThreeRolls {
+				t.Fatalf("Undecided match %d:%d after %d rolls should push, got %d", playerWins, botWins, len(rounds), payout)
+			}
+		}
+	})
+}
+
+// TestBestOfThreeGame_Play tests playing a best-of-three match through the Game interface.
+func TestBestOfThreeGame_Play(t *testing.T) {
+	g := NewBestOfThree(nil)
+	assert.Equal(t, "dicebo3", g.Command())
+
+	result, err := g.Play(context.Background(), 1, 100, map[string]any{"rounds": [][2]int{{6, 1}, {3, 3}, {2, 5}, {4, 2}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), result.Payout)
+	assert.Equal(t, 2, result.Details["player_wins"])
+	assert.Equal(t, 1, result.Details["bot_wins"])
+
+	_, err = g.Play(context.Background(), 1, 100, map[string]any{"rounds": [][2]int{{6, 1}}})
+	assert.ErrorIs(t, err, ErrMatchUnfinished)
+
+	_, err = g.Play(context.Background(), 1, 100, map[string]any{"rounds": [][2]int{{0, 1}, {2, 1}}})
+	assert.ErrorIs(t, err, ErrInvalidDice)
+}
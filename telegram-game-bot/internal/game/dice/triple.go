@@ -0,0 +1,132 @@
+package dice
+
+import (
+	"context"
+	"fmt"
+
+	"telegram-game-bot/internal/game"
+)
+
+// TripleDiceGame rolls three dice with its own paytable.
+// Paytable (net payout):
+//   - any triple (豹子): 3*bet
+//   - total 16-17: 2*bet
+//   - total 12-15: bet
+//   - total 11: push
+//   - total 3-10: -bet
+type TripleDiceGame struct {
+	maxBet   int64
+	cooldown int
+}
+
+// NewTriple creates a new TripleDiceGame with the given configuration.
+func NewTriple(cfg *Config) *TripleDiceGame {
+	d := New(cfg)
+	return &TripleDiceGame{
+		maxBet:   d.maxBet,
+		cooldown: d.cooldown,
+	}
+}
+
+// Name returns the game's display name.
+func (d *TripleDiceGame) Name() string {
+	return "Triple Dice"
+}
+
+// Command returns the command that triggers this game.
+func (d *TripleDiceGame) Command() string {
+	return "dice3"
+}
+
+// Description returns a brief description of the game.
+func (d *TripleDiceGame) Description() string {
+	return "Roll three dice: 3-10 lose, 11 push, 12-15 win, 16-17 pay 2x, any triple pays 3x!"
+}
+
+// MaxBet returns the maximum allowed bet.
+func (d *TripleDiceGame) MaxBet() int64 {
+	return d.maxBet
+}
+
+// Cooldown returns the cooldown duration in seconds.
+func (d *TripleDiceGame) Cooldown() int {
+	return d.cooldown
+}
+
+// ValidateBet checks if the bet amount is valid.
+func (d *TripleDiceGame) ValidateBet(bet int64, params map[string]any) error {
+	if bet <= 0 {
+		return ErrInvalidBet
+	}
+	if bet > d.maxBet {
+		return fmt.Errorf("%w: max bet is %d", ErrBetTooHigh, d.maxBet)
+	}
+	return nil
+}
+
+// Play executes the triple dice logic using dice1, dice2 and dice3 from params.
+func (d *TripleDiceGame) Play(ctx context.Context, userID int64, bet int64, params map[string]any) (*game.GameResult, error) {
+	if err := d.ValidateBet(bet, params); err != nil {
+		return nil, err
+	}
+
+	dice1, dice2, err := extractDiceValues(params)
+	if err != nil {
+		return nil, err
+	}
+	dice3, ok := extractInt(params, "dice3")
+	if !ok {
+		return nil, ErrMissingDice
+	}
+	if dice3 < 1 || dice3 > 6 {
+		return nil, ErrInvalidDice
+	}
+
+	payout := CalculateTriplePayout(dice1, dice2, dice3, bet)
+	total := dice1 + dice2 + dice3
+
+	var description string
+	switch {
+	case payout > bet:
+		description = fmt.Sprintf("🎲🎲🎲 Dice: %d + %d + %d = %d\n🎊 You won %d coins!", dice1, dice2, dice3, total, payout)
+	case payout > 0:
+		description = fmt.Sprintf("🎲🎲🎲 Dice: %d + %d + %d = %d\n🎉 You won %d coins!", dice1, dice2, dice3, total, payout)
+	case payout == 0:
+		description = fmt.Sprintf("🎲🎲🎲 Dice: %d + %d + %d = %d\n😐 Push! Your bet is returned.", dice1, dice2, dice3, total)
+	default:
+		description = fmt.Sprintf("🎲🎲🎲 Dice: %d + %d + %d = %d\n😢 You lost %d coins.", dice1, dice2, dice3, total, -payout)
+	}
+
+	return &game.GameResult{
+		Payout:      payout,
+		Description: description,
+		Details: map[string]any{
+			"dice1":  dice1,
+			"dice2":  dice2,
+			"dice3":  dice3,
+			"total":  total,
+			"triple": dice1 == dice2 && dice2 == dice3,
+			"bet":    bet,
+		},
+	}, nil
+}
+
+// CalculateTriplePayout calculates the net payout for three dice.
+// Over all 216 outcomes the expected payout is exactly zero.
+func CalculateTriplePayout(dice1, dice2, dice3 int, bet int64) int64 {
+	if dice1 == dice2 && dice2 == dice3 {
+		return bet * 3
+	}
+
+	total := dice1 + dice2 + dice3
+	switch {
+	case total <= 10:
+		return -bet
+	case total == 11:
+		return 0
+	case total <= 15:
+		return bet
+	default: // 16-17, 18 is always a triple
+		return bet * 2
+	}
+}
@@ -0,0 +1,333 @@
+// Package heist implements the cooperative heist (抢金库) group game.
+// A starter opens a heist with a buy-in, other players join by paying the same
+// buy-in while the crew is recruited, then a single roll decides whether the
+// whole crew splits the target prize or loses every buy-in.
+package heist
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultJoinDuration is the default recruiting phase duration in seconds
+	DefaultJoinDuration = 120
+	// MinPlayers is the crew a heist needs, smaller crews are refunded
+	MinPlayers = 2
+	// MaxPlayers caps the crew of a heist
+	MaxPlayers = 10
+	// ReturnPercent is the expected return of a buy-in, the rest is the house edge
+	ReturnPercent = 95
+
+	baseChance    = 30 // Success chance of a crew of one, in percent
+	chancePerCrew = 5  // Success chance each further member adds
+	maxChance     = 70 // Cap of the success chance
+)
+
+// Errors for the heist game
+var (
+	ErrNoActiveSession = errors.New("no active heist in this chat")
+	ErrSessionExists   = errors.New("heist already exists in this chat")
+	ErrJoinEnded       = errors.New("recruiting phase has ended")
+	ErrAlreadyJoined   = errors.New("already joined this heist")
+	ErrCrewFull        = errors.New("heist crew is full")
+	ErrInvalidBuyIn    = errors.New("buy-in must be positive")
+)
+
+// Session represents a heist recruiting its crew.
+type Session struct {
+	ChatID      int64
+	StarterID   int64
+	BuyIn       int64
+	StartTime   time.Time
+	JoinEndTime time.Time
+	Players     []int64 // In join order, the starter first
+	Settled     bool
+	mu          sync.RWMutex
+}
+
+// Result is the outcome of a settled heist.
+type Result struct {
+	ChatID  int64           // Chat of the heist
+	Players []int64         // In join order, the starter first
+	BuyIn   int64           // Buy-in paid by each player
+	Pot     int64           // Sum of the buy-ins
+	Prize   int64           // Target prize split on success
+	Chance  int             // Success chance in percent
+	Roll    int             // Roll in [0, 100), success if below Chance
+	Success bool            // The crew got away with the prize
+	Void    bool            // Crew too small, buy-ins refunded without a roll
+	Payouts map[int64]int64 // Shares of the prize, or refunds of a void heist
+}
+
+// SessionInfo describes an active heist for scheduling.
+type SessionInfo struct {
+	ChatID      int64
+	JoinEndTime time.Time
+}
+
+// HeistGame keeps the heists of all chats.
+type HeistGame struct {
+	sessions map[int64]*Session // chatID -> Session
+	mu       sync.RWMutex
+}
+
+// New creates a new HeistGame instance.
+func New() *HeistGame {
+	return &HeistGame{sessions: make(map[int64]*Session)}
+}
+
+// SuccessChance returns the success chance in percent of a crew of players.
+// Larger crews are more likely to succeed.
+func SuccessChance(players int) int {
+	if players < 1 {
+		return 0
+	}
+	chance := baseChance + chancePerCrew*(players-1)
+	if chance > maxChance {
+		chance = maxChance
+	}
+	return chance
+}
+
+// TargetPrize returns the prize a crew of players splits on success. It grows
+// with the crew while the expected return of each buy-in stays ReturnPercent.
+func TargetPrize(players int, buyIn int64) int64 {
+	chance := SuccessChance(players)
+	if chance == 0 {
+		return 0
+	}
+	return buyIn * int64(players) * ReturnPercent / int64(chance)
+}
+
+// SplitPrize splits a prize equally among the players; the remainder goes to
+// the first player, who started the heist.
+func SplitPrize(prize int64, players []int64) map[int64]int64 {
+	shares := make(map[int64]int64, len(players))
+	if len(players) == 0 {
+		return shares
+	}
+	share := prize / int64(len(players))
+	for _, userID := range players {
+		shares[userID] = share
+	}
+	shares[players[0]] += prize - share*int64(len(players))
+	return shares
+}
+
+// StartSession opens a heist in a chat with the starter as its first member.
+// duration is the recruiting phase in seconds.
+func (g *HeistGame) StartSession(chatID, starterID, buyIn int64, duration int) error {
+	if buyIn <= 0 {
+		return ErrInvalidBuyIn
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if session, exists := g.sessions[chatID]; exists && !session.Settled {
+		return ErrSessionExists
+	}
+
+	if duration <= 0 {
+		duration = DefaultJoinDuration
+	}
+
+	now := time.Now()
+	g.sessions[chatID] = &Session{
+		ChatID:      chatID,
+		StarterID:   starterID,
+		BuyIn:       buyIn,
+		StartTime:   now,
+		JoinEndTime: now.Add(time.Duration(duration) * time.Second),
+		Players:     []int64{starterID},
+	}
+	return nil
+}
+
+// Join adds a player to the chat's heist and returns the crew size.
+func (g *HeistGame) Join(chatID, userID int64) (int, error) {
+	g.mu.RLock()
+	session, exists := g.sessions[chatID]
+	g.mu.RUnlock()
+
+	if !exists || session.Settled {
+		return 0, ErrNoActiveSession
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if time.Now().After(session.JoinEndTime) {
+		return 0, ErrJoinEnded
+	}
+	for _, id := range session.Players {
+		if id == userID {
+			return 0, ErrAlreadyJoined
+		}
+	}
+	if len(session.Players) >= MaxPlayers {
+		return 0, ErrCrewFull
+	}
+
+	session.Players = append(session.Players, userID)
+	return len(session.Players), nil
+}
+
+// BuyIn returns the buy-in of the chat's heist, 0 if there is none.
+func (g *HeistGame) BuyIn(chatID int64) int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	session, exists := g.sessions[chatID]
+	if !exists || session.Settled {
+		return 0
+	}
+	return session.BuyIn
+}
+
+// GetSessionStats returns the crew size and buy-in of the chat's heist.
+func (g *HeistGame) GetSessionStats(chatID int64) (players int, buyIn int64) {
+	g.mu.RLock()
+	session, exists := g.sessions[chatID]
+	g.mu.RUnlock()
+
+	if !exists {
+		return 0, 0
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return len(session.Players), session.BuyIn
+}
+
+// GetSessionTimeRemaining returns seconds remaining in the recruiting phase.
+func (g *HeistGame) GetSessionTimeRemaining(chatID int64) int {
+	g.mu.RLock()
+	session, exists := g.sessions[chatID]
+	g.mu.RUnlock()
+
+	if !exists || session.Settled {
+		return 0
+	}
+
+	remaining := time.Until(session.JoinEndTime)
+	if remaining < 0 {
+		return 0
+	}
+	return int(remaining.Seconds())
+}
+
+// IsSessionActive checks if there's an active heist in the chat.
+func (g *HeistGame) IsSessionActive(chatID int64) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	session, exists := g.sessions[chatID]
+	return exists && !session.Settled
+}
+
+// ActiveSessions returns the active heists of all chats.
+func (g *HeistGame) ActiveSessions() []SessionInfo {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	sessions := make([]SessionInfo, 0, len(g.sessions))
+	for chatID, session := range g.sessions {
+		if session.Settled {
+			continue
+		}
+		sessions = append(sessions, SessionInfo{ChatID: chatID, JoinEndTime: session.JoinEndTime})
+	}
+	return sessions
+}
+
+// Settle ends the chat's heist and rolls for its success.
+// Crews smaller than MinPlayers are void and every buy-in is refunded.
+func (g *HeistGame) Settle(chatID int64) (*Result, error) {
+	return g.settle(chatID, func() int { return rand.Intn(100) })
+}
+
+// SettleWithRoll settles the heist with a specific roll in [0, 100) (for testing).
+func (g *HeistGame) SettleWithRoll(chatID int64, roll int) (*Result, error) {
+	return g.settle(chatID, func() int { return roll })
+}
+
+// settle ends a session with the roll returned by roll
+func (g *HeistGame) settle(chatID int64, roll func() int) (*Result, error) {
+	g.mu.Lock()
+	session, exists := g.sessions[chatID]
+	if !exists || session.Settled {
+		g.mu.Unlock()
+		return nil, ErrNoActiveSession
+	}
+	delete(g.sessions, chatID)
+	g.mu.Unlock()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.Settled = true
+
+	result := newResult(session)
+	players := result.Players
+	if len(players) < MinPlayers {
+		voidResult(result)
+		return result, nil
+	}
+
+	result.Prize = TargetPrize(len(players), session.BuyIn)
+	result.Roll = roll()
+	result.Success = result.Roll < result.Chance
+	if result.Success {
+		result.Payouts = SplitPrize(result.Prize, players)
+	}
+	return result, nil
+}
+
+// Discard ends every heist without a roll, e.g. to refund the buy-ins when
+// the bot stops. The results are void, paying every buy-in back.
+func (g *HeistGame) Discard() []*Result {
+	g.mu.Lock()
+	sessions := make([]*Session, 0, len(g.sessions))
+	for chatID, session := range g.sessions {
+		sessions = append(sessions, session)
+		delete(g.sessions, chatID)
+	}
+	g.mu.Unlock()
+
+	results := make([]*Result, 0, len(sessions))
+	for _, session := range sessions {
+		session.mu.Lock()
+		if !session.Settled {
+			session.Settled = true
+			result := newResult(session)
+			voidResult(result)
+			results = append(results, result)
+		}
+		session.mu.Unlock()
+	}
+	return results
+}
+
+// newResult starts the result of a session (session.mu held)
+func newResult(session *Session) *Result {
+	players := append([]int64(nil), session.Players...)
+	return &Result{
+		ChatID:  session.ChatID,
+		Players: players,
+		BuyIn:   session.BuyIn,
+		Pot:     session.BuyIn * int64(len(players)),
+		Chance:  SuccessChance(len(players)),
+	}
+}
+
+// voidResult marks a result void, refunding every buy-in
+func voidResult(result *Result) {
+	result.Void = true
+	result.Payouts = make(map[int64]int64, len(result.Players))
+	for _, userID := range result.Players {
+		result.Payouts[userID] = result.BuyIn
+	}
+}
@@ -0,0 +1,145 @@
+package heist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// TestSuccessChance tests that the chance grows with the crew up to its cap.
+func TestSuccessChance(t *testing.T) {
+	assert.Equal(t, 0, SuccessChance(0))
+	assert.Equal(t, 35, SuccessChance(2))
+	assert.Equal(t, 70, SuccessChance(9))
+	assert.Equal(t, 70, SuccessChance(MaxPlayers))
+
+	for n := 2; n <= MaxPlayers; n++ {
+		assert.GreaterOrEqual(t, SuccessChance(n), SuccessChance(n-1))
+	}
+}
+
+// TestTargetPrizeProperty tests that the prize grows with the crew while the
+// expected return of a buy-in never exceeds ReturnPercent.
+func TestTargetPrizeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		players := rapid.IntRange(MinPlayers, MaxPlayers).Draw(t, "players")
+		buyIn := rapid.Int64Range(1, 1_000_000).Draw(t, "buyIn")
+
+		prize := TargetPrize(players, buyIn)
+		pot := buyIn * int64(players)
+		if prize < pot {
+			t.Fatalf("prize %d below pot %d", prize, pot)
+		}
+		if prize*int64(SuccessChance(players)) > pot*ReturnPercent {
+			t.Fatalf("expected return of prize %d above %d%% of pot %d", prize, ReturnPercent, pot)
+		}
+		if players < MaxPlayers && TargetPrize(players+1, buyIn) < prize {
+			t.Fatalf("prize shrinks from %d players to %d", players, players+1)
+		}
+	})
+}
+
+// TestSplitPrizeProperty tests that shares add up to the prize and differ by
+// the remainder only, which goes to the starter.
+func TestSplitPrizeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(1, MaxPlayers).Draw(t, "n")
+		players := make([]int64, n)
+		for i := range players {
+			players[i] = int64(i + 1)
+		}
+		prize := rapid.Int64Range(0, 10_000_000).Draw(t, "prize")
+
+		shares := SplitPrize(prize, players)
+		var sum int64
+		for _, share := range shares {
+			sum += share
+		}
+		if sum != prize {
+			t.Fatalf("shares sum to %d, want %d", sum, prize)
+		}
+		for _, userID := range players[1:] {
+			if shares[players[0]]-shares[userID] >= int64(n) {
+				t.Fatalf("starter share %d vs %d exceeds remainder", shares[players[0]], shares[userID])
+			}
+		}
+	})
+}
+
+// TestHeistSession tests joining and settling a heist.
+func TestHeistSession(t *testing.T) {
+	g := New()
+	require.NoError(t, g.StartSession(1, 10, 100, 60))
+	assert.ErrorIs(t, g.StartSession(1, 11, 100, 60), ErrSessionExists)
+
+	_, err := g.Join(1, 10)
+	assert.ErrorIs(t, err, ErrAlreadyJoined)
+	n, err := g.Join(1, 11)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	result, err := g.SettleWithRoll(1, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, int64(200), result.Pot)
+	assert.Equal(t, TargetPrize(2, 100), result.Payouts[10]+result.Payouts[11])
+	assert.False(t, g.IsSessionActive(1))
+
+	_, err = g.Settle(1)
+	assert.ErrorIs(t, err, ErrNoActiveSession)
+}
+
+// TestHeistFailedAndVoid tests that a failed roll pays nothing and a lone
+// starter is refunded without a roll.
+func TestHeistFailedAndVoid(t *testing.T) {
+	g := New()
+	require.NoError(t, g.StartSession(1, 10, 100, 60))
+	_, err := g.Join(1, 11)
+	require.NoError(t, err)
+	result, err := g.SettleWithRoll(1, 99)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, result.Payouts)
+
+	require.NoError(t, g.StartSession(2, 10, 100, 60))
+	result, err = g.SettleWithRoll(2, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Void)
+	assert.Equal(t, map[int64]int64{10: 100}, result.Payouts)
+}
+
+// TestHeistCrewFull tests that the crew is capped at MaxPlayers.
+func TestHeistCrewFull(t *testing.T) {
+	g := New()
+	require.NoError(t, g.StartSession(1, 1, 100, 60))
+	for id := int64(2); id <= MaxPlayers; id++ {
+		_, err := g.Join(1, id)
+		require.NoError(t, err)
+	}
+	_, err := g.Join(1, MaxPlayers+1)
+	assert.ErrorIs(t, err, ErrCrewFull)
+}
+
+// TestHeistDiscard tests that discarding refunds every crew's buy-ins and
+// ends their heists.
+func TestHeistDiscard(t *testing.T) {
+	g := New()
+	require.NoError(t, g.StartSession(1, 10, 100, 60))
+	_, err := g.Join(1, 11)
+	require.NoError(t, err)
+	require.NoError(t, g.StartSession(2, 12, 50, 60))
+
+	results := g.Discard()
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.True(t, result.Void)
+		for _, userID := range result.Players {
+			assert.Equal(t, result.BuyIn, result.Payouts[userID])
+		}
+	}
+	assert.False(t, g.IsSessionActive(1))
+	assert.False(t, g.IsSessionActive(2))
+	assert.Empty(t, g.Discard())
+}
@@ -0,0 +1,73 @@
+package rob
+
+import (
+	"math/rand"
+)
+
+// Rob amount modes
+const (
+	AmountModeFixed  = "fixed"  // Flat MinRobAmount-MaxRobAmount range
+	AmountModeScaled = "scaled" // Bounded percentage of the target's balance
+)
+
+// AmountPolicy decides how many coins a robbery takes from the target.
+// The result is capped at the target's balance by the caller.
+type AmountPolicy interface {
+	Amount(targetBalance int64) int64
+}
+
+// FixedAmountPolicy takes a flat MinRobAmount-MaxRobAmount regardless of wealth (default)
+type FixedAmountPolicy struct{}
+
+// Amount returns a random amount between MinRobAmount and MaxRobAmount
+func (FixedAmountPolicy) Amount(targetBalance int64) int64 {
+	return GenerateAmount()
+}
+
+// ScaledAmountPolicy takes a random percentage of the target's balance,
+// clamped to [MinAmount, MaxAmount].
+type ScaledAmountPolicy struct {
+	MinPercent float64 // Lower bound of the percentage, e.g. 0.5 for 0.5%
+	MaxPercent float64 // Upper bound of the percentage, e.g. 3 for 3%
+	MinAmount  int64   // Floor so robbing new players is still worth it
+	MaxAmount  int64   // Ceiling so millionaires are not wiped out
+}
+
+// Amount returns a random amount between MinPercent and MaxPercent of targetBalance,
+// clamped to [MinAmount, MaxAmount]
+func (p ScaledAmountPolicy) Amount(targetBalance int64) int64 {
+	if targetBalance < 0 {
+		targetBalance = 0
+	}
+
+	lo := int64(float64(targetBalance) * p.MinPercent / 100)
+	hi := int64(float64(targetBalance) * p.MaxPercent / 100)
+	if hi < lo {
+		hi = lo
+	}
+
+	amount := lo + rand.Int63n(hi-lo+1)
+	if amount > p.MaxAmount {
+		amount = p.MaxAmount
+	}
+	if amount < p.MinAmount {
+		amount = p.MinAmount
+	}
+	return amount
+}
+
+// NewAmountPolicy returns the policy for the given mode; unknown modes fall back to fixed
+func NewAmountPolicy(mode string, minPercent, maxPercent float64, minAmount, maxAmount int64) AmountPolicy {
+	if mode != AmountModeScaled {
+		return FixedAmountPolicy{}
+	}
+	if maxAmount < minAmount {
+		maxAmount = minAmount
+	}
+	return ScaledAmountPolicy{
+		MinPercent: minPercent,
+		MaxPercent: maxPercent,
+		MinAmount:  minAmount,
+		MaxAmount:  maxAmount,
+	}
+}
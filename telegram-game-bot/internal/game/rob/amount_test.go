@@ -0,0 +1,76 @@
+package rob
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestScaledAmountBoundsProperty tests that scaled amounts stay within the configured bounds.
+// *For any* balance and policy: MinAmount <= amount <= MaxAmount, and when the percentage
+// range lies inside the bounds, the amount lies inside the percentage range.
+func TestScaledAmountBoundsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		minPercent := rapid.Float64Range(0, 10).Draw(t, "minPercent")
+		maxPercent := minPercent + rapid.Float64Range(0, 10).Draw(t, "extraPercent")
+		minAmount := rapid.Int64Range(1, 1000).Draw(t, "minAmount")
+		maxAmount := minAmount + rapid.Int64Range(0, 100000).Draw(t, "extraAmount")
+		balance := rapid.Int64Range(0, 1_000_000_000).Draw(t, "balance")
+
+		policy := ScaledAmountPolicy{
+			MinPercent: minPercent,
+			MaxPercent: maxPercent,
+			MinAmount:  minAmount,
+			MaxAmount:  maxAmount,
+		}
+		amount := policy.Amount(balance)
+
+		if amount < minAmount || amount > maxAmount {
+			t.Fatalf("Amount %d outside [%d, %d]", amount, minAmount, maxAmount)
+		}
+
+		lo := int64(float64(balance) * minPercent / 100)
+		hi := int64(float64(balance) * maxPercent / 100)
+		if lo >= minAmount && hi <= maxAmount && (amount < lo || amount > hi) {
+			t.Fatalf("Amount %d outside percentage range [%d, %d] for balance %d", amount, lo, hi, balance)
+		}
+	})
+}
+
+// TestScaledAmountMonotonicProperty tests that richer targets never lose less at the same percentage.
+// *For any* balances a <= b with a fixed percentage: Amount(a) <= Amount(b)
+func TestScaledAmountMonotonicProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		percent := rapid.Float64Range(0.1, 10).Draw(t, "percent")
+		a := rapid.Int64Range(0, 10_000_000).Draw(t, "a")
+		b := a + rapid.Int64Range(0, 10_000_000).Draw(t, "extra")
+
+		policy := ScaledAmountPolicy{MinPercent: percent, MaxPercent: percent, MinAmount: 10, MaxAmount: 5000}
+		if policy.Amount(a) > policy.Amount(b) {
+			t.Fatalf("Amount(%d)=%d > Amount(%d)=%d", a, policy.Amount(a), b, policy.Amount(b))
+		}
+	})
+}
+
+// TestNewAmountPolicy tests mode selection.
+func TestNewAmountPolicy(t *testing.T) {
+	if _, ok := NewAmountPolicy(AmountModeFixed, 0.5, 3, 10, 5000).(FixedAmountPolicy); !ok {
+		t.Error("fixed mode should return FixedAmountPolicy")
+	}
+	if _, ok := NewAmountPolicy("", 0.5, 3, 10, 5000).(FixedAmountPolicy); !ok {
+		t.Error("empty mode should fall back to FixedAmountPolicy")
+	}
+
+	policy, ok := NewAmountPolicy(AmountModeScaled, 0.5, 3, 10, 5000).(ScaledAmountPolicy)
+	if !ok {
+		t.Fatal("scaled mode should return ScaledAmountPolicy")
+	}
+	// Millionaire: 0.5%-3% of 1,000,000 is 5000-30000, capped at 5000
+	if amount := policy.Amount(1_000_000); amount != 5000 {
+		t.Errorf("Amount(1000000) = %d, want 5000", amount)
+	}
+	// New player: 0.5%-3% of 100 is 0-3, raised to 10
+	if amount := policy.Amount(100); amount != 10 {
+		t.Errorf("Amount(100) = %d, want 10", amount)
+	}
+}
@@ -0,0 +1,75 @@
+package rob
+
+import (
+	"math/rand"
+)
+
+// Counter-attack item modifiers
+const (
+	ThornArmorCounterBonus         = 10  // Victim's thorn armor: +10% counter-attack chance
+	ThornArmorCounterDamagePercent = 150 // Victim's thorn armor: counter-attacks deal 150% damage
+	GreatSwordCounterPenalty       = 10  // Robber's great sword: -10% counter-attack chance
+)
+
+// CounterEffects are the items that influence a counter-attack
+type CounterEffects struct {
+	VictimThornArmor bool
+	RobberGreatSword bool
+	RobberBluntKnife bool
+}
+
+// CounterAttackModifiers returns the counter-attack chance delta (percentage points)
+// and damage percentage for the given items. Effects apply in this priority order:
+//  1. Bypass weapons (blunt knife, great sword) ignore the victim's thorn armor,
+//     the same as on a successful robbery
+//  2. Thorn armor on the victim adds ThornArmorCounterBonus and raises damage to
+//     ThornArmorCounterDamagePercent
+//  3. A great sword on the robber subtracts GreatSwordCounterPenalty
+//
+// Items are not consumed by counter-attacks.
+func CounterAttackModifiers(e CounterEffects) (chanceDelta int, damagePercent int64) {
+	damagePercent = 100
+
+	bypass := e.RobberBluntKnife || e.RobberGreatSword
+	if e.VictimThornArmor && !bypass {
+		chanceDelta += ThornArmorCounterBonus
+		damagePercent = ThornArmorCounterDamagePercent
+	}
+	if e.RobberGreatSword {
+		chanceDelta -= GreatSwordCounterPenalty
+	}
+
+	return chanceDelta, damagePercent
+}
+
+// CalculateCounterAttackChance returns the counter-attack chance for a success rate and chance delta.
+// Without modifiers, the chance left after success is split 40/60 between fail and counter-attack;
+// the delta moves chance between fail and counter-attack and never touches the success rate.
+func CalculateCounterAttackChance(successRate, chanceDelta int) int {
+	remaining := 100 - successRate
+	chance := remaining - remaining*40/100 + chanceDelta
+	if chance < 0 {
+		return 0
+	}
+	if chance > remaining {
+		return remaining
+	}
+	return chance
+}
+
+// DetermineOutcomeWithModifiers determines the outcome with a custom success rate and counter-attack delta
+func DetermineOutcomeWithModifiers(successRate, chanceDelta int) RobOutcome {
+	roll := rand.Intn(100) // 0-99
+	if roll < successRate {
+		return OutcomeSuccess
+	}
+	if roll < 100-CalculateCounterAttackChance(successRate, chanceDelta) {
+		return OutcomeFail
+	}
+	return OutcomeCounterAttack
+}
+
+// ApplyCounterDamage scales a counter-attack amount by damagePercent
+func ApplyCounterDamage(amount, damagePercent int64) int64 {
+	return amount * damagePercent / 100
+}
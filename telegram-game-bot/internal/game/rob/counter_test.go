@@ -0,0 +1,80 @@
+package rob
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestCounterAttackModifiers tests the item priority order for counter-attacks.
+func TestCounterAttackModifiers(t *testing.T) {
+	tests := []struct {
+		name          string
+		effects       CounterEffects
+		wantDelta     int
+		wantDamagePct int64
+	}{
+		{"no items", CounterEffects{}, 0, 100},
+		{"thorn armor", CounterEffects{VictimThornArmor: true}, ThornArmorCounterBonus, ThornArmorCounterDamagePercent},
+		{"great sword", CounterEffects{RobberGreatSword: true}, -GreatSwordCounterPenalty, 100},
+		{"blunt knife", CounterEffects{RobberBluntKnife: true}, 0, 100},
+		{"thorn armor vs great sword", CounterEffects{VictimThornArmor: true, RobberGreatSword: true}, -GreatSwordCounterPenalty, 100},
+		{"thorn armor vs blunt knife", CounterEffects{VictimThornArmor: true, RobberBluntKnife: true}, 0, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, damagePct := CounterAttackModifiers(tt.effects)
+			if delta != tt.wantDelta || damagePct != tt.wantDamagePct {
+				t.Errorf("CounterAttackModifiers(%+v) = (%d, %d), want (%d, %d)",
+					tt.effects, delta, damagePct, tt.wantDelta, tt.wantDamagePct)
+			}
+		})
+	}
+}
+
+// TestCounterAttackChanceProperty tests counter-attack chance bounds.
+// *For any* success rate S and delta D: the counter chance lies in [0, 100-S],
+// equals the default 60% split for D = 0, and never decreases as D grows.
+func TestCounterAttackChanceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		successRate := rapid.IntRange(0, 100).Draw(t, "successRate")
+		delta := rapid.IntRange(-100, 100).Draw(t, "delta")
+
+		chance := CalculateCounterAttackChance(successRate, delta)
+		remaining := 100 - successRate
+		if chance < 0 || chance > remaining {
+			t.Fatalf("Counter chance %d outside [0, %d]", chance, remaining)
+		}
+		if CalculateCounterAttackChance(successRate, 0) != remaining-remaining*40/100 {
+			t.Fatalf("Default counter chance changed for success rate %d", successRate)
+		}
+		if CalculateCounterAttackChance(successRate, delta+1) < chance {
+			t.Fatalf("Counter chance should not decrease with a larger delta")
+		}
+	})
+}
+
+// TestDefaultCounterAttackChance tests that the default rates are unchanged.
+func TestDefaultCounterAttackChance(t *testing.T) {
+	if got := CalculateCounterAttackChance(SuccessChance, 0); got != CounterAttackChance {
+		t.Errorf("Default counter chance = %d, want %d", got, CounterAttackChance)
+	}
+	if got := CalculateCounterAttackChance(SuccessChance, ThornArmorCounterBonus); got != CounterAttackChance+ThornArmorCounterBonus {
+		t.Errorf("Thorn armor counter chance = %d, want %d", got, CounterAttackChance+ThornArmorCounterBonus)
+	}
+}
+
+// TestCounterDamageProperty tests that thorn armor raises counter damage and no items keep it.
+func TestCounterDamageProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		amount := rapid.Int64Range(0, 1000000).Draw(t, "amount")
+
+		if ApplyCounterDamage(amount, 100) != amount {
+			t.Fatalf("100%% damage should keep amount %d", amount)
+		}
+		if boosted := ApplyCounterDamage(amount, ThornArmorCounterDamagePercent); boosted < amount {
+			t.Fatalf("Thorn armor damage %d is less than base %d", boosted, amount)
+		}
+	})
+}
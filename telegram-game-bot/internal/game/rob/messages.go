@@ -0,0 +1,67 @@
+package rob
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/msgtmpl"
+	"telegram-game-bot/internal/shop"
+)
+
+// Outcomes of the message pool
+const (
+	messageFail     = model.RobMessageFail
+	messageCounter  = model.RobMessageCounter
+	messageSuccess  = model.RobMessageSuccess
+	messageCritical = model.RobMessageCritical
+)
+
+// MessagePool supplies flavor texts replacing the first line of robbery
+// results. Lines reporting item effects, insurance and protection are still
+// appended after it.
+type MessagePool interface {
+	// Message returns a text of an outcome (model.RobMessageFail etc.) with
+	// vars filled in, false to keep the built-in text
+	Message(ctx context.Context, outcome string, vars msgtmpl.Vars) (string, bool)
+}
+
+// SetMessagePool enables flavor texts of robbery results
+func (g *RobGame) SetMessagePool(pool MessagePool) {
+	g.messages = pool
+}
+
+// message returns the first line of a result: a text of the message pool
+// for the outcome if it has one, else fallback. item is the item that shaped
+// the outcome, empty if none; it is added to texts that do not mention it,
+// so players always see which item was used.
+func (g *RobGame) message(ctx context.Context, outcome, robberRef, victimRef string, amount int64, item shop.ItemType, fallback string) string {
+	if g.messages == nil {
+		return fallback
+	}
+	label := itemLabel(item)
+	vars := msgtmpl.Vars{
+		"robber": robberRef,
+		"victim": victimRef,
+		"amount": strconv.FormatInt(amount, 10),
+		"item":   label,
+	}
+	msg, ok := g.messages.Message(ctx, outcome, vars)
+	if !ok {
+		return fallback
+	}
+	if label != "" && !strings.Contains(msg, label) {
+		msg += "（" + label + "）"
+	}
+	return msg
+}
+
+// itemLabel names an item with its emoji, "" for no item
+func itemLabel(itemType shop.ItemType) string {
+	item, ok := shop.GetItem(itemType)
+	if !ok {
+		return ""
+	}
+	return item.Emoji + item.Name
+}
@@ -0,0 +1,53 @@
+package rob
+
+import (
+	"context"
+	"testing"
+
+	"telegram-game-bot/internal/pkg/msgtmpl"
+	"telegram-game-bot/internal/shop"
+)
+
+// fakeMessagePool renders one template per outcome
+type fakeMessagePool map[string]string
+
+func (p fakeMessagePool) Message(_ context.Context, outcome string, vars msgtmpl.Vars) (string, bool) {
+	tmpl, ok := p[outcome]
+	if !ok {
+		return "", false
+	}
+	return msgtmpl.Render(tmpl, vars), true
+}
+
+// TestMessage tests that pool texts replace the built-in first line, and
+// that the item used is named even by texts that do not mention it.
+func TestMessage(t *testing.T) {
+	g := &RobGame{}
+	ctx := context.Background()
+	if got := g.message(ctx, messageSuccess, "a", "b", 5, "", "built-in"); got != "built-in" {
+		t.Errorf("Without a pool got %q", got)
+	}
+
+	g.SetMessagePool(fakeMessagePool{
+		messageSuccess:  "{robber} 抢了 {victim} {amount}",
+		messageCritical: "{robber} 挥舞{item}",
+	})
+	tests := []struct {
+		name    string
+		outcome string
+		item    shop.ItemType
+		want    string
+	}{
+		{"no item", messageSuccess, "", "a 抢了 b 5"},
+		{"item not mentioned", messageSuccess, shop.ItemBluntKnife, "a 抢了 b 5（" + itemLabel(shop.ItemBluntKnife) + "）"},
+		{"item mentioned", messageCritical, shop.ItemGreatSword, "a 挥舞" + itemLabel(shop.ItemGreatSword)},
+		{"outcome without texts", messageFail, "", "built-in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.message(ctx, tt.outcome, "a", "b", 5, tt.item, "built-in"); got != tt.want {
+				t.Errorf("message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package rob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// Errors for protection extensions
+var (
+	ErrExtendDisabled      = errors.New("保护期续期未开放")
+	ErrNotProtected        = errors.New("你当前不在保护期，只能在保护期结束前续期")
+	ErrProtectionCapped    = errors.New("保护期剩余时间已达上限")
+	ErrInsufficientBalance = errors.New("余额不足")
+)
+
+// Errors for anti-targeting caps
+var (
+	ErrVictimHourlyCap = errors.New("目标最近1小时被打劫次数过多，请稍后再来")
+	ErrPairDailyCap    = errors.New("你24小时内打劫该目标次数已达上限，换个目标吧")
+)
+
+// ProtectionConfig configures new-user grace, paid protection extensions and anti-targeting caps.
+type ProtectionConfig struct {
+	NewUserGrace   time.Duration // Accounts younger than this cannot be robbed (0 = disabled)
+	ExtendCost     int64         // Coins per extension (0 = disabled)
+	ExtendDuration time.Duration // Protection added per extension
+	MaxRemaining   time.Duration // Cap on remaining protection after extending (0 = no cap)
+
+	VictimHourlyCap int // Successful robs of one victim per hour across all robbers (0 = no cap)
+	PairDailyCap    int // Successful robs of one victim by one robber per 24 hours (0 = no cap)
+}
+
+// SetProtectionRepository sets the repository persisting protection state
+func (g *RobGame) SetProtectionRepository(repo *repository.RobProtectionRepository) {
+	g.protectionRepo = repo
+}
+
+// SetHitRepository sets the repository counting successful robberies for the anti-targeting caps
+func (g *RobGame) SetHitRepository(repo *repository.RobHitRepository) {
+	g.hitRepo = repo
+}
+
+// SetPvPRepository sets the repository of PvP opt-outs; opted-out users can
+// neither rob nor be robbed
+func (g *RobGame) SetPvPRepository(repo *repository.PvPRepository) {
+	g.pvpRepo = repo
+}
+
+// checkPvPOptOut returns why a robbery between the users is refused because
+// of a PvP opt-out, "" if neither opted out
+func (g *RobGame) checkPvPOptOut(ctx context.Context, robberID, victimID int64) string {
+	if g.pvpRepo == nil {
+		return ""
+	}
+	robberOut, err := g.pvpRepo.IsOptedOut(ctx, robberID)
+	if err != nil {
+		return "系统繁忙，请稍后重试"
+	}
+	if robberOut {
+		return "🕊️ 你已退出 PvP，无法打劫（发送 /pvp on 重新加入）"
+	}
+	victimOut, err := g.pvpRepo.IsOptedOut(ctx, victimID)
+	if err != nil {
+		return "系统繁忙，请稍后重试"
+	}
+	if victimOut {
+		return "🕊️ 目标已退出 PvP，无法被打劫"
+	}
+	return ""
+}
+
+// SetProtectionConfig sets the new-user grace, extension and anti-targeting settings
+func (g *RobGame) SetProtectionConfig(cfg ProtectionConfig) {
+	g.protectionCfg = cfg
+}
+
+// ProtectionConfig returns the new-user grace, extension and anti-targeting settings
+func (g *RobGame) ProtectionConfig() ProtectionConfig {
+	return g.protectionCfg
+}
+
+// LoadProtections restores persisted protection state (called on startup)
+func (g *RobGame) LoadProtections(ctx context.Context) error {
+	if g.protectionRepo == nil {
+		return nil
+	}
+
+	protections, err := g.protectionRepo.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, p := range protections {
+		g.protection[p.UserID] = &ProtectionState{
+			ConsecutiveCount: p.ConsecutiveCount,
+			ProtectedUntil:   p.ProtectedUntil,
+		}
+	}
+	return nil
+}
+
+// saveProtection persists a protection state.
+// Failures are ignored: the in-memory state stays authoritative until restart.
+func (g *RobGame) saveProtection(ctx context.Context, userID int64, state ProtectionState) {
+	if g.protectionRepo == nil {
+		return
+	}
+	_ = g.protectionRepo.Save(ctx, userID, state.ConsecutiveCount, state.ProtectedUntil)
+}
+
+// checkTargetingCaps checks the anti-targeting caps against the persisted hit counters.
+// Counter lookups that fail are let through rather than blocking every robbery.
+func (g *RobGame) checkTargetingCaps(ctx context.Context, robberID, victimID int64) error {
+	cfg := g.protectionCfg
+	if g.hitRepo == nil || (cfg.VictimHourlyCap <= 0 && cfg.PairDailyCap <= 0) {
+		return nil
+	}
+
+	victimHourly, pairDaily, err := g.hitRepo.CountRecent(ctx, robberID, victimID)
+	if err != nil {
+		return nil
+	}
+	return CheckTargetingCaps(victimHourly, pairDaily, cfg.VictimHourlyCap, cfg.PairDailyCap)
+}
+
+// recordHit counts a successful robbery towards the anti-targeting caps
+func (g *RobGame) recordHit(ctx context.Context, robberID, victimID int64) {
+	if g.hitRepo == nil {
+		return
+	}
+	_ = g.hitRepo.Record(ctx, robberID, victimID)
+}
+
+// CheckTargetingCaps returns an error when another robbery would exceed a cap (0 = no cap)
+func CheckTargetingCaps(victimHourly, pairDaily, victimHourlyCap, pairDailyCap int) error {
+	if pairDailyCap > 0 && pairDaily >= pairDailyCap {
+		return ErrPairDailyCap
+	}
+	if victimHourlyCap > 0 && victimHourly >= victimHourlyCap {
+		return ErrVictimHourlyCap
+	}
+	return nil
+}
+
+// GraceRemaining returns the remaining new-user grace for an account created at createdAt
+func (g *RobGame) GraceRemaining(createdAt time.Time) time.Duration {
+	return CalculateGraceRemaining(createdAt, g.protectionCfg.NewUserGrace, time.Now())
+}
+
+// CalculateGraceRemaining returns how long an account created at createdAt stays immune at now
+func CalculateGraceRemaining(createdAt time.Time, grace time.Duration, now time.Time) time.Duration {
+	if grace <= 0 {
+		return 0
+	}
+	remaining := createdAt.Add(grace).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// CalculateExtendedUntil returns the new protection end after one extension.
+// Only a running protection can be extended, and the result may not exceed maxRemaining from now.
+func CalculateExtendedUntil(protectedUntil time.Time, extend, maxRemaining time.Duration, now time.Time) (time.Time, error) {
+	if !now.Before(protectedUntil) {
+		return time.Time{}, ErrNotProtected
+	}
+	until := protectedUntil.Add(extend)
+	if maxRemaining > 0 && until.Sub(now) > maxRemaining {
+		return time.Time{}, ErrProtectionCapped
+	}
+	return until, nil
+}
+
+// ExtendProtection extends a running protection by paying ExtendCost coins.
+// Returns the new protection end.
+func (g *RobGame) ExtendProtection(ctx context.Context, userID int64) (time.Time, error) {
+	cfg := g.protectionCfg
+	if cfg.ExtendCost <= 0 || cfg.ExtendDuration <= 0 {
+		return time.Time{}, ErrExtendDisabled
+	}
+
+	g.userLock.Lock(userID)
+	defer g.userLock.Unlock(userID)
+
+	// Validate before charging
+	g.mu.RLock()
+	var protectedUntil time.Time
+	if state, ok := g.protection[userID]; ok {
+		protectedUntil = state.ProtectedUntil
+	}
+	g.mu.RUnlock()
+	if _, err := CalculateExtendedUntil(protectedUntil, cfg.ExtendDuration, cfg.MaxRemaining, time.Now()); err != nil {
+		return time.Time{}, err
+	}
+
+	user, err := g.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Balance < cfg.ExtendCost {
+		return time.Time{}, ErrInsufficientBalance
+	}
+
+	if _, err := g.userRepo.UpdateBalance(ctx, userID, -cfg.ExtendCost); err != nil {
+		return time.Time{}, fmt.Errorf("failed to charge protection extension: %w", err)
+	}
+	desc := fmt.Sprintf("延长打劫保护 %d 分钟", int(cfg.ExtendDuration.Minutes()))
+	g.txRepo.Create(ctx, userID, -cfg.ExtendCost, TxTypeRobProtect, &desc)
+
+	// Apply the extension; if protection lapsed while charging, start from now
+	g.mu.Lock()
+	state, ok := g.protection[userID]
+	if !ok {
+		state = &ProtectionState{}
+		g.protection[userID] = state
+	}
+	start := state.ProtectedUntil
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	state.ProtectedUntil = start.Add(cfg.ExtendDuration)
+	snapshot := *state
+	g.mu.Unlock()
+
+	g.saveProtection(ctx, userID, snapshot)
+	return snapshot.ProtectedUntil, nil
+}
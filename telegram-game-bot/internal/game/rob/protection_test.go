@@ -0,0 +1,95 @@
+package rob
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestGraceRemainingProperty tests new-user grace.
+// *For any* account age A and grace G: the account is immune iff A < G,
+// and the remaining grace is exactly G - A.
+func TestGraceRemainingProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(1_700_000_000, 0)
+		age := time.Duration(rapid.Int64Range(0, 72*3600).Draw(t, "ageSecs")) * time.Second
+		grace := time.Duration(rapid.Int64Range(0, 48*3600).Draw(t, "graceSecs")) * time.Second
+
+		remaining := CalculateGraceRemaining(now.Add(-age), grace, now)
+
+		if age < grace {
+			if remaining != grace-age {
+				t.Fatalf("Age %v with grace %v: remaining %v, expected %v", age, grace, remaining, grace-age)
+			}
+		} else if remaining != 0 {
+			t.Fatalf("Age %v with grace %v should have no grace left, got %v", age, grace, remaining)
+		}
+	})
+}
+
+// TestExtendProtectionProperty tests protection extension rules.
+// *For any* protection end P, extension E and cap C:
+// - expired protection cannot be extended
+// - a running protection ends at P + E unless that exceeds C from now
+func TestExtendProtectionProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(1_700_000_000, 0)
+		offset := time.Duration(rapid.Int64Range(-3600, 3*3600).Draw(t, "offsetSecs")) * time.Second
+		extend := time.Duration(rapid.IntRange(1, 120).Draw(t, "extendMins")) * time.Minute
+		maxRemaining := time.Duration(rapid.IntRange(0, 240).Draw(t, "maxMins")) * time.Minute
+		protectedUntil := now.Add(offset)
+
+		until, err := CalculateExtendedUntil(protectedUntil, extend, maxRemaining, now)
+
+		switch {
+		case offset <= 0:
+			if !errors.Is(err, ErrNotProtected) {
+				t.Fatalf("Expired protection (offset %v) should not be extendable, got %v", offset, err)
+			}
+		case maxRemaining > 0 && offset+extend > maxRemaining:
+			if !errors.Is(err, ErrProtectionCapped) {
+				t.Fatalf("Extension to %v exceeds cap %v, got %v", offset+extend, maxRemaining, err)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !until.Equal(protectedUntil.Add(extend)) {
+				t.Fatalf("Protection ends at %v, expected %v", until, protectedUntil.Add(extend))
+			}
+		}
+	})
+}
+
+// TestTargetingCapsProperty tests the anti-targeting caps.
+// *For any* hit counts and caps: a robbery is allowed iff neither enabled cap is reached,
+// and a disabled cap (0) never blocks.
+func TestTargetingCapsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		victimHourly := rapid.IntRange(0, 20).Draw(t, "victimHourly")
+		pairDaily := rapid.IntRange(0, 10).Draw(t, "pairDaily")
+		victimHourlyCap := rapid.IntRange(0, 10).Draw(t, "victimHourlyCap")
+		pairDailyCap := rapid.IntRange(0, 5).Draw(t, "pairDailyCap")
+
+		err := CheckTargetingCaps(victimHourly, pairDaily, victimHourlyCap, pairDailyCap)
+
+		pairBlocked := pairDailyCap > 0 && pairDaily >= pairDailyCap
+		victimBlocked := victimHourlyCap > 0 && victimHourly >= victimHourlyCap
+		switch {
+		case pairBlocked:
+			if !errors.Is(err, ErrPairDailyCap) {
+				t.Fatalf("Pair %d/%d should be blocked, got %v", pairDaily, pairDailyCap, err)
+			}
+		case victimBlocked:
+			if !errors.Is(err, ErrVictimHourlyCap) {
+				t.Fatalf("Victim %d/%d should be blocked, got %v", victimHourly, victimHourlyCap, err)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("Robbery should be allowed, got %v", err)
+			}
+		}
+	})
+}
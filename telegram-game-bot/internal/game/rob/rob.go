@@ -10,8 +10,11 @@ import (
 	"sync"
 	"time"
 
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
 	"telegram-game-bot/internal/pkg/lock"
 	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
 )
 
 // Constants for rob game configuration
@@ -73,8 +76,35 @@ type ItemEffectChecker interface {
 	// This is triggered by Golden Cassock effect
 	// Requirements: 8.4 - Remove attacker's defensive items
 	RemoveDefensiveItems(ctx context.Context, userID int64) error
-	// DecrementUseCountByString decreases the use count of an item by 1
-	DecrementUseCountByString(ctx context.Context, userID int64, effectType string) error
+	// UseItem consumes one use of an item and returns the uses left.
+	// consumed is false if nothing was consumed, e.g. for duration based items.
+	UseItem(ctx context.Context, userID int64, effectType string) (remaining int, consumed bool)
+}
+
+// ItemThief moves item uses between players
+type ItemThief interface {
+	// StealRandomItem moves one use of a random item (never emperor clothes)
+	// from the victim to the robber. ok is false if there was nothing to steal.
+	StealRandomItem(ctx context.Context, victimID, robberID int64) (itemType shop.ItemType, ok bool)
+}
+
+// RobHook is notified of robberies, e.g. by events that score successful robs.
+// Hooks run while the robber and victim are locked and must not lock users.
+type RobHook interface {
+	// OnRobSuccess is called after a successful robbery
+	OnRobSuccess(ctx context.Context, robberID, victimID, amount int64)
+}
+
+// Insurance runs the pool funded by a cut of successful robberies that
+// compensates victims left below a balance floor. Like hooks it runs while
+// the robber and victim are locked and must not lock users.
+type Insurance interface {
+	// Collect takes the pool's cut of a robbery of amount from the robber,
+	// who holds balance, and returns it
+	Collect(ctx context.Context, robberID, amount, balance int64) int64
+	// Compensate pays a victim robbed of loss and left with balance from
+	// the pool and returns the compensation
+	Compensate(ctx context.Context, victimID, loss, balance int64) int64
 }
 
 // RobOutcome represents the outcome type of a robbery attempt
@@ -88,9 +118,10 @@ const (
 
 // Transaction types for robbery
 const (
-	TxTypeRob           = "rob"           // Robber gains coins
-	TxTypeRobbed        = "robbed"        // Victim loses coins
-	TxTypeCounterAttack = "counterattack" // Counter-attack (robber loses coins)
+	TxTypeRob           = model.TxTypeRob           // Robber gains coins
+	TxTypeRobbed        = model.TxTypeRobbed        // Victim loses coins
+	TxTypeCounterAttack = model.TxTypeCounterAttack // Counter-attack (robber loses coins)
+	TxTypeRobProtect    = model.TxTypeRobProtect    // Paid protection extension
 )
 
 // Errors for rob game
@@ -117,6 +148,17 @@ type RobResult struct {
 	VictimName  string
 	NewBalance  int64  // Robber's new balance
 	Message     string // Result message
+	Critical    bool   // Great sword critical hit
+	StolenItem  shop.ItemType // Item a use of which the critical hit stole, empty if none
+	InsuranceCut  int64 // Paid by the robber into the insurance pool
+	InsurancePaid int64 // Paid to the victim from the insurance pool
+	ItemsUsed   []shop.ItemUse // Items of either player consumed, with the uses left
+}
+
+// Attempted reports whether the robbery was carried out, as opposed to being
+// rejected before the robber's cooldown started.
+func (r *RobResult) Attempted() bool {
+	return r.Success || r.Outcome != OutcomeSuccess
 }
 
 // RobGame manages the robbery game logic
@@ -125,6 +167,17 @@ type RobGame struct {
 	txRepo      *repository.TransactionRepository
 	userLock    *lock.UserLock
 	itemChecker ItemEffectChecker // Optional: for shop item effects
+	itemThief   ItemThief         // Optional: great sword criticals also steal an item use
+	amounts     AmountPolicy      // Decides regular robbery amounts
+	hooks       []RobHook         // Optional: notified of successful robberies
+	insurance   Insurance         // Optional: insurance pool funded by successful robberies
+	messages    MessagePool       // Optional: flavor texts of results
+
+	// Optional: persisted protection state, new-user grace and paid extensions
+	protectionRepo *repository.RobProtectionRepository
+	hitRepo        *repository.RobHitRepository
+	pvpRepo        *repository.PvPRepository // Optional: users who opted out of PvP
+	protectionCfg  ProtectionConfig
 
 	// In-memory state (resets on restart)
 	protection map[int64]*ProtectionState // victim_id -> state
@@ -142,6 +195,7 @@ func NewRobGame(
 		userRepo:   userRepo,
 		txRepo:     txRepo,
 		userLock:   userLock,
+		amounts:    FixedAmountPolicy{},
 		protection: make(map[int64]*ProtectionState),
 		cooldowns:  make(map[int64]time.Time),
 	}
@@ -152,6 +206,29 @@ func (g *RobGame) SetItemChecker(checker ItemEffectChecker) {
 	g.itemChecker = checker
 }
 
+// SetItemThief enables stealing an item use on great sword critical hits
+func (g *RobGame) SetItemThief(thief ItemThief) {
+	g.itemThief = thief
+}
+
+// AddHook registers a hook notified of successful robberies
+func (g *RobGame) AddHook(hook RobHook) {
+	g.hooks = append(g.hooks, hook)
+}
+
+// SetInsurance enables the insurance pool funded by a cut of successful robberies
+func (g *RobGame) SetInsurance(insurance Insurance) {
+	g.insurance = insurance
+}
+
+// SetAmountPolicy sets the policy for regular robbery amounts (defaults to FixedAmountPolicy)
+func (g *RobGame) SetAmountPolicy(policy AmountPolicy) {
+	if policy == nil {
+		policy = FixedAmountPolicy{}
+	}
+	g.amounts = policy
+}
+
 // GenerateAmount generates a random robbery amount between MinRobAmount and MaxRobAmount
 func GenerateAmount() int64 {
 	return int64(rand.Intn(MaxRobAmount-MinRobAmount+1) + MinRobAmount)
@@ -182,19 +259,10 @@ func DetermineOutcome() RobOutcome {
 }
 
 // DetermineOutcomeWithRate determines outcome with custom success rate
+// Remaining chance is split between fail and counter-attack in the same ratio:
+// fail 20%, counter 30% -> fail 40%, counter 60% of remaining
 func DetermineOutcomeWithRate(successRate int) RobOutcome {
-	roll := rand.Intn(100) // 0-99
-	if roll < successRate {
-		return OutcomeSuccess
-	}
-	// Distribute remaining chance between fail and counter-attack
-	// Keep same ratio: fail 20%, counter 30% -> fail 40%, counter 60% of remaining
-	remaining := 100 - successRate
-	failThreshold := successRate + (remaining * 40 / 100)
-	if roll < failThreshold {
-		return OutcomeFail
-	}
-	return OutcomeCounterAttack
+	return DetermineOutcomeWithModifiers(successRate, 0)
 }
 
 // GetCooldown returns the remaining cooldown time for a robber
@@ -236,21 +304,43 @@ func (g *RobGame) IsProtected(userID int64) (bool, time.Duration) {
 // CanRob checks if a robbery can be performed
 // Returns (canRob, errorMessage)
 func (g *RobGame) CanRob(ctx context.Context, robberID, victimID int64) (bool, string) {
+	return g.canRob(ctx, robberID, victimID, nil)
+}
+
+// useItem consumes one use of a user's item and records the uses left in used (nil discards them)
+func (g *RobGame) useItem(ctx context.Context, userID int64, itemType shop.ItemType, used *[]shop.ItemUse) {
+	remaining, consumed := g.itemChecker.UseItem(ctx, userID, string(itemType))
+	if consumed && used != nil {
+		*used = append(*used, shop.ItemUse{UserID: userID, Type: itemType, Remaining: remaining})
+	}
+}
+
+// canRob implements CanRob, recording the defensive items consumed in used
+func (g *RobGame) canRob(ctx context.Context, robberID, victimID int64, used *[]shop.ItemUse) (bool, string) {
 	// Check self-robbery
 	if robberID == victimID {
 		return false, "不能打劫自己"
 	}
 
 	// Check if victim exists
-	exists, err := g.userRepo.Exists(ctx, victimID)
-	if err != nil || !exists {
+	victim, err := g.userRepo.GetByID(ctx, victimID)
+	if err != nil {
 		return false, "目标用户未注册"
 	}
 
+	// Archived users left the game and are not robbed until they return
+	if archived, err := g.userRepo.IsArchived(ctx, victimID); err == nil && archived {
+		return false, "💤 目标用户已长期不活跃"
+	}
+
+	// Check PvP opt-outs
+	if msg := g.checkPvPOptOut(ctx, robberID, victimID); msg != "" {
+		return false, msg
+	}
+
 	// Check cooldown
 	if remaining := g.GetCooldown(robberID); remaining > 0 {
-		secs := int(remaining.Seconds()) + 1
-		return false, fmt.Sprintf("打劫冷却中，请等待 %d 秒", secs)
+		return false, "打劫冷却中，请等待 " + cooldown.Format(remaining)
 	}
 
 	// Check protection
@@ -259,12 +349,23 @@ func (g *RobGame) CanRob(ctx context.Context, robberID, victimID int64) (bool, s
 		return false, fmt.Sprintf("目标用户在保护期，剩余 %d 分钟", mins)
 	}
 
+	// Check new-user grace
+	if remaining := g.GraceRemaining(victim.CreatedAt); remaining > 0 {
+		mins := int(remaining.Minutes()) + 1
+		return false, fmt.Sprintf("🐣 目标是新玩家，新手保护剩余 %d 分钟", mins)
+	}
+
+	// Check anti-targeting caps
+	if err := g.checkTargetingCaps(ctx, robberID, victimID); err != nil {
+		return false, "🚫 " + err.Error()
+	}
+
 	// Check shop item effects
 	if g.itemChecker != nil {
 		// Check if robber is handcuffed
 		if locked, remaining := g.itemChecker.IsHandcuffed(ctx, robberID); locked {
 			mins := int(remaining.Minutes()) + 1
-			return false, fmt.Sprintf("🔗 你被手铐锁定，无法打劫！剩余 %d 分钟", mins)
+			return false, fmt.Sprintf("🔗 你被手铐锁定，无法打劫！剩余 %d 分钟\n💪 发送 /struggle 可尝试挣脱", mins)
 		}
 
 		// Check if victim has Emperor Clothes (highest priority defense)
@@ -273,7 +374,7 @@ func (g *RobGame) CanRob(ctx context.Context, robberID, victimID int64) (bool, s
 		if g.itemChecker.HasEmperorClothes(ctx, victimID) {
 			// Decrement emperor clothes use count
 			// Requirements: 9.6 - Decrement use count by 1 on each use
-			g.itemChecker.DecrementUseCountByString(ctx, victimID, "emperor_clothes")
+			g.useItem(ctx, victimID, shop.ItemEmperorClothes, used)
 			return false, "👑 目标有皇帝的新衣，无法打劫"
 		}
 
@@ -283,7 +384,7 @@ func (g *RobGame) CanRob(ctx context.Context, robberID, victimID int64) (bool, s
 			// Remove attacker's defensive items (Shield, Thorn Armor)
 			g.itemChecker.RemoveDefensiveItems(ctx, robberID)
 			// Decrement golden cassock use count
-			g.itemChecker.DecrementUseCountByString(ctx, victimID, "golden_cassock")
+			g.useItem(ctx, victimID, shop.ItemGoldenCassock, used)
 		}
 
 		// Check if robber has blunt knife or great sword (bypasses shield and thorn armor)
@@ -298,7 +399,7 @@ func (g *RobGame) CanRob(ctx context.Context, robberID, victimID int64) (bool, s
 		if g.itemChecker.HasShield(ctx, victimID) && !hasBypassDefense {
 			// Decrement shield use count
 			// Requirements: 3.7 - Decrement use count by 1 on each use
-			g.itemChecker.DecrementUseCountByString(ctx, victimID, "shield")
+			g.useItem(ctx, victimID, shop.ItemShield, used)
 			return false, "🛡️ 目标有保护罩，无法打劫"
 		}
 	}
@@ -307,14 +408,26 @@ func (g *RobGame) CanRob(ctx context.Context, robberID, victimID int64) (bool, s
 }
 
 
-// Rob executes a robbery attempt
-func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName, victimName string) (*RobResult, error) {
+// NameFunc writes a player's name into result messages, e.g. as a mention.
+type NameFunc func(userID int64, name string) string
+
+// Rob executes a robbery attempt.
+// Result messages write the names with names (plain names if nil); the
+// recorded transactions always use the plain names.
+func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName, victimName string, names NameFunc) (*RobResult, error) {
+	robberRef, victimRef := robberName, victimName
+	if names != nil {
+		robberRef, victimRef = names(robberID, robberName), names(victimID, victimName)
+	}
+
 	// Validate robbery
-	canRob, errMsg := g.CanRob(ctx, robberID, victimID)
+	var used []shop.ItemUse
+	canRob, errMsg := g.canRob(ctx, robberID, victimID, &used)
 	if !canRob {
 		return &RobResult{
-			Success: false,
-			Message: errMsg,
+			Success:   false,
+			Message:   errMsg,
+			ItemsUsed: used,
 		}, nil
 	}
 
@@ -328,8 +441,9 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 	// Try to acquire first lock
 	if !g.userLock.TryLock(firstID) {
 		return &RobResult{
-			Success: false,
-			Message: "系统繁忙，请稍后重试",
+			Success:   false,
+			Message:   "系统繁忙，请稍后重试",
+			ItemsUsed: used,
 		}, nil
 	}
 	defer g.userLock.Unlock(firstID)
@@ -337,8 +451,9 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 	// Try to acquire second lock
 	if !g.userLock.TryLock(secondID) {
 		return &RobResult{
-			Success: false,
-			Message: "目标用户正在进行其他操作，请稍后重试",
+			Success:   false,
+			Message:   "目标用户正在进行其他操作，请稍后重试",
+			ItemsUsed: used,
 		}, nil
 	}
 	defer g.userLock.Unlock(secondID)
@@ -367,12 +482,25 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 		hasBloodthirst = true
 	}
 
-	// Determine outcome with appropriate success rate
-	outcome := DetermineOutcomeWithRate(successRate)
+	// Collect counter-attack item effects (see CounterAttackModifiers for the priority order)
+	var effects CounterEffects
+	if g.itemChecker != nil {
+		effects = CounterEffects{
+			VictimThornArmor: g.itemChecker.HasThornArmor(ctx, victimID),
+			RobberGreatSword: g.itemChecker.HasGreatSword(ctx, robberID),
+			RobberBluntKnife: g.itemChecker.HasBluntKnife(ctx, robberID),
+		}
+	}
+	counterDelta, counterDamagePercent := CounterAttackModifiers(effects)
+
+	// Determine outcome with appropriate success rate and counter-attack modifiers
+	outcome := DetermineOutcomeWithModifiers(successRate, counterDelta)
 
 	switch outcome {
 	case OutcomeFail:
 		// Robbery failed - no coins transferred
+		msg := g.message(ctx, messageFail, robberRef, victimRef, 0, "",
+			fmt.Sprintf("😅 %s 打劫 %s 失败了！空手而归...", robberRef, victimRef))
 		return &RobResult{
 			Success:    false,
 			Outcome:    OutcomeFail,
@@ -380,12 +508,13 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			RobberName: robberName,
 			VictimName: victimName,
 			NewBalance: robber.Balance,
-			Message:    fmt.Sprintf("😅 %s 打劫 %s 失败了！空手而归...", robberName, victimName),
+			Message:    msg,
+			ItemsUsed:  used,
 		}, nil
 
 	case OutcomeCounterAttack:
-		// Counter-attack - robber loses coins to victim
-		amount := GenerateAmount()
+		// Counter-attack - robber loses coins to victim, scaled on the robber's wealth
+		amount := ApplyCounterDamage(g.amounts.Amount(robber.Balance), counterDamagePercent)
 		// Cap at robber's balance (can't go negative)
 		if amount > robber.Balance {
 			amount = robber.Balance
@@ -399,7 +528,8 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 				RobberName: robberName,
 				VictimName: victimName,
 				NewBalance: robber.Balance,
-				Message:    fmt.Sprintf("⚔️ %s 被 %s 反击了！但你身无分文，逃过一劫...", robberName, victimName),
+				Message:    fmt.Sprintf("⚔️ %s 被 %s 反击了！但你身无分文，逃过一劫...", robberRef, victimRef),
+				ItemsUsed:  used,
 			}, nil
 		}
 
@@ -417,12 +547,20 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			return nil, fmt.Errorf("增加目标用户余额失败: %w", err)
 		}
 
-		// Record transactions
+		// Record the robber -> victim flow
 		counterDesc := fmt.Sprintf("打劫 %s 被反击损失 %d 金币", victimName, amount)
-		g.txRepo.Create(ctx, robberID, -amount, TxTypeCounterAttack, &counterDesc)
-
 		victimGainDesc := fmt.Sprintf("反击 %s 获得 %d 金币", robberName, amount)
-		g.txRepo.Create(ctx, victimID, amount, TxTypeRob, &victimGainDesc)
+		g.txRepo.CreateTransfer(ctx, robberID, victimID, amount, TxTypeCounterAttack, TxTypeRob, &counterDesc, &victimGainDesc)
+
+		var counterItem shop.ItemType
+		if counterDamagePercent > 100 {
+			counterItem = shop.ItemThornArmor
+		}
+		msg := g.message(ctx, messageCounter, robberRef, victimRef, amount, counterItem,
+			fmt.Sprintf("⚔️ %s 打劫 %s 被反击！损失 %d 金币！", robberRef, victimRef, amount))
+		if counterItem != "" {
+			msg += "\n🌵 荆棘刺甲加重了反击！"
+		}
 
 		return &RobResult{
 			Success:    false,
@@ -431,32 +569,30 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			RobberName: robberName,
 			VictimName: victimName,
 			NewBalance: newRobber.Balance,
-			Message:    fmt.Sprintf("⚔️ %s 打劫 %s 被反击！损失 %d 金币！", robberName, victimName, amount),
+			Message:    msg,
+			ItemsUsed:  used,
 		}, nil
 
 	default: // OutcomeSuccess
 		// Successful robbery
 		if victim.Balance <= 0 {
 			return &RobResult{
-				Success: false,
-				Outcome: OutcomeFail,
-				Message: "目标用户余额为0，无法打劫",
+				Success:   false,
+				Outcome:   OutcomeFail,
+				Message:   "目标用户余额为0，无法打劫",
+				ItemsUsed: used,
 			}, nil
 		}
 
 		// Check for blunt knife effect
 		// Requirements: 6.4, 6.5 - Blunt knife bypasses defense and limits amount to 1-100
-		hasBluntKnife := false
-		if g.itemChecker != nil && g.itemChecker.HasBluntKnife(ctx, robberID) {
-			hasBluntKnife = true
-		}
+		hasBluntKnife := effects.RobberBluntKnife
 
 		// Check for great sword effect
 		// Requirements: 7.5, 7.6 - Great sword bypasses defense and has 0.01% critical hit
-		hasGreatSword := false
+		hasGreatSword := effects.RobberGreatSword
 		isGreatSwordCritical := false
-		if g.itemChecker != nil && g.itemChecker.HasGreatSword(ctx, robberID) {
-			hasGreatSword = true
+		if hasGreatSword {
 			// Check for critical hit (0.01% chance)
 			isGreatSwordCritical = IsGreatSwordCritical()
 		}
@@ -472,7 +608,7 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			// Requirements: 7.6 - Great sword has 0.01% chance to rob 90% of target's coins
 			amount = CalculateGreatSwordCriticalAmount(victim.Balance)
 		} else {
-			amount = GenerateAmount()
+			amount = g.amounts.Amount(victim.Balance)
 		}
 		// Cap at victim's balance
 		if amount > victim.Balance {
@@ -480,7 +616,7 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 		}
 
 		// Transfer coins: deduct from victim
-		_, err = g.userRepo.UpdateBalance(ctx, victimID, -amount)
+		newVictim, err := g.userRepo.UpdateBalance(ctx, victimID, -amount)
 		if err != nil {
 			return nil, fmt.Errorf("扣除目标用户余额失败: %w", err)
 		}
@@ -493,12 +629,18 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			return nil, fmt.Errorf("增加打劫者余额失败: %w", err)
 		}
 
-		// Record transactions
+		// Record the victim -> robber flow
 		robDesc := fmt.Sprintf("打劫 %s 获得 %d 金币", victimName, amount)
-		g.txRepo.Create(ctx, robberID, amount, TxTypeRob, &robDesc)
-
 		robbedDesc := fmt.Sprintf("被 %s 打劫损失 %d 金币", robberName, amount)
-		g.txRepo.Create(ctx, victimID, -amount, TxTypeRobbed, &robbedDesc)
+		g.txRepo.CreateTransfer(ctx, victimID, robberID, amount, TxTypeRobbed, TxTypeRob, &robbedDesc, &robDesc)
+
+		// A great sword critical hit also takes one of the victim's item uses
+		var stolen shop.ItemType
+		if isGreatSwordCritical && g.itemThief != nil {
+			if itemType, ok := g.itemThief.StealRandomItem(ctx, victimID, robberID); ok {
+				stolen = itemType
+			}
+		}
 
 		// Check for thorn armor effect - attacker loses double coins
 		// Requirements: 6.4 - Blunt knife bypasses thorn armor
@@ -515,39 +657,49 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			}
 			if thornDamage > 0 {
 				// Deduct from robber
-				newRobber, err = g.userRepo.UpdateBalance(ctx, robberID, -thornDamage)
-				if err == nil {
+				if r, err := g.userRepo.UpdateBalance(ctx, robberID, -thornDamage); err == nil {
+					newRobber = r
 					// Add to victim
-					g.userRepo.UpdateBalance(ctx, victimID, thornDamage)
-					// Record transactions
+					if v, err := g.userRepo.UpdateBalance(ctx, victimID, thornDamage); err == nil {
+						newVictim = v
+					}
+					// Record the reflected robber -> victim flow
 					thornDesc := fmt.Sprintf("荆棘刺甲反伤 %d 金币", thornDamage)
-					g.txRepo.Create(ctx, robberID, -thornDamage, TxTypeRobbed, &thornDesc)
 					thornGainDesc := fmt.Sprintf("荆棘刺甲反伤获得 %d 金币", thornDamage)
-					g.txRepo.Create(ctx, victimID, thornDamage, TxTypeRob, &thornGainDesc)
+					g.txRepo.CreateTransfer(ctx, robberID, victimID, thornDamage, TxTypeRobbed, TxTypeRob, &thornDesc, &thornGainDesc)
 					thornArmorTriggered = true
 					// Decrement thorn armor use count
 					// Requirements: 4.5 - Decrement use count by 1 on each use
-					g.itemChecker.DecrementUseCountByString(ctx, victimID, "thorn_armor")
+					g.useItem(ctx, victimID, shop.ItemThornArmor, &used)
 				}
 			}
 		}
 
+		// A cut of the loot funds the insurance pool, which compensates
+		// victims left below the floor
+		var insuranceCut, insurancePaid int64
+		if g.insurance != nil {
+			insuranceCut = g.insurance.Collect(ctx, robberID, amount, newRobber.Balance)
+			newRobber.Balance -= insuranceCut
+			insurancePaid = g.insurance.Compensate(ctx, victimID, amount, newVictim.Balance)
+		}
+
 		// Decrement blunt knife use count after successful use
 		// Requirements: 6.5 - Decrement use count by 1 on each use
 		if hasBluntKnife && g.itemChecker != nil {
-			g.itemChecker.DecrementUseCountByString(ctx, robberID, "blunt_knife")
+			g.useItem(ctx, robberID, shop.ItemBluntKnife, &used)
 		}
 
 		// Decrement great sword use count after successful use
 		// Requirements: 7.6 - Decrement use count by 1 on each use
 		if hasGreatSword && g.itemChecker != nil {
-			g.itemChecker.DecrementUseCountByString(ctx, robberID, "great_sword")
+			g.useItem(ctx, robberID, shop.ItemGreatSword, &used)
 		}
 
 		// Decrement bloodthirst sword use count after successful use
 		// Requirements: 5.5 - Decrement use count by 1 on each use
 		if hasBloodthirst && g.itemChecker != nil {
-			g.itemChecker.DecrementUseCountByString(ctx, robberID, "bloodthirst")
+			g.useItem(ctx, robberID, shop.ItemBloodthirstSword, &used)
 		}
 
 		// Update victim's protection state
@@ -572,28 +724,49 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			state.ConsecutiveCount = 0 // Reset after protection activates
 			protectionActivated = true
 		}
+		snapshot := *state
 		g.mu.Unlock()
+		g.saveProtection(ctx, victimID, snapshot)
+		g.recordHit(ctx, robberID, victimID)
+		for _, hook := range g.hooks {
+			hook.OnRobSuccess(ctx, robberID, victimID, amount)
+		}
 
 		// Build result message
-		msg := fmt.Sprintf("🔫 %s 打劫了 %s，获得 %d 金币！", robberName, victimName, amount)
+		outcomeMsg, weapon := messageSuccess, shop.ItemType("")
+		msg := fmt.Sprintf("🔫 %s 打劫了 %s，获得 %d 金币！", robberRef, victimRef, amount)
 		if hasBluntKnife {
-			msg = fmt.Sprintf("🔪 %s 使用钝刀打劫了 %s，获得 %d 金币！", robberName, victimName, amount)
+			weapon = shop.ItemBluntKnife
+			msg = fmt.Sprintf("🔪 %s 使用钝刀打劫了 %s，获得 %d 金币！", robberRef, victimRef, amount)
 		} else if hasGreatSword {
+			weapon = shop.ItemGreatSword
 			if isGreatSwordCritical {
 				// Great sword critical hit message
 				// Requirements: 7.6 - Great sword has 0.01% chance to rob 90% of target's coins
-				msg = fmt.Sprintf("⚔️💥 %s 使用大宝剑打劫了 %s，触发暴击！获得 %d 金币（90%%）！", robberName, victimName, amount)
+				outcomeMsg = messageCritical
+				msg = fmt.Sprintf("⚔️💥 %s 使用大宝剑打劫了 %s，触发暴击！获得 %d 金币（90%%）！", robberRef, victimRef, amount)
 			} else {
-				msg = fmt.Sprintf("⚔️ %s 使用大宝剑打劫了 %s，获得 %d 金币！", robberName, victimName, amount)
+				msg = fmt.Sprintf("⚔️ %s 使用大宝剑打劫了 %s，获得 %d 金币！", robberRef, victimRef, amount)
 			}
 		} else if hasBloodthirst {
-			msg = fmt.Sprintf("🗡️ %s 使用饮血剑打劫了 %s，获得 %d 金币！", robberName, victimName, amount)
+			weapon = shop.ItemBloodthirstSword
+			msg = fmt.Sprintf("🗡️ %s 使用饮血剑打劫了 %s，获得 %d 金币！", robberRef, victimRef, amount)
+		}
+		msg = g.message(ctx, outcomeMsg, robberRef, victimRef, amount, weapon, msg)
+		if item, ok := shop.GetItem(stolen); ok {
+			msg += fmt.Sprintf("\n🎒 还顺走了 %s 的一次%s%s！", victimRef, item.Emoji, item.Name)
 		}
 		if thornArmorTriggered {
-			msg += fmt.Sprintf("\n🌵 荆棘刺甲反伤！%s 损失 %d 金币！", robberName, thornDamage)
+			msg += fmt.Sprintf("\n🌵 荆棘刺甲反伤！%s 损失 %d 金币！", robberRef, thornDamage)
+		}
+		if insuranceCut > 0 {
+			msg += fmt.Sprintf("\n🏦 %d 金币存入打劫保险池", insuranceCut)
+		}
+		if insurancePaid > 0 {
+			msg += fmt.Sprintf("\n☂️ 打劫保险池赔付 %s %d 金币", victimRef, insurancePaid)
 		}
 		if protectionActivated {
-			msg += fmt.Sprintf("\n🛡️ %s 触发保护期 %d 分钟", victimName, ProtectionDurationMin)
+			msg += fmt.Sprintf("\n🛡️ %s 触发保护期 %d 分钟", victimRef, ProtectionDurationMin)
 		}
 
 		return &RobResult{
@@ -604,6 +777,11 @@ func (g *RobGame) Rob(ctx context.Context, robberID, victimID int64, robberName,
 			VictimName: victimName,
 			NewBalance: newRobber.Balance,
 			Message:    msg,
+			Critical:   isGreatSwordCritical,
+			StolenItem: stolen,
+			ItemsUsed:  used,
+			InsuranceCut:  insuranceCut,
+			InsurancePaid: insurancePaid,
 		}, nil
 	}
 }
@@ -291,6 +291,11 @@ func (m *MockItemEffectChecker) DecrementUseCountByString(ctx context.Context, u
 	return nil
 }
 
+func (m *MockItemEffectChecker) UseItem(ctx context.Context, userID int64, effectType string) (int, bool) {
+	m.DecrementUseCountByString(ctx, userID, effectType)
+	return 0, true
+}
+
 // TestShieldProtectionEffectProperty tests that shield prevents robbery
 // Property 4: Shield Protection Effect
 // *For any* robbery attempt against a user with active shield, the robbery should fail with a protection message.
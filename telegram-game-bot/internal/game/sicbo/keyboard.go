@@ -191,7 +191,16 @@ func (kb *KeyboardBuilder) BuildMainPanelWithSettle() *tele.ReplyMarkup {
 		},
 	}
 
-	// Row 5: Early settle button [🎲 提前开奖]
+	// Row 5: Split the selected amount evenly [⚖️均分 大+4+5+6] [⚖️均分 小+1+2+3]
+	splitRow := make([]tele.InlineButton, 0, len(SplitPresets))
+	for _, preset := range SplitPresets {
+		splitRow = append(splitRow, tele.InlineButton{
+			Text: "⚖️均分 " + preset.Label,
+			Data: EncodeCallback("split", EncodeSplit(preset.BetTypes)),
+		})
+	}
+
+	// Row 6: Early settle button [🎲 提前开奖]
 	settleRow := []tele.InlineButton{
 		{
 			Text: "🎲 提前开奖",
@@ -204,6 +213,7 @@ func (kb *KeyboardBuilder) BuildMainPanelWithSettle() *tele.ReplyMarkup {
 		bigSmallRow,
 		singleRow1,
 		singleRow2,
+		splitRow,
 		settleRow,
 	}
 
@@ -222,22 +232,38 @@ func FormatPanelMessage(remainingTime int, playerCount int, totalBetAmount int64
 	msg += "  (单数出现概率: 42.1%)\n"
 	msg += "┄┄┄┄┄┄┄┄┄┄┄┄┄┄┄\n"
 	msg += "💡 先选择金额，再点击押注按钮\n"
-	msg += "💰 可选: 100 | 200 | 300 | 梭哈"
+	msg += "💰 可选: 100 | 200 | 300 | 梭哈\n"
+	msg += "⚖️ 均分: 所选金额平均押到多个选项\n"
+	msg += "💬 或回复本消息下注: 大 500 / 3 200"
 	return msg
 }
 
-// FormatSettlementMessage formats the settlement result message.
-func FormatSettlementMessage(dice [3]int, playerResults map[int64]PlayerResult, starterUsername string) string {
+// FormatVoidMessage formats the announcement of a round void for lack of players.
+func FormatVoidMessage(players, minPlayers int) string {
+	msg := "🚫 骰宝本局作废\n\n"
+	msg += fmt.Sprintf("👥 参与人数 %d，至少需要 %d 人\n", players, minPlayers)
+	if players > 0 {
+		msg += "💰 所有下注已退还"
+	} else {
+		msg += "😴 本局无人下注"
+	}
+	return msg
+}
+
+// NameFunc writes a player's name into a settlement message, e.g. as a mention.
+// The message is sent in the HTML parse mode, so names must be escaped.
+type NameFunc func(userID int64, name string) string
+
+// FormatSettlementMessage formats the settlement result message in the HTML
+// parse mode, writing player names with names.
+func FormatSettlementMessage(dice [3]int, playerResults map[int64]PlayerResult, starterID int64, starterUsername string, names NameFunc) string {
 	total := dice[0] + dice[1] + dice[2]
 	isTriple := IsTriple(dice)
 
 	// Header with starter info
 	msg := "🎰 骰宝开奖\n"
 	if starterUsername != "" {
-		if !strings.HasPrefix(starterUsername, "@") {
-			starterUsername = "@" + starterUsername
-		}
-		msg += fmt.Sprintf("🎯 发起者: %s\n", starterUsername)
+		msg += fmt.Sprintf("🎯 发起者: %s\n", names(starterID, starterUsername))
 	}
 	msg += "\n"
 	
@@ -277,28 +303,14 @@ func FormatSettlementMessage(dice [3]int, playerResults map[int64]PlayerResult,
 
 	// Show top winner
 	if hasWinner {
-		displayName := topWinner.Username
-		if displayName == "" {
-			displayName = fmt.Sprintf("%d", topWinner.UserID)
-		}
-		if !strings.HasPrefix(displayName, "@") {
-			displayName = "@" + displayName
-		}
-		msg += fmt.Sprintf("\n🏆 最大赢家 %s +%d\n", displayName, topWinner.TotalPayout)
+		msg += fmt.Sprintf("\n🏆 最大赢家 %s +%d\n", playerName(topWinner, names), topWinner.TotalPayout)
 	}
 
 	// Player results
 	msg += "\n📋 结算:\n"
 	for _, result := range playerResults {
 		net := result.TotalPayout
-		displayName := result.Username
-		if displayName == "" {
-			displayName = fmt.Sprintf("%d", result.UserID)
-		}
-		if !strings.HasPrefix(displayName, "@") {
-			displayName = "@" + displayName
-		}
-
+		displayName := playerName(result, names)
 		if net > 0 {
 			msg += fmt.Sprintf("🟢 %s +%d\n", displayName, net)
 		} else if net < 0 {
@@ -311,6 +323,15 @@ func FormatSettlementMessage(dice [3]int, playerResults map[int64]PlayerResult,
 	return msg
 }
 
+// playerName writes a player's name, the user ID for players without one
+func playerName(result PlayerResult, names NameFunc) string {
+	name := result.Username
+	if name == "" {
+		name = fmt.Sprintf("%d", result.UserID)
+	}
+	return names(result.UserID, name)
+}
+
 // PlayerResult represents a player's result in a SicBo game.
 type PlayerResult struct {
 	UserID      int64
@@ -27,6 +27,7 @@ var (
 	ErrInvalidBetType     = errors.New("invalid bet type")
 	ErrInvalidBetNumber   = errors.New("bet number must be between 1 and 6")
 	ErrInsufficientAmount = errors.New("bet amount must be positive")
+	ErrQuorumNotMet       = errors.New("not enough players, round is void")
 )
 
 // Bet represents a single bet placed by a user.
@@ -37,6 +38,13 @@ type Bet struct {
 	Amount    int64 // Accumulated amount for this bet option
 }
 
+// SessionKey identifies a session: the session of a chat, or of one forum
+// topic of a chat when topics play separate sessions.
+type SessionKey struct {
+	ChatID   int64
+	ThreadID int // Forum topic, 0 for the whole chat
+}
+
 // Session represents an active SicBo game session.
 type Session struct {
 	ChatID         int64
@@ -60,15 +68,35 @@ func betKey(betType BetType, betNumber int) string {
 // SicBoGame implements the MultiPlayerGame interface for Sic Bo.
 // Requirements: 5.1, 5.2, 5.7, 5.8, 10.1
 type SicBoGame struct {
-	sessions map[int64]*Session // chatID -> Session
-	mu       sync.RWMutex
+	sessions   map[SessionKey]*Session
+	minPlayers int // Distinct players a round needs to be played
+	mu         sync.RWMutex
 }
 
 // New creates a new SicBoGame instance.
 func New() *SicBoGame {
 	return &SicBoGame{
-		sessions: make(map[int64]*Session),
+		sessions:   make(map[SessionKey]*Session),
+		minPlayers: 1,
+	}
+}
+
+// SetMinPlayers sets the distinct players a round needs, rounds with fewer
+// are void and their bets refunded. Values below 1 are treated as 1.
+func (g *SicBoGame) SetMinPlayers(n int) {
+	if n < 1 {
+		n = 1
 	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.minPlayers = n
+}
+
+// MinPlayers returns the distinct players a round needs.
+func (g *SicBoGame) MinPlayers() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.minPlayers
 }
 
 // Name returns the game's display name.
@@ -112,12 +140,12 @@ func (g *SicBoGame) Play(ctx context.Context, userID int64, bet int64, params ma
 
 // StartSession begins a new multiplayer game session in a chat.
 // Requirements: 5.1
-func (g *SicBoGame) StartSession(ctx context.Context, chatID int64, starterID int64, duration int) error {
+func (g *SicBoGame) StartSession(ctx context.Context, key SessionKey, starterID int64, duration int) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	// Check if session already exists
-	if session, exists := g.sessions[chatID]; exists && !session.Settled {
+	if session, exists := g.sessions[key]; exists && !session.Settled {
 		return ErrSessionExists
 	}
 
@@ -126,8 +154,8 @@ func (g *SicBoGame) StartSession(ctx context.Context, chatID int64, starterID in
 	}
 
 	now := time.Now()
-	g.sessions[chatID] = &Session{
-		ChatID:         chatID,
+	g.sessions[key] = &Session{
+		ChatID:         key.ChatID,
 		StarterID:      starterID,
 		StartTime:      now,
 		BettingEndTime: now.Add(time.Duration(duration) * time.Second),
@@ -141,9 +169,9 @@ func (g *SicBoGame) StartSession(ctx context.Context, chatID int64, starterID in
 // PlaceBet places a bet for a user in an active session.
 // Supports accumulating bets on the same option (Requirements: 5.8).
 // Requirements: 5.2, 5.7, 5.8
-func (g *SicBoGame) PlaceBet(ctx context.Context, chatID, userID int64, betTypeStr string, amount int64) error {
+func (g *SicBoGame) PlaceBet(ctx context.Context, key SessionKey, userID int64, betTypeStr string, amount int64) error {
 	g.mu.RLock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	g.mu.RUnlock()
 
 	if !exists || session.Settled {
@@ -173,29 +201,81 @@ func (g *SicBoGame) PlaceBet(ctx context.Context, chatID, userID int64, betTypeS
 		return ErrInsufficientAmount
 	}
 
-	// Initialize user's bet map if needed
-	if session.Bets[userID] == nil {
-		session.Bets[userID] = make(map[string]*Bet)
+	session.addBet(userID, betType, betNumber, amount)
+	return nil
+}
+
+// BetSlip is one option and amount of a batch of bets.
+type BetSlip struct {
+	BetType string // Bet type accepted by PlaceBet: "big", "small" or "1".."6"
+	Amount  int64
+}
+
+// PlaceBets places several bets of a user at once: either all slips are
+// placed or, if any slip is invalid or betting has ended, none is.
+func (g *SicBoGame) PlaceBets(ctx context.Context, key SessionKey, userID int64, slips []BetSlip) error {
+	g.mu.RLock()
+	session, exists := g.sessions[key]
+	g.mu.RUnlock()
+
+	if !exists || session.Settled {
+		return ErrNoActiveSession
 	}
 
-	// Get or create bet for this option
-	key := betKey(betType, betNumber)
-	if existingBet, ok := session.Bets[userID][key]; ok {
-		// Accumulate bet amount (Requirements: 5.8)
-		existingBet.Amount += amount
-	} else {
-		// Create new bet
-		session.Bets[userID][key] = &Bet{
-			UserID:    userID,
-			BetType:   betType,
-			BetNumber: betNumber,
-			Amount:    amount,
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if time.Now().After(session.BettingEndTime) {
+		return ErrBettingEnded
+	}
+
+	// Validate every slip before placing any
+	type parsedSlip struct {
+		betType   BetType
+		betNumber int
+		amount    int64
+	}
+	parsed := make([]parsedSlip, 0, len(slips))
+	for _, slip := range slips {
+		betType, betNumber, err := parseBetType(slip.BetType)
+		if err != nil {
+			return err
+		}
+		if !ValidateBetType(betType, betNumber) {
+			return ErrInvalidBetType
+		}
+		if slip.Amount <= 0 {
+			return ErrInsufficientAmount
 		}
+		parsed = append(parsed, parsedSlip{betType: betType, betNumber: betNumber, amount: slip.Amount})
 	}
 
+	for _, p := range parsed {
+		session.addBet(userID, p.betType, p.betNumber, p.amount)
+	}
 	return nil
 }
 
+// addBet adds an amount to a user's bet on an option; the caller holds the session lock.
+// Bets on the same option accumulate (Requirements: 5.8).
+func (s *Session) addBet(userID int64, betType BetType, betNumber int, amount int64) {
+	if s.Bets[userID] == nil {
+		s.Bets[userID] = make(map[string]*Bet)
+	}
+
+	option := betKey(betType, betNumber)
+	if existingBet, ok := s.Bets[userID][option]; ok {
+		existingBet.Amount += amount
+		return
+	}
+	s.Bets[userID][option] = &Bet{
+		UserID:    userID,
+		BetType:   betType,
+		BetNumber: betNumber,
+		Amount:    amount,
+	}
+}
+
 // parseBetType parses a bet type string into BetType and bet number.
 // Format: "single_N" for single number, "big", "small" for big/small.
 func parseBetType(betTypeStr string) (BetType, int, error) {
@@ -221,9 +301,9 @@ func parseBetType(betTypeStr string) (BetType, int, error) {
 }
 
 // GetSessionBets returns all bets placed in the current session.
-func (g *SicBoGame) GetSessionBets(ctx context.Context, chatID int64) (map[int64]map[string]int64, error) {
+func (g *SicBoGame) GetSessionBets(ctx context.Context, key SessionKey) (map[int64]map[string]int64, error) {
 	g.mu.RLock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	g.mu.RUnlock()
 
 	if !exists {
@@ -236,8 +316,8 @@ func (g *SicBoGame) GetSessionBets(ctx context.Context, chatID int64) (map[int64
 	result := make(map[int64]map[string]int64)
 	for userID, bets := range session.Bets {
 		result[userID] = make(map[string]int64)
-		for key, bet := range bets {
-			result[userID][key] = bet.Amount
+		for option, bet := range bets {
+			result[userID][option] = bet.Amount
 		}
 	}
 
@@ -246,22 +326,57 @@ func (g *SicBoGame) GetSessionBets(ctx context.Context, chatID int64) (map[int64
 
 
 // Settle ends the session and calculates results for all participants.
+// If fewer players than the quorum bet, the round is void: Settle returns
+// ErrQuorumNotMet together with each player's total stake to refund.
 // Requirements: 5.7
-func (g *SicBoGame) Settle(ctx context.Context, chatID int64) (map[int64]int64, map[string]any, error) {
+func (g *SicBoGame) Settle(ctx context.Context, key SessionKey) (map[int64]int64, map[string]any, error) {
+	return g.settle(key, rollDice)
+}
+
+// SettleWithDice settles the game with specific dice values (for testing).
+func (g *SicBoGame) SettleWithDice(ctx context.Context, key SessionKey, dice [3]int) (map[int64]int64, map[string]any, error) {
+	return g.settle(key, func() [3]int { return dice })
+}
+
+// settle ends a session with the dice returned by roll
+func (g *SicBoGame) settle(key SessionKey, roll func() [3]int) (map[int64]int64, map[string]any, error) {
 	g.mu.Lock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	if !exists || session.Settled {
 		g.mu.Unlock()
 		return nil, nil, ErrNoActiveSession
 	}
+	minPlayers := g.minPlayers
 	g.mu.Unlock()
 
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
-	// Generate dice results
-	session.DiceResults = rollDice()
 	session.Settled = true
+	defer func() {
+		// Clean up session
+		g.mu.Lock()
+		delete(g.sessions, key)
+		g.mu.Unlock()
+	}()
+
+	// Not enough players: refund every stake instead of rolling.
+	// Without a quorum (1 player) even empty rounds are rolled.
+	if minPlayers > 1 && len(session.Bets) < minPlayers {
+		refunds := make(map[int64]int64, len(session.Bets))
+		for userID, bets := range session.Bets {
+			for _, bet := range bets {
+				refunds[userID] += bet.Amount
+			}
+		}
+		details := map[string]any{
+			"players":     len(session.Bets),
+			"min_players": minPlayers,
+		}
+		return refunds, details, ErrQuorumNotMet
+	}
+
+	session.DiceResults = roll()
 
 	// Calculate payouts for each user
 	payouts := make(map[int64]int64)
@@ -281,70 +396,81 @@ func (g *SicBoGame) Settle(ctx context.Context, chatID int64) (map[int64]int64,
 		"is_triple": IsTriple(session.DiceResults),
 	}
 
-	// Clean up session
-	g.mu.Lock()
-	delete(g.sessions, chatID)
-	g.mu.Unlock()
-
 	return payouts, details, nil
 }
 
-// SettleWithDice settles the game with specific dice values (for testing).
-func (g *SicBoGame) SettleWithDice(ctx context.Context, chatID int64, dice [3]int) (map[int64]int64, map[string]any, error) {
+// Cancel ends a session without rolling and returns each player's total
+// stake to refund, e.g. when an admin cancels a stuck round.
+func (g *SicBoGame) Cancel(ctx context.Context, key SessionKey) (map[int64]int64, error) {
 	g.mu.Lock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	if !exists || session.Settled {
 		g.mu.Unlock()
-		return nil, nil, ErrNoActiveSession
+		return nil, ErrNoActiveSession
 	}
+	delete(g.sessions, key)
 	g.mu.Unlock()
 
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
-	// Use provided dice results
-	session.DiceResults = dice
 	session.Settled = true
-
-	// Calculate payouts for each user
-	payouts := make(map[int64]int64)
+	refunds := make(map[int64]int64, len(session.Bets))
 	for userID, bets := range session.Bets {
-		var totalPayout int64
 		for _, bet := range bets {
-			payout := CalculatePayout(bet.BetType, bet.BetNumber, session.DiceResults, bet.Amount)
-			totalPayout += payout
+			refunds[userID] += bet.Amount
 		}
-		payouts[userID] = totalPayout
 	}
+	return refunds, nil
+}
 
-	// Build details
-	details := map[string]any{
-		"dice":      session.DiceResults,
-		"total":     session.DiceResults[0] + session.DiceResults[1] + session.DiceResults[2],
-		"is_triple": IsTriple(session.DiceResults),
-	}
+// IsSessionActive checks if there's an active session in the chat.
+func (g *SicBoGame) IsSessionActive(key SessionKey) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 
-	// Clean up session
-	g.mu.Lock()
-	delete(g.sessions, chatID)
-	g.mu.Unlock()
+	session, exists := g.sessions[key]
+	return exists && !session.Settled
+}
 
-	return payouts, details, nil
+// ChatSessionActive checks if any session is active in the chat, in any topic.
+func (g *SicBoGame) ChatSessionActive(chatID int64) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for key, session := range g.sessions {
+		if key.ChatID == chatID && !session.Settled {
+			return true
+		}
+	}
+	return false
 }
 
-// IsSessionActive checks if there's an active session in the chat.
-func (g *SicBoGame) IsSessionActive(chatID int64) bool {
+// SessionInfo describes an active session for scheduling.
+type SessionInfo struct {
+	SessionKey
+	BettingEndTime time.Time
+}
+
+// ActiveSessions returns the active sessions of all chats.
+func (g *SicBoGame) ActiveSessions() []SessionInfo {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	session, exists := g.sessions[chatID]
-	return exists && !session.Settled
+	sessions := make([]SessionInfo, 0, len(g.sessions))
+	for key, session := range g.sessions {
+		if session.Settled {
+			continue
+		}
+		sessions = append(sessions, SessionInfo{SessionKey: key, BettingEndTime: session.BettingEndTime})
+	}
+	return sessions
 }
 
 // GetSessionTimeRemaining returns seconds remaining in the betting phase.
-func (g *SicBoGame) GetSessionTimeRemaining(chatID int64) int {
+func (g *SicBoGame) GetSessionTimeRemaining(key SessionKey) int {
 	g.mu.RLock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	g.mu.RUnlock()
 
 	if !exists || session.Settled {
@@ -362,9 +488,9 @@ func (g *SicBoGame) GetSessionTimeRemaining(chatID int64) int {
 }
 
 // GetSessionStats returns statistics about the current session.
-func (g *SicBoGame) GetSessionStats(chatID int64) (playerCount int, totalBetAmount int64, betCount int) {
+func (g *SicBoGame) GetSessionStats(key SessionKey) (playerCount int, totalBetAmount int64, betCount int) {
 	g.mu.RLock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	g.mu.RUnlock()
 
 	if !exists {
@@ -386,9 +512,9 @@ func (g *SicBoGame) GetSessionStats(chatID int64) (playerCount int, totalBetAmou
 }
 
 // GetSessionStarterID returns the user ID who started the session.
-func (g *SicBoGame) GetSessionStarterID(chatID int64) int64 {
+func (g *SicBoGame) GetSessionStarterID(key SessionKey) int64 {
 	g.mu.RLock()
-	session, exists := g.sessions[chatID]
+	session, exists := g.sessions[key]
 	g.mu.RUnlock()
 
 	if !exists {
@@ -0,0 +1,79 @@
+package sicbo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// benchBetTypes cycles through the bet options a round usually sees
+var benchBetTypes = []string{"big", "small", "1", "3", "6"}
+
+// newBenchSession starts a session long enough to outlive the benchmark
+func newBenchSession(b *testing.B, key SessionKey) *SicBoGame {
+	game := New()
+	if err := game.StartSession(context.Background(), key, 1, 3600); err != nil {
+		b.Fatalf("Failed to start session: %v", err)
+	}
+	return game
+}
+
+// BenchmarkPlaceBet measures placing bets from a growing set of players in one session.
+func BenchmarkPlaceBet(b *testing.B) {
+	ctx := context.Background()
+	game := newBenchSession(b, SessionKey{ChatID: 1})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		userID := int64(i % 1000)
+		if err := game.PlaceBet(ctx, SessionKey{ChatID: 1}, userID, benchBetTypes[i%len(benchBetTypes)], 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPlaceBet_Parallel measures concurrent bets into one session,
+// which serialize on the session mutex.
+func BenchmarkPlaceBet_Parallel(b *testing.B) {
+	ctx := context.Background()
+	game := newBenchSession(b, SessionKey{ChatID: 1})
+	var nextID atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		userID := nextID.Add(1)
+		i := 0
+		for pb.Next() {
+			if err := game.PlaceBet(ctx, SessionKey{ChatID: 1}, userID, benchBetTypes[i%len(benchBetTypes)], 100); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkPlaceBet_ParallelChats measures concurrent bets spread over many chats.
+func BenchmarkPlaceBet_ParallelChats(b *testing.B) {
+	ctx := context.Background()
+	game := New()
+	const chats = 64
+	for chatID := int64(0); chatID < chats; chatID++ {
+		if err := game.StartSession(ctx, SessionKey{ChatID: chatID}, 1, 3600); err != nil {
+			b.Fatalf("Failed to start session: %v", err)
+		}
+	}
+	var nextID atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		userID := nextID.Add(1)
+		chatID := userID % chats
+		for pb.Next() {
+			if err := game.PlaceBet(ctx, SessionKey{ChatID: chatID}, userID, "big", 100); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
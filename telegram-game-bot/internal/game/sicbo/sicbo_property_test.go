@@ -5,6 +5,7 @@ package sicbo
 import (
 	"context"
 	"testing"
+	"time"
 
 	"pgregory.net/rapid"
 )
@@ -20,11 +21,11 @@ func TestSicBoBetAccumulationProperty(t *testing.T) {
 		game := New()
 
 		// Generate random chat and user IDs
-		chatID := rapid.Int64Range(1, 1000000).Draw(t, "chatID")
+		key := SessionKey{ChatID: rapid.Int64Range(1, 1000000).Draw(t, "chatID")}
 		userID := rapid.Int64Range(1, 1000000).Draw(t, "userID")
 
 		// Start a session
-		err := game.StartSession(ctx, chatID, userID, 300) // 5 minutes to ensure betting phase is active
+		err := game.StartSession(ctx, key, userID, 300) // 5 minutes to ensure betting phase is active
 		if err != nil {
 			t.Fatalf("Failed to start session: %v", err)
 		}
@@ -43,14 +44,14 @@ func TestSicBoBetAccumulationProperty(t *testing.T) {
 			amount := rapid.Int64Range(1, 1000).Draw(t, "betAmount")
 			expectedTotal += amount
 
-			err := game.PlaceBet(ctx, chatID, userID, betType, amount)
+			err := game.PlaceBet(ctx, key, userID, betType, amount)
 			if err != nil {
 				t.Fatalf("Failed to place bet %d: %v", i+1, err)
 			}
 		}
 
 		// Get session bets and verify accumulation
-		bets, err := game.GetSessionBets(ctx, chatID)
+		bets, err := game.GetSessionBets(ctx, key)
 		if err != nil {
 			t.Fatalf("Failed to get session bets: %v", err)
 		}
@@ -89,10 +90,10 @@ func TestSicBoBetAccumulationMultipleOptionsProperty(t *testing.T) {
 		ctx := context.Background()
 		game := New()
 
-		chatID := rapid.Int64Range(1, 1000000).Draw(t, "chatID")
+		key := SessionKey{ChatID: rapid.Int64Range(1, 1000000).Draw(t, "chatID")}
 		userID := rapid.Int64Range(1, 1000000).Draw(t, "userID")
 
-		err := game.StartSession(ctx, chatID, userID, 300)
+		err := game.StartSession(ctx, key, userID, 300)
 		if err != nil {
 			t.Fatalf("Failed to start session: %v", err)
 		}
@@ -117,7 +118,7 @@ func TestSicBoBetAccumulationMultipleOptionsProperty(t *testing.T) {
 		for i := 0; i < numBets1; i++ {
 			amount := rapid.Int64Range(1, 500).Draw(t, "amount1")
 			total1 += amount
-			err := game.PlaceBet(ctx, chatID, userID, betType1, amount)
+			err := game.PlaceBet(ctx, key, userID, betType1, amount)
 			if err != nil {
 				t.Fatalf("Failed to place bet on %s: %v", betType1, err)
 			}
@@ -127,14 +128,14 @@ func TestSicBoBetAccumulationMultipleOptionsProperty(t *testing.T) {
 		for i := 0; i < numBets2; i++ {
 			amount := rapid.Int64Range(1, 500).Draw(t, "amount2")
 			total2 += amount
-			err := game.PlaceBet(ctx, chatID, userID, betType2, amount)
+			err := game.PlaceBet(ctx, key, userID, betType2, amount)
 			if err != nil {
 				t.Fatalf("Failed to place bet on %s: %v", betType2, err)
 			}
 		}
 
 		// Verify both totals are correct
-		bets, err := game.GetSessionBets(ctx, chatID)
+		bets, err := game.GetSessionBets(ctx, key)
 		if err != nil {
 			t.Fatalf("Failed to get session bets: %v", err)
 		}
@@ -174,11 +175,11 @@ func TestSicBoBetAccumulationMultipleUsersProperty(t *testing.T) {
 		ctx := context.Background()
 		game := New()
 
-		chatID := rapid.Int64Range(1, 1000000).Draw(t, "chatID")
+		key := SessionKey{ChatID: rapid.Int64Range(1, 1000000).Draw(t, "chatID")}
 		userID1 := rapid.Int64Range(1, 500000).Draw(t, "userID1")
 		userID2 := rapid.Int64Range(500001, 1000000).Draw(t, "userID2")
 
-		err := game.StartSession(ctx, chatID, userID1, 300)
+		err := game.StartSession(ctx, key, userID1, 300)
 		if err != nil {
 			t.Fatalf("Failed to start session: %v", err)
 		}
@@ -194,7 +195,7 @@ func TestSicBoBetAccumulationMultipleUsersProperty(t *testing.T) {
 		for i := 0; i < numBets1; i++ {
 			amount := rapid.Int64Range(1, 500).Draw(t, "amount1")
 			total1 += amount
-			err := game.PlaceBet(ctx, chatID, userID1, betType, amount)
+			err := game.PlaceBet(ctx, key, userID1, betType, amount)
 			if err != nil {
 				t.Fatalf("User1 failed to place bet: %v", err)
 			}
@@ -206,14 +207,14 @@ func TestSicBoBetAccumulationMultipleUsersProperty(t *testing.T) {
 		for i := 0; i < numBets2; i++ {
 			amount := rapid.Int64Range(1, 500).Draw(t, "amount2")
 			total2 += amount
-			err := game.PlaceBet(ctx, chatID, userID2, betType, amount)
+			err := game.PlaceBet(ctx, key, userID2, betType, amount)
 			if err != nil {
 				t.Fatalf("User2 failed to place bet: %v", err)
 			}
 		}
 
 		// Verify each user's total is independent
-		bets, err := game.GetSessionBets(ctx, chatID)
+		bets, err := game.GetSessionBets(ctx, key)
 		if err != nil {
 			t.Fatalf("Failed to get session bets: %v", err)
 		}
@@ -225,10 +226,10 @@ func TestSicBoBetAccumulationMultipleUsersProperty(t *testing.T) {
 			return "single_" + betType
 		}
 
-		key := getKey(betType)
+		option := getKey(betType)
 
-		actual1 := bets[userID1][key]
-		actual2 := bets[userID2][key]
+		actual1 := bets[userID1][option]
+		actual2 := bets[userID2][option]
 
 		if actual1 != total1 {
 			t.Fatalf("User1 bet on %s: expected %d, got %d", betType, total1, actual1)
@@ -245,3 +246,194 @@ func TestSicBoBetAccumulationMultipleUsersProperty(t *testing.T) {
 		}
 	})
 }
+
+// TestActiveSessionsProperty tests that the scheduler view of sessions matches the game.
+// *For any* set of started sessions of which some are settled, ActiveSessions SHALL
+// list exactly the unsettled ones with their betting end time.
+func TestActiveSessionsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		game := New()
+
+		chatIDs := rapid.SliceOfNDistinct(rapid.Int64Range(-1000000, -1), 1, 10, rapid.ID[int64]).Draw(t, "chatIDs")
+		expected := make(map[int64]bool)
+		for _, chatID := range chatIDs {
+			if err := game.StartSession(ctx, SessionKey{ChatID: chatID}, 1, 300); err != nil {
+				t.Fatalf("Failed to start session: %v", err)
+			}
+			if rapid.Bool().Draw(t, "settle") {
+				if _, _, err := game.Settle(ctx, SessionKey{ChatID: chatID}); err != nil {
+					t.Fatalf("Failed to settle session: %v", err)
+				}
+				continue
+			}
+			expected[chatID] = true
+		}
+
+		sessions := game.ActiveSessions()
+		if len(sessions) != len(expected) {
+			t.Fatalf("Expected %d active sessions, got %d", len(expected), len(sessions))
+		}
+		for _, session := range sessions {
+			if !expected[session.ChatID] {
+				t.Fatalf("Settled or unknown session %d reported as active", session.ChatID)
+			}
+			if remaining := time.Until(session.BettingEndTime); remaining <= 0 || remaining > 300*time.Second {
+				t.Fatalf("Session %d has unexpected betting end time %s", session.ChatID, session.BettingEndTime)
+			}
+		}
+	})
+}
+
+// TestSicBoTopicSessionsProperty tests that the topics of a chat play
+// separate sessions: bets and settlement of one topic leave the others alone.
+func TestSicBoTopicSessionsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		game := New()
+
+		chatID := rapid.Int64Range(-1000000, -1).Draw(t, "chatID")
+		threads := rapid.SliceOfNDistinct(rapid.IntRange(0, 50), 2, 5, rapid.ID[int]).Draw(t, "threads")
+		for _, thread := range threads {
+			if err := game.StartSession(ctx, SessionKey{ChatID: chatID, ThreadID: thread}, 1, 300); err != nil {
+				t.Fatalf("Failed to start session in topic %d: %v", thread, err)
+			}
+		}
+
+		bettor := SessionKey{ChatID: chatID, ThreadID: threads[0]}
+		if err := game.PlaceBet(ctx, bettor, 7, "big", 100); err != nil {
+			t.Fatalf("Failed to place bet: %v", err)
+		}
+		for _, thread := range threads[1:] {
+			bets, err := game.GetSessionBets(ctx, SessionKey{ChatID: chatID, ThreadID: thread})
+			if err != nil {
+				t.Fatalf("Failed to get bets of topic %d: %v", thread, err)
+			}
+			if len(bets) != 0 {
+				t.Fatalf("Bet in topic %d leaked into topic %d", threads[0], thread)
+			}
+		}
+
+		if _, _, err := game.Settle(ctx, bettor); err != nil {
+			t.Fatalf("Failed to settle topic %d: %v", threads[0], err)
+		}
+		if game.IsSessionActive(bettor) {
+			t.Fatalf("Topic %d still active after settlement", threads[0])
+		}
+		for _, thread := range threads[1:] {
+			if !game.IsSessionActive(SessionKey{ChatID: chatID, ThreadID: thread}) {
+				t.Fatalf("Settling topic %d ended the session of topic %d", threads[0], thread)
+			}
+		}
+	})
+}
+
+// TestSicBoQuorumProperty tests that a round with fewer distinct players than
+// the quorum is void and refunds exactly each player's stakes, while a round
+// meeting the quorum is rolled.
+func TestSicBoQuorumProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		game := New()
+		minPlayers := rapid.IntRange(1, 4).Draw(t, "minPlayers")
+		game.SetMinPlayers(minPlayers)
+
+		key := SessionKey{ChatID: rapid.Int64Range(1, 1000000).Draw(t, "chatID")}
+		if err := game.StartSession(ctx, key, 0, 300); err != nil {
+			t.Fatalf("Failed to start session: %v", err)
+		}
+
+		players := rapid.IntRange(0, 5).Draw(t, "players")
+		staked := make(map[int64]int64)
+		for userID := int64(1); userID <= int64(players); userID++ {
+			for i := rapid.IntRange(1, 3).Draw(t, "bets"); i > 0; i-- {
+				betType := rapid.SampledFrom([]string{"big", "small", "3"}).Draw(t, "betType")
+				amount := rapid.Int64Range(1, 1000).Draw(t, "amount")
+				if err := game.PlaceBet(ctx, key, userID, betType, amount); err != nil {
+					t.Fatalf("Failed to place bet: %v", err)
+				}
+				staked[userID] += amount
+			}
+		}
+
+		results, details, err := game.SettleWithDice(ctx, key, [3]int{1, 2, 3})
+		if game.IsSessionActive(key) {
+			t.Fatal("Session still active after settling")
+		}
+
+		if minPlayers > 1 && players < minPlayers {
+			if err != ErrQuorumNotMet {
+				t.Fatalf("%d players with quorum %d: error = %v, want ErrQuorumNotMet", players, minPlayers, err)
+			}
+			if details["players"] != players || details["min_players"] != minPlayers {
+				t.Fatalf("Void details = %v", details)
+			}
+			if len(results) != len(staked) {
+				t.Fatalf("Refunded %d players, want %d", len(results), len(staked))
+			}
+			for userID, amount := range staked {
+				if results[userID] != amount {
+					t.Fatalf("User %d refunded %d, staked %d", userID, results[userID], amount)
+				}
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("%d players with quorum %d: unexpected error %v", players, minPlayers, err)
+		}
+		if _, ok := details["dice"]; !ok {
+			t.Fatal("Played round has no dice")
+		}
+	})
+}
+
+// TestSicBoCancelProperty tests that cancelling a session ends it and refunds
+// exactly each player's stakes.
+func TestSicBoCancelProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		game := New()
+
+		key := SessionKey{ChatID: rapid.Int64Range(1, 1000000).Draw(t, "chatID")}
+		if err := game.StartSession(ctx, key, 0, 300); err != nil {
+			t.Fatalf("Failed to start session: %v", err)
+		}
+
+		players := rapid.IntRange(0, 5).Draw(t, "players")
+		staked := make(map[int64]int64)
+		for userID := int64(1); userID <= int64(players); userID++ {
+			for i := rapid.IntRange(1, 3).Draw(t, "bets"); i > 0; i-- {
+				betType := rapid.SampledFrom([]string{"big", "small", "3"}).Draw(t, "betType")
+				amount := rapid.Int64Range(1, 1000).Draw(t, "amount")
+				if err := game.PlaceBet(ctx, key, userID, betType, amount); err != nil {
+					t.Fatalf("Failed to place bet: %v", err)
+				}
+				staked[userID] += amount
+			}
+		}
+
+		refunds, err := game.Cancel(ctx, key)
+		if err != nil {
+			t.Fatalf("Failed to cancel session: %v", err)
+		}
+		if game.IsSessionActive(key) {
+			t.Fatal("Session still active after cancelling")
+		}
+		if len(refunds) != len(staked) {
+			t.Fatalf("Refunded %d players, want %d", len(refunds), len(staked))
+		}
+		for userID, amount := range staked {
+			if refunds[userID] != amount {
+				t.Fatalf("User %d refunded %d, staked %d", userID, refunds[userID], amount)
+			}
+		}
+
+		if _, _, err := game.Settle(ctx, key); err != ErrNoActiveSession {
+			t.Fatalf("Settle after cancel: error = %v, want ErrNoActiveSession", err)
+		}
+		if err := game.StartSession(ctx, key, 0, 300); err != nil {
+			t.Fatalf("Cannot start a new session after cancelling: %v", err)
+		}
+	})
+}
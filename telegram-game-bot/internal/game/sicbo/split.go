@@ -0,0 +1,93 @@
+package sicbo
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxSplitOptions is the maximum number of options a split bet spreads over
+const MaxSplitOptions = 4
+
+// splitSeparator separates the options of a split bet in callback data
+const splitSeparator = "."
+
+// Errors for split bets
+var (
+	ErrInvalidSplit  = errors.New("invalid split bet")
+	ErrSplitTooSmall = errors.New("split amount is smaller than its options")
+)
+
+// SplitPreset is a quick button spreading the selected amount evenly over options.
+type SplitPreset struct {
+	Label    string   // Button label after "均分"
+	BetTypes []string // Bet types accepted by PlaceBet
+}
+
+// SplitPresets are the split buttons of the betting panel
+var SplitPresets = []SplitPreset{
+	{Label: "大+4+5+6", BetTypes: []string{"big", "4", "5", "6"}},
+	{Label: "小+1+2+3", BetTypes: []string{"small", "1", "2", "3"}},
+}
+
+// EncodeSplit encodes the options of a split bet as a callback parameter.
+func EncodeSplit(betTypes []string) string {
+	return strings.Join(betTypes, splitSeparator)
+}
+
+// ParseSplit parses the callback parameter of a split bet into its options:
+// 2 to MaxSplitOptions distinct valid bet types.
+func ParseSplit(param string) ([]string, error) {
+	betTypes := strings.Split(param, splitSeparator)
+	if len(betTypes) < 2 || len(betTypes) > MaxSplitOptions {
+		return nil, ErrInvalidSplit
+	}
+
+	seen := make(map[string]bool, len(betTypes))
+	for _, betType := range betTypes {
+		parsed, number, err := parseBetType(betType)
+		if err != nil || !ValidateBetType(parsed, number) {
+			return nil, ErrInvalidSplit
+		}
+		key := betKey(parsed, number)
+		if seen[key] {
+			return nil, ErrInvalidSplit
+		}
+		seen[key] = true
+	}
+	return betTypes, nil
+}
+
+// SplitBet spreads total evenly over the options. Coins that do not divide
+// evenly go one each to the first options, so the slips always add up to total.
+// Returns ErrSplitTooSmall if an option would get nothing.
+func SplitBet(total int64, betTypes []string) ([]BetSlip, error) {
+	n := int64(len(betTypes))
+	if n == 0 {
+		return nil, ErrInvalidSplit
+	}
+	if total < n {
+		return nil, ErrSplitTooSmall
+	}
+
+	share, rest := total/n, total%n
+	slips := make([]BetSlip, len(betTypes))
+	for i, betType := range betTypes {
+		amount := share
+		if int64(i) < rest {
+			amount++
+		}
+		slips[i] = BetSlip{BetType: betType, Amount: amount}
+	}
+	return slips, nil
+}
+
+// BetTypeName returns the display name of a bet type accepted by PlaceBet.
+func BetTypeName(betType string) string {
+	switch betType {
+	case "big":
+		return "大"
+	case "small":
+		return "小"
+	}
+	return betType
+}
@@ -0,0 +1,97 @@
+// Package sicbo tests for split bets.
+package sicbo
+
+import (
+	"context"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestParseSplit tests the split callback parameter.
+func TestParseSplit(t *testing.T) {
+	tests := []struct {
+		param   string
+		wantErr bool
+	}{
+		{"big.4.5.6", false},
+		{"small.1", false},
+		{"big", true},
+		{"big.big", true},
+		{"4.single_4", true},
+		{"big.7", true},
+		{"1.2.3.4.5", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			_, err := ParseSplit(tt.param)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSplit(%q) error = %v, wantErr %v", tt.param, err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, preset := range SplitPresets {
+		if _, err := ParseSplit(EncodeSplit(preset.BetTypes)); err != nil {
+			t.Errorf("Preset %q rejected: %v", preset.Label, err)
+		}
+	}
+}
+
+// TestSplitBetProperty tests that split slips add up to the total and differ by at most 1.
+func TestSplitBetProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		betTypes := SplitPresets[rapid.IntRange(0, len(SplitPresets)-1).Draw(t, "preset")].BetTypes
+		total := rapid.Int64Range(int64(len(betTypes)), 1000000).Draw(t, "total")
+
+		slips, err := SplitBet(total, betTypes)
+		if err != nil {
+			t.Fatalf("SplitBet(%d) failed: %v", total, err)
+		}
+
+		var sum int64
+		for _, slip := range slips {
+			sum += slip.Amount
+			if diff := slip.Amount - slips[len(slips)-1].Amount; diff < 0 || diff > 1 {
+				t.Fatalf("Uneven split %v", slips)
+			}
+		}
+		if sum != total {
+			t.Fatalf("Slips add up to %d, expected %d", sum, total)
+		}
+	})
+
+	if _, err := SplitBet(3, []string{"big", "1", "2", "3"}); err != ErrSplitTooSmall {
+		t.Errorf("SplitBet(3) error = %v, want ErrSplitTooSmall", err)
+	}
+}
+
+// TestPlaceBetsAtomic tests that an invalid slip places none of the batch.
+func TestPlaceBetsAtomic(t *testing.T) {
+	ctx := context.Background()
+	key := SessionKey{ChatID: 1}
+	game := New()
+	if err := game.StartSession(ctx, key, 1, 3600); err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+
+	err := game.PlaceBets(ctx, key, 42, []BetSlip{{BetType: "big", Amount: 100}, {BetType: "7", Amount: 100}})
+	if err != ErrInvalidBetType {
+		t.Fatalf("PlaceBets error = %v, want ErrInvalidBetType", err)
+	}
+	bets, _ := game.GetSessionBets(ctx, key)
+	if len(bets[42]) != 0 {
+		t.Fatalf("Invalid batch placed bets: %v", bets[42])
+	}
+
+	slips := []BetSlip{{BetType: "big", Amount: 100}, {BetType: "4", Amount: 50}, {BetType: "big", Amount: 25}}
+	if err := game.PlaceBets(ctx, key, 42, slips); err != nil {
+		t.Fatalf("PlaceBets failed: %v", err)
+	}
+	bets, _ = game.GetSessionBets(ctx, key)
+	if len(bets[42]) != 2 {
+		t.Fatalf("Expected 2 options, got %v", bets[42])
+	}
+}
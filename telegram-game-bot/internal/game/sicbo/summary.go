@@ -0,0 +1,60 @@
+package sicbo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BetResult is the outcome of one of a player's bets.
+type BetResult struct {
+	Key    string // Bet key, e.g. "big" or "single_3"
+	Amount int64
+	Net    int64 // Net payout: positive = win, negative = loss
+}
+
+// SettleBets returns the outcome of each of a player's bets for the dice,
+// sorted by bet key, and the player's net result over all of them.
+func SettleBets(dice [3]int, bets map[string]int64) ([]BetResult, int64) {
+	keys := make([]string, 0, len(bets))
+	for key := range bets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := make([]BetResult, 0, len(keys))
+	var net int64
+	for _, key := range keys {
+		amount := bets[key]
+		payout := -amount
+		if betType, betNumber, err := parseBetType(key); err == nil {
+			payout = CalculatePayout(betType, betNumber, dice, amount)
+		}
+		results = append(results, BetResult{Key: key, Amount: amount, Net: payout})
+		net += payout
+	}
+	return results, net
+}
+
+// FormatPersonalSummary formats the settlement DM of one player: the dice,
+// each bet with its result, the net result and the balance after settlement.
+func FormatPersonalSummary(dice [3]int, bets map[string]int64, balance int64) string {
+	results, net := SettleBets(dice, bets)
+
+	var sb strings.Builder
+	sb.WriteString("🎰 骰宝个人结算\n\n")
+	sb.WriteString(fmt.Sprintf("🎲 %d   🎲 %d   🎲 %d（点数 %d）\n", dice[0], dice[1], dice[2], dice[0]+dice[1]+dice[2]))
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	for _, result := range results {
+		outcome := "❌ 输"
+		if result.Net > 0 {
+			outcome = "✅ 赢"
+		}
+		sb.WriteString(fmt.Sprintf("• %s %d 金币: %s %+d\n", formatBetKey(result.Key), result.Amount, outcome, result.Net))
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("📊 净结果: %+d 金币\n", net))
+	sb.WriteString(fmt.Sprintf("💰 当前余额: %d 金币\n\n", balance))
+	sb.WriteString("发送 /sicbodm off 关闭骰宝结算私信")
+	return sb.String()
+}
@@ -0,0 +1,44 @@
+// Package sicbo tests for the personal settlement summary.
+package sicbo
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSettleBets tests the per-bet results and the net result of a player.
+func TestSettleBets(t *testing.T) {
+	dice := [3]int{3, 3, 6} // Sum 12: big
+	bets := map[string]int64{"big": 100, "small": 50, "single_3": 20, "single_1": 10}
+
+	results, net := SettleBets(dice, bets)
+
+	want := []BetResult{
+		{Key: "big", Amount: 100, Net: 100},
+		{Key: "single_1", Amount: 10, Net: -10},
+		{Key: "single_3", Amount: 20, Net: 40},
+		{Key: "small", Amount: 50, Net: -50},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Got %d results, want %d", len(results), len(want))
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("Result %d = %+v, want %+v", i, results[i], want[i])
+		}
+	}
+	if net != 80 {
+		t.Errorf("Net = %d, want 80", net)
+	}
+}
+
+// TestFormatPersonalSummary tests that the summary lists every bet, the net result and the balance.
+func TestFormatPersonalSummary(t *testing.T) {
+	msg := FormatPersonalSummary([3]int{1, 2, 3}, map[string]int64{"small": 200, "single_6": 50}, 1234)
+
+	for _, want := range []string{"小 200 金币: ✅ 赢 +200", "单一数字 6 50 金币: ❌ 输 -50", "净结果: +150", "当前余额: 1234"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Summary missing %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package sicbo
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTextBetSlips is the maximum number of bets in one text message
+const MaxTextBetSlips = 6
+
+// Errors for text bets
+var (
+	ErrEmptyTextBet     = errors.New("empty text bet")
+	ErrTooManyTextBets  = errors.New("too many bets in one message")
+	ErrInvalidBetAmount = errors.New("invalid bet amount")
+)
+
+// TextBet is a single bet parsed from a text message.
+type TextBet struct {
+	BetType string // Bet type accepted by PlaceBet: "big", "small" or "1".."6"
+	Amount  int64
+}
+
+// textBetOptions maps option words to PlaceBet bet types
+var textBetOptions = map[string]string{
+	"大":     "big",
+	"押大":    "big",
+	"big":   "big",
+	"小":     "small",
+	"押小":    "small",
+	"small": "small",
+}
+
+// ParseTextBets parses a text bet message into bet slips.
+// Grammar: slip {("," | "，" | ";" | newline) slip}, slip = option [" "] amount,
+// option = 大 | 小 | big | small | 1-6 (optionally prefixed with 押).
+// Examples: "大 500", "3 200", "大500，押6 100".
+func ParseTextBets(text string) ([]TextBet, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '；' || r == '\n'
+	})
+
+	var bets []TextBet
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		bet, err := parseTextBetSlip(field)
+		if err != nil {
+			return nil, err
+		}
+		bets = append(bets, bet)
+	}
+
+	if len(bets) == 0 {
+		return nil, ErrEmptyTextBet
+	}
+	if len(bets) > MaxTextBetSlips {
+		return nil, ErrTooManyTextBets
+	}
+	return bets, nil
+}
+
+// parseTextBetSlip parses a single "option amount" slip
+func parseTextBetSlip(slip string) (TextBet, error) {
+	var option, amountStr string
+	if parts := strings.Fields(slip); len(parts) == 2 {
+		option, amountStr = parts[0], parts[1]
+	} else if len(parts) == 1 {
+		// No separator: the amount is the trailing run of digits ("大500")
+		i := strings.LastIndexFunc(slip, func(r rune) bool { return !unicode.IsDigit(r) })
+		if i < 0 {
+			return TextBet{}, ErrInvalidBetType
+		}
+		_, size := utf8.DecodeRuneInString(slip[i:])
+		option, amountStr = slip[:i+size], slip[i+size:]
+	} else {
+		return TextBet{}, ErrInvalidBetType
+	}
+
+	betType, err := parseTextBetOption(option)
+	if err != nil {
+		return TextBet{}, err
+	}
+
+	amount, err := strconv.ParseInt(amountStr, 10, 64)
+	if err != nil || amount <= 0 {
+		return TextBet{}, ErrInvalidBetAmount
+	}
+
+	return TextBet{BetType: betType, Amount: amount}, nil
+}
+
+// parseTextBetOption maps an option word to a PlaceBet bet type
+func parseTextBetOption(option string) (string, error) {
+	option = strings.ToLower(option)
+	if betType, ok := textBetOptions[option]; ok {
+		return betType, nil
+	}
+
+	number := strings.TrimPrefix(option, "押")
+	if len(number) == 1 && number[0] >= '1' && number[0] <= '6' {
+		return number, nil
+	}
+	return "", ErrInvalidBetType
+}
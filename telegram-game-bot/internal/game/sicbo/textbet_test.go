@@ -0,0 +1,72 @@
+// Package sicbo tests for text bet parsing.
+package sicbo
+
+import (
+	"fmt"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestParseTextBets tests the text bet grammar.
+func TestParseTextBets(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []TextBet
+		wantErr bool
+	}{
+		{"大 500", []TextBet{{"big", 500}}, false},
+		{"小 200", []TextBet{{"small", 200}}, false},
+		{"3 200", []TextBet{{"3", 200}}, false},
+		{"大500", []TextBet{{"big", 500}}, false},
+		{"押6 100", []TextBet{{"6", 100}}, false},
+		{"BIG 100", []TextBet{{"big", 100}}, false},
+		{"大 500，3 200", []TextBet{{"big", 500}, {"3", 200}}, false},
+		{"小 100\n1 50", []TextBet{{"small", 100}, {"1", 50}}, false},
+		{"", nil, true},
+		{"hello", nil, true},
+		{"7 100", nil, true},
+		{"大 0", nil, true},
+		{"大 -5", nil, true},
+		{"3200", nil, true},
+		{"大 500 600", nil, true},
+		{"1 1,2 1,3 1,4 1,5 1,6 1,大 1", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseTextBets(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseTextBets(%q) = %v, want error", tt.text, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTextBets(%q) unexpected error: %v", tt.text, err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseTextBets(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTextBetsProperty tests that every parsed bet type is accepted by PlaceBet's parser.
+func TestParseTextBetsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		option := rapid.SampledFrom([]string{"大", "小", "押大", "押小", "big", "small", "1", "2", "3", "4", "5", "6", "押4"}).Draw(t, "option")
+		amount := rapid.Int64Range(1, 1000000).Draw(t, "amount")
+
+		bets, err := ParseTextBets(fmt.Sprintf("%s %d", option, amount))
+		if err != nil || len(bets) != 1 {
+			t.Fatalf("Failed to parse %q %d: %v", option, amount, err)
+		}
+		if bets[0].Amount != amount {
+			t.Fatalf("Amount %d, expected %d", bets[0].Amount, amount)
+		}
+		if _, _, err := parseBetType(bets[0].BetType); err != nil {
+			t.Fatalf("Bet type %q rejected by PlaceBet: %v", bets[0].BetType, err)
+		}
+	})
+}
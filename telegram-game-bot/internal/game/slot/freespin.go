@@ -0,0 +1,110 @@
+package slot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"telegram-game-bot/internal/game"
+)
+
+// Free spin prizes (reduced paytable, no stake)
+const (
+	FreeSpinPrizeSeven  = 500 // 三个 7️⃣
+	FreeSpinPrizeTriple = 200 // 其他三连
+	FreeSpinPrizePair   = 20  // 两连
+)
+
+// ErrFreeSpinStake is returned when a free spin is played with a stake
+var ErrFreeSpinStake = errors.New("free spin does not take a stake")
+
+// FreeSpinGame is the zero-stake daily free spin variant of the slot machine.
+// It uses the same reels as SlotGame but pays a fixed, reduced prize and never loses coins.
+type FreeSpinGame struct{}
+
+// NewFreeSpin creates a new FreeSpinGame.
+func NewFreeSpin() *FreeSpinGame {
+	return &FreeSpinGame{}
+}
+
+// Name returns the game's display name.
+func (f *FreeSpinGame) Name() string {
+	return "Daily Free Spin"
+}
+
+// Command returns the command that triggers this game.
+func (f *FreeSpinGame) Command() string {
+	return "freespin"
+}
+
+// Description returns a brief description of the game.
+func (f *FreeSpinGame) Description() string {
+	return "One free slot spin per day with a reduced paytable"
+}
+
+// MaxBet returns 0 since free spins take no stake.
+func (f *FreeSpinGame) MaxBet() int64 {
+	return 0
+}
+
+// Cooldown returns 0; the daily limit is tracked per user like the daily claim.
+func (f *FreeSpinGame) Cooldown() int {
+	return 0
+}
+
+// ValidateBet only accepts a zero stake.
+func (f *FreeSpinGame) ValidateBet(bet int64, params map[string]any) error {
+	if bet != 0 {
+		return ErrFreeSpinStake
+	}
+	return nil
+}
+
+// Play decodes the slot value and returns the free spin prize as payout.
+func (f *FreeSpinGame) Play(ctx context.Context, userID int64, bet int64, params map[string]any) (*game.GameResult, error) {
+	if err := f.ValidateBet(bet, params); err != nil {
+		return nil, err
+	}
+
+	slotValue, err := extractSlotValue(params)
+	if err != nil {
+		return nil, err
+	}
+
+	left, middle, right := DecodeSlot(slotValue)
+	prize := CalculateFreeSpinPrize(left, middle, right)
+
+	slotDisplay := fmt.Sprintf("%s %s %s", SymbolNames[left], SymbolNames[middle], SymbolNames[right])
+	description := fmt.Sprintf("🎰 %s\n😐 No prize this time.", slotDisplay)
+	if prize > 0 {
+		description = fmt.Sprintf("🎰 %s\n🎉 Free spin won %d coins!", slotDisplay, prize)
+	}
+
+	return &game.GameResult{
+		Payout:      prize,
+		Description: description,
+		Details: map[string]any{
+			"slot_value": slotValue,
+			"left":       left,
+			"middle":     middle,
+			"right":      right,
+			"bet":        bet,
+		},
+	}, nil
+}
+
+// CalculateFreeSpinPrize returns the prize for a free spin. It is never negative.
+func CalculateFreeSpinPrize(left, middle, right int) int64 {
+	if left == middle && middle == right {
+		if left == SymbolSeven {
+			return FreeSpinPrizeSeven
+		}
+		return FreeSpinPrizeTriple
+	}
+
+	if left == middle || middle == right || left == right {
+		return FreeSpinPrizePair
+	}
+
+	return 0
+}
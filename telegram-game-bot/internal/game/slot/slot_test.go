@@ -405,3 +405,51 @@ func TestSlotPayoutTieredMultiplierProperty(t *testing.T) {
 		}
 	})
 }
+
+// TestFreeSpinPrizeProperty verifies the free spin paytable is never negative
+// and pays the expected prize per match type.
+func TestFreeSpinPrizeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		slotValue := rapid.IntRange(1, 64).Draw(t, "slotValue")
+		left, middle, right := DecodeSlot(slotValue)
+
+		prize := CalculateFreeSpinPrize(left, middle, right)
+
+		var expected int64
+		switch {
+		case left == middle && middle == right && left == SymbolSeven:
+			expected = FreeSpinPrizeSeven
+		case left == middle && middle == right:
+			expected = FreeSpinPrizeTriple
+		case left == middle || middle == right || left == right:
+			expected = FreeSpinPrizePair
+		}
+
+		if prize != expected {
+			t.Fatalf("CalculateFreeSpinPrize(%d, %d, %d) = %d, want %d", left, middle, right, prize, expected)
+		}
+	})
+}
+
+func TestFreeSpinGame_Play(t *testing.T) {
+	g := NewFreeSpin()
+
+	if g.Command() != "freespin" {
+		t.Errorf("Command() = %q, want freespin", g.Command())
+	}
+	if err := g.ValidateBet(100, nil); err != ErrFreeSpinStake {
+		t.Errorf("ValidateBet(100) = %v, want ErrFreeSpinStake", err)
+	}
+
+	result, err := g.Play(context.Background(), 1, 0, map[string]any{"slot_value": 64})
+	if err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if result.Payout != FreeSpinPrizeSeven {
+		t.Errorf("Play(777) payout = %d, want %d", result.Payout, FreeSpinPrizeSeven)
+	}
+
+	if _, err := g.Play(context.Background(), 1, 0, nil); err != ErrMissingSlotValue {
+		t.Errorf("Play() without slot value error = %v, want ErrMissingSlotValue", err)
+	}
+}
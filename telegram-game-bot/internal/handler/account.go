@@ -6,10 +6,17 @@ package handler
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	tele "gopkg.in/telebot.v3"
 
+	"telegram-game-bot/internal/cosmetic"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
 	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/pkg/tgfmt"
 	"telegram-game-bot/internal/service"
 )
 
@@ -18,6 +25,11 @@ type AccountHandler struct {
 	accountService *service.AccountService
 	rankingService *service.RankingService
 	userLock       *lock.UserLock
+	cosmetics      *service.CosmeticService    // Optional: titles and pet accessories shown in /my
+	bailout        *service.BailoutService     // Optional: recovery grants for players below the floor
+	archive        *service.ArchiveService     // Optional: archival of inactive users
+	dailyRewards   *service.DailyRewardService // Optional: daily reward scaled inverse to inflation
+	degraded       *service.DegradedService    // Optional: cached answers while the database is down
 }
 
 // NewAccountHandler creates a new AccountHandler.
@@ -29,6 +41,85 @@ func NewAccountHandler(accountService *service.AccountService, rankingService *s
 	}
 }
 
+// SetCosmetics sets the service providing the users' equipped cosmetics
+func (h *AccountHandler) SetCosmetics(cosmetics *service.CosmeticService) {
+	h.cosmetics = cosmetics
+}
+
+// SetDegraded sets the service caching balances and the leaderboard for
+// answers while the database is down
+func (h *AccountHandler) SetDegraded(degraded *service.DegradedService) {
+	h.degraded = degraded
+}
+
+// SetBailout sets the service run by StartBailoutScheduler
+func (h *AccountHandler) SetBailout(bailout *service.BailoutService) {
+	h.bailout = bailout
+}
+
+// StartBailoutScheduler starts the background goroutine that pays recovery grants.
+func (h *AccountHandler) StartBailoutScheduler(interval time.Duration) {
+	if h.bailout == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("bailout", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("bailout")
+			h.bailout.Run(context.Background(), now)
+		}
+	}()
+}
+
+// SetArchive sets the service run by StartArchiveScheduler
+func (h *AccountHandler) SetArchive(archive *service.ArchiveService) {
+	h.archive = archive
+}
+
+// StartArchiveScheduler starts the background goroutine that archives inactive users.
+func (h *AccountHandler) StartArchiveScheduler(interval time.Duration) {
+	if h.archive == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("archive", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("archive")
+			h.archive.Run(context.Background(), now)
+		}
+	}()
+}
+
+// SetDailyRewards enables the daily reward scaled inverse to inflation.
+func (h *AccountHandler) SetDailyRewards(rewards *service.DailyRewardService) {
+	h.dailyRewards = rewards
+}
+
+// StartDailyRewardScheduler starts recalculating the dynamic daily reward,
+// first right away. Every instance recalculates, they all pay the reward.
+func (h *AccountHandler) StartDailyRewardScheduler(interval time.Duration) {
+	if h.dailyRewards == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		h.dailyRewards.Run(context.Background(), time.Now())
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("daily_reward", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("daily_reward")
+			h.dailyRewards.Run(context.Background(), now)
+		}
+	}()
+}
+
 // HandleStart handles the /start command.
 // Creates a new account with 1000 initial coins if user doesn't exist.
 // Requirements: 1.1, 9.1
@@ -39,10 +130,7 @@ func (h *AccountHandler) HandleStart(c tele.Context) error {
 		return nil
 	}
 
-	username := sender.Username
-	if username == "" {
-		username = sender.FirstName
-	}
+	username := senderName(sender)
 
 	// Acquire lock before balance-modifying operation
 	// Requirements: 9.1
@@ -62,9 +150,15 @@ func (h *AccountHandler) HandleStart(c tele.Context) error {
 				"/balance - 查看余额\n"+
 				"/daily - 每日签到\n"+
 				"/top - 富豪榜\n"+
-				"/dice <金额> - 骰子游戏\n"+
+				"/dice <金额> [保险] - 骰子游戏（加保险输了退还部分下注）\n"+
+				"/dice3 <金额> - 三骰子\n"+
+				"/dicebo3 <金额> - 三局两胜骰子\n"+
 				"/slot <金额> - 老虎机\n"+
-				"/pay @用户 <金额> - 转账",
+				"/blackjack <金额> - 21点\n"+
+				"/freespin - 每日免费旋转\n"+
+				"/protect - 打劫保护状态\n"+
+				"/raid - 群战突袭\n"+
+				"/pay @用户|#编号 <金额> - 转账",
 			username, user.Balance,
 		))
 	}
@@ -86,19 +180,28 @@ func (h *AccountHandler) HandleBalance(c tele.Context) error {
 		return nil
 	}
 
+	// The database is down, answer with the last known balance
+	if h.degraded != nil && h.degraded.Degraded() {
+		cached, ok := h.degraded.CachedBalance(sender.ID)
+		if !ok {
+			return c.Reply(service.DegradedRefusal)
+		}
+		return c.Reply(fmt.Sprintf("💰 当前余额: %d 金币\n%s", cached.Balance, service.FormatCachedAt(cached.At, time.Now())))
+	}
+
 	balance, err := h.accountService.GetBalance(ctx, sender.ID)
 	if err != nil {
 		// User might not exist, try to create
-		username := sender.Username
-		if username == "" {
-			username = sender.FirstName
-		}
+		username := senderName(sender)
 		user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
 		if err != nil {
 			return c.Reply("❌ 获取余额失败，请稍后重试")
 		}
 		balance = user.Balance
 	}
+	if h.degraded != nil {
+		h.degraded.RememberBalance(sender.ID, balance, time.Now())
+	}
 
 	return c.Reply(fmt.Sprintf("💰 当前余额: %d 金币", balance))
 }
@@ -115,37 +218,54 @@ func (h *AccountHandler) HandleMy(c tele.Context) error {
 	user, err := h.accountService.GetUser(ctx, sender.ID)
 	if err != nil {
 		// User might not exist, try to create
-		username := sender.Username
-		if username == "" {
-			username = sender.FirstName
-		}
+		username := senderName(sender)
 		user, _, err = h.accountService.EnsureUser(ctx, sender.ID, username)
 		if err != nil {
 			return c.Reply("❌ 获取账户信息失败，请稍后重试")
 		}
 	}
 
-	// Get daily profit
-	dailyProfit, _ := h.rankingService.GetUserDailyProfit(ctx, sender.ID)
+	// Get today's, rolling and lifetime profit
+	profit, err := h.rankingService.GetUserProfitSummary(ctx, sender.ID)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", sender.ID).Msg("Failed to load profit summary")
+		profit = &service.ProfitSummary{}
+	}
 
-	profitStr := fmt.Sprintf("%d", dailyProfit)
-	if dailyProfit > 0 {
-		profitStr = "+" + profitStr
+	// Equipped cosmetics bought with stars
+	var cosmeticLines tgfmt.HTML
+	if h.cosmetics != nil {
+		equipped, err := h.cosmetics.Equipped(ctx, sender.ID)
+		if err != nil {
+			log.Warn().Err(err).Int64("user_id", sender.ID).Msg("Failed to load cosmetics")
+		}
+		if title, ok := equipped[cosmetic.KindTitle]; ok {
+			cosmeticLines += tgfmt.Sprintf("🏷️ 称号: %s\n", title.Display)
+		}
+		if accessory, ok := equipped[cosmetic.KindPetAccessory]; ok {
+			cosmeticLines += tgfmt.Sprintf("🐾 宠物饰品: %s\n", accessory.Display)
+		}
 	}
 
-	return c.Reply(fmt.Sprintf(
-		"📊 账户信息\n"+
+	return replyHTML(c, tgfmt.Sprintf(
+		"📊 <b>账户信息</b>\n"+
 			"━━━━━━━━━━━━━━━\n"+
-			"👤 用户: @%s\n"+
-			"💰 余额: %d 金币\n"+
+			"👤 用户: %s\n"+
+			"🆔 编号: %s\n"+
+			"%s"+
+			"💰 余额: %s 金币\n"+
 			"📈 今日盈亏: %s\n"+
+			"📅 %d日盈亏: %s\n"+
+			"🏆 累计盈亏: %s\n"+
 			"━━━━━━━━━━━━━━━",
-		user.Username, user.Balance, profitStr,
+		textfilter.Name(user.Username), tgfmt.Code(user.Handle), cosmeticLines, tgfmt.Amount(user.Balance), tgfmt.SignedAmount(profit.Today),
+		service.ProfitWeekDays, tgfmt.SignedAmount(profit.Week), tgfmt.SignedAmount(profit.Lifetime),
 	))
 }
 
 // HandleDaily handles the /daily command.
-// Grants 500 coins if 24 hours have passed since last claim.
+// Grants the daily reward if 24 hours have passed since last claim. A
+// dynamic reward is explained along with the reply.
 // Requirements: 1.3, 1.4, 9.1
 func (h *AccountHandler) HandleDaily(c tele.Context) error {
 	ctx := context.Background()
@@ -155,10 +275,7 @@ func (h *AccountHandler) HandleDaily(c tele.Context) error {
 	}
 
 	// Ensure user exists first (outside lock to avoid nested locking)
-	username := sender.Username
-	if username == "" {
-		username = sender.FirstName
-	}
+	username := senderName(sender)
 
 	// Acquire lock before balance-modifying operation
 	// Requirements: 9.1
@@ -176,6 +293,9 @@ func (h *AccountHandler) HandleDaily(c tele.Context) error {
 		return c.Reply("❌ 签到失败，请稍后重试")
 	}
 
+	if h.dailyRewards != nil {
+		msg += "\n" + h.dailyRewards.Info()
+	}
 	if success {
 		return c.Reply(fmt.Sprintf("✅ %s", msg))
 	}
@@ -189,16 +309,32 @@ func (h *AccountHandler) HandleDaily(c tele.Context) error {
 func (h *AccountHandler) HandleTop(c tele.Context) error {
 	ctx := context.Background()
 
-	users, err := h.rankingService.GetTopUsers(ctx, 10)
-	if err != nil {
-		return c.Reply("❌ 获取排行榜失败，请稍后重试")
+	// The database is down, answer with the last known leaderboard
+	var stale tgfmt.HTML
+	var users []*model.User
+	if h.degraded != nil && h.degraded.Degraded() {
+		cached, at, ok := h.degraded.CachedTop()
+		if !ok {
+			return c.Reply(service.DegradedRefusal)
+		}
+		users = cached
+		stale = tgfmt.Sprintf("\n%s", service.FormatCachedAt(at, time.Now()))
+	} else {
+		var err error
+		users, err = h.rankingService.GetTopUsers(ctx, 10)
+		if err != nil {
+			return c.Reply("❌ 获取排行榜失败，请稍后重试")
+		}
+		if h.degraded != nil {
+			h.degraded.RememberTop(users, time.Now())
+		}
 	}
 
 	if len(users) == 0 {
 		return c.Reply("📊 暂无排行数据")
 	}
 
-	msg := "🏆 富豪榜 TOP 10\n"
+	msg := tgfmt.HTML("🏆 <b>富豪榜 TOP 10</b>\n")
 	msg += "━━━━━━━━━━━━━━━\n"
 
 	medals := []string{"🥇", "🥈", "🥉"}
@@ -208,15 +344,15 @@ func (h *AccountHandler) HandleTop(c tele.Context) error {
 			rank = medals[i]
 		}
 
-		displayName := user.Username
-		if displayName == "" {
-			displayName = fmt.Sprintf("User%d", user.TelegramID)
+		displayName := service.RankDisplayName(user.Username, user.TelegramID, user.HideFromLeaderboard)
+		if !user.HideFromLeaderboard && user.Handle != "" {
+			displayName += " " + user.Handle
 		}
-
-		msg += fmt.Sprintf("%s %s: %d\n", rank, displayName, user.Balance)
+		msg += tgfmt.Sprintf("%s %s: %s\n", rank, displayName, tgfmt.Amount(user.Balance))
 	}
 
 	msg += "━━━━━━━━━━━━━━━"
+	msg += stale
 
-	return c.Reply(msg)
+	return replyHTML(c, msg)
 }
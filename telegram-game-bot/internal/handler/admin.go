@@ -4,21 +4,36 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	tele "gopkg.in/telebot.v3"
 
 	"telegram-game-bot/internal/model"
 	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/textfilter"
 	"telegram-game-bot/internal/service"
+	"telegram-game-bot/internal/shop"
 )
 
 // AdminHandler handles admin-related commands.
 type AdminHandler struct {
 	accountService *service.AccountService
+	refundService  *service.RefundService   // optional, enables /refundtx
+	treasury       *service.TreasuryService // optional, enables /treasury
+	txSearch       *service.TxSearchService // optional, enables /findtx
+	rtp            *service.RTPService      // optional, enables /rtp
 	userLock       *lock.UserLock
+	simDefaults    service.EconomySimConfig // base settings of /simulate
+	adjustments    sync.Map                 // map[int64]service.AdminAdjustment - id -> previewed adjustment
+	nextAdjustment atomic.Int64
 }
 
 // NewAdminHandler creates a new AdminHandler.
@@ -29,195 +44,103 @@ func NewAdminHandler(accountService *service.AccountService, userLock *lock.User
 	}
 }
 
-// HandleAdminAdd handles the /admin_add command.
-// Format: /admin_add <user_id> <amount>
-// Requirements: 6.1, 6.5
-func (h *AdminHandler) HandleAdminAdd(c tele.Context) error {
-	ctx := context.Background()
-	sender := c.Sender()
-	if sender == nil {
-		return nil
-	}
-
-	// Parse arguments
-	targetID, amount, err := h.parseAdminArgs(c)
-	if err != nil {
-		return c.Reply(err.Error())
-	}
+// SetRefundService enables reversing transactions with /refundtx.
+func (h *AdminHandler) SetRefundService(refundService *service.RefundService) {
+	h.refundService = refundService
+}
 
-	if amount <= 0 {
-		return c.Reply("❌ 金额必须大于 0")
-	}
+// SetSimulationDefaults sets the live economy settings /simulate runs with.
+func (h *AdminHandler) SetSimulationDefaults(cfg service.EconomySimConfig) {
+	h.simDefaults = cfg
+}
 
-	// Acquire lock for target user
-	h.userLock.Lock(targetID)
-	defer h.userLock.Unlock(targetID)
+// HandleSimulate handles the /simulate command.
+// Runs a Monte Carlo simulation of the payout tables and item effects.
+// Format: /simulate [rounds] [bet] [daily_wager]
+func (h *AdminHandler) HandleSimulate(c tele.Context) error {
+	cfg := h.simDefaults
+	cfg.Rounds = service.SimDefaultRounds
+	cfg.Bet = service.SimDefaultBet
+	cfg.DailyWager = service.SimDefaultDailyWager
 
-	// Add balance
-	desc := fmt.Sprintf("管理员 %d 添加", sender.ID)
-	user, err := h.accountService.UpdateBalance(ctx, targetID, amount, model.TxTypeAdminAdd, &desc)
-	if err != nil {
-		return c.Reply("❌ 操作失败，用户可能不存在")
+	args := c.Args()
+	if len(args) > 3 {
+		return c.Reply("❌ 用法: /simulate [次数] [单注] [每日下注]")
+	}
+	for i, arg := range args {
+		v, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || v <= 0 {
+			return c.Reply("❌ 参数必须是正整数\n用法: /simulate [次数] [单注] [每日下注]")
+		}
+		switch i {
+		case 0:
+			if v > service.SimMaxRounds {
+				return c.Reply(fmt.Sprintf("❌ 次数最多 %d", service.SimMaxRounds))
+			}
+			cfg.Rounds = int(v)
+		case 1:
+			cfg.Bet = v
+		case 2:
+			cfg.DailyWager = v
+		}
 	}
 
-	// Log admin operation (Requirements: 6.5)
-	log.Info().
-		Int64("admin_id", sender.ID).
-		Int64("target_id", targetID).
-		Int64("amount", amount).
-		Str("operation", "admin_add").
-		Msg("Admin operation executed")
+	report := service.SimulateEconomy(cfg, rand.New(rand.NewSource(time.Now().UnixNano())))
 
-	displayName := user.Username
-	if displayName == "" {
-		displayName = fmt.Sprintf("%d", targetID)
-	}
+	log.Info().
+		Int64("admin_id", c.Sender().ID).
+		Int("rounds", cfg.Rounds).
+		Int64("bet", cfg.Bet).
+		Float64("inflation_per_player", report.InflationPerPlayer()).
+		Dur("elapsed", report.Elapsed).
+		Msg("Admin ran economy simulation")
+
+	return c.Reply(service.FormatEconomySimReport(report))
+}
 
-	return c.Reply(fmt.Sprintf(
-		"✅ 操作成功\n\n"+
-			"👤 用户: %s (ID: %d)\n"+
-			"➕ 添加: %d 金币\n"+
-			"💰 当前余额: %d 金币",
-		displayName, targetID, amount, user.Balance,
-	))
+// HandleAdminAdd handles the /admin_add command.
+// Format: /admin_add <user_id> <amount> <reason>
+// Requirements: 6.1, 6.5
+func (h *AdminHandler) HandleAdminAdd(c tele.Context) error {
+	return h.previewAdjustment(c, model.TxTypeAdminAdd)
 }
 
 // HandleAdminSub handles the /admin_sub command.
-// Format: /admin_sub <user_id> <amount>
+// Format: /admin_sub <user_id> <amount> <reason>
 // Requirements: 6.2, 6.5
 func (h *AdminHandler) HandleAdminSub(c tele.Context) error {
-	ctx := context.Background()
-	sender := c.Sender()
-	if sender == nil {
-		return nil
-	}
-
-	// Parse arguments
-	targetID, amount, err := h.parseAdminArgs(c)
-	if err != nil {
-		return c.Reply(err.Error())
-	}
-
-	if amount <= 0 {
-		return c.Reply("❌ 金额必须大于 0")
-	}
-
-	// Acquire lock for target user
-	h.userLock.Lock(targetID)
-	defer h.userLock.Unlock(targetID)
-
-	// Subtract balance (negative amount)
-	desc := fmt.Sprintf("管理员 %d 扣除", sender.ID)
-	user, err := h.accountService.UpdateBalance(ctx, targetID, -amount, model.TxTypeAdminSub, &desc)
-	if err != nil {
-		return c.Reply("❌ 操作失败，用户可能不存在")
-	}
-
-	// Log admin operation (Requirements: 6.5)
-	log.Info().
-		Int64("admin_id", sender.ID).
-		Int64("target_id", targetID).
-		Int64("amount", amount).
-		Str("operation", "admin_sub").
-		Msg("Admin operation executed")
-
-	displayName := user.Username
-	if displayName == "" {
-		displayName = fmt.Sprintf("%d", targetID)
-	}
-
-	return c.Reply(fmt.Sprintf(
-		"✅ 操作成功\n\n"+
-			"👤 用户: %s (ID: %d)\n"+
-			"➖ 扣除: %d 金币\n"+
-			"💰 当前余额: %d 金币",
-		displayName, targetID, amount, user.Balance,
-	))
+	return h.previewAdjustment(c, model.TxTypeAdminSub)
 }
 
 // HandleAdminSet handles the /admin_set command.
-// Format: /admin_set <user_id> <amount>
+// Format: /admin_set <user_id> <amount> <reason>
 // Requirements: 6.3, 6.5
 func (h *AdminHandler) HandleAdminSet(c tele.Context) error {
-	ctx := context.Background()
-	sender := c.Sender()
-	if sender == nil {
-		return nil
-	}
-
-	// Parse arguments
-	targetID, newBalance, err := h.parseAdminArgs(c)
-	if err != nil {
-		return c.Reply(err.Error())
-	}
-
-	if newBalance < 0 {
-		return c.Reply("❌ 余额不能为负数")
-	}
-
-	// Acquire lock for target user
-	h.userLock.Lock(targetID)
-	defer h.userLock.Unlock(targetID)
-
-	// Get current balance
-	currentBalance, err := h.accountService.GetBalance(ctx, targetID)
-	if err != nil {
-		return c.Reply("❌ 用户不存在")
-	}
-
-	// Calculate difference and update
-	diff := newBalance - currentBalance
-	desc := fmt.Sprintf("管理员 %d 设置余额", sender.ID)
-	user, err := h.accountService.UpdateBalance(ctx, targetID, diff, model.TxTypeAdminSet, &desc)
-	if err != nil {
-		return c.Reply("❌ 操作失败，请稍后重试")
-	}
-
-	// Log admin operation (Requirements: 6.5)
-	log.Info().
-		Int64("admin_id", sender.ID).
-		Int64("target_id", targetID).
-		Int64("old_balance", currentBalance).
-		Int64("new_balance", newBalance).
-		Str("operation", "admin_set").
-		Msg("Admin operation executed")
-
-	displayName := user.Username
-	if displayName == "" {
-		displayName = fmt.Sprintf("%d", targetID)
-	}
-
-	return c.Reply(fmt.Sprintf(
-		"✅ 操作成功\n\n"+
-			"👤 用户: %s (ID: %d)\n"+
-			"📝 原余额: %d 金币\n"+
-			"💰 新余额: %d 金币",
-		displayName, targetID, currentBalance, user.Balance,
-	))
+	return h.previewAdjustment(c, model.TxTypeAdminSet)
 }
 
 // parseAdminArgs parses admin command arguments.
-// Format: <user_id> <amount>
-// Returns targetID, amount, error
-func (h *AdminHandler) parseAdminArgs(c tele.Context) (int64, int64, error) {
+// Format: <user_id> <amount> <reason>
+// Returns targetID, amount, reason, error
+func (h *AdminHandler) parseAdminArgs(c tele.Context, op string) (int64, int64, string, error) {
 	args := c.Args()
 	if len(args) < 2 {
-		return 0, 0, fmt.Errorf("❌ 用法: /admin_add <用户ID> <金额>\n例如: /admin_add 123456789 100")
+		return 0, 0, "", fmt.Errorf("❌ 用法: /%s <用户ID> <金额> <原因>\n例如: /%s 123456789 100 活动补偿", op, op)
 	}
 
 	// Parse target user ID
 	targetID, err := strconv.ParseInt(args[0], 10, 64)
 	if err != nil {
-		return 0, 0, fmt.Errorf("❌ 用户ID格式错误，请输入数字")
+		return 0, 0, "", fmt.Errorf("❌ 用户ID格式错误，请输入数字")
 	}
 
 	// Parse amount
 	amount, err := strconv.ParseInt(args[1], 10, 64)
 	if err != nil {
-		return 0, 0, fmt.Errorf("❌ 金额格式错误，请输入整数")
+		return 0, 0, "", fmt.Errorf("❌ 金额格式错误，请输入整数")
 	}
 
-	return targetID, amount, nil
+	return targetID, amount, strings.Join(args[2:], " "), nil
 }
 
 // HandleAdminGiftAll handles the /admin_gift_all command.
@@ -242,7 +165,8 @@ func (h *AdminHandler) HandleAdminGiftAll(c tele.Context) error {
 	}
 
 	// Add balance to all users
-	count, err := h.accountService.AddBalanceToAllUsers(ctx, amount)
+	desc := fmt.Sprintf("管理员 %d 全员赠送", sender.ID)
+	count, err := h.accountService.AddBalanceToAllUsers(ctx, amount, &desc)
 	if err != nil {
 		return c.Reply("❌ 操作失败，请稍后重试")
 	}
@@ -262,3 +186,71 @@ func (h *AdminHandler) HandleAdminGiftAll(c tele.Context) error {
 		amount, count,
 	))
 }
+
+// HandleRefundTx handles the /refundtx command.
+// Format: /refundtx <transaction_id> [reason]
+func (h *AdminHandler) HandleRefundTx(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil || h.refundService == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) < 1 {
+		return c.Reply("❌ 用法: /refundtx 交易ID [原因]\n例如: /refundtx 1024 重复扣款")
+	}
+
+	txID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil || txID <= 0 {
+		return c.Reply("❌ 无效的交易ID")
+	}
+	reason := strings.Join(args[1:], " ")
+
+	result, err := h.refundService.RefundTransaction(ctx, txID, sender.ID, reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTxNotFound),
+			errors.Is(err, service.ErrTxNotReversible),
+			errors.Is(err, service.ErrTxAlreadyRefunded),
+			errors.Is(err, service.ErrReversalOverdraft):
+			return c.Reply("❌ " + err.Error())
+		case errors.Is(err, service.ErrUserNotFound):
+			return c.Reply("❌ 用户不存在")
+		}
+		log.Error().Err(err).Int64("tx_id", txID).Msg("Failed to refund transaction")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	return c.Reply(formatTxRefund(result))
+}
+
+// formatTxRefund formats the confirmation of a transaction refund
+func formatTxRefund(result *service.TxRefundResult) string {
+	tx := result.Transaction
+	refund := result.Refund
+
+	displayName := textfilter.Name(result.User.Username)
+	if displayName == "" {
+		displayName = fmt.Sprintf("%d", result.User.TelegramID)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("✅ 交易已撤销\n\n")
+	sb.WriteString(fmt.Sprintf("🧾 原交易: #%d %s %+d\n", tx.ID, tx.Type, tx.Amount))
+	sb.WriteString(fmt.Sprintf("↩️ 冲正交易: #%d %+d\n", *refund.RefundTxID, refund.Amount))
+	sb.WriteString(fmt.Sprintf("👤 用户: %s (ID: %d)\n", displayName, result.User.TelegramID))
+	if result.Item != nil {
+		item := result.Item
+		if item.IsDurationBased() {
+			sb.WriteString(fmt.Sprintf("%s 收回道具: %s %s\n", item.Emoji, item.Name, shop.FormatDuration(time.Duration(refund.EffectSeconds)*time.Second)))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s 收回道具: %s %d 次\n", item.Emoji, item.Name, refund.ItemUses))
+		}
+	}
+	if refund.Reason != "" {
+		sb.WriteString(fmt.Sprintf("📝 原因: %s\n", refund.Reason))
+	}
+	sb.WriteString(fmt.Sprintf("💰 当前余额: %d 金币", result.User.Balance))
+	return sb.String()
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+// Admin adjustment callbacks
+const (
+	adjustConfirmCallback = "adjust_confirm"
+	adjustCancelCallback  = "adjust_cancel"
+)
+
+// adjustmentVerbs name the admin balance operations in transaction descriptions
+var adjustmentVerbs = map[string]string{
+	model.TxTypeAdminAdd: "添加",
+	model.TxTypeAdminSub: "扣除",
+	model.TxTypeAdminSet: "设置余额",
+}
+
+// adjustmentLabels name the admin balance operations in previews
+var adjustmentLabels = map[string]string{
+	model.TxTypeAdminAdd: "➕ 添加",
+	model.TxTypeAdminSub: "➖ 扣除",
+	model.TxTypeAdminSet: "📝 设置余额",
+}
+
+// previewAdjustment replies with the effect of an admin balance command and
+// Confirm/Cancel buttons; the balance only changes once confirmed.
+func (h *AdminHandler) previewAdjustment(c tele.Context, op string) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	targetID, amount, reason, err := h.parseAdminArgs(c, op)
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+	adj := service.AdminAdjustment{
+		Op:        op,
+		AdminID:   sender.ID,
+		TargetID:  targetID,
+		Amount:    amount,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if err := adj.Validate(); err != nil {
+		if errors.Is(err, service.ErrAdjustmentReason) {
+			return c.Reply(fmt.Sprintf("❌ %s\n用法: /%s <用户ID> <金额> <原因>", err.Error(), op))
+		}
+		return c.Reply("❌ " + err.Error())
+	}
+
+	user, err := h.accountService.GetUser(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return c.Reply("❌ 用户不存在")
+		}
+		log.Error().Err(err).Int64("target_id", targetID).Msg("Failed to get adjustment target")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	h.sweepAdjustments(adj.CreatedAt)
+	id := h.nextAdjustment.Add(1)
+	h.adjustments.Store(id, adj)
+
+	log.Info().
+		Int64("admin_id", sender.ID).
+		Int64("target_id", targetID).
+		Int64("amount", amount).
+		Int64("balance", user.Balance).
+		Int64("new_balance", adj.NewBalance(user.Balance)).
+		Str("reason", reason).
+		Str("operation", op+"_preview").
+		Msg("Admin operation previewed")
+
+	markup := &tele.ReplyMarkup{}
+	data := strconv.FormatInt(id, 10)
+	markup.Inline(markup.Row(
+		markup.Data("✅ 确认", adjustConfirmCallback, data),
+		markup.Data("❌ 取消", adjustCancelCallback, data),
+	))
+	return c.Reply(fmt.Sprintf(
+		"⚠️ 请确认余额调整\n\n"+
+			"👤 用户: %s (ID: %d)\n"+
+			"%s: %d 金币\n"+
+			"💰 当前余额: %d 金币\n"+
+			"🔜 调整后: %d 金币\n"+
+			"📝 原因: %s\n\n"+
+			"%d 分钟内有效",
+		adjustmentName(user), targetID, adjustmentLabels[op], amount,
+		user.Balance, adj.NewBalance(user.Balance), reason,
+		int(service.AdminAdjustmentTTL.Minutes()),
+	), markup)
+}
+
+// HandleAdjustmentCallback handles the Confirm/Cancel buttons of a previewed
+// balance adjustment. Only the admin who typed the command may press them.
+// Data format: adjust_confirm|id, adjust_cancel|id
+func (h *AdminHandler) HandleAdjustmentCallback(c tele.Context) error {
+	ctx := context.Background()
+	callback := c.Callback()
+	sender := c.Sender()
+	if callback == nil || sender == nil {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, "\f"), "|")
+	if len(parts) != 2 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	value, ok := h.adjustments.Load(id)
+	if !ok {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作已失效", ShowAlert: true})
+	}
+	adj := value.(service.AdminAdjustment)
+	if adj.AdminID != sender.ID {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 只有发起的管理员可以确认", ShowAlert: true})
+	}
+	if !h.adjustments.CompareAndDelete(id, value) {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作已失效", ShowAlert: true})
+	}
+
+	if parts[0] == adjustCancelCallback {
+		log.Info().
+			Int64("admin_id", sender.ID).
+			Int64("target_id", adj.TargetID).
+			Int64("amount", adj.Amount).
+			Str("operation", adj.Op+"_cancel").
+			Msg("Admin operation cancelled")
+		if err := c.Edit("🚫 已取消余额调整"); err != nil {
+			log.Debug().Err(err).Msg("Failed to edit adjustment preview")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "已取消"})
+	}
+	if adj.Expired(time.Now()) {
+		if err := c.Edit("⌛ 余额调整已过期，请重新输入命令"); err != nil {
+			log.Debug().Err(err).Msg("Failed to edit adjustment preview")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作已过期", ShowAlert: true})
+	}
+
+	text, err := h.executeAdjustment(ctx, adj)
+	if err != nil {
+		text = "❌ " + err.Error()
+	}
+	if err := c.Edit(text); err != nil {
+		log.Debug().Err(err).Msg("Failed to edit adjustment preview")
+	}
+	return c.Respond()
+}
+
+// executeAdjustment applies a confirmed adjustment to the current balance of
+// its target and returns the confirmation text.
+func (h *AdminHandler) executeAdjustment(ctx context.Context, adj service.AdminAdjustment) (string, error) {
+	h.userLock.Lock(adj.TargetID)
+	defer h.userLock.Unlock(adj.TargetID)
+
+	current, err := h.accountService.GetBalance(ctx, adj.TargetID)
+	if err != nil {
+		return "", errors.New("用户不存在")
+	}
+
+	desc := fmt.Sprintf("管理员 %d %s: %s", adj.AdminID, adjustmentVerbs[adj.Op], adj.Reason)
+	user, err := h.accountService.UpdateBalance(ctx, adj.TargetID, adj.Delta(current), adj.Op, &desc, "")
+	if err != nil {
+		log.Error().Err(err).Int64("target_id", adj.TargetID).Str("operation", adj.Op).Msg("Admin operation failed")
+		return "", errors.New("操作失败，请稍后重试")
+	}
+
+	// Log admin operation (Requirements: 6.5)
+	log.Info().
+		Int64("admin_id", adj.AdminID).
+		Int64("target_id", adj.TargetID).
+		Int64("amount", adj.Amount).
+		Int64("old_balance", current).
+		Int64("new_balance", user.Balance).
+		Str("reason", adj.Reason).
+		Str("operation", adj.Op).
+		Msg("Admin operation executed")
+
+	return fmt.Sprintf(
+		"✅ 操作成功\n\n"+
+			"👤 用户: %s (ID: %d)\n"+
+			"%s: %d 金币\n"+
+			"📝 原余额: %d 金币\n"+
+			"💰 当前余额: %d 金币\n"+
+			"📝 原因: %s",
+		adjustmentName(user), adj.TargetID, adjustmentLabels[adj.Op], adj.Amount,
+		current, user.Balance, adj.Reason,
+	), nil
+}
+
+// sweepAdjustments drops the previews that can no longer be confirmed
+func (h *AdminHandler) sweepAdjustments(now time.Time) {
+	h.adjustments.Range(func(key, value any) bool {
+		if value.(service.AdminAdjustment).Expired(now) {
+			h.adjustments.Delete(key)
+		}
+		return true
+	})
+}
+
+// adjustmentName returns the display name of an adjusted user
+func adjustmentName(user *model.User) string {
+	if name := textfilter.Name(user.Username); name != "" {
+		return name
+	}
+	return strconv.FormatInt(user.TelegramID, 10)
+}
@@ -5,12 +5,15 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	tele "gopkg.in/telebot.v3"
 
 	"telegram-game-bot/internal/game/allin"
+	"telegram-game-bot/internal/pkg/cooldown"
 	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/tgfmt"
 	"telegram-game-bot/internal/service"
 )
 
@@ -19,6 +22,7 @@ type AllInHandler struct {
 	accountService *service.AccountService
 	allInGame      *allin.AllInGame
 	userLock       *lock.UserLock
+	cooldowns      *cooldown.Manager // optional, shared aggression cooldown
 }
 
 // NewAllInHandler creates a new AllInHandler.
@@ -34,6 +38,26 @@ func NewAllInHandler(
 	}
 }
 
+// SetCooldowns sets the cooldown manager holding the aggression cooldown shared with /dj.
+func (h *AllInHandler) SetCooldowns(cooldowns *cooldown.Manager) {
+	h.cooldowns = cooldowns
+}
+
+// aggressionWait returns how long the user must wait before the attack, 0 if allowed
+func (h *AllInHandler) aggressionWait(userID int64, action string) time.Duration {
+	if h.cooldowns == nil {
+		return 0
+	}
+	return h.cooldowns.BucketWait(userID, CooldownAggression, action)
+}
+
+// chargeAggression adds an attack to the user's aggression cooldown
+func (h *AllInHandler) chargeAggression(userID int64, action string) {
+	if h.cooldowns != nil {
+		h.cooldowns.Charge(userID, CooldownAggression, action)
+	}
+}
+
 // HandleAllInRob handles the /shdj command for all-in robbery.
 func (h *AllInHandler) HandleAllInRob(c tele.Context) error {
 	ctx := context.Background()
@@ -45,10 +69,7 @@ func (h *AllInHandler) HandleAllInRob(c tele.Context) error {
 	}
 
 	// Get robber's username
-	robberName := sender.Username
-	if robberName == "" {
-		robberName = sender.FirstName
-	}
+	robberName := senderName(sender)
 
 	// Ensure robber exists
 	_, _, err := h.accountService.EnsureUser(ctx, sender.ID, robberName)
@@ -63,10 +84,7 @@ func (h *AllInHandler) HandleAllInRob(c tele.Context) error {
 	// Check if replying to a message
 	if c.Message().ReplyTo != nil && c.Message().ReplyTo.Sender != nil {
 		victimID = c.Message().ReplyTo.Sender.ID
-		victimName = c.Message().ReplyTo.Sender.Username
-		if victimName == "" {
-			victimName = c.Message().ReplyTo.Sender.FirstName
-		}
+		victimName = senderName(c.Message().ReplyTo.Sender)
 	} else {
 		return c.Reply("❌ 用法: 回复目标用户的消息，然后发送 /shdj")
 	}
@@ -77,17 +95,26 @@ func (h *AllInHandler) HandleAllInRob(c tele.Context) error {
 		return c.Reply("❌ 目标用户未注册")
 	}
 
+	if remaining := h.aggressionWait(sender.ID, attackAllInRob); remaining > 0 {
+		return c.Reply(aggressionMessage(remaining))
+	}
+
 	// Execute all-in robbery
 	result, err := h.allInGame.AllInRob(ctx, sender.ID, victimID, robberName, victimName)
 	if err != nil {
 		log.Error().Err(err).Int64("robber", sender.ID).Int64("victim", victimID).Msg("All-in robbery failed")
 		return c.Reply("❌ " + err.Error())
 	}
+	if result.Attempted() {
+		h.chargeAggression(sender.ID, attackAllInRob)
+	}
 
-	return c.Reply(result.Message)
+	itemsLeft := formatItemsLeft(result.ItemsUsed, map[int64]string{victimID: victimName})
+	return c.Reply(result.Message + itemsLeft)
 }
 
 // HandleDuel handles the /duijue command for duel challenge.
+// "/duijue 3" challenges to a best-of-three match with escalating stakes.
 func (h *AllInHandler) HandleDuel(c tele.Context) error {
 	ctx := context.Background()
 	sender := c.Sender()
@@ -98,10 +125,7 @@ func (h *AllInHandler) HandleDuel(c tele.Context) error {
 	}
 
 	// Get challenger's username
-	challengerName := sender.Username
-	if challengerName == "" {
-		challengerName = sender.FirstName
-	}
+	challengerName := senderName(sender)
 
 	// Ensure challenger exists
 	_, _, err := h.accountService.EnsureUser(ctx, sender.ID, challengerName)
@@ -115,12 +139,14 @@ func (h *AllInHandler) HandleDuel(c tele.Context) error {
 
 	if c.Message().ReplyTo != nil && c.Message().ReplyTo.Sender != nil {
 		targetID = c.Message().ReplyTo.Sender.ID
-		targetName = c.Message().ReplyTo.Sender.Username
-		if targetName == "" {
-			targetName = c.Message().ReplyTo.Sender.FirstName
-		}
+		targetName = senderName(c.Message().ReplyTo.Sender)
 	} else {
-		return c.Reply("❌ 用法: 回复目标用户的消息，然后发送 /duijue")
+		return c.Reply("❌ 用法: 回复目标用户的消息，然后发送 /duijue（三局两胜: /duijue 3）")
+	}
+
+	bestOf := 1
+	if args := c.Args(); len(args) > 0 && (args[0] == "3" || strings.EqualFold(args[0], "bo3")) {
+		bestOf = allin.DuelMatchRounds
 	}
 
 	// Ensure target exists
@@ -129,12 +155,17 @@ func (h *AllInHandler) HandleDuel(c tele.Context) error {
 		return c.Reply("❌ 目标用户未注册")
 	}
 
+	if remaining := h.aggressionWait(sender.ID, attackDuel); remaining > 0 {
+		return c.Reply(aggressionMessage(remaining))
+	}
+
 	// Create duel challenge
-	duel, err := h.allInGame.CreateDuel(ctx, sender.ID, targetID, challengerName, targetName, chat.ID)
+	duel, err := h.allInGame.CreateDuel(ctx, sender.ID, targetID, challengerName, targetName, chat.ID, bestOf)
 	if err != nil {
 		log.Error().Err(err).Int64("challenger", sender.ID).Int64("target", targetID).Msg("Create duel failed")
 		return c.Reply("❌ " + err.Error())
 	}
+	h.chargeAggression(sender.ID, attackDuel)
 
 	// Build inline keyboard
 	markup := &tele.ReplyMarkup{}
@@ -145,10 +176,15 @@ func (h *AllInHandler) HandleDuel(c tele.Context) error {
 	)
 
 	// Send challenge message
-	msg := fmt.Sprintf("⚔️ @%s 向 @%s 发起梭哈对决！\n\n💰 赌注: %d 金币\n⏰ 60秒内响应\n\n只有 @%s 可以接受或拒绝",
-		challengerName, targetName, duel.Amount, targetName)
+	target := tgfmt.Mention(targetID, targetName)
+	msg := tgfmt.Sprintf("⚔️ %s 向 %s 发起梭哈对决！\n\n💰 赌注: %s 金币\n⏰ 60秒内响应\n\n只有 %s 可以接受或拒绝",
+		tgfmt.Mention(sender.ID, challengerName), target, tgfmt.Amount(duel.Amount), target)
+	if duel.BestOf == allin.DuelMatchRounds {
+		msg = tgfmt.Sprintf("⚔️ %s 向 %s 发起三局两胜对决！\n\n💰 底注: 每人 %s 金币\n🔥 每局输家可选择加倍或认输\n⏰ 60秒内响应\n\n只有 %s 可以接受或拒绝",
+			tgfmt.Mention(sender.ID, challengerName), target, tgfmt.Amount(duel.Amount), target)
+	}
 
-	sentMsg, err := c.Bot().Send(chat, msg, markup)
+	sentMsg, err := sendInTopic(c, msg.String(), markup, tele.ModeHTML)
 	if err != nil {
 		return c.Reply("❌ 发送挑战失败")
 	}
@@ -188,6 +224,11 @@ func (h *AllInHandler) HandleDuelCallback(c tele.Context) error {
 	var targetID int64
 	fmt.Sscanf(targetIDStr, "%d", &targetID)
 
+	// Decisions between the rounds of a match carry the match ID instead
+	if decision, ok := duelDecisionActions[action]; ok {
+		return h.handleDuelDecision(c, targetID, decision)
+	}
+
 	log.Debug().
 		Str("action", action).
 		Int64("targetID", targetID).
@@ -213,6 +254,10 @@ func (h *AllInHandler) HandleDuelCallback(c tele.Context) error {
 
 	switch action {
 	case "duel_accept":
+		if duel.BestOf == allin.DuelMatchRounds {
+			return h.startDuelMatch(c, targetID)
+		}
+
 		// Accept and execute duel
 		result, err := h.allInGame.AcceptDuel(ctx, targetID)
 		if err != nil {
@@ -237,7 +282,8 @@ func (h *AllInHandler) HandleDuelCallback(c tele.Context) error {
 		}
 
 		// Update message
-		c.Edit(fmt.Sprintf("❌ @%s 拒绝了 @%s 的对决挑战", duel.TargetName, duel.ChallengerName))
+		c.Edit(tgfmt.Sprintf("❌ %s 拒绝了 %s 的对决挑战",
+			tgfmt.Escape(duel.TargetName), tgfmt.Mention(duel.ChallengerID, duel.ChallengerName)).String(), tele.ModeHTML)
 		return c.Respond(&tele.CallbackResponse{Text: "已拒绝对决"})
 	}
 
@@ -254,10 +300,7 @@ func (h *AllInHandler) HandleAllInDice(c tele.Context) error {
 	}
 
 	// Get username
-	username := sender.Username
-	if username == "" {
-		username = sender.FirstName
-	}
+	username := senderName(sender)
 
 	// Ensure user exists
 	_, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/service"
+)
+
+// BalanceAlertHandler lets users opt in to DM alerts about large balance changes.
+type BalanceAlertHandler struct {
+	alerts *service.BalanceAlertService
+}
+
+// NewBalanceAlertHandler creates a new BalanceAlertHandler.
+func NewBalanceAlertHandler(alerts *service.BalanceAlertService) *BalanceAlertHandler {
+	return &BalanceAlertHandler{alerts: alerts}
+}
+
+// HandleAlerts handles the /alerts command.
+// Format: /alerts [on|off|阈值]
+func (h *BalanceAlertHandler) HandleAlerts(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	minThreshold := h.alerts.MinThreshold()
+	if minThreshold <= 0 {
+		return c.Reply("❌ " + service.ErrBalanceAlertsDisabled.Error())
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		threshold, err := h.alerts.Threshold(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		status := "未开启"
+		if threshold > 0 {
+			status = fmt.Sprintf("已开启（阈值 %d 金币）", threshold)
+		}
+		return c.Reply(fmt.Sprintf(
+			"🔔 余额变动提醒: %s\n\n"+
+				"离开群聊期间被打劫、管理员调整余额、获得突袭奖池或退款等变动达到阈值时，机器人会私聊通知你\n\n"+
+				"📖 用法:\n"+
+				"/alerts on - 开启（默认阈值 %d 金币）\n"+
+				"/alerts 金额 - 开启并设置阈值（不低于 %d）\n"+
+				"/alerts off - 关闭\n\n"+
+				"⚠️ 请先私聊机器人发送 /start，否则无法收到提醒",
+			status, minThreshold, minThreshold,
+		))
+	}
+
+	var threshold int64
+	switch arg := strings.ToLower(args[0]); arg {
+	case "off":
+		if err := h.alerts.Disable(ctx, sender.ID); err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to disable balance alerts")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 已关闭余额变动提醒")
+	case "on":
+	default:
+		amount, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || amount <= 0 {
+			return c.Reply("❌ 用法: /alerts on | off | 金额")
+		}
+		threshold = amount
+	}
+
+	threshold, err := h.alerts.Enable(ctx, sender.ID, threshold)
+	if err != nil {
+		if errors.Is(err, service.ErrAlertThresholdTooLow) {
+			return c.Reply(fmt.Sprintf("❌ %s，最低 %d 金币", err.Error(), minThreshold))
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to enable balance alerts")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	return c.Reply(fmt.Sprintf("✅ 已开启余额变动提醒\n\n💰 阈值: %d 金币\n⚠️ 请确认已私聊机器人发送 /start", threshold))
+}
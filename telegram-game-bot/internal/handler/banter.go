@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+)
+
+// banterUsage explains the /banter subcommands
+const banterUsage = "📖 用法:\n" +
+	"/banter - 查看趣味短语\n" +
+	"/banter add win|lose 语言 短语 - 添加短语，例如 /banter add lose zh 下次一定\n" +
+	"/banter del 编号 - 删除短语\n" +
+	"群组开关: /flag banter chat 群ID on|off"
+
+// banterKindLabels names the banter kinds
+var banterKindLabels = map[string]string{
+	model.BanterWin:  "🎉 赢了",
+	model.BanterLose: "😜 输了",
+}
+
+// BanterHandler lets admins manage the phrases appended to game results.
+type BanterHandler struct {
+	banter *service.BanterService
+}
+
+// NewBanterHandler creates a new BanterHandler.
+func NewBanterHandler(banter *service.BanterService) *BanterHandler {
+	return &BanterHandler{banter: banter}
+}
+
+// HandleBanter handles the /banter admin command.
+// Format: /banter [add kind locale text | del id]
+func (h *BanterHandler) HandleBanter(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		phrases, err := h.banter.List(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list banter phrases")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(formatBanterPhrases(phrases))
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) < 4 {
+			return c.Reply(banterUsage)
+		}
+		text := strings.Join(args[3:], " ")
+		phrase, err := h.banter.Add(ctx, strings.ToLower(args[1]), args[2], text, sender.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrBanterUnknownKind), errors.Is(err, service.ErrBanterLocale),
+				errors.Is(err, service.ErrBanterEmpty), errors.Is(err, service.ErrBanterBlocked):
+				return c.Reply("❌ " + err.Error())
+			case errors.Is(err, service.ErrBanterTooLong):
+				return c.Reply(fmt.Sprintf("❌ %s，最多 %d 个字且只能一行", err.Error(), service.BanterMaxRunes))
+			}
+			log.Error().Err(err).Msg("Failed to add banter phrase")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已添加短语 #%d (%s / %s)", phrase.ID, banterKindLabels[phrase.Kind], phrase.Locale))
+
+	case "del":
+		if len(args) < 2 {
+			return c.Reply(banterUsage)
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return c.Reply("❌ 编号格式错误")
+		}
+		if err := h.banter.Delete(ctx, id, sender.ID); err != nil {
+			if errors.Is(err, service.ErrBanterNotFound) {
+				return c.Reply("❌ " + err.Error())
+			}
+			log.Error().Err(err).Int64("phrase_id", id).Msg("Failed to delete banter phrase")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已删除短语 #%d", id))
+	}
+	return c.Reply(banterUsage)
+}
+
+// formatBanterPhrases formats the phrases of all kinds and locales
+func formatBanterPhrases(phrases []model.BanterPhrase) string {
+	var sb strings.Builder
+	sb.WriteString("💬 趣味短语\n━━━━━━━━━━━━━━━\n")
+	if len(phrases) == 0 {
+		sb.WriteString("暂无短语\n")
+	}
+	for _, p := range phrases {
+		sb.WriteString(fmt.Sprintf("#%d %s [%s] %s\n", p.ID, banterKindLabels[p.Kind], p.Locale, p.Text))
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString(banterUsage)
+	return sb.String()
+}
+
+// SetBanter sets the service appending phrases to game results
+func (h *BaseHandler) SetBanter(banter *service.BanterService) {
+	h.banter = banter
+}
+
+// banterLine returns a phrase to append to a game result of a player, as a
+// line of its own, or "" if the chat gets none this time
+func (h *BaseHandler) banterLine(ctx context.Context, chatID int64, sender *tele.User, won bool) string {
+	if h.banter == nil || sender == nil || !h.featureEnabled(service.FlagBanter, chatID, 0, false) {
+		return ""
+	}
+	kind := model.BanterLose
+	if won {
+		kind = model.BanterWin
+	}
+	if line := h.banter.Line(ctx, chatID, kind, sender.LanguageCode); line != "" {
+		return "\n💬 " + line
+	}
+	return ""
+}
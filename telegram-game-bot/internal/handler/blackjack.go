@@ -0,0 +1,558 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/blackjack"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+// Blackjack settings
+const (
+	blackjackTickInterval = 5 * time.Second // 检查闲置手牌的间隔
+	blackjackHit          = "bj_hit"        // 要牌按钮的回调
+	blackjackStand        = "bj_stand"      // 停牌按钮的回调
+	blackjackDouble       = "bj_double"     // 加倍按钮的回调
+)
+
+// blackjackSeat is the handler's state of a hand in play
+type blackjackSeat struct {
+	scope    model.BalanceScope // Balance the bet was paid from
+	chatID   int64
+	threadID int    // 论坛话题ID（0表示不在话题中）
+	msgID    int    // 牌面消息ID（0表示消息未发送）
+	name     string // Player display name
+}
+
+// BlackjackHandler handles blackjack hands, dealt with /blackjack and played
+// with the hit, stand and double buttons of the hand message.
+type BlackjackHandler struct {
+	*BaseHandler
+	blackjackGame  *blackjack.BlackjackGame            // Optional: blackjack
+	blackjackSeats sync.Map                            // map[int64]*blackjackSeat - userID -> hand state
+	blackjackHands *repository.BlackjackHandRepository // Optional: stakes of hands in play, refunded after a restart
+}
+
+// NewBlackjackHandler creates a new BlackjackHandler. Blackjack stays closed until SetBlackjack.
+func NewBlackjackHandler(base *BaseHandler) *BlackjackHandler {
+	return &BlackjackHandler{BaseHandler: base}
+}
+
+// SetBlackjack sets the blackjack game
+func (h *BlackjackHandler) SetBlackjack(blackjackGame *blackjack.BlackjackGame) {
+	h.blackjackGame = blackjackGame
+}
+
+// SetBlackjackHands sets the repository keeping the stakes of hands in play
+func (h *BlackjackHandler) SetBlackjackHands(hands *repository.BlackjackHandRepository) {
+	h.blackjackHands = hands
+}
+
+// HandleBlackjack handles the /blackjack command dealing a hand.
+// Format: /blackjack [金额]
+func (h *BlackjackHandler) HandleBlackjack(c tele.Context) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 21点只能在群组中进行，请加入群组后使用")
+	}
+
+	// Parse bet amount, falling back to the default stake
+	bet, err := h.parseStake(ctx, c, "blackjack")
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+
+	if remaining := h.checkCooldown(sender.ID, "blackjack"); remaining > 0 {
+		return c.Reply(cooldownMessage(remaining))
+	}
+	if _, err := h.blackjackGame.Hand(sender.ID); err == nil {
+		return c.Reply("❌ 你还有一手牌没打完")
+	}
+
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	scope := h.balanceScope(ctx, chat.ID)
+
+	h.userLock.Lock(sender.ID)
+	hand, err := h.dealBlackjack(ctx, scope, chat.ID, sender.ID, bet)
+	h.userLock.Unlock(sender.ID)
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+
+	h.setCooldown(sender.ID, "blackjack", h.blackjackGame.Cooldown())
+	h.recordWager(chat.ID, bet)
+
+	seat := &blackjackSeat{scope: scope, chatID: chat.ID, threadID: topicOf(c), name: username}
+	if hand.Finished {
+		// A natural was dealt, the hand is over before any button
+		h.finishBlackjack(ctx, c.Bot(), hand, seat, user)
+		return nil
+	}
+
+	persona := h.chatPersona(ctx, chat.ID)
+	msg, err := sendInTopic(c, formatBlackjackHand(persona, scope, hand, username).String(), tele.ModeHTML, blackjackMarkup(hand))
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to send blackjack hand")
+	} else {
+		h.trackMessage(chat.ID, msg.ID)
+		seat.msgID = msg.ID
+	}
+	h.blackjackSeats.Store(sender.ID, seat)
+
+	log.Info().
+		Int64("chat_id", chat.ID).
+		Int64("user_id", sender.ID).
+		Int64("bet", bet).
+		Msg("Blackjack hand dealt")
+	return nil
+}
+
+// HandleBlackjackCallback handles the hit, stand and double buttons of a hand.
+// Data format: bj_hit|userID
+func (h *BlackjackHandler) HandleBlackjackCallback(c tele.Context) error {
+	ctx := context.Background()
+	callback := c.Callback()
+	sender := c.Sender()
+	if callback == nil || sender == nil {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, "\f"), "|")
+	if len(parts) != 2 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	if sender.ID != userID {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 这不是你的牌", ShowAlert: true})
+	}
+
+	var hand *blackjack.Hand
+	var reply string
+	switch parts[0] {
+	case blackjackHit:
+		hand, err = h.blackjackGame.Hit(userID)
+		reply = "🃏 要牌"
+	case blackjackStand:
+		hand, err = h.blackjackGame.Stand(userID)
+		reply = "✋ 停牌"
+	case blackjackDouble:
+		hand, err = h.doubleBlackjack(ctx, userID)
+		reply = "💰 加倍"
+	default:
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	if err != nil {
+		text := err.Error()
+		switch {
+		case errors.Is(err, blackjack.ErrNoHand):
+			text = "❌ 这手牌已结束"
+		case errors.Is(err, blackjack.ErrCannotDouble):
+			text = "❌ 只有前两张牌可以加倍"
+		}
+		return c.Respond(&tele.CallbackResponse{Text: text, ShowAlert: true})
+	}
+
+	seat := h.blackjackSeat(ctx, hand)
+	if hand.Finished {
+		user, _ := h.accountService.GetUser(ctx, userID)
+		h.finishBlackjack(ctx, c.Bot(), hand, seat, user)
+	} else if seat.msgID != 0 {
+		persona := h.chatPersona(ctx, seat.chatID)
+		editMsg := &tele.Message{ID: seat.msgID, Chat: &tele.Chat{ID: seat.chatID}}
+		if _, err := c.Bot().Edit(editMsg, formatBlackjackHand(persona, seat.scope, hand, seat.name).String(), tele.ModeHTML, blackjackMarkup(hand)); err != nil {
+			log.Debug().Err(err).Int64("user_id", userID).Msg("Failed to refresh blackjack hand")
+		}
+	}
+
+	return c.Respond(&tele.CallbackResponse{Text: reply})
+}
+
+// StartBlackjackScheduler starts the loop standing hands left without a
+// button press, so every stake is settled.
+func (h *BlackjackHandler) StartBlackjackScheduler(bot *tele.Bot) {
+	idle := time.Duration(h.cfg.Games.Blackjack.IdleSeconds) * time.Second
+	go func() {
+		ticker := time.NewTicker(blackjackTickInterval)
+		heartbeat.Start("blackjack", blackjackTickInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("blackjack")
+			for _, userID := range h.blackjackGame.Idle(now.Add(-idle)) {
+				hand, err := h.blackjackGame.Stand(userID)
+				if err != nil {
+					continue // Finished with a button meanwhile
+				}
+				start := time.Now()
+				ctx := context.Background()
+				user, _ := h.accountService.GetUser(ctx, userID)
+				h.finishBlackjack(ctx, bot, hand, h.blackjackSeat(ctx, hand), user)
+				h.observeJob("job:blackjack_stand", start)
+			}
+		}
+	}()
+}
+
+// dealBlackjack checks the balance tier and balance, deducts the bet and
+// deals a hand; the caller must hold the user lock.
+// On failure the returned error text is the reply for the user.
+func (h *BlackjackHandler) dealBlackjack(ctx context.Context, scope model.BalanceScope, chatID, userID, bet int64) (*blackjack.Hand, error) {
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, userID)
+	if err != nil {
+		return nil, errors.New("❌ 获取余额失败")
+	}
+
+	maxBet := h.getEffectiveMaxBet(balance, h.cfg.Games.Blackjack.MaxBet)
+	if bet > maxBet {
+		tierMaxBet, tierThreshold := getBalanceTierInfo(balance)
+		if tierThreshold > 0 {
+			return nil, fmt.Errorf("❌ 余额超过 %d，单次下注上限为 %d", tierThreshold, tierMaxBet)
+		}
+		return nil, fmt.Errorf("❌ 最大下注金额为 %d", maxBet)
+	}
+	if bet > h.blackjackGame.MaxBet() {
+		return nil, fmt.Errorf("❌ 21点最大下注金额为 %d", h.blackjackGame.MaxBet())
+	}
+	if balance < bet {
+		return nil, errors.New("❌ 余额不足")
+	}
+
+	desc := fmt.Sprintf("21点下注 %d", bet)
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, -bet, model.TxTypeBlackjack, &desc); err != nil {
+		return nil, errors.New("❌ 扣款失败，请稍后重试")
+	}
+
+	hand, err := h.blackjackGame.Deal(chatID, userID, bet)
+	if err != nil {
+		h.refundBlackjack(ctx, scope, userID, bet, "21点发牌失败后退还下注失败")
+		if errors.Is(err, blackjack.ErrHandExists) {
+			return nil, errors.New("❌ 你还有一手牌没打完")
+		}
+		log.Error().Err(err).Int64("user_id", userID).Msg("Failed to deal blackjack hand")
+		return nil, errors.New("❌ 发牌失败，请稍后重试")
+	}
+	if !hand.Finished {
+		h.openBlackjackHand(ctx, scope, hand)
+	}
+	return hand, nil
+}
+
+// doubleBlackjack deducts the extra bet of a double down and doubles the
+// hand. Errors other than the game's are the reply for the user.
+func (h *BlackjackHandler) doubleBlackjack(ctx context.Context, userID int64) (*blackjack.Hand, error) {
+	h.userLock.Lock(userID)
+	defer h.userLock.Unlock(userID)
+
+	current, err := h.blackjackGame.Hand(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(current.Player) != 2 {
+		return nil, blackjack.ErrCannotDouble
+	}
+	seat := h.blackjackSeat(ctx, current)
+
+	balance, err := h.accountService.GetBalanceIn(ctx, seat.scope, userID)
+	if err != nil {
+		return nil, errors.New("❌ 获取余额失败")
+	}
+	if balance < current.Bet {
+		return nil, fmt.Errorf("❌ 余额不足，加倍需要 %d", current.Bet)
+	}
+
+	desc := fmt.Sprintf("21点加倍 %d", current.Bet)
+	if _, err := h.accountService.UpdateBalanceIn(ctx, seat.scope, userID, -current.Bet, model.TxTypeBlackjack, &desc); err != nil {
+		return nil, errors.New("❌ 扣款失败，请稍后重试")
+	}
+
+	hand, err := h.blackjackGame.Double(userID)
+	if err != nil {
+		h.refundBlackjack(ctx, seat.scope, userID, current.Bet, "21点加倍失败后退还失败")
+		return nil, err
+	}
+	h.recordWager(seat.chatID, current.Bet)
+	if h.blackjackHands != nil {
+		if err := h.blackjackHands.SetStake(ctx, userID, hand.Stake()); err != nil {
+			log.Error().Err(err).Int64("user_id", userID).Msg("Failed to record doubled blackjack stake")
+		}
+	}
+	return hand, nil
+}
+
+// finishBlackjack pays a finished hand and shows the outcome in place of the
+// hand message. Credits that fail are reported for compensation.
+func (h *BlackjackHandler) finishBlackjack(ctx context.Context, bot *tele.Bot, hand *blackjack.Hand, seat *blackjackSeat, user *model.User) {
+	h.blackjackSeats.Delete(hand.UserID)
+	h.closeBlackjackHand(ctx, hand.UserID)
+
+	result, err := h.blackjackGame.Play(ctx, hand.UserID, hand.Bet, map[string]any{
+		"player":  hand.Player,
+		"dealer":  hand.Dealer,
+		"doubled": hand.Doubled,
+	})
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", hand.UserID).Msg("Failed to settle blackjack hand")
+		h.userLock.Lock(hand.UserID)
+		h.refundBlackjack(ctx, seat.scope, hand.UserID, hand.Stake(), "21点结算失败后退还下注失败")
+		h.userLock.Unlock(hand.UserID)
+		h.showBlackjack(bot, seat, tgfmt.Escape("❌ 21点结算失败，已退还下注"))
+		return
+	}
+
+	payout := result.Payout
+	if payout >= 0 {
+		credit := hand.Stake() + payout
+		desc := fmt.Sprintf("21点赢得 %d", payout)
+		h.userLock.Lock(hand.UserID)
+		if _, err := h.accountService.UpdateBalanceIn(ctx, seat.scope, hand.UserID, credit, model.TxTypeBlackjack, &desc); err != nil {
+			h.reportIncidentIn(seat.scope, service.IncidentCreditFailed, "21点奖金到账失败",
+				service.CompensationClaim{UserID: hand.UserID, Amount: credit})
+		}
+		h.userLock.Unlock(hand.UserID)
+		h.recordWin(seat.chatID, user, payout)
+		h.publishWin(seat.chatID, user, "blackjack", payout)
+	}
+	newBalance, _ := h.accountService.GetBalanceIn(ctx, seat.scope, hand.UserID)
+
+	persona := h.chatPersona(ctx, seat.chatID)
+	vars := service.PersonaVars{User: seat.name, Amount: payout, Balance: newBalance, Game: "21点"}
+	var outcome string
+	switch result.Details["outcome"] {
+	case blackjack.OutcomeBlackjack:
+		outcome = service.RenderOutcome(persona, true, "🃏 Blackjack！赢得 {amount} 金币！", vars)
+	case blackjack.OutcomeWin:
+		outcome = service.RenderOutcome(persona, true, defaultWinLine, vars)
+	case blackjack.OutcomePush:
+		outcome = "😐 平局，返还下注"
+	case blackjack.OutcomeBust:
+		vars.Amount = hand.Stake()
+		outcome = service.RenderOutcome(persona, false, "💥 爆牌！输了 {amount} 金币", vars)
+	default:
+		vars.Amount = hand.Stake()
+		outcome = service.RenderOutcome(persona, false, defaultLoseLine, vars)
+	}
+	msg := formatBlackjackHand(persona, seat.scope, hand, seat.name) +
+		tgfmt.Sprintf("\n%s\n%s", outcome, h.renderBalance(persona, seat.scope, newBalance))
+	h.showBlackjack(bot, seat, msg)
+
+	log.Info().
+		Int64("chat_id", seat.chatID).
+		Int64("user_id", hand.UserID).
+		Int64("stake", hand.Stake()).
+		Int64("payout", payout).
+		Str("outcome", result.Details["outcome"].(string)).
+		Msg("Blackjack hand settled")
+}
+
+// RefundBlackjackHands refunds the hands in play, which are discarded, when
+// the bot stops; the hand messages say so in place of the buttons.
+func (h *BlackjackHandler) RefundBlackjackHands(ctx context.Context, bot *tele.Bot) {
+	if h.blackjackGame == nil {
+		return
+	}
+	for _, hand := range h.blackjackGame.Discard() {
+		seat := h.blackjackSeat(ctx, hand)
+		h.blackjackSeats.Delete(hand.UserID)
+		// Closed first, so a stop cut short here cannot refund twice
+		h.closeBlackjackHand(ctx, hand.UserID)
+		h.userLock.Lock(hand.UserID)
+		h.refundBlackjack(ctx, seat.scope, hand.UserID, hand.Stake(), "机器人停止时退还21点下注失败")
+		h.userLock.Unlock(hand.UserID)
+		h.showBlackjack(bot, seat, tgfmt.Sprintf("🃏 21点 | %s\n♻️ 机器人重启，这手牌已取消，退还下注 %d %s",
+			seat.name, hand.Stake(), coinName(seat.scope)))
+		log.Info().Int64("user_id", hand.UserID).Int64("stake", hand.Stake()).Msg("Blackjack hand refunded on shutdown")
+	}
+}
+
+// RefundOrphanedBlackjackHands refunds the hands recorded as in play that
+// this instance does not hold, left by a bot that stopped without settling
+// them. Call it before taking updates; owns selects the chats of this
+// instance so hands held by other instances are left alone.
+func (h *BlackjackHandler) RefundOrphanedBlackjackHands(ctx context.Context, owns func(chatID int64) bool) {
+	if h.blackjackHands == nil {
+		return
+	}
+	hands, err := h.blackjackHands.List(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list open blackjack hands")
+		return
+	}
+	for _, open := range hands {
+		if !owns(open.ChatID) {
+			continue
+		}
+		if _, err := h.blackjackGame.Hand(open.UserID); err == nil {
+			continue // Dealt by this instance meanwhile
+		}
+		scope := model.RealBalance
+		if open.SandboxChatID != 0 {
+			scope = model.SandboxBalance(open.SandboxChatID)
+		}
+		// Closed first, so a failed refund is compensated once instead of retried every start
+		closed, err := h.blackjackHands.Close(ctx, open.UserID)
+		if err != nil || !closed {
+			continue
+		}
+		h.userLock.Lock(open.UserID)
+		h.refundBlackjack(ctx, scope, open.UserID, open.Stake, "重启后退还21点下注失败")
+		h.userLock.Unlock(open.UserID)
+		log.Info().
+			Int64("chat_id", open.ChatID).
+			Int64("user_id", open.UserID).
+			Int64("stake", open.Stake).
+			Msg("Unsettled blackjack hand refunded")
+	}
+}
+
+// openBlackjackHand records the stake of a hand in play
+func (h *BlackjackHandler) openBlackjackHand(ctx context.Context, scope model.BalanceScope, hand *blackjack.Hand) {
+	if h.blackjackHands == nil {
+		return
+	}
+	open := &model.OpenBlackjackHand{UserID: hand.UserID, ChatID: hand.ChatID, SandboxChatID: scope.SandboxChatID, Stake: hand.Stake()}
+	if err := h.blackjackHands.Open(ctx, open); err != nil {
+		log.Error().Err(err).Int64("user_id", hand.UserID).Msg("Failed to record blackjack hand")
+	}
+}
+
+// closeBlackjackHand forgets the stake of a settled or refunded hand
+func (h *BlackjackHandler) closeBlackjackHand(ctx context.Context, userID int64) {
+	if h.blackjackHands == nil {
+		return
+	}
+	if _, err := h.blackjackHands.Close(ctx, userID); err != nil {
+		log.Error().Err(err).Int64("user_id", userID).Msg("Failed to close blackjack hand")
+	}
+}
+
+// showBlackjack replaces the hand message with msg, dropping its buttons, or
+// sends msg when the hand message was never sent
+func (h *BlackjackHandler) showBlackjack(bot *tele.Bot, seat *blackjackSeat, msg tgfmt.HTML) {
+	if bot == nil {
+		return
+	}
+	if seat.msgID != 0 {
+		editMsg := &tele.Message{ID: seat.msgID, Chat: &tele.Chat{ID: seat.chatID}}
+		if _, err := bot.Edit(editMsg, msg.String(), tele.ModeHTML); err == nil {
+			return
+		}
+	}
+	sent, err := bot.Send(&tele.Chat{ID: seat.chatID}, msg.String(), topicOptions(seat.threadID), tele.ModeHTML)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", seat.chatID).Msg("Failed to send blackjack result")
+		return
+	}
+	h.trackMessage(seat.chatID, sent.ID)
+}
+
+// blackjackSeat returns the state of a hand, rebuilt from the hand when the
+// hand message was not stored yet
+func (h *BlackjackHandler) blackjackSeat(ctx context.Context, hand *blackjack.Hand) *blackjackSeat {
+	if value, ok := h.blackjackSeats.Load(hand.UserID); ok {
+		return value.(*blackjackSeat)
+	}
+	name := strconv.FormatInt(hand.UserID, 10)
+	if user, err := h.accountService.GetUser(ctx, hand.UserID); err == nil && user != nil {
+		name = textfilter.Name(user.Username)
+	}
+	return &blackjackSeat{scope: h.balanceScope(ctx, hand.ChatID), chatID: hand.ChatID, name: name}
+}
+
+// refundBlackjack returns coins of a hand that could not be played; the
+// caller must hold the user lock
+func (h *BlackjackHandler) refundBlackjack(ctx context.Context, scope model.BalanceScope, userID, amount int64, description string) {
+	desc := "21点退还下注"
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, amount, model.TxTypeBlackjack, &desc); err != nil {
+		h.reportIncidentIn(scope, service.IncidentRefundFailed, description,
+			service.CompensationClaim{UserID: userID, Amount: amount})
+	}
+}
+
+// blackjackMarkup builds the buttons of a hand in play; doubling is offered
+// on the first two cards only
+func blackjackMarkup(hand *blackjack.Hand) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	userID := strconv.FormatInt(hand.UserID, 10)
+	row := markup.Row(
+		markup.Data("🃏 要牌", blackjackHit, userID),
+		markup.Data("✋ 停牌", blackjackStand, userID),
+	)
+	if len(hand.Player) == 2 {
+		row = append(row, markup.Data(fmt.Sprintf("💰 加倍 (%d)", hand.Bet), blackjackDouble, userID))
+	}
+	markup.Inline(row)
+	return markup
+}
+
+// formatBlackjackHand renders the cards of a hand; the dealer's hole card
+// stays hidden until the hand is finished
+func formatBlackjackHand(persona *model.ChatPersona, scope model.BalanceScope, hand *blackjack.Hand, name string) tgfmt.HTML {
+	var msg tgfmt.HTML
+	if scope.IsSandbox() {
+		msg = tgfmt.Escape(sandboxBanner + "\n")
+	}
+	msg += tgfmt.Sprintf("🃏 21点 | %s 下注 %d %s", playerName(persona, hand.UserID, name), hand.Stake(), coinName(scope))
+	if hand.Doubled {
+		msg += tgfmt.Escape("（已加倍）")
+	}
+
+	if hand.Finished {
+		msg += tgfmt.Sprintf("\n\n🤵 庄家: %s (%s)", formatCards(hand.Dealer), formatHandTotal(hand.Dealer))
+	} else {
+		msg += tgfmt.Sprintf("\n\n🤵 庄家: %s 🂠", hand.Dealer[0].String())
+	}
+	msg += tgfmt.Sprintf("\n🙋 你的牌: %s (%s)", formatCards(hand.Player), formatHandTotal(hand.Player))
+	if !hand.Finished {
+		msg += tgfmt.Escape("\n\n👉 要牌、停牌或加倍，庄家 17 点停牌，Blackjack 赔 3:2")
+	}
+	return msg
+}
+
+// formatCards lists cards separated by spaces
+func formatCards(cards []blackjack.Card) string {
+	names := make([]string, len(cards))
+	for i, c := range cards {
+		names[i] = c.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// formatHandTotal describes the total of cards: soft totals, busts and naturals are labeled
+func formatHandTotal(cards []blackjack.Card) string {
+	total, soft := blackjack.HandValue(cards)
+	switch {
+	case blackjack.IsBlackjack(cards):
+		return "Blackjack"
+	case total > blackjack.Target:
+		return fmt.Sprintf("%d 爆牌", total)
+	case soft:
+		return fmt.Sprintf("软 %d", total)
+	}
+	return strconv.Itoa(total)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+)
+
+// celebrationUsage explains the /celebrate subcommands
+const celebrationUsage = "📖 用法:\n" +
+	"/celebrate - 查看庆祝素材\n" +
+	"/celebrate add 类型 - 回复一个贴纸或动图，添加为该类型的庆祝素材\n" +
+	"/celebrate del 编号 - 删除庆祝素材"
+
+// celebrationKindLabels names the celebration kinds
+var celebrationKindLabels = map[string]string{
+	model.CelebrationSlotTriple:     "🎰 老虎机三连",
+	model.CelebrationGreatSwordCrit: "⚔️ 大宝剑暴击",
+	model.CelebrationRaidVictory:    "🏁 群战突袭获胜",
+}
+
+// CelebrationHandler lets admins manage the media sent after big wins.
+type CelebrationHandler struct {
+	celebrations *service.CelebrationService
+}
+
+// NewCelebrationHandler creates a new CelebrationHandler.
+func NewCelebrationHandler(celebrations *service.CelebrationService) *CelebrationHandler {
+	return &CelebrationHandler{celebrations: celebrations}
+}
+
+// HandleCelebrate handles the /celebrate admin command.
+// Format: /celebrate [add kind | del id]
+func (h *CelebrationHandler) HandleCelebrate(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		media, err := h.celebrations.List(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list celebration media")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(formatCelebrationMedia(media))
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) < 2 {
+			return c.Reply(celebrationUsage)
+		}
+		mediaType, fileID := repliedMedia(c.Message())
+		if fileID == "" {
+			return c.Reply("❌ 请回复一个贴纸或动图使用此命令")
+		}
+		media, err := h.celebrations.Add(ctx, strings.ToLower(args[1]), mediaType, fileID, sender.ID)
+		if err != nil {
+			if errors.Is(err, service.ErrCelebrationUnknownKind) || errors.Is(err, service.ErrCelebrationUnknownType) {
+				return c.Reply("❌ " + err.Error() + "\n\n" + formatCelebrationKinds())
+			}
+			log.Error().Err(err).Msg("Failed to add celebration media")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已添加庆祝素材 #%d (%s)", media.ID, celebrationKindLabels[media.Kind]))
+
+	case "del":
+		if len(args) < 2 {
+			return c.Reply(celebrationUsage)
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return c.Reply("❌ 编号格式错误")
+		}
+		if err := h.celebrations.Delete(ctx, id, sender.ID); err != nil {
+			if errors.Is(err, service.ErrCelebrationNotFound) {
+				return c.Reply("❌ " + err.Error())
+			}
+			log.Error().Err(err).Int64("media_id", id).Msg("Failed to delete celebration media")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已删除庆祝素材 #%d", id))
+	}
+	return c.Reply(celebrationUsage)
+}
+
+// repliedMedia returns the type and file ID of the sticker or animation the
+// message replies to, an empty file ID if it replies to neither
+func repliedMedia(msg *tele.Message) (string, string) {
+	if msg == nil || msg.ReplyTo == nil {
+		return "", ""
+	}
+	switch {
+	case msg.ReplyTo.Sticker != nil:
+		return model.CelebrationSticker, msg.ReplyTo.Sticker.FileID
+	case msg.ReplyTo.Animation != nil:
+		return model.CelebrationAnimation, msg.ReplyTo.Animation.FileID
+	}
+	return "", ""
+}
+
+// formatCelebrationMedia formats the media of all celebration kinds
+func formatCelebrationMedia(media map[string][]model.CelebrationMedia) string {
+	var sb strings.Builder
+	sb.WriteString("🎉 庆祝素材\n━━━━━━━━━━━━━━━\n")
+	for _, kind := range service.CelebrationKinds() {
+		sb.WriteString(fmt.Sprintf("%s (%s): ", celebrationKindLabels[kind], kind))
+		if len(media[kind]) == 0 {
+			sb.WriteString("无\n")
+			continue
+		}
+		ids := make([]string, 0, len(media[kind]))
+		for _, m := range media[kind] {
+			icon := "🖼️"
+			if m.MediaType == model.CelebrationAnimation {
+				icon = "🎞️"
+			}
+			ids = append(ids, fmt.Sprintf("%s#%d", icon, m.ID))
+		}
+		sb.WriteString(strings.Join(ids, " ") + "\n")
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString(celebrationUsage)
+	return sb.String()
+}
+
+// formatCelebrationKinds lists the celebration kinds admins can add media to
+func formatCelebrationKinds() string {
+	lines := make([]string, 0, len(service.CelebrationKinds()))
+	for _, kind := range service.CelebrationKinds() {
+		lines = append(lines, fmt.Sprintf("%s - %s", kind, celebrationKindLabels[kind]))
+	}
+	return "可用类型:\n" + strings.Join(lines, "\n")
+}
+
+// CelebrationSender posts celebration media through the bot.
+type CelebrationSender struct {
+	bot *tele.Bot
+}
+
+// NewCelebrationSender creates a new CelebrationSender.
+func NewCelebrationSender(bot *tele.Bot) *CelebrationSender {
+	return &CelebrationSender{bot: bot}
+}
+
+// SendMedia sends a sticker or animation to a chat.
+func (s *CelebrationSender) SendMedia(chatID int64, mediaType, fileID string) error {
+	var what interface{}
+	switch mediaType {
+	case model.CelebrationAnimation:
+		what = &tele.Animation{File: tele.File{FileID: fileID}}
+	default:
+		what = &tele.Sticker{File: tele.File{FileID: fileID}}
+	}
+	_, err := s.bot.Send(&tele.Chat{ID: chatID}, what)
+	return err
+}
+
+// SetCelebrations sets the service celebrating big wins with media
+func (h *BaseHandler) SetCelebrations(celebrations *service.CelebrationService) {
+	h.celebrations = celebrations
+}
+
+// celebrate posts celebration media for a big win in a chat (best effort)
+func (h *BaseHandler) celebrate(ctx context.Context, chatID int64, kind string) {
+	if h.celebrations == nil || !h.featureEnabled(service.FlagCelebrations, chatID, 0, true) {
+		return
+	}
+	h.celebrations.Celebrate(ctx, chatID, kind)
+}
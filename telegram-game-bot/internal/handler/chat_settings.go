@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/roles"
+	"telegram-game-bot/internal/service"
+)
+
+// Setup wizard callbacks
+const (
+	setupGamesCallback = "setup_games"
+	setupTZCallback    = "setup_tz"
+	setupCleanCallback = "setup_clean"
+	setupQuickCallback = "setup_quick"
+	setupQuietCallback = "setup_quiet"
+	setupDoneCallback  = "setup_done"
+)
+
+// ChatSettingsHandler posts the setup wizard in newly allowed groups and
+// lets their admins change the chat settings with its buttons.
+type ChatSettingsHandler struct {
+	cfg       *config.Config
+	settings  *service.ChatSettingsService
+	whitelist *service.WhitelistService
+}
+
+// NewChatSettingsHandler creates a new ChatSettingsHandler.
+func NewChatSettingsHandler(cfg *config.Config, settings *service.ChatSettingsService, whitelist *service.WhitelistService) *ChatSettingsHandler {
+	return &ChatSettingsHandler{cfg: cfg, settings: settings, whitelist: whitelist}
+}
+
+// HandleAddedToGroup posts the setup wizard when the bot joins an allowed
+// group that was never onboarded.
+func (h *ChatSettingsHandler) HandleAddedToGroup(c tele.Context) error {
+	chat := c.Chat()
+	if chat == nil || chat.Type == tele.ChatPrivate || !h.whitelist.IsChatAllowed(chat.ID) {
+		return nil
+	}
+	h.onboard(c.Bot(), chat)
+	return nil
+}
+
+// OnboardWhitelisted posts the setup wizard in the whitelisted chats that
+// were never onboarded, so chats added to the config whitelist are set up on
+// the next start. Chats added with /whitelist are onboarded right away.
+func (h *ChatSettingsHandler) OnboardWhitelisted(bot *tele.Bot) {
+	for _, chatID := range h.whitelist.Chats(context.Background()) {
+		h.Onboard(bot, chatID)
+	}
+}
+
+// Onboard posts the setup wizard in a chat that was never onboarded.
+func (h *ChatSettingsHandler) Onboard(bot *tele.Bot, chatID int64) {
+	h.onboard(bot, &tele.Chat{ID: chatID})
+}
+
+// onboard posts the setup wizard in a chat once
+func (h *ChatSettingsHandler) onboard(bot *tele.Bot, chat *tele.Chat) {
+	ctx := context.Background()
+	onboarded, err := h.settings.Onboarded(ctx, chat.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to check chat onboarding")
+		return
+	}
+	if onboarded {
+		return
+	}
+
+	settings, err := h.settings.Get(ctx, chat.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get chat settings")
+		return
+	}
+	text := "👋 感谢使用！请群管理员完成以下设置（之后可通过 /settings 修改）\n\n" + formatChatSettings(settings)
+	if _, err := bot.Send(chat, text, chatSettingsMarkup(settings)); err != nil {
+		log.Warn().Err(err).Int64("chat_id", chat.ID).Msg("Failed to post setup wizard")
+		return
+	}
+	if err := h.settings.MarkOnboarded(ctx, chat.ID); err != nil {
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to mark chat onboarded")
+		return
+	}
+	log.Info().Int64("chat_id", chat.ID).Msg("Setup wizard posted")
+}
+
+// HandleSettings handles the /settings command: reopens the setup wizard (group admins only).
+// "/settings quiet 23-7" sets quiet hours other than the offered ones, "off" removes them.
+func (h *ChatSettingsHandler) HandleSettings(c tele.Context) error {
+	chat := c.Chat()
+	if chat == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中使用此命令")
+	}
+	if !hasRole(c, h.cfg, roles.ChatAdmin) {
+		return c.Reply("❌ 只有群管理员可以修改群设置")
+	}
+
+	ctx := context.Background()
+	if args := c.Args(); len(args) > 0 {
+		if len(args) != 2 || args[0] != "quiet" {
+			return c.Reply("❌ 用法: /settings 或 /settings quiet <23-7|off>")
+		}
+		start, end, err := service.ParseQuietHours(args[1])
+		if err == nil {
+			err = h.settings.SetQuietHours(ctx, chat.ID, c.Sender().ID, start, end)
+		}
+		if errors.Is(err, service.ErrChatQuiet) {
+			return c.Reply("❌ " + err.Error())
+		}
+		if err != nil {
+			log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to update chat settings")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 安静时段: " + service.FormatQuietHours(start, end))
+	}
+
+	settings, err := h.settings.Get(ctx, chat.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get chat settings")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	return c.Reply("⚙️ 群设置\n\n"+formatChatSettings(settings), chatSettingsMarkup(settings))
+}
+
+// HandleSettingsCallback handles the buttons of the setup wizard (group admins only).
+// Data format: setup_games|on, setup_tz|Asia/Tokyo, setup_clean|30, setup_quick|on, setup_quiet|23-7, setup_done
+func (h *ChatSettingsHandler) HandleSettingsCallback(c tele.Context) error {
+	ctx := context.Background()
+	callback := c.Callback()
+	sender := c.Sender()
+	chat := c.Chat()
+	if callback == nil || sender == nil || chat == nil {
+		return nil
+	}
+	if !hasRole(c, h.cfg, roles.ChatAdmin) {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 只有群管理员可以修改群设置", ShowAlert: true})
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(callback.Data, "\f"), "|", 2)
+	value := ""
+	if len(parts) == 2 {
+		value = parts[1]
+	}
+
+	if parts[0] == setupDoneCallback {
+		settings, err := h.settings.Get(ctx, chat.ID)
+		if err != nil {
+			log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get chat settings")
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 操作失败", ShowAlert: true})
+		}
+		if err := c.Edit("✅ 群设置已保存（/settings 可随时修改）\n\n" + formatChatSettings(settings)); err != nil {
+			log.Debug().Err(err).Int64("chat_id", chat.ID).Msg("Failed to close setup wizard")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "✅ 设置完成"})
+	}
+
+	var err error
+	switch parts[0] {
+	case setupGamesCallback:
+		err = h.settings.SetGamesEnabled(ctx, chat.ID, sender.ID, value == "on")
+	case setupQuickCallback:
+		err = h.settings.SetQuickBets(ctx, chat.ID, sender.ID, value == "on")
+	case setupQuietCallback:
+		var start, end int
+		if start, end, err = service.ParseQuietHours(value); err == nil {
+			err = h.settings.SetQuietHours(ctx, chat.ID, sender.ID, start, end)
+		}
+	case setupTZCallback:
+		err = h.settings.SetTimezone(ctx, chat.ID, sender.ID, value)
+	case setupCleanCallback:
+		var minutes int
+		if minutes, err = strconv.Atoi(value); err == nil {
+			err = h.settings.SetCleanup(ctx, chat.ID, sender.ID, minutes)
+		} else {
+			err = service.ErrChatCleanup
+		}
+	default:
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	if err != nil {
+		if errors.Is(err, service.ErrChatTimezone) || errors.Is(err, service.ErrChatCleanup) || errors.Is(err, service.ErrChatQuiet) {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to update chat settings")
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作失败", ShowAlert: true})
+	}
+
+	settings, err := h.settings.Get(ctx, chat.ID)
+	if err == nil {
+		if err := c.Edit("⚙️ 群设置\n\n"+formatChatSettings(settings), chatSettingsMarkup(settings)); err != nil {
+			log.Debug().Err(err).Int64("chat_id", chat.ID).Msg("Failed to refresh setup wizard")
+		}
+	}
+	return c.Respond(&tele.CallbackResponse{Text: "✅ 已更新"})
+}
+
+// formatChatSettings renders the current settings of a chat
+func formatChatSettings(s model.ChatSettings) string {
+	games := "开启"
+	if !s.GamesEnabled {
+		games = "关闭"
+	}
+	quick := "关闭"
+	if s.QuickBets {
+		quick = "开启（d100 = /dice 100，s500 = /slot 500）"
+	}
+	return fmt.Sprintf("🎮 游戏: %s\n🕐 时区: %s\n🧹 消息清理: %s\n⚡ 快捷下注: %s\n🌙 安静时段: %s",
+		games, timezoneLabel(s.Timezone), cleanupLabel(s.CleanupMinutes), quick, service.FormatQuietHours(s.QuietStart, s.QuietEnd))
+}
+
+// chatSettingsMarkup builds the wizard buttons, marking the current choices
+func chatSettingsMarkup(s model.ChatSettings) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	mark := func(label string, chosen bool) string {
+		if chosen {
+			return "✅ " + label
+		}
+		return label
+	}
+
+	rows := []tele.Row{markup.Row(
+		markup.Data(mark("开启游戏", s.GamesEnabled), setupGamesCallback, "on"),
+		markup.Data(mark("关闭游戏", !s.GamesEnabled), setupGamesCallback, "off"),
+	)}
+
+	var tzButtons []tele.Btn
+	for _, tz := range append([]string{""}, service.ChatTimezones...) {
+		tzButtons = append(tzButtons, markup.Data(mark(timezoneLabel(tz), s.Timezone == tz), setupTZCallback, tz))
+	}
+	rows = append(rows, markup.Split(3, tzButtons)...)
+
+	var cleanButtons []tele.Btn
+	for _, minutes := range service.ChatCleanupOptions {
+		cleanButtons = append(cleanButtons, markup.Data(mark(cleanupLabel(minutes), s.CleanupMinutes == minutes), setupCleanCallback, strconv.Itoa(minutes)))
+	}
+	rows = append(rows, markup.Row(cleanButtons...))
+
+	rows = append(rows, markup.Row(
+		markup.Data(mark("开启快捷下注", s.QuickBets), setupQuickCallback, "on"),
+		markup.Data(mark("关闭快捷下注", !s.QuickBets), setupQuickCallback, "off"),
+	))
+
+	var quietButtons []tele.Btn
+	for _, hours := range service.ChatQuietOptions {
+		label, data := "无安静时段", "off"
+		if hours[0] != hours[1] {
+			label, data = fmt.Sprintf("🌙 %02d-%02d", hours[0], hours[1]), fmt.Sprintf("%d-%d", hours[0], hours[1])
+		}
+		chosen := s.QuietStart == hours[0] && s.QuietEnd == hours[1] || hours[0] == hours[1] && s.QuietStart == s.QuietEnd
+		quietButtons = append(quietButtons, markup.Data(mark(label, chosen), setupQuietCallback, data))
+	}
+	rows = append(rows, markup.Row(quietButtons...))
+
+	rows = append(rows, markup.Row(markup.Data("✔️ 完成", setupDoneCallback)))
+	markup.Inline(rows...)
+	return markup
+}
+
+// timezoneLabel names a chat timezone, empty is the bot default
+func timezoneLabel(tz string) string {
+	if tz == "" {
+		return "默认"
+	}
+	return tz
+}
+
+// cleanupLabel describes a cleanup delay
+func cleanupLabel(minutes int) string {
+	if minutes == 0 {
+		return "不清理"
+	}
+	return fmt.Sprintf("%d 分钟", minutes)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// pinnedStats is the pinned statistics message of a chat
+type pinnedStats struct {
+	messageID int
+	lastText  string
+	lastEdit  time.Time
+}
+
+// ChatStatsHandler manages the optional auto-updating pinned statistics message per chat.
+type ChatStatsHandler struct {
+	cfg       *config.Config
+	chatStats *service.ChatStatsService
+	sicboGame *sicbo.SicBoGame
+	mu        sync.Mutex
+	pins      map[int64]*pinnedStats // chatID -> pinned message
+}
+
+// NewChatStatsHandler creates a new ChatStatsHandler.
+func NewChatStatsHandler(cfg *config.Config, chatStats *service.ChatStatsService, sicboGame *sicbo.SicBoGame) *ChatStatsHandler {
+	return &ChatStatsHandler{
+		cfg:       cfg,
+		chatStats: chatStats,
+		sicboGame: sicboGame,
+		pins:      make(map[int64]*pinnedStats),
+	}
+}
+
+// HandlePinStats handles the /pinstats command (admin only).
+// Toggles the pinned statistics message of the current group.
+func (h *ChatStatsHandler) HandlePinStats(c tele.Context) error {
+	chat := c.Chat()
+	if chat == nil || chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中使用此命令")
+	}
+
+	h.mu.Lock()
+	pin, pinned := h.pins[chat.ID]
+	if pinned {
+		delete(h.pins, chat.ID)
+	}
+	h.mu.Unlock()
+
+	if pinned {
+		if err := c.Bot().Unpin(chat, pin.messageID); err != nil {
+			log.Debug().Err(err).Int64("chat_id", chat.ID).Msg("Failed to unpin stats message")
+		}
+		return c.Reply("✅ 已关闭置顶统计")
+	}
+
+	text := h.render(chat.ID)
+	msg, err := sendInTopic(c, text)
+	if err != nil {
+		return c.Reply("❌ 发送统计消息失败")
+	}
+	if err := c.Bot().Pin(msg, tele.Silent); err != nil {
+		log.Warn().Err(err).Int64("chat_id", chat.ID).Msg("Failed to pin stats message")
+		_ = c.Bot().Delete(msg)
+		return c.Reply("❌ 置顶失败，请确认机器人有置顶消息权限")
+	}
+
+	h.mu.Lock()
+	h.pins[chat.ID] = &pinnedStats{messageID: msg.ID, lastText: text, lastEdit: time.Now()}
+	h.mu.Unlock()
+
+	return nil
+}
+
+// StartRefresher starts the background goroutine that refreshes pinned stats messages.
+func (h *ChatStatsHandler) StartRefresher(bot *tele.Bot) {
+	interval := time.Duration(h.cfg.ChatStats.RefreshSeconds) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("chat_stats", interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("chat_stats")
+			h.refresh(bot)
+		}
+	}()
+}
+
+// refresh edits pinned messages whose content changed.
+// At most MaxEditsPerRefresh edits are made per tick to stay within Telegram's edit limits;
+// the least recently edited chats go first so no chat starves.
+func (h *ChatStatsHandler) refresh(bot *tele.Bot) {
+	type pending struct {
+		chatID    int64
+		messageID int
+		text      string
+		lastEdit  time.Time
+	}
+
+	h.mu.Lock()
+	var due []pending
+	for chatID, pin := range h.pins {
+		text := h.render(chatID)
+		if text == pin.lastText {
+			continue
+		}
+		due = append(due, pending{chatID: chatID, messageID: pin.messageID, text: text, lastEdit: pin.lastEdit})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].lastEdit.Before(due[j].lastEdit) })
+	if limit := h.cfg.ChatStats.MaxEditsPerRefresh; limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	for _, p := range due {
+		editMsg := &tele.Message{
+			ID:   p.messageID,
+			Chat: &tele.Chat{ID: p.chatID},
+		}
+		_, err := bot.Edit(editMsg, p.text)
+		if err != nil && !errors.Is(err, tele.ErrSameMessageContent) && !errors.Is(err, tele.ErrMessageNotModified) {
+			log.Debug().Err(err).Int64("chat_id", p.chatID).Msg("Failed to refresh pinned stats")
+			if errors.Is(err, tele.ErrCantEditMessage) || strings.Contains(err.Error(), "message to edit not found") {
+				// The message was deleted or unpinned by hand, stop refreshing it
+				h.mu.Lock()
+				delete(h.pins, p.chatID)
+				h.mu.Unlock()
+			}
+			continue
+		}
+
+		h.mu.Lock()
+		if pin, ok := h.pins[p.chatID]; ok && pin.messageID == p.messageID {
+			pin.lastText = p.text
+			pin.lastEdit = time.Now()
+		}
+		h.mu.Unlock()
+	}
+}
+
+// render builds the statistics message of a chat
+func (h *ChatStatsHandler) render(chatID int64) string {
+	stats := h.chatStats.Get(chatID)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📌 今日群统计 (%s)\n", stats.Date))
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("💵 今日总下注: %d 金币\n", stats.TotalWagers))
+	if stats.BiggestWin > 0 {
+		sb.WriteString(fmt.Sprintf("🏆 最大单笔赢奖: %s +%d\n", stats.BiggestWinner, stats.BiggestWin))
+	} else {
+		sb.WriteString("🏆 最大单笔赢奖: 暂无\n")
+	}
+
+	if key := (sicbo.SessionKey{ChatID: chatID}); h.sicboGame.IsSessionActive(key) {
+		remaining := h.sicboGame.GetSessionTimeRemaining(key)
+		playerCount, totalBetAmount, _ := h.sicboGame.GetSessionStats(key)
+		sb.WriteString(fmt.Sprintf("🎲 骰宝: 下注中 (剩余 %d 秒, %d 人, 共 %d 金币)\n", remaining, playerCount, totalBetAmount))
+	} else {
+		sb.WriteString("🎲 骰宝: 未开始 (/sicbo 开局)\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// comebackClaimCallback is the unique of the claim button of an offer DM
+const comebackClaimCallback = "comeback_claim"
+
+// ComebackHandler lets users opt in to welcome-back offers and claim them.
+type ComebackHandler struct {
+	comeback *service.ComebackService
+}
+
+// NewComebackHandler creates a new ComebackHandler.
+func NewComebackHandler(comeback *service.ComebackService) *ComebackHandler {
+	return &ComebackHandler{comeback: comeback}
+}
+
+// HandleComeback handles the /comeback command.
+// Format: /comeback [on|off]
+func (h *ComebackHandler) HandleComeback(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		enabled, err := h.comeback.Enabled(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		status := "未开启"
+		if enabled {
+			status = "已开启"
+		}
+		return c.Reply("👋 回归奖励私信: " + status + "\n\n" +
+			"常玩的你如果一段时间没来，机器人会私聊送上一份回归奖励，点击按钮即可领取\n\n" +
+			"📖 用法:\n" +
+			"/comeback on - 开启\n" +
+			"/comeback off - 关闭\n\n" +
+			"⚠️ 请先私聊机器人发送 /start，否则无法收到私信")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		if err := h.comeback.Enable(ctx, sender.ID); err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to enable comeback offers")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 已开启回归奖励私信\n⚠️ 请确认已私聊机器人发送 /start")
+	case "off":
+		if err := h.comeback.Disable(ctx, sender.ID); err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to disable comeback offers")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 已关闭回归奖励私信")
+	default:
+		return c.Reply("❌ 用法: /comeback on | off")
+	}
+}
+
+// HandleComebackStats handles the /comeback_stats admin command.
+func (h *ComebackHandler) HandleComebackStats(c tele.Context) error {
+	stats, err := h.comeback.Stats(context.Background(), time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get comeback stats")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	return c.Reply(service.FormatComebackStats(stats))
+}
+
+// HandleComebackCallback handles the claim button of an offer DM.
+func (h *ComebackHandler) HandleComebackCallback(c tele.Context) error {
+	sender := c.Sender()
+	callback := c.Callback()
+	if sender == nil || callback == nil {
+		return nil
+	}
+
+	data := strings.TrimPrefix(callback.Data, "\f")
+	parts := strings.Split(data, "|")
+	if len(parts) != 2 || parts[0] != comebackClaimCallback {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	offerID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	bonus, balance, err := h.comeback.Claim(context.Background(), offerID, sender.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrComebackUnavailable) {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Int64("offer_id", offerID).Msg("Failed to claim comeback bonus")
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作失败，请稍后重试", ShowAlert: true})
+	}
+
+	if err := c.Edit(fmt.Sprintf("🎉 欢迎回来！已领取回归奖励 %d 金币\n💰 当前余额: %d", bonus, balance)); err != nil {
+		log.Debug().Err(err).Msg("Failed to edit comeback offer")
+	}
+	return c.Respond(&tele.CallbackResponse{Text: "✅ 领取成功"})
+}
+
+// StartScheduler starts the background goroutine that sends welcome-back offers.
+func (h *ComebackHandler) StartScheduler(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("comeback", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("comeback")
+			h.comeback.Run(context.Background(), now)
+		}
+	}()
+}
+
+// ComebackNotifier sends welcome-back offers as Telegram private messages.
+type ComebackNotifier struct {
+	bot *tele.Bot
+}
+
+// NewComebackNotifier creates a new ComebackNotifier.
+func NewComebackNotifier(bot *tele.Bot) *ComebackNotifier {
+	return &ComebackNotifier{bot: bot}
+}
+
+// SendComebackOffer sends an offer DM with its claim button.
+func (n *ComebackNotifier) SendComebackOffer(offer *model.ComebackOffer, text string) error {
+	markup := &tele.ReplyMarkup{}
+	btn := markup.Data(fmt.Sprintf("🎁 领取 %d 金币", offer.Bonus), comebackClaimCallback, strconv.FormatInt(offer.ID, 10))
+	markup.Inline(markup.Row(btn))
+	_, err := n.bot.Send(&tele.User{ID: offer.UserID}, text, markup)
+	return err
+}
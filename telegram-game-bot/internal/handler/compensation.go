@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/service"
+)
+
+// compPendingLimit limits the incidents listed by /comp_pending
+const compPendingLimit = 10
+
+// CompensationHandler handles admin review of compensation incidents.
+type CompensationHandler struct {
+	compensationService *service.CompensationService
+}
+
+// NewCompensationHandler creates a new CompensationHandler.
+func NewCompensationHandler(compensationService *service.CompensationService) *CompensationHandler {
+	return &CompensationHandler{
+		compensationService: compensationService,
+	}
+}
+
+// HandleCompPending handles the /comp_pending command (admin only).
+// Lists incidents waiting for approval.
+func (h *CompensationHandler) HandleCompPending(c tele.Context) error {
+	ctx := context.Background()
+
+	incidents, err := h.compensationService.GetPendingIncidents(ctx, compPendingLimit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pending incidents")
+		return c.Reply("❌ 获取失败，请稍后重试")
+	}
+
+	if len(incidents) == 0 {
+		return c.Reply("✅ 没有待审批的补偿")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🩹 待审批补偿\n")
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	for _, incident := range incidents {
+		sb.WriteString(fmt.Sprintf("#%d %s %s\n💰 %d 金币 | %s\n",
+			incident.ID, incident.CreatedAt.Format("01-02 15:04"), service.IncidentName(incident.Kind),
+			incident.TotalAmount, incident.Description))
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString("批准: /comp_approve <ID>\n拒绝: /comp_reject <ID>")
+
+	return c.Reply(sb.String())
+}
+
+// HandleCompApprove handles the /comp_approve command (admin only).
+// Format: /comp_approve <incident_id>
+func (h *CompensationHandler) HandleCompApprove(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	incidentID, err := parseIncidentID(c)
+	if err != nil {
+		return c.Reply("❌ 用法: /comp_approve <事故ID>")
+	}
+
+	incident, paid, err := h.compensationService.Approve(ctx, incidentID, sender.ID)
+	if err != nil {
+		return h.replyCompensationError(c, err)
+	}
+
+	return c.Reply(fmt.Sprintf("✅ 事故 #%d 已批准，已向 %d 位用户发放补偿（共 %d 金币）",
+		incident.ID, paid, incident.TotalAmount))
+}
+
+// HandleCompReject handles the /comp_reject command (admin only).
+// Format: /comp_reject <incident_id>
+func (h *CompensationHandler) HandleCompReject(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	incidentID, err := parseIncidentID(c)
+	if err != nil {
+		return c.Reply("❌ 用法: /comp_reject <事故ID>")
+	}
+
+	incident, err := h.compensationService.Reject(ctx, incidentID, sender.ID)
+	if err != nil {
+		return h.replyCompensationError(c, err)
+	}
+
+	return c.Reply(fmt.Sprintf("🚫 事故 #%d 已拒绝，不发放补偿", incident.ID))
+}
+
+// replyCompensationError replies with a user facing compensation error
+func (h *CompensationHandler) replyCompensationError(c tele.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrIncidentNotFound),
+		errors.Is(err, service.ErrIncidentResolved):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Msg("Failed to resolve compensation incident")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
+
+// parseIncidentID parses the incident ID argument
+func parseIncidentID(c tele.Context) (int64, error) {
+	args := c.Args()
+	if len(args) < 1 {
+		return 0, errors.New("missing incident id")
+	}
+	return strconv.ParseInt(args[0], 10, 64)
+}
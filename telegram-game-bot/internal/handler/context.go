@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"context"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// TraceContextKey is the key of the update context set by the tracing
+// middleware, carrying the span of the update.
+const TraceContextKey = "trace_ctx"
+
+// RequestContext returns the context of an update: the traced context when
+// tracing is enabled, the background context otherwise.
+func RequestContext(c tele.Context) context.Context {
+	if ctx, ok := c.Get(TraceContextKey).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/cooldown"
+)
+
+// Cooldown names of cooldowns tracked outside the game handler
+const (
+	CooldownRob       = "rob"
+	CooldownAllInRob  = "allin_rob"
+	CooldownAllInDice = "allin_dice"
+	CooldownDaily     = "daily"
+	CooldownFreeSpin  = "freespin"
+
+	// CooldownAggression is the bucket shared by all attacks
+	CooldownAggression = "aggression"
+)
+
+// Attack actions consuming the aggression bucket
+const (
+	attackRob      = "rob"
+	attackAllInRob = "allin_rob"
+	attackDuel     = "duel"
+)
+
+// cooldownLabels are the display names of cooldowns in /cooldowns
+var cooldownLabels = map[string]string{
+	"dice":            "🎲 骰子 /dice",
+	"dice3":           "🎲 三骰 /dice3",
+	"dicebo3":         "🎲 三局两胜 /dicebo3",
+	"slot":            "🎰 老虎机 /slot",
+	"blackjack":       "🃏 21点 /blackjack",
+	CooldownRob:       "🔫 打劫 /dj",
+	CooldownAllInRob:  "💀 梭哈打劫 /shdj",
+	CooldownAllInDice: "🎲 梭哈骰子 /shdice",
+	CooldownDaily:     "📅 签到 /daily",
+	CooldownFreeSpin:  "🎁 免费转盘 /freespin",
+
+	CooldownAggression: "⚔️ 攻击 /dj /shdj /duijue",
+}
+
+// Cooldowns returns the cooldown manager, so other features can register their cooldowns.
+func (h *BaseHandler) Cooldowns() *cooldown.Manager {
+	return h.cooldowns
+}
+
+// registerCooldownSources registers the cooldowns the game handlers can query
+// but do not track themselves. The rob cooldown is registered by NewRobHandler.
+func (h *BaseHandler) registerCooldownSources() {
+	if h.accountService != nil {
+		h.cooldowns.Register(CooldownDaily, func(ctx context.Context, userID int64) time.Duration {
+			canClaim, remaining, err := h.accountService.CanClaimDaily(ctx, userID)
+			if err != nil || canClaim {
+				return 0
+			}
+			return remaining
+		})
+		h.cooldowns.Register(CooldownFreeSpin, func(ctx context.Context, userID int64) time.Duration {
+			canSpin, remaining, err := h.accountService.CanFreeSpin(ctx, userID, h.cfg.Games.FreeSpin.CooldownHours)
+			if err != nil || canSpin {
+				return 0
+			}
+			return remaining
+		})
+	}
+}
+
+// registerAggressionBucket sets up the cooldown shared by all attacks
+func (h *BaseHandler) registerAggressionBucket() {
+	cfg := h.cfg.Games.Aggression
+	h.cooldowns.AddBucket(CooldownAggression, time.Duration(cfg.BucketSeconds)*time.Second, map[string]time.Duration{
+		attackRob:      time.Duration(cfg.RobSeconds) * time.Second,
+		attackAllInRob: time.Duration(cfg.AllInRobSeconds) * time.Second,
+		attackDuel:     time.Duration(cfg.DuelSeconds) * time.Second,
+	})
+}
+
+// aggressionMessage returns the rejection message of an attack made too soon after others
+func aggressionMessage(remaining time.Duration) string {
+	return "⚔️ 攻击太频繁，请等待 " + cooldown.Format(remaining) + " 后再发起攻击"
+}
+
+// cooldownMessage returns the rejection message of a game played during its cooldown
+func cooldownMessage(remaining time.Duration) string {
+	return "⏰ 冷却中，请等待 " + cooldown.Format(remaining) + " 后再玩"
+}
+
+// HandleCooldowns handles /cooldowns, listing all active cooldowns of the user.
+func (h *BaseHandler) HandleCooldowns(c tele.Context) error {
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	entries := h.cooldowns.Active(context.Background(), sender.ID)
+	if len(entries) == 0 {
+		return c.Reply("✅ 当前没有冷却中的游戏，随时可以开玩")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("⏰ 冷却中\n\n")
+	for _, e := range entries {
+		label, ok := cooldownLabels[e.Name]
+		if !ok {
+			label = e.Name
+		}
+		sb.WriteString("• " + label + ": " + cooldown.Format(e.Remaining) + "\n")
+	}
+	return c.Reply(strings.TrimRight(sb.String(), "\n"))
+}
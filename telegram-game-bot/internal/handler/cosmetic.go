@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/cosmetic"
+	"telegram-game-bot/internal/service"
+)
+
+// CosmeticHandler sells cosmetic-only items for Telegram Stars.
+type CosmeticHandler struct {
+	cosmetics *service.CosmeticService
+}
+
+// NewCosmeticHandler creates a new CosmeticHandler.
+func NewCosmeticHandler(cosmetics *service.CosmeticService) *CosmeticHandler {
+	return &CosmeticHandler{cosmetics: cosmetics}
+}
+
+// HandleStars handles the /stars command.
+// Format: /stars [buy|use|off <装扮>]
+func (h *CosmeticHandler) HandleStars(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		return h.showCatalog(ctx, c, sender.ID)
+	}
+	if len(args) != 2 {
+		return c.Reply("❌ 用法: /stars buy|use|off 装扮编号")
+	}
+
+	itemID := strings.ToLower(args[1])
+	switch strings.ToLower(args[0]) {
+	case "buy":
+		item, payload, err := h.cosmetics.PrepareInvoice(ctx, sender.ID, itemID)
+		if err != nil {
+			return h.replyError(c, sender.ID, err)
+		}
+		invoice := &tele.Invoice{
+			Title:       item.Label(),
+			Description: item.Description + "\n仅为装扮，不含金币，不影响游戏",
+			Payload:     payload,
+			Currency:    cosmetic.CurrencyStars,
+			Prices:      []tele.Price{{Label: item.Name, Amount: item.Stars}},
+		}
+		return c.Send(invoice)
+	case "use":
+		item, err := h.cosmetics.Equip(ctx, sender.ID, itemID)
+		if err != nil {
+			return h.replyError(c, sender.ID, err)
+		}
+		return c.Reply(fmt.Sprintf("✅ 已装备%s: %s", cosmetic.KindNames[item.Kind], item.Display))
+	case "off":
+		item, err := h.cosmetics.Unequip(ctx, sender.ID, itemID)
+		if err != nil {
+			return h.replyError(c, sender.ID, err)
+		}
+		return c.Reply(fmt.Sprintf("✅ 已卸下%s", cosmetic.KindNames[item.Kind]))
+	default:
+		return c.Reply("❌ 用法: /stars buy|use|off 装扮编号")
+	}
+}
+
+// showCatalog lists the cosmetics with the user's owned and equipped ones marked
+func (h *CosmeticHandler) showCatalog(ctx context.Context, c tele.Context, userID int64) error {
+	owned, err := h.cosmetics.Owned(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", userID).Msg("Failed to list cosmetics")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	status := make(map[string]string, len(owned))
+	for _, o := range owned {
+		if o.Equipped {
+			status[o.ItemID] = "（装备中）"
+		} else {
+			status[o.ItemID] = "（已拥有）"
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("⭐ 星星装扮店\n")
+	sb.WriteString("装扮只改变外观，不含金币，也不影响任何游戏\n")
+
+	var kind cosmetic.Kind
+	for _, item := range cosmetic.All() {
+		if item.Kind != kind {
+			kind = item.Kind
+			sb.WriteString(fmt.Sprintf("\n【%s】\n", cosmetic.KindNames[kind]))
+		}
+		sb.WriteString(fmt.Sprintf("%s %s ⭐%d%s\n", item.Label(), item.ID, item.Stars, status[item.ID]))
+		sb.WriteString(fmt.Sprintf("   %s\n", item.Description))
+	}
+
+	if !h.cosmetics.Enabled() {
+		sb.WriteString("\n⚠️ 星星商店暂未开放购买")
+	}
+	sb.WriteString("\n📖 用法:\n")
+	sb.WriteString("/stars buy 编号 - 用星星购买\n")
+	sb.WriteString("/stars use 编号 - 装备已拥有的装扮\n")
+	sb.WriteString("/stars off 编号 - 卸下该类装扮")
+	return c.Reply(sb.String())
+}
+
+// replyError replies with the error of a cosmetic command
+func (h *CosmeticHandler) replyError(c tele.Context, userID int64, err error) error {
+	switch {
+	case errors.Is(err, service.ErrStarsShopDisabled),
+		errors.Is(err, service.ErrCosmeticNotFound),
+		errors.Is(err, service.ErrCosmeticOwned),
+		errors.Is(err, service.ErrCosmeticNotOwned):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Int64("user_id", userID).Msg("Cosmetic command failed")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
+
+// HandleCheckout answers pre-checkout queries, the last chance to refuse a payment.
+func (h *CosmeticHandler) HandleCheckout(c tele.Context) error {
+	query := c.PreCheckoutQuery()
+	if query == nil || query.Sender == nil {
+		return nil
+	}
+
+	_, err := h.cosmetics.ValidateCheckout(context.Background(), query.Sender.ID, query.Payload, query.Currency, query.Total)
+	if err != nil {
+		log.Warn().Err(err).
+			Int64("user_id", query.Sender.ID).
+			Str("payload", query.Payload).
+			Msg("Refused pre-checkout query")
+		reason := err.Error()
+		if !errors.Is(err, service.ErrStarsShopDisabled) && !errors.Is(err, service.ErrCosmeticNotFound) &&
+			!errors.Is(err, service.ErrCosmeticOwned) && !errors.Is(err, service.ErrStarPaymentInvalid) {
+			reason = "暂时无法完成支付，请稍后重试"
+		}
+		return c.Accept(reason)
+	}
+	return c.Accept()
+}
+
+// HandlePayment records successful payments and grants the cosmetic.
+func (h *CosmeticHandler) HandlePayment(c tele.Context) error {
+	msg := c.Message()
+	sender := c.Sender()
+	if msg == nil || msg.Payment == nil || sender == nil {
+		return nil
+	}
+	payment := msg.Payment
+
+	item, created, err := h.cosmetics.CompletePurchase(context.Background(), sender.ID, service.StarPayment{
+		Currency:         payment.Currency,
+		Total:            payment.Total,
+		Payload:          payment.Payload,
+		TelegramChargeID: payment.TelegramChargeID,
+		ProviderChargeID: payment.ProviderChargeID,
+	})
+	if err != nil {
+		return c.Reply(fmt.Sprintf("❌ 装扮发放失败，请使用 /support 联系管理员\n支付单号: %s", payment.TelegramChargeID))
+	}
+	if !created {
+		return nil
+	}
+	return c.Reply(fmt.Sprintf(
+		"✅ 支付成功，获得%s %s\n\n发送 /stars use %s 装备",
+		cosmetic.KindNames[item.Kind], item.Label(), item.ID,
+	))
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// DegradedHandler runs the database probe deciding when the bot runs degraded.
+type DegradedHandler struct {
+	degraded *service.DegradedService
+}
+
+// NewDegradedHandler creates a new DegradedHandler.
+func NewDegradedHandler(degraded *service.DegradedService) *DegradedHandler {
+	return &DegradedHandler{degraded: degraded}
+}
+
+// StartProbe starts the background goroutine that probes the database.
+// Every instance probes on its own, since each one serves commands.
+func (h *DegradedHandler) StartProbe(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("db_probe", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("db_probe")
+			h.degraded.Probe(context.Background(), now)
+		}
+	}()
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/service"
+)
+
+// DiceHandler handles the dice games: /dice and the modes of dice_modes.go.
+type DiceHandler struct {
+	*BaseHandler
+}
+
+// NewDiceHandler creates a new DiceHandler.
+func NewDiceHandler(base *BaseHandler) *DiceHandler {
+	return &DiceHandler{BaseHandler: base}
+}
+
+// HandleDice handles the /dice command.
+// Requirements: 3.1
+func (h *DiceHandler) HandleDice(c tele.Context) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	// 仅限群组使用
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 骰子游戏只能在群组中进行，请加入群组后使用")
+	}
+
+	// Parse bet amount, falling back to the default stake
+	bet, err := h.parseStake(ctx, c, "dice")
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+
+	// "/dice 1000 insure" insures the bet
+	insured := false
+	if args := c.Args(); len(args) > 1 {
+		if !insuranceWords[strings.ToLower(args[1])] {
+			return c.Reply("❌ 用法: /dice <金额> [insure|保险]")
+		}
+		if !h.featureEnabled(service.FlagDiceInsurance, chat.ID, sender.ID, h.cfg.Games.Dice.InsuranceEnabled) {
+			return c.Reply("❌ 骰子保险暂未开放")
+		}
+		insured = true
+	}
+
+	return h.playDice(c, bet, insured)
+}
+
+// insuranceWords insure a /dice bet
+var insuranceWords = map[string]bool{"insure": true, "保险": true}
+
+// playDice plays a dice game for the sender; shared by /dice and its replay
+// button. An insured bet pays a premium to get part of the stake back on a loss.
+func (h *DiceHandler) playDice(c tele.Context, bet int64, insured bool) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+
+	// Check cooldown (3 seconds)
+	cooldownSecs := 3
+	if remaining := h.checkCooldown(sender.ID, "dice"); remaining > 0 {
+		return c.Reply(cooldownMessage(remaining))
+	}
+
+	// Ensure user exists
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	scope := h.balanceScope(ctx, chat.ID)
+
+	// Acquire lock
+	h.userLock.Lock(sender.ID)
+	defer h.userLock.Unlock(sender.ID)
+
+	// Check balance
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+	if err != nil {
+		return c.Reply("❌ 获取余额失败")
+	}
+
+	// Check max bet based on balance
+	maxBet := h.getEffectiveMaxBet(balance, h.cfg.Games.Dice.MaxBet)
+	if bet > maxBet {
+		tierMaxBet, tierThreshold := getBalanceTierInfo(balance)
+		if tierThreshold > 0 {
+			return c.Reply(fmt.Sprintf("❌ 余额超过 %d，单次下注上限为 %d", tierThreshold, tierMaxBet))
+		}
+		return c.Reply(fmt.Sprintf("❌ 最大下注金额为 %d", maxBet))
+	}
+
+	var premium int64
+	if insured {
+		premium = dice.InsurancePremium(bet, h.cfg.Games.Dice.InsurancePremiumPercent)
+	}
+	if balance < bet+premium {
+		if premium > 0 {
+			return c.Reply(fmt.Sprintf("❌ 余额不足（下注 %d + 保险费 %d）", bet, premium))
+		}
+		return c.Reply("❌ 余额不足")
+	}
+
+	// Deduct bet first
+	desc := fmt.Sprintf("骰子游戏下注 %d", bet)
+	_, err = h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -bet, model.TxTypeDice, &desc)
+	if err != nil {
+		return c.Reply("❌ 扣款失败，请稍后重试")
+	}
+	if premium > 0 {
+		premiumDesc := fmt.Sprintf("骰子保险费 %d", premium)
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -premium, model.TxTypeDiceInsure, &premiumDesc); err != nil {
+			if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, bet, model.TxTypeDice, nil); err != nil {
+				h.reportIncidentIn(scope, service.IncidentRefundFailed, "骰子保险扣费失败后退还下注失败",
+					service.CompensationClaim{UserID: sender.ID, Amount: bet})
+			}
+			return c.Reply("❌ 扣款失败，请稍后重试")
+		}
+	}
+
+	// Send two dice
+	dice1Msg, err := sendInTopic(c, tele.Cube)
+	if err != nil {
+		// Refund on error
+		h.refundDice(ctx, scope, sender.ID, bet, premium)
+		return c.Reply("❌ 发送骰子失败")
+	}
+	h.trackMessage(c.Chat().ID, dice1Msg.ID)
+
+	// Wait a bit before sending second dice
+	time.Sleep(500 * time.Millisecond)
+
+	dice2Msg, err := sendInTopic(c, tele.Cube)
+	if err != nil {
+		// Refund on error
+		h.refundDice(ctx, scope, sender.ID, bet, premium)
+		return c.Reply("❌ 发送骰子失败")
+	}
+	h.trackMessage(c.Chat().ID, dice2Msg.ID)
+
+	// Get dice values
+	dice1Val := dice1Msg.Dice.Value
+	dice2Val := dice2Msg.Dice.Value
+
+	// Calculate payout
+	payout := dice.CalculatePayout(dice1Val, dice2Val, bet)
+	total := dice1Val + dice2Val
+
+	// Set cooldown
+	h.setCooldown(sender.ID, "dice", cooldownSecs)
+	h.recordWager(c.Chat().ID, bet)
+
+	// Process result asynchronously to avoid blocking
+	go func() {
+		// Wait for dice animation
+		time.Sleep(3 * time.Second)
+
+		// Credit winnings (payout is net, so add bet back + payout)
+		if payout >= 0 {
+			// Win or push - credit bet + payout
+			creditAmount := bet + payout
+			if creditAmount > 0 {
+				h.userLock.Lock(sender.ID)
+				desc := fmt.Sprintf("骰子游戏赢得 %d", payout)
+				if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, creditAmount, model.TxTypeDice, &desc); err != nil {
+					h.reportIncidentIn(scope, service.IncidentCreditFailed, "骰子游戏奖金到账失败",
+						service.CompensationClaim{UserID: sender.ID, Amount: creditAmount})
+				}
+				h.userLock.Unlock(sender.ID)
+			}
+			h.recordWin(c.Chat().ID, user, payout)
+			h.publishWin(c.Chat().ID, user, "dice", payout)
+		}
+		// If payout < 0, bet was already deducted; an insured bet gets part of it back
+		var refund int64
+		if premium > 0 {
+			refund = dice.InsuranceRefund(dice1Val, dice2Val, bet, h.cfg.Games.Dice.InsuranceRefundPercent)
+		}
+		if refund > 0 {
+			h.userLock.Lock(sender.ID)
+			desc := fmt.Sprintf("骰子保险赔付 %d", refund)
+			if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, refund, model.TxTypeDiceInsure, &desc); err != nil {
+				h.reportIncidentIn(scope, service.IncidentCreditFailed, "骰子保险赔付到账失败",
+					service.CompensationClaim{UserID: sender.ID, Amount: refund})
+			}
+			h.userLock.Unlock(sender.ID)
+		}
+		h.recordRound(ctx, &model.GameRound{
+			Game:          model.GameRoundDice,
+			UserID:        sender.ID,
+			ChatID:        c.Chat().ID,
+			Bet:           bet,
+			Values:        []int{dice1Val, dice2Val},
+			Premium:       premium,
+			RefundPercent: h.cfg.Games.Dice.InsuranceRefundPercent,
+			Payout:        payout,
+			Refund:        refund,
+		})
+
+		// Get new balance
+		newBalance, _ := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+
+		// Build result message mentioning the player, worded by the chat's persona
+		persona := h.chatPersona(ctx, c.Chat().ID)
+		vars := service.PersonaVars{User: username, Amount: payout, Balance: newBalance, Game: "骰子"}
+		var outcome string
+		switch {
+		case payout > bet:
+			outcome = service.RenderOutcome(persona, true, "🎊 JACKPOT! 赢得 {amount} 金币！", vars)
+		case payout > 0:
+			outcome = service.RenderOutcome(persona, true, defaultWinLine, vars)
+		case payout == 0:
+			outcome = "😐 平局，返还下注"
+		default:
+			vars.Amount = bet
+			outcome = service.RenderOutcome(persona, false, defaultLoseLine, vars)
+		}
+		switch {
+		case refund > 0:
+			outcome += fmt.Sprintf("\n🛡️ 保险赔付 %d 金币（保险费 %d）", refund, premium)
+		case premium > 0:
+			outcome += fmt.Sprintf("\n🛡️ 保险未触发（保险费 %d）", premium)
+		}
+		if payout != 0 {
+			outcome += h.banterLine(ctx, c.Chat().ID, sender, payout > 0)
+		}
+		resultMsg := tgfmt.Sprintf("%s 🎲🎲 %d + %d = %d\n%s\n%s", playerName(persona, sender.ID, username), dice1Val, dice2Val, total, outcome, h.renderBalance(persona, scope, newBalance))
+
+		replyMsg, err := sendInTopic(c, resultMsg.String(), tele.ModeHTML, replayMarkup(replayDice, sender.ID, bet))
+		if err == nil && replyMsg != nil {
+			h.trackMessage(c.Chat().ID, replyMsg.ID)
+		}
+	}()
+
+	return nil
+}
+
+// refundDice returns the stake and insurance premium of a dice game that
+// could not be played
+func (h *DiceHandler) refundDice(ctx context.Context, scope model.BalanceScope, userID, bet, premium int64) {
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, bet, model.TxTypeDice, nil); err != nil {
+		h.reportIncidentIn(scope, service.IncidentRefundFailed, "骰子发送失败后退还下注失败",
+			service.CompensationClaim{UserID: userID, Amount: bet})
+	}
+	if premium <= 0 {
+		return
+	}
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, premium, model.TxTypeDiceInsure, nil); err != nil {
+		h.reportIncidentIn(scope, service.IncidentRefundFailed, "骰子发送失败后退还保险费失败",
+			service.CompensationClaim{UserID: userID, Amount: premium})
+	}
+}
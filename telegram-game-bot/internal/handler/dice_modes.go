@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/service"
+)
+
+// prepareStake parses the stake argument and checks cooldown, balance tier and balance.
+// On success the stake is deducted and returned; the caller must hold the user lock.
+// On failure the returned error text is the reply for the user.
+func (h *DiceHandler) prepareStake(ctx context.Context, c tele.Context, scope model.BalanceScope, command string) (int64, error) {
+	sender := c.Sender()
+
+	args := c.Args()
+	if len(args) < 1 {
+		return 0, fmt.Errorf("❌ 用法: /%s <金额>\n例如: /%s 100", command, command)
+	}
+
+	bet, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || bet <= 0 {
+		return 0, errors.New("❌ 请输入有效的下注金额")
+	}
+
+	if remaining := h.checkCooldown(sender.ID, command); remaining > 0 {
+		return 0, errors.New(cooldownMessage(remaining))
+	}
+
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+	if err != nil {
+		return 0, errors.New("❌ 获取余额失败")
+	}
+
+	maxBet := h.getEffectiveMaxBet(balance, h.cfg.Games.Dice.MaxBet)
+	if bet > maxBet {
+		tierMaxBet, tierThreshold := getBalanceTierInfo(balance)
+		if tierThreshold > 0 {
+			return 0, fmt.Errorf("❌ 余额超过 %d，单次下注上限为 %d", tierThreshold, tierMaxBet)
+		}
+		return 0, fmt.Errorf("❌ 最大下注金额为 %d", maxBet)
+	}
+
+	if balance < bet {
+		return 0, errors.New("❌ 余额不足")
+	}
+
+	desc := fmt.Sprintf("/%s 下注 %d", command, bet)
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -bet, model.TxTypeDice, &desc); err != nil {
+		return 0, errors.New("❌ 扣款失败，请稍后重试")
+	}
+
+	return bet, nil
+}
+
+// refundStake returns a stake after the game could not be played.
+// Failed refunds are reported for compensation.
+func (h *DiceHandler) refundStake(ctx context.Context, scope model.BalanceScope, userID int64, bet int64, description string) {
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, bet, model.TxTypeDice, nil); err != nil {
+		h.reportIncidentIn(scope, service.IncidentRefundFailed, description,
+			service.CompensationClaim{UserID: userID, Amount: bet})
+	}
+}
+
+// creditWinnings credits stake + payout for a won or pushed game and feeds the chat statistics.
+func (h *DiceHandler) creditWinnings(ctx context.Context, scope model.BalanceScope, chatID int64, user *model.User, bet, payout int64, desc string) {
+	if payout < 0 {
+		return
+	}
+
+	creditAmount := bet + payout
+	h.userLock.Lock(user.TelegramID)
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, user.TelegramID, creditAmount, model.TxTypeDice, &desc); err != nil {
+		h.reportIncidentIn(scope, service.IncidentCreditFailed, desc,
+			service.CompensationClaim{UserID: user.TelegramID, Amount: creditAmount})
+	}
+	h.userLock.Unlock(user.TelegramID)
+	h.recordWin(chatID, user, payout)
+	h.publishWin(chatID, user, "dice", payout)
+}
+
+// HandleDice3 handles the /dice3 command: three dice with the triple dice paytable.
+func (h *DiceHandler) HandleDice3(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 骰子游戏只能在群组中进行，请加入群组后使用")
+	}
+
+	tripleGame, ok := h.gameRegistry.Get("dice3")
+	if !ok {
+		return c.Reply("❌ 该游戏暂未开放")
+	}
+
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	scope := h.balanceScope(ctx, chat.ID)
+
+	h.userLock.Lock(sender.ID)
+	defer h.userLock.Unlock(sender.ID)
+
+	bet, err := h.prepareStake(ctx, c, scope, "dice3")
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+
+	// Send three dice
+	params := make(map[string]any)
+	for i := 1; i <= 3; i++ {
+		diceMsg, err := sendInTopic(c, tele.Cube)
+		if err != nil {
+			h.refundStake(ctx, scope, sender.ID, bet, "三骰子发送失败后退还下注失败")
+			return c.Reply("❌ 发送骰子失败")
+		}
+		h.trackMessage(chat.ID, diceMsg.ID)
+		params[fmt.Sprintf("dice%d", i)] = diceMsg.Dice.Value
+		if i < 3 {
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+
+	result, err := tripleGame.Play(ctx, sender.ID, bet, params)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to play triple dice")
+		h.refundStake(ctx, scope, sender.ID, bet, "三骰子结算失败后退还下注失败")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	payout := result.Payout
+	dice1, dice2, dice3 := params["dice1"].(int), params["dice2"].(int), params["dice3"].(int)
+	total := result.Details["total"].(int)
+	triple := result.Details["triple"].(bool)
+
+	h.setCooldown(sender.ID, "dice3", tripleGame.Cooldown())
+	h.recordWager(chat.ID, bet)
+
+	go func() {
+		// Wait for dice animation
+		time.Sleep(3 * time.Second)
+
+		h.creditWinnings(ctx, scope, chat.ID, user, bet, payout, fmt.Sprintf("三骰子赢得 %d", payout))
+		newBalance, _ := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+
+		persona := h.chatPersona(ctx, chat.ID)
+		vars := service.PersonaVars{User: username, Amount: payout, Balance: newBalance, Game: "三骰子"}
+		roll := tgfmt.Sprintf("%s 🎲🎲🎲 %d + %d + %d = %d", playerName(persona, sender.ID, username), dice1, dice2, dice3, total)
+		var outcome string
+		switch {
+		case triple:
+			outcome = service.RenderOutcome(persona, true, "🐆 豹子！赢得 {amount} 金币！", vars)
+		case payout > bet:
+			outcome = service.RenderOutcome(persona, true, "🎊 大赢！赢得 {amount} 金币！", vars)
+		case payout > 0:
+			outcome = service.RenderOutcome(persona, true, defaultWinLine, vars)
+		case payout == 0:
+			outcome = "😐 平局，返还下注"
+		default:
+			vars.Amount = bet
+			outcome = service.RenderOutcome(persona, false, defaultLoseLine, vars)
+		}
+		if payout != 0 {
+			outcome += h.banterLine(ctx, chat.ID, sender, payout > 0)
+		}
+		resultMsg := tgfmt.Sprintf("%s\n%s\n%s", roll, outcome, h.renderBalance(persona, scope, newBalance))
+
+		replyMsg, err := sendInTopic(c, resultMsg.String(), tele.ModeHTML)
+		if err == nil && replyMsg != nil {
+			h.trackMessage(chat.ID, replyMsg.ID)
+		}
+	}()
+
+	return nil
+}
+
+// HandleDiceBo3 handles the /dicebo3 command: a best-of-three dice duel against the bot.
+// The stake is deducted once and rides until one side has won two rounds.
+func (h *DiceHandler) HandleDiceBo3(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 骰子游戏只能在群组中进行，请加入群组后使用")
+	}
+
+	bo3Game, ok := h.gameRegistry.Get("dicebo3")
+	if !ok {
+		return c.Reply("❌ 该游戏暂未开放")
+	}
+
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	scope := h.balanceScope(ctx, chat.ID)
+
+	h.userLock.Lock(sender.ID)
+	bet, err := h.prepareStake(ctx, c, scope, "dicebo3")
+	h.userLock.Unlock(sender.ID)
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+
+	h.setCooldown(sender.ID, "dicebo3", bo3Game.Cooldown())
+	h.recordWager(chat.ID, bet)
+
+	if err := c.Reply(fmt.Sprintf("🎲 三局两胜开始！押注 %d 金币\n每轮先掷的是你，后掷的是机器人", bet)); err != nil {
+		log.Debug().Err(err).Msg("Failed to announce best-of-three match")
+	}
+
+	// The match takes several rolls, so it runs in the background
+	go func() {
+		var rounds [][2]int
+		for !dice.IsBestOfThreeFinished(rounds) {
+			playerMsg, err := sendInTopic(c, tele.Cube)
+			if err != nil {
+				h.abortBo3(ctx, c, scope, sender.ID, bet)
+				return
+			}
+			h.trackMessage(chat.ID, playerMsg.ID)
+			time.Sleep(500 * time.Millisecond)
+
+			botMsg, err := sendInTopic(c, tele.Cube)
+			if err != nil {
+				h.abortBo3(ctx, c, scope, sender.ID, bet)
+				return
+			}
+			h.trackMessage(chat.ID, botMsg.ID)
+
+			rounds = append(rounds, [2]int{playerMsg.Dice.Value, botMsg.Dice.Value})
+
+			// Wait for dice animation
+			time.Sleep(3 * time.Second)
+		}
+
+		result, err := bo3Game.Play(ctx, sender.ID, bet, map[string]any{"rounds": rounds})
+		if err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to play best-of-three dice")
+			h.abortBo3(ctx, c, scope, sender.ID, bet)
+			return
+		}
+		payout := result.Payout
+
+		h.creditWinnings(ctx, scope, chat.ID, user, bet, payout, fmt.Sprintf("三局两胜赢得 %d", payout))
+		newBalance, _ := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+
+		persona := h.chatPersona(ctx, chat.ID)
+		var sb strings.Builder
+		sb.WriteString(tgfmt.Sprintf("%s 🎲 三局两胜 %d:%d\n", playerName(persona, sender.ID, username),
+			result.Details["player_wins"].(int), result.Details["bot_wins"].(int)).String())
+		for i, round := range rounds {
+			outcome := "平"
+			switch {
+			case round[0] > round[1]:
+				outcome = "胜"
+			case round[1] > round[0]:
+				outcome = "负"
+			}
+			sb.WriteString(fmt.Sprintf("第%d掷: 你 %d vs 机器人 %d (%s)\n", i+1, round[0], round[1], outcome))
+		}
+		vars := service.PersonaVars{User: username, Amount: payout, Balance: newBalance, Game: "三局两胜"}
+		switch {
+		case payout > 0:
+			sb.WriteString(tgfmt.Escape(service.RenderOutcome(persona, true, defaultWinLine, vars)).String() + "\n")
+		case payout == 0:
+			sb.WriteString("😐 未分胜负，返还下注\n")
+		default:
+			vars.Amount = bet
+			sb.WriteString(tgfmt.Escape(service.RenderOutcome(persona, false, defaultLoseLine, vars)).String() + "\n")
+		}
+		sb.WriteString(tgfmt.Escape(h.renderBalance(persona, scope, newBalance)).String())
+
+		replyMsg, err := sendInTopic(c, sb.String(), tele.ModeHTML)
+		if err == nil && replyMsg != nil {
+			h.trackMessage(chat.ID, replyMsg.ID)
+		}
+	}()
+
+	return nil
+}
+
+// abortBo3 refunds a best-of-three match that could not be completed
+func (h *DiceHandler) abortBo3(ctx context.Context, c tele.Context, scope model.BalanceScope, userID int64, bet int64) {
+	h.userLock.Lock(userID)
+	h.refundStake(ctx, scope, userID, bet, "三局两胜中断后退还下注失败")
+	h.userLock.Unlock(userID)
+
+	if _, err := sendInTopic(c, "❌ 发送骰子失败，本局已取消并退还下注"); err != nil {
+		log.Debug().Err(err).Msg("Failed to announce aborted best-of-three match")
+	}
+}
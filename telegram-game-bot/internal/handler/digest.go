@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// digestTickInterval is how often the digest job checks whether the digest is due
+const digestTickInterval = time.Minute
+
+// DigestHandler sends the nightly admin digest.
+type DigestHandler struct {
+	digest *service.DigestService
+}
+
+// NewDigestHandler creates a new DigestHandler.
+func NewDigestHandler(digest *service.DigestService) *DigestHandler {
+	return &DigestHandler{digest: digest}
+}
+
+// StartScheduler starts the loop sending the digest once it is due each day.
+func (h *DigestHandler) StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(digestTickInterval)
+		heartbeat.Start("admin_digest", digestTickInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("admin_digest")
+			h.digest.Run(context.Background(), now)
+		}
+	}()
+}
+
+// HandleDigest handles the /digest command (admin only): today's digest so far.
+func (h *DigestHandler) HandleDigest(c tele.Context) error {
+	d, err := h.digest.Build(context.Background(), time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build admin digest")
+		return c.Reply("❌ 生成摘要失败，请稍后重试")
+	}
+	return c.Reply(service.FormatAdminDigest(d))
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/allin"
+	"telegram-game-bot/internal/pkg/tgfmt"
+)
+
+// duelDecisionActions maps the decision buttons of a best-of-three duel
+var duelDecisionActions = map[string]allin.DuelDecision{
+	"duel_double":   allin.DuelDouble,
+	"duel_continue": allin.DuelContinue,
+	"duel_quit":     allin.DuelQuit,
+}
+
+// startDuelMatch accepts a best-of-three duel and plays it in the background.
+func (h *AllInHandler) startDuelMatch(c tele.Context, targetID int64) error {
+	match, err := h.allInGame.StartDuelMatch(context.Background(), targetID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ " + err.Error(),
+			ShowAlert: true,
+		})
+	}
+
+	c.Edit(fmt.Sprintf("⚔️ 三局两胜对决开始！%s vs %s\n💰 双方各押 %d 金币，奖池 %d 金币\n每局先掷的是 %s，后掷的是 %s",
+		match.Names[0], match.Names[1], match.Stake, match.Pot(), match.Names[0], match.Names[1]))
+	go h.runDuelMatch(c.Bot(), &tele.Chat{ID: match.ChatID}, topicOptions(topicOf(c)), match)
+	return c.Respond(&tele.CallbackResponse{Text: "⚔️ 对决开始！"})
+}
+
+// runDuelMatch rolls the rounds of a match, asking the round loser to double
+// or quit in between, until the match is decided. Messages are sent with
+// topic, keeping them in the forum topic of the challenge.
+func (h *AllInHandler) runDuelMatch(bot *tele.Bot, chat *tele.Chat, topic *tele.SendOptions, match *allin.DuelMatch) {
+	ctx := context.Background()
+
+	for round := 1; ; round++ {
+		var values [2]int
+		for i := range values {
+			diceMsg, err := bot.Send(chat, tele.Cube, topic)
+			if err != nil {
+				h.cancelDuelMatch(bot, chat, topic, match.ID)
+				return
+			}
+			values[i] = diceMsg.Dice.Value
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		// Wait for dice animation
+		time.Sleep(3 * time.Second)
+
+		res, err := h.allInGame.RecordDuelRound(ctx, match.ID, values[0], values[1])
+		if err != nil {
+			log.Error().Err(err).Int64("match_id", match.ID).Msg("Failed to record duel round")
+			return
+		}
+		match = res.Match
+
+		line := fmt.Sprintf("第%d局: %s %d vs %s %d", round, match.Names[0], values[0], match.Names[1], values[1])
+		if res.RoundWinner < 0 {
+			bot.Send(chat, line+"\n🤝 平局，重掷", topic)
+			continue
+		}
+		line += fmt.Sprintf("\n%s 赢下本局（比分 %d:%d）", match.Names[res.RoundWinner], match.Wins[0], match.Wins[1])
+		if res.Result != nil {
+			bot.Send(chat, line+"\n\n"+res.Result.Message, topic)
+			return
+		}
+
+		if ended := h.askDuelDecision(ctx, bot, chat, topic, match, line); ended {
+			return
+		}
+	}
+}
+
+// askDuelDecision asks the round loser to double or quit and applies the
+// choice. It reports whether the match ended.
+func (h *AllInHandler) askDuelDecision(ctx context.Context, bot *tele.Bot, chat *tele.Chat, topic *tele.SendOptions, match *allin.DuelMatch, line string) bool {
+	loser := match.Decider
+	extra := h.allInGame.DuelDoubleOffer(ctx, match)
+
+	markup := &tele.ReplyMarkup{}
+	matchID := fmt.Sprintf("%d", match.ID)
+	btnQuit := markup.Data("🏳️ 认输", "duel_quit", matchID)
+	if extra > 0 {
+		markup.Inline(markup.Row(markup.Data(fmt.Sprintf("🔥 加倍 (+%d)", extra), "duel_double", matchID), btnQuit))
+	} else {
+		markup.Inline(markup.Row(markup.Data("▶️ 继续", "duel_continue", matchID), btnQuit))
+	}
+
+	prompt := tgfmt.Sprintf("%s\n\n%s 加倍还是认输？%d秒内未选择则按原注继续",
+		tgfmt.Escape(line), tgfmt.Mention(match.PlayerIDs[loser], match.Names[loser]), allin.DuelDecisionTimeout)
+	promptMsg, err := bot.Send(chat, prompt.String(), topic, markup, tele.ModeHTML)
+	if err != nil {
+		log.Warn().Err(err).Int64("match_id", match.ID).Msg("Failed to ask duel decision")
+	}
+
+	decision := h.allInGame.AwaitDuelDecision(match.ID)
+
+	var outcome string
+	switch decision {
+	case allin.DuelQuit:
+		result, err := h.allInGame.ForfeitDuelMatch(ctx, match.ID, loser)
+		if err != nil {
+			log.Error().Err(err).Int64("match_id", match.ID).Msg("Failed to forfeit duel match")
+			return true
+		}
+		h.editDuelPrompt(bot, promptMsg, line, fmt.Sprintf("🏳️ %s 认输", match.Names[loser]))
+		bot.Send(chat, result.Message, topic)
+		return true
+	case allin.DuelDouble:
+		added, err := h.allInGame.DoubleDuelStake(ctx, match.ID)
+		if err != nil {
+			outcome = fmt.Sprintf("⚠️ 加倍失败（%s），按原注继续", err.Error())
+		} else {
+			outcome = fmt.Sprintf("🔥 %s 加倍！双方各追加 %d 金币，奖池 %d 金币", match.Names[loser], added, match.Pot()+2*added)
+		}
+	default:
+		outcome = "▶️ 按原注继续"
+	}
+	h.editDuelPrompt(bot, promptMsg, line, outcome)
+	return false
+}
+
+// editDuelPrompt replaces the decision buttons with the choice made
+func (h *AllInHandler) editDuelPrompt(bot *tele.Bot, promptMsg *tele.Message, line, outcome string) {
+	if promptMsg == nil {
+		return
+	}
+	if _, err := bot.Edit(promptMsg, line+"\n\n"+outcome); err != nil {
+		log.Debug().Err(err).Msg("Failed to edit duel decision prompt")
+	}
+}
+
+// cancelDuelMatch refunds a match whose dice could not be sent
+func (h *AllInHandler) cancelDuelMatch(bot *tele.Bot, chat *tele.Chat, topic *tele.SendOptions, matchID int64) {
+	if err := h.allInGame.CancelDuelMatch(context.Background(), matchID); err != nil {
+		log.Error().Err(err).Int64("match_id", matchID).Msg("Failed to refund duel match")
+		return
+	}
+	bot.Send(chat, "❌ 发送骰子失败，对决取消，押注已退还", topic)
+}
+
+// handleDuelDecision handles the double/continue/quit buttons of a match.
+func (h *AllInHandler) handleDuelDecision(c tele.Context, matchID int64, decision allin.DuelDecision) error {
+	if err := h.allInGame.SubmitDuelDecision(matchID, c.Sender().ID, decision); err != nil {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ " + err.Error(),
+			ShowAlert: true,
+		})
+	}
+	return c.Respond(&tele.CallbackResponse{Text: "已选择"})
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+// ExportHandler sends users a CSV of their own transactions.
+type ExportHandler struct {
+	exportService *service.ExportService
+}
+
+// NewExportHandler creates a new ExportHandler.
+func NewExportHandler(exportService *service.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// HandleExportMe handles the /export_me command (private chat only, once a day).
+func (h *ExportHandler) HandleExportMe(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	// 仅限私聊使用
+	if chat.Type != tele.ChatPrivate {
+		return c.Reply("❌ 请私聊机器人导出数据")
+	}
+
+	canExport, remaining, err := h.exportService.CanExport(ctx, sender.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return c.Reply("❌ 你还没有账户，请先在群里发送 /start")
+		}
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	if !canExport {
+		return c.Reply("⏰ 每天只能导出一次，请等待 " + cooldown.Format(remaining) + " 后再来")
+	}
+
+	data, rows, err := h.exportService.Export(ctx, sender.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportTooSoon) {
+			return c.Reply("❌ " + err.Error())
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to export user data")
+		return c.Reply("❌ 导出失败，请稍后重试")
+	}
+
+	doc := &tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("transactions_%d.csv", sender.ID),
+		Caption:  fmt.Sprintf("📄 你的交易记录，共 %d 条", rows),
+	}
+	return c.Reply(doc)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// featureFlagRefreshInterval is how often flag changes made on other instances are picked up
+const featureFlagRefreshInterval = time.Minute
+
+// featureFlagUsage explains the /flag subcommands
+const featureFlagUsage = "📖 用法:\n" +
+	"/flag - 查看功能开关\n" +
+	"/flag <开关> on|off [范围] - 开启或关闭\n" +
+	"/flag <开关> clear [范围] - 移除覆盖，恢复默认\n\n" +
+	"范围: global（默认）、chat [群ID]（群内可省略群ID）、user <用户ID|#编号>\n" +
+	"优先级: 用户 > 群 > 全局 > 默认"
+
+// FeatureFlagHandler lets admins turn features on and off for everyone, a
+// chat or a user.
+type FeatureFlagHandler struct {
+	flags          *service.FeatureFlagService
+	accountService *service.AccountService
+}
+
+// NewFeatureFlagHandler creates a new FeatureFlagHandler.
+func NewFeatureFlagHandler(flags *service.FeatureFlagService, accountService *service.AccountService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{flags: flags, accountService: accountService}
+}
+
+// StartRefresher periodically reloads the flag overrides. Every instance
+// keeps its own copy, so every instance refreshes.
+func (h *FeatureFlagHandler) StartRefresher() {
+	go func() {
+		ticker := time.NewTicker(featureFlagRefreshInterval)
+		heartbeat.Start("feature_flags", featureFlagRefreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("feature_flags")
+			if err := h.flags.Refresh(context.Background()); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh feature flags")
+			}
+		}
+	}()
+}
+
+// HandleFlag handles the /flag command (admin only).
+func (h *FeatureFlagHandler) HandleFlag(c tele.Context) error {
+	ctx := context.Background()
+	args := c.Args()
+	if len(args) == 0 {
+		return h.replyList(ctx, c)
+	}
+	if len(args) < 2 {
+		return c.Reply(featureFlagUsage)
+	}
+
+	flag, action := strings.ToLower(args[0]), strings.ToLower(args[1])
+	if action != "on" && action != "off" && action != "clear" {
+		return c.Reply(featureFlagUsage)
+	}
+	scope, targetID, ok := h.parseScope(ctx, c, args[2:])
+	if !ok {
+		return nil
+	}
+
+	var err error
+	if action == "clear" {
+		err = h.flags.Clear(ctx, flag, scope, targetID, c.Sender().ID)
+	} else {
+		err = h.flags.Set(ctx, flag, scope, targetID, action == "on", c.Sender().ID)
+	}
+	switch {
+	case errors.Is(err, service.ErrFeatureFlagUnknown):
+		return c.Reply("❌ " + err.Error() + "\n\n" + h.formatDefaults())
+	case errors.Is(err, service.ErrFeatureFlagScope), errors.Is(err, service.ErrFeatureFlagNotFound):
+		return c.Reply("❌ " + err.Error())
+	case err != nil:
+		log.Error().Err(err).Str("flag", flag).Msg("Failed to change feature flag")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	target := formatFlagTarget(scope, targetID)
+	switch action {
+	case "clear":
+		return c.Reply(fmt.Sprintf("✅ 已移除 %s 对%s的覆盖", flag, target))
+	case "on":
+		return c.Reply(fmt.Sprintf("✅ 已对%s开启 %s", target, flag))
+	default:
+		return c.Reply(fmt.Sprintf("✅ 已对%s关闭 %s", target, flag))
+	}
+}
+
+// parseScope parses the optional scope arguments of /flag.
+// On failure it replies to the user and returns ok = false.
+func (h *FeatureFlagHandler) parseScope(ctx context.Context, c tele.Context, args []string) (string, int64, bool) {
+	if len(args) == 0 {
+		return model.FlagScopeGlobal, 0, true
+	}
+
+	switch strings.ToLower(args[0]) {
+	case model.FlagScopeGlobal:
+		if len(args) == 1 {
+			return model.FlagScopeGlobal, 0, true
+		}
+	case model.FlagScopeChat:
+		if len(args) == 1 {
+			if chat := c.Chat(); chat != nil && chat.Type != tele.ChatPrivate {
+				return model.FlagScopeChat, chat.ID, true
+			}
+			return "", 0, h.usage(c)
+		}
+		if chatID, err := strconv.ParseInt(args[1], 10, 64); err == nil && len(args) == 2 {
+			return model.FlagScopeChat, chatID, true
+		}
+	case model.FlagScopeUser:
+		if len(args) != 2 {
+			break
+		}
+		if isHandleArg(args[1]) {
+			userID, _, ok := resolveHandleTarget(ctx, c, h.accountService, args[1])
+			return model.FlagScopeUser, userID, ok
+		}
+		if userID, err := strconv.ParseInt(args[1], 10, 64); err == nil {
+			return model.FlagScopeUser, userID, true
+		}
+	}
+	return "", 0, h.usage(c)
+}
+
+// usage replies with the usage and returns false
+func (h *FeatureFlagHandler) usage(c tele.Context) bool {
+	_ = c.Reply("❌ " + service.ErrFeatureFlagScope.Error() + "\n\n" + featureFlagUsage)
+	return false
+}
+
+// replyList shows the flags with their defaults and overrides
+func (h *FeatureFlagHandler) replyList(ctx context.Context, c tele.Context) error {
+	var sb strings.Builder
+	sb.WriteString(h.formatDefaults())
+
+	overrides, err := h.flags.Overrides(ctx)
+	switch {
+	case err != nil:
+		log.Warn().Err(err).Msg("Failed to load feature flags")
+		sb.WriteString("\n⚠️ 覆盖设置暂不可用，仅默认值生效\n")
+	case len(overrides) > 0:
+		sb.WriteString("\n覆盖:\n")
+		for _, f := range overrides {
+			state := "🔴 关"
+			if f.Enabled {
+				state = "🟢 开"
+			}
+			fmt.Fprintf(&sb, "%s %s · %s\n", state, f.Flag, formatFlagTarget(f.Scope, f.TargetID))
+		}
+	}
+
+	sb.WriteString("\n" + featureFlagUsage)
+	return c.Reply(sb.String())
+}
+
+// formatDefaults lists the known flags with their defaults
+func (h *FeatureFlagHandler) formatDefaults() string {
+	var sb strings.Builder
+	sb.WriteString("🚩 功能开关\n━━━━━━━━━━━━━━━\n")
+	names, defaults := h.flags.Defaults()
+	for _, name := range names {
+		state := "🔴 默认关"
+		if defaults[name] {
+			state = "🟢 默认开"
+		}
+		fmt.Fprintf(&sb, "%s %s（%s）\n", state, name, service.FeatureFlagNames[name])
+	}
+	return sb.String()
+}
+
+// formatFlagTarget describes the target of an override
+func formatFlagTarget(scope string, targetID int64) string {
+	switch scope {
+	case model.FlagScopeChat:
+		return fmt.Sprintf("群 %d", targetID)
+	case model.FlagScopeUser:
+		return fmt.Sprintf("用户 %d", targetID)
+	default:
+		return "全局"
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+)
+
+// limitsUsage explains /limits
+const limitsUsage = "📖 用法:\n" +
+	"/limits - 查看限额和今日统计\n" +
+	"/limits set wager 金额 - 设置每日下注上限\n" +
+	"/limits set loss 金额 - 设置每日亏损上限\n" +
+	"金额为 0 表示取消该限额\n\n" +
+	"⚠️ 达到上限后当天无法再参与游戏；当天达到上限后只能收紧、不能放宽"
+
+// GamblingLimitHandler lets users cap how much they stake and lose per day.
+type GamblingLimitHandler struct {
+	limits *service.GamblingLimitService
+}
+
+// NewGamblingLimitHandler creates a new GamblingLimitHandler.
+func NewGamblingLimitHandler(limits *service.GamblingLimitService) *GamblingLimitHandler {
+	return &GamblingLimitHandler{limits: limits}
+}
+
+// HandleLimits handles the /limits command.
+// Format: /limits [set wager|loss <amount>]
+func (h *GamblingLimitHandler) HandleLimits(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		limits, err := h.limits.Limits(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		totals, err := h.limits.Today(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(formatGamblingLimits(limits, totals) + "\n\n" + limitsUsage)
+	}
+
+	if len(args) != 3 || strings.ToLower(args[0]) != "set" {
+		return c.Reply(limitsUsage)
+	}
+	amount, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || amount < 0 {
+		return c.Reply("❌ " + service.ErrInvalidGamblingLimit.Error() + "\n\n" + limitsUsage)
+	}
+
+	var limits model.GamblingLimits
+	switch strings.ToLower(args[1]) {
+	case "wager", "下注":
+		limits, err = h.limits.SetWagerLimit(ctx, sender.ID, amount)
+	case "loss", "亏损":
+		limits, err = h.limits.SetLossLimit(ctx, sender.ID, amount)
+	default:
+		return c.Reply(limitsUsage)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrGamblingLimitLocked) || errors.Is(err, service.ErrInvalidGamblingLimit) {
+			return c.Reply("❌ " + err.Error())
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to set gambling limits")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	totals, err := h.limits.Today(ctx, sender.ID)
+	if err != nil {
+		return c.Reply("✅ 限额已更新")
+	}
+	return c.Reply("✅ 限额已更新\n\n" + formatGamblingLimits(limits, totals))
+}
+
+// formatGamblingLimits formats a user's limits with today's totals
+func formatGamblingLimits(limits model.GamblingLimits, totals *model.GamblingDayTotals) string {
+	limitText := func(limit int64) string {
+		if limit == 0 {
+			return "未设置"
+		}
+		return fmt.Sprintf("%d 金币", limit)
+	}
+	var loss int64
+	if totals.Net < 0 {
+		loss = -totals.Net
+	}
+
+	return fmt.Sprintf("🛡️ 每日游戏限额\n\n"+
+		"🎲 下注上限: %s（今日已下注 %d）\n"+
+		"📉 亏损上限: %s（今日已亏损 %d）",
+		limitText(limits.DailyWager), totals.Wagered,
+		limitText(limits.DailyLoss), loss)
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/game"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/pkg/events"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/metrics"
+	"telegram-game-bot/internal/pkg/tracing"
+	"telegram-game-bot/internal/service"
+)
+
+const (
+	// MessageDeleteInterval is the interval for auto-deleting bot messages (30 minutes)
+	MessageDeleteInterval = 30 * time.Minute
+)
+
+// BetTier represents a balance tier with its max bet limit
+type BetTier struct {
+	MinBalance int64 // Minimum balance for this tier
+	MaxBet     int64 // Maximum bet allowed for this tier
+}
+
+// BetTiers defines the tiered betting limits based on balance
+// Higher balance = higher max bet allowed
+var BetTiers = []BetTier{
+	{MinBalance: 500000, MaxBet: 10000}, // 50万+ 余额: 最大下注 1万
+	{MinBalance: 100000, MaxBet: 5000},  // 10万-50万 余额: 最大下注 5千
+	{MinBalance: 0, MaxBet: 3000},       // 10万以下: 最大下注 3千
+}
+
+// TrackedMessage represents a message to be deleted later
+type TrackedMessage struct {
+	ChatID      int64
+	MessageID   int
+	SentAt      time.Time
+	DeleteAfter time.Duration // Chosen by the chat, MessageDeleteInterval by default
+}
+
+// BaseHandler holds what the game handlers share: accounts and locking,
+// cooldowns, tracked bot messages for cleanup, chat personas and the optional
+// services that record and celebrate results.
+type BaseHandler struct {
+	cfg                 *config.Config
+	accountService      *service.AccountService
+	compensationService *service.CompensationService
+	chatStats           *service.ChatStatsService
+	gameRegistry        *game.Registry
+	userLock            *lock.UserLock
+	cooldowns           *cooldown.Manager
+	trackedMessages     []TrackedMessage
+	messagesMu          sync.Mutex
+	persona             *service.PersonaService
+	celebrations        *service.CelebrationService  // Optional: media after big wins
+	banter              *service.BanterService       // Optional: phrases appended to results
+	sandbox             *service.SandboxService      // Optional: play money in sandbox chats
+	durations           *metrics.HistogramVec        // Optional: timings of background settlements
+	tracer              *tracing.Tracer              // Optional: spans of background settlements
+	events              *events.Bus                  // Optional: game wins are published for win records
+	chatSettings        *service.ChatSettingsService // Optional: per chat message cleanup
+	flags               *service.FeatureFlagService  // Optional: features turned on and off per chat or user
+	rounds              *service.GameRoundService    // Optional: dice and slot rounds recorded for replays
+}
+
+// NewBaseHandler creates the state shared by the game handlers.
+func NewBaseHandler(
+	cfg *config.Config,
+	accountService *service.AccountService,
+	compensationService *service.CompensationService,
+	gameRegistry *game.Registry,
+	userLock *lock.UserLock,
+) *BaseHandler {
+	h := &BaseHandler{
+		cfg:                 cfg,
+		accountService:      accountService,
+		compensationService: compensationService,
+		gameRegistry:        gameRegistry,
+		userLock:            userLock,
+		cooldowns:           cooldown.New(),
+		trackedMessages:     make([]TrackedMessage, 0),
+	}
+	h.registerCooldownSources()
+	h.registerAggressionBucket()
+	return h
+}
+
+// SetChatStats sets the tracker fed with wagers and wins for pinned chat statistics
+func (h *BaseHandler) SetChatStats(chatStats *service.ChatStatsService) {
+	h.chatStats = chatStats
+}
+
+// recordWager adds a bet to the chat statistics; play money is not counted
+func (h *BaseHandler) recordWager(chatID int64, amount int64) {
+	if h.chatStats != nil && !h.inSandbox(chatID) {
+		h.chatStats.RecordWager(chatID, amount)
+	}
+}
+
+// recordWin adds a win to the chat statistics, respecting the user's leaderboard privacy.
+// Play money is not counted.
+func (h *BaseHandler) recordWin(chatID int64, user *model.User, amount int64) {
+	if h.chatStats == nil || user == nil || h.inSandbox(chatID) {
+		return
+	}
+	name := service.RankDisplayName(user.Username, user.TelegramID, user.HideFromLeaderboard)
+	h.chatStats.RecordWin(chatID, name, amount)
+}
+
+// SetChatSettings sets the per chat settings deciding when bot messages are deleted
+func (h *BaseHandler) SetChatSettings(settings *service.ChatSettingsService) {
+	h.chatSettings = settings
+}
+
+// SetFeatureFlags sets the flags deciding which features are on
+func (h *BaseHandler) SetFeatureFlags(flags *service.FeatureFlagService) {
+	h.flags = flags
+}
+
+// featureEnabled reports whether a feature flag is on for a user in a chat,
+// fallback without feature flags
+func (h *BaseHandler) featureEnabled(flag string, chatID, userID int64, fallback bool) bool {
+	if h.flags == nil {
+		return fallback
+	}
+	return h.flags.Enabled(flag, chatID, userID)
+}
+
+// SetGameRounds sets the service recording dice and slot rounds
+func (h *BaseHandler) SetGameRounds(rounds *service.GameRoundService) {
+	h.rounds = rounds
+}
+
+// recordRound records a settled round if rounds are recorded
+func (h *BaseHandler) recordRound(ctx context.Context, round *model.GameRound) {
+	if h.rounds != nil {
+		h.rounds.Record(ctx, round)
+	}
+}
+
+// SetEventBus sets the bus that game wins are published to
+func (h *BaseHandler) SetEventBus(bus *events.Bus) {
+	h.events = bus
+}
+
+// publishWin publishes a won round of a game in a chat; play money is not published
+func (h *BaseHandler) publishWin(chatID int64, user *model.User, gameName string, amount int64) {
+	if h.events == nil || user == nil || amount <= 0 || h.inSandbox(chatID) {
+		return
+	}
+	h.events.PublishGameWon(events.GameWon{
+		ChatID: chatID,
+		UserID: user.TelegramID,
+		Name:   service.RankDisplayName(user.Username, user.TelegramID, user.HideFromLeaderboard),
+		Game:   gameName,
+		Amount: amount,
+		At:     time.Now(),
+	})
+}
+
+// reportIncident hands losses caused by the bot to the compensation service.
+// Runs in the background so callers may still hold the affected users' locks.
+func (h *BaseHandler) reportIncident(kind, description string, claims ...service.CompensationClaim) {
+	if h.compensationService == nil || len(claims) == 0 {
+		return
+	}
+	go func() {
+		if _, err := h.compensationService.ReportIncident(context.Background(), kind, description, claims); err != nil {
+			log.Error().Err(err).Str("kind", kind).Msg("Failed to report compensation incident")
+		}
+	}()
+}
+
+// SetDurations sets the histogram timing settlements that run outside of handlers
+func (h *BaseHandler) SetDurations(durations *metrics.HistogramVec) {
+	h.durations = durations
+}
+
+// SetTracer sets the tracer recording settlements that run outside of handlers
+func (h *BaseHandler) SetTracer(tracer *tracing.Tracer) {
+	h.tracer = tracer
+}
+
+// observeJob records how long a background job started at start took
+func (h *BaseHandler) observeJob(label string, start time.Time) {
+	if h.durations != nil {
+		h.durations.Observe(label, time.Since(start).Seconds())
+	}
+}
+
+// StartMessageCleaner starts the background goroutine to delete old messages.
+func (h *BaseHandler) StartMessageCleaner(bot *tele.Bot) {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute) // Check every 5 minutes
+		heartbeat.Start("message_cleaner", 5*time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			heartbeat.Beat("message_cleaner")
+			h.cleanOldMessages(bot)
+		}
+	}()
+}
+
+// cleanOldMessages deletes messages older than the cleanup delay of their chat.
+// Messages are deleted in bulk per chat, outside the lock so tracking new
+// messages is never blocked by the API calls.
+func (h *BaseHandler) cleanOldMessages(bot *tele.Bot) {
+	h.messagesMu.Lock()
+	now := time.Now()
+	remaining := make([]TrackedMessage, 0)
+	var expired []TrackedMessage
+
+	for _, msg := range h.trackedMessages {
+		if now.Sub(msg.SentAt) >= msg.DeleteAfter {
+			expired = append(expired, msg)
+		} else {
+			remaining = append(remaining, msg)
+		}
+	}
+
+	h.trackedMessages = remaining
+	h.messagesMu.Unlock()
+
+	deleteMessages(bot, expired)
+}
+
+// trackMessage adds a message to the tracking list for later deletion.
+// Messages of chats that keep bot messages are not tracked.
+func (h *BaseHandler) trackMessage(chatID int64, messageID int) {
+	delay := MessageDeleteInterval
+	if h.chatSettings != nil {
+		delay = h.chatSettings.CleanupDelay(chatID)
+	}
+	if delay <= 0 {
+		return
+	}
+
+	h.messagesMu.Lock()
+	defer h.messagesMu.Unlock()
+
+	h.trackedMessages = append(h.trackedMessages, TrackedMessage{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		SentAt:      time.Now(),
+		DeleteAfter: delay,
+	})
+}
+
+// getEffectiveMaxBet returns the max bet based on user's balance using tiered limits.
+// Tiered limits take priority over config max bet.
+func (h *BaseHandler) getEffectiveMaxBet(balance int64, configMaxBet int64) int64 {
+	// Find the appropriate tier based on balance
+	for _, tier := range BetTiers {
+		if balance >= tier.MinBalance {
+			return tier.MaxBet
+		}
+	}
+	// Fallback to config max bet if no tier matches
+	return configMaxBet
+}
+
+// getBalanceTierInfo returns the current tier's max bet and threshold for error messages
+func getBalanceTierInfo(balance int64) (maxBet int64, threshold int64) {
+	for _, tier := range BetTiers {
+		if balance >= tier.MinBalance {
+			return tier.MaxBet, tier.MinBalance
+		}
+	}
+	return BetTiers[len(BetTiers)-1].MaxBet, 0
+}
+
+// checkCooldown returns the remaining time of a user's cooldown for a game, 0 if none.
+func (h *BaseHandler) checkCooldown(userID int64, gameName string) time.Duration {
+	return h.cooldowns.Remaining(userID, gameName)
+}
+
+// setCooldown starts the cooldown of a user for a game.
+func (h *BaseHandler) setCooldown(userID int64, gameName string, cooldownSecs int) {
+	h.cooldowns.Start(userID, gameName, time.Duration(cooldownSecs)*time.Second)
+}
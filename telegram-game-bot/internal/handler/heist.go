@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/heist"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/service"
+)
+
+// Heist settings
+const (
+	heistTickInterval = time.Second  // 检查招募结束的间隔
+	minHeistDuration  = 10           // 最短招募时长（秒）
+	heistDefaultBuyIn = 100          // 不带金额时的买入
+	heistJoinCallback = "heist_join" // 加入按钮的回调
+)
+
+// heistRound is the handler's state of a chat's heist
+type heistRound struct {
+	panelMsgID int                // 招募面板消息ID（0表示面板未发送）
+	threadID   int                // 论坛话题ID（0表示不在话题中）
+	scope      model.BalanceScope // Balance the buy-ins were paid from
+}
+
+// HeistHandler handles cooperative heists, opened with /heist and joined
+// with the button of the recruiting panel.
+type HeistHandler struct {
+	*BaseHandler
+	heistGame   *heist.HeistGame // Optional: cooperative heists
+	heistRounds sync.Map         // map[int64]*heistRound - chatID -> heist state
+}
+
+// NewHeistHandler creates a new HeistHandler. Heists stay closed until SetHeist.
+func NewHeistHandler(base *BaseHandler) *HeistHandler {
+	return &HeistHandler{BaseHandler: base}
+}
+
+// SetHeist sets the cooperative heist game
+func (h *HeistHandler) SetHeist(heistGame *heist.HeistGame) {
+	h.heistGame = heistGame
+}
+
+// HandleHeist handles the /heist command opening a heist in a group.
+// Format: /heist [买入]
+func (h *HeistHandler) HandleHeist(c tele.Context) error {
+	ctx := context.Background()
+	chat := c.Chat()
+	sender := c.Sender()
+	if chat == nil || sender == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 抢金库只能在群组中进行")
+	}
+	if !h.featureEnabled(service.FlagHeist, chat.ID, sender.ID, true) {
+		return c.Reply("❌ 抢金库暂未开放")
+	}
+
+	if h.heistGame.IsSessionActive(chat.ID) {
+		return c.Reply(fmt.Sprintf("❌ 当前已有招募中的行动，剩余 %d 秒，点击面板按钮加入", h.heistGame.GetSessionTimeRemaining(chat.ID)))
+	}
+
+	buyIn := int64(heistDefaultBuyIn)
+	if args := c.Args(); len(args) > 0 {
+		var err error
+		buyIn, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil || buyIn <= 0 {
+			return c.Reply("❌ 用法: /heist <买入金额>\n例如: /heist 200")
+		}
+	}
+	if maxBuyIn := h.cfg.Games.Heist.MaxBuyIn; maxBuyIn > 0 && buyIn > maxBuyIn {
+		return c.Reply(fmt.Sprintf("❌ 买入不能超过 %d", maxBuyIn))
+	}
+
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, senderName(sender)); err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	scope := h.balanceScope(ctx, chat.ID)
+	if err := h.payHeistBuyIn(ctx, scope, sender.ID, buyIn); err != nil {
+		return c.Reply(err.Error())
+	}
+
+	duration := h.cfg.Games.Heist.JoinDurationSeconds
+	if duration < minHeistDuration {
+		duration = heist.DefaultJoinDuration
+	}
+	if err := h.heistGame.StartSession(chat.ID, sender.ID, buyIn, duration); err != nil {
+		h.refundHeistBuyIn(ctx, scope, sender.ID, buyIn)
+		if errors.Is(err, heist.ErrSessionExists) {
+			return c.Reply("❌ 当前已有招募中的行动")
+		}
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to start heist")
+		return c.Reply("❌ 启动失败，请稍后重试")
+	}
+	h.recordWager(chat.ID, buyIn)
+
+	round := &heistRound{scope: scope, threadID: topicOf(c)}
+	panel, err := sendInTopic(c, formatHeistPanel(scope, buyIn, 1, duration), heistMarkup(buyIn))
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to send heist panel")
+	} else {
+		h.trackMessage(chat.ID, panel.ID)
+		round.panelMsgID = panel.ID
+	}
+	h.heistRounds.Store(chat.ID, round)
+
+	log.Info().
+		Int64("chat_id", chat.ID).
+		Int64("starter_id", sender.ID).
+		Int64("buy_in", buyIn).
+		Int("duration", duration).
+		Msg("Heist started")
+	return nil
+}
+
+// HandleHeistCallback handles the join button of a heist panel.
+func (h *HeistHandler) HandleHeistCallback(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	buyIn := h.heistGame.BuyIn(chat.ID)
+	value, ok := h.heistRounds.Load(chat.ID)
+	if buyIn == 0 || !ok {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 行动已结束", ShowAlert: true})
+	}
+	round := value.(*heistRound)
+
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, senderName(sender)); err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作失败", ShowAlert: true})
+	}
+	if err := h.payHeistBuyIn(ctx, round.scope, sender.ID, buyIn); err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error(), ShowAlert: true})
+	}
+
+	players, err := h.heistGame.Join(chat.ID, sender.ID)
+	if err != nil {
+		h.refundHeistBuyIn(ctx, round.scope, sender.ID, buyIn)
+		text := "❌ 加入失败"
+		switch {
+		case errors.Is(err, heist.ErrAlreadyJoined):
+			text = "❌ 你已经在队伍里了"
+		case errors.Is(err, heist.ErrCrewFull):
+			text = fmt.Sprintf("❌ 队伍已满（%d 人）", heist.MaxPlayers)
+		case errors.Is(err, heist.ErrJoinEnded), errors.Is(err, heist.ErrNoActiveSession):
+			text = "❌ 招募已结束"
+		}
+		return c.Respond(&tele.CallbackResponse{Text: text, ShowAlert: true})
+	}
+	h.recordWager(chat.ID, buyIn)
+
+	if round.panelMsgID != 0 {
+		remaining := h.heistGame.GetSessionTimeRemaining(chat.ID)
+		editMsg := &tele.Message{ID: round.panelMsgID, Chat: chat}
+		if _, err := c.Bot().Edit(editMsg, formatHeistPanel(round.scope, buyIn, players, remaining), heistMarkup(buyIn)); err != nil {
+			log.Debug().Err(err).Int64("chat_id", chat.ID).Msg("Failed to refresh heist panel")
+		}
+	}
+
+	return c.Respond(&tele.CallbackResponse{
+		Text: fmt.Sprintf("🦹 已加入！当前 %d 人，成功率 %d%%", players, heist.SuccessChance(players)),
+	})
+}
+
+// StartHeistScheduler starts the loop settling heists once recruiting ends.
+func (h *HeistHandler) StartHeistScheduler(bot *tele.Bot) {
+	go func() {
+		ticker := time.NewTicker(heistTickInterval)
+		heartbeat.Start("heist", heistTickInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("heist")
+			for _, session := range h.heistGame.ActiveSessions() {
+				if !now.Before(session.JoinEndTime) {
+					start := time.Now()
+					h.settleHeist(context.Background(), session.ChatID, bot)
+					h.observeJob("job:heist_settle", start)
+				}
+			}
+		}
+	}()
+}
+
+// settleHeist rolls a chat's heist, pays the crew and announces the outcome.
+// Credits that fail are reported for compensation.
+func (h *HeistHandler) settleHeist(ctx context.Context, chatID int64, bot *tele.Bot) {
+	result, err := h.heistGame.Settle(chatID)
+	if err != nil {
+		log.Debug().Err(err).Int64("chat_id", chatID).Msg("Heist already settled")
+		return
+	}
+
+	scope := h.balanceScope(ctx, chatID)
+	panelMsgID, threadID := 0, 0
+	if value, ok := h.heistRounds.LoadAndDelete(chatID); ok {
+		round := value.(*heistRound)
+		scope, panelMsgID, threadID = round.scope, round.panelMsgID, round.threadID
+	}
+
+	txType, desc := model.TxTypeHeistWin, "抢金库成功分赃"
+	if result.Void {
+		txType, desc = model.TxTypeHeistRefund, "抢金库人数不足，退还买入"
+	}
+
+	var failedCredits []service.CompensationClaim
+	names := make(map[int64]string, len(result.Players))
+	for _, userID := range result.Players {
+		user, err := h.accountService.GetUser(ctx, userID)
+		names[userID] = strconv.FormatInt(userID, 10)
+		if err == nil && user != nil {
+			names[userID] = textfilter.Name(user.Username)
+		}
+
+		amount := result.Payouts[userID]
+		if amount <= 0 {
+			continue
+		}
+		h.userLock.Lock(userID)
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, amount, txType, &desc); err != nil {
+			failedCredits = append(failedCredits, service.CompensationClaim{UserID: userID, Amount: amount})
+		}
+		h.userLock.Unlock(userID)
+		if result.Success {
+			h.recordWin(chatID, user, amount-result.BuyIn)
+		}
+	}
+	h.reportIncidentIn(scope, service.IncidentCreditFailed, fmt.Sprintf("群 %d 抢金库到账失败", chatID), failedCredits...)
+
+	if bot != nil {
+		if panelMsgID != 0 {
+			editMsg := &tele.Message{ID: panelMsgID, Chat: &tele.Chat{ID: chatID}}
+			if _, err := bot.Edit(editMsg, fmt.Sprintf("🏦 抢金库招募已结束（%d 人）", len(result.Players))); err != nil {
+				log.Debug().Err(err).Int64("chat_id", chatID).Msg("Failed to close heist panel")
+			}
+		}
+		msg := formatHeistResult(h.chatPersona(ctx, chatID), scope, result, names)
+		if _, err := bot.Send(&tele.Chat{ID: chatID}, msg.String(), topicOptions(threadID), tele.ModeHTML); err != nil {
+			log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send heist result")
+		}
+	}
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int("players", len(result.Players)).
+		Int64("buy_in", result.BuyIn).
+		Int("chance", result.Chance).
+		Int("roll", result.Roll).
+		Bool("success", result.Success).
+		Bool("void", result.Void).
+		Msg("Heist settled")
+}
+
+// RefundHeists refunds the buy-ins of the heists still recruiting, which are
+// discarded, when the bot stops; their panels say so in place of the button.
+func (h *HeistHandler) RefundHeists(ctx context.Context, bot *tele.Bot) {
+	if h.heistGame == nil {
+		return
+	}
+	for _, result := range h.heistGame.Discard() {
+		scope, panelMsgID := h.balanceScope(ctx, result.ChatID), 0
+		if value, ok := h.heistRounds.LoadAndDelete(result.ChatID); ok {
+			round := value.(*heistRound)
+			scope, panelMsgID = round.scope, round.panelMsgID
+		}
+
+		desc := "机器人重启，抢金库取消，退还买入"
+		var failedCredits []service.CompensationClaim
+		for _, userID := range result.Players {
+			h.userLock.Lock(userID)
+			if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, result.Payouts[userID], model.TxTypeHeistRefund, &desc); err != nil {
+				failedCredits = append(failedCredits, service.CompensationClaim{UserID: userID, Amount: result.Payouts[userID]})
+			}
+			h.userLock.Unlock(userID)
+		}
+		h.reportIncidentIn(scope, service.IncidentRefundFailed, fmt.Sprintf("群 %d 机器人停止时退还抢金库买入失败", result.ChatID), failedCredits...)
+
+		if bot != nil && panelMsgID != 0 {
+			editMsg := &tele.Message{ID: panelMsgID, Chat: &tele.Chat{ID: result.ChatID}}
+			if _, err := bot.Edit(editMsg, fmt.Sprintf("🏦 抢金库招募已结束\n♻️ 机器人重启，行动已取消，退还买入 %d %s", result.BuyIn, coinName(scope))); err != nil {
+				log.Debug().Err(err).Int64("chat_id", result.ChatID).Msg("Failed to close heist panel")
+			}
+		}
+		log.Info().Int64("chat_id", result.ChatID).Int("players", len(result.Players)).Int64("buy_in", result.BuyIn).Msg("Heist refunded on shutdown")
+	}
+}
+
+// payHeistBuyIn deducts a buy-in; the returned error text is the reply for the user
+func (h *HeistHandler) payHeistBuyIn(ctx context.Context, scope model.BalanceScope, userID, buyIn int64) error {
+	h.userLock.Lock(userID)
+	defer h.userLock.Unlock(userID)
+
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, userID)
+	if err != nil {
+		return errors.New("❌ 获取余额失败")
+	}
+	if balance < buyIn {
+		return fmt.Errorf("❌ 余额不足（需要 %d，当前 %d）", buyIn, balance)
+	}
+
+	desc := fmt.Sprintf("抢金库买入 %d", buyIn)
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, -buyIn, model.TxTypeHeistBuyIn, &desc); err != nil {
+		return errors.New("❌ 扣款失败")
+	}
+	return nil
+}
+
+// refundHeistBuyIn returns a buy-in that did not get its payer into a crew
+func (h *HeistHandler) refundHeistBuyIn(ctx context.Context, scope model.BalanceScope, userID, buyIn int64) {
+	h.userLock.Lock(userID)
+	defer h.userLock.Unlock(userID)
+
+	desc := "抢金库加入失败，退还买入"
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, buyIn, model.TxTypeHeistRefund, &desc); err != nil {
+		h.reportIncidentIn(scope, service.IncidentRefundFailed, "抢金库加入失败后退还失败",
+			service.CompensationClaim{UserID: userID, Amount: buyIn})
+	}
+}
+
+// heistMarkup builds the join button of a heist panel
+func heistMarkup(buyIn int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(markup.Row(markup.Data(fmt.Sprintf("🦹 加入行动 (买入 %d)", buyIn), heistJoinCallback)))
+	return markup
+}
+
+// formatHeistPanel renders the recruiting panel of a heist
+func formatHeistPanel(scope model.BalanceScope, buyIn int64, players, remaining int) string {
+	msg := fmt.Sprintf("🏦 抢金库行动招募中！\n\n"+
+		"💰 买入: %d %s\n"+
+		"👥 队伍: %d/%d 人（至少 %d 人）\n"+
+		"🎯 成功率: %d%%\n"+
+		"💎 目标奖金: %d %s（成功后平分）\n"+
+		"⏱️ 剩余: %d 秒\n\n"+
+		"人越多成功率和奖金越高，失败则全员损失买入",
+		buyIn, coinName(scope),
+		players, heist.MaxPlayers, heist.MinPlayers,
+		heist.SuccessChance(players),
+		heist.TargetPrize(players, buyIn), coinName(scope),
+		remaining)
+	if scope.IsSandbox() {
+		msg = sandboxBanner + "\n" + msg
+	}
+	return msg
+}
+
+// formatHeistResult renders the outcome of a settled heist
+func formatHeistResult(persona *model.ChatPersona, scope model.BalanceScope, result *heist.Result, names map[int64]string) tgfmt.HTML {
+	var msg tgfmt.HTML
+	if scope.IsSandbox() {
+		msg = tgfmt.Escape(sandboxBanner + "\n")
+	}
+
+	switch {
+	case result.Void:
+		return msg + tgfmt.Sprintf("🏦 抢金库人数不足（至少 %d 人），行动取消，买入已退还", heist.MinPlayers)
+	case result.Success:
+		msg += tgfmt.Sprintf("🏦 抢金库成功！(%d 人，成功率 %d%%)\n\n💎 %s %s 到手，全员平分:\n",
+			len(result.Players), result.Chance, tgfmt.Amount(result.Prize), coinName(scope))
+		for _, userID := range result.Players {
+			msg += tgfmt.Sprintf("%s +%s\n", playerName(persona, userID, names[userID]), tgfmt.Amount(result.Payouts[userID]-result.BuyIn))
+		}
+		return msg
+	}
+
+	msg += tgfmt.Sprintf("🚨 抢金库失败！(%d 人，成功率 %d%%)\n\n警报响起，全员损失 %s %s 买入:\n",
+		len(result.Players), result.Chance, tgfmt.Amount(result.BuyIn), coinName(scope))
+	for _, userID := range result.Players {
+		msg += playerName(persona, userID, names[userID]) + "\n"
+	}
+	return msg
+}
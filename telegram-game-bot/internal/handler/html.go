@@ -0,0 +1,12 @@
+package handler
+
+import (
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/tgfmt"
+)
+
+// replyHTML replies with a message built by tgfmt in the HTML parse mode
+func replyHTML(c tele.Context, msg tgfmt.HTML, opts ...interface{}) error {
+	return c.Reply(msg.String(), append(opts, tele.ModeHTML)...)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// maintenanceTickInterval is how often the maintenance window is refreshed and announced
+const maintenanceTickInterval = 30 * time.Second
+
+// maintenanceUsage explains the /maintenance subcommands
+const maintenanceUsage = "📖 用法:\n" +
+	"/maintenance - 查看计划中的维护\n" +
+	"/maintenance schedule <时间> <时长> - 计划维护并通知各群\n" +
+	"/maintenance cancel - 取消维护（维护中则提前结束）\n\n" +
+	"时间: 15:04、2006-01-02 15:04 或 +30m\n" +
+	"时长: 分钟数或 1h30m"
+
+// MaintenanceHandler lets admins schedule maintenance windows.
+type MaintenanceHandler struct {
+	cfg         *config.Config
+	maintenance *service.MaintenanceService
+}
+
+// NewMaintenanceHandler creates a new MaintenanceHandler.
+func NewMaintenanceHandler(cfg *config.Config, maintenance *service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{cfg: cfg, maintenance: maintenance}
+}
+
+// StartScheduler refreshes the maintenance window now and periodically.
+// Only the instance with announce set posts the countdown to the chats.
+func (h *MaintenanceHandler) StartScheduler(announce bool) {
+	h.maintenance.Tick(context.Background(), announce)
+	go func() {
+		ticker := time.NewTicker(maintenanceTickInterval)
+		heartbeat.Start("maintenance", maintenanceTickInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("maintenance")
+			h.maintenance.Tick(context.Background(), announce)
+		}
+	}()
+}
+
+// HandleMaintenance handles the /maintenance command (admin only).
+func (h *MaintenanceHandler) HandleMaintenance(c tele.Context) error {
+	ctx := context.Background()
+	args := c.Args()
+	if len(args) == 0 {
+		window := h.maintenance.Window()
+		if window == nil {
+			return c.Reply("🔧 当前没有计划中的维护\n\n" + maintenanceUsage)
+		}
+		return c.Reply(fmt.Sprintf("🔧 计划中的维护\n\n开始: %s\n结束: %s\n\n%s",
+			h.maintenance.FormatTime(window.StartsAt), h.maintenance.FormatTime(window.EndsAt), maintenanceUsage))
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "schedule":
+		startsAt, duration, err := service.ParseMaintenanceSchedule(args[1:], time.Now())
+		if err != nil {
+			return c.Reply("❌ " + err.Error() + "\n\n" + maintenanceUsage)
+		}
+		window, err := h.maintenance.Schedule(ctx, c.Sender().ID, startsAt, duration)
+		if err != nil {
+			return h.replyMaintenanceError(c, err)
+		}
+		return c.Reply(fmt.Sprintf("✅ 已计划维护: %s 至 %s，已通知各群",
+			h.maintenance.FormatTime(window.StartsAt), h.maintenance.FormatTime(window.EndsAt)))
+	case "cancel":
+		if err := h.maintenance.Cancel(ctx, c.Sender().ID); err != nil {
+			return h.replyMaintenanceError(c, err)
+		}
+		return c.Reply("✅ 维护已取消")
+	default:
+		return c.Reply(maintenanceUsage)
+	}
+}
+
+// replyMaintenanceError replies with the error of a maintenance operation
+func (h *MaintenanceHandler) replyMaintenanceError(c tele.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrMaintenanceExists),
+		errors.Is(err, service.ErrNoMaintenance),
+		errors.Is(err, service.ErrMaintenanceTime),
+		errors.Is(err, service.ErrMaintenanceDuration):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Msg("Maintenance operation failed")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+)
+
+// setAssetUsage explains /setasset
+const setAssetUsage = "📖 用法:\n" +
+	"/setasset - 查看素材\n" +
+	"回复图片发送 /setasset 名称 - 设置素材\n" +
+	"/setasset 名称 clear - 清除素材（改为纯文字面板）"
+
+// mediaAssetLabels names the configurable assets
+var mediaAssetLabels = map[string]string{
+	model.MediaAssetShopBanner: "商店横幅",
+}
+
+// MediaAssetHandler lets admins replace the media the bot shows at runtime.
+type MediaAssetHandler struct {
+	assets *service.MediaAssetService
+}
+
+// NewMediaAssetHandler creates a new MediaAssetHandler.
+func NewMediaAssetHandler(assets *service.MediaAssetService) *MediaAssetHandler {
+	return &MediaAssetHandler{assets: assets}
+}
+
+// HandleSetAsset handles the /setasset command (admin only).
+// Format: /setasset [name [clear]], replying to the new media when setting
+func (h *MediaAssetHandler) HandleSetAsset(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		assets, err := h.assets.List(ctx)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(formatMediaAssets(assets) + "\n\n" + setAssetUsage)
+	}
+
+	key := strings.ToLower(args[0])
+	var err error
+	if len(args) > 1 && strings.ToLower(args[1]) == "clear" {
+		err = h.assets.Clear(ctx, key, sender.ID)
+	} else {
+		mediaType, fileID := repliedAsset(c.Message())
+		if fileID == "" {
+			return c.Reply("❌ 请回复一张图片、动图或贴纸\n\n" + setAssetUsage)
+		}
+		err = h.assets.Set(ctx, key, mediaType, fileID, sender.ID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMediaAssetUnknownKey), errors.Is(err, service.ErrMediaAssetNotSet):
+			return c.Reply("❌ " + err.Error() + "\n\n" + setAssetUsage)
+		case errors.Is(err, service.ErrMediaAssetWrongType):
+			return c.Reply(fmt.Sprintf("❌ %s，%s 需要 %s", err.Error(), key, service.MediaAssetType(key)))
+		}
+		log.Error().Err(err).Str("key", key).Msg("Failed to update media asset")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	assets, err := h.assets.List(ctx)
+	if err != nil {
+		return c.Reply("✅ 素材已更新")
+	}
+	return c.Reply("✅ 素材已更新\n\n" + formatMediaAssets(assets))
+}
+
+// repliedAsset returns the type and file ID of the photo, animation or sticker
+// the message replies to, an empty file ID if it replies to none of them
+func repliedAsset(msg *tele.Message) (string, string) {
+	if msg == nil || msg.ReplyTo == nil {
+		return "", ""
+	}
+	switch {
+	case msg.ReplyTo.Photo != nil:
+		return model.MediaPhoto, msg.ReplyTo.Photo.FileID
+	case msg.ReplyTo.Animation != nil:
+		return model.MediaAnimation, msg.ReplyTo.Animation.FileID
+	case msg.ReplyTo.Sticker != nil:
+		return model.MediaSticker, msg.ReplyTo.Sticker.FileID
+	}
+	return "", ""
+}
+
+// formatMediaAssets formats the configurable assets and whether they are set
+func formatMediaAssets(assets map[string]model.MediaAsset) string {
+	var sb strings.Builder
+	sb.WriteString("🖼️ 媒体素材\n━━━━━━━━━━━━━━━\n")
+	for _, key := range service.MediaAssetKeys() {
+		status := "未设置（纯文字）"
+		if asset, ok := assets[key]; ok {
+			status = fmt.Sprintf("已设置（%s）", asset.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+		sb.WriteString(fmt.Sprintf("%s (%s): %s\n", mediaAssetLabels[key], key, status))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/service"
+)
+
+// User merge callbacks
+const (
+	mergeConfirmCallback = "merge_confirm"
+	mergeCancelCallback  = "merge_cancel"
+)
+
+// MergeHandler lets admins merge the account of a user who moved to a new
+// Telegram account, after confirming a preview.
+type MergeHandler struct {
+	merges   *service.MergeService
+	requests sync.Map // map[int64]service.MergeRequest - id -> previewed merge
+	nextID   atomic.Int64
+}
+
+// NewMergeHandler creates a new MergeHandler.
+func NewMergeHandler(merges *service.MergeService) *MergeHandler {
+	return &MergeHandler{merges: merges}
+}
+
+// HandleMerge handles the /merge command (admin only).
+// Format: /merge <old_id> <new_id> <reason>
+func (h *MergeHandler) HandleMerge(c tele.Context) error {
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) < 2 {
+		return c.Reply("❌ 用法: /merge <旧用户ID> <新用户ID> <原因>\n例如: /merge 123456789 987654321 更换Telegram账号")
+	}
+	oldID, err1 := strconv.ParseInt(args[0], 10, 64)
+	newID, err2 := strconv.ParseInt(args[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return c.Reply("❌ 用户ID格式错误，请输入数字")
+	}
+	req := service.MergeRequest{
+		OldID:     oldID,
+		NewID:     newID,
+		AdminID:   sender.ID,
+		Reason:    strings.Join(args[2:], " "),
+		CreatedAt: time.Now(),
+	}
+
+	oldUser, newUser, err := h.merges.Preview(context.Background(), req)
+	if err != nil {
+		return h.replyMergeError(c, err)
+	}
+
+	h.sweep(req.CreatedAt)
+	id := h.nextID.Add(1)
+	h.requests.Store(id, req)
+
+	log.Info().
+		Int64("admin_id", sender.ID).
+		Int64("old_id", oldID).
+		Int64("new_id", newID).
+		Int64("old_balance", oldUser.Balance).
+		Int64("new_balance", newUser.Balance).
+		Str("reason", req.Reason).
+		Str("operation", "user_merge_preview").
+		Msg("Admin operation previewed")
+
+	markup := &tele.ReplyMarkup{}
+	data := strconv.FormatInt(id, 10)
+	markup.Inline(markup.Row(
+		markup.Data("✅ 确认合并", mergeConfirmCallback, data),
+		markup.Data("❌ 取消", mergeCancelCallback, data),
+	))
+	return c.Reply(fmt.Sprintf(
+		"⚠️ 请确认合并账号\n\n"+
+			"📤 旧账号: %s (ID: %d)，余额 %d 金币\n"+
+			"📥 新账号: %s (ID: %d)，余额 %d 金币\n"+
+			"💰 合并后余额: %d 金币\n"+
+			"📝 原因: %s\n\n"+
+			"道具、生效中的效果、装扮和交易记录将一并转入新账号，旧账号余额清零\n"+
+			"%d 分钟内有效",
+		adjustmentName(oldUser), oldID, oldUser.Balance,
+		adjustmentName(newUser), newID, newUser.Balance,
+		oldUser.Balance+newUser.Balance, req.Reason,
+		int(service.AdminAdjustmentTTL.Minutes()),
+	), markup)
+}
+
+// HandleMergeCallback handles the Confirm/Cancel buttons of a previewed merge.
+// Only the admin who typed the command may press them.
+// Data format: merge_confirm|id, merge_cancel|id
+func (h *MergeHandler) HandleMergeCallback(c tele.Context) error {
+	callback := c.Callback()
+	sender := c.Sender()
+	if callback == nil || sender == nil {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, "\f"), "|")
+	if len(parts) != 2 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	value, ok := h.requests.Load(id)
+	if !ok {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作已失效", ShowAlert: true})
+	}
+	req := value.(service.MergeRequest)
+	if req.AdminID != sender.ID {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 只有发起的管理员可以确认", ShowAlert: true})
+	}
+	if !h.requests.CompareAndDelete(id, value) {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作已失效", ShowAlert: true})
+	}
+
+	if parts[0] == mergeCancelCallback {
+		log.Info().
+			Int64("admin_id", sender.ID).
+			Int64("old_id", req.OldID).
+			Int64("new_id", req.NewID).
+			Str("operation", "user_merge_cancel").
+			Msg("Admin operation cancelled")
+		if err := c.Edit("🚫 已取消合并账号"); err != nil {
+			log.Debug().Err(err).Msg("Failed to edit merge preview")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "已取消"})
+	}
+	if req.Expired(time.Now()) {
+		if err := c.Edit("⌛ 合并请求已过期，请重新输入命令"); err != nil {
+			log.Debug().Err(err).Msg("Failed to edit merge preview")
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作已过期", ShowAlert: true})
+	}
+
+	text := ""
+	merge, err := h.merges.Merge(context.Background(), req)
+	if err != nil {
+		text = "❌ " + mergeErrorText(err)
+	} else {
+		text = fmt.Sprintf(
+			"✅ 账号已合并 (#%d)\n\n"+
+				"📤 旧账号: %d → 📥 新账号: %d\n"+
+				"💰 转入余额: %d 金币\n"+
+				"🎒 道具: %d 项，效果: %d 个，装扮: %d 件\n"+
+				"🧾 交易记录: %d 条\n",
+			merge.ID, merge.OldID, merge.NewID, merge.Balance,
+			merge.Items, merge.Effects, merge.Cosmetics, merge.Transactions,
+		)
+		if merge.Compensation > 0 {
+			text += fmt.Sprintf("🪙 超出持有上限补偿: %d 金币\n", merge.Compensation)
+		}
+		text += "📝 原因: " + merge.Reason
+	}
+	if err := c.Edit(text); err != nil {
+		log.Debug().Err(err).Msg("Failed to edit merge preview")
+	}
+	return c.Respond()
+}
+
+// sweep drops the previews that can no longer be confirmed
+func (h *MergeHandler) sweep(now time.Time) {
+	h.requests.Range(func(key, value any) bool {
+		if value.(service.MergeRequest).Expired(now) {
+			h.requests.Delete(key)
+		}
+		return true
+	})
+}
+
+// replyMergeError replies with a user facing merge error
+func (h *MergeHandler) replyMergeError(c tele.Context, err error) error {
+	return c.Reply("❌ " + mergeErrorText(err))
+}
+
+// mergeErrorText describes a merge error, logging unexpected ones
+func mergeErrorText(err error) string {
+	switch {
+	case errors.Is(err, service.ErrMergeSameUser),
+		errors.Is(err, service.ErrMergeInvalidUser),
+		errors.Is(err, service.ErrMergeUserNotFound),
+		errors.Is(err, service.ErrMergeAlreadyMerged):
+		return err.Error()
+	case errors.Is(err, service.ErrMergeReason):
+		return err.Error() + "\n用法: /merge <旧用户ID> <新用户ID> <原因>"
+	}
+	log.Error().Err(err).Msg("User merge failed")
+	return "操作失败，请稍后重试"
+}
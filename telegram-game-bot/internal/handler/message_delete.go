@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+)
+
+// deleteBatchSize is the most messages deleteMessages accepts per call
+const deleteBatchSize = 100
+
+// bulkDeleteUnsupported is set once the Bot API server rejected
+// deleteMessages as unknown (older self-hosted servers); messages are then
+// deleted one by one
+var bulkDeleteUnsupported atomic.Bool
+
+// deletionBatches groups messages by chat, in order of first appearance, and
+// splits each chat's messages into batches of at most deleteBatchSize.
+func deletionBatches(msgs []TrackedMessage) [][]TrackedMessage {
+	byChat := make(map[int64][]TrackedMessage)
+	var chats []int64
+	for _, msg := range msgs {
+		if _, ok := byChat[msg.ChatID]; !ok {
+			chats = append(chats, msg.ChatID)
+		}
+		byChat[msg.ChatID] = append(byChat[msg.ChatID], msg)
+	}
+
+	var batches [][]TrackedMessage
+	for _, chatID := range chats {
+		chatMsgs := byChat[chatID]
+		for len(chatMsgs) > 0 {
+			n := min(len(chatMsgs), deleteBatchSize)
+			batches = append(batches, chatMsgs[:n])
+			chatMsgs = chatMsgs[n:]
+		}
+	}
+	return batches
+}
+
+// deleteMessages deletes tracked messages with one deleteMessages call per
+// chat and batch, falling back to single deletes where bulk deletion is not
+// supported. Failures are logged only, the messages may be gone already.
+func deleteMessages(bot *tele.Bot, msgs []TrackedMessage) {
+	for _, batch := range deletionBatches(msgs) {
+		editables := make([]tele.Editable, len(batch))
+		for i, msg := range batch {
+			editables[i] = &tele.Message{ID: msg.MessageID, Chat: &tele.Chat{ID: msg.ChatID}}
+		}
+
+		if !bulkDeleteUnsupported.Load() {
+			err := bot.DeleteMany(editables)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, tele.ErrNotFound) {
+				log.Debug().Err(err).Int64("chat_id", batch[0].ChatID).Int("count", len(batch)).Msg("Failed to delete old messages")
+				continue
+			}
+			log.Warn().Err(err).Msg("Bot API server does not support deleteMessages, deleting messages one by one")
+			bulkDeleteUnsupported.Store(true)
+		}
+
+		for _, msg := range editables {
+			if err := bot.Delete(msg); err != nil {
+				log.Debug().Err(err).Int64("chat_id", batch[0].ChatID).Msg("Failed to delete old message")
+			}
+		}
+	}
+}
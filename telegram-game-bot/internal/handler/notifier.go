@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+)
+
+// Notifier sends the DMs of the services and their messages to the admins.
+// Admin messages go to the support chat if configured, otherwise to each admin.
+type Notifier struct {
+	bot *tele.Bot
+	cfg *config.Config
+}
+
+// NewNotifier creates a new Notifier.
+func NewNotifier(bot *tele.Bot, cfg *config.Config) *Notifier {
+	return &Notifier{bot: bot, cfg: cfg}
+}
+
+// NotifyUser sends a private message to a user (best effort).
+func (n *Notifier) NotifyUser(userID int64, text string) {
+	if _, err := n.bot.Send(&tele.User{ID: userID}, text); err != nil {
+		log.Debug().Err(err).Int64("user_id", userID).Msg("Failed to notify user")
+	}
+}
+
+// NotifyAdmins sends a message to the admins (best effort).
+func (n *Notifier) NotifyAdmins(text string) {
+	if n.cfg.Support.ChatID != 0 {
+		if _, err := n.bot.Send(&tele.Chat{ID: n.cfg.Support.ChatID}, text); err != nil {
+			log.Warn().Err(err).Msg("Failed to notify support chat")
+		}
+		return
+	}
+
+	for _, adminID := range n.cfg.Admin.IDs {
+		if _, err := n.bot.Send(&tele.User{ID: adminID}, text); err != nil {
+			log.Debug().Err(err).Int64("admin_id", adminID).Msg("Failed to notify admin")
+		}
+	}
+}
+
+// Announcer posts the announcements of the services in group chats.
+type Announcer struct {
+	bot *tele.Bot
+}
+
+// NewAnnouncer creates a new Announcer.
+func NewAnnouncer(bot *tele.Bot) *Announcer {
+	return &Announcer{bot: bot}
+}
+
+// Announce sends an announcement to a chat (best effort).
+func (a *Announcer) Announce(chatID int64, text string) {
+	if _, err := a.bot.Send(&tele.Chat{ID: chatID}, text); err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to announce")
+	}
+}
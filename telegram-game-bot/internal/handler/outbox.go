@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// outboxFlushInterval is how often due direct messages are sent
+const outboxFlushInterval = 10 * time.Second
+
+// DirectSender sends outbox messages as Telegram private messages.
+type DirectSender struct {
+	bot *tele.Bot
+}
+
+// NewDirectSender creates a new DirectSender.
+func NewDirectSender(bot *tele.Bot) *DirectSender {
+	return &DirectSender{bot: bot}
+}
+
+// SendDirect sends a private message to a user.
+func (s *DirectSender) SendDirect(userID int64, text string) error {
+	_, err := s.bot.Send(&tele.User{ID: userID}, text)
+	return err
+}
+
+// OutboxHandler delivers the direct message outbox.
+type OutboxHandler struct {
+	outbox *service.OutboxService
+}
+
+// NewOutboxHandler creates a new OutboxHandler.
+func NewOutboxHandler(outbox *service.OutboxService) *OutboxHandler {
+	return &OutboxHandler{outbox: outbox}
+}
+
+// StartScheduler starts the loop sending due direct messages.
+func (h *OutboxHandler) StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(outboxFlushInterval)
+		heartbeat.Start("dm_outbox", outboxFlushInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("dm_outbox")
+			h.outbox.Flush(context.Background(), now)
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/pkg/roles"
+)
+
+// ChatAllower reports whether the bot operates in a chat. Both the config
+// and the whitelist admins change with /whitelist implement it.
+type ChatAllower interface {
+	IsChatAllowed(chatID int64) bool
+}
+
+// chatRoleTTL is how long a chat role fetched from Telegram is trusted.
+// Promotions and demotions take effect within this time.
+const chatRoleTTL = 5 * time.Minute
+
+// chatRoles caches the chat roles of senders across all handlers
+var chatRoles = roles.NewCache(chatRoleTTL)
+
+// senderRole returns the role of the sender in the current chat. Bot admins
+// are global admins everywhere; in groups the chat role is looked up via the
+// Telegram API and cached. Failed lookups count as a regular member and are
+// not cached.
+func senderRole(c tele.Context, cfg *config.Config) roles.Role {
+	sender, chat := c.Sender(), c.Chat()
+	if sender == nil {
+		return roles.Member
+	}
+	if cfg.IsAdmin(sender.ID) {
+		return roles.GlobalAdmin
+	}
+	if chat == nil || chat.Type == tele.ChatPrivate {
+		return roles.Member
+	}
+
+	if role, ok := chatRoles.Get(chat.ID, sender.ID); ok {
+		return role
+	}
+	member, err := c.Bot().ChatMemberOf(chat, sender)
+	if err != nil {
+		log.Debug().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get chat member")
+		return roles.Member
+	}
+	role := roles.Member
+	if member.Role == tele.Creator || member.Role == tele.Administrator {
+		role = roles.ChatAdmin
+	}
+	chatRoles.Set(chat.ID, sender.ID, role)
+	return role
+}
+
+// hasRole reports whether the sender holds at least the required role in the
+// current chat. Every permission check of chat-scoped management commands
+// goes through here; denials are logged.
+func hasRole(c tele.Context, cfg *config.Config, required roles.Role) bool {
+	role := senderRole(c, cfg)
+	if role.Allows(required) {
+		return true
+	}
+
+	event := log.Warn().Str("command", c.Text()).Str("role", required.String())
+	if sender := c.Sender(); sender != nil {
+		event = event.Int64("user_id", sender.ID)
+	}
+	if chat := c.Chat(); chat != nil {
+		event = event.Int64("chat_id", chat.ID)
+	}
+	event.Msg("Permission denied for management command")
+	return false
+}
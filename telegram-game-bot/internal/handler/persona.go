@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/roles"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/service"
+)
+
+// personaUsage explains the /persona subcommands
+const personaUsage = "📖 用法:\n" +
+	"/persona name 昵称 - 设置机器人昵称\n" +
+	"/persona win 模板 - 设置赢钱文案\n" +
+	"/persona lose 模板 - 设置输钱文案\n" +
+	"/persona theme 主题 - 设置表情主题\n" +
+	"/persona pings on|off - 游戏结果是否通知玩家\n" +
+	"/persona reset - 恢复默认\n\n" +
+	"模板变量: {user} {amount} {balance} {game} {bot} {emoji}\n" +
+	"例如: /persona win {emoji} {bot}恭喜{user}赢下 {amount} 金币！\n" +
+	"模板留空则恢复默认文案"
+
+// PersonaHandler lets group admins customize the bot's flavor text in their chat.
+type PersonaHandler struct {
+	cfg            *config.Config
+	personaService *service.PersonaService
+}
+
+// NewPersonaHandler creates a new PersonaHandler.
+func NewPersonaHandler(cfg *config.Config, personaService *service.PersonaService) *PersonaHandler {
+	return &PersonaHandler{
+		cfg:            cfg,
+		personaService: personaService,
+	}
+}
+
+// HandlePersona handles the /persona command.
+// Without arguments it shows the chat's persona, subcommands change it (group admins only).
+func (h *PersonaHandler) HandlePersona(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中使用此命令")
+	}
+
+	sub, value, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+	sub = strings.ToLower(sub)
+	if sub == "" {
+		return c.Reply(formatPersona(h.personaService.Get(ctx, chat.ID)))
+	}
+
+	if !hasRole(c, h.cfg, roles.ChatAdmin) {
+		return c.Reply("❌ 只有群管理员可以修改机器人设定")
+	}
+
+	var err error
+	switch sub {
+	case "name":
+		_, err = h.personaService.SetNickname(ctx, chat.ID, sender.ID, value)
+	case "win":
+		_, err = h.personaService.SetWinTemplate(ctx, chat.ID, sender.ID, value)
+	case "lose":
+		_, err = h.personaService.SetLoseTemplate(ctx, chat.ID, sender.ID, value)
+	case "theme":
+		_, err = h.personaService.SetTheme(ctx, chat.ID, sender.ID, value)
+	case "pings":
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "on":
+			_, err = h.personaService.SetNoPings(ctx, chat.ID, sender.ID, false)
+		case "off":
+			_, err = h.personaService.SetNoPings(ctx, chat.ID, sender.ID, true)
+		default:
+			return c.Reply(personaUsage)
+		}
+	case "reset":
+		err = h.personaService.Reset(ctx, chat.ID, sender.ID)
+	default:
+		return c.Reply(personaUsage)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPersonaNicknameTooLong),
+			errors.Is(err, service.ErrPersonaTemplateTooLong),
+			errors.Is(err, service.ErrPersonaTemplateInvalid),
+			errors.Is(err, service.ErrPersonaUnknownTheme):
+			return c.Reply("❌ " + err.Error())
+		}
+		log.Error().Err(err).Int64("chat_id", chat.ID).Str("subcommand", sub).Msg("Failed to update chat persona")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	return c.Reply("✅ 已更新\n\n" + formatPersona(h.personaService.Get(ctx, chat.ID)))
+}
+
+// formatPersona formats a chat's persona with a preview of its result lines
+func formatPersona(persona *model.ChatPersona) string {
+	nickname := service.PersonaDefaultNickname
+	themeName := ""
+	winTmpl, loseTmpl := "默认", "默认"
+	pings := "开启"
+	if persona != nil {
+		if persona.NoPings {
+			pings = "关闭"
+		}
+		if persona.Nickname != "" {
+			nickname = persona.Nickname
+		}
+		themeName = persona.Theme
+		if persona.WinTemplate != "" {
+			winTmpl = persona.WinTemplate
+		}
+		if persona.LoseTemplate != "" {
+			loseTmpl = persona.LoseTemplate
+		}
+	}
+	theme, _ := service.GetPersonaTheme(themeName)
+
+	preview := service.PersonaVars{User: "player", Amount: 100, Balance: 1100, Game: "骰子"}
+
+	var sb strings.Builder
+	sb.WriteString("🎭 机器人设定\n━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("🤖 昵称: %s\n", nickname))
+	sb.WriteString(fmt.Sprintf("🎨 主题: %s %s%s%s\n", theme.Label, theme.Win, theme.Lose, theme.Balance))
+	sb.WriteString(fmt.Sprintf("🏆 赢钱文案: %s\n", winTmpl))
+	sb.WriteString(fmt.Sprintf("💸 输钱文案: %s\n", loseTmpl))
+	sb.WriteString(fmt.Sprintf("🔔 结果通知玩家: %s\n", pings))
+	sb.WriteString("━━━━━━━━━━━━━━━\n👀 预览:\n")
+	sb.WriteString(service.RenderOutcome(persona, true, defaultWinLine, preview) + "\n")
+	sb.WriteString(service.RenderOutcome(persona, false, defaultLoseLine, preview) + "\n")
+	sb.WriteString(service.RenderBalance(persona, preview.Balance) + "\n\n")
+
+	themes := make([]string, 0, len(service.PersonaThemes()))
+	for _, t := range service.PersonaThemes() {
+		themes = append(themes, fmt.Sprintf("%s(%s%s)", t.Name, t.Label, t.Win))
+	}
+	sb.WriteString("可选主题: " + strings.Join(themes, " ") + "\n\n")
+	sb.WriteString(personaUsage)
+	return sb.String()
+}
+
+// Built-in result line templates of the game handlers
+const (
+	defaultWinLine  = "{emoji} 赢得 {amount} 金币！"
+	defaultLoseLine = "{emoji} 输了 {amount} 金币"
+)
+
+// SetPersona sets the per-chat persona used to render game results
+func (h *BaseHandler) SetPersona(persona *service.PersonaService) {
+	h.persona = persona
+}
+
+// playerName names a player in a game result: a mention that notifies the
+// player, or just the name in bold in chats that turned pings off
+func playerName(persona *model.ChatPersona, userID int64, name string) tgfmt.HTML {
+	if persona != nil && persona.NoPings {
+		return tgfmt.Bold(name)
+	}
+	return tgfmt.Mention(userID, name)
+}
+
+// playerNames returns playerName for the game packages, which write names
+// into their own result messages
+func playerNames(persona *model.ChatPersona) func(userID int64, name string) string {
+	return func(userID int64, name string) string {
+		return playerName(persona, userID, name).String()
+	}
+}
+
+// chatPersona returns the persona of a chat, nil when the chat has none
+func (h *BaseHandler) chatPersona(ctx context.Context, chatID int64) *model.ChatPersona {
+	if h.persona == nil {
+		return nil
+	}
+	return h.persona.Get(ctx, chatID)
+}
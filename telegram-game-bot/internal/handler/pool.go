@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/roles"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/service"
+)
+
+// maxPoolWinnersShown caps the winners listed in a resolution message
+const maxPoolWinnersShown = 10
+
+// PoolHandler handles parimutuel betting pools.
+type PoolHandler struct {
+	cfg            *config.Config
+	poolService    *service.PoolService
+	accountService *service.AccountService
+	robInsurance   *service.RobInsuranceService // Optional: rob insurance pool shown by /pool
+}
+
+// NewPoolHandler creates a new PoolHandler.
+func NewPoolHandler(cfg *config.Config, poolService *service.PoolService, accountService *service.AccountService) *PoolHandler {
+	return &PoolHandler{
+		cfg:            cfg,
+		poolService:    poolService,
+		accountService: accountService,
+	}
+}
+
+// SetRobInsurance shows the rob insurance pool in /pool
+func (h *PoolHandler) SetRobInsurance(robInsurance *service.RobInsuranceService) {
+	h.robInsurance = robInsurance
+}
+
+// HandlePool handles the /pool command.
+// Without arguments it shows the chat's open pool; admins open one with
+// /pool [分钟] "问题" 选项1 选项2 ...
+func (h *PoolHandler) HandlePool(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中使用此命令")
+	}
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		p, totals, err := h.poolService.Current(ctx, chat.ID)
+		if errors.Is(err, service.ErrNoPool) && h.robInsurance != nil {
+			return replyHTML(c, "📭 本群当前没有竞猜\n\n"+h.formatRobInsurance(ctx))
+		}
+		if err != nil {
+			return h.replyPoolError(c, err)
+		}
+		if h.robInsurance != nil {
+			return replyHTML(c, formatPool(p, totals)+"\n\n"+h.formatRobInsurance(ctx))
+		}
+		return replyHTML(c, formatPool(p, totals))
+	}
+
+	if !hasRole(c, h.cfg, roles.GlobalAdmin) {
+		return c.Reply("❌ 只有管理员可以开设竞猜")
+	}
+
+	question, outcomes, window, err := service.ParsePoolCommand(payload)
+	if err != nil {
+		return h.replyPoolError(c, err)
+	}
+	p, err := h.poolService.Open(ctx, chat.ID, sender.ID, question, outcomes, window)
+	if err != nil {
+		return h.replyPoolError(c, err)
+	}
+	return replyHTML(c, tgfmt.Sprintf("📣 竞猜 #%d 开始下注！\n\n", p.ID)+formatPool(p, make([]int64, len(p.Outcomes))))
+}
+
+// HandlePoolBet handles the /pool_bet command.
+// Format: /pool_bet <选项编号> <金额>
+func (h *PoolHandler) HandlePoolBet(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) != 2 {
+		return c.Reply("❌ 用法: /pool_bet <选项编号> <金额>\n例如: /pool_bet 1 100")
+	}
+	outcome, err := strconv.Atoi(args[0])
+	if err != nil {
+		return c.Reply("❌ 请输入有效的选项编号")
+	}
+	amount, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || amount <= 0 {
+		return c.Reply("❌ 请输入有效的下注金额")
+	}
+
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, senderName(sender)); err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	p, err := h.poolService.Bet(ctx, chat.ID, sender.ID, outcome, amount)
+	if err != nil {
+		return h.replyPoolError(c, err)
+	}
+	return replyHTML(c, tgfmt.Sprintf("✅ 已押注「%s」%s %s", p.Outcomes[outcome-1], tgfmt.Amount(amount), coinName(p.Scope())))
+}
+
+// HandlePoolResolve handles the /pool_resolve command (admin only).
+// Format: /pool_resolve <获胜选项编号>
+func (h *PoolHandler) HandlePoolResolve(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) != 1 {
+		return c.Reply("❌ 用法: /pool_resolve <获胜选项编号>")
+	}
+	outcome, err := strconv.Atoi(args[0])
+	if err != nil {
+		return c.Reply("❌ 请输入有效的选项编号")
+	}
+
+	settlement, err := h.poolService.Resolve(ctx, chat.ID, sender.ID, outcome)
+	if err != nil {
+		return h.replyPoolError(c, err)
+	}
+	return replyHTML(c, h.formatSettlement(ctx, settlement))
+}
+
+// HandlePoolCancel handles the /pool_cancel command (admin only): refunds every stake.
+func (h *PoolHandler) HandlePoolCancel(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	settlement, err := h.poolService.Cancel(ctx, chat.ID, sender.ID)
+	if err != nil {
+		return h.replyPoolError(c, err)
+	}
+	return replyHTML(c, h.formatSettlement(ctx, settlement))
+}
+
+// formatPool renders a pool with the stakes and current odds of each outcome
+func formatPool(p *model.Pool, totals []int64) tgfmt.HTML {
+	var total int64
+	for _, t := range totals {
+		total += t
+	}
+	prize := total - total*int64(p.RakePercent)/100
+
+	msg := tgfmt.Sprintf("🎰 竞猜 #%d: %s\n\n", p.ID, p.Question)
+	for i, outcome := range p.Outcomes {
+		odds := "-"
+		if totals[i] > 0 {
+			odds = fmt.Sprintf("%.2f", float64(prize)/float64(totals[i]))
+		}
+		msg += tgfmt.Sprintf("%d. %s — %s %s (赔率 %s)\n", i+1, outcome, tgfmt.Amount(totals[i]), coinName(p.Scope()), odds)
+	}
+
+	closing := "已截止下注，等待开奖"
+	if remaining := time.Until(p.ClosesAt); remaining > 0 {
+		closing = fmt.Sprintf("剩余 %d 分钟截止下注", int(remaining.Minutes())+1)
+	}
+	msg += tgfmt.Sprintf("\n💰 总奖池: %s %s (抽成 %d%%)\n⏰ %s\n💡 /pool_bet 选项编号 金额", tgfmt.Amount(total), coinName(p.Scope()), p.RakePercent, closing)
+	return msg
+}
+
+// formatRobInsurance renders the rob insurance pool
+func (h *PoolHandler) formatRobInsurance(ctx context.Context) tgfmt.HTML {
+	pool, err := h.robInsurance.Pool(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get rob insurance pool")
+		return "☂️ 打劫保险池: 暂不可用"
+	}
+	return tgfmt.Sprintf("☂️ 打劫保险池: %s 金币\n累计存入 %s，累计赔付 %s\n被打劫后余额低于 %s 的玩家可获部分赔付",
+		tgfmt.Amount(pool.Balance), tgfmt.Amount(pool.Collected), tgfmt.Amount(pool.PaidOut), tgfmt.Amount(h.robInsurance.Floor()))
+}
+
+// formatSettlement renders the result of a resolved or cancelled pool
+func (h *PoolHandler) formatSettlement(ctx context.Context, s *service.PoolSettlement) tgfmt.HTML {
+	p := s.Pool
+	var msg tgfmt.HTML
+	switch {
+	case p.Status == model.PoolStatusCancelled:
+		msg = tgfmt.Sprintf("🚫 竞猜 #%d 已取消: %s\n所有下注已退还", p.ID, p.Question)
+	case s.Refunded:
+		msg = tgfmt.Sprintf("🏁 竞猜 #%d 开奖: %s\n结果: %s\n无人押中，所有下注已退还", p.ID, p.Question, p.Outcomes[*p.WinningOutcome])
+	default:
+		msg = tgfmt.Sprintf("🏁 竞猜 #%d 开奖: %s\n结果: %s\n\n💰 总奖池 %s %s，抽成 %s，%d 人瓜分\n",
+			p.ID, p.Question, p.Outcomes[*p.WinningOutcome], tgfmt.Amount(s.TotalPool), coinName(p.Scope()), tgfmt.Amount(s.Rake), len(s.Payouts))
+		for i, payout := range s.Payouts {
+			if i == maxPoolWinnersShown {
+				msg += tgfmt.Sprintf("…等 %d 人\n", len(s.Payouts))
+				break
+			}
+			name := strconv.FormatInt(payout.UserID, 10)
+			if user, err := h.accountService.GetUser(ctx, payout.UserID); err == nil {
+				name = user.Username
+			}
+			msg += tgfmt.Sprintf("%s +%s\n", tgfmt.Mention(payout.UserID, name), tgfmt.Amount(payout.Amount))
+		}
+	}
+	return msg
+}
+
+// replyPoolError replies with a user facing pool error
+func (h *PoolHandler) replyPoolError(c tele.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNoPool):
+		return c.Reply("📭 本群当前没有竞猜")
+	case errors.Is(err, service.ErrInsufficientBalance):
+		return c.Reply("❌ 余额不足")
+	case errors.Is(err, service.ErrPoolOutcomes):
+		return c.Reply(fmt.Sprintf("❌ %s\n例如: /pool \"今晚谁会赢？\" A队 B队", err.Error()))
+	case errors.Is(err, service.ErrPoolExists),
+		errors.Is(err, service.ErrPoolSyntax),
+		errors.Is(err, service.ErrPoolTooLong),
+		errors.Is(err, service.ErrPoolWindow),
+		errors.Is(err, service.ErrPoolBettingClosed),
+		errors.Is(err, service.ErrPoolUnknownOutcome):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Msg("Pool operation failed")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
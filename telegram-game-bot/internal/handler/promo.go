@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+	"telegram-game-bot/internal/shop"
+)
+
+// PromoHandler handles promo code generation and redemption commands.
+type PromoHandler struct {
+	promoService   *service.PromoService
+	accountService *service.AccountService
+}
+
+// NewPromoHandler creates a new PromoHandler.
+func NewPromoHandler(promoService *service.PromoService, accountService *service.AccountService) *PromoHandler {
+	return &PromoHandler{
+		promoService:   promoService,
+		accountService: accountService,
+	}
+}
+
+// HandleGenCode handles the /gencode command (admin only).
+// Format: /gencode <金额|道具类型> <次数> <有效期>
+// Examples: /gencode 500 10 24h, /gencode shield 1 7d, /gencode 1000 1 0
+func (h *PromoHandler) HandleGenCode(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) < 3 {
+		return c.Reply("❌ 用法: /gencode <金额|道具类型> <次数> <有效期>\n" +
+			"例如: /gencode 500 10 24h\n" +
+			"有效期: 30m / 24h / 7d，0 表示永久有效")
+	}
+
+	reward, err := service.ParsePromoReward(args[0])
+	if err != nil {
+		return c.Reply("❌ " + err.Error())
+	}
+
+	maxUses, err := strconv.Atoi(args[1])
+	if err != nil || maxUses <= 0 {
+		return c.Reply("❌ " + service.ErrInvalidPromoUses.Error())
+	}
+
+	validFor, err := service.ParsePromoDuration(args[2])
+	if err != nil {
+		return c.Reply("❌ " + err.Error())
+	}
+
+	promo, err := h.promoService.GenerateCode(ctx, sender.ID, reward, maxUses, validFor)
+	if err != nil {
+		log.Error().Err(err).Int64("admin_id", sender.ID).Msg("Failed to generate promo code")
+		return c.Reply("❌ 生成兑换码失败，请稍后重试")
+	}
+
+	mode := "多次兑换"
+	if promo.IsSingleUse() {
+		mode = "单次兑换"
+	}
+
+	expiry := "永久有效"
+	if promo.ExpiresAt != nil {
+		expiry = promo.ExpiresAt.Format("2006-01-02 15:04")
+	}
+
+	return c.Reply(fmt.Sprintf(
+		"✅ 兑换码已生成\n\n"+
+			"🎟️ 兑换码: <code>%s</code>\n"+
+			"🎁 奖励: %s\n"+
+			"🔢 次数: %d（%s）\n"+
+			"⏰ 过期时间: %s\n\n"+
+			"使用方法: /redeem %s",
+		promo.Code, formatPromoReward(promo), promo.MaxUses, mode, expiry, promo.Code,
+	), tele.ModeHTML)
+}
+
+// HandleRedeem handles the /redeem command.
+// Format: /redeem <兑换码>
+func (h *PromoHandler) HandleRedeem(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) < 1 {
+		return c.Reply("❌ 用法: /redeem <兑换码>")
+	}
+
+	username := senderName(sender)
+
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, username); err != nil {
+		return c.Reply("❌ 获取账户失败，请稍后重试")
+	}
+
+	promo, err := h.promoService.Redeem(ctx, sender.ID, args[0])
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPromoNotFound),
+			errors.Is(err, service.ErrPromoExpired),
+			errors.Is(err, service.ErrPromoExhausted),
+			errors.Is(err, service.ErrPromoAlreadyRedeemed),
+			errors.Is(err, service.ErrMaxItemTypesReached),
+			errors.Is(err, service.ErrInventoryFull),
+			errors.Is(err, service.ErrItemNotFound):
+			return c.Reply("❌ " + err.Error())
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to redeem promo code")
+		return c.Reply("❌ 兑换失败，请稍后重试")
+	}
+
+	return c.Reply(fmt.Sprintf("🎉 兑换成功！获得 %s", formatPromoReward(promo)))
+}
+
+// formatPromoReward returns a display string for the promo code reward
+func formatPromoReward(promo *model.PromoCode) string {
+	if promo.RewardItem != nil {
+		if item, ok := shop.GetItem(shop.ItemType(*promo.RewardItem)); ok {
+			return item.Emoji + " " + item.Name
+		}
+		return *promo.RewardItem
+	}
+	return fmt.Sprintf("%d 金币", promo.RewardAmount)
+}
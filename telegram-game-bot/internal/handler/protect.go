@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/rob"
+)
+
+// HandleProtect handles the /protect command.
+// /protect shows the rob protection status, /protect extend pays to extend a running protection.
+func (h *RobHandler) HandleProtect(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	cfg := h.robGame.ProtectionConfig()
+	args := c.Args()
+
+	if len(args) > 0 && strings.EqualFold(args[0], "extend") {
+		until, err := h.robGame.ExtendProtection(ctx, sender.ID)
+		if err != nil {
+			if errors.Is(err, rob.ErrExtendDisabled) || errors.Is(err, rob.ErrNotProtected) ||
+				errors.Is(err, rob.ErrProtectionCapped) || errors.Is(err, rob.ErrInsufficientBalance) {
+				return c.Reply("❌ " + err.Error())
+			}
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to extend rob protection")
+			return c.Reply("❌ 续期失败，请稍后重试")
+		}
+
+		newBalance, _ := h.accountService.GetBalance(ctx, sender.ID)
+		return c.Reply(fmt.Sprintf(
+			"✅ 保护期已延长 %d 分钟，剩余 %d 分钟\n💸 花费 %d 金币\n💰 余额: %d",
+			int(cfg.ExtendDuration.Minutes()), int(time.Until(until).Minutes())+1, cfg.ExtendCost, newBalance,
+		))
+	}
+
+	var sb strings.Builder
+	protected, remaining := h.robGame.IsProtected(sender.ID)
+	grace := h.robGame.GraceRemaining(user.CreatedAt)
+	switch {
+	case protected:
+		sb.WriteString(fmt.Sprintf("🛡️ 你在打劫保护期，剩余 %d 分钟", int(remaining.Minutes())+1))
+	case grace > 0:
+		sb.WriteString(fmt.Sprintf("🐣 新手保护中，剩余 %d 分钟", int(grace.Minutes())+1))
+	default:
+		sb.WriteString("⚔️ 你当前没有打劫保护")
+	}
+
+	if cfg.ExtendCost > 0 && cfg.ExtendDuration > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n💡 保护期内可发送 /protect extend 花费 %d 金币延长 %d 分钟",
+			cfg.ExtendCost, int(cfg.ExtendDuration.Minutes())))
+	}
+
+	return c.Reply(sb.String())
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/service"
+)
+
+// pvpUsage explains the /pvp subcommands
+const pvpUsage = "📖 用法:\n" +
+	"/pvp off - 退出 PvP，不会被打劫、挑战或使用手铐，但也不能攻击他人\n" +
+	"/pvp on - 重新加入 PvP\n\n" +
+	"⚠️ 每 24 小时只能修改一次"
+
+// PvPHandler lets users opt out of rob, duels and handcuffs.
+type PvPHandler struct {
+	pvp *service.PvPService
+}
+
+// NewPvPHandler creates a new PvPHandler.
+func NewPvPHandler(pvp *service.PvPService) *PvPHandler {
+	return &PvPHandler{pvp: pvp}
+}
+
+// HandlePvP handles the /pvp command.
+// Format: /pvp [on|off]
+func (h *PvPHandler) HandlePvP(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		pref, err := h.pvp.Get(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		status := "⚔️ PvP: 参与中"
+		if pref != nil && pref.OptedOut {
+			status = "🕊️ PvP: 已退出"
+		}
+		return c.Reply(status + "\n\n" + pvpUsage)
+	}
+
+	var optedOut bool
+	switch strings.ToLower(args[0]) {
+	case "off":
+		optedOut = true
+	case "on":
+		optedOut = false
+	default:
+		return c.Reply(pvpUsage)
+	}
+
+	remaining, err := h.pvp.SetOptedOut(ctx, sender.ID, optedOut, time.Now())
+	switch {
+	case err == nil:
+		if optedOut {
+			return c.Reply("🕊️ 已退出 PvP，其他人无法再打劫、挑战你或对你使用手铐，你也不能攻击他人")
+		}
+		return c.Reply("⚔️ 已重新加入 PvP")
+	case errors.Is(err, service.ErrPvPUnchanged):
+		if optedOut {
+			return c.Reply("❌ 你已经退出了 PvP")
+		}
+		return c.Reply("❌ 你已经在参与 PvP")
+	case errors.Is(err, service.ErrPvPChangeCooldown):
+		return c.Reply("⏰ " + err.Error() + "，请等待 " + cooldown.Format(remaining))
+	}
+	log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to change PvP preference")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
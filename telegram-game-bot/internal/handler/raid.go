@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// raidTickInterval is how often raids are started and settled
+const raidTickInterval = 30 * time.Second
+
+// RaidHandler handles group vs group raid events.
+type RaidHandler struct {
+	cfg            *config.Config
+	whitelist      ChatAllower // Chats that may take part, the config's by default
+	raidService    *service.RaidService
+	accountService *service.AccountService
+}
+
+// NewRaidHandler creates a new RaidHandler.
+func NewRaidHandler(cfg *config.Config, raidService *service.RaidService, accountService *service.AccountService) *RaidHandler {
+	return &RaidHandler{
+		cfg:            cfg,
+		whitelist:      cfg,
+		raidService:    raidService,
+		accountService: accountService,
+	}
+}
+
+// SetWhitelist sets the whitelist the participating chats must be on.
+func (h *RaidHandler) SetWhitelist(whitelist ChatAllower) {
+	h.whitelist = whitelist
+}
+
+// StartScheduler periodically starts scheduled raids and settles finished ones.
+func (h *RaidHandler) StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(raidTickInterval)
+		heartbeat.Start("raid", raidTickInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("raid")
+			h.raidService.Tick(context.Background())
+		}
+	}()
+}
+
+// HandleRaidStart handles the /raid_start command (admin only).
+// Format: /raid_start <chat_a> <chat_b> <minutes> <prize_pool> [delay_minutes]
+func (h *RaidHandler) HandleRaidStart(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	usage := "❌ 用法: /raid_start <群A ID> <群B ID> <分钟> <奖池> [延迟分钟]\n例如: /raid_start -100123 -100456 60 10000"
+	args := c.Args()
+	if len(args) < 4 {
+		return c.Reply(usage)
+	}
+
+	chatA, errA := strconv.ParseInt(args[0], 10, 64)
+	chatB, errB := strconv.ParseInt(args[1], 10, 64)
+	minutes, errM := strconv.Atoi(args[2])
+	prizePool, errP := strconv.ParseInt(args[3], 10, 64)
+	if errA != nil || errB != nil || errM != nil || errP != nil {
+		return c.Reply(usage)
+	}
+	delayMinutes := 0
+	if len(args) >= 5 {
+		d, err := strconv.Atoi(args[4])
+		if err != nil {
+			return c.Reply(usage)
+		}
+		delayMinutes = d
+	}
+
+	if !h.whitelist.IsChatAllowed(chatA) || !h.whitelist.IsChatAllowed(chatB) {
+		return c.Reply("❌ 对战双方必须都是白名单群组")
+	}
+
+	raid, err := h.raidService.Schedule(ctx, sender.ID, chatA, chatB,
+		time.Duration(minutes)*time.Minute, time.Duration(delayMinutes)*time.Minute, prizePool)
+	if err != nil {
+		if errors.Is(err, service.ErrRaidInvalid) {
+			return c.Reply(fmt.Sprintf("❌ %s（时长 %d-%d 分钟，奖池需大于 0）",
+				err.Error(), int(service.MinRaidDuration.Minutes()), int(service.MaxRaidDuration.Minutes())))
+		}
+		return h.replyRaidError(c, err)
+	}
+
+	return c.Reply(fmt.Sprintf("✅ 突袭 #%d 已创建\n开始: %s\n结束: %s\n奖池: %d 金币",
+		raid.ID, raid.StartsAt.Format("01-02 15:04"), raid.EndsAt.Format("01-02 15:04"), raid.PrizePool))
+}
+
+// HandleRaidCancel handles the /raid_cancel command (admin only).
+func (h *RaidHandler) HandleRaidCancel(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	raid, err := h.raidService.Cancel(ctx, sender.ID)
+	if err != nil {
+		return h.replyRaidError(c, err)
+	}
+	return c.Reply(fmt.Sprintf("🚫 突袭 #%d 已取消", raid.ID))
+}
+
+// HandleRaidJoin handles the /raid_join command: joins the side of the current chat.
+func (h *RaidHandler) HandleRaidJoin(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在参战群组中发送 /raid_join")
+	}
+
+	username := senderName(sender)
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, username); err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	raid, err := h.raidService.Join(ctx, sender.ID, chat.ID)
+	if err != nil {
+		return h.replyRaidError(c, err)
+	}
+
+	if raid.Status == model.RaidStatusScheduled {
+		return c.Reply(fmt.Sprintf("✅ 已加入本群阵营，突袭将于 %s 开始", raid.StartsAt.Format("01-02 15:04")))
+	}
+	return c.Reply("✅ 已加入本群阵营！打劫对方阵营成员成功即可为本群得分")
+}
+
+// HandleRaid handles the /raid command: shows the current raid and score.
+func (h *RaidHandler) HandleRaid(c tele.Context) error {
+	ctx := context.Background()
+	chat := c.Chat()
+	if chat == nil {
+		return nil
+	}
+
+	raid := h.raidService.Current()
+	if raid == nil {
+		return c.Reply("📭 当前没有突袭活动")
+	}
+
+	if raid.Status == model.RaidStatusScheduled {
+		return c.Reply(fmt.Sprintf("📣 突袭 #%d 将于 %s 开始\n奖池: %d 金币\n发送 /raid_join 加入本群阵营",
+			raid.ID, raid.StartsAt.Format("01-02 15:04"), raid.PrizePool))
+	}
+
+	members, err := h.raidService.GetMembers(ctx, raid.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("raid_id", raid.ID).Msg("Failed to get raid members")
+		return c.Reply("❌ 获取失败，请稍后重试")
+	}
+
+	pointsA, pointsB := service.RaidSideTotals(raid, members)
+	scoreLine := fmt.Sprintf("A 方 %d : %d B 方", pointsA, pointsB)
+	if raid.HasChat(chat.ID) {
+		ours, theirs := pointsA, pointsB
+		if chat.ID == raid.ChatB {
+			ours, theirs = pointsB, pointsA
+		}
+		scoreLine = fmt.Sprintf("本群 %d : %d 对方", ours, theirs)
+	}
+
+	remaining := time.Until(raid.EndsAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return c.Reply(fmt.Sprintf("⚔️ 突袭 #%d 进行中\n\n%s\n参战人数: %d\n奖池: %d 金币\n剩余: %d 分钟",
+		raid.ID, scoreLine, len(members), raid.PrizePool, int(remaining.Minutes())+1))
+}
+
+// replyRaidError replies with a user facing raid error
+func (h *RaidHandler) replyRaidError(c tele.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrRaidExists),
+		errors.Is(err, service.ErrNoRaid),
+		errors.Is(err, service.ErrRaidSameChat),
+		errors.Is(err, service.ErrRaidInvalid),
+		errors.Is(err, service.ErrRaidWrongChat),
+		errors.Is(err, service.ErrRaidJoined):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Msg("Raid operation failed")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
@@ -5,9 +5,12 @@ package handler
 import (
 	"context"
 	"fmt"
+	"time"
 
 	tele "gopkg.in/telebot.v3"
 
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/tgfmt"
 	"telegram-game-bot/internal/service"
 )
 
@@ -23,6 +26,25 @@ func NewRankingHandler(rankingService *service.RankingService) *RankingHandler {
 	}
 }
 
+// StartAggregateScheduler starts the background goroutine that aggregates
+// the profit of finished days, first right away.
+func (h *RankingHandler) StartAggregateScheduler(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		h.rankingService.RunAggregation(context.Background(), time.Now())
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("profit_aggregate", interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("profit_aggregate")
+			h.rankingService.RunAggregation(context.Background(), now)
+		}
+	}()
+}
+
 // HandleDailyTop handles the /daily_top command.
 // Displays today's top winners and losers.
 // Requirements: 11.1, 11.3
@@ -41,11 +63,11 @@ func (h *RankingHandler) HandleDailyTop(c tele.Context) error {
 		return c.Reply("❌ 获取排行榜失败，请稍后重试")
 	}
 
-	msg := "📊 今日游戏榜\n"
+	msg := tgfmt.HTML("📊 <b>今日游戏榜</b>\n")
 	msg += "━━━━━━━━━━━━━━━\n"
 
 	// Winners section
-	msg += "🏆 赢家榜 TOP 10\n"
+	msg += "🏆 <b>赢家榜 TOP 10</b>\n"
 	if len(winners) == 0 {
 		msg += "暂无数据\n"
 	} else {
@@ -56,35 +78,27 @@ func (h *RankingHandler) HandleDailyTop(c tele.Context) error {
 				rank = medals[i]
 			}
 
-			displayName := winner.Username
-			if displayName == "" {
-				displayName = fmt.Sprintf("User%d", winner.UserID)
-			}
-
-			msg += fmt.Sprintf("%s %s: +%d\n", rank, displayName, winner.NetProfit)
+			displayName := service.RankDisplayName(winner.Username, winner.UserID, winner.Hidden)
+			msg += tgfmt.Sprintf("%s %s: %s\n", rank, displayName, tgfmt.SignedAmount(winner.NetProfit))
 		}
 	}
 
 	msg += "\n━━━━━━━━━━━━━━━\n"
 
 	// Losers section
-	msg += "😢 输家榜 TOP 10\n"
+	msg += "😢 <b>输家榜 TOP 10</b>\n"
 	if len(losers) == 0 {
 		msg += "暂无数据\n"
 	} else {
 		for i, loser := range losers {
 			rank := fmt.Sprintf("%d.", i+1)
 
-			displayName := loser.Username
-			if displayName == "" {
-				displayName = fmt.Sprintf("User%d", loser.UserID)
-			}
-
-			msg += fmt.Sprintf("%s %s: %d\n", rank, displayName, loser.NetProfit)
+			displayName := service.RankDisplayName(loser.Username, loser.UserID, loser.Hidden)
+			msg += tgfmt.Sprintf("%s %s: %s\n", rank, displayName, tgfmt.SignedAmount(loser.NetProfit))
 		}
 	}
 
 	msg += "━━━━━━━━━━━━━━━"
 
-	return c.Reply(msg)
+	return replyHTML(c, msg)
 }
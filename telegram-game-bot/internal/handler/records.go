@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+)
+
+// RecordsHandler shows the biggest single wins of a chat.
+type RecordsHandler struct {
+	records *service.RecordsService
+}
+
+// NewRecordsHandler creates a new RecordsHandler.
+func NewRecordsHandler(records *service.RecordsService) *RecordsHandler {
+	return &RecordsHandler{records: records}
+}
+
+// HandleRecords handles the /records command (groups only).
+func (h *RecordsHandler) HandleRecords(c tele.Context) error {
+	chat := c.Chat()
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中查看本群纪录")
+	}
+
+	records, err := h.records.Records(context.Background(), chat.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to list win records")
+		return c.Reply("❌ 获取纪录失败，请稍后重试")
+	}
+	return c.Reply(formatWinRecords(records))
+}
+
+// formatWinRecords formats the records of a chat by game and period
+func formatWinRecords(records []model.WinRecord) string {
+	byKey := make(map[string]model.WinRecord, len(records))
+	for _, rec := range records {
+		byKey[rec.Game+"/"+rec.Period] = rec
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🏆 本群单次最高赢取纪录\n")
+	for _, game := range service.RecordGames {
+		sb.WriteString("\n" + service.RecordGameLabel(game) + "\n")
+		for _, period := range []string{model.RecordPeriodDay, model.RecordPeriodWeek} {
+			rec, ok := byKey[game+"/"+period]
+			if !ok {
+				sb.WriteString(fmt.Sprintf("  %s: 暂无纪录\n", service.RecordPeriodLabel(period)))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("  %s: %s %d 金币\n", service.RecordPeriodLabel(period), rec.Name, rec.Amount))
+		}
+	}
+	sb.WriteString("\n打破纪录时会在群内播报")
+	return sb.String()
+}
+
+// RecordsAnnouncer posts broken win records in the chats.
+type RecordsAnnouncer struct {
+	bot      *tele.Bot
+	settings *service.ChatSettingsService // Optional: nothing is posted in quiet hours
+}
+
+// NewRecordsAnnouncer creates a new RecordsAnnouncer; settings may be nil.
+func NewRecordsAnnouncer(bot *tele.Bot, settings *service.ChatSettingsService) *RecordsAnnouncer {
+	return &RecordsAnnouncer{bot: bot, settings: settings}
+}
+
+// Announce sends a record announcement to a chat (best effort), unless the
+// chat is in its quiet hours.
+func (a *RecordsAnnouncer) Announce(chatID int64, text string) {
+	if a.settings != nil && a.settings.QuietNow(chatID, time.Now()) {
+		return
+	}
+	if _, err := a.bot.Send(&tele.Chat{ID: chatID}, text); err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to announce win record")
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/model"
+)
+
+// RobHandler handles robbing other users and protecting against it.
+type RobHandler struct {
+	*BaseHandler
+	robGame *rob.RobGame
+}
+
+// NewRobHandler creates a new RobHandler and registers the rob cooldown
+// with the shared cooldown manager.
+func NewRobHandler(base *BaseHandler, robGame *rob.RobGame) *RobHandler {
+	h := &RobHandler{BaseHandler: base, robGame: robGame}
+	if robGame != nil {
+		h.cooldowns.Register(CooldownRob, func(_ context.Context, userID int64) time.Duration {
+			return h.robGame.GetCooldown(userID)
+		})
+	}
+	return h
+}
+
+// HandleDajie handles the /dajie command for robbery game.
+// Requirements: Rob Game - Allow users to rob coins from other users
+func (h *RobHandler) HandleDajie(c tele.Context) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	// 仅限群组使用
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 打劫游戏只能在群组中进行，请加入群组后使用")
+	}
+
+	// Get robber's username
+	robberName := senderName(sender)
+
+	// Ensure robber exists
+	_, _, err := h.accountService.EnsureUser(ctx, sender.ID, robberName)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	// Determine victim from reply or @mention
+	var victimID int64
+	var victimName string
+
+	// Check if replying to a message
+	if c.Message().ReplyTo != nil && c.Message().ReplyTo.Sender != nil {
+		victimID = c.Message().ReplyTo.Sender.ID
+		victimName = senderName(c.Message().ReplyTo.Sender)
+	} else {
+		// Check for #handle or @mention in args
+		args := c.Args()
+		if len(args) < 1 {
+			return c.Reply("❌ 用法: /dj (回复消息) 或 /dj #编号")
+		}
+
+		if isHandleArg(args[0]) {
+			id, name, ok := resolveHandleTarget(ctx, c, h.accountService, args[0])
+			if !ok {
+				return nil
+			}
+			victimID, victimName = id, name
+		} else {
+			// Telegram does not allow looking up users by @username
+			return c.Reply("❌ 请回复目标用户的消息，或使用 /dj #编号 发起打劫")
+		}
+	}
+
+	// All attacks share the aggression cooldown
+	if remaining := h.cooldowns.BucketWait(sender.ID, CooldownAggression, attackRob); remaining > 0 {
+		return c.Reply(aggressionMessage(remaining))
+	}
+
+	// Execute robbery
+	names := playerNames(h.chatPersona(ctx, c.Chat().ID))
+	result, err := h.robGame.Rob(ctx, sender.ID, victimID, robberName, victimName, names)
+	if err != nil {
+		log.Error().Err(err).Int64("robber", sender.ID).Int64("victim", victimID).Msg("Robbery failed")
+		return c.Reply("❌ 打劫失败，请稍后重试")
+	}
+	if result.Attempted() {
+		h.cooldowns.Charge(sender.ID, CooldownAggression, attackRob)
+	}
+
+	// Items consumed on the way, so players know what they have left
+	itemsLeft := formatItemsLeft(result.ItemsUsed, map[int64]string{
+		sender.ID: names(sender.ID, robberName),
+		victimID:  names(victimID, victimName),
+	})
+
+	// Send result, its names are mentions
+	if result.Success {
+		msg := result.Message + fmt.Sprintf("\n💰 你的余额: %d", result.NewBalance) + itemsLeft
+		err := c.Reply(msg, tele.ModeHTML)
+		if result.Critical {
+			h.celebrate(ctx, c.Chat().ID, model.CelebrationGreatSwordCrit)
+		}
+		return err
+	}
+
+	return c.Reply("❌ "+result.Message+itemsLeft, tele.ModeHTML)
+}
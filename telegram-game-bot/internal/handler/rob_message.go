@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/service"
+)
+
+// robMessageUsage explains the /robmsg subcommands
+const robMessageUsage = "📖 用法:\n" +
+	"/robmsg - 查看打劫文案\n" +
+	"/robmsg add 结果 稀有度 文案 - 添加文案\n" +
+	"  结果: fail counter success critical\n" +
+	"  稀有度: common rare epic\n" +
+	"  变量: {robber} {victim} {amount} {item}\n" +
+	"  例如 /robmsg add success rare 🥷 {robber} 借着夜色摸走了 {victim} 的 {amount} 金币\n" +
+	"/robmsg del 编号 - 删除文案"
+
+// robMessageOutcomeLabels names the rob message outcomes
+var robMessageOutcomeLabels = map[string]string{
+	model.RobMessageFail:     "😅 失败",
+	model.RobMessageCounter:  "⚔️ 被反击",
+	model.RobMessageSuccess:  "🔫 成功",
+	model.RobMessageCritical: "💥 暴击",
+}
+
+// robMessageTierLabels names the rob message rarity tiers
+var robMessageTierLabels = map[string]string{
+	model.RobTierCommon: "普通",
+	model.RobTierRare:   "稀有",
+	model.RobTierEpic:   "史诗",
+}
+
+// RobMessageHandler lets admins manage the flavor texts of rob results.
+type RobMessageHandler struct {
+	robMessages *service.RobMessageService
+}
+
+// NewRobMessageHandler creates a new RobMessageHandler.
+func NewRobMessageHandler(robMessages *service.RobMessageService) *RobMessageHandler {
+	return &RobMessageHandler{robMessages: robMessages}
+}
+
+// HandleRobMessage handles the /robmsg admin command.
+// Format: /robmsg [add outcome tier text | del id]
+func (h *RobMessageHandler) HandleRobMessage(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		msgs, err := h.robMessages.List(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list rob messages")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(formatRobMessages(msgs))
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) < 4 {
+			return c.Reply(robMessageUsage)
+		}
+		text := strings.Join(args[3:], " ")
+		msg, err := h.robMessages.Add(ctx, strings.ToLower(args[1]), strings.ToLower(args[2]), text, sender.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrRobMessageUnknownOutcome), errors.Is(err, service.ErrRobMessageUnknownTier),
+				errors.Is(err, service.ErrRobMessageEmpty), errors.Is(err, service.ErrRobMessageInvalid),
+				errors.Is(err, service.ErrRobMessageBlocked):
+				return c.Reply("❌ " + err.Error())
+			case errors.Is(err, service.ErrRobMessageTooLong):
+				return c.Reply(fmt.Sprintf("❌ %s，最多 %d 个字", err.Error(), service.RobMessageMaxRunes))
+			}
+			log.Error().Err(err).Msg("Failed to add rob message")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已添加文案 #%d (%s / %s)", msg.ID,
+			robMessageOutcomeLabels[msg.Outcome], robMessageTierLabels[msg.Tier]))
+
+	case "del":
+		if len(args) < 2 {
+			return c.Reply(robMessageUsage)
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return c.Reply("❌ 编号格式错误")
+		}
+		if err := h.robMessages.Delete(ctx, id, sender.ID); err != nil {
+			if errors.Is(err, service.ErrRobMessageNotFound) {
+				return c.Reply("❌ " + err.Error())
+			}
+			log.Error().Err(err).Int64("message_id", id).Msg("Failed to delete rob message")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已删除文案 #%d", id))
+	}
+	return c.Reply(robMessageUsage)
+}
+
+// formatRobMessages formats the texts of all outcomes; texts of the config
+// file have no number and cannot be deleted
+func formatRobMessages(msgs []model.RobMessage) string {
+	var sb strings.Builder
+	sb.WriteString("🔫 打劫文案\n━━━━━━━━━━━━━━━\n")
+	if len(msgs) == 0 {
+		sb.WriteString("暂无文案，使用内置文案\n")
+	}
+	for _, m := range msgs {
+		id := "配置"
+		if m.ID != 0 {
+			id = fmt.Sprintf("#%d", m.ID)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s [%s] %s\n", id, robMessageOutcomeLabels[m.Outcome], robMessageTierLabels[m.Tier], m.Text))
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString(robMessageUsage)
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/service"
+)
+
+// SetRTP enables the RTP report of /rtp.
+func (h *AdminHandler) SetRTP(rtp *service.RTPService) {
+	h.rtp = rtp
+}
+
+// HandleRTP handles the /rtp command.
+// Reports the realized return-to-player of the house games from the ledger
+// against the payout tables, by default over the last 24 hours, 7 and 30 days.
+// Format: /rtp [window...], e.g. /rtp 12h 3d
+func (h *AdminHandler) HandleRTP(c tele.Context) error {
+	if h.rtp == nil {
+		return nil
+	}
+
+	windows, err := service.ParseRTPWindows(c.Args())
+	if err != nil {
+		return c.Reply("❌ " + err.Error() + "\n用法: /rtp [时间窗口...]，例如 /rtp 24h 7d 30d")
+	}
+
+	report, err := h.rtp.Report(context.Background(), windows, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build RTP report")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	for _, window := range report {
+		for _, g := range window.Games {
+			if g.Drifting() {
+				log.Warn().
+					Str("game", g.Name).
+					Dur("window", window.Window).
+					Float64("rtp", g.RTP()).
+					Float64("theoretical", g.Theoretical).
+					Int64("bets", g.Bets).
+					Msg("Game RTP drifting from design")
+			}
+		}
+	}
+
+	return c.Reply(service.FormatRTPReport(report))
+}
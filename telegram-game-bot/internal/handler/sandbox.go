@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/roles"
+	"telegram-game-bot/internal/service"
+)
+
+// sandboxBanner heads game results played with play money
+const sandboxBanner = "🧪 沙盒模式 · 体验币，不影响真实余额"
+
+// sandboxUsage explains the /sandbox subcommands
+const sandboxUsage = "📖 用法:\n" +
+	"/sandbox - 查看沙盒状态和你的体验币\n" +
+	"/sandbox on - 开启沙盒模式\n" +
+	"/sandbox off - 关闭沙盒模式\n" +
+	"/sandbox reset - 重置本群所有体验币"
+
+// SandboxHandler lets group admins run the games of their chat with play money.
+type SandboxHandler struct {
+	cfg       *config.Config
+	sandbox   *service.SandboxService
+	sicboGame *sicbo.SicBoGame
+}
+
+// NewSandboxHandler creates a new SandboxHandler.
+func NewSandboxHandler(cfg *config.Config, sandbox *service.SandboxService, sicboGame *sicbo.SicBoGame) *SandboxHandler {
+	return &SandboxHandler{cfg: cfg, sandbox: sandbox, sicboGame: sicboGame}
+}
+
+// HandleSandbox handles the /sandbox command.
+// Without arguments it shows the chat's sandbox state, subcommands change it (group admins only).
+func (h *SandboxHandler) HandleSandbox(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中使用此命令")
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		if !h.sandbox.IsSandbox(ctx, chat.ID) {
+			return c.Reply("🧪 沙盒模式: 关闭\n\n" + sandboxUsage)
+		}
+		balance, err := h.sandbox.Balance(ctx, chat.ID, sender.ID)
+		if err != nil {
+			log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get sandbox balance")
+			return c.Reply("❌ 获取余额失败")
+		}
+		return c.Reply(fmt.Sprintf("🧪 沙盒模式: 开启\n本群游戏使用体验币，不影响真实余额\n\n💰 你的体验币: %d\n\n%s", balance, sandboxUsage))
+	}
+
+	if !hasRole(c, h.cfg, roles.ChatAdmin) {
+		return c.Reply("❌ 只有群管理员可以切换沙盒模式")
+	}
+
+	sub := strings.ToLower(args[0])
+	if sub != "reset" && h.sicboGame != nil && h.sicboGame.ChatSessionActive(chat.ID) {
+		return c.Reply("❌ 当前有进行中的骰宝，请开奖后再切换")
+	}
+
+	switch sub {
+	case "on":
+		if err := h.sandbox.Enable(ctx, chat.ID, sender.ID); err != nil {
+			log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to enable sandbox")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已开启沙盒模式\n骰子、老虎机和骰宝改用体验币，每人初始 %d\n转账、签到、打劫等涉及真实金币的命令暂停使用", h.sandbox.StartBalance()))
+
+	case "off":
+		if err := h.sandbox.Disable(ctx, chat.ID, sender.ID); err != nil {
+			log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to disable sandbox")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 已关闭沙盒模式，游戏恢复使用真实金币")
+
+	case "reset":
+		n, err := h.sandbox.Reset(ctx, chat.ID, sender.ID)
+		if err != nil {
+			log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to reset sandbox balances")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(fmt.Sprintf("✅ 已重置 %d 名玩家的体验币", n))
+	}
+	return c.Reply(sandboxUsage)
+}
+
+// SetSandbox sets the service deciding which chats play with play money
+func (h *BaseHandler) SetSandbox(sandbox *service.SandboxService) {
+	h.sandbox = sandbox
+}
+
+// balanceScope returns the balance games in a chat are played with
+func (h *BaseHandler) balanceScope(ctx context.Context, chatID int64) model.BalanceScope {
+	if h.sandbox == nil {
+		return model.RealBalance
+	}
+	return h.sandbox.Scope(ctx, chatID)
+}
+
+// inSandbox reports whether a chat plays with play money
+func (h *BaseHandler) inSandbox(chatID int64) bool {
+	return h.balanceScope(context.Background(), chatID).IsSandbox()
+}
+
+// reportIncidentIn reports losses caused by the bot for compensation.
+// Play money is never compensated with real coins, so sandbox losses are only logged.
+func (h *BaseHandler) reportIncidentIn(scope model.BalanceScope, kind, description string, claims ...service.CompensationClaim) {
+	if scope.IsSandbox() {
+		if len(claims) > 0 {
+			log.Warn().Str("kind", kind).Int64("chat_id", scope.SandboxChatID).Msg("Sandbox incident not compensated: " + description)
+		}
+		return
+	}
+	h.reportIncident(kind, description, claims...)
+}
+
+// renderBalance renders the balance line of a game result; play money is labeled as such
+func (h *BaseHandler) renderBalance(persona *model.ChatPersona, scope model.BalanceScope, balance int64) string {
+	if scope.IsSandbox() {
+		return "🧪 体验币余额: " + strconv.FormatInt(balance, 10) + "（沙盒）"
+	}
+	return service.RenderBalance(persona, balance)
+}
+
+// coinName names the currency of a scope
+func coinName(scope model.BalanceScope) string {
+	if scope.IsSandbox() {
+		return "体验币"
+	}
+	return "金币"
+}
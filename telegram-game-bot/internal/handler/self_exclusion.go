@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+// selfExcludeUsage explains /selfexclude
+var selfExcludeUsage = fmt.Sprintf("📖 用法:\n"+
+	"/selfexclude 天数 - 查看说明\n"+
+	"/selfexclude 天数 confirm - 确认禁赌（%d-%d 天）\n\n"+
+	"⚠️ 禁赌期间无法参与任何游戏，到期前不可撤销或缩短，只能延长",
+	service.SelfExclusionMinDays, service.SelfExclusionMaxDays)
+
+// SelfExclusionHandler lets users voluntarily lock themselves out of gambling.
+type SelfExclusionHandler struct {
+	exclusions *service.SelfExclusionService
+}
+
+// NewSelfExclusionHandler creates a new SelfExclusionHandler.
+func NewSelfExclusionHandler(exclusions *service.SelfExclusionService) *SelfExclusionHandler {
+	return &SelfExclusionHandler{exclusions: exclusions}
+}
+
+// HandleSelfExclude handles the /selfexclude command.
+// Format: /selfexclude <days> [confirm]
+func (h *SelfExclusionHandler) HandleSelfExclude(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		status := "🎲 自我禁赌: 未开启"
+		if until, excluded := h.exclusions.ExcludedUntil(ctx, sender.ID); excluded {
+			status = fmt.Sprintf("🚫 自我禁赌中，至 %s 结束", until.Format("2006-01-02 15:04"))
+		}
+		return c.Reply(status + "\n\n" + selfExcludeUsage)
+	}
+
+	days, err := strconv.Atoi(args[0])
+	if err != nil || days < service.SelfExclusionMinDays || days > service.SelfExclusionMaxDays {
+		return c.Reply(fmt.Sprintf("❌ %s，请输入 %d-%d 天\n\n%s",
+			service.ErrSelfExclusionDays.Error(), service.SelfExclusionMinDays, service.SelfExclusionMaxDays, selfExcludeUsage))
+	}
+
+	// Irreversible, so the user has to confirm explicitly
+	if len(args) < 2 || strings.ToLower(args[1]) != "confirm" {
+		return c.Reply(fmt.Sprintf(
+			"⚠️ 确认自我禁赌 %d 天？\n\n"+
+				"禁赌期间无法使用任何游戏命令和下注按钮，到期前不可撤销或缩短\n\n"+
+				"确认请发送: /selfexclude %d confirm",
+			days, days,
+		))
+	}
+
+	until, err := h.exclusions.Exclude(ctx, sender.ID, days)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return c.Reply("❌ 你还没有账户，请先在群里发送 /start")
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to self exclude")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	return c.Reply(fmt.Sprintf("✅ 已开启自我禁赌\n\n🚫 至 %s 结束，期间无法参与任何游戏\n💚 照顾好自己", until.Format("2006-01-02 15:04")))
+}
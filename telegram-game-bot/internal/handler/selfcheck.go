@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/pkg/heartbeat"
+)
+
+// selfCheckTimeout bounds each check of /selfcheck
+const selfCheckTimeout = 5 * time.Second
+
+// Pinger checks that a dependency is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SelfCheckHandler reports the runtime health of the bot to admins.
+type SelfCheckHandler struct {
+	db      Pinger
+	started time.Time
+}
+
+// NewSelfCheckHandler creates a new SelfCheckHandler.
+func NewSelfCheckHandler(db Pinger) *SelfCheckHandler {
+	return &SelfCheckHandler{db: db, started: time.Now()}
+}
+
+// HandleSelfCheck handles the /selfcheck command (admin only).
+// It checks the database, the Telegram API and the background loops of this instance.
+func (h *SelfCheckHandler) HandleSelfCheck(c tele.Context) error {
+	healthy := true
+	var sb strings.Builder
+	sb.WriteString("🩺 自检报告\n\n")
+
+	if h.db == nil {
+		sb.WriteString("⚪ 数据库: 未配置检查\n")
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), selfCheckTimeout)
+		start := time.Now()
+		err := h.db.Ping(ctx)
+		cancel()
+		if err != nil {
+			healthy = false
+			log.Error().Err(err).Msg("Self-check: database unreachable")
+			sb.WriteString(fmt.Sprintf("❌ 数据库: 无法连接 (%v)\n", err))
+		} else {
+			sb.WriteString(fmt.Sprintf("✅ 数据库: 正常 (%dms)\n", time.Since(start).Milliseconds()))
+		}
+	}
+
+	start := time.Now()
+	if _, err := c.Bot().Raw("getMe", nil); err != nil {
+		healthy = false
+		log.Error().Err(err).Msg("Self-check: Telegram API unreachable")
+		sb.WriteString(fmt.Sprintf("❌ Telegram: 请求失败 (%v)\n", err))
+	} else {
+		sb.WriteString(fmt.Sprintf("✅ Telegram: 正常 (%dms)\n", time.Since(start).Milliseconds()))
+	}
+
+	now := time.Now()
+	statuses := heartbeat.Statuses(now)
+	sb.WriteString("\n⏱ 定时任务:\n")
+	if len(statuses) == 0 {
+		sb.WriteString("⚪ 本实例未运行定时任务\n")
+	}
+	for _, s := range statuses {
+		if s.Stalled {
+			healthy = false
+			log.Warn().Str("loop", s.Name).Time("last_beat", s.LastBeat).Msg("Self-check: background loop stalled")
+			sb.WriteString(fmt.Sprintf("❌ %s: 已停滞，上次运行于 %s前\n", s.Name, cooldown.Format(now.Sub(s.LastBeat))))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("✅ %s: %s前运行\n", s.Name, cooldown.Format(now.Sub(s.LastBeat))))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n🕐 已运行 %s\n", cooldown.Format(now.Sub(h.started))))
+	if healthy {
+		sb.WriteString("🟢 一切正常")
+	} else {
+		sb.WriteString("🔴 存在异常，请查看日志")
+	}
+	return c.Reply(sb.String())
+}
@@ -4,22 +4,29 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	tele "gopkg.in/telebot.v3"
 
+	"telegram-game-bot/internal/cosmetic"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/pkg/heartbeat"
 	"telegram-game-bot/internal/service"
 	"telegram-game-bot/internal/shop"
 )
 
-// Shop banner image file ID
-const ShopBannerFileID = "AgACAgUAAxkBAAIXnWlMyQYxJ7Pj1TY_YkM0sv0VCVDkAAKDC2sbh7RoVmNP_zn_fF-lAQADAgADeQADNgQ"
-
 // ShopHandler handles shop-related commands
 type ShopHandler struct {
-	shopService    *service.ShopService
-	accountService *service.AccountService
+	shopService      *service.ShopService
+	accountService   *service.AccountService
+	inventoryCleanup *service.InventoryCleanupService // Optional: periodic inventory cleanup
+	cosmetics        *service.CosmeticService         // Optional: shop skins bought with stars
+	assets           *service.MediaAssetService       // Optional: shop banner, text-only panel without it
 }
 
 // NewShopHandler creates a new ShopHandler
@@ -30,6 +37,52 @@ func NewShopHandler(shopService *service.ShopService, accountService *service.Ac
 	}
 }
 
+// SetInventoryCleanup sets the service run by StartInventoryCleaner
+func (h *ShopHandler) SetInventoryCleanup(cleanup *service.InventoryCleanupService) {
+	h.inventoryCleanup = cleanup
+}
+
+// SetMediaAssets sets the service providing the shop banner
+func (h *ShopHandler) SetMediaAssets(assets *service.MediaAssetService) {
+	h.assets = assets
+}
+
+// SetCosmetics sets the service providing the users' shop skins
+func (h *ShopHandler) SetCosmetics(cosmetics *service.CosmeticService) {
+	h.cosmetics = cosmetics
+}
+
+// shopMessage creates the shop main menu in the user's shop skin
+func (h *ShopHandler) shopMessage(ctx context.Context, userID, balance int64) string {
+	msg := shop.FormatShopMessage(balance)
+	if h.cosmetics == nil {
+		return msg
+	}
+	equipped, err := h.cosmetics.Equipped(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to load shop skin")
+		return msg
+	}
+	return cosmetic.ApplyShopSkin(msg, equipped[cosmetic.KindShopSkin])
+}
+
+// StartInventoryCleaner starts the background goroutine that purges empty and expired inventory.
+func (h *ShopHandler) StartInventoryCleaner(interval time.Duration) {
+	if h.inventoryCleanup == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		heartbeat.Start("inventory_cleaner", interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("inventory_cleaner")
+			h.inventoryCleanup.Run(context.Background())
+		}
+	}()
+}
+
 // HandleShopStart handles /start in private chat to show shop
 func (h *ShopHandler) HandleShopStart(c tele.Context) error {
 	ctx := context.Background()
@@ -46,10 +99,7 @@ func (h *ShopHandler) HandleShopStart(c tele.Context) error {
 	}
 
 	// Ensure user exists
-	username := sender.Username
-	if username == "" {
-		username = sender.FirstName
-	}
+	username := senderName(sender)
 	_, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
 	if err != nil {
 		return c.Reply("❌ 操作失败，请稍后重试")
@@ -61,22 +111,34 @@ func (h *ShopHandler) HandleShopStart(c tele.Context) error {
 		balance = 0
 	}
 
-	// Send shop panel with photo
-	photo := &tele.Photo{File: tele.File{FileID: ShopBannerFileID}}
-	photo.Caption = shop.FormatShopMessage(balance)
-	markup := shop.BuildShopPanel()
-	return c.Send(photo, markup)
+	// Send shop panel below the banner
+	return h.sendShopPanel(c, h.shopMessage(ctx, sender.ID, balance), shop.BuildShopPanel())
+}
+
+// sendShopPanel sends a shop panel as the caption of the shop banner, or as
+// text if the banner is not set or cannot be sent (e.g. set by another bot)
+func (h *ShopHandler) sendShopPanel(c tele.Context, text string, markup *tele.ReplyMarkup) error {
+	if h.assets != nil {
+		if banner, ok := h.assets.Get(context.Background(), model.MediaAssetShopBanner); ok {
+			photo := &tele.Photo{File: tele.File{FileID: banner.FileID}, Caption: text}
+			_, err := sendInTopic(c, photo, markup)
+			if err == nil {
+				return nil
+			}
+			log.Warn().Err(err).Msg("Failed to send shop banner, falling back to text")
+		}
+	}
+	_, err := sendInTopic(c, text, markup)
+	return err
 }
 
-// editShopPhoto deletes old message and sends new photo message
+// editShopPhoto deletes old message and sends the panel again
 func (h *ShopHandler) editShopPhoto(c tele.Context, caption string, markup *tele.ReplyMarkup) error {
 	// Delete old message
 	c.Delete()
 	
-	// Send new photo message
-	photo := &tele.Photo{File: tele.File{FileID: ShopBannerFileID}}
-	photo.Caption = caption
-	return c.Send(photo, markup)
+	// Send new panel message
+	return h.sendShopPanel(c, caption, markup)
 }
 
 // HandleShopCallback handles shop button callbacks
@@ -98,7 +160,7 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 	// Handle home - back to main menu
 	if data == shop.CallbackShopHome {
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-		caption := shop.FormatShopMessage(balance)
+		caption := h.shopMessage(ctx, sender.ID, balance)
 		markup := shop.BuildShopPanel()
 		if err := h.editShopPhoto(c, caption, markup); err != nil {
 			log.Error().Err(err).Msg("Failed to edit shop photo")
@@ -109,7 +171,7 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 	// Handle refresh
 	if data == shop.CallbackShopRefresh {
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-		caption := shop.FormatShopMessage(balance)
+		caption := h.shopMessage(ctx, sender.ID, balance)
 		markup := shop.BuildShopPanel()
 		if err := h.editShopPhoto(c, caption, markup); err != nil {
 			log.Error().Err(err).Msg("Failed to edit shop photo")
@@ -131,8 +193,9 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 	// Handle attack items view
 	if data == shop.CallbackShopAttack {
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-		caption := shop.FormatAttackItemsMessage(balance)
-		markup := shop.BuildAttackItemsPanel()
+		quotes := h.shopService.PriceQuotes(ctx)
+		caption := shop.FormatAttackItemsMessage(balance, quotes)
+		markup := shop.BuildAttackItemsPanel(quotes)
 		if err := h.editShopPhoto(c, caption, markup); err != nil {
 			log.Error().Err(err).Msg("Failed to edit shop photo")
 		}
@@ -142,8 +205,9 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 	// Handle defense items view
 	if data == shop.CallbackShopDefense {
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-		caption := shop.FormatDefenseItemsMessage(balance)
-		markup := shop.BuildDefenseItemsPanel()
+		quotes := h.shopService.PriceQuotes(ctx)
+		caption := shop.FormatDefenseItemsMessage(balance, quotes)
+		markup := shop.BuildDefenseItemsPanel(quotes)
 		if err := h.editShopPhoto(c, caption, markup); err != nil {
 			log.Error().Err(err).Msg("Failed to edit shop photo")
 		}
@@ -152,37 +216,89 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 
 	// Handle bag view
 	if data == shop.CallbackShopBag {
-		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-		inventory, err := h.shopService.GetUserInventory(ctx, sender.ID)
+		caption, markup, err := h.bagView(ctx, sender.ID)
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{Text: "❌ 获取背包失败", ShowAlert: true})
 		}
+		if err := h.editShopPhoto(c, caption, markup); err != nil {
+			log.Error().Err(err).Msg("Failed to edit shop photo")
+		}
+		return c.Respond()
+	}
+
+	// Handle sell-back from the bag
+	if strings.HasPrefix(data, shop.CallbackShopSell) {
+		itemType, uses, ok := shop.ParseSellBackData(data)
+		if !ok {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 无效的操作", ShowAlert: true})
+		}
 
-		// Convert items to display format (use count based)
-		var effects []shop.EffectInfo
-		for _, item := range inventory.Items {
-			// Skip handcuffs as they are shown separately
-			if item.ItemType == string(shop.ItemHandcuff) {
-				continue
+		amount, _, err := h.shopService.SellBack(ctx, sender.ID, itemType, uses)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrItemNotFound),
+				errors.Is(err, service.ErrItemNotSellable),
+				errors.Is(err, service.ErrNotEnoughUses):
+				return c.Respond(&tele.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+			default:
+				return c.Respond(&tele.CallbackResponse{Text: "❌ 回收失败，请稍后重试", ShowAlert: true})
 			}
-			effects = append(effects, shop.EffectInfo{
-				EffectType:   item.ItemType,
-				RemainingStr: shop.FormatUseCount(item.UseCount),
-			})
 		}
 
-		caption := shop.FormatInventoryMessage(balance, inventory.HandcuffCount, effects)
-		markup := shop.BuildBagPanel()
+		caption, markup, err := h.bagView(ctx, sender.ID)
+		if err == nil {
+			if err := h.editShopPhoto(c, caption, markup); err != nil {
+				log.Error().Err(err).Msg("Failed to edit shop photo")
+			}
+		}
+		return c.Respond(&tele.CallbackResponse{Text: fmt.Sprintf("✅ 回收成功，获得 %d 金币", amount)})
+	}
+
+	// Handle settings view
+	if data == shop.CallbackShopSettings {
+		user, err := h.accountService.GetUser(ctx, sender.ID)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 获取设置失败", ShowAlert: true})
+		}
+
+		caption := shop.FormatSettingsMessage(user.HideFromLeaderboard)
+		markup := shop.BuildSettingsPanel(user.HideFromLeaderboard)
 		if err := h.editShopPhoto(c, caption, markup); err != nil {
 			log.Error().Err(err).Msg("Failed to edit shop photo")
 		}
 		return c.Respond()
 	}
 
+	// Handle leaderboard privacy toggle
+	if data == shop.CallbackShopPrivacy {
+		user, err := h.accountService.GetUser(ctx, sender.ID)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 获取设置失败", ShowAlert: true})
+		}
+
+		hidden := !user.HideFromLeaderboard
+		if err := h.accountService.SetLeaderboardHidden(ctx, sender.ID, hidden); err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to update leaderboard privacy")
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 设置失败，请稍后重试", ShowAlert: true})
+		}
+
+		caption := shop.FormatSettingsMessage(hidden)
+		markup := shop.BuildSettingsPanel(hidden)
+		if err := h.editShopPhoto(c, caption, markup); err != nil {
+			log.Error().Err(err).Msg("Failed to edit shop photo")
+		}
+
+		text := "✅ 已关闭排行榜匿名"
+		if hidden {
+			text = "✅ 已开启排行榜匿名"
+		}
+		return c.Respond(&tele.CallbackResponse{Text: text})
+	}
+
 	// Handle cancel - back to shop (legacy, keep for compatibility)
 	if data == shop.CallbackShopCancel {
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-		caption := shop.FormatShopMessage(balance)
+		caption := h.shopMessage(ctx, sender.ID, balance)
 		markup := shop.BuildShopPanel()
 		if err := h.editShopPhoto(c, caption, markup); err != nil {
 			log.Error().Err(err).Msg("Failed to edit shop photo")
@@ -202,14 +318,15 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 		}
 
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
+		quote := h.shopService.QuotePrice(ctx, item)
 		
 		// Get daily purchase count for items with daily limit
 		var caption string
 		if item.HasDailyLimit() {
 			_, dailyCount, _ := h.shopService.CheckDailyLimit(ctx, sender.ID, itemType)
-			caption = shop.FormatItemDetailWithDailyCount(item, balance, dailyCount)
+			caption = shop.FormatItemDetailWithDailyCount(item, quote, balance, dailyCount)
 		} else {
-			caption = shop.FormatItemDetail(item, balance)
+			caption = shop.FormatItemDetail(item, quote, balance)
 		}
 		
 		markup := shop.BuildConfirmPanel(itemType)
@@ -244,6 +361,12 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 					ShowAlert: true,
 				})
 			}
+			if errors.Is(err, service.ErrInventoryFull) || errors.Is(err, service.ErrMaxItemTypesReached) {
+				return c.Respond(&tele.CallbackResponse{
+					Text:      "❌ " + err.Error(),
+					ShowAlert: true,
+				})
+			}
 			log.Error().Err(err).Int64("user_id", sender.ID).Str("item", string(itemType)).Msg("Purchase failed")
 			return c.Respond(&tele.CallbackResponse{
 				Text:      "❌ 购买失败，请稍后重试",
@@ -257,15 +380,16 @@ func (h *ShopHandler) HandleShopCallback(c tele.Context) error {
 		})
 
 		balance, _ := h.accountService.GetBalance(ctx, sender.ID)
+		quotes := h.shopService.PriceQuotes(ctx)
 		
 		// Return to the appropriate category
 		if item.Category == shop.CategoryAttack {
-			caption := shop.FormatAttackItemsMessage(balance)
-			markup := shop.BuildAttackItemsPanel()
+			caption := shop.FormatAttackItemsMessage(balance, quotes)
+			markup := shop.BuildAttackItemsPanel(quotes)
 			h.editShopPhoto(c, caption, markup)
 		} else {
-			caption := shop.FormatDefenseItemsMessage(balance)
-			markup := shop.BuildDefenseItemsPanel()
+			caption := shop.FormatDefenseItemsMessage(balance, quotes)
+			markup := shop.BuildDefenseItemsPanel(quotes)
 			h.editShopPhoto(c, caption, markup)
 		}
 		return nil
@@ -289,14 +413,31 @@ func (h *ShopHandler) HandleBag(c tele.Context) error {
 		return c.Reply("❌ 请私聊机器人查看背包")
 	}
 
-	balance, _ := h.accountService.GetBalance(ctx, sender.ID)
-	inventory, err := h.shopService.GetUserInventory(ctx, sender.ID)
+	msg, markup, err := h.bagView(ctx, sender.ID)
 	if err != nil {
 		return c.Reply("❌ 获取背包失败")
 	}
+	return c.Reply(msg, markup)
+}
+
+// bagView builds the inventory message and its sell-back panel
+func (h *ShopHandler) bagView(ctx context.Context, userID int64) (string, *tele.ReplyMarkup, error) {
+	balance, _ := h.accountService.GetBalance(ctx, userID)
+	inventory, err := h.shopService.GetUserInventory(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	effects := inventoryEffectInfos(inventory)
+	msg := shop.FormatInventoryMessage(balance, inventory.HandcuffCount, effects)
+	return msg, shop.BuildBagPanel(h.shopService.SellBackOptions(ctx, inventory)), nil
+}
 
-	// Convert items to display format (use count based)
+// inventoryEffectInfos converts inventory items and timed effects to display format
+func inventoryEffectInfos(inventory *service.UserInventory) []shop.EffectInfo {
 	var effects []shop.EffectInfo
+
+	// Use count based items
 	for _, item := range inventory.Items {
 		// Skip handcuffs as they are shown separately
 		if item.ItemType == string(shop.ItemHandcuff) {
@@ -304,12 +445,20 @@ func (h *ShopHandler) HandleBag(c tele.Context) error {
 		}
 		effects = append(effects, shop.EffectInfo{
 			EffectType:   item.ItemType,
-			RemainingStr: shop.FormatUseCount(item.UseCount),
+			RemainingStr: shop.FormatUseCountWithExpiry(item.UseCount, item.ExpiresAt, time.Now()),
 		})
 	}
 
-	msg := shop.FormatInventoryMessage(balance, inventory.HandcuffCount, effects)
-	return c.Reply(msg)
+	// Duration based effects
+	for _, effect := range inventory.Effects {
+		remaining := int64(time.Until(effect.ExpiresAt).Seconds())
+		effects = append(effects, shop.EffectInfo{
+			EffectType:   effect.EffectType,
+			RemainingStr: "剩余" + shop.FormatRemainingTime(remaining),
+		})
+	}
+
+	return effects
 }
 
 // HandleHandcuff handles /handcuff command
@@ -338,12 +487,15 @@ func (h *ShopHandler) HandleHandcuff(c tele.Context) error {
 
 	if c.Message().ReplyTo != nil && c.Message().ReplyTo.Sender != nil {
 		targetID = c.Message().ReplyTo.Sender.ID
-		targetName = c.Message().ReplyTo.Sender.Username
-		if targetName == "" {
-			targetName = c.Message().ReplyTo.Sender.FirstName
+		targetName = senderName(c.Message().ReplyTo.Sender)
+	} else if args := c.Args(); len(args) > 0 && isHandleArg(args[0]) {
+		id, name, ok := resolveHandleTarget(ctx, c, h.accountService, args[0])
+		if !ok {
+			return nil
 		}
+		targetID, targetName = id, name
 	} else {
-		return c.Reply("❌ 请回复目标用户的消息来使用手铐")
+		return c.Reply("❌ 请回复目标用户的消息，或使用 /handcuff #编号 来使用手铐")
 	}
 
 	// Use handcuff
@@ -358,6 +510,9 @@ func (h *ShopHandler) HandleHandcuff(c tele.Context) error {
 		if errors.Is(err, service.ErrAlreadyLocked) {
 			return c.Reply("❌ 目标已被锁定")
 		}
+		if errors.Is(err, service.ErrHandcuffOptedOut) || errors.Is(err, service.ErrTargetOptedOut) {
+			return c.Reply("🕊️ " + err.Error())
+		}
 		if errors.Is(err, service.ErrNoHandcuff) {
 			return nil // Silent ignore
 		}
@@ -366,12 +521,9 @@ func (h *ShopHandler) HandleHandcuff(c tele.Context) error {
 	}
 
 	// Get username
-	username := sender.Username
-	if username == "" {
-		username = sender.FirstName
-	}
+	username := senderName(sender)
 
-	return c.Reply("🔗 " + username + " 对 " + targetName + " 使用了手铐！\n⏱️ 锁定时间: 30分钟\n🚫 " + targetName + " 无法打劫任何人")
+	return c.Reply("🔗 " + username + " 对 " + targetName + " 使用了手铐！\n⏱️ 锁定时间: 30分钟\n🚫 " + targetName + " 无法打劫任何人\n💪 被锁定者可发送 /struggle 尝试挣脱")
 }
 
 // HandleKey handles /key command to unlock self from handcuffs
@@ -406,10 +558,139 @@ func (h *ShopHandler) HandleKey(c tele.Context) error {
 	}
 
 	// Get username
-	username := sender.Username
-	if username == "" {
-		username = sender.FirstName
-	}
+	username := senderName(sender)
 
 	return c.Reply("🔑 " + username + " 使用钥匙解开了手铐！\n✅ 你现在可以自由行动了")
 }
+
+// HandleStruggle handles /struggle: a paid chance for a handcuffed user to break free early
+func (h *ShopHandler) HandleStruggle(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+	username := senderName(sender)
+
+	result, err := h.shopService.Struggle(ctx, sender.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotLocked):
+			return c.Reply("❌ 你没有被锁定")
+		case errors.Is(err, service.ErrStruggleCooldown):
+			return c.Reply(fmt.Sprintf("⏳ 手腕还在发麻，%s 后才能再次挣扎\n🔗 手铐剩余 %s",
+				cooldown.Format(result.Wait), cooldown.Format(result.Remaining)))
+		case errors.Is(err, service.ErrStruggleFee):
+			return c.Reply(fmt.Sprintf("❌ 挣扎需要 %d 金币，余额不足", service.StruggleFee))
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Struggle failed")
+		return c.Reply("❌ 挣扎失败，请稍后重试")
+	}
+
+	if result.Freed {
+		return c.Reply(fmt.Sprintf("💪 %s 拼命挣扎（第 %d 次），挣脱了手铐！\n✅ 你现在可以自由行动了\n💰 花费 %d 金币，余额 %d",
+			username, result.Attempts, service.StruggleFee, result.Balance))
+	}
+	return c.Reply(fmt.Sprintf("😣 %s 挣扎了一番（第 %d 次），手铐纹丝不动\n🔗 手铐剩余 %s，%s 后可再次挣扎（%d%% 成功率）\n💰 花费 %d 金币，余额 %d",
+		username, result.Attempts, cooldown.Format(result.Remaining), cooldown.Format(service.StruggleCooldown),
+		service.StruggleChance, service.StruggleFee, result.Balance))
+}
+
+// HandleSale handles the /sale command (admin only).
+// Formats: /sale | /sale <道具|all> <折扣%> <分钟> | /sale off <道具|all>
+func (h *ShopHandler) HandleSale(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	switch {
+	case len(args) == 0:
+		return h.replySales(ctx, c)
+
+	case len(args) == 2 && args[0] == "off":
+		if err := h.shopService.EndSale(ctx, sender.ID, args[1]); err != nil {
+			return h.replySaleError(c, err)
+		}
+		return c.Reply("✅ 特惠已结束")
+
+	case len(args) == 3:
+		percent, errP := strconv.Atoi(strings.TrimSuffix(args[1], "%"))
+		minutes, errM := strconv.Atoi(args[2])
+		if errP != nil || errM != nil {
+			break
+		}
+		sale, err := h.shopService.StartSale(ctx, sender.ID, args[0], percent, time.Duration(minutes)*time.Minute)
+		if err != nil {
+			return h.replySaleError(c, err)
+		}
+		return c.Reply(fmt.Sprintf("✅ 限时特惠已开启\n%s 减 %d%%\n截止: %s",
+			formatSaleTarget(sale.Target), sale.DiscountPercent, sale.EndsAt.Format("01-02 15:04")))
+	}
+
+	return c.Reply("❌ 用法:\n/sale - 查看进行中的特惠\n/sale <道具|all> <折扣%> <分钟> - 开启特惠\n/sale off <道具|all> - 结束特惠\n例如: /sale 保护罩 30 60")
+}
+
+// replySales lists the running flash sales
+func (h *ShopHandler) replySales(ctx context.Context, c tele.Context) error {
+	sales, err := h.shopService.GetActiveSales(ctx)
+	if err != nil {
+		return h.replySaleError(c, err)
+	}
+	if len(sales) == 0 {
+		return c.Reply("📭 当前没有进行中的特惠")
+	}
+
+	msg := "🔥 进行中的特惠\n\n"
+	for _, sale := range sales {
+		msg += fmt.Sprintf("%s 减 %d%% (截止 %s)\n",
+			formatSaleTarget(sale.Target), sale.DiscountPercent, sale.EndsAt.Format("01-02 15:04"))
+	}
+	return c.Reply(msg)
+}
+
+// replySaleError replies with a user facing sale error
+func (h *ShopHandler) replySaleError(c tele.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrPricingDisabled),
+		errors.Is(err, service.ErrSaleInvalidTarget),
+		errors.Is(err, service.ErrSaleInvalidPercent),
+		errors.Is(err, service.ErrSaleInvalidLength),
+		errors.Is(err, service.ErrSaleNotFound):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Msg("Sale operation failed")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
+
+// formatSaleTarget returns the display name of a sale target
+func formatSaleTarget(target string) string {
+	if target == shop.AllItemsSale {
+		return "全部道具"
+	}
+	if item, ok := shop.GetItem(shop.ItemType(target)); ok {
+		return item.Emoji + " " + item.Name
+	}
+	return target
+}
+
+// formatItemsLeft formats the uses left of the items a robbery consumed, one
+// line per player, e.g. "\n🎒 张三: 🔪钝刀剩余2次". names holds the names shown.
+func formatItemsLeft(uses []shop.ItemUse, names map[int64]string) string {
+	var order []int64
+	byUser := make(map[int64][]shop.ItemUse)
+	for _, use := range uses {
+		if _, ok := byUser[use.UserID]; !ok {
+			order = append(order, use.UserID)
+		}
+		byUser[use.UserID] = append(byUser[use.UserID], use)
+	}
+
+	var b strings.Builder
+	for _, userID := range order {
+		fmt.Fprintf(&b, "\n🎒 %s: %s", names[userID], shop.FormatItemUses(byUser[userID]))
+	}
+	return b.String()
+}
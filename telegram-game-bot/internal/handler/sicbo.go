@@ -0,0 +1,766 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/shard"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/service"
+)
+
+// SicBoHandler handles SicBo sessions: betting panels and text bets, the
+// coordinator settling them, automatic rounds and forced recovery.
+type SicBoHandler struct {
+	*BaseHandler
+	sicboGame      *sicbo.SicBoGame
+	sicboCoord     *sicboCoordinator
+	whitelist      ChatAllower // Chats where automatic rounds start, the config's by default
+	sicboAuto      *service.SicBoAutoService
+	sicboSummaries *service.SicBoSummaryService // Optional: personal SicBo settlement DMs
+	shards         *shard.Set                   // Optional: chats processed by this instance (nil = all)
+	maintenance    *service.MaintenanceService  // Optional: pauses automatic rounds before maintenance
+	userBetAmounts sync.Map                     // map[int64]int64 - userID -> selected bet amount
+}
+
+// NewSicBoHandler creates a new SicBoHandler.
+func NewSicBoHandler(base *BaseHandler, sicboGame *sicbo.SicBoGame) *SicBoHandler {
+	return &SicBoHandler{
+		BaseHandler: base,
+		sicboGame:   sicboGame,
+		sicboCoord:  newSicBoCoordinator(),
+		whitelist:   base.cfg,
+	}
+}
+
+// SetSicBoSummaries sets the service DMing opted in players their SicBo results
+func (h *SicBoHandler) SetSicBoSummaries(summaries *service.SicBoSummaryService) {
+	h.sicboSummaries = summaries
+}
+
+// SetWhitelist sets the whitelist consulted before starting automatic rounds
+func (h *SicBoHandler) SetWhitelist(whitelist ChatAllower) {
+	h.whitelist = whitelist
+}
+
+// HandleSicBoStart handles the /sicbo command to start a new game session.
+// Requirements: 5.1
+func (h *SicBoHandler) HandleSicBoStart(c tele.Context) error {
+	ctx := RequestContext(c)
+	chat := c.Chat()
+	sender := c.Sender()
+
+	if chat == nil || sender == nil {
+		return nil
+	}
+
+	// Only allow in group chats
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 骰宝游戏只能在群组中进行")
+	}
+
+	// Check if session already exists
+	threadID := topicOf(c)
+	key := h.sicboKey(chat.ID, threadID)
+	if h.sicboGame.IsSessionActive(key) {
+		remaining := h.sicboGame.GetSessionTimeRemaining(key)
+		if remaining == 0 {
+			return c.Reply("❌ 当前游戏正在结算，如长时间未出结果，群管理员可使用 /sicbo_force 处理")
+		}
+		return c.Reply(fmt.Sprintf("❌ 当前已有进行中的游戏，剩余 %d 秒", remaining))
+	}
+
+	if err := h.startSicBoSession(ctx, c.Bot(), chat, key, threadID, sender.ID); err != nil {
+		if errors.Is(err, sicbo.ErrSessionExists) {
+			return c.Reply("❌ 当前已有进行中的游戏")
+		}
+		return c.Reply("❌ 启动游戏失败，请稍后重试")
+	}
+	return nil
+}
+
+// sicboKey returns the session a command in a forum topic plays in: the
+// topic's own session if topics play separately, the chat's otherwise.
+func (h *SicBoHandler) sicboKey(chatID int64, threadID int) sicbo.SessionKey {
+	if h.cfg.Games.SicBo.PerTopic {
+		return sicbo.SessionKey{ChatID: chatID, ThreadID: threadID}
+	}
+	return sicbo.SessionKey{ChatID: chatID}
+}
+
+// startSicBoSession starts a session and sends its betting panel to the topic threadID.
+// starterID is 0 for rounds started automatically.
+func (h *SicBoHandler) startSicBoSession(ctx context.Context, bot *tele.Bot, chat *tele.Chat, key sicbo.SessionKey, threadID int, starterID int64) error {
+	duration := h.cfg.Games.SicBo.BettingDurationSeconds
+	if duration < minSicBoDuration {
+		log.Warn().Int("configured", duration).Msg("SicBo betting duration not configured or too short, using default 60 seconds")
+		duration = sicbo.DefaultBettingDuration
+	}
+
+	log.Info().
+		Int64("chat_id", chat.ID).
+		Int("thread_id", key.ThreadID).
+		Int64("starter_id", starterID).
+		Int("duration", duration).
+		Msg("Starting SicBo session")
+
+	if err := h.sicboGame.StartSession(ctx, key, starterID, duration); err != nil {
+		return err
+	}
+
+	// Build keyboard with early settle button (only starter sees it)
+	kb := sicbo.NewKeyboardBuilder()
+	markup := kb.BuildMainPanelWithSettle()
+
+	// Send betting panel
+	msg := sicbo.FormatPanelMessage(duration, 0, 0)
+	panelMsgID := 0
+	panelMsg, err := bot.Send(chat, msg, topicOptions(threadID), markup)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to send sicbo panel")
+	} else {
+		h.trackMessage(chat.ID, panelMsg.ID)
+		panelMsgID = panelMsg.ID
+	}
+
+	// The coordinator refreshes the panel and settles when betting ends
+	h.sicboCoord.track(key, threadID, panelMsgID, time.Now())
+
+	return nil
+}
+
+// HandleSicBoSettle handles the /sicbo_settle command to manually settle the game.
+func (h *SicBoHandler) HandleSicBoSettle(c tele.Context) error {
+	ctx := RequestContext(c)
+	chat := c.Chat()
+
+	if chat == nil {
+		return nil
+	}
+
+	threadID := topicOf(c)
+	key := h.sicboKey(chat.ID, threadID)
+	if !h.sicboGame.IsSessionActive(key) {
+		return c.Reply("❌ 当前没有进行中的游戏")
+	}
+
+	return h.settleSicBo(ctx, key, threadID, c.Bot())
+}
+
+// settleSicBo settles the SicBo game and sends results to the topic threadID.
+func (h *SicBoHandler) settleSicBo(ctx context.Context, key sicbo.SessionKey, threadID int, bot *tele.Bot) error {
+	chatID := key.ChatID
+	scope := h.balanceScope(ctx, chatID)
+
+	// Get all bets before settling
+	bets, err := h.sicboGame.GetSessionBets(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to get session bets")
+		return err
+	}
+
+	// Get starter info before settling (session will be deleted after settle)
+	starterID := h.sicboGame.GetSessionStarterID(key)
+	starterUsername := ""
+	if starterID != 0 {
+		starterUser, err := h.accountService.GetUser(ctx, starterID)
+		if err == nil && starterUser != nil {
+			starterUsername = textfilter.Name(starterUser.Username)
+		}
+	}
+
+	// Rounds without bets pause automatic starts, which only play the chat's session
+	if h.sicboAuto != nil && key.ThreadID == 0 {
+		h.sicboAuto.RoundFinished(chatID, len(bets) > 0)
+	}
+
+	// Settle the game
+	h.sicboCoord.cancel(key)
+	payouts, details, err := h.sicboGame.Settle(ctx, key)
+	if errors.Is(err, sicbo.ErrQuorumNotMet) {
+		h.voidSicBo(ctx, chatID, threadID, payouts, details, bot)
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to settle sicbo game")
+		return err
+	}
+
+	// Get dice results
+	diceArr, ok := details["dice"].([3]int)
+	if !ok {
+		log.Error().Msg("Invalid dice result type")
+		// The session is gone and bets were already deducted, so refund them
+		var claims []service.CompensationClaim
+		for userID, userBets := range bets {
+			for _, amount := range userBets {
+				claims = append(claims, service.CompensationClaim{UserID: userID, Amount: amount})
+			}
+		}
+		h.reportIncidentIn(scope, service.IncidentSicBoSettleFailed, fmt.Sprintf("群 %d 骰宝结算结果无效", chatID), claims...)
+		return errors.New("invalid dice result")
+	}
+
+	var failedCredits []service.CompensationClaim
+
+	// Process payouts and build results
+	playerResults := make(map[int64]sicbo.PlayerResult)
+	for userID, netPayout := range payouts {
+		// Calculate total bet for this user
+		var totalBet int64
+		if userBets, ok := bets[userID]; ok {
+			for _, amount := range userBets {
+				totalBet += amount
+			}
+		}
+
+		// Get username (we'll need to look this up)
+		user, err := h.accountService.GetUser(ctx, userID)
+		username := ""
+		if err == nil && user != nil {
+			username = textfilter.Name(user.Username)
+		}
+
+		playerResults[userID] = sicbo.PlayerResult{
+			UserID:      userID,
+			Username:    username,
+			TotalBet:    totalBet,
+			TotalPayout: netPayout,
+		}
+
+		// Update user balance
+		// Note: Bet amount was already deducted when placing the bet
+		// netPayout is the net result: positive = win, negative = loss
+		// For wins: we need to credit (bet + winnings) = totalBet + netPayout
+		// For losses: netPayout is negative, but bet was already deducted, so we don't deduct again
+		//
+		// Example: User bets 100 on "big", dice shows 12 (big wins)
+		//   - At bet time: -100 deducted
+		//   - netPayout = +100 (1:1 payout)
+		//   - Credit amount = totalBet + netPayout = 100 + 100 = 200
+		//   - Final: -100 + 200 = +100 net gain ✓
+		//
+		// Example: User bets 100 on "big", dice shows 8 (big loses)
+		//   - At bet time: -100 deducted
+		//   - netPayout = -100 (loss)
+		//   - Since netPayout < 0, we don't credit anything (bet already lost)
+		//   - Final: -100 net loss ✓
+
+		if netPayout > 0 {
+			// User won - credit bet amount + winnings
+			creditAmount := totalBet + netPayout
+			h.userLock.Lock(userID)
+			desc := fmt.Sprintf("骰宝赢得 %d (本金 %d + 盈利 %d)", creditAmount, totalBet, netPayout)
+			if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, creditAmount, model.TxTypeSicBoWin, &desc); err != nil {
+				failedCredits = append(failedCredits, service.CompensationClaim{UserID: userID, Amount: creditAmount})
+			}
+			h.userLock.Unlock(userID)
+			h.recordWin(chatID, user, netPayout)
+		}
+		// If netPayout <= 0, user lost - bet was already deducted, nothing more to do
+	}
+
+	// Winnings that could not be credited are batched into one incident
+	h.reportIncidentIn(scope, service.IncidentCreditFailed, fmt.Sprintf("群 %d 骰宝奖金到账失败", chatID), failedCredits...)
+
+	// Format and send settlement message
+	names := playerNames(h.chatPersona(ctx, chatID))
+	msg := sicbo.FormatSettlementMessage(diceArr, playerResults, starterID, starterUsername, names)
+	if scope.IsSandbox() {
+		msg = sandboxBanner + "\n" + msg
+	}
+
+	// Send result to chat
+	if bot != nil {
+		chat := &tele.Chat{ID: chatID}
+		_, err = bot.Send(chat, msg, topicOptions(threadID), tele.ModeHTML)
+		if err != nil {
+			log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send sicbo settlement message")
+		}
+	}
+
+	// Opted in players get their own lines by DM; play money rounds are not summarized
+	if h.sicboSummaries != nil && !scope.IsSandbox() {
+		go h.sicboSummaries.EnqueueSummaries(context.Background(), diceArr, bets, func(userID int64) (int64, error) {
+			return h.accountService.GetBalance(context.Background(), userID)
+		})
+	}
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Interface("dice", diceArr).
+		Interface("payouts", payouts).
+		Msg("SicBo game settled")
+
+	return nil
+}
+
+// voidSicBo refunds the stakes of a round without enough players and announces it in the topic threadID.
+// Refunds that fail are reported for compensation.
+func (h *SicBoHandler) voidSicBo(ctx context.Context, chatID int64, threadID int, refunds map[int64]int64, details map[string]any, bot *tele.Bot) {
+	h.refundSicBoBets(ctx, chatID, refunds, "人数不足", "作废")
+
+	players, _ := details["players"].(int)
+	minPlayers, _ := details["min_players"].(int)
+	if bot != nil {
+		if _, err := bot.Send(&tele.Chat{ID: chatID}, sicbo.FormatVoidMessage(players, minPlayers), topicOptions(threadID)); err != nil {
+			log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send sicbo void message")
+		}
+	}
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int("players", players).
+		Int("min_players", minPlayers).
+		Interface("refunds", refunds).
+		Msg("SicBo round void, bets refunded")
+}
+
+// refundSicBoBets returns the stakes of a round that was not rolled.
+// reason ends up in the transaction description, outcome in the incident of
+// refunds that fail.
+func (h *SicBoHandler) refundSicBoBets(ctx context.Context, chatID int64, refunds map[int64]int64, reason, outcome string) {
+	scope := h.balanceScope(ctx, chatID)
+	var failedRefunds []service.CompensationClaim
+	for userID, amount := range refunds {
+		if amount <= 0 {
+			continue
+		}
+		desc := fmt.Sprintf("骰宝%s，退还下注 %d", reason, amount)
+		h.userLock.Lock(userID)
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, userID, amount, model.TxTypeSicBoBet, &desc); err != nil {
+			failedRefunds = append(failedRefunds, service.CompensationClaim{UserID: userID, Amount: amount})
+		}
+		h.userLock.Unlock(userID)
+	}
+	h.reportIncidentIn(scope, service.IncidentRefundFailed, fmt.Sprintf("群 %d 骰宝%s退还下注失败", chatID, outcome), failedRefunds...)
+}
+
+// HandleSicBoCallback handles SicBo inline button callbacks.
+// Requirements: 5.2, 5.6, 5.8
+func (h *SicBoHandler) HandleSicBoCallback(c tele.Context) error {
+	ctx := RequestContext(c)
+	callback := c.Callback()
+	sender := c.Sender()
+	chat := c.Chat()
+
+	if callback == nil || sender == nil || chat == nil {
+		return nil
+	}
+
+	// Parse callback data
+	action, param := sicbo.DecodeCallback(callback.Data)
+
+	// Debug logging
+	log.Debug().
+		Str("raw_data", callback.Data).
+		Str("action", action).
+		Str("param", param).
+		Int64("user_id", sender.ID).
+		Int64("chat_id", chat.ID).
+		Msg("SicBo callback received")
+
+	if action == "" {
+		return c.Respond(&tele.CallbackResponse{
+			Text: "❌ 无效操作",
+		})
+	}
+
+	// Buttons act on the session of the topic the panel was sent to
+	key := h.sicboKey(chat.ID, topicOf(c))
+
+	// Handle early settle action
+	if action == "early_settle" {
+		// Check if user is the session starter
+		starterID := h.sicboGame.GetSessionStarterID(key)
+
+		// Debug logging for starter check
+		log.Debug().
+			Int64("starter_id", starterID).
+			Int64("sender_id", sender.ID).
+			Int64("chat_id", chat.ID).
+			Bool("is_starter", starterID == sender.ID).
+			Msg("Early settle check")
+
+		if starterID != sender.ID {
+			return c.Respond(&tele.CallbackResponse{
+				Text:      fmt.Sprintf("❌ 只有发起者可以提前开奖 (发起者ID: %d, 你的ID: %d)", starterID, sender.ID),
+				ShowAlert: true,
+			})
+		}
+
+		// Check if session is active
+		if !h.sicboGame.IsSessionActive(key) {
+			return c.Respond(&tele.CallbackResponse{
+				Text:      "❌ 游戏已结束",
+				ShowAlert: true,
+			})
+		}
+
+		// The coordinator rolls the dice and settles on its next tick
+		if !h.sicboCoord.requestSettle(key) {
+			return c.Respond(&tele.CallbackResponse{
+				Text: "🎲 正在开奖中...",
+			})
+		}
+		return c.Respond(&tele.CallbackResponse{
+			Text: "🎲 开始开奖...",
+		})
+	}
+
+	// Check if session is active
+	if !h.sicboGame.IsSessionActive(key) {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 游戏已结束",
+			ShowAlert: true,
+		})
+	}
+
+	scope := h.balanceScope(ctx, chat.ID)
+
+	// Handle amount selection
+	if action == "amount" {
+		var selectedAmount int64
+		if param == "allin" {
+			// 梭哈：获取用户当前余额
+			balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+			if err != nil {
+				return c.Respond(&tele.CallbackResponse{
+					Text:      "❌ 获取余额失败",
+					ShowAlert: true,
+				})
+			}
+			selectedAmount = balance
+			h.userBetAmounts.Store(sender.ID, selectedAmount)
+			return c.Respond(&tele.CallbackResponse{
+				Text: fmt.Sprintf("🔥 已选择梭哈！下注金额: %d 金币\n请点击押注按钮下注", selectedAmount),
+			})
+		} else {
+			// 固定金额选择
+			amount, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				return c.Respond(&tele.CallbackResponse{
+					Text: "❌ 无效金额",
+				})
+			}
+			selectedAmount = amount
+			h.userBetAmounts.Store(sender.ID, selectedAmount)
+			return c.Respond(&tele.CallbackResponse{
+				Text: fmt.Sprintf("💰 已选择下注金额: %d 金币\n请点击押注按钮下注", selectedAmount),
+			})
+		}
+	}
+
+	// Split buttons spread the selected amount over several options
+	if action == "split" {
+		return h.placeSplitBet(ctx, c, key, scope, param)
+	}
+
+	// Determine bet type
+	var betType string
+	switch action {
+	case "single":
+		betType = param // "1", "2", etc.
+	case "big":
+		betType = "big"
+	case "small":
+		betType = "small"
+	default:
+		return c.Respond(&tele.CallbackResponse{
+			Text: "❌ 无效操作",
+		})
+	}
+
+	// Ensure user exists
+	username := senderName(sender)
+	_, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 操作失败",
+			ShowAlert: true,
+		})
+	}
+
+	betAmount := h.selectedBetAmount(sender.ID)
+
+	// Validate bet amount
+	if betAmount <= 0 {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 请先选择下注金额",
+			ShowAlert: true,
+		})
+	}
+
+	// Check balance
+	h.userLock.Lock(sender.ID)
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+	if err != nil {
+		h.userLock.Unlock(sender.ID)
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 获取余额失败",
+			ShowAlert: true,
+		})
+	}
+
+	if balance < betAmount {
+		h.userLock.Unlock(sender.ID)
+		return c.Respond(&tele.CallbackResponse{
+			Text:      fmt.Sprintf("❌ 下注失败，余额不足（需要 %d，当前 %d）", betAmount, balance),
+			ShowAlert: true,
+		})
+	}
+
+	// Deduct bet amount
+	desc := fmt.Sprintf("骰宝下注 %s", betType)
+	_, err = h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -betAmount, model.TxTypeSicBoBet, &desc)
+	h.userLock.Unlock(sender.ID)
+
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 扣款失败",
+			ShowAlert: true,
+		})
+	}
+
+	// Place bet
+	err = h.sicboGame.PlaceBet(ctx, key, sender.ID, betType, betAmount)
+	if err != nil {
+		// Refund on error
+		h.userLock.Lock(sender.ID)
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, betAmount, model.TxTypeSicBoBet, nil); err != nil {
+			h.reportIncidentIn(scope, service.IncidentRefundFailed, "骰宝下注失败后退还失败",
+				service.CompensationClaim{UserID: sender.ID, Amount: betAmount})
+		}
+		h.userLock.Unlock(sender.ID)
+
+		if errors.Is(err, sicbo.ErrBettingEnded) {
+			return c.Respond(&tele.CallbackResponse{
+				Text:      "❌ 下注时间已结束",
+				ShowAlert: true,
+			})
+		}
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 下注失败",
+			ShowAlert: true,
+		})
+	}
+
+	h.recordWager(chat.ID, betAmount)
+
+	betName := sicbo.BetTypeName(betType)
+
+	// Don't refresh panel on every bet - let the 15s timer handle it
+	// This reduces API calls and makes the UI less jumpy
+
+	return c.Respond(&tele.CallbackResponse{
+		Text: fmt.Sprintf("✅ 已下注 %s: %d %s", betName, betAmount, coinName(scope)),
+	})
+}
+
+// selectedBetAmount returns the amount a user selected on the panel, 100 if none
+func (h *SicBoHandler) selectedBetAmount(userID int64) int64 {
+	if storedAmount, ok := h.userBetAmounts.Load(userID); ok {
+		return storedAmount.(int64)
+	}
+	return 100
+}
+
+// placeSplitBet spreads the selected amount evenly over the options of a
+// split button. The slips are checked together against the balance and the
+// bet cap, paid with a single deduction and placed all at once.
+func (h *SicBoHandler) placeSplitBet(ctx context.Context, c tele.Context, key sicbo.SessionKey, scope model.BalanceScope, param string) error {
+	sender := c.Sender()
+	betTypes, err := sicbo.ParseSplit(param)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, senderName(sender)); err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 操作失败", ShowAlert: true})
+	}
+
+	total := h.selectedBetAmount(sender.ID)
+	if total <= 0 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 请先选择下注金额", ShowAlert: true})
+	}
+	slips, err := sicbo.SplitBet(total, betTypes)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      fmt.Sprintf("❌ 下注金额 %d 不够均分到 %d 个选项", total, len(betTypes)),
+			ShowAlert: true,
+		})
+	}
+
+	h.userLock.Lock(sender.ID)
+	defer h.userLock.Unlock(sender.ID)
+
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 获取余额失败", ShowAlert: true})
+	}
+	if balance < total {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      fmt.Sprintf("❌ 下注失败，余额不足（需要 %d，当前 %d）", total, balance),
+			ShowAlert: true,
+		})
+	}
+	// The split is capped like a single dice bet, the same as a text bet message
+	if maxBet := h.getEffectiveMaxBet(balance, h.cfg.Games.Dice.MaxBet); total > maxBet {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      fmt.Sprintf("❌ 均分下注合计不能超过 %d", maxBet),
+			ShowAlert: true,
+		})
+	}
+
+	names := make([]string, len(slips))
+	for i, slip := range slips {
+		names[i] = fmt.Sprintf("%s %d", sicbo.BetTypeName(slip.BetType), slip.Amount)
+	}
+	desc := fmt.Sprintf("骰宝均分下注 %s", strings.Join(names, " / "))
+	if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -total, model.TxTypeSicBoBet, &desc); err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 扣款失败", ShowAlert: true})
+	}
+
+	if err := h.sicboGame.PlaceBets(ctx, key, sender.ID, slips); err != nil {
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, total, model.TxTypeSicBoBet, nil); err != nil {
+			h.reportIncidentIn(scope, service.IncidentRefundFailed, "骰宝均分下注失败后退还失败",
+				service.CompensationClaim{UserID: sender.ID, Amount: total})
+		}
+		if errors.Is(err, sicbo.ErrBettingEnded) {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 下注时间已结束", ShowAlert: true})
+		}
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 下注失败", ShowAlert: true})
+	}
+
+	h.recordWager(key.ChatID, total)
+	return c.Respond(&tele.CallbackResponse{
+		Text: fmt.Sprintf("✅ 已均分下注 %d %s: %s", total, coinName(scope), strings.Join(names, " / ")),
+	})
+}
+
+// Reactions used to confirm text bets instead of reply messages
+const (
+	textBetAcceptedReaction = "👍"
+	textBetRejectedReaction = "👎"
+)
+
+// HandleSicBoTextBet handles text bets sent as replies to the sicbo panel.
+// Format: "大 500", "小 200", "3 200"; several slips can be separated by commas or new lines.
+// Messages that are not replies to the active panel, or do not parse as bets, are ignored.
+func (h *SicBoHandler) HandleSicBoTextBet(c tele.Context) error {
+	ctx := RequestContext(c)
+	msg := c.Message()
+	sender := c.Sender()
+	chat := c.Chat()
+	if msg == nil || sender == nil || chat == nil || msg.ReplyTo == nil {
+		return nil
+	}
+
+	key := h.sicboKey(chat.ID, topicOf(c))
+	panelMsgID, ok := h.sicboCoord.panelID(key)
+	if !ok || panelMsgID != msg.ReplyTo.ID || !h.sicboGame.IsSessionActive(key) {
+		return nil
+	}
+
+	bets, err := sicbo.ParseTextBets(msg.Text)
+	if err != nil {
+		return nil
+	}
+
+	username := senderName(sender)
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, username); err != nil {
+		return h.reactTextBet(c, false)
+	}
+
+	scope := h.balanceScope(ctx, chat.ID)
+	h.userLock.Lock(sender.ID)
+	defer h.userLock.Unlock(sender.ID)
+
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+	if err != nil {
+		return h.reactTextBet(c, false)
+	}
+
+	// The whole message is capped like a single dice bet and must be covered by the balance
+	var total int64
+	for _, bet := range bets {
+		total += bet.Amount
+	}
+	if total > balance || total > h.getEffectiveMaxBet(balance, h.cfg.Games.Dice.MaxBet) {
+		return h.reactTextBet(c, false)
+	}
+
+	// Slips are placed in order; slips placed before a failure stay placed
+	for _, bet := range bets {
+		desc := fmt.Sprintf("骰宝下注 %s", bet.BetType)
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -bet.Amount, model.TxTypeSicBoBet, &desc); err != nil {
+			return h.reactTextBet(c, false)
+		}
+
+		if err := h.sicboGame.PlaceBet(ctx, key, sender.ID, bet.BetType, bet.Amount); err != nil {
+			if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, bet.Amount, model.TxTypeSicBoBet, nil); err != nil {
+				h.reportIncidentIn(scope, service.IncidentRefundFailed, "骰宝文字下注失败后退还失败",
+					service.CompensationClaim{UserID: sender.ID, Amount: bet.Amount})
+			}
+			return h.reactTextBet(c, false)
+		}
+		h.recordWager(chat.ID, bet.Amount)
+	}
+
+	return h.reactTextBet(c, true)
+}
+
+// reactTextBet confirms or rejects a text bet with a reaction on the message
+func (h *SicBoHandler) reactTextBet(c tele.Context, accepted bool) error {
+	emoji := textBetRejectedReaction
+	if accepted {
+		emoji = textBetAcceptedReaction
+	}
+
+	err := c.Bot().React(c.Chat(), c.Message(), tele.ReactionOptions{
+		Reactions: []tele.Reaction{{Type: "emoji", Emoji: emoji}},
+	})
+	if err != nil {
+		log.Debug().Err(err).Int64("chat_id", c.Chat().ID).Msg("Failed to react to text bet")
+	}
+	return nil
+}
+
+// HandleMyBets handles the /mybets command to show user's current bets.
+func (h *SicBoHandler) HandleMyBets(c tele.Context) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	key := h.sicboKey(chat.ID, topicOf(c))
+	if !h.sicboGame.IsSessionActive(key) {
+		return c.Reply("❌ 当前没有进行中的游戏")
+	}
+
+	bets, err := h.sicboGame.GetSessionBets(ctx, key)
+	if err != nil {
+		return c.Reply("❌ 获取下注信息失败")
+	}
+
+	userBets, ok := bets[sender.ID]
+	if !ok || len(userBets) == 0 {
+		return c.Reply("📋 您还没有下注")
+	}
+
+	msg := sicbo.FormatMyBets(userBets)
+	return c.Reply(msg)
+}
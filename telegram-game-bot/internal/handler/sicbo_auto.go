@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/roles"
+	"telegram-game-bot/internal/pkg/shard"
+	"telegram-game-bot/internal/service"
+)
+
+// sicboAutoTickInterval is how often automatic sicbo starts are checked
+const sicboAutoTickInterval = 30 * time.Second
+
+// sicboAutoUsage explains the /sicbo_auto subcommands
+const sicboAutoUsage = "📖 用法:\n" +
+	"/sicbo_auto on <间隔分钟> [时段] - 开启自动开局\n" +
+	"/sicbo_auto off - 关闭自动开局\n\n" +
+	"例如: /sicbo_auto on 10 19-23 每晚 19 点到 23 点每 10 分钟开一局\n" +
+	"不填时段则全天开局，上一局无人下注时暂停到下一个时段"
+
+// SetShards sets the chats this instance processes; scheduled rounds of other chats are left to their instances
+func (h *SicBoHandler) SetShards(shards *shard.Set) {
+	h.shards = shards
+}
+
+// SetMaintenance sets the service that pauses automatic rounds before maintenance
+func (h *SicBoHandler) SetMaintenance(maintenance *service.MaintenanceService) {
+	h.maintenance = maintenance
+}
+
+// SetSicBoAuto sets the service that starts sicbo rounds on a schedule
+func (h *SicBoHandler) SetSicBoAuto(sicboAuto *service.SicBoAutoService) {
+	h.sicboAuto = sicboAuto
+}
+
+// StartSicBoAutoScheduler starts the loop that starts scheduled sicbo rounds.
+func (h *SicBoHandler) StartSicBoAutoScheduler(bot *tele.Bot) {
+	if h.sicboAuto == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(sicboAutoTickInterval)
+		heartbeat.Start("sicbo_auto", sicboAutoTickInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("sicbo_auto")
+			h.tickSicBoAuto(context.Background(), bot, now)
+		}
+	}()
+}
+
+// tickSicBoAuto starts the rounds due at now in chats without a running session
+func (h *SicBoHandler) tickSicBoAuto(ctx context.Context, bot *tele.Bot, now time.Time) {
+	if h.maintenance != nil && h.maintenance.BlocksNewRounds(now) {
+		return
+	}
+	for _, chatID := range h.sicboAuto.Due(ctx, now) {
+		if !h.whitelist.IsChatAllowed(chatID) || !h.shards.Owns(chatID) || h.sicboGame.IsSessionActive(sicbo.SessionKey{ChatID: chatID}) {
+			continue
+		}
+		if h.chatSettings != nil && h.chatSettings.QuietNow(chatID, now) {
+			continue
+		}
+		if err := h.startSicBoSession(ctx, bot, &tele.Chat{ID: chatID}, sicbo.SessionKey{ChatID: chatID}, 0, 0); err != nil {
+			log.Debug().Err(err).Int64("chat_id", chatID).Msg("Failed to auto-start sicbo session")
+		}
+	}
+}
+
+// HandleSicBoAuto handles the /sicbo_auto command.
+// Without arguments it shows the chat's schedule, subcommands change it (group admins only).
+func (h *SicBoHandler) HandleSicBoAuto(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 请在群组中使用此命令")
+	}
+	if h.sicboAuto == nil {
+		return c.Reply("❌ 自动开局未启用")
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		schedule, err := h.sicboAuto.Get(ctx, chat.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply(formatSicBoAuto(schedule) + "\n\n" + sicboAutoUsage)
+	}
+
+	if !hasRole(c, h.cfg, roles.ChatAdmin) {
+		return c.Reply("❌ 只有群管理员可以设置自动开局")
+	}
+
+	var err error
+	switch strings.ToLower(args[0]) {
+	case "on":
+		if len(args) < 2 {
+			return c.Reply(sicboAutoUsage)
+		}
+		interval, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return c.Reply(sicboAutoUsage)
+		}
+		startHour, endHour := 0, 0
+		if len(args) >= 3 {
+			startHour, endHour, err = service.ParseSicBoAutoHours(args[2])
+			if err != nil {
+				return c.Reply("❌ " + err.Error())
+			}
+		}
+		var schedule *model.SicBoAutoSchedule
+		schedule, err = h.sicboAuto.Enable(ctx, chat.ID, sender.ID, interval, startHour, endHour)
+		if err == nil {
+			return c.Reply("✅ 已开启\n\n" + formatSicBoAuto(schedule))
+		}
+	case "off":
+		err = h.sicboAuto.Disable(ctx, chat.ID, sender.ID)
+		if err == nil {
+			return c.Reply("✅ 已关闭自动开局")
+		}
+	default:
+		return c.Reply(sicboAutoUsage)
+	}
+
+	switch {
+	case errors.Is(err, service.ErrSicBoAutoInvalidInterval),
+		errors.Is(err, service.ErrSicBoAutoInvalidHours),
+		errors.Is(err, service.ErrSicBoAutoNotConfigured):
+		return c.Reply("❌ " + err.Error())
+	}
+	log.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to update sicbo auto schedule")
+	return c.Reply("❌ 操作失败，请稍后重试")
+}
+
+// formatSicBoAuto formats a chat's auto-start schedule
+func formatSicBoAuto(schedule *model.SicBoAutoSchedule) string {
+	if schedule == nil || !schedule.Enabled {
+		return "⏰ 骰宝自动开局: 关闭"
+	}
+	window := "全天"
+	if schedule.StartHour != schedule.EndHour {
+		window = fmt.Sprintf("%02d:00-%02d:00", schedule.StartHour, schedule.EndHour)
+	}
+	return fmt.Sprintf("⏰ 骰宝自动开局: 开启\n间隔: 每 %d 分钟\n时段: %s", schedule.IntervalMinutes, window)
+}
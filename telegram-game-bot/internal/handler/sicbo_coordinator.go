@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/tracing"
+)
+
+// SicBo coordinator timing
+const (
+	sicboTickInterval         = time.Second            // 协调器检查间隔
+	sicboPanelRefreshInterval = 15 * time.Second       // 下注面板刷新间隔
+	sicboRollLead             = 3 * time.Second        // 开奖前骰子动画时长
+	sicboRollGap              = 300 * time.Millisecond // 三颗骰子之间的间隔
+	minSicBoDuration          = 10                     // 最短下注时长（秒），避免刚开局就开奖
+)
+
+// sicboActionKind is a step the coordinator takes for a session
+type sicboActionKind int
+
+const (
+	sicboRefresh sicboActionKind = iota // 刷新下注面板
+	sicboRoll                           // 发送骰子动画
+	sicboSettle                         // 结算
+)
+
+// sicboAction is a step due for a session on a tick
+type sicboAction struct {
+	kind       sicboActionKind
+	key        sicbo.SessionKey
+	threadID   int // Forum topic the session's messages go to
+	panelMsgID int
+}
+
+// sicboSchedule is the coordinator's state of one session
+type sicboSchedule struct {
+	panelMsgID  int       // 下注面板消息ID（0表示面板未发送）
+	threadID    int       // 论坛话题ID（0表示不在话题中）
+	nextRefresh time.Time // 下次刷新面板的时间
+	settleAt    time.Time // 骰子动画已发送，到点结算（零值表示尚未掷骰）
+	settleNow   bool      // 发起者请求提前开奖
+}
+
+// sicboCoordinator schedules panel refreshes and settlement of all sicbo sessions
+// from a single loop instead of a pair of goroutines per session.
+// The schedules are reconciled with the game's active sessions on every tick:
+// sessions settled elsewhere are dropped and unknown sessions (e.g. after the
+// loop restarted) are adopted and still settled on time.
+// Rolls and settlements run in a goroutine of their own so a slow chat does not
+// hold up the others; a session is not planned while one of them is in flight.
+type sicboCoordinator struct {
+	mu        sync.Mutex
+	schedules map[sicbo.SessionKey]*sicboSchedule
+	inFlight  map[sicbo.SessionKey]bool // Sessions being rolled or settled
+}
+
+// newSicBoCoordinator creates an empty coordinator
+func newSicBoCoordinator() *sicboCoordinator {
+	return &sicboCoordinator{
+		schedules: make(map[sicbo.SessionKey]*sicboSchedule),
+		inFlight:  make(map[sicbo.SessionKey]bool),
+	}
+}
+
+// track registers a new session and its betting panel, sent to the topic threadID
+func (c *sicboCoordinator) track(key sicbo.SessionKey, threadID int, panelMsgID int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.schedules[key] = &sicboSchedule{
+		panelMsgID:  panelMsgID,
+		threadID:    threadID,
+		nextRefresh: now.Add(sicboPanelRefreshInterval),
+	}
+}
+
+// panelID returns the betting panel message of a session
+func (c *sicboCoordinator) panelID(key sicbo.SessionKey) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sched, ok := c.schedules[key]
+	if !ok || sched.panelMsgID == 0 {
+		return 0, false
+	}
+	return sched.panelMsgID, true
+}
+
+// requestSettle makes a session roll and settle on the next tick.
+// Returns false if the session is already being settled.
+func (c *sicboCoordinator) requestSettle(key sicbo.SessionKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sched, ok := c.schedules[key]
+	if !ok {
+		sched = &sicboSchedule{threadID: key.ThreadID}
+		c.schedules[key] = sched
+	}
+	if sched.settleNow || !sched.settleAt.IsZero() {
+		return false
+	}
+	sched.settleNow = true
+	return true
+}
+
+// cancel stops scheduling a session, e.g. once it was settled
+func (c *sicboCoordinator) cancel(key sicbo.SessionKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.schedules, key)
+}
+
+// done marks the roll or settlement of a session as finished
+func (c *sicboCoordinator) done(key sicbo.SessionKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.inFlight, key)
+}
+
+// plan reconciles the schedules with the active sessions and returns the actions due at now.
+// Sessions with a roll or settlement in flight are skipped; the ones returned are
+// marked in flight until done.
+func (c *sicboCoordinator) plan(sessions []sicbo.SessionInfo, now time.Time) []sicboAction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := make(map[sicbo.SessionKey]bool, len(sessions))
+	var actions []sicboAction
+	for _, session := range sessions {
+		key := session.SessionKey
+		active[key] = true
+		if c.inFlight[key] {
+			continue
+		}
+
+		sched, ok := c.schedules[key]
+		if !ok {
+			// Adopted sessions post to their own topic
+			sched = &sicboSchedule{threadID: key.ThreadID, nextRefresh: now.Add(sicboPanelRefreshInterval)}
+			c.schedules[key] = sched
+		}
+
+		switch {
+		case !sched.settleAt.IsZero():
+			if !now.Before(sched.settleAt) {
+				actions = append(actions, sicboAction{kind: sicboSettle, key: key, threadID: sched.threadID})
+				delete(c.schedules, key)
+				c.inFlight[key] = true
+			}
+		case sched.settleNow || !now.Before(session.BettingEndTime.Add(-sicboRollLead)):
+			actions = append(actions, sicboAction{kind: sicboRoll, key: key, threadID: sched.threadID})
+			sched.settleAt = now.Add(sicboRollLead)
+			c.inFlight[key] = true
+		case sched.panelMsgID != 0 && !now.Before(sched.nextRefresh):
+			actions = append(actions, sicboAction{kind: sicboRefresh, key: key, threadID: sched.threadID, panelMsgID: sched.panelMsgID})
+			sched.nextRefresh = now.Add(sicboPanelRefreshInterval)
+		}
+	}
+
+	// Sessions settled or lost outside the coordinator
+	for key := range c.schedules {
+		if !active[key] {
+			delete(c.schedules, key)
+		}
+	}
+	return actions
+}
+
+// StartSicBoCoordinator starts the loop that refreshes sicbo panels and settles sessions.
+func (h *SicBoHandler) StartSicBoCoordinator(bot *tele.Bot) {
+	go func() {
+		ticker := time.NewTicker(sicboTickInterval)
+		heartbeat.Start("sicbo_coordinator", sicboTickInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("sicbo_coordinator")
+			h.tickSicBo(context.Background(), bot, now)
+		}
+	}()
+}
+
+// tickSicBo runs the sicbo actions due at now; rolls and settlements are
+// started in the background
+func (h *SicBoHandler) tickSicBo(ctx context.Context, bot *tele.Bot, now time.Time) {
+	for _, action := range h.sicboCoord.plan(h.sicboGame.ActiveSessions(), now) {
+		switch action.kind {
+		case sicboRefresh:
+			h.refreshSicBoPanel(bot, action.key, action.panelMsgID)
+		case sicboRoll:
+			go func(action sicboAction) {
+				defer h.sicboCoord.done(action.key)
+				h.rollSicBoDice(bot, action.key.ChatID, action.threadID)
+			}(action)
+		case sicboSettle:
+			go func(action sicboAction) {
+				defer h.sicboCoord.done(action.key)
+				h.autoSettleSicBo(ctx, bot, action)
+			}(action)
+		}
+	}
+}
+
+// autoSettleSicBo settles a session whose dice were rolled
+func (h *SicBoHandler) autoSettleSicBo(ctx context.Context, bot *tele.Bot, action sicboAction) {
+	start := time.Now()
+	settleCtx, span := h.tracer.Start(ctx, "job:sicbo_settle", trace.SpanKindInternal, attribute.Int64("chat_id", action.key.ChatID))
+	err := h.settleSicBo(settleCtx, action.key, action.threadID, bot)
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", action.key.ChatID).Int("thread_id", action.key.ThreadID).Msg("Failed to auto-settle sicbo session")
+	}
+	tracing.End(span, err)
+	h.observeJob("job:sicbo_settle", start)
+}
+
+// refreshSicBoPanel edits the betting panel with the current countdown and stats
+func (h *SicBoHandler) refreshSicBoPanel(bot *tele.Bot, key sicbo.SessionKey, panelMsgID int) {
+	chatID := key.ChatID
+	remaining := h.sicboGame.GetSessionTimeRemaining(key)
+	playerCount, totalBetAmount, _ := h.sicboGame.GetSessionStats(key)
+
+	kb := sicbo.NewKeyboardBuilder()
+	markup := kb.BuildMainPanelWithSettle()
+	msg := sicbo.FormatPanelMessage(remaining, playerCount, totalBetAmount)
+
+	editMsg := &tele.Message{
+		ID:   panelMsgID,
+		Chat: &tele.Chat{ID: chatID},
+	}
+	if _, err := bot.Edit(editMsg, msg, markup); err != nil {
+		log.Debug().Err(err).Int64("chat_id", chatID).Msg("Failed to refresh sicbo panel")
+	}
+}
+
+// rollSicBoDice sends the three dice animation shown before settlement to the topic threadID
+func (h *SicBoHandler) rollSicBoDice(bot *tele.Bot, chatID int64, threadID int) {
+	chat := &tele.Chat{ID: chatID}
+	for i := 0; i < 3; i++ {
+		diceMsg, err := bot.Send(chat, tele.Cube, topicOptions(threadID))
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to send sicbo dice animation")
+		} else {
+			h.trackMessage(chatID, diceMsg.ID)
+		}
+		if i < 2 {
+			time.Sleep(sicboRollGap)
+		}
+	}
+}
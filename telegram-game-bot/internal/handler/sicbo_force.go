@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/pkg/roles"
+)
+
+// SicBo watchdog timing
+const (
+	sicboWatchdogInterval = 30 * time.Second // 看门狗检查间隔
+	sicboStuckAfter       = time.Minute      // 下注结束后仍未结算多久视为卡住
+)
+
+// sicboForceUsage explains the /sicbo_force subcommands
+const sicboForceUsage = "用法:\n" +
+	"/sicbo_force settle - 立即开奖结算\n" +
+	"/sicbo_force cancel - 取消本局并退还全部下注"
+
+// stuckSicBoSessions returns the sessions whose betting ended more than
+// sicboStuckAfter before now without being settled
+func stuckSicBoSessions(sessions []sicbo.SessionInfo, now time.Time) []sicbo.SessionKey {
+	var stuck []sicbo.SessionKey
+	for _, session := range sessions {
+		if now.Sub(session.BettingEndTime) > sicboStuckAfter {
+			stuck = append(stuck, session.SessionKey)
+		}
+	}
+	return stuck
+}
+
+// HandleSicBoForce handles the /sicbo_force command: a chat admin settles
+// or cancels a round that did not settle on its own.
+func (h *SicBoHandler) HandleSicBoForce(c tele.Context) error {
+	ctx := context.Background()
+	chat := c.Chat()
+	sender := c.Sender()
+
+	if chat == nil || sender == nil {
+		return nil
+	}
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 骰宝游戏只能在群组中进行")
+	}
+	if !hasRole(c, h.cfg, roles.ChatAdmin) {
+		return c.Reply("❌ 只有群管理员可以强制结算")
+	}
+
+	action := "settle"
+	if args := c.Args(); len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+	if action != "settle" && action != "cancel" {
+		return c.Reply(sicboForceUsage)
+	}
+
+	threadID := topicOf(c)
+	key := h.sicboKey(chat.ID, threadID)
+	if !h.sicboGame.IsSessionActive(key) {
+		return c.Reply("❌ 当前没有进行中的游戏")
+	}
+
+	log.Warn().
+		Int64("chat_id", chat.ID).
+		Int("thread_id", key.ThreadID).
+		Int64("admin_id", sender.ID).
+		Str("action", action).
+		Msg("Forcing sicbo session")
+
+	if action == "cancel" {
+		if err := h.cancelSicBo(ctx, key, threadID, c.Bot()); err != nil {
+			if errors.Is(err, sicbo.ErrNoActiveSession) {
+				return c.Reply("❌ 当前没有进行中的游戏")
+			}
+			return c.Reply("❌ 取消失败，请稍后重试")
+		}
+		return nil
+	}
+
+	if err := h.settleSicBo(ctx, key, threadID, c.Bot()); err != nil {
+		return c.Reply("❌ 结算失败，可使用 /sicbo_force cancel 取消本局并退还下注")
+	}
+	return nil
+}
+
+// cancelSicBo ends a session without rolling, refunds every stake and
+// announces it in the topic threadID.
+func (h *SicBoHandler) cancelSicBo(ctx context.Context, key sicbo.SessionKey, threadID int, bot *tele.Bot) error {
+	chatID := key.ChatID
+	h.sicboCoord.cancel(key)
+	refunds, err := h.sicboGame.Cancel(ctx, key)
+	if err != nil {
+		return err
+	}
+	if h.sicboAuto != nil && key.ThreadID == 0 {
+		h.sicboAuto.RoundFinished(chatID, len(refunds) > 0)
+	}
+
+	h.refundSicBoBets(ctx, chatID, refunds, "已取消", "取消")
+
+	if bot != nil {
+		msg := fmt.Sprintf("🚫 本局骰宝已取消，%d 名玩家的下注已全部退还", len(refunds))
+		if _, err := bot.Send(&tele.Chat{ID: chatID}, msg, topicOptions(threadID)); err != nil {
+			log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send sicbo cancel message")
+		}
+	}
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Interface("refunds", refunds).
+		Msg("SicBo round cancelled, bets refunded")
+	return nil
+}
+
+// StartSicBoWatchdog starts the loop recovering sessions the coordinator
+// failed to settle. It runs apart from the coordinator so that a coordinator
+// stuck on one chat does not leave every other chat stuck with it.
+func (h *SicBoHandler) StartSicBoWatchdog(bot *tele.Bot) {
+	go func() {
+		ticker := time.NewTicker(sicboWatchdogInterval)
+		heartbeat.Start("sicbo_watchdog", sicboWatchdogInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("sicbo_watchdog")
+			h.recoverStuckSicBo(context.Background(), bot, now)
+		}
+	}()
+}
+
+// recoverStuckSicBo settles the sessions stuck past their end time, cancelling
+// with refunds those that fail to settle
+func (h *SicBoHandler) recoverStuckSicBo(ctx context.Context, bot *tele.Bot, now time.Time) {
+	for _, key := range stuckSicBoSessions(h.sicboGame.ActiveSessions(), now) {
+		log.Warn().Int64("chat_id", key.ChatID).Int("thread_id", key.ThreadID).Msg("SicBo session stuck past its end time, recovering")
+
+		start := time.Now()
+		err := h.settleSicBo(ctx, key, key.ThreadID, bot)
+		h.observeJob("job:sicbo_recover", start)
+		if err == nil || !h.sicboGame.IsSessionActive(key) {
+			continue
+		}
+
+		log.Error().Err(err).Int64("chat_id", key.ChatID).Msg("Failed to settle stuck sicbo session, cancelling")
+		if err := h.cancelSicBo(ctx, key, key.ThreadID, bot); err != nil && !errors.Is(err, sicbo.ErrNoActiveSession) {
+			log.Error().Err(err).Int64("chat_id", key.ChatID).Msg("Failed to cancel stuck sicbo session")
+		}
+	}
+}
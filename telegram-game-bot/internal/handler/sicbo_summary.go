@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/service"
+)
+
+// SicBoSummaryHandler lets users opt in to a personal DM after each SicBo round.
+type SicBoSummaryHandler struct {
+	summaries *service.SicBoSummaryService
+}
+
+// NewSicBoSummaryHandler creates a new SicBoSummaryHandler.
+func NewSicBoSummaryHandler(summaries *service.SicBoSummaryService) *SicBoSummaryHandler {
+	return &SicBoSummaryHandler{summaries: summaries}
+}
+
+// HandleSicBoDM handles the /sicbodm command.
+// Format: /sicbodm [on|off]
+func (h *SicBoSummaryHandler) HandleSicBoDM(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		enabled, err := h.summaries.Enabled(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		status := "未开启"
+		if enabled {
+			status = "已开启"
+		}
+		return c.Reply("🎰 骰宝结算私信: " + status + "\n\n" +
+			"每局骰宝结算后，机器人会私聊发送你的押注、输赢、净结果和最新余额\n\n" +
+			"📖 用法:\n" +
+			"/sicbodm on - 开启\n" +
+			"/sicbodm off - 关闭\n\n" +
+			"⚠️ 请先私聊机器人发送 /start，否则无法收到私信")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		if err := h.summaries.Enable(ctx, sender.ID); err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to enable sicbo summary")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 已开启骰宝结算私信\n⚠️ 请确认已私聊机器人发送 /start")
+	case "off":
+		if err := h.summaries.Disable(ctx, sender.ID); err != nil {
+			log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to disable sicbo summary")
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return c.Reply("✅ 已关闭骰宝结算私信")
+	default:
+		return c.Reply("❌ 用法: /sicbodm on | off")
+	}
+}
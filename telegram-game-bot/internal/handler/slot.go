@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/slot"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/pkg/tgfmt"
+	"telegram-game-bot/internal/service"
+)
+
+// SlotHandler handles the slot machine and its daily free spin.
+type SlotHandler struct {
+	*BaseHandler
+}
+
+// NewSlotHandler creates a new SlotHandler.
+func NewSlotHandler(base *BaseHandler) *SlotHandler {
+	return &SlotHandler{BaseHandler: base}
+}
+
+// HandleSlot handles the /slot command.
+// Requirements: 4.1
+func (h *SlotHandler) HandleSlot(c tele.Context) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	// 仅限群组使用
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 老虎机游戏只能在群组中进行，请加入群组后使用")
+	}
+
+	// Parse bet amount, falling back to the default stake
+	bet, err := h.parseStake(ctx, c, "slot")
+	if err != nil {
+		return c.Reply(err.Error())
+	}
+
+	return h.playSlot(c, bet)
+}
+
+// playSlot plays a slot game for the sender; shared by /slot and its replay button
+func (h *SlotHandler) playSlot(c tele.Context, bet int64) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+
+	// Check cooldown (3 seconds)
+	cooldownSecs := 3
+	if remaining := h.checkCooldown(sender.ID, "slot"); remaining > 0 {
+		return c.Reply(cooldownMessage(remaining))
+	}
+
+	// Ensure user exists
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	scope := h.balanceScope(ctx, chat.ID)
+
+	// Acquire lock
+	h.userLock.Lock(sender.ID)
+	defer h.userLock.Unlock(sender.ID)
+
+	// Check balance
+	balance, err := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+	if err != nil {
+		return c.Reply("❌ 获取余额失败")
+	}
+
+	// Check max bet based on balance (use dice max bet as default)
+	maxBet := h.getEffectiveMaxBet(balance, h.cfg.Games.Dice.MaxBet)
+	if bet > maxBet {
+		tierMaxBet, tierThreshold := getBalanceTierInfo(balance)
+		if tierThreshold > 0 {
+			return c.Reply(fmt.Sprintf("❌ 余额超过 %d，单次下注上限为 %d", tierThreshold, tierMaxBet))
+		}
+		return c.Reply(fmt.Sprintf("❌ 最大下注金额为 %d", maxBet))
+	}
+
+	if balance < bet {
+		return c.Reply("❌ 余额不足")
+	}
+
+	// Deduct bet first
+	desc := fmt.Sprintf("老虎机下注 %d", bet)
+	_, err = h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, -bet, model.TxTypeSlot, &desc)
+	if err != nil {
+		return c.Reply("❌ 扣款失败，请稍后重试")
+	}
+
+	// Send slot machine
+	slotMsg, err := sendInTopic(c, tele.Slot)
+	if err != nil {
+		// Refund on error
+		if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, bet, model.TxTypeSlot, nil); err != nil {
+			h.reportIncidentIn(scope, service.IncidentRefundFailed, "老虎机发送失败后退还下注失败",
+				service.CompensationClaim{UserID: sender.ID, Amount: bet})
+		}
+		return c.Reply("❌ 发送老虎机失败")
+	}
+	h.trackMessage(c.Chat().ID, slotMsg.ID)
+
+	// Get slot value
+	slotValue := slotMsg.Dice.Value
+
+	// Decode and calculate payout
+	left, middle, right := slot.DecodeSlot(slotValue)
+	payout := slot.CalculatePayout(left, middle, right, bet)
+
+	// Set cooldown
+	h.setCooldown(sender.ID, "slot", cooldownSecs)
+	h.recordWager(c.Chat().ID, bet)
+
+	// Process result asynchronously to avoid blocking
+	go func() {
+		// Wait for slot animation
+		time.Sleep(3 * time.Second)
+
+		// Credit winnings
+		if payout >= 0 {
+			creditAmount := bet + payout
+			if creditAmount > 0 {
+				h.userLock.Lock(sender.ID)
+				desc := fmt.Sprintf("老虎机赢得 %d", payout)
+				if _, err := h.accountService.UpdateBalanceIn(ctx, scope, sender.ID, creditAmount, model.TxTypeSlot, &desc); err != nil {
+					h.reportIncidentIn(scope, service.IncidentCreditFailed, "老虎机奖金到账失败",
+						service.CompensationClaim{UserID: sender.ID, Amount: creditAmount})
+				}
+				h.userLock.Unlock(sender.ID)
+			}
+			h.recordWin(c.Chat().ID, user, payout)
+			h.publishWin(c.Chat().ID, user, "slot", payout)
+		}
+		h.recordRound(ctx, &model.GameRound{
+			Game:   model.GameRoundSlot,
+			UserID: sender.ID,
+			ChatID: c.Chat().ID,
+			Bet:    bet,
+			Values: []int{slotValue},
+			Payout: payout,
+		})
+
+		// Get new balance
+		newBalance, _ := h.accountService.GetBalanceIn(ctx, scope, sender.ID)
+
+		// Build result message with @username
+		symbols := []string{slot.SymbolNames[left], slot.SymbolNames[middle], slot.SymbolNames[right]}
+		slotDisplay := strings.Join(symbols, " ")
+
+		persona := h.chatPersona(ctx, c.Chat().ID)
+		vars := service.PersonaVars{User: username, Amount: payout, Balance: newBalance, Game: "老虎机"}
+		var outcome string
+		switch {
+		case payout > 0:
+			outcome = service.RenderOutcome(persona, true, "🎊 三连！赢得 {amount} 金币！", vars)
+		case payout == 0:
+			outcome = "😐 两连，返还下注"
+		default:
+			vars.Amount = bet
+			outcome = service.RenderOutcome(persona, false, "{emoji} 没中，输了 {amount} 金币", vars)
+		}
+		if payout != 0 {
+			outcome += h.banterLine(ctx, c.Chat().ID, sender, payout > 0)
+		}
+		resultMsg := tgfmt.Sprintf("%s 🎰 %s\n%s\n%s", playerName(persona, sender.ID, username), slotDisplay, outcome, h.renderBalance(persona, scope, newBalance))
+
+		replyMsg, err := sendInTopic(c, resultMsg.String(), tele.ModeHTML, replayMarkup(replaySlot, sender.ID, bet))
+		if err == nil && replyMsg != nil {
+			h.trackMessage(c.Chat().ID, replyMsg.ID)
+		}
+		if payout > 0 {
+			h.celebrate(ctx, c.Chat().ID, model.CelebrationSlotTriple)
+		}
+	}()
+
+	return nil
+}
+
+// HandleFreeSpin handles the /freespin command.
+// One free slot spin per day with no stake and a reduced paytable,
+// played through the registered "freespin" game with a zero bet.
+func (h *SlotHandler) HandleFreeSpin(c tele.Context) error {
+	ctx := RequestContext(c)
+	sender := c.Sender()
+	chat := c.Chat()
+	if sender == nil || chat == nil {
+		return nil
+	}
+
+	// 仅限群组使用
+	if chat.Type == tele.ChatPrivate {
+		return c.Reply("❌ 免费旋转只能在群组中进行，请加入群组后使用")
+	}
+
+	freeSpinGame, ok := h.gameRegistry.Get("freespin")
+	if !ok {
+		return c.Reply("❌ 免费旋转暂未开放")
+	}
+
+	// Ensure user exists
+	username := senderName(sender)
+	user, _, err := h.accountService.EnsureUser(ctx, sender.ID, username)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	// Acquire lock
+	h.userLock.Lock(sender.ID)
+	defer h.userLock.Unlock(sender.ID)
+
+	canSpin, remaining, err := h.accountService.CanFreeSpin(ctx, sender.ID, h.cfg.Games.FreeSpin.CooldownHours)
+	if err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	if !canSpin {
+		return c.Reply("⏰ 今天的免费旋转已用完，请等待 " + cooldown.Format(remaining) + " 后再来")
+	}
+
+	// Send slot machine
+	slotMsg, err := sendInTopic(c, tele.Slot)
+	if err != nil {
+		return c.Reply("❌ 发送老虎机失败")
+	}
+	h.trackMessage(c.Chat().ID, slotMsg.ID)
+
+	// Record the spin before crediting so it cannot be repeated
+	if err := h.accountService.MarkFreeSpin(ctx, sender.ID); err != nil {
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to record free spin")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	result, err := freeSpinGame.Play(ctx, sender.ID, 0, map[string]any{"slot_value": slotMsg.Dice.Value})
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to play free spin")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	left := result.Details["left"].(int)
+	middle := result.Details["middle"].(int)
+	right := result.Details["right"].(int)
+	prize := result.Payout
+
+	// Process result asynchronously to avoid blocking
+	go func() {
+		// Wait for slot animation
+		time.Sleep(3 * time.Second)
+
+		if prize > 0 {
+			h.userLock.Lock(sender.ID)
+			desc := fmt.Sprintf("免费旋转赢得 %d", prize)
+			if _, err := h.accountService.UpdateBalance(ctx, sender.ID, prize, model.TxTypeFreeSpin, &desc, ""); err != nil {
+				h.reportIncident(service.IncidentCreditFailed, "免费旋转奖金到账失败",
+					service.CompensationClaim{UserID: sender.ID, Amount: prize})
+			}
+			h.userLock.Unlock(sender.ID)
+			h.recordWin(c.Chat().ID, user, prize)
+		}
+
+		newBalance, _ := h.accountService.GetBalance(ctx, sender.ID)
+
+		symbols := []string{slot.SymbolNames[left], slot.SymbolNames[middle], slot.SymbolNames[right]}
+		slotDisplay := strings.Join(symbols, " ")
+
+		persona := h.chatPersona(ctx, c.Chat().ID)
+		outcome := "😐 没中，明天再来吧"
+		if prize > 0 {
+			vars := service.PersonaVars{User: username, Amount: prize, Balance: newBalance, Game: "免费旋转"}
+			outcome = service.RenderOutcome(persona, true, defaultWinLine, vars)
+		}
+		resultMsg := tgfmt.Sprintf("%s 🎁 免费旋转 🎰 %s\n%s\n%s", playerName(persona, sender.ID, username), slotDisplay, outcome, service.RenderBalance(persona, newBalance))
+
+		replyMsg, err := sendInTopic(c, resultMsg.String(), tele.ModeHTML)
+		if err == nil && replyMsg != nil {
+			h.trackMessage(c.Chat().ID, replyMsg.ID)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+)
+
+// Games that can be replayed from their result message
+const (
+	replayDice = "replay_dice"
+	replaySlot = "replay_slot"
+)
+
+// parseStake returns the stake given as the command's first argument, or the
+// sender's default stake when the command has no argument.
+// On failure the returned error text is the reply for the user.
+func (h *BaseHandler) parseStake(ctx context.Context, c tele.Context, command string) (int64, error) {
+	args := c.Args()
+	if len(args) == 0 {
+		stake, err := h.accountService.GetDefaultStake(ctx, c.Sender().ID)
+		if err != nil || stake <= 0 {
+			return 0, fmt.Errorf("❌ 用法: /%s <金额>\n例如: /%s 100\n💡 用 /stake 金额 设置默认下注后可直接发送 /%s", command, command, command)
+		}
+		return stake, nil
+	}
+
+	bet, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || bet <= 0 {
+		return 0, errors.New("❌ 请输入有效的下注金额")
+	}
+	return bet, nil
+}
+
+// replayMarkup builds the "再来一次" button of a result message, replaying the
+// same bet for the player only
+func replayMarkup(game string, userID, bet int64) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	btn := markup.Data(fmt.Sprintf("🔁 再来一次 (%d)", bet), game, strconv.FormatInt(userID, 10), strconv.FormatInt(bet, 10))
+	markup.Inline(markup.Row(btn))
+	return markup
+}
+
+// HandleReplayCallback handles the "再来一次" button of dice and slot results.
+// Data format: replay_dice|userID|bet
+func (h *GameHandler) HandleReplayCallback(c tele.Context) error {
+	callback := c.Callback()
+	sender := c.Sender()
+	chat := c.Chat()
+	if callback == nil || sender == nil || chat == nil {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, "\f"), "|")
+	if len(parts) != 3 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+	userID, err1 := strconv.ParseInt(parts[1], 10, 64)
+	bet, err2 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || bet <= 0 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	if sender.ID != userID {
+		return c.Respond(&tele.CallbackResponse{
+			Text:      "❌ 这不是你的下注",
+			ShowAlert: true,
+		})
+	}
+
+	if err := c.Respond(&tele.CallbackResponse{Text: fmt.Sprintf("🔁 再来一次: %d", bet)}); err != nil {
+		log.Debug().Err(err).Msg("Failed to answer replay callback")
+	}
+
+	switch parts[0] {
+	case replayDice:
+		return h.playDice(c, bet, false)
+	case replaySlot:
+		return h.playSlot(c, bet)
+	}
+	return nil
+}
+
+// HandleStake handles the /stake command setting the default stake of /dice and /slot.
+// Format: /stake [金额|off]
+func (h *BaseHandler) HandleStake(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, senderName(sender)); err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		stake, err := h.accountService.GetDefaultStake(ctx, sender.ID)
+		if err != nil {
+			return c.Reply("❌ 操作失败，请稍后重试")
+		}
+		current := "未设置"
+		if stake > 0 {
+			current = strconv.FormatInt(stake, 10)
+		}
+		return c.Reply(fmt.Sprintf("🎯 默认下注: %s\n\n📖 用法:\n/stake 金额 - 设置默认下注，/dice 和 /slot 不带金额时使用\n/stake off - 取消默认下注", current))
+	}
+
+	var stake int64
+	if strings.ToLower(args[0]) != "off" {
+		var err error
+		stake, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil || stake <= 0 {
+			return c.Reply("❌ 请输入有效的下注金额")
+		}
+	}
+
+	if err := h.accountService.SetDefaultStake(ctx, sender.ID, stake); err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	if stake == 0 {
+		return c.Reply("✅ 已取消默认下注")
+	}
+	return c.Reply(fmt.Sprintf("✅ 默认下注已设为 %d，直接发送 /dice 或 /slot 即可下注", stake))
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/roles"
+	"telegram-game-bot/internal/service"
+)
+
+// maxRefundButtons limits the refund buttons attached to a ticket
+const maxRefundButtons = 5
+
+// SupportHandler handles /support tickets and their admin resolution buttons.
+type SupportHandler struct {
+	cfg            *config.Config
+	supportService *service.SupportService
+	accountService *service.AccountService
+}
+
+// NewSupportHandler creates a new SupportHandler.
+func NewSupportHandler(cfg *config.Config, supportService *service.SupportService, accountService *service.AccountService) *SupportHandler {
+	return &SupportHandler{
+		cfg:            cfg,
+		supportService: supportService,
+		accountService: accountService,
+	}
+}
+
+// HandleSupport handles the /support command.
+// Format: /support <问题描述>
+// Forwards the issue with the user's recent transactions to the admin support chat.
+func (h *SupportHandler) HandleSupport(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	if h.cfg.Support.ChatID == 0 {
+		return c.Reply("❌ 客服功能暂未开放")
+	}
+
+	message := strings.TrimSpace(c.Message().Payload)
+	if message == "" {
+		return c.Reply("❌ 用法: /support <问题描述>\n例如: /support 骰子结果没有到账")
+	}
+
+	username := senderName(sender)
+	if _, _, err := h.accountService.EnsureUser(ctx, sender.ID, username); err != nil {
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	ticket, transactions, err := h.supportService.CreateTicket(ctx, sender.ID, message)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTicketEmpty),
+			errors.Is(err, service.ErrTicketTooLong),
+			errors.Is(err, service.ErrTooManyOpenTickets):
+			return c.Reply("❌ " + err.Error())
+		}
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to create support ticket")
+		return c.Reply("❌ 提交失败，请稍后重试")
+	}
+
+	text := formatTicket(ticket, username, transactions)
+	markup := buildTicketMarkup(ticket, transactions)
+	if _, err := c.Bot().Send(&tele.Chat{ID: h.cfg.Support.ChatID}, text, markup); err != nil {
+		log.Error().Err(err).Int64("ticket_id", ticket.ID).Msg("Failed to forward support ticket")
+		return c.Reply("❌ 工单已记录，但通知管理员失败，请稍后再试")
+	}
+
+	return c.Reply(fmt.Sprintf("✅ 工单 #%d 已提交，管理员会尽快处理", ticket.ID))
+}
+
+// HandleSupportCallback handles the refund/dismiss buttons on tickets.
+// Only configured admins can resolve tickets; the acting admin is the callback sender.
+func (h *SupportHandler) HandleSupportCallback(c tele.Context) error {
+	ctx := context.Background()
+	callback := c.Callback()
+	sender := c.Sender()
+	if callback == nil || sender == nil {
+		return nil
+	}
+
+	if !hasRole(c, h.cfg, roles.GlobalAdmin) {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 权限不足：需要管理员权限", ShowAlert: true})
+	}
+
+	data := strings.TrimPrefix(callback.Data, "\f")
+	parts := strings.Split(data, "|")
+	if len(parts) < 2 {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	ticketID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+	}
+
+	adminName := senderName(sender)
+
+	ticketText := ""
+	if callback.Message != nil {
+		ticketText = callback.Message.Text
+	}
+
+	switch parts[0] {
+	case "support_refund":
+		if len(parts) < 3 {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+		}
+		txID, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+		}
+
+		ticket, amount, err := h.supportService.Refund(ctx, ticketID, txID, sender.ID)
+		if err != nil {
+			return h.respondSupportError(c, err)
+		}
+
+		c.Edit(fmt.Sprintf("%s\n\n✅ 已由 %s 退款 %d 金币（交易 #%d）", ticketText, adminName, amount, txID))
+		h.notifyUser(c, ticket.UserID, fmt.Sprintf("✅ 你的工单 #%d 已处理，已退还 %d 金币", ticket.ID, amount))
+		return c.Respond(&tele.CallbackResponse{Text: "✅ 已退款"})
+
+	case "support_dismiss":
+		ticket, err := h.supportService.Dismiss(ctx, ticketID, sender.ID)
+		if err != nil {
+			return h.respondSupportError(c, err)
+		}
+
+		c.Edit(fmt.Sprintf("%s\n\n🚫 已由 %s 驳回", ticketText, adminName))
+		h.notifyUser(c, ticket.UserID, fmt.Sprintf("ℹ️ 你的工单 #%d 已处理，未发现需要退款的问题", ticket.ID))
+		return c.Respond(&tele.CallbackResponse{Text: "已驳回"})
+	}
+
+	return c.Respond(&tele.CallbackResponse{Text: "❌ 无效操作"})
+}
+
+// respondSupportError answers a ticket callback with a user facing error
+func (h *SupportHandler) respondSupportError(c tele.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrTicketNotFound),
+		errors.Is(err, service.ErrTicketResolved),
+		errors.Is(err, service.ErrRefundNotAllowed),
+		errors.Is(err, service.ErrTxAlreadyRefunded):
+		return c.Respond(&tele.CallbackResponse{Text: "❌ " + err.Error(), ShowAlert: true})
+	}
+	log.Error().Err(err).Msg("Failed to resolve support ticket")
+	return c.Respond(&tele.CallbackResponse{Text: "❌ 操作失败，请稍后重试", ShowAlert: true})
+}
+
+// notifyUser sends a private message to the ticket owner (best effort)
+func (h *SupportHandler) notifyUser(c tele.Context, userID int64, text string) {
+	if _, err := c.Bot().Send(&tele.User{ID: userID}, text); err != nil {
+		log.Debug().Err(err).Int64("user_id", userID).Msg("Failed to notify ticket owner")
+	}
+}
+
+// formatTicket formats a ticket for the admin support chat
+func formatTicket(ticket *model.SupportTicket, username string, transactions []*model.Transaction) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎫 工单 #%d\n", ticket.ID))
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("👤 用户: %s (ID: %d)\n", username, ticket.UserID))
+	sb.WriteString(fmt.Sprintf("🕐 时间: %s\n", ticket.CreatedAt.Format("2006-01-02 15:04:05")))
+	sb.WriteString(fmt.Sprintf("📝 问题: %s\n", ticket.Message))
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	sb.WriteString("📜 最近交易:\n")
+
+	if len(transactions) == 0 {
+		sb.WriteString("暂无交易记录\n")
+	}
+	for _, tx := range transactions {
+		desc := ""
+		if tx.Description != nil {
+			desc = " " + *tx.Description
+		}
+		sb.WriteString(fmt.Sprintf("#%d %s %s %+d%s\n",
+			tx.ID, tx.CreatedAt.Format("01-02 15:04"), tx.Type, tx.Amount, desc))
+	}
+
+	return sb.String()
+}
+
+// buildTicketMarkup builds refund buttons for the ticket's recent losses plus a dismiss button
+func buildTicketMarkup(ticket *model.SupportTicket, transactions []*model.Transaction) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	var rows []tele.Row
+
+	for _, tx := range transactions {
+		if len(rows) >= maxRefundButtons {
+			break
+		}
+		if !service.IsRefundable(tx, ticket.UserID) {
+			continue
+		}
+		btn := markup.Data(
+			fmt.Sprintf("💸 退款 #%d (%d)", tx.ID, -tx.Amount),
+			"support_refund",
+			strconv.FormatInt(ticket.ID, 10), strconv.FormatInt(tx.ID, 10),
+		)
+		rows = append(rows, markup.Row(btn))
+	}
+
+	btnDismiss := markup.Data("🚫 驳回", "support_dismiss", strconv.FormatInt(ticket.ID, 10))
+	rows = append(rows, markup.Row(btnDismiss))
+
+	markup.Inline(rows...)
+	return markup
+}
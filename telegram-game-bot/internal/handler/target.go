@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/service"
+)
+
+// senderName returns the cleaned name of a Telegram user: the username, or the
+// first name for users without one
+func senderName(u *tele.User) string {
+	name := u.Username
+	if name == "" {
+		name = u.FirstName
+	}
+	return textfilter.Name(name)
+}
+
+// isHandleArg reports whether a command argument looks like a user handle
+func isHandleArg(arg string) bool {
+	return strings.HasPrefix(arg, "#")
+}
+
+// resolveHandleTarget resolves a "#A1B2C3" argument to a user ID and display name.
+// On failure it replies to the user and returns ok = false.
+func resolveHandleTarget(ctx context.Context, c tele.Context, accountService *service.AccountService, arg string) (int64, string, bool) {
+	user, err := accountService.GetUserByHandle(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidHandle):
+			_ = c.Reply("❌ 编号格式错误，应为 # 加 6 位字母数字，例如 #A1B2C3")
+		case errors.Is(err, service.ErrUserNotFound):
+			_ = c.Reply("❌ 找不到编号为 " + strings.ToUpper(arg) + " 的用户")
+		default:
+			log.Error().Err(err).Str("handle", arg).Msg("Failed to resolve user handle")
+			_ = c.Reply("❌ 操作失败，请稍后重试")
+		}
+		return 0, "", false
+	}
+	return user.TelegramID, textfilter.Name(user.Username), true
+}
+
+// formatUserWithHandle returns "name (#A1B2C3)", or just the name if the user has no handle
+func formatUserWithHandle(name, handle string) string {
+	if handle == "" {
+		return name
+	}
+	return name + " (" + handle + ")"
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	tele "gopkg.in/telebot.v3"
+)
+
+// topicOf returns the forum topic an update was sent in, 0 outside of topics
+func topicOf(c tele.Context) int {
+	if msg := c.Message(); msg != nil && msg.TopicMessage {
+		return msg.ThreadID
+	}
+	return 0
+}
+
+// topicOptions sends a message to the forum topic threadID, 0 for the chat.
+// telebot replaces earlier options with a *tele.SendOptions, so pass it
+// before any parse mode or markup.
+func topicOptions(threadID int) *tele.SendOptions {
+	return &tele.SendOptions{ThreadID: threadID}
+}
+
+// sendInTopic sends a message to the chat and forum topic an update came from
+func sendInTopic(c tele.Context, what interface{}, opts ...interface{}) (*tele.Message, error) {
+	return c.Bot().Send(c.Chat(), what, append([]interface{}{topicOptions(topicOf(c))}, opts...)...)
+}
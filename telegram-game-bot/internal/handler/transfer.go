@@ -5,13 +5,14 @@ package handler
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strconv"
 	"strings"
 
 	tele "gopkg.in/telebot.v3"
 
 	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/pkg/tgfmt"
 	"telegram-game-bot/internal/service"
 )
 
@@ -36,7 +37,7 @@ func NewTransferHandler(
 }
 
 // HandlePay handles the /pay command.
-// Format: /pay @username amount
+// Format: /pay @username amount, or /pay #HANDLE amount
 // Requirements: 2.1, 2.2, 2.3, 2.4, 2.5
 func (h *TransferHandler) HandlePay(c tele.Context) error {
 	ctx := context.Background()
@@ -48,13 +49,13 @@ func (h *TransferHandler) HandlePay(c tele.Context) error {
 	// Parse arguments
 	args := c.Args()
 	if len(args) < 2 {
-		return c.Reply("❌ 用法: /pay @用户名 金额\n例如: /pay @alice 100")
+		return c.Reply("❌ 用法: /pay @用户名 金额 或 /pay #编号 金额\n例如: /pay @alice 100")
 	}
 
 	// Parse target user
 	targetStr := args[0]
-	if !strings.HasPrefix(targetStr, "@") {
-		return c.Reply("❌ 请使用 @用户名 格式指定收款人")
+	if !strings.HasPrefix(targetStr, "@") && !isHandleArg(targetStr) {
+		return c.Reply("❌ 请使用 @用户名 或 #编号 格式指定收款人")
 	}
 	targetUsername := strings.TrimPrefix(targetStr, "@")
 
@@ -69,11 +70,19 @@ func (h *TransferHandler) HandlePay(c tele.Context) error {
 		return c.Reply("❌ 转账金额必须大于 0")
 	}
 
-	// Get target user by username from message mention or reply
+	// Get target user by handle, or by username from message mention or reply
 	var targetID int64
 
+	if isHandleArg(targetStr) {
+		id, name, ok := resolveHandleTarget(ctx, c, h.accountService, targetStr)
+		if !ok {
+			return nil
+		}
+		targetID, targetUsername = id, name
+	}
+
 	// Check if message has entities (mentions)
-	if c.Message() != nil && len(c.Message().Entities) > 0 {
+	if targetID == 0 && c.Message() != nil && len(c.Message().Entities) > 0 {
 		for _, entity := range c.Message().Entities {
 			if entity.Type == tele.EntityMention && entity.User != nil {
 				if entity.User.Username == targetUsername {
@@ -95,7 +104,7 @@ func (h *TransferHandler) HandlePay(c tele.Context) error {
 	// If still no target found, we need to look up by username
 	// This is a limitation - Telegram doesn't allow looking up users by username
 	if targetID == 0 {
-		return c.Reply("❌ 找不到用户 @" + targetUsername + "\n请确保该用户已使用过本机器人，或回复该用户的消息进行转账")
+		return c.Reply("❌ 找不到用户 @" + textfilter.Name(targetUsername) + "\n请确保该用户已使用过本机器人，或使用对方的 #编号 / 回复该用户的消息进行转账")
 	}
 
 	// Prevent self-transfer (Requirements: 2.4)
@@ -104,10 +113,7 @@ func (h *TransferHandler) HandlePay(c tele.Context) error {
 	}
 
 	// Ensure both users exist
-	senderUsername := sender.Username
-	if senderUsername == "" {
-		senderUsername = sender.FirstName
-	}
+	senderUsername := senderName(sender)
 	_, _, err = h.accountService.EnsureUser(ctx, sender.ID, senderUsername)
 	if err != nil {
 		return c.Reply("❌ 操作失败，请稍后重试")
@@ -118,7 +124,7 @@ func (h *TransferHandler) HandlePay(c tele.Context) error {
 	defer h.userLock.Unlock(sender.ID)
 
 	// Execute transfer (Requirements: 2.1, 2.2, 2.5)
-	err = h.transferService.Transfer(ctx, sender.ID, targetID, amount)
+	err = h.transferService.Transfer(ctx, sender.ID, targetID, amount, "")
 	if err != nil {
 		if errors.Is(err, service.ErrInsufficientBalance) {
 			return c.Reply("❌ 余额不足")
@@ -138,11 +144,17 @@ func (h *TransferHandler) HandlePay(c tele.Context) error {
 	// Get updated balance
 	newBalance, _ := h.accountService.GetBalance(ctx, sender.ID)
 
-	return c.Reply(fmt.Sprintf(
+	// Receipts show the receiver's handle, which stays stable across username changes
+	targetHandle := ""
+	if target, err := h.accountService.GetUser(ctx, targetID); err == nil {
+		targetHandle = target.Handle
+	}
+
+	return replyHTML(c, tgfmt.Sprintf(
 		"✅ 转账成功！\n\n"+
-			"💸 已向 @%s 转账 %d 金币\n"+
-			"💰 当前余额: %d 金币",
-		targetUsername, amount, newBalance,
+			"💸 已向 %s 转账 %s 金币\n"+
+			"💰 当前余额: %s 金币",
+		tgfmt.Mention(targetID, formatUserWithHandle(targetUsername, targetHandle)), tgfmt.Amount(amount), tgfmt.Amount(newBalance),
 	))
 }
 
@@ -166,10 +178,7 @@ func (h *TransferHandler) HandlePayReply(c tele.Context) error {
 	}
 
 	targetID := replyTo.Sender.ID
-	targetUsername := replyTo.Sender.Username
-	if targetUsername == "" {
-		targetUsername = replyTo.Sender.FirstName
-	}
+	targetUsername := senderName(replyTo.Sender)
 
 	// Parse amount from args
 	args := c.Args()
@@ -191,10 +200,7 @@ func (h *TransferHandler) HandlePayReply(c tele.Context) error {
 	}
 
 	// Ensure sender exists
-	senderUsername := sender.Username
-	if senderUsername == "" {
-		senderUsername = sender.FirstName
-	}
+	senderUsername := senderName(sender)
 	_, _, err = h.accountService.EnsureUser(ctx, sender.ID, senderUsername)
 	if err != nil {
 		return c.Reply("❌ 操作失败，请稍后重试")
@@ -205,7 +211,7 @@ func (h *TransferHandler) HandlePayReply(c tele.Context) error {
 	defer h.userLock.Unlock(sender.ID)
 
 	// Execute transfer
-	err = h.transferService.Transfer(ctx, sender.ID, targetID, amount)
+	err = h.transferService.Transfer(ctx, sender.ID, targetID, amount, "")
 	if err != nil {
 		if errors.Is(err, service.ErrInsufficientBalance) {
 			return c.Reply("❌ 余额不足")
@@ -218,10 +224,10 @@ func (h *TransferHandler) HandlePayReply(c tele.Context) error {
 
 	newBalance, _ := h.accountService.GetBalance(ctx, sender.ID)
 
-	return c.Reply(fmt.Sprintf(
+	return replyHTML(c, tgfmt.Sprintf(
 		"✅ 转账成功！\n\n"+
-			"💸 已向 @%s 转账 %d 金币\n"+
-			"💰 当前余额: %d 金币",
-		targetUsername, amount, newBalance,
+			"💸 已向 %s 转账 %s 金币\n"+
+			"💰 当前余额: %s 金币",
+		tgfmt.Mention(targetID, targetUsername), tgfmt.Amount(amount), tgfmt.Amount(newBalance),
 	))
 }
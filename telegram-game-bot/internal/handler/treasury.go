@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// treasuryAuditInterval is how often the treasury invariant is checked
+const treasuryAuditInterval = 10 * time.Minute
+
+// treasuryCategoryLabels names the treasury categories in reports
+var treasuryCategoryLabels = map[string]string{
+	service.TreasuryGames:    "🎲 游戏",
+	service.TreasuryShop:     "🛒 商店",
+	service.TreasuryIssuance: "🎁 发放",
+	service.TreasuryPeer:     "🤝 玩家间",
+	service.TreasuryOther:    "❔ 其他",
+}
+
+// SetTreasury enables the /treasury report and the treasury audit.
+func (h *AdminHandler) SetTreasury(treasury *service.TreasuryService) {
+	h.treasury = treasury
+}
+
+// StartTreasuryAudit starts the loop that checks user balances plus treasury stay zero.
+func (h *AdminHandler) StartTreasuryAudit() {
+	if h.treasury == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(treasuryAuditInterval)
+		heartbeat.Start("treasury_audit", treasuryAuditInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("treasury_audit")
+			if _, err := h.treasury.CheckInvariant(context.Background()); err != nil {
+				log.Error().Err(err).Msg("Failed to audit treasury")
+			}
+		}
+	}()
+}
+
+// HandleTreasury handles the /treasury command.
+// Shows the house balance, its income per category and the invariant check.
+func (h *AdminHandler) HandleTreasury(c tele.Context) error {
+	if h.treasury == nil {
+		return c.Reply("❌ 国库未启用")
+	}
+
+	report, err := h.treasury.Report(context.Background(), time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build treasury report")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	return c.Reply(formatTreasuryReport(report))
+}
+
+// formatTreasuryReport formats the /treasury report
+func formatTreasuryReport(report *service.TreasuryReport) string {
+	var sb strings.Builder
+	sb.WriteString("🏦 国库报告\n\n")
+	sb.WriteString(fmt.Sprintf("国库余额: %d 金币\n", report.Snapshot.Balance))
+	sb.WriteString(fmt.Sprintf("玩家总余额: %d 金币\n", report.Snapshot.UsersTotal))
+	if drift := report.Drift(); drift != 0 {
+		sb.WriteString(fmt.Sprintf("⚠️ 账目不平: 差额 %+d 金币\n", drift))
+	} else {
+		sb.WriteString("✅ 账目平衡\n")
+	}
+
+	for _, period := range []struct {
+		title string
+		flows service.TreasuryFlows
+	}{
+		{"今日", report.Today},
+		{"近 7 天", report.Week},
+	} {
+		sb.WriteString(fmt.Sprintf("\n📊 %s收支 (庄家盈亏 %+d)\n", period.title, period.flows.HousePnL()))
+		for _, category := range service.TreasuryCategories {
+			amount, ok := period.flows[category]
+			if !ok {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("%s: %+d\n", treasuryCategoryLabels[category], amount))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/service"
+)
+
+// findTxUsage explains the /findtx filters
+const findTxUsage = "📖 用法: /findtx [筛选条件...]\n" +
+	"user=用户ID|#编号 - 指定用户\n" +
+	"type=类型 - 交易类型，如 dice、transfer，或 game、pvp 一类\n" +
+	"min=金额 - 金额绝对值不低于\n" +
+	"since=24h|7d|2006-01-02 - 起始时间\n" +
+	"before=交易ID - 翻页，只看更早的交易\n\n" +
+	"例如: /findtx user=#A1B2C3 type=game min=1000 since=7d"
+
+// SetTxSearch enables searching transactions with /findtx.
+func (h *AdminHandler) SetTxSearch(txSearch *service.TxSearchService) {
+	h.txSearch = txSearch
+}
+
+// HandleFindTx handles the /findtx command.
+// Format: /findtx [user=<id|#handle>] [type=<type>] [min=<amount>] [since=<age|date>] [before=<id>]
+func (h *AdminHandler) HandleFindTx(c tele.Context) error {
+	if h.txSearch == nil {
+		return nil
+	}
+	args := c.Args()
+	if len(args) == 1 && (args[0] == "help" || args[0] == "帮助") {
+		return c.Reply(findTxUsage)
+	}
+
+	query, err := service.ParseTxSearch(args, time.Now())
+	if err != nil {
+		return c.Reply("❌ " + err.Error() + "\n\n" + findTxUsage)
+	}
+
+	page, err := h.txSearch.Search(context.Background(), query)
+	if err != nil {
+		if errors.Is(err, service.ErrTxSearchUser) {
+			return c.Reply("❌ " + err.Error())
+		}
+		log.Error().Err(err).Strs("filters", args).Msg("Failed to search transactions")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	return c.Reply(formatTxSearchPage(query, page))
+}
+
+// formatTxSearchPage formats a page of /findtx results with the command of the next page
+func formatTxSearchPage(query service.TxSearchQuery, page *service.TxSearchPage) string {
+	if len(page.Transactions) == 0 {
+		if query.BeforeID > 0 {
+			return "🔎 没有更早的交易了"
+		}
+		return "🔎 没有符合条件的交易"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🔎 交易查询")
+	if filters := query.Args(); len(filters) > 0 {
+		sb.WriteString(" · " + strings.Join(filters, " "))
+	}
+	sb.WriteString("\n━━━━━━━━━━━━━━━\n")
+	for _, tx := range page.Transactions {
+		sb.WriteString(fmt.Sprintf("#%d %s 用户 %d %s %+d\n", tx.ID, tx.CreatedAt.Format("01-02 15:04"), tx.UserID, tx.Type, tx.Amount))
+		if tx.Description != nil && *tx.Description != "" {
+			sb.WriteString("    " + *tx.Description + "\n")
+		}
+	}
+
+	if page.NextBeforeID > 0 {
+		next := query
+		next.BeforeID = page.NextBeforeID
+		sb.WriteString("\n下一页: /findtx " + strings.Join(next.Args(), " "))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
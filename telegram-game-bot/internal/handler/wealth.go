@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+// balanceSnapshotInterval is how often balances of active users are recorded
+const balanceSnapshotInterval = time.Hour
+
+// WealthHandler shows users how their balance developed.
+type WealthHandler struct {
+	history        *service.BalanceHistoryService
+	accountService *service.AccountService
+}
+
+// NewWealthHandler creates a new WealthHandler.
+func NewWealthHandler(history *service.BalanceHistoryService, accountService *service.AccountService) *WealthHandler {
+	return &WealthHandler{history: history, accountService: accountService}
+}
+
+// StartScheduler starts recording the balances of active users.
+func (h *WealthHandler) StartScheduler() {
+	go func() {
+		h.history.Snapshot(context.Background(), time.Now())
+		ticker := time.NewTicker(balanceSnapshotInterval)
+		heartbeat.Start("balance_history", balanceSnapshotInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			heartbeat.Beat("balance_history")
+			h.history.Snapshot(context.Background(), now)
+		}
+	}()
+}
+
+// HandleWealth handles the /wealth command: a sparkline of the user's
+// balance over the last days.
+func (h *WealthHandler) HandleWealth(c tele.Context) error {
+	ctx := context.Background()
+	sender := c.Sender()
+	if sender == nil {
+		return nil
+	}
+
+	balance, err := h.accountService.GetBalance(ctx, sender.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return c.Reply("❌ 你还没有账户，请先使用 /start")
+		}
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	values, err := h.history.History(ctx, sender.ID, balance, time.Now(), service.BalanceHistoryDays)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", sender.ID).Msg("Failed to get balance history")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+	return c.Reply(formatWealth(values))
+}
+
+// formatWealth renders a balance series with its sparkline
+func formatWealth(values []int64) string {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	first, last := values[0], values[len(values)-1]
+
+	msg := fmt.Sprintf("💹 近 %d 天资产走势\n\n%s\n\n", len(values), service.Sparkline(values))
+	msg += fmt.Sprintf("📈 最高: %d 金币\n📉 最低: %d 金币\n💰 当前: %d 金币（%+d）", hi, lo, last, last-first)
+	if len(values) < service.BalanceHistoryDays {
+		msg += fmt.Sprintf("\n\n💡 记录不足 %d 天，之后会逐日补全", service.BalanceHistoryDays)
+	}
+	return msg
+}
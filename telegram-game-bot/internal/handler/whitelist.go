@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/pkg/heartbeat"
+	"telegram-game-bot/internal/service"
+)
+
+// whitelistRefreshInterval is how often whitelist changes made on other instances are picked up
+const whitelistRefreshInterval = time.Minute
+
+// whitelistUsage explains the /whitelist subcommands
+const whitelistUsage = "📖 用法:\n" +
+	"/whitelist list - 查看白名单\n" +
+	"/whitelist add [群ID] - 添加群（在群内使用可省略群ID）\n" +
+	"/whitelist remove [群ID] - 移除群，包括配置文件中的群"
+
+// WhitelistHandler lets admins change the chat whitelist without a restart.
+type WhitelistHandler struct {
+	whitelist    *service.WhitelistService
+	chatSettings *ChatSettingsHandler // Optional: posts the setup wizard in added chats
+}
+
+// NewWhitelistHandler creates a new WhitelistHandler.
+func NewWhitelistHandler(whitelist *service.WhitelistService) *WhitelistHandler {
+	return &WhitelistHandler{whitelist: whitelist}
+}
+
+// SetChatSettings sets the handler posting the setup wizard in added chats
+func (h *WhitelistHandler) SetChatSettings(chatSettings *ChatSettingsHandler) {
+	h.chatSettings = chatSettings
+}
+
+// StartRefresher periodically reloads the whitelist. Every instance keeps
+// its own copy, so every instance refreshes.
+func (h *WhitelistHandler) StartRefresher() {
+	go func() {
+		ticker := time.NewTicker(whitelistRefreshInterval)
+		heartbeat.Start("whitelist", whitelistRefreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			heartbeat.Beat("whitelist")
+			if err := h.whitelist.Refresh(context.Background()); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh chat whitelist")
+			}
+		}
+	}()
+}
+
+// HandleWhitelist handles the /whitelist command (admin only).
+func (h *WhitelistHandler) HandleWhitelist(c tele.Context) error {
+	ctx := context.Background()
+	args := c.Args()
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		return h.replyList(ctx, c)
+	}
+
+	sub := strings.ToLower(args[0])
+	if sub != "add" && sub != "remove" {
+		return c.Reply(whitelistUsage)
+	}
+
+	var chatID int64
+	switch {
+	case len(args) == 2:
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || id == 0 {
+			return c.Reply("❌ 群ID格式错误\n\n" + whitelistUsage)
+		}
+		chatID = id
+	case len(args) == 1 && c.Chat() != nil && c.Chat().Type != tele.ChatPrivate:
+		chatID = c.Chat().ID
+	default:
+		return c.Reply(whitelistUsage)
+	}
+
+	var err error
+	if sub == "add" {
+		err = h.whitelist.Add(ctx, chatID, c.Sender().ID)
+	} else {
+		err = h.whitelist.Remove(ctx, chatID, c.Sender().ID)
+	}
+	if errors.Is(err, service.ErrWhitelistAlreadyAllowed) || errors.Is(err, service.ErrWhitelistNotAllowed) || errors.Is(err, service.ErrWhitelistLastChat) {
+		return c.Reply("❌ " + err.Error())
+	}
+	if err != nil {
+		log.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to change chat whitelist")
+		return c.Reply("❌ 操作失败，请稍后重试")
+	}
+
+	if sub == "remove" {
+		return c.Reply(fmt.Sprintf("✅ 已将群 %d 移出白名单，机器人将不再响应该群", chatID))
+	}
+	if h.chatSettings != nil {
+		go h.chatSettings.Onboard(c.Bot(), chatID)
+	}
+	return c.Reply(fmt.Sprintf("✅ 已将群 %d 加入白名单", chatID))
+}
+
+// replyList shows the whitelist with where each chat comes from
+func (h *WhitelistHandler) replyList(ctx context.Context, c tele.Context) error {
+	chats, err := h.whitelist.List(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load chat whitelist")
+	}
+	if len(chats) == 0 {
+		return c.Reply("📋 白名单为空，所有群都可使用\n\n" + whitelistUsage)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📋 白名单\n━━━━━━━━━━━━━━━\n")
+	for _, chat := range chats {
+		switch {
+		case !chat.Allowed:
+			fmt.Fprintf(&sb, "🚫 %d（配置文件，已被 %d 移除）\n", chat.ChatID, chat.UpdatedBy)
+		case chat.UpdatedBy != 0:
+			fmt.Fprintf(&sb, "✅ %d（由 %d 添加）\n", chat.ChatID, chat.UpdatedBy)
+		default:
+			fmt.Fprintf(&sb, "✅ %d（配置文件）\n", chat.ChatID)
+		}
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━\n")
+	if err != nil {
+		sb.WriteString("⚠️ 数据库暂不可用，仅显示配置文件中的群\n")
+	}
+	sb.WriteString("\n" + whitelistUsage)
+	return c.Reply(sb.String())
+}
@@ -0,0 +1,4 @@
+// Package integration holds end-to-end tests that drive the bot handlers with
+// simulated Telegram updates against a real PostgreSQL database.
+// Tests use testcontainers-go and are skipped when Docker is not available.
+package integration
@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// apiCall is a single recorded Bot API request
+type apiCall struct {
+	Method    string
+	Params    map[string]string
+	MessageID int // ID of the returned message, 0 if none
+}
+
+// fakeTelegram is a fake Telegram Bot API server.
+// It records every request and answers with minimal but well formed results,
+// rolling dice from a queue so dice outcomes are deterministic.
+type fakeTelegram struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	calls     []apiCall
+	dice      []int
+	messageID int
+}
+
+// newFakeTelegram starts a fake Bot API server that is closed with the test
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	f := &fakeTelegram{messageID: 1000}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// URL returns the API base URL to pass to tele.Settings
+func (f *fakeTelegram) URL() string {
+	return f.server.URL
+}
+
+// QueueDice queues the values returned by the next sendDice calls.
+// When the queue is empty dice roll 1.
+func (f *fakeTelegram) QueueDice(values ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dice = append(f.dice, values...)
+}
+
+// Calls returns the recorded requests of a method
+func (f *fakeTelegram) Calls(method string) []apiCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []apiCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// SentTexts returns the texts of all sent messages
+func (f *fakeTelegram) SentTexts() []string {
+	var texts []string
+	for _, call := range f.Calls("sendMessage") {
+		texts = append(texts, call.Params["text"])
+	}
+	return texts
+}
+
+// WaitForText waits until a sent message contains substr and returns it
+func (f *fakeTelegram) WaitForText(t *testing.T, substr string, timeout time.Duration) string {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, text := range f.SentTexts() {
+			if strings.Contains(text, substr) {
+				return text
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("no message containing %q was sent within %s, sent: %q", substr, timeout, f.SentTexts())
+	return ""
+}
+
+func (f *fakeTelegram) serve(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	var params map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&params)
+
+	f.mu.Lock()
+	call := apiCall{Method: method, Params: params}
+
+	var result interface{} = true
+	switch method {
+	case "sendMessage", "editMessageText", "sendDice":
+		msg := f.message(params)
+		if method == "sendDice" {
+			msg["dice"] = map[string]interface{}{"emoji": params["emoji"], "value": f.nextDice()}
+		}
+		call.MessageID = msg["message_id"].(int)
+		result = msg
+	}
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// message builds the message returned for a send or edit request
+func (f *fakeTelegram) message(params map[string]string) map[string]interface{} {
+	chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+
+	id := f.messageID
+	if edited, err := strconv.Atoi(params["message_id"]); err == nil {
+		id = edited
+	} else {
+		f.messageID++
+	}
+
+	return map[string]interface{}{
+		"message_id": id,
+		"date":       time.Now().Unix(),
+		"chat":       map[string]interface{}{"id": chatID, "type": "supergroup"},
+		"text":       params["text"],
+	}
+}
+
+// nextDice pops the next queued dice value
+func (f *fakeTelegram) nextDice() int {
+	if len(f.dice) == 0 {
+		return 1
+	}
+	value := f.dice[0]
+	f.dice = f.dice[1:]
+	return value
+}
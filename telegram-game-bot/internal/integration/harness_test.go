@@ -0,0 +1,263 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/game"
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/handler"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+)
+
+// initialBalance is the balance every new user starts with
+const initialBalance = 1000
+
+// testChat is the group chat all scenarios are played in
+var testChat = &tele.Chat{ID: -100123456, Type: tele.ChatSuperGroup, Title: "integration"}
+
+// harness wires the repositories, services and handlers like cmd/bot/main.go,
+// backed by a PostgreSQL container and a fake Telegram Bot API
+type harness struct {
+	t    *testing.T
+	pool *pgxpool.Pool
+	api  *fakeTelegram
+	bot  *tele.Bot
+
+	accountService  *service.AccountService
+	transferService *service.TransferService
+	shopService     *service.ShopService
+	inventoryRepo   *repository.InventoryRepository
+	sicboGame       *sicbo.SicBoGame
+	gameHandler     *handler.GameHandler
+
+	updateID  atomic.Int64
+	messageID atomic.Int64
+}
+
+// checkDockerAvailable checks if Docker is available and running
+func checkDockerAvailable() bool {
+	cmd := exec.Command("docker", "info")
+	err := cmd.Run()
+	return err == nil
+}
+
+// newHarness starts PostgreSQL, applies the migrations and wires the bot
+// Skips the test if Docker is not available
+func newHarness(t *testing.T) *harness {
+	if !checkDockerAvailable() {
+		t.Skip("Docker is not available, skipping integration test")
+	}
+
+	ctx := context.Background()
+	pool := setupDatabase(t, ctx)
+
+	cfg, err := config.Load(t.TempDir())
+	require.NoError(t, err)
+	// Scenario users are created moments before they are robbed
+	cfg.Games.Rob.NewUserGraceHours = 0
+
+	api := newFakeTelegram(t)
+	bot, err := tele.NewBot(tele.Settings{
+		Token:       "test",
+		URL:         api.URL(),
+		Offline:     true,
+		Synchronous: true,
+	})
+	require.NoError(t, err)
+
+	userRepo := repository.NewUserRepository(pool)
+	txRepo := repository.NewTransactionRepository(pool)
+	inventoryRepo := repository.NewInventoryRepository(pool)
+	compensationRepo := repository.NewCompensationRepository(pool)
+
+	accountService := service.NewAccountService(userRepo, txRepo, cfg.Daily.Reward, cfg.Daily.CooldownHours)
+	transferService := service.NewTransferService(userRepo, txRepo)
+	idempotencyRepo := repository.NewIdempotencyRepository(pool)
+	accountService.SetIdempotency(idempotencyRepo)
+	transferService.SetIdempotency(idempotencyRepo)
+	userLock := lock.NewUserLock()
+
+	gameRegistry := game.NewRegistry()
+	require.NoError(t, gameRegistry.Register(dice.New(&dice.Config{
+		MaxBet:   cfg.Games.Dice.MaxBet,
+		Cooldown: cfg.Games.Dice.CooldownSeconds,
+	})))
+	sicboGame := sicbo.New()
+
+	robGame := rob.NewRobGame(userRepo, txRepo, userLock)
+	robCfg := cfg.Games.Rob
+	robGame.SetAmountPolicy(rob.NewAmountPolicy(robCfg.AmountMode, robCfg.MinPercent, robCfg.MaxPercent, robCfg.MinAmount, robCfg.MaxAmount))
+	robGame.SetProtectionConfig(rob.ProtectionConfig{
+		NewUserGrace:    time.Duration(robCfg.NewUserGraceHours) * time.Hour,
+		ExtendCost:      robCfg.ProtectExtendCost,
+		ExtendDuration:  time.Duration(robCfg.ProtectExtendMinutes) * time.Minute,
+		MaxRemaining:    time.Duration(robCfg.ProtectMaxMinutes) * time.Minute,
+		VictimHourlyCap: robCfg.VictimHourlyCap,
+		PairDailyCap:    robCfg.PairDailyCap,
+	})
+	robGame.SetProtectionRepository(repository.NewRobProtectionRepository(pool))
+	robGame.SetHitRepository(repository.NewRobHitRepository(pool))
+
+	shopService := service.NewShopService(userRepo, txRepo, inventoryRepo, userLock)
+	robGame.SetItemChecker(shopService)
+
+	compensationService := service.NewCompensationService(
+		compensationRepo,
+		userRepo,
+		txRepo,
+		userLock,
+		cfg.Compensation.AutoApproveLimit,
+		cfg.Compensation.MaxPerUser,
+		cfg.Compensation.MaxPerIncident,
+	)
+
+	gameHandler := handler.NewGameHandler(cfg, accountService, compensationService, gameRegistry, sicboGame, robGame, userLock)
+	bot.Handle("/dice", gameHandler.HandleDice)
+	bot.Handle("/dj", gameHandler.HandleDajie)
+	bot.Handle("/sicbo", gameHandler.HandleSicBoStart)
+	bot.Handle("/sicbo_settle", gameHandler.HandleSicBoSettle)
+	bot.Handle(tele.OnText, gameHandler.HandleSicBoTextBet)
+
+	h := &harness{
+		t:               t,
+		pool:            pool,
+		api:             api,
+		bot:             bot,
+		accountService:  accountService,
+		transferService: transferService,
+		shopService:     shopService,
+		inventoryRepo:   inventoryRepo,
+		sicboGame:       sicboGame,
+		gameHandler:     gameHandler,
+	}
+	h.messageID.Store(1)
+	return h
+}
+
+// setupDatabase creates a PostgreSQL container with all migrations applied
+func setupDatabase(t *testing.T, ctx context.Context) *pgxpool.Pool {
+	pgContainer, err := postgres.Run(ctx,
+		"postgres:15-alpine",
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60*time.Second),
+		),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pgContainer.Terminate(ctx) })
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(ctx, connStr)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	require.NoError(t, applyMigrations(ctx, pool))
+	return pool
+}
+
+// applyMigrations applies the migrations/*.up.sql files in order
+func applyMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	files, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newUser registers a Telegram user and returns it
+func (h *harness) newUser(id int64, username string) *tele.User {
+	h.t.Helper()
+
+	_, _, err := h.accountService.EnsureUser(context.Background(), id, username)
+	require.NoError(h.t, err)
+	return &tele.User{ID: id, Username: username, FirstName: username}
+}
+
+// send delivers a group message from a user and returns it
+func (h *harness) send(from *tele.User, text string) *tele.Message {
+	return h.sendReply(from, text, nil)
+}
+
+// sendReply delivers a group message from a user replying to another message
+func (h *harness) sendReply(from *tele.User, text string, replyTo *tele.Message) *tele.Message {
+	msg := &tele.Message{
+		ID:       int(h.messageID.Add(1)),
+		Sender:   from,
+		Chat:     testChat,
+		Text:     text,
+		ReplyTo:  replyTo,
+		Unixtime: time.Now().Unix(),
+	}
+	h.bot.ProcessUpdate(tele.Update{ID: int(h.updateID.Add(1)), Message: msg})
+	return msg
+}
+
+// balance returns a user's current balance
+func (h *harness) balance(userID int64) int64 {
+	h.t.Helper()
+
+	balance, err := h.accountService.GetBalance(context.Background(), userID)
+	require.NoError(h.t, err)
+	return balance
+}
+
+// assertLedger checks the ledger invariants over all users:
+// every balance equals the initial balance plus the sum of the user's transactions,
+// and no balance is negative
+func (h *harness) assertLedger() {
+	h.t.Helper()
+
+	rows, err := h.pool.Query(context.Background(), `
+		SELECT u.telegram_id, u.balance, COALESCE(SUM(t.amount), 0)
+		FROM users u
+		LEFT JOIN transactions t ON t.user_id = u.telegram_id
+		GROUP BY u.telegram_id, u.balance
+	`)
+	require.NoError(h.t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, balance, ledger int64
+		require.NoError(h.t, rows.Scan(&userID, &balance, &ledger))
+		require.Equalf(h.t, initialBalance+ledger, balance,
+			"user %d: balance does not match the initial balance plus transactions", userID)
+		require.GreaterOrEqualf(h.t, balance, int64(0), "user %d: negative balance", userID)
+	}
+	require.NoError(h.t, rows.Err())
+}
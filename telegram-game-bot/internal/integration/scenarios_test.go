@@ -0,0 +1,255 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tele "gopkg.in/telebot.v3"
+
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/service"
+	"telegram-game-bot/internal/shop"
+)
+
+// TestScenario_DiceBet plays /dice with fixed dice and checks the payout reaches the ledger
+func TestScenario_DiceBet(t *testing.T) {
+	h := newHarness(t)
+	player := h.newUser(1001, "dice_player")
+
+	h.api.QueueDice(6, 5)
+	h.send(player, "/dice 100")
+
+	require.Len(t, h.api.Calls("sendDice"), 2)
+	result := h.api.WaitForText(t, "🎲🎲", 10*time.Second)
+	assert.Contains(t, result, "6 + 5 = 11")
+
+	expected := int64(initialBalance - 100)
+	if payout := dice.CalculatePayout(6, 5, 100); payout >= 0 {
+		expected = initialBalance + payout
+	}
+	assert.Equal(t, expected, h.balance(player.ID))
+
+	h.assertLedger()
+}
+
+// TestScenario_RobWithItems robs a shielded victim with and without a defense bypassing weapon
+func TestScenario_RobWithItems(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	robber := h.newUser(2001, "robber")
+	knifeRobber := h.newUser(2002, "knife_robber")
+	victim := h.newUser(2003, "victim")
+
+	desc := "integration top up"
+	for _, user := range []*tele.User{robber, knifeRobber} {
+		_, err := h.accountService.UpdateBalance(ctx, user.ID, 5000, model.TxTypeAdminAdd, &desc, "")
+		require.NoError(t, err)
+	}
+	require.NoError(t, h.shopService.PurchaseItem(ctx, robber.ID, shop.ItemBloodthirstSword))
+	require.NoError(t, h.shopService.PurchaseItem(ctx, knifeRobber.ID, shop.ItemBluntKnife))
+	require.NoError(t, h.shopService.PurchaseItem(ctx, victim.ID, shop.ItemShield))
+
+	victimMsg := h.send(victim, "hello")
+	victimBalance := h.balance(victim.ID)
+	shieldExpiry := h.shopService.GetEffectExpiry(ctx, victim.ID, shop.ItemShield)
+	require.False(t, shieldExpiry.IsZero(), "shield should be active")
+
+	// The shield blocks a plain robbery without being used up
+	h.sendReply(robber, "/dj", victimMsg)
+	h.api.WaitForText(t, "目标有保护罩", time.Second)
+	assert.Equal(t, victimBalance, h.balance(victim.ID))
+	assert.True(t, shieldExpiry.Equal(h.shopService.GetEffectExpiry(ctx, victim.ID, shop.ItemShield)))
+
+	// The blunt knife ignores the shield, which stays active
+	replies := len(h.api.SentTexts())
+	h.sendReply(knifeRobber, "/dj", victimMsg)
+	require.Greater(t, len(h.api.SentTexts()), replies, "robbery should be answered")
+	assert.True(t, h.shopService.HasShield(ctx, victim.ID))
+
+	h.assertLedger()
+}
+
+// TestScenario_TimedShield buys the shield, a duration based item, and checks
+// it is active for an hour, extends on a second purchase and expires
+func TestScenario_TimedShield(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	buyer := h.newUser(2101, "shield_buyer")
+	item, ok := shop.GetItem(shop.ItemShield)
+	require.True(t, ok)
+	require.True(t, item.IsDurationBased())
+
+	require.NoError(t, h.shopService.PurchaseItem(ctx, buyer.ID, shop.ItemShield))
+	assert.True(t, h.shopService.HasShield(ctx, buyer.ID))
+	expiry := h.shopService.GetEffectExpiry(ctx, buyer.ID, shop.ItemShield)
+	assert.WithinDuration(t, time.Now().Add(item.ActiveDuration), expiry, time.Minute)
+	assert.Equal(t, initialBalance-item.Price, h.balance(buyer.ID))
+
+	// A timed effect is not used up, and holds no uses
+	remaining, consumed := h.shopService.UseItem(ctx, buyer.ID, string(shop.ItemShield))
+	assert.False(t, consumed)
+	assert.Zero(t, remaining)
+	assert.True(t, h.shopService.HasShield(ctx, buyer.ID))
+
+	// A second purchase extends the effect instead of starting a new one
+	require.NoError(t, h.shopService.PurchaseItem(ctx, buyer.ID, shop.ItemShield))
+	extended := h.shopService.GetEffectExpiry(ctx, buyer.ID, shop.ItemShield)
+	assert.WithinDuration(t, expiry.Add(item.ActiveDuration), extended, time.Second)
+	inventory, err := h.shopService.GetUserInventory(ctx, buyer.ID)
+	require.NoError(t, err)
+	require.Len(t, inventory.Effects, 1)
+	assert.Empty(t, inventory.Items)
+
+	// Once its time runs out the shield no longer protects
+	_, err = h.pool.Exec(ctx, `UPDATE user_effects SET expires_at = NOW() - INTERVAL '1 second' WHERE user_id = $1`, buyer.ID)
+	require.NoError(t, err)
+	assert.False(t, h.shopService.HasShield(ctx, buyer.ID))
+	assert.True(t, h.shopService.GetEffectExpiry(ctx, buyer.ID, shop.ItemShield).IsZero())
+	inventory, err = h.shopService.GetUserInventory(ctx, buyer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, inventory.Effects)
+
+	h.assertLedger()
+}
+
+// TestScenario_IdempotentRetries retries a credit and a transfer with their
+// idempotency keys and checks each is applied once
+func TestScenario_IdempotentRetries(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	payer := h.newUser(4001, "payer")
+	payee := h.newUser(4002, "payee")
+
+	desc := "integration import"
+	first, err := h.accountService.UpdateBalance(ctx, payer.ID, 500, model.TxTypeAdminAdd, &desc, "import-4001")
+	require.NoError(t, err)
+	replay, err := h.accountService.UpdateBalance(ctx, payer.ID, 500, model.TxTypeAdminAdd, &desc, "import-4001")
+	require.NoError(t, err)
+	assert.Equal(t, first.Balance, replay.Balance)
+	assert.Equal(t, int64(initialBalance+500), h.balance(payer.ID))
+
+	// The key belongs to the credit above
+	_, err = h.accountService.UpdateBalance(ctx, payer.ID, 700, model.TxTypeAdminAdd, &desc, "import-4001")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+
+	// A failed transfer frees its key for the retry
+	err = h.transferService.Transfer(ctx, payer.ID, payee.ID, 5000, "api-transfer-1")
+	require.ErrorIs(t, err, service.ErrInsufficientBalance)
+	_, err = h.accountService.UpdateBalance(ctx, payer.ID, 5000, model.TxTypeAdminAdd, &desc, "")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, h.transferService.Transfer(ctx, payer.ID, payee.ID, 5000, "api-transfer-1"))
+	}
+	assert.Equal(t, int64(initialBalance+500), h.balance(payer.ID))
+	assert.Equal(t, int64(initialBalance+5000), h.balance(payee.ID))
+
+	h.assertLedger()
+}
+
+// TestScenario_SicBoRound plays a sicbo round from start to manual settlement with text bets
+func TestScenario_SicBoRound(t *testing.T) {
+	h := newHarness(t)
+
+	starter := h.newUser(3001, "starter")
+	big := h.newUser(3002, "big_player")
+	small := h.newUser(3003, "small_player")
+
+	h.send(starter, "/sicbo")
+	require.True(t, h.sicboGame.IsSessionActive(sicbo.SessionKey{ChatID: testChat.ID}))
+
+	panels := h.api.Calls("sendMessage")
+	require.NotEmpty(t, panels)
+	panel := &tele.Message{ID: panels[len(panels)-1].MessageID, Chat: testChat}
+
+	h.sendReply(big, "大 100", panel)
+	h.sendReply(small, "小 200", panel)
+
+	reactions := h.api.Calls("setMessageReaction")
+	require.Len(t, reactions, 2)
+	for _, reaction := range reactions {
+		assert.Contains(t, reaction.Params["reaction"], "👍")
+	}
+	assert.Equal(t, int64(initialBalance-100), h.balance(big.ID))
+	assert.Equal(t, int64(initialBalance-200), h.balance(small.ID))
+
+	h.send(starter, "/sicbo_settle")
+	assert.False(t, h.sicboGame.IsSessionActive(sicbo.SessionKey{ChatID: testChat.ID}))
+	settlement := h.api.WaitForText(t, "骰宝开奖", time.Second)
+	assert.True(t, strings.Contains(settlement, "@starter"), "settlement should name the starter")
+
+	// Big and small never both win, and the starter did not bet
+	won := 0
+	for _, player := range []*tele.User{big, small} {
+		if h.balance(player.ID) > initialBalance {
+			won++
+		}
+	}
+	assert.LessOrEqual(t, won, 1)
+	assert.Equal(t, int64(initialBalance), h.balance(starter.ID))
+
+	h.assertLedger()
+}
+
+// TestScenario_MergeProfitTotals merges an account with aggregated and recent
+// game profit and checks the /my totals of the new account add up both
+func TestScenario_MergeProfitTotals(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	oldUser := h.newUser(5001, "old_account")
+	newUser := h.newUser(5002, "new_account")
+
+	userRepo := repository.NewUserRepository(h.pool)
+	txRepo := repository.NewTransactionRepository(h.pool)
+	ranking := service.NewRankingService(userRepo, txRepo, time.Local)
+	ranking.SetAggregates(repository.NewUserAggregateRepository(h.pool))
+
+	// Both played on a finished day, which is aggregated, and today
+	played := time.Now().AddDate(0, 0, -2)
+	desc := "integration game"
+	for _, g := range []struct {
+		userID int64
+		past   int64
+		today  int64
+	}{{oldUser.ID, 300, -50}, {newUser.ID, -100, 20}} {
+		_, err := txRepo.CreateWithTime(ctx, g.userID, g.past, model.TxTypeDice, &desc, played)
+		require.NoError(t, err)
+		_, err = userRepo.UpdateBalance(ctx, g.userID, g.past)
+		require.NoError(t, err)
+		_, err = h.accountService.UpdateBalance(ctx, g.userID, g.today, model.TxTypeDice, &desc, "")
+		require.NoError(t, err)
+	}
+	ranking.RunAggregation(ctx, time.Now())
+
+	oldBefore, err := ranking.GetUserProfitSummary(ctx, oldUser.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &service.ProfitSummary{Today: -50, Week: 250, Lifetime: 250}, oldBefore)
+	newBefore, err := ranking.GetUserProfitSummary(ctx, newUser.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &service.ProfitSummary{Today: 20, Week: -80, Lifetime: -80}, newBefore)
+
+	// The ledger invariant of assertLedger does not hold across a merge, the
+	// initial balance of the old account moves without a transaction
+	merges := service.NewMergeService(repository.NewMergeRepository(h.pool), userRepo, lock.NewUserLock())
+	_, err = merges.Merge(ctx, service.MergeRequest{OldID: oldUser.ID, NewID: newUser.ID, AdminID: 1, Reason: "integration"})
+	require.NoError(t, err)
+
+	oldAfter, err := ranking.GetUserProfitSummary(ctx, oldUser.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &service.ProfitSummary{}, oldAfter)
+	newAfter, err := ranking.GetUserProfitSummary(ctx, newUser.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &service.ProfitSummary{Today: -30, Week: 170, Lifetime: 170}, newAfter)
+	assert.Equal(t, int64(2*initialBalance+170), h.balance(newUser.ID))
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// HandleLength is the number of characters after the '#' of a user handle
+const HandleLength = 6
+
+// handleAlphabet is the character set of user handles (uppercase hex)
+const handleAlphabet = "0123456789ABCDEF"
+
+// NewHandle returns a random user handle such as "#A1B2C3".
+// Uniqueness is enforced by the users.handle unique index; callers retry on conflict.
+func NewHandle() string {
+	buf := make([]byte, HandleLength)
+	_, _ = rand.Read(buf)
+	var sb strings.Builder
+	sb.WriteByte('#')
+	for _, b := range buf {
+		sb.WriteByte(handleAlphabet[int(b)%len(handleAlphabet)])
+	}
+	return sb.String()
+}
+
+// ParseHandle normalizes user input such as "#a1b2c3" to "#A1B2C3".
+// Returns false if s is not a well formed handle.
+func ParseHandle(s string) (string, bool) {
+	if len(s) != HandleLength+1 || s[0] != '#' {
+		return "", false
+	}
+	handle := strings.ToUpper(s)
+	for _, c := range handle[1:] {
+		if !strings.ContainsRune(handleAlphabet, c) {
+			return "", false
+		}
+	}
+	return handle, true
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestNewHandleParsesProperty tests that generated handles are well formed
+// and survive ParseHandle in any letter case.
+func TestNewHandleParsesProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		handle := NewHandle()
+		if len(handle) != HandleLength+1 {
+			t.Fatalf("Handle %q has length %d", handle, len(handle))
+		}
+
+		input := handle
+		if rapid.Bool().Draw(t, "lower") {
+			input = strings.ToLower(handle)
+		}
+		parsed, ok := ParseHandle(input)
+		if !ok || parsed != handle {
+			t.Fatalf("ParseHandle(%q) = %q, %v; want %q", input, parsed, ok, handle)
+		}
+	})
+}
+
+// TestParseHandleRejects tests malformed handles
+func TestParseHandleRejects(t *testing.T) {
+	for _, input := range []string{"", "#", "A1B2C3", "#A1B2C", "#A1B2C3D", "#G1B2C3", "@A1B2C3", "#A1 2C3"} {
+		if _, ok := ParseHandle(input); ok {
+			t.Errorf("ParseHandle(%q) should fail", input)
+		}
+	}
+}
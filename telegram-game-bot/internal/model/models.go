@@ -1,17 +1,22 @@
 // Package model defines the data models for the Telegram game bot.
 package model
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 // User represents a Telegram user account in the game system.
 // Requirements: 8.1 - users table with telegram_id, username, balance, last_daily_claim, created_at, updated_at
 type User struct {
-	TelegramID     int64     `db:"telegram_id"`
-	Username       string    `db:"username"`
-	Balance        int64     `db:"balance"`
-	LastDailyClaim int64     `db:"last_daily_claim"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+	TelegramID          int64     `db:"telegram_id"`
+	Username            string    `db:"username"`
+	Balance             int64     `db:"balance"`
+	LastDailyClaim      int64     `db:"last_daily_claim"`
+	HideFromLeaderboard bool      `db:"hide_from_leaderboard"` // Shown anonymously on public leaderboards
+	Handle              string    `db:"handle"`                // Stable public handle, e.g. "#A1B2C3"
+	CreatedAt           time.Time `db:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at"`
 }
 
 // Transaction represents a balance change record.
@@ -25,14 +30,627 @@ type Transaction struct {
 	CreatedAt   time.Time `db:"created_at"`
 }
 
+// TransactionExport is a transaction as written to a user's data export.
+type TransactionExport struct {
+	Transaction
+	Counterparty *int64 // Other account of the ledger entry (0 = treasury), nil if not booked
+}
+
 // DailyRank represents a user's daily game performance for ranking.
-// Used by the daily_game_stats view for winner/loser rankings.
+// Read from the game transactions for the daily winner/loser rankings.
 type DailyRank struct {
 	UserID    int64  `db:"user_id"`
 	Username  string `db:"username"`
+	Hidden    bool   `db:"hide_from_leaderboard"`
 	NetProfit int64  `db:"net_profit"`
 }
 
+// TxTypeTotals sums the transactions of one type, split by direction.
+// Credits without a description are refunds of a failed or void bet.
+type TxTypeTotals struct {
+	Type     string `db:"type"`
+	Debits   int64  `db:"debits"`   // Number of negative transactions
+	Debited  int64  `db:"debited"`  // Sum of the negative amounts, as a positive number
+	Credited int64  `db:"credited"` // Sum of the positive amounts with a description
+	Refunded int64  `db:"refunded"` // Sum of the positive amounts without a description
+}
+
+// PromoCode represents an admin-generated gift code.
+// A code grants either coins (RewardAmount) or an item (RewardItem) and can be
+// redeemed at most once per user, up to MaxUses times in total.
+type PromoCode struct {
+	Code         string     `db:"code"`
+	RewardAmount int64      `db:"reward_amount"`
+	RewardItem   *string    `db:"reward_item"`
+	MaxUses      int        `db:"max_uses"`
+	UsedCount    int        `db:"used_count"`
+	ExpiresAt    *time.Time `db:"expires_at"`
+	CreatedBy    int64      `db:"created_by"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// IsSingleUse reports whether the code can only be redeemed once in total.
+func (p *PromoCode) IsSingleUse() bool {
+	return p.MaxUses == 1
+}
+
+// IsExpired reports whether the code has expired at the given time.
+// Codes without an expiry never expire.
+func (p *PromoCode) IsExpired(now time.Time) bool {
+	return p.ExpiresAt != nil && !now.Before(*p.ExpiresAt)
+}
+
+// IsExhausted reports whether the code has no remaining uses.
+func (p *PromoCode) IsExhausted() bool {
+	return p.UsedCount >= p.MaxUses
+}
+
+// SupportTicket represents a user issue forwarded to the admin support chat.
+type SupportTicket struct {
+	ID           int64      `db:"id"`
+	UserID       int64      `db:"user_id"`
+	Message      string     `db:"message"`
+	Status       string     `db:"status"`
+	ResolvedBy   *int64     `db:"resolved_by"`
+	RefundTxID   *int64     `db:"refund_tx_id"`
+	RefundAmount int64      `db:"refund_amount"`
+	CreatedAt    time.Time  `db:"created_at"`
+	ResolvedAt   *time.Time `db:"resolved_at"`
+}
+
+// Support ticket statuses.
+const (
+	TicketStatusOpen      = "open"
+	TicketStatusRefunded  = "refunded"
+	TicketStatusDismissed = "dismissed"
+)
+
+// TransactionRefund is the audit record of an admin reversing a transaction.
+// A transaction can be reversed at most once (TxID is unique).
+type TransactionRefund struct {
+	TxID          int64     `db:"tx_id"`          // Reversed transaction
+	UserID        int64     `db:"user_id"`        // Owner of the reversed transaction
+	Amount        int64     `db:"amount"`         // Balance change of the reversal (-original amount)
+	RefundTxID    *int64    `db:"refund_tx_id"`   // Compensating transaction
+	RefundedBy    int64     `db:"refunded_by"`    // Admin who reversed it
+	Reason        string    `db:"reason"`         // Optional note from the admin
+	ItemType      *string   `db:"item_type"`      // Item of a reversed shop purchase
+	ItemUses      int       `db:"item_uses"`      // Item uses removed from the inventory
+	EffectSeconds int64     `db:"effect_seconds"` // Timed effect removed, in seconds
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// IdempotencyKey records a balance change applied under a caller's key, so a
+// retried request gets the original result instead of being applied again.
+type IdempotencyKey struct {
+	Key         string    `db:"key"`
+	Operation   string    `db:"operation"`   // Method the key was used with, e.g. "transfer"
+	Fingerprint string    `db:"fingerprint"` // Parameters of the request; a replay must match them
+	Completed   bool      `db:"completed"`   // Set in the same transaction as the change
+	UserID      int64     `db:"user_id"`     // User of the result
+	Balance     int64     `db:"balance"`     // Balance of the user after the change
+	CreatedAt   time.Time `db:"created_at"`  // When the change was applied
+}
+
+// ChatPersona is a group's customization of the bot's flavor text.
+// Empty fields fall back to the built-in texts.
+type ChatPersona struct {
+	ChatID       int64     `db:"chat_id"`
+	Nickname     string    `db:"nickname"`      // Bot nickname used in templates ({bot})
+	WinTemplate  string    `db:"win_template"`  // Template of game win lines
+	LoseTemplate string    `db:"lose_template"` // Template of game loss lines
+	Theme        string    `db:"theme"`         // Emoji theme
+	NoPings      bool      `db:"no_pings"`      // Game results name players without notifying them
+	UpdatedBy    int64     `db:"updated_by"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// StarPurchase is a ledger entry of a cosmetic paid with Telegram Stars.
+// Star purchases never touch coin balances or the transactions table.
+type StarPurchase struct {
+	ID               int64     `db:"id"`
+	UserID           int64     `db:"user_id"`
+	ItemID           string    `db:"item_id"`
+	Currency         string    `db:"currency"`
+	Amount           int       `db:"amount"` // Total paid in the smallest currency unit (Stars)
+	Payload          string    `db:"payload"`
+	TelegramChargeID string    `db:"telegram_charge_id"` // Unique, makes redelivered payments idempotent
+	ProviderChargeID string    `db:"provider_charge_id"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// UserCosmetic is a cosmetic owned by a user.
+type UserCosmetic struct {
+	UserID     int64     `db:"user_id"`
+	ItemID     string    `db:"item_id"`
+	Kind       string    `db:"kind"`
+	Equipped   bool      `db:"equipped"` // At most one equipped cosmetic per kind
+	PurchaseID int64     `db:"purchase_id"`
+	AcquiredAt time.Time `db:"acquired_at"`
+}
+
+// SicBoAutoSchedule makes sicbo rounds start automatically in a chat.
+// Rounds start every IntervalMinutes from StartHour up to EndHour (local time);
+// equal hours mean all day.
+type SicBoAutoSchedule struct {
+	ChatID          int64     `db:"chat_id"`
+	IntervalMinutes int       `db:"interval_minutes"`
+	StartHour       int       `db:"start_hour"`
+	EndHour         int       `db:"end_hour"`
+	Enabled         bool      `db:"enabled"`
+	UpdatedBy       int64     `db:"updated_by"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// TreasurySnapshot is the state of the house account at one point in time.
+// Every transaction is booked against the treasury, so Balance + UsersTotal
+// stays zero unless a balance changed without a transaction.
+type TreasurySnapshot struct {
+	Balance    int64     `db:"balance"`     // Treasury balance, negative for coins in circulation
+	UsersTotal int64     `db:"users_total"` // Sum of all user balances
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// PvPPreference records whether a user takes part in PvP features
+// (rob, all-in rob, duels and handcuffs).
+type PvPPreference struct {
+	UserID    int64     `db:"user_id"`
+	OptedOut  bool      `db:"opted_out"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+// CelebrationMedia is a sticker or animation sent after a jackpot-class win.
+type CelebrationMedia struct {
+	ID        int64     `db:"id"`
+	Kind      string    `db:"kind"`       // Win that triggers the media
+	MediaType string    `db:"media_type"` // CelebrationSticker or CelebrationAnimation
+	FileID    string    `db:"file_id"`    // Telegram file ID
+	AddedBy   int64     `db:"added_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// BanterPhrase is a short taunt or congratulation occasionally appended to game results.
+type BanterPhrase struct {
+	ID        int64     `db:"id"`
+	Kind      string    `db:"kind"`   // BanterWin or BanterLose
+	Locale    string    `db:"locale"` // Language of the phrase, e.g. zh or en
+	Text      string    `db:"text"`
+	AddedBy   int64     `db:"added_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// RobMessage is a flavor text of a robbery result, picked from the pool of
+// its outcome with the weight of its rarity tier.
+type RobMessage struct {
+	ID        int64     `db:"id"`      // 0 for messages defined in the config file
+	Outcome   string    `db:"outcome"` // RobMessageFail, RobMessageCounter, RobMessageSuccess or RobMessageCritical
+	Tier      string    `db:"tier"`    // RobTierCommon, RobTierRare or RobTierEpic
+	Text      string    `db:"text"`    // Template, see RobMessageVars
+	AddedBy   int64     `db:"added_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// OpenBlackjackHand is the stake of a blackjack hand in play, kept until the
+// hand is settled so it can be refunded if the bot stops before that.
+type OpenBlackjackHand struct {
+	UserID        int64     `db:"user_id"`
+	ChatID        int64     `db:"chat_id"`
+	SandboxChatID int64     `db:"sandbox_chat_id"` // Balance scope the stake was paid from, 0 for the real balance
+	Stake         int64     `db:"stake"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// MediaAsset is a Telegram file used by the bot, e.g. the shop banner.
+// File IDs are only valid for the bot that received the file.
+type MediaAsset struct {
+	Key       string    `db:"key"`        // Asset name, e.g. MediaAssetShopBanner
+	MediaType string    `db:"media_type"` // MediaPhoto, MediaAnimation or MediaSticker
+	FileID    string    `db:"file_id"`
+	UpdatedBy int64     `db:"updated_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Media asset types.
+const (
+	MediaPhoto     = "photo"
+	MediaAnimation = "animation"
+	MediaSticker   = "sticker"
+)
+
+// Media asset keys.
+const (
+	MediaAssetShopBanner = "shop_banner" // Photo above the shop panel
+)
+
+// BalancePoint is the balance of a user on a day.
+type BalancePoint struct {
+	UserID  int64     `db:"user_id"`
+	Day     time.Time `db:"day"`
+	Balance int64     `db:"balance"` // Latest balance seen that day
+}
+
+// UserMerge records a duplicate account merged into a user's new account.
+type UserMerge struct {
+	ID           int64     `db:"id"`
+	OldID        int64     `db:"old_id"`
+	NewID        int64     `db:"new_id"`
+	Balance      int64     `db:"balance"`      // Balance moved to the new account
+	Items        int       `db:"items"`        // Inventory rows moved
+	Effects      int       `db:"effects"`      // Active effects moved
+	Cosmetics    int       `db:"cosmetics"`    // Cosmetics moved
+	Transactions int       `db:"transactions"` // Transactions re-pointed
+	Compensation int64     `db:"compensation"` // Coins credited for item uses over the stack caps
+	MergedBy     int64     `db:"merged_by"`
+	Reason       string    `db:"reason"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// ChatSettings are the choices a group made in the setup wizard.
+type ChatSettings struct {
+	ChatID         int64      `db:"chat_id"`
+	GamesEnabled   bool       `db:"games_enabled"`
+	Timezone       string     `db:"timezone"`        // IANA name, empty = bot default
+	CleanupMinutes int        `db:"cleanup_minutes"` // Delay before bot messages are deleted, 0 = keep
+	QuickBets      bool       `db:"quick_bets"`      // Shorthand bets such as d100 are accepted
+	QuietStart     int        `db:"quiet_start"`     // First quiet hour in the chat timezone
+	QuietEnd       int        `db:"quiet_end"`       // First hour after the quiet hours, equal to QuietStart = none
+	OnboardedAt    *time.Time `db:"onboarded_at"`    // When the setup wizard was posted
+	UpdatedBy      int64      `db:"updated_by"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// SandboxChat is a group whose games are played with play money.
+type SandboxChat struct {
+	ChatID    int64     `db:"chat_id"`
+	EnabledBy int64     `db:"enabled_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// RobInsurancePool is the community pool funded by a cut of successful robs
+// that compensates victims left below a balance floor.
+type RobInsurancePool struct {
+	Balance   int64     `db:"balance"`
+	Collected int64     `db:"collected"` // All cuts ever paid in
+	PaidOut   int64     `db:"paid_out"`  // All compensation ever paid
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Feature flag scopes, from the broadest to the most specific override.
+const (
+	FlagScopeGlobal = "global"
+	FlagScopeChat   = "chat"
+	FlagScopeUser   = "user"
+)
+
+// FeatureFlag overrides the configured default of a feature for everyone,
+// one chat or one user.
+type FeatureFlag struct {
+	Flag      string    `db:"flag"`
+	Scope     string    `db:"scope"`     // FlagScopeGlobal, FlagScopeChat or FlagScopeUser
+	TargetID  int64     `db:"target_id"` // Chat or user ID, 0 for global
+	Enabled   bool      `db:"enabled"`
+	UpdatedBy int64     `db:"updated_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Games with recorded rounds
+const (
+	GameRoundDice = "dice" // Values are both dice
+	GameRoundSlot = "slot" // Values is the slot machine value
+)
+
+// GameRound is the recorded input and outcome of a house game round, so the
+// outcome can be replayed with cmd/replay.
+type GameRound struct {
+	ID            int64     `db:"id"`
+	Game          string    `db:"game"` // GameRoundDice or GameRoundSlot
+	UserID        int64     `db:"user_id"`
+	ChatID        int64     `db:"chat_id"`
+	Bet           int64     `db:"bet"`
+	Values        []int     `db:"dice_values"`    // Values rolled by Telegram
+	Premium       int64     `db:"premium"`        // Dice insurance premium, 0 if uninsured
+	RefundPercent int       `db:"refund_percent"` // Share of a lost insured stake refunded
+	Payout        int64     `db:"payout"`         // Net payout, negative for a loss
+	Refund        int64     `db:"refund"`         // Insurance refund paid
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// ChatWhitelistEntry adds a chat to the configured whitelist or removes one
+// from it, changed by admins with /whitelist.
+type ChatWhitelistEntry struct {
+	ChatID    int64     `db:"chat_id"`
+	Allowed   bool      `db:"allowed"` // false removes a configured chat
+	UpdatedBy int64     `db:"updated_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// BalanceScope selects the balance a game is played with: the real balance,
+// or the play money of a sandbox chat that never touches the real economy.
+type BalanceScope struct {
+	SandboxChatID int64 // Sandbox chat whose play money is used, 0 for the real balance
+}
+
+// RealBalance is the scope of the real balance.
+var RealBalance = BalanceScope{}
+
+// SandboxBalance returns the scope of a sandbox chat's play money.
+func SandboxBalance(chatID int64) BalanceScope {
+	return BalanceScope{SandboxChatID: chatID}
+}
+
+// IsSandbox reports whether the scope is play money.
+func (s BalanceScope) IsSandbox() bool {
+	return s.SandboxChatID != 0
+}
+
+// Celebration media types.
+const (
+	CelebrationSticker   = "sticker"
+	CelebrationAnimation = "animation"
+)
+
+// Celebration kinds, the wins that trigger celebration media.
+const (
+	CelebrationSlotTriple     = "slot_triple"      // Slot machine three of a kind
+	CelebrationGreatSwordCrit = "great_sword_crit" // Critical great sword robbery
+	CelebrationRaidVictory    = "raid_victory"     // Chat won a raid event
+)
+
+// Banter kinds, the results a banter phrase is appended to.
+const (
+	BanterWin  = "win"  // Congratulations after a win
+	BanterLose = "lose" // Taunts after a loss
+)
+
+// Rob message outcomes, the robbery results a flavor text replaces.
+const (
+	RobMessageFail     = "fail"     // Robbery failed, nothing taken
+	RobMessageCounter  = "counter"  // Victim counter-attacked, robber lost {amount}
+	RobMessageSuccess  = "success"  // Robber took {amount}
+	RobMessageCritical = "critical" // Great sword critical hit took {amount}
+)
+
+// Rob message rarity tiers, from most to least frequent.
+const (
+	RobTierCommon = "common"
+	RobTierRare   = "rare"
+	RobTierEpic   = "epic"
+)
+
+// RobMessageOutcomes lists the rob message outcomes
+var RobMessageOutcomes = []string{RobMessageFail, RobMessageCounter, RobMessageSuccess, RobMessageCritical}
+
+// RobMessageTiers lists the rob message rarity tiers
+var RobMessageTiers = []string{RobTierCommon, RobTierRare, RobTierEpic}
+
+// RobMessageVars are the variables rob message templates may use: the
+// players, the coins taken or lost and the item that shaped the outcome
+// (the robber's weapon, or the victim's thorn armor on counter-attacks;
+// empty without one).
+var RobMessageVars = []string{"robber", "victim", "amount", "item"}
+
+// BailoutState tracks a user's eligibility for recovery grants.
+type BailoutState struct {
+	UserID      int64      `db:"user_id"`
+	BelowSince  *time.Time `db:"below_since"`   // Since when the balance is below the floor, nil if above
+	LastGrantAt *time.Time `db:"last_grant_at"` // Last recovery grant, nil if never
+}
+
+// ComebackActivity is the recent play of a regular who stopped playing,
+// a candidate for a welcome-back offer.
+type ComebackActivity struct {
+	UserID      int64      `db:"user_id"`
+	LastPlayed  time.Time  `db:"last_played"`   // Last game transaction
+	PlayDays    int        `db:"play_days"`     // Days with a game transaction in the lookback before LastPlayed
+	MonthOffers int        `db:"month_offers"`  // Offers made this calendar month
+	LastOfferAt *time.Time `db:"last_offer_at"` // Latest offer, nil if never
+}
+
+// ComebackOffer is a welcome-back bonus offered by DM.
+type ComebackOffer struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	Bonus     int64      `db:"bonus"`
+	Delivered bool       `db:"delivered"`  // Whether the DM reached the user
+	ExpiresAt time.Time  `db:"expires_at"` // Last moment the bonus can be claimed
+	ClaimedAt *time.Time `db:"claimed_at"` // Nil until claimed
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// ComebackStats measures the welcome-back offers made over a period.
+type ComebackStats struct {
+	Offered   int   // Offers delivered by DM
+	Claimed   int   // Delivered offers whose bonus was claimed
+	Returned  int   // Delivered offers followed by a game played by the user
+	BonusPaid int64 // Coins of the claimed bonuses
+}
+
+// CompensationIncident groups compensation entries caused by one bot failure
+// (e.g. a failed settlement or a failed credit).
+type CompensationIncident struct {
+	ID          int64      `db:"id"`
+	Kind        string     `db:"kind"`
+	Description string     `db:"description"`
+	Status      string     `db:"status"`
+	TotalAmount int64      `db:"total_amount"`
+	ReviewedBy  *int64     `db:"reviewed_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ResolvedAt  *time.Time `db:"resolved_at"`
+}
+
+// CompensationEntry is a single user's compensation within an incident.
+type CompensationEntry struct {
+	ID         int64 `db:"id"`
+	IncidentID int64 `db:"incident_id"`
+	UserID     int64 `db:"user_id"`
+	Amount     int64 `db:"amount"`
+	Paid       bool  `db:"paid"`
+}
+
+// Compensation incident statuses.
+const (
+	IncidentStatusPending  = "pending"  // Waiting for admin approval (large totals)
+	IncidentStatusPaid     = "paid"     // Compensation credited
+	IncidentStatusRejected = "rejected" // Rejected by admin
+)
+
+// RobProtection is the persisted rob protection state of a user.
+type RobProtection struct {
+	UserID           int64     `db:"user_id"`
+	ConsecutiveCount int       `db:"consecutive_count"`
+	ProtectedUntil   time.Time `db:"protected_until"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+// RaidEvent is a timed rob competition between two chats.
+// Successful robs of members of the opposing side score points; the side with
+// more points splits the prize pool.
+type RaidEvent struct {
+	ID         int64     `db:"id"`
+	ChatA      int64     `db:"chat_a"`
+	ChatB      int64     `db:"chat_b"`
+	PrizePool  int64     `db:"prize_pool"`
+	Status     string    `db:"status"`
+	StartsAt   time.Time `db:"starts_at"`
+	EndsAt     time.Time `db:"ends_at"`
+	WinnerChat *int64    `db:"winner_chat"`
+	CreatedBy  int64     `db:"created_by"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// HasChat reports whether chatID is one of the two sides.
+func (r *RaidEvent) HasChat(chatID int64) bool {
+	return chatID == r.ChatA || chatID == r.ChatB
+}
+
+// RaidMember is a user who joined a raid for one side.
+type RaidMember struct {
+	RaidID   int64     `db:"raid_id"`
+	UserID   int64     `db:"user_id"`
+	ChatID   int64     `db:"chat_id"`
+	Points   int       `db:"points"`
+	JoinedAt time.Time `db:"joined_at"`
+}
+
+// Raid event statuses.
+const (
+	RaidStatusScheduled = "scheduled" // Waiting for StartsAt
+	RaidStatusActive    = "active"    // Robs between the sides score points
+	RaidStatusFinished  = "finished"  // Settled, prize paid
+	RaidStatusCancelled = "cancelled" // Cancelled by an admin
+)
+
+// Pool is a parimutuel betting question opened by an admin in a chat.
+// Bets go into the pool of an outcome; when the admin resolves the question,
+// the whole pool minus the rake is split among the bets on the winning outcome.
+type Pool struct {
+	ID             int64      `db:"id"`
+	ChatID         int64      `db:"chat_id"`
+	Question       string     `db:"question"`
+	Outcomes       []string   `db:"outcomes"`
+	Status         string     `db:"status"`
+	RakePercent    int        `db:"rake_percent"`
+	ClosesAt       time.Time  `db:"closes_at"`       // Betting window end
+	WinningOutcome *int       `db:"winning_outcome"` // Index into Outcomes once resolved
+	CreatedBy      int64      `db:"created_by"`
+	SandboxChatID  int64      `db:"sandbox_chat_id"` // Sandbox chat whose play money is staked, 0 for real coins
+	CreatedAt      time.Time  `db:"created_at"`
+	ResolvedAt     *time.Time `db:"resolved_at"`
+}
+
+// Scope returns the balance the pool's stakes are taken from and paid to.
+func (p *Pool) Scope() BalanceScope {
+	return SandboxBalance(p.SandboxChatID)
+}
+
+// PoolBet is a stake on one outcome of a pool.
+type PoolBet struct {
+	ID        int64     `db:"id"`
+	PoolID    int64     `db:"pool_id"`
+	UserID    int64     `db:"user_id"`
+	Outcome   int       `db:"outcome"`
+	Amount    int64     `db:"amount"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// PoolResolution is the audit record of a pool being resolved or cancelled.
+type PoolResolution struct {
+	ID             int64     `db:"id"`
+	PoolID         int64     `db:"pool_id"`
+	AdminID        int64     `db:"admin_id"`
+	Action         string    `db:"action"`          // PoolStatusResolved or PoolStatusCancelled
+	WinningOutcome *int      `db:"winning_outcome"` // Nil when cancelled
+	TotalPool      int64     `db:"total_pool"`
+	Rake           int64     `db:"rake"`
+	Paid           int64     `db:"paid"`    // Sum of payouts and refunds
+	Winners        int       `db:"winners"` // Users paid
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// Pool statuses.
+const (
+	PoolStatusOpen      = "open"      // Taking bets until ClosesAt
+	PoolStatusResolved  = "resolved"  // Winning outcome set, pool paid out
+	PoolStatusCancelled = "cancelled" // Cancelled by an admin, stakes refunded
+)
+
+// MaintenanceWindow is a scheduled downtime announced to the chats. Players
+// cannot use the bot from StartsAt until EndsAt.
+type MaintenanceWindow struct {
+	ID          int64      `db:"id"`
+	StartsAt    time.Time  `db:"starts_at"`
+	EndsAt      time.Time  `db:"ends_at"`
+	Announced   int        `db:"announced"` // Last announced stage, see service.MaintenanceStage
+	CreatedBy   int64      `db:"created_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+	CancelledAt *time.Time `db:"cancelled_at"`
+}
+
+// Win record periods.
+const (
+	RecordPeriodDay  = "day"
+	RecordPeriodWeek = "week"
+)
+
+// WinRecord is the biggest single win of a game in a chat during a day or week.
+type WinRecord struct {
+	ChatID      int64     `db:"chat_id"`
+	Game        string    `db:"game"`         // Game command, e.g. "dice"
+	Period      string    `db:"period"`       // RecordPeriodDay or RecordPeriodWeek
+	PeriodStart time.Time `db:"period_start"` // First day of the period
+	UserID      int64     `db:"user_id"`
+	Name        string    `db:"name"` // Display name of the holder when the record was set
+	Amount      int64     `db:"amount"`
+	SetAt       time.Time `db:"set_at"`
+}
+
+// GamblingLimits are the daily caps a user set for themselves (0 = no limit).
+type GamblingLimits struct {
+	UserID     int64     `db:"user_id"`
+	DailyWager int64     `db:"daily_wager"` // Max coins staked per day
+	DailyLoss  int64     `db:"daily_loss"`  // Max net loss per day
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// GamblingDayTotals are a user's game and PvP totals of one day.
+type GamblingDayTotals struct {
+	UserID  int64     `db:"user_id"`
+	Day     time.Time `db:"day"`
+	Wagered int64     `db:"wagered"` // Coins staked
+	Net     int64     `db:"net"`     // Net result, negative = loss
+}
+
+// OutboxMessage is a direct message waiting to be delivered.
+// Messages that fail to send are retried with backoff.
+type OutboxMessage struct {
+	ID            int64     `db:"id"`
+	UserID        int64     `db:"user_id"`
+	Text          string    `db:"text"`
+	Attempts      int       `db:"attempts"` // Failed send attempts so far
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
 // Transaction types for categorizing balance changes.
 const (
 	TxTypeInitial      = "initial"       // Initial balance on account creation
@@ -48,10 +666,104 @@ const (
 	TxTypeRob          = "rob"           // Robbery - robber gains coins
 	TxTypeRobbed       = "robbed"        // Robbery - victim loses coins
 	TxTypeShopPurchase = "shop_purchase" // Shop item purchase
+	TxTypePromoRedeem  = "promo_redeem"  // Promo code redemption
+	TxTypeFreeSpin     = "free_spin"     // Daily free slot spin prize
+	TxTypeRefund       = "refund"        // Admin refund from a support ticket
+	TxTypeCompensation = "compensation"  // Compensation for losses caused by the bot
+	TxTypeRobProtect   = "rob_protect"   // Paid rob protection extension
+	TxTypeStruggleFee  = "struggle_fee"  // Fee of a /struggle attempt against handcuffs
+	TxTypeRaidPrize    = "raid_prize"    // Share of a raid event prize pool
+	TxTypeReversal     = "reversal"      // Admin reversal of a specific transaction
+	TxTypeSellBack     = "sell_back"     // Unused item uses sold back to the shop
+	TxTypeBailout      = "bailout"       // Weekly recovery grant for players stuck below the floor
+	TxTypePoolBet      = "pool_bet"      // Stake on a betting pool outcome
+	TxTypePoolWin      = "pool_win"      // Share of a resolved betting pool
+	TxTypePoolRefund   = "pool_refund"   // Betting pool stake refunded
+	TxTypeHeistBuyIn   = "heist_buyin"   // Buy-in to join a heist crew
+	TxTypeHeistWin     = "heist_win"     // Share of a successful heist
+	TxTypeHeistRefund  = "heist_refund"  // Buy-in of a void heist refunded
+	TxTypeDiceInsure   = "dice_insure"   // Dice insurance premium (negative) or refund of a lost stake (positive)
+	TxTypeComeback     = "comeback"      // Welcome-back bonus claimed by a returning regular
+	TxTypeBlackjack    = "blackjack"     // Blackjack bet or double (negative) and payout (positive)
+
+	TxTypeCounterAttack = "counterattack"  // Robbery - robber loses coins to a counter-attack
+	TxTypeRobInsureCut  = "rob_insure_cut" // Share of a successful rob paid into the rob insurance pool
+	TxTypeRobInsurePay  = "rob_insure_pay" // Rob insurance pool compensation for a victim left below the floor
+	TxTypeAllInRobWin   = "allin_rob_win"  // All-in robbery won
+	TxTypeAllInRobLose  = "allin_rob_lose" // All-in robbery lost
+	TxTypeDuelWin       = "duel_win"       // Duel won
+	TxTypeDuelLose      = "duel_lose"      // Duel lost
+	TxTypeDuelEscrow    = "duel_escrow"    // Best-of-three duel stake escrowed (negative) or refunded (positive)
+	TxTypeDiceWin       = "dice_win"       // All-in dice won
+	TxTypeDiceLose      = "dice_lose"      // All-in dice lost
+)
+
+// Transaction classes group transaction types by what caused them.
+const (
+	TxClassGame  = "game"  // Bets and payouts of house games
+	TxClassPvP   = "pvp"   // Coins won or lost against other players
+	TxClassOther = "other" // Rewards, transfers, shop and admin changes
 )
 
-// GameTransactionTypes returns the transaction types that count towards daily game rankings.
+// txClassByType classifies the transaction types that are not TxClassOther.
+// New games only need an entry here to count towards daily rankings.
+var txClassByType = map[string]string{
+	TxTypeDice:        TxClassGame,
+	TxTypeSlot:        TxClassGame,
+	TxTypeSicBoBet:    TxClassGame,
+	TxTypeSicBoWin:    TxClassGame,
+	TxTypeDiceWin:     TxClassGame,
+	TxTypeDiceLose:    TxClassGame,
+	TxTypePoolBet:     TxClassGame,
+	TxTypePoolWin:     TxClassGame,
+	TxTypePoolRefund:  TxClassGame,
+	TxTypeHeistBuyIn:  TxClassGame,
+	TxTypeHeistWin:    TxClassGame,
+	TxTypeHeistRefund: TxClassGame,
+	TxTypeDiceInsure:  TxClassGame,
+	TxTypeBlackjack:   TxClassGame,
+
+	TxTypeRob:           TxClassPvP,
+	TxTypeRobbed:        TxClassPvP,
+	TxTypeCounterAttack: TxClassPvP,
+	TxTypeRobInsureCut:  TxClassPvP,
+	TxTypeRobInsurePay:  TxClassPvP,
+	TxTypeAllInRobWin:   TxClassPvP,
+	TxTypeAllInRobLose:  TxClassPvP,
+	TxTypeDuelWin:       TxClassPvP,
+	TxTypeDuelLose:      TxClassPvP,
+	TxTypeDuelEscrow:    TxClassPvP,
+}
+
+// TransactionClass returns the class of a transaction type.
+func TransactionClass(txType string) string {
+	if class, ok := txClassByType[txType]; ok {
+		return class
+	}
+	return TxClassOther
+}
+
+// TransactionTypesOf returns the transaction types of a class, sorted.
+// TxClassOther has no fixed list and returns nil.
+func TransactionTypesOf(class string) []string {
+	var types []string
+	for txType, c := range txClassByType {
+		if c == class {
+			types = append(types, txType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// GameTransactionTypes returns the transaction types that count towards daily game rankings:
+// house games and PvP.
 // Requirements: 11.5 - Only count game-related transactions (exclude transfers, daily rewards)
 func GameTransactionTypes() []string {
-	return []string{TxTypeDice, TxTypeSlot, TxTypeSicBoWin, TxTypeSicBoBet, TxTypeRob, TxTypeRobbed}
+	return append(TransactionTypesOf(TxClassGame), TransactionTypesOf(TxClassPvP)...)
+}
+
+// PvPTransactionTypes returns the transaction types of coins won or lost against other players.
+func PvPTransactionTypes() []string {
+	return TransactionTypesOf(TxClassPvP)
 }
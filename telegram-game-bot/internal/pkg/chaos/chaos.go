@@ -0,0 +1,99 @@
+// Package chaos injects random faults into Telegram API calls and database
+// queries, so refunds, compensation and retries can be exercised in staging
+// before a real outage does it in production. It is off unless configured.
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInjected is the error of every injected fault
+var ErrInjected = errors.New("chaos: injected fault")
+
+// unaffectedMethods are Telegram methods never failed: failing them only
+// stalls polling or startup without exercising any recovery path
+var unaffectedMethods = map[string]bool{
+	"getUpdates": true,
+	"getMe":      true,
+}
+
+// Injector fails a percentage of Telegram API calls and database queries.
+type Injector struct {
+	telegramPercent float64
+	dbPercent       float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	telegramFaults atomic.Int64
+	dbFaults       atomic.Int64
+}
+
+// New creates an Injector failing telegramPercent of Telegram API calls and
+// dbPercent of database queries (0-100).
+func New(telegramPercent, dbPercent float64) *Injector {
+	return &Injector{
+		telegramPercent: telegramPercent,
+		dbPercent:       dbPercent,
+		rng:             rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// roll reports whether a fault with the given percentage should be injected
+func (i *Injector) roll(percent float64) bool {
+	if percent <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rng.Float64()*100 < percent
+}
+
+// DBFault returns ErrInjected for dbPercent of calls, nil otherwise.
+func (i *Injector) DBFault() error {
+	if !i.roll(i.dbPercent) {
+		return nil
+	}
+	n := i.dbFaults.Add(1)
+	log.Warn().Int64("db_faults", n).Msg("Chaos: failing database query")
+	return ErrInjected
+}
+
+// Faults returns the number of faults injected so far.
+func (i *Injector) Faults() (telegram, db int64) {
+	return i.telegramFaults.Load(), i.dbFaults.Load()
+}
+
+// Transport wraps base so that telegramPercent of Telegram API calls fail
+// before reaching Telegram. A nil base uses http.DefaultTransport.
+func (i *Injector) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{injector: i, base: base}
+}
+
+// transport is the http.RoundTripper of Injector.Transport
+type transport struct {
+	injector *Injector
+	base     http.RoundTripper
+}
+
+// RoundTrip fails the request or passes it on to the base transport
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	if !unaffectedMethods[method] && t.injector.roll(t.injector.telegramPercent) {
+		n := t.injector.telegramFaults.Add(1)
+		log.Warn().Str("method", method).Int64("telegram_faults", n).Msg("Chaos: failing Telegram API call")
+		return nil, ErrInjected
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,59 @@
+package chaos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// okTransport answers every request with 200
+type okTransport struct{ calls int }
+
+func (t *okTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return httptest.NewRecorder().Result(), nil
+}
+
+// TestInjectorExtremesProperty tests that 0% never fails and 100% always fails.
+func TestInjectorExtremesProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		calls := rapid.IntRange(1, 50).Draw(t, "calls")
+
+		never := New(0, 0)
+		always := New(100, 100)
+		for n := 0; n < calls; n++ {
+			if err := never.DBFault(); err != nil {
+				t.Fatalf("0%% injector failed: %v", err)
+			}
+			if err := always.DBFault(); !errors.Is(err, ErrInjected) {
+				t.Fatalf("100%% injector passed: %v", err)
+			}
+		}
+		if _, db := always.Faults(); db != int64(calls) {
+			t.Fatalf("db faults = %d, want %d", db, calls)
+		}
+	})
+}
+
+// TestTransport tests that Telegram calls fail except polling and startup.
+func TestTransport(t *testing.T) {
+	base := &okTransport{}
+	client := &http.Client{Transport: New(100, 0).Transport(base)}
+
+	if _, err := client.Get("https://api.telegram.org/botTOKEN/sendMessage"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("sendMessage error = %v, want ErrInjected", err)
+	}
+	for _, method := range []string{"getUpdates", "getMe"} {
+		resp, err := client.Get("https://api.telegram.org/botTOKEN/" + method)
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		resp.Body.Close()
+	}
+	if base.calls != 2 {
+		t.Fatalf("base calls = %d, want 2", base.calls)
+	}
+}
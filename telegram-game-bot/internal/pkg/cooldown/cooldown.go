@@ -0,0 +1,333 @@
+// Package cooldown tracks per user cooldowns of games and actions in one place,
+// so they can be checked, listed and formatted consistently.
+package cooldown
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/pkg/ttlstore"
+)
+
+// Key prefixes of cooldowns and bucket levels in a store
+const (
+	cooldownPrefix = "cooldown:"
+	bucketPrefix   = "bucket:"
+)
+
+// storeTimeout bounds each store call, so a slow store cannot stall games
+const storeTimeout = 2 * time.Second
+
+// Source reports the remaining cooldown of a user for cooldowns that are
+// tracked elsewhere (e.g. robbery state or the daily claim in the database).
+type Source func(ctx context.Context, userID int64) time.Duration
+
+// Entry is an active cooldown of a user.
+type Entry struct {
+	Name      string
+	Remaining time.Duration
+}
+
+type key struct {
+	userID int64
+	name   string
+}
+
+type source struct {
+	name string
+	fn   Source
+}
+
+type bucket struct {
+	capacity time.Duration
+	weights  map[string]time.Duration // action -> level added per use
+}
+
+// wait returns how long a level must drain before action fits under capacity
+func (b *bucket) wait(level time.Duration, action string) time.Duration {
+	weight, ok := b.weights[action]
+	if !ok || weight <= 0 {
+		return 0
+	}
+	room := b.capacity - weight
+	if room < 0 {
+		room = 0
+	}
+	if level <= room {
+		return 0
+	}
+	return level - room
+}
+
+// Manager tracks cooldowns started through it and queries registered sources.
+// The zero value is not usable, use New.
+type Manager struct {
+	mu      sync.Mutex
+	until   map[key]time.Time
+	drained map[key]time.Time // bucket levels, as the time they drain to zero
+	buckets map[string]*bucket
+	sources []source
+	store   ttlstore.Store // Optional: durable copy shared by instances
+	now     func() time.Time
+}
+
+// New creates an empty Manager.
+func New() *Manager {
+	return &Manager{
+		until:   make(map[key]time.Time),
+		drained: make(map[key]time.Time),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetStore makes cooldowns and bucket levels durable: they are written to
+// store and read back from it, so they survive restarts and are shared by
+// all instances. The in-memory copy is used while the store fails.
+// Must be called before the manager is used concurrently.
+func (m *Manager) SetStore(store ttlstore.Store) {
+	m.store = store
+}
+
+// Start puts a user's named cooldown in effect for d.
+func (m *Manager) Start(userID int64, name string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	k := key{userID, name}
+	m.mu.Lock()
+	until := m.now().Add(d)
+	m.until[k] = until
+	m.mu.Unlock()
+	m.persist(cooldownPrefix, k, until)
+}
+
+// Remaining returns the remaining time of a user's named cooldown, 0 if not in effect.
+func (m *Manager) Remaining(userID int64, name string) time.Duration {
+	k := key{userID, name}
+	stored := m.stored(cooldownPrefix, k)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mergeLater(m.until, k, stored)
+
+	until, ok := m.until[k]
+	if !ok {
+		return 0
+	}
+	remaining := until.Sub(m.now())
+	if remaining <= 0 {
+		delete(m.until, k)
+		return 0
+	}
+	return remaining
+}
+
+// Register adds a source of an externally tracked cooldown.
+// Must be called before the manager is used concurrently.
+func (m *Manager) Register(name string, fn Source) {
+	m.sources = append(m.sources, source{name: name, fn: fn})
+}
+
+// AddBucket defines a cooldown shared by several actions. Each charged action
+// adds its weight to the user's level, which drains in real time, and an
+// action is allowed while its weight still fits under capacity. Actions
+// without a positive weight are not limited.
+// Must be called before the manager is used concurrently.
+func (m *Manager) AddBucket(name string, capacity time.Duration, weights map[string]time.Duration) {
+	if capacity <= 0 {
+		return
+	}
+	m.buckets[name] = &bucket{capacity: capacity, weights: weights}
+}
+
+// level returns a user's current bucket level. m.mu must be held.
+func (m *Manager) level(k key, now time.Time) time.Duration {
+	drained, ok := m.drained[k]
+	if !ok {
+		return 0
+	}
+	level := drained.Sub(now)
+	if level <= 0 {
+		delete(m.drained, k)
+		return 0
+	}
+	return level
+}
+
+// BucketWait returns how long a user must wait before action fits in the
+// named bucket, 0 if it fits now or the bucket is not defined.
+func (m *Manager) BucketWait(userID int64, name, action string) time.Duration {
+	b, ok := m.buckets[name]
+	if !ok {
+		return 0
+	}
+	k := key{userID, name}
+	stored := m.stored(bucketPrefix, k)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mergeLater(m.drained, k, stored)
+	return b.wait(m.level(k, m.now()), action)
+}
+
+// Charge adds the weight of action to a user's level in the named bucket.
+func (m *Manager) Charge(userID int64, name, action string) {
+	b, ok := m.buckets[name]
+	if !ok || b.weights[action] <= 0 {
+		return
+	}
+	k := key{userID, name}
+	stored := m.stored(bucketPrefix, k)
+
+	m.mu.Lock()
+	mergeLater(m.drained, k, stored)
+	now := m.now()
+	drained := now.Add(m.level(k, now) + b.weights[action])
+	m.drained[k] = drained
+	m.mu.Unlock()
+	m.persist(bucketPrefix, k, drained)
+}
+
+// Active returns all cooldowns in effect for a user, shortest first.
+// A bucket is listed while its lightest action has to wait.
+func (m *Manager) Active(ctx context.Context, userID int64) []Entry {
+	var entries []Entry
+	cooldowns := m.storedOf(cooldownPrefix, userID)
+	levels := m.storedOf(bucketPrefix, userID)
+
+	m.mu.Lock()
+	for name, until := range cooldowns {
+		mergeLater(m.until, key{userID, name}, until)
+	}
+	for name, drained := range levels {
+		mergeLater(m.drained, key{userID, name}, drained)
+	}
+	now := m.now()
+	for k, until := range m.until {
+		if k.userID != userID {
+			continue
+		}
+		if remaining := until.Sub(now); remaining > 0 {
+			entries = append(entries, Entry{Name: k.name, Remaining: remaining})
+		} else {
+			delete(m.until, k)
+		}
+	}
+	for name, b := range m.buckets {
+		level := m.level(key{userID, name}, now)
+		var remaining time.Duration
+		for action := range b.weights {
+			if wait := b.wait(level, action); wait > 0 && (remaining == 0 || wait < remaining) {
+				remaining = wait
+			}
+		}
+		if remaining > 0 {
+			entries = append(entries, Entry{Name: name, Remaining: remaining})
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range m.sources {
+		if remaining := s.fn(ctx, userID); remaining > 0 {
+			entries = append(entries, Entry{Name: s.name, Remaining: remaining})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Remaining != entries[j].Remaining {
+			return entries[i].Remaining < entries[j].Remaining
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// mergeLater keeps the later of the in-memory and stored expiry of k
+func mergeLater(times map[key]time.Time, k key, stored time.Time) {
+	if stored.After(times[k]) {
+		times[k] = stored
+	}
+}
+
+// storeKey returns the store key of a user's cooldown or bucket level
+func storeKey(prefix string, k key) string {
+	return prefix + strconv.FormatInt(k.userID, 10) + ":" + k.name
+}
+
+// stored returns the expiry of a user's cooldown or bucket level in the
+// store, zero without a store or when it fails
+func (m *Manager) stored(prefix string, k key) time.Time {
+	if m.store == nil {
+		return time.Time{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	item, ok, err := m.store.Get(ctx, storeKey(prefix, k))
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", k.userID).Str("cooldown", k.name).Msg("Failed to read cooldown")
+		return time.Time{}
+	}
+	if !ok {
+		return time.Time{}
+	}
+	return item.ExpiresAt
+}
+
+// storedOf returns the stored cooldowns or bucket levels of a user by name,
+// none without a store or when it fails
+func (m *Manager) storedOf(prefix string, userID int64) map[string]time.Time {
+	if m.store == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	userPrefix := storeKey(prefix, key{userID: userID})
+	items, err := m.store.Scan(ctx, userPrefix)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to read cooldowns")
+		return nil
+	}
+	stored := make(map[string]time.Time, len(items))
+	for _, item := range items {
+		stored[strings.TrimPrefix(item.Key, userPrefix)] = item.ExpiresAt
+	}
+	return stored
+}
+
+// persist writes a user's cooldown or bucket level to the store, if any
+func (m *Manager) persist(prefix string, k key, expiresAt time.Time) {
+	if m.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := m.store.Set(ctx, storeKey(prefix, k), "", expiresAt); err != nil {
+		log.Warn().Err(err).Int64("user_id", k.userID).Str("cooldown", k.name).Msg("Failed to store cooldown")
+	}
+}
+
+// Format formats a remaining cooldown, rounded up to whole seconds,
+// e.g. "5秒", "2分3秒" or "3小时0分12秒".
+func Format(d time.Duration) string {
+	secs := int64((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	hours, minutes, seconds := secs/3600, secs%3600/60, secs%60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%d小时%d分%d秒", hours, minutes, seconds)
+	case minutes > 0:
+		return fmt.Sprintf("%d分%d秒", minutes, seconds)
+	default:
+		return fmt.Sprintf("%d秒", seconds)
+	}
+}
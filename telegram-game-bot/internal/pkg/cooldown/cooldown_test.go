@@ -0,0 +1,165 @@
+package cooldown
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/pkg/ttlstore"
+)
+
+// TestManagerRemainingProperty tests that a started cooldown counts down to
+// zero and is only visible to its own user and name.
+func TestManagerRemainingProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		d := time.Duration(rapid.Int64Range(1, int64(time.Hour)).Draw(t, "duration"))
+		elapsed := time.Duration(rapid.Int64Range(0, int64(2*time.Hour)).Draw(t, "elapsed"))
+
+		now := time.Unix(1700000000, 0)
+		m := New()
+		m.now = func() time.Time { return now }
+		m.Start(1, "dice", d)
+		now = now.Add(elapsed)
+
+		want := d - elapsed
+		if want < 0 {
+			want = 0
+		}
+		if got := m.Remaining(1, "dice"); got != want {
+			t.Fatalf("Remaining after %v of %v = %v, want %v", elapsed, d, got, want)
+		}
+		if got := m.Remaining(2, "dice"); got != 0 {
+			t.Fatalf("Other user has remaining %v", got)
+		}
+		if got := m.Remaining(1, "slot"); got != 0 {
+			t.Fatalf("Other game has remaining %v", got)
+		}
+	})
+}
+
+// TestManagerActive tests that Active lists tracked and registered cooldowns
+// of a user, shortest first, and leaves out those not in effect.
+func TestManagerActive(t *testing.T) {
+	m := New()
+	m.Register("rob", func(_ context.Context, userID int64) time.Duration {
+		if userID == 1 {
+			return 10 * time.Second
+		}
+		return 0
+	})
+	m.Register("daily", func(context.Context, int64) time.Duration { return 0 })
+	m.Start(1, "slot", 5*time.Second)
+	m.Start(1, "dice", time.Minute)
+	m.Start(2, "dice", time.Minute)
+
+	entries := m.Active(context.Background(), 1)
+	if len(entries) != 3 {
+		t.Fatalf("Active returned %d entries, want 3: %v", len(entries), entries)
+	}
+	for i, name := range []string{"slot", "rob", "dice"} {
+		if entries[i].Name != name {
+			t.Errorf("Entry %d is %s, want %s", i, entries[i].Name, name)
+		}
+	}
+	if got := m.Active(context.Background(), 3); len(got) != 0 {
+		t.Errorf("User without cooldowns has %v", got)
+	}
+}
+
+// TestFormat tests remaining time formatting, rounded up to whole seconds.
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{100 * time.Millisecond, "1秒"},
+		{3 * time.Second, "3秒"},
+		{3*time.Second + time.Millisecond, "4秒"},
+		{90 * time.Second, "1分30秒"},
+		{2*time.Hour + 12*time.Second, "2小时0分12秒"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.d); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+// TestBucketProperty tests that charged actions fill a shared bucket, that an
+// action waits exactly until its weight fits again, and that the bucket
+// drains in real time.
+func TestBucketProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		capacity := time.Duration(rapid.IntRange(1, 600).Draw(t, "capacity")) * time.Second
+		robWeight := time.Duration(rapid.IntRange(1, 300).Draw(t, "robWeight")) * time.Second
+		duelWeight := time.Duration(rapid.IntRange(1, 300).Draw(t, "duelWeight")) * time.Second
+		charges := rapid.IntRange(0, 10).Draw(t, "charges")
+
+		now := time.Unix(1700000000, 0)
+		m := New()
+		m.now = func() time.Time { return now }
+		m.AddBucket("aggression", capacity, map[string]time.Duration{"rob": robWeight, "duel": duelWeight})
+		for i := 0; i < charges; i++ {
+			m.Charge(1, "aggression", "rob")
+		}
+
+		level := time.Duration(charges) * robWeight
+		room := capacity - duelWeight
+		if room < 0 {
+			room = 0
+		}
+		want := level - room
+		if want < 0 {
+			want = 0
+		}
+		if got := m.BucketWait(1, "aggression", "duel"); got != want {
+			t.Fatalf("Wait after %d robs = %v, want %v", charges, got, want)
+		}
+		if got := m.BucketWait(1, "aggression", "dice"); got != 0 {
+			t.Fatalf("Unweighted action waits %v", got)
+		}
+		if got := m.BucketWait(2, "aggression", "duel"); got != 0 {
+			t.Fatalf("Other user waits %v", got)
+		}
+
+		now = now.Add(want)
+		if got := m.BucketWait(1, "aggression", "duel"); got != 0 {
+			t.Fatalf("Still waiting %v after the announced wait", got)
+		}
+	})
+}
+
+// TestManagerStore tests that cooldowns and bucket levels written through a
+// store are seen by another manager, as after a restart or on another instance.
+func TestManagerStore(t *testing.T) {
+	store := ttlstore.NewMemory()
+	weights := map[string]time.Duration{"rob": time.Minute}
+
+	first := New()
+	first.SetStore(store)
+	first.AddBucket("aggression", time.Minute, weights)
+	first.Start(1, "dice", time.Minute)
+	first.Charge(1, "aggression", "rob")
+
+	second := New()
+	second.SetStore(store)
+	second.AddBucket("aggression", time.Minute, weights)
+	if got := second.Remaining(1, "dice"); got <= 0 || got > time.Minute {
+		t.Fatalf("Stored cooldown remaining %v", got)
+	}
+	if got := second.BucketWait(1, "aggression", "rob"); got <= 0 {
+		t.Fatalf("Stored bucket level lets rob through")
+	}
+	if got := second.Remaining(2, "dice"); got != 0 {
+		t.Fatalf("Other user has remaining %v", got)
+	}
+
+	entries := New()
+	entries.SetStore(store)
+	entries.AddBucket("aggression", time.Minute, weights)
+	if got := entries.Active(context.Background(), 1); len(got) != 2 {
+		t.Fatalf("Active listed %v, want dice and aggression", got)
+	}
+}
@@ -4,12 +4,18 @@ package db
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"telegram-game-bot/internal/config"
+	"telegram-game-bot/internal/pkg/tracing"
 )
 
 // Pool wraps pgxpool.Pool with additional functionality.
@@ -17,8 +23,38 @@ type Pool struct {
 	*pgxpool.Pool
 }
 
+// options holds the optional settings of NewPool
+type options struct {
+	fault  func() error
+	tracer *tracing.Tracer
+}
+
+// Option customizes a connection pool created by NewPool.
+type Option func(*options)
+
+// WithFaults fails every query for which fault returns an error, e.g. to
+// inject faults in staging. The connection check at startup is never failed.
+func WithFaults(fault func() error) Option {
+	return func(o *options) {
+		o.fault = fault
+	}
+}
+
+// WithTracer records a span for every query, as a child of the span of the
+// query context.
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
 // NewPool creates a new PostgreSQL connection pool.
-func NewPool(ctx context.Context, cfg *config.DatabaseConfig) (*Pool, error) {
+func NewPool(ctx context.Context, cfg *config.DatabaseConfig, opts ...Option) (*Pool, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database config: %w", err)
@@ -54,6 +90,23 @@ func NewPool(ctx context.Context, cfg *config.DatabaseConfig) (*Pool, error) {
 	// Health check settings
 	poolConfig.HealthCheckPeriod = 30 * time.Second
 
+	if o.tracer != nil {
+		poolConfig.ConnConfig.Tracer = &queryTracer{tracer: o.tracer}
+	}
+
+	// Injected faults fail the query acquiring the connection, once the pool is verified
+	var faultsArmed atomic.Bool
+	if o.fault != nil {
+		poolConfig.PrepareConn = func(ctx context.Context, _ *pgx.Conn) (bool, error) {
+			if faultsArmed.Load() {
+				if err := o.fault(); err != nil {
+					return true, err
+				}
+			}
+			return true, nil
+		}
+	}
+
 	log.Info().
 		Str("host", cfg.Host).
 		Int("port", cfg.Port).
@@ -73,6 +126,7 @@ func NewPool(ctx context.Context, cfg *config.DatabaseConfig) (*Pool, error) {
 	}
 
 	log.Info().Msg("Successfully connected to PostgreSQL")
+	faultsArmed.Store(true)
 
 	return &Pool{Pool: pool}, nil
 }
@@ -99,3 +153,23 @@ func (p *Pool) HealthCheck(ctx context.Context) error {
 func WithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(parent, timeout)
 }
+
+// queryTracer records database queries as spans
+type queryTracer struct {
+	tracer *tracing.Tracer
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "db.query", trace.SpanKindClient,
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", strings.Join(strings.Fields(data.SQL), " ")))
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	tracing.End(span, data.Err)
+}
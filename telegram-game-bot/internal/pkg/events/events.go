@@ -0,0 +1,95 @@
+// Package events provides a minimal in-process event bus so features can
+// react to domain events (e.g. balance changes) without the code raising them
+// knowing about every consumer.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// BalanceChanged is published whenever a transaction is recorded for a user.
+type BalanceChanged struct {
+	UserID      int64
+	Amount      int64  // Signed balance change
+	TxType      string // Transaction type (model.TxType*)
+	Description string
+	TxID        int64
+	At          time.Time
+}
+
+// BalanceHandler handles BalanceChanged events.
+// Handlers run synchronously on the publishing goroutine, so they must return
+// quickly and hand slow work (e.g. Telegram calls) to their own goroutines.
+type BalanceHandler func(event BalanceChanged)
+
+// GameWon is published when a player wins a game round in a chat.
+type GameWon struct {
+	ChatID int64
+	UserID int64
+	Name   string // Display name, respecting leaderboard privacy
+	Game   string // Game command, e.g. "dice" or "slot"
+	Amount int64  // Net win
+	At     time.Time
+}
+
+// GameWonHandler handles GameWon events; like BalanceHandler it runs on the
+// publishing goroutine.
+type GameWonHandler func(event GameWon)
+
+// Bus dispatches events to their subscribers.
+// The zero value is ready to use and a nil *Bus drops all events.
+type Bus struct {
+	mu      sync.RWMutex
+	balance []BalanceHandler
+	gameWon []GameWonHandler
+}
+
+// NewBus creates a new event bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// OnBalanceChanged subscribes a handler to balance changes.
+func (b *Bus) OnBalanceChanged(handler BalanceHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.balance = append(b.balance, handler)
+}
+
+// PublishBalanceChanged dispatches a balance change to all subscribers.
+func (b *Bus) PublishBalanceChanged(event BalanceChanged) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.balance
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// OnGameWon subscribes a handler to game wins.
+func (b *Bus) OnGameWon(handler GameWonHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gameWon = append(b.gameWon, handler)
+}
+
+// PublishGameWon dispatches a game win to all subscribers.
+func (b *Bus) PublishGameWon(event GameWon) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.gameWon
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,89 @@
+// Package heartbeat tracks the background loops of the bot, so a health check
+// can tell a loop that stopped ticking (e.g. stuck on a lock or a panic in its
+// goroutine) from one that runs on schedule.
+package heartbeat
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// stallFactor is how many intervals a loop may miss before it counts as stalled
+const stallFactor = 3
+
+// Status is the state of a background loop.
+type Status struct {
+	Name     string
+	Interval time.Duration
+	LastBeat time.Time
+	Stalled  bool // No beat for stallFactor intervals
+}
+
+type loop struct {
+	interval time.Duration
+	lastBeat time.Time
+}
+
+// Monitor records the beats of background loops.
+type Monitor struct {
+	mu    sync.Mutex
+	loops map[string]*loop
+	now   func() time.Time
+}
+
+// New creates an empty Monitor.
+func New() *Monitor {
+	return &Monitor{loops: make(map[string]*loop), now: time.Now}
+}
+
+// Start registers a loop ticking every interval; starting counts as its first beat.
+func (m *Monitor) Start(name string, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loops[name] = &loop{interval: interval, lastBeat: m.now()}
+}
+
+// Beat records that a loop ran. Beats of loops never started are ignored.
+func (m *Monitor) Beat(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.loops[name]; ok {
+		l.lastBeat = m.now()
+	}
+}
+
+// Statuses returns the state of every started loop at now, sorted by name.
+func (m *Monitor) Statuses(now time.Time) []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]Status, 0, len(m.loops))
+	for name, l := range m.loops {
+		statuses = append(statuses, Status{
+			Name:     name,
+			Interval: l.interval,
+			LastBeat: l.lastBeat,
+			Stalled:  now.Sub(l.lastBeat) > stallFactor*l.interval,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// defaultMonitor is the monitor of the loops of this process
+var defaultMonitor = New()
+
+// Start registers a loop with the default monitor.
+func Start(name string, interval time.Duration) {
+	defaultMonitor.Start(name, interval)
+}
+
+// Beat records a beat of a loop with the default monitor.
+func Beat(name string) {
+	defaultMonitor.Beat(name)
+}
+
+// Statuses returns the loops of the default monitor.
+func Statuses(now time.Time) []Status {
+	return defaultMonitor.Statuses(now)
+}
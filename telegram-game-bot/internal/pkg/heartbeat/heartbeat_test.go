@@ -0,0 +1,32 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStatuses tests that loops stall after missing stallFactor intervals and
+// that beats of unknown loops are ignored.
+func TestStatuses(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := New()
+	m.now = func() time.Time { return now }
+
+	m.Start("sicbo", time.Second)
+	m.Start("bailout", 30*time.Minute)
+	m.Beat("unknown")
+
+	statuses := m.Statuses(now.Add(10 * time.Second))
+	if len(statuses) != 2 || statuses[0].Name != "bailout" || statuses[1].Name != "sicbo" {
+		t.Fatalf("Statuses = %+v, want bailout and sicbo", statuses)
+	}
+	if statuses[0].Stalled || !statuses[1].Stalled {
+		t.Fatalf("Statuses = %+v, want only sicbo stalled", statuses)
+	}
+
+	now = now.Add(9 * time.Second)
+	m.Beat("sicbo")
+	if statuses := m.Statuses(now.Add(2 * time.Second)); statuses[1].Stalled || !statuses[1].LastBeat.Equal(now) {
+		t.Fatalf("sicbo after beat = %+v, want running", statuses[1])
+	}
+}
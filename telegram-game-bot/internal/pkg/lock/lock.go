@@ -9,67 +9,113 @@ import (
 	"time"
 )
 
-// userMutex wraps a mutex with reference counting for cleanup.
+// shardBits sets the number of lock shards (1 << shardBits).
+const shardBits = 6
+
+const shardCount = 1 << shardBits
+
+// userMutex is a per-user lock with reference counting for reclamation.
+// The lock is a one slot channel so acquisition can be abandoned on timeout.
 type userMutex struct {
-	mu       sync.Mutex
-	refCount int
+	ch   chan struct{} // holds a token while the user is locked
+	refs int           // holders and waiters, guarded by the shard mutex
+}
+
+// lockShard owns the user locks of the users hashed to it.
+type lockShard struct {
+	mu    sync.Mutex
+	locks map[int64]*userMutex
 }
 
 // UserLock provides per-user locking to prevent race conditions
 // during balance operations and game sessions.
+// Users are spread over shards so unrelated users rarely contend, and a user's
+// lock is reclaimed as soon as nobody holds or waits for it, so memory stays
+// proportional to the number of busy users.
 type UserLock struct {
-	locks sync.Map // map[int64]*userMutex
-	pool  sync.Pool
+	shards [shardCount]lockShard
+	pool   sync.Pool
 }
 
 // NewUserLock creates a new UserLock instance.
 func NewUserLock() *UserLock {
-	return &UserLock{
+	ul := &UserLock{
 		pool: sync.Pool{
 			New: func() any {
-				return &userMutex{}
+				return &userMutex{ch: make(chan struct{}, 1)}
 			},
 		},
 	}
+	for i := range ul.shards {
+		ul.shards[i].locks = make(map[int64]*userMutex)
+	}
+	return ul
 }
 
-// getLock retrieves or creates a mutex for the given user ID.
-func (ul *UserLock) getLock(userID int64) *userMutex {
-	// Try to load existing lock
-	if v, ok := ul.locks.Load(userID); ok {
-		return v.(*userMutex)
+// shard returns the shard of a user (Fibonacci hashing spreads sequential IDs).
+func (ul *UserLock) shard(userID int64) *lockShard {
+	return &ul.shards[(uint64(userID)*0x9E3779B97F4A7C15)>>(64-shardBits)]
+}
+
+// acquire returns the user's lock, creating it if needed, and registers the
+// caller as a holder or waiter so the lock is not reclaimed meanwhile.
+func (ul *UserLock) acquire(userID int64) *userMutex {
+	s := ul.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.locks[userID]
+	if !ok {
+		m = ul.pool.Get().(*userMutex)
+		s.locks[userID] = m
 	}
+	m.refs++
+	return m
+}
 
-	// Create new lock from pool
-	newLock := ul.pool.Get().(*userMutex)
-	newLock.refCount = 0
+// release drops a reference taken by acquire, reclaiming the lock when idle.
+func (ul *UserLock) release(userID int64, m *userMutex) {
+	s := ul.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Store or load existing (handles race condition)
-	actual, loaded := ul.locks.LoadOrStore(userID, newLock)
-	if loaded {
-		// Another goroutine created the lock first, return ours to pool
-		ul.pool.Put(newLock)
+	ul.drop(s, userID, m)
+}
+
+// drop decrements the references of a lock. The shard mutex must be held.
+func (ul *UserLock) drop(s *lockShard, userID int64, m *userMutex) {
+	m.refs--
+	if m.refs == 0 {
+		delete(s.locks, userID)
+		ul.pool.Put(m)
 	}
-	return actual.(*userMutex)
 }
 
 // Lock acquires the lock for a user.
 // This should be called before any balance-modifying operation.
 // Requirements: 9.1
 func (ul *UserLock) Lock(userID int64) {
-	lock := ul.getLock(userID)
-	lock.mu.Lock()
-	lock.refCount++
+	m := ul.acquire(userID)
+	m.ch <- struct{}{}
 }
 
 // Unlock releases the lock for a user.
 // This should be called after balance-modifying operations complete.
+// Unlocking a user that is not locked is a no-op.
 // Requirements: 9.1
 func (ul *UserLock) Unlock(userID int64) {
-	if v, ok := ul.locks.Load(userID); ok {
-		lock := v.(*userMutex)
-		lock.refCount--
-		lock.mu.Unlock()
+	s := ul.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.locks[userID]
+	if !ok {
+		return
+	}
+	select {
+	case <-m.ch:
+		ul.drop(s, userID, m)
+	default:
 	}
 }
 
@@ -77,45 +123,46 @@ func (ul *UserLock) Unlock(userID int64) {
 // Returns true if the lock was acquired, false otherwise.
 // Requirements: 9.2
 func (ul *UserLock) TryLock(userID int64) bool {
-	lock := ul.getLock(userID)
-	if lock.mu.TryLock() {
-		lock.refCount++
+	m := ul.acquire(userID)
+	select {
+	case m.ch <- struct{}{}:
 		return true
+	default:
+		ul.release(userID, m)
+		return false
 	}
-	return false
+}
+
+// TryLockWithTimeout attempts to acquire the lock, waiting at most timeout.
+// Returns true if the lock was acquired, false if the timeout occurred.
+func (ul *UserLock) TryLockWithTimeout(userID int64, timeout time.Duration) bool {
+	return ul.LockWithTimeout(context.Background(), userID, timeout)
 }
 
 // LockWithTimeout attempts to acquire the lock with a timeout.
-// Returns true if the lock was acquired, false if timeout occurred.
+// Returns true if the lock was acquired, false if the timeout occurred or ctx was cancelled.
 // Requirements: 9.1, 9.2
 func (ul *UserLock) LockWithTimeout(ctx context.Context, userID int64, timeout time.Duration) bool {
-	lock := ul.getLock(userID)
-
-	// Create a channel to signal lock acquisition
-	done := make(chan struct{})
+	m := ul.acquire(userID)
 
-	go func() {
-		lock.mu.Lock()
-		close(done)
-	}()
+	// Fast path when the lock is free
+	select {
+	case m.ch <- struct{}{}:
+		return true
+	default:
+	}
 
-	// Create timeout context if not already set
-	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
 	select {
-	case <-done:
-		lock.refCount++
+	case m.ch <- struct{}{}:
 		return true
-	case <-timeoutCtx.Done():
-		// Timeout occurred - we need to handle the goroutine that's waiting for the lock
-		// It will eventually acquire and release, but we return false
-		go func() {
-			<-done // Wait for lock acquisition
-			lock.mu.Unlock()
-		}()
-		return false
+	case <-timer.C:
+	case <-ctx.Done():
 	}
+	ul.release(userID, m)
+	return false
 }
 
 // WithLock executes a function while holding the user's lock.
@@ -149,16 +196,25 @@ func (ul *UserLock) WithLockContext(ctx context.Context, userID int64, timeout t
 // Note: This is a point-in-time check and may change immediately after.
 // Requirements: 9.2
 func (ul *UserLock) IsLocked(userID int64) bool {
-	if v, ok := ul.locks.Load(userID); ok {
-		lock := v.(*userMutex)
-		// Try to acquire and immediately release to check if locked
-		if lock.mu.TryLock() {
-			lock.mu.Unlock()
-			return false
-		}
-		return true
+	s := ul.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.locks[userID]
+	return ok && len(m.ch) > 0
+}
+
+// Len returns the number of users whose lock is held or awaited.
+// Idle locks are reclaimed, so this is zero when no operation is in flight.
+func (ul *UserLock) Len() int {
+	n := 0
+	for i := range ul.shards {
+		s := &ul.shards[i]
+		s.mu.Lock()
+		n += len(s.locks)
+		s.mu.Unlock()
 	}
-	return false
+	return n
 }
 
 // DefaultUserLock is the global user lock instance.
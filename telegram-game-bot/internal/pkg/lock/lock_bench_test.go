@@ -0,0 +1,147 @@
+package lock
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// userLocker is the API shared by UserLock and the benchmark baseline
+type userLocker interface {
+	Lock(userID int64)
+	Unlock(userID int64)
+	TryLock(userID int64) bool
+}
+
+// syncMapUserLock is the previous UserLock implementation, kept as a benchmark
+// baseline: one sync.Map entry per user, never reclaimed.
+type syncMapUserLock struct {
+	locks sync.Map // map[int64]*sync.Mutex
+}
+
+func (l *syncMapUserLock) get(userID int64) *sync.Mutex {
+	if v, ok := l.locks.Load(userID); ok {
+		return v.(*sync.Mutex)
+	}
+	v, _ := l.locks.LoadOrStore(userID, &sync.Mutex{})
+	return v.(*sync.Mutex)
+}
+
+func (l *syncMapUserLock) Lock(userID int64) {
+	l.get(userID).Lock()
+}
+
+func (l *syncMapUserLock) Unlock(userID int64) {
+	if v, ok := l.locks.Load(userID); ok {
+		v.(*sync.Mutex).Unlock()
+	}
+}
+
+func (l *syncMapUserLock) TryLock(userID int64) bool {
+	return l.get(userID).TryLock()
+}
+
+// benchImplementations returns fresh instances of the compared implementations
+func benchImplementations() []struct {
+	name string
+	new  func() userLocker
+} {
+	return []struct {
+		name string
+		new  func() userLocker
+	}{
+		{"sharded", func() userLocker { return NewUserLock() }},
+		{"syncmap", func() userLocker { return &syncMapUserLock{} }},
+	}
+}
+
+// runLockBenchmark runs a benchmark body against every implementation
+func runLockBenchmark(b *testing.B, body func(b *testing.B, ul userLocker)) {
+	for _, impl := range benchImplementations() {
+		b.Run(impl.name, func(b *testing.B) {
+			body(b, impl.new())
+		})
+	}
+}
+
+// BenchmarkUserLock_LockUnlock measures an uncontended lock round trip for one user.
+func BenchmarkUserLock_LockUnlock(b *testing.B) {
+	runLockBenchmark(b, func(b *testing.B, ul userLocker) {
+		for i := 0; i < b.N; i++ {
+			ul.Lock(1)
+			ul.Unlock(1)
+		}
+	})
+}
+
+// BenchmarkUserLock_ParallelDistinctUsers measures lock round trips from
+// concurrent goroutines that each work on their own user, the common case
+// of many players betting at once.
+func BenchmarkUserLock_ParallelDistinctUsers(b *testing.B) {
+	runLockBenchmark(b, func(b *testing.B, ul userLocker) {
+		var nextID atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			userID := nextID.Add(1)
+			for pb.Next() {
+				ul.Lock(userID)
+				ul.Unlock(userID)
+			}
+		})
+	})
+}
+
+// BenchmarkUserLock_ParallelSameUser measures lock round trips when all
+// goroutines contend for a single user.
+func BenchmarkUserLock_ParallelSameUser(b *testing.B) {
+	runLockBenchmark(b, func(b *testing.B, ul userLocker) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				ul.Lock(1)
+				ul.Unlock(1)
+			}
+		})
+	})
+}
+
+// BenchmarkUserLock_ManyUsers measures locking users seen for the first time.
+// The baseline keeps every user's mutex forever; the sharded lock reclaims them.
+func BenchmarkUserLock_ManyUsers(b *testing.B) {
+	runLockBenchmark(b, func(b *testing.B, ul userLocker) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			userID := int64(i)
+			ul.Lock(userID)
+			ul.Unlock(userID)
+		}
+	})
+}
+
+// BenchmarkUserLock_TryLock measures a successful non-blocking acquisition.
+func BenchmarkUserLock_TryLock(b *testing.B) {
+	runLockBenchmark(b, func(b *testing.B, ul userLocker) {
+		for i := 0; i < b.N; i++ {
+			if ul.TryLock(1) {
+				ul.Unlock(1)
+			}
+		}
+	})
+}
+
+// BenchmarkUserLock_WithLock measures the closure based helper.
+func BenchmarkUserLock_WithLock(b *testing.B) {
+	ul := NewUserLock()
+	fn := func() error { return nil }
+	for i := 0; i < b.N; i++ {
+		_ = ul.WithLock(1, fn)
+	}
+}
+
+// BenchmarkUserLock_TryLockWithTimeout measures a timed acquisition of a free lock.
+func BenchmarkUserLock_TryLockWithTimeout(b *testing.B) {
+	ul := NewUserLock()
+	for i := 0; i < b.N; i++ {
+		if ul.TryLockWithTimeout(1, 0) {
+			ul.Unlock(1)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"pgregory.net/rapid"
 )
@@ -290,3 +291,79 @@ func TestLockUnlockSymmetryProperty(t *testing.T) {
 		ul.Unlock(userID)
 	})
 }
+
+// TestIdleLocksReclaimedProperty tests that user locks do not outlive their use.
+// *For any* set of users locked and unlocked concurrently, including failed TryLock
+// and timed out attempts, no user lock SHALL remain once every operation completed.
+func TestIdleLocksReclaimedProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		numUsers := rapid.IntRange(1, 200).Draw(t, "numUsers")
+		opsPerUser := rapid.IntRange(1, 10).Draw(t, "opsPerUser")
+
+		ul := NewUserLock()
+
+		var wg sync.WaitGroup
+		wg.Add(numUsers * opsPerUser)
+		for i := 0; i < numUsers; i++ {
+			userID := int64(i) * 7919 // Spread users over the shards
+			for j := 0; j < opsPerUser; j++ {
+				go func(uid int64, op int) {
+					defer wg.Done()
+					switch op % 3 {
+					case 0:
+						ul.Lock(uid)
+						ul.Unlock(uid)
+					case 1:
+						if ul.TryLock(uid) {
+							ul.Unlock(uid)
+						}
+					default:
+						if ul.TryLockWithTimeout(uid, time.Millisecond) {
+							ul.Unlock(uid)
+						}
+					}
+				}(userID, j)
+			}
+		}
+		wg.Wait()
+
+		// Property: Every idle lock has been reclaimed
+		if n := ul.Len(); n != 0 {
+			t.Fatalf("Expected all idle locks to be reclaimed, %d remain", n)
+		}
+	})
+}
+
+// TestTryLockWithTimeoutProperty tests that a timed acquisition gives up while the
+// lock is held, succeeds once it is released, and never leaves the lock held.
+func TestTryLockWithTimeoutProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		userID := rapid.Int64Range(1, 1000000).Draw(t, "userID")
+		timeout := time.Duration(rapid.IntRange(1, 5).Draw(t, "timeoutMs")) * time.Millisecond
+
+		ul := NewUserLock()
+		ul.Lock(userID)
+
+		// Property: Times out while another holder keeps the lock
+		if ul.TryLockWithTimeout(userID, timeout) {
+			t.Fatal("TryLockWithTimeout should time out while the lock is held")
+		}
+		if !ul.IsLocked(userID) {
+			t.Fatal("Timed out attempt must not release the holder's lock")
+		}
+
+		// Property: Succeeds when the holder releases within the timeout
+		go func() {
+			time.Sleep(timeout / 2)
+			ul.Unlock(userID)
+		}()
+		if !ul.TryLockWithTimeout(userID, time.Second) {
+			t.Fatal("TryLockWithTimeout should acquire the lock after release")
+		}
+		ul.Unlock(userID)
+
+		if ul.IsLocked(userID) || ul.Len() != 0 {
+			t.Fatal("Lock should be free and reclaimed after unlock")
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrorCounter is a zerolog hook counting the log events at error level and above.
+type ErrorCounter struct {
+	count atomic.Uint64
+}
+
+// NewErrorCounter creates a new ErrorCounter.
+func NewErrorCounter() *ErrorCounter {
+	return &ErrorCounter{}
+}
+
+// Run implements zerolog.Hook.
+func (c *ErrorCounter) Run(_ *zerolog.Event, level zerolog.Level, _ string) {
+	if level >= zerolog.ErrorLevel && level < zerolog.NoLevel {
+		c.count.Add(1)
+	}
+}
+
+// Count returns the number of errors logged since the process started.
+func (c *ErrorCounter) Count() uint64 {
+	return c.count.Load()
+}
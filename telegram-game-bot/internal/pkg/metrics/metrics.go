@@ -0,0 +1,247 @@
+// Package metrics records handler timings as Prometheus histograms, and event
+// counts as counters, and serves them in the Prometheus text exposition
+// format, without a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds of handler duration buckets
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// OtherLabel replaces label values once a histogram holds MaxSeries series,
+// so arbitrary commands sent by users cannot grow the output without bound
+const OtherLabel = "other"
+
+// MaxSeries caps the distinct label values of a histogram
+const MaxSeries = 256
+
+// series is the histogram of one label value
+type series struct {
+	counts []uint64 // Observations per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec is a histogram partitioned by one label.
+type HistogramVec struct {
+	name    string
+	help    string
+	label   string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewHistogramVec creates a histogram partitioned by label with the given
+// bucket upper bounds, which must be sorted ascending.
+func NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		label:   label,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+}
+
+// Observe records a value for a label value.
+func (h *HistogramVec) Observe(labelValue string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labelValue]
+	if !ok {
+		if len(h.series) >= MaxSeries {
+			labelValue = OtherLabel
+			s = h.series[labelValue]
+		}
+		if s == nil {
+			s = &series{counts: make([]uint64, len(h.buckets))}
+			h.series[labelValue] = s
+		}
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// Count returns the number of observations of a label value.
+func (h *HistogramVec) Count(labelValue string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.series[labelValue]; ok {
+		return s.count
+	}
+	return 0
+}
+
+// WriteTo writes the histogram in the Prometheus text exposition format.
+func (h *HistogramVec) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", h.name)
+
+	values := make([]string, 0, len(h.series))
+	for v := range h.series {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	for _, v := range values {
+		s := h.series[v]
+		label := fmt.Sprintf("%s=\"%s\"", h.label, escapeLabel(v))
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", h.name, label, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, label, s.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", h.name, label, formatFloat(s.sum))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", h.name, label, s.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// CounterVec is a counter partitioned by one label.
+type CounterVec struct {
+	name  string
+	help  string
+	label string
+
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// NewCounterVec creates a counter partitioned by label.
+func NewCounterVec(name, help, label string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		label:  label,
+		counts: make(map[string]uint64),
+	}
+}
+
+// Inc increments the counter of a label value.
+func (c *CounterVec) Inc(labelValue string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.counts[labelValue]; !ok && len(c.counts) >= MaxSeries {
+		labelValue = OtherLabel
+	}
+	c.counts[labelValue]++
+}
+
+// Count returns the counter of a label value.
+func (c *CounterVec) Count(labelValue string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[labelValue]
+}
+
+// WriteTo writes the counter in the Prometheus text exposition format.
+func (c *CounterVec) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", c.name)
+
+	values := make([]string, 0, len(c.counts))
+	for v := range c.counts {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	for _, v := range values {
+		fmt.Fprintf(&b, "%s{%s=\"%s\"} %d\n", c.name, c.label, escapeLabel(v), c.counts[v])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Collector is a metric served on the metrics endpoint.
+type Collector interface {
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// Registry collects the metrics served on the metrics endpoint.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a metric to the registry.
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes all registered metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	var total int64
+	for _, c := range r.collectors {
+		n, err := c.WriteTo(bw)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, bw.Flush()
+}
+
+// Handler returns the HTTP handler of the metrics endpoint.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// labelEscaper escapes the characters the exposition format reserves in label values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// formatFloat formats a sample value the way Prometheus clients do
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
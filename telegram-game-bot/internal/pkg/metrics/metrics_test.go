@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"pgregory.net/rapid"
+)
+
+// TestHistogramCountsProperty tests that every observation lands in exactly
+// one bucket and the +Inf bucket equals the count.
+func TestHistogramCountsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		h := NewHistogramVec("test_seconds", "Test.", "handler", DefaultBuckets)
+		values := rapid.SliceOf(rapid.Float64Range(0, 20)).Draw(t, "values")
+		for _, v := range values {
+			h.Observe("/dice", v)
+		}
+
+		if got := h.Count("/dice"); got != uint64(len(values)) {
+			t.Fatalf("count = %d, want %d", got, len(values))
+		}
+
+		var out strings.Builder
+		if _, err := h.WriteTo(&out); err != nil {
+			t.Fatal(err)
+		}
+		if len(values) == 0 {
+			return
+		}
+		want := fmt.Sprintf(`test_seconds_bucket{handler="/dice",le="+Inf"} %d`, len(values))
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output lacks %q:\n%s", want, out.String())
+		}
+	})
+}
+
+// TestHistogramExposition tests the exposition format of a histogram.
+func TestHistogramExposition(t *testing.T) {
+	h := NewHistogramVec("handler_seconds", "Handler duration.", "handler", []float64{0.1, 1})
+	h.Observe("/sicbo", 0.05)
+	h.Observe("/sicbo", 0.5)
+	h.Observe("/sicbo", 3)
+
+	var out strings.Builder
+	if _, err := h.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP handler_seconds Handler duration.
+# TYPE handler_seconds histogram
+handler_seconds_bucket{handler="/sicbo",le="0.1"} 1
+handler_seconds_bucket{handler="/sicbo",le="1"} 2
+handler_seconds_bucket{handler="/sicbo",le="+Inf"} 3
+handler_seconds_sum{handler="/sicbo"} 3.55
+handler_seconds_count{handler="/sicbo"} 3
+`
+	if out.String() != want {
+		t.Fatalf("exposition =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+// TestHistogramMaxSeries tests that label values beyond MaxSeries are folded into OtherLabel.
+func TestHistogramMaxSeries(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "Test.", "handler", DefaultBuckets)
+	for i := 0; i < MaxSeries+10; i++ {
+		h.Observe(fmt.Sprintf("/cmd%d", i), 0.01)
+	}
+
+	if got := h.Count(OtherLabel); got != 10 {
+		t.Fatalf("other count = %d, want 10", got)
+	}
+	if got := h.Count("/cmd0"); got != 1 {
+		t.Fatalf("first series count = %d, want 1", got)
+	}
+}
+
+// TestCounterExposition tests the exposition format of a counter.
+func TestCounterExposition(t *testing.T) {
+	c := NewCounterVec("updates_total", "Updates.", "outcome")
+	c.Inc("forwarded")
+	c.Inc("dropped")
+	c.Inc("dropped")
+
+	var out strings.Builder
+	if _, err := c.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP updates_total Updates.
+# TYPE updates_total counter
+updates_total{outcome="dropped"} 2
+updates_total{outcome="forwarded"} 1
+`
+	if out.String() != want {
+		t.Fatalf("exposition =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+// TestEscapeLabel tests escaping of reserved characters in label values.
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("escapeLabel = %q", got)
+	}
+}
+
+// TestErrorCounter tests that only events at error level and above are counted.
+func TestErrorCounter(t *testing.T) {
+	counter := NewErrorCounter()
+	logger := zerolog.New(io.Discard).Hook(counter)
+
+	logger.Info().Msg("info")
+	logger.Warn().Msg("warn")
+	logger.Error().Msg("error")
+	logger.WithLevel(zerolog.FatalLevel).Msg("fatal")
+	logger.Log().Msg("no level")
+
+	if got := counter.Count(); got != 2 {
+		t.Fatalf("Count = %d, want 2", got)
+	}
+}
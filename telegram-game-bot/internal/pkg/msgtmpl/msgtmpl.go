@@ -0,0 +1,98 @@
+// Package msgtmpl renders user supplied message templates such as
+// "{user} 赢得 {amount} 金币！".
+//
+// Templates can only reference named variables: there is no expression
+// evaluation, values are inserted verbatim in a single pass (a value that
+// contains "{amount}" is never expanded again), and "{{" / "}}" produce
+// literal braces.
+package msgtmpl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Template errors
+var (
+	ErrUnclosedBrace = errors.New("unclosed brace")
+	ErrUnknownVar    = errors.New("unknown variable")
+)
+
+// Vars maps variable names to their values
+type Vars map[string]string
+
+// Render substitutes the variables of a template.
+// Unknown variables and stray braces are kept as written, so rendering never fails.
+func Render(tmpl string, vars Vars) string {
+	var sb strings.Builder
+	sb.Grow(len(tmpl))
+
+	for i := 0; i < len(tmpl); {
+		switch c := tmpl[i]; {
+		case c == '{' && strings.HasPrefix(tmpl[i:], "{{"):
+			sb.WriteByte('{')
+			i += 2
+		case c == '}' && strings.HasPrefix(tmpl[i:], "}}"):
+			sb.WriteByte('}')
+			i += 2
+		case c == '{':
+			end := strings.IndexByte(tmpl[i+1:], '}')
+			if end < 0 {
+				sb.WriteString(tmpl[i:])
+				return sb.String()
+			}
+			name := tmpl[i+1 : i+1+end]
+			if value, ok := vars[name]; ok {
+				sb.WriteString(value)
+			} else {
+				sb.WriteString(tmpl[i : i+2+end])
+			}
+			i += end + 2
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// Validate checks that a template only references the allowed variables and
+// that its braces are balanced.
+func Validate(tmpl string, allowed []string) error {
+	for i := 0; i < len(tmpl); {
+		switch c := tmpl[i]; {
+		case (c == '{' || c == '}') && i+1 < len(tmpl) && tmpl[i+1] == c:
+			i += 2
+		case c == '{':
+			end := strings.IndexByte(tmpl[i+1:], '}')
+			if end < 0 {
+				return ErrUnclosedBrace
+			}
+			name := tmpl[i+1 : i+1+end]
+			if !contains(allowed, name) {
+				return fmt.Errorf("%w: {%s}", ErrUnknownVar, name)
+			}
+			i += end + 2
+		case c == '}':
+			return ErrUnclosedBrace
+		default:
+			i++
+		}
+	}
+	if !utf8.ValidString(tmpl) {
+		return errors.New("invalid utf-8")
+	}
+	return nil
+}
+
+// contains reports whether a name is in the list
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
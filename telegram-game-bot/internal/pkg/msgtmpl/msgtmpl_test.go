@@ -0,0 +1,64 @@
+package msgtmpl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestRenderValuesVerbatimProperty tests that values are inserted exactly as
+// given and never expanded again, even if they look like template variables.
+func TestRenderValuesVerbatimProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		user := rapid.StringOf(rapid.SampledFrom([]rune("ab{}金币 "))).Draw(t, "user")
+		amount := rapid.SampledFrom([]string{"100", "{user}", "{{amount}}", "}"}).Draw(t, "amount")
+		prefix := rapid.StringOf(rapid.SampledFrom([]rune("xy 🎉"))).Draw(t, "prefix")
+
+		got := Render(prefix+"{user}|{amount}", Vars{"user": user, "amount": amount})
+		want := prefix + user + "|" + amount
+		if got != want {
+			t.Fatalf("Render = %q, want %q", got, want)
+		}
+	})
+}
+
+// TestRenderWithoutVarsProperty tests that a template without braces is
+// returned unchanged.
+func TestRenderWithoutVarsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		tmpl := rapid.String().Filter(func(s string) bool {
+			return !strings.ContainsAny(s, "{}")
+		}).Draw(t, "tmpl")
+
+		if got := Render(tmpl, Vars{"user": "x"}); got != tmpl {
+			t.Fatalf("Render(%q) = %q", tmpl, got)
+		}
+	})
+}
+
+// TestValidateProperty tests that Validate accepts templates built from the
+// allowed variables and literal text, and rejects unknown variables.
+func TestValidateProperty(t *testing.T) {
+	allowed := []string{"user", "amount", "balance"}
+
+	rapid.Check(t, func(t *rapid.T) {
+		parts := rapid.SliceOf(rapid.SampledFrom([]string{
+			"{user}", "{amount}", "{balance}", "{{", "}}", "赢了", " ", "🎉",
+		})).Draw(t, "parts")
+		tmpl := strings.Join(parts, "")
+
+		if err := Validate(tmpl, allowed); err != nil {
+			t.Fatalf("Validate(%q) = %v", tmpl, err)
+		}
+
+		bad := tmpl + "{secret}"
+		if err := Validate(bad, allowed); !errors.Is(err, ErrUnknownVar) {
+			t.Fatalf("Validate(%q) = %v, want ErrUnknownVar", bad, err)
+		}
+		if err := Validate(tmpl+"{user", allowed); !errors.Is(err, ErrUnclosedBrace) {
+			t.Fatalf("Validate(%q) = %v, want ErrUnclosedBrace", tmpl+"{user", err)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+// Package pacer paces Telegram sends per chat. Telegram answers a burst of
+// sends to one chat (e.g. the dice and results of a sicbo settlement) with
+// "Too Many Requests: retry after N", and refuses every send to a chat where
+// the bot was muted; sending on regardless only fails again and again.
+// The pacer learns these restrictions from the responses and holds back
+// later sends to the chat: until the retry time has passed, or failing fast
+// for a while once the bot is muted. Other chats are not affected.
+package pacer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/pkg/metrics"
+)
+
+// Errors of sends the pacer refuses without calling Telegram
+var (
+	ErrChatMuted   = errors.New("pacer: bot cannot send to the chat")
+	ErrRateLimited = errors.New("pacer: chat is rate limited")
+)
+
+// Reasons labelling deferred sends in the metrics
+const (
+	ReasonWaited      = "waited"       // Held until the chat's retry time
+	ReasonRateLimited = "rate_limited" // Refused, the retry time was too far away
+	ReasonMuted       = "muted"        // Refused, the bot cannot send to the chat
+)
+
+// mutedDescriptions are parts of Telegram error descriptions meaning the bot
+// is not allowed to send to a chat
+var mutedDescriptions = []string{
+	"not enough rights",
+	"have no rights to send",
+	"chat_write_forbidden",
+	"chat_restricted",
+}
+
+// chatState is what the pacer learned about a chat
+type chatState struct {
+	until time.Time // Sends are held or refused until then
+	muted bool      // Refused rather than held
+}
+
+// Pacer holds back sends to chats with a known restriction.
+type Pacer struct {
+	maxWait  time.Duration // Longest a send is held; later retry times fail fast
+	mutedFor time.Duration // How long sends fail fast after the bot was muted
+
+	mu    sync.Mutex
+	chats map[string]*chatState // chat_id -> state
+
+	deferred *metrics.HistogramVec // Optional: seconds held, by reason
+	now      func() time.Time
+	sleep    func(*http.Request, time.Duration) error
+}
+
+// New creates a Pacer holding sends up to maxWait and failing sends fast for
+// mutedFor after the bot was muted in a chat.
+func New(maxWait, mutedFor time.Duration) *Pacer {
+	return &Pacer{
+		maxWait:  maxWait,
+		mutedFor: mutedFor,
+		chats:    make(map[string]*chatState),
+		now:      time.Now,
+		sleep:    sleepRequest,
+	}
+}
+
+// SetMetrics records deferred and refused sends in a histogram of the
+// seconds held, labelled by reason.
+func (p *Pacer) SetMetrics(deferred *metrics.HistogramVec) {
+	p.deferred = deferred
+}
+
+// Transport wraps base so that sends to restricted chats are paced.
+// A nil base uses http.DefaultTransport.
+func (p *Pacer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{pacer: p, base: base}
+}
+
+// wait returns how long a send to chatID must be held now, or the error to
+// fail it with
+func (p *Pacer) wait(chatID string) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.chats[chatID]
+	if !ok {
+		return 0, nil
+	}
+	wait := state.until.Sub(p.now())
+	switch {
+	case wait <= 0:
+		delete(p.chats, chatID)
+		return 0, nil
+	case state.muted:
+		p.observe(ReasonMuted, 0)
+		return 0, ErrChatMuted
+	case wait > p.maxWait:
+		p.observe(ReasonRateLimited, 0)
+		return 0, ErrRateLimited
+	}
+	return wait, nil
+}
+
+// learn records the restriction a Telegram response reveals about chatID
+func (p *Pacer) learn(chatID string, status int, body []byte) {
+	var resp struct {
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+
+	var state *chatState
+	switch {
+	case status == http.StatusTooManyRequests && resp.Parameters.RetryAfter > 0:
+		state = &chatState{until: p.now().Add(time.Duration(resp.Parameters.RetryAfter) * time.Second)}
+	case status == http.StatusBadRequest || status == http.StatusForbidden:
+		if !isMuted(resp.Description) || p.mutedFor <= 0 {
+			return
+		}
+		state = &chatState{until: p.now().Add(p.mutedFor), muted: true}
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	if current, ok := p.chats[chatID]; !ok || state.until.After(current.until) {
+		p.chats[chatID] = state
+	}
+	p.mu.Unlock()
+
+	log.Warn().
+		Str("chat_id", chatID).
+		Int("status", status).
+		Str("description", resp.Description).
+		Time("until", state.until).
+		Msg("Pacing sends to restricted chat")
+}
+
+// observe records a deferred send, if metrics are set
+func (p *Pacer) observe(reason string, held time.Duration) {
+	if p.deferred != nil {
+		p.deferred.Observe(reason, held.Seconds())
+	}
+}
+
+// isMuted reports whether a Telegram error description means the bot cannot send
+func isMuted(description string) bool {
+	description = strings.ToLower(description)
+	for _, part := range mutedDescriptions {
+		if strings.Contains(description, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepRequest waits d unless the request is cancelled first
+func sleepRequest(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// transport is the http.RoundTripper of Pacer.Transport
+type transport struct {
+	pacer *Pacer
+	base  http.RoundTripper
+}
+
+// RoundTrip holds or refuses sends to restricted chats and learns
+// restrictions from the responses
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(path.Base(req.URL.Path), "send") {
+		return t.base.RoundTrip(req)
+	}
+	req, chatID, err := requestChatID(req)
+	if err != nil {
+		return nil, err
+	}
+	if chatID == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	wait, err := t.pacer.wait(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		if err := t.pacer.sleep(req, wait); err != nil {
+			return nil, err
+		}
+		t.pacer.observe(ReasonWaited, wait)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.pacer.learn(chatID, resp.StatusCode, body)
+	return resp, nil
+}
+
+// requestChatID returns the chat_id of a JSON request, "" for other requests,
+// with a copy of the request whose body can still be sent.
+func requestChatID(req *http.Request) (*http.Request, string, error) {
+	if req.Body == nil || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return req, "", nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		ChatID json.RawMessage `json:"chat_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return req, "", nil
+	}
+	return req, strings.Trim(string(payload.ChatID), `"`), nil
+}
@@ -0,0 +1,145 @@
+package pacer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-game-bot/internal/pkg/metrics"
+)
+
+// scriptedTransport answers requests with the queued responses, then with 200
+type scriptedTransport struct {
+	responses []*http.Response
+	chats     []string
+}
+
+func (t *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	t.chats = append(t.chats, string(body))
+	if len(t.responses) == 0 {
+		return response(http.StatusOK, `{"ok":true}`), nil
+	}
+	resp := t.responses[0]
+	t.responses = t.responses[1:]
+	return resp, nil
+}
+
+func response(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+// testPacer returns a pacer on a fake clock whose sleeps advance the clock
+func testPacer(maxWait, mutedFor time.Duration) (*Pacer, *time.Time, *[]time.Duration) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	p := New(maxWait, mutedFor)
+	p.now = func() time.Time { return now }
+	p.sleep = func(_ *http.Request, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+	return p, &now, &slept
+}
+
+func send(t *testing.T, client *http.Client, method, chatID string) error {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "https://api.telegram.org/botTOKEN/"+method,
+		strings.NewReader(`{"chat_id":"`+chatID+`","text":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// TestPacerHoldsRateLimitedChat tests that sends to a chat told to retry
+// later wait until then, while other chats are not held.
+func TestPacerHoldsRateLimitedChat(t *testing.T) {
+	p, _, slept := testPacer(10*time.Second, time.Minute)
+	deferred := metrics.NewHistogramVec("test_deferred", "", "reason", metrics.DefaultBuckets)
+	p.SetMetrics(deferred)
+	base := &scriptedTransport{responses: []*http.Response{
+		response(http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3","parameters":{"retry_after":3}}`),
+	}}
+	client := &http.Client{Transport: p.Transport(base)}
+
+	if err := send(t, client, "sendMessage", "-100"); err != nil {
+		t.Fatalf("first send failed: %v", err)
+	}
+	if err := send(t, client, "sendMessage", "-200"); err != nil || len(*slept) != 0 {
+		t.Fatalf("other chat: err = %v, slept = %v", err, *slept)
+	}
+	if err := send(t, client, "sendDice", "-100"); err != nil {
+		t.Fatalf("held send failed: %v", err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 3*time.Second {
+		t.Fatalf("slept = %v, want [3s]", *slept)
+	}
+	if got := deferred.Count(ReasonWaited); got != 1 {
+		t.Fatalf("waited count = %d, want 1", got)
+	}
+	if len(base.chats) != 3 || !strings.Contains(base.chats[2], `"text":"hi"`) {
+		t.Fatalf("base got %q, want the full body of all three sends", base.chats)
+	}
+
+	// The retry time has passed
+	if err := send(t, client, "sendMessage", "-100"); err != nil || len(*slept) != 1 {
+		t.Fatalf("after retry time: err = %v, slept = %v", err, *slept)
+	}
+}
+
+// TestPacerRefusesLongWaits tests that sends are refused rather than held
+// past maxWait.
+func TestPacerRefusesLongWaits(t *testing.T) {
+	p, now, _ := testPacer(5*time.Second, time.Minute)
+	base := &scriptedTransport{responses: []*http.Response{
+		response(http.StatusTooManyRequests, `{"ok":false,"parameters":{"retry_after":30}}`),
+	}}
+	client := &http.Client{Transport: p.Transport(base)}
+
+	_ = send(t, client, "sendMessage", "-100")
+	if err := send(t, client, "sendMessage", "-100"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	*now = now.Add(30 * time.Second)
+	if err := send(t, client, "sendMessage", "-100"); err != nil {
+		t.Fatalf("after retry time: %v", err)
+	}
+	if len(base.chats) != 2 {
+		t.Fatalf("base calls = %d, want 2", len(base.chats))
+	}
+}
+
+// TestPacerMutedChat tests that sends to a chat where the bot was muted fail
+// fast for mutedFor, and that other errors are not mistaken for it.
+func TestPacerMutedChat(t *testing.T) {
+	p, now, _ := testPacer(5*time.Second, time.Minute)
+	base := &scriptedTransport{responses: []*http.Response{
+		response(http.StatusBadRequest, `{"ok":false,"description":"Bad Request: message text is empty"}`),
+		response(http.StatusBadRequest, `{"ok":false,"description":"Bad Request: not enough rights to send text messages to the chat"}`),
+	}}
+	client := &http.Client{Transport: p.Transport(base)}
+
+	_ = send(t, client, "sendMessage", "-100")
+	_ = send(t, client, "sendMessage", "-100")
+	if err := send(t, client, "sendMessage", "-100"); !errors.Is(err, ErrChatMuted) {
+		t.Fatalf("err = %v, want ErrChatMuted", err)
+	}
+	if err := send(t, client, "editMessageText", "-100"); err != nil {
+		t.Fatalf("edits are not paced: %v", err)
+	}
+	*now = now.Add(time.Minute)
+	if err := send(t, client, "sendMessage", "-100"); err != nil {
+		t.Fatalf("after mutedFor: %v", err)
+	}
+}
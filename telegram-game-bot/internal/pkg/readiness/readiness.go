@@ -0,0 +1,40 @@
+// Package readiness tells load balancers and orchestrators whether the bot
+// takes updates yet, so a deploy can wait for a new instance to finish
+// warming its caches before moving traffic to it.
+package readiness
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Gate is closed until the bot is ready to take updates.
+type Gate struct {
+	ready atomic.Bool
+}
+
+// New creates a closed Gate.
+func New() *Gate {
+	return &Gate{}
+}
+
+// Open marks the bot as ready.
+func (g *Gate) Open() {
+	g.ready.Store(true)
+}
+
+// Ready reports whether the gate is open.
+func (g *Gate) Ready() bool {
+	return g.ready.Load()
+}
+
+// ServeHTTP answers 200 once the gate is open and 503 before, for /readyz.
+func (g *Gate) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !g.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("warming up\n"))
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
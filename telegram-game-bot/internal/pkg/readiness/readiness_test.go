@@ -0,0 +1,25 @@
+package readiness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestGate tests that /readyz fails until the gate opens.
+func TestGate(t *testing.T) {
+	g := New()
+	status := func() int {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if g.Ready() || status() != http.StatusServiceUnavailable {
+		t.Fatalf("New gate is ready (status %d)", status())
+	}
+	g.Open()
+	if !g.Ready() || status() != http.StatusOK {
+		t.Fatalf("Open gate is not ready (status %d)", status())
+	}
+}
@@ -0,0 +1,103 @@
+// Package roles models who may run management commands: global bot admins
+// from the config, and Telegram chat administrators for their own chats.
+// Chat roles come from the Telegram API and are cached for a while, so
+// permission checks do not cost an API call per command.
+package roles
+
+import (
+	"sync"
+	"time"
+)
+
+// Role is the permission level of a user in a chat. Higher roles include lower ones.
+type Role int
+
+// Roles
+const (
+	Member      Role = iota // Regular user
+	ChatAdmin               // Creator or administrator of the chat
+	GlobalAdmin             // Bot admin from the config, allowed in every chat
+)
+
+// String returns the display name of a role
+func (r Role) String() string {
+	switch r {
+	case GlobalAdmin:
+		return "机器人管理员"
+	case ChatAdmin:
+		return "群管理员"
+	default:
+		return "成员"
+	}
+}
+
+// Allows reports whether the role grants at least the required role.
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
+
+type key struct {
+	chatID int64
+	userID int64
+}
+
+type entry struct {
+	role    Role
+	expires time.Time
+}
+
+// Cache remembers chat roles fetched from Telegram for a fixed time.
+// The zero value is not usable, use NewCache.
+type Cache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[key]entry
+}
+
+// NewCache creates a cache keeping roles for ttl.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, now: time.Now, entries: make(map[key]entry)}
+}
+
+// Get returns the cached role of a user in a chat, false if unknown or expired.
+func (c *Cache) Get(chatID, userID int64) (Role, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key{chatID, userID}]
+	if !ok {
+		return Member, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key{chatID, userID})
+		return Member, false
+	}
+	return e.role, true
+}
+
+// Set caches the role of a user in a chat.
+func (c *Cache) Set(chatID, userID int64, role Role) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.entries[key{chatID, userID}] = entry{role: role, expires: now.Add(c.ttl)}
+
+	// Drop expired entries now and then so the map only holds recent lookups
+	if len(c.entries)%256 == 0 {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+}
+
+// Invalidate forgets the cached role of a user in a chat, e.g. after a promotion.
+func (c *Cache) Invalidate(chatID, userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key{chatID, userID})
+}
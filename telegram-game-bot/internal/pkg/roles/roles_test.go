@@ -0,0 +1,58 @@
+package roles
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestRoleAllowsProperty tests that a role grants itself and every lower role only.
+func TestRoleAllowsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		role := Role(rapid.IntRange(int(Member), int(GlobalAdmin)).Draw(t, "role"))
+		required := Role(rapid.IntRange(int(Member), int(GlobalAdmin)).Draw(t, "required"))
+		if role.Allows(required) != (role >= required) {
+			t.Fatalf("%v allows %v = %v", role, required, role.Allows(required))
+		}
+	})
+}
+
+// TestCacheExpiryProperty tests that a cached role is returned until the TTL
+// has passed, only for its own chat and user.
+func TestCacheExpiryProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ttl := time.Duration(rapid.Int64Range(1, int64(time.Hour)).Draw(t, "ttl"))
+		elapsed := time.Duration(rapid.Int64Range(0, int64(2*time.Hour)).Draw(t, "elapsed"))
+
+		now := time.Unix(1700000000, 0)
+		c := NewCache(ttl)
+		c.now = func() time.Time { return now }
+		c.Set(-100, 1, ChatAdmin)
+		now = now.Add(elapsed)
+
+		role, ok := c.Get(-100, 1)
+		if ok != (elapsed < ttl) {
+			t.Fatalf("Cached after %v of %v = %v", elapsed, ttl, ok)
+		}
+		if ok && role != ChatAdmin {
+			t.Fatalf("Cached role %v, want %v", role, ChatAdmin)
+		}
+		if _, ok := c.Get(-200, 1); ok {
+			t.Fatalf("Role visible in another chat")
+		}
+		if _, ok := c.Get(-100, 2); ok {
+			t.Fatalf("Role visible for another user")
+		}
+	})
+}
+
+// TestCacheInvalidate tests that an invalidated role is fetched again.
+func TestCacheInvalidate(t *testing.T) {
+	c := NewCache(time.Hour)
+	c.Set(-100, 1, ChatAdmin)
+	c.Invalidate(-100, 1)
+	if _, ok := c.Get(-100, 1); ok {
+		t.Fatal("Invalidated role still cached")
+	}
+}
@@ -0,0 +1,59 @@
+package shard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// secretHeader is the header Telegram sends the webhook secret token in
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Forwarder posts updates to the webhook of the instance owning their shard,
+// for deployments whose load balancer spreads updates without routing them
+// by AffinityKey.
+type Forwarder struct {
+	urls   []string // Webhook URL by shard, empty for shards without one
+	secret string
+	client *http.Client
+}
+
+// NewForwarder creates a Forwarder posting to urls, indexed by shard, with the
+// webhook secret token the instances verify. Returns nil if no URL is set.
+func NewForwarder(urls []string, secret string) *Forwarder {
+	for _, url := range urls {
+		if url != "" {
+			return &Forwarder{urls: urls, secret: secret, client: &http.Client{Timeout: 5 * time.Second}}
+		}
+	}
+	return nil
+}
+
+// Forward posts the JSON of an update to the instance owning shard. Returns
+// false if the shard has no URL.
+func (f *Forwarder) Forward(ctx context.Context, shard int, update []byte) (bool, error) {
+	if f == nil || shard < 0 || shard >= len(f.urls) || f.urls[shard] == "" {
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.urls[shard], bytes.NewReader(update))
+	if err != nil {
+		return true, fmt.Errorf("failed to build forward request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.secret != "" {
+		req.Header.Set(secretHeader, f.secret)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to forward update to shard %d: %w", shard, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return true, fmt.Errorf("shard %d answered %s", shard, resp.Status)
+	}
+	return true, nil
+}
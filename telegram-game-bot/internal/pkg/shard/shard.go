@@ -0,0 +1,94 @@
+// Package shard assigns chats to bot instances for horizontal scaling. Every
+// chat maps to one of a fixed number of shards by its ID, and each instance
+// processes only the updates of the shards it owns. All instances share the
+// database, which is the only coordination between them: there is no Redis,
+// and short-lived state shared by instances uses the postgres state backend.
+//
+// Updates reach the owning instance in one of two ways. A router in front of
+// the instances can send each update to the instance of AffinityKey, using Of
+// with the same shard count. Otherwise every instance receives any update and
+// hands those of other shards to their owner with a Forwarder; updates of
+// shards without a forward URL are dropped.
+package shard
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// Of returns the shard of a chat among count shards. Private chats use the
+// user's ID, so a user's private chat and pre-checkout queries land on the
+// same shard. count below 2 means a single shard 0.
+func Of(chatID int64, count int) int {
+	if count < 2 {
+		return 0
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(chatID))
+	h := fnv.New64a()
+	_, _ = h.Write(b[:])
+	return int(h.Sum64() % uint64(count))
+}
+
+// AffinityKey returns the session affinity key of a chat, e.g. for a router
+// forwarding webhook updates to the instance owning the chat.
+func AffinityKey(chatID int64, count int) string {
+	return "shard-" + strconv.Itoa(Of(chatID, count))
+}
+
+// Set is the shards owned by this instance.
+type Set struct {
+	count int
+	owned map[int]bool
+}
+
+// New creates the set of shards owned by this instance out of count shards.
+// With count below 2 sharding is disabled and the instance owns every chat.
+func New(count int, owned []int) (*Set, error) {
+	s := &Set{count: count, owned: make(map[int]bool, len(owned))}
+	if count < 2 {
+		return s, nil
+	}
+	if len(owned) == 0 {
+		return nil, fmt.Errorf("sharding across %d shards but this instance owns none", count)
+	}
+	for _, id := range owned {
+		if id < 0 || id >= count {
+			return nil, fmt.Errorf("shard %d out of range 0-%d", id, count-1)
+		}
+		s.owned[id] = true
+	}
+	return s, nil
+}
+
+// Enabled reports whether chats are sharded across instances.
+func (s *Set) Enabled() bool {
+	return s != nil && s.count >= 2
+}
+
+// Of returns the shard of a chat.
+func (s *Set) Of(chatID int64) int {
+	if !s.Enabled() {
+		return 0
+	}
+	return Of(chatID, s.count)
+}
+
+// Owns reports whether this instance processes a chat.
+func (s *Set) Owns(chatID int64) bool {
+	if !s.Enabled() {
+		return true
+	}
+	return s.owned[Of(chatID, s.count)]
+}
+
+// Primary reports whether this instance owns shard 0, which runs the jobs
+// that are not tied to a chat (raids, treasury audit, cleanup, grants).
+func (s *Set) Primary() bool {
+	if !s.Enabled() {
+		return true
+	}
+	return s.owned[0]
+}
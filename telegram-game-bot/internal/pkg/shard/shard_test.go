@@ -0,0 +1,116 @@
+package shard
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestOfProperty tests that every chat maps to a stable shard in range.
+func TestOfProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		chatID := rapid.Int64().Draw(t, "chatID")
+		count := rapid.IntRange(-1, 64).Draw(t, "count")
+
+		got := Of(chatID, count)
+		if count < 2 {
+			if got != 0 {
+				t.Fatalf("Of(%d, %d) = %d, want 0", chatID, count, got)
+			}
+			return
+		}
+		if got < 0 || got >= count {
+			t.Fatalf("Of(%d, %d) = %d out of range", chatID, count, got)
+		}
+		if Of(chatID, count) != got {
+			t.Fatal("Of is not stable")
+		}
+	})
+}
+
+// TestSetOwnsProperty tests that the instances owning disjoint shards that
+// cover all shards process every chat exactly once.
+func TestSetOwnsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		count := rapid.IntRange(2, 16).Draw(t, "count")
+		instances := rapid.IntRange(1, count).Draw(t, "instances")
+
+		owned := make([][]int, instances)
+		for id := 0; id < count; id++ {
+			owned[id%instances] = append(owned[id%instances], id)
+		}
+		sets := make([]*Set, instances)
+		for i := range sets {
+			s, err := New(count, owned[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			sets[i] = s
+		}
+
+		chatID := rapid.Int64().Draw(t, "chatID")
+		owners, primaries := 0, 0
+		for _, s := range sets {
+			if s.Owns(chatID) {
+				owners++
+			}
+			if s.Primary() {
+				primaries++
+			}
+		}
+		if owners != 1 || primaries != 1 {
+			t.Fatalf("chat %d has %d owners and %d primaries, want 1 each", chatID, owners, primaries)
+		}
+	})
+}
+
+// TestNewValidation tests that misconfigured shard sets are rejected.
+func TestNewValidation(t *testing.T) {
+	if _, err := New(4, nil); err == nil {
+		t.Fatal("instance owning no shard accepted")
+	}
+	if _, err := New(4, []int{4}); err == nil {
+		t.Fatal("out of range shard accepted")
+	}
+
+	s, err := New(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Enabled() || !s.Owns(42) || !s.Primary() {
+		t.Fatal("single shard does not own everything")
+	}
+}
+
+// TestForwarder tests that updates are posted with the secret token to the
+// URL of their shard only.
+func TestForwarder(t *testing.T) {
+	var got []byte
+	var secret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = io.ReadAll(r.Body)
+		secret = r.Header.Get(secretHeader)
+	}))
+	defer server.Close()
+
+	if NewForwarder([]string{"", ""}, "s3cret") != nil {
+		t.Fatal("forwarder without URLs should be nil")
+	}
+
+	f := NewForwarder([]string{"", server.URL}, "s3cret")
+	ok, err := f.Forward(context.Background(), 0, []byte(`{}`))
+	if ok || err != nil {
+		t.Fatalf("shard without URL: ok=%v err=%v", ok, err)
+	}
+	ok, err = f.Forward(context.Background(), 1, []byte(`{"update_id":1}`))
+	if !ok || err != nil {
+		t.Fatalf("Forward: ok=%v err=%v", ok, err)
+	}
+	if string(got) != `{"update_id":1}` || secret != "s3cret" {
+		t.Fatalf("received %q with secret %q", got, secret)
+	}
+}
@@ -0,0 +1,187 @@
+// Package textfilter cleans user supplied strings, such as display names,
+// before the bot interpolates them into its own messages.
+//
+// Names are reduced to a single line without control or invisible
+// formatting characters, long runs of one character are shortened, blocked
+// words are masked and the result is capped to a maximum length.
+package textfilter
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// Ellipsis marks a name cut to the maximum length
+const Ellipsis = "…"
+
+// Options configures a Filter.
+type Options struct {
+	BlockedWords []string // Masked case-insensitively
+	Replacement  string   // Replaces each blocked word
+	MaxLength    int      // Maximum runes of a name, 0 = unlimited
+	MaxRepeat    int      // Longest run of one character kept, 0 = unlimited
+}
+
+// Filter cleans user supplied strings. The zero value only removes control
+// and formatting characters.
+type Filter struct {
+	opts    Options
+	blocked [][]rune // Lowercased blocked words
+}
+
+// New creates a Filter. Empty blocked words are ignored.
+func New(opts Options) *Filter {
+	f := &Filter{opts: opts}
+	for _, word := range opts.BlockedWords {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		f.blocked = append(f.blocked, lower([]rune(word)))
+	}
+	return f
+}
+
+// Name cleans a display name for use in a message.
+func (f *Filter) Name(s string) string {
+	runes := singleLine(s)
+	runes = collapseRepeats(runes, f.opts.MaxRepeat)
+	runes = f.mask(runes)
+	return truncate(strings.TrimSpace(string(runes)), f.opts.MaxLength)
+}
+
+// Blocked reports whether s contains a blocked word.
+func (f *Filter) Blocked(s string) bool {
+	lowered := lower([]rune(s))
+	for i := range lowered {
+		if f.blockedAt(lowered[i:]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// singleLine replaces control characters, including newlines, with spaces
+// and drops invisible formatting characters such as direction overrides
+func singleLine(s string) []rune {
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case unicode.IsControl(r):
+			runes = append(runes, ' ')
+		case unicode.Is(unicode.Cf, r):
+			// Zero width and bidi characters
+		default:
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// collapseRepeats shortens runs of the same character to at most max
+func collapseRepeats(runes []rune, max int) []rune {
+	if max <= 0 {
+		return runes
+	}
+	out := runes[:0]
+	run := 0
+	for i, r := range runes {
+		if i > 0 && r == runes[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run <= max {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// mask replaces every blocked word with the replacement
+func (f *Filter) mask(runes []rune) []rune {
+	if len(f.blocked) == 0 {
+		return runes
+	}
+	lowered := lower(runes)
+	replacement := []rune(f.opts.Replacement)
+
+	var out []rune
+	for i := 0; i < len(runes); {
+		if n := f.blockedAt(lowered[i:]); n > 0 {
+			out = append(out, replacement...)
+			i += n
+			continue
+		}
+		out = append(out, runes[i])
+		i++
+	}
+	return out
+}
+
+// blockedAt returns the length of the longest blocked word s starts with
+func (f *Filter) blockedAt(s []rune) int {
+	longest := 0
+	for _, word := range f.blocked {
+		if len(word) > longest && hasPrefix(s, word) {
+			longest = len(word)
+		}
+	}
+	return longest
+}
+
+// truncate caps s to max runes, ending cut names with Ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:max-1])) + Ellipsis
+}
+
+func lower(runes []rune) []rune {
+	out := make([]rune, len(runes))
+	for i, r := range runes {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultFilter is the filter used by the package level functions
+var defaultFilter atomic.Pointer[Filter]
+
+// SetDefault sets the filter used by Name.
+func SetDefault(f *Filter) {
+	defaultFilter.Store(f)
+}
+
+// Name cleans a display name with the default filter.
+func Name(s string) string {
+	f := defaultFilter.Load()
+	if f == nil {
+		f = &Filter{}
+	}
+	return f.Name(s)
+}
+
+// Blocked reports whether s contains a word blocked by the default filter.
+func Blocked(s string) bool {
+	f := defaultFilter.Load()
+	if f == nil {
+		return false
+	}
+	return f.Blocked(s)
+}
@@ -0,0 +1,104 @@
+package textfilter
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"pgregory.net/rapid"
+)
+
+// TestNameSingleLineProperty tests that names never contain line breaks,
+// control characters or direction overrides.
+func TestNameSingleLineProperty(t *testing.T) {
+	f := New(Options{})
+
+	rapid.Check(t, func(t *rapid.T) {
+		s := rapid.StringOf(rapid.SampledFrom([]rune("ab 金\n\r\t‮​\x00"))).Draw(t, "s")
+
+		got := f.Name(s)
+		if strings.ContainsAny(got, "\n\r\t‮​\x00") {
+			t.Fatalf("Name(%q) = %q still contains control characters", s, got)
+		}
+	})
+}
+
+// TestNameMaxLengthProperty tests that names are capped to MaxLength runes.
+func TestNameMaxLengthProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		max := rapid.IntRange(1, 20).Draw(t, "max")
+		s := rapid.StringOf(rapid.SampledFrom([]rune("abc 金币🎉"))).Draw(t, "s")
+
+		got := New(Options{MaxLength: max}).Name(s)
+		if n := utf8.RuneCountInString(got); n > max {
+			t.Fatalf("Name(%q) = %q has %d runes, want at most %d", s, got, n, max)
+		}
+	})
+}
+
+// TestNameMaxRepeatProperty tests that no character repeats more than MaxRepeat times in a row.
+func TestNameMaxRepeatProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		max := rapid.IntRange(1, 5).Draw(t, "max")
+		s := rapid.StringOf(rapid.SampledFrom([]rune("aab哈"))).Draw(t, "s")
+
+		got := []rune(New(Options{MaxRepeat: max}).Name(s))
+		run := 0
+		for i, r := range got {
+			if i > 0 && r == got[i-1] {
+				run++
+			} else {
+				run = 1
+			}
+			if run > max {
+				t.Fatalf("Name(%q) = %q repeats %q more than %d times", s, string(got), r, max)
+			}
+		}
+	})
+}
+
+// TestNameBlockedWordsProperty tests that blocked words are masked in any letter case.
+func TestNameBlockedWordsProperty(t *testing.T) {
+	f := New(Options{BlockedWords: []string{"Bad", "坏蛋"}, Replacement: "***"})
+
+	rapid.Check(t, func(t *rapid.T) {
+		word := rapid.SampledFrom([]string{"bad", "BAD", "bAd", "坏蛋"}).Draw(t, "word")
+		prefix := rapid.StringOf(rapid.SampledFrom([]rune("xy 1"))).Draw(t, "prefix")
+		suffix := rapid.StringOf(rapid.SampledFrom([]rune("xy 1"))).Draw(t, "suffix")
+
+		got := f.Name(prefix + word + suffix)
+		if strings.Contains(strings.ToLower(got), strings.ToLower(word)) {
+			t.Fatalf("Name(%q) = %q still contains %q", prefix+word+suffix, got, word)
+		}
+		if !strings.Contains(got, "***") {
+			t.Fatalf("Name(%q) = %q is missing the replacement", prefix+word+suffix, got)
+		}
+	})
+}
+
+// TestBlockedProperty tests that Blocked finds blocked words in any letter case.
+func TestBlockedProperty(t *testing.T) {
+	f := New(Options{BlockedWords: []string{"Bad", "坏蛋"}})
+
+	rapid.Check(t, func(t *rapid.T) {
+		prefix := rapid.StringOf(rapid.SampledFrom([]rune("xy 1"))).Draw(t, "prefix")
+		suffix := rapid.StringOf(rapid.SampledFrom([]rune("xy 1"))).Draw(t, "suffix")
+		if f.Blocked(prefix + suffix) {
+			t.Fatalf("Blocked(%q) = true without a blocked word", prefix+suffix)
+		}
+
+		word := rapid.SampledFrom([]string{"bad", "BAD", "bAd", "坏蛋"}).Draw(t, "word")
+		if !f.Blocked(prefix + word + suffix) {
+			t.Fatalf("Blocked(%q) = false", prefix+word+suffix)
+		}
+	})
+}
+
+func TestNameDefault(t *testing.T) {
+	SetDefault(New(Options{MaxLength: 5}))
+	defer SetDefault(nil)
+
+	if got := Name("abcdefgh"); got != "abcd"+Ellipsis {
+		t.Fatalf("Name = %q, want %q", got, "abcd"+Ellipsis)
+	}
+}
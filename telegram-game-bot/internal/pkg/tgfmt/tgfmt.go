@@ -0,0 +1,83 @@
+// Package tgfmt builds messages for Telegram's HTML parse mode.
+//
+// Text from users must never be written into an HTML message as is: a name
+// such as "<b>" or "a&b" would break the message or change its formatting.
+// Plain strings are escaped by every helper of this package, while HTML
+// values, which the helpers return, are inserted verbatim. Messages built
+// here must be sent with tele.ModeHTML.
+package tgfmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HTML is text that is already formatted for the HTML parse mode.
+type HTML string
+
+// htmlEscaper escapes the characters Telegram's HTML parser reserves
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// Escape escapes plain text for the HTML parse mode.
+func Escape(s string) HTML {
+	return HTML(htmlEscaper.Replace(s))
+}
+
+// Bold returns s in bold.
+func Bold(s string) HTML {
+	return "<b>" + Escape(s) + "</b>"
+}
+
+// Italic returns s in italics.
+func Italic(s string) HTML {
+	return "<i>" + Escape(s) + "</i>"
+}
+
+// Code returns s in a monospace font, e.g. for codes users copy.
+func Code(s string) HTML {
+	return "<code>" + Escape(s) + "</code>"
+}
+
+// Amount returns a coin amount in bold.
+func Amount(n int64) HTML {
+	return Bold(strconv.FormatInt(n, 10))
+}
+
+// SignedAmount returns a coin change in bold, with a sign also for gains.
+func SignedAmount(n int64) HTML {
+	return Bold(fmt.Sprintf("%+d", n))
+}
+
+// Mention links the name to a Telegram user by ID. Unlike @username it also
+// works for users without a username.
+func Mention(userID int64, name string) HTML {
+	return HTML(`<a href="tg://user?id=`+strconv.FormatInt(userID, 10)+`">`) + Escape(name) + "</a>"
+}
+
+// Sprintf formats like fmt.Sprintf for the HTML parse mode. The format is
+// trusted markup; string, error and fmt.Stringer arguments are escaped, HTML
+// arguments are inserted as is and other arguments are formatted by fmt.
+func Sprintf(format string, args ...any) HTML {
+	safe := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case HTML:
+			safe[i] = string(v)
+		case string:
+			safe[i] = string(Escape(v))
+		case error:
+			safe[i] = string(Escape(v.Error()))
+		case fmt.Stringer:
+			safe[i] = string(Escape(v.String()))
+		default:
+			safe[i] = arg
+		}
+	}
+	return HTML(fmt.Sprintf(format, safe...))
+}
+
+// String returns the formatted text for sending.
+func (h HTML) String() string {
+	return string(h)
+}
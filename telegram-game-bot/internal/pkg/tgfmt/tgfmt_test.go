@@ -0,0 +1,57 @@
+package tgfmt
+
+import (
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestEscapeRoundTripProperty tests that escaped text contains no markup and
+// unescapes to the original text.
+func TestEscapeRoundTripProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		s := rapid.StringOf(rapid.SampledFrom([]rune(`ab<>&"'金 `))).Draw(t, "s")
+
+		escaped := string(Escape(s))
+		if strings.ContainsAny(escaped, `<>"`) {
+			t.Fatalf("Escape(%q) = %q still contains markup", s, escaped)
+		}
+		if got := html.UnescapeString(escaped); got != s {
+			t.Fatalf("Unescape(Escape(%q)) = %q", s, got)
+		}
+	})
+}
+
+// TestSprintfEscapesArgumentsProperty tests that plain arguments are escaped
+// while HTML arguments and the format are kept.
+func TestSprintfEscapesArgumentsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		name := rapid.StringOf(rapid.SampledFrom([]rune(`ab<>&/`))).Draw(t, "name")
+		amount := rapid.Int64Range(-1000, 1000).Draw(t, "amount")
+
+		got := Sprintf("<b>%s</b> %s %d", name, Amount(amount), amount)
+		want := HTML("<b>") + Escape(name) + "</b> " + Amount(amount) + HTML(" "+strconv.FormatInt(amount, 10))
+		if got != want {
+			t.Fatalf("Sprintf = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestSprintfEscapesErrors(t *testing.T) {
+	got := Sprintf("❌ %s", errors.New("a<b"))
+	if got != "❌ a&lt;b" {
+		t.Fatalf("Sprintf = %q, want %q", got, "❌ a&lt;b")
+	}
+}
+
+func TestMention(t *testing.T) {
+	got := Mention(42, `<Bob & "Co">`)
+	want := HTML(`<a href="tg://user?id=42">&lt;Bob &amp; &quot;Co&quot;&gt;</a>`)
+	if got != want {
+		t.Fatalf("Mention = %q, want %q", got, want)
+	}
+}
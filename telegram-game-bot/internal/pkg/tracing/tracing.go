@@ -0,0 +1,77 @@
+// Package tracing records spans of updates, database queries and Telegram API
+// calls with the OpenTelemetry SDK and exports them to a collector over
+// OTLP/HTTP. A nil Tracer records nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName is the name of the tracer the spans are recorded with
+const instrumentationName = "telegram-game-bot"
+
+// Tracer starts spans and exports finished ones in batches.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New creates a tracer exporting to the OTLP/HTTP endpoint of a collector,
+// e.g. http://localhost:4318, under serviceName. samplePercent of traces
+// are recorded. Spans are exported in the background until Shutdown.
+func New(ctx context.Context, endpoint, serviceName string, samplePercent float64) (*Tracer, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return newTracer(sdktrace.NewBatchSpanProcessor(exporter), serviceName, samplePercent), nil
+}
+
+// newTracer creates a tracer handing finished spans to processor
+func newTracer(processor sdktrace.SpanProcessor, serviceName string, samplePercent float64) *Tracer {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		// Children follow the decision of the root, so traces are whole or absent
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplePercent/100))),
+	)
+	return &Tracer{provider: provider, tracer: provider.Tracer(instrumentationName)}
+}
+
+// Start starts a span as a child of the current span of ctx, or as the root
+// of a new trace, and returns a context carrying it. A nil tracer returns a
+// span that records nothing.
+func (t *Tracer) Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End finishes a span, marking it failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+// Shutdown exports the remaining spans and stops the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"pgregory.net/rapid"
+)
+
+// newTestTracer creates a tracer recording spans in memory
+func newTestTracer(samplePercent float64) (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return newTracer(sdktrace.NewSimpleSpanProcessor(exporter), "bot", samplePercent), exporter
+}
+
+// TestChildSpansProperty tests that nested spans share the trace of the root
+// and point at their parent.
+func TestChildSpansProperty(t *testing.T) {
+	tracer, _ := newTestTracer(100)
+	rapid.Check(t, func(t *rapid.T) {
+		depth := rapid.IntRange(1, 10).Draw(t, "depth")
+		ctx, root := tracer.Start(context.Background(), "update", trace.SpanKindServer)
+		parent := root.SpanContext()
+		for i := 0; i < depth; i++ {
+			var span trace.Span
+			ctx, span = tracer.Start(ctx, "child", trace.SpanKindInternal)
+			sc := span.(sdktrace.ReadOnlySpan)
+			if sc.SpanContext().TraceID() != root.SpanContext().TraceID() || sc.Parent().SpanID() != parent.SpanID() {
+				t.Fatalf("Child %d: trace %s parent %s, want trace %s parent %s",
+					i, sc.SpanContext().TraceID(), sc.Parent().SpanID(), root.SpanContext().TraceID(), parent.SpanID())
+			}
+			parent = sc.SpanContext()
+		}
+	})
+}
+
+// TestSampling tests that no traces are recorded at 0% and all at 100%.
+func TestSampling(t *testing.T) {
+	for _, percent := range []float64{0, 100} {
+		tracer, exporter := newTestTracer(percent)
+		for i := 0; i < 20; i++ {
+			ctx, root := tracer.Start(context.Background(), "update", trace.SpanKindServer)
+			_, child := tracer.Start(ctx, "db.query", trace.SpanKindClient)
+			End(child, nil)
+			End(root, nil)
+		}
+		if want := int(percent / 100 * 40); len(exporter.GetSpans()) != want {
+			t.Fatalf("%v%%: got %d spans, want %d", percent, len(exporter.GetSpans()), want)
+		}
+	}
+}
+
+// TestNilTracer tests that a disabled tracer records nothing.
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "update", trace.SpanKindServer)
+	if span.IsRecording() || trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		t.Fatal("Nil tracer started a span")
+	}
+	span.SetAttributes(attribute.String("k", "v"))
+	End(span, errors.New("boom"))
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if tracer.Transport(nil) != http.DefaultTransport {
+		t.Fatal("Nil tracer wrapped the transport")
+	}
+}
+
+// TestEnd tests that failures are marked as errors.
+func TestEnd(t *testing.T) {
+	tracer, exporter := newTestTracer(100)
+	ctx, root := tracer.Start(context.Background(), "/dice", trace.SpanKindServer, attribute.Int64("user_id", 42))
+	_, child := tracer.Start(ctx, "db.query", trace.SpanKindClient)
+	End(child, errors.New("timeout"))
+	End(root, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Got %d spans, want 2", len(spans))
+	}
+	db, dice := spans[0], spans[1]
+	if db.Status.Code != codes.Error || db.Status.Description != "timeout" || db.Parent.SpanID() != dice.SpanContext.SpanID() {
+		t.Fatalf("Query span %+v", db)
+	}
+	if dice.Status.Code != codes.Ok || dice.Parent.IsValid() || dice.SpanKind != trace.SpanKindServer {
+		t.Fatalf("Update span %+v", dice)
+	}
+	if v, ok := dice.Resource.Set().Value("service.name"); !ok || v.AsString() != "bot" {
+		t.Fatalf("Resource %v", dice.Resource)
+	}
+}
+
+// TestTransport tests that Telegram API calls are recorded under the API
+// method without the bot token, and long polls are not recorded.
+func TestTransport(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	tracer, exporter := newTestTracer(100)
+	client := &http.Client{Transport: tracer.Transport(nil)}
+	for _, method := range []string{"sendMessage", "getUpdates"} {
+		resp, err := client.Post(server.URL+"/bot123:SECRET/"+method, "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(paths) != 2 || paths[0] != "/bot123:SECRET/sendMessage" {
+		t.Fatalf("Server got %v", paths)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "telegram sendMessage" || spans[0].SpanKind != trace.SpanKindClient {
+		t.Fatalf("Got spans %v", spans.Snapshots())
+	}
+	for _, attr := range spans[0].Attributes {
+		if strings.Contains(attr.Value.Emit(), "SECRET") {
+			t.Fatalf("Attribute %s holds the token: %s", attr.Key, attr.Value.Emit())
+		}
+	}
+}
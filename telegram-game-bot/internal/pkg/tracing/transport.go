@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// untracedMethods are Telegram API methods not worth a span: long polls
+var untracedMethods = map[string]bool{"getUpdates": true}
+
+// urlKey is the context key of the request URL holding the bot token
+type urlKey struct{}
+
+// Transport wraps base with otelhttp so that every Telegram Bot API call is
+// recorded as a client span named after the API method. The bot token in
+// the URL path is replaced before otelhttp sees the request, so it is never
+// recorded. A nil base uses http.DefaultTransport.
+func (t *Tracer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t == nil {
+		return base
+	}
+	traced := otelhttp.NewTransport(restoreURL{base: base},
+		otelhttp.WithTracerProvider(t.provider),
+		otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+			return "telegram " + path.Base(req.URL.Path)
+		}),
+		otelhttp.WithFilter(func(req *http.Request) bool {
+			return !untracedMethods[path.Base(req.URL.Path)]
+		}),
+	)
+	return redactURL{next: traced}
+}
+
+// redactURL hands next a request whose URL path holds the API method only
+type redactURL struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt redactURL) RoundTrip(req *http.Request) (*http.Response, error) {
+	redacted := req.Clone(context.WithValue(req.Context(), urlKey{}, req.URL))
+	u := *req.URL
+	u.Path = "/bot/" + path.Base(req.URL.Path)
+	u.RawPath = ""
+	redacted.URL = &u
+	return rt.next.RoundTrip(redacted)
+}
+
+// restoreURL sends the request to the URL redactURL replaced
+type restoreURL struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt restoreURL) RoundTrip(req *http.Request) (*http.Response, error) {
+	if original, ok := req.Context().Value(urlKey{}).(*url.URL); ok {
+		req = req.Clone(req.Context())
+		req.URL = original
+	}
+	return rt.base.RoundTrip(req)
+}
@@ -0,0 +1,119 @@
+// Package ttlstore keeps ephemeral state (cooldowns, rate limits, handled
+// callbacks) as keys that expire, in memory or in a durable backend shared
+// by all instances.
+package ttlstore
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Item is a stored key with its value and expiry.
+type Item struct {
+	Key       string
+	Value     string
+	ExpiresAt time.Time
+}
+
+// Store is a key-value store whose keys expire. Expired keys are never
+// returned, even before DeleteExpired removes them.
+type Store interface {
+	// Set stores value under key until expiresAt, replacing any previous value.
+	Set(ctx context.Context, key, value string, expiresAt time.Time) error
+	// SetNX stores value under key until expiresAt unless the key is present,
+	// and reports whether it did.
+	SetNX(ctx context.Context, key, value string, expiresAt time.Time) (bool, error)
+	// Get returns the item of key; ok is false if it is absent or expired.
+	Get(ctx context.Context, key string) (item Item, ok bool, err error)
+	// Scan returns the items whose key starts with prefix.
+	Scan(ctx context.Context, prefix string) ([]Item, error)
+	// DeleteExpired removes expired keys and returns how many there were.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Memory is a Store local to the process. The zero value is not usable, use
+// NewMemory.
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]Item
+	now   func() time.Time
+}
+
+// NewMemory creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string]Item), now: time.Now}
+}
+
+// Set implements Store.
+func (m *Memory) Set(_ context.Context, key, value string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = Item{Key: key, Value: value, ExpiresAt: expiresAt}
+	return nil
+}
+
+// SetNX implements Store.
+func (m *Memory) SetNX(_ context.Context, key, value string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.liveLocked(key); ok {
+		return false, nil
+	}
+	m.items[key] = Item{Key: key, Value: value, ExpiresAt: expiresAt}
+	return true, nil
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, key string) (Item, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.liveLocked(key)
+	return item, ok, nil
+}
+
+// Scan implements Store.
+func (m *Memory) Scan(_ context.Context, prefix string) ([]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []Item
+	for key := range m.items {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if item, ok := m.liveLocked(key); ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// DeleteExpired implements Store.
+func (m *Memory) DeleteExpired(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	var deleted int64
+	for key, item := range m.items {
+		if !item.ExpiresAt.After(now) {
+			delete(m.items, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// liveLocked returns an unexpired item, deleting an expired one; the caller
+// must hold m.mu
+func (m *Memory) liveLocked(key string) (Item, bool) {
+	item, ok := m.items[key]
+	if !ok {
+		return Item{}, false
+	}
+	if !item.ExpiresAt.After(m.now()) {
+		delete(m.items, key)
+		return Item{}, false
+	}
+	return item, true
+}
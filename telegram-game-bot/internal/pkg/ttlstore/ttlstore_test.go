@@ -0,0 +1,61 @@
+package ttlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestMemoryExpiryProperty tests that a key is visible until it expires and
+// can be claimed again with SetNX afterwards.
+func TestMemoryExpiryProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ttl := time.Duration(rapid.Int64Range(1, int64(time.Hour)).Draw(t, "ttl"))
+		elapsed := time.Duration(rapid.Int64Range(0, int64(2*time.Hour)).Draw(t, "elapsed"))
+		ctx := context.Background()
+
+		now := time.Unix(1700000000, 0)
+		m := NewMemory()
+		m.now = func() time.Time { return now }
+
+		if ok, _ := m.SetNX(ctx, "cooldown:1:dice", "a", now.Add(ttl)); !ok {
+			t.Fatal("First SetNX refused")
+		}
+		if ok, _ := m.SetNX(ctx, "cooldown:1:dice", "b", now.Add(ttl)); ok {
+			t.Fatal("Second SetNX claimed a live key")
+		}
+
+		now = now.Add(elapsed)
+		live := elapsed < ttl
+		if _, ok, _ := m.Get(ctx, "cooldown:1:dice"); ok != live {
+			t.Fatalf("After %v of %v: visible %v", elapsed, ttl, ok)
+		}
+		if items, _ := m.Scan(ctx, "cooldown:1:"); (len(items) == 1) != live {
+			t.Fatalf("After %v of %v: scanned %d items", elapsed, ttl, len(items))
+		}
+		if ok, _ := m.SetNX(ctx, "cooldown:1:dice", "c", now.Add(ttl)); ok == live {
+			t.Fatalf("After %v of %v: SetNX claimed %v", elapsed, ttl, ok)
+		}
+	})
+}
+
+// TestMemoryDeleteExpired tests that only expired keys are deleted.
+func TestMemoryDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	_ = m.Set(ctx, "old", "", now.Add(-time.Second))
+	_ = m.Set(ctx, "edge", "", now)
+	_ = m.Set(ctx, "new", "", now.Add(time.Second))
+
+	if deleted, _ := m.DeleteExpired(ctx); deleted != 2 {
+		t.Fatalf("Deleted %d keys, want 2", deleted)
+	}
+	if _, ok, _ := m.Get(ctx, "new"); !ok {
+		t.Fatal("Live key deleted")
+	}
+}
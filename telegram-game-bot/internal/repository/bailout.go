@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// BailoutRepository handles recovery grant state and payouts.
+type BailoutRepository struct {
+	pool *pgxpool.Pool
+	bus  *events.Bus // optional, notified of granted bailouts
+}
+
+// NewBailoutRepository creates a new BailoutRepository instance.
+func NewBailoutRepository(pool *pgxpool.Pool) *BailoutRepository {
+	return &BailoutRepository{pool: pool}
+}
+
+// SetEventBus sets the bus notified of granted bailouts.
+func (r *BailoutRepository) SetEventBus(bus *events.Bus) {
+	r.bus = bus
+}
+
+// TrackFloor records since when users are below the floor and clears the
+// mark of users back at or above it. Archived users are not tracked.
+func (r *BailoutRepository) TrackFloor(ctx context.Context, floor int64) error {
+	const markQuery = `
+		INSERT INTO bailout_states (user_id, below_since)
+		SELECT telegram_id, NOW() FROM users WHERE balance < $1 AND archived_at IS NULL
+		ON CONFLICT (user_id) DO UPDATE SET below_since = NOW()
+		WHERE bailout_states.below_since IS NULL
+	`
+	const clearQuery = `
+		UPDATE bailout_states s
+		SET below_since = NULL
+		FROM users u
+		WHERE u.telegram_id = s.user_id AND (u.balance >= $1 OR u.archived_at IS NOT NULL) AND s.below_since IS NOT NULL
+	`
+
+	if _, err := r.pool.Exec(ctx, markQuery, floor); err != nil {
+		return fmt.Errorf("failed to mark users below bailout floor: %w", err)
+	}
+	if _, err := r.pool.Exec(ctx, clearQuery, floor); err != nil {
+		return fmt.Errorf("failed to clear users above bailout floor: %w", err)
+	}
+	return nil
+}
+
+// ListBelowFloor returns the states of all users currently below the floor.
+func (r *BailoutRepository) ListBelowFloor(ctx context.Context) ([]model.BailoutState, error) {
+	const query = `
+		SELECT user_id, below_since, last_grant_at
+		FROM bailout_states
+		WHERE below_since IS NOT NULL
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bailout states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []model.BailoutState
+	for rows.Next() {
+		var s model.BailoutState
+		if err := rows.Scan(&s.UserID, &s.BelowSince, &s.LastGrantAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bailout state: %w", err)
+		}
+		states = append(states, s)
+	}
+	return states, rows.Err()
+}
+
+// Grant credits a recovery grant to a user still below the floor, records
+// the transaction and resets the user's state, all in one transaction.
+// Returns nil if the user is no longer below the floor.
+func (r *BailoutRepository) Grant(ctx context.Context, userID, amount, floor int64, description string) (*model.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const creditQuery = `
+		UPDATE users
+		SET balance = balance + $2, updated_at = NOW()
+		WHERE telegram_id = $1 AND balance < $3
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+	`
+	var user model.User
+	err = tx.QueryRow(ctx, creditQuery, userID, amount, floor).Scan(
+		&user.TelegramID,
+		&user.Username,
+		&user.Balance,
+		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to credit bailout: %w", err)
+	}
+
+	const txQuery = `
+		INSERT INTO transactions (user_id, amount, type, description, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, user_id, amount, type, description, created_at
+	`
+	var record model.Transaction
+	err = tx.QueryRow(ctx, txQuery, userID, amount, model.TxTypeBailout, description).Scan(
+		&record.ID,
+		&record.UserID,
+		&record.Amount,
+		&record.Type,
+		&record.Description,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record bailout: %w", err)
+	}
+
+	const stateQuery = `
+		UPDATE bailout_states
+		SET below_since = NULL, last_grant_at = NOW()
+		WHERE user_id = $1
+	`
+	if _, err := tx.Exec(ctx, stateQuery, userID); err != nil {
+		return nil, fmt.Errorf("failed to update bailout state: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit bailout: %w", err)
+	}
+
+	publishTransaction(r.bus, &record)
+	return &user, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BalanceAlertRepository handles balance alert opt-ins.
+type BalanceAlertRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBalanceAlertRepository creates a new BalanceAlertRepository instance.
+func NewBalanceAlertRepository(pool *pgxpool.Pool) *BalanceAlertRepository {
+	return &BalanceAlertRepository{pool: pool}
+}
+
+// GetThreshold returns the alert threshold of a user, 0 if the user has not opted in.
+func (r *BalanceAlertRepository) GetThreshold(ctx context.Context, userID int64) (int64, error) {
+	var threshold int64
+	err := r.pool.QueryRow(ctx, `SELECT threshold FROM balance_alerts WHERE user_id = $1`, userID).Scan(&threshold)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get balance alert: %w", err)
+	}
+	return threshold, nil
+}
+
+// SetThreshold opts a user in to balance alerts with the given threshold.
+func (r *BalanceAlertRepository) SetThreshold(ctx context.Context, userID, threshold int64) error {
+	const query = `
+		INSERT INTO balance_alerts (user_id, threshold, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET threshold = $2, updated_at = NOW()
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, threshold); err != nil {
+		return fmt.Errorf("failed to set balance alert: %w", err)
+	}
+	return nil
+}
+
+// Delete opts a user out of balance alerts.
+func (r *BalanceAlertRepository) Delete(ctx context.Context, userID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM balance_alerts WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete balance alert: %w", err)
+	}
+	return nil
+}
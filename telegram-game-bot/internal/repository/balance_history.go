@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// BalanceHistoryRepository handles daily balance snapshots.
+type BalanceHistoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBalanceHistoryRepository creates a new BalanceHistoryRepository instance.
+func NewBalanceHistoryRepository(pool *pgxpool.Pool) *BalanceHistoryRepository {
+	return &BalanceHistoryRepository{pool: pool}
+}
+
+// Record stores the balance of every user updated since the given time as
+// their balance on day. Returns the number of users recorded.
+func (r *BalanceHistoryRepository) Record(ctx context.Context, day, since time.Time) (int64, error) {
+	const query = `
+		INSERT INTO balance_history (user_id, day, balance)
+		SELECT telegram_id, $1::date, balance FROM users WHERE updated_at >= $2
+		ON CONFLICT (user_id, day) DO UPDATE SET balance = EXCLUDED.balance
+	`
+	tag, err := r.pool.Exec(ctx, query, day, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record balance history: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Range returns the balances of a user from one day to another (inclusive),
+// oldest first.
+func (r *BalanceHistoryRepository) Range(ctx context.Context, userID int64, from, to time.Time) ([]model.BalancePoint, error) {
+	const query = `
+		SELECT user_id, day, balance
+		FROM balance_history
+		WHERE user_id = $1 AND day BETWEEN $2::date AND $3::date
+		ORDER BY day
+	`
+	rows, err := r.pool.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []model.BalancePoint
+	for rows.Next() {
+		var p model.BalancePoint
+		if err := rows.Scan(&p.UserID, &p.Day, &p.Balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance history: %w", err)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// LastBefore returns the latest balance of a user recorded before day, nil if none.
+func (r *BalanceHistoryRepository) LastBefore(ctx context.Context, userID int64, day time.Time) (*model.BalancePoint, error) {
+	const query = `
+		SELECT user_id, day, balance
+		FROM balance_history
+		WHERE user_id = $1 AND day < $2::date
+		ORDER BY day DESC
+		LIMIT 1
+	`
+	var p model.BalancePoint
+	err := r.pool.QueryRow(ctx, query, userID, day).Scan(&p.UserID, &p.Day, &p.Balance)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get balance history: %w", err)
+	}
+	return &p, nil
+}
+
+// DeleteBefore removes the snapshots older than day.
+func (r *BalanceHistoryRepository) DeleteBefore(ctx context.Context, day time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM balance_history WHERE day < $1::date`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete balance history: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// BanterRepository handles banter phrase persistence.
+type BanterRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBanterRepository creates a new BanterRepository instance.
+func NewBanterRepository(pool *pgxpool.Pool) *BanterRepository {
+	return &BanterRepository{pool: pool}
+}
+
+// Add stores a banter phrase.
+// Adding a phrase the kind and locale already have returns the existing entry.
+func (r *BanterRepository) Add(ctx context.Context, phrase *model.BanterPhrase) error {
+	const query = `
+		INSERT INTO banter_phrases (kind, locale, text, added_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (kind, locale, text) DO UPDATE SET text = EXCLUDED.text
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, phrase.Kind, phrase.Locale, phrase.Text, phrase.AddedBy).
+		Scan(&phrase.ID, &phrase.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add banter phrase: %w", err)
+	}
+	return nil
+}
+
+// List returns all banter phrases, ordered by kind, locale and ID.
+func (r *BanterRepository) List(ctx context.Context) ([]model.BanterPhrase, error) {
+	const query = `
+		SELECT id, kind, locale, text, added_by, created_at
+		FROM banter_phrases
+		ORDER BY kind, locale, id
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list banter phrases: %w", err)
+	}
+	defer rows.Close()
+
+	var phrases []model.BanterPhrase
+	for rows.Next() {
+		var p model.BanterPhrase
+		if err := rows.Scan(&p.ID, &p.Kind, &p.Locale, &p.Text, &p.AddedBy, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan banter phrase: %w", err)
+		}
+		phrases = append(phrases, p)
+	}
+	return phrases, rows.Err()
+}
+
+// Delete removes a banter phrase. Returns false if it did not exist.
+func (r *BanterRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM banter_phrases WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete banter phrase: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
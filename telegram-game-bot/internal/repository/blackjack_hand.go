@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// BlackjackHandRepository keeps the stakes of blackjack hands in play, so
+// hands the bot did not settle before stopping can be refunded.
+type BlackjackHandRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewBlackjackHandRepository creates a new BlackjackHandRepository instance.
+func NewBlackjackHandRepository(pool *pgxpool.Pool) *BlackjackHandRepository {
+	return &BlackjackHandRepository{pool: pool}
+}
+
+// Open records the stake of a hand dealt to a user, replacing any previous one.
+func (r *BlackjackHandRepository) Open(ctx context.Context, hand *model.OpenBlackjackHand) error {
+	const query = `
+		INSERT INTO blackjack_hands (user_id, chat_id, sandbox_chat_id, stake, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET chat_id = EXCLUDED.chat_id, sandbox_chat_id = EXCLUDED.sandbox_chat_id,
+			stake = EXCLUDED.stake, created_at = EXCLUDED.created_at
+	`
+
+	if _, err := r.pool.Exec(ctx, query, hand.UserID, hand.ChatID, hand.SandboxChatID, hand.Stake); err != nil {
+		return fmt.Errorf("failed to open blackjack hand: %w", err)
+	}
+	return nil
+}
+
+// SetStake updates the stake of a user's hand, e.g. after a double down.
+func (r *BlackjackHandRepository) SetStake(ctx context.Context, userID, stake int64) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE blackjack_hands SET stake = $2 WHERE user_id = $1`, userID, stake); err != nil {
+		return fmt.Errorf("failed to update blackjack stake: %w", err)
+	}
+	return nil
+}
+
+// Close removes the hand of a user once it is settled or refunded.
+// Returns false if the user had no open hand.
+func (r *BlackjackHandRepository) Close(ctx context.Context, userID int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM blackjack_hands WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to close blackjack hand: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// List returns the open hands, oldest first.
+func (r *BlackjackHandRepository) List(ctx context.Context) ([]*model.OpenBlackjackHand, error) {
+	const query = `
+		SELECT user_id, chat_id, sandbox_chat_id, stake, created_at
+		FROM blackjack_hands
+		ORDER BY created_at, user_id
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blackjack hands: %w", err)
+	}
+	defer rows.Close()
+
+	var hands []*model.OpenBlackjackHand
+	for rows.Next() {
+		h := &model.OpenBlackjackHand{}
+		if err := rows.Scan(&h.UserID, &h.ChatID, &h.SandboxChatID, &h.Stake, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blackjack hand: %w", err)
+		}
+		hands = append(hands, h)
+	}
+	return hands, rows.Err()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// CelebrationRepository handles celebration media persistence.
+type CelebrationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCelebrationRepository creates a new CelebrationRepository instance.
+func NewCelebrationRepository(pool *pgxpool.Pool) *CelebrationRepository {
+	return &CelebrationRepository{pool: pool}
+}
+
+// Add stores a media file for a celebration kind.
+// Adding a file the kind already has returns the existing entry.
+func (r *CelebrationRepository) Add(ctx context.Context, media *model.CelebrationMedia) error {
+	const query = `
+		INSERT INTO celebration_media (kind, media_type, file_id, added_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (kind, file_id) DO UPDATE SET media_type = EXCLUDED.media_type
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, media.Kind, media.MediaType, media.FileID, media.AddedBy).
+		Scan(&media.ID, &media.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add celebration media: %w", err)
+	}
+	return nil
+}
+
+// List returns all celebration media, ordered by kind and ID.
+func (r *CelebrationRepository) List(ctx context.Context) ([]model.CelebrationMedia, error) {
+	const query = `
+		SELECT id, kind, media_type, file_id, added_by, created_at
+		FROM celebration_media
+		ORDER BY kind, id
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list celebration media: %w", err)
+	}
+	defer rows.Close()
+
+	var media []model.CelebrationMedia
+	for rows.Next() {
+		var m model.CelebrationMedia
+		if err := rows.Scan(&m.ID, &m.Kind, &m.MediaType, &m.FileID, &m.AddedBy, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan celebration media: %w", err)
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+// Delete removes a celebration media entry. Returns false if it did not exist.
+func (r *CelebrationRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM celebration_media WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete celebration media: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
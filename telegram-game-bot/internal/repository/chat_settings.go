@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// ChatSettingsRepository handles per chat settings.
+type ChatSettingsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewChatSettingsRepository creates a new ChatSettingsRepository instance.
+func NewChatSettingsRepository(pool *pgxpool.Pool) *ChatSettingsRepository {
+	return &ChatSettingsRepository{pool: pool}
+}
+
+// List returns the settings of all chats that have any.
+func (r *ChatSettingsRepository) List(ctx context.Context) ([]model.ChatSettings, error) {
+	const query = `
+		SELECT chat_id, games_enabled, timezone, cleanup_minutes, quick_bets, quiet_start, quiet_end, onboarded_at, updated_by, updated_at
+		FROM chat_settings
+	`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []model.ChatSettings
+	for rows.Next() {
+		var s model.ChatSettings
+		if err := rows.Scan(&s.ChatID, &s.GamesEnabled, &s.Timezone, &s.CleanupMinutes, &s.QuickBets, &s.QuietStart, &s.QuietEnd, &s.OnboardedAt, &s.UpdatedBy, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat settings: %w", err)
+		}
+		settings = append(settings, s)
+	}
+	return settings, rows.Err()
+}
+
+// Upsert stores the settings of a chat.
+func (r *ChatSettingsRepository) Upsert(ctx context.Context, s *model.ChatSettings) error {
+	const query = `
+		INSERT INTO chat_settings (chat_id, games_enabled, timezone, cleanup_minutes, quick_bets, quiet_start, quiet_end, onboarded_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (chat_id) DO UPDATE
+		SET games_enabled = EXCLUDED.games_enabled, timezone = EXCLUDED.timezone,
+			cleanup_minutes = EXCLUDED.cleanup_minutes, quick_bets = EXCLUDED.quick_bets,
+			quiet_start = EXCLUDED.quiet_start, quiet_end = EXCLUDED.quiet_end, onboarded_at = EXCLUDED.onboarded_at,
+			updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`
+	err := r.pool.QueryRow(ctx, query, s.ChatID, s.GamesEnabled, s.Timezone, s.CleanupMinutes, s.QuickBets, s.QuietStart, s.QuietEnd, s.OnboardedAt, s.UpdatedBy).
+		Scan(&s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save chat settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// ChatWhitelistRepository handles the chats admins added to or removed from
+// the configured whitelist.
+type ChatWhitelistRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewChatWhitelistRepository creates a new ChatWhitelistRepository instance.
+func NewChatWhitelistRepository(pool *pgxpool.Pool) *ChatWhitelistRepository {
+	return &ChatWhitelistRepository{pool: pool}
+}
+
+// List returns all whitelist entries.
+func (r *ChatWhitelistRepository) List(ctx context.Context) ([]model.ChatWhitelistEntry, error) {
+	rows, err := r.pool.Query(ctx, `SELECT chat_id, allowed, updated_by, updated_at FROM chat_whitelist ORDER BY updated_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat whitelist: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.ChatWhitelistEntry
+	for rows.Next() {
+		var e model.ChatWhitelistEntry
+		if err := rows.Scan(&e.ChatID, &e.Allowed, &e.UpdatedBy, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat whitelist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Upsert stores whether a chat is allowed, replacing an earlier entry.
+func (r *ChatWhitelistRepository) Upsert(ctx context.Context, e *model.ChatWhitelistEntry) error {
+	const query = `
+		INSERT INTO chat_whitelist (chat_id, allowed, updated_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (chat_id) DO UPDATE
+		SET allowed = EXCLUDED.allowed, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`
+	if err := r.pool.QueryRow(ctx, query, e.ChatID, e.Allowed, e.UpdatedBy).Scan(&e.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save chat whitelist entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entry of a chat, leaving it to the configured whitelist.
+func (r *ChatWhitelistRepository) Delete(ctx context.Context, chatID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM chat_whitelist WHERE chat_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete chat whitelist entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// ErrComebackOfferUnavailable is returned when claiming an offer that is
+// claimed, expired or not the user's.
+var ErrComebackOfferUnavailable = errors.New("comeback offer unavailable")
+
+// ComebackRepository handles welcome-back offers and the opt-ins for them.
+type ComebackRepository struct {
+	pool *pgxpool.Pool
+	bus  *events.Bus // optional, notified of claimed bonuses
+}
+
+// NewComebackRepository creates a new ComebackRepository instance.
+func NewComebackRepository(pool *pgxpool.Pool) *ComebackRepository {
+	return &ComebackRepository{pool: pool}
+}
+
+// SetEventBus sets the bus that claimed bonuses are published to.
+func (r *ComebackRepository) SetEventBus(bus *events.Bus) {
+	r.bus = bus
+}
+
+// IsOptedIn reports whether a user receives welcome-back offers.
+func (r *ComebackRepository) IsOptedIn(ctx context.Context, userID int64) (bool, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx, `SELECT user_id FROM comeback_optins WHERE user_id = $1`, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get comeback opt-in: %w", err)
+	}
+	return true, nil
+}
+
+// Enable opts a user in to welcome-back offers.
+func (r *ComebackRepository) Enable(ctx context.Context, userID int64) error {
+	const query = `INSERT INTO comeback_optins (user_id, created_at) VALUES ($1, NOW()) ON CONFLICT (user_id) DO NOTHING`
+	if _, err := r.pool.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to enable comeback offers: %w", err)
+	}
+	return nil
+}
+
+// Disable opts a user out of welcome-back offers.
+func (r *ComebackRepository) Disable(ctx context.Context, userID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM comeback_optins WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable comeback offers: %w", err)
+	}
+	return nil
+}
+
+// Candidates returns the activity of opted in users who are not self-excluded,
+// played a game since playedSince and not since inactiveSince. PlayDays counts
+// the days played in lookbackDays before the last game, MonthOffers the offers
+// made since monthStart.
+func (r *ComebackRepository) Candidates(ctx context.Context, gameTypes []string, playedSince, inactiveSince, monthStart time.Time, lookbackDays int) ([]*model.ComebackActivity, error) {
+	const query = `
+		WITH last_play AS (
+			SELECT user_id, MAX(created_at) AS last_played
+			FROM transactions
+			WHERE type = ANY($1) AND created_at >= $2
+			GROUP BY user_id
+		)
+		SELECT l.user_id, l.last_played,
+			(SELECT COUNT(DISTINCT t.created_at::date) FROM transactions t
+			 WHERE t.user_id = l.user_id AND t.type = ANY($1)
+			   AND t.created_at >= l.last_played - make_interval(days => $5)),
+			(SELECT COUNT(*) FROM comeback_offers c WHERE c.user_id = l.user_id AND c.created_at >= $4),
+			(SELECT MAX(c.created_at) FROM comeback_offers c WHERE c.user_id = l.user_id)
+		FROM last_play l
+		JOIN comeback_optins o ON o.user_id = l.user_id
+		JOIN users u ON u.telegram_id = l.user_id
+		WHERE l.last_played < $3
+		  AND (u.self_excluded_until IS NULL OR u.self_excluded_until <= NOW())
+	`
+
+	rows, err := r.pool.Query(ctx, query, gameTypes, playedSince, inactiveSince, monthStart, lookbackDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comeback candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*model.ComebackActivity
+	for rows.Next() {
+		var a model.ComebackActivity
+		if err := rows.Scan(&a.UserID, &a.LastPlayed, &a.PlayDays, &a.MonthOffers, &a.LastOfferAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comeback candidate: %w", err)
+		}
+		candidates = append(candidates, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comeback candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// CreateOffer records an offer of bonus coins to a user, claimable until expiresAt.
+func (r *ComebackRepository) CreateOffer(ctx context.Context, userID, bonus int64, expiresAt time.Time) (*model.ComebackOffer, error) {
+	const query = `
+		INSERT INTO comeback_offers (user_id, bonus, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, user_id, bonus, delivered, expires_at, claimed_at, created_at
+	`
+
+	var offer model.ComebackOffer
+	err := r.pool.QueryRow(ctx, query, userID, bonus, expiresAt).Scan(
+		&offer.ID,
+		&offer.UserID,
+		&offer.Bonus,
+		&offer.Delivered,
+		&offer.ExpiresAt,
+		&offer.ClaimedAt,
+		&offer.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comeback offer: %w", err)
+	}
+	return &offer, nil
+}
+
+// MarkDelivered records that the DM of an offer reached the user.
+func (r *ComebackRepository) MarkDelivered(ctx context.Context, offerID int64) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE comeback_offers SET delivered = TRUE WHERE id = $1`, offerID); err != nil {
+		return fmt.Errorf("failed to mark comeback offer delivered: %w", err)
+	}
+	return nil
+}
+
+// Claim credits the bonus of an unclaimed, unexpired offer of a user, records
+// the transaction and marks the offer claimed, all in one transaction.
+// Returns ErrComebackOfferUnavailable if the offer cannot be claimed.
+func (r *ComebackRepository) Claim(ctx context.Context, offerID, userID int64, description string) (*model.User, int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const claimQuery = `
+		UPDATE comeback_offers
+		SET claimed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND claimed_at IS NULL AND expires_at > NOW()
+		RETURNING bonus
+	`
+	var bonus int64
+	if err := tx.QueryRow(ctx, claimQuery, offerID, userID).Scan(&bonus); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, ErrComebackOfferUnavailable
+		}
+		return nil, 0, fmt.Errorf("failed to claim comeback offer: %w", err)
+	}
+
+	const creditQuery = `
+		UPDATE users
+		SET balance = balance + $2, updated_at = NOW()
+		WHERE telegram_id = $1
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+	`
+	var user model.User
+	err = tx.QueryRow(ctx, creditQuery, userID, bonus).Scan(
+		&user.TelegramID,
+		&user.Username,
+		&user.Balance,
+		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, ErrUserNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to credit comeback bonus: %w", err)
+	}
+
+	const txQuery = `
+		INSERT INTO transactions (user_id, amount, type, description, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, user_id, amount, type, description, created_at
+	`
+	var record model.Transaction
+	err = tx.QueryRow(ctx, txQuery, userID, bonus, model.TxTypeComeback, description).Scan(
+		&record.ID,
+		&record.UserID,
+		&record.Amount,
+		&record.Type,
+		&record.Description,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to record comeback bonus: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit comeback bonus: %w", err)
+	}
+
+	publishTransaction(r.bus, &record)
+	return &user, bonus, nil
+}
+
+// Stats measures the offers delivered since the given time. An offer counts
+// as returned once the user played any of gameTypes after it was made.
+func (r *ComebackRepository) Stats(ctx context.Context, since time.Time, gameTypes []string) (*model.ComebackStats, error) {
+	const query = `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE c.claimed_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM transactions t
+				WHERE t.user_id = c.user_id AND t.type = ANY($2) AND t.created_at > c.created_at
+			)),
+			COALESCE(SUM(c.bonus) FILTER (WHERE c.claimed_at IS NOT NULL), 0)
+		FROM comeback_offers c
+		WHERE c.delivered AND c.created_at >= $1
+	`
+
+	var stats model.ComebackStats
+	err := r.pool.QueryRow(ctx, query, since, gameTypes).Scan(&stats.Offered, &stats.Claimed, &stats.Returned, &stats.BonusPaid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comeback stats: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// Compensation errors.
+var (
+	ErrIncidentNotFound = errors.New("compensation incident not found")
+	ErrIncidentResolved = errors.New("compensation incident already resolved")
+)
+
+// CompensationRepository handles compensation incidents and their entries.
+type CompensationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCompensationRepository creates a new CompensationRepository instance.
+func NewCompensationRepository(pool *pgxpool.Pool) *CompensationRepository {
+	return &CompensationRepository{pool: pool}
+}
+
+// CreateIncident stores an incident together with its entries in one transaction.
+func (r *CompensationRepository) CreateIncident(ctx context.Context, incident *model.CompensationIncident, entries []*model.CompensationEntry) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const incidentQuery = `
+		INSERT INTO compensation_incidents (kind, description, status, total_amount, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, incidentQuery, incident.Kind, incident.Description, incident.Status, incident.TotalAmount).
+		Scan(&incident.ID, &incident.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create compensation incident: %w", err)
+	}
+
+	const entryQuery = `
+		INSERT INTO compensation_entries (incident_id, user_id, amount, paid)
+		VALUES ($1, $2, $3, FALSE)
+		RETURNING id
+	`
+	for _, entry := range entries {
+		entry.IncidentID = incident.ID
+		if err := tx.QueryRow(ctx, entryQuery, incident.ID, entry.UserID, entry.Amount).Scan(&entry.ID); err != nil {
+			return fmt.Errorf("failed to create compensation entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit compensation incident: %w", err)
+	}
+	return nil
+}
+
+// GetIncident retrieves an incident.
+// Returns ErrIncidentNotFound if the incident does not exist.
+func (r *CompensationRepository) GetIncident(ctx context.Context, incidentID int64) (*model.CompensationIncident, error) {
+	const query = `
+		SELECT id, kind, description, status, total_amount, reviewed_by, created_at, resolved_at
+		FROM compensation_incidents
+		WHERE id = $1
+	`
+
+	var incident model.CompensationIncident
+	err := r.pool.QueryRow(ctx, query, incidentID).Scan(
+		&incident.ID,
+		&incident.Kind,
+		&incident.Description,
+		&incident.Status,
+		&incident.TotalAmount,
+		&incident.ReviewedBy,
+		&incident.CreatedAt,
+		&incident.ResolvedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, fmt.Errorf("failed to get compensation incident: %w", err)
+	}
+	return &incident, nil
+}
+
+// GetPendingIncidents returns incidents waiting for admin approval, oldest first.
+func (r *CompensationRepository) GetPendingIncidents(ctx context.Context, limit int) ([]*model.CompensationIncident, error) {
+	const query = `
+		SELECT id, kind, description, status, total_amount, reviewed_by, created_at, resolved_at
+		FROM compensation_incidents
+		WHERE status = 'pending'
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []*model.CompensationIncident
+	for rows.Next() {
+		var incident model.CompensationIncident
+		if err := rows.Scan(
+			&incident.ID,
+			&incident.Kind,
+			&incident.Description,
+			&incident.Status,
+			&incident.TotalAmount,
+			&incident.ReviewedBy,
+			&incident.CreatedAt,
+			&incident.ResolvedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, &incident)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating incidents: %w", err)
+	}
+	return incidents, nil
+}
+
+// GetUnpaidEntries returns the unpaid entries of an incident.
+func (r *CompensationRepository) GetUnpaidEntries(ctx context.Context, incidentID int64) ([]*model.CompensationEntry, error) {
+	const query = `
+		SELECT id, incident_id, user_id, amount, paid
+		FROM compensation_entries
+		WHERE incident_id = $1 AND paid = FALSE
+		ORDER BY id
+	`
+
+	rows, err := r.pool.Query(ctx, query, incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compensation entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*model.CompensationEntry
+	for rows.Next() {
+		var entry model.CompensationEntry
+		if err := rows.Scan(&entry.ID, &entry.IncidentID, &entry.UserID, &entry.Amount, &entry.Paid); err != nil {
+			return nil, fmt.Errorf("failed to scan compensation entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating compensation entries: %w", err)
+	}
+	return entries, nil
+}
+
+// MarkEntryPaid marks an entry as paid. Returns false if it was already paid.
+func (r *CompensationRepository) MarkEntryPaid(ctx context.Context, entryID int64) (bool, error) {
+	const query = `UPDATE compensation_entries SET paid = TRUE, paid_at = NOW() WHERE id = $1 AND paid = FALSE`
+
+	result, err := r.pool.Exec(ctx, query, entryID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark compensation entry paid: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ResolveIncident moves a pending incident to the given status.
+// Returns ErrIncidentResolved if it is no longer pending.
+func (r *CompensationRepository) ResolveIncident(ctx context.Context, incidentID int64, status string, reviewerID *int64) error {
+	const query = `
+		UPDATE compensation_incidents
+		SET status = $2, reviewed_by = $3, resolved_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.pool.Exec(ctx, query, incidentID, status, reviewerID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve compensation incident: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		if _, err := r.GetIncident(ctx, incidentID); err != nil {
+			return err
+		}
+		return ErrIncidentResolved
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// ErrCosmeticNotOwned is returned when equipping a cosmetic the user does not own.
+var ErrCosmeticNotOwned = errors.New("cosmetic not owned")
+
+// CosmeticRepository handles the Telegram Stars purchase ledger and owned cosmetics.
+type CosmeticRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCosmeticRepository creates a new CosmeticRepository instance.
+func NewCosmeticRepository(pool *pgxpool.Pool) *CosmeticRepository {
+	return &CosmeticRepository{pool: pool}
+}
+
+// RecordPurchase records a successful payment in the ledger and grants the
+// cosmetic in one database transaction. Telegram may deliver a payment more than
+// once; a charge that is already recorded returns false and changes nothing.
+func (r *CosmeticRepository) RecordPurchase(ctx context.Context, purchase *model.StarPurchase, kind string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const ledgerQuery = `
+		INSERT INTO star_purchases (user_id, item_id, currency, amount, payload, telegram_charge_id, provider_charge_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (telegram_charge_id) DO NOTHING
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, ledgerQuery,
+		purchase.UserID,
+		purchase.ItemID,
+		purchase.Currency,
+		purchase.Amount,
+		purchase.Payload,
+		purchase.TelegramChargeID,
+		purchase.ProviderChargeID,
+	).Scan(&purchase.ID, &purchase.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record star purchase: %w", err)
+	}
+
+	// Paying twice for the same cosmetic keeps the first grant, the ledger still has both payments
+	const grantQuery = `
+		INSERT INTO user_cosmetics (user_id, item_id, kind, equipped, purchase_id, acquired_at)
+		VALUES ($1, $2, $3, FALSE, $4, NOW())
+		ON CONFLICT (user_id, item_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, grantQuery, purchase.UserID, purchase.ItemID, kind, purchase.ID); err != nil {
+		return false, fmt.Errorf("failed to grant cosmetic: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit star purchase: %w", err)
+	}
+	return true, nil
+}
+
+// Owns reports whether a user owns a cosmetic.
+func (r *CosmeticRepository) Owns(ctx context.Context, userID int64, itemID string) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM user_cosmetics WHERE user_id = $1 AND item_id = $2)`
+
+	var owned bool
+	if err := r.pool.QueryRow(ctx, query, userID, itemID).Scan(&owned); err != nil {
+		return false, fmt.Errorf("failed to check cosmetic: %w", err)
+	}
+	return owned, nil
+}
+
+// ListOwned returns the cosmetics owned by a user, oldest first.
+func (r *CosmeticRepository) ListOwned(ctx context.Context, userID int64) ([]*model.UserCosmetic, error) {
+	const query = `
+		SELECT user_id, item_id, kind, equipped, purchase_id, acquired_at
+		FROM user_cosmetics
+		WHERE user_id = $1
+		ORDER BY acquired_at, item_id
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cosmetics: %w", err)
+	}
+	defer rows.Close()
+
+	var cosmetics []*model.UserCosmetic
+	for rows.Next() {
+		var c model.UserCosmetic
+		if err := rows.Scan(&c.UserID, &c.ItemID, &c.Kind, &c.Equipped, &c.PurchaseID, &c.AcquiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cosmetic: %w", err)
+		}
+		cosmetics = append(cosmetics, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cosmetics: %w", err)
+	}
+	return cosmetics, nil
+}
+
+// Equip equips an owned cosmetic, unequipping the one of the same kind.
+// Returns ErrCosmeticNotOwned if the user does not own the cosmetic.
+func (r *CosmeticRepository) Equip(ctx context.Context, userID int64, itemID, kind string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const unequipQuery = `
+		UPDATE user_cosmetics SET equipped = FALSE
+		WHERE user_id = $1 AND kind = $2 AND equipped AND item_id <> $3
+	`
+	if _, err := tx.Exec(ctx, unequipQuery, userID, kind, itemID); err != nil {
+		return fmt.Errorf("failed to unequip cosmetic: %w", err)
+	}
+
+	const equipQuery = `UPDATE user_cosmetics SET equipped = TRUE WHERE user_id = $1 AND item_id = $2`
+	result, err := tx.Exec(ctx, equipQuery, userID, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to equip cosmetic: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrCosmeticNotOwned
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit cosmetic equip: %w", err)
+	}
+	return nil
+}
+
+// Unequip unequips the cosmetic of a kind, if any.
+func (r *CosmeticRepository) Unequip(ctx context.Context, userID int64, kind string) error {
+	const query = `UPDATE user_cosmetics SET equipped = FALSE WHERE user_id = $1 AND kind = $2 AND equipped`
+	if _, err := r.pool.Exec(ctx, query, userID, kind); err != nil {
+		return fmt.Errorf("failed to unequip cosmetic: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GameVolume is the activity of one transaction type of a house game.
+type GameVolume struct {
+	Wagered  int64 // Stakes debited from players
+	HouseNet int64 // Stakes minus payouts, positive when the house won
+	Rounds   int   // Number of stakes
+}
+
+// DigestRepository provides the aggregates of the admin digest.
+type DigestRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDigestRepository creates a new DigestRepository instance.
+func NewDigestRepository(pool *pgxpool.Pool) *DigestRepository {
+	return &DigestRepository{pool: pool}
+}
+
+// Activity returns the number of users created since the given time and the
+// number of users with a transaction since then.
+func (r *DigestRepository) Activity(ctx context.Context, since time.Time) (newUsers, activeUsers int, err error) {
+	const query = `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE created_at >= $1),
+			(SELECT COUNT(DISTINCT user_id) FROM transactions WHERE created_at >= $1)
+	`
+
+	if err := r.pool.QueryRow(ctx, query, since).Scan(&newUsers, &activeUsers); err != nil {
+		return 0, 0, fmt.Errorf("failed to get user activity: %w", err)
+	}
+	return newUsers, activeUsers, nil
+}
+
+// GameVolumes returns the activity per transaction type since the given time,
+// for the given types only.
+func (r *DigestRepository) GameVolumes(ctx context.Context, since time.Time, txTypes []string) (map[string]GameVolume, error) {
+	const query = `
+		SELECT type,
+			COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0),
+			COALESCE(-SUM(amount), 0),
+			COUNT(*) FILTER (WHERE amount < 0)
+		FROM transactions
+		WHERE created_at >= $1 AND type = ANY($2)
+		GROUP BY type
+	`
+
+	rows, err := r.pool.Query(ctx, query, since, txTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game volumes: %w", err)
+	}
+	defer rows.Close()
+
+	volumes := make(map[string]GameVolume)
+	for rows.Next() {
+		var txType string
+		var v GameVolume
+		if err := rows.Scan(&txType, &v.Wagered, &v.HouseNet, &v.Rounds); err != nil {
+			return nil, fmt.Errorf("failed to scan game volume: %w", err)
+		}
+		volumes[txType] = v
+	}
+	return volumes, rows.Err()
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// FeatureFlagRepository handles feature flag overrides.
+type FeatureFlagRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewFeatureFlagRepository creates a new FeatureFlagRepository instance.
+func NewFeatureFlagRepository(pool *pgxpool.Pool) *FeatureFlagRepository {
+	return &FeatureFlagRepository{pool: pool}
+}
+
+// List returns all overrides ordered by flag, scope and target.
+func (r *FeatureFlagRepository) List(ctx context.Context) ([]model.FeatureFlag, error) {
+	const query = `
+		SELECT flag, scope, target_id, enabled, updated_by, updated_at
+		FROM feature_flags
+		ORDER BY flag, scope, target_id
+	`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	var flags []model.FeatureFlag
+	for rows.Next() {
+		var f model.FeatureFlag
+		if err := rows.Scan(&f.Flag, &f.Scope, &f.TargetID, &f.Enabled, &f.UpdatedBy, &f.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
+// Upsert stores an override, replacing an earlier one of the same target.
+func (r *FeatureFlagRepository) Upsert(ctx context.Context, f *model.FeatureFlag) error {
+	const query = `
+		INSERT INTO feature_flags (flag, scope, target_id, enabled, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (flag, scope, target_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`
+	if err := r.pool.QueryRow(ctx, query, f.Flag, f.Scope, f.TargetID, f.Enabled, f.UpdatedBy).Scan(&f.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an override. Returns false if there was none.
+func (r *FeatureFlagRepository) Delete(ctx context.Context, flag, scope string, targetID int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM feature_flags WHERE flag = $1 AND scope = $2 AND target_id = $3`, flag, scope, targetID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// GamblingLimitRepository handles self-set gambling limits and daily totals.
+type GamblingLimitRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewGamblingLimitRepository creates a new GamblingLimitRepository instance.
+func NewGamblingLimitRepository(pool *pgxpool.Pool) *GamblingLimitRepository {
+	return &GamblingLimitRepository{pool: pool}
+}
+
+// GetLimits returns the limits of a user, nil if none were ever set.
+func (r *GamblingLimitRepository) GetLimits(ctx context.Context, userID int64) (*model.GamblingLimits, error) {
+	const query = `SELECT user_id, daily_wager, daily_loss, updated_at FROM gambling_limits WHERE user_id = $1`
+
+	var limits model.GamblingLimits
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&limits.UserID, &limits.DailyWager, &limits.DailyLoss, &limits.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get gambling limits: %w", err)
+	}
+	return &limits, nil
+}
+
+// SetLimits stores the limits of a user.
+func (r *GamblingLimitRepository) SetLimits(ctx context.Context, userID, dailyWager, dailyLoss int64) error {
+	const query = `
+		INSERT INTO gambling_limits (user_id, daily_wager, daily_loss, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET daily_wager = $2, daily_loss = $3, updated_at = NOW()
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, dailyWager, dailyLoss); err != nil {
+		return fmt.Errorf("failed to set gambling limits: %w", err)
+	}
+	return nil
+}
+
+// AddDayTotals adds stakes and a net result to a user's totals of a day.
+func (r *GamblingLimitRepository) AddDayTotals(ctx context.Context, userID int64, day time.Time, wagered, net int64) error {
+	const query = `
+		INSERT INTO gambling_day_totals (user_id, day, wagered, net)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET wagered = gambling_day_totals.wagered + $3, net = gambling_day_totals.net + $4
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, day, wagered, net); err != nil {
+		return fmt.Errorf("failed to add gambling day totals: %w", err)
+	}
+	return nil
+}
+
+// GetDayTotals returns a user's totals of a day, zero if nothing was played.
+func (r *GamblingLimitRepository) GetDayTotals(ctx context.Context, userID int64, day time.Time) (*model.GamblingDayTotals, error) {
+	const query = `SELECT wagered, net FROM gambling_day_totals WHERE user_id = $1 AND day = $2`
+
+	totals := &model.GamblingDayTotals{UserID: userID, Day: day}
+	err := r.pool.QueryRow(ctx, query, userID, day).Scan(&totals.Wagered, &totals.Net)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get gambling day totals: %w", err)
+	}
+	return totals, nil
+}
+
+// DeleteDayTotalsBefore removes the totals of days before the given day.
+// Returns the number of rows removed.
+func (r *GamblingLimitRepository) DeleteDayTotalsBefore(ctx context.Context, day time.Time) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM gambling_day_totals WHERE day < $1`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old gambling day totals: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// ErrGameRoundNotFound is returned when no recorded round matches.
+var ErrGameRoundNotFound = errors.New("game round not found")
+
+// gameRoundColumns lists the columns scanned by scanGameRound
+const gameRoundColumns = `id, game, user_id, chat_id, bet, dice_values, premium, refund_percent, payout, refund, created_at`
+
+// GameRoundRepository handles recorded house game rounds.
+type GameRoundRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewGameRoundRepository creates a new GameRoundRepository instance.
+func NewGameRoundRepository(pool *pgxpool.Pool) *GameRoundRepository {
+	return &GameRoundRepository{pool: pool}
+}
+
+// Create records a round, setting its ID and creation time.
+func (r *GameRoundRepository) Create(ctx context.Context, round *model.GameRound) error {
+	const query = `
+		INSERT INTO game_rounds (game, user_id, chat_id, bet, dice_values, premium, refund_percent, payout, refund, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		RETURNING id, created_at
+	`
+	err := r.pool.QueryRow(ctx, query, round.Game, round.UserID, round.ChatID, round.Bet, round.Values,
+		round.Premium, round.RefundPercent, round.Payout, round.Refund).Scan(&round.ID, &round.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create game round: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a round.
+// Returns ErrGameRoundNotFound if the round does not exist.
+func (r *GameRoundRepository) GetByID(ctx context.Context, id int64) (*model.GameRound, error) {
+	query := `SELECT ` + gameRoundColumns + ` FROM game_rounds WHERE id = $1`
+	return r.scanGameRound(r.pool.QueryRow(ctx, query, id))
+}
+
+// FindNear retrieves the round of a game played by a user closest to at,
+// within window on either side.
+// Returns ErrGameRoundNotFound if there is none.
+func (r *GameRoundRepository) FindNear(ctx context.Context, userID int64, game string, at time.Time, window time.Duration) (*model.GameRound, error) {
+	query := `
+		SELECT ` + gameRoundColumns + `
+		FROM game_rounds
+		WHERE user_id = $1 AND game = $2 AND created_at BETWEEN $3 AND $4
+		ORDER BY ABS(EXTRACT(EPOCH FROM created_at - $5::timestamptz))
+		LIMIT 1
+	`
+	return r.scanGameRound(r.pool.QueryRow(ctx, query, userID, game, at.Add(-window), at.Add(window), at))
+}
+
+// scanGameRound scans a row of gameRoundColumns
+func (r *GameRoundRepository) scanGameRound(row pgx.Row) (*model.GameRound, error) {
+	var round model.GameRound
+	err := row.Scan(&round.ID, &round.Game, &round.UserID, &round.ChatID, &round.Bet, &round.Values,
+		&round.Premium, &round.RefundPercent, &round.Payout, &round.Refund, &round.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGameRoundNotFound
+		}
+		return nil, fmt.Errorf("failed to get game round: %w", err)
+	}
+	return &round, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// IdempotencyRepository applies balance changes requested by external
+// callers that may retry them. The key, the change and its result are written
+// in one database transaction, so a change is either applied with its key or
+// not at all, and a crash can never leave it applied without the key.
+type IdempotencyRepository struct {
+	pool *pgxpool.Pool
+	bus  *events.Bus // optional, notified of applied transactions
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository instance.
+func NewIdempotencyRepository(pool *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{pool: pool}
+}
+
+// SetEventBus sets the bus that applied transactions are published to.
+func (r *IdempotencyRepository) SetEventBus(bus *events.Bus) {
+	r.bus = bus
+}
+
+// idempotentResult is what a change applied under a key returns
+type idempotentResult struct {
+	user *model.User          // User of the result
+	txs  []*model.Transaction // Recorded transactions, published once committed
+}
+
+// once applies a change under a key. Returns nil and the result if the key
+// was free, otherwise the record of the earlier request without applying
+// anything.
+func (r *IdempotencyRepository) once(
+	ctx context.Context,
+	key, operation, fingerprint string,
+	apply func(tx pgx.Tx) (*idempotentResult, error),
+) (*model.IdempotencyKey, *idempotentResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A concurrent request with the same key waits here until the first
+	// commits, then finds its record, or takes the key if the first rolled back
+	const claimQuery = `
+		INSERT INTO idempotency_keys (key, operation, fingerprint, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (key) DO NOTHING
+		RETURNING key
+	`
+	var claimed string
+	err = tx.QueryRow(ctx, claimQuery, key, operation, fingerprint).Scan(&claimed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		record, err := r.get(ctx, tx, key)
+		return record, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	result, err := apply(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	const completeQuery = `
+		UPDATE idempotency_keys
+		SET completed = TRUE, user_id = $2, balance = $3
+		WHERE key = $1
+	`
+	if _, err := tx.Exec(ctx, completeQuery, key, result.user.TelegramID, result.user.Balance); err != nil {
+		return nil, nil, fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit %s: %w", operation, err)
+	}
+
+	for _, t := range result.txs {
+		publishTransaction(r.bus, t)
+	}
+	return nil, result, nil
+}
+
+// get returns the record of a key
+func (r *IdempotencyRepository) get(ctx context.Context, tx pgx.Tx, key string) (*model.IdempotencyKey, error) {
+	const query = `
+		SELECT key, operation, fingerprint, completed, user_id, balance, created_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+	var record model.IdempotencyKey
+	err := tx.QueryRow(ctx, query, key).Scan(
+		&record.Key,
+		&record.Operation,
+		&record.Fingerprint,
+		&record.Completed,
+		&record.UserID,
+		&record.Balance,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &record, nil
+}
+
+// ApplyBalance adds amount to a user's balance and records the transaction
+// under a key. Returns the updated user if the key was free, otherwise the
+// record of the earlier request, whose change is not applied again.
+// Returns ErrInsufficientBalance if a negative amount exceeds the balance and
+// ErrUserNotFound if the user does not exist.
+func (r *IdempotencyRepository) ApplyBalance(
+	ctx context.Context,
+	key, operation, fingerprint string,
+	userID, amount int64,
+	txType string,
+	description *string,
+) (*model.IdempotencyKey, *model.User, error) {
+	record, result, err := r.once(ctx, key, operation, fingerprint, func(tx pgx.Tx) (*idempotentResult, error) {
+		user, err := updateBalance(ctx, tx, userID, amount)
+		if err != nil {
+			return nil, err
+		}
+		const txQuery = `
+			INSERT INTO transactions (user_id, amount, type, description, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			RETURNING id, user_id, amount, type, description, created_at
+		`
+		var t model.Transaction
+		err = tx.QueryRow(ctx, txQuery, userID, amount, txType, description).Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Amount,
+			&t.Type,
+			&t.Description,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transaction: %w", err)
+		}
+		return &idempotentResult{user: user, txs: []*model.Transaction{&t}}, nil
+	})
+	if err != nil || record != nil {
+		return record, nil, err
+	}
+	return nil, result.user, nil
+}
+
+// ApplyTransfer moves amount from one user to another as one ledger entry
+// with a transaction leg per user, under a key. Returns the sender after the
+// debit if the key was free, otherwise the record of the earlier request,
+// whose transfer is not applied again.
+// Returns ErrInsufficientBalance if the sender holds less than amount and
+// ErrUserNotFound if either user does not exist.
+func (r *IdempotencyRepository) ApplyTransfer(
+	ctx context.Context,
+	key, operation, fingerprint string,
+	fromID, toID, amount int64,
+	txType string,
+	fromDesc, toDesc *string,
+) (*model.IdempotencyKey, *model.User, error) {
+	record, result, err := r.once(ctx, key, operation, fingerprint, func(tx pgx.Tx) (*idempotentResult, error) {
+		sender, err := updateBalance(ctx, tx, fromID, -amount)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := updateBalance(ctx, tx, toID, amount); err != nil {
+			return nil, err
+		}
+
+		const entryQuery = `
+			INSERT INTO ledger_entries (debit_account, credit_account, amount, type, description, created_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+			RETURNING id
+		`
+		var entryID int64
+		if err := tx.QueryRow(ctx, entryQuery, fromID, toID, amount, txType, toDesc).Scan(&entryID); err != nil {
+			return nil, fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+		from, err := createLeg(ctx, tx, entryID, fromID, -amount, txType, fromDesc)
+		if err != nil {
+			return nil, err
+		}
+		to, err := createLeg(ctx, tx, entryID, toID, amount, txType, toDesc)
+		if err != nil {
+			return nil, err
+		}
+		return &idempotentResult{user: sender, txs: []*model.Transaction{from, to}}, nil
+	})
+	if err != nil || record != nil {
+		return record, nil, err
+	}
+	return nil, result.user, nil
+}
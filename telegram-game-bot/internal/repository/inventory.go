@@ -3,26 +3,45 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
 )
 
+// ErrNotEnoughUses is returned when selling back more uses than a user holds.
+var ErrNotEnoughUses = errors.New("not enough item uses")
+
 // UserItem represents a use-count based item in user's inventory
 // Requirements: 3.7, 4.5, 5.5, 6.6, 7.7, 8.5, 9.6 - Use count based items
 type UserItem struct {
 	UserID    int64
 	ItemType  string
 	UseCount  int
+	ExpiresAt *time.Time // nil if the item never expires
 	UpdatedAt time.Time
 }
 
+// UserEffect represents a duration based effect on a user
+type UserEffect struct {
+	UserID     int64
+	EffectType string
+	ExpiresAt  time.Time
+}
+
 // HandcuffLock represents a user locked by handcuffs
 type HandcuffLock struct {
-	TargetID  int64
-	LockedBy  int64
-	ExpiresAt time.Time
-	CreatedAt time.Time
+	TargetID         int64
+	LockedBy         int64
+	ExpiresAt        time.Time
+	CreatedAt        time.Time
+	StruggleAttempts int        // /struggle attempts made against this lock
+	LastStruggleAt   *time.Time // nil if the user has not struggled yet
 }
 
 // DailyPurchase represents a daily purchase record
@@ -37,6 +56,7 @@ type DailyPurchase struct {
 // InventoryRepository handles shop item persistence
 type InventoryRepository struct {
 	pool *pgxpool.Pool
+	bus  *events.Bus // optional, notified of sell-back credits
 }
 
 // NewInventoryRepository creates a new InventoryRepository instance
@@ -44,18 +64,36 @@ func NewInventoryRepository(pool *pgxpool.Pool) *InventoryRepository {
 	return &InventoryRepository{pool: pool}
 }
 
+// SetEventBus sets the bus that sell-back transactions are published to
+func (r *InventoryRepository) SetEventBus(bus *events.Bus) {
+	r.bus = bus
+}
+
 // ========== User Items (Use Count Based) ==========
 
 // AddItem adds use count to a user's item
 // Requirements: 3.6 - Add item with use count
 func (r *InventoryRepository) AddItem(ctx context.Context, userID int64, itemType string, useCount int) error {
+	return r.AddItemWithExpiry(ctx, userID, itemType, useCount, nil)
+}
+
+// AddItemWithExpiry adds use count to a user's item and sets its expiry (nil = never expires)
+// Uses left on an already expired row are discarded, and the expiry warning is reset
+func (r *InventoryRepository) AddItemWithExpiry(ctx context.Context, userID int64, itemType string, useCount int, expiresAt *time.Time) error {
 	const query = `
-		INSERT INTO user_items (user_id, item_type, use_count, updated_at)
-		VALUES ($1, $2, $3, NOW())
+		INSERT INTO user_items (user_id, item_type, use_count, expires_at, expiry_warned, updated_at)
+		VALUES ($1, $2, $3, $4, FALSE, NOW())
 		ON CONFLICT (user_id, item_type) 
-		DO UPDATE SET use_count = user_items.use_count + $3, updated_at = NOW()
+		DO UPDATE SET
+			use_count = CASE
+				WHEN user_items.expires_at IS NOT NULL AND user_items.expires_at <= NOW() THEN $3
+				ELSE user_items.use_count + $3
+			END,
+			expires_at = $4,
+			expiry_warned = FALSE,
+			updated_at = NOW()
 	`
-	_, err := r.pool.Exec(ctx, query, userID, itemType, useCount)
+	_, err := r.pool.Exec(ctx, query, userID, itemType, useCount, expiresAt)
 	return err
 }
 
@@ -64,7 +102,7 @@ func (r *InventoryRepository) AddItem(ctx context.Context, userID int64, itemTyp
 func (r *InventoryRepository) GetUseCount(ctx context.Context, userID int64, itemType string) (int, error) {
 	const query = `
 		SELECT use_count FROM user_items
-		WHERE user_id = $1 AND item_type = $2
+		WHERE user_id = $1 AND item_type = $2 AND (expires_at IS NULL OR expires_at > NOW())
 	`
 	var useCount int
 	err := r.pool.QueryRow(ctx, query, userID, itemType).Scan(&useCount)
@@ -81,7 +119,7 @@ func (r *InventoryRepository) DecrementUseCount(ctx context.Context, userID int6
 	const query = `
 		UPDATE user_items
 		SET use_count = use_count - 1, updated_at = NOW()
-		WHERE user_id = $1 AND item_type = $2 AND use_count > 0
+		WHERE user_id = $1 AND item_type = $2 AND use_count > 0 AND (expires_at IS NULL OR expires_at > NOW())
 	`
 	result, err := r.pool.Exec(ctx, query, userID, itemType)
 	if err != nil {
@@ -90,6 +128,130 @@ func (r *InventoryRepository) DecrementUseCount(ctx context.Context, userID int6
 	return result.RowsAffected() > 0, nil
 }
 
+// ConsumeUse decreases item use count by 1 and returns the uses left.
+// ok is false if the user holds no unexpired use of the item.
+func (r *InventoryRepository) ConsumeUse(ctx context.Context, userID int64, itemType string) (remaining int, ok bool, err error) {
+	const query = `
+		UPDATE user_items
+		SET use_count = use_count - 1, updated_at = NOW()
+		WHERE user_id = $1 AND item_type = $2 AND use_count > 0 AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING use_count
+	`
+	err = r.pool.QueryRow(ctx, query, userID, itemType).Scan(&remaining)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to consume item use: %w", err)
+	}
+	return remaining, true, nil
+}
+
+// TransferUse moves one unexpired use of an item from one user to another.
+// A new row of the receiver keeps the giver's expiry, an existing one keeps
+// its own. transferred is false if the giver holds no use of the item.
+func (r *InventoryRepository) TransferUse(ctx context.Context, fromID, toID int64, itemType string) (transferred bool, err error) {
+	const query = `
+		WITH taken AS (
+			UPDATE user_items
+			SET use_count = use_count - 1, updated_at = NOW()
+			WHERE user_id = $1 AND item_type = $3 AND use_count > 0 AND (expires_at IS NULL OR expires_at > NOW())
+			RETURNING expires_at
+		)
+		INSERT INTO user_items (user_id, item_type, use_count, expires_at, expiry_warned, updated_at)
+		SELECT $2, $3, 1, expires_at, FALSE, NOW() FROM taken
+		ON CONFLICT (user_id, item_type)
+		DO UPDATE SET
+			use_count = CASE
+				WHEN user_items.expires_at IS NOT NULL AND user_items.expires_at <= NOW() THEN 1
+				ELSE user_items.use_count + 1
+			END,
+			expires_at = CASE
+				WHEN user_items.expires_at IS NOT NULL AND user_items.expires_at <= NOW() THEN EXCLUDED.expires_at
+				ELSE user_items.expires_at
+			END,
+			updated_at = NOW()
+	`
+	result, err := r.pool.Exec(ctx, query, fromID, toID, itemType)
+	if err != nil {
+		return false, fmt.Errorf("failed to transfer item use: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// SellBack returns uses of an item to the shop in one database transaction:
+// the uses are removed, the balance is credited and a sell-back transaction is recorded.
+// Returns ErrNotEnoughUses if the user holds fewer unexpired uses.
+func (r *InventoryRepository) SellBack(ctx context.Context, userID int64, itemType string, uses int, amount int64, description string) (*model.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const usesQuery = `
+		UPDATE user_items
+		SET use_count = use_count - $3, updated_at = NOW()
+		WHERE user_id = $1 AND item_type = $2 AND use_count >= $3 AND (expires_at IS NULL OR expires_at > NOW())
+	`
+	result, err := tx.Exec(ctx, usesQuery, userID, itemType, uses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove item uses: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrNotEnoughUses
+	}
+
+	const balanceQuery = `
+		UPDATE users
+		SET balance = balance + $2, updated_at = NOW()
+		WHERE telegram_id = $1
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+	`
+	var user model.User
+	err = tx.QueryRow(ctx, balanceQuery, userID, amount).Scan(
+		&user.TelegramID,
+		&user.Username,
+		&user.Balance,
+		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	const txQuery = `
+		INSERT INTO transactions (user_id, amount, type, description, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, user_id, amount, type, description, created_at
+	`
+	var sale model.Transaction
+	err = tx.QueryRow(ctx, txQuery, userID, amount, model.TxTypeSellBack, description).Scan(
+		&sale.ID,
+		&sale.UserID,
+		&sale.Amount,
+		&sale.Type,
+		&sale.Description,
+		&sale.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit sell-back: %w", err)
+	}
+
+	publishTransaction(r.bus, &sale)
+	return &user, nil
+}
+
 // RemoveItem removes an item completely from user's inventory
 func (r *InventoryRepository) RemoveItem(ctx context.Context, userID int64, itemType string) error {
 	const query = `
@@ -100,12 +262,12 @@ func (r *InventoryRepository) RemoveItem(ctx context.Context, userID int64, item
 	return err
 }
 
-// GetAllItems returns all items for a user with use_count > 0
+// GetAllItems returns all unexpired items for a user with use_count > 0
 func (r *InventoryRepository) GetAllItems(ctx context.Context, userID int64) ([]UserItem, error) {
 	const query = `
-		SELECT user_id, item_type, use_count, updated_at
+		SELECT user_id, item_type, use_count, expires_at, updated_at
 		FROM user_items
-		WHERE user_id = $1 AND use_count > 0
+		WHERE user_id = $1 AND use_count > 0 AND (expires_at IS NULL OR expires_at > NOW())
 	`
 	rows, err := r.pool.Query(ctx, query, userID)
 	if err != nil {
@@ -116,7 +278,33 @@ func (r *InventoryRepository) GetAllItems(ctx context.Context, userID int64) ([]
 	var items []UserItem
 	for rows.Next() {
 		var item UserItem
-		if err := rows.Scan(&item.UserID, &item.ItemType, &item.UseCount, &item.UpdatedAt); err != nil {
+		if err := rows.Scan(&item.UserID, &item.ItemType, &item.UseCount, &item.ExpiresAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetExpiringItems returns items expiring within the given window whose owners were not warned yet
+func (r *InventoryRepository) GetExpiringItems(ctx context.Context, within time.Duration) ([]UserItem, error) {
+	const query = `
+		SELECT user_id, item_type, use_count, expires_at, updated_at
+		FROM user_items
+		WHERE use_count > 0 AND NOT expiry_warned
+			AND expires_at > NOW() AND expires_at <= NOW() + $1 * INTERVAL '1 second'
+		ORDER BY expires_at ASC
+	`
+	rows, err := r.pool.Query(ctx, query, int64(within.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []UserItem
+	for rows.Next() {
+		var item UserItem
+		if err := rows.Scan(&item.UserID, &item.ItemType, &item.UseCount, &item.ExpiresAt, &item.UpdatedAt); err != nil {
 			return nil, err
 		}
 		items = append(items, item)
@@ -124,6 +312,29 @@ func (r *InventoryRepository) GetAllItems(ctx context.Context, userID int64) ([]
 	return items, rows.Err()
 }
 
+// MarkExpiryWarned records that the owner of an item was warned about its expiry
+func (r *InventoryRepository) MarkExpiryWarned(ctx context.Context, userID int64, itemType string) error {
+	const query = `
+		UPDATE user_items SET expiry_warned = TRUE
+		WHERE user_id = $1 AND item_type = $2
+	`
+	_, err := r.pool.Exec(ctx, query, userID, itemType)
+	return err
+}
+
+// CleanEmptyItems removes items with no uses left and items past their expiry
+func (r *InventoryRepository) CleanEmptyItems(ctx context.Context) (int64, error) {
+	const query = `
+		DELETE FROM user_items
+		WHERE use_count <= 0 OR (expires_at IS NOT NULL AND expires_at <= NOW())
+	`
+	result, err := r.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 // HasItem checks if a user has an item with use_count > 0
 func (r *InventoryRepository) HasItem(ctx context.Context, userID int64, itemType string) (bool, error) {
 	useCount, err := r.GetUseCount(ctx, userID, itemType)
@@ -145,33 +356,134 @@ func (r *InventoryRepository) DecrementItem(ctx context.Context, userID int64, i
 	return r.DecrementUseCount(ctx, userID, itemType)
 }
 
-// HasActiveEffect checks if a user has an active effect (use_count > 0)
-// This replaces the old time-based effect system with use-count based system
+// ========== Timed Effects (Duration Based) ==========
+
+// HasActiveEffect checks if a user has an active effect
+// An effect is active if the item has use_count > 0 or an unexpired timed effect
 func (r *InventoryRepository) HasActiveEffect(ctx context.Context, userID int64, effectType string) (bool, error) {
-	return r.HasItem(ctx, userID, effectType)
+	const query = `
+		SELECT
+			EXISTS(SELECT 1 FROM user_items WHERE user_id = $1 AND item_type = $2 AND use_count > 0
+				AND (expires_at IS NULL OR expires_at > NOW()))
+			OR EXISTS(SELECT 1 FROM user_effects WHERE user_id = $1 AND effect_type = $2 AND expires_at > NOW())
+	`
+	var active bool
+	if err := r.pool.QueryRow(ctx, query, userID, effectType).Scan(&active); err != nil {
+		return false, err
+	}
+	return active, nil
 }
 
 // GetActiveEffects returns all items with use_count > 0 as "effects"
-// This is for backward compatibility with the old effect system
+// Timed effects are returned separately by GetTimedEffects
 func (r *InventoryRepository) GetActiveEffects(ctx context.Context, userID int64) ([]UserItem, error) {
 	return r.GetAllItems(ctx, userID)
 }
 
-// GetEffectExpiry is deprecated - returns zero time since we no longer use time-based effects
-// Deprecated: Use GetUseCount instead to check remaining uses
+// GetTimedEffects returns all unexpired timed effects for a user
+func (r *InventoryRepository) GetTimedEffects(ctx context.Context, userID int64) ([]UserEffect, error) {
+	const query = `
+		SELECT user_id, effect_type, MAX(expires_at)
+		FROM user_effects
+		WHERE user_id = $1 AND expires_at > NOW()
+		GROUP BY user_id, effect_type
+	`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var effects []UserEffect
+	for rows.Next() {
+		var effect UserEffect
+		if err := rows.Scan(&effect.UserID, &effect.EffectType, &effect.ExpiresAt); err != nil {
+			return nil, err
+		}
+		effects = append(effects, effect)
+	}
+	return effects, rows.Err()
+}
+
+// GetEffectExpiry returns the expiry time of a user's timed effect
+// Returns zero time if the user has no active timed effect of this type
 func (r *InventoryRepository) GetEffectExpiry(ctx context.Context, userID int64, effectType string) (time.Time, error) {
-	// No longer using time-based effects, return zero time
-	return time.Time{}, nil
+	const query = `
+		SELECT MAX(expires_at) FROM user_effects
+		WHERE user_id = $1 AND effect_type = $2 AND expires_at > NOW()
+	`
+	var expiresAt *time.Time
+	if err := r.pool.QueryRow(ctx, query, userID, effectType).Scan(&expiresAt); err != nil {
+		return time.Time{}, err
+	}
+	if expiresAt == nil {
+		return time.Time{}, nil
+	}
+	return *expiresAt, nil
 }
 
-// AddEffect is deprecated - use AddItem instead
-// This method is kept for backward compatibility
-// Deprecated: Use AddItem instead
+// AddEffect adds a timed effect that expires at the given time
 func (r *InventoryRepository) AddEffect(ctx context.Context, userID int64, effectType string, expiresAt time.Time) error {
-	// For backward compatibility, add 1 use count
-	return r.AddItem(ctx, userID, effectType, 1)
+	const query = `
+		INSERT INTO user_effects (user_id, effect_type, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`
+	_, err := r.pool.Exec(ctx, query, userID, effectType, expiresAt)
+	return err
+}
+
+// ExtendEffect extends a user's active timed effect by the given duration,
+// or starts a new one from now if none is active. Returns the new expiry time.
+func (r *InventoryRepository) ExtendEffect(ctx context.Context, userID int64, effectType string, duration time.Duration) (time.Time, error) {
+	const updateQuery = `
+		UPDATE user_effects
+		SET expires_at = expires_at + $3 * INTERVAL '1 second'
+		WHERE id = (
+			SELECT id FROM user_effects
+			WHERE user_id = $1 AND effect_type = $2 AND expires_at > NOW()
+			ORDER BY expires_at DESC
+			LIMIT 1
+		)
+		RETURNING expires_at
+	`
+	seconds := int64(duration.Seconds())
+
+	var expiresAt time.Time
+	err := r.pool.QueryRow(ctx, updateQuery, userID, effectType, seconds).Scan(&expiresAt)
+	if err == nil {
+		return expiresAt, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, err
+	}
+
+	const insertQuery = `
+		INSERT INTO user_effects (user_id, effect_type, expires_at, created_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second', NOW())
+		RETURNING expires_at
+	`
+	err = r.pool.QueryRow(ctx, insertQuery, userID, effectType, seconds).Scan(&expiresAt)
+	return expiresAt, err
 }
 
+// RemoveEffect removes all timed effects of a type from a user
+func (r *InventoryRepository) RemoveEffect(ctx context.Context, userID int64, effectType string) error {
+	const query = `
+		DELETE FROM user_effects
+		WHERE user_id = $1 AND effect_type = $2
+	`
+	_, err := r.pool.Exec(ctx, query, userID, effectType)
+	return err
+}
+
+// CleanExpiredEffects removes expired timed effects
+func (r *InventoryRepository) CleanExpiredEffects(ctx context.Context) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM user_effects WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
 
 // ========== Daily Purchases ==========
 
@@ -208,7 +520,7 @@ func (r *InventoryRepository) IncrementDailyPurchase(ctx context.Context, userID
 func (r *InventoryRepository) CleanOldDailyPurchases(ctx context.Context, daysOld int) (int64, error) {
 	const query = `
 		DELETE FROM daily_purchases
-		WHERE purchase_date < CURRENT_DATE - $1::interval
+		WHERE purchase_date < CURRENT_DATE - $1::int
 	`
 	result, err := r.pool.Exec(ctx, query, daysOld)
 	if err != nil {
@@ -225,7 +537,7 @@ func (r *InventoryRepository) AddHandcuffLock(ctx context.Context, targetID, loc
 		INSERT INTO handcuff_locks (target_id, locked_by, expires_at, created_at)
 		VALUES ($1, $2, $3, NOW())
 		ON CONFLICT (target_id) 
-		DO UPDATE SET locked_by = $2, expires_at = $3, created_at = NOW()
+		DO UPDATE SET locked_by = $2, expires_at = $3, created_at = NOW(), struggle_attempts = 0, last_struggle_at = NULL
 	`
 	_, err := r.pool.Exec(ctx, query, targetID, lockedBy, expiresAt)
 	return err
@@ -248,6 +560,46 @@ func (r *InventoryRepository) IsHandcuffed(ctx context.Context, userID int64) (b
 	return true, remaining, lockedBy, nil
 }
 
+// GetHandcuffLock returns the active handcuff lock of a user, nil if not locked
+func (r *InventoryRepository) GetHandcuffLock(ctx context.Context, userID int64) (*HandcuffLock, error) {
+	const query = `
+		SELECT target_id, locked_by, expires_at, created_at, struggle_attempts, last_struggle_at
+		FROM handcuff_locks
+		WHERE target_id = $1 AND expires_at > NOW()
+	`
+	var lock HandcuffLock
+	err := r.pool.QueryRow(ctx, query, userID).Scan(
+		&lock.TargetID, &lock.LockedBy, &lock.ExpiresAt, &lock.CreatedAt, &lock.StruggleAttempts, &lock.LastStruggleAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get handcuff lock: %w", err)
+	}
+	return &lock, nil
+}
+
+// RecordStruggle counts a /struggle attempt against a user's active lock,
+// unless the previous attempt was less than cooldown ago.
+// Returns the attempts made so far, or ok false if nothing was recorded.
+func (r *InventoryRepository) RecordStruggle(ctx context.Context, userID int64, cooldown time.Duration) (attempts int, ok bool, err error) {
+	const query = `
+		UPDATE handcuff_locks
+		SET struggle_attempts = struggle_attempts + 1, last_struggle_at = NOW()
+		WHERE target_id = $1 AND expires_at > NOW()
+			AND (last_struggle_at IS NULL OR last_struggle_at <= NOW() - $2 * INTERVAL '1 second')
+		RETURNING struggle_attempts
+	`
+	err = r.pool.QueryRow(ctx, query, userID, int64(cooldown.Seconds())).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record struggle: %w", err)
+	}
+	return attempts, true, nil
+}
+
 // CleanExpiredLocks removes expired handcuff locks
 func (r *InventoryRepository) CleanExpiredLocks(ctx context.Context) (int64, error) {
 	result, err := r.pool.Exec(ctx, `DELETE FROM handcuff_locks WHERE expires_at <= NOW()`)
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// MaintenanceRepository handles scheduled maintenance windows.
+type MaintenanceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewMaintenanceRepository creates a new MaintenanceRepository instance.
+func NewMaintenanceRepository(pool *pgxpool.Pool) *MaintenanceRepository {
+	return &MaintenanceRepository{pool: pool}
+}
+
+// Create schedules a maintenance window, not announced yet.
+func (r *MaintenanceRepository) Create(ctx context.Context, startsAt, endsAt time.Time, adminID int64) (*model.MaintenanceWindow, error) {
+	const query = `
+		INSERT INTO maintenance_windows (starts_at, ends_at, announced, created_by, created_at)
+		VALUES ($1, $2, -1, $3, NOW())
+		RETURNING id, starts_at, ends_at, announced, created_by, created_at, cancelled_at
+	`
+
+	var w model.MaintenanceWindow
+	err := r.pool.QueryRow(ctx, query, startsAt, endsAt, adminID).Scan(
+		&w.ID,
+		&w.StartsAt,
+		&w.EndsAt,
+		&w.Announced,
+		&w.CreatedBy,
+		&w.CreatedAt,
+		&w.CancelledAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	return &w, nil
+}
+
+// Current returns the earliest window that has not ended yet or whose last
+// stage (lastStage) was not announced yet. Windows that ended more than an
+// hour ago are no longer announced. Returns nil if there is none.
+func (r *MaintenanceRepository) Current(ctx context.Context, lastStage int) (*model.MaintenanceWindow, error) {
+	const query = `
+		SELECT id, starts_at, ends_at, announced, created_by, created_at, cancelled_at
+		FROM maintenance_windows
+		WHERE cancelled_at IS NULL
+		  AND (ends_at > NOW() OR announced < $1)
+		  AND ends_at > NOW() - INTERVAL '1 hour'
+		ORDER BY starts_at
+		LIMIT 1
+	`
+
+	var w model.MaintenanceWindow
+	err := r.pool.QueryRow(ctx, query, lastStage).Scan(
+		&w.ID,
+		&w.StartsAt,
+		&w.EndsAt,
+		&w.Announced,
+		&w.CreatedBy,
+		&w.CreatedAt,
+		&w.CancelledAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return &w, nil
+}
+
+// SetAnnounced records the last stage of a window announced to the chats.
+func (r *MaintenanceRepository) SetAnnounced(ctx context.Context, id int64, stage int) error {
+	const query = `UPDATE maintenance_windows SET announced = $2 WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, query, id, stage); err != nil {
+		return fmt.Errorf("failed to mark maintenance announced: %w", err)
+	}
+	return nil
+}
+
+// Cancel cancels a window, ending it early if it already started.
+func (r *MaintenanceRepository) Cancel(ctx context.Context, id int64) error {
+	const query = `UPDATE maintenance_windows SET cancelled_at = NOW() WHERE id = $1 AND cancelled_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to cancel maintenance window: %w", err)
+	}
+	return nil
+}
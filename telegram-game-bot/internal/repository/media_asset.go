@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// MediaAssetRepository handles media asset persistence.
+type MediaAssetRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewMediaAssetRepository creates a new MediaAssetRepository instance.
+func NewMediaAssetRepository(pool *pgxpool.Pool) *MediaAssetRepository {
+	return &MediaAssetRepository{pool: pool}
+}
+
+// List returns all media assets, ordered by key.
+func (r *MediaAssetRepository) List(ctx context.Context) ([]model.MediaAsset, error) {
+	const query = `SELECT key, media_type, file_id, updated_by, updated_at FROM media_assets ORDER BY key`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []model.MediaAsset
+	for rows.Next() {
+		var a model.MediaAsset
+		if err := rows.Scan(&a.Key, &a.MediaType, &a.FileID, &a.UpdatedBy, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan media asset: %w", err)
+		}
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+// Set stores a media asset, replacing the file of its key.
+func (r *MediaAssetRepository) Set(ctx context.Context, asset *model.MediaAsset) error {
+	const query = `
+		INSERT INTO media_assets (key, media_type, file_id, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET media_type = EXCLUDED.media_type, file_id = EXCLUDED.file_id,
+			updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`
+	err := r.pool.QueryRow(ctx, query, asset.Key, asset.MediaType, asset.FileID, asset.UpdatedBy).Scan(&asset.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set media asset: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a media asset. Returns false if the key was not set.
+func (r *MediaAssetRepository) Delete(ctx context.Context, key string) (bool, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM media_assets WHERE key = $1`, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete media asset: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// User merge errors.
+var (
+	ErrUserAlreadyMerged = errors.New("user already merged")
+)
+
+// MergeRepository merges duplicate accounts.
+type MergeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewMergeRepository creates a new MergeRepository instance.
+func NewMergeRepository(pool *pgxpool.Pool) *MergeRepository {
+	return &MergeRepository{pool: pool}
+}
+
+// MergeStackCap caps the uses of an item held after a merge. Uses over the
+// cap are compensated at Price per UseCount uses.
+type MergeStackCap struct {
+	Name     string
+	MaxStack int
+	Price    int64
+	UseCount int
+}
+
+// Merge moves everything of merge.OldID into merge.NewID in one database
+// transaction: the balance, inventory, active effects, cosmetics and daily
+// profit aggregates are combined and the transactions and ledger entries
+// re-pointed. Moved item
+// uses over the caps, keyed by item type, are credited as compensation.
+// Entries between the two accounts would become transfers of the account to
+// itself, so they are removed with their transactions. The old account is
+// kept with a zero balance. merge is filled with what was moved.
+// Returns ErrUserNotFound if either account does not exist and
+// ErrUserAlreadyMerged if the old account was merged before.
+func (r *MergeRepository) Merge(ctx context.Context, merge *model.UserMerge, caps map[string]MergeStackCap) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Both rows are locked in a fixed order so concurrent merges cannot deadlock
+	const lockQuery = `
+		SELECT telegram_id, balance FROM users
+		WHERE telegram_id = ANY($1)
+		ORDER BY telegram_id
+		FOR UPDATE
+	`
+	rows, err := tx.Query(ctx, lockQuery, []int64{merge.OldID, merge.NewID})
+	if err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	found := 0
+	for rows.Next() {
+		var id, balance int64
+		if err := rows.Scan(&id, &balance); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan user: %w", err)
+		}
+		if id == merge.OldID {
+			merge.Balance = balance
+		}
+		found++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	if found != 2 {
+		return ErrUserNotFound
+	}
+
+	// The unique old_id makes a second merge of the same account fail here
+	const claimQuery = `
+		INSERT INTO user_merges (old_id, new_id, merged_by, reason, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (old_id) DO NOTHING
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, claimQuery, merge.OldID, merge.NewID, merge.MergedBy, merge.Reason).
+		Scan(&merge.ID, &merge.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserAlreadyMerged
+		}
+		return fmt.Errorf("failed to record user merge: %w", err)
+	}
+
+	const balanceQuery = `
+		UPDATE users
+		SET balance = CASE WHEN telegram_id = $1 THEN 0 ELSE balance + $3 END, updated_at = NOW()
+		WHERE telegram_id IN ($1, $2)
+	`
+	if _, err := tx.Exec(ctx, balanceQuery, merge.OldID, merge.NewID, merge.Balance); err != nil {
+		return fmt.Errorf("failed to move balance: %w", err)
+	}
+
+	// Uses of the same item add up, the later expiry wins
+	const itemsQuery = `
+		WITH moved AS (
+			DELETE FROM user_items WHERE user_id = $1
+			RETURNING item_type, use_count, expires_at
+		), merged AS (
+			INSERT INTO user_items (user_id, item_type, use_count, expires_at, expiry_warned, updated_at)
+			SELECT $2, item_type, use_count, expires_at, FALSE, NOW() FROM moved
+			ON CONFLICT (user_id, item_type) DO UPDATE SET
+				use_count = user_items.use_count + EXCLUDED.use_count,
+				expires_at = CASE
+					WHEN user_items.expires_at IS NULL OR EXCLUDED.expires_at IS NULL THEN NULL
+					ELSE GREATEST(user_items.expires_at, EXCLUDED.expires_at)
+				END,
+				expiry_warned = FALSE,
+				updated_at = NOW()
+			RETURNING item_type, use_count
+		)
+		SELECT merged.item_type, merged.use_count, moved.use_count
+		FROM merged JOIN moved USING (item_type)
+	`
+	rows, err = tx.Query(ctx, itemsQuery, merge.OldID, merge.NewID)
+	if err != nil {
+		return fmt.Errorf("failed to move inventory: %w", err)
+	}
+	// Uses over the cap, at most the ones moved: an account already over its
+	// cap keeps what it had
+	overflows := make(map[string]int)
+	for rows.Next() {
+		var itemType string
+		var held, moved int
+		if err := rows.Scan(&itemType, &held, &moved); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan moved item: %w", err)
+		}
+		merge.Items++
+		if c, ok := caps[itemType]; ok && held > c.MaxStack {
+			overflows[itemType] = min(held-c.MaxStack, moved)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to move inventory: %w", err)
+	}
+
+	const capQuery = `UPDATE user_items SET use_count = use_count - $3 WHERE user_id = $1 AND item_type = $2`
+	const compensationQuery = `
+		INSERT INTO transactions (user_id, amount, type, description, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`
+	for itemType, overflow := range overflows {
+		c := caps[itemType]
+		if _, err := tx.Exec(ctx, capQuery, merge.NewID, itemType, overflow); err != nil {
+			return fmt.Errorf("failed to cap inventory: %w", err)
+		}
+		amount := c.Price * int64(overflow) / int64(max(c.UseCount, 1))
+		if amount <= 0 {
+			continue
+		}
+		desc := fmt.Sprintf("账号合并超出持有上限: %s %d次", c.Name, overflow)
+		if _, err := tx.Exec(ctx, compensationQuery, merge.NewID, amount, model.TxTypeCompensation, desc); err != nil {
+			return fmt.Errorf("failed to record compensation: %w", err)
+		}
+		merge.Compensation += amount
+	}
+	if merge.Compensation > 0 {
+		const creditQuery = `UPDATE users SET balance = balance + $2, updated_at = NOW() WHERE telegram_id = $1`
+		if _, err := tx.Exec(ctx, creditQuery, merge.NewID, merge.Compensation); err != nil {
+			return fmt.Errorf("failed to credit compensation: %w", err)
+		}
+	}
+
+	const effectsQuery = `UPDATE user_effects SET user_id = $2 WHERE user_id = $1 AND expires_at > NOW()`
+	tag, err := tx.Exec(ctx, effectsQuery, merge.OldID, merge.NewID)
+	if err != nil {
+		return fmt.Errorf("failed to move effects: %w", err)
+	}
+	merge.Effects = int(tag.RowsAffected())
+
+	// Moved cosmetics are unequipped, the new account keeps its own choice
+	const cosmeticsQuery = `
+		WITH moved AS (
+			DELETE FROM user_cosmetics WHERE user_id = $1
+			RETURNING item_id, kind, purchase_id, acquired_at
+		)
+		INSERT INTO user_cosmetics (user_id, item_id, kind, equipped, purchase_id, acquired_at)
+		SELECT $2, item_id, kind, FALSE, purchase_id, acquired_at FROM moved
+		ON CONFLICT (user_id, item_id) DO NOTHING
+	`
+	tag, err = tx.Exec(ctx, cosmeticsQuery, merge.OldID, merge.NewID)
+	if err != nil {
+		return fmt.Errorf("failed to move cosmetics: %w", err)
+	}
+	merge.Cosmetics = int(tag.RowsAffected())
+
+	// Transfers between the two accounts net to zero once they are one
+	const internalQuery = `
+		WITH internal AS (
+			DELETE FROM ledger_entries
+			WHERE (debit_account = $1 AND credit_account = $2) OR (debit_account = $2 AND credit_account = $1)
+			RETURNING id
+		)
+		DELETE FROM transactions WHERE ledger_entry_id IN (SELECT id FROM internal)
+	`
+	if _, err := tx.Exec(ctx, internalQuery, merge.OldID, merge.NewID); err != nil {
+		return fmt.Errorf("failed to remove transfers between the accounts: %w", err)
+	}
+
+	tag, err = tx.Exec(ctx, `UPDATE transactions SET user_id = $2 WHERE user_id = $1`, merge.OldID, merge.NewID)
+	if err != nil {
+		return fmt.Errorf("failed to re-point transactions: %w", err)
+	}
+	merge.Transactions = int(tag.RowsAffected())
+
+	// Profit of the same day adds up, like the re-pointed transactions it was
+	// aggregated from
+	const aggregatesQuery = `
+		WITH moved AS (
+			DELETE FROM user_daily_aggregates WHERE user_id = $1
+			RETURNING day, net_profit, games
+		)
+		INSERT INTO user_daily_aggregates (user_id, day, net_profit, games)
+		SELECT $2, day, net_profit, games FROM moved
+		ON CONFLICT (user_id, day) DO UPDATE SET
+			net_profit = user_daily_aggregates.net_profit + EXCLUDED.net_profit,
+			games = user_daily_aggregates.games + EXCLUDED.games
+	`
+	if _, err := tx.Exec(ctx, aggregatesQuery, merge.OldID, merge.NewID); err != nil {
+		return fmt.Errorf("failed to move daily aggregates: %w", err)
+	}
+
+	const ledgerQuery = `
+		UPDATE ledger_entries
+		SET debit_account = CASE WHEN debit_account = $1 THEN $2 ELSE debit_account END,
+			credit_account = CASE WHEN credit_account = $1 THEN $2 ELSE credit_account END
+		WHERE debit_account = $1 OR credit_account = $1
+	`
+	if _, err := tx.Exec(ctx, ledgerQuery, merge.OldID, merge.NewID); err != nil {
+		return fmt.Errorf("failed to re-point ledger entries: %w", err)
+	}
+
+	const finishQuery = `
+		UPDATE user_merges
+		SET balance = $2, items = $3, effects = $4, cosmetics = $5, transactions = $6, compensation = $7
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, finishQuery, merge.ID, merge.Balance, merge.Items, merge.Effects, merge.Cosmetics,
+		merge.Transactions, merge.Compensation); err != nil {
+		return fmt.Errorf("failed to update user merge: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit user merge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// OutboxRepository handles direct messages waiting to be delivered.
+type OutboxRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewOutboxRepository creates a new OutboxRepository instance.
+func NewOutboxRepository(pool *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{pool: pool}
+}
+
+// Enqueue stores a direct message to a user, due right away.
+func (r *OutboxRepository) Enqueue(ctx context.Context, userID int64, text string) error {
+	const query = `INSERT INTO dm_outbox (user_id, text, next_attempt_at, created_at) VALUES ($1, $2, NOW(), NOW())`
+	if _, err := r.pool.Exec(ctx, query, userID, text); err != nil {
+		return fmt.Errorf("failed to enqueue direct message: %w", err)
+	}
+	return nil
+}
+
+// Due returns up to limit messages due at now, oldest first.
+func (r *OutboxRepository) Due(ctx context.Context, now time.Time, limit int) ([]model.OutboxMessage, error) {
+	const query = `
+		SELECT id, user_id, text, attempts, next_attempt_at, created_at
+		FROM dm_outbox
+		WHERE next_attempt_at <= $1
+		ORDER BY next_attempt_at, id
+		LIMIT $2
+	`
+	rows, err := r.pool.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due direct messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []model.OutboxMessage
+	for rows.Next() {
+		var msg model.OutboxMessage
+		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.Text, &msg.Attempts, &msg.NextAttemptAt, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan direct message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// Delete removes a delivered (or abandoned) message.
+func (r *OutboxRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM dm_outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete direct message: %w", err)
+	}
+	return nil
+}
+
+// Reschedule records a failed send attempt and sets the time of the next one.
+func (r *OutboxRepository) Reschedule(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time) error {
+	const query = `UPDATE dm_outbox SET attempts = $2, next_attempt_at = $3 WHERE id = $1`
+	if _, err := r.pool.Exec(ctx, query, id, attempts, nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to reschedule direct message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// PersonaRepository handles per-chat persona persistence.
+type PersonaRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPersonaRepository creates a new PersonaRepository instance.
+func NewPersonaRepository(pool *pgxpool.Pool) *PersonaRepository {
+	return &PersonaRepository{pool: pool}
+}
+
+// Get retrieves the persona of a chat.
+// Returns nil if the chat has not customized the bot.
+func (r *PersonaRepository) Get(ctx context.Context, chatID int64) (*model.ChatPersona, error) {
+	const query = `
+		SELECT chat_id, nickname, win_template, lose_template, theme, no_pings, updated_by, updated_at
+		FROM chat_personas
+		WHERE chat_id = $1
+	`
+
+	var persona model.ChatPersona
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(
+		&persona.ChatID,
+		&persona.Nickname,
+		&persona.WinTemplate,
+		&persona.LoseTemplate,
+		&persona.Theme,
+		&persona.NoPings,
+		&persona.UpdatedBy,
+		&persona.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get chat persona: %w", err)
+	}
+	return &persona, nil
+}
+
+// Upsert creates or replaces the persona of a chat.
+func (r *PersonaRepository) Upsert(ctx context.Context, persona *model.ChatPersona) error {
+	const query = `
+		INSERT INTO chat_personas (chat_id, nickname, win_template, lose_template, theme, no_pings, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (chat_id) DO UPDATE SET
+			nickname = EXCLUDED.nickname,
+			win_template = EXCLUDED.win_template,
+			lose_template = EXCLUDED.lose_template,
+			theme = EXCLUDED.theme,
+			no_pings = EXCLUDED.no_pings,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		persona.ChatID, persona.Nickname, persona.WinTemplate, persona.LoseTemplate, persona.Theme, persona.NoPings, persona.UpdatedBy,
+	).Scan(&persona.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save chat persona: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the persona of a chat, restoring the built-in texts.
+func (r *PersonaRepository) Delete(ctx context.Context, chatID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM chat_personas WHERE chat_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete chat persona: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// Pool errors.
+var (
+	ErrPoolNotFound = errors.New("pool not found")
+	ErrPoolClosed   = errors.New("pool is not taking bets")
+)
+
+// PoolRepository handles betting pools, their bets and resolution audit.
+type PoolRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPoolRepository creates a new PoolRepository instance.
+func NewPoolRepository(pool *pgxpool.Pool) *PoolRepository {
+	return &PoolRepository{pool: pool}
+}
+
+// Create stores a new open pool.
+func (r *PoolRepository) Create(ctx context.Context, p *model.Pool) error {
+	const query = `
+		INSERT INTO pools (chat_id, question, outcomes, status, rake_percent, closes_at, created_by, sandbox_chat_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		p.ChatID, p.Question, p.Outcomes, p.Status, p.RakePercent, p.ClosesAt, p.CreatedBy, p.SandboxChatID,
+	).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create pool: %w", err)
+	}
+	return nil
+}
+
+// GetOpenByChat returns the open pool of a chat.
+// Returns ErrPoolNotFound if the chat has none.
+func (r *PoolRepository) GetOpenByChat(ctx context.Context, chatID int64) (*model.Pool, error) {
+	const query = `
+		SELECT id, chat_id, question, outcomes, status, rake_percent, closes_at,
+		       winning_outcome, created_by, sandbox_chat_id, created_at, resolved_at
+		FROM pools
+		WHERE chat_id = $1 AND status = $2
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var p model.Pool
+	err := r.pool.QueryRow(ctx, query, chatID, model.PoolStatusOpen).Scan(
+		&p.ID, &p.ChatID, &p.Question, &p.Outcomes, &p.Status, &p.RakePercent, &p.ClosesAt,
+		&p.WinningOutcome, &p.CreatedBy, &p.SandboxChatID, &p.CreatedAt, &p.ResolvedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, fmt.Errorf("failed to get open pool: %w", err)
+	}
+	return &p, nil
+}
+
+// AddBet stores a bet if the pool is still open and inside its betting window.
+// Returns ErrPoolClosed otherwise.
+func (r *PoolRepository) AddBet(ctx context.Context, bet *model.PoolBet) error {
+	const query = `
+		INSERT INTO pool_bets (pool_id, user_id, outcome, amount, created_at)
+		SELECT p.id, $2, $3, $4, NOW()
+		FROM pools p
+		WHERE p.id = $1 AND p.status = $5 AND p.closes_at > NOW()
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, bet.PoolID, bet.UserID, bet.Outcome, bet.Amount, model.PoolStatusOpen).
+		Scan(&bet.ID, &bet.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPoolClosed
+		}
+		return fmt.Errorf("failed to add pool bet: %w", err)
+	}
+	return nil
+}
+
+// GetBets returns all bets of a pool in the order they were placed.
+func (r *PoolRepository) GetBets(ctx context.Context, poolID int64) ([]*model.PoolBet, error) {
+	const query = `
+		SELECT id, pool_id, user_id, outcome, amount, created_at
+		FROM pool_bets
+		WHERE pool_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.pool.Query(ctx, query, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool bets: %w", err)
+	}
+	defer rows.Close()
+
+	var bets []*model.PoolBet
+	for rows.Next() {
+		var b model.PoolBet
+		if err := rows.Scan(&b.ID, &b.PoolID, &b.UserID, &b.Outcome, &b.Amount, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pool bet: %w", err)
+		}
+		bets = append(bets, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pool bets: %w", err)
+	}
+	return bets, nil
+}
+
+// Finish moves an open pool to status, resolved with winningOutcome or cancelled (nil).
+// Returns ErrPoolClosed if the pool is no longer open.
+func (r *PoolRepository) Finish(ctx context.Context, poolID int64, status string, winningOutcome *int) error {
+	const query = `
+		UPDATE pools
+		SET status = $2, winning_outcome = $3, resolved_at = NOW()
+		WHERE id = $1 AND status = $4
+	`
+
+	tag, err := r.pool.Exec(ctx, query, poolID, status, winningOutcome, model.PoolStatusOpen)
+	if err != nil {
+		return fmt.Errorf("failed to finish pool: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPoolClosed
+	}
+	return nil
+}
+
+// AddResolution stores the audit record of a resolved or cancelled pool.
+func (r *PoolRepository) AddResolution(ctx context.Context, res *model.PoolResolution) error {
+	const query = `
+		INSERT INTO pool_resolutions (pool_id, admin_id, action, winning_outcome, total_pool, rake, paid, winners, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		res.PoolID, res.AdminID, res.Action, res.WinningOutcome, res.TotalPool, res.Rake, res.Paid, res.Winners,
+	).Scan(&res.ID, &res.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add pool resolution: %w", err)
+	}
+	return nil
+}
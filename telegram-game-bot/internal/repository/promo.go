@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// Promo code errors.
+var (
+	ErrPromoNotFound        = errors.New("promo code not found")
+	ErrPromoCodeExists      = errors.New("promo code already exists")
+	ErrPromoExpired         = errors.New("promo code expired")
+	ErrPromoExhausted       = errors.New("promo code exhausted")
+	ErrPromoAlreadyRedeemed = errors.New("promo code already redeemed by user")
+)
+
+// PromoRepository handles promo code persistence and redemption tracking.
+type PromoRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPromoRepository creates a new PromoRepository instance.
+func NewPromoRepository(pool *pgxpool.Pool) *PromoRepository {
+	return &PromoRepository{pool: pool}
+}
+
+// Create stores a new promo code.
+// Returns ErrPromoCodeExists if the code is already taken.
+func (r *PromoRepository) Create(ctx context.Context, promo *model.PromoCode) error {
+	const query = `
+		INSERT INTO promo_codes (code, reward_amount, reward_item, max_uses, used_count, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $6, NOW())
+		ON CONFLICT (code) DO NOTHING
+		RETURNING created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		promo.Code, promo.RewardAmount, promo.RewardItem, promo.MaxUses, promo.ExpiresAt, promo.CreatedBy,
+	).Scan(&promo.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPromoCodeExists
+		}
+		return fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return nil
+}
+
+// GetByCode retrieves a promo code.
+// Returns ErrPromoNotFound if the code does not exist.
+func (r *PromoRepository) GetByCode(ctx context.Context, code string) (*model.PromoCode, error) {
+	const query = `
+		SELECT code, reward_amount, reward_item, max_uses, used_count, expires_at, created_by, created_at
+		FROM promo_codes
+		WHERE code = $1
+	`
+
+	var promo model.PromoCode
+	err := r.pool.QueryRow(ctx, query, code).Scan(
+		&promo.Code,
+		&promo.RewardAmount,
+		&promo.RewardItem,
+		&promo.MaxUses,
+		&promo.UsedCount,
+		&promo.ExpiresAt,
+		&promo.CreatedBy,
+		&promo.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPromoNotFound
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	return &promo, nil
+}
+
+// Redeem records a redemption of the code by the user and consumes one use.
+// The code row is locked for the duration of the transaction so concurrent
+// redemptions cannot exceed max_uses.
+// Returns the promo code as it was before redemption.
+func (r *PromoRepository) Redeem(ctx context.Context, code string, userID int64) (*model.PromoCode, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selectQuery = `
+		SELECT code, reward_amount, reward_item, max_uses, used_count, expires_at, created_by, created_at
+		FROM promo_codes
+		WHERE code = $1
+		FOR UPDATE
+	`
+
+	var promo model.PromoCode
+	err = tx.QueryRow(ctx, selectQuery, code).Scan(
+		&promo.Code,
+		&promo.RewardAmount,
+		&promo.RewardItem,
+		&promo.MaxUses,
+		&promo.UsedCount,
+		&promo.ExpiresAt,
+		&promo.CreatedBy,
+		&promo.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPromoNotFound
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	if promo.IsExpired(time.Now()) {
+		return nil, ErrPromoExpired
+	}
+	if promo.IsExhausted() {
+		return nil, ErrPromoExhausted
+	}
+
+	const insertQuery = `
+		INSERT INTO promo_redemptions (code, user_id, redeemed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (code, user_id) DO NOTHING
+	`
+	result, err := tx.Exec(ctx, insertQuery, code, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record redemption: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrPromoAlreadyRedeemed
+	}
+
+	const updateQuery = `UPDATE promo_codes SET used_count = used_count + 1 WHERE code = $1`
+	if _, err := tx.Exec(ctx, updateQuery, code); err != nil {
+		return nil, fmt.Errorf("failed to update promo code usage: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit redemption: %w", err)
+	}
+
+	return &promo, nil
+}
+
+// CancelRedemption reverts a redemption, e.g. when granting the reward failed.
+func (r *PromoRepository) CancelRedemption(ctx context.Context, code string, userID int64) error {
+	const query = `
+		WITH deleted AS (
+			DELETE FROM promo_redemptions WHERE code = $1 AND user_id = $2 RETURNING code
+		)
+		UPDATE promo_codes SET used_count = used_count - 1
+		WHERE code IN (SELECT code FROM deleted)
+	`
+	_, err := r.pool.Exec(ctx, query, code, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel redemption: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// PvPRepository handles PvP opt-out persistence.
+type PvPRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPvPRepository creates a new PvPRepository instance.
+func NewPvPRepository(pool *pgxpool.Pool) *PvPRepository {
+	return &PvPRepository{pool: pool}
+}
+
+// Get retrieves a user's PvP preference.
+// Returns nil if the user never changed it.
+func (r *PvPRepository) Get(ctx context.Context, userID int64) (*model.PvPPreference, error) {
+	const query = `
+		SELECT user_id, opted_out, changed_at
+		FROM pvp_preferences
+		WHERE user_id = $1
+	`
+
+	var p model.PvPPreference
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&p.UserID, &p.OptedOut, &p.ChangedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pvp preference: %w", err)
+	}
+	return &p, nil
+}
+
+// IsOptedOut reports whether a user opted out of PvP features.
+func (r *PvPRepository) IsOptedOut(ctx context.Context, userID int64) (bool, error) {
+	const query = `
+		SELECT EXISTS (SELECT 1 FROM pvp_preferences WHERE user_id = $1 AND opted_out)
+	`
+
+	var optedOut bool
+	if err := r.pool.QueryRow(ctx, query, userID).Scan(&optedOut); err != nil {
+		return false, fmt.Errorf("failed to check pvp opt-out: %w", err)
+	}
+	return optedOut, nil
+}
+
+// Set stores a user's PvP preference.
+func (r *PvPRepository) Set(ctx context.Context, userID int64, optedOut bool) (*model.PvPPreference, error) {
+	const query = `
+		INSERT INTO pvp_preferences (user_id, opted_out, changed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			opted_out = EXCLUDED.opted_out,
+			changed_at = NOW()
+		RETURNING user_id, opted_out, changed_at
+	`
+
+	var p model.PvPPreference
+	if err := r.pool.QueryRow(ctx, query, userID, optedOut).Scan(&p.UserID, &p.OptedOut, &p.ChangedAt); err != nil {
+		return nil, fmt.Errorf("failed to set pvp preference: %w", err)
+	}
+	return &p, nil
+}
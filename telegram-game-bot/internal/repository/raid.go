@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// Raid errors.
+var (
+	ErrRaidNotFound       = errors.New("raid event not found")
+	ErrRaidClosed         = errors.New("raid event already finished or cancelled")
+	ErrRaidMemberExists   = errors.New("user already joined the raid")
+	ErrRaidMemberNotFound = errors.New("raid member not found")
+)
+
+// RaidRepository handles raid events and their members.
+type RaidRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRaidRepository creates a new RaidRepository instance.
+func NewRaidRepository(pool *pgxpool.Pool) *RaidRepository {
+	return &RaidRepository{pool: pool}
+}
+
+// Create stores a new raid event.
+func (r *RaidRepository) Create(ctx context.Context, raid *model.RaidEvent) error {
+	const query = `
+		INSERT INTO raid_events (chat_a, chat_b, prize_pool, status, starts_at, ends_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		raid.ChatA, raid.ChatB, raid.PrizePool, raid.Status, raid.StartsAt, raid.EndsAt, raid.CreatedBy,
+	).Scan(&raid.ID, &raid.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create raid event: %w", err)
+	}
+	return nil
+}
+
+// GetCurrent returns the latest scheduled or active raid.
+// Returns ErrRaidNotFound if there is none.
+func (r *RaidRepository) GetCurrent(ctx context.Context) (*model.RaidEvent, error) {
+	const query = `
+		SELECT id, chat_a, chat_b, prize_pool, status, starts_at, ends_at, winner_chat, created_by, created_at
+		FROM raid_events
+		WHERE status IN ($1, $2)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var raid model.RaidEvent
+	err := r.pool.QueryRow(ctx, query, model.RaidStatusScheduled, model.RaidStatusActive).Scan(
+		&raid.ID, &raid.ChatA, &raid.ChatB, &raid.PrizePool, &raid.Status,
+		&raid.StartsAt, &raid.EndsAt, &raid.WinnerChat, &raid.CreatedBy, &raid.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRaidNotFound
+		}
+		return nil, fmt.Errorf("failed to get current raid: %w", err)
+	}
+	return &raid, nil
+}
+
+// UpdateStatus moves a scheduled or active raid to status.
+// Returns ErrRaidClosed if the raid is already finished or cancelled.
+func (r *RaidRepository) UpdateStatus(ctx context.Context, raidID int64, status string, winnerChat *int64) error {
+	const query = `
+		UPDATE raid_events
+		SET status = $2, winner_chat = $3
+		WHERE id = $1 AND status IN ($4, $5)
+	`
+
+	tag, err := r.pool.Exec(ctx, query, raidID, status, winnerChat, model.RaidStatusScheduled, model.RaidStatusActive)
+	if err != nil {
+		return fmt.Errorf("failed to update raid status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRaidClosed
+	}
+	return nil
+}
+
+// AddMember adds a user to one side of a raid.
+// Returns ErrRaidMemberExists if the user already joined.
+func (r *RaidRepository) AddMember(ctx context.Context, raidID, userID, chatID int64) error {
+	const query = `
+		INSERT INTO raid_members (raid_id, user_id, chat_id, points, joined_at)
+		VALUES ($1, $2, $3, 0, NOW())
+		ON CONFLICT (raid_id, user_id) DO NOTHING
+	`
+
+	tag, err := r.pool.Exec(ctx, query, raidID, userID, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to add raid member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRaidMemberExists
+	}
+	return nil
+}
+
+// GetMember returns a raid member.
+// Returns ErrRaidMemberNotFound if the user did not join.
+func (r *RaidRepository) GetMember(ctx context.Context, raidID, userID int64) (*model.RaidMember, error) {
+	const query = `
+		SELECT raid_id, user_id, chat_id, points, joined_at
+		FROM raid_members
+		WHERE raid_id = $1 AND user_id = $2
+	`
+
+	var m model.RaidMember
+	err := r.pool.QueryRow(ctx, query, raidID, userID).Scan(&m.RaidID, &m.UserID, &m.ChatID, &m.Points, &m.JoinedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRaidMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get raid member: %w", err)
+	}
+	return &m, nil
+}
+
+// AddPoints adds points to a raid member.
+func (r *RaidRepository) AddPoints(ctx context.Context, raidID, userID int64, points int) error {
+	const query = `UPDATE raid_members SET points = points + $3 WHERE raid_id = $1 AND user_id = $2`
+
+	if _, err := r.pool.Exec(ctx, query, raidID, userID, points); err != nil {
+		return fmt.Errorf("failed to add raid points: %w", err)
+	}
+	return nil
+}
+
+// GetMembers returns all members of a raid, highest points first.
+func (r *RaidRepository) GetMembers(ctx context.Context, raidID int64) ([]*model.RaidMember, error) {
+	const query = `
+		SELECT raid_id, user_id, chat_id, points, joined_at
+		FROM raid_members
+		WHERE raid_id = $1
+		ORDER BY points DESC, user_id ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, raidID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raid members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*model.RaidMember
+	for rows.Next() {
+		var m model.RaidMember
+		if err := rows.Scan(&m.RaidID, &m.UserID, &m.ChatID, &m.Points, &m.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan raid member: %w", err)
+		}
+		members = append(members, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate raid members: %w", err)
+	}
+
+	return members, nil
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// Transaction refund errors.
+var (
+	ErrTransactionRefunded = errors.New("transaction already refunded")
+	ErrRefundOverdraft     = errors.New("refund would make balance negative")
+)
+
+// RefundRepository handles admin reversals of specific transactions.
+type RefundRepository struct {
+	pool *pgxpool.Pool
+	bus  *events.Bus // optional, notified of the compensating transaction
+}
+
+// NewRefundRepository creates a new RefundRepository instance.
+func NewRefundRepository(pool *pgxpool.Pool) *RefundRepository {
+	return &RefundRepository{pool: pool}
+}
+
+// SetEventBus sets the bus that compensating transactions are published to.
+func (r *RefundRepository) SetEventBus(bus *events.Bus) {
+	r.bus = bus
+}
+
+// IsRefunded reports whether a transaction was already refunded, either
+// reversed by an admin or refunded through a support ticket.
+func (r *RefundRepository) IsRefunded(ctx context.Context, txID int64) (bool, error) {
+	const query = `
+		SELECT EXISTS (SELECT 1 FROM transaction_refunds WHERE tx_id = $1)
+			OR EXISTS (SELECT 1 FROM support_tickets WHERE refund_tx_id = $1 AND status = 'refunded')
+	`
+
+	var refunded bool
+	if err := r.pool.QueryRow(ctx, query, txID).Scan(&refunded); err != nil {
+		return false, fmt.Errorf("failed to check transaction refund: %w", err)
+	}
+	return refunded, nil
+}
+
+// GetByTxID retrieves the refund record of a transaction.
+// Returns nil if the transaction was not reversed.
+func (r *RefundRepository) GetByTxID(ctx context.Context, txID int64) (*model.TransactionRefund, error) {
+	const query = `
+		SELECT tx_id, user_id, amount, refund_tx_id, refunded_by, reason, item_type, item_uses, effect_seconds, created_at
+		FROM transaction_refunds
+		WHERE tx_id = $1
+	`
+
+	var refund model.TransactionRefund
+	err := r.pool.QueryRow(ctx, query, txID).Scan(
+		&refund.TxID,
+		&refund.UserID,
+		&refund.Amount,
+		&refund.RefundTxID,
+		&refund.RefundedBy,
+		&refund.Reason,
+		&refund.ItemType,
+		&refund.ItemUses,
+		&refund.EffectSeconds,
+		&refund.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction refund: %w", err)
+	}
+	return &refund, nil
+}
+
+// Reverse applies a refund in one database transaction: it records the refund,
+// adjusts the balance by refund.Amount, creates the compensating transaction and,
+// for a shop purchase (refund.ItemType set), removes up to refund.ItemUses uses or
+// refund.EffectSeconds of the timed effect and gives back the daily purchase.
+// refund.ItemUses and refund.EffectSeconds are updated to what was actually removed.
+// Returns ErrTransactionRefunded if the transaction was already refunded and
+// ErrRefundOverdraft if taking back a credit would make the balance negative.
+func (r *RefundRepository) Reverse(ctx context.Context, refund *model.TransactionRefund, description string) (*model.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Refunds through support tickets count as well
+	var ticketRefunded bool
+	const ticketQuery = `SELECT EXISTS (SELECT 1 FROM support_tickets WHERE refund_tx_id = $1 AND status = 'refunded')`
+	if err := tx.QueryRow(ctx, ticketQuery, refund.TxID).Scan(&ticketRefunded); err != nil {
+		return nil, fmt.Errorf("failed to check support refunds: %w", err)
+	}
+	if ticketRefunded {
+		return nil, ErrTransactionRefunded
+	}
+
+	// The primary key makes concurrent reversals of the same transaction fail here
+	const claimQuery = `
+		INSERT INTO transaction_refunds (tx_id, user_id, amount, refunded_by, reason, item_type, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (tx_id) DO NOTHING
+		RETURNING created_at
+	`
+	err = tx.QueryRow(ctx, claimQuery, refund.TxID, refund.UserID, refund.Amount, refund.RefundedBy, refund.Reason, refund.ItemType).
+		Scan(&refund.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransactionRefunded
+		}
+		return nil, fmt.Errorf("failed to record transaction refund: %w", err)
+	}
+
+	const balanceQuery = `
+		UPDATE users
+		SET balance = balance + $2, updated_at = NOW()
+		WHERE telegram_id = $1 AND balance + $2 >= 0
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+	`
+	var user model.User
+	err = tx.QueryRow(ctx, balanceQuery, refund.UserID, refund.Amount).Scan(
+		&user.TelegramID,
+		&user.Username,
+		&user.Balance,
+		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			var exists bool
+			if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE telegram_id = $1)`, refund.UserID).Scan(&exists); err != nil {
+				return nil, fmt.Errorf("failed to check user: %w", err)
+			}
+			if !exists {
+				return nil, ErrUserNotFound
+			}
+			return nil, ErrRefundOverdraft
+		}
+		return nil, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	const txQuery = `
+		INSERT INTO transactions (user_id, amount, type, description, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, user_id, amount, type, description, created_at
+	`
+	var reversal model.Transaction
+	err = tx.QueryRow(ctx, txQuery, refund.UserID, refund.Amount, model.TxTypeReversal, description).Scan(
+		&reversal.ID,
+		&reversal.UserID,
+		&reversal.Amount,
+		&reversal.Type,
+		&reversal.Description,
+		&reversal.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	refundTxID := reversal.ID
+	refund.RefundTxID = &refundTxID
+
+	if refund.ItemType != nil {
+		if err := r.takeBackItem(ctx, tx, refund); err != nil {
+			return nil, err
+		}
+	} else {
+		refund.ItemUses = 0
+		refund.EffectSeconds = 0
+	}
+
+	const finishQuery = `
+		UPDATE transaction_refunds
+		SET refund_tx_id = $2, item_uses = $3, effect_seconds = $4
+		WHERE tx_id = $1
+	`
+	if _, err := tx.Exec(ctx, finishQuery, refund.TxID, refundTxID, refund.ItemUses, refund.EffectSeconds); err != nil {
+		return nil, fmt.Errorf("failed to update transaction refund: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction refund: %w", err)
+	}
+
+	publishTransaction(r.bus, &reversal)
+	return &user, nil
+}
+
+// takeBackItem removes the item of a reversed purchase from the inventory.
+// Uses or effect time already consumed cannot be taken back, so at most what is
+// left is removed.
+func (r *RefundRepository) takeBackItem(ctx context.Context, tx pgx.Tx, refund *model.TransactionRefund) error {
+	itemType := *refund.ItemType
+
+	if refund.ItemUses > 0 {
+		const usesQuery = `
+			WITH old AS (
+				SELECT use_count FROM user_items
+				WHERE user_id = $1 AND item_type = $2
+				FOR UPDATE
+			)
+			UPDATE user_items u
+			SET use_count = GREATEST(u.use_count - $3, 0), updated_at = NOW()
+			FROM old
+			WHERE u.user_id = $1 AND u.item_type = $2
+			RETURNING old.use_count - u.use_count
+		`
+		var removed int
+		err := tx.QueryRow(ctx, usesQuery, refund.UserID, itemType, refund.ItemUses).Scan(&removed)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to remove item uses: %w", err)
+		}
+		refund.ItemUses = removed
+	}
+
+	if refund.EffectSeconds > 0 {
+		const effectQuery = `
+			SELECT id, expires_at FROM user_effects
+			WHERE user_id = $1 AND effect_type = $2 AND expires_at > NOW()
+			ORDER BY expires_at DESC
+			LIMIT 1
+			FOR UPDATE
+		`
+		var effectID int64
+		var expiresAt time.Time
+		err := tx.QueryRow(ctx, effectQuery, refund.UserID, itemType).Scan(&effectID, &expiresAt)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			refund.EffectSeconds = 0
+		case err != nil:
+			return fmt.Errorf("failed to get timed effect: %w", err)
+		default:
+			remaining := int64(time.Until(expiresAt).Seconds())
+			if remaining < refund.EffectSeconds {
+				refund.EffectSeconds = remaining
+			}
+			const shortenQuery = `
+				UPDATE user_effects
+				SET expires_at = expires_at - $2 * INTERVAL '1 second'
+				WHERE id = $1
+			`
+			if _, err := tx.Exec(ctx, shortenQuery, effectID, refund.EffectSeconds); err != nil {
+				return fmt.Errorf("failed to shorten timed effect: %w", err)
+			}
+		}
+	}
+
+	// Give back the daily purchase of the day the item was bought
+	const dailyQuery = `
+		UPDATE daily_purchases
+		SET purchase_count = purchase_count - 1
+		WHERE user_id = $1 AND item_type = $2 AND purchase_count > 0
+			AND purchase_date = (SELECT created_at::date FROM transactions WHERE id = $3)
+	`
+	if _, err := tx.Exec(ctx, dailyQuery, refund.UserID, itemType, refund.TxID); err != nil {
+		return fmt.Errorf("failed to update daily purchases: %w", err)
+	}
+	return nil
+}
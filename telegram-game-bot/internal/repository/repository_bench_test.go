@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"telegram-game-bot/internal/model"
+)
+
+// benchUsers is the number of users the parallel balance benchmarks spread over
+const benchUsers = 64
+
+// createBenchUsers creates users 1..n for the balance benchmarks
+func createBenchUsers(b *testing.B, repo *UserRepository, n int) {
+	ctx := context.Background()
+	for id := int64(1); id <= int64(n); id++ {
+		_, err := repo.Create(ctx, id, "bench")
+		require.NoError(b, err)
+	}
+}
+
+// BenchmarkUserRepository_UpdateBalance measures a single balance update.
+func BenchmarkUserRepository_UpdateBalance(b *testing.B) {
+	pool, cleanup := setupTestDB(b)
+	defer cleanup()
+
+	repo := NewUserRepository(pool)
+	ctx := context.Background()
+	createBenchUsers(b, repo, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.UpdateBalance(ctx, 1, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUserRepository_UpdateBalance_Parallel measures concurrent balance
+// updates spread over many users, as during a busy sicbo settlement.
+func BenchmarkUserRepository_UpdateBalance_Parallel(b *testing.B) {
+	pool, cleanup := setupTestDB(b)
+	defer cleanup()
+
+	repo := NewUserRepository(pool)
+	ctx := context.Background()
+	createBenchUsers(b, repo, benchUsers)
+	var next atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			userID := next.Add(1)%benchUsers + 1
+			if _, err := repo.UpdateBalance(ctx, userID, 1); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkUpdateBalanceWithTransaction measures a balance update followed by
+// its transaction record, the pair written by AccountService.UpdateBalance.
+func BenchmarkUpdateBalanceWithTransaction(b *testing.B) {
+	pool, cleanup := setupTestDB(b)
+	defer cleanup()
+
+	userRepo := NewUserRepository(pool)
+	txRepo := NewTransactionRepository(pool)
+	ctx := context.Background()
+	createBenchUsers(b, userRepo, 1)
+	desc := "bench"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := userRepo.UpdateBalance(ctx, 1, 1); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := txRepo.Create(ctx, 1, 1, model.TxTypeDice, &desc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
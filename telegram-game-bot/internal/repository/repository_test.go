@@ -5,6 +5,7 @@ package repository
 
 import (
 	"context"
+	"os"
 	"os/exec"
 	"testing"
 	"time"
@@ -28,7 +29,7 @@ func checkDockerAvailable() bool {
 
 // setupTestDB creates a PostgreSQL container and returns a connection pool
 // Skips the test if Docker is not available
-func setupTestDB(t *testing.T) (*pgxpool.Pool, func()) {
+func setupTestDB(t testing.TB) (*pgxpool.Pool, func()) {
 	if !checkDockerAvailable() {
 		t.Skip("Docker is not available, skipping integration test")
 	}
@@ -79,9 +80,13 @@ func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 			username VARCHAR(255) NOT NULL,
 			balance BIGINT NOT NULL DEFAULT 1000,
 			last_daily_claim BIGINT DEFAULT 0,
+			last_free_spin BIGINT NOT NULL DEFAULT 0,
+			hide_from_leaderboard BOOLEAN NOT NULL DEFAULT FALSE,
+			handle VARCHAR(16),
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		)
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle)
 	`)
 	if err != nil {
 		return err
@@ -98,6 +103,18 @@ func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		)
 	`)
+	if err != nil {
+		return err
+	}
+
+	// Create PvP preference table (read by the daily rankings)
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS pvp_preferences (
+			user_id BIGINT PRIMARY KEY REFERENCES users(telegram_id) ON DELETE CASCADE,
+			opted_out BOOLEAN NOT NULL DEFAULT FALSE,
+			changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
 	return err
 }
 
@@ -186,6 +203,13 @@ func TestUserRepository_UpdateBalance(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(1200), user.Balance)
 
+	// Test a debit the balance does not cover
+	_, err = repo.UpdateBalance(ctx, 12345, -1201)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	user, err = repo.GetByID(ctx, 12345)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1200), user.Balance)
+
 	// Test updating non-existent user
 	_, err = repo.UpdateBalance(ctx, 99999, 100)
 	assert.ErrorIs(t, err, ErrUserNotFound)
@@ -547,3 +571,235 @@ func TestTransactionRepository_ExcludesNonGameTransactions(t *testing.T) {
 	require.Len(t, stats, 1)
 	assert.Equal(t, int64(500), stats[0].NetProfit) // Only dice transaction
 }
+
+// ============================================================================
+// TransactionPartitionRepository Tests
+// ============================================================================
+
+func TestTransactionPartitionRepository_Pruning(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	partitions := NewTransactionPartitionRepository(pool)
+	ctx := context.Background()
+
+	// Partition the transactions table like migration 54
+	_, err := pool.Exec(ctx, `
+		DROP TABLE transactions;
+		CREATE TABLE transactions (
+			id BIGSERIAL,
+			user_id BIGINT NOT NULL REFERENCES users(telegram_id) ON DELETE CASCADE,
+			amount BIGINT NOT NULL,
+			type VARCHAR(50) NOT NULL,
+			description TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (id, created_at)
+		) PARTITION BY RANGE (created_at)
+	`)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := -2; i <= 0; i++ {
+		created, err := partitions.Ensure(ctx, month.AddDate(0, i, 0))
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+	created, err := partitions.Ensure(ctx, month)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	names, err := partitions.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, names, 3)
+
+	// The daily profit query only reads the partition of its day
+	plan := explain(t, pool, dailyProfitQuery, 12345, now.Add(-time.Minute), now, model.GameTransactionTypes())
+	assert.Contains(t, plan, TransactionPartitionName(month))
+	assert.NotContains(t, plan, TransactionPartitionName(month.AddDate(0, -1, 0)))
+	assert.NotContains(t, plan, TransactionPartitionName(month.AddDate(0, -2, 0)))
+
+	// Detached partitions are no longer listed
+	require.NoError(t, partitions.Detach(ctx, TransactionPartitionName(month.AddDate(0, -2, 0))))
+	names, err = partitions.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, names, 2)
+}
+
+// Query shapes of the hot transaction reads, filtered on created_at
+const (
+	dailyProfitQuery = `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1 AND type = ANY($4) AND created_at >= $2 AND created_at < $3
+	`
+	dailyStatsQuery = `
+		SELECT user_id, SUM(amount)
+		FROM transactions
+		WHERE type = ANY($3) AND created_at >= $1 AND created_at < $2
+		GROUP BY user_id
+	`
+	profitSinceQuery = `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1 AND type = ANY($3) AND created_at >= $2
+	`
+)
+
+// explain returns the plan of query with args
+func explain(t *testing.T, pool *pgxpool.Pool, query string, args ...any) string {
+	t.Helper()
+	rows, err := pool.Query(context.Background(), "EXPLAIN "+query, args...)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var plan string
+	for rows.Next() {
+		var line string
+		require.NoError(t, rows.Scan(&line))
+		plan += line + "\n"
+	}
+	require.NoError(t, rows.Err())
+	return plan
+}
+
+func TestTransactionPartitionMigration_LegacyAttach(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	partitions := NewTransactionPartitionRepository(pool)
+	ctx := context.Background()
+
+	// Tables and trigger function the migration expects from earlier migrations
+	_, err := pool.Exec(ctx, `
+		ALTER TABLE transactions ADD COLUMN ledger_entry_id BIGINT;
+		CREATE TABLE transaction_refunds (tx_id BIGINT PRIMARY KEY, refund_tx_id BIGINT);
+		CREATE FUNCTION ledger_book_transaction() RETURNS TRIGGER AS $$
+		BEGIN
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+		INSERT INTO users (telegram_id, username) VALUES (12345, 'player');
+	`)
+	require.NoError(t, err)
+
+	// Rows of this month must fit in the legacy partition
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	_, err = pool.Exec(ctx, `
+		INSERT INTO transactions (user_id, amount, type, created_at)
+		VALUES (12345, 100, 'dice', $1), (12345, -50, 'dice', $2)
+	`, month.AddDate(0, -1, 0), now)
+	require.NoError(t, err)
+
+	migration, err := os.ReadFile("../../migrations/054_partition_transactions.up.sql")
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, string(migration))
+	require.NoError(t, err)
+	// Reruns on every boot
+	_, err = pool.Exec(ctx, string(migration))
+	require.NoError(t, err)
+
+	legacyEnd, ok, err := partitions.LegacyEnd(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	next := month.AddDate(0, 1, 0)
+	assert.True(t, legacyEnd.Equal(next), "legacy partition ends at %s", legacyEnd)
+
+	names, err := partitions.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"transactions_legacy",
+		TransactionPartitionName(next),
+		TransactionPartitionName(next.AddDate(0, 1, 0)),
+	}, names)
+
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM transactions`).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	types := model.GameTransactionTypes()
+	day := next.AddDate(0, 0, 3)
+	tests := []struct {
+		name     string
+		query    string
+		args     []any
+		reads    string
+		notReads []string
+	}{
+		{"daily profit today", dailyProfitQuery, []any{12345, now.Add(-time.Minute), now, types},
+			"transactions_legacy", []string{TransactionPartitionName(next), TransactionPartitionName(next.AddDate(0, 1, 0))}},
+		{"daily profit next month", dailyProfitQuery, []any{12345, day, day.AddDate(0, 0, 1), types},
+			TransactionPartitionName(next), []string{"transactions_legacy", TransactionPartitionName(next.AddDate(0, 1, 0))}},
+		{"daily stats next month", dailyStatsQuery, []any{day, day.AddDate(0, 0, 1), types},
+			TransactionPartitionName(next), []string{"transactions_legacy", TransactionPartitionName(next.AddDate(0, 1, 0))}},
+		{"profit since next month", profitSinceQuery, []any{12345, day, types},
+			TransactionPartitionName(next), []string{"transactions_legacy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := explain(t, pool, tt.query, tt.args...)
+			assert.Contains(t, plan, tt.reads)
+			for _, name := range tt.notReads {
+				assert.NotContains(t, plan, name)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// IdempotencyRepository Tests
+// ============================================================================
+
+func TestIdempotencyRepository_ApplyBalance(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE idempotency_keys (
+			key VARCHAR(128) PRIMARY KEY,
+			operation VARCHAR(32) NOT NULL,
+			fingerprint TEXT NOT NULL,
+			completed BOOLEAN NOT NULL DEFAULT FALSE,
+			user_id BIGINT NOT NULL DEFAULT 0,
+			balance BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	require.NoError(t, err)
+
+	userRepo := NewUserRepository(pool)
+	repo := NewIdempotencyRepository(pool)
+	_, err = userRepo.Create(ctx, 12345, "testuser")
+	require.NoError(t, err)
+
+	// The first request applies the change with its key
+	record, user, err := repo.ApplyBalance(ctx, "k1", "update_balance", "fp", 12345, 500, model.TxTypeCompensation, nil)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Equal(t, int64(1500), user.Balance)
+
+	// A replay gets the original result without applying the change again
+	record, user, err = repo.ApplyBalance(ctx, "k1", "update_balance", "fp", 12345, 500, model.TxTypeCompensation, nil)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Nil(t, user)
+	assert.True(t, record.Completed)
+	assert.Equal(t, int64(1500), record.Balance)
+
+	current, err := userRepo.GetByID(ctx, 12345)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), current.Balance)
+
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM transactions WHERE user_id = 12345`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// A failed change leaves the key free, so it can be retried
+	_, _, err = repo.ApplyBalance(ctx, "k2", "update_balance", "fp", 99999, 500, model.TxTypeCompensation, nil)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM idempotency_keys WHERE key = 'k2'`).Scan(&count))
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RobHitRepository persists successful robberies for the anti-targeting caps.
+type RobHitRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRobHitRepository creates a new RobHitRepository instance.
+func NewRobHitRepository(pool *pgxpool.Pool) *RobHitRepository {
+	return &RobHitRepository{pool: pool}
+}
+
+// Record stores a successful robbery and prunes hits older than any cap window.
+func (r *RobHitRepository) Record(ctx context.Context, robberID, victimID int64) error {
+	const query = `
+		INSERT INTO rob_hits (robber_id, victim_id, created_at)
+		VALUES ($1, $2, NOW())
+	`
+	if _, err := r.pool.Exec(ctx, query, robberID, victimID); err != nil {
+		return fmt.Errorf("failed to record rob hit: %w", err)
+	}
+
+	const pruneQuery = `DELETE FROM rob_hits WHERE created_at < NOW() - INTERVAL '2 days'`
+	if _, err := r.pool.Exec(ctx, pruneQuery); err != nil {
+		return fmt.Errorf("failed to prune rob hits: %w", err)
+	}
+	return nil
+}
+
+// CountRecent returns how often the victim was robbed in the last hour by anyone,
+// and how often the given robber robbed the victim in the last 24 hours.
+func (r *RobHitRepository) CountRecent(ctx context.Context, robberID, victimID int64) (victimHourly int, pairDaily int, err error) {
+	const query = `
+		SELECT
+			COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '1 hour'),
+			COUNT(*) FILTER (WHERE robber_id = $2)
+		FROM rob_hits
+		WHERE victim_id = $1 AND created_at > NOW() - INTERVAL '24 hours'
+	`
+
+	if err := r.pool.QueryRow(ctx, query, victimID, robberID).Scan(&victimHourly, &pairDaily); err != nil {
+		return 0, 0, fmt.Errorf("failed to count rob hits: %w", err)
+	}
+	return victimHourly, pairDaily, nil
+}
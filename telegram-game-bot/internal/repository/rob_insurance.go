@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// RobInsuranceRepository handles the rob insurance pool. Coins move in and
+// out of it together with a ledger entry of the player paying or receiving.
+type RobInsuranceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRobInsuranceRepository creates a new RobInsuranceRepository instance.
+func NewRobInsuranceRepository(pool *pgxpool.Pool) *RobInsuranceRepository {
+	return &RobInsuranceRepository{pool: pool}
+}
+
+// Get returns the insurance pool.
+func (r *RobInsuranceRepository) Get(ctx context.Context) (*model.RobInsurancePool, error) {
+	const query = `SELECT balance, collected, paid_out, updated_at FROM rob_insurance_pool WHERE id = 1`
+
+	var p model.RobInsurancePool
+	if err := r.pool.QueryRow(ctx, query).Scan(&p.Balance, &p.Collected, &p.PaidOut, &p.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to get rob insurance pool: %w", err)
+	}
+	return &p, nil
+}
+
+// Deposit adds a cut to the pool.
+func (r *RobInsuranceRepository) Deposit(ctx context.Context, amount int64) error {
+	const query = `
+		UPDATE rob_insurance_pool
+		SET balance = balance + $1, collected = collected + $1, updated_at = NOW()
+		WHERE id = 1
+	`
+	if _, err := r.pool.Exec(ctx, query, amount); err != nil {
+		return fmt.Errorf("failed to deposit into rob insurance pool: %w", err)
+	}
+	return nil
+}
+
+// Withdraw takes up to amount out of the pool and returns what was taken,
+// less than amount if the pool holds less.
+func (r *RobInsuranceRepository) Withdraw(ctx context.Context, amount int64) (int64, error) {
+	const query = `
+		WITH cur AS (
+			SELECT LEAST($1::BIGINT, balance) AS taken FROM rob_insurance_pool WHERE id = 1 FOR UPDATE
+		)
+		UPDATE rob_insurance_pool
+		SET balance = balance - cur.taken, paid_out = paid_out + cur.taken, updated_at = NOW()
+		FROM cur
+		WHERE id = 1
+		RETURNING cur.taken
+	`
+	var taken int64
+	if err := r.pool.QueryRow(ctx, query, amount).Scan(&taken); err != nil {
+		return 0, fmt.Errorf("failed to withdraw from rob insurance pool: %w", err)
+	}
+	return taken, nil
+}
+
+// Refund puts back a withdrawal that could not be paid out, undoing it
+// rather than counting it as collected.
+func (r *RobInsuranceRepository) Refund(ctx context.Context, amount int64) error {
+	const query = `
+		UPDATE rob_insurance_pool
+		SET balance = balance + $1, paid_out = paid_out - $1, updated_at = NOW()
+		WHERE id = 1
+	`
+	if _, err := r.pool.Exec(ctx, query, amount); err != nil {
+		return fmt.Errorf("failed to refund rob insurance pool: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// RobMessageRepository handles rob flavor text persistence.
+type RobMessageRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRobMessageRepository creates a new RobMessageRepository instance.
+func NewRobMessageRepository(pool *pgxpool.Pool) *RobMessageRepository {
+	return &RobMessageRepository{pool: pool}
+}
+
+// Add stores a rob message.
+// Adding a text the outcome already has moves it to the given tier.
+func (r *RobMessageRepository) Add(ctx context.Context, msg *model.RobMessage) error {
+	const query = `
+		INSERT INTO rob_messages (outcome, tier, text, added_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (outcome, text) DO UPDATE SET tier = EXCLUDED.tier
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, msg.Outcome, msg.Tier, msg.Text, msg.AddedBy).
+		Scan(&msg.ID, &msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add rob message: %w", err)
+	}
+	return nil
+}
+
+// List returns all rob messages, ordered by outcome, tier and ID.
+func (r *RobMessageRepository) List(ctx context.Context) ([]model.RobMessage, error) {
+	const query = `
+		SELECT id, outcome, tier, text, added_by, created_at
+		FROM rob_messages
+		ORDER BY outcome, tier, id
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rob messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []model.RobMessage
+	for rows.Next() {
+		var m model.RobMessage
+		if err := rows.Scan(&m.ID, &m.Outcome, &m.Tier, &m.Text, &m.AddedBy, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rob message: %w", err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// Delete removes a rob message. Returns false if it did not exist.
+func (r *RobMessageRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM rob_messages WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete rob message: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
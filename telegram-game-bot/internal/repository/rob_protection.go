@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// RobProtectionRepository persists rob protection state so it survives restarts.
+type RobProtectionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRobProtectionRepository creates a new RobProtectionRepository instance.
+func NewRobProtectionRepository(pool *pgxpool.Pool) *RobProtectionRepository {
+	return &RobProtectionRepository{pool: pool}
+}
+
+// Save upserts the protection state of a user.
+func (r *RobProtectionRepository) Save(ctx context.Context, userID int64, consecutiveCount int, protectedUntil time.Time) error {
+	const query = `
+		INSERT INTO rob_protections (user_id, consecutive_count, protected_until, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET consecutive_count = EXCLUDED.consecutive_count,
+		    protected_until = EXCLUDED.protected_until,
+		    updated_at = NOW()
+	`
+
+	if _, err := r.pool.Exec(ctx, query, userID, consecutiveCount, protectedUntil); err != nil {
+		return fmt.Errorf("failed to save rob protection: %w", err)
+	}
+	return nil
+}
+
+// ListActive returns protection states that still matter: running protections
+// and robbery streaks that have not been reset yet.
+func (r *RobProtectionRepository) ListActive(ctx context.Context) ([]*model.RobProtection, error) {
+	const query = `
+		SELECT user_id, consecutive_count, protected_until, updated_at
+		FROM rob_protections
+		WHERE protected_until > NOW() OR consecutive_count > 0
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rob protections: %w", err)
+	}
+	defer rows.Close()
+
+	var protections []*model.RobProtection
+	for rows.Next() {
+		var p model.RobProtection
+		if err := rows.Scan(&p.UserID, &p.ConsecutiveCount, &p.ProtectedUntil, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rob protection: %w", err)
+		}
+		protections = append(protections, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rob protections: %w", err)
+	}
+
+	return protections, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SandboxRepository handles sandbox chats and their play-money balances.
+type SandboxRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSandboxRepository creates a new SandboxRepository instance.
+func NewSandboxRepository(pool *pgxpool.Pool) *SandboxRepository {
+	return &SandboxRepository{pool: pool}
+}
+
+// ListChats returns the IDs of all sandbox chats.
+func (r *SandboxRepository) ListChats(ctx context.Context) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `SELECT chat_id FROM sandbox_chats`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sandbox chats: %w", err)
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			return nil, fmt.Errorf("failed to scan sandbox chat: %w", err)
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+	return chatIDs, rows.Err()
+}
+
+// Enable marks a chat as a sandbox chat. Enabling it again is a no-op.
+func (r *SandboxRepository) Enable(ctx context.Context, chatID, adminID int64) error {
+	const query = `
+		INSERT INTO sandbox_chats (chat_id, enabled_by, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (chat_id) DO NOTHING
+	`
+
+	if _, err := r.pool.Exec(ctx, query, chatID, adminID); err != nil {
+		return fmt.Errorf("failed to enable sandbox: %w", err)
+	}
+	return nil
+}
+
+// Disable turns the sandbox off for a chat. Its play money is kept for the
+// next time the sandbox is enabled.
+func (r *SandboxRepository) Disable(ctx context.Context, chatID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM sandbox_chats WHERE chat_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to disable sandbox: %w", err)
+	}
+	return nil
+}
+
+// GetBalance returns a user's play money in a chat, start if they have not played there yet.
+func (r *SandboxRepository) GetBalance(ctx context.Context, chatID, userID, start int64) (int64, error) {
+	const query = `SELECT balance FROM sandbox_balances WHERE chat_id = $1 AND user_id = $2`
+
+	var balance int64
+	err := r.pool.QueryRow(ctx, query, chatID, userID).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return start, nil
+		}
+		return 0, fmt.Errorf("failed to get sandbox balance: %w", err)
+	}
+	return balance, nil
+}
+
+// AddBalance adds amount (negative to subtract) to a user's play money in a
+// chat, starting from start on their first game. Returns the new balance.
+func (r *SandboxRepository) AddBalance(ctx context.Context, chatID, userID, amount, start int64) (int64, error) {
+	const query = `
+		INSERT INTO sandbox_balances (chat_id, user_id, balance, updated_at)
+		VALUES ($1, $2, $3::BIGINT + $4::BIGINT, NOW())
+		ON CONFLICT (chat_id, user_id)
+		DO UPDATE SET balance = sandbox_balances.balance + $4, updated_at = NOW()
+		RETURNING balance
+	`
+
+	var balance int64
+	if err := r.pool.QueryRow(ctx, query, chatID, userID, start, amount).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to update sandbox balance: %w", err)
+	}
+	return balance, nil
+}
+
+// ResetBalances deletes all play money of a chat, so everyone starts over.
+func (r *SandboxRepository) ResetBalances(ctx context.Context, chatID int64) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM sandbox_balances WHERE chat_id = $1`, chatID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset sandbox balances: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ShopSale represents a stored flash sale.
+type ShopSale struct {
+	Target          string
+	DiscountPercent int
+	EndsAt          time.Time
+	CreatedBy       int64
+	CreatedAt       time.Time
+}
+
+// PricingRepository handles flash sales and daily item sales counters.
+type PricingRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPricingRepository creates a new PricingRepository instance.
+func NewPricingRepository(pool *pgxpool.Pool) *PricingRepository {
+	return &PricingRepository{pool: pool}
+}
+
+// UpsertSale creates or replaces the flash sale for target.
+func (r *PricingRepository) UpsertSale(ctx context.Context, sale *ShopSale) error {
+	const query = `
+		INSERT INTO shop_sales (target, discount_percent, ends_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (target) DO UPDATE SET
+			discount_percent = EXCLUDED.discount_percent,
+			ends_at = EXCLUDED.ends_at,
+			created_by = EXCLUDED.created_by,
+			created_at = NOW()
+		RETURNING created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, sale.Target, sale.DiscountPercent, sale.EndsAt, sale.CreatedBy).Scan(&sale.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert shop sale: %w", err)
+	}
+	return nil
+}
+
+// DeleteSale removes the flash sale for target. Returns false if there was none.
+func (r *PricingRepository) DeleteSale(ctx context.Context, target string) (bool, error) {
+	const query = `DELETE FROM shop_sales WHERE target = $1`
+
+	tag, err := r.pool.Exec(ctx, query, target)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete shop sale: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetActiveSales returns all flash sales that have not ended.
+func (r *PricingRepository) GetActiveSales(ctx context.Context) ([]*ShopSale, error) {
+	const query = `
+		SELECT target, discount_percent, ends_at, created_by, created_at
+		FROM shop_sales
+		WHERE ends_at > NOW()
+		ORDER BY ends_at ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active shop sales: %w", err)
+	}
+	defer rows.Close()
+
+	var sales []*ShopSale
+	for rows.Next() {
+		var s ShopSale
+		if err := rows.Scan(&s.Target, &s.DiscountPercent, &s.EndsAt, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shop sale: %w", err)
+		}
+		sales = append(sales, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate shop sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+// IncrementItemSold adds one unit to today's sales counter of an item.
+func (r *PricingRepository) IncrementItemSold(ctx context.Context, itemType string) error {
+	const query = `
+		INSERT INTO shop_item_sales (item_type, sale_date, sold_count)
+		VALUES ($1, CURRENT_DATE, 1)
+		ON CONFLICT (item_type, sale_date) DO UPDATE SET sold_count = shop_item_sales.sold_count + 1
+	`
+
+	if _, err := r.pool.Exec(ctx, query, itemType); err != nil {
+		return fmt.Errorf("failed to increment item sales: %w", err)
+	}
+	return nil
+}
+
+// GetSoldToday returns today's sales counters keyed by item type.
+func (r *PricingRepository) GetSoldToday(ctx context.Context) (map[string]int, error) {
+	const query = `SELECT item_type, sold_count FROM shop_item_sales WHERE sale_date = CURRENT_DATE`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item sales: %w", err)
+	}
+	defer rows.Close()
+
+	sold := make(map[string]int)
+	for rows.Next() {
+		var itemType string
+		var count int
+		if err := rows.Scan(&itemType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan item sales: %w", err)
+		}
+		sold[itemType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate item sales: %w", err)
+	}
+
+	return sold, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// SicBoAutoRepository handles sicbo auto-start schedule persistence.
+type SicBoAutoRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSicBoAutoRepository creates a new SicBoAutoRepository instance.
+func NewSicBoAutoRepository(pool *pgxpool.Pool) *SicBoAutoRepository {
+	return &SicBoAutoRepository{pool: pool}
+}
+
+// Get retrieves the auto-start schedule of a chat.
+// Returns nil if the chat never configured one.
+func (r *SicBoAutoRepository) Get(ctx context.Context, chatID int64) (*model.SicBoAutoSchedule, error) {
+	const query = `
+		SELECT chat_id, interval_minutes, start_hour, end_hour, enabled, updated_by, updated_at
+		FROM sicbo_auto_schedules
+		WHERE chat_id = $1
+	`
+
+	var s model.SicBoAutoSchedule
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(
+		&s.ChatID,
+		&s.IntervalMinutes,
+		&s.StartHour,
+		&s.EndHour,
+		&s.Enabled,
+		&s.UpdatedBy,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sicbo auto schedule: %w", err)
+	}
+	return &s, nil
+}
+
+// ListEnabled returns the schedules of all chats with auto-start enabled.
+func (r *SicBoAutoRepository) ListEnabled(ctx context.Context) ([]model.SicBoAutoSchedule, error) {
+	const query = `
+		SELECT chat_id, interval_minutes, start_hour, end_hour, enabled, updated_by, updated_at
+		FROM sicbo_auto_schedules
+		WHERE enabled
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sicbo auto schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []model.SicBoAutoSchedule
+	for rows.Next() {
+		var s model.SicBoAutoSchedule
+		if err := rows.Scan(
+			&s.ChatID,
+			&s.IntervalMinutes,
+			&s.StartHour,
+			&s.EndHour,
+			&s.Enabled,
+			&s.UpdatedBy,
+			&s.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan sicbo auto schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// Upsert creates or replaces the auto-start schedule of a chat.
+func (r *SicBoAutoRepository) Upsert(ctx context.Context, s *model.SicBoAutoSchedule) error {
+	const query = `
+		INSERT INTO sicbo_auto_schedules (chat_id, interval_minutes, start_hour, end_hour, enabled, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (chat_id) DO UPDATE SET
+			interval_minutes = EXCLUDED.interval_minutes,
+			start_hour = EXCLUDED.start_hour,
+			end_hour = EXCLUDED.end_hour,
+			enabled = EXCLUDED.enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		s.ChatID, s.IntervalMinutes, s.StartHour, s.EndHour, s.Enabled, s.UpdatedBy,
+	).Scan(&s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save sicbo auto schedule: %w", err)
+	}
+	return nil
+}
+
+// SetEnabled turns auto-start of a chat on or off, keeping its settings.
+// Returns false if the chat has no schedule.
+func (r *SicBoAutoRepository) SetEnabled(ctx context.Context, chatID int64, enabled bool, updatedBy int64) (bool, error) {
+	const query = `
+		UPDATE sicbo_auto_schedules
+		SET enabled = $2, updated_by = $3, updated_at = NOW()
+		WHERE chat_id = $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, chatID, enabled, updatedBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to update sicbo auto schedule: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
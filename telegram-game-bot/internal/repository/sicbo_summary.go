@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SicBoSummaryRepository handles opt-ins for personal SicBo settlement DMs.
+type SicBoSummaryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSicBoSummaryRepository creates a new SicBoSummaryRepository instance.
+func NewSicBoSummaryRepository(pool *pgxpool.Pool) *SicBoSummaryRepository {
+	return &SicBoSummaryRepository{pool: pool}
+}
+
+// IsOptedIn reports whether a user receives SicBo settlement DMs.
+func (r *SicBoSummaryRepository) IsOptedIn(ctx context.Context, userID int64) (bool, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx, `SELECT user_id FROM sicbo_summary_optins WHERE user_id = $1`, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get sicbo summary opt-in: %w", err)
+	}
+	return true, nil
+}
+
+// OptedIn returns which of the given users receive SicBo settlement DMs.
+func (r *SicBoSummaryRepository) OptedIn(ctx context.Context, userIDs []int64) (map[int64]bool, error) {
+	optedIn := make(map[int64]bool)
+	if len(userIDs) == 0 {
+		return optedIn, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT user_id FROM sicbo_summary_optins WHERE user_id = ANY($1)`, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sicbo summary opt-ins: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan sicbo summary opt-in: %w", err)
+		}
+		optedIn[userID] = true
+	}
+	return optedIn, rows.Err()
+}
+
+// Enable opts a user in to SicBo settlement DMs.
+func (r *SicBoSummaryRepository) Enable(ctx context.Context, userID int64) error {
+	const query = `INSERT INTO sicbo_summary_optins (user_id, created_at) VALUES ($1, NOW()) ON CONFLICT (user_id) DO NOTHING`
+	if _, err := r.pool.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to enable sicbo summary: %w", err)
+	}
+	return nil
+}
+
+// Disable opts a user out of SicBo settlement DMs.
+func (r *SicBoSummaryRepository) Disable(ctx context.Context, userID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM sicbo_summary_optins WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable sicbo summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// Support ticket errors.
+var (
+	ErrTicketNotFound = errors.New("support ticket not found")
+	ErrTicketResolved = errors.New("support ticket already resolved")
+)
+
+// SupportRepository handles support ticket persistence.
+// Tickets double as the audit trail for refunds and dismissals.
+type SupportRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSupportRepository creates a new SupportRepository instance.
+func NewSupportRepository(pool *pgxpool.Pool) *SupportRepository {
+	return &SupportRepository{pool: pool}
+}
+
+// Create creates a new open support ticket.
+func (r *SupportRepository) Create(ctx context.Context, userID int64, message string) (*model.SupportTicket, error) {
+	const query = `
+		INSERT INTO support_tickets (user_id, message, status, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, user_id, message, status, resolved_by, refund_tx_id, refund_amount, created_at, resolved_at
+	`
+
+	ticket, err := scanTicket(r.pool.QueryRow(ctx, query, userID, message, model.TicketStatusOpen))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create support ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+// GetByID retrieves a support ticket.
+// Returns ErrTicketNotFound if the ticket does not exist.
+func (r *SupportRepository) GetByID(ctx context.Context, ticketID int64) (*model.SupportTicket, error) {
+	const query = `
+		SELECT id, user_id, message, status, resolved_by, refund_tx_id, refund_amount, created_at, resolved_at
+		FROM support_tickets
+		WHERE id = $1
+	`
+
+	ticket, err := scanTicket(r.pool.QueryRow(ctx, query, ticketID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("failed to get support ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+// CountOpenByUser returns the number of open tickets for a user.
+func (r *SupportRepository) CountOpenByUser(ctx context.Context, userID int64) (int, error) {
+	const query = `SELECT COUNT(*) FROM support_tickets WHERE user_id = $1 AND status = $2`
+
+	var count int
+	err := r.pool.QueryRow(ctx, query, userID, model.TicketStatusOpen).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count open tickets: %w", err)
+	}
+	return count, nil
+}
+
+// Resolve moves an open ticket to the given status.
+// Only one resolution can win: returns ErrTicketResolved if the ticket is no longer open.
+func (r *SupportRepository) Resolve(ctx context.Context, ticketID int64, status string, adminID int64, refundTxID *int64, refundAmount int64) error {
+	const query = `
+		UPDATE support_tickets
+		SET status = $2, resolved_by = $3, refund_tx_id = $4, refund_amount = $5, resolved_at = NOW()
+		WHERE id = $1 AND status = 'open'
+	`
+
+	result, err := r.pool.Exec(ctx, query, ticketID, status, adminID, refundTxID, refundAmount)
+	if err != nil {
+		return fmt.Errorf("failed to resolve support ticket: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		if _, err := r.GetByID(ctx, ticketID); err != nil {
+			return err
+		}
+		return ErrTicketResolved
+	}
+	return nil
+}
+
+// Reopen moves a resolved ticket back to open, used when a refund could not be credited.
+func (r *SupportRepository) Reopen(ctx context.Context, ticketID int64) error {
+	const query = `
+		UPDATE support_tickets
+		SET status = 'open', resolved_by = NULL, refund_tx_id = NULL, refund_amount = 0, resolved_at = NULL
+		WHERE id = $1
+	`
+
+	_, err := r.pool.Exec(ctx, query, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to reopen support ticket: %w", err)
+	}
+	return nil
+}
+
+// scanTicket scans a single support ticket row.
+func scanTicket(row pgx.Row) (*model.SupportTicket, error) {
+	var ticket model.SupportTicket
+	err := row.Scan(
+		&ticket.ID,
+		&ticket.UserID,
+		&ticket.Message,
+		&ticket.Status,
+		&ticket.ResolvedBy,
+		&ticket.RefundTxID,
+		&ticket.RefundAmount,
+		&ticket.CreatedAt,
+		&ticket.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
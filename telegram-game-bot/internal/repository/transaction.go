@@ -2,18 +2,25 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
 )
 
+// ErrTransactionNotFound is returned when a transaction does not exist.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
 // TransactionRepository handles transaction data persistence.
 // Requirements: 2.5, 11.2 - Transaction history and daily stats
 type TransactionRepository struct {
 	pool *pgxpool.Pool
+	bus  *events.Bus // optional, notified of every recorded transaction
 }
 
 // NewTransactionRepository creates a new TransactionRepository instance.
@@ -21,6 +28,34 @@ func NewTransactionRepository(pool *pgxpool.Pool) *TransactionRepository {
 	return &TransactionRepository{pool: pool}
 }
 
+// SetEventBus sets the bus that recorded transactions are published to.
+func (r *TransactionRepository) SetEventBus(bus *events.Bus) {
+	r.bus = bus
+}
+
+// publish announces a recorded transaction on the event bus
+func (r *TransactionRepository) publish(tx *model.Transaction) {
+	publishTransaction(r.bus, tx)
+}
+
+// publishTransaction announces a recorded transaction as a balance change
+func publishTransaction(bus *events.Bus, tx *model.Transaction) {
+	if bus == nil {
+		return
+	}
+	event := events.BalanceChanged{
+		UserID: tx.UserID,
+		Amount: tx.Amount,
+		TxType: tx.Type,
+		TxID:   tx.ID,
+		At:     tx.CreatedAt,
+	}
+	if tx.Description != nil {
+		event.Description = *tx.Description
+	}
+	bus.PublishBalanceChanged(event)
+}
+
 // Create creates a new transaction record.
 // Requirements: 2.5 - Record all transfers in transaction history
 func (r *TransactionRepository) Create(ctx context.Context, userID int64, amount int64, txType string, description *string) (*model.Transaction, error) {
@@ -43,6 +78,7 @@ func (r *TransactionRepository) Create(ctx context.Context, userID int64, amount
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
+	r.publish(&tx)
 	return &tx, nil
 }
 
@@ -68,9 +104,101 @@ func (r *TransactionRepository) CreateWithTime(ctx context.Context, userID int64
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
+	r.publish(&tx)
 	return &tx, nil
 }
 
+// CreateTransfer records coins moving from one user to another as a single
+// ledger entry with one transaction leg per user, so the flow can be traced
+// from payer to payee. The treasury is not involved.
+func (r *TransactionRepository) CreateTransfer(ctx context.Context, fromID, toID, amount int64, fromType, toType string, fromDesc, toDesc *string) (*model.Transaction, *model.Transaction, error) {
+	const entryQuery = `
+		INSERT INTO ledger_entries (debit_account, credit_account, amount, type, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id
+	`
+
+	if amount <= 0 {
+		return nil, nil, fmt.Errorf("invalid transfer amount: %d", amount)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var entryID int64
+	if err := tx.QueryRow(ctx, entryQuery, fromID, toID, amount, toType, toDesc).Scan(&entryID); err != nil {
+		return nil, nil, fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+	from, err := createLeg(ctx, tx, entryID, fromID, -amount, fromType, fromDesc)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := createLeg(ctx, tx, entryID, toID, amount, toType, toDesc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	r.publish(from)
+	r.publish(to)
+	return from, to, nil
+}
+
+// createLeg inserts one user's leg of a ledger entry
+func createLeg(ctx context.Context, tx pgx.Tx, entryID, userID, amount int64, txType string, description *string) (*model.Transaction, error) {
+	const query = `
+		INSERT INTO transactions (user_id, amount, type, description, ledger_entry_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, user_id, amount, type, description, created_at
+	`
+
+	var t model.Transaction
+	err := tx.QueryRow(ctx, query, userID, amount, txType, description, entryID).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Amount,
+		&t.Type,
+		&t.Description,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return &t, nil
+}
+
+// GetByID retrieves a single transaction.
+// Returns ErrTransactionNotFound if the transaction does not exist.
+func (r *TransactionRepository) GetByID(ctx context.Context, id int64) (*model.Transaction, error) {
+	const query = `
+		SELECT id, user_id, amount, type, description, created_at
+		FROM transactions
+		WHERE id = $1
+	`
+
+	var tx model.Transaction
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&tx.ID,
+		&tx.UserID,
+		&tx.Amount,
+		&tx.Type,
+		&tx.Description,
+		&tx.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return &tx, nil
+}
 
 // GetByUserID retrieves all transactions for a user, ordered by creation time (newest first).
 func (r *TransactionRepository) GetByUserID(ctx context.Context, userID int64, limit int) ([]*model.Transaction, error) {
@@ -112,6 +240,48 @@ func (r *TransactionRepository) GetByUserID(ctx context.Context, userID int64, l
 	return transactions, nil
 }
 
+// GetAllForExport retrieves all transactions of a user, oldest first, with the
+// other account of their ledger entry.
+func (r *TransactionRepository) GetAllForExport(ctx context.Context, userID int64) ([]*model.TransactionExport, error) {
+	const query = `
+		SELECT t.id, t.user_id, t.amount, t.type, t.description, t.created_at,
+		       CASE WHEN e.debit_account = t.user_id THEN e.credit_account ELSE e.debit_account END
+		FROM transactions t
+		LEFT JOIN ledger_entries e ON e.id = t.ledger_entry_id
+		WHERE t.user_id = $1
+		ORDER BY t.created_at, t.id
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions for export: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*model.TransactionExport
+	for rows.Next() {
+		var tx model.TransactionExport
+		if err := rows.Scan(
+			&tx.ID,
+			&tx.UserID,
+			&tx.Amount,
+			&tx.Type,
+			&tx.Description,
+			&tx.CreatedAt,
+			&tx.Counterparty,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, &tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
 // GetByUserIDAndType retrieves transactions for a user filtered by type.
 func (r *TransactionRepository) GetByUserIDAndType(ctx context.Context, userID int64, txType string, limit int) ([]*model.Transaction, error) {
 	const query = `
@@ -155,6 +325,8 @@ func (r *TransactionRepository) GetByUserIDAndType(ctx context.Context, userID i
 
 // GetDailyStats retrieves daily game statistics for ranking.
 // Returns users with their net profit/loss for the specified date.
+// Counted types come from model.GameTransactionTypes; PvP rows of users who
+// opted out of PvP are not counted.
 // Requirements: 11.2 - Track daily net profit/loss for each user from game transactions
 func (r *TransactionRepository) GetDailyStats(ctx context.Context, date time.Time) ([]*model.DailyRank, error) {
 	// Get the start and end of the day
@@ -162,17 +334,20 @@ func (r *TransactionRepository) GetDailyStats(ctx context.Context, date time.Tim
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
 	const query = `
-		SELECT t.user_id, u.username, COALESCE(SUM(t.amount), 0) as net_profit
+		SELECT t.user_id, u.username, u.hide_from_leaderboard, COALESCE(SUM(t.amount), 0) as net_profit
 		FROM transactions t
 		JOIN users u ON t.user_id = u.telegram_id
-		WHERE t.type IN ('dice', 'slot', 'sicbo_win', 'sicbo_bet', 'rob', 'robbed')
+		WHERE t.type = ANY($3)
 		  AND t.created_at >= $1
 		  AND t.created_at < $2
-		GROUP BY t.user_id, u.username
+		  AND NOT (t.type = ANY($4) AND EXISTS (
+			SELECT 1 FROM pvp_preferences p WHERE p.user_id = t.user_id AND p.opted_out
+		  ))
+		GROUP BY t.user_id, u.username, u.hide_from_leaderboard
 		ORDER BY net_profit DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, startOfDay, endOfDay)
+	rows, err := r.pool.Query(ctx, query, startOfDay, endOfDay, model.GameTransactionTypes(), model.PvPTransactionTypes())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily stats: %w", err)
 	}
@@ -184,6 +359,7 @@ func (r *TransactionRepository) GetDailyStats(ctx context.Context, date time.Tim
 		err := rows.Scan(
 			&rank.UserID,
 			&rank.Username,
+			&rank.Hidden,
 			&rank.NetProfit,
 		)
 		if err != nil {
@@ -201,25 +377,30 @@ func (r *TransactionRepository) GetDailyStats(ctx context.Context, date time.Tim
 
 // GetDailyWinners retrieves the top winners for a specific date.
 // Winners are users with positive net profit, sorted by profit descending.
+// Counted types come from model.GameTransactionTypes; PvP rows of users who
+// opted out of PvP are not counted.
 // Requirements: 11.3 - Show top 10 winners (most profit)
 func (r *TransactionRepository) GetDailyWinners(ctx context.Context, date time.Time, limit int) ([]*model.DailyRank, error) {
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
 	const query = `
-		SELECT t.user_id, u.username, COALESCE(SUM(t.amount), 0) as net_profit
+		SELECT t.user_id, u.username, u.hide_from_leaderboard, COALESCE(SUM(t.amount), 0) as net_profit
 		FROM transactions t
 		JOIN users u ON t.user_id = u.telegram_id
-		WHERE t.type IN ('dice', 'slot', 'sicbo_win', 'sicbo_bet', 'rob', 'robbed')
+		WHERE t.type = ANY($3)
 		  AND t.created_at >= $1
 		  AND t.created_at < $2
-		GROUP BY t.user_id, u.username
+		  AND NOT (t.type = ANY($4) AND EXISTS (
+			SELECT 1 FROM pvp_preferences p WHERE p.user_id = t.user_id AND p.opted_out
+		  ))
+		GROUP BY t.user_id, u.username, u.hide_from_leaderboard
 		HAVING SUM(t.amount) > 0
 		ORDER BY net_profit DESC
-		LIMIT $3
+		LIMIT $5
 	`
 
-	rows, err := r.pool.Query(ctx, query, startOfDay, endOfDay, limit)
+	rows, err := r.pool.Query(ctx, query, startOfDay, endOfDay, model.GameTransactionTypes(), model.PvPTransactionTypes(), limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily winners: %w", err)
 	}
@@ -231,6 +412,7 @@ func (r *TransactionRepository) GetDailyWinners(ctx context.Context, date time.T
 		err := rows.Scan(
 			&rank.UserID,
 			&rank.Username,
+			&rank.Hidden,
 			&rank.NetProfit,
 		)
 		if err != nil {
@@ -248,25 +430,30 @@ func (r *TransactionRepository) GetDailyWinners(ctx context.Context, date time.T
 
 // GetDailyLosers retrieves the top losers for a specific date.
 // Losers are users with negative net profit, sorted by loss descending (most loss first).
+// Counted types come from model.GameTransactionTypes; PvP rows of users who
+// opted out of PvP are not counted.
 // Requirements: 11.3 - Show top 10 losers (most loss)
 func (r *TransactionRepository) GetDailyLosers(ctx context.Context, date time.Time, limit int) ([]*model.DailyRank, error) {
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
 	const query = `
-		SELECT t.user_id, u.username, COALESCE(SUM(t.amount), 0) as net_profit
+		SELECT t.user_id, u.username, u.hide_from_leaderboard, COALESCE(SUM(t.amount), 0) as net_profit
 		FROM transactions t
 		JOIN users u ON t.user_id = u.telegram_id
-		WHERE t.type IN ('dice', 'slot', 'sicbo_win', 'sicbo_bet', 'rob', 'robbed')
+		WHERE t.type = ANY($3)
 		  AND t.created_at >= $1
 		  AND t.created_at < $2
-		GROUP BY t.user_id, u.username
+		  AND NOT (t.type = ANY($4) AND EXISTS (
+			SELECT 1 FROM pvp_preferences p WHERE p.user_id = t.user_id AND p.opted_out
+		  ))
+		GROUP BY t.user_id, u.username, u.hide_from_leaderboard
 		HAVING SUM(t.amount) < 0
 		ORDER BY net_profit ASC
-		LIMIT $3
+		LIMIT $5
 	`
 
-	rows, err := r.pool.Query(ctx, query, startOfDay, endOfDay, limit)
+	rows, err := r.pool.Query(ctx, query, startOfDay, endOfDay, model.GameTransactionTypes(), model.PvPTransactionTypes(), limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily losers: %w", err)
 	}
@@ -278,6 +465,7 @@ func (r *TransactionRepository) GetDailyLosers(ctx context.Context, date time.Ti
 		err := rows.Scan(
 			&rank.UserID,
 			&rank.Username,
+			&rank.Hidden,
 			&rank.NetProfit,
 		)
 		if err != nil {
@@ -302,16 +490,49 @@ func (r *TransactionRepository) GetUserDailyProfit(ctx context.Context, userID i
 		SELECT COALESCE(SUM(amount), 0)
 		FROM transactions
 		WHERE user_id = $1
-		  AND type IN ('dice', 'slot', 'sicbo_win', 'sicbo_bet', 'rob', 'robbed')
+		  AND type = ANY($4)
 		  AND created_at >= $2
 		  AND created_at < $3
 	`
 
 	var profit int64
-	err := r.pool.QueryRow(ctx, query, userID, startOfDay, endOfDay).Scan(&profit)
+	err := r.pool.QueryRow(ctx, query, userID, startOfDay, endOfDay, model.GameTransactionTypes()).Scan(&profit)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get user daily profit: %w", err)
 	}
 
 	return profit, nil
 }
+
+// GetUserProfitSince retrieves a user's net game profit of the transactions
+// created at or after since; the zero time sums all of them.
+func (r *TransactionRepository) GetUserProfitSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	const query = `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1
+		  AND type = ANY($3)
+		  AND created_at >= $2
+	`
+
+	var profit int64
+	err := r.pool.QueryRow(ctx, query, userID, since, model.GameTransactionTypes()).Scan(&profit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user profit: %w", err)
+	}
+
+	return profit, nil
+}
+
+// GetFirstTransactionTime returns when the oldest transaction was created,
+// false if there are none.
+func (r *TransactionRepository) GetFirstTransactionTime(ctx context.Context) (time.Time, bool, error) {
+	var first *time.Time
+	if err := r.pool.QueryRow(ctx, `SELECT MIN(created_at) FROM transactions`).Scan(&first); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get first transaction time: %w", err)
+	}
+	if first == nil {
+		return time.Time{}, false, nil
+	}
+	return *first, true, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TransactionPartitionRepository manages the monthly partitions of the
+// transactions table. Partitions cover calendar months in UTC and are named
+// by TransactionPartitionName; transactions_legacy holds everything before
+// the first monthly partition.
+type TransactionPartitionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTransactionPartitionRepository creates a new TransactionPartitionRepository instance.
+func NewTransactionPartitionRepository(pool *pgxpool.Pool) *TransactionPartitionRepository {
+	return &TransactionPartitionRepository{pool: pool}
+}
+
+// TransactionPartitionName returns the name of the partition of the UTC month of t.
+func TransactionPartitionName(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("transactions_y%04dm%02d", t.Year(), int(t.Month()))
+}
+
+// List returns the names of the partitions attached to transactions.
+func (r *TransactionPartitionRepository) List(ctx context.Context) ([]string, error) {
+	const query = `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'transactions'::regclass
+		ORDER BY c.relname
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transaction partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction partition: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// LegacyEnd returns the upper bound of transactions_legacy, false once it
+// is no longer attached. Monthly partitions start at or after it.
+func (r *TransactionPartitionRepository) LegacyEnd(ctx context.Context) (time.Time, bool, error) {
+	const query = `
+		SELECT substring(pg_get_expr(c.relpartbound, c.oid) FROM 'TO \(''(.*)''\)')::timestamptz
+		FROM pg_class c
+		WHERE c.oid = to_regclass('transactions_legacy') AND c.relispartition
+	`
+
+	var end time.Time
+	err := r.pool.QueryRow(ctx, query).Scan(&end)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get legacy transaction partition bound: %w", err)
+	}
+	return end.UTC(), true, nil
+}
+
+// Ensure creates the partition of the UTC month starting at month unless it
+// exists. Returns true if it was created.
+func (r *TransactionPartitionRepository) Ensure(ctx context.Context, month time.Time) (bool, error) {
+	month = month.UTC()
+	name := TransactionPartitionName(month)
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check transaction partition: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	// DDL takes no parameters; the name and bounds are formatted from month
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF transactions FOR VALUES FROM ('%s') TO ('%s')`,
+		pgx.Identifier{name}.Sanitize(), month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339))
+	if _, err := r.pool.Exec(ctx, ddl); err != nil {
+		return false, fmt.Errorf("failed to create transaction partition %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Detach detaches a partition from transactions, archiving its rows: they
+// stay in a table of the same name but are no longer read by any query,
+// exports, transaction lookups and refunds included.
+func (r *TransactionPartitionRepository) Detach(ctx context.Context, name string) error {
+	ddl := fmt.Sprintf(`ALTER TABLE transactions DETACH PARTITION %s`, pgx.Identifier{name}.Sanitize())
+	if _, err := r.pool.Exec(ctx, ddl); err != nil {
+		return fmt.Errorf("failed to detach transaction partition %s: %w", name, err)
+	}
+	return nil
+}
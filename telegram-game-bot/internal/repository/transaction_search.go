@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TransactionFilter selects transactions for a search. Zero fields do not filter.
+type TransactionFilter struct {
+	UserID    int64
+	Types     []string  // Any of these types
+	MinAmount int64     // Smallest absolute amount
+	Since     time.Time // Created at or after
+	BeforeID  int64     // Keyset cursor: only transactions with a smaller ID
+	Limit     int
+}
+
+// buildTransactionSearch builds the query and arguments of a search, newest first
+func buildTransactionSearch(f TransactionFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.UserID != 0 {
+		add("user_id = $%d", f.UserID)
+	}
+	if len(f.Types) == 1 {
+		add("type = $%d", f.Types[0])
+	} else if len(f.Types) > 1 {
+		add("type = ANY($%d)", f.Types)
+	}
+	if f.MinAmount > 0 {
+		add("ABS(amount) >= $%d", f.MinAmount)
+	}
+	if !f.Since.IsZero() {
+		add("created_at >= $%d", f.Since)
+	}
+	if f.BeforeID > 0 {
+		add("id < $%d", f.BeforeID)
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT id, user_id, amount, type, description, created_at FROM transactions")
+	if len(conds) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(conds, " AND "))
+	}
+	args = append(args, f.Limit)
+	fmt.Fprintf(&b, " ORDER BY id DESC LIMIT $%d", len(args))
+	return b.String(), args
+}
+
+// Search returns the transactions matching a filter, newest first.
+func (r *TransactionRepository) Search(ctx context.Context, f TransactionFilter) ([]*model.Transaction, error) {
+	query, args := buildTransactionSearch(f)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*model.Transaction
+	for rows.Next() {
+		var tx model.Transaction
+		if err := rows.Scan(&tx.ID, &tx.UserID, &tx.Amount, &tx.Type, &tx.Description, &tx.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, &tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+	return transactions, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"telegram-game-bot/internal/model"
+)
+
+// GetTypeTotals sums the transactions of the given types created at or after
+// since, one row per type that has any.
+func (r *TransactionRepository) GetTypeTotals(ctx context.Context, types []string, since time.Time) ([]*model.TxTypeTotals, error) {
+	const query = `
+		SELECT type,
+			COUNT(*) FILTER (WHERE amount < 0),
+			COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0),
+			COALESCE(SUM(amount) FILTER (WHERE amount > 0 AND description IS NOT NULL), 0),
+			COALESCE(SUM(amount) FILTER (WHERE amount > 0 AND description IS NULL), 0)
+		FROM transactions
+		WHERE type = ANY($1) AND created_at >= $2
+		GROUP BY type
+	`
+
+	rows, err := r.pool.Query(ctx, query, types, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []*model.TxTypeTotals
+	for rows.Next() {
+		var t model.TxTypeTotals
+		if err := rows.Scan(&t.Type, &t.Debits, &t.Debited, &t.Credited, &t.Refunded); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction totals: %w", err)
+		}
+		totals = append(totals, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction totals: %w", err)
+	}
+	return totals, nil
+}
+
+// GetGameActivity returns the number of game transactions created in
+// [from, to) and the largest single win among them (0 if none won).
+func (r *TransactionRepository) GetGameActivity(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	const query = `
+		SELECT COUNT(*), COALESCE(MAX(amount) FILTER (WHERE amount > 0), 0)
+		FROM transactions
+		WHERE type = ANY($3) AND created_at >= $1 AND created_at < $2
+	`
+
+	var count, biggest int64
+	if err := r.pool.QueryRow(ctx, query, from, to, model.GameTransactionTypes()).Scan(&count, &biggest); err != nil {
+		return 0, 0, fmt.Errorf("failed to get game activity: %w", err)
+	}
+	return count, biggest, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TreasuryRepository reads the house account. The treasury balance itself is
+// maintained by a trigger on ledger entries.
+type TreasuryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTreasuryRepository creates a new TreasuryRepository instance.
+func NewTreasuryRepository(pool *pgxpool.Pool) *TreasuryRepository {
+	return &TreasuryRepository{pool: pool}
+}
+
+// Snapshot returns the treasury balance together with the sum of all user
+// balances, read in one statement so both come from the same snapshot.
+func (r *TreasuryRepository) Snapshot(ctx context.Context) (*model.TreasurySnapshot, error) {
+	const query = `
+		SELECT t.balance, (SELECT COALESCE(SUM(balance), 0) FROM users), t.updated_at
+		FROM treasury t
+		WHERE t.id = 1
+	`
+
+	var s model.TreasurySnapshot
+	err := r.pool.QueryRow(ctx, query).Scan(&s.Balance, &s.UsersTotal, &s.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get treasury snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// FlowsByType returns the net amount users received per transaction type
+// since the given time. The treasury moved by the opposite amount.
+func (r *TreasuryRepository) FlowsByType(ctx context.Context, since time.Time) (map[string]int64, error) {
+	const query = `
+		SELECT type, COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE created_at >= $1
+		GROUP BY type
+	`
+
+	rows, err := r.pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get treasury flows: %w", err)
+	}
+	defer rows.Close()
+
+	flows := make(map[string]int64)
+	for rows.Next() {
+		var txType string
+		var amount int64
+		if err := rows.Scan(&txType, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan treasury flow: %w", err)
+		}
+		flows[txType] = amount
+	}
+	return flows, rows.Err()
+}
+
+// DailyNetFlows returns the net amount users received on each of the last
+// days full days, oldest first. Days without transactions count as zero.
+func (r *TreasuryRepository) DailyNetFlows(ctx context.Context, days int) ([]int64, error) {
+	const query = `
+		SELECT d.day, COALESCE(SUM(t.amount), 0)
+		FROM generate_series(CURRENT_DATE - $1::int, CURRENT_DATE - 1, INTERVAL '1 day') AS d(day)
+		LEFT JOIN transactions t ON t.created_at >= d.day AND t.created_at < d.day + INTERVAL '1 day'
+		GROUP BY d.day
+		ORDER BY d.day
+	`
+
+	rows, err := r.pool.Query(ctx, query, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily flows: %w", err)
+	}
+	defer rows.Close()
+
+	var flows []int64
+	for rows.Next() {
+		var day time.Time
+		var amount int64
+		if err := rows.Scan(&day, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan daily flow: %w", err)
+		}
+		flows = append(flows, amount)
+	}
+	return flows, rows.Err()
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/pkg/ttlstore"
+)
+
+// TTLStoreRepository is a ttlstore.Store in PostgreSQL, shared by all
+// instances and kept across restarts.
+type TTLStoreRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTTLStoreRepository creates a new TTLStoreRepository instance.
+func NewTTLStoreRepository(pool *pgxpool.Pool) *TTLStoreRepository {
+	return &TTLStoreRepository{pool: pool}
+}
+
+// Set stores value under key until expiresAt, replacing any previous value.
+func (r *TTLStoreRepository) Set(ctx context.Context, key, value string, expiresAt time.Time) error {
+	const query = `
+		INSERT INTO ttl_store (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+	`
+	if _, err := r.pool.Exec(ctx, query, key, value, expiresAt); err != nil {
+		return fmt.Errorf("failed to set ttl key: %w", err)
+	}
+	return nil
+}
+
+// SetNX stores value under key until expiresAt unless a live key is present,
+// and reports whether it did. An expired key is replaced.
+func (r *TTLStoreRepository) SetNX(ctx context.Context, key, value string, expiresAt time.Time) (bool, error) {
+	const query = `
+		INSERT INTO ttl_store (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+		WHERE ttl_store.expires_at <= NOW()
+		RETURNING key
+	`
+	var stored string
+	err := r.pool.QueryRow(ctx, query, key, value, expiresAt).Scan(&stored)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim ttl key: %w", err)
+	}
+	return true, nil
+}
+
+// Get returns the item of key; ok is false if it is absent or expired.
+func (r *TTLStoreRepository) Get(ctx context.Context, key string) (ttlstore.Item, bool, error) {
+	const query = `
+		SELECT key, value, expires_at FROM ttl_store
+		WHERE key = $1 AND expires_at > NOW()
+	`
+	var item ttlstore.Item
+	err := r.pool.QueryRow(ctx, query, key).Scan(&item.Key, &item.Value, &item.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ttlstore.Item{}, false, nil
+	}
+	if err != nil {
+		return ttlstore.Item{}, false, fmt.Errorf("failed to get ttl key: %w", err)
+	}
+	return item, true, nil
+}
+
+// Scan returns the live items whose key starts with prefix.
+func (r *TTLStoreRepository) Scan(ctx context.Context, prefix string) ([]ttlstore.Item, error) {
+	const query = `
+		SELECT key, value, expires_at FROM ttl_store
+		WHERE key LIKE $1 AND expires_at > NOW()
+	`
+	rows, err := r.pool.Query(ctx, query, likePrefix(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan ttl keys: %w", err)
+	}
+	defer rows.Close()
+
+	var items []ttlstore.Item
+	for rows.Next() {
+		var item ttlstore.Item
+		if err := rows.Scan(&item.Key, &item.Value, &item.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ttl key: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ttl keys: %w", err)
+	}
+	return items, nil
+}
+
+// DeleteExpired removes expired keys and returns how many there were.
+func (r *TTLStoreRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM ttl_store WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired ttl keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// likePrefix returns a LIKE pattern matching keys that start with prefix
+func likePrefix(prefix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	return escaped + "%"
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"telegram-game-bot/internal/model"
@@ -15,9 +16,13 @@ import (
 
 // Common errors for repository operations.
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInsufficientBalance = errors.New("insufficient balance")
 )
 
+// maxHandleAttempts is how often Create retries with a new handle on a handle collision
+const maxHandleAttempts = 5
+
 // UserRepository handles user data persistence.
 // Requirements: 1.1, 1.3, 1.5 - User account management
 type UserRepository struct {
@@ -33,23 +38,76 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 // The user is created with the default initial balance (1000 coins).
 // Requirements: 1.1 - Create account with 1000 initial coins
 func (r *UserRepository) Create(ctx context.Context, telegramID int64, username string) (*model.User, error) {
+	// The starting balance is booked as an initial transaction so the
+	// treasury records it as issued
 	const query = `
-		INSERT INTO users (telegram_id, username, balance, last_daily_claim, created_at, updated_at)
-		VALUES ($1, $2, 1000, 0, NOW(), NOW())
-		RETURNING telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		WITH created AS (
+			INSERT INTO users (telegram_id, username, balance, last_daily_claim, handle, created_at, updated_at)
+			VALUES ($1, $2, 1000, 0, $3, NOW(), NOW())
+			RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+		), initial AS (
+			INSERT INTO transactions (user_id, amount, type, created_at)
+			SELECT telegram_id, balance, $4, NOW() FROM created
+		)
+		SELECT telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+		FROM created
 	`
 
 	var user model.User
-	err := r.pool.QueryRow(ctx, query, telegramID, username).Scan(
+	var err error
+	for attempt := 0; attempt < maxHandleAttempts; attempt++ {
+		err = r.pool.QueryRow(ctx, query, telegramID, username, model.NewHandle(), model.TxTypeInitial).Scan(
+			&user.TelegramID,
+			&user.Username,
+			&user.Balance,
+			&user.LastDailyClaim,
+			&user.HideFromLeaderboard,
+			&user.Handle,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if !isHandleConflict(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// isHandleConflict reports whether err is a unique violation of the users.handle index
+func isHandleConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_users_handle"
+}
+
+// GetByHandle retrieves a user by their public handle (e.g. "#A1B2C3").
+// Returns ErrUserNotFound if no user has the handle.
+func (r *UserRepository) GetByHandle(ctx context.Context, handle string) (*model.User, error) {
+	const query = `
+		SELECT telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
+		FROM users
+		WHERE handle = $1
+	`
+
+	var user model.User
+	err := r.pool.QueryRow(ctx, query, handle).Scan(
 		&user.TelegramID,
 		&user.Username,
 		&user.Balance,
 		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by handle: %w", err)
 	}
 
 	return &user, nil
@@ -60,7 +118,7 @@ func (r *UserRepository) Create(ctx context.Context, telegramID int64, username
 // Returns ErrUserNotFound if the user does not exist.
 func (r *UserRepository) GetByID(ctx context.Context, telegramID int64) (*model.User, error) {
 	const query = `
-		SELECT telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		SELECT telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
 		FROM users
 		WHERE telegram_id = $1
 	`
@@ -71,6 +129,8 @@ func (r *UserRepository) GetByID(ctx context.Context, telegramID int64) (*model.
 		&user.Username,
 		&user.Balance,
 		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -113,29 +173,54 @@ func (r *UserRepository) GetOrCreate(ctx context.Context, telegramID int64, user
 
 // UpdateBalance updates a user's balance by adding the specified amount.
 // The amount can be negative to subtract from the balance.
-// Returns the updated user.
+// Returns the updated user, or ErrInsufficientBalance if the balance does not
+// cover a negative amount.
 func (r *UserRepository) UpdateBalance(ctx context.Context, telegramID int64, amount int64) (*model.User, error) {
+	return updateBalance(ctx, r.pool, telegramID, amount)
+}
+
+// rowQuerier runs a query returning one row, on the pool or within a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// updateBalance adds amount to a user's balance. A debit is applied only if
+// the balance covers it, checked by the update itself so that concurrent
+// debits, e.g. from instances that do not share a user lock, cannot overdraw.
+func updateBalance(ctx context.Context, q rowQuerier, telegramID int64, amount int64) (*model.User, error) {
 	const query = `
 		UPDATE users
 		SET balance = balance + $2, updated_at = NOW()
-		WHERE telegram_id = $1
-		RETURNING telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		WHERE telegram_id = $1 AND ($2 >= 0 OR balance + $2 >= 0)
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
 	`
 
 	var user model.User
-	err := r.pool.QueryRow(ctx, query, telegramID, amount).Scan(
+	err := q.QueryRow(ctx, query, telegramID, amount).Scan(
 		&user.TelegramID,
 		&user.Username,
 		&user.Balance,
 		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to update balance: %w", err)
+		}
+		if amount >= 0 {
+			return nil, ErrUserNotFound
+		}
+		var exists bool
+		if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE telegram_id = $1)`, telegramID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if !exists {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to update balance: %w", err)
+		return nil, ErrInsufficientBalance
 	}
 
 	return &user, nil
@@ -148,7 +233,7 @@ func (r *UserRepository) SetBalance(ctx context.Context, telegramID int64, balan
 		UPDATE users
 		SET balance = $2, updated_at = NOW()
 		WHERE telegram_id = $1
-		RETURNING telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
 	`
 
 	var user model.User
@@ -157,6 +242,8 @@ func (r *UserRepository) SetBalance(ctx context.Context, telegramID int64, balan
 		&user.Username,
 		&user.Balance,
 		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -171,12 +258,13 @@ func (r *UserRepository) SetBalance(ctx context.Context, telegramID int64, balan
 }
 
 
-// GetTopUsers retrieves the top N users by balance.
+// GetTopUsers retrieves the top N users by balance, skipping archived users.
 // Requirements: 1.5 - Display top 10 users by balance
 func (r *UserRepository) GetTopUsers(ctx context.Context, limit int) ([]*model.User, error) {
 	const query = `
-		SELECT telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		SELECT telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
 		FROM users
+		WHERE archived_at IS NULL
 		ORDER BY balance DESC
 		LIMIT $1
 	`
@@ -195,6 +283,8 @@ func (r *UserRepository) GetTopUsers(ctx context.Context, limit int) ([]*model.U
 			&user.Username,
 			&user.Balance,
 			&user.LastDailyClaim,
+			&user.HideFromLeaderboard,
+			&user.Handle,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -218,7 +308,7 @@ func (r *UserRepository) UpdateDailyClaim(ctx context.Context, telegramID int64,
 		UPDATE users
 		SET last_daily_claim = $2, updated_at = NOW()
 		WHERE telegram_id = $1
-		RETURNING telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		RETURNING telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
 	`
 
 	var user model.User
@@ -227,6 +317,8 @@ func (r *UserRepository) UpdateDailyClaim(ctx context.Context, telegramID int64,
 		&user.Username,
 		&user.Balance,
 		&user.LastDailyClaim,
+		&user.HideFromLeaderboard,
+		&user.Handle,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -269,6 +361,190 @@ func (r *UserRepository) CanClaimDaily(ctx context.Context, telegramID int64, co
 	return false, remaining, nil
 }
 
+// CanFreeSpin checks if a user can take their daily free slot spin.
+// Tracked like the daily claim: a unix timestamp of the last spin plus a cooldown.
+func (r *UserRepository) CanFreeSpin(ctx context.Context, telegramID int64, cooldownHours int) (bool, time.Duration, error) {
+	const query = `SELECT last_free_spin FROM users WHERE telegram_id = $1`
+
+	var lastSpin int64
+	err := r.pool.QueryRow(ctx, query, telegramID).Scan(&lastSpin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, 0, ErrUserNotFound
+		}
+		return false, 0, fmt.Errorf("failed to get last free spin: %w", err)
+	}
+
+	if lastSpin == 0 {
+		return true, 0, nil
+	}
+
+	nextSpinTime := time.Unix(lastSpin, 0).Add(time.Duration(cooldownHours) * time.Hour)
+	now := time.Now()
+	if !now.Before(nextSpinTime) {
+		return true, 0, nil
+	}
+
+	return false, nextSpinTime.Sub(now), nil
+}
+
+// UpdateFreeSpin updates the user's last free spin timestamp.
+func (r *UserRepository) UpdateFreeSpin(ctx context.Context, telegramID int64, spinTime int64) error {
+	const query = `
+		UPDATE users
+		SET last_free_spin = $2, updated_at = NOW()
+		WHERE telegram_id = $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, telegramID, spinTime)
+	if err != nil {
+		return fmt.Errorf("failed to update free spin: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// CanExport checks if a user can request a data export.
+// Tracked like the free spin: a unix timestamp of the last export plus a cooldown.
+func (r *UserRepository) CanExport(ctx context.Context, telegramID int64, cooldown time.Duration) (bool, time.Duration, error) {
+	const query = `SELECT last_export FROM users WHERE telegram_id = $1`
+
+	var lastExport int64
+	err := r.pool.QueryRow(ctx, query, telegramID).Scan(&lastExport)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, 0, ErrUserNotFound
+		}
+		return false, 0, fmt.Errorf("failed to get last export: %w", err)
+	}
+
+	if lastExport == 0 {
+		return true, 0, nil
+	}
+
+	nextExportTime := time.Unix(lastExport, 0).Add(cooldown)
+	now := time.Now()
+	if !now.Before(nextExportTime) {
+		return true, 0, nil
+	}
+
+	return false, nextExportTime.Sub(now), nil
+}
+
+// ClaimExport records an export at exportTime unless the user already exported
+// within the cooldown. Returns false if another export got there first.
+func (r *UserRepository) ClaimExport(ctx context.Context, telegramID int64, exportTime int64, cooldown time.Duration) (bool, error) {
+	const query = `
+		UPDATE users
+		SET last_export = $2, updated_at = NOW()
+		WHERE telegram_id = $1 AND last_export <= $3
+	`
+
+	result, err := r.pool.Exec(ctx, query, telegramID, exportTime, exportTime-int64(cooldown/time.Second))
+	if err != nil {
+		return false, fmt.Errorf("failed to update last export: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// GetDefaultStake returns the stake used when a user plays without an amount, 0 if unset.
+func (r *UserRepository) GetDefaultStake(ctx context.Context, telegramID int64) (int64, error) {
+	const query = `SELECT default_stake FROM users WHERE telegram_id = $1`
+
+	var stake int64
+	err := r.pool.QueryRow(ctx, query, telegramID).Scan(&stake)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to get default stake: %w", err)
+	}
+	return stake, nil
+}
+
+// SetDefaultStake sets the stake used when a user plays without an amount (0 to unset).
+func (r *UserRepository) SetDefaultStake(ctx context.Context, telegramID int64, stake int64) error {
+	const query = `
+		UPDATE users
+		SET default_stake = $2, updated_at = NOW()
+		WHERE telegram_id = $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, telegramID, stake)
+	if err != nil {
+		return fmt.Errorf("failed to update default stake: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetSelfExclusion returns the time a user excluded themselves from gambling
+// until, nil if never excluded.
+func (r *UserRepository) GetSelfExclusion(ctx context.Context, telegramID int64) (*time.Time, error) {
+	const query = `SELECT self_excluded_until FROM users WHERE telegram_id = $1`
+
+	var until *time.Time
+	err := r.pool.QueryRow(ctx, query, telegramID).Scan(&until)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get self exclusion: %w", err)
+	}
+	return until, nil
+}
+
+// ExtendSelfExclusion excludes a user from gambling until the given time. An
+// exclusion is never shortened: the later of the current and the new end is
+// kept and returned.
+func (r *UserRepository) ExtendSelfExclusion(ctx context.Context, telegramID int64, until time.Time) (time.Time, error) {
+	const query = `
+		UPDATE users
+		SET self_excluded_until = GREATEST(COALESCE(self_excluded_until, $2), $2), updated_at = NOW()
+		WHERE telegram_id = $1
+		RETURNING self_excluded_until
+	`
+
+	var effective time.Time
+	err := r.pool.QueryRow(ctx, query, telegramID, until).Scan(&effective)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, ErrUserNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to update self exclusion: %w", err)
+	}
+	return effective, nil
+}
+
+// SetLeaderboardHidden sets whether the user is shown anonymously on public leaderboards.
+func (r *UserRepository) SetLeaderboardHidden(ctx context.Context, telegramID int64, hidden bool) error {
+	const query = `
+		UPDATE users
+		SET hide_from_leaderboard = $2, updated_at = NOW()
+		WHERE telegram_id = $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, telegramID, hidden)
+	if err != nil {
+		return fmt.Errorf("failed to update leaderboard privacy: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
 // UpdateUsername updates a user's username.
 // This is useful when a user changes their Telegram username.
 func (r *UserRepository) UpdateUsername(ctx context.Context, telegramID int64, username string) error {
@@ -306,7 +582,7 @@ func (r *UserRepository) Exists(ctx context.Context, telegramID int64) (bool, er
 // GetAllUsers retrieves all users from the database.
 func (r *UserRepository) GetAllUsers(ctx context.Context) ([]*model.User, error) {
 	const query = `
-		SELECT telegram_id, username, balance, last_daily_claim, created_at, updated_at
+		SELECT telegram_id, username, balance, last_daily_claim, hide_from_leaderboard, handle, created_at, updated_at
 		FROM users
 	`
 
@@ -324,6 +600,8 @@ func (r *UserRepository) GetAllUsers(ctx context.Context) ([]*model.User, error)
 			&user.Username,
 			&user.Balance,
 			&user.LastDailyClaim,
+			&user.HideFromLeaderboard,
+			&user.Handle,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -340,18 +618,81 @@ func (r *UserRepository) GetAllUsers(ctx context.Context) ([]*model.User, error)
 	return users, nil
 }
 
-// AddBalanceToAllUsers adds the specified amount to all users' balances.
+// AddBalanceToAllUsers adds the specified amount to all users' balances and
+// records an admin_add transaction for each of them.
 // Returns the number of users updated.
-func (r *UserRepository) AddBalanceToAllUsers(ctx context.Context, amount int64) (int64, error) {
+func (r *UserRepository) AddBalanceToAllUsers(ctx context.Context, amount int64, description *string) (int64, error) {
 	const query = `
-		UPDATE users
-		SET balance = balance + $1, updated_at = NOW()
+		WITH updated AS (
+			UPDATE users
+			SET balance = balance + $1, updated_at = NOW()
+			RETURNING telegram_id
+		)
+		INSERT INTO transactions (user_id, amount, type, description, created_at)
+		SELECT telegram_id, $1, $2, $3, NOW() FROM updated
 	`
 
-	result, err := r.pool.Exec(ctx, query, amount)
+	result, err := r.pool.Exec(ctx, query, amount, model.TxTypeAdminAdd, description)
 	if err != nil {
 		return 0, fmt.Errorf("failed to add balance to all users: %w", err)
 	}
 
 	return result.RowsAffected(), nil
 }
+
+// TouchActivity records an interaction of a user and restores the user if
+// archived. Returns true if the user was archived.
+func (r *UserRepository) TouchActivity(ctx context.Context, telegramID int64) (bool, error) {
+	const query = `
+		UPDATE users u
+		SET last_active_at = NOW(), archived_at = NULL
+		FROM (SELECT telegram_id, archived_at FROM users WHERE telegram_id = $1 FOR UPDATE) old
+		WHERE u.telegram_id = old.telegram_id
+		RETURNING old.archived_at IS NOT NULL
+	`
+
+	var restored bool
+	err := r.pool.QueryRow(ctx, query, telegramID).Scan(&restored)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to touch user activity: %w", err)
+	}
+
+	return restored, nil
+}
+
+// ArchiveInactive archives all users without an interaction since before.
+// Users from before activity tracking count their last update instead.
+// Returns the number of users archived.
+func (r *UserRepository) ArchiveInactive(ctx context.Context, before time.Time) (int64, error) {
+	const query = `
+		UPDATE users
+		SET archived_at = NOW()
+		WHERE archived_at IS NULL AND COALESCE(last_active_at, updated_at) < $1
+	`
+
+	result, err := r.pool.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive inactive users: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// IsArchived reports whether a user is archived.
+func (r *UserRepository) IsArchived(ctx context.Context, telegramID int64) (bool, error) {
+	const query = `SELECT archived_at IS NOT NULL FROM users WHERE telegram_id = $1`
+
+	var archived bool
+	err := r.pool.QueryRow(ctx, query, telegramID).Scan(&archived)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrUserNotFound
+		}
+		return false, fmt.Errorf("failed to check user archival: %w", err)
+	}
+
+	return archived, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// userDailyWatermark names the watermark of user_daily_aggregates
+const userDailyWatermark = "user_daily"
+
+// dayLayout formats days for DATE columns, so the day does not depend on the
+// timezone of the database session
+const dayLayout = "2006-01-02"
+
+// UserAggregateRepository handles the per user daily profit aggregates.
+// Days are given as any time on the day in the bot timezone.
+type UserAggregateRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserAggregateRepository creates a new UserAggregateRepository instance.
+func NewUserAggregateRepository(pool *pgxpool.Pool) *UserAggregateRepository {
+	return &UserAggregateRepository{pool: pool}
+}
+
+// AggregatedThrough returns the last aggregated day as a date in UTC, false if
+// nothing was aggregated yet.
+func (r *UserAggregateRepository) AggregatedThrough(ctx context.Context) (time.Time, bool, error) {
+	var day time.Time
+	err := r.pool.QueryRow(ctx, `SELECT through_day FROM aggregate_watermarks WHERE name = $1`, userDailyWatermark).Scan(&day)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get aggregate watermark: %w", err)
+	}
+	return day, true, nil
+}
+
+// AggregateDay stores the game profit of every user who played on the day
+// from start (inclusive) to end (exclusive) and moves the watermark to the
+// day, in one transaction. Aggregating a day again replaces its rows.
+func (r *UserAggregateRepository) AggregateDay(ctx context.Context, start, end time.Time) error {
+	day := start.Format(dayLayout)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const aggregateQuery = `
+		INSERT INTO user_daily_aggregates (user_id, day, net_profit, games)
+		SELECT user_id, $1::date, SUM(amount), COUNT(*)
+		FROM transactions
+		WHERE type = ANY($4) AND created_at >= $2 AND created_at < $3
+		GROUP BY user_id
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET net_profit = EXCLUDED.net_profit, games = EXCLUDED.games
+	`
+	if _, err := tx.Exec(ctx, aggregateQuery, day, start, end, model.GameTransactionTypes()); err != nil {
+		return fmt.Errorf("failed to aggregate day: %w", err)
+	}
+
+	const watermarkQuery = `
+		INSERT INTO aggregate_watermarks (name, through_day, updated_at)
+		VALUES ($1, $2::date, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET through_day = GREATEST(aggregate_watermarks.through_day, EXCLUDED.through_day), updated_at = NOW()
+	`
+	if _, err := tx.Exec(ctx, watermarkQuery, userDailyWatermark, day); err != nil {
+		return fmt.Errorf("failed to move aggregate watermark: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit aggregation: %w", err)
+	}
+	return nil
+}
+
+// GetUserProfit sums the aggregated game profit of a user from one day to
+// another (inclusive).
+func (r *UserAggregateRepository) GetUserProfit(ctx context.Context, userID int64, from, to time.Time) (int64, error) {
+	const query = `
+		SELECT COALESCE(SUM(net_profit), 0)
+		FROM user_daily_aggregates
+		WHERE user_id = $1 AND day BETWEEN $2::date AND $3::date
+	`
+	var profit int64
+	if err := r.pool.QueryRow(ctx, query, userID, from.Format(dayLayout), to.Format(dayLayout)).Scan(&profit); err != nil {
+		return 0, fmt.Errorf("failed to get aggregated profit: %w", err)
+	}
+	return profit, nil
+}
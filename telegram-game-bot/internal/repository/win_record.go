@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"telegram-game-bot/internal/model"
+)
+
+// WinRecordRepository handles the biggest single wins per chat, game and period.
+type WinRecordRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewWinRecordRepository creates a new WinRecordRepository instance.
+func NewWinRecordRepository(pool *pgxpool.Pool) *WinRecordRepository {
+	return &WinRecordRepository{pool: pool}
+}
+
+// Beat stores a win as the record of its chat, game and period if it is
+// bigger than the current record. Returns whether the win became the record
+// and the record it replaced (nil if the period had none).
+func (r *WinRecordRepository) Beat(ctx context.Context, rec *model.WinRecord) (bool, *model.WinRecord, error) {
+	const query = `
+		WITH prev AS (
+			SELECT user_id, name, amount, set_at
+			FROM win_records
+			WHERE chat_id = $1 AND game = $2 AND period = $3 AND period_start = $4
+		)
+		INSERT INTO win_records (chat_id, game, period, period_start, user_id, name, amount, set_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (chat_id, game, period, period_start) DO UPDATE
+		SET user_id = EXCLUDED.user_id, name = EXCLUDED.name, amount = EXCLUDED.amount, set_at = EXCLUDED.set_at
+		WHERE win_records.amount < EXCLUDED.amount
+		RETURNING (SELECT user_id FROM prev), (SELECT name FROM prev), (SELECT amount FROM prev), (SELECT set_at FROM prev)
+	`
+
+	var prevUserID, prevAmount *int64
+	var prevName *string
+	var prevSetAt *time.Time
+	err := r.pool.QueryRow(ctx, query, rec.ChatID, rec.Game, rec.Period, rec.PeriodStart, rec.UserID, rec.Name, rec.Amount).
+		Scan(&prevUserID, &prevName, &prevAmount, &prevSetAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil, nil
+		}
+		return false, nil, fmt.Errorf("failed to store win record: %w", err)
+	}
+	if prevUserID == nil {
+		return true, nil, nil
+	}
+
+	prev := *rec
+	prev.UserID = *prevUserID
+	prev.Name = *prevName
+	prev.Amount = *prevAmount
+	prev.SetAt = *prevSetAt
+	return true, &prev, nil
+}
+
+// List returns the records of a chat for the day starting at dayStart and
+// the week starting at weekStart, ordered by game and period.
+func (r *WinRecordRepository) List(ctx context.Context, chatID int64, dayStart, weekStart time.Time) ([]model.WinRecord, error) {
+	const query = `
+		SELECT chat_id, game, period, period_start, user_id, name, amount, set_at
+		FROM win_records
+		WHERE chat_id = $1
+		  AND ((period = 'day' AND period_start = $2) OR (period = 'week' AND period_start = $3))
+		ORDER BY game, period
+	`
+
+	rows, err := r.pool.Query(ctx, query, chatID, dayStart, weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list win records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []model.WinRecord
+	for rows.Next() {
+		var rec model.WinRecord
+		if err := rows.Scan(&rec.ChatID, &rec.Game, &rec.Period, &rec.PeriodStart, &rec.UserID, &rec.Name, &rec.Amount, &rec.SetAt); err != nil {
+			return nil, fmt.Errorf("failed to scan win record: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
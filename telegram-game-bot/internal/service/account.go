@@ -8,12 +8,14 @@ import (
 	"time"
 
 	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
 	"telegram-game-bot/internal/repository"
 )
 
 // Common errors for account operations.
 var (
 	ErrDailyAlreadyClaimed = errors.New("daily reward already claimed")
+	ErrInvalidHandle       = errors.New("invalid handle")
 )
 
 // AccountService handles user account operations.
@@ -23,6 +25,14 @@ type AccountService struct {
 	txRepo      *repository.TransactionRepository
 	dailyReward int64
 	cooldownHrs int
+	sandbox     *SandboxService                   // Optional: play money of sandbox chats
+	rewards     DailyRewarder                     // Optional: dynamic daily reward replacing dailyReward
+	idempotency *repository.IdempotencyRepository // Optional: keys of retried balance changes
+}
+
+// DailyRewarder decides the daily reward, e.g. from recent inflation.
+type DailyRewarder interface {
+	Reward() int64
 }
 
 // NewAccountService creates a new AccountService instance.
@@ -76,13 +86,73 @@ func (s *AccountService) GetUser(ctx context.Context, telegramID int64) (*model.
 	return s.userRepo.GetByID(ctx, telegramID)
 }
 
+// GetUserByHandle retrieves a user by their public handle, e.g. "#A1B2C3" (case insensitive).
+// Returns ErrInvalidHandle for malformed input and ErrUserNotFound if nobody has the handle.
+func (s *AccountService) GetUserByHandle(ctx context.Context, input string) (*model.User, error) {
+	handle, ok := model.ParseHandle(input)
+	if !ok {
+		return nil, ErrInvalidHandle
+	}
+	user, err := s.userRepo.GetByHandle(ctx, handle)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
 // UpdateBalance updates a user's balance by adding the specified amount.
 // The amount can be negative to subtract from the balance.
 // Also records a transaction for the balance change.
-func (s *AccountService) UpdateBalance(ctx context.Context, telegramID int64, amount int64, txType string, description *string) (*model.User, error) {
+// A non-empty idemKey applies the change and records its transaction in one
+// database transaction with the key, so it is applied once: retrying it with
+// the same key returns the user with the balance of the original change.
+func (s *AccountService) UpdateBalance(ctx context.Context, telegramID int64, amount int64, txType string, description *string, idemKey string) (*model.User, error) {
+	if idemKey == "" {
+		return s.updateBalance(ctx, telegramID, amount, txType, description)
+	}
+
+	if s.idempotency == nil {
+		return nil, ErrIdempotencyUnavailable
+	}
+
+	fingerprint := idempotencyFingerprint(telegramID, amount, txType)
+	record, user, err := s.idempotency.ApplyBalance(ctx, idemKey, idempotencyOpUpdateBalance, fingerprint, telegramID, amount, txType, description)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInsufficientBalance):
+			return nil, ErrInsufficientBalance
+		case errors.Is(err, repository.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update balance: %w", err)
+	}
+	if record == nil {
+		return user, nil
+	}
+	if err := checkReplay(record, idempotencyOpUpdateBalance, fingerprint); err != nil {
+		return nil, err
+	}
+
+	// Replayed: answer with the balance of the original change
+	current, err := s.userRepo.GetByID(ctx, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	current.Balance = record.Balance
+	return current, nil
+}
+
+// updateBalance adds amount to a user's balance and records the transaction.
+func (s *AccountService) updateBalance(ctx context.Context, telegramID int64, amount int64, txType string, description *string) (*model.User, error) {
 	// Update the balance
 	user, err := s.userRepo.UpdateBalance(ctx, telegramID, amount)
 	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, ErrInsufficientBalance
+		}
 		return nil, fmt.Errorf("failed to update balance: %w", err)
 	}
 
@@ -96,6 +166,51 @@ func (s *AccountService) UpdateBalance(ctx context.Context, telegramID int64, am
 	return user, nil
 }
 
+// SetDailyRewarder sets the source of a dynamic daily reward.
+func (s *AccountService) SetDailyRewarder(rewards DailyRewarder) {
+	s.rewards = rewards
+}
+
+// SetIdempotency sets the repository of idempotency keys, enabling the
+// idemKey of UpdateBalance.
+func (s *AccountService) SetIdempotency(repo *repository.IdempotencyRepository) {
+	s.idempotency = repo
+}
+
+// SetSandbox sets the service holding the play money of sandbox chats.
+func (s *AccountService) SetSandbox(sandbox *SandboxService) {
+	s.sandbox = sandbox
+}
+
+// GetBalanceIn retrieves a user's balance in a scope: the real balance or
+// the play money of a sandbox chat.
+func (s *AccountService) GetBalanceIn(ctx context.Context, scope model.BalanceScope, telegramID int64) (int64, error) {
+	if !scope.IsSandbox() {
+		return s.GetBalance(ctx, telegramID)
+	}
+	if s.sandbox == nil {
+		return 0, ErrSandboxUnavailable
+	}
+	return s.sandbox.Balance(ctx, scope.SandboxChatID, telegramID)
+}
+
+// UpdateBalanceIn adds amount to a user's balance in a scope and returns the
+// new balance. Play money changes record no transaction, publish no balance
+// events and never reach the ledger.
+func (s *AccountService) UpdateBalanceIn(ctx context.Context, scope model.BalanceScope, telegramID int64, amount int64, txType string, description *string) (int64, error) {
+	if !scope.IsSandbox() {
+		user, err := s.UpdateBalance(ctx, telegramID, amount, txType, description, "")
+		if err != nil {
+			return 0, err
+		}
+		return user.Balance, nil
+	}
+	if s.sandbox == nil {
+		return 0, ErrSandboxUnavailable
+	}
+	return s.sandbox.AddBalance(ctx, scope.SandboxChatID, telegramID, amount)
+}
+
 
 // ClaimDaily attempts to claim the daily reward for a user.
 // Returns:
@@ -111,16 +226,16 @@ func (s *AccountService) ClaimDaily(ctx context.Context, telegramID int64) (bool
 	}
 
 	if !canClaim {
-		// Format remaining time
-		hours := int(remaining.Hours())
-		minutes := int(remaining.Minutes()) % 60
-		seconds := int(remaining.Seconds()) % 60
-		msg := fmt.Sprintf("请等待 %d小时%d分%d秒 后再领取", hours, minutes, seconds)
-		return false, msg, nil
+		return false, "请等待 " + cooldown.Format(remaining) + " 后再领取", nil
+	}
+
+	reward := s.dailyReward
+	if s.rewards != nil {
+		reward = s.rewards.Reward()
 	}
 
 	// Update balance with daily reward
-	_, err = s.userRepo.UpdateBalance(ctx, telegramID, s.dailyReward)
+	_, err = s.userRepo.UpdateBalance(ctx, telegramID, reward)
 	if err != nil {
 		return false, "", fmt.Errorf("failed to add daily reward: %w", err)
 	}
@@ -134,12 +249,12 @@ func (s *AccountService) ClaimDaily(ctx context.Context, telegramID int64) (bool
 
 	// Record transaction
 	desc := "每日签到奖励"
-	_, err = s.txRepo.Create(ctx, telegramID, s.dailyReward, model.TxTypeDaily, &desc)
+	_, err = s.txRepo.Create(ctx, telegramID, reward, model.TxTypeDaily, &desc)
 	if err != nil {
 		// Non-fatal, balance was already updated
 	}
 
-	msg := fmt.Sprintf("签到成功！获得 %d 金币", s.dailyReward)
+	msg := fmt.Sprintf("签到成功！获得 %d 金币", reward)
 	return true, msg, nil
 }
 
@@ -149,6 +264,36 @@ func (s *AccountService) CanClaimDaily(ctx context.Context, telegramID int64) (b
 	return s.userRepo.CanClaimDaily(ctx, telegramID, s.cooldownHrs)
 }
 
+// CanFreeSpin checks if a user can take their daily free slot spin.
+// Returns eligibility status and remaining time if not eligible.
+func (s *AccountService) CanFreeSpin(ctx context.Context, telegramID int64, cooldownHours int) (bool, time.Duration, error) {
+	return s.userRepo.CanFreeSpin(ctx, telegramID, cooldownHours)
+}
+
+// MarkFreeSpin records that the user has taken their free spin now.
+func (s *AccountService) MarkFreeSpin(ctx context.Context, telegramID int64) error {
+	return s.userRepo.UpdateFreeSpin(ctx, telegramID, time.Now().Unix())
+}
+
+// GetDefaultStake returns the stake used when the user plays without an amount, 0 if unset.
+func (s *AccountService) GetDefaultStake(ctx context.Context, telegramID int64) (int64, error) {
+	return s.userRepo.GetDefaultStake(ctx, telegramID)
+}
+
+// SetDefaultStake sets the stake used when the user plays without an amount (0 to unset).
+func (s *AccountService) SetDefaultStake(ctx context.Context, telegramID int64, stake int64) error {
+	if stake < 0 {
+		return ErrInvalidAmount
+	}
+	return s.userRepo.SetDefaultStake(ctx, telegramID, stake)
+}
+
+// SetLeaderboardHidden sets the user's leaderboard privacy flag.
+// Hidden users are shown as "匿名玩家" on public leaderboards.
+func (s *AccountService) SetLeaderboardHidden(ctx context.Context, telegramID int64, hidden bool) error {
+	return s.userRepo.SetLeaderboardHidden(ctx, telegramID, hidden)
+}
+
 // GetTopUsers retrieves the top users by balance.
 // Requirements: 1.5 - Display top 10 users by balance on /top
 func (s *AccountService) GetTopUsers(ctx context.Context, limit int) ([]*model.User, error) {
@@ -157,6 +302,6 @@ func (s *AccountService) GetTopUsers(ctx context.Context, limit int) ([]*model.U
 
 // AddBalanceToAllUsers adds the specified amount to all users' balances.
 // Returns the number of users updated.
-func (s *AccountService) AddBalanceToAllUsers(ctx context.Context, amount int64) (int64, error) {
-	return s.userRepo.AddBalanceToAllUsers(ctx, amount)
+func (s *AccountService) AddBalanceToAllUsers(ctx context.Context, amount int64, description *string) (int64, error) {
+	return s.userRepo.AddBalanceToAllUsers(ctx, amount, description)
 }
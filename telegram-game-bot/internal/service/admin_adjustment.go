@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"telegram-game-bot/internal/model"
+)
+
+// AdminAdjustmentTTL is how long a previewed balance adjustment waits for confirmation.
+const AdminAdjustmentTTL = 5 * time.Minute
+
+// Admin adjustment errors
+var (
+	ErrAdjustmentAmount   = errors.New("金额必须大于 0")
+	ErrAdjustmentNegative = errors.New("余额不能为负数")
+	ErrAdjustmentReason   = errors.New("请填写调整原因")
+)
+
+// AdminAdjustment is a balance adjustment typed by an admin, previewed
+// before it is executed. Op is one of the admin transaction types: the
+// amount is added, subtracted or becomes the new balance.
+type AdminAdjustment struct {
+	Op        string
+	AdminID   int64
+	TargetID  int64
+	Amount    int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Validate checks the amount and reason of an adjustment.
+func (a AdminAdjustment) Validate() error {
+	switch {
+	case a.Op == model.TxTypeAdminSet && a.Amount < 0:
+		return ErrAdjustmentNegative
+	case a.Op != model.TxTypeAdminSet && a.Amount <= 0:
+		return ErrAdjustmentAmount
+	case a.Reason == "":
+		return ErrAdjustmentReason
+	}
+	return nil
+}
+
+// Delta returns the balance change the adjustment makes to current.
+func (a AdminAdjustment) Delta(current int64) int64 {
+	switch a.Op {
+	case model.TxTypeAdminSub:
+		return -a.Amount
+	case model.TxTypeAdminSet:
+		return a.Amount - current
+	}
+	return a.Amount
+}
+
+// NewBalance returns the balance after applying the adjustment to current.
+func (a AdminAdjustment) NewBalance(current int64) int64 {
+	return current + a.Delta(current)
+}
+
+// Expired reports whether the adjustment can no longer be confirmed at now.
+func (a AdminAdjustment) Expired(now time.Time) bool {
+	return now.Sub(a.CreatedAt) >= AdminAdjustmentTTL
+}
@@ -0,0 +1,68 @@
+// Package service provides business logic implementations.
+// Property-based tests for previewed admin balance adjustments.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestAdminAdjustmentNewBalanceProperty tests that adding, subtracting and
+// setting move the balance as previewed.
+func TestAdminAdjustmentNewBalanceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		current := rapid.Int64Range(0, 1_000_000_000).Draw(t, "current")
+		amount := rapid.Int64Range(0, 1_000_000_000).Draw(t, "amount")
+		op := rapid.SampledFrom([]string{model.TxTypeAdminAdd, model.TxTypeAdminSub, model.TxTypeAdminSet}).Draw(t, "op")
+
+		a := AdminAdjustment{Op: op, Amount: amount}
+		want := map[string]int64{
+			model.TxTypeAdminAdd: current + amount,
+			model.TxTypeAdminSub: current - amount,
+			model.TxTypeAdminSet: amount,
+		}[op]
+		if got := a.NewBalance(current); got != want {
+			t.Fatalf("%s %d on %d: got %d, want %d", op, amount, current, got, want)
+		}
+		if current+a.Delta(current) != a.NewBalance(current) {
+			t.Fatalf("Delta and new balance disagree")
+		}
+	})
+}
+
+// TestAdminAdjustmentValidateProperty tests that a reason is required and
+// that only a set may use zero.
+func TestAdminAdjustmentValidateProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		op := rapid.SampledFrom([]string{model.TxTypeAdminAdd, model.TxTypeAdminSub, model.TxTypeAdminSet}).Draw(t, "op")
+		amount := rapid.Int64Range(-100, 100).Draw(t, "amount")
+		reason := rapid.SampledFrom([]string{"", "补偿"}).Draw(t, "reason")
+
+		err := AdminAdjustment{Op: op, Amount: amount, Reason: reason}.Validate()
+		least := int64(1)
+		if op == model.TxTypeAdminSet {
+			least = 0
+		}
+		valid := amount >= least && reason != ""
+		if (err == nil) != valid {
+			t.Fatalf("%s %d %q: err %v, want valid %v", op, amount, reason, err, valid)
+		}
+	})
+}
+
+// TestAdminAdjustmentExpiredProperty tests that adjustments expire after the TTL.
+func TestAdminAdjustmentExpiredProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		created := time.Unix(rapid.Int64Range(0, 1<<32).Draw(t, "created"), 0)
+		age := time.Duration(rapid.Int64Range(0, int64(2*AdminAdjustmentTTL)).Draw(t, "age"))
+
+		a := AdminAdjustment{CreatedAt: created}
+		if a.Expired(created.Add(age)) != (age >= AdminAdjustmentTTL) {
+			t.Fatalf("Age %v: expired %v", age, a.Expired(created.Add(age)))
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// archiveTouchInterval is how often the interactions of a user are written,
+// far below any archival threshold
+const archiveTouchInterval = time.Hour
+
+// ArchiveService archives users inactive for a long time so they stop
+// cluttering leaderboards, bailouts and rob targets. Interactions are written
+// at most once per archiveTouchInterval per user, and the first one of an
+// archived user restores the account.
+type ArchiveService struct {
+	userRepo      *repository.UserRepository
+	inactiveAfter time.Duration
+	now           func() time.Time
+
+	mu        sync.Mutex
+	lastTouch map[int64]time.Time // userID -> last written interaction
+	lastSweep time.Time
+}
+
+// NewArchiveService creates a new ArchiveService instance.
+func NewArchiveService(userRepo *repository.UserRepository, inactiveAfter time.Duration) *ArchiveService {
+	return &ArchiveService{
+		userRepo:      userRepo,
+		inactiveAfter: inactiveAfter,
+		now:           time.Now,
+		lastTouch:     make(map[int64]time.Time),
+	}
+}
+
+// RecordInteraction records that a user interacted with the bot, restoring
+// the user if archived.
+func (s *ArchiveService) RecordInteraction(ctx context.Context, userID int64) {
+	if !s.touchDue(userID) {
+		return
+	}
+
+	restored, err := s.userRepo.TouchActivity(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to record user activity")
+		s.mu.Lock()
+		delete(s.lastTouch, userID)
+		s.mu.Unlock()
+		return
+	}
+	if restored {
+		log.Info().
+			Str("operation", "user_restore").
+			Int64("user_id", userID).
+			Msg("Archived user restored")
+	}
+}
+
+// Run archives the users inactive for longer than the configured period.
+func (s *ArchiveService) Run(ctx context.Context, now time.Time) {
+	archived, err := s.userRepo.ArchiveInactive(ctx, now.Add(-s.inactiveAfter))
+	if err != nil {
+		log.Error().Err(err).Str("operation", "user_archive").Msg("Failed to archive inactive users")
+		return
+	}
+	if archived > 0 {
+		log.Info().
+			Str("operation", "user_archive").
+			Int64("archived", archived).
+			Msg("Inactive users archived")
+	}
+}
+
+// touchDue reports whether an interaction of a user must be written and, if
+// so, marks it as written
+func (s *ArchiveService) touchDue(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if last, ok := s.lastTouch[userID]; ok && now.Sub(last) < archiveTouchInterval {
+		return false
+	}
+	s.lastTouch[userID] = now
+
+	// Forget old writes so the map only holds recently active users
+	if now.Sub(s.lastSweep) >= archiveTouchInterval {
+		for id, last := range s.lastTouch {
+			if now.Sub(last) >= archiveTouchInterval {
+				delete(s.lastTouch, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	return true
+}
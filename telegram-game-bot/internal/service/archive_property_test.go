@@ -0,0 +1,36 @@
+// Package service provides business logic implementations.
+// Property-based tests for user archival.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestArchiveTouchDueProperty tests that interactions of a user are written
+// at most once per archiveTouchInterval and always after it has passed.
+func TestArchiveTouchDueProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := NewArchiveService(nil, 180*24*time.Hour)
+		s.now = func() time.Time { return now }
+
+		written := make(map[int64]time.Time)
+		steps := rapid.IntRange(1, 50).Draw(t, "steps")
+		for i := 0; i < steps; i++ {
+			now = now.Add(time.Duration(rapid.Int64Range(0, int64(90*time.Minute)).Draw(t, "advance")))
+			userID := rapid.Int64Range(1, 4).Draw(t, "userID")
+
+			last, seen := written[userID]
+			want := !seen || now.Sub(last) >= archiveTouchInterval
+			if got := s.touchDue(userID); got != want {
+				t.Fatalf("touchDue(%d) at %v = %v, want %v (last write %v)", userID, now, got, want, last)
+			}
+			if want {
+				written[userID] = now
+			}
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// BailoutService credits a small recovery grant to players whose balance
+// stayed below a floor for a while, at most once per interval.
+type BailoutService struct {
+	repo     *repository.BailoutRepository
+	notifier UserNotifier
+	floor    int64
+	grant    int64
+	belowFor time.Duration // How long a balance must stay below the floor
+	interval time.Duration // Minimum time between two grants of a user
+}
+
+// NewBailoutService creates a new BailoutService instance.
+// Users below floor for belowFor get grant coins, at most once per interval.
+func NewBailoutService(repo *repository.BailoutRepository, floor, grant int64, belowFor, interval time.Duration) *BailoutService {
+	return &BailoutService{
+		repo:     repo,
+		floor:    floor,
+		grant:    grant,
+		belowFor: belowFor,
+		interval: interval,
+	}
+}
+
+// SetNotifier sets the notifier used to announce grants.
+func (s *BailoutService) SetNotifier(notifier UserNotifier) {
+	s.notifier = notifier
+}
+
+// Run updates who is below the floor and pays the grants that are due at now.
+// Returns the number of grants paid.
+func (s *BailoutService) Run(ctx context.Context, now time.Time) int {
+	if s.grant <= 0 || s.floor <= 0 {
+		return 0
+	}
+
+	if err := s.repo.TrackFloor(ctx, s.floor); err != nil {
+		log.Error().Err(err).Str("operation", "bailout").Msg("Failed to track bailout floor")
+		return 0
+	}
+	states, err := s.repo.ListBelowFloor(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "bailout").Msg("Failed to list bailout candidates")
+		return 0
+	}
+
+	granted := 0
+	for _, state := range states {
+		if !BailoutDue(state, now, s.belowFor, s.interval) {
+			continue
+		}
+		user, err := s.repo.Grant(ctx, state.UserID, s.grant, s.floor, fmt.Sprintf("破产救济金 %d", s.grant))
+		if err != nil {
+			log.Error().Err(err).Str("operation", "bailout").Int64("user_id", state.UserID).Msg("Failed to grant bailout")
+			continue
+		}
+		if user == nil {
+			continue // Recovered since the floor was tracked
+		}
+		granted++
+		if s.notifier != nil {
+			s.notifier.NotifyUser(user.TelegramID, FormatBailoutGrant(s.grant, s.floor, s.belowFor, user.Balance))
+		}
+	}
+
+	if granted > 0 {
+		log.Info().
+			Str("operation", "bailout").
+			Int("granted", granted).
+			Int64("amount", s.grant).
+			Msg("Bailout grants paid")
+	}
+	return granted
+}
+
+// BailoutDue reports whether a user with state is owed a grant at now:
+// below the floor for at least belowFor and no grant within interval.
+func BailoutDue(state model.BailoutState, now time.Time, belowFor, interval time.Duration) bool {
+	if state.BelowSince == nil || now.Sub(*state.BelowSince) < belowFor {
+		return false
+	}
+	return state.LastGrantAt == nil || now.Sub(*state.LastGrantAt) >= interval
+}
+
+// FormatBailoutGrant returns the message announcing a grant
+func FormatBailoutGrant(grant, floor int64, belowFor time.Duration, balance int64) string {
+	return fmt.Sprintf("🆘 你的余额已连续 %d 小时低于 %d 金币，系统发放救济金 %d 金币\n💰 当前余额: %d",
+		int64(belowFor.Hours()), floor, grant, balance)
+}
@@ -0,0 +1,42 @@
+// Package service provides business logic implementations.
+// Property-based tests for bailout grants.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestBailoutDueProperty tests that a grant is due only after belowFor below
+// the floor and only once interval passed since the previous grant.
+func TestBailoutDueProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(1700000000, 0)
+		belowFor := time.Duration(rapid.IntRange(1, 72).Draw(t, "belowHours")) * time.Hour
+		interval := time.Duration(rapid.IntRange(1, 14).Draw(t, "intervalDays")) * 24 * time.Hour
+
+		var state model.BailoutState
+		below := rapid.Bool().Draw(t, "below")
+		belowAge := time.Duration(rapid.IntRange(0, 200).Draw(t, "belowAgeHours")) * time.Hour
+		if below {
+			since := now.Add(-belowAge)
+			state.BelowSince = &since
+		}
+		granted := rapid.Bool().Draw(t, "granted")
+		grantAge := time.Duration(rapid.IntRange(0, 400).Draw(t, "grantAgeHours")) * time.Hour
+		if granted {
+			last := now.Add(-grantAge)
+			state.LastGrantAt = &last
+		}
+
+		want := below && belowAge >= belowFor && (!granted || grantAge >= interval)
+		if got := BailoutDue(state, now, belowFor, interval); got != want {
+			t.Fatalf("BailoutDue = %v, want %v (below=%v age=%v granted=%v age=%v)",
+				got, want, below, belowAge, granted, grantAge)
+		}
+	})
+}
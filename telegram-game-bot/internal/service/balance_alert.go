@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+	"telegram-game-bot/internal/repository"
+)
+
+// Balance alert errors
+var (
+	ErrBalanceAlertsDisabled = errors.New("余额提醒功能未开启")
+	ErrAlertThresholdTooLow  = errors.New("提醒阈值过低")
+)
+
+// balanceAlertLabels names the balance changes users can be alerted about.
+// Only changes the user did not cause themselves are listed.
+var balanceAlertLabels = map[string]string{
+	model.TxTypeRobbed:       "🔫 被打劫",
+	model.TxTypeAdminAdd:     "👮 管理员增加余额",
+	model.TxTypeAdminSub:     "👮 管理员扣除余额",
+	model.TxTypeAdminSet:     "👮 管理员调整余额",
+	model.TxTypeRaidPrize:    "⚔️ 突袭奖池分红",
+	model.TxTypeCompensation: "🩹 系统补偿",
+	model.TxTypeRefund:       "💸 工单退款",
+	model.TxTypeReversal:     "↩️ 交易撤销",
+	model.TxTypeTransfer:     "💸 收到转账",
+}
+
+// BalanceAlertLabel returns the label of a balance change users are alerted
+// about, false for changes caused by the user (bets, purchases, outgoing transfers...).
+func BalanceAlertLabel(txType string, amount int64) (string, bool) {
+	if txType == model.TxTypeTransfer && amount <= 0 {
+		return "", false
+	}
+	label, ok := balanceAlertLabels[txType]
+	return label, ok
+}
+
+// ShouldAlertBalance reports whether a balance change is alerted to a user
+// with the given threshold (0 = not opted in) and presence.
+func ShouldAlertBalance(txType string, amount, threshold int64, offline bool) bool {
+	if threshold <= 0 || !offline {
+		return false
+	}
+	if _, ok := BalanceAlertLabel(txType, amount); !ok {
+		return false
+	}
+	if amount < 0 {
+		amount = -amount
+	}
+	return amount >= threshold
+}
+
+// BalanceAlertService DMs opted in users about large balance changes they did
+// not cause while they are away from the groups. It is driven by the balance
+// events of the event bus; group activity is tracked in memory, so every user
+// counts as offline after a restart.
+type BalanceAlertService struct {
+	repo         *repository.BalanceAlertRepository
+	notifier     UserNotifier
+	minThreshold int64         // Minimum and default threshold (0 = alerts disabled)
+	offlineAfter time.Duration // Inactivity after which a user counts as offline
+	now          func() time.Time
+
+	mu         sync.Mutex
+	lastSeen   map[int64]time.Time // userID -> last group activity
+	lastSweep  time.Time
+	thresholds map[int64]int64 // userID -> threshold cache, 0 = not opted in
+}
+
+// NewBalanceAlertService creates a new BalanceAlertService instance.
+func NewBalanceAlertService(repo *repository.BalanceAlertRepository, minThreshold int64, offlineAfter time.Duration) *BalanceAlertService {
+	return &BalanceAlertService{
+		repo:         repo,
+		minThreshold: minThreshold,
+		offlineAfter: offlineAfter,
+		now:          time.Now,
+		lastSeen:     make(map[int64]time.Time),
+		thresholds:   make(map[int64]int64),
+	}
+}
+
+// SetNotifier sets the notifier used to deliver alerts.
+func (s *BalanceAlertService) SetNotifier(notifier UserNotifier) {
+	s.notifier = notifier
+}
+
+// Subscribe registers the service for balance changes on the bus.
+func (s *BalanceAlertService) Subscribe(bus *events.Bus) {
+	bus.OnBalanceChanged(s.HandleBalanceChanged)
+}
+
+// MinThreshold returns the minimum (and default) alert threshold, 0 if alerts are disabled.
+func (s *BalanceAlertService) MinThreshold() int64 {
+	return s.minThreshold
+}
+
+// Touch records group activity of a user.
+func (s *BalanceAlertService) Touch(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.lastSeen[userID] = now
+
+	// Forget users who went offline so the map only holds active users
+	if now.Sub(s.lastSweep) >= s.offlineAfter {
+		for id, seen := range s.lastSeen {
+			if now.Sub(seen) >= s.offlineAfter {
+				delete(s.lastSeen, id)
+			}
+		}
+		s.lastSweep = now
+	}
+}
+
+// IsOffline reports whether a user had no group activity recently.
+func (s *BalanceAlertService) IsOffline(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen, ok := s.lastSeen[userID]
+	return !ok || s.now().Sub(seen) >= s.offlineAfter
+}
+
+// Enable opts a user in with the given threshold (0 = the default threshold).
+// Returns the threshold in effect.
+func (s *BalanceAlertService) Enable(ctx context.Context, userID, threshold int64) (int64, error) {
+	if s.minThreshold <= 0 {
+		return 0, ErrBalanceAlertsDisabled
+	}
+	if threshold == 0 {
+		threshold = s.minThreshold
+	}
+	if threshold < s.minThreshold {
+		return 0, ErrAlertThresholdTooLow
+	}
+
+	if err := s.repo.SetThreshold(ctx, userID, threshold); err != nil {
+		return 0, err
+	}
+	s.cacheThreshold(userID, threshold)
+
+	log.Info().
+		Int64("user_id", userID).
+		Int64("threshold", threshold).
+		Str("operation", "balance_alert_enable").
+		Msg("Balance alerts enabled")
+	return threshold, nil
+}
+
+// Disable opts a user out of balance alerts.
+func (s *BalanceAlertService) Disable(ctx context.Context, userID int64) error {
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	s.cacheThreshold(userID, 0)
+
+	log.Info().
+		Int64("user_id", userID).
+		Str("operation", "balance_alert_disable").
+		Msg("Balance alerts disabled")
+	return nil
+}
+
+// Threshold returns the alert threshold of a user, 0 if the user has not opted in.
+func (s *BalanceAlertService) Threshold(ctx context.Context, userID int64) (int64, error) {
+	s.mu.Lock()
+	threshold, ok := s.thresholds[userID]
+	s.mu.Unlock()
+	if ok {
+		return threshold, nil
+	}
+
+	threshold, err := s.repo.GetThreshold(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.cacheThreshold(userID, threshold)
+	return threshold, nil
+}
+
+// cacheThreshold stores a user's threshold in the cache
+func (s *BalanceAlertService) cacheThreshold(userID, threshold int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.thresholds[userID] = threshold
+}
+
+// HandleBalanceChanged alerts the user about a balance change if it qualifies.
+// Cheap checks run inline; the opt-in lookup and the DM run in the background
+// so the publisher (usually holding the user's lock) is never delayed.
+func (s *BalanceAlertService) HandleBalanceChanged(event events.BalanceChanged) {
+	if s.notifier == nil || !ShouldAlertBalance(event.TxType, event.Amount, s.minThreshold, s.IsOffline(event.UserID)) {
+		return
+	}
+
+	go func() {
+		threshold, err := s.Threshold(context.Background(), event.UserID)
+		if err != nil {
+			log.Warn().Err(err).Int64("user_id", event.UserID).Msg("Failed to load balance alert setting")
+			return
+		}
+		if !ShouldAlertBalance(event.TxType, event.Amount, threshold, true) {
+			return
+		}
+		s.notifier.NotifyUser(event.UserID, FormatBalanceAlert(event))
+	}()
+}
+
+// FormatBalanceAlert formats the DM of a balance change
+func FormatBalanceAlert(event events.BalanceChanged) string {
+	label, _ := BalanceAlertLabel(event.TxType, event.Amount)
+
+	var sb strings.Builder
+	sb.WriteString("🔔 余额变动提醒\n\n")
+	sb.WriteString(fmt.Sprintf("%s: %+d 金币\n", label, event.Amount))
+	if event.Description != "" {
+		sb.WriteString(fmt.Sprintf("📝 %s\n", event.Description))
+	}
+	sb.WriteString(fmt.Sprintf("🕐 %s\n\n", event.At.Format("2006-01-02 15:04:05")))
+	sb.WriteString("发送 /alerts off 关闭提醒")
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+// Package service provides business logic implementations.
+// Property-based tests for balance change alerts.
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// TestShouldAlertBalanceProperty tests that only opted in, offline users are
+// alerted, only about large enough changes they did not cause themselves.
+func TestShouldAlertBalanceProperty(t *testing.T) {
+	txTypes := []string{
+		model.TxTypeDice, model.TxTypeSicBoBet, model.TxTypeShopPurchase, model.TxTypeRob,
+		model.TxTypeRobbed, model.TxTypeAdminSub, model.TxTypeRaidPrize, model.TxTypeTransfer,
+	}
+	selfCaused := map[string]bool{
+		model.TxTypeDice: true, model.TxTypeSicBoBet: true, model.TxTypeShopPurchase: true, model.TxTypeRob: true,
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		txType := rapid.SampledFrom(txTypes).Draw(t, "txType")
+		amount := rapid.Int64Range(-20000, 20000).Draw(t, "amount")
+		threshold := rapid.Int64Range(0, 10000).Draw(t, "threshold")
+		offline := rapid.Bool().Draw(t, "offline")
+
+		got := ShouldAlertBalance(txType, amount, threshold, offline)
+
+		size := amount
+		if size < 0 {
+			size = -size
+		}
+		outgoingTransfer := txType == model.TxTypeTransfer && amount <= 0
+		want := threshold > 0 && offline && !selfCaused[txType] && !outgoingTransfer && size >= threshold
+		if got != want {
+			t.Fatalf("ShouldAlertBalance(%s, %d, %d, %v) = %v, want %v", txType, amount, threshold, offline, got, want)
+		}
+	})
+}
+
+// TestBalanceAlertPresenceProperty tests that users count as offline until
+// they are active in a group and again once the offline period has passed.
+func TestBalanceAlertPresenceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		offlineAfter := time.Duration(rapid.IntRange(1, 60).Draw(t, "offlineMinutes")) * time.Minute
+		s := NewBalanceAlertService(nil, 1000, offlineAfter)
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		s.now = func() time.Time { return now }
+
+		userID := rapid.Int64Range(1, 1000).Draw(t, "userID")
+		if !s.IsOffline(userID) {
+			t.Fatal("Unknown user should be offline")
+		}
+
+		s.Touch(userID)
+		elapsed := time.Duration(rapid.Int64Range(0, int64(2*offlineAfter)).Draw(t, "elapsed"))
+		now = now.Add(elapsed)
+
+		if got, want := s.IsOffline(userID), elapsed >= offlineAfter; got != want {
+			t.Fatalf("IsOffline after %v (offline after %v) = %v, want %v", elapsed, offlineAfter, got, want)
+		}
+
+		// Touching another user sweeps users who went offline
+		s.Touch(userID + 1)
+		s.mu.Lock()
+		_, kept := s.lastSeen[userID]
+		s.mu.Unlock()
+		if elapsed >= offlineAfter && kept {
+			t.Fatal("Offline user was not swept")
+		}
+	})
+}
+
+// recordingNotifier records the users that were notified
+type recordingNotifier struct {
+	mu    sync.Mutex
+	users []int64
+	sent  chan struct{}
+}
+
+func (n *recordingNotifier) NotifyUser(userID int64, text string) {
+	n.mu.Lock()
+	n.users = append(n.users, userID)
+	n.mu.Unlock()
+	n.sent <- struct{}{}
+}
+
+// TestBalanceAlertDeliveryProperty tests that a qualifying balance change
+// published on the bus is delivered to an opted in user with their own threshold.
+func TestBalanceAlertDeliveryProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		s := NewBalanceAlertService(nil, 1000, 10*time.Minute)
+		notifier := &recordingNotifier{sent: make(chan struct{}, 1)}
+		s.SetNotifier(notifier)
+		bus := events.NewBus()
+		s.Subscribe(bus)
+
+		userID := rapid.Int64Range(1, 1000).Draw(t, "userID")
+		threshold := rapid.Int64Range(1000, 5000).Draw(t, "threshold")
+		s.cacheThreshold(userID, threshold)
+
+		amount := -rapid.Int64Range(threshold, 10000).Draw(t, "amount")
+		bus.PublishBalanceChanged(events.BalanceChanged{UserID: userID, Amount: amount, TxType: model.TxTypeRobbed, At: time.Now()})
+
+		select {
+		case <-notifier.sent:
+		case <-time.After(time.Second):
+			t.Fatal("Alert was not delivered")
+		}
+		if len(notifier.users) != 1 || notifier.users[0] != userID {
+			t.Fatalf("Notified %v, want [%d]", notifier.users, userID)
+		}
+	})
+}
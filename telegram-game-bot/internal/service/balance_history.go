@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Balance history settings
+const (
+	BalanceHistoryDays          = 14 // Days shown by /wealth
+	BalanceHistoryRetentionDays = 90 // Snapshots older than this are deleted
+)
+
+// sparkLevels are the bars of a sparkline from lowest to highest
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a row of bars scaled between their minimum and
+// maximum. Equal values are drawn as a flat middle line.
+func Sparkline(values []int64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := len(sparkLevels) / 2
+		if hi > lo {
+			level = int(float64(v-lo) / float64(hi-lo) * float64(len(sparkLevels)-1))
+		}
+		bars[i] = sparkLevels[level]
+	}
+	return string(bars)
+}
+
+// FillBalanceHistory returns the balance on each of days days starting at
+// from (midnight UTC of a local date). Days without a snapshot keep the
+// previous balance, since snapshots are only taken for users whose balance
+// changed; seed is the balance before from, nil if unknown. Leading days
+// without any known balance are left out.
+func FillBalanceHistory(points []model.BalancePoint, seed *int64, from time.Time, days int) []int64 {
+	byDay := make(map[time.Time]int64, len(points))
+	for _, p := range points {
+		byDay[p.Day] = p.Balance
+	}
+
+	var values []int64
+	known := seed != nil
+	var balance int64
+	if known {
+		balance = *seed
+	}
+	for i := 0; i < days; i++ {
+		if b, ok := byDay[from.AddDate(0, 0, i)]; ok {
+			balance, known = b, true
+		}
+		if known {
+			values = append(values, balance)
+		}
+	}
+	return values
+}
+
+// localDay returns the date of t in loc as midnight UTC
+func localDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// BalanceHistoryService records daily balance snapshots of active users and
+// reads them back as a time series.
+type BalanceHistoryService struct {
+	repo *repository.BalanceHistoryRepository
+	loc  *time.Location // Days start at midnight in this location
+
+	mu      sync.Mutex
+	lastRun time.Time // Users updated since the previous snapshot are recorded
+}
+
+// NewBalanceHistoryService creates a new BalanceHistoryService instance.
+func NewBalanceHistoryService(repo *repository.BalanceHistoryRepository, loc *time.Location) *BalanceHistoryService {
+	return &BalanceHistoryService{repo: repo, loc: loc}
+}
+
+// Snapshot records today's balance of every user whose balance changed since
+// the previous snapshot (the last day on the first one) and deletes
+// snapshots past the retention.
+func (s *BalanceHistoryService) Snapshot(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	since := s.lastRun
+	s.mu.Unlock()
+	if since.IsZero() {
+		since = now.Add(-24 * time.Hour)
+	}
+
+	day := localDay(now, s.loc)
+	recorded, err := s.repo.Record(ctx, day, since)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to record balance history")
+		return
+	}
+	s.mu.Lock()
+	s.lastRun = now
+	s.mu.Unlock()
+
+	deleted, err := s.repo.DeleteBefore(ctx, day.AddDate(0, 0, -BalanceHistoryRetentionDays))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to prune balance history")
+	}
+	log.Debug().Int64("recorded", recorded).Int64("deleted", deleted).Msg("Balance history recorded")
+}
+
+// History returns the balance of a user on each of the last days days,
+// oldest first, ending with current as today's balance.
+func (s *BalanceHistoryService) History(ctx context.Context, userID, current int64, now time.Time, days int) ([]int64, error) {
+	today := localDay(now, s.loc)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	points, err := s.repo.Range(ctx, userID, from, today)
+	if err != nil {
+		return nil, err
+	}
+	var seed *int64
+	last, err := s.repo.LastBefore(ctx, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		seed = &last.Balance
+	}
+
+	values := FillBalanceHistory(points, seed, from, days-1)
+	return append(values, current), nil
+}
@@ -0,0 +1,92 @@
+// Package service provides business logic implementations.
+// Property-based tests for balance history and sparklines.
+package service
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestSparklineProperty tests that a sparkline has one bar per value, with
+// the lowest and highest bars at the minimum and maximum.
+func TestSparklineProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		values := rapid.SliceOfN(rapid.Int64Range(-1_000_000, 1_000_000), 1, 30).Draw(t, "values")
+		line := []rune(Sparkline(values))
+		if len(line) != len(values) {
+			t.Fatalf("%d bars for %d values", len(line), len(values))
+		}
+
+		lo, hi := 0, 0
+		for i, v := range values {
+			if v < values[lo] {
+				lo = i
+			}
+			if v > values[hi] {
+				hi = i
+			}
+		}
+		if values[lo] == values[hi] {
+			return
+		}
+		if line[lo] != '▁' || line[hi] != '█' {
+			t.Fatalf("Sparkline %q: min bar %q, max bar %q", string(line), line[lo], line[hi])
+		}
+	})
+}
+
+// TestSparklineEmpty tests that no values render nothing.
+func TestSparklineEmpty(t *testing.T) {
+	if line := Sparkline(nil); utf8.RuneCountInString(line) != 0 {
+		t.Fatalf("Empty sparkline %q", line)
+	}
+}
+
+// TestFillBalanceHistoryProperty tests that days without a snapshot carry
+// the previous balance and that days before any known balance are left out.
+func TestFillBalanceHistoryProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		days := rapid.IntRange(1, 30).Draw(t, "days")
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		var seed *int64
+		if rapid.Bool().Draw(t, "seeded") {
+			v := rapid.Int64Range(0, 1000).Draw(t, "seed")
+			seed = &v
+		}
+
+		recorded := make(map[int]int64)
+		var points []model.BalancePoint
+		for i := 0; i < days; i++ {
+			if rapid.Bool().Draw(t, "has") {
+				b := rapid.Int64Range(0, 1000).Draw(t, "balance")
+				recorded[i] = b
+				points = append(points, model.BalancePoint{Day: from.AddDate(0, 0, i), Balance: b})
+			}
+		}
+
+		values := FillBalanceHistory(points, seed, from, days)
+		offset := days - len(values)
+		if seed != nil && offset != 0 {
+			t.Fatalf("Seeded history left out %d days", offset)
+		}
+		if _, ok := recorded[offset]; seed == nil && len(values) > 0 && !ok {
+			t.Fatalf("History starts at day %d without a snapshot", offset)
+		}
+		for i, v := range values {
+			b, ok := recorded[offset+i]
+			switch {
+			case ok && v != b:
+				t.Fatalf("Day %d: got %d, recorded %d", offset+i, v, b)
+			case !ok && i > 0 && v != values[i-1]:
+				t.Fatalf("Day %d: got %d, previous %d", offset+i, v, values[i-1])
+			case !ok && i == 0 && v != *seed:
+				t.Fatalf("Day 0: got %d, seed %d", v, *seed)
+			}
+		}
+	})
+}
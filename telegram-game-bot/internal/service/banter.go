@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/repository"
+)
+
+// BanterMaxRunes is the maximum length of a banter phrase
+const BanterMaxRunes = 60
+
+// Banter errors
+var (
+	ErrBanterUnknownKind = errors.New("未知的类型，只能是 win 或 lose")
+	ErrBanterLocale      = errors.New("语言格式错误，例如 zh 或 en")
+	ErrBanterEmpty       = errors.New("短语不能为空")
+	ErrBanterTooLong     = errors.New("短语过长")
+	ErrBanterBlocked     = errors.New("短语包含屏蔽词")
+	ErrBanterNotFound    = errors.New("短语不存在")
+)
+
+// BanterLocale reduces a Telegram language code such as "zh-hans" or
+// "en-US" to the locale of banter phrases, "" if it is not a language code.
+func BanterLocale(languageCode string) string {
+	locale, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(languageCode)), "-")
+	if len(locale) < 2 || len(locale) > 8 {
+		return ""
+	}
+	for _, r := range locale {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return locale
+}
+
+// ValidateBanterText checks that a phrase is a short single line without blocked words.
+func ValidateBanterText(text string) error {
+	switch {
+	case text == "":
+		return ErrBanterEmpty
+	case utf8.RuneCountInString(text) > BanterMaxRunes || strings.ContainsAny(text, "\r\n"):
+		return ErrBanterTooLong
+	case textfilter.Blocked(text):
+		return ErrBanterBlocked
+	}
+	return nil
+}
+
+// BanterService occasionally appends a short taunt or congratulation to game
+// results. Phrases are picked in the player's language, falling back to the
+// default locale, from a pool admins manage at runtime. Each result gets a
+// phrase with the configured chance, at most once per cooldown in each chat.
+// The phrases are cached in memory since they rarely change.
+type BanterService struct {
+	repo          *repository.BanterRepository
+	chancePercent int
+	cooldown      time.Duration
+	defaultLocale string
+
+	mu       sync.Mutex
+	phrases  map[string][]model.BanterPhrase // kind/locale -> phrases, nil until loaded
+	lastSent map[int64]time.Time             // chatID -> last phrase
+}
+
+// NewBanterService creates a new BanterService instance.
+func NewBanterService(repo *repository.BanterRepository, chancePercent int, cooldown time.Duration, defaultLocale string) *BanterService {
+	return &BanterService{
+		repo:          repo,
+		chancePercent: chancePercent,
+		cooldown:      cooldown,
+		defaultLocale: BanterLocale(defaultLocale),
+		lastSent:      make(map[int64]time.Time),
+	}
+}
+
+// Line returns a random phrase of kind for a player's language code, or ""
+// if the roll fails, the chat had banter within the cooldown or there is no
+// phrase. Best effort: failures are logged.
+func (s *BanterService) Line(ctx context.Context, chatID int64, kind, languageCode string) string {
+	if rand.Intn(100) >= s.chancePercent {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load banter phrases")
+		return ""
+	}
+	phrases := s.phrases[banterPoolKey(kind, BanterLocale(languageCode))]
+	if len(phrases) == 0 {
+		phrases = s.phrases[banterPoolKey(kind, s.defaultLocale)]
+	}
+	if len(phrases) == 0 {
+		return ""
+	}
+
+	now := time.Now()
+	if last, ok := s.lastSent[chatID]; ok && now.Sub(last) < s.cooldown {
+		return ""
+	}
+	s.lastSent[chatID] = now
+	return phrases[rand.Intn(len(phrases))].Text
+}
+
+// List returns all banter phrases, ordered by kind, locale and ID.
+func (s *BanterService) List(ctx context.Context) ([]model.BanterPhrase, error) {
+	return s.repo.List(ctx)
+}
+
+// Add adds a phrase to the pool of a kind and locale.
+func (s *BanterService) Add(ctx context.Context, kind, locale, text string, adminID int64) (*model.BanterPhrase, error) {
+	if kind != model.BanterWin && kind != model.BanterLose {
+		return nil, ErrBanterUnknownKind
+	}
+	if locale = BanterLocale(locale); locale == "" {
+		return nil, ErrBanterLocale
+	}
+	text = strings.TrimSpace(text)
+	if err := ValidateBanterText(text); err != nil {
+		return nil, err
+	}
+
+	phrase := &model.BanterPhrase{Kind: kind, Locale: locale, Text: text, AddedBy: adminID}
+	if err := s.repo.Add(ctx, phrase); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("phrase_id", phrase.ID).
+		Str("kind", kind).
+		Str("locale", locale).
+		Str("operation", "banter_add").
+		Msg("Banter phrase added")
+	return phrase, nil
+}
+
+// Delete removes a banter phrase.
+func (s *BanterService) Delete(ctx context.Context, id, adminID int64) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrBanterNotFound
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("phrase_id", id).
+		Str("operation", "banter_delete").
+		Msg("Banter phrase deleted")
+	return nil
+}
+
+// loadLocked fills the phrase cache if needed; the caller must hold s.mu
+func (s *BanterService) loadLocked(ctx context.Context) error {
+	if s.phrases != nil {
+		return nil
+	}
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.phrases = make(map[string][]model.BanterPhrase)
+	for _, p := range all {
+		key := banterPoolKey(p.Kind, p.Locale)
+		s.phrases[key] = append(s.phrases[key], p)
+	}
+	return nil
+}
+
+// invalidate drops the phrase cache after a change
+func (s *BanterService) invalidate() {
+	s.mu.Lock()
+	s.phrases = nil
+	s.mu.Unlock()
+}
+
+// banterPoolKey identifies the phrases of a kind in a locale
+func banterPoolKey(kind, locale string) string {
+	return kind + "/" + locale
+}
@@ -0,0 +1,54 @@
+// Package service provides business logic implementations.
+// Property-based tests for banter phrases.
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/pkg/textfilter"
+)
+
+// TestBanterLocaleProperty tests that language codes reduce to their
+// lowercase primary language.
+func TestBanterLocaleProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		lang := rapid.StringMatching(`[a-zA-Z]{2,3}`).Draw(t, "lang")
+		region := rapid.StringMatching(`(-[a-zA-Z]{2,4})?`).Draw(t, "region")
+
+		if got := BanterLocale(lang + region); got != strings.ToLower(lang) {
+			t.Fatalf("BanterLocale(%q) = %q, want %q", lang+region, got, strings.ToLower(lang))
+		}
+	})
+
+	for _, code := range []string{"", "x", "1a", "中文"} {
+		if got := BanterLocale(code); got != "" {
+			t.Fatalf("BanterLocale(%q) = %q, want empty", code, got)
+		}
+	}
+}
+
+// TestValidateBanterTextProperty tests that phrases are rejected when too
+// long, multi-line or containing a blocked word.
+func TestValidateBanterTextProperty(t *testing.T) {
+	textfilter.SetDefault(textfilter.New(textfilter.Options{BlockedWords: []string{"坏蛋"}}))
+	defer textfilter.SetDefault(nil)
+
+	rapid.Check(t, func(t *rapid.T) {
+		text := rapid.StringOfN(rapid.SampledFrom([]rune("好运气 ab!")), 1, BanterMaxRunes, -1).Draw(t, "text")
+		if err := ValidateBanterText(text); err != nil {
+			t.Fatalf("ValidateBanterText(%q) = %v", text, err)
+		}
+		if err := ValidateBanterText(text + "\n" + text); err == nil {
+			t.Fatalf("Multi-line phrase %q accepted", text)
+		}
+		if err := ValidateBanterText(text + strings.Repeat("!", BanterMaxRunes)); err != ErrBanterTooLong {
+			t.Fatalf("Long phrase accepted: %v", err)
+		}
+		if err := ValidateBanterText("你这个坏蛋"); err != ErrBanterBlocked {
+			t.Fatalf("Blocked phrase accepted: %v", err)
+		}
+	})
+}
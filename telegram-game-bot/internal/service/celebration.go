@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Celebration errors
+var (
+	ErrCelebrationUnknownKind = errors.New("未知的庆祝类型")
+	ErrCelebrationUnknownType = errors.New("只支持贴纸和动图")
+	ErrCelebrationNotFound    = errors.New("庆祝素材不存在")
+)
+
+// celebrationKinds lists the celebration kinds in display order
+var celebrationKinds = []string{
+	model.CelebrationSlotTriple,
+	model.CelebrationGreatSwordCrit,
+	model.CelebrationRaidVictory,
+}
+
+// CelebrationKinds returns the wins that can trigger celebration media.
+func CelebrationKinds() []string {
+	return celebrationKinds
+}
+
+// CelebrationSender posts celebration media in a chat.
+type CelebrationSender interface {
+	SendMedia(chatID int64, mediaType, fileID string) error
+}
+
+// CelebrationService sends a sticker or animation picked from a configurable
+// set after jackpot-class wins, at most once per cooldown in each chat.
+// The media are cached in memory since they rarely change.
+type CelebrationService struct {
+	repo     *repository.CelebrationRepository
+	sender   CelebrationSender
+	cooldown time.Duration
+
+	mu       sync.Mutex
+	media    map[string][]model.CelebrationMedia // kind -> media, nil until loaded
+	lastSent map[int64]time.Time                 // chatID -> last celebration
+}
+
+// NewCelebrationService creates a new CelebrationService instance.
+func NewCelebrationService(repo *repository.CelebrationRepository, cooldown time.Duration) *CelebrationService {
+	return &CelebrationService{
+		repo:     repo,
+		cooldown: cooldown,
+		lastSent: make(map[int64]time.Time),
+	}
+}
+
+// SetSender sets the sender used to post celebration media.
+func (s *CelebrationService) SetSender(sender CelebrationSender) {
+	s.sender = sender
+}
+
+// Celebrate posts a random media of kind in a chat, unless the chat celebrated
+// within the cooldown or the kind has no media. Best effort: failures are logged.
+func (s *CelebrationService) Celebrate(ctx context.Context, chatID int64, kind string) {
+	if s.sender == nil {
+		return
+	}
+
+	media, err := s.mediaOf(ctx, kind)
+	if err != nil {
+		log.Warn().Err(err).Str("kind", kind).Msg("Failed to load celebration media")
+		return
+	}
+	if len(media) == 0 {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	last, ok := s.lastSent[chatID]
+	if !CelebrationDue(last, ok, now, s.cooldown) {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSent[chatID] = now
+	s.mu.Unlock()
+
+	pick := media[rand.Intn(len(media))]
+	if err := s.sender.SendMedia(chatID, pick.MediaType, pick.FileID); err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Int64("media_id", pick.ID).Msg("Failed to send celebration media")
+	}
+}
+
+// CelebrationDue reports whether a chat that last celebrated at last (ok is
+// false if never) may celebrate again at now.
+func CelebrationDue(last time.Time, ok bool, now time.Time, cooldown time.Duration) bool {
+	return !ok || now.Sub(last) >= cooldown
+}
+
+// List returns all celebration media grouped by kind.
+func (s *CelebrationService) List(ctx context.Context) (map[string][]model.CelebrationMedia, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		return nil, err
+	}
+	return s.media, nil
+}
+
+// Add adds a sticker or animation to the media of a celebration kind.
+func (s *CelebrationService) Add(ctx context.Context, kind, mediaType, fileID string, adminID int64) (*model.CelebrationMedia, error) {
+	if !isCelebrationKind(kind) {
+		return nil, ErrCelebrationUnknownKind
+	}
+	if mediaType != model.CelebrationSticker && mediaType != model.CelebrationAnimation {
+		return nil, ErrCelebrationUnknownType
+	}
+
+	media := &model.CelebrationMedia{Kind: kind, MediaType: mediaType, FileID: fileID, AddedBy: adminID}
+	if err := s.repo.Add(ctx, media); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("media_id", media.ID).
+		Str("kind", kind).
+		Str("operation", "celebration_add").
+		Msg("Celebration media added")
+	return media, nil
+}
+
+// Delete removes a celebration media entry.
+func (s *CelebrationService) Delete(ctx context.Context, id, adminID int64) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCelebrationNotFound
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("media_id", id).
+		Str("operation", "celebration_delete").
+		Msg("Celebration media deleted")
+	return nil
+}
+
+// mediaOf returns the media of a celebration kind
+func (s *CelebrationService) mediaOf(ctx context.Context, kind string) ([]model.CelebrationMedia, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		return nil, err
+	}
+	return s.media[kind], nil
+}
+
+// loadLocked fills the media cache if needed; the caller must hold s.mu
+func (s *CelebrationService) loadLocked(ctx context.Context) error {
+	if s.media != nil {
+		return nil
+	}
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.media = make(map[string][]model.CelebrationMedia)
+	for _, m := range all {
+		s.media[m.Kind] = append(s.media[m.Kind], m)
+	}
+	return nil
+}
+
+// invalidate drops the media cache after a change
+func (s *CelebrationService) invalidate() {
+	s.mu.Lock()
+	s.media = nil
+	s.mu.Unlock()
+}
+
+// isCelebrationKind reports whether kind is a known celebration kind
+func isCelebrationKind(kind string) bool {
+	for _, k := range celebrationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+// Package service provides business logic implementations.
+// Property-based tests for celebration media.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestCelebrationDueProperty tests that a chat celebrates at most once per
+// cooldown, and always if it never celebrated.
+func TestCelebrationDueProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(1700000000, 0)
+		cooldown := time.Duration(rapid.IntRange(0, 3600).Draw(t, "cooldownSeconds")) * time.Second
+		age := time.Duration(rapid.IntRange(0, 7200).Draw(t, "ageSeconds")) * time.Second
+		ok := rapid.Bool().Draw(t, "celebrated")
+
+		due := CelebrationDue(now.Add(-age), ok, now, cooldown)
+		if want := !ok || age >= cooldown; due != want {
+			t.Fatalf("CelebrationDue(age=%v, ok=%v, cooldown=%v) = %v, want %v", age, ok, cooldown, due, want)
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// ChatDefaultCleanupMinutes is the cleanup delay of chats that never chose one.
+const ChatDefaultCleanupMinutes = 30
+
+// Chat settings errors
+var (
+	ErrChatTimezone = errors.New("不支持的时区")
+	ErrChatCleanup  = errors.New("不支持的清理间隔")
+	ErrChatQuiet    = errors.New("安静时段格式错误，例如 23-7")
+)
+
+// ChatTimezones lists the timezones offered by the setup wizard.
+var ChatTimezones = []string{
+	"Asia/Shanghai",
+	"Asia/Tokyo",
+	"Europe/London",
+	"America/New_York",
+	"UTC",
+}
+
+// ChatCleanupOptions lists the cleanup delays in minutes offered by the
+// setup wizard; 0 keeps bot messages.
+var ChatCleanupOptions = []int{0, 10, 30, 60}
+
+// ChatQuietOptions lists the quiet hours offered by the setup wizard as
+// start and end hours; equal hours turn quiet hours off.
+var ChatQuietOptions = [][2]int{{0, 0}, {23, 7}, {0, 8}, {1, 9}}
+
+// InQuietHours reports whether hour lies in the quiet hours from start up
+// to end, which may wrap past midnight. Equal hours mean no quiet hours.
+func InQuietHours(start, end, hour int) bool {
+	return start != end && SicBoAutoInWindow(start, end, hour)
+}
+
+// ParseQuietHours parses quiet hours such as "23-7"; "off" turns them off.
+func ParseQuietHours(s string) (start, end int, err error) {
+	if strings.EqualFold(strings.TrimSpace(s), "off") {
+		return 0, 0, nil
+	}
+	start, end, err = ParseSicBoAutoHours(s)
+	if err != nil {
+		return 0, 0, ErrChatQuiet
+	}
+	return start, end % 24, nil
+}
+
+// FormatQuietHours describes quiet hours, e.g. "23:00-07:00".
+func FormatQuietHours(start, end int) string {
+	if start == end {
+		return "无"
+	}
+	return fmt.Sprintf("%02d:00-%02d:00", start, end)
+}
+
+// ValidChatTimezone reports whether tz may be chosen by a chat. The empty
+// name restores the bot default.
+func ValidChatTimezone(tz string) bool {
+	if tz == "" {
+		return true
+	}
+	for _, t := range ChatTimezones {
+		if t == tz {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidChatCleanup reports whether minutes is an offered cleanup delay.
+func ValidChatCleanup(minutes int) bool {
+	for _, m := range ChatCleanupOptions {
+		if m == minutes {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultChatSettings returns the settings of a chat that never changed any.
+func DefaultChatSettings(chatID int64) model.ChatSettings {
+	return model.ChatSettings{
+		ChatID:         chatID,
+		GamesEnabled:   true,
+		CleanupMinutes: ChatDefaultCleanupMinutes,
+	}
+}
+
+// ChatSettingsService manages the per chat choices of the setup wizard.
+// Settings are cached in memory since they are read on every game.
+type ChatSettingsService struct {
+	repo *repository.ChatSettingsRepository
+
+	mu       sync.Mutex
+	settings map[int64]model.ChatSettings // chatID -> settings, nil until loaded
+}
+
+// NewChatSettingsService creates a new ChatSettingsService instance.
+func NewChatSettingsService(repo *repository.ChatSettingsRepository) *ChatSettingsService {
+	return &ChatSettingsService{repo: repo}
+}
+
+// Get returns the settings of a chat, the defaults if it has none.
+func (s *ChatSettingsService) Get(ctx context.Context, chatID int64) (model.ChatSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		return DefaultChatSettings(chatID), err
+	}
+	if settings, ok := s.settings[chatID]; ok {
+		return settings, nil
+	}
+	return DefaultChatSettings(chatID), nil
+}
+
+// Warm loads the settings of all chats ahead of the first game.
+func (s *ChatSettingsService) Warm(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked(ctx)
+}
+
+// GamesEnabled reports whether games may be played in a chat.
+// Games stay enabled when the settings cannot be loaded.
+func (s *ChatSettingsService) GamesEnabled(ctx context.Context, chatID int64) bool {
+	settings, err := s.Get(ctx, chatID)
+	if err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to load chat settings")
+	}
+	return settings.GamesEnabled
+}
+
+// CleanupDelay returns how long bot messages are kept in a chat, 0 to keep them.
+func (s *ChatSettingsService) CleanupDelay(chatID int64) time.Duration {
+	settings, err := s.Get(context.Background(), chatID)
+	if err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to load chat settings")
+	}
+	return time.Duration(settings.CleanupMinutes) * time.Minute
+}
+
+// Location returns the timezone chosen by a chat, nil for the bot default.
+func (s *ChatSettingsService) Location(chatID int64) *time.Location {
+	settings, err := s.Get(context.Background(), chatID)
+	if err != nil || settings.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		log.Warn().Err(err).Str("timezone", settings.Timezone).Msg("Failed to load chat timezone")
+		return nil
+	}
+	return loc
+}
+
+// QuickBetsEnabled reports whether shorthand bets are accepted in a chat.
+// Quick bets stay off when the settings cannot be loaded.
+func (s *ChatSettingsService) QuickBetsEnabled(chatID int64) bool {
+	settings, err := s.Get(context.Background(), chatID)
+	if err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to load chat settings")
+		return false
+	}
+	return settings.QuickBets
+}
+
+// QuietHours returns the quiet hours of a chat, e.g. "23:00-07:00", if it
+// is in them at now, in the chat timezone or the local one. Quiet hours are
+// off when the settings cannot be loaded.
+func (s *ChatSettingsService) QuietHours(chatID int64, now time.Time) (string, bool) {
+	settings, err := s.Get(context.Background(), chatID)
+	if err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to load chat settings")
+		return "", false
+	}
+	if settings.QuietStart == settings.QuietEnd {
+		return "", false
+	}
+	loc := s.Location(chatID)
+	if loc == nil {
+		loc = time.Local
+	}
+	if !InQuietHours(settings.QuietStart, settings.QuietEnd, now.In(loc).Hour()) {
+		return "", false
+	}
+	return FormatQuietHours(settings.QuietStart, settings.QuietEnd), true
+}
+
+// QuietNow reports whether a chat is in its quiet hours at now.
+func (s *ChatSettingsService) QuietNow(chatID int64, now time.Time) bool {
+	_, quiet := s.QuietHours(chatID, now)
+	return quiet
+}
+
+// Onboarded reports whether the setup wizard was already posted in a chat.
+func (s *ChatSettingsService) Onboarded(ctx context.Context, chatID int64) (bool, error) {
+	settings, err := s.Get(ctx, chatID)
+	if err != nil {
+		return false, err
+	}
+	return settings.OnboardedAt != nil, nil
+}
+
+// MarkOnboarded records that the setup wizard was posted in a chat.
+func (s *ChatSettingsService) MarkOnboarded(ctx context.Context, chatID int64) error {
+	return s.update(ctx, chatID, 0, "chat_onboarded", func(settings *model.ChatSettings) {
+		now := time.Now()
+		settings.OnboardedAt = &now
+	})
+}
+
+// SetGamesEnabled enables or disables games in a chat.
+func (s *ChatSettingsService) SetGamesEnabled(ctx context.Context, chatID, adminID int64, enabled bool) error {
+	return s.update(ctx, chatID, adminID, "chat_games", func(settings *model.ChatSettings) {
+		settings.GamesEnabled = enabled
+	})
+}
+
+// SetQuickBets enables or disables shorthand bets in a chat.
+func (s *ChatSettingsService) SetQuickBets(ctx context.Context, chatID, adminID int64, enabled bool) error {
+	return s.update(ctx, chatID, adminID, "chat_quick_bets", func(settings *model.ChatSettings) {
+		settings.QuickBets = enabled
+	})
+}
+
+// SetQuietHours sets the quiet hours of a chat; equal hours turn them off.
+func (s *ChatSettingsService) SetQuietHours(ctx context.Context, chatID, adminID int64, start, end int) error {
+	if start < 0 || start > 23 || end < 0 || end > 23 {
+		return ErrChatQuiet
+	}
+	return s.update(ctx, chatID, adminID, "chat_quiet_hours", func(settings *model.ChatSettings) {
+		settings.QuietStart, settings.QuietEnd = start, end
+	})
+}
+
+// SetTimezone sets the timezone of a chat; empty restores the bot default.
+func (s *ChatSettingsService) SetTimezone(ctx context.Context, chatID, adminID int64, tz string) error {
+	if !ValidChatTimezone(tz) {
+		return ErrChatTimezone
+	}
+	return s.update(ctx, chatID, adminID, "chat_timezone", func(settings *model.ChatSettings) {
+		settings.Timezone = tz
+	})
+}
+
+// SetCleanup sets how many minutes bot messages are kept in a chat, 0 to keep them.
+func (s *ChatSettingsService) SetCleanup(ctx context.Context, chatID, adminID int64, minutes int) error {
+	if !ValidChatCleanup(minutes) {
+		return ErrChatCleanup
+	}
+	return s.update(ctx, chatID, adminID, "chat_cleanup", func(settings *model.ChatSettings) {
+		settings.CleanupMinutes = minutes
+	})
+}
+
+// update applies change to the settings of a chat and stores them
+func (s *ChatSettingsService) update(ctx context.Context, chatID, adminID int64, operation string, change func(*model.ChatSettings)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		return err
+	}
+
+	settings, ok := s.settings[chatID]
+	if !ok {
+		settings = DefaultChatSettings(chatID)
+	}
+	change(&settings)
+	if adminID != 0 {
+		settings.UpdatedBy = adminID
+	}
+	if err := s.repo.Upsert(ctx, &settings); err != nil {
+		return err
+	}
+	s.settings[chatID] = settings
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Bool("games_enabled", settings.GamesEnabled).
+		Str("timezone", settings.Timezone).
+		Int("cleanup_minutes", settings.CleanupMinutes).
+		Bool("quick_bets", settings.QuickBets).
+		Int("quiet_start", settings.QuietStart).
+		Int("quiet_end", settings.QuietEnd).
+		Str("operation", operation).
+		Msg("Chat settings updated")
+	return nil
+}
+
+// loadLocked fills the settings cache if needed; the caller must hold s.mu
+func (s *ChatSettingsService) loadLocked(ctx context.Context) error {
+	if s.settings != nil {
+		return nil
+	}
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.settings = make(map[int64]model.ChatSettings, len(all))
+	for _, settings := range all {
+		s.settings[settings.ChatID] = settings
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package service provides business logic implementations.
+// Property-based tests for per chat settings.
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestChatTimezonesLoadProperty tests that every offered timezone can be
+// loaded and that other names are refused.
+func TestChatTimezonesLoadProperty(t *testing.T) {
+	offered := make(map[string]bool)
+	for _, tz := range ChatTimezones {
+		offered[tz] = true
+		if _, err := time.LoadLocation(tz); err != nil {
+			t.Fatalf("Offered timezone %s cannot be loaded: %v", tz, err)
+		}
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		tz := rapid.StringMatching(`[A-Za-z_/]{1,20}`).Draw(t, "tz")
+		if ValidChatTimezone(tz) != offered[tz] {
+			t.Fatalf("Timezone %q offered %v but valid %v", tz, offered[tz], ValidChatTimezone(tz))
+		}
+	})
+}
+
+// TestChatCleanupProperty tests that only offered cleanup delays are valid
+// and that the default is one of them.
+func TestChatCleanupProperty(t *testing.T) {
+	if !ValidChatCleanup(ChatDefaultCleanupMinutes) {
+		t.Fatalf("Default cleanup %d is not offered", ChatDefaultCleanupMinutes)
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		minutes := rapid.IntRange(-10, 200).Draw(t, "minutes")
+		offered := false
+		for _, m := range ChatCleanupOptions {
+			offered = offered || m == minutes
+		}
+		if ValidChatCleanup(minutes) != offered {
+			t.Fatalf("Cleanup %d offered %v but valid %v", minutes, offered, ValidChatCleanup(minutes))
+		}
+	})
+}
+
+// TestInQuietHoursProperty tests that quiet hours cover end-start hours of
+// the day (wrapping past midnight) and that equal hours are never quiet.
+func TestInQuietHoursProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		start := rapid.IntRange(0, 23).Draw(t, "start")
+		end := rapid.IntRange(0, 23).Draw(t, "end")
+
+		quiet := 0
+		for hour := 0; hour < 24; hour++ {
+			if InQuietHours(start, end, hour) {
+				quiet++
+			}
+		}
+		if want := (end - start + 24) % 24; quiet != want {
+			t.Fatalf("Quiet hours %d-%d cover %d hours, want %d", start, end, quiet, want)
+		}
+		if start != end && (!InQuietHours(start, end, start) || InQuietHours(start, end, end)) {
+			t.Fatalf("Quiet hours %d-%d must include the start and exclude the end", start, end)
+		}
+	})
+}
+
+// TestParseQuietHoursProperty tests that parsed quiet hours round-trip and
+// that 24 as the end means midnight.
+func TestParseQuietHoursProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		start := rapid.IntRange(0, 23).Draw(t, "start")
+		end := rapid.IntRange(0, 24).Draw(t, "end")
+
+		gotStart, gotEnd, err := ParseQuietHours(fmt.Sprintf("%d-%d", start, end))
+		if err != nil {
+			t.Fatalf("Parse %d-%d: %v", start, end, err)
+		}
+		if gotStart != start || gotEnd != end%24 {
+			t.Fatalf("Parse %d-%d = %d-%d", start, end, gotStart, gotEnd)
+		}
+	})
+
+	if start, end, err := ParseQuietHours("off"); err != nil || start != end {
+		t.Fatalf("off parsed as %d-%d, %v", start, end, err)
+	}
+	if _, _, err := ParseQuietHours("25-7"); err != ErrChatQuiet {
+		t.Fatalf("25-7 parsed, error %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// ChatStats holds today's game statistics of a chat
+type ChatStats struct {
+	Date          string // Day the stats belong to (YYYY-MM-DD)
+	TotalWagers   int64  // Sum of all bets placed today
+	BiggestWin    int64  // Largest single win today
+	BiggestWinner string // Username of the biggest win
+}
+
+// ChatStatsService tracks per-chat daily game statistics in memory.
+// Stats reset at midnight in the configured timezone and are lost on restart.
+type ChatStatsService struct {
+	mu       sync.Mutex
+	stats    map[int64]*ChatStats
+	timezone *time.Location
+	now      func() time.Time
+}
+
+// NewChatStatsService creates a new ChatStatsService instance.
+func NewChatStatsService(timezone *time.Location) *ChatStatsService {
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	return &ChatStatsService{
+		stats:    make(map[int64]*ChatStats),
+		timezone: timezone,
+		now:      time.Now,
+	}
+}
+
+// RecordWager adds a bet to the chat's total wagers
+func (s *ChatStatsService) RecordWager(chatID int64, amount int64) {
+	if amount <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.today(chatID).TotalWagers += amount
+}
+
+// RecordWin records a win, keeping it if it is the biggest of the day
+func (s *ChatStatsService) RecordWin(chatID int64, username string, amount int64) {
+	if amount <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.today(chatID)
+	if amount > stats.BiggestWin {
+		stats.BiggestWin = amount
+		stats.BiggestWinner = username
+	}
+}
+
+// Get returns a copy of the chat's stats for today
+func (s *ChatStatsService) Get(chatID int64) ChatStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.today(chatID)
+}
+
+// today returns the chat's stats, resetting them when the day changed.
+// Caller must hold s.mu.
+func (s *ChatStatsService) today(chatID int64) *ChatStats {
+	date := s.now().In(s.timezone).Format("2006-01-02")
+	stats, ok := s.stats[chatID]
+	if !ok || stats.Date != date {
+		stats = &ChatStats{Date: date}
+		s.stats[chatID] = stats
+	}
+	return stats
+}
@@ -0,0 +1,62 @@
+// Package service provides business logic implementations.
+// Property-based tests for per-chat daily statistics.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestChatStatsProperty tests that total wagers are the sum of recorded bets
+// and the biggest win is the maximum recorded win of the chat.
+func TestChatStatsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		s := NewChatStatsService(time.UTC)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		s.now = func() time.Time { return now }
+
+		var wantWagers, wantBiggest int64
+		n := rapid.IntRange(0, 50).Draw(t, "n")
+		for i := 0; i < n; i++ {
+			chatID := rapid.Int64Range(1, 2).Draw(t, "chatID")
+			amount := rapid.Int64Range(-100, 10000).Draw(t, "amount")
+			if rapid.Bool().Draw(t, "isWin") {
+				s.RecordWin(chatID, "user", amount)
+				if chatID == 1 && amount > wantBiggest {
+					wantBiggest = amount
+				}
+			} else {
+				s.RecordWager(chatID, amount)
+				if chatID == 1 && amount > 0 {
+					wantWagers += amount
+				}
+			}
+		}
+
+		stats := s.Get(1)
+		if stats.TotalWagers != wantWagers {
+			t.Fatalf("Total wagers %d, expected %d", stats.TotalWagers, wantWagers)
+		}
+		if stats.BiggestWin != wantBiggest {
+			t.Fatalf("Biggest win %d, expected %d", stats.BiggestWin, wantBiggest)
+		}
+	})
+}
+
+// TestChatStatsResetDaily tests that stats reset when the day changes
+func TestChatStatsResetDaily(t *testing.T) {
+	s := NewChatStatsService(time.UTC)
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	s.RecordWager(1, 500)
+	s.RecordWin(1, "alice", 800)
+
+	now = now.Add(2 * time.Minute)
+	stats := s.Get(1)
+	if stats.Date != "2024-05-02" || stats.TotalWagers != 0 || stats.BiggestWin != 0 || stats.BiggestWinner != "" {
+		t.Fatalf("Stats should reset on a new day, got %+v", stats)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// ComebackLookbackDays is the window before the last game in which a regular
+// must have played on ComebackConfig.RegularDays days
+const ComebackLookbackDays = 30
+
+// ComebackStatsDays is the period /comeback_stats measures
+const ComebackStatsDays = 30
+
+// Comeback errors
+var (
+	ErrComebackUnavailable = errors.New("回归奖励已领取或已过期")
+)
+
+// ComebackRules decides who gets a welcome-back offer.
+type ComebackRules struct {
+	Inactive    time.Duration // Time without playing before an offer
+	RegularDays int           // Days played in the lookback to count as a regular
+	MonthlyCap  int           // Offers per user per calendar month
+}
+
+// ComebackEligible reports whether a user with the given activity is owed an
+// offer at now: a regular who has not played for rules.Inactive, below the
+// monthly cap and not offered one since the last game.
+func ComebackEligible(a model.ComebackActivity, now time.Time, rules ComebackRules) bool {
+	if now.Sub(a.LastPlayed) < rules.Inactive {
+		return false
+	}
+	if a.PlayDays < rules.RegularDays || a.MonthOffers >= rules.MonthlyCap {
+		return false
+	}
+	return a.LastOfferAt == nil || a.LastOfferAt.Before(a.LastPlayed)
+}
+
+// ComebackNotifier DMs welcome-back offers with a claim button.
+type ComebackNotifier interface {
+	// SendComebackOffer sends the offer DM, returning the send error
+	SendComebackOffer(offer *model.ComebackOffer, text string) error
+}
+
+// ComebackService DMs a small welcome-back bonus to opted in regulars who
+// stopped playing. The bonus is credited when the user claims it with the
+// button of the DM, and offers are counted to measure how many come back.
+type ComebackService struct {
+	repo     *repository.ComebackRepository
+	notifier ComebackNotifier
+	rules    ComebackRules
+	bonus    int64
+	claimFor time.Duration // How long an offer can be claimed
+	loc      *time.Location
+}
+
+// NewComebackService creates a new ComebackService instance.
+func NewComebackService(repo *repository.ComebackRepository, bonus int64, rules ComebackRules, claimFor time.Duration, loc *time.Location) *ComebackService {
+	return &ComebackService{
+		repo:     repo,
+		rules:    rules,
+		bonus:    bonus,
+		claimFor: claimFor,
+		loc:      loc,
+	}
+}
+
+// SetNotifier sets the notifier used to send offers.
+func (s *ComebackService) SetNotifier(notifier ComebackNotifier) {
+	s.notifier = notifier
+}
+
+// Enabled reports whether a user opted in to welcome-back offers.
+func (s *ComebackService) Enabled(ctx context.Context, userID int64) (bool, error) {
+	return s.repo.IsOptedIn(ctx, userID)
+}
+
+// Enable opts a user in to welcome-back offers.
+func (s *ComebackService) Enable(ctx context.Context, userID int64) error {
+	return s.repo.Enable(ctx, userID)
+}
+
+// Disable opts a user out of welcome-back offers.
+func (s *ComebackService) Disable(ctx context.Context, userID int64) error {
+	return s.repo.Disable(ctx, userID)
+}
+
+// Run makes and sends the offers due at now. Returns the number delivered.
+// An offer whose DM fails still counts against the monthly cap, so users
+// who blocked the bot are not retried on every run.
+func (s *ComebackService) Run(ctx context.Context, now time.Time) int {
+	if s.bonus <= 0 || s.notifier == nil {
+		return 0
+	}
+
+	local := now.In(s.loc)
+	monthStart := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, s.loc)
+	inactiveSince := now.Add(-s.rules.Inactive)
+	playedSince := inactiveSince.AddDate(0, 0, -ComebackLookbackDays)
+
+	candidates, err := s.repo.Candidates(ctx, model.GameTransactionTypes(), playedSince, inactiveSince, monthStart, ComebackLookbackDays)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "comeback").Msg("Failed to list comeback candidates")
+		return 0
+	}
+
+	delivered := 0
+	for _, candidate := range candidates {
+		if !ComebackEligible(*candidate, now, s.rules) {
+			continue
+		}
+		offer, err := s.repo.CreateOffer(ctx, candidate.UserID, s.bonus, now.Add(s.claimFor))
+		if err != nil {
+			log.Error().Err(err).Str("operation", "comeback").Int64("user_id", candidate.UserID).Msg("Failed to create comeback offer")
+			continue
+		}
+		if err := s.notifier.SendComebackOffer(offer, FormatComebackOffer(offer, now.Sub(candidate.LastPlayed))); err != nil {
+			log.Debug().Err(err).Int64("user_id", candidate.UserID).Msg("Failed to send comeback offer")
+			continue
+		}
+		if err := s.repo.MarkDelivered(ctx, offer.ID); err != nil {
+			log.Warn().Err(err).Int64("offer_id", offer.ID).Msg("Failed to mark comeback offer delivered")
+		}
+		delivered++
+	}
+
+	if delivered > 0 {
+		log.Info().
+			Str("operation", "comeback").
+			Int("delivered", delivered).
+			Int64("bonus", s.bonus).
+			Msg("Comeback offers sent")
+	}
+	return delivered
+}
+
+// Claim credits the bonus of an offer to its user and returns the bonus and
+// the new balance. Returns ErrComebackUnavailable if it was claimed, expired
+// or belongs to someone else.
+func (s *ComebackService) Claim(ctx context.Context, offerID, userID int64) (int64, int64, error) {
+	user, bonus, err := s.repo.Claim(ctx, offerID, userID, fmt.Sprintf("回归奖励 #%d", offerID))
+	if err != nil {
+		if errors.Is(err, repository.ErrComebackOfferUnavailable) {
+			return 0, 0, ErrComebackUnavailable
+		}
+		return 0, 0, err
+	}
+
+	log.Info().
+		Str("operation", "comeback_claim").
+		Int64("user_id", userID).
+		Int64("offer_id", offerID).
+		Int64("bonus", bonus).
+		Msg("Comeback bonus claimed")
+	return bonus, user.Balance, nil
+}
+
+// Stats measures the offers delivered in the last ComebackStatsDays days.
+func (s *ComebackService) Stats(ctx context.Context, now time.Time) (*model.ComebackStats, error) {
+	return s.repo.Stats(ctx, now.AddDate(0, 0, -ComebackStatsDays), model.GameTransactionTypes())
+}
+
+// FormatComebackOffer returns the DM of an offer to a user away for the given time.
+func FormatComebackOffer(offer *model.ComebackOffer, away time.Duration) string {
+	return fmt.Sprintf("👋 好久不见！你已经 %d 天没来玩了\n"+
+		"🎁 送你一份回归奖励 %d 金币，点击下方按钮领取\n"+
+		"⏰ 领取截止: %s\n\n"+
+		"不想再收到此类私信？发送 /comeback off",
+		int(away.Hours()/24), offer.Bonus, offer.ExpiresAt.Format("01-02 15:04"))
+}
+
+// FormatComebackStats formats the offer statistics for admins.
+func FormatComebackStats(stats *model.ComebackStats) string {
+	percent := func(n int) float64 {
+		if stats.Offered == 0 {
+			return 0
+		}
+		return float64(n) * 100 / float64(stats.Offered)
+	}
+	return fmt.Sprintf("👋 回归奖励（近 %d 天）\n"+
+		"━━━━━━━━━━━━━━━\n"+
+		"📨 已送达: %d\n"+
+		"🎁 已领取: %d (%.1f%%)\n"+
+		"🎲 回来玩了: %d (%.1f%%)\n"+
+		"💰 发放金币: %d",
+		ComebackStatsDays, stats.Offered, stats.Claimed, percent(stats.Claimed),
+		stats.Returned, percent(stats.Returned), stats.BonusPaid)
+}
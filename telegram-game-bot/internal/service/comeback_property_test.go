@@ -0,0 +1,74 @@
+// Package service provides business logic implementations.
+// Property-based tests for welcome-back offers.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestComebackEligibleProperty tests that an offer is due exactly for
+// regulars away long enough, below the monthly cap and not offered one since
+// their last game.
+func TestComebackEligibleProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+		rules := ComebackRules{
+			Inactive:    time.Duration(rapid.IntRange(1, 14).Draw(t, "inactiveDays")) * 24 * time.Hour,
+			RegularDays: rapid.IntRange(1, 10).Draw(t, "regularDays"),
+			MonthlyCap:  rapid.IntRange(1, 3).Draw(t, "cap"),
+		}
+		a := model.ComebackActivity{
+			UserID:      1,
+			LastPlayed:  now.Add(-time.Duration(rapid.IntRange(0, 30*24).Draw(t, "awayHours")) * time.Hour),
+			PlayDays:    rapid.IntRange(0, 30).Draw(t, "playDays"),
+			MonthOffers: rapid.IntRange(0, 4).Draw(t, "monthOffers"),
+		}
+		offeredSince := false
+		if rapid.Bool().Draw(t, "offeredBefore") {
+			offerAt := a.LastPlayed.Add(time.Duration(rapid.IntRange(-100, 100).Draw(t, "offerHours")) * time.Hour)
+			a.LastOfferAt = &offerAt
+			offeredSince = !offerAt.Before(a.LastPlayed)
+		}
+
+		want := now.Sub(a.LastPlayed) >= rules.Inactive &&
+			a.PlayDays >= rules.RegularDays &&
+			a.MonthOffers < rules.MonthlyCap &&
+			!offeredSince
+		if got := ComebackEligible(a, now, rules); got != want {
+			t.Fatalf("ComebackEligible(%+v) = %v, want %v", a, got, want)
+		}
+	})
+}
+
+// TestComebackOfferOncePerAbsenceProperty tests that after an offer a user
+// gets no further one until playing again, however long the absence lasts.
+func TestComebackOfferOncePerAbsenceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		rules := ComebackRules{Inactive: 7 * 24 * time.Hour, RegularDays: 3, MonthlyCap: 10}
+		lastPlayed := time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)
+		a := model.ComebackActivity{UserID: 1, LastPlayed: lastPlayed, PlayDays: 3}
+
+		offerAt := lastPlayed.Add(rules.Inactive)
+		if !ComebackEligible(a, offerAt, rules) {
+			t.Fatalf("a regular away for %v should get an offer", rules.Inactive)
+		}
+		a.LastOfferAt = &offerAt
+		a.MonthOffers++
+
+		later := offerAt.Add(time.Duration(rapid.IntRange(0, 60*24).Draw(t, "laterHours")) * time.Hour)
+		if ComebackEligible(a, later, rules) {
+			t.Fatalf("second offer at %v without playing since %v", later, lastPlayed)
+		}
+
+		// Playing again starts a new absence
+		a.LastPlayed = offerAt.Add(time.Hour)
+		if !ComebackEligible(a, a.LastPlayed.Add(rules.Inactive), rules) {
+			t.Fatal("a new absence after playing again should get an offer")
+		}
+	})
+}
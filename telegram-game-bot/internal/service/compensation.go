@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+)
+
+// Incident kinds for losses caused by the bot
+const (
+	IncidentSicBoSettleFailed = "sicbo_settle_failed" // Settlement failed after bets were deducted
+	IncidentCreditFailed      = "credit_failed"       // Winnings could not be credited
+	IncidentRefundFailed      = "refund_failed"       // Bet refund after a send failure could not be credited
+)
+
+// incidentNames are the user facing names of incident kinds
+var incidentNames = map[string]string{
+	IncidentSicBoSettleFailed: "骰宝结算失败",
+	IncidentCreditFailed:      "奖金到账失败",
+	IncidentRefundFailed:      "下注退还失败",
+}
+
+// Compensation errors
+var (
+	ErrIncidentNotFound = errors.New("事故记录不存在")
+	ErrIncidentResolved = errors.New("事故已处理")
+)
+
+// CompensationClaim is an amount owed to a user because of an incident
+type CompensationClaim struct {
+	UserID int64
+	Amount int64
+}
+
+// CompensationNotifier delivers compensation DMs and approval requests.
+type CompensationNotifier interface {
+	UserNotifier
+	// NotifyAdmins sends a message to the admins (approval requests)
+	NotifyAdmins(text string)
+}
+
+// CompensationService automatically compensates users for losses caused by the bot.
+// Claims of one incident are batched into a single record, capped per user and per incident.
+// Small totals are paid immediately, large totals wait for admin approval.
+type CompensationService struct {
+	compRepo         *repository.CompensationRepository
+	userRepo         *repository.UserRepository
+	txRepo           *repository.TransactionRepository
+	accounts         *AccountService // Optional: credits each entry once by its key
+	userLock         *lock.UserLock
+	notifier         CompensationNotifier
+	autoApproveLimit int64
+	maxPerUser       int64
+	maxPerIncident   int64
+}
+
+// NewCompensationService creates a new CompensationService instance.
+func NewCompensationService(
+	compRepo *repository.CompensationRepository,
+	userRepo *repository.UserRepository,
+	txRepo *repository.TransactionRepository,
+	userLock *lock.UserLock,
+	autoApproveLimit int64,
+	maxPerUser int64,
+	maxPerIncident int64,
+) *CompensationService {
+	return &CompensationService{
+		compRepo:         compRepo,
+		userRepo:         userRepo,
+		txRepo:           txRepo,
+		userLock:         userLock,
+		autoApproveLimit: autoApproveLimit,
+		maxPerUser:       maxPerUser,
+		maxPerIncident:   maxPerIncident,
+	}
+}
+
+// SetNotifier sets the notifier used for user DMs and admin approval requests
+func (s *CompensationService) SetNotifier(notifier CompensationNotifier) {
+	s.notifier = notifier
+}
+
+// SetAccountService sets the service entries are credited through, making
+// each payout idempotent by its entry.
+func (s *CompensationService) SetAccountService(accounts *AccountService) {
+	s.accounts = accounts
+}
+
+// ReportIncident records an incident and compensates the affected users.
+// Must not be called while holding the user lock of an affected user.
+// Returns nil if there is nothing to compensate.
+func (s *CompensationService) ReportIncident(ctx context.Context, kind, description string, claims []CompensationClaim) (*model.CompensationIncident, error) {
+	entries := BuildCompensationEntries(claims, s.maxPerUser, s.maxPerIncident)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Amount
+	}
+
+	incident := &model.CompensationIncident{
+		Kind:        kind,
+		Description: description,
+		Status:      model.IncidentStatusPending,
+		TotalAmount: total,
+	}
+	if err := s.compRepo.CreateIncident(ctx, incident, entries); err != nil {
+		return nil, err
+	}
+
+	log.Warn().
+		Int64("incident_id", incident.ID).
+		Str("kind", kind).
+		Str("description", description).
+		Int("users", len(entries)).
+		Int64("total", total).
+		Msg("Compensation incident recorded")
+
+	if total > s.autoApproveLimit {
+		if s.notifier != nil {
+			s.notifier.NotifyAdmins(fmt.Sprintf(
+				"⚠️ 事故 #%d 需要审批\n\n"+
+					"类型: %s\n"+
+					"说明: %s\n"+
+					"影响用户: %d 人\n"+
+					"补偿总额: %d 金币\n\n"+
+					"批准: /comp_approve %d\n"+
+					"拒绝: /comp_reject %d",
+				incident.ID, IncidentName(kind), description, len(entries), total, incident.ID, incident.ID,
+			))
+		}
+		return incident, nil
+	}
+
+	if err := s.compRepo.ResolveIncident(ctx, incident.ID, model.IncidentStatusPaid, nil); err != nil {
+		return incident, err
+	}
+	incident.Status = model.IncidentStatusPaid
+	s.payIncident(ctx, incident)
+
+	return incident, nil
+}
+
+// Approve approves a pending incident and pays its entries.
+// Returns the incident and the number of users paid.
+func (s *CompensationService) Approve(ctx context.Context, incidentID, adminID int64) (*model.CompensationIncident, int, error) {
+	incident, err := s.resolve(ctx, incidentID, model.IncidentStatusPaid, adminID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	paid := s.payIncident(ctx, incident)
+
+	log.Info().
+		Int64("incident_id", incidentID).
+		Int64("admin_id", adminID).
+		Int("paid", paid).
+		Str("operation", "compensation_approve").
+		Msg("Compensation incident approved")
+
+	return incident, paid, nil
+}
+
+// Reject rejects a pending incident without paying.
+func (s *CompensationService) Reject(ctx context.Context, incidentID, adminID int64) (*model.CompensationIncident, error) {
+	incident, err := s.resolve(ctx, incidentID, model.IncidentStatusRejected, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int64("incident_id", incidentID).
+		Int64("admin_id", adminID).
+		Str("operation", "compensation_reject").
+		Msg("Compensation incident rejected")
+
+	return incident, nil
+}
+
+// GetPendingIncidents returns incidents waiting for approval.
+func (s *CompensationService) GetPendingIncidents(ctx context.Context, limit int) ([]*model.CompensationIncident, error) {
+	return s.compRepo.GetPendingIncidents(ctx, limit)
+}
+
+// resolve moves a pending incident to status, mapping repository errors
+func (s *CompensationService) resolve(ctx context.Context, incidentID int64, status string, adminID int64) (*model.CompensationIncident, error) {
+	if err := s.compRepo.ResolveIncident(ctx, incidentID, status, &adminID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrIncidentNotFound):
+			return nil, ErrIncidentNotFound
+		case errors.Is(err, repository.ErrIncidentResolved):
+			return nil, ErrIncidentResolved
+		}
+		return nil, err
+	}
+
+	incident, err := s.compRepo.GetIncident(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	return incident, nil
+}
+
+// payIncident credits all unpaid entries of an incident and DMs the users.
+// Returns the number of entries paid.
+func (s *CompensationService) payIncident(ctx context.Context, incident *model.CompensationIncident) int {
+	entries, err := s.compRepo.GetUnpaidEntries(ctx, incident.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("incident_id", incident.ID).Msg("Failed to load compensation entries")
+		return 0
+	}
+
+	paid := 0
+	for _, entry := range entries {
+		if err := s.payEntry(ctx, incident, entry); err != nil {
+			log.Error().Err(err).
+				Int64("incident_id", incident.ID).
+				Int64("user_id", entry.UserID).
+				Int64("amount", entry.Amount).
+				Msg("Failed to pay compensation entry")
+			continue
+		}
+		paid++
+	}
+	return paid
+}
+
+// payEntry credits a single compensation entry
+func (s *CompensationService) payEntry(ctx context.Context, incident *model.CompensationIncident, entry *model.CompensationEntry) error {
+	s.userLock.Lock(entry.UserID)
+	defer s.userLock.Unlock(entry.UserID)
+
+	desc := fmt.Sprintf("事故 #%d 补偿（%s）", incident.ID, IncidentName(incident.Kind))
+	if s.accounts != nil {
+		// A crash before the entry is marked paid leaves it unpaid; the key
+		// keeps the retried payout from crediting it twice
+		key := fmt.Sprintf("compensation:%d", entry.ID)
+		if _, err := s.accounts.UpdateBalance(ctx, entry.UserID, entry.Amount, model.TxTypeCompensation, &desc, key); err != nil {
+			return err
+		}
+		if _, err := s.compRepo.MarkEntryPaid(ctx, entry.ID); err != nil {
+			return err
+		}
+	} else {
+		if _, err := s.userRepo.UpdateBalance(ctx, entry.UserID, entry.Amount); err != nil {
+			return err
+		}
+		if _, err := s.compRepo.MarkEntryPaid(ctx, entry.ID); err != nil {
+			return err
+		}
+		_, _ = s.txRepo.Create(ctx, entry.UserID, entry.Amount, model.TxTypeCompensation, &desc)
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyUser(entry.UserID, fmt.Sprintf(
+			"🙏 非常抱歉！由于机器人故障（%s），你受到了影响。\n已为你补偿 %d 金币，感谢你的理解。",
+			IncidentName(incident.Kind), entry.Amount,
+		))
+	}
+	return nil
+}
+
+// BuildCompensationEntries merges claims per user and applies the caps.
+// Each user receives at most maxPerUser and the incident total never exceeds maxPerIncident
+// (a cap <= 0 means no cap). Entries are ordered by user ID for stable payouts.
+func BuildCompensationEntries(claims []CompensationClaim, maxPerUser, maxPerIncident int64) []*model.CompensationEntry {
+	perUser := make(map[int64]int64)
+	for _, claim := range claims {
+		if claim.Amount > 0 {
+			perUser[claim.UserID] += claim.Amount
+		}
+	}
+
+	userIDs := make([]int64, 0, len(perUser))
+	for userID := range perUser {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	var entries []*model.CompensationEntry
+	var total int64
+	for _, userID := range userIDs {
+		amount := perUser[userID]
+		if maxPerUser > 0 && amount > maxPerUser {
+			amount = maxPerUser
+		}
+		if maxPerIncident > 0 && total+amount > maxPerIncident {
+			amount = maxPerIncident - total
+		}
+		if amount <= 0 {
+			break
+		}
+		total += amount
+		entries = append(entries, &model.CompensationEntry{UserID: userID, Amount: amount})
+	}
+	return entries
+}
+
+// IncidentName returns the display name of an incident kind
+func IncidentName(kind string) string {
+	if name, ok := incidentNames[kind]; ok {
+		return name
+	}
+	return kind
+}
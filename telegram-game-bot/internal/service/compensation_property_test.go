@@ -0,0 +1,64 @@
+// Package service provides business logic implementations.
+// Property-based tests for compensation batching and caps.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestBuildCompensationEntriesProperty tests that entries are merged per user
+// and never exceed the per-user and per-incident caps.
+func TestBuildCompensationEntriesProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(0, 30).Draw(t, "n")
+		maxPerUser := rapid.Int64Range(0, 5000).Draw(t, "maxPerUser")
+		maxPerIncident := rapid.Int64Range(0, 20000).Draw(t, "maxPerIncident")
+
+		claims := make([]CompensationClaim, n)
+		owed := make(map[int64]int64)
+		for i := range claims {
+			claims[i] = CompensationClaim{
+				UserID: rapid.Int64Range(1, 10).Draw(t, "userID"),
+				Amount: rapid.Int64Range(-100, 3000).Draw(t, "amount"),
+			}
+			if claims[i].Amount > 0 {
+				owed[claims[i].UserID] += claims[i].Amount
+			}
+		}
+
+		entries := BuildCompensationEntries(claims, maxPerUser, maxPerIncident)
+
+		var total, owedTotal int64
+		seen := make(map[int64]bool)
+		for _, entry := range entries {
+			if seen[entry.UserID] {
+				t.Fatalf("User %d appears in more than one entry", entry.UserID)
+			}
+			seen[entry.UserID] = true
+
+			if entry.Amount <= 0 || entry.Amount > owed[entry.UserID] {
+				t.Fatalf("Entry amount %d out of range for owed %d", entry.Amount, owed[entry.UserID])
+			}
+			if maxPerUser > 0 && entry.Amount > maxPerUser {
+				t.Fatalf("Entry amount %d exceeds per-user cap %d", entry.Amount, maxPerUser)
+			}
+			total += entry.Amount
+		}
+		for _, amount := range owed {
+			if maxPerUser > 0 && amount > maxPerUser {
+				amount = maxPerUser
+			}
+			owedTotal += amount
+		}
+
+		if maxPerIncident > 0 && total > maxPerIncident {
+			t.Fatalf("Total %d exceeds per-incident cap %d", total, maxPerIncident)
+		}
+		// Nothing is cut unless the incident cap forces it
+		if (maxPerIncident <= 0 || owedTotal <= maxPerIncident) && total != owedTotal {
+			t.Fatalf("Total %d should equal capped owed total %d", total, owedTotal)
+		}
+	})
+}
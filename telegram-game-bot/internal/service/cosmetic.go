@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/cosmetic"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Cosmetic errors
+var (
+	ErrStarsShopDisabled  = errors.New("星星商店未开启")
+	ErrCosmeticNotFound   = errors.New("装扮不存在")
+	ErrCosmeticOwned      = errors.New("已拥有该装扮")
+	ErrCosmeticNotOwned   = errors.New("尚未拥有该装扮")
+	ErrStarPaymentInvalid = errors.New("支付信息无效")
+)
+
+// StarPayment is a successful payment reported by Telegram
+type StarPayment struct {
+	Currency         string
+	Total            int
+	Payload          string
+	TelegramChargeID string
+	ProviderChargeID string
+}
+
+// CosmeticService sells cosmetics for Telegram Stars.
+// It never reads or changes coin balances: cosmetics are outside the coin economy.
+type CosmeticService struct {
+	repo    *repository.CosmeticRepository
+	enabled bool
+}
+
+// NewCosmeticService creates a new CosmeticService instance.
+func NewCosmeticService(repo *repository.CosmeticRepository, enabled bool) *CosmeticService {
+	return &CosmeticService{repo: repo, enabled: enabled}
+}
+
+// Enabled reports whether cosmetics can be bought.
+func (s *CosmeticService) Enabled() bool {
+	return s.enabled
+}
+
+// CheckCheckout validates a checkout against the catalog: the payload must be
+// a cosmetic of the paying user, paid in Stars at the current price.
+func CheckCheckout(userID int64, payload, currency string, total int) (cosmetic.Item, error) {
+	itemID, buyerID, err := cosmetic.ParsePayload(payload)
+	if err != nil || buyerID != userID {
+		return cosmetic.Item{}, ErrStarPaymentInvalid
+	}
+	item, ok := cosmetic.Get(itemID)
+	if !ok {
+		return cosmetic.Item{}, ErrCosmeticNotFound
+	}
+	if currency != cosmetic.CurrencyStars || total != item.Stars {
+		return cosmetic.Item{}, ErrStarPaymentInvalid
+	}
+	return item, nil
+}
+
+// PrepareInvoice returns the cosmetic and the invoice payload of a purchase.
+func (s *CosmeticService) PrepareInvoice(ctx context.Context, userID int64, itemID string) (cosmetic.Item, string, error) {
+	if !s.enabled {
+		return cosmetic.Item{}, "", ErrStarsShopDisabled
+	}
+	item, ok := cosmetic.Get(itemID)
+	if !ok {
+		return cosmetic.Item{}, "", ErrCosmeticNotFound
+	}
+
+	owned, err := s.repo.Owns(ctx, userID, itemID)
+	if err != nil {
+		return cosmetic.Item{}, "", err
+	}
+	if owned {
+		return cosmetic.Item{}, "", ErrCosmeticOwned
+	}
+	return item, cosmetic.FormatPayload(item.ID, userID), nil
+}
+
+// ValidateCheckout decides whether Telegram may charge the user.
+// This is the last chance to refuse a payment, so it also refuses cosmetics
+// the user already owns.
+func (s *CosmeticService) ValidateCheckout(ctx context.Context, userID int64, payload, currency string, total int) (cosmetic.Item, error) {
+	if !s.enabled {
+		return cosmetic.Item{}, ErrStarsShopDisabled
+	}
+	item, err := CheckCheckout(userID, payload, currency, total)
+	if err != nil {
+		return cosmetic.Item{}, err
+	}
+
+	owned, err := s.repo.Owns(ctx, userID, item.ID)
+	if err != nil {
+		return cosmetic.Item{}, err
+	}
+	if owned {
+		return cosmetic.Item{}, ErrCosmeticOwned
+	}
+	return item, nil
+}
+
+// CompletePurchase records a successful payment and grants the cosmetic.
+// The user has already been charged, so the price and the enabled flag are not
+// checked again. Returns false if the payment was already recorded.
+func (s *CosmeticService) CompletePurchase(ctx context.Context, userID int64, payment StarPayment) (cosmetic.Item, bool, error) {
+	itemID, buyerID, err := cosmetic.ParsePayload(payment.Payload)
+	item, ok := cosmetic.Get(itemID)
+	if err != nil || buyerID != userID || !ok || payment.Currency != cosmetic.CurrencyStars || payment.TelegramChargeID == "" {
+		log.Error().
+			Int64("user_id", userID).
+			Str("payload", payment.Payload).
+			Str("currency", payment.Currency).
+			Int("total", payment.Total).
+			Str("charge_id", payment.TelegramChargeID).
+			Str("operation", "star_purchase").
+			Msg("Received payment that does not match a cosmetic")
+		return cosmetic.Item{}, false, ErrStarPaymentInvalid
+	}
+
+	purchase := &model.StarPurchase{
+		UserID:           userID,
+		ItemID:           item.ID,
+		Currency:         payment.Currency,
+		Amount:           payment.Total,
+		Payload:          payment.Payload,
+		TelegramChargeID: payment.TelegramChargeID,
+		ProviderChargeID: payment.ProviderChargeID,
+	}
+	created, err := s.repo.RecordPurchase(ctx, purchase, string(item.Kind))
+	if err != nil {
+		log.Error().Err(err).
+			Int64("user_id", userID).
+			Str("item_id", item.ID).
+			Str("charge_id", payment.TelegramChargeID).
+			Str("operation", "star_purchase").
+			Msg("Failed to record star purchase")
+		return cosmetic.Item{}, false, err
+	}
+
+	if created {
+		log.Info().
+			Int64("user_id", userID).
+			Str("item_id", item.ID).
+			Int("stars", payment.Total).
+			Str("charge_id", payment.TelegramChargeID).
+			Str("operation", "star_purchase").
+			Msg("Cosmetic purchased with stars")
+	}
+	return item, created, nil
+}
+
+// Owned returns the cosmetics owned by a user.
+func (s *CosmeticService) Owned(ctx context.Context, userID int64) ([]*model.UserCosmetic, error) {
+	return s.repo.ListOwned(ctx, userID)
+}
+
+// Equipped returns the equipped cosmetic of each kind.
+func (s *CosmeticService) Equipped(ctx context.Context, userID int64) (map[cosmetic.Kind]cosmetic.Item, error) {
+	owned, err := s.repo.ListOwned(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	equipped := make(map[cosmetic.Kind]cosmetic.Item)
+	for _, c := range owned {
+		if !c.Equipped {
+			continue
+		}
+		// Cosmetics removed from the catalog stay in the ledger but are no longer shown
+		if item, ok := cosmetic.Get(c.ItemID); ok {
+			equipped[item.Kind] = item
+		}
+	}
+	return equipped, nil
+}
+
+// Equip equips an owned cosmetic in its slot.
+func (s *CosmeticService) Equip(ctx context.Context, userID int64, itemID string) (cosmetic.Item, error) {
+	item, ok := cosmetic.Get(itemID)
+	if !ok {
+		return cosmetic.Item{}, ErrCosmeticNotFound
+	}
+	if err := s.repo.Equip(ctx, userID, item.ID, string(item.Kind)); err != nil {
+		if errors.Is(err, repository.ErrCosmeticNotOwned) {
+			return cosmetic.Item{}, ErrCosmeticNotOwned
+		}
+		return cosmetic.Item{}, err
+	}
+	return item, nil
+}
+
+// Unequip empties the slot of an owned cosmetic.
+func (s *CosmeticService) Unequip(ctx context.Context, userID int64, itemID string) (cosmetic.Item, error) {
+	item, ok := cosmetic.Get(itemID)
+	if !ok {
+		return cosmetic.Item{}, ErrCosmeticNotFound
+	}
+	if err := s.repo.Unequip(ctx, userID, string(item.Kind)); err != nil {
+		return cosmetic.Item{}, err
+	}
+	return item, nil
+}
@@ -0,0 +1,52 @@
+// Package service provides business logic implementations.
+// Property-based tests for cosmetics sold for Telegram Stars.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/cosmetic"
+)
+
+// TestCheckCheckoutProperty tests that a checkout is only accepted for a
+// cosmetic of the paying user, paid in Stars at exactly the catalog price.
+func TestCheckCheckoutProperty(t *testing.T) {
+	items := cosmetic.All()
+
+	rapid.Check(t, func(t *rapid.T) {
+		item := rapid.SampledFrom(items).Draw(t, "item")
+		buyerID := rapid.Int64Range(1, 1<<40).Draw(t, "buyerID")
+		payerID := rapid.SampledFrom([]int64{buyerID, buyerID + 1}).Draw(t, "payerID")
+		currency := rapid.SampledFrom([]string{cosmetic.CurrencyStars, "USD"}).Draw(t, "currency")
+		total := item.Stars + rapid.IntRange(-1, 1).Draw(t, "priceDelta")
+
+		got, err := CheckCheckout(payerID, cosmetic.FormatPayload(item.ID, buyerID), currency, total)
+
+		valid := payerID == buyerID && currency == cosmetic.CurrencyStars && total == item.Stars
+		if valid && (err != nil || got.ID != item.ID) {
+			t.Fatalf("Valid checkout of %s refused: %v", item.ID, err)
+		}
+		if !valid && err != ErrStarPaymentInvalid {
+			t.Fatalf("Invalid checkout (payer %d, buyer %d, %d %s) error = %v, want ErrStarPaymentInvalid",
+				payerID, buyerID, total, currency, err)
+		}
+	})
+}
+
+// TestCheckCheckoutUnknownItemProperty tests that payloads of cosmetics not in
+// the catalog are refused.
+func TestCheckCheckoutUnknownItemProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		itemID := rapid.StringMatching(`[a-z0-9_]{1,20}`).Draw(t, "itemID")
+		if _, ok := cosmetic.Get(itemID); ok {
+			t.Skip("Item exists")
+		}
+		userID := rapid.Int64Range(1, 1<<40).Draw(t, "userID")
+
+		if _, err := CheckCheckout(userID, cosmetic.FormatPayload(itemID, userID), cosmetic.CurrencyStars, 50); err != ErrCosmeticNotFound {
+			t.Fatalf("CheckCheckout of unknown item %q error = %v, want ErrCosmeticNotFound", itemID, err)
+		}
+	})
+}
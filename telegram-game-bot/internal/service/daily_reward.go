@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// dailyRewardHistoryFactor is how many EMA windows of history warm up the average
+const dailyRewardHistoryFactor = 3
+
+// InflationEMA returns the exponential moving average of the daily inflation
+// in percent of supply, over flows (the net coins users received per day,
+// oldest first) with the smoothing of a days-day window.
+func InflationEMA(flows []int64, supply int64, days int) float64 {
+	if len(flows) == 0 || supply <= 0 {
+		return 0
+	}
+	alpha := 2 / float64(days+1)
+	ema := float64(flows[0]) / float64(supply) * 100
+	for _, flow := range flows[1:] {
+		rate := float64(flow) / float64(supply) * 100
+		ema = alpha*rate + (1-alpha)*ema
+	}
+	return ema
+}
+
+// DynamicDailyReward scales base inverse to inflation: at the target
+// inflation the reward is base, at twice the target half of it. Deflation
+// pays the maximum. The result is bounded by minReward and maxReward.
+func DynamicDailyReward(base, minReward, maxReward int64, target, inflation float64) int64 {
+	if inflation <= 0 {
+		return maxReward
+	}
+	// Bounded as a float, tiny inflation would overflow int64
+	reward := math.Round(float64(base) * target / inflation)
+	if reward < float64(minReward) {
+		return minReward
+	}
+	if reward > float64(maxReward) {
+		return maxReward
+	}
+	return int64(reward)
+}
+
+// FormatDailyRewardInfo explains how today's dynamic reward was derived.
+func FormatDailyRewardInfo(reward, minReward, maxReward int64, inflation float64) string {
+	return fmt.Sprintf("📈 近期通胀: %+.2f%%/天，今日签到奖励 %d 金币（范围 %d-%d，通胀越高奖励越低）",
+		inflation, reward, minReward, maxReward)
+}
+
+// DailyRewardService scales the daily reward inverse to recent economy
+// inflation, computed from the net coins users received per day.
+type DailyRewardService struct {
+	repo      *repository.TreasuryRepository
+	base      int64
+	minReward int64
+	maxReward int64
+	target    float64 // Daily inflation in percent at which the reward is base
+	emaDays   int
+
+	mu        sync.Mutex
+	reward    int64
+	inflation float64
+}
+
+// NewDailyRewardService creates a new DailyRewardService instance.
+// Until the first recalculation the reward is base.
+func NewDailyRewardService(repo *repository.TreasuryRepository, base, minReward, maxReward int64, target float64, emaDays int) *DailyRewardService {
+	return &DailyRewardService{
+		repo:      repo,
+		base:      base,
+		minReward: minReward,
+		maxReward: maxReward,
+		target:    target,
+		emaDays:   emaDays,
+		reward:    base,
+	}
+}
+
+// Reward returns the current daily reward.
+func (s *DailyRewardService) Reward() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reward
+}
+
+// Info explains the current daily reward for /daily.
+func (s *DailyRewardService) Info() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return FormatDailyRewardInfo(s.reward, s.minReward, s.maxReward, s.inflation)
+}
+
+// Recalculate updates the reward from the inflation of the recent days.
+func (s *DailyRewardService) Recalculate(ctx context.Context) error {
+	snapshot, err := s.repo.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	flows, err := s.repo.DailyNetFlows(ctx, s.emaDays*dailyRewardHistoryFactor)
+	if err != nil {
+		return err
+	}
+
+	inflation := InflationEMA(flows, snapshot.UsersTotal, s.emaDays)
+	reward := DynamicDailyReward(s.base, s.minReward, s.maxReward, s.target, inflation)
+
+	s.mu.Lock()
+	previous := s.reward
+	s.reward, s.inflation = reward, inflation
+	s.mu.Unlock()
+
+	log.Info().
+		Float64("inflation", inflation).
+		Int64("supply", snapshot.UsersTotal).
+		Int64("previous_reward", previous).
+		Int64("reward", reward).
+		Str("operation", "daily_reward_recalc").
+		Msg("Daily reward recalculated")
+	return nil
+}
+
+// Run recalculates the reward, logging failures; the previous reward stays.
+func (s *DailyRewardService) Run(ctx context.Context, now time.Time) {
+	if err := s.Recalculate(ctx); err != nil {
+		log.Error().Err(err).Time("at", now).Msg("Failed to recalculate daily reward")
+	}
+}
@@ -0,0 +1,64 @@
+// Package service provides business logic implementations.
+// Property-based tests for the inflation based daily reward.
+package service
+
+import (
+	"math"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestDynamicDailyRewardBoundedProperty tests that the reward stays within
+// its bounds and never grows with inflation.
+func TestDynamicDailyRewardBoundedProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		minReward := rapid.Int64Range(1, 500).Draw(t, "min")
+		maxReward := rapid.Int64Range(minReward, 5000).Draw(t, "max")
+		base := rapid.Int64Range(minReward, maxReward).Draw(t, "base")
+		target := rapid.Float64Range(0.01, 10).Draw(t, "target")
+		low := rapid.Float64Range(-10, 10).Draw(t, "low")
+		high := low + rapid.Float64Range(0, 10).Draw(t, "delta")
+
+		atLow := DynamicDailyReward(base, minReward, maxReward, target, low)
+		atHigh := DynamicDailyReward(base, minReward, maxReward, target, high)
+		if atLow < minReward || atLow > maxReward || atHigh < minReward || atHigh > maxReward {
+			t.Fatalf("Rewards %d, %d outside [%d, %d]", atLow, atHigh, minReward, maxReward)
+		}
+		if atHigh > atLow {
+			t.Fatalf("Inflation %.3f pays %d, more than %d at %.3f", high, atHigh, atLow, low)
+		}
+	})
+}
+
+// TestDynamicDailyRewardAtTargetProperty tests that the base reward is paid
+// at the target inflation.
+func TestDynamicDailyRewardAtTargetProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		base := rapid.Int64Range(1, 5000).Draw(t, "base")
+		target := rapid.Float64Range(0.01, 10).Draw(t, "target")
+		if got := DynamicDailyReward(base, 1, 5000, target, target); got != base {
+			t.Fatalf("At target got %d, want %d", got, base)
+		}
+	})
+}
+
+// TestInflationEMAConstantProperty tests that a constant daily flow averages
+// to its own rate.
+func TestInflationEMAConstantProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		supply := rapid.Int64Range(1, 1_000_000_000).Draw(t, "supply")
+		flow := rapid.Int64Range(-1_000_000, 1_000_000).Draw(t, "flow")
+		n := rapid.IntRange(1, 30).Draw(t, "n")
+		days := rapid.IntRange(1, 30).Draw(t, "days")
+
+		flows := make([]int64, n)
+		for i := range flows {
+			flows[i] = flow
+		}
+		want := float64(flow) / float64(supply) * 100
+		if got := InflationEMA(flows, supply, days); math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+			t.Fatalf("EMA %v, want %v", got, want)
+		}
+	})
+}
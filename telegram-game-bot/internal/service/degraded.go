@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/cooldown"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// degradedProbeTimeout bounds each database probe
+const degradedProbeTimeout = 3 * time.Second
+
+// DegradedRefusal is the reply to balance changes while the bot runs degraded
+const DegradedRefusal = "🔧 维护中：数据库暂时不可用，涉及金币的操作已暂停，请稍后再试"
+
+// degradedAllowedCommands keep working while the database is down: they need
+// no database or answer from the last known values
+var degradedAllowedCommands = map[string]bool{
+	"/start":     true,
+	"/balance":   true,
+	"/top":       true,
+	"/cooldowns": true,
+}
+
+// DegradedAllows reports whether a command may run while the bot is degraded.
+// Messages that are not commands are allowed; text bets are refused by the caller.
+func DegradedAllows(text string) bool {
+	if !strings.HasPrefix(text, "/") {
+		return true
+	}
+	command, _, _ := strings.Cut(strings.Fields(text)[0], "@")
+	return degradedAllowedCommands[strings.ToLower(command)]
+}
+
+// DBPinger checks that the database is reachable.
+type DBPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DegradedNotifier alerts admins when the bot enters or leaves degraded mode.
+type DegradedNotifier interface {
+	NotifyAdmins(text string)
+}
+
+// CachedBalance is the last known balance of a user.
+type CachedBalance struct {
+	Balance int64
+	At      time.Time // When the balance was read or last changed
+}
+
+// DegradedService probes the database and switches the bot to degraded mode
+// after enough failed probes in a row, until a probe succeeds again. It keeps
+// the last known balances and leaderboard so read-only commands can still
+// answer while the database is down.
+type DegradedService struct {
+	db       DBPinger
+	failures int // Failed probes in a row before degrading
+	notifier DegradedNotifier
+
+	mu       sync.Mutex
+	failed   int // Failed probes in a row so far
+	degraded bool
+	since    time.Time // When degraded mode was entered
+	balances map[int64]CachedBalance
+	top      []*model.User
+	topAt    time.Time
+}
+
+// NewDegradedService creates a new DegradedService instance.
+func NewDegradedService(db DBPinger, failures int) *DegradedService {
+	if failures < 1 {
+		failures = 1
+	}
+	return &DegradedService{
+		db:       db,
+		failures: failures,
+		balances: make(map[int64]CachedBalance),
+	}
+}
+
+// SetNotifier sets the notifier alerted on entering and leaving degraded mode.
+func (s *DegradedService) SetNotifier(notifier DegradedNotifier) {
+	s.notifier = notifier
+}
+
+// Subscribe registers the service for balance changes on the bus, keeping
+// the cached balances current.
+func (s *DegradedService) Subscribe(bus *events.Bus) {
+	bus.OnBalanceChanged(s.HandleBalanceChanged)
+}
+
+// Degraded reports whether the bot runs degraded.
+func (s *DegradedService) Degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.degraded
+}
+
+// Probe pings the database and records the result at now, alerting admins
+// when the mode changes.
+func (s *DegradedService) Probe(ctx context.Context, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, degradedProbeTimeout)
+	err := s.db.Ping(ctx)
+	cancel()
+
+	entered, left, since := s.observe(err == nil, now)
+	switch {
+	case entered:
+		log.Error().Err(err).Str("operation", "degraded").Int("failures", s.failures).Msg("Database unreachable, entering degraded mode")
+		s.notify(fmt.Sprintf("🚨 数据库连续 %d 次检查失败，机器人进入降级模式\n"+
+			"涉及金币的操作已暂停，/balance 和 /top 使用缓存数据", s.failures))
+	case left:
+		log.Info().Str("operation", "degraded").Dur("duration", now.Sub(since)).Msg("Database reachable again, leaving degraded mode")
+		s.notify(fmt.Sprintf("✅ 数据库已恢复，机器人退出降级模式（持续 %s）", cooldown.Format(now.Sub(since))))
+	case err != nil:
+		log.Warn().Err(err).Str("operation", "degraded").Msg("Database probe failed")
+	}
+}
+
+// observe records a probe result at now. It reports whether degraded mode
+// was entered or left, and when the mode that was left had started.
+func (s *DegradedService) observe(ok bool, now time.Time) (entered, left bool, since time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ok {
+		s.failed = 0
+		if !s.degraded {
+			return false, false, time.Time{}
+		}
+		s.degraded = false
+		return false, true, s.since
+	}
+
+	s.failed++
+	if s.degraded || s.failed < s.failures {
+		return false, false, time.Time{}
+	}
+	s.degraded = true
+	s.since = now
+	return true, false, time.Time{}
+}
+
+// notify alerts the admins if a notifier is set
+func (s *DegradedService) notify(text string) {
+	if s.notifier != nil {
+		s.notifier.NotifyAdmins(text)
+	}
+}
+
+// RememberBalance caches a balance read from the database at at.
+func (s *DegradedService) RememberBalance(userID, balance int64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[userID] = CachedBalance{Balance: balance, At: at}
+}
+
+// HandleBalanceChanged applies a recorded transaction to the cached balance of its user.
+// Users whose balance was never read are not cached.
+func (s *DegradedService) HandleBalanceChanged(event events.BalanceChanged) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.balances[event.UserID]
+	if !ok {
+		return
+	}
+	cached.Balance += event.Amount
+	if event.At.After(cached.At) {
+		cached.At = event.At
+	}
+	s.balances[event.UserID] = cached
+}
+
+// CachedBalance returns the last known balance of a user.
+func (s *DegradedService) CachedBalance(userID int64) (CachedBalance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.balances[userID]
+	return cached, ok
+}
+
+// RememberTop caches the balance leaderboard read from the database at at.
+func (s *DegradedService) RememberTop(users []*model.User, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.top = users
+	s.topAt = at
+}
+
+// CachedTop returns the last known balance leaderboard and when it was read.
+func (s *DegradedService) CachedTop() ([]*model.User, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.top, s.topAt, s.top != nil
+}
+
+// FormatCachedAt describes how old a cached answer is, for replies served while degraded.
+func FormatCachedAt(at, now time.Time) string {
+	return fmt.Sprintf("⚠️ 数据库维护中，以下为 %s前的缓存数据", cooldown.Format(now.Sub(at)))
+}
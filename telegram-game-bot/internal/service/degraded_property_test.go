@@ -0,0 +1,107 @@
+// Package service provides business logic implementations.
+// Property-based tests for degraded mode.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// TestDegradedModeProperty tests that the bot degrades once the configured
+// number of probes failed in a row, recovers on the first successful probe,
+// and reports each transition exactly once.
+func TestDegradedModeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		failures := rapid.IntRange(1, 5).Draw(t, "failures")
+		s := NewDegradedService(nil, failures)
+		results := rapid.SliceOfN(rapid.Bool(), 0, 40).Draw(t, "results")
+
+		now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		failedInRow := 0
+		var enteredAt time.Time
+		for i, ok := range results {
+			now = now.Add(10 * time.Second)
+			wasDegraded := s.Degraded()
+			entered, left, since := s.observe(ok, now)
+
+			if ok {
+				failedInRow = 0
+			} else {
+				failedInRow++
+			}
+			wantDegraded := failedInRow >= failures
+			if s.Degraded() != wantDegraded {
+				t.Fatalf("probe %d: degraded = %v after %d failures in a row, want %v", i, s.Degraded(), failedInRow, wantDegraded)
+			}
+			if entered != (!wasDegraded && wantDegraded) {
+				t.Fatalf("probe %d: entered = %v", i, entered)
+			}
+			if left != (wasDegraded && !wantDegraded) {
+				t.Fatalf("probe %d: left = %v", i, left)
+			}
+			if entered {
+				enteredAt = now
+			}
+			if left && !since.Equal(enteredAt) {
+				t.Fatalf("probe %d: degraded since %v, want %v", i, since, enteredAt)
+			}
+		}
+	})
+}
+
+// TestDegradedCachedBalanceProperty tests that a cached balance follows the
+// transactions of its user and users never read are not cached.
+func TestDegradedCachedBalanceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		s := NewDegradedService(nil, 1)
+		readAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		balance := rapid.Int64Range(0, 1_000_000).Draw(t, "balance")
+		s.RememberBalance(1, balance, readAt)
+
+		want := balance
+		lastAt := readAt
+		n := rapid.IntRange(0, 30).Draw(t, "events")
+		for i := 0; i < n; i++ {
+			userID := rapid.Int64Range(1, 3).Draw(t, "userID")
+			amount := rapid.Int64Range(-10000, 10000).Draw(t, "amount")
+			at := readAt.Add(time.Duration(i+1) * time.Minute)
+			s.HandleBalanceChanged(events.BalanceChanged{UserID: userID, Amount: amount, At: at})
+			if userID == 1 {
+				want += amount
+				lastAt = at
+			}
+		}
+
+		cached, ok := s.CachedBalance(1)
+		if !ok || cached.Balance != want || !cached.At.Equal(lastAt) {
+			t.Fatalf("cached balance %+v (ok %v), want %d at %v", cached, ok, want, lastAt)
+		}
+		for _, userID := range []int64{2, 3} {
+			if _, ok := s.CachedBalance(userID); ok {
+				t.Fatalf("user %d was never read but is cached", userID)
+			}
+		}
+	})
+}
+
+// TestDegradedAllowsProperty tests that only read-only commands run while
+// degraded, whatever their arguments and bot mention.
+func TestDegradedAllowsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		suffix := rapid.SampledFrom([]string{"", "@GameBot", "@GameBot 100", " 100"}).Draw(t, "suffix")
+
+		allowed := rapid.SampledFrom([]string{"/start", "/balance", "/top", "/cooldowns", "/BALANCE"}).Draw(t, "allowed")
+		if !DegradedAllows(allowed + suffix) {
+			t.Fatalf("%q refused while degraded", allowed+suffix)
+		}
+
+		refused := rapid.SampledFrom([]string{"/dice", "/slot", "/pay", "/daily", "/sicbo", "/dj", "/redeem", "/my"}).Draw(t, "refused")
+		if DegradedAllows(refused + suffix) {
+			t.Fatalf("%q allowed while degraded", refused+suffix)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// digestTopGames is how many games the digest lists
+const digestTopGames = 3
+
+// digestFlagCandidates is how many of the day's winners are checked for flagging
+const digestFlagCandidates = 20
+
+// digestGameByType groups the transaction types of house games into games
+var digestGameByType = map[string]string{
+	model.TxTypeDice:      "🎲 骰子",
+	model.TxTypeSlot:      "🎰 老虎机",
+	model.TxTypeSicBoBet:  "🎲 骰宝",
+	model.TxTypeSicBoWin:  "🎲 骰宝",
+	model.TxTypeFreeSpin:  "🎁 免费旋转",
+	model.TxTypeDiceWin:   "🎲 梭哈骰子",
+	model.TxTypeDiceLose:  "🎲 梭哈骰子",
+	model.TxTypeBlackjack: "🃏 21点",
+}
+
+// DigestGame is the activity of one game in the digest.
+type DigestGame struct {
+	Name     string
+	Wagered  int64
+	HouseNet int64 // Positive when the house won
+	Rounds   int
+}
+
+// AdminDigest is the daily overview sent to the admins.
+type AdminDigest struct {
+	Date        time.Time
+	NewUsers    int
+	ActiveUsers int
+	Wagered     int64 // Stakes of all house games
+	HousePnL    int64 // Game and shop income of the treasury
+	Drift       int64 // User balances plus treasury, non-zero is a bug
+	TopGames    []DigestGame
+	Flagged     []*model.DailyRank // Players whose game profit today reached the flag threshold
+	Errors      uint64             // Errors logged since the previous digest
+}
+
+// SummarizeDigestGames groups the volumes per transaction type into games,
+// ordered by stakes, busiest first.
+func SummarizeDigestGames(volumes map[string]repository.GameVolume) []DigestGame {
+	byName := make(map[string]*DigestGame)
+	for txType, v := range volumes {
+		name, ok := digestGameByType[txType]
+		if !ok {
+			continue
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &DigestGame{Name: name}
+			byName[name] = g
+		}
+		g.Wagered += v.Wagered
+		g.HouseNet += v.HouseNet
+		g.Rounds += v.Rounds
+	}
+
+	games := make([]DigestGame, 0, len(byName))
+	for _, g := range byName {
+		games = append(games, *g)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Wagered != games[j].Wagered {
+			return games[i].Wagered > games[j].Wagered
+		}
+		return games[i].Name < games[j].Name
+	})
+	return games
+}
+
+// FormatAdminDigest formats the digest DM.
+func FormatAdminDigest(d *AdminDigest) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 每日运营摘要 %s\n\n", d.Date.Format("2006-01-02")))
+	sb.WriteString(fmt.Sprintf("👥 新用户: %d\n", d.NewUsers))
+	sb.WriteString(fmt.Sprintf("🙋 活跃用户: %d\n", d.ActiveUsers))
+	sb.WriteString(fmt.Sprintf("🎲 总下注: %d\n", d.Wagered))
+	sb.WriteString(fmt.Sprintf("🏦 庄家盈亏: %+d\n", d.HousePnL))
+	if d.Drift != 0 {
+		sb.WriteString(fmt.Sprintf("🚨 账目偏差: %+d（余额变动未记账，请检查 /treasury）\n", d.Drift))
+	}
+
+	sb.WriteString("\n🔥 热门游戏:\n")
+	if len(d.TopGames) == 0 {
+		sb.WriteString("今日暂无游戏记录\n")
+	}
+	for i, g := range d.TopGames {
+		if i == digestTopGames {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s: %d 局，下注 %d，庄家 %+d\n", i+1, g.Name, g.Rounds, g.Wagered, g.HouseNet))
+	}
+
+	sb.WriteString("\n🚩 可疑账户:\n")
+	if len(d.Flagged) == 0 {
+		sb.WriteString("无\n")
+	}
+	for _, r := range d.Flagged {
+		sb.WriteString(fmt.Sprintf("• %s (%d): 今日净赚 %d\n", r.Username, r.UserID, r.NetProfit))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n⚠️ 错误日志: %d 条", d.Errors))
+	return sb.String()
+}
+
+// ErrorCounts counts the errors logged by the process.
+type ErrorCounts interface {
+	Count() uint64
+}
+
+// DigestService builds the nightly admin digest from the stats, treasury and
+// ranking data, and DMs it to the admins. The last sent date is kept in
+// memory, so a restart after the digest hour sends that day's digest again.
+type DigestService struct {
+	repo       *repository.DigestRepository
+	treasury   *TreasuryService
+	ranking    *RankingService
+	errors     ErrorCounts // Optional: error logs are not counted if nil
+	notifier   UserNotifier
+	admins     []int64
+	flagProfit int64 // Game profit in a day from which a player is flagged (0 = no flagging)
+	hour       int   // Local hour the digest is sent at
+	loc        *time.Location
+
+	mu         sync.Mutex
+	lastSent   time.Time // Local date of the last digest sent
+	lastErrors uint64    // Error count when the last digest was sent
+}
+
+// NewDigestService creates a new DigestService instance.
+func NewDigestService(repo *repository.DigestRepository, treasury *TreasuryService, ranking *RankingService,
+	admins []int64, flagProfit int64, hour int, loc *time.Location) *DigestService {
+	return &DigestService{
+		repo:       repo,
+		treasury:   treasury,
+		ranking:    ranking,
+		admins:     admins,
+		flagProfit: flagProfit,
+		hour:       hour,
+		loc:        loc,
+	}
+}
+
+// SetNotifier sets the notifier delivering the digest.
+func (s *DigestService) SetNotifier(notifier UserNotifier) {
+	s.notifier = notifier
+}
+
+// SetErrorCounts sets the counter of logged errors.
+func (s *DigestService) SetErrorCounts(errors ErrorCounts) {
+	s.errors = errors
+}
+
+// Build builds the digest of the local day containing now.
+func (s *DigestService) Build(ctx context.Context, now time.Time) (*AdminDigest, error) {
+	local := now.In(s.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.loc)
+	d := &AdminDigest{Date: midnight}
+
+	var err error
+	if d.NewUsers, d.ActiveUsers, err = s.repo.Activity(ctx, midnight); err != nil {
+		return nil, err
+	}
+
+	txTypes := make([]string, 0, len(digestGameByType))
+	for txType := range digestGameByType {
+		txTypes = append(txTypes, txType)
+	}
+	volumes, err := s.repo.GameVolumes(ctx, midnight, txTypes)
+	if err != nil {
+		return nil, err
+	}
+	d.TopGames = SummarizeDigestGames(volumes)
+	for _, g := range d.TopGames {
+		d.Wagered += g.Wagered
+	}
+
+	report, err := s.treasury.Report(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	d.HousePnL = report.Today.HousePnL()
+	d.Drift = report.Drift()
+
+	if s.flagProfit > 0 {
+		winners, err := s.ranking.GetDailyWinnersForDate(ctx, local, digestFlagCandidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range winners {
+			if w.NetProfit >= s.flagProfit {
+				d.Flagged = append(d.Flagged, w)
+			}
+		}
+	}
+
+	if s.errors != nil {
+		s.mu.Lock()
+		d.Errors = s.errors.Count() - s.lastErrors
+		s.mu.Unlock()
+	}
+	return d, nil
+}
+
+// Due reports whether the digest of the day containing now is due: the
+// configured hour has been reached and it was not sent yet.
+func (s *DigestService) Due(now time.Time) bool {
+	local := now.In(s.loc)
+	if local.Hour() < s.hour {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	y, m, d := local.Date()
+	sy, sm, sd := s.lastSent.Date()
+	return y != sy || m != sm || d != sd
+}
+
+// Run sends the digest to the admins if it is due at now.
+func (s *DigestService) Run(ctx context.Context, now time.Time) {
+	if !s.Due(now) {
+		return
+	}
+
+	errorsBefore := uint64(0)
+	if s.errors != nil {
+		errorsBefore = s.errors.Count()
+	}
+	d, err := s.Build(ctx, now)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build admin digest")
+		return
+	}
+
+	s.mu.Lock()
+	s.lastSent = now.In(s.loc)
+	s.lastErrors = errorsBefore
+	s.mu.Unlock()
+
+	if s.notifier == nil {
+		return
+	}
+	text := FormatAdminDigest(d)
+	for _, adminID := range s.admins {
+		s.notifier.NotifyUser(adminID, text)
+	}
+	log.Info().
+		Int("admins", len(s.admins)).
+		Int("new_users", d.NewUsers).
+		Int("active_users", d.ActiveUsers).
+		Int64("house_pnl", d.HousePnL).
+		Int("flagged", len(d.Flagged)).
+		Msg("Admin digest sent")
+}
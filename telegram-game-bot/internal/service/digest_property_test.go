@@ -0,0 +1,93 @@
+// Package service provides business logic implementations.
+// Property-based tests for the admin digest.
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// TestSummarizeDigestGamesProperty tests that grouping transaction types into
+// games keeps the totals of house games and orders games by stakes.
+func TestSummarizeDigestGamesProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		txTypes := []string{model.TxTypeDice, model.TxTypeSlot, model.TxTypeSicBoBet, model.TxTypeSicBoWin, model.TxTypeDiceWin, model.TxTypeTransfer}
+		volumes := make(map[string]repository.GameVolume)
+		var wantWagered int64
+		for _, txType := range txTypes {
+			if !rapid.Bool().Draw(t, "has_"+txType) {
+				continue
+			}
+			v := repository.GameVolume{
+				Wagered:  rapid.Int64Range(0, 1e6).Draw(t, "wagered_"+txType),
+				HouseNet: rapid.Int64Range(-1e6, 1e6).Draw(t, "net_"+txType),
+				Rounds:   rapid.IntRange(0, 1000).Draw(t, "rounds_"+txType),
+			}
+			volumes[txType] = v
+			if txType != model.TxTypeTransfer {
+				wantWagered += v.Wagered
+			}
+		}
+
+		games := SummarizeDigestGames(volumes)
+		var gotWagered int64
+		seen := make(map[string]bool)
+		for i, g := range games {
+			if seen[g.Name] {
+				t.Fatalf("Game %s listed twice", g.Name)
+			}
+			seen[g.Name] = true
+			gotWagered += g.Wagered
+			if i > 0 && games[i-1].Wagered < g.Wagered {
+				t.Fatalf("Games not ordered by stakes: %+v", games)
+			}
+		}
+		if gotWagered != wantWagered {
+			t.Fatalf("Wagered %d, want %d", gotWagered, wantWagered)
+		}
+	})
+}
+
+// TestDigestDueProperty tests that the digest is due once per local day, from
+// the configured hour on.
+func TestDigestDueProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		s := &DigestService{hour: rapid.IntRange(0, 23).Draw(t, "hour"), loc: time.UTC}
+		now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rapid.IntRange(0, 3*24*60).Draw(t, "minutes")) * time.Minute)
+
+		if got, want := s.Due(now), now.Hour() >= s.hour; got != want {
+			t.Fatalf("Due at %v with hour %d = %v before sending", now, s.hour, got)
+		}
+		s.lastSent = now
+		later := now.Add(time.Duration(rapid.IntRange(0, 24*60).Draw(t, "later")) * time.Minute)
+		if got, want := s.Due(later), later.Day() != now.Day() && later.Hour() >= s.hour; got != want {
+			t.Fatalf("Due at %v after sending at %v = %v", later, now, got)
+		}
+	})
+}
+
+// TestFormatAdminDigest tests that the digest shows every section.
+func TestFormatAdminDigest(t *testing.T) {
+	text := FormatAdminDigest(&AdminDigest{
+		Date:        time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		NewUsers:    12,
+		ActiveUsers: 345,
+		Wagered:     67890,
+		HousePnL:    -1200,
+		Drift:       5,
+		TopGames:    []DigestGame{{Name: "🎰 老虎机", Wagered: 50000, HouseNet: 800, Rounds: 40}},
+		Flagged:     []*model.DailyRank{{UserID: 42, Username: "lucky", NetProfit: 99999}},
+		Errors:      7,
+	})
+	for _, want := range []string{"2026-05-01", "12", "345", "67890", "-1200", "账目偏差", "🎰 老虎机", "lucky (42)", "99999", "7 条"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Digest does not contain %q:\n%s", want, text)
+		}
+	}
+}
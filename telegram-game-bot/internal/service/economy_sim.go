@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"telegram-game-bot/internal/game/blackjack"
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/game/slot"
+	"telegram-game-bot/internal/model"
+)
+
+// Economy simulation limits
+const (
+	SimDefaultRounds     = 100000  // Rounds per scenario
+	SimMaxRounds         = 1000000 // Cap so /simulate stays fast
+	SimDefaultBet        = 100     // Stake of every simulated bet
+	SimDefaultDailyWager = 2000    // Coins an active player wagers per day, split evenly across the games
+	SimRobBalance        = 10000   // Balance of simulated robbers and victims
+)
+
+// EconomySimConfig configures an economy simulation.
+type EconomySimConfig struct {
+	Rounds      int              // Rounds per scenario
+	Bet         int64            // Stake of every simulated bet
+	DailyReward int64            // /daily reward, a coin faucet
+	DailyWager  int64            // Coins an active player wagers per day
+	RobAmounts  rob.AmountPolicy // Regular robbery amounts (nil = fixed)
+}
+
+// GameSimResult is the outcome of a simulated betting game.
+type GameSimResult struct {
+	Name    string
+	BetType string // Transaction type the game's bets are recorded with
+	Rounds  int
+	Wagered int64
+	Net     int64 // Player net result, negative when the house wins
+}
+
+// HouseEdge returns the share of the stakes kept by the house.
+func (r GameSimResult) HouseEdge() float64 {
+	if r.Wagered == 0 {
+		return 0
+	}
+	return -float64(r.Net) / float64(r.Wagered)
+}
+
+// RTP returns the share of the stakes paid back to players, 1 - HouseEdge.
+func (r GameSimResult) RTP() float64 {
+	return 1 - r.HouseEdge()
+}
+
+// RobSimResult is the outcome of simulated robberies with an item loadout.
+// Robberies move coins between players and create none.
+type RobSimResult struct {
+	Loadout  string
+	Attempts int
+	Net      int64 // Robber net result
+}
+
+// AvgPerAttempt returns the robber's average result of one attempt.
+func (r RobSimResult) AvgPerAttempt() float64 {
+	if r.Attempts == 0 {
+		return 0
+	}
+	return float64(r.Net) / float64(r.Attempts)
+}
+
+// EconomySimReport summarizes an economy simulation.
+type EconomySimReport struct {
+	Config     EconomySimConfig
+	Games      []GameSimResult
+	FreeSpinEV float64 // Average prize of a daily free spin
+	Robs       []RobSimResult
+	Elapsed    time.Duration
+}
+
+// AvgHouseEdge returns the house edge of a daily wager split evenly across the games.
+func (r *EconomySimReport) AvgHouseEdge() float64 {
+	if len(r.Games) == 0 {
+		return 0
+	}
+	var total float64
+	for _, g := range r.Games {
+		total += g.HouseEdge()
+	}
+	return total / float64(len(r.Games))
+}
+
+// FaucetPerPlayer returns the coins created per active player per day (daily reward and free spin).
+func (r *EconomySimReport) FaucetPerPlayer() float64 {
+	return float64(r.Config.DailyReward) + r.FreeSpinEV
+}
+
+// SinkPerPlayer returns the coins the house keeps per active player per day.
+func (r *EconomySimReport) SinkPerPlayer() float64 {
+	return float64(r.Config.DailyWager) * r.AvgHouseEdge()
+}
+
+// InflationPerPlayer returns the net coins created per active player per day.
+// Shop purchases, promo codes and events are not included.
+func (r *EconomySimReport) InflationPerPlayer() float64 {
+	return r.FaucetPerPlayer() - r.SinkPerPlayer()
+}
+
+// robLoadout is an item combination of a simulated robbery
+type robLoadout struct {
+	name        string
+	bloodthirst bool
+	bluntKnife  bool
+	greatSword  bool
+	thornArmor  bool // worn by the victim
+}
+
+// simRobLoadouts are the simulated robbery item combinations.
+// Shields and emperor's clothes are left out, they prevent the robbery altogether.
+var simRobLoadouts = []robLoadout{
+	{name: "无道具"},
+	{name: "饮血剑", bloodthirst: true},
+	{name: "钝刀", bluntKnife: true},
+	{name: "大宝剑", greatSword: true},
+	{name: "无道具 vs 荆棘刺甲", thornArmor: true},
+	{name: "大宝剑 vs 荆棘刺甲", greatSword: true, thornArmor: true},
+}
+
+// SimulateEconomy runs a Monte Carlo simulation of the payout tables and item
+// effects with the games' own calculators. Robbery outcomes are drawn by the
+// rob package and use the global random source.
+func SimulateEconomy(cfg EconomySimConfig, rng *rand.Rand) *EconomySimReport {
+	if cfg.Rounds <= 0 {
+		cfg.Rounds = SimDefaultRounds
+	}
+	if cfg.Rounds > SimMaxRounds {
+		cfg.Rounds = SimMaxRounds
+	}
+	if cfg.Bet <= 0 {
+		cfg.Bet = SimDefaultBet
+	}
+	if cfg.RobAmounts == nil {
+		cfg.RobAmounts = rob.FixedAmountPolicy{}
+	}
+
+	start := time.Now()
+	report := &EconomySimReport{Config: cfg}
+	report.Games = SimulateGames(cfg.Rounds, cfg.Bet, rng)
+
+	var freeSpinTotal int64
+	for i := 0; i < cfg.Rounds; i++ {
+		freeSpinTotal += slot.CalculateFreeSpinPrize(slot.DecodeSlot(rng.Intn(64) + 1))
+	}
+	report.FreeSpinEV = float64(freeSpinTotal) / float64(cfg.Rounds)
+
+	for _, loadout := range simRobLoadouts {
+		result := RobSimResult{Loadout: loadout.name, Attempts: cfg.Rounds}
+		for i := 0; i < cfg.Rounds; i++ {
+			result.Net += simulateRob(loadout, cfg.RobAmounts, SimRobBalance, SimRobBalance)
+		}
+		report.Robs = append(report.Robs, result)
+	}
+
+	report.Elapsed = time.Since(start)
+	return report
+}
+
+// SimulateGames plays rounds bets of every betting game with the games' own
+// calculators.
+func SimulateGames(rounds int, bet int64, rng *rand.Rand) []GameSimResult {
+	d6 := func() int { return rng.Intn(6) + 1 }
+
+	games := []struct {
+		name    string
+		betType string
+		play    func() int64
+	}{
+		{"骰子 /dice", model.TxTypeDice, func() int64 { return dice.CalculatePayout(d6(), d6(), bet) }},
+		{"三骰 /dice3", model.TxTypeDice, func() int64 { return dice.CalculateTriplePayout(d6(), d6(), d6(), bet) }},
+		{"三局两胜 /dicebo3", model.TxTypeDice, func() int64 {
+			var rounds [][2]int
+			for {
+				rounds = append(rounds, [2]int{d6(), d6()})
+				if payout, finished := dice.CalculateBestOfThreePayout(rounds, bet); finished {
+					return payout
+				}
+			}
+		}},
+		{"老虎机 /slot", model.TxTypeSlot, func() int64 {
+			left, middle, right := slot.DecodeSlot(rng.Intn(64) + 1)
+			return slot.CalculatePayout(left, middle, right, bet)
+		}},
+		{"21点 /blackjack（跟庄策略）", model.TxTypeBlackjack, func() int64 { return blackjack.SimulateHand(rng, bet) }},
+		{"骰宝 大小", model.TxTypeSicBoBet, func() int64 {
+			return sicbo.CalculatePayout(sicbo.BetTypeBig, 0, [3]int{d6(), d6(), d6()}, bet)
+		}},
+		{"骰宝 单点", model.TxTypeSicBoBet, func() int64 {
+			return sicbo.CalculatePayout(sicbo.BetTypeSingle, d6(), [3]int{d6(), d6(), d6()}, bet)
+		}},
+	}
+
+	results := make([]GameSimResult, 0, len(games))
+	for _, g := range games {
+		result := GameSimResult{Name: g.name, BetType: g.betType, Rounds: rounds}
+		for i := 0; i < rounds; i++ {
+			result.Wagered += bet
+			result.Net += g.play()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// simulateRob returns the robber's net result of one robbery, following the
+// outcome, amount and thorn armor rules of rob.RobGame.Rob.
+func simulateRob(l robLoadout, amounts rob.AmountPolicy, robberBalance, victimBalance int64) int64 {
+	successRate := rob.SuccessChance
+	if l.bloodthirst {
+		successRate = rob.BloodthirstSuccessChance
+	}
+	counterDelta, damagePercent := rob.CounterAttackModifiers(rob.CounterEffects{
+		VictimThornArmor: l.thornArmor,
+		RobberGreatSword: l.greatSword,
+		RobberBluntKnife: l.bluntKnife,
+	})
+
+	switch rob.DetermineOutcomeWithModifiers(successRate, counterDelta) {
+	case rob.OutcomeFail:
+		return 0
+	case rob.OutcomeCounterAttack:
+		amount := rob.ApplyCounterDamage(amounts.Amount(robberBalance), damagePercent)
+		if amount > robberBalance {
+			amount = robberBalance
+		}
+		return -amount
+	}
+
+	var amount int64
+	switch {
+	case l.bluntKnife:
+		amount = rob.GenerateBluntKnifeAmount()
+	case l.greatSword && rob.IsGreatSwordCritical():
+		amount = rob.CalculateGreatSwordCriticalAmount(victimBalance)
+	default:
+		amount = amounts.Amount(victimBalance)
+	}
+	if amount > victimBalance {
+		amount = victimBalance
+	}
+
+	// Thorn armor reflects double the loot unless the weapon bypasses defense
+	if l.thornArmor && !l.bluntKnife && !l.greatSword {
+		thorn := amount * 2
+		if thorn > robberBalance+amount {
+			thorn = robberBalance + amount
+		}
+		return amount - thorn
+	}
+	return amount
+}
+
+// FormatEconomySimReport formats a simulation report for admins.
+func FormatEconomySimReport(r *EconomySimReport) string {
+	var sb strings.Builder
+	sb.WriteString("📊 经济模拟报告\n")
+	sb.WriteString(fmt.Sprintf("每项 %d 次，单注 %d 金币，耗时 %s\n", r.Config.Rounds, r.Config.Bet, r.Elapsed.Round(time.Millisecond)))
+
+	sb.WriteString("\n🎰 庄家优势（正数 = 庄家赢）\n")
+	for _, g := range r.Games {
+		sb.WriteString(fmt.Sprintf("• %s: %+.2f%%\n", g.Name, g.HouseEdge()*100))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n🎁 每日免费转盘期望: %.1f 金币\n", r.FreeSpinEV))
+
+	sb.WriteString(fmt.Sprintf("\n🔫 打劫期望（双方余额 %d，金币在玩家间转移）\n", SimRobBalance))
+	for _, rb := range r.Robs {
+		sb.WriteString(fmt.Sprintf("• %s: %+.1f / 次\n", rb.Loadout, rb.AvgPerAttempt()))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n💹 每名活跃玩家每日（下注 %d 金币，平均分配到各游戏）\n", r.Config.DailyWager))
+	sb.WriteString(fmt.Sprintf("• 产出（签到 + 免费转盘）: %.1f\n", r.FaucetPerPlayer()))
+	sb.WriteString(fmt.Sprintf("• 回收（庄家优势 %.2f%%）: %.1f\n", r.AvgHouseEdge()*100, r.SinkPerPlayer()))
+	sb.WriteString(fmt.Sprintf("• 净通胀: %+.1f 金币\n", r.InflationPerPlayer()))
+	sb.WriteString("\n⚠️ 未计入商店消费、兑换码和活动奖励")
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+// Package service provides business logic implementations.
+// Property-based tests for the economy simulation.
+package service
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/game/slot"
+)
+
+// TestSimulateEconomyConvergesProperty tests that simulated house edges match
+// the exact edges of the payout tables.
+func TestSimulateEconomyConvergesProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		bet := rapid.Int64Range(1, 1000).Draw(t, "bet")
+		seed := rapid.Int64().Draw(t, "seed")
+		report := SimulateEconomy(EconomySimConfig{Rounds: 20000, Bet: bet, DailyReward: 500, DailyWager: 2000}, rand.New(rand.NewSource(seed)))
+
+		// Exact edges by enumerating all outcomes
+		var diceNet, tripleNet int64
+		for a := 1; a <= 6; a++ {
+			for b := 1; b <= 6; b++ {
+				diceNet += dice.CalculatePayout(a, b, bet)
+				for c := 1; c <= 6; c++ {
+					tripleNet += dice.CalculateTriplePayout(a, b, c, bet)
+				}
+			}
+		}
+		var slotNet, freeSpin int64
+		for v := 1; v <= 64; v++ {
+			l, m, r := slot.DecodeSlot(v)
+			slotNet += slot.CalculatePayout(l, m, r, bet)
+			freeSpin += slot.CalculateFreeSpinPrize(l, m, r)
+		}
+		exact := map[string]float64{
+			"骰子 /dice":  -float64(diceNet) / float64(36*bet),
+			"三骰 /dice3": -float64(tripleNet) / float64(216*bet),
+			"老虎机 /slot": -float64(slotNet) / float64(64*bet),
+		}
+
+		for _, g := range report.Games {
+			want, ok := exact[g.Name]
+			if !ok {
+				continue
+			}
+			// Payouts are at most 3x the stake, 20000 rounds keep the error well below 0.05
+			if math.Abs(g.HouseEdge()-want) > 0.05 {
+				t.Fatalf("%s house edge %.4f, exact %.4f", g.Name, g.HouseEdge(), want)
+			}
+		}
+		if math.Abs(report.FreeSpinEV-float64(freeSpin)/64) > 10 {
+			t.Fatalf("Free spin EV %.2f, exact %.2f", report.FreeSpinEV, float64(freeSpin)/64)
+		}
+		if got, want := report.InflationPerPlayer(), report.FaucetPerPlayer()-report.SinkPerPlayer(); got != want {
+			t.Fatalf("Inflation %.2f, want faucet - sink %.2f", got, want)
+		}
+	})
+}
+
+// TestSimulateRobBoundsProperty tests that a simulated robbery never moves more
+// than the balances allow and respects the weapon limits.
+func TestSimulateRobBoundsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		loadout := rapid.SampledFrom(simRobLoadouts).Draw(t, "loadout")
+		robberBalance := rapid.Int64Range(0, 100000).Draw(t, "robberBalance")
+		victimBalance := rapid.Int64Range(0, 100000).Draw(t, "victimBalance")
+
+		net := simulateRob(loadout, rob.FixedAmountPolicy{}, robberBalance, victimBalance)
+
+		if net > victimBalance {
+			t.Fatalf("Robber gained %d from a victim with %d", net, victimBalance)
+		}
+		if net < -robberBalance {
+			t.Fatalf("Robber lost %d with a balance of %d", -net, robberBalance)
+		}
+		if loadout.bluntKnife && net > rob.BluntKnifeMaxAmount {
+			t.Fatalf("Blunt knife robbed %d, max %d", net, rob.BluntKnifeMaxAmount)
+		}
+	})
+}
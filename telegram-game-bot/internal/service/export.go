@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// ExportCooldown is how long a user waits between two data exports.
+const ExportCooldown = 24 * time.Hour
+
+// ErrExportTooSoon is returned when the user already exported within the cooldown.
+var ErrExportTooSoon = errors.New("每天只能导出一次")
+
+// exportHeader is the header row of the transaction export
+var exportHeader = []string{"id", "created_at", "type", "class", "amount", "counterparty", "description"}
+
+// ExportService builds a CSV of a user's own transactions, at most once a day.
+type ExportService struct {
+	userRepo *repository.UserRepository
+	txRepo   *repository.TransactionRepository
+}
+
+// NewExportService creates a new ExportService instance.
+func NewExportService(userRepo *repository.UserRepository, txRepo *repository.TransactionRepository) *ExportService {
+	return &ExportService{userRepo: userRepo, txRepo: txRepo}
+}
+
+// CanExport checks if a user can request an export now.
+// Returns eligibility status and remaining time if not eligible.
+func (s *ExportService) CanExport(ctx context.Context, userID int64) (bool, time.Duration, error) {
+	return s.userRepo.CanExport(ctx, userID, ExportCooldown)
+}
+
+// Export builds the CSV of a user's transactions and records the export.
+// Returns ErrExportTooSoon if a concurrent export was recorded first.
+func (s *ExportService) Export(ctx context.Context, userID int64) ([]byte, int, error) {
+	transactions, err := s.txRepo.GetAllForExport(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTransactionsCSV(&buf, transactions); err != nil {
+		return nil, 0, err
+	}
+
+	claimed, err := s.userRepo.ClaimExport(ctx, userID, time.Now().Unix(), ExportCooldown)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !claimed {
+		return nil, 0, ErrExportTooSoon
+	}
+
+	log.Info().
+		Int64("user_id", userID).
+		Int("rows", len(transactions)).
+		Str("operation", "export_me").
+		Msg("User data exported")
+	return buf.Bytes(), len(transactions), nil
+}
+
+// WriteTransactionsCSV writes transactions as CSV with a header row. A UTF-8
+// BOM is written first so spreadsheet apps read the Chinese descriptions.
+func WriteTransactionsCSV(w io.Writer, transactions []*model.TransactionExport) error {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportHeader); err != nil {
+		return err
+	}
+	for _, tx := range transactions {
+		counterparty := ""
+		if tx.Counterparty != nil {
+			counterparty = strconv.FormatInt(*tx.Counterparty, 10)
+		}
+		description := ""
+		if tx.Description != nil {
+			description = csvSafe(*tx.Description)
+		}
+		if err := cw.Write([]string{
+			strconv.FormatInt(tx.ID, 10),
+			tx.CreatedAt.UTC().Format(time.RFC3339),
+			tx.Type,
+			model.TransactionClass(tx.Type),
+			strconv.FormatInt(tx.Amount, 10),
+			counterparty,
+			description,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe keeps spreadsheet apps from evaluating free text (which may contain
+// user names) as a formula
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+// Package service provides business logic implementations.
+// Property-based tests for user data exports.
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestWriteTransactionsCSVProperty tests that every transaction becomes one
+// CSV row that reads back to its values, with formula-like text neutralized.
+func TestWriteTransactionsCSVProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(0, 20).Draw(t, "n")
+		transactions := make([]*model.TransactionExport, n)
+		for i := range transactions {
+			tx := &model.TransactionExport{Transaction: model.Transaction{
+				ID:        int64(i + 1),
+				UserID:    42,
+				Amount:    rapid.Int64Range(-100000, 100000).Draw(t, "amount"),
+				Type:      rapid.SampledFrom([]string{model.TxTypeDiceWin, model.TxTypeTransfer, "custom"}).Draw(t, "type"),
+				CreatedAt: time.Unix(rapid.Int64Range(0, 2000000000).Draw(t, "at"), 0),
+			}}
+			if rapid.Bool().Draw(t, "hasDesc") {
+				desc := rapid.StringOf(rapid.SampledFrom([]rune("ab,\"\n=+-@金 "))).Draw(t, "desc")
+				tx.Description = &desc
+			}
+			if rapid.Bool().Draw(t, "booked") {
+				other := rapid.Int64Range(0, 1000).Draw(t, "counterparty")
+				tx.Counterparty = &other
+			}
+			transactions[i] = tx
+		}
+
+		var buf bytes.Buffer
+		if err := WriteTransactionsCSV(&buf, transactions); err != nil {
+			t.Fatalf("WriteTransactionsCSV: %v", err)
+		}
+		records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\uFEFF"))).ReadAll()
+		if err != nil {
+			t.Fatalf("output is not valid CSV: %v", err)
+		}
+		if len(records) != n+1 {
+			t.Fatalf("got %d records, want %d", len(records), n+1)
+		}
+
+		for i, tx := range transactions {
+			row := records[i+1]
+			if row[4] != strconv.FormatInt(tx.Amount, 10) {
+				t.Fatalf("row %d amount = %q, want %d", i, row[4], tx.Amount)
+			}
+			if row[3] != model.TransactionClass(tx.Type) {
+				t.Fatalf("row %d class = %q, want %q", i, row[3], model.TransactionClass(tx.Type))
+			}
+			if (tx.Counterparty == nil) != (row[5] == "") {
+				t.Fatalf("row %d counterparty = %q, booked %v", i, row[5], tx.Counterparty != nil)
+			}
+			want := ""
+			if tx.Description != nil {
+				want = *tx.Description
+				if want != "" && strings.ContainsRune("=+-@", rune(want[0])) {
+					want = "'" + want
+				}
+			}
+			if row[6] != want {
+				t.Fatalf("row %d description = %q, want %q", i, row[6], want)
+			}
+		}
+	})
+}
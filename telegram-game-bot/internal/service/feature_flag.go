@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Feature flags
+const (
+	FlagDiceInsurance = "dice_insurance" // /dice <金额> insure is accepted
+	FlagHeist         = "heist"          // /heist can be started
+	FlagCelebrations  = "celebrations"   // Big wins are celebrated with media
+	FlagBanter        = "banter"         // Game results occasionally get a taunt or congratulation
+)
+
+// FeatureFlagNames names the known flags for /flag.
+var FeatureFlagNames = map[string]string{
+	FlagDiceInsurance: "骰子保险",
+	FlagHeist:         "抢金库",
+	FlagCelebrations:  "大奖庆祝动画",
+	FlagBanter:        "趣味短语",
+}
+
+// Feature flag errors
+var (
+	ErrFeatureFlagUnknown  = errors.New("未知的功能开关")
+	ErrFeatureFlagScope    = errors.New("范围需为 global、chat <群ID> 或 user <用户ID>")
+	ErrFeatureFlagNotFound = errors.New("没有这项覆盖设置")
+)
+
+// featureKey identifies the override of a flag for one target
+type featureKey struct {
+	flag     string
+	scope    string
+	targetID int64
+}
+
+// featureEnabled resolves a flag for a user in a chat: a user override wins
+// over a chat override, which wins over a global override, which wins over
+// the default. chatID and userID may be 0 when unknown.
+func featureEnabled(overrides map[featureKey]bool, def bool, flag string, chatID, userID int64) bool {
+	if userID != 0 {
+		if enabled, ok := overrides[featureKey{flag, model.FlagScopeUser, userID}]; ok {
+			return enabled
+		}
+	}
+	if chatID != 0 {
+		if enabled, ok := overrides[featureKey{flag, model.FlagScopeChat, chatID}]; ok {
+			return enabled
+		}
+	}
+	if enabled, ok := overrides[featureKey{flag, model.FlagScopeGlobal, 0}]; ok {
+		return enabled
+	}
+	return def
+}
+
+// FeatureFlagService decides which features are on, replacing scattered
+// config booleans. Every flag has a default, usually from the config, which
+// admins override for everyone, one chat or one user. Overrides are cached
+// in memory since they are read on every game, and reloaded periodically so
+// changes made on another instance apply without a restart.
+type FeatureFlagService struct {
+	repo     *repository.FeatureFlagRepository
+	defaults map[string]bool // flag -> default, the known flags
+
+	mu        sync.Mutex
+	overrides map[featureKey]bool // nil until loaded
+	flags     []model.FeatureFlag
+}
+
+// NewFeatureFlagService creates a new FeatureFlagService instance.
+// defaults lists the known flags with their defaults.
+func NewFeatureFlagService(repo *repository.FeatureFlagRepository, defaults map[string]bool) *FeatureFlagService {
+	return &FeatureFlagService{repo: repo, defaults: defaults}
+}
+
+// Enabled reports whether a flag is on for a user in a chat, 0 if unknown.
+// Only the defaults apply while the overrides cannot be loaded.
+func (s *FeatureFlagService) Enabled(flag string, chatID, userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Failed to load feature flags")
+	}
+	return featureEnabled(s.overrides, s.defaults[flag], flag, chatID, userID)
+}
+
+// Defaults returns the known flags in name order with their defaults.
+func (s *FeatureFlagService) Defaults() ([]string, map[string]bool) {
+	names := make([]string, 0, len(s.defaults))
+	for name := range s.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, s.defaults
+}
+
+// Overrides returns all overrides ordered by flag, scope and target.
+func (s *FeatureFlagService) Overrides(ctx context.Context) ([]model.FeatureFlag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		return nil, err
+	}
+	return append([]model.FeatureFlag(nil), s.flags...), nil
+}
+
+// Set overrides a flag for a target: 0 with FlagScopeGlobal, a chat or a user.
+func (s *FeatureFlagService) Set(ctx context.Context, flag, scope string, targetID int64, enabled bool, adminID int64) error {
+	if err := s.validate(flag, scope, targetID); err != nil {
+		return err
+	}
+
+	f := &model.FeatureFlag{Flag: flag, Scope: scope, TargetID: targetID, Enabled: enabled, UpdatedBy: adminID}
+	if err := s.repo.Upsert(ctx, f); err != nil {
+		return err
+	}
+	s.reload(ctx)
+
+	log.Info().
+		Str("operation", "feature_flag_set").
+		Str("flag", flag).
+		Str("scope", scope).
+		Int64("target_id", targetID).
+		Bool("enabled", enabled).
+		Int64("admin_id", adminID).
+		Msg("Feature flag overridden")
+	return nil
+}
+
+// Clear removes the override of a flag for a target.
+func (s *FeatureFlagService) Clear(ctx context.Context, flag, scope string, targetID int64, adminID int64) error {
+	if err := s.validate(flag, scope, targetID); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.Delete(ctx, flag, scope, targetID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFeatureFlagNotFound
+	}
+	s.reload(ctx)
+
+	log.Info().
+		Str("operation", "feature_flag_clear").
+		Str("flag", flag).
+		Str("scope", scope).
+		Int64("target_id", targetID).
+		Int64("admin_id", adminID).
+		Msg("Feature flag override cleared")
+	return nil
+}
+
+// Refresh reloads the overrides, picking up changes made on other instances.
+func (s *FeatureFlagService) Refresh(ctx context.Context) error {
+	flags, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(flags)
+	return nil
+}
+
+// validate checks that a flag is known and the target matches the scope
+func (s *FeatureFlagService) validate(flag, scope string, targetID int64) error {
+	if _, ok := s.defaults[flag]; !ok {
+		return ErrFeatureFlagUnknown
+	}
+	switch scope {
+	case model.FlagScopeGlobal:
+		if targetID != 0 {
+			return ErrFeatureFlagScope
+		}
+	case model.FlagScopeChat, model.FlagScopeUser:
+		if targetID == 0 {
+			return ErrFeatureFlagScope
+		}
+	default:
+		return ErrFeatureFlagScope
+	}
+	return nil
+}
+
+// reload refreshes the cache after a change, logging failures
+func (s *FeatureFlagService) reload(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload feature flags")
+	}
+}
+
+// loadLocked fills the override cache if needed; the caller must hold s.mu
+func (s *FeatureFlagService) loadLocked(ctx context.Context) error {
+	if s.overrides != nil {
+		return nil
+	}
+	flags, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.store(flags)
+	return nil
+}
+
+// store replaces the cached overrides; the caller must hold s.mu
+func (s *FeatureFlagService) store(flags []model.FeatureFlag) {
+	s.flags = flags
+	s.overrides = make(map[featureKey]bool, len(flags))
+	for _, f := range flags {
+		s.overrides[featureKey{f.Flag, f.Scope, f.TargetID}] = f.Enabled
+	}
+}
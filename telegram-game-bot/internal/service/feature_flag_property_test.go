@@ -0,0 +1,82 @@
+// Package service provides business logic implementations.
+// Property-based tests for feature flags.
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestFeatureEnabledProperty tests that a user override wins over a chat
+// override, which wins over a global override, which wins over the default.
+func TestFeatureEnabledProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		const flag = FlagHeist
+		def := rapid.Bool().Draw(t, "default")
+		chatID := rapid.Int64Range(-3, 3).Draw(t, "chatID")
+		userID := rapid.Int64Range(0, 3).Draw(t, "userID")
+
+		overrides := make(map[featureKey]bool)
+		global := rapid.IntRange(-1, 1).Draw(t, "global")
+		chat := rapid.IntRange(-1, 1).Draw(t, "chat")
+		user := rapid.IntRange(-1, 1).Draw(t, "user")
+		if global >= 0 {
+			overrides[featureKey{flag, model.FlagScopeGlobal, 0}] = global == 1
+		}
+		if chat >= 0 {
+			overrides[featureKey{flag, model.FlagScopeChat, chatID}] = chat == 1
+		}
+		if user >= 0 {
+			overrides[featureKey{flag, model.FlagScopeUser, userID}] = user == 1
+		}
+		// Overrides of other flags and targets never apply
+		overrides[featureKey{FlagCelebrations, model.FlagScopeGlobal, 0}] = !def
+		overrides[featureKey{flag, model.FlagScopeChat, chatID + 100}] = !def
+		overrides[featureKey{flag, model.FlagScopeUser, userID + 100}] = !def
+
+		want := def
+		switch {
+		case user >= 0 && userID != 0:
+			want = user == 1
+		case chat >= 0 && chatID != 0:
+			want = chat == 1
+		case global >= 0:
+			want = global == 1
+		}
+		if got := featureEnabled(overrides, def, flag, chatID, userID); got != want {
+			t.Fatalf("featureEnabled(chat %d, user %d) = %v, want %v", chatID, userID, got, want)
+		}
+	})
+}
+
+// TestFeatureFlagValidateProperty tests that only known flags are accepted
+// and that global overrides have no target while chat and user overrides do.
+func TestFeatureFlagValidateProperty(t *testing.T) {
+	s := NewFeatureFlagService(nil, map[string]bool{FlagDiceInsurance: true, FlagHeist: true})
+
+	rapid.Check(t, func(t *rapid.T) {
+		flag := rapid.SampledFrom([]string{FlagDiceInsurance, FlagHeist, FlagCelebrations, "unknown"}).Draw(t, "flag")
+		scope := rapid.SampledFrom([]string{model.FlagScopeGlobal, model.FlagScopeChat, model.FlagScopeUser, "team"}).Draw(t, "scope")
+		targetID := rapid.Int64Range(-2, 2).Draw(t, "targetID")
+
+		err := s.validate(flag, scope, targetID)
+		switch {
+		case flag != FlagDiceInsurance && flag != FlagHeist:
+			if !errors.Is(err, ErrFeatureFlagUnknown) {
+				t.Fatalf("validate(%q) = %v, want ErrFeatureFlagUnknown", flag, err)
+			}
+		case scope == "team", scope == model.FlagScopeGlobal && targetID != 0, scope != model.FlagScopeGlobal && targetID == 0:
+			if !errors.Is(err, ErrFeatureFlagScope) {
+				t.Fatalf("validate(%q, %q, %d) = %v, want ErrFeatureFlagScope", flag, scope, targetID, err)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("validate(%q, %q, %d) = %v, want nil", flag, scope, targetID, err)
+			}
+		}
+	})
+}
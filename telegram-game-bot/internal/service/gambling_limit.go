@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+	"telegram-game-bot/internal/repository"
+)
+
+// Gambling limit rules
+const (
+	// gamblingTotalsKeepDays is how many days of totals are kept
+	gamblingTotalsKeepDays = 7
+	// gamblingLimitCacheTTL bounds how long another instance may miss changed limits
+	gamblingLimitCacheTTL = time.Minute
+)
+
+// Gambling limit errors
+var (
+	ErrInvalidGamblingLimit = errors.New("限额无效")
+	ErrGamblingLimitLocked  = errors.New("今日已达上限，明天才能放宽限额")
+)
+
+// Reasons a gambling limit refuses play
+const (
+	GamblingLimitWager = "wager" // Daily wager cap reached
+	GamblingLimitLoss  = "loss"  // Daily loss cap reached
+)
+
+// GamblingCounted reports whether a transaction counts towards gambling
+// limits: house games and PvP the user started. Being robbed is not counted.
+func GamblingCounted(txType string) bool {
+	if txType == model.TxTypeRobbed {
+		return false
+	}
+	class := model.TransactionClass(txType)
+	return class == model.TxClassGame || class == model.TxClassPvP
+}
+
+// GamblingLimitHit returns which limit the totals of a day reached, "" if
+// the user may still play. The wager cap is checked first.
+func GamblingLimitHit(limits model.GamblingLimits, totals model.GamblingDayTotals) string {
+	if limits.DailyWager > 0 && totals.Wagered >= limits.DailyWager {
+		return GamblingLimitWager
+	}
+	if limits.DailyLoss > 0 && -totals.Net >= limits.DailyLoss {
+		return GamblingLimitLoss
+	}
+	return ""
+}
+
+// GamblingLimitLoosens reports whether changing a limit from before to after
+// allows more play (0 = no limit).
+func GamblingLimitLoosens(before, after int64) bool {
+	if before == 0 {
+		return false
+	}
+	return after == 0 || after > before
+}
+
+// gamblingLimitEntry is a cached limit setting; nil limits means none set
+type gamblingLimitEntry struct {
+	limits   *model.GamblingLimits
+	loadedAt time.Time
+}
+
+// GamblingLimitService lets users cap how much they stake and lose per day.
+// Stakes and results are counted from the balance events of the event bus
+// for every user, so limits set during a day already cover that day. Once a
+// limit is reached, gambling is refused until the day rolls over.
+type GamblingLimitService struct {
+	repo *repository.GamblingLimitRepository
+	loc  *time.Location // Days start at midnight in this location
+	now  func() time.Time
+
+	mu        sync.Mutex
+	limits    map[int64]gamblingLimitEntry
+	prunedDay time.Time
+}
+
+// NewGamblingLimitService creates a new GamblingLimitService instance.
+func NewGamblingLimitService(repo *repository.GamblingLimitRepository, loc *time.Location) *GamblingLimitService {
+	return &GamblingLimitService{
+		repo:   repo,
+		loc:    loc,
+		now:    time.Now,
+		limits: make(map[int64]gamblingLimitEntry),
+	}
+}
+
+// Subscribe registers the service for balance changes on the bus.
+func (s *GamblingLimitService) Subscribe(bus *events.Bus) {
+	bus.OnBalanceChanged(s.HandleBalanceChanged)
+}
+
+// day returns the day containing t
+func (s *GamblingLimitService) day(t time.Time) time.Time {
+	return RecordPeriodStart(model.RecordPeriodDay, t, s.loc)
+}
+
+// HandleBalanceChanged adds a game or PvP transaction to the user's totals of
+// the day in the background, so the publisher is never delayed by the database.
+func (s *GamblingLimitService) HandleBalanceChanged(event events.BalanceChanged) {
+	if !GamblingCounted(event.TxType) || event.Amount == 0 {
+		return
+	}
+	at := event.At
+	if at.IsZero() {
+		at = s.now()
+	}
+	day := s.day(at)
+
+	var wagered int64
+	if event.Amount < 0 {
+		wagered = -event.Amount
+	}
+
+	go func() {
+		ctx := context.Background()
+		if err := s.repo.AddDayTotals(ctx, event.UserID, day, wagered, event.Amount); err != nil {
+			log.Warn().Err(err).Int64("user_id", event.UserID).Msg("Failed to count gambling totals")
+		}
+		s.prune(ctx, day)
+	}()
+}
+
+// prune removes old totals once per day
+func (s *GamblingLimitService) prune(ctx context.Context, day time.Time) {
+	s.mu.Lock()
+	if !day.After(s.prunedDay) {
+		s.mu.Unlock()
+		return
+	}
+	s.prunedDay = day
+	s.mu.Unlock()
+
+	if _, err := s.repo.DeleteDayTotalsBefore(ctx, day.AddDate(0, 0, -gamblingTotalsKeepDays)); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune gambling totals")
+	}
+}
+
+// Limits returns the limits of a user, zero if none are set.
+func (s *GamblingLimitService) Limits(ctx context.Context, userID int64) (model.GamblingLimits, error) {
+	s.mu.Lock()
+	entry, ok := s.limits[userID]
+	s.mu.Unlock()
+
+	if !ok || s.now().Sub(entry.loadedAt) >= gamblingLimitCacheTTL {
+		limits, err := s.repo.GetLimits(ctx, userID)
+		if err != nil {
+			return model.GamblingLimits{}, err
+		}
+		entry = s.store(userID, limits)
+	}
+	if entry.limits == nil {
+		return model.GamblingLimits{UserID: userID}, nil
+	}
+	return *entry.limits, nil
+}
+
+// Today returns the user's totals of the current day.
+func (s *GamblingLimitService) Today(ctx context.Context, userID int64) (*model.GamblingDayTotals, error) {
+	return s.repo.GetDayTotals(ctx, userID, s.day(s.now()))
+}
+
+// Check returns which limit the user reached today, "" if they may play.
+// Users without limits cost no query; lookup failures let the user play.
+func (s *GamblingLimitService) Check(ctx context.Context, userID int64) string {
+	limits, err := s.Limits(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to load gambling limits")
+		return ""
+	}
+	if limits.DailyWager == 0 && limits.DailyLoss == 0 {
+		return ""
+	}
+	totals, err := s.Today(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to load gambling totals")
+		return ""
+	}
+	return GamblingLimitHit(limits, *totals)
+}
+
+// SetWagerLimit sets the daily wager cap of a user (0 removes it).
+func (s *GamblingLimitService) SetWagerLimit(ctx context.Context, userID, amount int64) (model.GamblingLimits, error) {
+	return s.set(ctx, userID, func(l *model.GamblingLimits) { l.DailyWager = amount }, amount)
+}
+
+// SetLossLimit sets the daily loss cap of a user (0 removes it).
+func (s *GamblingLimitService) SetLossLimit(ctx context.Context, userID, amount int64) (model.GamblingLimits, error) {
+	return s.set(ctx, userID, func(l *model.GamblingLimits) { l.DailyLoss = amount }, amount)
+}
+
+// set applies a limit change. Limits can always be tightened, but not
+// loosened on a day a limit was already reached.
+func (s *GamblingLimitService) set(ctx context.Context, userID int64, apply func(*model.GamblingLimits), amount int64) (model.GamblingLimits, error) {
+	if amount < 0 {
+		return model.GamblingLimits{}, ErrInvalidGamblingLimit
+	}
+
+	// Read fresh limits, the cache may be stale on sharded instances
+	current, err := s.repo.GetLimits(ctx, userID)
+	if err != nil {
+		return model.GamblingLimits{}, err
+	}
+	old := model.GamblingLimits{UserID: userID}
+	if current != nil {
+		old = *current
+	}
+	updated := old
+	apply(&updated)
+
+	if GamblingLimitLoosens(old.DailyWager, updated.DailyWager) || GamblingLimitLoosens(old.DailyLoss, updated.DailyLoss) {
+		totals, err := s.Today(ctx, userID)
+		if err != nil {
+			return model.GamblingLimits{}, err
+		}
+		if GamblingLimitHit(old, *totals) != "" {
+			return model.GamblingLimits{}, ErrGamblingLimitLocked
+		}
+	}
+
+	if err := s.repo.SetLimits(ctx, userID, updated.DailyWager, updated.DailyLoss); err != nil {
+		return model.GamblingLimits{}, err
+	}
+	s.store(userID, &updated)
+
+	log.Info().
+		Int64("user_id", userID).
+		Int64("daily_wager", updated.DailyWager).
+		Int64("daily_loss", updated.DailyLoss).
+		Str("operation", "gambling_limits_set").
+		Msg("Gambling limits updated")
+	return updated, nil
+}
+
+// store caches the limits of a user
+func (s *GamblingLimitService) store(userID int64, limits *model.GamblingLimits) gamblingLimitEntry {
+	now := s.now()
+	entry := gamblingLimitEntry{limits: limits, loadedAt: now}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits[userID] = entry
+
+	// Drop stale entries now and then so the cache only holds active users
+	if len(s.limits)%1024 == 0 {
+		for id, e := range s.limits {
+			if now.Sub(e.loadedAt) >= gamblingLimitCacheTTL {
+				delete(s.limits, id)
+			}
+		}
+	}
+	return entry
+}
@@ -0,0 +1,66 @@
+// Package service provides business logic implementations.
+// Property-based tests for daily gambling limits.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestGamblingLimitHitProperty tests that play is refused exactly when a set
+// limit is reached, and never without limits.
+func TestGamblingLimitHitProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		limits := model.GamblingLimits{
+			DailyWager: rapid.Int64Range(0, 10000).Draw(t, "wagerLimit"),
+			DailyLoss:  rapid.Int64Range(0, 10000).Draw(t, "lossLimit"),
+		}
+		totals := model.GamblingDayTotals{
+			Wagered: rapid.Int64Range(0, 20000).Draw(t, "wagered"),
+			Net:     rapid.Int64Range(-20000, 20000).Draw(t, "net"),
+		}
+
+		wagerHit := limits.DailyWager > 0 && totals.Wagered >= limits.DailyWager
+		lossHit := limits.DailyLoss > 0 && -totals.Net >= limits.DailyLoss
+
+		switch hit := GamblingLimitHit(limits, totals); {
+		case wagerHit && hit != GamblingLimitWager:
+			t.Fatalf("Wager limit %d reached at %d, got %q", limits.DailyWager, totals.Wagered, hit)
+		case !wagerHit && lossHit && hit != GamblingLimitLoss:
+			t.Fatalf("Loss limit %d reached at net %d, got %q", limits.DailyLoss, totals.Net, hit)
+		case !wagerHit && !lossHit && hit != "":
+			t.Fatalf("No limit reached, got %q", hit)
+		}
+	})
+}
+
+// TestGamblingLimitLoosensProperty tests that removing or raising a limit
+// loosens it, while setting, keeping or lowering one does not.
+func TestGamblingLimitLoosensProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		before := rapid.Int64Range(0, 10000).Draw(t, "before")
+		after := rapid.Int64Range(0, 10000).Draw(t, "after")
+
+		want := before > 0 && (after == 0 || after > before)
+		if got := GamblingLimitLoosens(before, after); got != want {
+			t.Fatalf("Loosens(%d, %d) = %v, want %v", before, after, got, want)
+		}
+	})
+}
+
+// TestGamblingCounted tests which transactions count towards the limits.
+func TestGamblingCounted(t *testing.T) {
+	for _, txType := range []string{model.TxTypeDice, model.TxTypeSicBoBet, model.TxTypeRob, model.TxTypeDuelEscrow} {
+		if !GamblingCounted(txType) {
+			t.Errorf("%s is not counted", txType)
+		}
+	}
+	for _, txType := range []string{model.TxTypeRobbed, model.TxTypeDaily, model.TxTypeTransfer} {
+		if GamblingCounted(txType) {
+			t.Errorf("%s is counted", txType)
+		}
+	}
+}
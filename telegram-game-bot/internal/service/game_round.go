@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/slot"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// gameRoundWindow is how far from a transaction its round may be recorded:
+// the stake is booked before the roll, winnings after the animation
+const gameRoundWindow = time.Minute
+
+// Game round errors
+var (
+	ErrGameRoundNotFound    = errors.New("找不到对应的游戏记录")
+	ErrGameRoundNotGame     = errors.New("该交易不属于可回放的游戏")
+	ErrGameRoundInvalid     = errors.New("游戏记录数据无效")
+	ErrGameRoundUnknownGame = errors.New("不支持回放的游戏")
+)
+
+// gameRoundByTxType maps the transaction types of recorded games to the game
+var gameRoundByTxType = map[string]string{
+	model.TxTypeDice:       model.GameRoundDice,
+	model.TxTypeDiceInsure: model.GameRoundDice,
+	model.TxTypeSlot:       model.GameRoundSlot,
+}
+
+// GameRoundReplay is a recorded round re-executed with the game calculators.
+type GameRoundReplay struct {
+	Steps  []string // Step by step explanation
+	Payout int64    // Recomputed net payout
+	Refund int64    // Recomputed insurance refund
+	Match  bool     // The recomputed outcome equals the recorded one
+}
+
+// ReplayGameRound re-executes the calculators of a recorded round on its
+// recorded inputs and compares the outcome with the recorded one.
+func ReplayGameRound(round *model.GameRound) (*GameRoundReplay, error) {
+	replay := &GameRoundReplay{}
+	step := func(format string, args ...any) {
+		replay.Steps = append(replay.Steps, fmt.Sprintf(format, args...))
+	}
+
+	switch round.Game {
+	case model.GameRoundDice:
+		if len(round.Values) != 2 || !validDie(round.Values[0]) || !validDie(round.Values[1]) {
+			return nil, ErrGameRoundInvalid
+		}
+		d1, d2 := round.Values[0], round.Values[1]
+		step("Stake %d on dice", round.Bet)
+		if round.Premium > 0 {
+			step("Insured for a premium of %d, refunding %d%% of a lost stake", round.Premium, round.RefundPercent)
+		}
+		step("Telegram rolled %d + %d = %d", d1, d2, d1+d2)
+		replay.Payout = dice.CalculatePayout(d1, d2, round.Bet)
+		step("Dice payout rules give %+d (%s)", replay.Payout, describePayout(replay.Payout, round.Bet))
+		if round.Premium > 0 {
+			replay.Refund = dice.InsuranceRefund(d1, d2, round.Bet, round.RefundPercent)
+			step("Insurance refunds %d", replay.Refund)
+		}
+	case model.GameRoundSlot:
+		if len(round.Values) != 1 || round.Values[0] < 1 || round.Values[0] > 64 {
+			return nil, ErrGameRoundInvalid
+		}
+		left, middle, right := slot.DecodeSlot(round.Values[0])
+		step("Stake %d on the slot machine", round.Bet)
+		step("Telegram rolled slot value %d, decoded as %s %s %s",
+			round.Values[0], slot.SymbolNames[left], slot.SymbolNames[middle], slot.SymbolNames[right])
+		replay.Payout = slot.CalculatePayout(left, middle, right, round.Bet)
+		step("Slot payout rules give %+d (%s)", replay.Payout, describePayout(replay.Payout, round.Bet))
+	default:
+		return nil, ErrGameRoundUnknownGame
+	}
+
+	replay.Match = replay.Payout == round.Payout && replay.Refund == round.Refund
+	if replay.Match {
+		step("Recorded payout %+d and refund %d match", round.Payout, round.Refund)
+	} else {
+		step("MISMATCH: recorded payout %+d and refund %d, replayed %+d and %d",
+			round.Payout, round.Refund, replay.Payout, replay.Refund)
+	}
+	return replay, nil
+}
+
+// validDie reports whether v is a die face
+func validDie(v int) bool {
+	return v >= 1 && v <= 6
+}
+
+// describePayout names the outcome of a net payout
+func describePayout(payout, bet int64) string {
+	switch {
+	case payout > bet:
+		return "jackpot"
+	case payout > 0:
+		return "win"
+	case payout == 0:
+		return "push, stake returned"
+	default:
+		return "loss"
+	}
+}
+
+// GameRoundService records the rounds of house games so support can replay
+// them when a player disputes an outcome.
+type GameRoundService struct {
+	repo   *repository.GameRoundRepository
+	txRepo *repository.TransactionRepository
+}
+
+// NewGameRoundService creates a new GameRoundService instance.
+func NewGameRoundService(repo *repository.GameRoundRepository, txRepo *repository.TransactionRepository) *GameRoundService {
+	return &GameRoundService{repo: repo, txRepo: txRepo}
+}
+
+// Record stores a played round. Failures are logged, the round is already settled.
+func (s *GameRoundService) Record(ctx context.Context, round *model.GameRound) {
+	if err := s.repo.Create(ctx, round); err != nil {
+		log.Warn().Err(err).
+			Str("game", round.Game).
+			Int64("user_id", round.UserID).
+			Msg("Failed to record game round")
+	}
+}
+
+// Get returns a recorded round.
+func (s *GameRoundService) Get(ctx context.Context, id int64) (*model.GameRound, error) {
+	round, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrGameRoundNotFound) {
+		return nil, ErrGameRoundNotFound
+	}
+	return round, err
+}
+
+// ForTransaction returns the transaction and the round it was booked for.
+func (s *GameRoundService) ForTransaction(ctx context.Context, txID int64) (*model.GameRound, *model.Transaction, error) {
+	tx, err := s.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			return nil, nil, ErrTxNotFound
+		}
+		return nil, nil, err
+	}
+	game, ok := gameRoundByTxType[tx.Type]
+	if !ok {
+		return nil, tx, ErrGameRoundNotGame
+	}
+
+	round, err := s.repo.FindNear(ctx, tx.UserID, game, tx.CreatedAt, gameRoundWindow)
+	if errors.Is(err, repository.ErrGameRoundNotFound) {
+		return nil, tx, ErrGameRoundNotFound
+	}
+	if err != nil {
+		return nil, tx, err
+	}
+	return round, tx, nil
+}
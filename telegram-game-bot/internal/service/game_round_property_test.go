@@ -0,0 +1,76 @@
+// Package service provides business logic implementations.
+// Property-based tests for game round replays.
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/game/dice"
+	"telegram-game-bot/internal/game/slot"
+	"telegram-game-bot/internal/model"
+)
+
+// TestReplayGameRoundProperty tests that a round recorded with the
+// calculators' outcome replays as a match and any other outcome does not.
+func TestReplayGameRoundProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		bet := rapid.Int64Range(1, 200_000).Draw(t, "bet")
+		round := &model.GameRound{Bet: bet}
+
+		if rapid.Bool().Draw(t, "slot") {
+			value := rapid.IntRange(1, 64).Draw(t, "value")
+			left, middle, right := slot.DecodeSlot(value)
+			round.Game = model.GameRoundSlot
+			round.Values = []int{value}
+			round.Payout = slot.CalculatePayout(left, middle, right, bet)
+		} else {
+			d1 := rapid.IntRange(1, 6).Draw(t, "dice1")
+			d2 := rapid.IntRange(1, 6).Draw(t, "dice2")
+			round.Game = model.GameRoundDice
+			round.Values = []int{d1, d2}
+			round.Payout = dice.CalculatePayout(d1, d2, bet)
+			if rapid.Bool().Draw(t, "insured") {
+				round.Premium = dice.InsurancePremium(bet, 10)
+				round.RefundPercent = rapid.IntRange(0, 100).Draw(t, "refundPercent")
+				round.Refund = dice.InsuranceRefund(d1, d2, bet, round.RefundPercent)
+			}
+		}
+
+		replay, err := ReplayGameRound(round)
+		if err != nil {
+			t.Fatalf("ReplayGameRound(%+v) failed: %v", round, err)
+		}
+		if !replay.Match || replay.Payout != round.Payout || replay.Refund != round.Refund {
+			t.Fatalf("recorded round %+v replayed as %+v", round, replay)
+		}
+		if len(replay.Steps) == 0 {
+			t.Fatal("replay has no explanation")
+		}
+
+		round.Payout += rapid.Int64Range(1, 1000).Draw(t, "tamper")
+		if replay, err := ReplayGameRound(round); err != nil || replay.Match {
+			t.Fatalf("tampered round %+v replayed as a match (err %v)", round, err)
+		}
+	})
+}
+
+// TestReplayGameRoundInvalidProperty tests that rounds with impossible
+// values are rejected instead of replayed.
+func TestReplayGameRoundInvalidProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		round := &model.GameRound{Bet: 100}
+		if rapid.Bool().Draw(t, "slot") {
+			round.Game = model.GameRoundSlot
+			round.Values = []int{rapid.SampledFrom([]int{-1, 0, 65, 100}).Draw(t, "value")}
+		} else {
+			round.Game = model.GameRoundDice
+			round.Values = []int{rapid.IntRange(1, 6).Draw(t, "dice1"), rapid.SampledFrom([]int{0, 7, -3}).Draw(t, "dice2")}
+		}
+		if _, err := ReplayGameRound(round); !errors.Is(err, ErrGameRoundInvalid) {
+			t.Fatalf("ReplayGameRound(%+v) = %v, want ErrGameRoundInvalid", round, err)
+		}
+	})
+}
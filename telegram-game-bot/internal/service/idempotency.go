@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"telegram-game-bot/internal/model"
+)
+
+// Idempotency errors.
+var (
+	ErrIdempotencyUnavailable = errors.New("idempotency keys are not enabled")
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was used for a different request")
+)
+
+// Operations recorded with idempotency keys
+const (
+	idempotencyOpUpdateBalance = "update_balance"
+	idempotencyOpTransfer      = "transfer"
+)
+
+// idempotencyFingerprint identifies the parameters of a request, so a key
+// replayed with different ones is refused instead of answered with a result
+// that does not belong to it. Each parameter is prefixed with its length so
+// different parameters never join to the same fingerprint.
+func idempotencyFingerprint(params ...any) string {
+	var sb strings.Builder
+	for _, param := range params {
+		value := fmt.Sprint(param)
+		fmt.Fprintf(&sb, "%d:%s;", len(value), value)
+	}
+	return sb.String()
+}
+
+// checkReplay checks that a key found already used was used for the same
+// request.
+func checkReplay(record *model.IdempotencyKey, operation, fingerprint string) error {
+	if record.Operation != operation || record.Fingerprint != fingerprint {
+		return ErrIdempotencyKeyReused
+	}
+	return nil
+}
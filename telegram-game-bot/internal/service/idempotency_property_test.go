@@ -0,0 +1,27 @@
+// Package service provides business logic implementations.
+// Property-based tests for idempotency keys.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestIdempotencyFingerprintProperty tests that requests with different
+// parameters never share a fingerprint, e.g. (1, 23, "x") and (1, 2, "3x").
+func TestIdempotencyFingerprintProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		a := []any{rapid.Int64().Draw(t, "a0"), rapid.Int64().Draw(t, "a1"), rapid.String().Draw(t, "a2")}
+		b := []any{rapid.Int64().Draw(t, "b0"), rapid.Int64().Draw(t, "b1"), rapid.String().Draw(t, "b2")}
+
+		same := a[0] == b[0] && a[1] == b[1] && a[2] == b[2]
+		if (idempotencyFingerprint(a...) == idempotencyFingerprint(b...)) != same {
+			t.Fatalf("Fingerprints of %v and %v: %q, %q", a, b, idempotencyFingerprint(a...), idempotencyFingerprint(b...))
+		}
+	})
+
+	if idempotencyFingerprint(1, 23, "x") == idempotencyFingerprint(1, 2, "3x") {
+		t.Fatal("Adjacent parameters collide")
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// InventoryCleanupService purges used up and expired inventory rows and
+// warns owners before their expiring items are purged.
+type InventoryCleanupService struct {
+	inventoryRepo     *repository.InventoryRepository
+	notifier          UserNotifier
+	warnBefore        time.Duration
+	purchaseRetention int // Days of daily purchase records to keep
+}
+
+// NewInventoryCleanupService creates a new InventoryCleanupService instance.
+func NewInventoryCleanupService(inventoryRepo *repository.InventoryRepository, warnBefore time.Duration, purchaseRetention int) *InventoryCleanupService {
+	return &InventoryCleanupService{
+		inventoryRepo:     inventoryRepo,
+		warnBefore:        warnBefore,
+		purchaseRetention: purchaseRetention,
+	}
+}
+
+// SetNotifier sets the notifier used for expiry warnings.
+func (s *InventoryCleanupService) SetNotifier(notifier UserNotifier) {
+	s.notifier = notifier
+}
+
+// Run warns about expiring items, then purges empty and expired inventory rows.
+// Each step is best effort so one failing table does not block the others.
+func (s *InventoryCleanupService) Run(ctx context.Context) {
+	warned := s.warnExpiring(ctx)
+
+	items, err := s.inventoryRepo.CleanEmptyItems(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "clean_items").Msg("Failed to clean inventory items")
+	}
+	effects, err := s.inventoryRepo.CleanExpiredEffects(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "clean_effects").Msg("Failed to clean expired effects")
+	}
+	locks, err := s.inventoryRepo.CleanExpiredLocks(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "clean_locks").Msg("Failed to clean expired handcuff locks")
+	}
+	var purchases int64
+	if s.purchaseRetention > 0 {
+		purchases, err = s.inventoryRepo.CleanOldDailyPurchases(ctx, s.purchaseRetention)
+		if err != nil {
+			log.Error().Err(err).Str("operation", "clean_daily_purchases").Msg("Failed to clean daily purchases")
+		}
+	}
+
+	if warned+int(items+effects+locks+purchases) > 0 {
+		log.Info().
+			Str("operation", "inventory_cleanup").
+			Int("warned", warned).
+			Int64("items", items).
+			Int64("effects", effects).
+			Int64("locks", locks).
+			Int64("daily_purchases", purchases).
+			Msg("Inventory cleanup finished")
+	}
+}
+
+// warnExpiring notifies owners of items expiring within warnBefore, once per item.
+// Returns the number of warnings sent.
+func (s *InventoryCleanupService) warnExpiring(ctx context.Context) int {
+	if s.notifier == nil || s.warnBefore <= 0 {
+		return 0
+	}
+
+	items, err := s.inventoryRepo.GetExpiringItems(ctx, s.warnBefore)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "get_expiring_items").Msg("Failed to get expiring items")
+		return 0
+	}
+
+	now := time.Now()
+	warned := 0
+	for _, item := range items {
+		if err := s.inventoryRepo.MarkExpiryWarned(ctx, item.UserID, item.ItemType); err != nil {
+			log.Error().Err(err).Int64("user_id", item.UserID).Str("item", item.ItemType).Msg("Failed to mark expiry warning")
+			continue
+		}
+		s.notifier.NotifyUser(item.UserID, FormatExpiryWarning(item, now))
+		warned++
+	}
+	return warned
+}
+
+// FormatExpiryWarning returns the expiry warning message of an item
+func FormatExpiryWarning(item repository.UserItem, now time.Time) string {
+	name := item.ItemType
+	if cfg, ok := shop.GetItem(shop.ItemType(item.ItemType)); ok {
+		name = cfg.Emoji + " " + cfg.Name
+	}
+	remaining := "近期"
+	if item.ExpiresAt != nil {
+		remaining = shop.FormatRemainingTime(int64(item.ExpiresAt.Sub(now).Seconds())) + "后"
+	}
+	return fmt.Sprintf("⏳ 道具即将过期\n\n你的 %s（剩余%d次）将在%s过期清除，请尽快使用", name, item.UseCount, remaining)
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Maintenance limits
+const (
+	MinMaintenanceDuration = time.Minute
+	MaxMaintenanceDuration = 24 * time.Hour
+	MaxMaintenanceDelay    = 7 * 24 * time.Hour
+)
+
+// Maintenance errors
+var (
+	ErrMaintenanceExists   = errors.New("已有计划中的维护，请先取消")
+	ErrNoMaintenance       = errors.New("当前没有计划中的维护")
+	ErrMaintenanceSyntax   = errors.New("时间或时长格式错误")
+	ErrMaintenanceTime     = errors.New("维护开始时间需在未来 7 天内")
+	ErrMaintenanceDuration = errors.New("维护时长需在 1 分钟到 24 小时之间")
+)
+
+// MaintenanceStage is how far a maintenance window has progressed. Every
+// stage is announced to the chats once, in order; stages passed while the bot
+// was down are skipped.
+type MaintenanceStage int
+
+const (
+	MaintenanceUnannounced MaintenanceStage = iota - 1 // 尚未公告
+	MaintenanceScheduled                               // 已计划
+	MaintenanceSoon                                    // 1 小时内开始
+	MaintenanceImminent                                // 10 分钟内开始
+	MaintenanceActive                                  // 维护中
+	MaintenanceOver                                    // 已结束
+)
+
+// Countdown reminders before a maintenance window starts
+const (
+	maintenanceSoon     = time.Hour
+	maintenanceImminent = 10 * time.Minute
+)
+
+// maintenanceBlockedCommands start rounds that could still be running when
+// maintenance starts, so they are refused shortly before it
+var maintenanceBlockedCommands = map[string]bool{
+	"/sicbo":     true,
+	"/duijue":    true,
+	"/heist":     true,
+	"/blackjack": true,
+}
+
+// MaintenanceBlocks reports whether a message starts a new round and is
+// therefore refused shortly before maintenance.
+func MaintenanceBlocks(text string) bool {
+	if !strings.HasPrefix(text, "/") {
+		return false
+	}
+	command, _, _ := strings.Cut(strings.Fields(text)[0], "@")
+	return maintenanceBlockedCommands[strings.ToLower(command)]
+}
+
+// MaintenanceStageAt returns the stage of a window at now.
+func MaintenanceStageAt(w *model.MaintenanceWindow, now time.Time) MaintenanceStage {
+	switch {
+	case !now.Before(w.EndsAt):
+		return MaintenanceOver
+	case !now.Before(w.StartsAt):
+		return MaintenanceActive
+	case w.StartsAt.Sub(now) <= maintenanceImminent:
+		return MaintenanceImminent
+	case w.StartsAt.Sub(now) <= maintenanceSoon:
+		return MaintenanceSoon
+	default:
+		return MaintenanceScheduled
+	}
+}
+
+// ParseMaintenanceSchedule parses the arguments of /maintenance schedule:
+// a start time and a duration. The start is either "15:04" (the next time the
+// clock shows it), "2006-01-02 15:04" or relative like "+30m"; the duration
+// is either minutes or a Go duration like "1h30m". Times are read in now's
+// location.
+func ParseMaintenanceSchedule(args []string, now time.Time) (time.Time, time.Duration, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return time.Time{}, 0, ErrMaintenanceSyntax
+	}
+	durationArg := args[len(args)-1]
+	startArg := strings.Join(args[:len(args)-1], " ")
+
+	var startsAt time.Time
+	switch {
+	case strings.HasPrefix(startArg, "+"):
+		delay, err := time.ParseDuration(startArg[1:])
+		if err != nil {
+			return time.Time{}, 0, ErrMaintenanceSyntax
+		}
+		startsAt = now.Add(delay)
+	case len(args) == 3:
+		t, err := time.ParseInLocation("2006-01-02 15:04", startArg, now.Location())
+		if err != nil {
+			return time.Time{}, 0, ErrMaintenanceSyntax
+		}
+		startsAt = t
+	default:
+		clock, err := time.ParseInLocation("15:04", startArg, now.Location())
+		if err != nil {
+			return time.Time{}, 0, ErrMaintenanceSyntax
+		}
+		startsAt = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !startsAt.After(now) {
+			startsAt = startsAt.AddDate(0, 0, 1)
+		}
+	}
+
+	duration, err := time.ParseDuration(durationArg)
+	if err != nil {
+		minutes, convErr := strconv.Atoi(durationArg)
+		if convErr != nil {
+			return time.Time{}, 0, ErrMaintenanceSyntax
+		}
+		duration = time.Duration(minutes) * time.Minute
+	}
+	return startsAt, duration, nil
+}
+
+// ChatLister returns the chats the bot operates in.
+type ChatLister interface {
+	Chats(ctx context.Context) []int64
+}
+
+// MaintenanceService schedules maintenance windows. The window is announced
+// to the configured chats with a countdown; shortly before it starts new
+// rounds are refused so none is cut off, and while it lasts players cannot
+// use the bot. The current window is cached in memory and refreshed by Tick,
+// so every instance sees windows scheduled on another one.
+type MaintenanceService struct {
+	repo      *repository.MaintenanceRepository
+	chats     []int64
+	lister    ChatLister // Optional: current chats, replacing chats
+	blockLead time.Duration
+	loc       *time.Location
+	notifier  ChatAnnouncer
+
+	mu     sync.Mutex
+	window *model.MaintenanceWindow // Scheduled or active window, nil if none
+	now    func() time.Time
+}
+
+// NewMaintenanceService creates a new MaintenanceService instance.
+// Announcements go to chats; new rounds are refused from blockLead before a
+// window starts. Times are shown in loc.
+func NewMaintenanceService(repo *repository.MaintenanceRepository, chats []int64, blockLead time.Duration, loc *time.Location) *MaintenanceService {
+	return &MaintenanceService{
+		repo:      repo,
+		chats:     chats,
+		blockLead: blockLead,
+		loc:       loc,
+		now:       time.Now,
+	}
+}
+
+// SetNotifier sets the notifier used for maintenance announcements
+func (s *MaintenanceService) SetNotifier(notifier ChatAnnouncer) {
+	s.notifier = notifier
+}
+
+// SetChatLister sets the source of the chats announced to, so chats added at
+// runtime are announced to as well.
+func (s *MaintenanceService) SetChatLister(lister ChatLister) {
+	s.lister = lister
+}
+
+// Window returns the scheduled or active window, nil if there is none.
+func (s *MaintenanceService) Window() *model.MaintenanceWindow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.window == nil || MaintenanceStageAt(s.window, s.now()) == MaintenanceOver {
+		return nil
+	}
+	w := *s.window
+	return &w
+}
+
+// Active reports whether the bot is in maintenance at now.
+func (s *MaintenanceService) Active(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.window != nil && MaintenanceStageAt(s.window, now) == MaintenanceActive
+}
+
+// BlocksNewRounds reports whether new rounds are refused at now because
+// maintenance starts soon or is under way.
+func (s *MaintenanceService) BlocksNewRounds(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.window != nil && !now.Add(s.blockLead).Before(s.window.StartsAt) && now.Before(s.window.EndsAt)
+}
+
+// Schedule schedules a maintenance window and announces it.
+func (s *MaintenanceService) Schedule(ctx context.Context, adminID int64, startsAt time.Time, duration time.Duration) (*model.MaintenanceWindow, error) {
+	now := s.now()
+	if !startsAt.After(now) || startsAt.Sub(now) > MaxMaintenanceDelay {
+		return nil, ErrMaintenanceTime
+	}
+	if duration < MinMaintenanceDuration || duration > MaxMaintenanceDuration {
+		return nil, ErrMaintenanceDuration
+	}
+
+	s.mu.Lock()
+	if s.window != nil && MaintenanceStageAt(s.window, now) != MaintenanceOver {
+		s.mu.Unlock()
+		return nil, ErrMaintenanceExists
+	}
+	window, err := s.repo.Create(ctx, startsAt, startsAt.Add(duration), adminID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.window = window
+	s.mu.Unlock()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("window_id", window.ID).
+		Time("starts_at", window.StartsAt).
+		Time("ends_at", window.EndsAt).
+		Str("operation", "maintenance_schedule").
+		Msg("Maintenance scheduled")
+
+	s.announceDue(ctx, window)
+	return window, nil
+}
+
+// Cancel cancels the scheduled window, or ends an active one early.
+func (s *MaintenanceService) Cancel(ctx context.Context, adminID int64) error {
+	s.mu.Lock()
+	window := s.window
+	if window == nil || MaintenanceStageAt(window, s.now()) == MaintenanceOver {
+		s.mu.Unlock()
+		return ErrNoMaintenance
+	}
+	if err := s.repo.Cancel(ctx, window.ID); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.window = nil
+	stage := MaintenanceStageAt(window, s.now())
+	s.mu.Unlock()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("window_id", window.ID).
+		Str("operation", "maintenance_cancel").
+		Msg("Maintenance cancelled")
+
+	// Chats never told about the window need no cancellation either
+	switch {
+	case stage == MaintenanceActive:
+		s.announce("✅ 维护已提前结束，欢迎回来！")
+	case window.Announced > int(MaintenanceUnannounced):
+		s.announce(fmt.Sprintf("✅ 原定于 %s 开始的维护已取消", s.FormatTime(window.StartsAt)))
+	}
+	return nil
+}
+
+// Tick refreshes the current window and, if announce is set, announces the
+// stage it reached. Only one instance should announce.
+func (s *MaintenanceService) Tick(ctx context.Context, announce bool) {
+	window, err := s.repo.Current(ctx, int(MaintenanceOver))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to refresh maintenance window")
+		return
+	}
+	s.mu.Lock()
+	s.window = window
+	s.mu.Unlock()
+
+	if announce && window != nil {
+		s.announceDue(ctx, window)
+	}
+}
+
+// announceDue announces the stage a window reached if it was not announced yet.
+// The stage is recorded first: a lost announcement beats a repeated one.
+func (s *MaintenanceService) announceDue(ctx context.Context, window *model.MaintenanceWindow) {
+	stage := MaintenanceStageAt(window, s.now())
+	s.mu.Lock()
+	announced := window.Announced
+	s.mu.Unlock()
+	if int(stage) <= announced {
+		return
+	}
+	if err := s.repo.SetAnnounced(ctx, window.ID, int(stage)); err != nil {
+		log.Error().Err(err).Int64("window_id", window.ID).Msg("Failed to record maintenance announcement")
+		return
+	}
+	s.mu.Lock()
+	window.Announced = int(stage)
+	s.mu.Unlock()
+
+	// The first announcement always carries the full schedule
+	if announced == int(MaintenanceUnannounced) && stage < MaintenanceActive {
+		stage = MaintenanceScheduled
+	}
+	s.announce(s.Notice(window, stage))
+}
+
+// announce sends a text to every chat
+func (s *MaintenanceService) announce(text string) {
+	if s.notifier == nil {
+		return
+	}
+	chats := s.chats
+	if s.lister != nil {
+		chats = s.lister.Chats(context.Background())
+	}
+	if len(chats) == 0 {
+		log.Warn().Msg("No whitelisted chats to announce maintenance to")
+		return
+	}
+	for _, chatID := range chats {
+		s.notifier.Announce(chatID, text)
+	}
+}
+
+// Notice returns the announcement of a window reaching a stage.
+func (s *MaintenanceService) Notice(w *model.MaintenanceWindow, stage MaintenanceStage) string {
+	duration := formatMaintenanceDuration(w.EndsAt.Sub(w.StartsAt))
+	switch stage {
+	case MaintenanceScheduled:
+		return fmt.Sprintf("🔧 维护通知\n\n机器人将于 %s 开始维护，预计持续 %s，%s 恢复\n维护前 %d 分钟起暂停开新局（骰宝、决斗、抢劫团）",
+			s.FormatTime(w.StartsAt), duration, s.FormatTime(w.EndsAt), int(s.blockLead.Minutes()))
+	case MaintenanceSoon, MaintenanceImminent:
+		return fmt.Sprintf("⏰ 维护倒计时: 还有 %s 开始维护（%s），预计持续 %s",
+			formatMaintenanceDuration(w.StartsAt.Sub(s.now())), s.FormatTime(w.StartsAt), duration)
+	case MaintenanceActive:
+		return fmt.Sprintf("🔧 维护开始，暂停服务，预计 %s 恢复", s.FormatTime(w.EndsAt))
+	default:
+		return "✅ 维护结束，欢迎回来！"
+	}
+}
+
+// FormatTime formats a time of a window as shown to players.
+func (s *MaintenanceService) FormatTime(t time.Time) string {
+	return t.In(s.loc).Format("01-02 15:04")
+}
+
+// formatMaintenanceDuration formats a duration in whole minutes, rounded up
+func formatMaintenanceDuration(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%d 分钟", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d 小时", minutes/60)
+	}
+	return fmt.Sprintf("%d 小时 %d 分钟", minutes/60, minutes%60)
+}
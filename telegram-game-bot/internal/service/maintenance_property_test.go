@@ -0,0 +1,132 @@
+// Package service provides business logic implementations.
+// Property-based tests for scheduled maintenance.
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// drawMaintenanceWindow draws a window starting up to a day after base
+func drawMaintenanceWindow(t *rapid.T, base time.Time) *model.MaintenanceWindow {
+	startsAt := base.Add(time.Duration(rapid.IntRange(0, 24*60).Draw(t, "startMinutes")) * time.Minute)
+	duration := time.Duration(rapid.IntRange(1, 24*60).Draw(t, "durationMinutes")) * time.Minute
+	return &model.MaintenanceWindow{StartsAt: startsAt, EndsAt: startsAt.Add(duration), Announced: int(MaintenanceUnannounced)}
+}
+
+// TestMaintenanceStageProperty tests that stages only move forward as time
+// passes and that the bot is in maintenance exactly from start to end.
+func TestMaintenanceStageProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		w := drawMaintenanceWindow(t, base)
+
+		a := base.Add(time.Duration(rapid.IntRange(0, 3*24*60).Draw(t, "a")) * time.Minute)
+		b := a.Add(time.Duration(rapid.IntRange(0, 3*24*60).Draw(t, "b")) * time.Minute)
+		if MaintenanceStageAt(w, a) > MaintenanceStageAt(w, b) {
+			t.Fatalf("Stage went back from %d at %v to %d at %v", MaintenanceStageAt(w, a), a, MaintenanceStageAt(w, b), b)
+		}
+
+		active := !a.Before(w.StartsAt) && a.Before(w.EndsAt)
+		if (MaintenanceStageAt(w, a) == MaintenanceActive) != active {
+			t.Fatalf("Active at %v = %v, window %v-%v", a, !active, w.StartsAt, w.EndsAt)
+		}
+	})
+}
+
+// TestMaintenanceBlocksNewRoundsProperty tests that new rounds are refused
+// from blockLead before the window until it ends, and that maintenance
+// always blocks them.
+func TestMaintenanceBlocksNewRoundsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := &MaintenanceService{
+			blockLead: time.Duration(rapid.IntRange(0, 30).Draw(t, "leadMinutes")) * time.Minute,
+			window:    drawMaintenanceWindow(t, base),
+			now:       time.Now,
+		}
+		now := base.Add(time.Duration(rapid.IntRange(0, 3*24*60).Draw(t, "now")) * time.Minute)
+
+		want := !now.Before(s.window.StartsAt.Add(-s.blockLead)) && now.Before(s.window.EndsAt)
+		if got := s.BlocksNewRounds(now); got != want {
+			t.Fatalf("BlocksNewRounds at %v = %v, want %v (lead %v, window %v-%v)",
+				now, got, want, s.blockLead, s.window.StartsAt, s.window.EndsAt)
+		}
+		if s.Active(now) && !s.BlocksNewRounds(now) {
+			t.Fatal("Maintenance does not block new rounds")
+		}
+	})
+}
+
+// TestParseMaintenanceScheduleProperty tests that clock times are the next
+// occurrence within a day and durations are read as minutes or Go durations.
+func TestParseMaintenanceScheduleProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC).Add(time.Duration(rapid.IntRange(0, 24*60*60-1).Draw(t, "nowSeconds")) * time.Second)
+		hour := rapid.IntRange(0, 23).Draw(t, "hour")
+		minute := rapid.IntRange(0, 59).Draw(t, "minute")
+		minutes := rapid.IntRange(1, 600).Draw(t, "minutes")
+
+		durationArg := fmt.Sprint(minutes)
+		if rapid.Bool().Draw(t, "goDuration") {
+			durationArg = fmt.Sprintf("%dm", minutes)
+		}
+		startsAt, duration, err := ParseMaintenanceSchedule([]string{fmt.Sprintf("%02d:%02d", hour, minute), durationArg}, now)
+		if err != nil {
+			t.Fatalf("Failed to parse %02d:%02d %s: %v", hour, minute, durationArg, err)
+		}
+		if !startsAt.After(now) || startsAt.Sub(now) > 24*time.Hour {
+			t.Fatalf("Start %v is not within a day after %v", startsAt, now)
+		}
+		if startsAt.Hour() != hour || startsAt.Minute() != minute {
+			t.Fatalf("Start %v, want %02d:%02d", startsAt, hour, minute)
+		}
+		if duration != time.Duration(minutes)*time.Minute {
+			t.Fatalf("Duration %v, want %d minutes", duration, minutes)
+		}
+	})
+}
+
+// TestParseMaintenanceSchedule tests the other start formats and malformed input.
+func TestParseMaintenanceSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	startsAt, duration, err := ParseMaintenanceSchedule([]string{"+30m", "1h30m"}, now)
+	if err != nil || !startsAt.Equal(now.Add(30*time.Minute)) || duration != 90*time.Minute {
+		t.Fatalf("+30m 1h30m = %v, %v, %v", startsAt, duration, err)
+	}
+
+	startsAt, _, err = ParseMaintenanceSchedule([]string{"2026-03-11", "03:00", "60"}, now)
+	if err != nil || !startsAt.Equal(time.Date(2026, 3, 11, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("2026-03-11 03:00 = %v, %v", startsAt, err)
+	}
+
+	for _, args := range [][]string{{"03:00"}, {"25:00", "60"}, {"03:00", "soon"}, {"+x", "60"}, {"a", "b", "c", "d"}} {
+		if _, _, err := ParseMaintenanceSchedule(args, now); err != ErrMaintenanceSyntax {
+			t.Errorf("ParseMaintenanceSchedule(%q) error = %v, want ErrMaintenanceSyntax", args, err)
+		}
+	}
+}
+
+// TestMaintenanceBlocks tests that only commands starting new rounds are refused before maintenance.
+func TestMaintenanceBlocks(t *testing.T) {
+	for text, want := range map[string]bool{
+		"/sicbo":         true,
+		"/sicbo@GameBot": true,
+		"/duijue 100":    true,
+		"/heist 500":     true,
+		"/sicbo_settle":  false,
+		"/balance":       false,
+		"大 100":          false,
+		"":               false,
+	} {
+		if got := MaintenanceBlocks(text); got != want {
+			t.Errorf("MaintenanceBlocks(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Media asset errors
+var (
+	ErrMediaAssetUnknownKey = errors.New("未知的素材名称")
+	ErrMediaAssetWrongType  = errors.New("素材类型不符")
+	ErrMediaAssetNotSet     = errors.New("素材未设置")
+)
+
+// mediaAssetKeys lists the configurable assets in display order
+var mediaAssetKeys = []string{
+	model.MediaAssetShopBanner,
+}
+
+// mediaAssetTypes are the media types each asset accepts
+var mediaAssetTypes = map[string]string{
+	model.MediaAssetShopBanner: model.MediaPhoto,
+}
+
+// MediaAssetKeys returns the names of the configurable assets.
+func MediaAssetKeys() []string {
+	return mediaAssetKeys
+}
+
+// MediaAssetType returns the media type an asset accepts, "" for unknown keys.
+func MediaAssetType(key string) string {
+	return mediaAssetTypes[key]
+}
+
+// MediaAssetService stores the Telegram file IDs of media the bot shows, so
+// they can be replaced at runtime instead of being hardcoded. Panels fall back
+// to text when their asset is not set. Assets are cached in memory since they
+// rarely change.
+type MediaAssetService struct {
+	repo *repository.MediaAssetRepository
+
+	mu     sync.Mutex
+	assets map[string]model.MediaAsset // key -> asset, nil until loaded
+}
+
+// NewMediaAssetService creates a new MediaAssetService instance.
+func NewMediaAssetService(repo *repository.MediaAssetRepository) *MediaAssetService {
+	return &MediaAssetService{repo: repo}
+}
+
+// Get returns an asset, false if it is not set or cannot be loaded.
+func (s *MediaAssetService) Get(ctx context.Context, key string) (model.MediaAsset, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to load media assets")
+		return model.MediaAsset{}, false
+	}
+	asset, ok := s.assets[key]
+	return asset, ok
+}
+
+// List returns all set assets by key.
+func (s *MediaAssetService) List(ctx context.Context) (map[string]model.MediaAsset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		return nil, err
+	}
+	return s.assets, nil
+}
+
+// Set replaces the file of an asset; the media type must match the asset.
+func (s *MediaAssetService) Set(ctx context.Context, key, mediaType, fileID string, adminID int64) error {
+	want := MediaAssetType(key)
+	if want == "" {
+		return ErrMediaAssetUnknownKey
+	}
+	if mediaType != want {
+		return ErrMediaAssetWrongType
+	}
+
+	asset := &model.MediaAsset{Key: key, MediaType: mediaType, FileID: fileID, UpdatedBy: adminID}
+	if err := s.repo.Set(ctx, asset); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Str("key", key).
+		Str("operation", "media_asset_set").
+		Msg("Media asset set")
+	return nil
+}
+
+// Clear removes an asset, so its panel falls back to text.
+func (s *MediaAssetService) Clear(ctx context.Context, key string, adminID int64) error {
+	if MediaAssetType(key) == "" {
+		return ErrMediaAssetUnknownKey
+	}
+	deleted, err := s.repo.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrMediaAssetNotSet
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Str("key", key).
+		Str("operation", "media_asset_clear").
+		Msg("Media asset cleared")
+	return nil
+}
+
+// loadLocked fills the asset cache if needed; the caller must hold s.mu
+func (s *MediaAssetService) loadLocked(ctx context.Context) error {
+	if s.assets != nil {
+		return nil
+	}
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.assets = make(map[string]model.MediaAsset, len(all))
+	for _, a := range all {
+		s.assets[a.Key] = a
+	}
+	return nil
+}
+
+// invalidate drops the asset cache after a change
+func (s *MediaAssetService) invalidate() {
+	s.mu.Lock()
+	s.assets = nil
+	s.mu.Unlock()
+}
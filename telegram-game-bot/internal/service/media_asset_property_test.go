@@ -0,0 +1,28 @@
+// Package service provides business logic implementations.
+// Property-based tests for configurable media assets.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestMediaAssetTypeProperty tests that every listed asset accepts a media
+// type and that unlisted names are unknown.
+func TestMediaAssetTypeProperty(t *testing.T) {
+	listed := make(map[string]bool)
+	for _, key := range MediaAssetKeys() {
+		listed[key] = true
+		if MediaAssetType(key) == "" {
+			t.Fatalf("Asset %s accepts no media type", key)
+		}
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		key := rapid.StringMatching(`[a-z_]{1,20}`).Draw(t, "key")
+		if listed[key] != (MediaAssetType(key) != "") {
+			t.Fatalf("Asset %q listed %v but type %q", key, listed[key], MediaAssetType(key))
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// User merge errors
+var (
+	ErrMergeSameUser      = errors.New("新旧账号不能相同")
+	ErrMergeInvalidUser   = errors.New("无效的用户ID")
+	ErrMergeReason        = errors.New("请填写合并原因")
+	ErrMergeUserNotFound  = errors.New("账号不存在")
+	ErrMergeAlreadyMerged = errors.New("旧账号已经合并过")
+)
+
+// MergeRequest is a merge of a duplicate account typed by an admin, previewed
+// before it is executed.
+type MergeRequest struct {
+	OldID     int64
+	NewID     int64
+	AdminID   int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Validate checks the accounts and reason of a merge request.
+func (r MergeRequest) Validate() error {
+	switch {
+	case r.OldID <= 0 || r.NewID <= 0:
+		return ErrMergeInvalidUser
+	case r.OldID == r.NewID:
+		return ErrMergeSameUser
+	case r.Reason == "":
+		return ErrMergeReason
+	}
+	return nil
+}
+
+// Expired reports whether the request can no longer be confirmed at now.
+// Merges are confirmed within the same window as balance adjustments.
+func (r MergeRequest) Expired(now time.Time) bool {
+	return now.Sub(r.CreatedAt) >= AdminAdjustmentTTL
+}
+
+// MergeService merges the accounts of users who moved to a new Telegram account.
+type MergeService struct {
+	repo     *repository.MergeRepository
+	userRepo *repository.UserRepository
+	userLock *lock.UserLock
+}
+
+// NewMergeService creates a new MergeService instance.
+func NewMergeService(repo *repository.MergeRepository, userRepo *repository.UserRepository, userLock *lock.UserLock) *MergeService {
+	return &MergeService{repo: repo, userRepo: userRepo, userLock: userLock}
+}
+
+// Preview returns both accounts of a merge request without changing them.
+func (s *MergeService) Preview(ctx context.Context, req MergeRequest) (oldUser, newUser *model.User, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if oldUser, err = s.userRepo.GetByID(ctx, req.OldID); err == nil {
+		newUser, err = s.userRepo.GetByID(ctx, req.NewID)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, nil, ErrMergeUserNotFound
+		}
+		return nil, nil, err
+	}
+	return oldUser, newUser, nil
+}
+
+// mergeStackCaps returns the stack caps of the shop items, keyed by item type
+func mergeStackCaps() map[string]repository.MergeStackCap {
+	caps := make(map[string]repository.MergeStackCap)
+	for _, item := range shop.GetAllItems() {
+		if item.HasStackLimit() {
+			caps[string(item.Type)] = repository.MergeStackCap{
+				Name:     item.Name,
+				MaxStack: item.MaxStack,
+				Price:    item.Price,
+				UseCount: item.UseCount,
+			}
+		}
+	}
+	return caps
+}
+
+// Merge moves the balance, inventory, effects and cosmetics of the old
+// account into the new one and re-points its transactions, atomically.
+// Item uses over the stack caps are compensated at the list price.
+func (s *MergeService) Merge(ctx context.Context, req MergeRequest) (*model.UserMerge, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Locked in a fixed order so concurrent merges cannot deadlock
+	first, second := req.OldID, req.NewID
+	if first > second {
+		first, second = second, first
+	}
+	s.userLock.Lock(first)
+	defer s.userLock.Unlock(first)
+	s.userLock.Lock(second)
+	defer s.userLock.Unlock(second)
+
+	merge := &model.UserMerge{OldID: req.OldID, NewID: req.NewID, MergedBy: req.AdminID, Reason: req.Reason}
+	if err := s.repo.Merge(ctx, merge, mergeStackCaps()); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			return nil, ErrMergeUserNotFound
+		case errors.Is(err, repository.ErrUserAlreadyMerged):
+			return nil, ErrMergeAlreadyMerged
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Int64("admin_id", req.AdminID).
+		Int64("old_id", req.OldID).
+		Int64("new_id", req.NewID).
+		Int64("merge_id", merge.ID).
+		Int64("balance", merge.Balance).
+		Int("items", merge.Items).
+		Int("effects", merge.Effects).
+		Int("cosmetics", merge.Cosmetics).
+		Int("transactions", merge.Transactions).
+		Int64("compensation", merge.Compensation).
+		Str("reason", req.Reason).
+		Str("operation", "user_merge").
+		Msg("Users merged")
+	return merge, nil
+}
@@ -0,0 +1,69 @@
+// Package service provides business logic implementations.
+// Property-based tests for merging duplicate accounts.
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/shop"
+)
+
+// TestMergeRequestValidateProperty tests that a merge needs two distinct
+// valid accounts and a reason.
+func TestMergeRequestValidateProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		req := MergeRequest{
+			OldID:  rapid.Int64Range(-5, 5).Draw(t, "old"),
+			NewID:  rapid.Int64Range(-5, 5).Draw(t, "new"),
+			Reason: rapid.SampledFrom([]string{"", "换号"}).Draw(t, "reason"),
+		}
+		err := req.Validate()
+
+		var want error
+		switch {
+		case req.OldID <= 0 || req.NewID <= 0:
+			want = ErrMergeInvalidUser
+		case req.OldID == req.NewID:
+			want = ErrMergeSameUser
+		case req.Reason == "":
+			want = ErrMergeReason
+		}
+		if !errors.Is(err, want) || (want == nil) != (err == nil) {
+			t.Fatalf("%+v: got %v, want %v", req, err, want)
+		}
+	})
+}
+
+// TestMergeRequestExpiredProperty tests that merge requests expire with
+// balance adjustments.
+func TestMergeRequestExpiredProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		created := time.Unix(rapid.Int64Range(0, 1<<32).Draw(t, "created"), 0)
+		age := time.Duration(rapid.Int64Range(0, int64(2*AdminAdjustmentTTL)).Draw(t, "age"))
+
+		req := MergeRequest{CreatedAt: created}
+		adj := AdminAdjustment{CreatedAt: created}
+		if req.Expired(created.Add(age)) != adj.Expired(created.Add(age)) {
+			t.Fatalf("Age %v: merge and adjustment expiry disagree", age)
+		}
+	})
+}
+
+// TestMergeStackCaps tests that the uses moved by a merge are capped like
+// purchases: items with a stack limit are capped, duration items are not.
+func TestMergeStackCaps(t *testing.T) {
+	caps := mergeStackCaps()
+	for _, item := range shop.GetAllItems() {
+		c, ok := caps[string(item.Type)]
+		if ok != item.HasStackLimit() {
+			t.Fatalf("%s: capped %v, stack limit %v", item.Type, ok, item.HasStackLimit())
+		}
+		if ok && (c.MaxStack != item.MaxStack || c.Price != item.Price || c.UseCount != item.UseCount) {
+			t.Fatalf("%s: cap %+v", item.Type, c)
+		}
+	}
+}
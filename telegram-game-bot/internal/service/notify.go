@@ -0,0 +1,14 @@
+package service
+
+// UserNotifier sends direct messages to users.
+// Implemented by the bot layer so the services do not depend on Telegram.
+type UserNotifier interface {
+	// NotifyUser sends a direct message to a user
+	NotifyUser(userID int64, text string)
+}
+
+// ChatAnnouncer posts announcements in group chats.
+type ChatAnnouncer interface {
+	// Announce sends a message to a chat
+	Announce(chatID int64, text string)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// Outbox delivery rules
+const (
+	OutboxBaseDelay   = 30 * time.Second // Delay before the first retry, doubled on every further failure
+	OutboxMaxDelay    = 30 * time.Minute // Upper bound of the retry delay
+	OutboxMaxAttempts = 8                // Failed attempts after which a message is dropped
+	OutboxBatchSize   = 50               // Messages sent per flush
+)
+
+// DirectSender delivers direct messages.
+type DirectSender interface {
+	// SendDirect sends a direct message to a user, returning the send error
+	SendDirect(userID int64, text string) error
+}
+
+// OutboxBackoff returns the delay before the next attempt after the given
+// number of failed attempts: OutboxBaseDelay doubled per failure, capped at OutboxMaxDelay.
+func OutboxBackoff(attempts int) time.Duration {
+	delay := OutboxBaseDelay
+	for i := 1; i < attempts && delay < OutboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > OutboxMaxDelay {
+		return OutboxMaxDelay
+	}
+	return delay
+}
+
+// OutboxService stores direct messages before sending them, so a message
+// survives send failures and restarts and is retried until delivered.
+type OutboxService struct {
+	repo   *repository.OutboxRepository
+	sender DirectSender
+}
+
+// NewOutboxService creates a new OutboxService instance.
+func NewOutboxService(repo *repository.OutboxRepository) *OutboxService {
+	return &OutboxService{repo: repo}
+}
+
+// SetSender sets the sender used to deliver messages.
+func (s *OutboxService) SetSender(sender DirectSender) {
+	s.sender = sender
+}
+
+// Enqueue stores a direct message to a user for delivery by the next flush.
+func (s *OutboxService) Enqueue(ctx context.Context, userID int64, text string) error {
+	return s.repo.Enqueue(ctx, userID, text)
+}
+
+// Flush sends the messages due at now. Delivered messages are removed, failed
+// ones rescheduled with OutboxBackoff and dropped after OutboxMaxAttempts.
+func (s *OutboxService) Flush(ctx context.Context, now time.Time) {
+	if s.sender == nil {
+		return
+	}
+
+	messages, err := s.repo.Due(ctx, now, OutboxBatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load due direct messages")
+		return
+	}
+
+	for _, msg := range messages {
+		sendErr := s.sender.SendDirect(msg.UserID, msg.Text)
+		if sendErr == nil {
+			if err := s.repo.Delete(ctx, msg.ID); err != nil {
+				log.Error().Err(err).Int64("message_id", msg.ID).Msg("Failed to remove delivered direct message")
+			}
+			continue
+		}
+
+		attempts := msg.Attempts + 1
+		if attempts >= OutboxMaxAttempts {
+			log.Warn().Err(sendErr).
+				Int64("message_id", msg.ID).
+				Int64("user_id", msg.UserID).
+				Int("attempts", attempts).
+				Msg("Dropping undeliverable direct message")
+			if err := s.repo.Delete(ctx, msg.ID); err != nil {
+				log.Error().Err(err).Int64("message_id", msg.ID).Msg("Failed to drop direct message")
+			}
+			continue
+		}
+
+		log.Debug().Err(sendErr).Int64("user_id", msg.UserID).Int("attempts", attempts).Msg("Failed to send direct message, retrying later")
+		if err := s.repo.Reschedule(ctx, msg.ID, attempts, now.Add(OutboxBackoff(attempts))); err != nil {
+			log.Error().Err(err).Int64("message_id", msg.ID).Msg("Failed to reschedule direct message")
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package service provides business logic implementations.
+// Property-based tests for the direct message outbox retry backoff.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestOutboxBackoffProperty tests that the retry delay starts at
+// OutboxBaseDelay, never shrinks and never exceeds OutboxMaxDelay.
+func TestOutboxBackoffProperty(t *testing.T) {
+	if got := OutboxBackoff(1); got != OutboxBaseDelay {
+		t.Fatalf("First retry after %v, want %v", got, OutboxBaseDelay)
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		attempts := rapid.IntRange(1, 100).Draw(t, "attempts")
+		delay := OutboxBackoff(attempts)
+		next := OutboxBackoff(attempts + 1)
+
+		if delay > OutboxMaxDelay || next > OutboxMaxDelay {
+			t.Fatalf("Delay %v / %v exceeds %v", delay, next, OutboxMaxDelay)
+		}
+		if next < delay {
+			t.Fatalf("Delay shrinks from %v to %v after attempt %d", delay, next, attempts)
+		}
+		want := 2 * delay
+		if want > OutboxMaxDelay {
+			want = OutboxMaxDelay
+		}
+		if next != want {
+			t.Fatalf("Delay after attempt %d is %v, want %v", attempts+1, next, want)
+		}
+	})
+}
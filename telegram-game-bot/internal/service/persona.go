@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/msgtmpl"
+	"telegram-game-bot/internal/repository"
+)
+
+// Persona settings
+const (
+	PersonaMaxNicknameRunes = 32        // Maximum length of a bot nickname
+	PersonaMaxTemplateRunes = 200       // Maximum length of a message template
+	PersonaDefaultNickname  = "荷官"      // {bot} when the chat set no nickname
+	PersonaDefaultTheme     = "default" // Theme used when the chat chose none
+)
+
+// PersonaTemplateVars are the variables message templates may use
+var PersonaTemplateVars = []string{"user", "amount", "balance", "game", "bot", "emoji"}
+
+// Persona errors
+var (
+	ErrPersonaNicknameTooLong = errors.New("昵称过长")
+	ErrPersonaTemplateTooLong = errors.New("模板过长")
+	ErrPersonaTemplateInvalid = errors.New("模板格式错误，只能使用 {user} {amount} {balance} {game} {bot} {emoji}")
+	ErrPersonaUnknownTheme    = errors.New("未知的表情主题")
+)
+
+// PersonaTheme is a set of emojis used in game result messages
+type PersonaTheme struct {
+	Name    string // Identifier used in /persona theme
+	Label   string // Display name
+	Win     string // Emoji of win lines ({emoji} in win templates)
+	Lose    string // Emoji of loss lines ({emoji} in lose templates)
+	Balance string // Emoji of the balance line
+}
+
+// personaThemes lists the available emoji themes, the first one is the default
+var personaThemes = []PersonaTheme{
+	{Name: PersonaDefaultTheme, Label: "经典", Win: "🎉", Lose: "😢", Balance: "💰"},
+	{Name: "cat", Label: "猫咪", Win: "😻", Lose: "🙀", Balance: "🐟"},
+	{Name: "dragon", Label: "神龙", Win: "🐉", Lose: "💀", Balance: "🏮"},
+	{Name: "space", Label: "太空", Win: "🚀", Lose: "☄️", Balance: "🪐"},
+	{Name: "fruit", Label: "水果", Win: "🍒", Lose: "🍋", Balance: "🍉"},
+}
+
+// PersonaThemes returns the available emoji themes
+func PersonaThemes() []PersonaTheme {
+	return personaThemes
+}
+
+// GetPersonaTheme returns a theme by name, the empty name being the default theme
+func GetPersonaTheme(name string) (PersonaTheme, bool) {
+	if name == "" {
+		return personaThemes[0], true
+	}
+	for _, theme := range personaThemes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return PersonaTheme{}, false
+}
+
+// PersonaVars are the values of a game result available to templates
+type PersonaVars struct {
+	User    string // Player display name, e.g. alice
+	Amount  int64  // Amount won or lost
+	Balance int64  // Balance after the game
+	Game    string // Game name
+}
+
+// RenderOutcome renders the win or loss line of a game result. The chat's
+// template replaces the built-in fallback template when set; both are rendered
+// with the chat's theme. A nil persona renders the fallback with the default theme.
+func RenderOutcome(persona *model.ChatPersona, win bool, fallback string, vars PersonaVars) string {
+	tmpl := fallback
+	theme, _ := GetPersonaTheme("")
+	nickname := PersonaDefaultNickname
+	if persona != nil {
+		if t, ok := GetPersonaTheme(persona.Theme); ok {
+			theme = t
+		}
+		if persona.Nickname != "" {
+			nickname = persona.Nickname
+		}
+		if win && persona.WinTemplate != "" {
+			tmpl = persona.WinTemplate
+		}
+		if !win && persona.LoseTemplate != "" {
+			tmpl = persona.LoseTemplate
+		}
+	}
+
+	emoji := theme.Lose
+	if win {
+		emoji = theme.Win
+	}
+	return msgtmpl.Render(tmpl, msgtmpl.Vars{
+		"user":    vars.User,
+		"amount":  strconv.FormatInt(vars.Amount, 10),
+		"balance": strconv.FormatInt(vars.Balance, 10),
+		"game":    vars.Game,
+		"bot":     nickname,
+		"emoji":   emoji,
+	})
+}
+
+// RenderBalance renders the balance line of a game result with the chat's theme
+func RenderBalance(persona *model.ChatPersona, balance int64) string {
+	theme, _ := GetPersonaTheme("")
+	if persona != nil {
+		if t, ok := GetPersonaTheme(persona.Theme); ok {
+			theme = t
+		}
+	}
+	return theme.Balance + " 余额: " + strconv.FormatInt(balance, 10)
+}
+
+// ValidatePersonaTemplate checks a win/lose template set by a chat admin
+func ValidatePersonaTemplate(tmpl string) error {
+	if utf8.RuneCountInString(tmpl) > PersonaMaxTemplateRunes {
+		return ErrPersonaTemplateTooLong
+	}
+	if err := msgtmpl.Validate(tmpl, PersonaTemplateVars); err != nil {
+		return ErrPersonaTemplateInvalid
+	}
+	return nil
+}
+
+// PersonaService manages per-chat bot personas.
+// Personas are cached in memory since every game result renders one.
+type PersonaService struct {
+	repo  *repository.PersonaRepository
+	mu    sync.RWMutex
+	cache map[int64]*model.ChatPersona // chatID -> persona, nil when the chat has none
+}
+
+// NewPersonaService creates a new PersonaService instance.
+func NewPersonaService(repo *repository.PersonaRepository) *PersonaService {
+	return &PersonaService{
+		repo:  repo,
+		cache: make(map[int64]*model.ChatPersona),
+	}
+}
+
+// Get returns the persona of a chat, nil if the chat has not customized the bot.
+// Lookup failures are logged and fall back to the built-in texts.
+func (s *PersonaService) Get(ctx context.Context, chatID int64) *model.ChatPersona {
+	s.mu.RLock()
+	persona, ok := s.cache[chatID]
+	s.mu.RUnlock()
+	if ok {
+		return persona
+	}
+
+	persona, err := s.repo.Get(ctx, chatID)
+	if err != nil {
+		log.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to load chat persona")
+		return nil
+	}
+
+	s.mu.Lock()
+	s.cache[chatID] = persona
+	s.mu.Unlock()
+	return persona
+}
+
+// SetNickname sets the bot nickname of a chat
+func (s *PersonaService) SetNickname(ctx context.Context, chatID, adminID int64, nickname string) (*model.ChatPersona, error) {
+	nickname = strings.TrimSpace(nickname)
+	if utf8.RuneCountInString(nickname) > PersonaMaxNicknameRunes {
+		return nil, ErrPersonaNicknameTooLong
+	}
+	return s.update(ctx, chatID, adminID, "nickname", func(p *model.ChatPersona) { p.Nickname = nickname })
+}
+
+// SetWinTemplate sets the win line template of a chat, empty restores the built-in text
+func (s *PersonaService) SetWinTemplate(ctx context.Context, chatID, adminID int64, tmpl string) (*model.ChatPersona, error) {
+	tmpl = strings.TrimSpace(tmpl)
+	if err := ValidatePersonaTemplate(tmpl); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, chatID, adminID, "win_template", func(p *model.ChatPersona) { p.WinTemplate = tmpl })
+}
+
+// SetLoseTemplate sets the loss line template of a chat, empty restores the built-in text
+func (s *PersonaService) SetLoseTemplate(ctx context.Context, chatID, adminID int64, tmpl string) (*model.ChatPersona, error) {
+	tmpl = strings.TrimSpace(tmpl)
+	if err := ValidatePersonaTemplate(tmpl); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, chatID, adminID, "lose_template", func(p *model.ChatPersona) { p.LoseTemplate = tmpl })
+}
+
+// SetTheme sets the emoji theme of a chat
+func (s *PersonaService) SetTheme(ctx context.Context, chatID, adminID int64, name string) (*model.ChatPersona, error) {
+	theme, ok := GetPersonaTheme(strings.ToLower(strings.TrimSpace(name)))
+	if !ok {
+		return nil, ErrPersonaUnknownTheme
+	}
+	return s.update(ctx, chatID, adminID, "theme", func(p *model.ChatPersona) { p.Theme = theme.Name })
+}
+
+// SetNoPings sets whether game results in a chat name players without notifying them
+func (s *PersonaService) SetNoPings(ctx context.Context, chatID, adminID int64, noPings bool) (*model.ChatPersona, error) {
+	return s.update(ctx, chatID, adminID, "no_pings", func(p *model.ChatPersona) { p.NoPings = noPings })
+}
+
+// Reset removes all customizations of a chat
+func (s *PersonaService) Reset(ctx context.Context, chatID, adminID int64) error {
+	if err := s.repo.Delete(ctx, chatID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cache[chatID] = nil
+	s.mu.Unlock()
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Str("operation", "persona_reset").
+		Msg("Chat persona reset")
+	return nil
+}
+
+// update applies a change to a chat's persona and stores it
+func (s *PersonaService) update(ctx context.Context, chatID, adminID int64, field string, apply func(p *model.ChatPersona)) (*model.ChatPersona, error) {
+	persona, err := s.repo.Get(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if persona == nil {
+		persona = &model.ChatPersona{ChatID: chatID}
+	}
+	apply(persona)
+	persona.UpdatedBy = adminID
+
+	if err := s.repo.Upsert(ctx, persona); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[chatID] = persona
+	s.mu.Unlock()
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Str("field", field).
+		Str("operation", "persona_update").
+		Msg("Chat persona updated")
+	return persona, nil
+}
@@ -0,0 +1,105 @@
+// Package service provides business logic implementations.
+// Property-based tests for chat personas.
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestRenderOutcomeDefaultProperty tests that chats without a persona keep
+// the built-in result texts.
+func TestRenderOutcomeDefaultProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		vars := PersonaVars{
+			User:    "@" + rapid.StringMatching(`[a-z]{1,10}`).Draw(t, "user"),
+			Amount:  rapid.Int64Range(1, 1_000_000).Draw(t, "amount"),
+			Balance: rapid.Int64Range(0, 1_000_000).Draw(t, "balance"),
+		}
+
+		win := RenderOutcome(nil, true, "{emoji} 赢得 {amount} 金币！", vars)
+		if want := fmt.Sprintf("🎉 赢得 %d 金币！", vars.Amount); win != want {
+			t.Fatalf("Win line %q, want %q", win, want)
+		}
+		lose := RenderOutcome(nil, false, "{emoji} 输了 {amount} 金币", vars)
+		if want := fmt.Sprintf("😢 输了 %d 金币", vars.Amount); lose != want {
+			t.Fatalf("Lose line %q, want %q", lose, want)
+		}
+		if got, want := RenderBalance(nil, vars.Balance), fmt.Sprintf("💰 余额: %d", vars.Balance); got != want {
+			t.Fatalf("Balance line %q, want %q", got, want)
+		}
+	})
+}
+
+// TestRenderOutcomePersonaProperty tests that a chat's templates replace the
+// built-in text of the matching outcome only, with the chat's theme and nickname.
+func TestRenderOutcomePersonaProperty(t *testing.T) {
+	themes := PersonaThemes()
+
+	rapid.Check(t, func(t *rapid.T) {
+		theme := themes[rapid.IntRange(0, len(themes)-1).Draw(t, "theme")]
+		persona := &model.ChatPersona{
+			Nickname:     rapid.StringMatching(`[a-z]{0,8}`).Draw(t, "nickname"),
+			WinTemplate:  rapid.SampledFrom([]string{"", "{bot}: {user} +{amount} {emoji}"}).Draw(t, "win"),
+			LoseTemplate: rapid.SampledFrom([]string{"", "{bot}: {user} -{amount} {emoji}"}).Draw(t, "lose"),
+			Theme:        theme.Name,
+		}
+		// User names are inserted verbatim, even when they look like variables
+		vars := PersonaVars{
+			User:   rapid.SampledFrom([]string{"@alice", "{amount}", "{{bot}}"}).Draw(t, "user"),
+			Amount: rapid.Int64Range(1, 1_000_000).Draw(t, "amount"),
+		}
+		nickname := persona.Nickname
+		if nickname == "" {
+			nickname = PersonaDefaultNickname
+		}
+
+		for _, win := range []bool{true, false} {
+			tmpl, emoji, sign := persona.LoseTemplate, theme.Lose, "-"
+			if win {
+				tmpl, emoji, sign = persona.WinTemplate, theme.Win, "+"
+			}
+
+			got := RenderOutcome(persona, win, "{emoji} {amount}", vars)
+			want := fmt.Sprintf("%s %d", emoji, vars.Amount)
+			if tmpl != "" {
+				want = fmt.Sprintf("%s: %s %s%d %s", nickname, vars.User, sign, vars.Amount, emoji)
+			}
+			if got != want {
+				t.Fatalf("RenderOutcome(win=%v) = %q, want %q", win, got, want)
+			}
+		}
+
+		if got := RenderBalance(persona, 5); !strings.HasPrefix(got, theme.Balance+" ") {
+			t.Fatalf("Balance line %q does not use theme %s", got, theme.Name)
+		}
+	})
+}
+
+// TestValidatePersonaTemplateProperty tests that templates over the length
+// limit or with unknown variables are rejected.
+func TestValidatePersonaTemplateProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(0, PersonaMaxTemplateRunes+20).Draw(t, "n")
+		tmpl := strings.Repeat("赢", n)
+
+		err := ValidatePersonaTemplate(tmpl)
+		if n > PersonaMaxTemplateRunes && err != ErrPersonaTemplateTooLong {
+			t.Fatalf("Template of %d runes: %v", n, err)
+		}
+		if n <= PersonaMaxTemplateRunes && err != nil {
+			t.Fatalf("Template of %d runes rejected: %v", n, err)
+		}
+
+		if n+len("{token}") <= PersonaMaxTemplateRunes {
+			if err := ValidatePersonaTemplate(tmpl + "{token}"); err != ErrPersonaTemplateInvalid {
+				t.Fatalf("Unknown variable accepted: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+)
+
+// Pool limits
+const (
+	MinPoolOutcomes      = 2
+	MaxPoolOutcomes      = 6
+	MaxPoolQuestionRunes = 200
+	MaxPoolOutcomeRunes  = 32
+	MaxPoolWindow        = 7 * 24 * time.Hour
+)
+
+// Pool errors
+var (
+	ErrPoolExists         = errors.New("本群已有进行中的竞猜")
+	ErrNoPool             = errors.New("本群当前没有竞猜")
+	ErrPoolSyntax         = errors.New("格式错误，例如: /pool \"今晚谁会赢？\" A队 B队")
+	ErrPoolOutcomes       = errors.New("竞猜选项需要 2 到 6 个")
+	ErrPoolTooLong        = errors.New("问题或选项过长")
+	ErrPoolWindow         = errors.New("下注时长无效")
+	ErrPoolBettingClosed  = errors.New("竞猜已截止下注")
+	ErrPoolUnknownOutcome = errors.New("没有这个选项")
+)
+
+// PoolPayout is a user's payout of a resolved pool, or their refund
+type PoolPayout struct {
+	UserID int64
+	Amount int64
+}
+
+// PoolSettlement is the result of resolving or cancelling a pool
+type PoolSettlement struct {
+	Pool      *model.Pool
+	TotalPool int64
+	Rake      int64
+	Payouts   []PoolPayout // Ordered by amount, highest first
+	Refunded  bool         // Stakes were returned instead of paid out
+}
+
+// PoolService runs parimutuel betting pools: an admin opens a question with
+// 2-6 outcomes, users bet into the outcome pools during the betting window, and
+// once the admin resolves the question the whole pool minus the rake is split
+// among the winning bets pro-rata. Every resolution is recorded for audit.
+// Pools of sandbox chats are staked with play money.
+type PoolService struct {
+	poolRepo     *repository.PoolRepository
+	accounts     *AccountService
+	userLock     *lock.UserLock
+	sandbox      *SandboxService      // Optional: play money of sandbox chats
+	compensation *CompensationService // Optional: compensates payouts that fail
+	rakePercent  int
+	window       time.Duration
+
+	mu sync.Mutex // Serializes opening and finishing pools
+}
+
+// NewPoolService creates a new PoolService instance.
+// rakePercent of each resolved pool stays with the house; window is the default betting window.
+func NewPoolService(
+	poolRepo *repository.PoolRepository,
+	accounts *AccountService,
+	userLock *lock.UserLock,
+	rakePercent int,
+	window time.Duration,
+) *PoolService {
+	return &PoolService{
+		poolRepo:    poolRepo,
+		accounts:    accounts,
+		userLock:    userLock,
+		rakePercent: rakePercent,
+		window:      window,
+	}
+}
+
+// SetSandbox sets the sandbox chats, whose pools are staked with play money.
+func (s *PoolService) SetSandbox(sandbox *SandboxService) {
+	s.sandbox = sandbox
+}
+
+// SetCompensation sets the service payouts that fail are reported to.
+func (s *PoolService) SetCompensation(compensation *CompensationService) {
+	s.compensation = compensation
+}
+
+// Open opens a pool in a chat. A zero window uses the default betting window.
+func (s *PoolService) Open(ctx context.Context, chatID, adminID int64, question string, outcomes []string, window time.Duration) (*model.Pool, error) {
+	if len(outcomes) < MinPoolOutcomes || len(outcomes) > MaxPoolOutcomes {
+		return nil, ErrPoolOutcomes
+	}
+	if utf8.RuneCountInString(question) > MaxPoolQuestionRunes {
+		return nil, ErrPoolTooLong
+	}
+	for _, outcome := range outcomes {
+		if utf8.RuneCountInString(outcome) > MaxPoolOutcomeRunes {
+			return nil, ErrPoolTooLong
+		}
+	}
+	if window == 0 {
+		window = s.window
+	}
+	if window <= 0 || window > MaxPoolWindow {
+		return nil, ErrPoolWindow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.poolRepo.GetOpenByChat(ctx, chatID); err == nil {
+		return nil, ErrPoolExists
+	} else if !errors.Is(err, repository.ErrPoolNotFound) {
+		return nil, err
+	}
+
+	p := &model.Pool{
+		ChatID:      chatID,
+		Question:    question,
+		Outcomes:    outcomes,
+		Status:      model.PoolStatusOpen,
+		RakePercent: s.rakePercent,
+		ClosesAt:    time.Now().Add(window),
+		CreatedBy:   adminID,
+	}
+	if s.sandbox != nil {
+		p.SandboxChatID = s.sandbox.Scope(ctx, chatID).SandboxChatID
+	}
+	if err := s.poolRepo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int64("pool_id", p.ID).
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Int("outcomes", len(outcomes)).
+		Time("closes_at", p.ClosesAt).
+		Str("operation", "pool_open").
+		Msg("Pool opened")
+	return p, nil
+}
+
+// Current returns the open pool of a chat and the total staked on each outcome.
+func (s *PoolService) Current(ctx context.Context, chatID int64) (*model.Pool, []int64, error) {
+	p, err := s.poolRepo.GetOpenByChat(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPoolNotFound) {
+			return nil, nil, ErrNoPool
+		}
+		return nil, nil, err
+	}
+	bets, err := s.poolRepo.GetBets(ctx, p.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, PoolOutcomeTotals(bets, len(p.Outcomes)), nil
+}
+
+// Bet stakes amount on an outcome (1-based, as shown to users) of the chat's open pool.
+func (s *PoolService) Bet(ctx context.Context, chatID, userID int64, outcome int, amount int64) (*model.Pool, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	p, err := s.poolRepo.GetOpenByChat(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPoolNotFound) {
+			return nil, ErrNoPool
+		}
+		return nil, err
+	}
+	if outcome < 1 || outcome > len(p.Outcomes) {
+		return nil, ErrPoolUnknownOutcome
+	}
+	if !time.Now().Before(p.ClosesAt) {
+		return nil, ErrPoolBettingClosed
+	}
+
+	s.userLock.Lock(userID)
+	defer s.userLock.Unlock(userID)
+
+	scope := p.Scope()
+	balance, err := s.accounts.GetBalanceIn(ctx, scope, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance < amount {
+		return nil, ErrInsufficientBalance
+	}
+
+	desc := fmt.Sprintf("竞猜 #%d 押注「%s」", p.ID, p.Outcomes[outcome-1])
+	if _, err := s.accounts.UpdateBalanceIn(ctx, scope, userID, -amount, model.TxTypePoolBet, &desc); err != nil {
+		return nil, err
+	}
+
+	bet := &model.PoolBet{PoolID: p.ID, UserID: userID, Outcome: outcome - 1, Amount: amount}
+	if err := s.poolRepo.AddBet(ctx, bet); err != nil {
+		// The pool closed in the meantime: give the stake back
+		refundDesc := fmt.Sprintf("竞猜 #%d 下注失败退还", p.ID)
+		if _, rerr := s.accounts.UpdateBalanceIn(ctx, scope, userID, amount, model.TxTypePoolRefund, &refundDesc); rerr != nil {
+			log.Error().Err(rerr).Int64("pool_id", p.ID).Int64("user_id", userID).Msg("Failed to refund rejected pool bet")
+			s.reportFailedPayouts(p, IncidentRefundFailed, fmt.Sprintf("竞猜 #%d 下注失败后退还失败", p.ID),
+				CompensationClaim{UserID: userID, Amount: amount})
+		}
+		if errors.Is(err, repository.ErrPoolClosed) {
+			return nil, ErrPoolBettingClosed
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// Resolve settles the chat's open pool with a winning outcome (1-based).
+// If nobody backed the winner, every stake is refunded and no rake is taken.
+func (s *PoolService) Resolve(ctx context.Context, chatID, adminID int64, outcome int) (*PoolSettlement, error) {
+	return s.finish(ctx, chatID, adminID, &outcome)
+}
+
+// Cancel cancels the chat's open pool and refunds every stake.
+func (s *PoolService) Cancel(ctx context.Context, chatID, adminID int64) (*PoolSettlement, error) {
+	return s.finish(ctx, chatID, adminID, nil)
+}
+
+// finish resolves (outcome set) or cancels (outcome nil) the chat's open pool
+func (s *PoolService) finish(ctx context.Context, chatID, adminID int64, outcome *int) (*PoolSettlement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.poolRepo.GetOpenByChat(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPoolNotFound) {
+			return nil, ErrNoPool
+		}
+		return nil, err
+	}
+
+	status := model.PoolStatusCancelled
+	var winning *int
+	if outcome != nil {
+		if *outcome < 1 || *outcome > len(p.Outcomes) {
+			return nil, ErrPoolUnknownOutcome
+		}
+		status = model.PoolStatusResolved
+		index := *outcome - 1
+		winning = &index
+	}
+
+	// Close the pool first so no bet lands after the bets are read
+	if err := s.poolRepo.Finish(ctx, p.ID, status, winning); err != nil {
+		if errors.Is(err, repository.ErrPoolClosed) {
+			return nil, ErrNoPool
+		}
+		return nil, err
+	}
+	p.Status = status
+	p.WinningOutcome = winning
+
+	bets, err := s.poolRepo.GetBets(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := &PoolSettlement{Pool: p}
+	for _, b := range bets {
+		settlement.TotalPool += b.Amount
+	}
+	if winning != nil {
+		settlement.Payouts, settlement.Rake = SplitPool(bets, *winning, p.RakePercent)
+	}
+	if settlement.Payouts == nil {
+		settlement.Payouts = PoolRefunds(bets)
+		settlement.Refunded = true
+	}
+
+	res := &model.PoolResolution{
+		PoolID:         p.ID,
+		AdminID:        adminID,
+		Action:         status,
+		WinningOutcome: winning,
+		TotalPool:      settlement.TotalPool,
+		Rake:           settlement.Rake,
+		Winners:        len(settlement.Payouts),
+	}
+	for _, payout := range settlement.Payouts {
+		res.Paid += payout.Amount
+	}
+	if err := s.poolRepo.AddResolution(ctx, res); err != nil {
+		log.Error().Err(err).Int64("pool_id", p.ID).Msg("Failed to record pool resolution")
+	}
+
+	txType, desc := model.TxTypePoolWin, fmt.Sprintf("竞猜 #%d 奖金", p.ID)
+	if settlement.Refunded {
+		txType, desc = model.TxTypePoolRefund, fmt.Sprintf("竞猜 #%d 退还下注", p.ID)
+	}
+	var failed []CompensationClaim
+	for _, payout := range settlement.Payouts {
+		if err := s.pay(ctx, p.Scope(), payout, txType, desc); err != nil {
+			log.Error().Err(err).
+				Int64("pool_id", p.ID).
+				Int64("user_id", payout.UserID).
+				Int64("amount", payout.Amount).
+				Msg("Failed to pay pool payout")
+			failed = append(failed, CompensationClaim{UserID: payout.UserID, Amount: payout.Amount})
+		}
+	}
+	s.reportFailedPayouts(p, IncidentCreditFailed, fmt.Sprintf("竞猜 #%d 奖金到账失败", p.ID), failed...)
+
+	log.Info().
+		Int64("pool_id", p.ID).
+		Int64("admin_id", adminID).
+		Str("status", status).
+		Int64("total_pool", settlement.TotalPool).
+		Int64("rake", settlement.Rake).
+		Int("payouts", len(settlement.Payouts)).
+		Bool("refunded", settlement.Refunded).
+		Str("operation", "pool_finish").
+		Msg("Pool finished")
+	return settlement, nil
+}
+
+// pay credits a payout or refund
+func (s *PoolService) pay(ctx context.Context, scope model.BalanceScope, payout PoolPayout, txType, desc string) error {
+	s.userLock.Lock(payout.UserID)
+	defer s.userLock.Unlock(payout.UserID)
+
+	_, err := s.accounts.UpdateBalanceIn(ctx, scope, payout.UserID, payout.Amount, txType, &desc)
+	return err
+}
+
+// reportFailedPayouts reports coins a pool failed to pay for compensation.
+// Play money is never compensated with real coins, so sandbox pools are
+// only logged. Must not be called while holding a claimant's user lock.
+func (s *PoolService) reportFailedPayouts(p *model.Pool, kind, description string, claims ...CompensationClaim) {
+	if len(claims) == 0 || p.Scope().IsSandbox() || s.compensation == nil {
+		return
+	}
+	go func() {
+		if _, err := s.compensation.ReportIncident(context.Background(), kind, description, claims); err != nil {
+			log.Error().Err(err).Int64("pool_id", p.ID).Str("kind", kind).Msg("Failed to report compensation incident")
+		}
+	}()
+}
+
+// PoolOutcomeTotals sums the stakes on each of n outcomes
+func PoolOutcomeTotals(bets []*model.PoolBet, n int) []int64 {
+	totals := make([]int64, n)
+	for _, b := range bets {
+		if b.Outcome >= 0 && b.Outcome < n {
+			totals[b.Outcome] += b.Amount
+		}
+	}
+	return totals
+}
+
+// SplitPool splits a pool among the bets on the winning outcome (0-based),
+// pro-rata to each user's stake on it, after taking rakePercent of the whole
+// pool. The rounding remainder goes to the largest winning stake (lowest user
+// ID on ties), so payouts plus rake always sum to the pool. Returns nil
+// payouts and no rake if nobody backed the winner.
+func SplitPool(bets []*model.PoolBet, winning int, rakePercent int) ([]PoolPayout, int64) {
+	var total, winningTotal int64
+	stakes := make(map[int64]int64)
+	for _, b := range bets {
+		total += b.Amount
+		if b.Outcome == winning {
+			winningTotal += b.Amount
+			stakes[b.UserID] += b.Amount
+		}
+	}
+	if winningTotal == 0 {
+		return nil, 0
+	}
+
+	rake := total * int64(rakePercent) / 100
+	prize := total - rake
+
+	payouts := make([]PoolPayout, 0, len(stakes))
+	for userID, stake := range stakes {
+		payouts = append(payouts, PoolPayout{UserID: userID, Amount: stake})
+	}
+	sortPoolPayouts(payouts)
+
+	var paid int64
+	for i := range payouts {
+		payouts[i].Amount = prize * payouts[i].Amount / winningTotal
+		paid += payouts[i].Amount
+	}
+	payouts[0].Amount += prize - paid
+	sortPoolPayouts(payouts)
+	return payouts, rake
+}
+
+// PoolRefunds returns every user's total stake
+func PoolRefunds(bets []*model.PoolBet) []PoolPayout {
+	stakes := make(map[int64]int64)
+	for _, b := range bets {
+		stakes[b.UserID] += b.Amount
+	}
+	refunds := make([]PoolPayout, 0, len(stakes))
+	for userID, stake := range stakes {
+		refunds = append(refunds, PoolPayout{UserID: userID, Amount: stake})
+	}
+	sortPoolPayouts(refunds)
+	return refunds
+}
+
+// sortPoolPayouts orders payouts by amount, highest first, then by user ID
+func sortPoolPayouts(payouts []PoolPayout) {
+	sort.Slice(payouts, func(i, j int) bool {
+		if payouts[i].Amount != payouts[j].Amount {
+			return payouts[i].Amount > payouts[j].Amount
+		}
+		return payouts[i].UserID < payouts[j].UserID
+	})
+}
+
+// ParsePoolCommand parses the arguments of /pool: an optional betting window
+// in minutes, the question in quotes ("" or “”) and the outcomes separated by
+// spaces, e.g. `30 "今晚谁会赢？" A队 B队`. A zero window means the default.
+func ParsePoolCommand(payload string) (question string, outcomes []string, window time.Duration, err error) {
+	payload = strings.TrimSpace(payload)
+
+	if first, rest, ok := strings.Cut(payload, " "); ok {
+		if minutes, convErr := strconv.Atoi(first); convErr == nil {
+			if minutes <= 0 {
+				return "", nil, 0, ErrPoolWindow
+			}
+			window = time.Duration(minutes) * time.Minute
+			payload = strings.TrimSpace(rest)
+		}
+	}
+
+	var closing string
+	if rest, ok := strings.CutPrefix(payload, `"`); ok {
+		payload, closing = rest, `"`
+	} else if rest, ok := strings.CutPrefix(payload, "“"); ok {
+		payload, closing = rest, "”"
+	} else {
+		return "", nil, 0, ErrPoolSyntax
+	}
+	question, payload, ok := strings.Cut(payload, closing)
+	question = strings.TrimSpace(question)
+	if !ok || question == "" {
+		return "", nil, 0, ErrPoolSyntax
+	}
+
+	outcomes = strings.Fields(payload)
+	if len(outcomes) < MinPoolOutcomes || len(outcomes) > MaxPoolOutcomes {
+		return "", nil, 0, ErrPoolOutcomes
+	}
+	return question, outcomes, window, nil
+}
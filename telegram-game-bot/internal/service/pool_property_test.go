@@ -0,0 +1,143 @@
+// Package service provides business logic implementations.
+// Property-based tests for parimutuel betting pools.
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// drawPoolBets draws bets of a few users on n outcomes
+func drawPoolBets(t *rapid.T, n int) []*model.PoolBet {
+	count := rapid.IntRange(0, 30).Draw(t, "count")
+	bets := make([]*model.PoolBet, count)
+	for i := range bets {
+		bets[i] = &model.PoolBet{
+			UserID:  rapid.Int64Range(1, 8).Draw(t, "user"),
+			Outcome: rapid.IntRange(0, n-1).Draw(t, "outcome"),
+			Amount:  rapid.Int64Range(1, 1_000_000).Draw(t, "amount"),
+		}
+	}
+	return bets
+}
+
+// TestSplitPoolProperty tests that a resolved pool pays out exactly the pool
+// minus the rake, only to users who backed the winner, and that nobody backing
+// the winner leaves the pool unsplit and unraked.
+func TestSplitPoolProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(MinPoolOutcomes, MaxPoolOutcomes).Draw(t, "outcomes")
+		bets := drawPoolBets(t, n)
+		winning := rapid.IntRange(0, n-1).Draw(t, "winning")
+		rakePercent := rapid.IntRange(0, 50).Draw(t, "rake")
+
+		var total int64
+		winners := make(map[int64]int64)
+		for _, b := range bets {
+			total += b.Amount
+			if b.Outcome == winning {
+				winners[b.UserID] += b.Amount
+			}
+		}
+
+		payouts, rake := SplitPool(bets, winning, rakePercent)
+		if len(winners) == 0 {
+			if payouts != nil || rake != 0 {
+				t.Fatalf("no winners: payouts = %v, rake = %d", payouts, rake)
+			}
+			return
+		}
+
+		if rake != total*int64(rakePercent)/100 {
+			t.Fatalf("rake = %d, want %d%% of %d", rake, rakePercent, total)
+		}
+		if len(payouts) != len(winners) {
+			t.Fatalf("%d payouts for %d winners", len(payouts), len(winners))
+		}
+		sum := rake
+		for i, p := range payouts {
+			if _, ok := winners[p.UserID]; !ok {
+				t.Fatalf("user %d paid without backing the winner", p.UserID)
+			}
+			if p.Amount < 0 {
+				t.Fatalf("negative payout %d", p.Amount)
+			}
+			if i > 0 && payouts[i-1].Amount < p.Amount {
+				t.Fatalf("payouts not ordered: %v", payouts)
+			}
+			sum += p.Amount
+		}
+		if sum != total {
+			t.Fatalf("payouts + rake = %d, want pool %d", sum, total)
+		}
+	})
+}
+
+// TestPoolRefundsProperty tests that refunds return every user's total stake.
+func TestPoolRefundsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		bets := drawPoolBets(t, MaxPoolOutcomes)
+
+		stakes := make(map[int64]int64)
+		for _, b := range bets {
+			stakes[b.UserID] += b.Amount
+		}
+
+		refunds := PoolRefunds(bets)
+		if len(refunds) != len(stakes) {
+			t.Fatalf("%d refunds for %d users", len(refunds), len(stakes))
+		}
+		for _, r := range refunds {
+			if r.Amount != stakes[r.UserID] {
+				t.Fatalf("user %d refunded %d, staked %d", r.UserID, r.Amount, stakes[r.UserID])
+			}
+		}
+	})
+}
+
+// TestParsePoolCommandProperty tests that /pool arguments round-trip through
+// the parser with either quote style and an optional betting window.
+func TestParsePoolCommandProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		question := rapid.StringMatching(`[a-z?？谁赢 ]{0,10}[a-z?？谁赢]`).Draw(t, "question")
+		n := rapid.IntRange(MinPoolOutcomes, MaxPoolOutcomes).Draw(t, "n")
+		outcomes := make([]string, n)
+		for i := range outcomes {
+			outcomes[i] = rapid.StringMatching(`[A-Za-z0-9队]{1,6}`).Draw(t, "outcome")
+		}
+		minutes := rapid.IntRange(0, 600).Draw(t, "minutes")
+		quotes := rapid.SampledFrom([][2]string{{`"`, `"`}, {"“", "”"}}).Draw(t, "quotes")
+
+		payload := fmt.Sprintf("%s%s%s %s", quotes[0], question, quotes[1], strings.Join(outcomes, " "))
+		if minutes > 0 {
+			payload = fmt.Sprintf("%d %s", minutes, payload)
+		}
+
+		gotQuestion, gotOutcomes, window, err := ParsePoolCommand(payload)
+		if err != nil {
+			t.Fatalf("ParsePoolCommand(%q): %v", payload, err)
+		}
+		if gotQuestion != strings.TrimSpace(question) {
+			t.Fatalf("question = %q, want %q", gotQuestion, question)
+		}
+		if strings.Join(gotOutcomes, " ") != strings.Join(outcomes, " ") {
+			t.Fatalf("outcomes = %v, want %v", gotOutcomes, outcomes)
+		}
+		if window != time.Duration(minutes)*time.Minute {
+			t.Fatalf("window = %v, want %d minutes", window, minutes)
+		}
+
+		if _, _, _, err := ParsePoolCommand(question + " " + strings.Join(outcomes, " ")); err == nil {
+			t.Fatalf("unquoted question %q accepted", question)
+		}
+		if _, _, _, err := ParsePoolCommand(`"` + question + `" ` + outcomes[0]); err == nil {
+			t.Fatal("single outcome accepted")
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// Promo code settings
+const (
+	PromoCodeLength   = 8
+	promoCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Without 0/O/1/I to avoid confusion
+	promoCodeAttempts = 5
+)
+
+// Promo code errors
+var (
+	ErrInvalidPromoUses     = errors.New("兑换次数必须大于 0")
+	ErrInvalidPromoReward   = errors.New("奖励必须是正整数金额或道具类型")
+	ErrInvalidPromoDuration = errors.New("有效期格式错误")
+	ErrPromoNotFound        = errors.New("兑换码不存在")
+	ErrPromoExpired         = errors.New("兑换码已过期")
+	ErrPromoExhausted       = errors.New("兑换码已被领完")
+	ErrPromoAlreadyRedeemed = errors.New("你已经兑换过这个兑换码")
+)
+
+// PromoReward describes what a promo code grants: either coins or an item
+type PromoReward struct {
+	Amount int64
+	Item   shop.ItemType
+}
+
+// IsItem returns true if the reward is an item
+func (r PromoReward) IsItem() bool {
+	return r.Item != ""
+}
+
+// PromoService handles promo code generation and redemption.
+type PromoService struct {
+	userRepo    *repository.UserRepository
+	txRepo      *repository.TransactionRepository
+	promoRepo   *repository.PromoRepository
+	shopService *ShopService
+	userLock    *lock.UserLock
+}
+
+// NewPromoService creates a new PromoService instance.
+func NewPromoService(
+	userRepo *repository.UserRepository,
+	txRepo *repository.TransactionRepository,
+	promoRepo *repository.PromoRepository,
+	shopService *ShopService,
+	userLock *lock.UserLock,
+) *PromoService {
+	return &PromoService{
+		userRepo:    userRepo,
+		txRepo:      txRepo,
+		promoRepo:   promoRepo,
+		shopService: shopService,
+		userLock:    userLock,
+	}
+}
+
+// GenerateCode creates a new random promo code.
+// maxUses == 1 creates a single-use code, maxUses > 1 a multi-use code.
+// validFor == 0 creates a code that never expires.
+func (s *PromoService) GenerateCode(ctx context.Context, adminID int64, reward PromoReward, maxUses int, validFor time.Duration) (*model.PromoCode, error) {
+	if maxUses <= 0 {
+		return nil, ErrInvalidPromoUses
+	}
+	if reward.IsItem() {
+		if _, ok := shop.GetItem(reward.Item); !ok {
+			return nil, ErrItemNotFound
+		}
+	} else if reward.Amount <= 0 {
+		return nil, ErrInvalidPromoReward
+	}
+
+	promo := &model.PromoCode{
+		RewardAmount: reward.Amount,
+		MaxUses:      maxUses,
+		CreatedBy:    adminID,
+	}
+	if reward.IsItem() {
+		item := string(reward.Item)
+		promo.RewardItem = &item
+	}
+	if validFor > 0 {
+		expiresAt := time.Now().Add(validFor)
+		promo.ExpiresAt = &expiresAt
+	}
+
+	// Retry on the unlikely event of a code collision
+	for attempt := 0; attempt < promoCodeAttempts; attempt++ {
+		code, err := generatePromoCode()
+		if err != nil {
+			return nil, err
+		}
+		promo.Code = code
+
+		err = s.promoRepo.Create(ctx, promo)
+		if err == nil {
+			log.Info().
+				Int64("admin_id", adminID).
+				Str("code", promo.Code).
+				Int64("reward_amount", promo.RewardAmount).
+				Str("reward_item", string(reward.Item)).
+				Int("max_uses", maxUses).
+				Str("operation", "promo_generate").
+				Msg("Promo code generated")
+			return promo, nil
+		}
+		if !errors.Is(err, repository.ErrPromoCodeExists) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("failed to generate unique promo code after %d attempts", promoCodeAttempts)
+}
+
+// Redeem redeems a promo code for the user and grants its reward.
+// Each user can redeem a given code at most once.
+func (s *PromoService) Redeem(ctx context.Context, userID int64, code string) (*model.PromoCode, error) {
+	code = NormalizePromoCode(code)
+
+	s.userLock.Lock(userID)
+	defer s.userLock.Unlock(userID)
+
+	promo, err := s.promoRepo.Redeem(ctx, code, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPromoNotFound):
+			return nil, ErrPromoNotFound
+		case errors.Is(err, repository.ErrPromoExpired):
+			return nil, ErrPromoExpired
+		case errors.Is(err, repository.ErrPromoExhausted):
+			return nil, ErrPromoExhausted
+		case errors.Is(err, repository.ErrPromoAlreadyRedeemed):
+			return nil, ErrPromoAlreadyRedeemed
+		}
+		return nil, err
+	}
+
+	if err := s.grantReward(ctx, userID, promo); err != nil {
+		// Give the use back so the code is not lost on a failed grant
+		if cancelErr := s.promoRepo.CancelRedemption(ctx, code, userID); cancelErr != nil {
+			log.Error().Err(cancelErr).Str("code", code).Int64("user_id", userID).Msg("Failed to cancel promo redemption")
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Int64("user_id", userID).
+		Str("code", code).
+		Int64("reward_amount", promo.RewardAmount).
+		Int("used_count", promo.UsedCount+1).
+		Int("max_uses", promo.MaxUses).
+		Str("operation", "promo_redeem").
+		Msg("Promo code redeemed")
+
+	return promo, nil
+}
+
+// grantReward gives the promo code reward to the user
+func (s *PromoService) grantReward(ctx context.Context, userID int64, promo *model.PromoCode) error {
+	if promo.RewardItem != nil {
+		return s.shopService.GrantItem(ctx, userID, shop.ItemType(*promo.RewardItem))
+	}
+
+	if _, err := s.userRepo.UpdateBalance(ctx, userID, promo.RewardAmount); err != nil {
+		return fmt.Errorf("failed to grant promo reward: %w", err)
+	}
+
+	desc := "兑换码 " + promo.Code
+	_, _ = s.txRepo.Create(ctx, userID, promo.RewardAmount, model.TxTypePromoRedeem, &desc)
+	return nil
+}
+
+// NormalizePromoCode trims and upper-cases a user supplied code
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ParsePromoReward parses a reward argument: a positive coin amount or an item type
+func ParsePromoReward(arg string) (PromoReward, error) {
+	if amount, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		if amount <= 0 {
+			return PromoReward{}, ErrInvalidPromoReward
+		}
+		return PromoReward{Amount: amount}, nil
+	}
+
+	itemType := shop.ItemType(strings.ToLower(arg))
+	if _, ok := shop.GetItem(itemType); !ok {
+		return PromoReward{}, ErrInvalidPromoReward
+	}
+	return PromoReward{Item: itemType}, nil
+}
+
+// ParsePromoDuration parses a validity argument such as "30m", "24h" or "7d".
+// "0" means the code never expires.
+func ParsePromoDuration(arg string) (time.Duration, error) {
+	if arg == "0" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(arg, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(arg, "d"))
+		if err != nil || days <= 0 {
+			return 0, ErrInvalidPromoDuration
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return 0, ErrInvalidPromoDuration
+	}
+	return d, nil
+}
+
+// generatePromoCode returns a random code from promoCodeAlphabet
+func generatePromoCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(promoCodeAlphabet)))
+	for i := 0; i < PromoCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate promo code: %w", err)
+		}
+		sb.WriteByte(promoCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,126 @@
+// Package service provides business logic implementations.
+// Property-based tests for PromoService argument parsing and code generation.
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/shop"
+)
+
+// TestParsePromoRewardProperty tests that positive amounts parse as coin rewards
+// and non-positive amounts are rejected.
+func TestParsePromoRewardProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		amount := rapid.Int64Range(-1000000, 1000000).Draw(t, "amount")
+
+		reward, err := ParsePromoReward(strconv.FormatInt(amount, 10))
+		if amount <= 0 {
+			if err == nil {
+				t.Fatalf("Amount %d should be rejected", amount)
+			}
+			return
+		}
+		if err != nil || reward.IsItem() || reward.Amount != amount {
+			t.Fatalf("Amount %d should parse as coin reward, got %+v, err=%v", amount, reward, err)
+		}
+	})
+}
+
+// TestParsePromoRewardItems tests that every shop item type parses as an item reward
+func TestParsePromoRewardItems(t *testing.T) {
+	for _, item := range shop.GetAllItems() {
+		reward, err := ParsePromoReward(strings.ToUpper(string(item.Type)))
+		if err != nil || reward.Item != item.Type {
+			t.Errorf("Item %s should parse as item reward, got %+v, err=%v", item.Type, reward, err)
+		}
+	}
+
+	if _, err := ParsePromoReward("not_an_item"); err == nil {
+		t.Error("Unknown item should be rejected")
+	}
+}
+
+// TestParsePromoDurationProperty tests minute, hour and day validity arguments
+func TestParsePromoDurationProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(1, 365).Draw(t, "n")
+		unit := rapid.SampledFrom([]string{"m", "h", "d"}).Draw(t, "unit")
+
+		expected := map[string]time.Duration{
+			"m": time.Minute,
+			"h": time.Hour,
+			"d": 24 * time.Hour,
+		}[unit] * time.Duration(n)
+
+		d, err := ParsePromoDuration(strconv.Itoa(n) + unit)
+		if err != nil || d != expected {
+			t.Fatalf("Expected %v for %d%s, got %v, err=%v", expected, n, unit, d, err)
+		}
+	})
+
+	if d, err := ParsePromoDuration("0"); err != nil || d != 0 {
+		t.Errorf("\"0\" should mean no expiry, got %v, err=%v", d, err)
+	}
+	for _, arg := range []string{"", "abc", "-1d", "0d", "-5h"} {
+		if _, err := ParsePromoDuration(arg); err == nil {
+			t.Errorf("Invalid duration %q should be rejected", arg)
+		}
+	}
+}
+
+// TestGeneratePromoCodeProperty tests that generated codes use the expected alphabet and length
+// and survive normalization unchanged
+func TestGeneratePromoCodeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		code, err := generatePromoCode()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(code) != PromoCodeLength {
+			t.Fatalf("Expected length %d, got %q", PromoCodeLength, code)
+		}
+		for _, ch := range code {
+			if !strings.ContainsRune(promoCodeAlphabet, ch) {
+				t.Fatalf("Code %q contains invalid character %q", code, ch)
+			}
+		}
+		if NormalizePromoCode(" "+strings.ToLower(code)+" ") != code {
+			t.Fatalf("Normalization should recover code %q", code)
+		}
+	})
+}
+
+// TestPromoCodeUsageState tests single-use, expiry and exhaustion checks
+func TestPromoCodeUsageState(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		maxUses := rapid.IntRange(1, 100).Draw(t, "maxUses")
+		usedCount := rapid.IntRange(0, 100).Draw(t, "usedCount")
+		offset := time.Duration(rapid.IntRange(-3600, 3600).Draw(t, "offset")) * time.Second
+
+		now := time.Now()
+		expiresAt := now.Add(offset)
+		promo := &model.PromoCode{MaxUses: maxUses, UsedCount: usedCount, ExpiresAt: &expiresAt}
+
+		if promo.IsSingleUse() != (maxUses == 1) {
+			t.Fatalf("IsSingleUse mismatch for maxUses=%d", maxUses)
+		}
+		if promo.IsExhausted() != (usedCount >= maxUses) {
+			t.Fatalf("IsExhausted mismatch for used=%d max=%d", usedCount, maxUses)
+		}
+		if promo.IsExpired(now) != (offset <= 0) {
+			t.Fatalf("IsExpired mismatch for offset=%v", offset)
+		}
+
+		promo.ExpiresAt = nil
+		if promo.IsExpired(now) {
+			t.Fatal("Code without expiry should never expire")
+		}
+	})
+}
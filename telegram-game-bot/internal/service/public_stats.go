@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Public stats settings
+const (
+	PublicStatsTopCount      = 10 // Players listed on the page
+	publicHandleVisibleChars = 2  // Handle characters kept after the '#'
+)
+
+// PublicStats is the snapshot shown on the public stats page. It holds no
+// balances, names or Telegram IDs.
+type PublicStats struct {
+	Top        []string  `json:"top"`         // Anonymized handles of the richest players, best first
+	GamesToday int64     `json:"games_today"` // Game transactions since midnight
+	BiggestWin int64     `json:"biggest_win"` // Largest single game win since midnight
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PublicHandle anonymizes a user's handle for the public stats page: all but
+// the first characters are masked, so the page cannot be used to target
+// players with handle commands. Users who opted out of leaderboards are shown
+// as AnonymousPlayerName.
+func PublicHandle(handle string, hidden bool) string {
+	if hidden || len(handle) <= 1+publicHandleVisibleChars {
+		return AnonymousPlayerName
+	}
+	return handle[:1+publicHandleVisibleChars] + strings.Repeat("*", len(handle)-1-publicHandleVisibleChars)
+}
+
+// publicStatsPage renders PublicStats; html/template escapes every value
+var publicStatsPage = template.Must(template.New("public_stats").Parse(`<!DOCTYPE html>
+<html lang="zh">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>游戏统计</title>
+</head>
+<body>
+<h1>🎲 游戏统计</h1>
+<p>今日游戏: {{.GamesToday}}</p>
+<p>今日最大奖: {{.BiggestWin}} 金币</p>
+<h2>🏆 富豪榜</h2>
+<ol>
+{{- range .Top}}
+<li>{{.}}</li>
+{{- end}}
+</ol>
+<p><small>更新于 {{.UpdatedAt.Format "2006-01-02 15:04"}}</small></p>
+</body>
+</html>
+`))
+
+// RenderPublicStats renders the public stats page.
+func RenderPublicStats(stats *PublicStats) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := publicStatsPage.Execute(&buf, stats); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PublicStatsService keeps the snapshot of the public stats page, refreshed
+// on a schedule so page views never query the database. It serves the page
+// over HTTP and can also write it to a file for a static web server.
+type PublicStatsService struct {
+	userRepo   *repository.UserRepository
+	txRepo     *repository.TransactionRepository
+	timezone   *time.Location
+	outputFile string // "" = not written
+
+	mu   sync.RWMutex
+	page []byte
+	json []byte
+}
+
+// NewPublicStatsService creates a new PublicStatsService instance.
+func NewPublicStatsService(userRepo *repository.UserRepository, txRepo *repository.TransactionRepository, timezone *time.Location, outputFile string) *PublicStatsService {
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	return &PublicStatsService{userRepo: userRepo, txRepo: txRepo, timezone: timezone, outputFile: outputFile}
+}
+
+// Refresh rebuilds the snapshot at now. The previous snapshot is kept when
+// the database cannot be read.
+func (s *PublicStatsService) Refresh(ctx context.Context, now time.Time) {
+	stats, err := s.collect(ctx, now)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "public_stats").Msg("Failed to collect public stats")
+		return
+	}
+	page, err := RenderPublicStats(stats)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "public_stats").Msg("Failed to render public stats")
+		return
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "public_stats").Msg("Failed to encode public stats")
+		return
+	}
+
+	s.mu.Lock()
+	s.page, s.json = page, data
+	s.mu.Unlock()
+
+	if s.outputFile != "" {
+		if err := writeFileAtomic(s.outputFile, page); err != nil {
+			log.Error().Err(err).Str("operation", "public_stats").Str("file", s.outputFile).Msg("Failed to write public stats")
+		}
+	}
+}
+
+// collect reads the stats shown at now
+func (s *PublicStatsService) collect(ctx context.Context, now time.Time) (*PublicStats, error) {
+	users, err := s.userRepo.GetTopUsers(ctx, PublicStatsTopCount)
+	if err != nil {
+		return nil, err
+	}
+	now = now.In(s.timezone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.timezone)
+	games, biggest, err := s.txRepo.GetGameActivity(ctx, today, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &PublicStats{Top: publicTop(users), GamesToday: games, BiggestWin: biggest, UpdatedAt: now}
+	return stats, nil
+}
+
+// publicTop anonymizes the handles of the ranked users
+func publicTop(users []*model.User) []string {
+	top := make([]string, 0, len(users))
+	for _, u := range users {
+		top = append(top, PublicHandle(u.Handle, u.HideFromLeaderboard))
+	}
+	return top
+}
+
+// ServeHTTP serves the page on "/" and the same stats as JSON on
+// "/stats.json". Until the first refresh it answers 503.
+func (s *PublicStatsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	page, data := s.page, s.json
+	s.mu.RUnlock()
+
+	if page == nil {
+		http.Error(w, "stats not ready", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.URL.Path {
+	case "/", "/index.html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	case "/stats.json":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeFileAtomic replaces path with data, so a web server never reads a
+// partly written page
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".public_stats-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,82 @@
+// Package service provides business logic implementations.
+// Property-based tests for the public stats page.
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestPublicHandleProperty tests that anonymized handles cannot be resolved
+// to a user, keep the handle's prefix and hide opted-out users entirely.
+func TestPublicHandleProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		handle := model.NewHandle()
+		hidden := rapid.Bool().Draw(t, "hidden")
+
+		public := PublicHandle(handle, hidden)
+
+		if _, ok := model.ParseHandle(public); ok {
+			t.Fatalf("Anonymized %q as the resolvable handle %q", handle, public)
+		}
+		if hidden {
+			if public != AnonymousPlayerName {
+				t.Fatalf("Opted-out user shown as %q", public)
+			}
+			return
+		}
+		if len(public) != len(handle) || !strings.HasPrefix(handle, public[:1+publicHandleVisibleChars]) {
+			t.Fatalf("Anonymized %q as %q", handle, public)
+		}
+	})
+}
+
+// TestRenderPublicStatsProperty tests that the page lists the anonymized
+// handles in order and shows no balance or Telegram ID.
+func TestRenderPublicStatsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(0, PublicStatsTopCount).Draw(t, "players")
+		users := make([]*model.User, n)
+		for i := range users {
+			users[i] = &model.User{
+				TelegramID:          rapid.Int64Range(100000000, 9999999999).Draw(t, "id"),
+				Balance:             rapid.Int64Range(1000000, 1000000000).Draw(t, "balance"),
+				Handle:              model.NewHandle(),
+				HideFromLeaderboard: rapid.Bool().Draw(t, "hidden"),
+			}
+		}
+		stats := &PublicStats{
+			Top:        publicTop(users),
+			GamesToday: rapid.Int64Range(0, 999).Draw(t, "games"),
+			BiggestWin: rapid.Int64Range(0, 999).Draw(t, "biggestWin"),
+			UpdatedAt:  time.Unix(1700000000, 0),
+		}
+
+		page, err := RenderPublicStats(stats)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		html := string(page)
+		pos := 0
+		for i, u := range users {
+			if strings.Contains(html, strconv.FormatInt(u.TelegramID, 10)) || strings.Contains(html, strconv.FormatInt(u.Balance, 10)) {
+				t.Fatalf("Page exposes the ID or balance of player %d", i)
+			}
+			if strings.Contains(html, u.Handle) {
+				t.Fatalf("Page exposes the handle %s", u.Handle)
+			}
+			item := "<li>" + stats.Top[i] + "</li>"
+			next := strings.Index(html[pos:], item)
+			if next < 0 {
+				t.Fatalf("Player %d (%s) missing or out of order", i, stats.Top[i])
+			}
+			pos += next + len(item)
+		}
+	})
+}
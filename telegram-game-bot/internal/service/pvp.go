@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// PvPChangeCooldown is how long a PvP opt-out or opt-in stays in effect
+// before it can be changed again
+const PvPChangeCooldown = 24 * time.Hour
+
+// PvP preference errors
+var (
+	ErrPvPUnchanged      = errors.New("PvP 状态未变化")
+	ErrPvPChangeCooldown = errors.New("PvP 状态每 24 小时只能修改一次")
+)
+
+// PvPService lets users opt out of rob, duels and handcuffs.
+// Opted-out users can neither be attacked nor attack others.
+type PvPService struct {
+	repo *repository.PvPRepository
+}
+
+// NewPvPService creates a new PvPService instance.
+func NewPvPService(repo *repository.PvPRepository) *PvPService {
+	return &PvPService{repo: repo}
+}
+
+// Get returns a user's PvP preference, nil if the user never changed it.
+func (s *PvPService) Get(ctx context.Context, userID int64) (*model.PvPPreference, error) {
+	return s.repo.Get(ctx, userID)
+}
+
+// SetOptedOut changes whether a user takes part in PvP features.
+// Returns the remaining wait with ErrPvPChangeCooldown.
+func (s *PvPService) SetOptedOut(ctx context.Context, userID int64, optedOut bool, now time.Time) (time.Duration, error) {
+	current, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if remaining, err := CheckPvPChange(current, optedOut, now); err != nil {
+		return remaining, err
+	}
+
+	if _, err := s.repo.Set(ctx, userID, optedOut); err != nil {
+		return 0, err
+	}
+
+	log.Info().
+		Str("operation", "pvp_preference").
+		Int64("user_id", userID).
+		Bool("opted_out", optedOut).
+		Msg("PvP preference changed")
+	return 0, nil
+}
+
+// CheckPvPChange validates changing the preference current (nil = never
+// changed, taking part) to optedOut at now. Returns the remaining wait with
+// ErrPvPChangeCooldown.
+func CheckPvPChange(current *model.PvPPreference, optedOut bool, now time.Time) (time.Duration, error) {
+	if current == nil {
+		if !optedOut {
+			return 0, ErrPvPUnchanged
+		}
+		return 0, nil
+	}
+	if current.OptedOut == optedOut {
+		return 0, ErrPvPUnchanged
+	}
+	if remaining := current.ChangedAt.Add(PvPChangeCooldown).Sub(now); remaining > 0 {
+		return remaining, ErrPvPChangeCooldown
+	}
+	return 0, nil
+}
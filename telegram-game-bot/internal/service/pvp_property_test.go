@@ -0,0 +1,52 @@
+// Package service provides business logic implementations.
+// Property-based tests for PvP opt-outs.
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestPvPChangeProperty tests that the PvP preference can only be flipped,
+// and only once the previous change is PvPChangeCooldown old.
+func TestPvPChangeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(1700000000, 0)
+		optedOut := rapid.Bool().Draw(t, "optedOut")
+		current := rapid.Bool().Draw(t, "current")
+		age := time.Duration(rapid.Int64Range(0, int64(48*time.Hour)).Draw(t, "age"))
+		pref := &model.PvPPreference{UserID: 1, OptedOut: current, ChangedAt: now.Add(-age)}
+
+		remaining, err := CheckPvPChange(pref, optedOut, now)
+		switch {
+		case current == optedOut:
+			if !errors.Is(err, ErrPvPUnchanged) {
+				t.Fatalf("Setting unchanged state returned %v", err)
+			}
+		case age < PvPChangeCooldown:
+			if !errors.Is(err, ErrPvPChangeCooldown) || remaining != PvPChangeCooldown-age {
+				t.Fatalf("Change after %v returned %v, remaining %v", age, err, remaining)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("Change after %v returned %v", age, err)
+			}
+		}
+	})
+}
+
+// TestPvPChangeWithoutPreference tests that users take part until they first opt out.
+func TestPvPChangeWithoutPreference(t *testing.T) {
+	now := time.Now()
+	if _, err := CheckPvPChange(nil, true, now); err != nil {
+		t.Fatalf("First opt-out returned %v", err)
+	}
+	if _, err := CheckPvPChange(nil, false, now); !errors.Is(err, ErrPvPUnchanged) {
+		t.Fatalf("Opting in without preference returned %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+// quickBetCommands maps the letter of a quick bet to the command it places
+var quickBetCommands = map[string]string{
+	"d": "/dice",
+	"s": "/slot",
+}
+
+// quickBetPattern matches a quick bet: a game letter followed by the amount
+var quickBetPattern = regexp.MustCompile(`^([a-zA-Z])\s*([0-9]{1,12})$`)
+
+// ParseQuickBet turns a shorthand bet such as "d100" (dice 100) or "s500"
+// (slot 500) into the command it stands for. Any other text is not a quick bet.
+func ParseQuickBet(text string) (command string, ok bool) {
+	m := quickBetPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return "", false
+	}
+	game, ok := quickBetCommands[strings.ToLower(m[1])]
+	if !ok {
+		return "", false
+	}
+	return game + " " + m[2], true
+}
@@ -0,0 +1,48 @@
+// Package service provides business logic implementations.
+// Property-based tests for quick bets.
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestParseQuickBetProperty tests that a game letter and an amount, in any
+// case and spacing, become the command of that game with the same amount.
+func TestParseQuickBetProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		letter := rapid.SampledFrom([]string{"d", "D", "s", "S"}).Draw(t, "letter")
+		amount := rapid.Int64Range(0, 999_999_999_999).Draw(t, "amount")
+		space := rapid.SampledFrom([]string{"", " ", "  "}).Draw(t, "space")
+		text := space + letter + space + strconv.FormatInt(amount, 10) + space
+
+		command, ok := ParseQuickBet(text)
+		if !ok {
+			t.Fatalf("%q not parsed", text)
+		}
+		want := quickBetCommands[strings.ToLower(letter)] + " " + strconv.FormatInt(amount, 10)
+		if command != want {
+			t.Fatalf("%q parsed as %q, want %q", text, command, want)
+		}
+	})
+}
+
+// TestParseQuickBetRejectsProperty tests that chat messages which are not
+// exactly a known letter and an amount are left alone.
+func TestParseQuickBetRejectsProperty(t *testing.T) {
+	for _, text := range []string{"", "d", "100", "x100", "d-100", "d 1e3", "dd100", "d100 please", "/dice 100", "hi s500"} {
+		if command, ok := ParseQuickBet(text); ok {
+			t.Fatalf("%q parsed as %q", text, command)
+		}
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		text := rapid.StringMatching(`[a-zA-Z ]{0,20}`).Draw(t, "text")
+		if command, ok := ParseQuickBet(text); ok {
+			t.Fatalf("%q without an amount parsed as %q", text, command)
+		}
+	})
+}
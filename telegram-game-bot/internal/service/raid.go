@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+)
+
+// Raid limits
+const (
+	MinRaidDuration  = 10 * time.Minute
+	MaxRaidDuration  = 24 * time.Hour
+	MaxRaidDelay     = 7 * 24 * time.Hour
+	RaidPointsPerRob = 1 // Points for a successful rob of an opposing member
+)
+
+// Raid errors
+var (
+	ErrRaidExists    = errors.New("已有未结束的突袭活动")
+	ErrNoRaid        = errors.New("当前没有突袭活动")
+	ErrRaidSameChat  = errors.New("对战双方不能是同一个群")
+	ErrRaidInvalid   = errors.New("突袭活动参数无效")
+	ErrRaidWrongChat = errors.New("本群没有参加当前突袭活动")
+	ErrRaidJoined    = errors.New("你已经加入了本次突袭")
+)
+
+// RaidPayout is a member's share of the prize pool
+type RaidPayout struct {
+	UserID int64
+	Amount int64
+}
+
+// RaidService runs raid events: two chats compete, and successful robs of members
+// of the opposing side score points. When the window closes, the side with more
+// points splits the prize pool by points.
+type RaidService struct {
+	raidRepo     *repository.RaidRepository
+	userRepo     *repository.UserRepository
+	txRepo       *repository.TransactionRepository
+	userLock     *lock.UserLock
+	notifier     ChatAnnouncer
+	celebrations *CelebrationService // Optional: media for the winning chat
+
+	mu      sync.Mutex
+	current *model.RaidEvent // Scheduled or active raid, nil if none
+	now     func() time.Time
+}
+
+// NewRaidService creates a new RaidService instance.
+func NewRaidService(
+	raidRepo *repository.RaidRepository,
+	userRepo *repository.UserRepository,
+	txRepo *repository.TransactionRepository,
+	userLock *lock.UserLock,
+) *RaidService {
+	return &RaidService{
+		raidRepo: raidRepo,
+		userRepo: userRepo,
+		txRepo:   txRepo,
+		userLock: userLock,
+		now:      time.Now,
+	}
+}
+
+// SetNotifier sets the notifier used for raid announcements
+func (s *RaidService) SetNotifier(notifier ChatAnnouncer) {
+	s.notifier = notifier
+}
+
+// SetCelebrations sets the service celebrating the winning chat with media
+func (s *RaidService) SetCelebrations(celebrations *CelebrationService) {
+	s.celebrations = celebrations
+}
+
+// Load restores the current raid (called on startup)
+func (s *RaidService) Load(ctx context.Context) error {
+	raid, err := s.raidRepo.GetCurrent(ctx)
+	if err != nil {
+		if errors.Is(err, repository.ErrRaidNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	s.current = raid
+	s.mu.Unlock()
+	return nil
+}
+
+// Schedule creates a raid between chatA and chatB starting after delay.
+func (s *RaidService) Schedule(ctx context.Context, adminID, chatA, chatB int64, duration, delay time.Duration, prizePool int64) (*model.RaidEvent, error) {
+	if chatA == chatB {
+		return nil, ErrRaidSameChat
+	}
+	if duration < MinRaidDuration || duration > MaxRaidDuration || delay < 0 || delay > MaxRaidDelay || prizePool <= 0 {
+		return nil, ErrRaidInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil {
+		return nil, ErrRaidExists
+	}
+
+	startsAt := s.now().Add(delay)
+	raid := &model.RaidEvent{
+		ChatA:     chatA,
+		ChatB:     chatB,
+		PrizePool: prizePool,
+		Status:    model.RaidStatusScheduled,
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(duration),
+		CreatedBy: adminID,
+	}
+	if delay == 0 {
+		raid.Status = model.RaidStatusActive
+	}
+	if err := s.raidRepo.Create(ctx, raid); err != nil {
+		return nil, err
+	}
+	s.current = raid
+
+	log.Info().
+		Int64("raid_id", raid.ID).
+		Int64("chat_a", chatA).
+		Int64("chat_b", chatB).
+		Int64("prize_pool", prizePool).
+		Time("starts_at", raid.StartsAt).
+		Time("ends_at", raid.EndsAt).
+		Str("operation", "raid_schedule").
+		Msg("Raid scheduled")
+
+	var text string
+	if raid.Status == model.RaidStatusActive {
+		text = fmt.Sprintf("⚔️ 群战突袭开始！\n\n"+
+			"持续 %d 分钟，奖池 %d 金币\n"+
+			"发送 /raid_join 加入本群阵营，打劫对方阵营成员成功可得 %d 分\n"+
+			"结束时积分高的一方按个人积分瓜分奖池",
+			int(duration.Minutes()), prizePool, RaidPointsPerRob)
+	} else {
+		text = fmt.Sprintf("📣 群战突袭预告！\n\n"+
+			"开始时间: %s\n"+
+			"持续 %d 分钟，奖池 %d 金币\n"+
+			"现在就可以发送 /raid_join 加入本群阵营",
+			raid.StartsAt.Format("01-02 15:04"), int(duration.Minutes()), prizePool)
+	}
+	s.announce(raid, text)
+
+	return raid, nil
+}
+
+// Cancel cancels the current raid without paying the prize.
+func (s *RaidService) Cancel(ctx context.Context, adminID int64) (*model.RaidEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raid := s.current
+	if raid == nil {
+		return nil, ErrNoRaid
+	}
+	if err := s.raidRepo.UpdateStatus(ctx, raid.ID, model.RaidStatusCancelled, nil); err != nil {
+		if !errors.Is(err, repository.ErrRaidClosed) {
+			return nil, err
+		}
+	}
+	s.current = nil
+	raid.Status = model.RaidStatusCancelled
+
+	log.Info().
+		Int64("raid_id", raid.ID).
+		Int64("admin_id", adminID).
+		Str("operation", "raid_cancel").
+		Msg("Raid cancelled")
+
+	s.announce(raid, "🚫 群战突袭已被管理员取消")
+	return raid, nil
+}
+
+// Join adds a user to the side of chatID.
+func (s *RaidService) Join(ctx context.Context, userID, chatID int64) (*model.RaidEvent, error) {
+	raid := s.Current()
+	if raid == nil {
+		return nil, ErrNoRaid
+	}
+	if !raid.HasChat(chatID) {
+		return nil, ErrRaidWrongChat
+	}
+
+	if err := s.raidRepo.AddMember(ctx, raid.ID, userID, chatID); err != nil {
+		if errors.Is(err, repository.ErrRaidMemberExists) {
+			return nil, ErrRaidJoined
+		}
+		return nil, err
+	}
+	return raid, nil
+}
+
+// Current returns a copy of the scheduled or active raid, or nil.
+func (s *RaidService) Current() *model.RaidEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return nil
+	}
+	raid := *s.current
+	return &raid
+}
+
+// GetMembers returns the members of a raid, highest points first.
+func (s *RaidService) GetMembers(ctx context.Context, raidID int64) ([]*model.RaidMember, error) {
+	return s.raidRepo.GetMembers(ctx, raidID)
+}
+
+// OnRobSuccess scores a successful rob of an opposing member during an active raid.
+// Implements rob.RobHook.
+func (s *RaidService) OnRobSuccess(ctx context.Context, robberID, victimID, amount int64) {
+	raid := s.Current()
+	if raid == nil || raid.Status != model.RaidStatusActive {
+		return
+	}
+	if now := s.now(); now.Before(raid.StartsAt) || !now.Before(raid.EndsAt) {
+		return
+	}
+
+	robber, err := s.raidRepo.GetMember(ctx, raid.ID, robberID)
+	if err != nil {
+		return
+	}
+	victim, err := s.raidRepo.GetMember(ctx, raid.ID, victimID)
+	if err != nil {
+		return
+	}
+	if robber.ChatID == victim.ChatID {
+		return
+	}
+
+	if err := s.raidRepo.AddPoints(ctx, raid.ID, robberID, RaidPointsPerRob); err != nil {
+		log.Warn().Err(err).Int64("raid_id", raid.ID).Int64("user_id", robberID).Msg("Failed to score raid rob")
+	}
+}
+
+// Tick starts a scheduled raid and settles an expired one. Called periodically.
+func (s *RaidService) Tick(ctx context.Context) {
+	s.mu.Lock()
+	raid := s.current
+	if raid == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	now := s.now()
+	if raid.Status == model.RaidStatusScheduled && !now.Before(raid.StartsAt) {
+		if err := s.raidRepo.UpdateStatus(ctx, raid.ID, model.RaidStatusActive, nil); err != nil {
+			s.mu.Unlock()
+			log.Error().Err(err).Int64("raid_id", raid.ID).Msg("Failed to start raid")
+			return
+		}
+		raid.Status = model.RaidStatusActive
+		s.mu.Unlock()
+
+		s.announce(raid, fmt.Sprintf("⚔️ 群战突袭开始！\n\n"+
+			"持续 %d 分钟，奖池 %d 金币\n"+
+			"发送 /raid_join 加入本群阵营，打劫对方阵营成员成功可得 %d 分",
+			int(raid.EndsAt.Sub(raid.StartsAt).Minutes()), raid.PrizePool, RaidPointsPerRob))
+		return
+	}
+
+	if raid.Status != model.RaidStatusActive || now.Before(raid.EndsAt) {
+		s.mu.Unlock()
+		return
+	}
+
+	// Close the raid under the lock, pay without it: payouts take user locks,
+	// which rob hooks already hold while waiting for s.mu
+	members, err := s.raidRepo.GetMembers(ctx, raid.ID)
+	if err != nil {
+		s.mu.Unlock()
+		log.Error().Err(err).Int64("raid_id", raid.ID).Msg("Failed to load raid members")
+		return
+	}
+	pointsA, pointsB := RaidSideTotals(raid, members)
+	winner := DecideRaidWinner(raid, pointsA, pointsB)
+	if err := s.raidRepo.UpdateStatus(ctx, raid.ID, model.RaidStatusFinished, winner); err != nil {
+		s.mu.Unlock()
+		log.Error().Err(err).Int64("raid_id", raid.ID).Msg("Failed to finish raid")
+		return
+	}
+	raid.Status = model.RaidStatusFinished
+	raid.WinnerChat = winner
+	s.current = nil
+	s.mu.Unlock()
+
+	s.settle(ctx, raid, members, pointsA, pointsB)
+}
+
+// settle pays the winning side and announces the result
+func (s *RaidService) settle(ctx context.Context, raid *model.RaidEvent, members []*model.RaidMember, pointsA, pointsB int) {
+	var payouts []RaidPayout
+	if raid.WinnerChat != nil {
+		payouts = SplitRaidPrize(raid.PrizePool, *raid.WinnerChat, members)
+	}
+
+	for _, payout := range payouts {
+		if err := s.payPrize(ctx, raid, payout); err != nil {
+			log.Error().Err(err).
+				Int64("raid_id", raid.ID).
+				Int64("user_id", payout.UserID).
+				Int64("amount", payout.Amount).
+				Msg("Failed to pay raid prize")
+		}
+	}
+
+	log.Info().
+		Int64("raid_id", raid.ID).
+		Int("points_a", pointsA).
+		Int("points_b", pointsB).
+		Int("winners", len(payouts)).
+		Str("operation", "raid_settle").
+		Msg("Raid settled")
+
+	if s.notifier == nil {
+		return
+	}
+	for _, chatID := range []int64{raid.ChatA, raid.ChatB} {
+		ours, theirs := pointsA, pointsB
+		if chatID == raid.ChatB {
+			ours, theirs = pointsB, pointsA
+		}
+
+		text := fmt.Sprintf("🏁 群战突袭结束！\n\n本群 %d : %d 对方\n", ours, theirs)
+		switch {
+		case raid.WinnerChat == nil:
+			text += "🤝 双方打平，奖池不发放"
+		case *raid.WinnerChat == chatID:
+			text += fmt.Sprintf("🏆 本群获胜！奖池 %d 金币已按积分分给 %d 名成员", raid.PrizePool, len(payouts))
+		default:
+			text += "😢 本群惜败，下次再战！"
+		}
+		s.notifier.Announce(chatID, text)
+	}
+
+	if raid.WinnerChat != nil && s.celebrations != nil {
+		s.celebrations.Celebrate(ctx, *raid.WinnerChat, model.CelebrationRaidVictory)
+	}
+}
+
+// payPrize credits a member's share of the prize pool
+func (s *RaidService) payPrize(ctx context.Context, raid *model.RaidEvent, payout RaidPayout) error {
+	s.userLock.Lock(payout.UserID)
+	defer s.userLock.Unlock(payout.UserID)
+
+	if _, err := s.userRepo.UpdateBalance(ctx, payout.UserID, payout.Amount); err != nil {
+		return err
+	}
+	desc := fmt.Sprintf("群战突袭 #%d 奖励", raid.ID)
+	_, _ = s.txRepo.Create(ctx, payout.UserID, payout.Amount, model.TxTypeRaidPrize, &desc)
+	return nil
+}
+
+// announce sends text to both chats of a raid
+func (s *RaidService) announce(raid *model.RaidEvent, text string) {
+	if s.notifier == nil {
+		return
+	}
+	s.notifier.Announce(raid.ChatA, text)
+	s.notifier.Announce(raid.ChatB, text)
+}
+
+// RaidSideTotals sums the points of each side
+func RaidSideTotals(raid *model.RaidEvent, members []*model.RaidMember) (pointsA, pointsB int) {
+	for _, m := range members {
+		switch m.ChatID {
+		case raid.ChatA:
+			pointsA += m.Points
+		case raid.ChatB:
+			pointsB += m.Points
+		}
+	}
+	return pointsA, pointsB
+}
+
+// DecideRaidWinner returns the winning chat, or nil on a tie
+func DecideRaidWinner(raid *model.RaidEvent, pointsA, pointsB int) *int64 {
+	switch {
+	case pointsA > pointsB:
+		winner := raid.ChatA
+		return &winner
+	case pointsB > pointsA:
+		winner := raid.ChatB
+		return &winner
+	default:
+		return nil
+	}
+}
+
+// SplitRaidPrize splits the prize pool among the members of the winning chat by points.
+// Members without points get nothing; the rounding remainder goes to the top scorer
+// (lowest user ID on ties), so the payouts always sum to the pool when anyone scored.
+// Payouts are ordered by user ID.
+func SplitRaidPrize(prizePool int64, winnerChat int64, members []*model.RaidMember) []RaidPayout {
+	var scorers []*model.RaidMember
+	var totalPoints int64
+	for _, m := range members {
+		if m.ChatID == winnerChat && m.Points > 0 {
+			scorers = append(scorers, m)
+			totalPoints += int64(m.Points)
+		}
+	}
+	if totalPoints == 0 || prizePool <= 0 {
+		return nil
+	}
+
+	sort.Slice(scorers, func(i, j int) bool {
+		if scorers[i].Points != scorers[j].Points {
+			return scorers[i].Points > scorers[j].Points
+		}
+		return scorers[i].UserID < scorers[j].UserID
+	})
+
+	payouts := make([]RaidPayout, len(scorers))
+	var paid int64
+	for i, m := range scorers {
+		amount := prizePool * int64(m.Points) / totalPoints
+		payouts[i] = RaidPayout{UserID: m.UserID, Amount: amount}
+		paid += amount
+	}
+	payouts[0].Amount += prizePool - paid
+
+	sort.Slice(payouts, func(i, j int) bool {
+		return payouts[i].UserID < payouts[j].UserID
+	})
+	return payouts
+}
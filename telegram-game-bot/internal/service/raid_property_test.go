@@ -0,0 +1,110 @@
+// Package service provides business logic implementations.
+// Property-based tests for raid scoring and prize splitting.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// drawRaidMembers draws members with unique user IDs on either side of raid.
+func drawRaidMembers(t *rapid.T, raid *model.RaidEvent) []*model.RaidMember {
+	n := rapid.IntRange(0, 20).Draw(t, "n")
+	members := make([]*model.RaidMember, n)
+	for i := range members {
+		chatID := raid.ChatA
+		if rapid.Bool().Draw(t, "sideB") {
+			chatID = raid.ChatB
+		}
+		members[i] = &model.RaidMember{
+			RaidID: raid.ID,
+			UserID: int64(i + 1),
+			ChatID: chatID,
+			Points: rapid.IntRange(0, 50).Draw(t, "points"),
+		}
+	}
+	return members
+}
+
+// TestSplitRaidPrizeProperty tests the prize split.
+// *For any* members and pool P: only scoring members of the winning chat are paid,
+// nobody gets a negative share, a member with more points never gets less,
+// and the payouts sum to P whenever anyone on the winning side scored.
+func TestSplitRaidPrizeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		raid := &model.RaidEvent{ID: 1, ChatA: -100, ChatB: -200}
+		members := drawRaidMembers(t, raid)
+		pool := rapid.Int64Range(1, 1_000_000).Draw(t, "pool")
+		winner := rapid.SampledFrom([]int64{raid.ChatA, raid.ChatB}).Draw(t, "winner")
+
+		payouts := SplitRaidPrize(pool, winner, members)
+
+		byUser := make(map[int64]*model.RaidMember)
+		winnerPoints := 0
+		for _, m := range members {
+			byUser[m.UserID] = m
+			if m.ChatID == winner {
+				winnerPoints += m.Points
+			}
+		}
+
+		var total int64
+		for _, p := range payouts {
+			m := byUser[p.UserID]
+			if m == nil || m.ChatID != winner || m.Points == 0 {
+				t.Fatalf("User %d should not be paid", p.UserID)
+			}
+			if p.Amount < 0 {
+				t.Fatalf("Negative payout %d for user %d", p.Amount, p.UserID)
+			}
+			total += p.Amount
+		}
+
+		if winnerPoints == 0 {
+			if len(payouts) != 0 {
+				t.Fatalf("No points on the winning side, expected no payouts, got %d", len(payouts))
+			}
+			return
+		}
+		if total != pool {
+			t.Fatalf("Payouts sum to %d, expected pool %d", total, pool)
+		}
+
+		for _, a := range payouts {
+			for _, b := range payouts {
+				if byUser[a.UserID].Points > byUser[b.UserID].Points && a.Amount < b.Amount {
+					t.Fatalf("User %d has more points but less prize than user %d", a.UserID, b.UserID)
+				}
+			}
+		}
+	})
+}
+
+// TestDecideRaidWinnerProperty tests that the side with more points wins and ties have no winner.
+func TestDecideRaidWinnerProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		raid := &model.RaidEvent{ID: 1, ChatA: -100, ChatB: -200}
+		members := drawRaidMembers(t, raid)
+
+		pointsA, pointsB := RaidSideTotals(raid, members)
+		winner := DecideRaidWinner(raid, pointsA, pointsB)
+
+		switch {
+		case pointsA > pointsB:
+			if winner == nil || *winner != raid.ChatA {
+				t.Fatalf("A leads %d:%d but winner is %v", pointsA, pointsB, winner)
+			}
+		case pointsB > pointsA:
+			if winner == nil || *winner != raid.ChatB {
+				t.Fatalf("B leads %d:%d but winner is %v", pointsA, pointsB, winner)
+			}
+		default:
+			if winner != nil {
+				t.Fatalf("Tie %d:%d should have no winner, got %d", pointsA, pointsB, *winner)
+			}
+		}
+	})
+}
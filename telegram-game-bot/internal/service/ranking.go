@@ -2,18 +2,24 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/textfilter"
 	"telegram-game-bot/internal/repository"
 )
 
+// AnonymousPlayerName replaces the name of users who opted out of public leaderboards
+const AnonymousPlayerName = "匿名玩家"
+
 // RankingService handles ranking and leaderboard operations.
 // Requirements: 1.5, 11.1, 11.2, 11.3 - Ranking functionality
 type RankingService struct {
-	userRepo *repository.UserRepository
-	txRepo   *repository.TransactionRepository
-	timezone *time.Location
+	userRepo   *repository.UserRepository
+	txRepo     *repository.TransactionRepository
+	aggregates *repository.UserAggregateRepository // Optional: daily profit aggregates
+	timezone   *time.Location
 }
 
 // NewRankingService creates a new RankingService instance.
@@ -74,3 +80,15 @@ func (s *RankingService) GetUserDailyProfit(ctx context.Context, userID int64) (
 	today := time.Now().In(s.timezone)
 	return s.txRepo.GetUserDailyProfit(ctx, userID, today)
 }
+
+// RankDisplayName returns the name shown for a user on public leaderboards.
+// Users who opted out are shown as AnonymousPlayerName but still keep their rank.
+func RankDisplayName(username string, userID int64, hidden bool) string {
+	if hidden {
+		return AnonymousPlayerName
+	}
+	if name := textfilter.Name(username); name != "" {
+		return name
+	}
+	return fmt.Sprintf("User%d", userID)
+}
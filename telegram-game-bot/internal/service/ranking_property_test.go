@@ -6,6 +6,7 @@
 package service
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 
@@ -161,6 +162,62 @@ func TestGameTransactionTypesOnlyProperty(t *testing.T) {
 	})
 }
 
+// TestGameTransactionClassProperty tests that rankings count exactly the house
+// game and PvP types, including the all-in and duel types.
+func TestGameTransactionClassProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		txType := rapid.SampledFrom([]string{
+			model.TxTypeDice, model.TxTypeSlot, model.TxTypeSicBoWin, model.TxTypeSicBoBet,
+			model.TxTypeRob, model.TxTypeRobbed, model.TxTypeCounterAttack,
+			model.TxTypeAllInRobWin, model.TxTypeAllInRobLose, model.TxTypeDuelWin, model.TxTypeDuelLose,
+			model.TxTypeDiceWin, model.TxTypeDiceLose,
+			model.TxTypeTransfer, model.TxTypeDaily, model.TxTypeShopPurchase, model.TxTypeBailout,
+		}).Draw(t, "txType")
+
+		class := model.TransactionClass(txType)
+		if got, want := isGameTransaction(txType), class != model.TxClassOther; got != want {
+			t.Fatalf("Transaction type %s (class %s): expected isGame=%v, got %v", txType, class, want, got)
+		}
+
+		isPvP := false
+		for _, pvpType := range model.PvPTransactionTypes() {
+			if pvpType == txType {
+				isPvP = true
+			}
+		}
+		if isPvP != (class == model.TxClassPvP) {
+			t.Fatalf("Transaction type %s (class %s): expected isPvP=%v", txType, class, class == model.TxClassPvP)
+		}
+	})
+}
+
+// TestRankDisplayNamePrivacyProperty tests that users who opted out of leaderboards
+// are always shown as AnonymousPlayerName and never leak their username or ID.
+func TestRankDisplayNamePrivacyProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		username := rapid.StringMatching(`[a-zA-Z0-9_]{0,16}`).Draw(t, "username")
+		userID := rapid.Int64Range(1, 1000000).Draw(t, "userID")
+		hidden := rapid.Bool().Draw(t, "hidden")
+
+		name := RankDisplayName(username, userID, hidden)
+
+		switch {
+		case hidden:
+			if name != AnonymousPlayerName {
+				t.Fatalf("Hidden user should be shown as %q, got %q", AnonymousPlayerName, name)
+			}
+		case username == "":
+			if name != fmt.Sprintf("User%d", userID) {
+				t.Fatalf("User without username should fall back to ID, got %q", name)
+			}
+		default:
+			if name != username {
+				t.Fatalf("Visible user should be shown as %q, got %q", username, name)
+			}
+		}
+	})
+}
+
 // Helper functions that mirror the repository/service logic
 
 // getTopUsersSorted sorts users by balance descending and returns top N.
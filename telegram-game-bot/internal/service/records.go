@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+	"telegram-game-bot/internal/repository"
+)
+
+// recordGameLabels names the games whose biggest wins are tracked.
+var recordGameLabels = map[string]string{
+	"dice":      "🎲 骰子",
+	"slot":      "🎰 老虎机",
+	"blackjack": "🃏 21点",
+}
+
+// RecordGames lists the games with win records in display order.
+var RecordGames = []string{"dice", "slot", "blackjack"}
+
+// recordPeriodLabels names the record periods.
+var recordPeriodLabels = map[string]string{
+	model.RecordPeriodDay:  "今日",
+	model.RecordPeriodWeek: "本周",
+}
+
+// RecordGameLabel returns the display name of a game with win records.
+func RecordGameLabel(game string) string {
+	return recordGameLabels[game]
+}
+
+// RecordPeriodLabel returns the display name of a record period.
+func RecordPeriodLabel(period string) string {
+	return recordPeriodLabels[period]
+}
+
+// RecordPeriodStart returns the first day of the day or week (starting on
+// Monday) containing t in loc, as midnight UTC of that date.
+func RecordPeriodStart(period string, t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	if period == model.RecordPeriodWeek {
+		offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// FormatRecordBroken formats the announcement of a win breaking prev, the
+// record of the period.
+func FormatRecordBroken(win events.GameWon, period string, prev *model.WinRecord) string {
+	text := fmt.Sprintf("🏆 新纪录！%s 在%s单次赢得 %d 金币，刷新了%s最高纪录！",
+		win.Name, RecordGameLabel(win.Game), win.Amount, RecordPeriodLabel(period))
+	if prev.UserID == win.UserID {
+		return text + fmt.Sprintf("\n💪 超越了自己之前的 %d 金币", prev.Amount)
+	}
+	return text + fmt.Sprintf("\n👑 前纪录保持者: %s（%d 金币）", prev.Name, prev.Amount)
+}
+
+// RecordsService tracks the biggest single win of each game per chat, today
+// and this week, from the game wins of the event bus.
+type RecordsService struct {
+	repo     *repository.WinRecordRepository
+	notifier ChatAnnouncer
+	loc      *time.Location // Days and weeks start at midnight in this location
+	now      func() time.Time
+}
+
+// NewRecordsService creates a new RecordsService instance.
+func NewRecordsService(repo *repository.WinRecordRepository, loc *time.Location) *RecordsService {
+	return &RecordsService{repo: repo, loc: loc, now: time.Now}
+}
+
+// SetNotifier sets the notifier announcing broken records.
+func (s *RecordsService) SetNotifier(notifier ChatAnnouncer) {
+	s.notifier = notifier
+}
+
+// Subscribe registers the service for game wins on the bus.
+func (s *RecordsService) Subscribe(bus *events.Bus) {
+	bus.OnGameWon(s.HandleGameWon)
+}
+
+// HandleGameWon records a win of a tracked game in the background, so the
+// publisher is never delayed by the database.
+func (s *RecordsService) HandleGameWon(event events.GameWon) {
+	if event.Amount <= 0 || RecordGameLabel(event.Game) == "" {
+		return
+	}
+	go s.Record(context.Background(), event)
+}
+
+// Record stores a win as the record of its day and week where it is the
+// biggest one, and announces the most significant record it broke. Setting
+// the first record of a period is not announced.
+func (s *RecordsService) Record(ctx context.Context, win events.GameWon) {
+	at := win.At
+	if at.IsZero() {
+		at = s.now()
+	}
+
+	var announced bool
+	for _, period := range []string{model.RecordPeriodWeek, model.RecordPeriodDay} {
+		rec := &model.WinRecord{
+			ChatID:      win.ChatID,
+			Game:        win.Game,
+			Period:      period,
+			PeriodStart: RecordPeriodStart(period, at, s.loc),
+			UserID:      win.UserID,
+			Name:        win.Name,
+			Amount:      win.Amount,
+		}
+		beaten, prev, err := s.repo.Beat(ctx, rec)
+		if err != nil {
+			log.Warn().Err(err).Int64("chat_id", win.ChatID).Str("game", win.Game).Msg("Failed to store win record")
+			continue
+		}
+		if !beaten || prev == nil || announced {
+			continue
+		}
+
+		announced = true
+		log.Info().
+			Int64("chat_id", win.ChatID).
+			Str("game", win.Game).
+			Str("period", period).
+			Int64("user_id", win.UserID).
+			Int64("amount", win.Amount).
+			Int64("previous_user_id", prev.UserID).
+			Int64("previous_amount", prev.Amount).
+			Msg("Win record broken")
+		if s.notifier != nil {
+			s.notifier.Announce(win.ChatID, FormatRecordBroken(win, period, prev))
+		}
+	}
+}
+
+// Records returns the records of a chat for today and this week.
+func (s *RecordsService) Records(ctx context.Context, chatID int64) ([]model.WinRecord, error) {
+	now := s.now()
+	return s.repo.List(ctx, chatID,
+		RecordPeriodStart(model.RecordPeriodDay, now, s.loc),
+		RecordPeriodStart(model.RecordPeriodWeek, now, s.loc))
+}
@@ -0,0 +1,55 @@
+// Package service provides business logic implementations.
+// Property-based tests for per-chat win records.
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/events"
+)
+
+// TestRecordPeriodStartProperty tests that a day starts on the local date and
+// a week on the Monday at most six days before it.
+func TestRecordPeriodStartProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		loc := time.FixedZone("test", rapid.IntRange(-12, 14).Draw(t, "offsetHours")*3600)
+		at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rapid.IntRange(0, 365*24*60).Draw(t, "minutes")) * time.Minute)
+		local := at.In(loc)
+
+		day := RecordPeriodStart(model.RecordPeriodDay, at, loc)
+		if day.Year() != local.Year() || day.YearDay() != local.YearDay() || day.Location() != time.UTC {
+			t.Fatalf("Day start %v, want the date of %v", day, local)
+		}
+
+		week := RecordPeriodStart(model.RecordPeriodWeek, at, loc)
+		if week.Weekday() != time.Monday {
+			t.Fatalf("Week start %v is a %v", week, week.Weekday())
+		}
+		if days := day.Sub(week) / (24 * time.Hour); days < 0 || days > 6 {
+			t.Fatalf("Week start %v is %d days before %v", week, days, day)
+		}
+	})
+}
+
+// TestFormatRecordBroken tests that announcements name the previous holder,
+// or the player's own previous record.
+func TestFormatRecordBroken(t *testing.T) {
+	win := events.GameWon{ChatID: -100, UserID: 1, Name: "Alice", Game: "slot", Amount: 5000}
+
+	text := FormatRecordBroken(win, model.RecordPeriodWeek, &model.WinRecord{UserID: 2, Name: "Bob", Amount: 3000})
+	for _, want := range []string{"Alice", "🎰 老虎机", "5000", "本周", "Bob", "3000"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Announcement %q does not mention %q", text, want)
+		}
+	}
+
+	text = FormatRecordBroken(win, model.RecordPeriodDay, &model.WinRecord{UserID: 1, Name: "Alice", Amount: 3000})
+	if !strings.Contains(text, "自己") || !strings.Contains(text, "今日") {
+		t.Errorf("Announcement %q does not mention the own previous record", text)
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// Transaction refund errors
+var (
+	ErrTxNotFound        = errors.New("交易不存在")
+	ErrTxNotReversible   = errors.New("该交易不可撤销")
+	ErrTxAlreadyRefunded = errors.New("该交易已退款，不能重复退款")
+	ErrReversalOverdraft = errors.New("用户余额不足，无法撤销该笔收入")
+)
+
+// TxRefundResult describes an applied transaction refund.
+type TxRefundResult struct {
+	Refund      *model.TransactionRefund // Audit record, with the item state actually taken back
+	Transaction *model.Transaction       // Reversed transaction
+	User        *model.User              // Owner after the refund
+	Item        *shop.ItemConfig         // Item of a reversed shop purchase, nil otherwise
+}
+
+// RefundService lets admins reverse a specific transaction.
+// Each transaction can be reversed at most once, and a transaction refunded
+// through a support ticket cannot be reversed again.
+type RefundService struct {
+	refundRepo *repository.RefundRepository
+	txRepo     *repository.TransactionRepository
+	userLock   *lock.UserLock
+}
+
+// NewRefundService creates a new RefundService instance.
+func NewRefundService(
+	refundRepo *repository.RefundRepository,
+	txRepo *repository.TransactionRepository,
+	userLock *lock.UserLock,
+) *RefundService {
+	return &RefundService{
+		refundRepo: refundRepo,
+		txRepo:     txRepo,
+		userLock:   userLock,
+	}
+}
+
+// RefundTransaction reverses a transaction: the opposite amount is booked as a
+// compensating transaction and, for a shop purchase, the item is taken back.
+// Taking back a credit fails with ErrReversalOverdraft instead of overdrawing.
+func (s *RefundService) RefundTransaction(ctx context.Context, txID, adminID int64, reason string) (*TxRefundResult, error) {
+	tx, err := s.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			return nil, ErrTxNotFound
+		}
+		return nil, err
+	}
+	if !IsReversible(tx) {
+		return nil, ErrTxNotReversible
+	}
+
+	refund, item := PlanRefund(tx, adminID, strings.TrimSpace(reason))
+
+	s.userLock.Lock(tx.UserID)
+	defer s.userLock.Unlock(tx.UserID)
+
+	desc := fmt.Sprintf("撤销交易 #%d（管理员 %d）", tx.ID, adminID)
+	user, err := s.refundRepo.Reverse(ctx, refund, desc)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransactionRefunded):
+			return nil, ErrTxAlreadyRefunded
+		case errors.Is(err, repository.ErrRefundOverdraft):
+			return nil, ErrReversalOverdraft
+		case errors.Is(err, repository.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	event := log.Info().
+		Int64("admin_id", adminID).
+		Int64("user_id", tx.UserID).
+		Int64("tx_id", tx.ID).
+		Str("tx_type", tx.Type).
+		Int64("amount", refund.Amount).
+		Int64("refund_tx_id", *refund.RefundTxID).
+		Str("reason", refund.Reason).
+		Str("operation", "tx_refund")
+	if refund.ItemType != nil {
+		event = event.
+			Str("item_type", *refund.ItemType).
+			Int("item_uses", refund.ItemUses).
+			Int64("effect_seconds", refund.EffectSeconds)
+	}
+	event.Msg("Transaction refunded")
+
+	return &TxRefundResult{
+		Refund:      refund,
+		Transaction: tx,
+		User:        user,
+		Item:        item,
+	}, nil
+}
+
+// IsReversible reports whether an admin may reverse a transaction:
+// it must move balance and must not itself be a refund.
+func IsReversible(tx *model.Transaction) bool {
+	if tx == nil || tx.Amount == 0 {
+		return false
+	}
+	switch tx.Type {
+	case model.TxTypeRefund, model.TxTypeReversal:
+		return false
+	}
+	return true
+}
+
+// PlanRefund builds the refund record of a transaction. A shop purchase also
+// takes back one purchase worth of the item (uses or effect time).
+func PlanRefund(tx *model.Transaction, adminID int64, reason string) (*model.TransactionRefund, *shop.ItemConfig) {
+	refund := &model.TransactionRefund{
+		TxID:       tx.ID,
+		UserID:     tx.UserID,
+		Amount:     -tx.Amount,
+		RefundedBy: adminID,
+		Reason:     reason,
+	}
+
+	item, ok := purchasedItem(tx)
+	if !ok {
+		return refund, nil
+	}
+	itemType := string(item.Type)
+	refund.ItemType = &itemType
+	if item.IsDurationBased() {
+		refund.EffectSeconds = int64(item.ActiveDuration.Seconds())
+	} else {
+		refund.ItemUses = item.UseCount
+	}
+	return refund, &item
+}
+
+// purchasedItem resolves the item bought by a shop purchase transaction from
+// its description ("购买" + item name)
+func purchasedItem(tx *model.Transaction) (shop.ItemConfig, bool) {
+	if tx.Type != model.TxTypeShopPurchase || tx.Description == nil {
+		return shop.ItemConfig{}, false
+	}
+	name, ok := strings.CutPrefix(*tx.Description, "购买")
+	if !ok {
+		return shop.ItemConfig{}, false
+	}
+	for _, item := range shop.GetAllItems() {
+		if item.Name == name {
+			return item, true
+		}
+	}
+	return shop.ItemConfig{}, false
+}
@@ -0,0 +1,84 @@
+// Package service provides business logic implementations.
+// Property-based tests for transaction refunds.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/shop"
+)
+
+// TestPlanRefundProperty tests that a refund exactly offsets the transaction
+// and that only shop purchases take back an item, one purchase worth of it.
+func TestPlanRefundProperty(t *testing.T) {
+	items := shop.GetAllItems()
+	txTypes := []string{
+		model.TxTypeDice, model.TxTypeSicBoBet, model.TxTypeRobbed,
+		model.TxTypeTransfer, model.TxTypeShopPurchase, model.TxTypeDaily,
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		item := items[rapid.IntRange(0, len(items)-1).Draw(t, "item")]
+		desc := "购买" + item.Name
+		tx := &model.Transaction{
+			ID:          rapid.Int64Range(1, 1_000_000).Draw(t, "txID"),
+			UserID:      rapid.Int64Range(1, 1_000_000).Draw(t, "userID"),
+			Amount:      rapid.Int64Range(-100_000, 100_000).Draw(t, "amount"),
+			Type:        rapid.SampledFrom(txTypes).Draw(t, "type"),
+			Description: &desc,
+		}
+		adminID := rapid.Int64Range(1, 1_000_000).Draw(t, "adminID")
+
+		refund, got := PlanRefund(tx, adminID, "")
+
+		if refund.TxID != tx.ID || refund.UserID != tx.UserID || refund.RefundedBy != adminID {
+			t.Fatalf("Refund %+v does not match transaction %+v", refund, tx)
+		}
+		if tx.Amount+refund.Amount != 0 {
+			t.Fatalf("Refund amount %d does not offset %d", refund.Amount, tx.Amount)
+		}
+
+		if tx.Type != model.TxTypeShopPurchase {
+			if got != nil || refund.ItemType != nil || refund.ItemUses != 0 || refund.EffectSeconds != 0 {
+				t.Fatalf("Non purchase %s takes back an item: %+v", tx.Type, refund)
+			}
+			return
+		}
+
+		if got == nil || refund.ItemType == nil || *refund.ItemType != string(item.Type) {
+			t.Fatalf("Purchase of %s does not take back the item: %+v", item.Type, refund)
+		}
+		if item.IsDurationBased() {
+			if refund.EffectSeconds != int64(item.ActiveDuration.Seconds()) || refund.ItemUses != 0 {
+				t.Fatalf("Timed item %s refund %+v", item.Type, refund)
+			}
+		} else if refund.ItemUses != item.UseCount || refund.EffectSeconds != 0 {
+			t.Fatalf("Use count item %s refund %+v", item.Type, refund)
+		}
+	})
+}
+
+// TestIsReversibleProperty tests that refunds themselves and zero amount
+// transactions are never reversible, so a refund cannot be undone or chained.
+func TestIsReversibleProperty(t *testing.T) {
+	txTypes := []string{
+		model.TxTypeDice, model.TxTypeShopPurchase, model.TxTypeAdminAdd,
+		model.TxTypeRefund, model.TxTypeReversal, model.TxTypeCompensation,
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		tx := &model.Transaction{
+			Amount: rapid.Int64Range(-1000, 1000).Draw(t, "amount"),
+			Type:   rapid.SampledFrom(txTypes).Draw(t, "type"),
+		}
+
+		isRefund := tx.Type == model.TxTypeRefund || tx.Type == model.TxTypeReversal
+		want := tx.Amount != 0 && !isRefund
+		if got := IsReversible(tx); got != want {
+			t.Fatalf("IsReversible(%s %d) = %v, want %v", tx.Type, tx.Amount, got, want)
+		}
+	})
+}
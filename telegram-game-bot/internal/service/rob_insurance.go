@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// RobInsuranceCut returns the share of a successful rob of amount paid into
+// the insurance pool, rounded down.
+func RobInsuranceCut(amount int64, cutPercent int) int64 {
+	if amount <= 0 || cutPercent <= 0 {
+		return 0
+	}
+	return amount * int64(cutPercent) / 100
+}
+
+// RobInsurancePayout returns the compensation owed to a victim robbed of loss
+// who was left with balance: coverPercent of the loss, at most maxPayout and
+// never lifting the victim above floor. Victims at or above floor get nothing.
+func RobInsurancePayout(loss, balance, floor int64, coverPercent int, maxPayout int64) int64 {
+	if loss <= 0 || balance >= floor {
+		return 0
+	}
+	payout := loss * int64(coverPercent) / 100
+	if payout > maxPayout {
+		payout = maxPayout
+	}
+	if payout > floor-balance {
+		payout = floor - balance
+	}
+	if payout < 0 {
+		return 0
+	}
+	return payout
+}
+
+// RobInsuranceService runs the rob insurance pool: robbers pay a cut of every
+// successful rob into it and victims left below a balance floor are partly
+// compensated from it, as far as the pool reaches. Both flows are booked in
+// the ledger. The rob game calls it while robber and victim are locked, so it
+// never locks users itself.
+type RobInsuranceService struct {
+	repo         *repository.RobInsuranceRepository
+	userRepo     *repository.UserRepository
+	txRepo       *repository.TransactionRepository
+	cutPercent   int
+	floor        int64
+	coverPercent int
+	maxPayout    int64
+}
+
+// NewRobInsuranceService creates a new RobInsuranceService instance.
+func NewRobInsuranceService(repo *repository.RobInsuranceRepository, userRepo *repository.UserRepository, txRepo *repository.TransactionRepository,
+	cutPercent int, floor int64, coverPercent int, maxPayout int64) *RobInsuranceService {
+	return &RobInsuranceService{
+		repo:         repo,
+		userRepo:     userRepo,
+		txRepo:       txRepo,
+		cutPercent:   cutPercent,
+		floor:        floor,
+		coverPercent: coverPercent,
+		maxPayout:    maxPayout,
+	}
+}
+
+// Pool returns the insurance pool.
+func (s *RobInsuranceService) Pool(ctx context.Context) (*model.RobInsurancePool, error) {
+	return s.repo.Get(ctx)
+}
+
+// Floor returns the balance below which robbed victims are compensated.
+func (s *RobInsuranceService) Floor() int64 {
+	return s.floor
+}
+
+// Collect takes the pool's cut of a successful rob of amount from the robber,
+// who holds balance. Returns the cut, 0 if none was taken.
+func (s *RobInsuranceService) Collect(ctx context.Context, robberID, amount, balance int64) int64 {
+	cut := RobInsuranceCut(amount, s.cutPercent)
+	if cut > balance {
+		cut = balance
+	}
+	if cut <= 0 {
+		return 0
+	}
+
+	if _, err := s.userRepo.UpdateBalance(ctx, robberID, -cut); err != nil {
+		log.Error().Err(err).Int64("user_id", robberID).Msg("Failed to charge rob insurance cut")
+		return 0
+	}
+	if err := s.repo.Deposit(ctx, cut); err != nil {
+		log.Error().Err(err).Int64("user_id", robberID).Msg("Failed to deposit rob insurance cut")
+		if _, rerr := s.userRepo.UpdateBalance(ctx, robberID, cut); rerr != nil {
+			log.Error().Err(rerr).Int64("user_id", robberID).Int64("amount", cut).Msg("Failed to return rob insurance cut")
+		}
+		return 0
+	}
+	desc := fmt.Sprintf("打劫所得 %d 金币的 %d%% 存入打劫保险池", amount, s.cutPercent)
+	_, _ = s.txRepo.Create(ctx, robberID, -cut, model.TxTypeRobInsureCut, &desc)
+	return cut
+}
+
+// Compensate pays a victim robbed of loss and left with balance from the
+// pool. Returns the compensation, 0 if none is owed or the pool is empty.
+func (s *RobInsuranceService) Compensate(ctx context.Context, victimID, loss, balance int64) int64 {
+	owed := RobInsurancePayout(loss, balance, s.floor, s.coverPercent, s.maxPayout)
+	if owed <= 0 {
+		return 0
+	}
+
+	paid, err := s.repo.Withdraw(ctx, owed)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", victimID).Msg("Failed to withdraw rob insurance compensation")
+		return 0
+	}
+	if paid <= 0 {
+		return 0
+	}
+	if _, err := s.userRepo.UpdateBalance(ctx, victimID, paid); err != nil {
+		log.Error().Err(err).Int64("user_id", victimID).Msg("Failed to pay rob insurance compensation")
+		if derr := s.repo.Refund(ctx, paid); derr != nil {
+			log.Error().Err(derr).Int64("amount", paid).Msg("Failed to return rob insurance compensation to the pool")
+		}
+		return 0
+	}
+	desc := fmt.Sprintf("被打劫后余额低于 %d，打劫保险池赔付 %d 金币", s.floor, paid)
+	_, _ = s.txRepo.Create(ctx, victimID, paid, model.TxTypeRobInsurePay, &desc)
+
+	log.Info().
+		Str("operation", "rob_insurance_payout").
+		Int64("user_id", victimID).
+		Int64("loss", loss).
+		Int64("paid", paid).
+		Msg("Rob insurance compensation paid")
+	return paid
+}
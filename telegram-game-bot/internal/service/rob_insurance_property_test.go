@@ -0,0 +1,62 @@
+// Package service provides business logic implementations.
+// Property-based tests for the rob insurance pool.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestRobInsuranceCutProperty tests that the cut is the configured share of
+// the loot rounded down, never more than the loot and never negative.
+func TestRobInsuranceCutProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		amount := rapid.Int64Range(-100, 1_000_000).Draw(t, "amount")
+		percent := rapid.IntRange(0, 100).Draw(t, "percent")
+
+		cut := RobInsuranceCut(amount, percent)
+		if amount <= 0 || percent == 0 {
+			if cut != 0 {
+				t.Fatalf("RobInsuranceCut(%d, %d) = %d, want 0", amount, percent, cut)
+			}
+			return
+		}
+		if cut < 0 || cut > amount {
+			t.Fatalf("RobInsuranceCut(%d, %d) = %d out of [0, amount]", amount, percent, cut)
+		}
+		if cut*100 > amount*int64(percent) || (cut+1)*100 <= amount*int64(percent) {
+			t.Fatalf("RobInsuranceCut(%d, %d) = %d is not the share rounded down", amount, percent, cut)
+		}
+	})
+}
+
+// TestRobInsurancePayoutProperty tests that only victims below the floor are
+// compensated, by at most the covered share of the loss and the cap, and
+// never above the floor.
+func TestRobInsurancePayoutProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		loss := rapid.Int64Range(0, 100_000).Draw(t, "loss")
+		balance := rapid.Int64Range(0, 5_000).Draw(t, "balance")
+		floor := rapid.Int64Range(1, 5_000).Draw(t, "floor")
+		cover := rapid.IntRange(0, 100).Draw(t, "cover")
+		maxPayout := rapid.Int64Range(1, 2_000).Draw(t, "maxPayout")
+
+		payout := RobInsurancePayout(loss, balance, floor, cover, maxPayout)
+		if payout < 0 {
+			t.Fatalf("payout %d is negative", payout)
+		}
+		if balance >= floor && payout != 0 {
+			t.Fatalf("victim at %d, floor %d was paid %d", balance, floor, payout)
+		}
+		if payout > loss*int64(cover)/100 || payout > maxPayout {
+			t.Fatalf("payout %d exceeds %d%% of %d or the cap %d", payout, cover, loss, maxPayout)
+		}
+		if balance+payout > floor && payout > 0 {
+			t.Fatalf("payout %d lifts balance %d above the floor %d", payout, balance, floor)
+		}
+		if balance < floor && payout == 0 && loss*int64(cover)/100 > 0 {
+			t.Fatalf("victim below the floor got nothing for a loss of %d", loss)
+		}
+	})
+}
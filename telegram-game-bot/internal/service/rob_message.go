@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/msgtmpl"
+	"telegram-game-bot/internal/pkg/textfilter"
+	"telegram-game-bot/internal/repository"
+)
+
+// RobMessageMaxRunes is the maximum length of a rob message
+const RobMessageMaxRunes = 120
+
+// Rob message errors
+var (
+	ErrRobMessageUnknownOutcome = errors.New("未知的结果，只能是 fail、counter、success 或 critical")
+	ErrRobMessageUnknownTier    = errors.New("未知的稀有度，只能是 common、rare 或 epic")
+	ErrRobMessageEmpty          = errors.New("文案不能为空")
+	ErrRobMessageTooLong        = errors.New("文案过长")
+	ErrRobMessageInvalid        = errors.New("文案格式错误，只能使用 {robber} {victim} {amount} {item}")
+	ErrRobMessageBlocked        = errors.New("文案包含屏蔽词")
+	ErrRobMessageNotFound       = errors.New("文案不存在")
+)
+
+// ValidateRobMessage checks the outcome, tier and template of a rob message.
+func ValidateRobMessage(outcome, tier, text string) error {
+	switch {
+	case !slices.Contains(model.RobMessageOutcomes, outcome):
+		return ErrRobMessageUnknownOutcome
+	case !slices.Contains(model.RobMessageTiers, tier):
+		return ErrRobMessageUnknownTier
+	case text == "":
+		return ErrRobMessageEmpty
+	case utf8.RuneCountInString(text) > RobMessageMaxRunes:
+		return ErrRobMessageTooLong
+	case msgtmpl.Validate(text, model.RobMessageVars) != nil:
+		return ErrRobMessageInvalid
+	case textfilter.Blocked(text):
+		return ErrRobMessageBlocked
+	}
+	return nil
+}
+
+// PickRobMessage picks a message of an outcome: a tier is drawn with the
+// weights of the tiers that have messages of the outcome, then one of its
+// messages uniformly. Returns false if no tier with a positive weight has one.
+func PickRobMessage(msgs []model.RobMessage, outcome string, weights map[string]int, rng *rand.Rand) (model.RobMessage, bool) {
+	byTier := make(map[string][]model.RobMessage)
+	for _, m := range msgs {
+		if m.Outcome == outcome {
+			byTier[m.Tier] = append(byTier[m.Tier], m)
+		}
+	}
+
+	total := 0
+	for _, tier := range model.RobMessageTiers {
+		if len(byTier[tier]) > 0 && weights[tier] > 0 {
+			total += weights[tier]
+		}
+	}
+	if total == 0 {
+		return model.RobMessage{}, false
+	}
+
+	roll := rng.Intn(total)
+	for _, tier := range model.RobMessageTiers {
+		pool := byTier[tier]
+		if len(pool) == 0 || weights[tier] <= 0 {
+			continue
+		}
+		if roll < weights[tier] {
+			return pool[rng.Intn(len(pool))], true
+		}
+		roll -= weights[tier]
+	}
+	return model.RobMessage{}, false
+}
+
+// RobMessageService supplies the flavor texts of robbery results, so the
+// same outcome reads differently from one rob to the next. Texts come from
+// the config file and from the pool admins manage with /robmsg; rare and
+// epic texts show up less often than common ones. Outcomes without texts
+// keep the built-in messages. The pool is cached in memory.
+type RobMessageService struct {
+	repo       *repository.RobMessageRepository
+	weights    map[string]int     // tier -> weight
+	configured []model.RobMessage // Texts of the config file, not managed by /robmsg
+
+	mu   sync.Mutex
+	rng  *rand.Rand
+	msgs []model.RobMessage // configured and stored texts, nil until loaded
+}
+
+// NewRobMessageService creates a new RobMessageService instance.
+func NewRobMessageService(repo *repository.RobMessageRepository, weights map[string]int, configured []model.RobMessage) *RobMessageService {
+	return &RobMessageService{
+		repo:       repo,
+		weights:    weights,
+		configured: configured,
+		rng:        rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// Message returns a random text of an outcome with vars filled in, false if
+// the outcome has none. Results are HTML: the text is escaped, vars are
+// inserted as given. Best effort: failures are logged.
+func (s *RobMessageService) Message(ctx context.Context, outcome string, vars msgtmpl.Vars) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load rob messages")
+		return "", false
+	}
+	msg, ok := PickRobMessage(s.msgs, outcome, s.weights, s.rng)
+	if !ok {
+		return "", false
+	}
+	return msgtmpl.Render(html.EscapeString(msg.Text), vars), true
+}
+
+// List returns the texts of the config file followed by the stored ones.
+func (s *RobMessageService) List(ctx context.Context) ([]model.RobMessage, error) {
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clone(s.configured), stored...), nil
+}
+
+// Add adds a text to the pool of an outcome in a tier.
+func (s *RobMessageService) Add(ctx context.Context, outcome, tier, text string, adminID int64) (*model.RobMessage, error) {
+	text = strings.TrimSpace(text)
+	if err := ValidateRobMessage(outcome, tier, text); err != nil {
+		return nil, err
+	}
+
+	msg := &model.RobMessage{Outcome: outcome, Tier: tier, Text: text, AddedBy: adminID}
+	if err := s.repo.Add(ctx, msg); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("message_id", msg.ID).
+		Str("outcome", outcome).
+		Str("tier", tier).
+		Str("operation", "rob_message_add").
+		Msg("Rob message added")
+	return msg, nil
+}
+
+// Delete removes a stored text.
+func (s *RobMessageService) Delete(ctx context.Context, id, adminID int64) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRobMessageNotFound
+	}
+	s.invalidate()
+
+	log.Info().
+		Int64("admin_id", adminID).
+		Int64("message_id", id).
+		Str("operation", "rob_message_delete").
+		Msg("Rob message deleted")
+	return nil
+}
+
+// loadLocked fills the message cache if needed; the caller must hold s.mu
+func (s *RobMessageService) loadLocked(ctx context.Context) error {
+	if s.msgs != nil {
+		return nil
+	}
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.msgs = append(slices.Clone(s.configured), stored...)
+	if s.msgs == nil {
+		s.msgs = []model.RobMessage{}
+	}
+	return nil
+}
+
+// invalidate drops the message cache after a change
+func (s *RobMessageService) invalidate() {
+	s.mu.Lock()
+	s.msgs = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,100 @@
+// Package service provides business logic implementations.
+// Property-based tests for rob flavor texts.
+package service
+
+import (
+	"math/rand"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestPickRobMessageProperty tests that picked messages belong to the
+// outcome and to a tier with a positive weight, and that a message is picked
+// whenever such a tier has one.
+func TestPickRobMessageProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		n := rapid.IntRange(0, 12).Draw(t, "messages")
+		msgs := make([]model.RobMessage, n)
+		for i := range msgs {
+			msgs[i] = model.RobMessage{
+				ID:      int64(i + 1),
+				Outcome: rapid.SampledFrom(model.RobMessageOutcomes).Draw(t, "outcome"),
+				Tier:    rapid.SampledFrom(model.RobMessageTiers).Draw(t, "tier"),
+			}
+		}
+		weights := map[string]int{}
+		for _, tier := range model.RobMessageTiers {
+			weights[tier] = rapid.IntRange(0, 100).Draw(t, "weight_"+tier)
+		}
+		outcome := rapid.SampledFrom(model.RobMessageOutcomes).Draw(t, "pick")
+		rng := rand.New(rand.NewSource(rapid.Int64().Draw(t, "seed")))
+
+		msg, ok := PickRobMessage(msgs, outcome, weights, rng)
+
+		eligible := false
+		for _, m := range msgs {
+			if m.Outcome == outcome && weights[m.Tier] > 0 {
+				eligible = true
+			}
+		}
+		if ok != eligible {
+			t.Fatalf("Picked %v, want %v", ok, eligible)
+		}
+		if ok && (msg.Outcome != outcome || weights[msg.Tier] <= 0) {
+			t.Fatalf("Picked %+v for outcome %s with weights %v", msg, outcome, weights)
+		}
+	})
+}
+
+// TestPickRobMessageTierWeights tests that tiers are drawn with their
+// weights: a tier weighted 9 times another is picked about 9 times as often.
+func TestPickRobMessageTierWeights(t *testing.T) {
+	msgs := []model.RobMessage{
+		{ID: 1, Outcome: model.RobMessageSuccess, Tier: model.RobTierCommon},
+		{ID: 2, Outcome: model.RobMessageSuccess, Tier: model.RobTierCommon},
+		{ID: 3, Outcome: model.RobMessageSuccess, Tier: model.RobTierEpic},
+		{ID: 4, Outcome: model.RobMessageFail, Tier: model.RobTierEpic},
+	}
+	weights := map[string]int{model.RobTierCommon: 90, model.RobTierRare: 500, model.RobTierEpic: 10}
+	rng := rand.New(rand.NewSource(1))
+
+	epic := 0
+	const draws = 20000
+	for i := 0; i < draws; i++ {
+		msg, ok := PickRobMessage(msgs, model.RobMessageSuccess, weights, rng)
+		if !ok {
+			t.Fatal("No message picked")
+		}
+		if msg.Tier == model.RobTierEpic {
+			epic++
+		}
+	}
+	if share := float64(epic) / draws; share < 0.08 || share > 0.12 {
+		t.Fatalf("Epic share %.3f, want about 0.10 (the empty rare tier must be skipped)", share)
+	}
+}
+
+// TestValidateRobMessageProperty tests that templates may only use the rob
+// message variables.
+func TestValidateRobMessageProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		v := rapid.SampledFrom(append([]string{"user", "balance"}, model.RobMessageVars...)).Draw(t, "var")
+		text := "🔫 {" + v + "} 出手了"
+
+		err := ValidateRobMessage(model.RobMessageSuccess, model.RobTierRare, text)
+		known := v != "user" && v != "balance"
+		if (err == nil) != known {
+			t.Fatalf("Validate(%q) = %v", text, err)
+		}
+	})
+
+	if err := ValidateRobMessage("win", model.RobTierCommon, "x"); err != ErrRobMessageUnknownOutcome {
+		t.Fatalf("Unknown outcome accepted: %v", err)
+	}
+	if err := ValidateRobMessage(model.RobMessageFail, "legendary", "x"); err != ErrRobMessageUnknownTier {
+		t.Fatalf("Unknown tier accepted: %v", err)
+	}
+}
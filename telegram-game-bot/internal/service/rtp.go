@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// RTP report settings
+const (
+	RTPSimRounds      = 200000 // Simulated rounds per game mode for the theoretical RTP
+	RTPDriftThreshold = 0.05   // Realized RTP further than this from the theoretical one is flagged
+	RTPMinBets        = 200    // Bets a window needs before its drift is judged
+	RTPMaxWindows     = 4      // Windows of one /rtp report
+)
+
+// DefaultRTPWindows are the windows of /rtp without arguments.
+var DefaultRTPWindows = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// ErrRTPWindow is returned for malformed /rtp windows.
+var ErrRTPWindow = errors.New("时间窗口格式错误，应为 24h、7d 这样的时长，最多 4 个")
+
+// rtpGame is a house game of the RTP report
+type rtpGame struct {
+	name    string
+	betType string // Stakes, and refunds of failed or void bets
+	winType string // Payouts; betType when the game records both with one type
+}
+
+// rtpGames are the games of the RTP report. The ledger records all dice
+// modes with one type, so their realized RTP is compared against the mean
+// of the simulated modes.
+var rtpGames = []rtpGame{
+	{name: "骰子 /dice /dice3 /dicebo3", betType: model.TxTypeDice, winType: model.TxTypeDice},
+	{name: "老虎机 /slot", betType: model.TxTypeSlot, winType: model.TxTypeSlot},
+	{name: "骰宝 /sicbo", betType: model.TxTypeSicBoBet, winType: model.TxTypeSicBoWin},
+}
+
+// RTPGameResult is the realized return-to-player of a game over a window.
+type RTPGameResult struct {
+	Name        string
+	Bets        int64   // Stakes placed
+	Wagered     int64   // Coins staked, refunds deducted
+	PaidOut     int64   // Coins paid back, stakes of winning bets included
+	Theoretical float64 // RTP of the payout tables, from the simulation
+}
+
+// RTP returns the share of the stakes paid back to players.
+func (r RTPGameResult) RTP() float64 {
+	if r.Wagered <= 0 {
+		return 0
+	}
+	return float64(r.PaidOut) / float64(r.Wagered)
+}
+
+// Drifting reports whether the realized RTP strays from the theoretical one
+// by more than RTPDriftThreshold over a window with at least RTPMinBets bets.
+func (r RTPGameResult) Drifting() bool {
+	return r.Bets >= RTPMinBets && math.Abs(r.RTP()-r.Theoretical) > RTPDriftThreshold
+}
+
+// RTPWindow is the RTP report of one window.
+type RTPWindow struct {
+	Window time.Duration
+	Games  []RTPGameResult
+}
+
+// rtpResult computes a game's result from the ledger totals of its types
+func rtpResult(g rtpGame, totals map[string]*model.TxTypeTotals) RTPGameResult {
+	result := RTPGameResult{Name: g.name}
+	if bets := totals[g.betType]; bets != nil {
+		result.Bets = bets.Debits
+		result.Wagered = bets.Debited - bets.Refunded
+		if g.winType != g.betType {
+			// Credits of the bet type give back the stakes of a void round
+			result.Wagered -= bets.Credited
+		}
+	}
+	if wins := totals[g.winType]; wins != nil {
+		result.PaidOut = wins.Credited
+	}
+	return result
+}
+
+// ParseRTPWindows parses /rtp windows such as "24h" or "7d".
+// No arguments mean DefaultRTPWindows.
+func ParseRTPWindows(args []string) ([]time.Duration, error) {
+	if len(args) == 0 {
+		return DefaultRTPWindows, nil
+	}
+	if len(args) > RTPMaxWindows {
+		return nil, ErrRTPWindow
+	}
+	windows := make([]time.Duration, 0, len(args))
+	for _, arg := range args {
+		window, err := ParsePromoDuration(arg)
+		if err != nil || window == 0 {
+			return nil, ErrRTPWindow
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// RTPService compares the realized return-to-player of the house games in
+// the ledger with the RTP of their payout tables.
+type RTPService struct {
+	txRepo *repository.TransactionRepository
+
+	simOnce     sync.Once
+	theoretical map[string]float64 // bet type -> mean simulated RTP of its modes
+}
+
+// NewRTPService creates a new RTPService instance.
+func NewRTPService(txRepo *repository.TransactionRepository) *RTPService {
+	return &RTPService{txRepo: txRepo}
+}
+
+// theoreticalRTP returns the simulated RTP per bet type, simulated once
+func (s *RTPService) theoreticalRTP() map[string]float64 {
+	s.simOnce.Do(func() {
+		sums := make(map[string]float64)
+		modes := make(map[string]int)
+		for _, g := range SimulateGames(RTPSimRounds, SimDefaultBet, rand.New(rand.NewSource(time.Now().UnixNano()))) {
+			sums[g.BetType] += g.RTP()
+			modes[g.BetType]++
+		}
+		s.theoretical = make(map[string]float64, len(sums))
+		for betType, sum := range sums {
+			s.theoretical[betType] = sum / float64(modes[betType])
+		}
+	})
+	return s.theoretical
+}
+
+// Report returns the RTP of every house game over each window ending at now.
+func (s *RTPService) Report(ctx context.Context, windows []time.Duration, now time.Time) ([]RTPWindow, error) {
+	var types []string
+	for _, g := range rtpGames {
+		types = append(types, g.betType)
+		if g.winType != g.betType {
+			types = append(types, g.winType)
+		}
+	}
+	theoretical := s.theoreticalRTP()
+
+	report := make([]RTPWindow, 0, len(windows))
+	for _, window := range windows {
+		rows, err := s.txRepo.GetTypeTotals(ctx, types, now.Add(-window))
+		if err != nil {
+			return nil, err
+		}
+		totals := make(map[string]*model.TxTypeTotals, len(rows))
+		for _, row := range rows {
+			totals[row.Type] = row
+		}
+
+		result := RTPWindow{Window: window}
+		for _, g := range rtpGames {
+			game := rtpResult(g, totals)
+			game.Theoretical = theoretical[g.betType]
+			result.Games = append(result.Games, game)
+		}
+		report = append(report, result)
+	}
+	return report, nil
+}
+
+// formatRTPWindow formats a window as days or hours
+func formatRTPWindow(window time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case window >= 2*day && window%day == 0:
+		return fmt.Sprintf("%d 天", window/day)
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%d 小时", window/time.Hour)
+	default:
+		return window.String()
+	}
+}
+
+// FormatRTPReport formats an RTP report for admins.
+func FormatRTPReport(report []RTPWindow) string {
+	var sb strings.Builder
+	sb.WriteString("📈 返奖率报告（实际 / 理论）\n")
+	for _, window := range report {
+		sb.WriteString(fmt.Sprintf("\n⏱ 近 %s\n", formatRTPWindow(window.Window)))
+		for _, g := range window.Games {
+			if g.Bets == 0 || g.Wagered <= 0 {
+				sb.WriteString(fmt.Sprintf("• %s: 无下注\n", g.Name))
+				continue
+			}
+			mark := ""
+			switch {
+			case g.Drifting():
+				mark = " ⚠️ 偏离"
+			case g.Bets < RTPMinBets:
+				mark = " (样本不足)"
+			}
+			sb.WriteString(fmt.Sprintf("• %s: %.2f%% / %.2f%%%s\n    %d 注，下注 %d，返还 %d\n",
+				g.Name, g.RTP()*100, g.Theoretical*100, mark, g.Bets, g.Wagered, g.PaidOut))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n⚠️ 至少 %d 注且偏离理论值超过 %.0f 个百分点时标记", RTPMinBets, RTPDriftThreshold*100))
+	return sb.String()
+}
@@ -0,0 +1,129 @@
+// Package service provides business logic implementations.
+// Property-based tests for the RTP report.
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// ledgerTotals adds a transaction to the totals of its type like GetTypeTotals
+func ledgerTotals(totals map[string]*model.TxTypeTotals, txType string, amount int64, described bool) {
+	t := totals[txType]
+	if t == nil {
+		t = &model.TxTypeTotals{Type: txType}
+		totals[txType] = t
+	}
+	switch {
+	case amount < 0:
+		t.Debits++
+		t.Debited -= amount
+	case described:
+		t.Credited += amount
+	default:
+		t.Refunded += amount
+	}
+}
+
+// TestRTPFromLedgerProperty tests that the RTP computed from the ledger
+// totals is what the played rounds paid back, whichever rounds were
+// refunded and whether the game records payouts with its bet type.
+func TestRTPFromLedgerProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		game := rapid.SampledFrom(rtpGames).Draw(t, "game")
+		totals := make(map[string]*model.TxTypeTotals)
+
+		var bets, wagered, paidOut int64
+		n := rapid.IntRange(0, 50).Draw(t, "rounds")
+		for i := 0; i < n; i++ {
+			stake := rapid.Int64Range(1, 10000).Draw(t, "stake")
+			ledgerTotals(totals, game.betType, -stake, true)
+			bets++
+
+			switch rapid.IntRange(0, 3).Draw(t, "outcome") {
+			case 0: // Lost
+				wagered += stake
+			case 1: // Won, the stake is paid back with the payout
+				payout := stake + rapid.Int64Range(0, 100000).Draw(t, "payout")
+				ledgerTotals(totals, game.winType, payout, true)
+				wagered += stake
+				paidOut += payout
+			case 2: // Failed and refunded
+				ledgerTotals(totals, game.betType, stake, false)
+			case 3: // Void round refunded by the game
+				if game.winType == game.betType {
+					ledgerTotals(totals, game.betType, stake, false)
+				} else {
+					ledgerTotals(totals, game.betType, stake, true)
+				}
+			}
+		}
+
+		result := rtpResult(game, totals)
+		if result.Bets != bets || result.Wagered != wagered || result.PaidOut != paidOut {
+			t.Fatalf("result %+v, want %d bets, %d wagered, %d paid out", result, bets, wagered, paidOut)
+		}
+		if wagered > 0 && result.RTP() != float64(paidOut)/float64(wagered) {
+			t.Fatalf("RTP = %v, want %v", result.RTP(), float64(paidOut)/float64(wagered))
+		}
+	})
+}
+
+// TestRTPDriftProperty tests that only windows with enough bets and an RTP
+// beyond the threshold are flagged.
+func TestRTPDriftProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		wagered := rapid.Int64Range(1, 1_000_000).Draw(t, "wagered")
+		result := RTPGameResult{
+			Bets:        rapid.Int64Range(0, 2*RTPMinBets).Draw(t, "bets"),
+			Wagered:     wagered,
+			PaidOut:     rapid.Int64Range(0, 2*wagered).Draw(t, "paidOut"),
+			Theoretical: rapid.Float64Range(0.8, 1).Draw(t, "theoretical"),
+		}
+
+		diff := result.RTP() - result.Theoretical
+		want := result.Bets >= RTPMinBets && (diff > RTPDriftThreshold || diff < -RTPDriftThreshold)
+		if result.Drifting() != want {
+			t.Fatalf("Drifting() = %v for %+v (RTP %.4f)", result.Drifting(), result, result.RTP())
+		}
+	})
+}
+
+// TestParseRTPWindowsProperty tests that up to RTPMaxWindows valid windows
+// parse in order and anything else is rejected.
+func TestParseRTPWindowsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		days := rapid.SliceOfN(rapid.IntRange(1, 365), 1, RTPMaxWindows+1).Draw(t, "days")
+		args := make([]string, len(days))
+		for i, d := range days {
+			args[i] = strconv.Itoa(d) + "d"
+		}
+
+		windows, err := ParseRTPWindows(args)
+		if len(args) > RTPMaxWindows {
+			if !errors.Is(err, ErrRTPWindow) {
+				t.Fatalf("%d windows: err = %v, want ErrRTPWindow", len(args), err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("ParseRTPWindows(%q) failed: %v", args, err)
+		}
+		for i, d := range days {
+			if windows[i] != time.Duration(d)*24*time.Hour {
+				t.Fatalf("window %d = %v, want %d days", i, windows[i], d)
+			}
+		}
+
+		bad := rapid.SampledFrom([]string{"0", "-1d", "week", "7", "0d"}).Draw(t, "bad")
+		if _, err := ParseRTPWindows([]string{bad}); !errors.Is(err, ErrRTPWindow) {
+			t.Fatalf("ParseRTPWindows(%q) err = %v, want ErrRTPWindow", bad, err)
+		}
+	})
+}
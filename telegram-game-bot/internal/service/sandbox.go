@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// ErrSandboxUnavailable is returned when play money is used without a sandbox service.
+var ErrSandboxUnavailable = errors.New("沙盒模式未启用")
+
+// sandboxBlockedCommands are the commands that move real coins outside the
+// house games and are therefore unavailable in sandbox chats
+var sandboxBlockedCommands = map[string]bool{
+	"/daily":     true,
+	"/pay":       true,
+	"/freespin":  true,
+	"/dj":        true,
+	"/shdj":      true,
+	"/duijue":    true,
+	"/shdice":    true,
+	"/raid":      true,
+	"/raid_join": true,
+	"/handcuff":  true,
+	"/key":       true,
+	"/struggle":  true,
+	"/redeem":    true,
+}
+
+// SandboxAllows reports whether a message may be handled in a sandbox chat.
+// Only commands that would move real coins are refused; text bets, the house
+// games and informational commands are allowed.
+func SandboxAllows(text string) bool {
+	if !strings.HasPrefix(text, "/") {
+		return true
+	}
+	command, _, _ := strings.Cut(strings.Fields(text)[0], "@")
+	return !sandboxBlockedCommands[strings.ToLower(command)]
+}
+
+// SandboxService manages sandbox chats, groups where the house games run
+// against per-chat play money so the bot can be demoed without touching the
+// real economy. Sandbox chats are cached in memory since they rarely change.
+type SandboxService struct {
+	repo         *repository.SandboxRepository
+	startBalance int64
+
+	mu    sync.Mutex
+	chats map[int64]bool // nil until loaded
+}
+
+// NewSandboxService creates a new SandboxService instance.
+// Players start with startBalance play money in each sandbox chat.
+func NewSandboxService(repo *repository.SandboxRepository, startBalance int64) *SandboxService {
+	return &SandboxService{repo: repo, startBalance: startBalance}
+}
+
+// StartBalance returns the play money players start with.
+func (s *SandboxService) StartBalance() int64 {
+	return s.startBalance
+}
+
+// IsSandbox reports whether a chat plays with play money.
+// Chats are treated as real if the sandbox chats cannot be loaded.
+func (s *SandboxService) IsSandbox(ctx context.Context, chatID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load sandbox chats")
+		return false
+	}
+	return s.chats[chatID]
+}
+
+// Scope returns the balance scope games in a chat are played with.
+func (s *SandboxService) Scope(ctx context.Context, chatID int64) model.BalanceScope {
+	if s.IsSandbox(ctx, chatID) {
+		return model.SandboxBalance(chatID)
+	}
+	return model.RealBalance
+}
+
+// Enable turns a chat into a sandbox chat.
+func (s *SandboxService) Enable(ctx context.Context, chatID, adminID int64) error {
+	if err := s.repo.Enable(ctx, chatID, adminID); err != nil {
+		return err
+	}
+	s.setCached(chatID, true)
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Str("operation", "sandbox_enable").
+		Msg("Sandbox enabled")
+	return nil
+}
+
+// Disable turns the sandbox off for a chat; its games use real balances again.
+func (s *SandboxService) Disable(ctx context.Context, chatID, adminID int64) error {
+	if err := s.repo.Disable(ctx, chatID); err != nil {
+		return err
+	}
+	s.setCached(chatID, false)
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Str("operation", "sandbox_disable").
+		Msg("Sandbox disabled")
+	return nil
+}
+
+// Reset gives everyone in a chat the starting play money again.
+// Returns the number of balances reset.
+func (s *SandboxService) Reset(ctx context.Context, chatID, adminID int64) (int64, error) {
+	n, err := s.repo.ResetBalances(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Int64("balances", n).
+		Str("operation", "sandbox_reset").
+		Msg("Sandbox balances reset")
+	return n, nil
+}
+
+// Balance returns a user's play money in a chat.
+func (s *SandboxService) Balance(ctx context.Context, chatID, userID int64) (int64, error) {
+	return s.repo.GetBalance(ctx, chatID, userID, s.startBalance)
+}
+
+// AddBalance adds amount (negative to subtract) to a user's play money in a
+// chat and returns the new balance.
+func (s *SandboxService) AddBalance(ctx context.Context, chatID, userID, amount int64) (int64, error) {
+	return s.repo.AddBalance(ctx, chatID, userID, amount, s.startBalance)
+}
+
+// loadLocked fills the sandbox chat cache if needed; the caller must hold s.mu
+func (s *SandboxService) loadLocked(ctx context.Context) error {
+	if s.chats != nil {
+		return nil
+	}
+	chatIDs, err := s.repo.ListChats(ctx)
+	if err != nil {
+		return err
+	}
+	s.chats = make(map[int64]bool, len(chatIDs))
+	for _, chatID := range chatIDs {
+		s.chats[chatID] = true
+	}
+	return nil
+}
+
+// setCached records a chat's sandbox state in the cache once it is loaded
+func (s *SandboxService) setCached(chatID int64, sandbox bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chats == nil {
+		return
+	}
+	if sandbox {
+		s.chats[chatID] = true
+	} else {
+		delete(s.chats, chatID)
+	}
+}
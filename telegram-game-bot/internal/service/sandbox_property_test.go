@@ -0,0 +1,55 @@
+// Package service provides business logic implementations.
+// Property-based tests for sandbox chats.
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestSandboxAllowsProperty tests that commands moving real coins are refused
+// whatever their bot suffix, case and arguments, while house games and plain
+// text pass.
+func TestSandboxAllowsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		suffix := rapid.SampledFrom([]string{"", "@GameBot", "@gamebot"}).Draw(t, "suffix")
+		args := rapid.SampledFrom([]string{"", " 100", " @alice 50", "\n大 100"}).Draw(t, "args")
+
+		blocked := rapid.SampledFrom([]string{"/pay", "/daily", "/dj", "/shdj", "/duijue", "/freespin", "/raid_join"}).Draw(t, "blocked")
+		if rapid.Bool().Draw(t, "upper") {
+			blocked = strings.ToUpper(blocked)
+		}
+		if SandboxAllows(blocked + suffix + args) {
+			t.Fatalf("SandboxAllows(%q) = true, want false", blocked+suffix+args)
+		}
+
+		allowed := rapid.SampledFrom([]string{"/dice", "/slot", "/dice3", "/sicbo", "/balance", "/sandbox", "/payday"}).Draw(t, "allowed")
+		if !SandboxAllows(allowed + suffix + args) {
+			t.Fatalf("SandboxAllows(%q) = false, want true", allowed+suffix+args)
+		}
+
+		text := rapid.SampledFrom([]string{"", "大 500", "hello /pay"}).Draw(t, "text")
+		if !SandboxAllows(text) {
+			t.Fatalf("SandboxAllows(%q) = false, want true", text)
+		}
+	})
+}
+
+// TestBalanceScopeProperty tests that only sandbox scopes are play money.
+func TestBalanceScopeProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		chatID := rapid.Int64().Filter(func(id int64) bool { return id != 0 }).Draw(t, "chatID")
+
+		scope := model.SandboxBalance(chatID)
+		if !scope.IsSandbox() || scope.SandboxChatID != chatID {
+			t.Fatalf("SandboxBalance(%d) = %+v", chatID, scope)
+		}
+		if model.RealBalance.IsSandbox() {
+			t.Fatal("RealBalance is play money")
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/repository"
+)
+
+// Self exclusion rules
+const (
+	SelfExclusionMinDays = 1
+	SelfExclusionMaxDays = 365
+
+	// selfExclusionCacheTTL bounds how long another instance may miss a new exclusion
+	selfExclusionCacheTTL = time.Minute
+)
+
+// Self exclusion errors
+var (
+	ErrSelfExclusionDays = errors.New("禁赌天数无效")
+)
+
+// gamblingCommands are refused to self-excluded users and users over their limits
+var gamblingCommands = map[string]bool{
+	"/dice":      true,
+	"/dice3":     true,
+	"/dicebo3":   true,
+	"/slot":      true,
+	"/blackjack": true,
+	"/freespin":  true,
+	"/sicbo":     true,
+	"/heist":     true,
+	"/dj":        true,
+	"/raid":      true,
+	"/raid_join": true,
+	"/pool_bet":  true,
+	"/shdj":      true,
+	"/duijue":    true,
+	"/shdice":    true,
+}
+
+// gamblingCallbacks are the prefixes of buttons placing bets or joining rounds.
+// Blackjack hit and stand stay allowed so a hand in play can be finished.
+var gamblingCallbacks = []string{sicbo.CallbackPrefix, "replay_", "heist_", "duel_", "bj_double"}
+
+// IsGambling reports whether a message gambles: a gambling command or a
+// SicBo text bet. Self exclusion and gambling limits refuse these.
+func IsGambling(text string) bool {
+	if !strings.HasPrefix(text, "/") {
+		_, err := sicbo.ParseTextBets(text)
+		return err == nil
+	}
+	command, _, _ := strings.Cut(strings.Fields(text)[0], "@")
+	return gamblingCommands[strings.ToLower(command)]
+}
+
+// IsGamblingCallback reports whether a button places a bet or joins a round.
+func IsGamblingCallback(data string) bool {
+	data = strings.TrimPrefix(data, "\f")
+	for _, prefix := range gamblingCallbacks {
+		if strings.HasPrefix(data, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// selfExclusionEntry is a cached exclusion end; the zero time means none
+type selfExclusionEntry struct {
+	until    time.Time
+	loadedAt time.Time
+}
+
+// SelfExclusionService lets users voluntarily lock themselves out of all
+// gambling for a number of days. An exclusion cannot be lifted or shortened
+// before it ends, only extended. Exclusions are cached briefly since every
+// message is checked.
+type SelfExclusionService struct {
+	userRepo *repository.UserRepository
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[int64]selfExclusionEntry
+}
+
+// NewSelfExclusionService creates a new SelfExclusionService instance.
+func NewSelfExclusionService(userRepo *repository.UserRepository) *SelfExclusionService {
+	return &SelfExclusionService{
+		userRepo: userRepo,
+		now:      time.Now,
+		cache:    make(map[int64]selfExclusionEntry),
+	}
+}
+
+// Exclude locks a user out of gambling for the given number of days from now.
+// Returns the end of the exclusion in effect, which is later if the user was
+// already excluded for longer.
+func (s *SelfExclusionService) Exclude(ctx context.Context, userID int64, days int) (time.Time, error) {
+	if days < SelfExclusionMinDays || days > SelfExclusionMaxDays {
+		return time.Time{}, ErrSelfExclusionDays
+	}
+
+	until, err := s.userRepo.ExtendSelfExclusion(ctx, userID, s.now().AddDate(0, 0, days))
+	if err != nil {
+		return time.Time{}, err
+	}
+	s.store(userID, until)
+
+	log.Info().
+		Int64("user_id", userID).
+		Int("days", days).
+		Time("until", until).
+		Str("operation", "self_exclude").
+		Msg("User excluded themselves from gambling")
+	return until, nil
+}
+
+// ExcludedUntil returns the end of a user's active exclusion, false if the
+// user may gamble. Lookup failures let the user play and are logged.
+func (s *SelfExclusionService) ExcludedUntil(ctx context.Context, userID int64) (time.Time, bool) {
+	now := s.now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[userID]
+	s.mu.Unlock()
+
+	if !ok || now.Sub(entry.loadedAt) >= selfExclusionCacheTTL {
+		until, err := s.userRepo.GetSelfExclusion(ctx, userID)
+		if err != nil {
+			log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to load self exclusion")
+			return time.Time{}, false
+		}
+		var end time.Time
+		if until != nil {
+			end = *until
+		}
+		entry = s.store(userID, end)
+	}
+
+	if !now.Before(entry.until) {
+		return time.Time{}, false
+	}
+	return entry.until, true
+}
+
+// store caches the exclusion end of a user
+func (s *SelfExclusionService) store(userID int64, until time.Time) selfExclusionEntry {
+	now := s.now()
+	entry := selfExclusionEntry{until: until, loadedAt: now}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[userID] = entry
+
+	// Drop stale entries now and then so the cache only holds active users
+	if len(s.cache)%1024 == 0 {
+		for id, e := range s.cache {
+			if now.Sub(e.loadedAt) >= selfExclusionCacheTTL {
+				delete(s.cache, id)
+			}
+		}
+	}
+	return entry
+}
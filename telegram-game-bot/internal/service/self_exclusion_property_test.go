@@ -0,0 +1,65 @@
+// Package service provides business logic implementations.
+// Property-based tests for self exclusion from gambling.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestIsGamblingProperty tests that gambling commands are refused
+// with any arguments, bot mention or case, while other commands are not.
+func TestIsGamblingProperty(t *testing.T) {
+	gambling := make([]string, 0, len(gamblingCommands))
+	for command := range gamblingCommands {
+		gambling = append(gambling, command)
+	}
+	allowed := []string{"/balance", "/mybets", "/bag", "/selfexclude", "/help", "/cooldowns", "/alerts"}
+
+	rapid.Check(t, func(t *rapid.T) {
+		suffix := rapid.SampledFrom([]string{"", "@game_bot", "@Game_Bot"}).Draw(t, "suffix")
+		args := rapid.SampledFrom([]string{"", " 100", " 3 confirm"}).Draw(t, "args")
+
+		command := rapid.SampledFrom(gambling).Draw(t, "gambling")
+		if rapid.Bool().Draw(t, "upper") {
+			command = "/" + string(command[1]-'a'+'A') + command[2:]
+		}
+		if !IsGambling(command + suffix + args) {
+			t.Fatalf("%q is not refused", command+suffix+args)
+		}
+
+		other := rapid.SampledFrom(allowed).Draw(t, "allowed")
+		if IsGambling(other + suffix + args) {
+			t.Fatalf("%q is refused", other+suffix+args)
+		}
+	})
+}
+
+// TestIsGamblingTextBets tests that SicBo text bets are refused but chat is not.
+func TestIsGamblingTextBets(t *testing.T) {
+	for _, text := range []string{"大 500", "3 200", "小 100，1 50"} {
+		if !IsGambling(text) {
+			t.Errorf("Text bet %q is not refused", text)
+		}
+	}
+	for _, text := range []string{"hello", "今天手气不错", ""} {
+		if IsGambling(text) {
+			t.Errorf("Chat message %q is refused", text)
+		}
+	}
+}
+
+// TestIsGamblingCallback tests that betting and joining buttons are refused.
+func TestIsGamblingCallback(t *testing.T) {
+	for _, data := range []string{"sicbo_big", "\fsicbo_single_3", "replay_dice_100", "heist_join", "duel_accept_1", "\fbj_double|42"} {
+		if !IsGamblingCallback(data) {
+			t.Errorf("Callback %q is not refused", data)
+		}
+	}
+	for _, data := range []string{"shop_buy_shield", "support_close_1", "\fbj_hit|42", "\fbj_stand|42"} {
+		if IsGamblingCallback(data) {
+			t.Errorf("Callback %q is refused", data)
+		}
+	}
+}
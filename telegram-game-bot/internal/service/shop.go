@@ -23,15 +23,19 @@ var (
 	ErrSelfHandcuff       = errors.New("不能对自己使用手铐")
 	ErrTargetNotFound     = errors.New("目标用户未找到")
 	ErrAlreadyLocked      = errors.New("目标已被锁定")
+	ErrHandcuffOptedOut   = errors.New("你已退出 PvP，无法使用手铐（发送 /pvp on 重新加入）")
+	ErrTargetOptedOut     = errors.New("目标已退出 PvP，无法被使用手铐")
 	ErrNotLocked          = errors.New("你没有被锁定")
 	ErrDailyLimitReached  = errors.New("今日购买次数已达上限")
 	ErrMaxItemTypesReached = errors.New("最多只能持有2种道具")
+	ErrInventoryFull       = errors.New("该道具持有数量已达上限")
 )
 
 // UserInventory represents a user's complete inventory
 type UserInventory struct {
 	HandcuffCount int
 	Items         []repository.UserItem
+	Effects       []repository.UserEffect // Active duration based effects
 }
 
 // ShopService handles shop-related business logic
@@ -40,6 +44,9 @@ type ShopService struct {
 	txRepo        *repository.TransactionRepository
 	inventoryRepo *repository.InventoryRepository
 	userLock      *lock.UserLock
+	pricingRepo   *repository.PricingRepository // Optional: flash sales and demand pricing
+	demand        shop.DemandPricing
+	pvpRepo       *repository.PvPRepository // Optional: users who opted out of PvP
 }
 
 // NewShopService creates a new ShopService instance
@@ -57,6 +64,12 @@ func NewShopService(
 	}
 }
 
+// SetPvPRepository sets the repository of PvP opt-outs; opted-out users can
+// neither use nor receive handcuffs.
+func (s *ShopService) SetPvPRepository(repo *repository.PvPRepository) {
+	s.pvpRepo = repo
+}
+
 // GetShopItems returns all available shop items
 func (s *ShopService) GetShopItems() []shop.ItemConfig {
 	return shop.GetAllItems()
@@ -71,28 +84,35 @@ func (s *ShopService) PurchaseItem(ctx context.Context, userID int64, itemType s
 		return ErrItemNotFound
 	}
 
+	// Resolve the price charged for this purchase
+	quote := s.QuotePrice(ctx, item)
+
 	// Lock user for balance operation
 	s.userLock.Lock(userID)
 	defer s.userLock.Unlock(userID)
 
 	// Check if user already has this item type
-	currentCount, err := s.inventoryRepo.GetUseCount(ctx, userID, string(itemType))
+	held, err := s.inventoryRepo.HasActiveEffect(ctx, userID, string(itemType))
 	if err != nil {
 		return err
 	}
 
 	// If user doesn't have this item, check max item types limit
-	if currentCount == 0 {
-		// Get all items user currently has
-		items, err := s.inventoryRepo.GetAllItems(ctx, userID)
+	if !held {
+		heldTypes, err := s.countHeldItemTypes(ctx, userID)
 		if err != nil {
 			return err
 		}
-		if len(items) >= MaxItemTypes {
+		if heldTypes >= MaxItemTypes {
 			return ErrMaxItemTypesReached
 		}
 	}
 
+	// Check the per item stack cap
+	if err := s.checkStackLimit(ctx, userID, item); err != nil {
+		return err
+	}
+
 	// Check daily purchase limit if applicable
 	// Requirements: 2.3, 2.9, 3.3, 3.8, 7.3, 7.8, 12.3, 12.4
 	if item.HasDailyLimit() {
@@ -111,23 +131,25 @@ func (s *ShopService) PurchaseItem(ctx context.Context, userID int64, itemType s
 		return err
 	}
 
-	if user.Balance < item.Price {
+	if user.Balance < quote.Price {
 		return ErrInsufficientBalance
 	}
 
 	// Deduct balance
 	desc := "购买" + item.Name
-	_, err = s.userRepo.UpdateBalance(ctx, userID, -item.Price)
+	_, err = s.userRepo.UpdateBalance(ctx, userID, -quote.Price)
 	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return ErrInsufficientBalance
+		}
 		return err
 	}
 
 	// Record transaction
-	s.txRepo.Create(ctx, userID, -item.Price, model.TxTypeShopPurchase, &desc)
+	s.txRepo.Create(ctx, userID, -quote.Price, model.TxTypeShopPurchase, &desc)
 
-	// Add item to inventory with use count
-	err = s.inventoryRepo.AddItem(ctx, userID, string(itemType), item.UseCount)
-	if err != nil {
+	// Add item to inventory
+	if err := s.addToInventory(ctx, userID, item); err != nil {
 		return err
 	}
 
@@ -139,9 +161,95 @@ func (s *ShopService) PurchaseItem(ctx context.Context, userID int64, itemType s
 		}
 	}
 
+	// Feed the demand pricing counter
+	if s.pricingRepo != nil {
+		_ = s.pricingRepo.IncrementItemSold(ctx, string(itemType))
+	}
+
 	return nil
 }
 
+// GrantItem gives an item to a user for free (e.g. promo code rewards).
+// The max item types limit still applies, daily purchase limits do not.
+// The caller must hold the user lock.
+func (s *ShopService) GrantItem(ctx context.Context, userID int64, itemType shop.ItemType) error {
+	item, ok := shop.GetItem(itemType)
+	if !ok {
+		return ErrItemNotFound
+	}
+
+	held, err := s.inventoryRepo.HasActiveEffect(ctx, userID, string(itemType))
+	if err != nil {
+		return err
+	}
+	if !held {
+		heldTypes, err := s.countHeldItemTypes(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if heldTypes >= MaxItemTypes {
+			return ErrMaxItemTypesReached
+		}
+	}
+	if err := s.checkStackLimit(ctx, userID, item); err != nil {
+		return err
+	}
+
+	return s.addToInventory(ctx, userID, item)
+}
+
+// checkStackLimit returns ErrInventoryFull if one more purchase would exceed the item's stack cap
+func (s *ShopService) checkStackLimit(ctx context.Context, userID int64, item shop.ItemConfig) error {
+	if !item.HasStackLimit() {
+		return nil
+	}
+	held, err := s.inventoryRepo.GetUseCount(ctx, userID, string(item.Type))
+	if err != nil {
+		return err
+	}
+	if !item.CanStack(held) {
+		return ErrInventoryFull
+	}
+	return nil
+}
+
+// addToInventory adds one purchase worth of the item: duration based items
+// extend their timed effect, use count based items add to their remaining uses
+// (expiring items restart their lifetime)
+func (s *ShopService) addToInventory(ctx context.Context, userID int64, item shop.ItemConfig) error {
+	if item.IsDurationBased() {
+		_, err := s.inventoryRepo.ExtendEffect(ctx, userID, string(item.Type), item.ActiveDuration)
+		return err
+	}
+	if item.Expires() {
+		expiresAt := time.Now().Add(item.Lifetime)
+		return s.inventoryRepo.AddItemWithExpiry(ctx, userID, string(item.Type), item.UseCount, &expiresAt)
+	}
+	return s.inventoryRepo.AddItem(ctx, userID, string(item.Type), item.UseCount)
+}
+
+// countHeldItemTypes returns the number of distinct item types a user holds,
+// counting both use count based items and active timed effects
+func (s *ShopService) countHeldItemTypes(ctx context.Context, userID int64) (int, error) {
+	items, err := s.inventoryRepo.GetAllItems(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	effects, err := s.inventoryRepo.GetTimedEffects(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	types := make(map[string]bool, len(items)+len(effects))
+	for _, item := range items {
+		types[item.ItemType] = true
+	}
+	for _, effect := range effects {
+		types[effect.EffectType] = true
+	}
+	return len(types), nil
+}
+
 // UseHandcuff uses a handcuff on a target user
 func (s *ShopService) UseHandcuff(ctx context.Context, userID, targetID int64) error {
 	// Can't handcuff yourself
@@ -155,6 +263,24 @@ func (s *ShopService) UseHandcuff(ctx context.Context, userID, targetID int64) e
 		return ErrTargetNotFound
 	}
 
+	// Opted-out users can neither handcuff nor be handcuffed
+	if s.pvpRepo != nil {
+		optedOut, err := s.pvpRepo.IsOptedOut(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if optedOut {
+			return ErrHandcuffOptedOut
+		}
+		optedOut, err = s.pvpRepo.IsOptedOut(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if optedOut {
+			return ErrTargetOptedOut
+		}
+	}
+
 	// Check if user has handcuffs
 	count, err := s.inventoryRepo.GetItemCount(ctx, userID, string(shop.ItemHandcuff))
 	if err != nil {
@@ -199,9 +325,16 @@ func (s *ShopService) GetUserInventory(ctx context.Context, userID int64) (*User
 		return nil, err
 	}
 
+	// Get all unexpired timed effects
+	effects, err := s.inventoryRepo.GetTimedEffects(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &UserInventory{
 		HandcuffCount: handcuffCount,
 		Items:         items,
+		Effects:       effects,
 	}, nil
 }
 
@@ -239,8 +372,8 @@ func (s *ShopService) HasBloodthirstSword(ctx context.Context, userID int64) boo
 	return err == nil && has
 }
 
-// GetEffectExpiry returns the expiry time of an effect
-// Deprecated: Use GetEffectUseCount instead since we now use use-count based system
+// GetEffectExpiry returns the expiry time of a duration based effect
+// Returns zero time if the user has no active timed effect of this type
 func (s *ShopService) GetEffectExpiry(ctx context.Context, userID int64, effectType shop.ItemType) time.Time {
 	expiry, _ := s.inventoryRepo.GetEffectExpiry(ctx, userID, string(effectType))
 	return expiry
@@ -253,20 +386,37 @@ func (s *ShopService) GetEffectUseCount(ctx context.Context, userID int64, effec
 }
 
 // DecrementUseCount decreases the use count of an item by 1
+// Duration based items are not consumed on use and are left untouched
 // Requirements: 3.6, 3.7, 4.4, 4.5, 5.4, 5.5, 6.5, 6.6, 7.6, 7.7, 8.4, 8.5, 9.5, 9.6
 func (s *ShopService) DecrementUseCount(ctx context.Context, userID int64, effectType shop.ItemType) error {
-	_, err := s.inventoryRepo.DecrementUseCount(ctx, userID, string(effectType))
-	return err
+	return s.DecrementUseCountByString(ctx, userID, string(effectType))
 }
 
 // DecrementUseCountByString decreases the use count of an item by 1 (accepts string type)
 // This method is used by the ItemEffectChecker interface
 // Requirements: 6.5, 7.6, 8.4, 9.5 - Decrement use count after item use
 func (s *ShopService) DecrementUseCountByString(ctx context.Context, userID int64, effectType string) error {
+	if item, ok := shop.GetItem(shop.ItemType(effectType)); ok && item.IsDurationBased() {
+		return nil
+	}
 	_, err := s.inventoryRepo.DecrementUseCount(ctx, userID, effectType)
 	return err
 }
 
+// UseItem consumes one use of an item and returns the uses left.
+// consumed is false for duration based items, which are not consumed on use,
+// and if the user holds no use of the item.
+func (s *ShopService) UseItem(ctx context.Context, userID int64, effectType string) (remaining int, consumed bool) {
+	if item, ok := shop.GetItem(shop.ItemType(effectType)); ok && item.IsDurationBased() {
+		return 0, false
+	}
+	remaining, consumed, err := s.inventoryRepo.ConsumeUse(ctx, userID, effectType)
+	if err != nil {
+		return 0, false
+	}
+	return remaining, consumed
+}
+
 // HasEmperorClothes checks if user has active emperor clothes (highest priority defense)
 // Requirements: 9.3, 9.4 - Emperor clothes immunity check
 func (s *ShopService) HasEmperorClothes(ctx context.Context, userID int64) bool {
@@ -299,14 +449,16 @@ func (s *ShopService) HasGoldenCassock(ctx context.Context, userID int64) bool {
 // This is triggered by Golden Cassock effect
 // Requirements: 8.4 - Remove attacker's defensive items
 func (s *ShopService) RemoveDefensiveItems(ctx context.Context, userID int64) error {
-	// Remove Shield
-	err := s.inventoryRepo.RemoveItem(ctx, userID, string(shop.ItemShield))
-	if err != nil {
-		return err
+	for _, itemType := range []shop.ItemType{shop.ItemShield, shop.ItemThornArmor} {
+		// Remove both use count based items and timed effects
+		if err := s.inventoryRepo.RemoveItem(ctx, userID, string(itemType)); err != nil {
+			return err
+		}
+		if err := s.inventoryRepo.RemoveEffect(ctx, userID, string(itemType)); err != nil {
+			return err
+		}
 	}
-	// Remove Thorn Armor
-	err = s.inventoryRepo.RemoveItem(ctx, userID, string(shop.ItemThornArmor))
-	return err
+	return nil
 }
 
 // CheckDailyLimit checks if a user has reached the daily purchase limit for an item
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// Flash sale limits
+const (
+	MinSaleDuration = 5 * time.Minute
+	MaxSaleDuration = 7 * 24 * time.Hour
+)
+
+// Pricing errors
+var (
+	ErrPricingDisabled    = errors.New("动态定价未启用")
+	ErrSaleInvalidTarget  = errors.New("未知道具")
+	ErrSaleInvalidPercent = errors.New("折扣需在 1-90% 之间")
+	ErrSaleInvalidLength  = errors.New("特惠时长需在 5 分钟到 7 天之间")
+	ErrSaleNotFound       = errors.New("该道具没有进行中的特惠")
+)
+
+// SetPricing enables flash sales and demand based pricing.
+func (s *ShopService) SetPricing(repo *repository.PricingRepository, demand shop.DemandPricing) {
+	s.pricingRepo = repo
+	s.demand = demand
+}
+
+// PriceQuotes resolves the current price of every item.
+// Falls back to list prices if pricing is disabled or its state cannot be loaded.
+func (s *ShopService) PriceQuotes(ctx context.Context) map[shop.ItemType]shop.PriceQuote {
+	sales, sold, ok := s.loadPricingState(ctx)
+	if !ok {
+		return shop.BaseQuotes()
+	}
+
+	now := time.Now()
+	quotes := make(map[shop.ItemType]shop.PriceQuote, len(shop.ShopItems))
+	for itemType, item := range shop.ShopItems {
+		quotes[itemType] = shop.ResolvePrice(item, sales, sold[string(itemType)], s.demand, now)
+	}
+	return quotes
+}
+
+// QuotePrice resolves the current price of a single item.
+func (s *ShopService) QuotePrice(ctx context.Context, item shop.ItemConfig) shop.PriceQuote {
+	sales, sold, ok := s.loadPricingState(ctx)
+	if !ok {
+		return shop.PriceQuote{Base: item.Price, Price: item.Price}
+	}
+	return shop.ResolvePrice(item, sales, sold[string(item.Type)], s.demand, time.Now())
+}
+
+// loadPricingState loads active sales and today's sales counters
+func (s *ShopService) loadPricingState(ctx context.Context) ([]shop.Sale, map[string]int, bool) {
+	if s.pricingRepo == nil {
+		return nil, nil, false
+	}
+
+	stored, err := s.pricingRepo.GetActiveSales(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "load_sales").Msg("Failed to load shop sales, using list prices")
+		return nil, nil, false
+	}
+	sold, err := s.pricingRepo.GetSoldToday(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "load_item_sales").Msg("Failed to load item sales, using list prices")
+		return nil, nil, false
+	}
+
+	sales := make([]shop.Sale, len(stored))
+	for i, sale := range stored {
+		sales[i] = shop.Sale{Target: sale.Target, DiscountPercent: sale.DiscountPercent, EndsAt: sale.EndsAt}
+	}
+	return sales, sold, true
+}
+
+// StartSale starts (or replaces) a flash sale on an item, or on all items with shop.AllItemsSale.
+func (s *ShopService) StartSale(ctx context.Context, adminID int64, target string, percent int, duration time.Duration) (*repository.ShopSale, error) {
+	if s.pricingRepo == nil {
+		return nil, ErrPricingDisabled
+	}
+
+	if target != shop.AllItemsSale {
+		item, ok := shop.FindItem(target)
+		if !ok {
+			return nil, ErrSaleInvalidTarget
+		}
+		target = string(item.Type)
+	}
+	if percent < 1 || percent > shop.MaxSaleDiscountPercent {
+		return nil, ErrSaleInvalidPercent
+	}
+	if duration < MinSaleDuration || duration > MaxSaleDuration {
+		return nil, ErrSaleInvalidLength
+	}
+
+	sale := &repository.ShopSale{
+		Target:          target,
+		DiscountPercent: percent,
+		EndsAt:          time.Now().Add(duration),
+		CreatedBy:       adminID,
+	}
+	if err := s.pricingRepo.UpsertSale(ctx, sale); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("operation", "start_sale").
+		Int64("admin_id", adminID).
+		Str("target", target).
+		Int("discount_percent", percent).
+		Time("ends_at", sale.EndsAt).
+		Msg("Flash sale started")
+
+	return sale, nil
+}
+
+// EndSale ends the flash sale on an item, or the all items sale with shop.AllItemsSale.
+func (s *ShopService) EndSale(ctx context.Context, adminID int64, target string) error {
+	if s.pricingRepo == nil {
+		return ErrPricingDisabled
+	}
+
+	if target != shop.AllItemsSale {
+		item, ok := shop.FindItem(target)
+		if !ok {
+			return ErrSaleInvalidTarget
+		}
+		target = string(item.Type)
+	}
+
+	deleted, err := s.pricingRepo.DeleteSale(ctx, target)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSaleNotFound
+	}
+
+	log.Info().
+		Str("operation", "end_sale").
+		Int64("admin_id", adminID).
+		Str("target", target).
+		Msg("Flash sale ended")
+
+	return nil
+}
+
+// GetActiveSales returns all running flash sales.
+func (s *ShopService) GetActiveSales(ctx context.Context) ([]*repository.ShopSale, error) {
+	if s.pricingRepo == nil {
+		return nil, ErrPricingDisabled
+	}
+	return s.pricingRepo.GetActiveSales(ctx)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// Sell-back errors
+var (
+	ErrItemNotSellable = errors.New("该道具不可回收")
+	ErrNotEnoughUses   = errors.New("道具剩余次数不足")
+)
+
+// SellBackPrice returns the per purchase price sell-backs are valued at: the
+// list price, or the current price if lower, so buying on a flash sale and
+// selling back never makes a profit.
+func (s *ShopService) SellBackPrice(ctx context.Context, item shop.ItemConfig) int64 {
+	quote := s.QuotePrice(ctx, item)
+	if quote.Price < item.Price {
+		return quote.Price
+	}
+	return item.Price
+}
+
+// SellBackOptions returns the sell-back offers for the uses in an inventory.
+func (s *ShopService) SellBackOptions(ctx context.Context, inventory *UserInventory) []shop.SellBackOption {
+	var options []shop.SellBackOption
+	for _, held := range inventory.Items {
+		item, ok := shop.GetItem(shop.ItemType(held.ItemType))
+		if !ok || !item.CanSellBack() || held.UseCount <= 0 {
+			continue
+		}
+		price := s.SellBackPrice(ctx, item)
+		options = append(options, shop.SellBackOption{
+			Item:     item,
+			Uses:     held.UseCount,
+			OneValue: item.SellBackValue(price, 1),
+			AllValue: item.SellBackValue(price, held.UseCount),
+		})
+	}
+	return options
+}
+
+// SellBack returns uses of an item to the shop for coins (uses <= 0 sells all of them).
+// The uses are removed and the coins credited atomically.
+// Returns the coins credited and the new balance.
+func (s *ShopService) SellBack(ctx context.Context, userID int64, itemType shop.ItemType, uses int) (int64, int64, error) {
+	item, ok := shop.GetItem(itemType)
+	if !ok {
+		return 0, 0, ErrItemNotFound
+	}
+	if !item.CanSellBack() {
+		return 0, 0, ErrItemNotSellable
+	}
+
+	price := s.SellBackPrice(ctx, item)
+
+	s.userLock.Lock(userID)
+	defer s.userLock.Unlock(userID)
+
+	held, err := s.inventoryRepo.GetUseCount(ctx, userID, string(itemType))
+	if err != nil {
+		return 0, 0, err
+	}
+	if uses <= 0 {
+		uses = held
+	}
+	if uses <= 0 || uses > held {
+		return 0, 0, ErrNotEnoughUses
+	}
+
+	amount := item.SellBackValue(price, uses)
+	desc := "回收" + item.Name
+	user, err := s.inventoryRepo.SellBack(ctx, userID, string(itemType), uses, amount, desc)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotEnoughUses) {
+			return 0, 0, ErrNotEnoughUses
+		}
+		log.Error().Err(err).
+			Str("operation", "sell_back").
+			Int64("user_id", userID).
+			Str("item", string(itemType)).
+			Int("uses", uses).
+			Msg("Failed to sell back item")
+		return 0, 0, err
+	}
+
+	log.Info().
+		Str("operation", "sell_back").
+		Int64("user_id", userID).
+		Str("item", string(itemType)).
+		Int("uses", uses).
+		Int64("amount", amount).
+		Msg("Item sold back")
+
+	return amount, user.Balance, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// StealableItems returns the item types a robber can take one use of from a
+// victim's items: never emperor clothes, and only what fits the robber's item
+// type and stack limits. robberUses are the robber's uses per item type and
+// robberTypes the number of item types the robber holds. Sorted by type.
+func StealableItems(victimItems []repository.UserItem, robberUses map[string]int, robberTypes int) []shop.ItemType {
+	var types []shop.ItemType
+	for _, held := range victimItems {
+		item, ok := shop.GetItem(shop.ItemType(held.ItemType))
+		if !ok || item.Type == shop.ItemEmperorClothes || item.IsDurationBased() || held.UseCount <= 0 {
+			continue
+		}
+		owned := robberUses[held.ItemType]
+		if owned == 0 && robberTypes >= MaxItemTypes {
+			continue
+		}
+		if item.HasStackLimit() && owned+1 > item.MaxStack {
+			continue
+		}
+		types = append(types, item.Type)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// StealRandomItem moves one use of a random stealable item from the victim to
+// the robber (see StealableItems). ok is false if there was nothing to steal.
+func (s *ShopService) StealRandomItem(ctx context.Context, victimID, robberID int64) (itemType shop.ItemType, ok bool) {
+	victimItems, err := s.inventoryRepo.GetAllItems(ctx, victimID)
+	if err != nil {
+		log.Warn().Err(err).Int64("victim_id", victimID).Msg("Failed to load items to steal")
+		return "", false
+	}
+	robberItems, err := s.inventoryRepo.GetAllItems(ctx, robberID)
+	if err != nil {
+		log.Warn().Err(err).Int64("robber_id", robberID).Msg("Failed to load robber items")
+		return "", false
+	}
+	robberTypes, err := s.countHeldItemTypes(ctx, robberID)
+	if err != nil {
+		log.Warn().Err(err).Int64("robber_id", robberID).Msg("Failed to count robber item types")
+		return "", false
+	}
+
+	robberUses := make(map[string]int, len(robberItems))
+	for _, held := range robberItems {
+		robberUses[held.ItemType] = held.UseCount
+	}
+	candidates := StealableItems(victimItems, robberUses, robberTypes)
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	itemType = candidates[rand.Intn(len(candidates))]
+	transferred, err := s.inventoryRepo.TransferUse(ctx, victimID, robberID, string(itemType))
+	if err != nil {
+		log.Warn().Err(err).Int64("victim_id", victimID).Str("item", string(itemType)).Msg("Failed to steal item")
+		return "", false
+	}
+	return itemType, transferred
+}
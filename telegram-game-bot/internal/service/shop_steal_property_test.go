@@ -0,0 +1,72 @@
+// Package service provides business logic implementations.
+// Property-based tests for stealing items on great sword critical hits.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/repository"
+	"telegram-game-bot/internal/shop"
+)
+
+// TestStealableItemsProperty tests that emperor clothes are never stolen and
+// that a stolen use always fits the robber's type and stack limits.
+func TestStealableItemsProperty(t *testing.T) {
+	types := make([]shop.ItemType, 0, len(shop.ShopItems))
+	for itemType := range shop.ShopItems {
+		types = append(types, itemType)
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		var victimItems []repository.UserItem
+		for _, itemType := range rapid.SliceOfNDistinct(rapid.SampledFrom(types), 0, 4, func(it shop.ItemType) shop.ItemType { return it }).Draw(t, "victimTypes") {
+			victimItems = append(victimItems, repository.UserItem{
+				ItemType: string(itemType),
+				UseCount: rapid.IntRange(0, 10).Draw(t, "victimUses"),
+			})
+		}
+		robberUses := make(map[string]int)
+		for _, itemType := range rapid.SliceOfNDistinct(rapid.SampledFrom(types), 0, MaxItemTypes, func(it shop.ItemType) shop.ItemType { return it }).Draw(t, "robberTypes") {
+			robberUses[string(itemType)] = rapid.IntRange(1, 10).Draw(t, "robberUses")
+		}
+		robberTypes := len(robberUses)
+
+		for _, itemType := range StealableItems(victimItems, robberUses, robberTypes) {
+			if itemType == shop.ItemEmperorClothes {
+				t.Fatalf("Emperor clothes are stealable")
+			}
+			owned := robberUses[string(itemType)]
+			if owned == 0 && robberTypes >= MaxItemTypes {
+				t.Fatalf("Stole %s although the robber holds %d item types", itemType, robberTypes)
+			}
+			item, _ := shop.GetItem(itemType)
+			if item.HasStackLimit() && owned+1 > item.MaxStack {
+				t.Fatalf("Stole %s beyond the stack limit %d (held %d)", itemType, item.MaxStack, owned)
+			}
+			found := false
+			for _, held := range victimItems {
+				if held.ItemType == string(itemType) && held.UseCount > 0 {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("Stole %s which the victim has no use of", itemType)
+			}
+		}
+	})
+}
+
+// TestStealableItemsEmptyRobber tests that a robber without items can take
+// any use-count item but emperor clothes.
+func TestStealableItemsEmptyRobber(t *testing.T) {
+	victimItems := []repository.UserItem{
+		{ItemType: string(shop.ItemEmperorClothes), UseCount: 3},
+		{ItemType: string(shop.ItemThornArmor), UseCount: 1},
+	}
+	got := StealableItems(victimItems, nil, 0)
+	if len(got) != 1 || got[0] != shop.ItemThornArmor {
+		t.Fatalf("Stealable items %v, want only the thorn armor", got)
+	}
+}
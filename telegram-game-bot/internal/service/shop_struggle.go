@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Handcuff struggle rules
+const (
+	StruggleCooldown = 5 * time.Minute // Time between two /struggle attempts
+	StruggleChance   = 20              // Percent chance to break free
+	StruggleFee      = 50              // Coins charged per attempt
+)
+
+// Struggle errors
+var (
+	ErrStruggleCooldown = errors.New("挣扎太频繁，请稍后再试")
+	ErrStruggleFee      = errors.New("余额不足，无法支付挣扎费用")
+)
+
+// StruggleResult is the outcome of a /struggle attempt.
+type StruggleResult struct {
+	Freed     bool
+	Attempts  int           // Attempts made against the lock, this one included
+	Remaining time.Duration // Lock time left if not freed
+	Wait      time.Duration // Set with ErrStruggleCooldown: time until the next attempt
+	Balance   int64         // Balance after the fee
+}
+
+// StruggleWait returns how long a locked user must wait before the next
+// attempt, 0 if they may struggle now.
+func StruggleWait(lastStruggle *time.Time, now time.Time) time.Duration {
+	if lastStruggle == nil {
+		return 0
+	}
+	wait := lastStruggle.Add(StruggleCooldown).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Struggle lets a handcuffed user pay StruggleFee for a StruggleChance percent
+// chance to break free, at most once per StruggleCooldown. Attempts are counted
+// on the lock itself, so they survive restarts and reset with a new lock.
+func (s *ShopService) Struggle(ctx context.Context, userID int64) (*StruggleResult, error) {
+	lock, err := s.inventoryRepo.GetHandcuffLock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, ErrNotLocked
+	}
+	if wait := StruggleWait(lock.LastStruggleAt, time.Now()); wait > 0 {
+		return &StruggleResult{Attempts: lock.StruggleAttempts, Remaining: time.Until(lock.ExpiresAt), Wait: wait}, ErrStruggleCooldown
+	}
+
+	s.userLock.Lock(userID)
+	defer s.userLock.Unlock(userID)
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Balance < StruggleFee {
+		return nil, ErrStruggleFee
+	}
+
+	// Counting the attempt also enforces the cooldown against concurrent calls
+	attempts, ok, err := s.inventoryRepo.RecordStruggle(ctx, userID, StruggleCooldown)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &StruggleResult{Attempts: lock.StruggleAttempts, Remaining: time.Until(lock.ExpiresAt), Wait: StruggleCooldown}, ErrStruggleCooldown
+	}
+
+	updated, err := s.userRepo.UpdateBalance(ctx, userID, -StruggleFee)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, ErrStruggleFee
+		}
+		return nil, err
+	}
+	desc := fmt.Sprintf("挣扎手铐第 %d 次", attempts)
+	s.txRepo.Create(ctx, userID, -StruggleFee, model.TxTypeStruggleFee, &desc)
+
+	result := &StruggleResult{Attempts: attempts, Balance: updated.Balance}
+	if rand.Intn(100) < StruggleChance {
+		if _, err := s.inventoryRepo.RemoveHandcuffLock(ctx, userID); err != nil {
+			return nil, err
+		}
+		result.Freed = true
+		return result, nil
+	}
+	result.Remaining = time.Until(lock.ExpiresAt)
+	return result, nil
+}
@@ -0,0 +1,35 @@
+// Package service provides business logic implementations.
+// Property-based tests for the handcuff struggle cooldown.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestStruggleWaitProperty tests that a user may struggle right away the first
+// time, and otherwise exactly StruggleCooldown after the previous attempt.
+func TestStruggleWaitProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		if wait := StruggleWait(nil, now); wait != 0 {
+			t.Fatalf("First attempt waits %v", wait)
+		}
+
+		ago := time.Duration(rapid.Int64Range(0, int64(2*StruggleCooldown)).Draw(t, "ago"))
+		last := now.Add(-ago)
+		wait := StruggleWait(&last, now)
+
+		if ago >= StruggleCooldown {
+			if wait != 0 {
+				t.Fatalf("Attempt %v ago still waits %v", ago, wait)
+			}
+			return
+		}
+		if wait != StruggleCooldown-ago {
+			t.Fatalf("Attempt %v ago waits %v, want %v", ago, wait, StruggleCooldown-ago)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// SicBo auto-start limits
+const (
+	SicBoAutoMinInterval = 3   // Minimum minutes between automatic starts
+	SicBoAutoMaxInterval = 240 // Maximum minutes between automatic starts
+)
+
+// SicBo auto-start errors
+var (
+	ErrSicBoAutoInvalidInterval = fmt.Errorf("间隔需在 %d-%d 分钟之间", SicBoAutoMinInterval, SicBoAutoMaxInterval)
+	ErrSicBoAutoInvalidHours    = errors.New("时段格式错误，例如 19-23（0-24 表示全天）")
+	ErrSicBoAutoNotConfigured   = errors.New("本群尚未设置自动开局")
+)
+
+// sicboAutoState is the in-memory scheduling state of a chat
+type sicboAutoState struct {
+	lastStart time.Time // Last automatic start
+	idle      bool      // The previous round had no bets, starts are skipped
+}
+
+// ChatLocator returns the timezone chosen by a chat, nil for the default.
+type ChatLocator interface {
+	Location(chatID int64) *time.Location
+}
+
+// SicBoAutoService decides when sicbo rounds start automatically in chats.
+// After a round without bets no further rounds are started until a round
+// with bets is played or the chat's time window opens again.
+type SicBoAutoService struct {
+	repo     *repository.SicBoAutoRepository
+	timezone *time.Location
+	locator  ChatLocator // Optional: per chat timezones
+
+	mu    sync.Mutex
+	state map[int64]*sicboAutoState // chatID -> state
+}
+
+// NewSicBoAutoService creates a new SicBoAutoService.
+// Hours of the schedules are interpreted in timezone (UTC if nil).
+func NewSicBoAutoService(repo *repository.SicBoAutoRepository, timezone *time.Location) *SicBoAutoService {
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	return &SicBoAutoService{
+		repo:     repo,
+		timezone: timezone,
+		state:    make(map[int64]*sicboAutoState),
+	}
+}
+
+// SetLocator sets the source of per chat timezones overriding the default one.
+func (s *SicBoAutoService) SetLocator(locator ChatLocator) {
+	s.locator = locator
+}
+
+// Get returns the auto-start schedule of a chat, nil if it has none.
+func (s *SicBoAutoService) Get(ctx context.Context, chatID int64) (*model.SicBoAutoSchedule, error) {
+	return s.repo.Get(ctx, chatID)
+}
+
+// Enable turns on auto-start for a chat. Equal hours mean all day.
+func (s *SicBoAutoService) Enable(ctx context.Context, chatID, updatedBy int64, intervalMinutes, startHour, endHour int) (*model.SicBoAutoSchedule, error) {
+	if intervalMinutes < SicBoAutoMinInterval || intervalMinutes > SicBoAutoMaxInterval {
+		return nil, ErrSicBoAutoInvalidInterval
+	}
+	if startHour < 0 || startHour > 23 || endHour < 0 || endHour > 24 {
+		return nil, ErrSicBoAutoInvalidHours
+	}
+
+	schedule := &model.SicBoAutoSchedule{
+		ChatID:          chatID,
+		IntervalMinutes: intervalMinutes,
+		StartHour:       startHour,
+		EndHour:         endHour % 24,
+		Enabled:         true,
+		UpdatedBy:       updatedBy,
+	}
+	if err := s.repo.Upsert(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.state, chatID)
+	s.mu.Unlock()
+
+	log.Info().
+		Str("operation", "sicbo_auto_enable").
+		Int64("chat_id", chatID).
+		Int64("updated_by", updatedBy).
+		Int("interval_minutes", intervalMinutes).
+		Int("start_hour", schedule.StartHour).
+		Int("end_hour", schedule.EndHour).
+		Msg("SicBo auto-start enabled")
+	return schedule, nil
+}
+
+// Disable turns off auto-start for a chat, keeping its settings.
+func (s *SicBoAutoService) Disable(ctx context.Context, chatID, updatedBy int64) error {
+	ok, err := s.repo.SetEnabled(ctx, chatID, false, updatedBy)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSicBoAutoNotConfigured
+	}
+
+	s.mu.Lock()
+	delete(s.state, chatID)
+	s.mu.Unlock()
+
+	log.Info().
+		Str("operation", "sicbo_auto_disable").
+		Int64("chat_id", chatID).
+		Int64("updated_by", updatedBy).
+		Msg("SicBo auto-start disabled")
+	return nil
+}
+
+// Due returns the chats where a round should start automatically at now and
+// records the start. Chats with a round in progress are skipped by the caller.
+func (s *SicBoAutoService) Due(ctx context.Context, now time.Time) []int64 {
+	schedules, err := s.repo.ListEnabled(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load sicbo auto schedules")
+		return nil
+	}
+	return s.due(schedules, now)
+}
+
+// due returns the chats of schedules due at now and records their start
+func (s *SicBoAutoService) due(schedules []model.SicBoAutoSchedule, now time.Time) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chats []int64
+	for _, schedule := range schedules {
+		st, ok := s.state[schedule.ChatID]
+		if !ok {
+			st = &sicboAutoState{}
+			s.state[schedule.ChatID] = st
+		}
+
+		// Outside the window: the next window starts fresh
+		if !SicBoAutoInWindow(schedule.StartHour, schedule.EndHour, s.hourIn(schedule.ChatID, now)) {
+			st.idle = false
+			continue
+		}
+		if st.idle {
+			continue
+		}
+		interval := time.Duration(schedule.IntervalMinutes) * time.Minute
+		if !st.lastStart.IsZero() && now.Sub(st.lastStart) < interval {
+			continue
+		}
+
+		st.lastStart = now
+		chats = append(chats, schedule.ChatID)
+	}
+	return chats
+}
+
+// hourIn returns the hour of now in the timezone of a chat
+func (s *SicBoAutoService) hourIn(chatID int64, now time.Time) int {
+	if s.locator != nil {
+		if loc := s.locator.Location(chatID); loc != nil {
+			return now.In(loc).Hour()
+		}
+	}
+	return now.In(s.timezone).Hour()
+}
+
+// RoundFinished records whether a settled round had bets.
+// Only chats that had automatic starts are tracked.
+func (s *SicBoAutoService) RoundFinished(chatID int64, hadBets bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.state[chatID]; ok {
+		st.idle = !hadBets
+	}
+}
+
+// SicBoAutoInWindow reports whether hour lies in the window from startHour up
+// to endHour. Windows may wrap past midnight, equal hours mean all day.
+func SicBoAutoInWindow(startHour, endHour, hour int) bool {
+	switch {
+	case startHour == endHour:
+		return true
+	case startHour < endHour:
+		return hour >= startHour && hour < endHour
+	default:
+		return hour >= startHour || hour < endHour
+	}
+}
+
+// ParseSicBoAutoHours parses a time window such as "19-23".
+func ParseSicBoAutoHours(s string) (int, int, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, ErrSicBoAutoInvalidHours
+	}
+	start, errStart := strconv.Atoi(strings.TrimSpace(startStr))
+	end, errEnd := strconv.Atoi(strings.TrimSpace(endStr))
+	if errStart != nil || errEnd != nil || start < 0 || start > 23 || end < 0 || end > 24 {
+		return 0, 0, ErrSicBoAutoInvalidHours
+	}
+	return start, end, nil
+}
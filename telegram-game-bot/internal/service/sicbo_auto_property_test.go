@@ -0,0 +1,88 @@
+// Package service provides business logic implementations.
+// Property-based tests for scheduled sicbo rounds.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestSicBoAutoInWindowProperty tests that a window covers exactly
+// (end - start) mod 24 hours of the day, or all of them for equal hours.
+func TestSicBoAutoInWindowProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		start := rapid.IntRange(0, 23).Draw(t, "start")
+		end := rapid.IntRange(0, 23).Draw(t, "end")
+
+		covered := 0
+		for hour := 0; hour < 24; hour++ {
+			if SicBoAutoInWindow(start, end, hour) {
+				covered++
+			}
+		}
+		want := (end - start + 24) % 24
+		if start == end {
+			want = 24
+		}
+		if covered != want {
+			t.Fatalf("Window %d-%d covers %d hours, want %d", start, end, covered, want)
+		}
+		if !SicBoAutoInWindow(start, end, start) {
+			t.Fatalf("Window %d-%d does not contain its start hour", start, end)
+		}
+	})
+}
+
+// TestSicBoAutoDueProperty tests that rounds start at most once per interval
+// and that a round without bets pauses starts until the window reopens.
+func TestSicBoAutoDueProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		interval := rapid.IntRange(SicBoAutoMinInterval, SicBoAutoMaxInterval).Draw(t, "interval")
+		schedules := []model.SicBoAutoSchedule{{ChatID: -100, IntervalMinutes: interval, Enabled: true}}
+		s := NewSicBoAutoService(nil, time.UTC)
+
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		if got := s.due(schedules, now); len(got) != 1 {
+			t.Fatalf("First tick started %d rounds, want 1", len(got))
+		}
+
+		step := time.Duration(rapid.IntRange(1, interval-1).Draw(t, "step")) * time.Minute
+		if got := s.due(schedules, now.Add(step)); len(got) != 0 {
+			t.Fatalf("Round started again after %v with an interval of %d minutes", step, interval)
+		}
+
+		next := now.Add(time.Duration(interval) * time.Minute)
+		hadBets := rapid.Bool().Draw(t, "hadBets")
+		s.RoundFinished(-100, hadBets)
+		started := len(s.due(schedules, next)) == 1
+		if started != hadBets {
+			t.Fatalf("Round after a round with bets=%v started=%v", hadBets, started)
+		}
+	})
+}
+
+// TestSicBoAutoIdleResetsOutsideWindow tests that an idle chat resumes once
+// its window has closed and opened again.
+func TestSicBoAutoIdleResetsOutsideWindow(t *testing.T) {
+	schedules := []model.SicBoAutoSchedule{{ChatID: -100, IntervalMinutes: 10, StartHour: 19, EndHour: 23, Enabled: true}}
+	s := NewSicBoAutoService(nil, time.UTC)
+
+	evening := time.Date(2024, 1, 1, 19, 0, 0, 0, time.UTC)
+	if got := s.due(schedules, evening); len(got) != 1 {
+		t.Fatalf("Start of the window started %d rounds, want 1", len(got))
+	}
+	s.RoundFinished(-100, false)
+	if got := s.due(schedules, evening.Add(time.Hour)); len(got) != 0 {
+		t.Fatal("Idle chat got a round in the same window")
+	}
+	if got := s.due(schedules, evening.Add(8*time.Hour)); len(got) != 0 {
+		t.Fatal("Round started outside the window")
+	}
+	if got := s.due(schedules, evening.Add(24*time.Hour)); len(got) != 1 {
+		t.Fatal("Idle chat did not resume in the next window")
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/game/sicbo"
+	"telegram-game-bot/internal/repository"
+)
+
+// SicBoSummaryService DMs opted in players a personal summary of each SicBo
+// round they bet in. Summaries go through the outbox so they survive send failures.
+type SicBoSummaryService struct {
+	repo   *repository.SicBoSummaryRepository
+	outbox *OutboxService
+}
+
+// NewSicBoSummaryService creates a new SicBoSummaryService instance.
+func NewSicBoSummaryService(repo *repository.SicBoSummaryRepository, outbox *OutboxService) *SicBoSummaryService {
+	return &SicBoSummaryService{repo: repo, outbox: outbox}
+}
+
+// Enabled reports whether a user receives SicBo settlement DMs.
+func (s *SicBoSummaryService) Enabled(ctx context.Context, userID int64) (bool, error) {
+	return s.repo.IsOptedIn(ctx, userID)
+}
+
+// Enable opts a user in to SicBo settlement DMs.
+func (s *SicBoSummaryService) Enable(ctx context.Context, userID int64) error {
+	if err := s.repo.Enable(ctx, userID); err != nil {
+		return err
+	}
+	log.Info().Int64("user_id", userID).Str("operation", "sicbo_summary_enable").Msg("SicBo summary DMs enabled")
+	return nil
+}
+
+// Disable opts a user out of SicBo settlement DMs.
+func (s *SicBoSummaryService) Disable(ctx context.Context, userID int64) error {
+	if err := s.repo.Disable(ctx, userID); err != nil {
+		return err
+	}
+	log.Info().Int64("user_id", userID).Str("operation", "sicbo_summary_disable").Msg("SicBo summary DMs disabled")
+	return nil
+}
+
+// EnqueueSummaries queues the personal summary of a settled round for every
+// opted in player. bets are the round's bets per player; balance returns a
+// player's balance after settlement. Failures are logged, never returned, so
+// settlement is not affected.
+func (s *SicBoSummaryService) EnqueueSummaries(ctx context.Context, dice [3]int, bets map[int64]map[string]int64, balance func(userID int64) (int64, error)) {
+	userIDs := make([]int64, 0, len(bets))
+	for userID := range bets {
+		userIDs = append(userIDs, userID)
+	}
+	optedIn, err := s.repo.OptedIn(ctx, userIDs)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load sicbo summary opt-ins")
+		return
+	}
+
+	for userID := range optedIn {
+		newBalance, err := balance(userID)
+		if err != nil {
+			log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to load balance for sicbo summary")
+			continue
+		}
+		if err := s.outbox.Enqueue(ctx, userID, sicbo.FormatPersonalSummary(dice, bets[userID], newBalance)); err != nil {
+			log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to enqueue sicbo summary")
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/pkg/lock"
+	"telegram-game-bot/internal/repository"
+)
+
+// Support ticket settings
+const (
+	SupportRecentTxLimit   = 10  // Transactions attached to a ticket
+	SupportMaxOpenTickets  = 3   // Open tickets allowed per user
+	SupportMaxMessageRunes = 500 // Maximum length of a ticket message
+)
+
+// Support ticket errors
+var (
+	ErrTicketEmpty        = errors.New("请描述你遇到的问题")
+	ErrTicketTooLong      = errors.New("问题描述过长")
+	ErrTooManyOpenTickets = errors.New("你有太多未处理的工单，请等待管理员处理")
+	ErrTicketNotFound     = errors.New("工单不存在")
+	ErrTicketResolved     = errors.New("工单已处理")
+	ErrRefundNotAllowed   = errors.New("该交易不可退款")
+)
+
+// SupportService handles support tickets and their refund/dismiss resolution.
+// All identities come from the Telegram update (ticket owner, resolving admin),
+// never from user supplied text, so tickets cannot be filed or resolved on behalf of others.
+type SupportService struct {
+	ticketRepo *repository.SupportRepository
+	userRepo   *repository.UserRepository
+	txRepo     *repository.TransactionRepository
+	refundRepo *repository.RefundRepository
+	userLock   *lock.UserLock
+}
+
+// NewSupportService creates a new SupportService instance.
+func NewSupportService(
+	ticketRepo *repository.SupportRepository,
+	userRepo *repository.UserRepository,
+	txRepo *repository.TransactionRepository,
+	refundRepo *repository.RefundRepository,
+	userLock *lock.UserLock,
+) *SupportService {
+	return &SupportService{
+		ticketRepo: ticketRepo,
+		userRepo:   userRepo,
+		txRepo:     txRepo,
+		refundRepo: refundRepo,
+		userLock:   userLock,
+	}
+}
+
+// CreateTicket opens a new ticket for the user and returns it with the user's recent transactions.
+func (s *SupportService) CreateTicket(ctx context.Context, userID int64, message string) (*model.SupportTicket, []*model.Transaction, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, nil, ErrTicketEmpty
+	}
+	if utf8.RuneCountInString(message) > SupportMaxMessageRunes {
+		return nil, nil, ErrTicketTooLong
+	}
+
+	openCount, err := s.ticketRepo.CountOpenByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if openCount >= SupportMaxOpenTickets {
+		return nil, nil, ErrTooManyOpenTickets
+	}
+
+	ticket, err := s.ticketRepo.Create(ctx, userID, message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions, err := s.txRepo.GetByUserID(ctx, userID, SupportRecentTxLimit)
+	if err != nil {
+		// The ticket is still useful without transactions
+		log.Warn().Err(err).Int64("ticket_id", ticket.ID).Msg("Failed to load ticket transactions")
+	}
+
+	log.Info().
+		Int64("ticket_id", ticket.ID).
+		Int64("user_id", userID).
+		Str("operation", "support_ticket_create").
+		Msg("Support ticket created")
+
+	return ticket, transactions, nil
+}
+
+// GetTicket retrieves a ticket by ID.
+func (s *SupportService) GetTicket(ctx context.Context, ticketID int64) (*model.SupportTicket, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if errors.Is(err, repository.ErrTicketNotFound) {
+		return nil, ErrTicketNotFound
+	}
+	return ticket, err
+}
+
+// Refund credits back a losing transaction of the ticket owner and closes the ticket.
+// Returns the ticket and the refunded amount.
+func (s *SupportService) Refund(ctx context.Context, ticketID, txID, adminID int64) (*model.SupportTicket, int64, error) {
+	ticket, err := s.GetTicket(ctx, ticketID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tx, err := s.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			return nil, 0, ErrRefundNotAllowed
+		}
+		return nil, 0, err
+	}
+	if !IsRefundable(tx, ticket.UserID) {
+		return nil, 0, ErrRefundNotAllowed
+	}
+	amount := -tx.Amount
+
+	s.userLock.Lock(ticket.UserID)
+	defer s.userLock.Unlock(ticket.UserID)
+
+	// A transaction reversed with /refundtx must not be refunded again
+	refunded, err := s.refundRepo.IsRefunded(ctx, txID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if refunded {
+		return nil, 0, ErrTxAlreadyRefunded
+	}
+
+	// Close the ticket first so two admins cannot refund the same ticket twice
+	if err := s.ticketRepo.Resolve(ctx, ticketID, model.TicketStatusRefunded, adminID, &txID, amount); err != nil {
+		if errors.Is(err, repository.ErrTicketResolved) {
+			return nil, 0, ErrTicketResolved
+		}
+		return nil, 0, err
+	}
+
+	if _, err := s.userRepo.UpdateBalance(ctx, ticket.UserID, amount); err != nil {
+		if reopenErr := s.ticketRepo.Reopen(ctx, ticketID); reopenErr != nil {
+			log.Error().Err(reopenErr).Int64("ticket_id", ticketID).Msg("Failed to reopen ticket after refund failure")
+		}
+		return nil, 0, fmt.Errorf("failed to credit refund: %w", err)
+	}
+
+	desc := fmt.Sprintf("工单 #%d 退款（交易 #%d，管理员 %d）", ticketID, txID, adminID)
+	_, _ = s.txRepo.Create(ctx, ticket.UserID, amount, model.TxTypeRefund, &desc)
+
+	log.Info().
+		Int64("ticket_id", ticketID).
+		Int64("admin_id", adminID).
+		Int64("user_id", ticket.UserID).
+		Int64("tx_id", txID).
+		Int64("amount", amount).
+		Str("operation", "support_refund").
+		Msg("Support ticket refunded")
+
+	return ticket, amount, nil
+}
+
+// Dismiss closes the ticket without a refund.
+func (s *SupportService) Dismiss(ctx context.Context, ticketID, adminID int64) (*model.SupportTicket, error) {
+	ticket, err := s.GetTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ticketRepo.Resolve(ctx, ticketID, model.TicketStatusDismissed, adminID, nil, 0); err != nil {
+		if errors.Is(err, repository.ErrTicketResolved) {
+			return nil, ErrTicketResolved
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Int64("ticket_id", ticketID).
+		Int64("admin_id", adminID).
+		Int64("user_id", ticket.UserID).
+		Str("operation", "support_dismiss").
+		Msg("Support ticket dismissed")
+
+	return ticket, nil
+}
+
+// IsRefundable reports whether a transaction can be refunded for the ticket owner:
+// it must belong to the owner and be a loss (negative amount).
+func IsRefundable(tx *model.Transaction, ticketUserID int64) bool {
+	return tx != nil && tx.UserID == ticketUserID && tx.Amount < 0
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// TransactionLegacyPartition is the partition holding the transactions
+// recorded before the table was partitioned, up to the first monthly partition
+// which starts the month after the migration.
+const TransactionLegacyPartition = "transactions_legacy"
+
+// TransactionPartitionMonths returns the starts of the UTC months that need a
+// partition at now: the current month and monthsAhead months after it.
+func TransactionPartitionMonths(now time.Time, monthsAhead int) []time.Time {
+	now = now.UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	months := make([]time.Time, 0, monthsAhead+1)
+	for i := 0; i <= monthsAhead; i++ {
+		months = append(months, month.AddDate(0, i, 0))
+	}
+	return months
+}
+
+// TransactionPartitionMonth returns the start of the UTC month a monthly
+// partition covers, false if name is not a monthly partition.
+func TransactionPartitionMonth(name string) (time.Time, bool) {
+	var year, month int
+	if _, err := fmt.Sscanf(name, "transactions_y%04dm%02d", &year, &month); err != nil || month < 1 || month > 12 {
+		return time.Time{}, false
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	if repository.TransactionPartitionName(start) != name {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// TransactionPartitionsToArchive returns the partitions holding only
+// transactions older than the retention at now: the monthly partitions of
+// months ending before the current UTC month minus retentionMonths, and the
+// legacy partition once the first monthly partition starts before that.
+func TransactionPartitionsToArchive(names []string, now time.Time, retentionMonths int) []string {
+	now = now.UTC()
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -retentionMonths, 0)
+
+	var archive []string
+	var first time.Time
+	legacy := false
+	for _, name := range names {
+		if name == TransactionLegacyPartition {
+			legacy = true
+			continue
+		}
+		month, ok := TransactionPartitionMonth(name)
+		if !ok {
+			continue
+		}
+		if first.IsZero() || month.Before(first) {
+			first = month
+		}
+		if month.Before(cutoff) {
+			archive = append(archive, name)
+		}
+	}
+	if legacy && !first.IsZero() && !first.After(cutoff) {
+		archive = append([]string{TransactionLegacyPartition}, archive...)
+	}
+	return archive
+}
+
+// TransactionPartitionService keeps the monthly partitions of the
+// transactions table ahead of time, so inserts never miss a partition, and
+// detaches partitions past the retention so queries stop reading them. The
+// detached tables stay in the database to be dumped or dropped by operators;
+// only the daily aggregates behind /my keep counting their rows, while
+// exports, transaction lookups and refunds no longer see them.
+type TransactionPartitionService struct {
+	repo            *repository.TransactionPartitionRepository
+	monthsAhead     int
+	retentionMonths int // 0 keeps every partition
+}
+
+// NewTransactionPartitionService creates a new TransactionPartitionService instance.
+func NewTransactionPartitionService(repo *repository.TransactionPartitionRepository, monthsAhead, retentionMonths int) *TransactionPartitionService {
+	return &TransactionPartitionService{repo: repo, monthsAhead: monthsAhead, retentionMonths: retentionMonths}
+}
+
+// Run creates the partitions due at now and archives those past the retention.
+func (s *TransactionPartitionService) Run(ctx context.Context, now time.Time) {
+	legacyEnd, _, err := s.repo.LegacyEnd(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "tx_partition_create").Msg("Failed to get legacy transaction partition")
+		return
+	}
+	for _, month := range TransactionPartitionMonths(now, s.monthsAhead) {
+		// Still covered by the legacy partition
+		if month.Before(legacyEnd) {
+			continue
+		}
+		created, err := s.repo.Ensure(ctx, month)
+		if err != nil {
+			log.Error().Err(err).Str("operation", "tx_partition_create").Time("month", month).Msg("Failed to create transaction partition")
+			return
+		}
+		if created {
+			log.Info().
+				Str("operation", "tx_partition_create").
+				Str("partition", repository.TransactionPartitionName(month)).
+				Msg("Transaction partition created")
+		}
+	}
+
+	if s.retentionMonths <= 0 {
+		return
+	}
+	names, err := s.repo.List(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "tx_partition_archive").Msg("Failed to list transaction partitions")
+		return
+	}
+	for _, name := range TransactionPartitionsToArchive(names, now, s.retentionMonths) {
+		if err := s.repo.Detach(ctx, name); err != nil {
+			log.Error().Err(err).Str("operation", "tx_partition_archive").Str("partition", name).Msg("Failed to archive transaction partition")
+			return
+		}
+		log.Info().
+			Str("operation", "tx_partition_archive").
+			Str("partition", name).
+			Msg("Transaction partition archived")
+	}
+}
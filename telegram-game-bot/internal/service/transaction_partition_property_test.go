@@ -0,0 +1,84 @@
+// Package service provides business logic implementations.
+// Property-based tests for transaction partitions.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// TestTransactionPartitionMonthsProperty tests that the current month and the
+// months ahead get a partition, named so they parse back to their month.
+func TestTransactionPartitionMonthsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(rapid.Int64Range(1600000000, 2000000000).Draw(t, "now"), 0)
+		ahead := rapid.IntRange(0, 24).Draw(t, "ahead")
+
+		months := TransactionPartitionMonths(now, ahead)
+		if len(months) != ahead+1 {
+			t.Fatalf("Got %d months, want %d", len(months), ahead+1)
+		}
+		if months[0].After(now) || !months[0].AddDate(0, 1, 0).After(now) {
+			t.Fatalf("First month %v does not contain %v", months[0], now)
+		}
+		for i, month := range months {
+			if i > 0 && !month.Equal(months[i-1].AddDate(0, 1, 0)) {
+				t.Fatalf("Months %v and %v are not consecutive", months[i-1], month)
+			}
+			parsed, ok := TransactionPartitionMonth(repository.TransactionPartitionName(month))
+			if !ok || !parsed.Equal(month) {
+				t.Fatalf("Partition of %v parsed as %v, %v", month, parsed, ok)
+			}
+		}
+	})
+}
+
+// TestTransactionPartitionsToArchiveProperty tests that only partitions
+// entirely before the retention are archived, the legacy one included once
+// the monthly partitions reach back past it.
+func TestTransactionPartitionsToArchiveProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(rapid.Int64Range(1600000000, 2000000000).Draw(t, "now"), 0)
+		history := rapid.IntRange(0, 36).Draw(t, "historyMonths")
+		retention := rapid.IntRange(1, 36).Draw(t, "retentionMonths")
+
+		current := TransactionPartitionMonths(now, 0)[0]
+		names := []string{TransactionLegacyPartition, "transactions_other"}
+		for i := 0; i <= history; i++ {
+			names = append(names, repository.TransactionPartitionName(current.AddDate(0, -i, 0)))
+		}
+
+		archived := TransactionPartitionsToArchive(names, now, retention)
+
+		wantMonthly := history - retention
+		if wantMonthly < 0 {
+			wantMonthly = 0
+		}
+		legacy := history >= retention
+		want := wantMonthly
+		if legacy {
+			want++
+		}
+		if len(archived) != want {
+			t.Fatalf("Archived %v, want %d partitions (history %d, retention %d)", archived, want, history, retention)
+		}
+		cutoff := current.AddDate(0, -retention, 0)
+		for _, name := range archived {
+			if name == TransactionLegacyPartition {
+				continue
+			}
+			month, ok := TransactionPartitionMonth(name)
+			if !ok || month.AddDate(0, 1, 0).After(cutoff) {
+				t.Fatalf("Archived %s which ends after the cutoff %v", name, cutoff)
+			}
+		}
+	})
+
+	if _, ok := TransactionPartitionMonth("transactions_y2024m13"); ok {
+		t.Fatal("Month 13 accepted")
+	}
+}
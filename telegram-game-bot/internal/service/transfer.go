@@ -20,8 +20,9 @@ var (
 // TransferService handles user-to-user transfers.
 // Requirements: 2.1, 2.2, 2.3, 2.4, 2.5 - Transfer functionality
 type TransferService struct {
-	userRepo *repository.UserRepository
-	txRepo   *repository.TransactionRepository
+	userRepo    *repository.UserRepository
+	txRepo      *repository.TransactionRepository
+	idempotency *repository.IdempotencyRepository // Optional: keys of retried transfers
 }
 
 // NewTransferService creates a new TransferService instance.
@@ -35,51 +36,102 @@ func NewTransferService(
 	}
 }
 
+// SetIdempotency sets the repository of idempotency keys, enabling the
+// idemKey of Transfer.
+func (s *TransferService) SetIdempotency(repo *repository.IdempotencyRepository) {
+	s.idempotency = repo
+}
+
 // Transfer transfers coins from one user to another.
+// A non-empty idemKey moves the coins and records the transfer in one
+// database transaction with the key, so it is applied once: retrying it with
+// the same key succeeds without moving the coins again.
 // Requirements:
 // - 2.1: Transfer coins to target user
 // - 2.2: Reject if sender balance is insufficient
 // - 2.3: Reject if amount <= 0
 // - 2.4: Prevent self-transfer
 // - 2.5: Record all transfers in transaction history
-func (s *TransferService) Transfer(ctx context.Context, fromID, toID int64, amount int64) error {
-	// Validate: amount must be positive (Requirement 2.3)
+func (s *TransferService) Transfer(ctx context.Context, fromID, toID int64, amount int64, idemKey string) error {
+	if idemKey == "" {
+		_, err := s.transfer(ctx, fromID, toID, amount)
+		return err
+	}
+
 	if amount <= 0 {
 		return ErrInvalidAmount
 	}
+	if fromID == toID {
+		return ErrSelfTransfer
+	}
+	if s.idempotency == nil {
+		return ErrIdempotencyUnavailable
+	}
+
+	fingerprint := idempotencyFingerprint(fromID, toID, amount)
+	senderDesc, receiverDesc := transferDescriptions(fromID, toID)
+	record, _, err := s.idempotency.ApplyTransfer(ctx, idemKey, idempotencyOpTransfer, fingerprint,
+		fromID, toID, amount, model.TxTypeTransfer, &senderDesc, &receiverDesc)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInsufficientBalance):
+			return ErrInsufficientBalance
+		case errors.Is(err, repository.ErrUserNotFound):
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to transfer: %w", err)
+	}
+	if record != nil {
+		return checkReplay(record, idempotencyOpTransfer, fingerprint)
+	}
+	return nil
+}
+
+// transferDescriptions returns the descriptions of the sender's and the
+// receiver's transactions of a transfer.
+func transferDescriptions(fromID, toID int64) (string, string) {
+	return fmt.Sprintf("转账给用户 %d", toID), fmt.Sprintf("收到用户 %d 的转账", fromID)
+}
+
+// transfer moves the coins and returns the sender after the deduction.
+func (s *TransferService) transfer(ctx context.Context, fromID, toID int64, amount int64) (*model.User, error) {
+	// Validate: amount must be positive (Requirement 2.3)
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
 
 	// Validate: cannot transfer to self (Requirement 2.4)
 	if fromID == toID {
-		return ErrSelfTransfer
+		return nil, ErrSelfTransfer
 	}
 
 	// Get sender to check balance
 	sender, err := s.userRepo.GetByID(ctx, fromID)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
-			return ErrUserNotFound
+			return nil, ErrUserNotFound
 		}
-		return fmt.Errorf("failed to get sender: %w", err)
+		return nil, fmt.Errorf("failed to get sender: %w", err)
 	}
 
 	// Validate: sender must have sufficient balance (Requirement 2.2)
 	if sender.Balance < amount {
-		return ErrInsufficientBalance
+		return nil, ErrInsufficientBalance
 	}
 
 	// Verify receiver exists
 	_, err = s.userRepo.GetByID(ctx, toID)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
-			return ErrUserNotFound
+			return nil, ErrUserNotFound
 		}
-		return fmt.Errorf("failed to get receiver: %w", err)
+		return nil, fmt.Errorf("failed to get receiver: %w", err)
 	}
 
 	// Deduct from sender (Requirement 2.1)
-	_, err = s.userRepo.UpdateBalance(ctx, fromID, -amount)
+	sender, err = s.userRepo.UpdateBalance(ctx, fromID, -amount)
 	if err != nil {
-		return fmt.Errorf("failed to deduct from sender: %w", err)
+		return nil, fmt.Errorf("failed to deduct from sender: %w", err)
 	}
 
 	// Add to receiver (Requirement 2.1)
@@ -87,17 +139,15 @@ func (s *TransferService) Transfer(ctx context.Context, fromID, toID int64, amou
 	if err != nil {
 		// Try to rollback sender's balance
 		_, _ = s.userRepo.UpdateBalance(ctx, fromID, amount)
-		return fmt.Errorf("failed to add to receiver: %w", err)
+		return nil, fmt.Errorf("failed to add to receiver: %w", err)
 	}
 
 	// Record transactions (Requirement 2.5)
-	senderDesc := fmt.Sprintf("转账给用户 %d", toID)
-	receiverDesc := fmt.Sprintf("收到用户 %d 的转账", fromID)
+	senderDesc, receiverDesc := transferDescriptions(fromID, toID)
 
-	_, _ = s.txRepo.Create(ctx, fromID, -amount, model.TxTypeTransfer, &senderDesc)
-	_, _ = s.txRepo.Create(ctx, toID, amount, model.TxTypeTransfer, &receiverDesc)
+	_, _, _ = s.txRepo.CreateTransfer(ctx, fromID, toID, amount, model.TxTypeTransfer, model.TxTypeTransfer, &senderDesc, &receiverDesc)
 
-	return nil
+	return sender, nil
 }
 
 // ValidateTransfer validates a transfer without executing it.
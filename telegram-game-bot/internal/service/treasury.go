@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/game/allin"
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Treasury flow categories
+const (
+	TreasuryGames    = "games"    // Bets and payouts of house games
+	TreasuryShop     = "shop"     // Item purchases, sell-backs and paid protection
+	TreasuryIssuance = "issuance" // Coins created or destroyed by the bot and admins
+	TreasuryPeer     = "peer"     // Coins moved between players, nets to zero
+	TreasuryOther    = "other"    // Types without a category
+)
+
+// TreasuryCategories lists the categories in report order.
+var TreasuryCategories = []string{TreasuryGames, TreasuryShop, TreasuryIssuance, TreasuryPeer, TreasuryOther}
+
+// treasuryCategoryByType maps transaction types to their treasury category
+var treasuryCategoryByType = map[string]string{
+	model.TxTypeDice:        TreasuryGames,
+	model.TxTypeDiceInsure:  TreasuryGames,
+	model.TxTypeSlot:        TreasuryGames,
+	model.TxTypeBlackjack:   TreasuryGames,
+	model.TxTypeSicBoBet:    TreasuryGames,
+	model.TxTypeSicBoWin:    TreasuryGames,
+	model.TxTypeFreeSpin:    TreasuryGames,
+	model.TxTypeHeistBuyIn:  TreasuryGames,
+	model.TxTypeHeistWin:    TreasuryGames,
+	model.TxTypeHeistRefund: TreasuryGames,
+	model.TxTypePoolBet:     TreasuryGames,
+	model.TxTypePoolWin:     TreasuryGames,
+	model.TxTypePoolRefund:  TreasuryGames,
+	allin.TxTypeDiceWin:     TreasuryGames,
+	allin.TxTypeDiceLose:    TreasuryGames,
+
+	model.TxTypeShopPurchase: TreasuryShop,
+	model.TxTypeSellBack:     TreasuryShop,
+	model.TxTypeRobProtect:   TreasuryShop,
+	model.TxTypeStruggleFee:  TreasuryShop,
+
+	model.TxTypeInitial:      TreasuryIssuance,
+	model.TxTypeDaily:        TreasuryIssuance,
+	model.TxTypeAdminAdd:     TreasuryIssuance,
+	model.TxTypeAdminSub:     TreasuryIssuance,
+	model.TxTypeAdminSet:     TreasuryIssuance,
+	model.TxTypePromoRedeem:  TreasuryIssuance,
+	model.TxTypeRefund:       TreasuryIssuance,
+	model.TxTypeCompensation: TreasuryIssuance,
+	model.TxTypeRaidPrize:    TreasuryIssuance,
+	model.TxTypeReversal:     TreasuryIssuance,
+	model.TxTypeBailout:      TreasuryIssuance,
+	model.TxTypeComeback:     TreasuryIssuance,
+
+	model.TxTypeTransfer:     TreasuryPeer,
+	model.TxTypeRob:          TreasuryPeer,
+	model.TxTypeRobbed:       TreasuryPeer,
+	rob.TxTypeCounterAttack:  TreasuryPeer,
+	model.TxTypeRobInsureCut: TreasuryPeer,
+	model.TxTypeRobInsurePay: TreasuryPeer,
+	allin.TxTypeAllInRobWin:  TreasuryPeer,
+	allin.TxTypeAllInRobLose: TreasuryPeer,
+	allin.TxTypeDuelWin:      TreasuryPeer,
+	allin.TxTypeDuelLose:     TreasuryPeer,
+	allin.TxTypeDuelEscrow:   TreasuryPeer,
+}
+
+// TreasuryCategory returns the treasury category of a transaction type.
+func TreasuryCategory(txType string) string {
+	if category, ok := treasuryCategoryByType[txType]; ok {
+		return category
+	}
+	return TreasuryOther
+}
+
+// TreasuryFlows is the treasury's net income per category over a period.
+// Positive values are coins the house took in.
+type TreasuryFlows map[string]int64
+
+// SummarizeTreasuryFlows turns the net user amounts per transaction type into
+// the treasury's income per category.
+func SummarizeTreasuryFlows(byType map[string]int64) TreasuryFlows {
+	flows := make(TreasuryFlows)
+	for txType, amount := range byType {
+		flows[TreasuryCategory(txType)] -= amount
+	}
+	return flows
+}
+
+// HousePnL returns the house profit: game and shop income.
+// Issuance and player-to-player flows are not earned by the house.
+func (f TreasuryFlows) HousePnL() int64 {
+	return f[TreasuryGames] + f[TreasuryShop]
+}
+
+// Total returns the treasury's net income over all categories.
+func (f TreasuryFlows) Total() int64 {
+	var total int64
+	for _, amount := range f {
+		total += amount
+	}
+	return total
+}
+
+// TreasuryReport is the house account overview shown by /treasury.
+type TreasuryReport struct {
+	Snapshot *model.TreasurySnapshot
+	Today    TreasuryFlows // Since local midnight
+	Week     TreasuryFlows // Last 7 days
+}
+
+// Drift returns how far user balances plus treasury are off zero.
+// Non-zero means a balance changed without a booked transaction.
+func (r *TreasuryReport) Drift() int64 {
+	return r.Snapshot.Balance + r.Snapshot.UsersTotal
+}
+
+// TreasuryService reports on the house account and checks that every balance
+// change was booked against it.
+type TreasuryService struct {
+	repo     *repository.TreasuryRepository
+	timezone *time.Location
+}
+
+// NewTreasuryService creates a new TreasuryService.
+// Days of the report start at midnight in timezone (UTC if nil).
+func NewTreasuryService(repo *repository.TreasuryRepository, timezone *time.Location) *TreasuryService {
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	return &TreasuryService{repo: repo, timezone: timezone}
+}
+
+// Report builds the treasury report at now.
+func (s *TreasuryService) Report(ctx context.Context, now time.Time) (*TreasuryReport, error) {
+	snapshot, err := s.repo.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	local := now.In(s.timezone)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.timezone)
+	today, err := s.repo.FlowsByType(ctx, midnight)
+	if err != nil {
+		return nil, err
+	}
+	week, err := s.repo.FlowsByType(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TreasuryReport{
+		Snapshot: snapshot,
+		Today:    SummarizeTreasuryFlows(today),
+		Week:     SummarizeTreasuryFlows(week),
+	}, nil
+}
+
+// CheckInvariant verifies that user balances plus treasury are zero and logs
+// an error otherwise. Returns the drift.
+func (s *TreasuryService) CheckInvariant(ctx context.Context) (int64, error) {
+	snapshot, err := s.repo.Snapshot(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	drift := snapshot.Balance + snapshot.UsersTotal
+	if drift != 0 {
+		log.Error().
+			Str("operation", "treasury_audit").
+			Int64("treasury", snapshot.Balance).
+			Int64("users_total", snapshot.UsersTotal).
+			Int64("drift", drift).
+			Msg("Treasury invariant violated: balances changed without transactions")
+	}
+	return drift, nil
+}
@@ -0,0 +1,81 @@
+// Package service provides business logic implementations.
+// Property-based tests for treasury accounting.
+package service
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/game/allin"
+	"telegram-game-bot/internal/game/rob"
+	"telegram-game-bot/internal/model"
+)
+
+// treasuryTestTypes are transaction types drawn by the treasury tests
+var treasuryTestTypes = []string{
+	model.TxTypeDice, model.TxTypeSlot, model.TxTypeSicBoBet, model.TxTypeSicBoWin,
+	model.TxTypeShopPurchase, model.TxTypeSellBack, model.TxTypeDaily, model.TxTypeInitial,
+	model.TxTypeTransfer, model.TxTypeRob, model.TxTypeRobbed, rob.TxTypeCounterAttack,
+	allin.TxTypeDuelWin, allin.TxTypeDuelLose, "unknown_type",
+}
+
+// TestTreasuryFlowsProperty tests that the treasury's income over all
+// categories is exactly what users lost, and that the house P&L only counts
+// games and the shop.
+func TestTreasuryFlowsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		byType := rapid.MapOf(
+			rapid.SampledFrom(treasuryTestTypes),
+			rapid.Int64Range(-1_000_000, 1_000_000),
+		).Draw(t, "byType")
+
+		var usersNet, houseUsersNet int64
+		for txType, amount := range byType {
+			usersNet += amount
+			if c := TreasuryCategory(txType); c == TreasuryGames || c == TreasuryShop {
+				houseUsersNet += amount
+			}
+		}
+
+		flows := SummarizeTreasuryFlows(byType)
+		if flows.Total() != -usersNet {
+			t.Fatalf("Treasury total %d, users net %d", flows.Total(), usersNet)
+		}
+		if flows.HousePnL() != -houseUsersNet {
+			t.Fatalf("House P&L %d, users lost %d to games and shop", flows.HousePnL(), -houseUsersNet)
+		}
+	})
+}
+
+// TestTreasuryPeerFlowsNetZeroProperty tests that coins moved between players
+// leave the treasury untouched.
+func TestTreasuryPeerFlowsNetZeroProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		amount := rapid.Int64Range(1, 1_000_000).Draw(t, "amount")
+		pairs := [][2]string{
+			{model.TxTypeRob, model.TxTypeRobbed},
+			{model.TxTypeRob, rob.TxTypeCounterAttack},
+			{allin.TxTypeDuelWin, allin.TxTypeDuelLose},
+			{allin.TxTypeAllInRobWin, allin.TxTypeAllInRobLose},
+		}
+		pair := rapid.SampledFrom(pairs).Draw(t, "pair")
+
+		flows := SummarizeTreasuryFlows(map[string]int64{pair[0]: amount, pair[1]: -amount})
+		if flows[TreasuryPeer] != 0 || flows.Total() != 0 {
+			t.Fatalf("Peer pair %v moved the treasury: %v", pair, flows)
+		}
+	})
+}
+
+// TestTreasuryReportDrift tests the invariant check of the report.
+func TestTreasuryReportDrift(t *testing.T) {
+	report := &TreasuryReport{Snapshot: &model.TreasurySnapshot{Balance: -5000, UsersTotal: 5000}}
+	if drift := report.Drift(); drift != 0 {
+		t.Fatalf("Balanced books have drift %d", drift)
+	}
+	report.Snapshot.UsersTotal += 100
+	if drift := report.Drift(); drift != 100 {
+		t.Fatalf("Unbooked credit of 100 has drift %d", drift)
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// TxSearchPageSize is the number of transactions on a page of /findtx
+const TxSearchPageSize = 10
+
+// Transaction search errors
+var (
+	ErrTxSearchFilter = errors.New("筛选条件格式错误，应为 user=用户ID|#编号 type=类型 min=金额 since=24h|7d|2006-01-02 before=交易ID")
+	ErrTxSearchUser   = errors.New("找不到该用户")
+)
+
+// txTypePattern matches transaction type names
+var txTypePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// TxSearchQuery is a parsed /findtx search. Zero fields do not filter.
+type TxSearchQuery struct {
+	UserID    int64
+	Handle    string // #A1B2C3 of the user, resolved by Search
+	Type      string // A transaction type, or the class "game" or "pvp"
+	MinAmount int64  // Smallest absolute amount
+	Since     time.Time
+	BeforeID  int64 // Page cursor: the last transaction of the previous page
+}
+
+// TxSearchPage is a page of search results.
+type TxSearchPage struct {
+	Transactions []*model.Transaction // Newest first
+	NextBeforeID int64                // Cursor of the next page, 0 on the last page
+}
+
+// ParseTxSearch parses /findtx arguments of the form key=value:
+// user=<id|#handle>, type=<type|game|pvp>, min=<amount>,
+// since=<24h|7d|2006-01-02|2006-01-02T15:04> and before=<transaction id>.
+func ParseTxSearch(args []string, now time.Time) (TxSearchQuery, error) {
+	var q TxSearchQuery
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return TxSearchQuery{}, ErrTxSearchFilter
+		}
+
+		switch strings.ToLower(key) {
+		case "user":
+			if handle, ok := model.ParseHandle(value); ok {
+				q.Handle = handle
+				continue
+			}
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				return TxSearchQuery{}, ErrTxSearchFilter
+			}
+			q.UserID = id
+		case "type":
+			txType := strings.ToLower(value)
+			if !txTypePattern.MatchString(txType) {
+				return TxSearchQuery{}, ErrTxSearchFilter
+			}
+			q.Type = txType
+		case "min":
+			amount, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || amount <= 0 {
+				return TxSearchQuery{}, ErrTxSearchFilter
+			}
+			q.MinAmount = amount
+		case "since":
+			since, err := parseTxSearchSince(value, now)
+			if err != nil {
+				return TxSearchQuery{}, err
+			}
+			q.Since = since
+		case "before":
+			id, err := strconv.ParseInt(strings.TrimPrefix(value, "#"), 10, 64)
+			if err != nil || id <= 0 {
+				return TxSearchQuery{}, ErrTxSearchFilter
+			}
+			q.BeforeID = id
+		default:
+			return TxSearchQuery{}, ErrTxSearchFilter
+		}
+	}
+	return q, nil
+}
+
+// Layouts of absolute since filters; relative ages are written back as txSearchMinuteLayout
+const (
+	txSearchDayLayout    = "2006-01-02"
+	txSearchMinuteLayout = "2006-01-02T15:04"
+)
+
+// parseTxSearchSince parses a relative age such as "24h" or "7d", rounded
+// down to the minute, or a date with an optional time
+func parseTxSearchSince(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{txSearchDayLayout, txSearchMinuteLayout} {
+		if since, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return since, nil
+		}
+	}
+	age, err := ParsePromoDuration(value)
+	if err != nil || age == 0 {
+		return time.Time{}, ErrTxSearchFilter
+	}
+	return now.Add(-age).Truncate(time.Minute), nil
+}
+
+// Args formats the query back into /findtx arguments, e.g. for the next page.
+func (q TxSearchQuery) Args() []string {
+	var args []string
+	switch {
+	case q.Handle != "":
+		args = append(args, "user="+q.Handle)
+	case q.UserID != 0:
+		args = append(args, "user="+strconv.FormatInt(q.UserID, 10))
+	}
+	if q.Type != "" {
+		args = append(args, "type="+q.Type)
+	}
+	if q.MinAmount > 0 {
+		args = append(args, "min="+strconv.FormatInt(q.MinAmount, 10))
+	}
+	if !q.Since.IsZero() {
+		args = append(args, "since="+q.Since.Format(txSearchMinuteLayout))
+	}
+	if q.BeforeID > 0 {
+		args = append(args, "before="+strconv.FormatInt(q.BeforeID, 10))
+	}
+	return args
+}
+
+// types returns the transaction types a query's type filter stands for
+func (q TxSearchQuery) types() []string {
+	switch q.Type {
+	case "":
+		return nil
+	case model.TxClassGame, model.TxClassPvP:
+		return model.TransactionTypesOf(q.Type)
+	default:
+		return []string{q.Type}
+	}
+}
+
+// TxSearchService lets admins search the transactions for support investigations.
+type TxSearchService struct {
+	txRepo   *repository.TransactionRepository
+	userRepo *repository.UserRepository
+}
+
+// NewTxSearchService creates a new TxSearchService instance.
+func NewTxSearchService(txRepo *repository.TransactionRepository, userRepo *repository.UserRepository) *TxSearchService {
+	return &TxSearchService{txRepo: txRepo, userRepo: userRepo}
+}
+
+// Search returns a page of the transactions matching a query, newest first.
+func (s *TxSearchService) Search(ctx context.Context, q TxSearchQuery) (*TxSearchPage, error) {
+	userID := q.UserID
+	if q.Handle != "" {
+		user, err := s.userRepo.GetByHandle(ctx, q.Handle)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return nil, ErrTxSearchUser
+			}
+			return nil, err
+		}
+		userID = user.TelegramID
+	}
+
+	// One extra row tells whether there is a next page
+	transactions, err := s.txRepo.Search(ctx, repository.TransactionFilter{
+		UserID:    userID,
+		Types:     q.types(),
+		MinAmount: q.MinAmount,
+		Since:     q.Since,
+		BeforeID:  q.BeforeID,
+		Limit:     TxSearchPageSize + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TxSearchPage{Transactions: transactions}
+	if len(transactions) > TxSearchPageSize {
+		page.Transactions = transactions[:TxSearchPageSize]
+		page.NextBeforeID = page.Transactions[TxSearchPageSize-1].ID
+	}
+	return page, nil
+}
@@ -0,0 +1,80 @@
+// Package service provides business logic implementations.
+// Property-based tests for transaction search.
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestTxSearchArgsRoundTripProperty tests that a query written back with Args,
+// e.g. as the next page command, parses to the same query.
+func TestTxSearchArgsRoundTripProperty(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 30, 45, 0, time.Local)
+	rapid.Check(t, func(t *rapid.T) {
+		var q TxSearchQuery
+		switch rapid.IntRange(0, 2).Draw(t, "user") {
+		case 1:
+			q.UserID = rapid.Int64Range(1, 1<<40).Draw(t, "userID")
+		case 2:
+			q.Handle = "#" + rapid.StringMatching(`[0-9A-F]{6}`).Draw(t, "handle")
+		}
+		q.Type = rapid.SampledFrom([]string{"", model.TxTypeDice, model.TxTypeTransfer, model.TxClassGame}).Draw(t, "type")
+		q.MinAmount = rapid.Int64Range(0, 1_000_000).Draw(t, "min")
+		if rapid.Bool().Draw(t, "hasSince") {
+			age := time.Duration(rapid.IntRange(1, 60*24*30).Draw(t, "ageMinutes")) * time.Minute
+			q.Since = now.Add(-age).Truncate(time.Minute)
+		}
+		q.BeforeID = rapid.Int64Range(0, 1<<40).Draw(t, "before")
+
+		parsed, err := ParseTxSearch(q.Args(), now)
+		if err != nil {
+			t.Fatalf("ParseTxSearch(%q) failed: %v", q.Args(), err)
+		}
+		if !parsed.Since.Equal(q.Since) {
+			t.Fatalf("since = %v, want %v", parsed.Since, q.Since)
+		}
+		parsed.Since, q.Since = time.Time{}, time.Time{}
+		if parsed != q {
+			t.Fatalf("parsed %+v, want %+v", parsed, q)
+		}
+	})
+}
+
+// TestTxSearchRelativeSinceProperty tests that relative ages count back from
+// now, rounded down to the minute.
+func TestTxSearchRelativeSinceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(rapid.Int64Range(1e9, 2e9).Draw(t, "now"), 0)
+		days := rapid.IntRange(1, 365).Draw(t, "days")
+
+		q, err := ParseTxSearch([]string{"since=" + strconv.Itoa(days) + "d"}, now)
+		if err != nil {
+			t.Fatalf("since=%dd failed: %v", days, err)
+		}
+		want := now.Add(-time.Duration(days) * 24 * time.Hour).Truncate(time.Minute)
+		if !q.Since.Equal(want) {
+			t.Fatalf("since = %v, want %v", q.Since, want)
+		}
+	})
+}
+
+// TestTxSearchInvalidFiltersProperty tests that malformed filters are rejected.
+func TestTxSearchInvalidFiltersProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		arg := rapid.SampledFrom([]string{
+			"user", "user=", "user=abc", "user=-5", "type=DROP TABLE", "min=0", "min=x",
+			"since=yesterday", "since=0", "before=-1", "amount=5", "dice",
+		}).Draw(t, "arg")
+
+		if _, err := ParseTxSearch([]string{"type=dice", arg}, time.Now()); !errors.Is(err, ErrTxSearchFilter) {
+			t.Fatalf("ParseTxSearch(%q) error = %v, want ErrTxSearchFilter", arg, err)
+		}
+	})
+}
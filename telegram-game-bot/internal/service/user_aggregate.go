@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/repository"
+)
+
+// Profit aggregate settings
+const (
+	ProfitWeekDays         = 7  // Days of the rolling profit, today included
+	aggregateMaxDaysPerRun = 31 // Days aggregated per run, so a backfill is spread over runs
+)
+
+// ProfitSummary is a user's net game profit over several periods.
+type ProfitSummary struct {
+	Today    int64
+	Week     int64 // Last ProfitWeekDays days, today included
+	Lifetime int64
+}
+
+// AggregateDaysDue returns the midnights in loc of the finished days to
+// aggregate at now, at most max of them: the days after through, the last
+// aggregated date (ok is false if none), or else the days from the one of the
+// first transaction.
+func AggregateDaysDue(through time.Time, ok bool, first, now time.Time, loc *time.Location, max int) []time.Time {
+	var day time.Time
+	if ok {
+		day = time.Date(through.Year(), through.Month(), through.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	} else {
+		first = first.In(loc)
+		day = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	}
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var days []time.Time
+	for day.Before(today) && len(days) < max {
+		days = append(days, day)
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
+
+// SetAggregates makes profit stats read finished days from the daily
+// aggregates filled by RunAggregation instead of scanning transactions.
+func (s *RankingService) SetAggregates(aggregates *repository.UserAggregateRepository) {
+	s.aggregates = aggregates
+}
+
+// RunAggregation aggregates the game profit of the finished days not yet
+// aggregated, oldest first.
+func (s *RankingService) RunAggregation(ctx context.Context, now time.Time) {
+	if s.aggregates == nil {
+		return
+	}
+
+	through, ok, err := s.aggregates.AggregatedThrough(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("operation", "profit_aggregate").Msg("Failed to get aggregate watermark")
+		return
+	}
+	var first time.Time
+	if !ok {
+		var found bool
+		first, found, err = s.txRepo.GetFirstTransactionTime(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("operation", "profit_aggregate").Msg("Failed to get first transaction")
+			return
+		}
+		if !found {
+			return
+		}
+	}
+
+	days := AggregateDaysDue(through, ok, first, now, s.timezone, aggregateMaxDaysPerRun)
+	for _, day := range days {
+		if err := s.aggregates.AggregateDay(ctx, day, day.AddDate(0, 0, 1)); err != nil {
+			log.Error().Err(err).Str("operation", "profit_aggregate").Time("day", day).Msg("Failed to aggregate day")
+			return
+		}
+	}
+	if len(days) > 0 {
+		log.Info().
+			Str("operation", "profit_aggregate").
+			Int("days", len(days)).
+			Time("through", days[len(days)-1]).
+			Msg("Daily profit aggregated")
+	}
+}
+
+// GetUserProfitSummary returns a user's profit of today, the rolling week and
+// all time. Finished days are read from the aggregates when available, the
+// days after them from transactions.
+func (s *RankingService) GetUserProfitSummary(ctx context.Context, userID int64) (*ProfitSummary, error) {
+	now := time.Now().In(s.timezone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.timezone)
+
+	var summary ProfitSummary
+	var err error
+	if summary.Today, err = s.txRepo.GetUserDailyProfit(ctx, userID, now); err != nil {
+		return nil, err
+	}
+
+	through, ok := s.aggregatedThrough(ctx)
+	if summary.Week, err = s.profitSince(ctx, userID, today.AddDate(0, 0, 1-ProfitWeekDays), through, ok); err != nil {
+		return nil, err
+	}
+	if summary.Lifetime, err = s.profitSince(ctx, userID, time.Time{}, through, ok); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// aggregatedThrough returns the midnight of the last aggregated day, false if
+// profit must be read from transactions only
+func (s *RankingService) aggregatedThrough(ctx context.Context) (time.Time, bool) {
+	if s.aggregates == nil {
+		return time.Time{}, false
+	}
+	through, ok, err := s.aggregates.AggregatedThrough(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get aggregate watermark, reading transactions")
+		return time.Time{}, false
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Date(through.Year(), through.Month(), through.Day(), 0, 0, 0, 0, s.timezone), true
+}
+
+// profitSince sums a user's profit from the midnight from (the zero time for
+// all time) up to now: the aggregated days up to through, if any, plus the
+// transactions after them
+func (s *RankingService) profitSince(ctx context.Context, userID int64, from, through time.Time, ok bool) (int64, error) {
+	if !ok || through.Before(from) {
+		return s.txRepo.GetUserProfitSince(ctx, userID, from)
+	}
+
+	aggregated, err := s.aggregates.GetUserProfit(ctx, userID, from, through)
+	if err != nil {
+		log.Warn().Err(err).Int64("user_id", userID).Msg("Failed to read aggregated profit, reading transactions")
+		return s.txRepo.GetUserProfitSince(ctx, userID, from)
+	}
+	recent, err := s.txRepo.GetUserProfitSince(ctx, userID, through.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+	return aggregated + recent, nil
+}
@@ -0,0 +1,58 @@
+// Package service provides business logic implementations.
+// Property-based tests for daily profit aggregates.
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// TestAggregateDaysDueProperty tests that only finished days after the
+// watermark are aggregated, consecutive and capped per run.
+func TestAggregateDaysDueProperty(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		loc = time.FixedZone("CST", 8*3600)
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Unix(rapid.Int64Range(1700000000, 1800000000).Draw(t, "now"), 0)
+		lag := rapid.IntRange(0, 60).Draw(t, "lagDays")
+		ok := rapid.Bool().Draw(t, "aggregated")
+		max := rapid.IntRange(1, 40).Draw(t, "max")
+
+		local := now.In(loc)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		through := time.Date(today.Year(), today.Month(), today.Day()-lag, 0, 0, 0, 0, time.UTC)
+		first := today.AddDate(0, 0, -lag).Add(time.Duration(rapid.IntRange(0, 86399).Draw(t, "firstSecond")) * time.Second)
+
+		days := AggregateDaysDue(through, ok, first, now, loc, max)
+
+		want := lag
+		if ok {
+			want = lag - 1
+		}
+		if want < 0 {
+			want = 0
+		}
+		if want > max {
+			want = max
+		}
+		if len(days) != want {
+			t.Fatalf("Got %d days, want %d (lag %d, aggregated %v, max %d)", len(days), want, lag, ok, max)
+		}
+		for i, day := range days {
+			if !day.Before(today) {
+				t.Fatalf("Day %v is not finished at %v", day, now)
+			}
+			if day.Hour() != 0 || day.Minute() != 0 || day.Location() != loc {
+				t.Fatalf("Day %v is not a midnight in %v", day, loc)
+			}
+			if i > 0 && !day.Equal(days[i-1].AddDate(0, 0, 1)) {
+				t.Fatalf("Days %v and %v are not consecutive", days[i-1], day)
+			}
+		}
+	})
+}
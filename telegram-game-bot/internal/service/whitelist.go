@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"telegram-game-bot/internal/model"
+	"telegram-game-bot/internal/repository"
+)
+
+// Whitelist errors
+var (
+	ErrWhitelistAlreadyAllowed = errors.New("该群已在白名单中")
+	ErrWhitelistNotAllowed     = errors.New("该群不在白名单中")
+	ErrWhitelistLastChat       = errors.New("不能移除最后一个白名单群，白名单为空时所有群都可使用")
+)
+
+// WhitelistChat is a chat of the whitelist as shown by /whitelist list.
+type WhitelistChat struct {
+	ChatID     int64
+	Configured bool  // Listed in the config file
+	Allowed    bool  // False for configured chats removed by an admin
+	UpdatedBy  int64 // Admin who added or removed the chat, 0 if only configured
+}
+
+// WhitelistChats returns the allowed chats: the configured ones plus those
+// added by admins, minus those removed. Entries override the config.
+func WhitelistChats(configured []int64, entries []model.ChatWhitelistEntry) map[int64]bool {
+	chats := make(map[int64]bool, len(configured)+len(entries))
+	for _, id := range configured {
+		chats[id] = true
+	}
+	for _, entry := range entries {
+		if entry.Allowed {
+			chats[entry.ChatID] = true
+		} else {
+			delete(chats, entry.ChatID)
+		}
+	}
+	return chats
+}
+
+// WhitelistService merges the configured whitelist with the chats admins add
+// or remove with /whitelist. Like the config file, an empty whitelist allows
+// every chat, so the last allowed chat cannot be removed. The entries are
+// cached in memory since they are read on every update, and reloaded
+// periodically so changes made on another instance apply without a restart.
+type WhitelistService struct {
+	repo          *repository.ChatWhitelistRepository
+	configured    []int64
+	supportChatID int64 // Always allowed so admins can resolve tickets there
+
+	mu      sync.Mutex
+	entries map[int64]model.ChatWhitelistEntry // nil until loaded
+	chats   map[int64]bool                     // Allowed chats, from configured and entries
+}
+
+// NewWhitelistService creates a new WhitelistService instance.
+// configured are the chats of the config file; supportChatID (0 = none) is always allowed.
+func NewWhitelistService(repo *repository.ChatWhitelistRepository, configured []int64, supportChatID int64) *WhitelistService {
+	return &WhitelistService{repo: repo, configured: configured, supportChatID: supportChatID}
+}
+
+// IsChatAllowed reports whether the bot operates in a chat.
+// Only the configured whitelist applies while the entries cannot be loaded.
+func (s *WhitelistService) IsChatAllowed(chatID int64) bool {
+	if s.supportChatID != 0 && chatID == s.supportChatID {
+		return true
+	}
+	chats := s.allowed(context.Background())
+	return len(chats) == 0 || chats[chatID]
+}
+
+// Restricted reports whether only whitelisted chats are allowed.
+func (s *WhitelistService) Restricted() bool {
+	return len(s.allowed(context.Background())) > 0
+}
+
+// Chats returns the allowed chats in ascending order, none if every chat is allowed.
+func (s *WhitelistService) Chats(ctx context.Context) []int64 {
+	allowed := s.allowed(ctx)
+	chats := make([]int64, 0, len(allowed))
+	for chatID := range allowed {
+		chats = append(chats, chatID)
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i] < chats[j] })
+	return chats
+}
+
+// List returns the configured chats and the added ones in ascending order
+// of chat ID, including configured chats that were removed.
+func (s *WhitelistService) List(ctx context.Context) ([]WhitelistChat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.loadLocked(ctx)
+
+	chats := make(map[int64]*WhitelistChat)
+	for _, id := range s.configured {
+		chats[id] = &WhitelistChat{ChatID: id, Configured: true, Allowed: true}
+	}
+	for id, entry := range s.entries {
+		chat, ok := chats[id]
+		if !ok {
+			if !entry.Allowed {
+				continue
+			}
+			chat = &WhitelistChat{ChatID: id}
+			chats[id] = chat
+		}
+		chat.Allowed = entry.Allowed
+		chat.UpdatedBy = entry.UpdatedBy
+	}
+
+	list := make([]WhitelistChat, 0, len(chats))
+	for _, chat := range chats {
+		list = append(list, *chat)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ChatID < list[j].ChatID })
+	return list, err
+}
+
+// Add allows a chat. Re-adding a removed configured chat restores it.
+func (s *WhitelistService) Add(ctx context.Context, chatID, adminID int64) error {
+	if s.allowed(ctx)[chatID] {
+		return ErrWhitelistAlreadyAllowed
+	}
+	return s.set(ctx, chatID, adminID, true)
+}
+
+// Remove stops the bot in a chat, including a configured one.
+func (s *WhitelistService) Remove(ctx context.Context, chatID, adminID int64) error {
+	chats := s.allowed(ctx)
+	if !chats[chatID] {
+		return ErrWhitelistNotAllowed
+	}
+	if len(chats) == 1 {
+		return ErrWhitelistLastChat
+	}
+	return s.set(ctx, chatID, adminID, false)
+}
+
+// set stores whether a chat is allowed and updates the cache
+func (s *WhitelistService) set(ctx context.Context, chatID, adminID int64, allowed bool) error {
+	entry := &model.ChatWhitelistEntry{ChatID: chatID, Allowed: allowed, UpdatedBy: adminID}
+	if err := s.repo.Upsert(ctx, entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.entries != nil {
+		s.entries[chatID] = *entry
+		s.chats = whitelistChatsOf(s.configured, s.entries)
+	}
+	s.mu.Unlock()
+
+	operation := "whitelist_add"
+	if !allowed {
+		operation = "whitelist_remove"
+	}
+	log.Info().
+		Int64("chat_id", chatID).
+		Int64("admin_id", adminID).
+		Str("operation", operation).
+		Msg("Chat whitelist changed")
+	return nil
+}
+
+// Refresh reloads the entries, picking up changes made on other instances.
+func (s *WhitelistService) Refresh(ctx context.Context) error {
+	entries, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(entries)
+	return nil
+}
+
+// allowed returns the allowed chats, only the configured ones while the
+// entries cannot be loaded. The map must not be modified.
+func (s *WhitelistService) allowed(ctx context.Context) map[int64]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to load chat whitelist")
+		return WhitelistChats(s.configured, nil)
+	}
+	return s.chats
+}
+
+// loadLocked fills the entry cache if needed; the caller must hold s.mu
+func (s *WhitelistService) loadLocked(ctx context.Context) error {
+	if s.entries != nil {
+		return nil
+	}
+	entries, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.store(entries)
+	return nil
+}
+
+// store replaces the cached entries; the caller must hold s.mu
+func (s *WhitelistService) store(entries []model.ChatWhitelistEntry) {
+	s.entries = make(map[int64]model.ChatWhitelistEntry, len(entries))
+	for _, entry := range entries {
+		s.entries[entry.ChatID] = entry
+	}
+	s.chats = whitelistChatsOf(s.configured, s.entries)
+}
+
+// whitelistChatsOf returns the allowed chats of cached entries
+func whitelistChatsOf(configured []int64, entries map[int64]model.ChatWhitelistEntry) map[int64]bool {
+	list := make([]model.ChatWhitelistEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	return WhitelistChats(configured, list)
+}
@@ -0,0 +1,93 @@
+// Package service provides business logic implementations.
+// Property-based tests for the chat whitelist.
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"telegram-game-bot/internal/model"
+)
+
+// TestWhitelistChatsProperty tests that entries override the configured
+// chats: a chat is allowed if its entry allows it, or if it has no entry and
+// is configured.
+func TestWhitelistChatsProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		chatID := rapid.Int64Range(-5, 5)
+		configured := rapid.SliceOfDistinct(chatID, func(id int64) int64 { return id }).Draw(t, "configured")
+		entries := rapid.SliceOfNDistinct(rapid.Custom(func(t *rapid.T) model.ChatWhitelistEntry {
+			return model.ChatWhitelistEntry{ChatID: chatID.Draw(t, "chat"), Allowed: rapid.Bool().Draw(t, "allowed")}
+		}), 0, 8, func(e model.ChatWhitelistEntry) int64 { return e.ChatID }).Draw(t, "entries")
+
+		chats := WhitelistChats(configured, entries)
+		for id := int64(-5); id <= 5; id++ {
+			want := false
+			for _, c := range configured {
+				want = want || c == id
+			}
+			for _, e := range entries {
+				if e.ChatID == id {
+					want = e.Allowed
+				}
+			}
+			if chats[id] != want {
+				t.Fatalf("chat %d allowed = %v, want %v (configured %v, entries %v)", id, chats[id], want, configured, entries)
+			}
+		}
+	})
+}
+
+// TestWhitelistServiceProperty tests that an empty whitelist allows every
+// chat, that the support chat is always allowed, and that the last allowed
+// chat cannot be removed.
+func TestWhitelistServiceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		chatID := rapid.Int64Range(-5, 5)
+		configured := rapid.SliceOfDistinct(chatID, func(id int64) int64 { return id }).Draw(t, "configured")
+		removed := rapid.SliceOfDistinct(chatID, func(id int64) int64 { return id }).Draw(t, "removed")
+
+		s := &WhitelistService{configured: configured, supportChatID: 100}
+		var entries []model.ChatWhitelistEntry
+		for _, id := range removed {
+			entries = append(entries, model.ChatWhitelistEntry{ChatID: id})
+		}
+		s.store(entries)
+
+		allowed := s.Chats(context.Background())
+		if !s.IsChatAllowed(100) {
+			t.Fatal("support chat not allowed")
+		}
+		if s.Restricted() != (len(allowed) > 0) {
+			t.Fatalf("Restricted() = %v with %d allowed chats", s.Restricted(), len(allowed))
+		}
+
+		probe := chatID.Draw(t, "probe")
+		inList := false
+		for _, id := range allowed {
+			inList = inList || id == probe
+		}
+		if s.IsChatAllowed(probe) != (len(allowed) == 0 || inList) {
+			t.Fatalf("IsChatAllowed(%d) = %v, allowed chats %v", probe, s.IsChatAllowed(probe), allowed)
+		}
+
+		if len(allowed) == 1 {
+			if err := s.Remove(context.Background(), allowed[0], 1); !errors.Is(err, ErrWhitelistLastChat) {
+				t.Fatalf("Remove(last chat) = %v, want ErrWhitelistLastChat", err)
+			}
+		}
+		if len(allowed) > 0 && !inList {
+			if err := s.Remove(context.Background(), probe, 1); !errors.Is(err, ErrWhitelistNotAllowed) {
+				t.Fatalf("Remove(%d) = %v, want ErrWhitelistNotAllowed", probe, err)
+			}
+		}
+		if inList {
+			if err := s.Add(context.Background(), probe, 1); !errors.Is(err, ErrWhitelistAlreadyAllowed) {
+				t.Fatalf("Add(%d) = %v, want ErrWhitelistAlreadyAllowed", probe, err)
+			}
+		}
+	})
+}
@@ -3,6 +3,7 @@
 package shop
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -31,111 +32,149 @@ const (
 	CategoryPassive ItemCategory = "passive" // 被动型道具
 )
 
+// EffectKind represents how an item's effect is consumed
+type EffectKind string
+
+const (
+	EffectKindUseCount EffectKind = "use_count" // 按次数消耗
+	EffectKindDuration EffectKind = "duration"  // 按时间生效，到期自动失效
+)
+
 // ItemConfig holds the configuration for a shop item
 type ItemConfig struct {
-	Type           ItemType      // 道具类型
-	Name           string        // 显示名称
-	Emoji          string        // 图标
-	Price          int64         // 价格（金币）
-	UseCount       int           // 使用次数
-	EffectDuration time.Duration // 效果持续时间（用于手铐锁定目标的时间）
-	Description    string        // 描述
-	Category       ItemCategory  // 分类
-	DailyLimit     int           // 每日购买限制（0表示无限制）
-	BypassDefense  bool          // 是否无视普通防御（保护罩、荆棘刺甲）
-	ImmuneBypass   bool          // 是否免疫无视防御攻击
+	Type            ItemType      // 道具类型
+	Name            string        // 显示名称
+	Emoji           string        // 图标
+	Price           int64         // 价格（金币）
+	Kind            EffectKind    // 效果类型（为空时按次数消耗）
+	UseCount        int           // 使用次数（按次数消耗的道具）
+	ActiveDuration  time.Duration // 生效时长（按时间生效的道具，重复购买叠加）
+	EffectDuration  time.Duration // 效果持续时间（用于手铐锁定目标的时间）
+	Description     string        // 描述
+	Category        ItemCategory  // 分类
+	DailyLimit      int           // 每日购买限制（0表示无限制）
+	MaxStack        int           // 持有上限：剩余次数上限（按次数消耗的道具，0表示无限制）
+	Lifetime        time.Duration // 有效期：自最后一次获得起算，到期清除（按次数消耗的道具，0表示永久）
+	BypassDefense   bool          // 是否无视普通防御（保护罩、荆棘刺甲）
+	ImmuneBypass    bool          // 是否免疫无视防御攻击
+	SellBackPercent int           // 回收比例：剩余次数按购买价格的百分比回收（0表示不可回收）
+}
+
+// ItemUse is one use of an item consumed by a game, with the uses left after it
+type ItemUse struct {
+	UserID    int64
+	Type      ItemType
+	Remaining int
 }
 
 // ShopItems contains all available shop items
 // Easily extensible - just add new items to this map
 var ShopItems = map[ItemType]ItemConfig{
 	ItemHandcuff: {
-		Type:           ItemHandcuff,
-		Name:           "手铐",
-		Emoji:          "🔗",
-		Price:          500,
-		UseCount:       1,
-		EffectDuration: 30 * time.Minute, // 锁定目标30分钟
-		Description:    "锁定目标30分钟，使其无法打劫",
-		Category:       CategoryAttack,
-		DailyLimit:     5,
+		Type:            ItemHandcuff,
+		Name:            "手铐",
+		Emoji:           "🔗",
+		Price:           500,
+		UseCount:        1,
+		EffectDuration:  30 * time.Minute, // 锁定目标30分钟
+		Description:     "锁定目标30分钟，使其无法打劫",
+		Category:        CategoryAttack,
+		DailyLimit:      5,
+		MaxStack:        5,
+		Lifetime:        7 * 24 * time.Hour, // 囤积的手铐7天后失效
+		SellBackPercent: 50,
 	},
 	ItemKey: {
-		Type:        ItemKey,
-		Name:        "钥匙",
-		Emoji:       "🔑",
-		Price:       300,
-		UseCount:    1,
-		Description: "解除自己身上的手铐锁定",
-		Category:    CategoryDefense,
+		Type:            ItemKey,
+		Name:            "钥匙",
+		Emoji:           "🔑",
+		Price:           300,
+		UseCount:        1,
+		Description:     "解除自己身上的手铐锁定",
+		Category:        CategoryDefense,
+		MaxStack:        3,
+		SellBackPercent: 50,
 	},
 	ItemShield: {
-		Type:        ItemShield,
-		Name:        "保护罩",
-		Emoji:       "🛡️",
-		Price:       500,
-		UseCount:    10,
-		Description: "防止被打劫10次",
-		Category:    CategoryDefense,
-		DailyLimit:  2,
+		Type:           ItemShield,
+		Name:           "保护罩",
+		Emoji:          "🛡️",
+		Price:          500,
+		Kind:           EffectKindDuration,
+		ActiveDuration: time.Hour, // 重复购买叠加时长
+		Description:    "1小时内防止被打劫",
+		Category:       CategoryDefense,
+		DailyLimit:     2,
 	},
 	ItemThornArmor: {
-		Type:        ItemThornArmor,
-		Name:        "荆棘刺甲",
-		Emoji:       "🌵",
-		Price:       500,
-		UseCount:    5,
-		Description: "被打劫成功时攻击方扣双倍（5次）",
-		Category:    CategoryPassive,
+		Type:            ItemThornArmor,
+		Name:            "荆棘刺甲",
+		Emoji:           "🌵",
+		Price:           500,
+		UseCount:        5,
+		Description:     "被打劫成功时攻击方扣双倍（5次）",
+		Category:        CategoryPassive,
+		MaxStack:        15,
+		SellBackPercent: 40,
 	},
 	ItemBloodthirstSword: {
-		Type:        ItemBloodthirstSword,
-		Name:        "饮血剑",
-		Emoji:       "🗡️",
-		Price:       1000,
-		UseCount:    10,
-		Description: "打劫成功率提升到80%（10次）",
-		Category:    CategoryAttack,
+		Type:            ItemBloodthirstSword,
+		Name:            "饮血剑",
+		Emoji:           "🗡️",
+		Price:           1000,
+		UseCount:        10,
+		Description:     "打劫成功率提升到80%（10次）",
+		Category:        CategoryAttack,
+		MaxStack:        30,
+		SellBackPercent: 40,
 	},
 	ItemBluntKnife: {
-		Type:          ItemBluntKnife,
-		Name:          "钝刀",
-		Emoji:         "🔪",
-		Price:         1000,
-		UseCount:      10,
-		Description:   "无视防御，打劫1-100随机（10次）",
-		Category:      CategoryAttack,
-		BypassDefense: true,
+		Type:            ItemBluntKnife,
+		Name:            "钝刀",
+		Emoji:           "🔪",
+		Price:           1000,
+		UseCount:        10,
+		Description:     "无视防御，打劫1-100随机（10次）",
+		Category:        CategoryAttack,
+		MaxStack:        30,
+		BypassDefense:   true,
+		SellBackPercent: 40,
 	},
 	ItemGreatSword: {
-		Type:          ItemGreatSword,
-		Name:          "大宝剑",
-		Emoji:         "⚔️",
-		Price:         10000,
-		UseCount:      3,
-		Description:   "无视防御，1%打劫90%（3次）",
-		Category:      CategoryAttack,
-		DailyLimit:    1,
-		BypassDefense: true,
+		Type:            ItemGreatSword,
+		Name:            "大宝剑",
+		Emoji:           "⚔️",
+		Price:           10000,
+		UseCount:        3,
+		Description:     "无视防御，1%打劫90%（3次）",
+		Category:        CategoryAttack,
+		DailyLimit:      1,
+		MaxStack:        6,
+		BypassDefense:   true,
+		SellBackPercent: 30,
 	},
 	ItemGoldenCassock: {
-		Type:        ItemGoldenCassock,
-		Name:        "紫金袈裟",
-		Emoji:       "👘",
-		Price:       10000,
-		UseCount:    3,
-		Description: "攻击者失去所有防御道具（3次）",
-		Category:    CategoryDefense,
+		Type:            ItemGoldenCassock,
+		Name:            "紫金袈裟",
+		Emoji:           "👘",
+		Price:           10000,
+		UseCount:        3,
+		Description:     "攻击者失去所有防御道具（3次）",
+		Category:        CategoryDefense,
+		MaxStack:        9,
+		SellBackPercent: 30,
 	},
 	ItemEmperorClothes: {
-		Type:         ItemEmperorClothes,
-		Name:         "皇帝的新衣",
-		Emoji:        "👑",
-		Price:        5000,
-		UseCount:     3,
-		Description:  "免疫所有攻击（3次）",
-		Category:     CategoryDefense,
-		ImmuneBypass: true,
+		Type:            ItemEmperorClothes,
+		Name:            "皇帝的新衣",
+		Emoji:           "👑",
+		Price:           5000,
+		UseCount:        3,
+		Description:     "免疫所有攻击（3次）",
+		Category:        CategoryDefense,
+		MaxStack:        9,
+		ImmuneBypass:    true,
+		SellBackPercent: 30,
 	},
 }
 
@@ -180,11 +219,63 @@ func GetItemsByCategory(category ItemCategory) []ItemConfig {
 	return items
 }
 
+// EffectKind returns the effect kind of the item, defaulting to use count
+func (c ItemConfig) EffectKind() EffectKind {
+	if c.Kind == "" {
+		return EffectKindUseCount
+	}
+	return c.Kind
+}
+
+// IsDurationBased returns true if the item's effect expires by time instead of use count
+func (c ItemConfig) IsDurationBased() bool {
+	return c.EffectKind() == EffectKindDuration
+}
+
+// UsageText returns the usage line shown in shop listings
+// e.g. "使用次数: 10次" or "持续时间: 1小时0分钟"
+func (c ItemConfig) UsageText() string {
+	if c.IsDurationBased() {
+		return "持续时间: " + FormatRemainingTime(int64(c.ActiveDuration.Seconds()))
+	}
+	return fmt.Sprintf("使用次数: %d次", c.UseCount)
+}
+
 // HasDailyLimit returns true if the item has a daily purchase limit
 func (c ItemConfig) HasDailyLimit() bool {
 	return c.DailyLimit > 0
 }
 
+// HasStackLimit returns true if the item caps the uses a user can hold
+func (c ItemConfig) HasStackLimit() bool {
+	return c.MaxStack > 0 && !c.IsDurationBased()
+}
+
+// CanStack returns true if one more purchase fits under the stack cap given the uses held
+func (c ItemConfig) CanStack(held int) bool {
+	return !c.HasStackLimit() || held+c.UseCount <= c.MaxStack
+}
+
+// Expires returns true if held uses of the item expire after a lifetime
+func (c ItemConfig) Expires() bool {
+	return c.Lifetime > 0 && !c.IsDurationBased()
+}
+
+// CanSellBack returns true if unused uses of the item can be sold back to the shop
+func (c ItemConfig) CanSellBack() bool {
+	return c.SellBackPercent > 0 && !c.IsDurationBased() && c.UseCount > 0
+}
+
+// SellBackValue returns the coins paid for selling uses back, given the price
+// of one purchase (UseCount uses). Rounds down, so selling uses one by one
+// never pays more than selling them at once.
+func (c ItemConfig) SellBackValue(price int64, uses int) int64 {
+	if !c.CanSellBack() || price <= 0 || uses <= 0 {
+		return 0
+	}
+	return price * int64(c.SellBackPercent) * int64(uses) / int64(100*c.UseCount)
+}
+
 // CanBypassDefense returns true if the item can bypass normal defenses
 func (c ItemConfig) CanBypassDefense() bool {
 	return c.BypassDefense
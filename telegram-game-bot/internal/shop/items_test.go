@@ -0,0 +1,152 @@
+package shop
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestItemEffectKindConfig tests that every shop item has a consistent effect configuration
+// Use count items must have a positive use count, duration items a positive active duration
+func TestItemEffectKindConfig(t *testing.T) {
+	for _, item := range GetAllItems() {
+		switch item.EffectKind() {
+		case EffectKindUseCount:
+			if item.UseCount <= 0 {
+				t.Errorf("Item %s is use count based but has use count %d", item.Type, item.UseCount)
+			}
+		case EffectKindDuration:
+			if item.ActiveDuration <= 0 {
+				t.Errorf("Item %s is duration based but has active duration %v", item.Type, item.ActiveDuration)
+			}
+		default:
+			t.Errorf("Item %s has unknown effect kind %q", item.Type, item.EffectKind())
+		}
+	}
+}
+
+// TestItemEffectKindDefault tests that an empty kind defaults to use count
+func TestItemEffectKindDefault(t *testing.T) {
+	item := ItemConfig{UseCount: 3}
+	if item.EffectKind() != EffectKindUseCount {
+		t.Fatalf("Empty kind should default to use count, got %q", item.EffectKind())
+	}
+	if item.IsDurationBased() {
+		t.Fatalf("Empty kind should not be duration based")
+	}
+	if got := item.UsageText(); got != "使用次数: 3次" {
+		t.Fatalf("Unexpected usage text %q", got)
+	}
+}
+
+// TestItemUsageTextDuration tests the usage text of duration based items
+func TestItemUsageTextDuration(t *testing.T) {
+	item := ItemConfig{Kind: EffectKindDuration, ActiveDuration: 90 * time.Minute}
+	if !item.IsDurationBased() {
+		t.Fatalf("Item should be duration based")
+	}
+	if got := item.UsageText(); !strings.HasPrefix(got, "持续时间: ") || !strings.Contains(got, "1小时30分钟") {
+		t.Fatalf("Unexpected usage text %q", got)
+	}
+}
+
+// TestItemStackLimitConfig tests that a stack cap always fits at least one purchase
+func TestItemStackLimitConfig(t *testing.T) {
+	for _, item := range GetAllItems() {
+		if item.HasStackLimit() && item.MaxStack < item.UseCount {
+			t.Errorf("Item %s caps at %d uses but one purchase adds %d", item.Type, item.MaxStack, item.UseCount)
+		}
+		if !item.CanStack(0) {
+			t.Errorf("Item %s cannot be bought with an empty inventory", item.Type)
+		}
+		if item.Lifetime < 0 {
+			t.Errorf("Item %s has negative lifetime %v", item.Type, item.Lifetime)
+		}
+	}
+}
+
+// TestItemCanStack tests the stack cap boundary
+func TestItemCanStack(t *testing.T) {
+	item := ItemConfig{UseCount: 3, MaxStack: 9}
+	for held, want := range map[int]bool{0: true, 5: true, 6: true, 7: false, 9: false} {
+		if got := item.CanStack(held); got != want {
+			t.Errorf("CanStack(%d) = %v, want %v", held, got, want)
+		}
+	}
+
+	unlimited := ItemConfig{UseCount: 3}
+	if !unlimited.CanStack(1_000_000) {
+		t.Errorf("Item without stack cap should always stack")
+	}
+}
+
+// TestFormatUseCountWithExpiry tests the bag line of expiring items
+func TestFormatUseCountWithExpiry(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(2*time.Hour + 30*time.Minute)
+
+	if got := FormatUseCountWithExpiry(3, nil, now); got != "剩余3次" {
+		t.Fatalf("Unexpected text without expiry %q", got)
+	}
+	if got := FormatUseCountWithExpiry(3, &expiresAt, now); !strings.Contains(got, "2小时30分钟后过期") {
+		t.Fatalf("Unexpected text with expiry %q", got)
+	}
+	if got := FormatLifetime(7 * 24 * time.Hour); got != "7天" {
+		t.Fatalf("Unexpected lifetime %q", got)
+	}
+}
+
+// TestSellBackValue tests that selling back never pays more than the purchase
+// price and that selling uses one by one never beats selling them at once
+func TestSellBackValue(t *testing.T) {
+	for _, item := range GetAllItems() {
+		if !item.CanSellBack() {
+			if v := item.SellBackValue(item.Price, 1); v != 0 {
+				t.Errorf("Item %s is not sellable but pays %d", item.Type, v)
+			}
+			continue
+		}
+		if item.SellBackPercent >= 100 {
+			t.Errorf("Item %s sells back at %d%%, must be below 100%%", item.Type, item.SellBackPercent)
+		}
+		if full := item.SellBackValue(item.Price, item.UseCount); full >= item.Price {
+			t.Errorf("Item %s sells back a full purchase for %d, price %d", item.Type, full, item.Price)
+		}
+		for uses := 1; uses <= item.UseCount*3; uses++ {
+			if one, all := item.SellBackValue(item.Price, 1), item.SellBackValue(item.Price, uses); one*int64(uses) > all {
+				t.Errorf("Item %s: %d single sales pay %d, selling all pays %d", item.Type, uses, one*int64(uses), all)
+			}
+		}
+	}
+}
+
+// TestParseSellBackData tests the round trip of sell-back callback data
+func TestParseSellBackData(t *testing.T) {
+	for _, uses := range []int{0, 1, 7} {
+		itemType, got, ok := ParseSellBackData(FormatSellBackData(ItemShield, uses))
+		if !ok || itemType != ItemShield || got != uses {
+			t.Errorf("ParseSellBackData(FormatSellBackData(shield, %d)) = %s, %d, %v", uses, itemType, got, ok)
+		}
+	}
+	for _, data := range []string{"shop_sell:", "shop_sell:shield", "shop_sell:shield:-1", "shop_sell::1", "shop_bag"} {
+		if _, _, ok := ParseSellBackData(data); ok {
+			t.Errorf("ParseSellBackData(%q) accepted invalid data", data)
+		}
+	}
+}
+
+// TestFormatItemUses tests that consumed items are listed once with their last count
+func TestFormatItemUses(t *testing.T) {
+	uses := []ItemUse{
+		{UserID: 1, Type: ItemBluntKnife, Remaining: 3},
+		{UserID: 1, Type: ItemBloodthirstSword, Remaining: 0},
+		{UserID: 1, Type: ItemBluntKnife, Remaining: 2},
+	}
+	want := "🔪钝刀剩余2次，🗡️饮血剑已用完"
+	if got := FormatItemUses(uses); got != want {
+		t.Errorf("FormatItemUses() = %q, want %q", got, want)
+	}
+	if got := FormatItemUses(nil); got != "" {
+		t.Errorf("FormatItemUses(nil) = %q, want empty", got)
+	}
+}
@@ -3,6 +3,9 @@ package shop
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	tele "gopkg.in/telebot.v3"
 )
@@ -18,6 +21,9 @@ const (
 	CallbackShopAttack   = "shop_attack"    // shop_attack - attack items
 	CallbackShopDefense  = "shop_defense"   // shop_defense - defense items
 	CallbackShopHome     = "shop_home"      // shop_home - back to main menu
+	CallbackShopSettings = "shop_settings"  // shop_settings - personal settings
+	CallbackShopPrivacy  = "shop_privacy"   // shop_privacy - toggle leaderboard privacy
+	CallbackShopSell     = "shop_sell:"     // shop_sell:shield:1 - sell uses back (0 = all)
 )
 
 // BuildShopPanel creates the main shop panel (first level: Bag | Goods)
@@ -31,12 +37,33 @@ func BuildShopPanel() *tele.ReplyMarkup {
 			{Text: "🛒 商品", Data: CallbackShopGoods},
 		},
 		{
+			{Text: "⚙️ 设置", Data: CallbackShopSettings},
 			{Text: "🔄 刷新", Data: CallbackShopRefresh},
 		},
 	}
 	return markup
 }
 
+// BuildSettingsPanel creates the personal settings panel
+func BuildSettingsPanel(hideFromLeaderboard bool) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	privacyText := "🙈 排行榜匿名: 关"
+	if hideFromLeaderboard {
+		privacyText = "🙈 排行榜匿名: 开"
+	}
+
+	markup.InlineKeyboard = [][]tele.InlineButton{
+		{
+			{Text: privacyText, Data: CallbackShopPrivacy},
+		},
+		{
+			{Text: "🔙 返回", Data: CallbackShopHome},
+		},
+	}
+	return markup
+}
+
 // BuildGoodsCategoryPanel creates the goods category panel (second level: Attack | Defense)
 func BuildGoodsCategoryPanel() *tele.ReplyMarkup {
 	markup := &tele.ReplyMarkup{}
@@ -54,7 +81,7 @@ func BuildGoodsCategoryPanel() *tele.ReplyMarkup {
 }
 
 // BuildAttackItemsPanel creates the attack items panel
-func BuildAttackItemsPanel() *tele.ReplyMarkup {
+func BuildAttackItemsPanel(quotes map[ItemType]PriceQuote) *tele.ReplyMarkup {
 	markup := &tele.ReplyMarkup{}
 	
 	items := GetItemsByCategory(CategoryAttack)
@@ -64,7 +91,7 @@ func BuildAttackItemsPanel() *tele.ReplyMarkup {
 	var currentRow []tele.InlineButton
 	for i, item := range items {
 		btn := tele.InlineButton{
-			Text: fmt.Sprintf("%s %s (%d💰)", item.Emoji, item.Name, item.Price),
+			Text: formatItemButton(item, quotes),
 			Data: CallbackShopItem + string(item.Type),
 		}
 		currentRow = append(currentRow, btn)
@@ -85,7 +112,7 @@ func BuildAttackItemsPanel() *tele.ReplyMarkup {
 }
 
 // BuildDefenseItemsPanel creates the defense items panel
-func BuildDefenseItemsPanel() *tele.ReplyMarkup {
+func BuildDefenseItemsPanel(quotes map[ItemType]PriceQuote) *tele.ReplyMarkup {
 	markup := &tele.ReplyMarkup{}
 	
 	// Get defense and passive items
@@ -99,7 +126,7 @@ func BuildDefenseItemsPanel() *tele.ReplyMarkup {
 	var currentRow []tele.InlineButton
 	for i, item := range items {
 		btn := tele.InlineButton{
-			Text: fmt.Sprintf("%s %s (%d💰)", item.Emoji, item.Name, item.Price),
+			Text: formatItemButton(item, quotes),
 			Data: CallbackShopItem + string(item.Type),
 		}
 		currentRow = append(currentRow, btn)
@@ -149,7 +176,22 @@ func FormatShopMessage(balance int64) string {
 	msg += "欢迎来到游戏商店！\n"
 	msg += "请选择要查看的内容：\n\n"
 	msg += "🎒 背包 - 查看已购买的道具\n"
-	msg += "🛒 商品 - 浏览和购买道具"
+	msg += "🛒 商品 - 浏览和购买道具\n"
+	msg += "⚙️ 设置 - 个人隐私设置"
+	return msg
+}
+
+// FormatSettingsMessage creates the personal settings message
+func FormatSettingsMessage(hideFromLeaderboard bool) string {
+	msg := "⚙️ 个人设置\n\n"
+	if hideFromLeaderboard {
+		msg += "🙈 排行榜匿名: 已开启\n"
+		msg += "   在 /top 和 /daily_top 中显示为「匿名玩家」\n"
+	} else {
+		msg += "🙈 排行榜匿名: 已关闭\n"
+		msg += "   在 /top 和 /daily_top 中显示你的用户名\n"
+	}
+	msg += "\n👇 点击按钮切换"
 	return msg
 }
 
@@ -163,13 +205,13 @@ func FormatGoodsCategoryMessage(balance int64) string {
 }
 
 // FormatAttackItemsMessage creates the attack items list message
-func FormatAttackItemsMessage(balance int64) string {
+func FormatAttackItemsMessage(balance int64, quotes map[ItemType]PriceQuote) string {
 	msg := fmt.Sprintf("⚔️ 攻击道具\n余额: %d 金币\n\n", balance)
 	
 	items := GetItemsByCategory(CategoryAttack)
 	for _, item := range items {
-		msg += fmt.Sprintf("%s %s - %d金币\n", item.Emoji, item.Name, item.Price)
-		msg += fmt.Sprintf("   使用次数: %d次", item.UseCount)
+		msg += formatItemLine(item, quotes)
+		msg += "   " + item.UsageText()
 		if item.HasDailyLimit() {
 			msg += fmt.Sprintf(" | 限购%d/日", item.DailyLimit)
 		}
@@ -181,7 +223,7 @@ func FormatAttackItemsMessage(balance int64) string {
 }
 
 // FormatDefenseItemsMessage creates the defense items list message
-func FormatDefenseItemsMessage(balance int64) string {
+func FormatDefenseItemsMessage(balance int64, quotes map[ItemType]PriceQuote) string {
 	msg := fmt.Sprintf("🛡️ 防御道具\n余额: %d 金币\n\n", balance)
 	
 	// Get defense and passive items
@@ -190,8 +232,8 @@ func FormatDefenseItemsMessage(balance int64) string {
 	items := append(defenseItems, passiveItems...)
 	
 	for _, item := range items {
-		msg += fmt.Sprintf("%s %s - %d金币\n", item.Emoji, item.Name, item.Price)
-		msg += fmt.Sprintf("   使用次数: %d次", item.UseCount)
+		msg += formatItemLine(item, quotes)
+		msg += "   " + item.UsageText()
 		if item.HasDailyLimit() {
 			msg += fmt.Sprintf(" | 限购%d/日", item.DailyLimit)
 		}
@@ -204,10 +246,11 @@ func FormatDefenseItemsMessage(balance int64) string {
 
 // FormatItemDetail creates the item detail message
 // Requirements: 1.2 - Show item name, price, use count, and daily limit info
-func FormatItemDetail(item ItemConfig, balance int64) string {
+func FormatItemDetail(item ItemConfig, quote PriceQuote, balance int64) string {
 	msg := fmt.Sprintf("%s %s\n\n", item.Emoji, item.Name)
-	msg += fmt.Sprintf("价格: %d 金币\n", item.Price)
-	msg += fmt.Sprintf("使用次数: %d次\n", item.UseCount)
+	msg += formatPriceDetail(quote)
+	msg += item.UsageText() + "\n"
+	msg += formatHoldingRules(item)
 
 	if item.HasDailyLimit() {
 		msg += fmt.Sprintf("每日限购: %d次\n", item.DailyLimit)
@@ -216,7 +259,7 @@ func FormatItemDetail(item ItemConfig, balance int64) string {
 	msg += fmt.Sprintf("说明: %s\n\n", item.Description)
 	msg += fmt.Sprintf("你的余额: %d 金币\n\n", balance)
 
-	if balance < item.Price {
+	if balance < quote.Price {
 		msg += "❌ 余额不足"
 	} else {
 		msg += "✅ 确认购买？"
@@ -227,10 +270,11 @@ func FormatItemDetail(item ItemConfig, balance int64) string {
 
 // FormatItemDetailWithDailyCount creates the item detail message with daily purchase count
 // Requirements: 1.2, 2.9, 3.8, 7.8 - Show daily limit and current purchase count
-func FormatItemDetailWithDailyCount(item ItemConfig, balance int64, dailyCount int) string {
+func FormatItemDetailWithDailyCount(item ItemConfig, quote PriceQuote, balance int64, dailyCount int) string {
 	msg := fmt.Sprintf("%s %s\n\n", item.Emoji, item.Name)
-	msg += fmt.Sprintf("价格: %d 金币\n", item.Price)
-	msg += fmt.Sprintf("使用次数: %d次\n", item.UseCount)
+	msg += formatPriceDetail(quote)
+	msg += item.UsageText() + "\n"
+	msg += formatHoldingRules(item)
 
 	if item.HasDailyLimit() {
 		msg += fmt.Sprintf("每日限购: %d/%d次\n", dailyCount, item.DailyLimit)
@@ -242,7 +286,7 @@ func FormatItemDetailWithDailyCount(item ItemConfig, balance int64, dailyCount i
 	// Check daily limit first
 	if item.HasDailyLimit() && dailyCount >= item.DailyLimit {
 		msg += "❌ 今日购买次数已达上限"
-	} else if balance < item.Price {
+	} else if balance < quote.Price {
 		msg += "❌ 余额不足"
 	} else {
 		msg += "✅ 确认购买？"
@@ -251,6 +295,58 @@ func FormatItemDetailWithDailyCount(item ItemConfig, balance int64, dailyCount i
 	return msg
 }
 
+// formatHoldingRules returns the stack cap and lifetime lines of an item
+func formatHoldingRules(item ItemConfig) string {
+	msg := ""
+	if item.HasStackLimit() {
+		msg += fmt.Sprintf("持有上限: %d次\n", item.MaxStack)
+	}
+	if item.Expires() {
+		msg += "有效期: " + FormatLifetime(item.Lifetime) + "\n"
+	}
+	return msg
+}
+
+// FormatLifetime formats an item lifetime, e.g. "7天" or "12小时"
+func FormatLifetime(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d天", int(d/(24*time.Hour)))
+	}
+	return FormatRemainingTime(int64(d.Seconds()))
+}
+
+// quoteFor returns the quote of an item, falling back to its list price
+func quoteFor(item ItemConfig, quotes map[ItemType]PriceQuote) PriceQuote {
+	if q, ok := quotes[item.Type]; ok {
+		return q
+	}
+	return PriceQuote{Base: item.Price, Price: item.Price}
+}
+
+// formatItemButton returns the button text of an item, marking flash sales
+func formatItemButton(item ItemConfig, quotes map[ItemType]PriceQuote) string {
+	q := quoteFor(item, quotes)
+	if q.OnSale() {
+		return fmt.Sprintf("🔥%s %s (%d💰)", item.Emoji, item.Name, q.Price)
+	}
+	return fmt.Sprintf("%s %s (%d💰)", item.Emoji, item.Name, q.Price)
+}
+
+// formatItemLine returns the list line of an item
+func formatItemLine(item ItemConfig, quotes map[ItemType]PriceQuote) string {
+	q := quoteFor(item, quotes)
+	return fmt.Sprintf("%s %s - %d金币%s\n", item.Emoji, item.Name, q.Price, FormatPriceTags(q))
+}
+
+// formatPriceDetail returns the price lines of the item detail message
+func formatPriceDetail(q PriceQuote) string {
+	msg := fmt.Sprintf("价格: %d 金币%s\n", q.Price, FormatPriceTags(q))
+	if q.OnSale() {
+		msg += fmt.Sprintf("⏰ 特惠截止: %s\n", q.SaleEndsAt.Format("01-02 15:04"))
+	}
+	return msg
+}
+
 // FormatInventoryMessage creates the inventory display message
 // Requirements: 11.2 - Show item name, quantity (for Handcuffs), and remaining use count (for other items)
 func FormatInventoryMessage(balance int64, handcuffCount int, effects []EffectInfo) string {
@@ -281,19 +377,67 @@ func FormatInventoryMessage(balance int64, handcuffCount int, effects []EffectIn
 	return msg
 }
 
-// BuildBagPanel creates the bag panel with back button
-func BuildBagPanel() *tele.ReplyMarkup {
+// SellBackOption is a sell-back offer for the uses of an item in the bag
+type SellBackOption struct {
+	Item     ItemConfig
+	Uses     int   // Uses held
+	OneValue int64 // Coins for selling one use
+	AllValue int64 // Coins for selling all uses
+}
+
+// BuildBagPanel creates the bag panel with sell-back and back buttons
+func BuildBagPanel(options []SellBackOption) *tele.ReplyMarkup {
 	markup := &tele.ReplyMarkup{}
-	
-	markup.InlineKeyboard = [][]tele.InlineButton{
-		{
-			{Text: "🔙 返回", Data: CallbackShopHome},
-			{Text: "🔄 刷新", Data: CallbackShopBag},
-		},
+
+	var rows [][]tele.InlineButton
+	for _, opt := range options {
+		if opt.AllValue <= 0 {
+			continue
+		}
+		var row []tele.InlineButton
+		if opt.Uses > 1 && opt.OneValue > 0 {
+			row = append(row, tele.InlineButton{
+				Text: fmt.Sprintf("💰 回收1次%s +%d", opt.Item.Emoji, opt.OneValue),
+				Data: FormatSellBackData(opt.Item.Type, 1),
+			})
+		}
+		row = append(row, tele.InlineButton{
+			Text: fmt.Sprintf("💰 全部回收%s +%d", opt.Item.Emoji, opt.AllValue),
+			Data: FormatSellBackData(opt.Item.Type, 0),
+		})
+		rows = append(rows, row)
 	}
+
+	rows = append(rows, []tele.InlineButton{
+		{Text: "🔙 返回", Data: CallbackShopHome},
+		{Text: "🔄 刷新", Data: CallbackShopBag},
+	})
+	markup.InlineKeyboard = rows
 	return markup
 }
 
+// FormatSellBackData returns the callback data of a sell-back button (uses 0 = all)
+func FormatSellBackData(itemType ItemType, uses int) string {
+	return fmt.Sprintf("%s%s:%d", CallbackShopSell, itemType, uses)
+}
+
+// ParseSellBackData parses the callback data of a sell-back button
+func ParseSellBackData(data string) (ItemType, int, bool) {
+	rest, ok := strings.CutPrefix(data, CallbackShopSell)
+	if !ok {
+		return "", 0, false
+	}
+	itemType, usesStr, ok := strings.Cut(rest, ":")
+	if !ok || itemType == "" {
+		return "", 0, false
+	}
+	uses, err := strconv.Atoi(usesStr)
+	if err != nil || uses < 0 {
+		return "", 0, false
+	}
+	return ItemType(itemType), uses, true
+}
+
 // EffectInfo holds effect display information
 type EffectInfo struct {
 	EffectType   string
@@ -315,6 +459,38 @@ func FormatRemainingTime(remaining int64) string {
 	return fmt.Sprintf("%d分钟", minutes)
 }
 
+// FormatUseCountWithExpiry formats use count and, for expiring items, the time left
+func FormatUseCountWithExpiry(useCount int, expiresAt *time.Time, now time.Time) string {
+	text := FormatUseCount(useCount)
+	if expiresAt == nil || useCount <= 0 {
+		return text
+	}
+	return text + "，" + FormatRemainingTime(int64(expiresAt.Sub(now).Seconds())) + "后过期"
+}
+
+// FormatItemUses formats the uses left of consumed items, e.g. "🔪钝刀剩余2次，🗡️饮血剑已用完".
+// An item used several times is listed once with the uses left after its last use.
+func FormatItemUses(uses []ItemUse) string {
+	var order []ItemType
+	remaining := make(map[ItemType]int, len(uses))
+	for _, use := range uses {
+		if _, seen := remaining[use.Type]; !seen {
+			order = append(order, use.Type)
+		}
+		remaining[use.Type] = use.Remaining
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, itemType := range order {
+		name := string(itemType)
+		if item, ok := GetItem(itemType); ok {
+			name = item.Emoji + item.Name
+		}
+		parts = append(parts, name+FormatUseCount(remaining[itemType]))
+	}
+	return strings.Join(parts, "，")
+}
+
 // FormatUseCount formats use count for display
 func FormatUseCount(useCount int) string {
 	if useCount <= 0 {
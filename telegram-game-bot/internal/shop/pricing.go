@@ -0,0 +1,133 @@
+// Package shop provides shop system for purchasing items.
+package shop
+
+import (
+	"fmt"
+	"time"
+)
+
+// AllItemsSale is the sale target that discounts every item
+const AllItemsSale = "all"
+
+// MaxSaleDiscountPercent caps flash sale discounts
+const MaxSaleDiscountPercent = 90
+
+// Sale is a time-boxed flash sale discount
+type Sale struct {
+	Target          string // Item type, or AllItemsSale
+	DiscountPercent int
+	EndsAt          time.Time
+}
+
+// IsActive reports whether the sale is running at now
+func (s Sale) IsActive(now time.Time) bool {
+	return s.DiscountPercent > 0 && now.Before(s.EndsAt)
+}
+
+// Applies reports whether the sale covers itemType
+func (s Sale) Applies(itemType ItemType) bool {
+	return s.Target == AllItemsSale || s.Target == string(itemType)
+}
+
+// DemandPricing raises prices as an item sells during the day:
+// every StepUnits sold today (across all users) add StepPercent, up to MaxMarkupPercent.
+type DemandPricing struct {
+	StepUnits        int
+	StepPercent      int
+	MaxMarkupPercent int
+}
+
+// Markup returns the demand markup percentage for soldToday units
+func (d DemandPricing) Markup(soldToday int) int {
+	if d.StepUnits <= 0 || d.StepPercent <= 0 || soldToday <= 0 {
+		return 0
+	}
+	markup := soldToday / d.StepUnits * d.StepPercent
+	if d.MaxMarkupPercent >= 0 && markup > d.MaxMarkupPercent {
+		markup = d.MaxMarkupPercent
+	}
+	return markup
+}
+
+// PriceQuote is the resolved price of an item at a point in time
+type PriceQuote struct {
+	Base            int64     // List price from ItemConfig
+	Price           int64     // Price charged
+	DiscountPercent int       // Flash sale discount (0 = none)
+	SaleEndsAt      time.Time // End of the flash sale
+	MarkupPercent   int       // Demand markup from today's sales
+}
+
+// OnSale reports whether a flash sale discount applies
+func (q PriceQuote) OnSale() bool {
+	return q.DiscountPercent > 0
+}
+
+// ResolvePrice is the single place item prices are decided.
+// The demand markup applies first, then the best active sale covering the item;
+// the result is never below 1 coin.
+func ResolvePrice(item ItemConfig, sales []Sale, soldToday int, demand DemandPricing, now time.Time) PriceQuote {
+	quote := PriceQuote{
+		Base:          item.Price,
+		MarkupPercent: demand.Markup(soldToday),
+	}
+
+	for _, sale := range sales {
+		if !sale.Applies(item.Type) || !sale.IsActive(now) {
+			continue
+		}
+		discount := sale.DiscountPercent
+		if discount > MaxSaleDiscountPercent {
+			discount = MaxSaleDiscountPercent
+		}
+		if discount > quote.DiscountPercent {
+			quote.DiscountPercent = discount
+			quote.SaleEndsAt = sale.EndsAt
+		}
+	}
+
+	price := item.Price * int64(100+quote.MarkupPercent) / 100
+	price = price * int64(100-quote.DiscountPercent) / 100
+	if price < 1 {
+		price = 1
+	}
+	quote.Price = price
+	return quote
+}
+
+// BaseQuotes returns list prices for all items, used when no pricing engine is configured
+func BaseQuotes() map[ItemType]PriceQuote {
+	quotes := make(map[ItemType]PriceQuote, len(ShopItems))
+	for itemType, item := range ShopItems {
+		quotes[itemType] = PriceQuote{Base: item.Price, Price: item.Price}
+	}
+	return quotes
+}
+
+// FindItem looks up an item by type or display name
+func FindItem(nameOrType string) (ItemConfig, bool) {
+	if item, ok := GetItem(ItemType(nameOrType)); ok {
+		return item, true
+	}
+	for _, item := range ShopItems {
+		if item.Name == nameOrType {
+			return item, true
+		}
+	}
+	return ItemConfig{}, false
+}
+
+// FormatPriceTags returns the sale/demand annotations of a quote, e.g. " (原价500 🔥8折 📈+10%)"
+func FormatPriceTags(q PriceQuote) string {
+	if q.Price == q.Base && !q.OnSale() && q.MarkupPercent == 0 {
+		return ""
+	}
+	tags := fmt.Sprintf(" (原价%d", q.Base)
+	if q.OnSale() {
+		tags += fmt.Sprintf(" 🔥限时%d%%off", q.DiscountPercent)
+	}
+	if q.MarkupPercent > 0 {
+		tags += fmt.Sprintf(" 📈热销+%d%%", q.MarkupPercent)
+	}
+	return tags + ")"
+}
@@ -0,0 +1,86 @@
+package shop
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// drawSales draws a mix of active and expired sales around now
+func drawSales(t *rapid.T, now time.Time) []Sale {
+	targets := []string{AllItemsSale}
+	for _, item := range GetAllItems() {
+		targets = append(targets, string(item.Type))
+	}
+
+	n := rapid.IntRange(0, 5).Draw(t, "sales")
+	sales := make([]Sale, n)
+	for i := range sales {
+		sales[i] = Sale{
+			Target:          rapid.SampledFrom(targets).Draw(t, "target"),
+			DiscountPercent: rapid.IntRange(0, 100).Draw(t, "discount"),
+			EndsAt:          now.Add(time.Duration(rapid.IntRange(-120, 120).Draw(t, "endsInMinutes")) * time.Minute),
+		}
+	}
+	return sales
+}
+
+// TestResolvePriceProperty tests the price resolution.
+// *For any* item, sales and demand: the price is at least 1, a sale never raises it,
+// the markup never exceeds its cap, and expired or unrelated sales are ignored.
+func TestResolvePriceProperty(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		now := time.Now()
+		item := rapid.SampledFrom(GetAllItems()).Draw(t, "item")
+		sales := drawSales(t, now)
+		sold := rapid.IntRange(0, 1000).Draw(t, "sold")
+		demand := DemandPricing{
+			StepUnits:        rapid.IntRange(0, 50).Draw(t, "stepUnits"),
+			StepPercent:      rapid.IntRange(0, 20).Draw(t, "stepPercent"),
+			MaxMarkupPercent: rapid.IntRange(0, 100).Draw(t, "maxMarkup"),
+		}
+
+		quote := ResolvePrice(item, sales, sold, demand, now)
+		noSale := ResolvePrice(item, nil, sold, demand, now)
+
+		if quote.Price < 1 {
+			t.Fatalf("Price %d is below 1", quote.Price)
+		}
+		if quote.Base != item.Price {
+			t.Fatalf("Base %d does not match list price %d", quote.Base, item.Price)
+		}
+		if quote.MarkupPercent < 0 || quote.MarkupPercent > demand.MaxMarkupPercent {
+			t.Fatalf("Markup %d%% outside [0, %d%%]", quote.MarkupPercent, demand.MaxMarkupPercent)
+		}
+		if quote.Price > noSale.Price {
+			t.Fatalf("Sale raised the price from %d to %d", noSale.Price, quote.Price)
+		}
+		if quote.DiscountPercent > MaxSaleDiscountPercent {
+			t.Fatalf("Discount %d%% exceeds the %d%% cap", quote.DiscountPercent, MaxSaleDiscountPercent)
+		}
+
+		applicable := false
+		for _, sale := range sales {
+			if sale.Applies(item.Type) && sale.IsActive(now) {
+				applicable = true
+			}
+		}
+		if !applicable && quote != noSale {
+			t.Fatalf("No active sale covers %s, expected %+v, got %+v", item.Type, noSale, quote)
+		}
+	})
+}
+
+// TestResolvePriceListPrice tests that without sales or demand the list price is charged
+func TestResolvePriceListPrice(t *testing.T) {
+	for _, item := range GetAllItems() {
+		quote := ResolvePrice(item, nil, 100, DemandPricing{}, time.Now())
+		if quote.Price != item.Price || quote.OnSale() || quote.MarkupPercent != 0 {
+			t.Errorf("Item %s: expected list price %d, got %+v", item.Type, item.Price, quote)
+		}
+		if tags := FormatPriceTags(quote); tags != "" {
+			t.Errorf("Item %s: list price should have no tags, got %q", item.Type, tags)
+		}
+	}
+}
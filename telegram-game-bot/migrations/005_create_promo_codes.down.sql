@@ -0,0 +1,4 @@
+-- Drop Promo Code Tables
+DROP INDEX IF EXISTS idx_promo_redemptions_user;
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
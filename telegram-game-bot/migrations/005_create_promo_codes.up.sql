@@ -0,0 +1,23 @@
+-- Promo Code Tables
+-- Admin-generated gift codes granting coins or items
+
+-- 兑换码表
+CREATE TABLE IF NOT EXISTS promo_codes (
+    code VARCHAR(32) PRIMARY KEY,
+    reward_amount BIGINT NOT NULL DEFAULT 0,
+    reward_item VARCHAR(50),
+    max_uses INT NOT NULL DEFAULT 1,
+    used_count INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 兑换记录表（每个用户每个兑换码只能兑换一次，同时作为审计记录）
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    code VARCHAR(32) NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    redeemed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_user ON promo_redemptions(user_id);
@@ -0,0 +1,2 @@
+-- Daily free slot spin tracking (unix timestamp, same as last_daily_claim)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_free_spin BIGINT NOT NULL DEFAULT 0;
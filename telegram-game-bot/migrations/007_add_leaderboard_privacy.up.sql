@@ -0,0 +1,2 @@
+-- Leaderboard privacy: hidden users are shown as "匿名玩家" on public leaderboards
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_from_leaderboard BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- Drop Support Tickets
+DROP INDEX IF EXISTS idx_support_tickets_user_status;
+DROP TABLE IF EXISTS support_tickets;
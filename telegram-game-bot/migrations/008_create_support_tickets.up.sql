@@ -0,0 +1,15 @@
+-- Support Tickets
+-- /support tickets forwarded to the admin chat; also the audit trail of refunds/dismissals
+
+CREATE TABLE IF NOT EXISTS support_tickets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    message TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open / refunded / dismissed
+    resolved_by BIGINT,
+    refund_tx_id BIGINT,
+    refund_amount BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_support_tickets_user_status ON support_tickets(user_id, status);
@@ -0,0 +1,5 @@
+-- Drop Compensation
+DROP INDEX IF EXISTS idx_compensation_entries_incident;
+DROP TABLE IF EXISTS compensation_entries;
+DROP INDEX IF EXISTS idx_compensation_incidents_status;
+DROP TABLE IF EXISTS compensation_incidents;
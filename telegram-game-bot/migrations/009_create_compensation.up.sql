@@ -0,0 +1,24 @@
+-- Compensation
+-- Incidents of losses caused by the bot and the per-user compensation entries
+
+CREATE TABLE IF NOT EXISTS compensation_incidents (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending / paid / rejected
+    total_amount BIGINT NOT NULL DEFAULT 0,
+    reviewed_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_compensation_incidents_status ON compensation_incidents(status);
+
+CREATE TABLE IF NOT EXISTS compensation_entries (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL REFERENCES compensation_incidents(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    paid BOOLEAN NOT NULL DEFAULT FALSE,
+    paid_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_compensation_entries_incident ON compensation_entries(incident_id);
@@ -0,0 +1,2 @@
+-- Drop Rob protection
+DROP TABLE IF EXISTS rob_protections;
@@ -0,0 +1,9 @@
+-- Rob protection
+-- Persisted protection state so protection periods survive restarts
+
+CREATE TABLE IF NOT EXISTS rob_protections (
+    user_id BIGINT PRIMARY KEY,
+    consecutive_count INT NOT NULL DEFAULT 0,
+    protected_until TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- Drop Rob hits
+DROP INDEX IF EXISTS idx_rob_hits_created;
+DROP INDEX IF EXISTS idx_rob_hits_victim_created;
+DROP TABLE IF EXISTS rob_hits;
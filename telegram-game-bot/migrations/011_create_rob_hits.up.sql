@@ -0,0 +1,11 @@
+-- Rob hits
+-- Successful robberies, counted for the per-victim and per-pair anti-targeting caps
+
+CREATE TABLE IF NOT EXISTS rob_hits (
+    id BIGSERIAL PRIMARY KEY,
+    robber_id BIGINT NOT NULL,
+    victim_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_rob_hits_victim_created ON rob_hits(victim_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_rob_hits_created ON rob_hits(created_at);
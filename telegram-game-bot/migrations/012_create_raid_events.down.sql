@@ -0,0 +1,4 @@
+-- Drop Raid events
+DROP TABLE IF EXISTS raid_members;
+DROP INDEX IF EXISTS idx_raid_events_status;
+DROP TABLE IF EXISTS raid_events;
@@ -0,0 +1,25 @@
+-- Raid events
+-- Timed rob competitions between two chats and the users who joined each side
+
+CREATE TABLE IF NOT EXISTS raid_events (
+    id BIGSERIAL PRIMARY KEY,
+    chat_a BIGINT NOT NULL,
+    chat_b BIGINT NOT NULL,
+    prize_pool BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled', -- scheduled / active / finished / cancelled
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    winner_chat BIGINT,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_raid_events_status ON raid_events(status);
+
+CREATE TABLE IF NOT EXISTS raid_members (
+    raid_id BIGINT NOT NULL REFERENCES raid_events(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    chat_id BIGINT NOT NULL,
+    points INT NOT NULL DEFAULT 0,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (raid_id, user_id)
+);
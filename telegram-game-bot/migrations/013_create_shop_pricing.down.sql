@@ -0,0 +1,3 @@
+-- Drop Shop pricing
+DROP TABLE IF EXISTS shop_item_sales;
+DROP TABLE IF EXISTS shop_sales;
@@ -0,0 +1,17 @@
+-- Shop pricing
+-- Admin flash sales and per-day item sales counters used for demand pricing
+
+CREATE TABLE IF NOT EXISTS shop_sales (
+    target VARCHAR(50) PRIMARY KEY, -- item type, or 'all'
+    discount_percent INT NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS shop_item_sales (
+    item_type VARCHAR(50) NOT NULL,
+    sale_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    sold_count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (item_type, sale_date)
+);
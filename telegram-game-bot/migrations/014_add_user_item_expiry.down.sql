@@ -0,0 +1,4 @@
+-- Drop User item expiry
+DROP INDEX IF EXISTS idx_user_items_expires_at;
+ALTER TABLE user_items DROP COLUMN IF EXISTS expiry_warned;
+ALTER TABLE user_items DROP COLUMN IF EXISTS expires_at;
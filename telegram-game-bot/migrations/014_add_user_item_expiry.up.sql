@@ -0,0 +1,6 @@
+-- User item expiry
+-- Optional lifetime of held items; owners are warned once before the cleanup job purges them
+
+ALTER TABLE user_items ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE user_items ADD COLUMN IF NOT EXISTS expiry_warned BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_user_items_expires_at ON user_items(expires_at) WHERE expires_at IS NOT NULL;
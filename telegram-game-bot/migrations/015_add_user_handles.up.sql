@@ -0,0 +1,22 @@
+-- User handles
+-- Stable public handles such as #A1B2C3, accepted by /pay, /dj and /handcuff
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(16);
+
+-- Backfill existing users with unique random handles
+DO $$
+DECLARE
+    u RECORD;
+    h TEXT;
+BEGIN
+    FOR u IN SELECT telegram_id FROM users WHERE handle IS NULL LOOP
+        LOOP
+            h := '#' || upper(substr(md5(random()::text), 1, 6));
+            EXIT WHEN NOT EXISTS (SELECT 1 FROM users WHERE handle = h);
+        END LOOP;
+        UPDATE users SET handle = h WHERE telegram_id = u.telegram_id;
+    END LOOP;
+END $$;
+
+ALTER TABLE users ALTER COLUMN handle SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle);
@@ -0,0 +1,3 @@
+-- Drop Transaction refunds
+DROP INDEX IF EXISTS idx_transaction_refunds_user;
+DROP TABLE IF EXISTS transaction_refunds;
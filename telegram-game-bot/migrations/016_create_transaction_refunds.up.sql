@@ -0,0 +1,17 @@
+-- Transaction refunds
+-- Audit record of admins reversing a specific transaction; the primary key
+-- guards against reversing the same transaction twice
+
+CREATE TABLE IF NOT EXISTS transaction_refunds (
+    tx_id BIGINT PRIMARY KEY REFERENCES transactions(id),
+    user_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,                      -- balance change of the reversal
+    refund_tx_id BIGINT REFERENCES transactions(id),
+    refunded_by BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    item_type VARCHAR(50),                       -- item of a reversed shop purchase
+    item_uses INT NOT NULL DEFAULT 0,            -- item uses removed
+    effect_seconds BIGINT NOT NULL DEFAULT 0,    -- timed effect removed
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_refunds_user ON transaction_refunds(user_id);
@@ -0,0 +1,2 @@
+-- Drop Chat personas
+DROP TABLE IF EXISTS chat_personas;
@@ -0,0 +1,12 @@
+-- Chat personas
+-- Per group bot nickname, win/lose message templates and emoji theme
+
+CREATE TABLE IF NOT EXISTS chat_personas (
+    chat_id BIGINT PRIMARY KEY,
+    nickname VARCHAR(32) NOT NULL DEFAULT '',
+    win_template TEXT NOT NULL DEFAULT '',       -- empty = built-in text
+    lose_template TEXT NOT NULL DEFAULT '',      -- empty = built-in text
+    theme VARCHAR(20) NOT NULL DEFAULT '',       -- empty = default theme
+    updated_by BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Drop Balance alerts
+DROP TABLE IF EXISTS balance_alerts;
@@ -0,0 +1,9 @@
+-- Balance alerts
+-- Users opted in to DM alerts about large balance changes while offline
+
+CREATE TABLE IF NOT EXISTS balance_alerts (
+    user_id BIGINT PRIMARY KEY,
+    threshold BIGINT NOT NULL,                   -- minimum absolute change to alert about
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
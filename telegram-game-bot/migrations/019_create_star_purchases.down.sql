@@ -0,0 +1,3 @@
+-- Drop Star purchases
+DROP TABLE IF EXISTS user_cosmetics;
+DROP TABLE IF EXISTS star_purchases;
@@ -0,0 +1,27 @@
+-- Star purchases
+-- Ledger of cosmetics paid with Telegram Stars and the cosmetics users own.
+-- Kept apart from balances and transactions: cosmetics never involve coins.
+
+CREATE TABLE IF NOT EXISTS star_purchases (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    item_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(8) NOT NULL,                 -- XTR for Telegram Stars
+    amount INT NOT NULL,                          -- total in the smallest currency unit
+    payload VARCHAR(128) NOT NULL,
+    telegram_charge_id VARCHAR(255) NOT NULL UNIQUE, -- redelivered payments are recorded once
+    provider_charge_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_star_purchases_user ON star_purchases(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS user_cosmetics (
+    user_id BIGINT NOT NULL,
+    item_id VARCHAR(64) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    equipped BOOLEAN NOT NULL DEFAULT FALSE,      -- at most one equipped cosmetic per kind
+    purchase_id BIGINT NOT NULL REFERENCES star_purchases(id),
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, item_id)
+);
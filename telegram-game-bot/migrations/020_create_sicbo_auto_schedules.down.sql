@@ -0,0 +1,2 @@
+-- Drop SicBo auto-start schedules
+DROP TABLE IF EXISTS sicbo_auto_schedules;
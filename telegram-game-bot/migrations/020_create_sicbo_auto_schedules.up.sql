@@ -0,0 +1,12 @@
+-- SicBo auto-start schedules
+-- Chats where sicbo rounds start automatically every N minutes during configured hours
+
+CREATE TABLE IF NOT EXISTS sicbo_auto_schedules (
+    chat_id BIGINT PRIMARY KEY,
+    interval_minutes INT NOT NULL,                -- minutes between automatic starts
+    start_hour SMALLINT NOT NULL DEFAULT 0,       -- first hour of the window (local time)
+    end_hour SMALLINT NOT NULL DEFAULT 0,         -- hour the window ends, equal to start_hour = all day
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
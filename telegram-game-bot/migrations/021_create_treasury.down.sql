@@ -0,0 +1,4 @@
+-- Drop Treasury
+DROP TRIGGER IF EXISTS trg_treasury_post_transaction ON transactions;
+DROP FUNCTION IF EXISTS treasury_post_transaction();
+DROP TABLE IF EXISTS treasury;
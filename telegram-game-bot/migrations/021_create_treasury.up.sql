@@ -0,0 +1,33 @@
+-- Treasury
+-- Virtual house account that is the counterparty of every transaction.
+-- Its balance starts at minus the coins users already hold and moves opposite
+-- to each booked transaction, so user balances plus treasury stay at zero.
+
+CREATE TABLE IF NOT EXISTS treasury (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),   -- single row
+    balance BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO treasury (id, balance)
+SELECT 1, -COALESCE(SUM(balance), 0) FROM users
+ON CONFLICT (id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION treasury_post_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE treasury SET balance = balance - NEW.amount, updated_at = NOW() WHERE id = 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Superseded by the ledger trigger of migration 22: creating it again on a
+-- later run would post every transaction to the treasury twice
+DO $$
+BEGIN
+    IF to_regclass('ledger_entries') IS NULL THEN
+        DROP TRIGGER IF EXISTS trg_treasury_post_transaction ON transactions;
+        CREATE TRIGGER trg_treasury_post_transaction
+            AFTER INSERT ON transactions
+            FOR EACH ROW EXECUTE FUNCTION treasury_post_transaction();
+    END IF;
+END $$;
@@ -0,0 +1,14 @@
+-- Drop Ledger entries
+DROP VIEW IF EXISTS ledger_legs;
+DROP TRIGGER IF EXISTS trg_treasury_post_ledger_entry ON ledger_entries;
+DROP FUNCTION IF EXISTS treasury_post_ledger_entry();
+DROP TRIGGER IF EXISTS trg_ledger_book_transaction ON transactions;
+DROP FUNCTION IF EXISTS ledger_book_transaction();
+ALTER TABLE transactions DROP COLUMN IF EXISTS ledger_entry_id;
+DROP TABLE IF EXISTS ledger_entries;
+
+-- Restore booking the treasury from transactions
+DROP TRIGGER IF EXISTS trg_treasury_post_transaction ON transactions;
+CREATE TRIGGER trg_treasury_post_transaction
+    AFTER INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION treasury_post_transaction();
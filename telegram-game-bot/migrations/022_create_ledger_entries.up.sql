@@ -0,0 +1,83 @@
+-- Ledger entries
+-- Double-entry records of every coin movement: amount moves from debit_account
+-- to credit_account. Account 0 is the treasury, other accounts are user IDs.
+-- transactions is kept as the per-user legs of the entries, so existing
+-- queries keep working; ledger_legs shows the legs of every account.
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    debit_account BIGINT NOT NULL,                -- account the coins leave
+    credit_account BIGINT NOT NULL,               -- account the coins go to
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    type VARCHAR(50) NOT NULL,                    -- transaction type of the credited leg
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (debit_account <> credit_account)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_debit ON ledger_entries(debit_account, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_credit ON ledger_entries(credit_account, created_at DESC);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ledger_entry_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_transactions_ledger_entry ON transactions(ledger_entry_id);
+
+-- Backfill: earlier transactions become entries against the treasury
+UPDATE transactions SET ledger_entry_id = nextval('ledger_entries_id_seq')
+WHERE ledger_entry_id IS NULL AND amount <> 0;
+
+INSERT INTO ledger_entries (id, debit_account, credit_account, amount, type, description, created_at)
+SELECT t.ledger_entry_id,
+       CASE WHEN t.amount > 0 THEN 0 ELSE t.user_id END,
+       CASE WHEN t.amount > 0 THEN t.user_id ELSE 0 END,
+       ABS(t.amount), t.type, t.description, t.created_at
+FROM transactions t
+WHERE t.ledger_entry_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM ledger_entries e WHERE e.id = t.ledger_entry_id);
+
+-- Transactions recorded without an entry are booked against the treasury
+CREATE OR REPLACE FUNCTION ledger_book_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.ledger_entry_id IS NULL AND NEW.amount <> 0 THEN
+        INSERT INTO ledger_entries (debit_account, credit_account, amount, type, description, created_at)
+        VALUES (
+            CASE WHEN NEW.amount > 0 THEN 0 ELSE NEW.user_id END,
+            CASE WHEN NEW.amount > 0 THEN NEW.user_id ELSE 0 END,
+            ABS(NEW.amount), NEW.type, NEW.description, NEW.created_at
+        )
+        RETURNING id INTO NEW.ledger_entry_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ledger_book_transaction ON transactions;
+CREATE TRIGGER trg_ledger_book_transaction
+    BEFORE INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION ledger_book_transaction();
+
+-- The treasury follows the ledger instead of the transactions
+DROP TRIGGER IF EXISTS trg_treasury_post_transaction ON transactions;
+
+CREATE OR REPLACE FUNCTION treasury_post_ledger_entry() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.credit_account = 0 THEN
+        UPDATE treasury SET balance = balance + NEW.amount, updated_at = NOW() WHERE id = 1;
+    ELSIF NEW.debit_account = 0 THEN
+        UPDATE treasury SET balance = balance - NEW.amount, updated_at = NOW() WHERE id = 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_treasury_post_ledger_entry ON ledger_entries;
+CREATE TRIGGER trg_treasury_post_ledger_entry
+    AFTER INSERT ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION treasury_post_ledger_entry();
+
+-- One signed leg per account and entry, in the shape of transactions
+CREATE OR REPLACE VIEW ledger_legs AS
+SELECT id AS entry_id, credit_account AS account, amount, type, description, created_at
+FROM ledger_entries
+UNION ALL
+SELECT id, debit_account, -amount, type, description, created_at
+FROM ledger_entries;
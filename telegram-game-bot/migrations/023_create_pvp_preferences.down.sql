@@ -0,0 +1,2 @@
+-- Drop PvP preferences
+DROP TABLE IF EXISTS pvp_preferences;
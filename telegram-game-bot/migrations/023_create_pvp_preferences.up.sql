@@ -0,0 +1,11 @@
+-- PvP preferences
+-- Users who opted out of rob, duels and handcuffs. Changes are limited to one
+-- per cooldown so opting out cannot be used to dodge retaliation.
+
+CREATE TABLE IF NOT EXISTS pvp_preferences (
+    user_id BIGINT PRIMARY KEY REFERENCES users(telegram_id) ON DELETE CASCADE,
+    opted_out BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()   -- last change of opted_out
+);
+
+CREATE INDEX IF NOT EXISTS idx_pvp_preferences_opted_out ON pvp_preferences(user_id) WHERE opted_out;
@@ -0,0 +1,2 @@
+-- Drop Bailout states
+DROP TABLE IF EXISTS bailout_states;
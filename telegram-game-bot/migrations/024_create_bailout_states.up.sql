@@ -0,0 +1,11 @@
+-- Bailout states
+-- Tracks how long users have been below the bailout floor and when they last
+-- received a recovery grant.
+
+CREATE TABLE IF NOT EXISTS bailout_states (
+    user_id BIGINT PRIMARY KEY REFERENCES users(telegram_id) ON DELETE CASCADE,
+    below_since TIMESTAMPTZ,          -- first check that found the balance below the floor, NULL when above
+    last_grant_at TIMESTAMPTZ         -- last recovery grant
+);
+
+CREATE INDEX IF NOT EXISTS idx_bailout_states_below_since ON bailout_states(below_since) WHERE below_since IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop Chat persona pings
+ALTER TABLE chat_personas DROP COLUMN IF EXISTS no_pings;
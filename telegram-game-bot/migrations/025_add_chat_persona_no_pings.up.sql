@@ -0,0 +1,4 @@
+-- Chat persona pings
+-- Game results link player names to their accounts; chats can turn this off so players are not notified
+
+ALTER TABLE chat_personas ADD COLUMN IF NOT EXISTS no_pings BOOLEAN NOT NULL DEFAULT FALSE;
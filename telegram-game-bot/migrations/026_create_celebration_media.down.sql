@@ -0,0 +1,2 @@
+-- Drop Celebration media
+DROP TABLE IF EXISTS celebration_media;
@@ -0,0 +1,14 @@
+-- Celebration media
+-- Stickers and animations the bot sends in a chat after a jackpot-class win
+
+CREATE TABLE IF NOT EXISTS celebration_media (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,        -- win that triggers the media, e.g. slot_triple
+    media_type VARCHAR(16) NOT NULL,  -- sticker or animation
+    file_id TEXT NOT NULL,            -- Telegram file ID
+    added_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, file_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_celebration_media_kind ON celebration_media(kind);
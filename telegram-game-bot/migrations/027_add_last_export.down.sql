@@ -0,0 +1,2 @@
+-- Drop Data export tracking
+ALTER TABLE users DROP COLUMN IF EXISTS last_export;
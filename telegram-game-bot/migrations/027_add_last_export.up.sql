@@ -0,0 +1,3 @@
+-- Data export tracking
+-- Unix timestamp of the user's last /export_me, same as last_free_spin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_export BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Drop Sandbox chats
+DROP TABLE IF EXISTS sandbox_balances;
+DROP TABLE IF EXISTS sandbox_chats;
@@ -0,0 +1,16 @@
+-- Sandbox chats
+-- Groups where games are played with per-chat play money instead of real balances
+
+CREATE TABLE IF NOT EXISTS sandbox_chats (
+    chat_id BIGINT PRIMARY KEY,
+    enabled_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS sandbox_balances (
+    chat_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    balance BIGINT NOT NULL,            -- play money, never part of the real economy
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chat_id, user_id)
+);
@@ -0,0 +1,2 @@
+-- Drop Default stake
+ALTER TABLE users DROP COLUMN IF EXISTS default_stake;
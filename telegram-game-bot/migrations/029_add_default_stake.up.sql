@@ -0,0 +1,3 @@
+-- Default stake
+-- Stake used by /dice and /slot without an amount (0 = none set)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_stake BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Drop Betting pools
+DROP TABLE IF EXISTS pool_resolutions;
+DROP TABLE IF EXISTS pool_bets;
+DROP TABLE IF EXISTS pools;
@@ -0,0 +1,41 @@
+-- Betting pools
+-- Parimutuel questions opened by admins, the stakes on their outcomes and the
+-- audit trail of how each pool was resolved or cancelled
+
+CREATE TABLE IF NOT EXISTS pools (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    question TEXT NOT NULL,
+    outcomes TEXT[] NOT NULL,                     -- 2 to 6 outcome labels
+    status VARCHAR(20) NOT NULL DEFAULT 'open',   -- open / resolved / cancelled
+    rake_percent INT NOT NULL,
+    closes_at TIMESTAMPTZ NOT NULL,               -- end of the betting window
+    winning_outcome INT,                          -- index into outcomes once resolved
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_pools_chat_status ON pools(chat_id, status);
+
+CREATE TABLE IF NOT EXISTS pool_bets (
+    id BIGSERIAL PRIMARY KEY,
+    pool_id BIGINT NOT NULL REFERENCES pools(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    outcome INT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_pool_bets_pool ON pool_bets(pool_id);
+
+CREATE TABLE IF NOT EXISTS pool_resolutions (
+    id BIGSERIAL PRIMARY KEY,
+    pool_id BIGINT NOT NULL REFERENCES pools(id) ON DELETE CASCADE,
+    admin_id BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,                  -- resolved / cancelled
+    winning_outcome INT,
+    total_pool BIGINT NOT NULL,
+    rake BIGINT NOT NULL,
+    paid BIGINT NOT NULL,                         -- payouts and refunds
+    winners INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
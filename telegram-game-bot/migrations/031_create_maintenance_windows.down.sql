@@ -0,0 +1,2 @@
+-- Drop Scheduled maintenance
+DROP TABLE IF EXISTS maintenance_windows;
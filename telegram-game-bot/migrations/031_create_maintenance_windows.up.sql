@@ -0,0 +1,14 @@
+-- Scheduled maintenance
+-- Downtime windows announced to the chats with a countdown; players cannot
+-- use the bot from starts_at until ends_at
+
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id BIGSERIAL PRIMARY KEY,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    announced INT NOT NULL DEFAULT -1,            -- last announced stage, -1 = none yet
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    cancelled_at TIMESTAMPTZ                      -- cancelled or ended early by an admin
+);
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_ends ON maintenance_windows(ends_at);
@@ -0,0 +1,2 @@
+-- Drop Win records
+DROP TABLE IF EXISTS win_records;
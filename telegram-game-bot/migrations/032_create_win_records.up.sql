@@ -0,0 +1,14 @@
+-- Win records
+-- Biggest single dice and slot win per chat, today and this week
+
+CREATE TABLE IF NOT EXISTS win_records (
+    chat_id BIGINT NOT NULL,
+    game VARCHAR(20) NOT NULL,                    -- game command, e.g. dice / slot
+    period VARCHAR(10) NOT NULL,                  -- day / week
+    period_start DATE NOT NULL,                   -- first day of the period (weeks start on Monday)
+    user_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,                   -- display name of the holder when the record was set
+    amount BIGINT NOT NULL,
+    set_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chat_id, game, period, period_start)
+);
@@ -0,0 +1,3 @@
+-- Drop Handcuff struggles
+ALTER TABLE handcuff_locks DROP COLUMN IF EXISTS last_struggle_at;
+ALTER TABLE handcuff_locks DROP COLUMN IF EXISTS struggle_attempts;
@@ -0,0 +1,5 @@
+-- Handcuff struggles
+-- Locked users can /struggle every few minutes for a chance to break free early
+
+ALTER TABLE handcuff_locks ADD COLUMN IF NOT EXISTS struggle_attempts INT NOT NULL DEFAULT 0; -- attempts made against this lock
+ALTER TABLE handcuff_locks ADD COLUMN IF NOT EXISTS last_struggle_at TIMESTAMPTZ;             -- last attempt, NULL if none
@@ -0,0 +1,3 @@
+-- Drop SicBo summary DMs
+DROP TABLE IF EXISTS dm_outbox;
+DROP TABLE IF EXISTS sicbo_summary_optins;
@@ -0,0 +1,18 @@
+-- SicBo summary DMs
+-- Opt-ins for personal SicBo settlement DMs and the outbox delivering them
+
+CREATE TABLE IF NOT EXISTS sicbo_summary_optins (
+    user_id BIGINT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS dm_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,                      -- recipient of the direct message
+    text TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,              -- failed send attempts so far
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dm_outbox_next_attempt ON dm_outbox(next_attempt_at);
@@ -0,0 +1,2 @@
+-- Drop Self exclusion
+ALTER TABLE users DROP COLUMN IF EXISTS self_excluded_until;
@@ -0,0 +1,4 @@
+-- Self exclusion
+-- Users may lock themselves out of all gambling commands until a given time
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS self_excluded_until TIMESTAMPTZ; -- null = not excluded
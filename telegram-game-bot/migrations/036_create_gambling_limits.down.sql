@@ -0,0 +1,3 @@
+-- Drop Gambling limits
+DROP TABLE IF EXISTS gambling_day_totals;
+DROP TABLE IF EXISTS gambling_limits;
@@ -0,0 +1,17 @@
+-- Gambling limits
+-- Daily wager and loss caps users set for themselves, and their daily totals
+
+CREATE TABLE IF NOT EXISTS gambling_limits (
+    user_id BIGINT PRIMARY KEY,
+    daily_wager BIGINT NOT NULL DEFAULT 0,        -- max coins staked per day, 0 = no limit
+    daily_loss BIGINT NOT NULL DEFAULT 0,         -- max net loss per day, 0 = no limit
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS gambling_day_totals (
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    wagered BIGINT NOT NULL DEFAULT 0,            -- coins staked in games and pvp
+    net BIGINT NOT NULL DEFAULT 0,                -- net result of games and pvp
+    PRIMARY KEY (user_id, day)
+);
@@ -0,0 +1,2 @@
+-- Drop Media assets
+DROP TABLE IF EXISTS media_assets;
@@ -0,0 +1,10 @@
+-- Media assets
+-- Telegram file IDs of images used in panels, configurable at runtime
+
+CREATE TABLE IF NOT EXISTS media_assets (
+    key VARCHAR(50) PRIMARY KEY,                  -- asset name, e.g. shop_banner
+    media_type VARCHAR(20) NOT NULL,              -- photo / animation / sticker
+    file_id TEXT NOT NULL,                        -- telegram file id, only valid for this bot
+    updated_by BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Drop Chat settings
+DROP TABLE IF EXISTS chat_settings;
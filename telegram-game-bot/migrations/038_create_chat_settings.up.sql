@@ -0,0 +1,12 @@
+-- Chat settings
+-- Per chat choices made in the setup wizard
+
+CREATE TABLE IF NOT EXISTS chat_settings (
+    chat_id BIGINT PRIMARY KEY,
+    games_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    timezone VARCHAR(50) NOT NULL DEFAULT '',     -- iana name, empty = bot default
+    cleanup_minutes INT NOT NULL DEFAULT 30,      -- delay before bot messages are deleted, 0 = keep
+    onboarded_at TIMESTAMPTZ,                     -- when the setup wizard was posted
+    updated_by BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Drop User merges
+DROP TABLE IF EXISTS user_merges;
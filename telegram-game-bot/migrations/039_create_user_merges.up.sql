@@ -0,0 +1,18 @@
+-- User merges
+-- Audit trail of duplicate accounts merged into a user's new account
+
+CREATE TABLE IF NOT EXISTS user_merges (
+    id BIGSERIAL PRIMARY KEY,
+    old_id BIGINT NOT NULL UNIQUE,                -- an account is merged away at most once
+    new_id BIGINT NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,            -- balance moved to the new account
+    items INT NOT NULL DEFAULT 0,                 -- inventory rows moved
+    effects INT NOT NULL DEFAULT 0,               -- active effects moved
+    cosmetics INT NOT NULL DEFAULT 0,             -- cosmetics moved
+    transactions INT NOT NULL DEFAULT 0,          -- transactions re-pointed
+    merged_by BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_merges_new_id ON user_merges(new_id);
@@ -0,0 +1,2 @@
+-- Drop Balance history
+DROP TABLE IF EXISTS balance_history;
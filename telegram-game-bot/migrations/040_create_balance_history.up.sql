@@ -0,0 +1,11 @@
+-- Balance history
+-- Daily balance snapshots of active users for /wealth
+
+CREATE TABLE IF NOT EXISTS balance_history (
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    balance BIGINT NOT NULL,                      -- latest balance seen that day
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_history_day ON balance_history(day);
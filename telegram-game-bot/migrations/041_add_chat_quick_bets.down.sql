@@ -0,0 +1,2 @@
+-- Drop Chat quick bets
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS quick_bets;
@@ -0,0 +1,4 @@
+-- Chat quick bets
+-- Per chat flag accepting shorthand bets such as d100 without a slash command
+
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS quick_bets BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Drop TTL store
+DROP TABLE IF EXISTS ttl_store;
@@ -0,0 +1,11 @@
+-- TTL store
+-- Ephemeral keys (cooldowns, rate limits, handled callbacks) shared by all instances
+
+CREATE TABLE IF NOT EXISTS ttl_store (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL  -- key is ignored and cleaned up after this
+);
+
+CREATE INDEX IF NOT EXISTS idx_ttl_store_prefix ON ttl_store(key text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_ttl_store_expires ON ttl_store(expires_at);
@@ -0,0 +1,3 @@
+-- Drop Chat quiet hours
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS quiet_end;
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS quiet_start;
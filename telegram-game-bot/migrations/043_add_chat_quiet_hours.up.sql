@@ -0,0 +1,5 @@
+-- Chat quiet hours
+-- Hours in the chat timezone during which games and announcements pause
+
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS quiet_start SMALLINT NOT NULL DEFAULT 0;  -- first quiet hour
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS quiet_end SMALLINT NOT NULL DEFAULT 0;    -- first hour after, equal = none
@@ -0,0 +1,2 @@
+-- Drop Chat whitelist
+DROP TABLE IF EXISTS chat_whitelist;
@@ -0,0 +1,9 @@
+-- Chat whitelist
+-- Chats added or removed by admins at runtime, on top of the configured whitelist
+
+CREATE TABLE IF NOT EXISTS chat_whitelist (
+    chat_id BIGINT PRIMARY KEY,
+    allowed BOOLEAN NOT NULL,           -- false removes a configured chat
+    updated_by BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Drop Rob insurance pool
+DROP TABLE IF EXISTS rob_insurance_pool;
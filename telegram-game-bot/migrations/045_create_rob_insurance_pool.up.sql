@@ -0,0 +1,12 @@
+-- Rob insurance pool
+-- Community pool funded by a cut of successful robs, compensating victims left below a floor
+
+CREATE TABLE IF NOT EXISTS rob_insurance_pool (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    balance BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    collected BIGINT NOT NULL DEFAULT 0,   -- all cuts ever paid in
+    paid_out BIGINT NOT NULL DEFAULT 0,    -- all compensation ever paid
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO rob_insurance_pool (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
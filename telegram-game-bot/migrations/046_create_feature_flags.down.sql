@@ -0,0 +1,2 @@
+-- Drop Feature flags
+DROP TABLE IF EXISTS feature_flags;
@@ -0,0 +1,12 @@
+-- Feature flags
+-- Overrides of configured feature defaults for everyone, one chat or one user
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    flag TEXT NOT NULL,
+    scope TEXT NOT NULL,                -- global, chat or user
+    target_id BIGINT NOT NULL DEFAULT 0, -- chat or user ID, 0 for global
+    enabled BOOLEAN NOT NULL,
+    updated_by BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (flag, scope, target_id)
+);
@@ -0,0 +1,4 @@
+-- Drop User archival
+DROP INDEX IF EXISTS idx_users_active_balance;
+ALTER TABLE users DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE users DROP COLUMN IF EXISTS last_active_at;
@@ -0,0 +1,8 @@
+-- User archival
+-- Users inactive for months are archived: hidden from leaderboards, skipped by
+-- bailouts and safe from robs until they interact again
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;  -- last interaction, NULL = before tracking, use updated_at
+ALTER TABLE users ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;     -- NULL = active
+
+CREATE INDEX IF NOT EXISTS idx_users_active_balance ON users(balance DESC) WHERE archived_at IS NULL;
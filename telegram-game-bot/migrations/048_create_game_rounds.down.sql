@@ -0,0 +1,2 @@
+-- Drop Game rounds
+DROP TABLE IF EXISTS game_rounds;
@@ -0,0 +1,17 @@
+-- Game rounds
+-- Inputs and outcome of every dice and slot round, replayed by cmd/replay
+
+CREATE TABLE IF NOT EXISTS game_rounds (
+    id BIGSERIAL PRIMARY KEY,
+    game TEXT NOT NULL,                     -- dice or slot
+    user_id BIGINT NOT NULL,
+    chat_id BIGINT NOT NULL,
+    bet BIGINT NOT NULL,
+    dice_values INT[] NOT NULL,             -- values rolled by Telegram
+    premium BIGINT NOT NULL DEFAULT 0,      -- dice insurance premium
+    refund_percent INT NOT NULL DEFAULT 0,  -- share of a lost insured stake refunded
+    payout BIGINT NOT NULL,                 -- net payout, negative for a loss
+    refund BIGINT NOT NULL DEFAULT 0,       -- insurance refund paid
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_game_rounds_user_time ON game_rounds(user_id, created_at);
@@ -0,0 +1,4 @@
+-- Drop Transaction search indexes
+DROP INDEX IF EXISTS idx_transactions_abs_amount;
+DROP INDEX IF EXISTS idx_transactions_type_id;
+DROP INDEX IF EXISTS idx_transactions_user_id;
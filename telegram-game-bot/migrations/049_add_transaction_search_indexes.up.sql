@@ -0,0 +1,6 @@
+-- Transaction search indexes
+-- /findtx pages through transactions newest first, filtered by user, type or amount
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_type_id ON transactions(type, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_abs_amount ON transactions((ABS(amount)), id DESC);
@@ -0,0 +1,2 @@
+-- Drop Idempotency key table
+DROP TABLE IF EXISTS idempotency_keys;
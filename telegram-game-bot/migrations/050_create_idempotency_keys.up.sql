@@ -0,0 +1,12 @@
+-- Idempotency key table
+-- Balance changes retried by external callers are applied once per key
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(128) PRIMARY KEY,
+    operation VARCHAR(32) NOT NULL,
+    fingerprint TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    balance BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
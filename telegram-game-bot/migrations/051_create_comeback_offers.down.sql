@@ -0,0 +1,3 @@
+-- Drop Welcome-back offer tables
+DROP TABLE IF EXISTS comeback_offers;
+DROP TABLE IF EXISTS comeback_optins;
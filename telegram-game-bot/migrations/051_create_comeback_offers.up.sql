@@ -0,0 +1,19 @@
+-- Welcome-back offer tables
+-- Opted in regulars who stopped playing are DMed a bonus they can claim once
+
+CREATE TABLE IF NOT EXISTS comeback_optins (
+    user_id BIGINT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS comeback_offers (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    bonus BIGINT NOT NULL,
+    delivered BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    claimed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comeback_offers_user_time ON comeback_offers(user_id, created_at);
@@ -0,0 +1,2 @@
+-- Drop Banter phrases
+DROP TABLE IF EXISTS banter_phrases;
@@ -0,0 +1,12 @@
+-- Banter phrases
+-- Short taunts and congratulations occasionally appended to game results, per locale
+
+CREATE TABLE IF NOT EXISTS banter_phrases (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,        -- result the phrase follows: win or lose
+    locale VARCHAR(8) NOT NULL,       -- language of the phrase, e.g. zh or en
+    text TEXT NOT NULL,
+    added_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, locale, text)
+);
@@ -0,0 +1,3 @@
+-- Drop User daily aggregates
+DROP TABLE IF EXISTS aggregate_watermarks;
+DROP TABLE IF EXISTS user_daily_aggregates;
@@ -0,0 +1,18 @@
+-- User daily aggregates
+-- Net game profit of each user per finished day, so 7-day and lifetime stats
+-- do not scan the whole transactions table
+
+CREATE TABLE IF NOT EXISTS user_daily_aggregates (
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,                -- day in the bot timezone
+    net_profit BIGINT NOT NULL,       -- sum of game transactions of the day
+    games INT NOT NULL,               -- number of game transactions of the day
+    PRIMARY KEY (user_id, day)
+);
+
+-- Last day aggregated, later days are read from transactions
+CREATE TABLE IF NOT EXISTS aggregate_watermarks (
+    name VARCHAR(32) PRIMARY KEY,
+    through_day DATE NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,32 @@
+-- Drop Monthly partitions of transactions
+-- Moves the attached partitions back into a plain table; detached
+-- (archived) partitions are left alone.
+
+DO $$
+DECLARE
+    stats_view TEXT := CASE WHEN to_regclass('daily_game_stats') IS NOT NULL
+        THEN pg_get_viewdef('daily_game_stats'::regclass) END;
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_class WHERE oid = to_regclass('transactions') AND relkind = 'p') THEN
+        CREATE TABLE transactions_unpartitioned (LIKE transactions INCLUDING DEFAULTS);
+        INSERT INTO transactions_unpartitioned SELECT * FROM transactions;
+        ALTER SEQUENCE transactions_id_seq OWNED BY transactions_unpartitioned.id;
+        DROP TABLE transactions CASCADE;
+        ALTER TABLE transactions_unpartitioned RENAME TO transactions;
+        ALTER TABLE transactions ADD PRIMARY KEY (id);
+        ALTER TABLE transactions ADD FOREIGN KEY (user_id) REFERENCES users(telegram_id) ON DELETE CASCADE;
+        CREATE INDEX idx_transactions_user_time ON transactions(user_id, created_at DESC);
+        CREATE INDEX idx_transactions_type_time ON transactions(type, created_at DESC);
+        CREATE INDEX idx_transactions_ledger_entry ON transactions(ledger_entry_id);
+        CREATE INDEX idx_transactions_user_id ON transactions(user_id, id DESC);
+        CREATE INDEX idx_transactions_type_id ON transactions(type, id DESC);
+        CREATE INDEX idx_transactions_abs_amount ON transactions((ABS(amount)), id DESC);
+        CREATE TRIGGER trg_ledger_book_transaction
+            BEFORE INSERT ON transactions
+            FOR EACH ROW EXECUTE FUNCTION ledger_book_transaction();
+        -- Views dropped with the partitioned table come back as they were
+        IF stats_view IS NOT NULL THEN
+            EXECUTE 'CREATE VIEW daily_game_stats AS ' || stats_view;
+        END IF;
+    END IF;
+END $$;
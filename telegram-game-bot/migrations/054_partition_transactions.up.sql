@@ -0,0 +1,69 @@
+-- Monthly partitions of transactions
+-- The table is range partitioned by created_at on UTC months. Existing rows
+-- stay in place: the old table is attached as transactions_legacy, covering
+-- everything up to the start of next month so rows written this month still
+-- validate. The scheduler creates the partitions of the following months and
+-- detaches those past the retention.
+-- A partitioned table has no primary key on id alone, so the refund audit
+-- keeps transaction IDs without a foreign key.
+
+DO $$
+DECLARE
+    boundary TIMESTAMPTZ := date_trunc('month', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    idx RECORD;
+    legacy_end TIMESTAMPTZ;
+    month TIMESTAMPTZ;
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_class WHERE oid = to_regclass('transactions') AND relkind = 'r') THEN
+        ALTER TABLE transaction_refunds DROP CONSTRAINT IF EXISTS transaction_refunds_tx_id_fkey;
+        ALTER TABLE transaction_refunds DROP CONSTRAINT IF EXISTS transaction_refunds_refund_tx_id_fkey;
+
+        ALTER TABLE transactions RENAME TO transactions_legacy;
+        FOR idx IN SELECT indexname FROM pg_indexes WHERE tablename = 'transactions_legacy' LOOP
+            EXECUTE format('ALTER INDEX %I RENAME TO %I', idx.indexname, idx.indexname || '_legacy');
+        END LOOP;
+        DROP TRIGGER IF EXISTS trg_ledger_book_transaction ON transactions_legacy;
+
+        CREATE TABLE transactions (
+            id BIGINT NOT NULL DEFAULT nextval('transactions_id_seq'),
+            user_id BIGINT NOT NULL REFERENCES users(telegram_id) ON DELETE CASCADE,
+            amount BIGINT NOT NULL,
+            type VARCHAR(50) NOT NULL,
+            description TEXT,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            ledger_entry_id BIGINT,
+            PRIMARY KEY (id, created_at)
+        ) PARTITION BY RANGE (created_at);
+        ALTER SEQUENCE transactions_id_seq OWNED BY transactions.id;
+
+        -- Same definitions as the legacy indexes, which are attached instead of rebuilt
+        CREATE INDEX idx_transactions_user_time ON transactions(user_id, created_at DESC);
+        CREATE INDEX idx_transactions_type_time ON transactions(type, created_at DESC);
+        CREATE INDEX idx_transactions_ledger_entry ON transactions(ledger_entry_id);
+        CREATE INDEX idx_transactions_user_id ON transactions(user_id, id DESC);
+        CREATE INDEX idx_transactions_type_id ON transactions(type, id DESC);
+        CREATE INDEX idx_transactions_abs_amount ON transactions((ABS(amount)), id DESC);
+
+        -- Bounded above every existing row, or the attach fails validation: the
+        -- start of next month, later if rows are dated past it
+        SELECT GREATEST(boundary + INTERVAL '1 month',
+                date_trunc('month', MAX(created_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' + INTERVAL '1 month')
+            INTO legacy_end FROM transactions_legacy;
+        EXECUTE format('ALTER TABLE transactions ATTACH PARTITION transactions_legacy FOR VALUES FROM (MINVALUE) TO (%L)', legacy_end);
+
+        CREATE TRIGGER trg_ledger_book_transaction
+            BEFORE INSERT ON transactions
+            FOR EACH ROW EXECUTE FUNCTION ledger_book_transaction();
+    END IF;
+
+    -- Two months from the end of the legacy partition, or from this month once
+    -- it is past, so inserts work before the scheduler first runs
+    SELECT substring(pg_get_expr(c.relpartbound, c.oid) FROM 'TO \(''(.*)''\)')::timestamptz
+        INTO legacy_end FROM pg_class c
+        WHERE c.oid = to_regclass('transactions_legacy') AND c.relispartition;
+    boundary := GREATEST(boundary, legacy_end);
+    FOR month IN SELECT generate_series(boundary, boundary + INTERVAL '1 month', INTERVAL '1 month') LOOP
+        EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF transactions FOR VALUES FROM (%L) TO (%L)',
+            'transactions_' || to_char(month AT TIME ZONE 'UTC', '"y"YYYY"m"MM'), month, month + INTERVAL '1 month');
+    END LOOP;
+END $$;
@@ -0,0 +1,2 @@
+-- Drop Rob messages
+DROP TABLE IF EXISTS rob_messages;
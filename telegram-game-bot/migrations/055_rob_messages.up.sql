@@ -0,0 +1,12 @@
+-- Rob messages
+-- Flavor texts of robbery results managed by admins, picked by rarity tier
+
+CREATE TABLE IF NOT EXISTS rob_messages (
+    id BIGSERIAL PRIMARY KEY,
+    outcome VARCHAR(16) NOT NULL,     -- result the text replaces: fail, counter, success or critical
+    tier VARCHAR(16) NOT NULL,        -- rarity: common, rare or epic
+    text TEXT NOT NULL,               -- template with {robber} {victim} {amount} {item}
+    added_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (outcome, text)
+);
@@ -0,0 +1,4 @@
+-- Drop User effects
+DROP INDEX IF EXISTS idx_user_effects_expires;
+DROP INDEX IF EXISTS idx_user_effects_user;
+DROP TABLE IF EXISTS user_effects;
@@ -0,0 +1,13 @@
+-- User effects
+-- Time-based effects (shield, thorn armor, bloodthirst sword); the table was
+-- only created by the bot at startup and missing from the migration files
+
+CREATE TABLE IF NOT EXISTS user_effects (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    effect_type VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_user_effects_user ON user_effects(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_effects_expires ON user_effects(expires_at);
@@ -0,0 +1,2 @@
+-- Drop Blackjack hands
+DROP TABLE IF EXISTS blackjack_hands;
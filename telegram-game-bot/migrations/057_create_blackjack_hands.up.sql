@@ -0,0 +1,11 @@
+-- Blackjack hands
+-- Stakes of the hands in play, refunded at startup if the bot stopped
+-- before settling them
+
+CREATE TABLE IF NOT EXISTS blackjack_hands (
+    user_id BIGINT PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    sandbox_chat_id BIGINT NOT NULL DEFAULT 0,  -- balance the stake was paid from, 0 = real balance
+    stake BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Coins credited on a merge for item uses over the stack caps
+ALTER TABLE user_merges ADD COLUMN IF NOT EXISTS compensation BIGINT NOT NULL DEFAULT 0;
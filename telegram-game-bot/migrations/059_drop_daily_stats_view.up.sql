@@ -0,0 +1,5 @@
+-- Drop the daily stats view
+-- Nothing reads it: the daily rankings sum the game transactions themselves,
+-- and its hardcoded list of game types went stale with every new game.
+
+DROP VIEW IF EXISTS daily_game_stats;
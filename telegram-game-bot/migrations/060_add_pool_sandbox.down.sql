@@ -0,0 +1 @@
+ALTER TABLE pools DROP COLUMN IF EXISTS sandbox_chat_id;
@@ -0,0 +1,2 @@
+-- Sandbox chat whose play money a pool is staked with, 0 for real coins
+ALTER TABLE pools ADD COLUMN IF NOT EXISTS sandbox_chat_id BIGINT NOT NULL DEFAULT 0;
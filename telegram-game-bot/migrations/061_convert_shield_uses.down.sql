@@ -0,0 +1,11 @@
+-- Turn active shield effect time back into uses, 10 uses per hour rounded up
+WITH timed AS (
+    DELETE FROM user_effects WHERE effect_type = 'shield'
+    RETURNING user_id, expires_at
+)
+INSERT INTO user_items (user_id, item_type, use_count, updated_at)
+SELECT user_id, 'shield', CEIL(EXTRACT(EPOCH FROM MAX(expires_at) - NOW()) / 360)::INT, NOW()
+FROM timed
+WHERE expires_at > NOW()
+GROUP BY user_id
+ON CONFLICT (user_id, item_type) DO UPDATE SET use_count = EXCLUDED.use_count, updated_at = NOW();
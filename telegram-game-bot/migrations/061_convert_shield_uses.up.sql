@@ -0,0 +1,9 @@
+-- The shield became a timed effect: held uses turn into effect time, one hour per 10 uses
+WITH held AS (
+    DELETE FROM user_items WHERE item_type = 'shield'
+    RETURNING user_id, use_count, expires_at
+)
+INSERT INTO user_effects (user_id, effect_type, expires_at, created_at)
+SELECT user_id, 'shield', NOW() + use_count * INTERVAL '6 minutes', NOW()
+FROM held
+WHERE use_count > 0 AND (expires_at IS NULL OR expires_at > NOW());